@@ -6,6 +6,16 @@
 // - Sending jobs and collecting results via channels
 // - Using context for cancellation and timeouts
 // - Waiting for all workers to finish with sync.WaitGroup
+// - Giving every channel one owner that closes it exactly once
+//
+// Channel ownership in this pool:
+//
+//	jobs     written by the producer  -> closed by the producer when done or cancelled
+//	results  written by the workers   -> closed by one closer goroutine after wg.Wait()
+//
+// The collector ranges over results until it is closed. It never stops
+// early, so a worker can always deliver the result of a job it started,
+// and no goroutine is left blocked on a send after cancellation.
 
 package main
 
@@ -34,84 +44,119 @@ type Job struct {
 // -------------------------------------
 // The same applies for the Result struct. Always use 'type' and curly braces.
 type Result struct {
-	JobID int // ID of the job processed
-	Value int // Result value (e.g., job.ID * 2)
+	JobID  int // ID of the job processed
+	Value  int // Result value (e.g., job.ID * 2)
+	Worker int // Worker that processed the job
 }
 
-// worker processes jobs from the jobs channel and sends results to the results channel.
-// It listens for context cancellation and exits if the context is done.
-func worker(ctx context.Context, id int, jobs <-chan Job, results chan<- Result, wg *sync.WaitGroup) {
-	defer wg.Done() // Mark this worker as done when function exits
+// WorkFunc processes one job. It should return early when ctx is done.
+type WorkFunc func(ctx context.Context, job Job) (int, error)
+
+// simulateWork sleeps for a random duration, or until ctx is cancelled.
+func simulateWork(ctx context.Context, job Job) (int, error) {
+	duration := time.Duration(rand.Intn(500)+100) * time.Millisecond
+	select {
+	case <-time.After(duration):
+		return job.ID * 2, nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// produce sends numJobs jobs and then closes the channel. It is the only
+// writer of jobs, so it is the only goroutine allowed to close it. On
+// cancellation it stops sending and closes early.
+func produce(ctx context.Context, numJobs int) <-chan Job {
+	jobs := make(chan Job)
+	go func() {
+		defer close(jobs)
+		for j := 1; j <= numJobs; j++ {
+			select {
+			case jobs <- Job{ID: j}:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return jobs
+}
+
+// worker processes jobs until the jobs channel is closed or ctx is done.
+// It never closes results: other workers are still writing to it.
+func worker(ctx context.Context, id int, jobs <-chan Job, results chan<- Result, work WorkFunc, wg *sync.WaitGroup) {
+	defer wg.Done() // Signal completion to the closer goroutine
 	for {
+		// Check for cancellation before taking another job. A select with
+		// both cases ready picks at random, so this check comes first.
+		if ctx.Err() != nil {
+			return
+		}
 		select {
 		case <-ctx.Done():
-			fmt.Printf("Worker %d: cancelled\n", id)
 			return
 		case job, ok := <-jobs:
 			if !ok {
-				fmt.Printf("Worker %d: jobs channel closed\n", id)
 				return
 			}
-			// Simulate work by sleeping for a random duration
-			duration := time.Duration(rand.Intn(500)+100) * time.Millisecond
-			time.Sleep(duration)
-			// Send result to results channel
-			results <- Result{JobID: job.ID, Value: job.ID * 2}
-			fmt.Printf("Worker %d: processed job %d\n", id, job.ID)
+			value, err := work(ctx, job)
+			if err != nil {
+				return // cancelled mid-job: there is no result to report
+			}
+			// The collector drains results until they are closed, so this
+			// send cannot block forever, even after cancellation.
+			results <- Result{JobID: job.ID, Value: value, Worker: id}
 		}
 	}
 }
 
-func main() {
-	numWorkers := 3 // Number of worker goroutines
-	numJobs := 10   // Number of jobs to process
+// runPool runs numJobs jobs on numWorkers workers and returns the results
+// in the order they completed. If ctx ends first, it returns the results
+// of every job that finished, together with ctx.Err().
+func runPool(ctx context.Context, numWorkers, numJobs int, work WorkFunc) ([]Result, error) {
+	jobs := produce(ctx, numJobs)
+	results := make(chan Result)
 
-	jobs := make(chan Job)       // Channel for sending jobs to workers
-	results := make(chan Result) // Channel for collecting results from workers
-	var wg sync.WaitGroup        // WaitGroup to wait for all workers to finish
-
-	// Create a context with timeout for cancellation
-	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
-	defer cancel()
-
-	// Start worker goroutines
+	var wg sync.WaitGroup
 	for w := 1; w <= numWorkers; w++ {
 		wg.Add(1)
-		go worker(ctx, w, jobs, results, &wg)
+		go worker(ctx, w, jobs, results, work, &wg)
 	}
 
-	// Send jobs to the jobs channel in a separate goroutine
+	// The closer: the one goroutine that closes results, exactly once,
+	// after every worker (every writer) has returned.
 	go func() {
-		for j := 1; j <= numJobs; j++ {
-			jobs <- Job{ID: j}
-		}
-		close(jobs) // Close jobs channel when done
+		wg.Wait()
+		close(results)
 	}()
 
-	// Collect results in a separate goroutine
-	done := make(chan struct{})
-	go func() {
-		for r := 1; r <= numJobs; r++ {
-			select {
-			case res := <-results:
-				fmt.Printf("Result: job %d -> %d\n", res.JobID, res.Value)
-			case <-ctx.Done():
-				fmt.Println("Result collection cancelled")
-				return
-			}
-		}
-		done <- struct{}{} // Signal that all results are collected
-	}()
+	// Collect on the calling goroutine. Ranging until close is what makes
+	// shutdown deterministic: when this loop ends, every worker has exited
+	// and every finished job's result is in out.
+	var out []Result
+	for res := range results {
+		out = append(out, res)
+	}
+	return out, ctx.Err()
+}
+
+func main() {
+	numWorkers := 3 // Number of worker goroutines
+	numJobs := 10   // Number of jobs to process
 
-	// Wait for all workers to finish
-	wg.Wait()
-	close(results) // Close results channel after all workers are done
+	fmt.Println("=== All jobs finish ===")
+	results, err := runPool(context.Background(), numWorkers, numJobs, simulateWork)
+	for _, res := range results {
+		fmt.Printf("Result: job %d -> %d (worker %d)\n", res.JobID, res.Value, res.Worker)
+	}
+	fmt.Printf("%d results, err=%v\n", len(results), err)
 
-	// Wait for results to be collected or context to timeout
-	select {
-	case <-done:
-		fmt.Println("All results collected.")
-	case <-ctx.Done():
-		fmt.Println("Main: context timeout or cancelled.")
+	fmt.Println("\n=== Timeout before all jobs finish ===")
+	// Create a context with timeout for cancellation
+	ctx, cancel := context.WithTimeout(context.Background(), 700*time.Millisecond)
+	defer cancel()
+	results, err = runPool(ctx, numWorkers, numJobs, simulateWork)
+	for _, res := range results {
+		fmt.Printf("Result: job %d -> %d (worker %d)\n", res.JobID, res.Value, res.Worker)
 	}
+	fmt.Printf("%d of %d results, err=%v\n", len(results), numJobs, err)
 }
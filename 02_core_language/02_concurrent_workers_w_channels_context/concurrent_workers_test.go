@@ -0,0 +1,102 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"runtime"
+	"sort"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// Run with: go test -race -v
+
+func TestRunPoolAllJobs(t *testing.T) {
+	work := func(ctx context.Context, job Job) (int, error) { return job.ID * 2, nil }
+	results, err := runPool(context.Background(), 4, 50, work)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(results) != 50 {
+		t.Fatalf("got %d results, want 50", len(results))
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].JobID < results[j].JobID })
+	for i, res := range results {
+		if res.JobID != i+1 || res.Value != 2*(i+1) {
+			t.Fatalf("results[%d] = %+v", i, res)
+		}
+	}
+}
+
+// TestRunPoolEarlyCancel cancels while jobs are in flight. runPool must
+// return, report context.Canceled, include a result for every job that
+// finished, and leave no goroutine behind.
+func TestRunPoolEarlyCancel(t *testing.T) {
+	before := runtime.NumGoroutine()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var finished atomic.Int32
+	work := func(ctx context.Context, job Job) (int, error) {
+		if job.ID == 5 {
+			cancel()
+		}
+		// Finish some jobs even after cancellation, as real work often
+		// does, so the result sends race with the shutdown.
+		if job.ID%2 == 0 {
+			finished.Add(1)
+			return job.ID, nil
+		}
+		select {
+		case <-time.After(time.Millisecond):
+			finished.Add(1)
+			return job.ID, nil
+		case <-ctx.Done():
+			return 0, ctx.Err()
+		}
+	}
+
+	done := make(chan struct{})
+	var results []Result
+	var err error
+	go func() {
+		results, err = runPool(ctx, 3, 1000, work)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("runPool did not return after cancel")
+	}
+
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if got, want := len(results), int(finished.Load()); got != want {
+		t.Errorf("got %d results, but %d jobs finished", got, want)
+	}
+	if len(results) >= 1000 {
+		t.Errorf("got all %d results; cancellation had no effect", len(results))
+	}
+
+	// Workers, producer and closer have all exited.
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > before && time.Now().Before(deadline) {
+		time.Sleep(10 * time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > before {
+		t.Errorf("%d goroutines after runPool, %d before", n, before)
+	}
+}
+
+func TestRunPoolCancelledBeforeStart(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls atomic.Int32
+	work := func(ctx context.Context, job Job) (int, error) { calls.Add(1); return 0, nil }
+	results, err := runPool(ctx, 3, 10, work)
+	if !errors.Is(err, context.Canceled) || len(results) != 0 || calls.Load() != 0 {
+		t.Fatalf("results=%v err=%v calls=%d; want none, context.Canceled, 0", results, err, calls.Load())
+	}
+}
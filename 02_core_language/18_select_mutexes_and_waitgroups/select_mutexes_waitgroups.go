@@ -10,6 +10,8 @@ import (
 // Demonstrates using select to handle multiple channels, sync.WaitGroup to
 // wait for goroutines, sync.Mutex to protect shared state, and a channel-based
 // alternative for serialized updates. Also describes when to prefer channels vs locks.
+// More select patterns (non-blocking, priority, nil channels, timers) are in
+// 19_select_patterns.
 
 func worker(id int, results chan<- int, errs chan<- error, wg *sync.WaitGroup) {
 	defer wg.Done()
@@ -0,0 +1,273 @@
+package main
+
+import (
+	"fmt"
+	"time"
+)
+
+// Demonstrates select patterns beyond the basics in 18_select_mutexes_and_waitgroups:
+// non-blocking send/receive with default, draining channels, prioritized
+// select, nil channels to switch cases off, and timeouts vs tickers in
+// select loops.
+
+// trySend sends v only if it can do so without blocking. Useful for
+// best-effort work such as metrics or notifications: dropping a value is
+// better than stalling the caller.
+func trySend(ch chan<- int, v int) bool {
+	select {
+	case ch <- v:
+		return true
+	default: // buffer full (or no receiver ready on an unbuffered channel)
+		return false
+	}
+}
+
+// tryRecv receives a value only if one is ready. ok is false when nothing
+// was waiting or the channel is closed.
+func tryRecv(ch <-chan int) (v int, ok bool) {
+	select {
+	case v, ok = <-ch:
+		return v, ok
+	default:
+		return 0, false
+	}
+}
+
+// drain empties whatever is buffered in ch right now, without waiting for
+// more, and returns it. Use it when the senders may still be running;
+// once they are done and ch is closed, a plain for range is simpler.
+func drain(ch <-chan int) []int {
+	var got []int
+	for {
+		select {
+		case v, ok := <-ch:
+			if !ok {
+				return got // closed
+			}
+			got = append(got, v)
+		default:
+			return got // nothing buffered
+		}
+	}
+}
+
+// nextByPriority returns the next job, always preferring high. A single
+// select picks at random among ready cases, so with both channels busy
+// half the picks would be low. Checking high first, with a default, fixes
+// the order; the second select then waits on both.
+func nextByPriority(high, low <-chan string) string {
+	select {
+	case job := <-high:
+		return job
+	default:
+	}
+	select {
+	case job := <-high:
+		return job
+	case job := <-low:
+		return job
+	}
+}
+
+// merge forwards values from a and b until both are closed. A receive
+// from a nil channel blocks forever, so setting a closed channel's
+// variable to nil switches its case off; without that, the closed
+// channel would be ready on every loop and spin.
+func merge(a, b <-chan int) <-chan int {
+	out := make(chan int)
+	go func() {
+		defer close(out)
+		for a != nil || b != nil {
+			select {
+			case v, ok := <-a:
+				if !ok {
+					a = nil
+					continue
+				}
+				out <- v
+			case v, ok := <-b:
+				if !ok {
+					b = nil
+					continue
+				}
+				out <- v
+			}
+		}
+	}()
+	return out
+}
+
+// batcher collects values and flushes a batch when it holds size values,
+// or when flushEvery passes with values pending. The nil-channel trick
+// again: the timer's channel is only live while a batch is pending.
+func batcher(in <-chan int, size int, flushEvery time.Duration, flush func([]int)) {
+	var batch []int
+	var timer *time.Timer
+	var timeout <-chan time.Time // nil: no batch pending, case disabled
+	send := func() {
+		flush(batch)
+		batch, timeout = nil, nil
+		timer.Stop()
+	}
+	for {
+		select {
+		case v, ok := <-in:
+			if !ok {
+				if len(batch) > 0 {
+					send()
+				}
+				return
+			}
+			batch = append(batch, v)
+			if len(batch) == 1 {
+				timer = time.NewTimer(flushEvery)
+				timeout = timer.C
+			}
+			if len(batch) == size {
+				send()
+			}
+		case <-timeout:
+			send()
+		}
+	}
+}
+
+// producer sends n values, sleeping gap between them, then closes.
+func producer(n int, gap time.Duration) <-chan int {
+	ch := make(chan int)
+	go func() {
+		defer close(ch)
+		for i := 1; i <= n; i++ {
+			time.Sleep(gap)
+			ch <- i
+		}
+	}()
+	return ch
+}
+
+func main() {
+	// --- Non-blocking send and receive ---
+	fmt.Println("-- non-blocking send/receive with default --")
+	events := make(chan int, 3)
+	dropped := 0
+	for i := 1; i <= 5; i++ {
+		if !trySend(events, i) {
+			dropped++
+		}
+	}
+	fmt.Printf("buffer of 3, sent 5: dropped %d\n", dropped)
+	v, ok := tryRecv(events)
+	fmt.Printf("tryRecv: %d %v\n", v, ok)
+
+	// --- Draining ---
+	fmt.Println("\n-- draining --")
+	fmt.Println("drained without blocking:", drain(events))
+	v, ok = tryRecv(events)
+	fmt.Printf("tryRecv on an empty channel: %d %v\n", v, ok)
+
+	// --- Prioritized select ---
+	fmt.Println("\n-- prioritized select --")
+	high, low := make(chan string, 10), make(chan string, 10)
+	for i := 1; i <= 3; i++ {
+		low <- fmt.Sprintf("low-%d", i)
+		high <- fmt.Sprintf("HIGH-%d", i)
+	}
+	for range 6 {
+		fmt.Print(nextByPriority(high, low), " ")
+	}
+	fmt.Println("\n(a single select would interleave them at random)")
+
+	// --- nil channels ---
+	fmt.Println("\n-- nil channels switch select cases off --")
+	sum, count := 0, 0
+	for v := range merge(producer(3, time.Millisecond), producer(5, time.Millisecond)) {
+		sum += v
+		count++
+	}
+	fmt.Printf("merged %d values, sum %d; merge exited once both inputs closed\n", count, sum)
+
+	fmt.Println("\nbatching: flush at 4 values or 50ms after the first pending one")
+	in := make(chan int)
+	go func() {
+		defer close(in)
+		for i := 1; i <= 9; i++ {
+			in <- i
+			if i == 6 {
+				time.Sleep(100 * time.Millisecond) // a pause triggers a timed flush
+			}
+		}
+	}()
+	start := time.Now()
+	batcher(in, 4, 50*time.Millisecond, func(b []int) {
+		fmt.Printf("  %3dms flush %v\n", time.Since(start).Milliseconds(), b)
+	})
+
+	// --- Timeouts vs tickers in select loops ---
+	fmt.Println("\n-- timeouts vs tickers --")
+	// time.After inside the loop is a new timer on every iteration, so it
+	// is an idle timeout: it only fires after 30ms with no value. A steady
+	// stream keeps it from ever firing.
+	values := producer(5, 10*time.Millisecond)
+idle:
+	for {
+		select {
+		case v, ok := <-values:
+			if !ok {
+				fmt.Println("idle timeout: stream ended before 30ms of silence")
+				break idle
+			}
+			_ = v
+		case <-time.After(30 * time.Millisecond):
+			fmt.Println("idle timeout fired")
+			break idle
+		}
+	}
+
+	// A deadline for the whole loop is created once, outside it.
+	values = producer(10, 10*time.Millisecond)
+	deadline := time.After(35 * time.Millisecond)
+	received := 0
+overall:
+	for {
+		select {
+		case _, ok := <-values:
+			if !ok {
+				break overall
+			}
+			received++
+		case <-deadline:
+			fmt.Printf("overall deadline: stopped after %d values\n", received)
+			break overall
+		}
+	}
+	for range values { // let the producer finish instead of blocking forever
+	}
+
+	// A ticker fires on a fixed schedule no matter how busy the loop is,
+	// for periodic work such as progress reports or heartbeats. Stop it
+	// when done; since Go 1.23 an unreferenced ticker is collected anyway,
+	// but Stop says what you mean.
+	ticker := time.NewTicker(20 * time.Millisecond)
+	defer ticker.Stop()
+	values = producer(10, 7*time.Millisecond)
+	received = 0
+	for loop := true; loop; {
+		select {
+		case _, ok := <-values:
+			if !ok {
+				loop = false
+				break
+			}
+			received++
+		case <-ticker.C:
+			fmt.Printf("tick: %d values so far\n", received)
+		}
+	}
+	fmt.Printf("ticker loop done: %d values\n", received)
+
+	fmt.Println("\nSummary:")
+	fmt.Println("- default makes a select non-blocking: try-send, try-receive, drain what is buffered.")
+	fmt.Println("- select chooses randomly among ready cases; check a high-priority channel first to prefer it.")
+	fmt.Println("- A nil channel is never ready: set a closed or unused channel to nil to switch its case off.")
+	fmt.Println("- time.After in the loop is an idle timeout; a deadline is created once outside; a ticker is a schedule.")
+}
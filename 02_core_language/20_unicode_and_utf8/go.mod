@@ -0,0 +1,5 @@
+module golang_roadmap/02_core_language/20_unicode_and_utf8
+
+go 1.24.11
+
+require golang.org/x/text v0.28.0
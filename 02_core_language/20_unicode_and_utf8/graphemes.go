@@ -0,0 +1,97 @@
+package main
+
+import (
+	"unicode"
+	"unicode/utf8"
+)
+
+// What a reader sees as one character, a grapheme cluster, can be several
+// runes: "é" as e + U+0301 COMBINING ACUTE ACCENT, a flag as two regional
+// indicators, a family emoji as people joined by U+200D ZERO WIDTH JOINER,
+// a thumbs-up with a skin tone modifier. Cutting between those runes
+// leaves a bare accent, half a flag or a different emoji.
+//
+// The full rules are Unicode's UAX #29, implemented by
+// github.com/rivo/uniseg. clusterLen covers the common cases with the
+// standard library alone.
+
+const (
+	zwj            = '\u200D'     // zero width joiner
+	riFirst        = '\U0001F1E6' // regional indicator A
+	riLast         = '\U0001F1FF' // regional indicator Z
+	modifierFirst  = '\U0001F3FB' // skin tones
+	modifierLast   = '\U0001F3FF'
+	tagFirst       = '\U000E0020' // tag characters, for subdivision flags
+	tagLast        = '\U000E007F'
+	variationFirst = '\uFE00' // variation selectors; FE0F asks for emoji style
+	variationLast  = '\uFE0F'
+)
+
+// clusterLen returns the length in bytes of the grapheme cluster at the
+// start of s.
+func clusterLen(s string) int {
+	if s == "" {
+		return 0
+	}
+	if len(s) >= 2 && s[0] == '\r' && s[1] == '\n' {
+		return 2
+	}
+	r, n := utf8.DecodeRuneInString(s)
+	if isRegionalIndicator(r) {
+		// Flags are pairs of regional indicators.
+		if r2, n2 := utf8.DecodeRuneInString(s[n:]); isRegionalIndicator(r2) {
+			n += n2
+		}
+		return n
+	}
+	for n < len(s) {
+		r, size := utf8.DecodeRuneInString(s[n:])
+		switch {
+		case extends(r):
+			n += size
+		case r == zwj:
+			n += size
+			// The joiner glues the next rune on: 👩 ZWJ 💻 is one picture.
+			if n < len(s) {
+				_, next := utf8.DecodeRuneInString(s[n:])
+				n += next
+			}
+		default:
+			return n
+		}
+	}
+	return n
+}
+
+// extends reports whether r belongs to the cluster before it: combining
+// marks (U+20E3 turns "1" into the keycap 1️⃣ this way), variation
+// selectors, skin tones and tag characters.
+func extends(r rune) bool {
+	return unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc) ||
+		r >= variationFirst && r <= variationLast ||
+		r >= modifierFirst && r <= modifierLast ||
+		r >= tagFirst && r <= tagLast
+}
+
+func isRegionalIndicator(r rune) bool { return r >= riFirst && r <= riLast }
+
+// Graphemes splits s into grapheme clusters.
+func Graphemes(s string) []string {
+	var out []string
+	for s != "" {
+		n := clusterLen(s)
+		out = append(out, s[:n])
+		s = s[n:]
+	}
+	return out
+}
+
+// GraphemeCount is what a person would count as the length of s.
+func GraphemeCount(s string) int {
+	count := 0
+	for s != "" {
+		s = s[clusterLen(s):]
+		count++
+	}
+	return count
+}
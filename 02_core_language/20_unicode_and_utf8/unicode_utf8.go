@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// Demonstrates strings as UTF-8 bytes: len vs utf8.RuneCountInString,
+// indexing bytes vs ranging over runes, invalid UTF-8, NFC/NFD
+// normalization with golang.org/x/text/unicode/norm, grapheme clusters
+// that span several runes, and truncating user text for display without
+// cutting a character in half.
+
+const ellipsis = "…"
+
+// TruncateBytes shortens s to at most n bytes, backing off to a rune
+// boundary. It is for byte limits such as a VARCHAR column or a header:
+// s[:n] alone can end in the middle of a multi-byte rune.
+func TruncateBytes(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// TruncateRunes shortens s to at most n runes. The result is valid UTF-8
+// but can still split what a reader sees as one character: "e" + U+0301
+// loses its accent, a flag becomes a lone letter.
+func TruncateRunes(s string, n int) string {
+	for i := range s {
+		if n == 0 {
+			return s[:i]
+		}
+		n--
+	}
+	return s
+}
+
+// Truncate shortens s for display to at most n grapheme clusters, the
+// ellipsis included, and never splits a cluster.
+func Truncate(s string, n int) string {
+	if n <= 0 {
+		return ""
+	}
+	if GraphemeCount(s) <= n {
+		return s
+	}
+	end := 0
+	for range n - 1 {
+		end += clusterLen(s[end:])
+	}
+	return strings.TrimRight(s[:end], " ") + ellipsis
+}
+
+// Equal compares strings as a reader would: "é" precomposed (U+00E9) and
+// "e" + U+0301 are different bytes but the same text once both are in
+// NFC. Normalize input once, at the boundary, rather than in every
+// comparison.
+func Equal(a, b string) bool {
+	return norm.NFC.String(a) == norm.NFC.String(b)
+}
+
+// ReverseRunes reverses s rune by rune; combining marks end up on the
+// wrong letter and flags turn into other flags.
+func ReverseRunes(s string) string {
+	r := []rune(s)
+	for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+		r[i], r[j] = r[j], r[i]
+	}
+	return string(r)
+}
+
+// Reverse reverses s cluster by cluster.
+func Reverse(s string) string {
+	g := Graphemes(s)
+	for i, j := 0, len(g)-1; i < j; i, j = i+1, j-1 {
+		g[i], g[j] = g[j], g[i]
+	}
+	return strings.Join(g, "")
+}
+
+func main() {
+	fmt.Println("=== Bytes vs runes ===")
+	for _, s := range []string{"Go", "héllo", "日本語", "👍🏽", "🇵🇱"} {
+		fmt.Printf("%-8q len=%2d runes=%d graphemes=%d\n", s, len(s), utf8.RuneCountInString(s), GraphemeCount(s))
+	}
+
+	s := "naïve"
+	fmt.Printf("\ns[2] = %#x, a byte, not a letter\n", s[2])
+	for i, r := range s { // i jumps by the rune's width in bytes
+		fmt.Printf("  %d: %q U+%04X width=%d\n", i, r, r, utf8.RuneLen(r))
+	}
+
+	fmt.Println("\n=== Invalid UTF-8 ===")
+	bad := "ok\xffgo"
+	fmt.Printf("valid=%v\n", utf8.ValidString(bad))
+	for _, r := range bad {
+		fmt.Printf("%q ", r) // the bad byte decodes as U+FFFD
+	}
+	fmt.Printf("\nToValidUTF8: %q\n", strings.ToValidUTF8(bad, "?"))
+
+	fmt.Println("\n=== Normalization ===")
+	nfc, nfd := "caf\u00e9", "cafe\u0301"
+	fmt.Printf("%s and %s: == %v, Equal %v\n", nfc, nfd, nfc == nfd, Equal(nfc, nfd))
+	fmt.Printf("NFC % x (%d bytes)\n", norm.NFC.String(nfd), len(norm.NFC.String(nfd)))
+	fmt.Printf("NFD % x (%d bytes)\n", norm.NFD.String(nfc), len(norm.NFD.String(nfc)))
+	fmt.Printf("strings.Contains(%q, \"é\") = %v\n", nfd, strings.Contains(nfd, "é"))
+
+	fmt.Println("\n=== Grapheme clusters ===")
+	for _, s := range []string{"cafe\u0301", "👩\u200d💻", "👨\u200d👩\u200d👧\u200d👦", "🇵🇱🇩🇪", "1\ufe0f\u20e3"} {
+		fmt.Printf("%-6s runes=%d graphemes=%d %q\n", s, utf8.RuneCountInString(s), GraphemeCount(s), Graphemes(s))
+	}
+	fmt.Printf("ReverseRunes(%q) = %q\n", "🇵🇱🇩🇪", ReverseRunes("🇵🇱🇩🇪"))
+	fmt.Printf("Reverse(%q)      = %q\n", "🇵🇱🇩🇪", Reverse("🇵🇱🇩🇪"))
+
+	fmt.Println("\n=== Truncating for display ===")
+	name := "Zoë 👩\u200d💻 Ångström"
+	fmt.Printf("TruncateBytes(7) = %q\n", TruncateBytes(name, 7))
+	fmt.Printf("s[:7]            = %q (valid=%v)\n", name[:7], utf8.ValidString(name[:7]))
+	fmt.Printf("TruncateRunes(6) = %q\n", TruncateRunes(name, 6))
+	fmt.Printf("Truncate(7)      = %q\n", Truncate(name, 7))
+}
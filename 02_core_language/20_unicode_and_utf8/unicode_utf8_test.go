@@ -0,0 +1,136 @@
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+	"unicode/utf8"
+)
+
+const (
+	eAcute     = "e\u0301"                // e + combining acute accent
+	thumbsUp   = "👍\U0001F3FD"            // with a skin tone modifier
+	family     = "👨\u200d👩\u200d👧\u200d👦" // four people, three joiners
+	polishFlag = "\U0001F1F5\U0001F1F1"   // regional indicators P, L
+	keycapOne  = "1\ufe0f\u20e3"
+	// A black flag followed by tag letters "gbsct" and a cancel tag.
+	scotland = "🏴\U000E0067\U000E0062\U000E0073\U000E0063\U000E0074\U000E007F"
+)
+
+func TestGraphemes(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+	}{
+		{"", nil},
+		{"abc", []string{"a", "b", "c"}},
+		{"caf" + eAcute, []string{"c", "a", "f", eAcute}},
+		{"a\u0301\u0327b", []string{"a\u0301\u0327", "b"}}, // two marks on one letter
+		{thumbsUp + "!", []string{thumbsUp, "!"}},
+		{family, []string{family}},
+		{polishFlag + polishFlag, []string{polishFlag, polishFlag}},
+		{"\U0001F1F5", []string{"\U0001F1F5"}}, // half a flag
+		{keycapOne + "2", []string{keycapOne, "2"}},
+		{scotland, []string{scotland}},
+		{"a\r\nb", []string{"a", "\r\n", "b"}},
+		{"ok\xffgo", []string{"o", "k", "\xff", "g", "o"}},
+	}
+	for _, tt := range tests {
+		if got := Graphemes(tt.s); !slices.Equal(got, tt.want) {
+			t.Errorf("Graphemes(%q) = %q; want %q", tt.s, got, tt.want)
+		}
+		if got := GraphemeCount(tt.s); got != len(tt.want) {
+			t.Errorf("GraphemeCount(%q) = %d; want %d", tt.s, got, len(tt.want))
+		}
+	}
+}
+
+func TestTruncateBytes(t *testing.T) {
+	s := "añ" + thumbsUp // 1 + 2 + 4 + 4 bytes
+	for n, want := range map[int]string{
+		0:  "",
+		1:  "a",
+		2:  "a", // inside ñ
+		3:  "añ",
+		6:  "añ", // inside 👍
+		7:  "añ👍",
+		10: "añ👍", // inside the skin tone: valid UTF-8, wrong picture
+		11: s,
+		99: s,
+	} {
+		got := TruncateBytes(s, n)
+		if got != want || !utf8.ValidString(got) {
+			t.Errorf("TruncateBytes(%q, %d) = %q; want %q", s, n, got, want)
+		}
+	}
+}
+
+func TestTruncateRunes_SplitsClusters(t *testing.T) {
+	// Valid UTF-8, but not what the reader saw.
+	if got := TruncateRunes("caf"+eAcute, 4); got != "cafe" {
+		t.Errorf("TruncateRunes = %q; want the accent dropped", got)
+	}
+	if got := TruncateRunes(polishFlag, 1); got != "\U0001F1F5" {
+		t.Errorf("TruncateRunes = %q; want half the flag", got)
+	}
+	if got := TruncateRunes("abc", 5); got != "abc" {
+		t.Errorf("TruncateRunes short string = %q", got)
+	}
+}
+
+func TestTruncate(t *testing.T) {
+	tests := []struct {
+		s    string
+		n    int
+		want string
+	}{
+		{"hello", 5, "hello"},
+		{"hello", 10, "hello"},
+		{"hello, world", 6, "hello…"},
+		{"hello world", 7, "hello…"}, // no space before the ellipsis
+		{"caf" + eAcute + "s", 5, "caf" + eAcute + "s"},
+		{"caf" + eAcute + " au lait", 5, "caf" + eAcute + "…"},
+		{"hi" + family + family + family, 4, "hi" + family + "…"},
+		{polishFlag + polishFlag + polishFlag, 2, polishFlag + "…"},
+		{thumbsUp + thumbsUp, 1, "…"},
+		{"abc", 0, ""},
+		{"", 3, ""},
+	}
+	for _, tt := range tests {
+		got := Truncate(tt.s, tt.n)
+		if got != tt.want {
+			t.Errorf("Truncate(%q, %d) = %q; want %q", tt.s, tt.n, got, tt.want)
+		}
+		if c := GraphemeCount(got); c > tt.n {
+			t.Errorf("Truncate(%q, %d) has %d graphemes", tt.s, tt.n, c)
+		}
+		if !strings.HasSuffix(got, "…") && got != tt.s && got != "" {
+			t.Errorf("Truncate(%q, %d) = %q: cut without an ellipsis", tt.s, tt.n, got)
+		}
+	}
+}
+
+func TestEqual(t *testing.T) {
+	if "café" == "caf"+eAcute {
+		t.Fatal("precomposed and decomposed forms compare equal with ==")
+	}
+	if !Equal("café", "caf"+eAcute) {
+		t.Error("Equal(NFC, NFD) = false; want true")
+	}
+	if !Equal("\u212b", "Å") { // ANGSTROM SIGN is canonically Å
+		t.Error("Equal(Å sign, Å) = false; want true")
+	}
+	if Equal("e", eAcute) {
+		t.Error("Equal(e, é) = true")
+	}
+}
+
+func TestReverse(t *testing.T) {
+	if got := Reverse("ab" + eAcute + thumbsUp); got != thumbsUp+eAcute+"ba" {
+		t.Errorf("Reverse = %q", got)
+	}
+	// Rune by rune, the accent moves onto the wrong letter.
+	if got := ReverseRunes("x" + eAcute); got != "\u0301ex" {
+		t.Errorf("ReverseRunes = %q", got)
+	}
+}
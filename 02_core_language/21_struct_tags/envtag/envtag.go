@@ -0,0 +1,196 @@
+// Package envtag fills a struct from environment variables named in its
+// struct tags:
+//
+//	type Config struct {
+//		Port    int           `env:"PORT" default:"8080"`
+//		DBURL   string        `env:"DATABASE_URL,required"`
+//		Timeout time.Duration `env:"TIMEOUT" default:"5s"`
+//		Hosts   []string      `env:"HOSTS"` // comma-separated
+//		Debug   *bool         `env:"DEBUG"` // nil when unset
+//	}
+//
+// Fields without an env tag are left alone, except untagged struct fields,
+// which are walked for tagged fields of their own.
+package envtag
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrRequired is wrapped by the error for a required variable that is not
+// set. A variable set to the empty string counts as set.
+var ErrRequired = errors.New("required but not set")
+
+// Tag is a parsed env tag together with the field's default tag.
+type Tag struct {
+	Name       string // the environment variable
+	Required   bool
+	Default    string
+	HasDefault bool // distinguishes default:"" from no default
+}
+
+// ParseTag reads the env and default keys of a field's tag. ok is false for
+// fields without an env tag and for env:"-".
+func ParseTag(st reflect.StructTag) (tag Tag, ok bool, err error) {
+	env, ok := st.Lookup("env")
+	if !ok || env == "-" {
+		return Tag{}, false, nil
+	}
+	name, opts, _ := strings.Cut(env, ",")
+	if name == "" {
+		return Tag{}, false, fmt.Errorf("env tag %q: empty variable name", env)
+	}
+	tag.Name = name
+	if opts != "" {
+		for opt := range strings.SplitSeq(opts, ",") {
+			if opt != "required" {
+				return Tag{}, false, fmt.Errorf("env tag %q: unknown option %q", env, opt)
+			}
+			tag.Required = true
+		}
+	}
+	tag.Default, tag.HasDefault = st.Lookup("default")
+	if tag.Required && tag.HasDefault {
+		return Tag{}, false, fmt.Errorf("env tag %q: a required variable cannot have a default", env)
+	}
+	return tag, true, nil
+}
+
+// Load fills the struct dst points to from the process environment.
+func Load(dst any) error { return LoadFrom(dst, os.LookupEnv) }
+
+// LoadFrom fills the struct dst points to using lookup, which tests can
+// back with a map. For each tagged field it takes the variable if set,
+// else the default if any, else leaves the field as it was, so dst can
+// arrive pre-filled. It reports every bad field, not just the first.
+func LoadFrom(dst any, lookup func(string) (string, bool)) error {
+	v := reflect.ValueOf(dst)
+	if v.Kind() != reflect.Pointer || v.IsNil() || v.Elem().Kind() != reflect.Struct {
+		return fmt.Errorf("envtag: want a non-nil pointer to a struct, got %T", dst)
+	}
+	var errs []error
+	walk(v.Elem(), "", func(fv reflect.Value, path string, tag Tag, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", path, err))
+			return
+		}
+		s, set := lookup(tag.Name)
+		switch {
+		case set:
+		case tag.Required:
+			errs = append(errs, fmt.Errorf("%s: %w", tag.Name, ErrRequired))
+			return
+		case tag.HasDefault:
+			s = tag.Default
+		default:
+			return
+		}
+		if err := setValue(fv, s); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", tag.Name, err))
+		}
+	})
+	return errors.Join(errs...)
+}
+
+// walk calls fn for every env-tagged field of v, recursing into untagged
+// struct fields. path is the Go field path, such as DB.Timeout.
+func walk(v reflect.Value, prefix string, fn func(fv reflect.Value, path string, tag Tag, err error)) {
+	t := v.Type()
+	for i := range t.NumField() {
+		sf := t.Field(i)
+		path := prefix + sf.Name
+		tag, ok, err := ParseTag(sf.Tag)
+		switch {
+		case err != nil:
+			fn(reflect.Value{}, path, Tag{}, err)
+		case ok && !sf.IsExported():
+			fn(reflect.Value{}, path, Tag{}, errors.New("env tag on an unexported field"))
+		case ok:
+			fn(v.Field(i), path, tag, nil)
+		case sf.Type.Kind() == reflect.Struct && sf.IsExported():
+			walk(v.Field(i), path+".", fn)
+		}
+	}
+}
+
+var (
+	durationType        = reflect.TypeFor[time.Duration]()
+	textUnmarshalerType = reflect.TypeFor[encoding.TextUnmarshaler]()
+)
+
+// setValue converts s to v's type and stores it. Types that implement
+// encoding.TextUnmarshaler (net.IP, slog.Level, time.Time...) parse
+// themselves.
+func setValue(v reflect.Value, s string) error {
+	if v.Kind() == reflect.Pointer {
+		p := reflect.New(v.Type().Elem())
+		if err := setValue(p.Elem(), s); err != nil {
+			return err
+		}
+		v.Set(p)
+		return nil
+	}
+	if reflect.PointerTo(v.Type()).Implements(textUnmarshalerType) {
+		return v.Addr().Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s))
+	}
+	if v.Type() == durationType {
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return err
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", s)
+		}
+		v.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not an %s: %w", s, v.Type(), errors.Unwrap(err))
+		}
+		v.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(s, 10, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a %s: %w", s, v.Type(), errors.Unwrap(err))
+		}
+		v.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		f, err := strconv.ParseFloat(s, v.Type().Bits())
+		if err != nil {
+			return fmt.Errorf("%q is not a %s: %w", s, v.Type(), errors.Unwrap(err))
+		}
+		v.SetFloat(f)
+	case reflect.Slice:
+		// Comma-separated, and an empty variable is an empty slice.
+		out := reflect.MakeSlice(v.Type(), 0, 0)
+		for part := range strings.SplitSeq(s, ",") {
+			if part = strings.TrimSpace(part); part == "" {
+				continue
+			}
+			elem := reflect.New(v.Type().Elem()).Elem()
+			if err := setValue(elem, part); err != nil {
+				return err
+			}
+			out = reflect.Append(out, elem)
+		}
+		v.Set(out)
+	default:
+		return fmt.Errorf("unsupported type %s", v.Type())
+	}
+	return nil
+}
@@ -0,0 +1,189 @@
+package envtag
+
+import (
+	"errors"
+	"log/slog"
+	"net"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+}
+
+type DB struct {
+	URL     string        `env:"DATABASE_URL,required"`
+	Timeout time.Duration `env:"DB_TIMEOUT" default:"5s"`
+}
+
+type settings struct {
+	Port    int        `env:"PORT" default:"8080"`
+	Host    string     `env:"HOST" default:"localhost"`
+	Debug   *bool      `env:"DEBUG"`
+	Ratio   float64    `env:"RATIO" default:"0.5"`
+	Workers uint8      `env:"WORKERS" default:"4"`
+	Tags    []string   `env:"TAGS"`
+	Ports   []int      `env:"PORTS"`
+	Level   slog.Level `env:"LOG_LEVEL" default:"info"`
+	IP      net.IP     `env:"BIND_IP"`
+	DB      DB         // untagged struct: walked
+	Ignored string     `env:"-"`
+	Plain   string     // left alone
+	when    time.Time  // unexported and untagged: skipped
+	_       struct{}
+}
+
+func TestLoadFrom_Defaults(t *testing.T) {
+	var s settings
+	if err := LoadFrom(&s, env(map[string]string{"DATABASE_URL": "postgres://db"})); err != nil {
+		t.Fatal(err)
+	}
+	if s.Port != 8080 || s.Host != "localhost" || s.Ratio != 0.5 || s.Workers != 4 ||
+		s.Level != slog.LevelInfo || s.DB.Timeout != 5*time.Second {
+		t.Errorf("defaults not applied: %+v", s)
+	}
+	if s.Debug != nil || s.Tags != nil || s.IP != nil {
+		t.Errorf("fields without a default or variable were set: %+v", s)
+	}
+}
+
+func TestLoadFrom_Conversion(t *testing.T) {
+	var s settings
+	err := LoadFrom(&s, env(map[string]string{
+		"PORT":         "9000",
+		"DEBUG":        "false",
+		"RATIO":        "1e-3",
+		"WORKERS":      "255",
+		"TAGS":         " a, b ,,c ",
+		"PORTS":        "80,443",
+		"LOG_LEVEL":    "warn",
+		"BIND_IP":      "10.0.0.1",
+		"DATABASE_URL": "postgres://db",
+		"DB_TIMEOUT":   "1m30s",
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Port != 9000 || s.Ratio != 0.001 || s.Workers != 255 || s.Level != slog.LevelWarn ||
+		s.DB.Timeout != 90*time.Second || s.DB.URL != "postgres://db" {
+		t.Errorf("got %+v", s)
+	}
+	if s.Debug == nil || *s.Debug {
+		t.Errorf("Debug = %v; want a pointer to false", s.Debug)
+	}
+	if !slices.Equal(s.Tags, []string{"a", "b", "c"}) || !slices.Equal(s.Ports, []int{80, 443}) {
+		t.Errorf("Tags = %q, Ports = %v", s.Tags, s.Ports)
+	}
+	if !s.IP.Equal(net.IPv4(10, 0, 0, 1)) {
+		t.Errorf("IP = %v", s.IP)
+	}
+}
+
+func TestLoadFrom_Errors(t *testing.T) {
+	var s settings
+	err := LoadFrom(&s, env(map[string]string{
+		"PORT":       "http",
+		"WORKERS":    "256", // overflows uint8
+		"DEBUG":      "maybe",
+		"PORTS":      "80,x",
+		"DB_TIMEOUT": "5",
+		"LOG_LEVEL":  "loud",
+	}))
+	if !errors.Is(err, ErrRequired) {
+		t.Errorf("missing DATABASE_URL: err = %v; want ErrRequired", err)
+	}
+	// Every problem is reported, each under its variable.
+	for _, want := range []string{
+		`PORT: "http" is not an int`,
+		`WORKERS: "256" is not a uint8: value out of range`,
+		`DEBUG: "maybe" is not a boolean`,
+		`PORTS: "x" is not an int`,
+		`DB_TIMEOUT: time: missing unit`,
+		`LOG_LEVEL: slog: level string "loud"`,
+		`DATABASE_URL: required but not set`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v\nwant it to contain %q", err, want)
+		}
+	}
+}
+
+func TestLoadFrom_RequiredSetEmpty(t *testing.T) {
+	var db DB
+	if err := LoadFrom(&db, env(map[string]string{"DATABASE_URL": ""})); err != nil {
+		t.Errorf("required variable set to \"\": %v; want it accepted", err)
+	}
+}
+
+func TestLoadFrom_KeepsPrefilledValues(t *testing.T) {
+	s := settings{Tags: []string{"preset"}, DB: DB{URL: "x"}}
+	if err := LoadFrom(&s, env(map[string]string{"DATABASE_URL": "y"})); err != nil {
+		t.Fatal(err)
+	}
+	if !slices.Equal(s.Tags, []string{"preset"}) {
+		t.Errorf("Tags = %q; an unset variable without a default must not clear the field", s.Tags)
+	}
+}
+
+func TestLoadFrom_BadTargets(t *testing.T) {
+	var s settings
+	var nilPtr *settings
+	for _, dst := range []any{s, nilPtr, new(int), nil} {
+		if err := LoadFrom(dst, env(nil)); err == nil {
+			t.Errorf("LoadFrom(%T) = nil; want an error", dst)
+		}
+	}
+
+	var bad struct {
+		Fine   string   `env:"FINE"`
+		hidden string   `env:"HIDDEN"`
+		Chan   chan int `env:"CHAN"`
+	}
+	err := LoadFrom(&bad, env(map[string]string{"HIDDEN": "x", "CHAN": "x"}))
+	for _, want := range []string{"hidden: env tag on an unexported field", "CHAN: unsupported type chan int"} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v; want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		tag     reflect.StructTag
+		want    Tag
+		ok      bool
+		wantErr string
+	}{
+		{`env:"PORT" default:"8080"`, Tag{Name: "PORT", Default: "8080", HasDefault: true}, true, ""},
+		{`env:"KEY,required"`, Tag{Name: "KEY", Required: true}, true, ""},
+		{`env:"EMPTY" default:""`, Tag{Name: "EMPTY", HasDefault: true}, true, ""},
+		{`json:"port"`, Tag{}, false, ""},
+		{`env:"-"`, Tag{}, false, ""},
+		{`env:""`, Tag{}, false, "empty variable name"},
+		{`env:"X,requird"`, Tag{}, false, `unknown option "requird"`},
+		{`env:"X,required" default:"1"`, Tag{}, false, "cannot have a default"},
+		// Not key:"value": Lookup finds nothing. go vet's structtag check
+		// reports tags like these.
+		{`env: "PORT"`, Tag{}, false, ""},
+		{`env:PORT`, Tag{}, false, ""},
+	}
+	for _, tt := range tests {
+		got, ok, err := ParseTag(tt.tag)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("ParseTag(%s) err = %v; want %q", tt.tag, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || ok != tt.ok || got != tt.want {
+			t.Errorf("ParseTag(%s) = %+v, %v, %v; want %+v, %v", tt.tag, got, ok, err, tt.want, tt.ok)
+		}
+	}
+}
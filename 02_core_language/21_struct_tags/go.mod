@@ -0,0 +1,3 @@
+module golang_roadmap/02_core_language/21_struct_tags
+
+go 1.24.11
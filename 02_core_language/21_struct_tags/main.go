@@ -0,0 +1,83 @@
+// Demonstrates struct tags: metadata on struct fields read with reflect.
+//
+// This example shows:
+// - The key:"value" convention and reflect.StructTag Get vs Lookup
+// - Several packages reading one field's tags (json, env, default)
+// - Malformed tags, which Get silently ignores and go vet reports
+// - Options inside a tag value, as in json:"name,omitempty"
+// - The envtag package: an env-to-struct loader driven by tags
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"reflect"
+	"time"
+
+	"golang_roadmap/02_core_language/21_struct_tags/envtag"
+)
+
+type Server struct {
+	Port    int           `json:"port" env:"PORT" default:"8080"`
+	Host    string        `json:"host,omitempty" env:"HOST"`
+	Token   string        `json:"-" env:"API_TOKEN,required"`
+	Timeout time.Duration `json:"timeout" env:"TIMEOUT" default:"5s"`
+	Origins []string      `json:"origins" env:"CORS_ORIGINS" default:"https://example.com"`
+	Verbose *bool         `json:"verbose,omitempty" env:"VERBOSE"`
+}
+
+func main() {
+	fmt.Println("=== Reading tags with reflect ===")
+	t := reflect.TypeFor[Server]()
+	for i := range t.NumField() {
+		f := t.Field(i)
+		def, hasDef := f.Tag.Lookup("default")
+		fmt.Printf("%-8s json=%-22q env=%-22q default=%q (set: %v)\n",
+			f.Name, f.Tag.Get("json"), f.Tag.Get("env"), def, hasDef)
+	}
+
+	fmt.Println("\n=== Malformed tags ===")
+	// A tag is a plain string literal; the compiler does not check it.
+	// Get finds nothing in these, and go vet's structtag check flags them.
+	for _, tag := range []reflect.StructTag{
+		`env:"PORT"`,
+		`env: "PORT"`,             // space after the colon
+		`env:'PORT'`,              // single quotes
+		`env:"PORT",default:"80"`, // a comma, not a space: default is lost
+	} {
+		v, ok := tag.Lookup("env")
+		fmt.Printf("%-26s Lookup(env) = %q, %v   Lookup(default) ok=%v\n", tag, v, ok, lookupOK(tag, "default"))
+	}
+
+	fmt.Println("\n=== Parsing one package's tag ===")
+	for _, tag := range []reflect.StructTag{
+		`env:"PORT" default:"8080"`,
+		`env:"API_TOKEN,required"`,
+		`env:"X,requird"`,
+		`env:"X,required" default:"1"`,
+	} {
+		parsed, ok, err := envtag.ParseTag(tag)
+		fmt.Printf("%-30s %+v ok=%v err=%v\n", tag, parsed, ok, err)
+	}
+
+	fmt.Println("\n=== Loading from the environment ===")
+	vars := map[string]string{"PORT": "9000", "CORS_ORIGINS": "https://a.test, https://b.test"}
+	lookup := func(k string) (string, bool) { v, ok := vars[k]; return v, ok }
+	var s Server
+	fmt.Printf("without API_TOKEN: %v\n", envtag.LoadFrom(&s, lookup))
+	vars["API_TOKEN"] = "s3cret"
+	vars["VERBOSE"] = "yes"
+	fmt.Printf("VERBOSE=yes:       %v\n", envtag.LoadFrom(&s, lookup))
+	vars["VERBOSE"] = "true"
+	if err := envtag.LoadFrom(&s, lookup); err != nil {
+		log.Fatal(err)
+	}
+	js, _ := json.Marshal(s) // the same struct, now read by encoding/json
+	fmt.Printf("loaded: %s\n", js)
+}
+
+func lookupOK(tag reflect.StructTag, key string) bool {
+	_, ok := tag.Lookup(key)
+	return ok
+}
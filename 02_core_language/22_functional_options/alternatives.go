@@ -0,0 +1,84 @@
+package main
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+)
+
+// The same Server, built the two other common ways, for comparison.
+
+// ServerConfig is the config-struct style: one exported struct, where the
+// zero value of each field means "use the default".
+//
+//	NewServerFromConfig(h, ServerConfig{Addr: ":9000"})
+//
+// It is plain data: easy to fill from a file, to print and to compare. But
+// a zero cannot be asked for on purpose (a timeout of 0 means "default",
+// not "none"), and every caller sees every field, even in the simplest
+// call.
+type ServerConfig struct {
+	Addr         string
+	Timeout      time.Duration
+	Logger       *slog.Logger
+	MaxBodyBytes int64
+}
+
+func NewServerFromConfig(h http.Handler, c ServerConfig) (*Server, error) {
+	var opts []ServerOption
+	if c.Addr != "" {
+		opts = append(opts, WithAddr(c.Addr))
+	}
+	if c.Timeout != 0 {
+		opts = append(opts, WithTimeout(c.Timeout))
+	}
+	if c.Logger != nil {
+		opts = append(opts, WithLogger(c.Logger))
+	}
+	if c.MaxBodyBytes != 0 {
+		opts = append(opts, WithMaxBodyBytes(c.MaxBodyBytes))
+	}
+	return NewServer(h, opts...)
+}
+
+// ServerBuilder is the builder style: chained setters and a final Build.
+//
+//	NewServerBuilder(h).Addr(":9000").Timeout(5 * time.Second).Build()
+//
+// It reads well, but errors have to wait until Build, the builder is a
+// second type to document, and a half-configured builder can be passed
+// around and mutated. It is common in Java, less so in Go.
+type ServerBuilder struct {
+	h    http.Handler
+	opts []ServerOption
+}
+
+func NewServerBuilder(h http.Handler) *ServerBuilder { return &ServerBuilder{h: h} }
+
+func (b *ServerBuilder) Addr(addr string) *ServerBuilder {
+	b.opts = append(b.opts, WithAddr(addr))
+	return b
+}
+
+func (b *ServerBuilder) Timeout(d time.Duration) *ServerBuilder {
+	b.opts = append(b.opts, WithTimeout(d))
+	return b
+}
+
+func (b *ServerBuilder) Logger(l *slog.Logger) *ServerBuilder {
+	b.opts = append(b.opts, WithLogger(l))
+	return b
+}
+
+func (b *ServerBuilder) MaxBodyBytes(n int64) *ServerBuilder {
+	b.opts = append(b.opts, WithMaxBodyBytes(n))
+	return b
+}
+
+func (b *ServerBuilder) Build() (*Server, error) {
+	if b.h == nil {
+		return nil, errors.New("ServerBuilder: nil handler")
+	}
+	return NewServer(b.h, b.opts...)
+}
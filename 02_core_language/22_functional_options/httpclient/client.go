@@ -0,0 +1,278 @@
+// Package httpclient wraps http.Client for calling one JSON API: a base
+// URL, default headers, a timeout, request logging and retries, all set
+// with functional options.
+//
+//	c, err := httpclient.New(
+//		httpclient.WithBaseURL("https://api.example.com"),
+//		httpclient.WithTimeout(5*time.Second),
+//		httpclient.WithRetry(retry.Policy{MaxAttempts: 4}),
+//	)
+//
+// New() with no options works too: every option has a default.
+package httpclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"golang_roadmap/12_operations/03_retry/retry"
+)
+
+// Client sends requests to one API. It is safe for concurrent use.
+type Client struct {
+	hc      *http.Client
+	baseURL *url.URL
+	header  http.Header
+	logger  *slog.Logger
+	retry   *retry.Policy // nil: one attempt
+	token   func(context.Context) (string, error)
+}
+
+// Option configures a Client. An option returns an error for a value it
+// cannot use; New collects them.
+type Option func(*Client) error
+
+// WithTimeout limits each attempt, from dialling to reading the body
+// (default 10s). With retries, the caller's context bounds the total.
+func WithTimeout(d time.Duration) Option {
+	return func(c *Client) error {
+		if d <= 0 {
+			return fmt.Errorf("WithTimeout: must be positive, got %v", d)
+		}
+		c.hc.Timeout = d
+		return nil
+	}
+}
+
+// WithLogger logs each attempt: method, URL, status and duration at Info,
+// retries at Warn (default: no logging).
+func WithLogger(l *slog.Logger) Option {
+	return func(c *Client) error {
+		if l == nil {
+			return errors.New("WithLogger: nil logger")
+		}
+		c.logger = l
+		return nil
+	}
+}
+
+// WithRetry retries idempotent requests (GET, HEAD, OPTIONS, PUT, DELETE)
+// on network errors, 429 and 5xx, honouring Retry-After. A request with a
+// body is retried only if it can be re-read (http.NewRequest sets GetBody
+// for the common body types). POST and PATCH are never retried: the first
+// attempt may have done its work.
+func WithRetry(p retry.Policy) Option {
+	return func(c *Client) error {
+		if p.MaxAttempts < 0 || p.Initial < 0 || p.Max < 0 {
+			return fmt.Errorf("WithRetry: negative setting in %+v", p)
+		}
+		c.retry = &p
+		return nil
+	}
+}
+
+// WithBaseURL resolves request paths against raw, an absolute http or
+// https URL. Without it, paths must be absolute URLs.
+func WithBaseURL(raw string) Option {
+	return func(c *Client) error {
+		u, err := url.Parse(raw)
+		if err != nil {
+			return fmt.Errorf("WithBaseURL: %w", err)
+		}
+		if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+			return fmt.Errorf("WithBaseURL: %q is not an absolute http(s) URL", raw)
+		}
+		c.baseURL = u
+		return nil
+	}
+}
+
+// WithHeader adds a header to every request. Headers set on a request
+// win over it.
+func WithHeader(key, value string) Option {
+	return func(c *Client) error {
+		if key == "" {
+			return errors.New("WithHeader: empty key")
+		}
+		c.header.Add(key, value)
+		return nil
+	}
+}
+
+// WithBearerToken sends "Authorization: Bearer <token>" with every
+// request that has no Authorization header of its own. token is called
+// once per request, so a token that changes, such as one read from the
+// OS keychain after a login, is used without building a new Client. An
+// empty token sends no header; an error fails the request unsent.
+func WithBearerToken(token func(ctx context.Context) (string, error)) Option {
+	return func(c *Client) error {
+		if token == nil {
+			return errors.New("WithBearerToken: nil token function")
+		}
+		c.token = token
+		return nil
+	}
+}
+
+// WithTransport replaces http.DefaultTransport, e.g. with a tracing or a
+// test transport.
+func WithTransport(rt http.RoundTripper) Option {
+	return func(c *Client) error {
+		if rt == nil {
+			return errors.New("WithTransport: nil transport")
+		}
+		c.hc.Transport = rt
+		return nil
+	}
+}
+
+// New returns a client with the defaults, changed by opts in order. It
+// reports every invalid option, not just the first.
+func New(opts ...Option) (*Client, error) {
+	c := &Client{
+		hc:     &http.Client{Timeout: 10 * time.Second},
+		header: make(http.Header),
+		logger: slog.New(slog.DiscardHandler),
+	}
+	var errs []error
+	for _, opt := range opts {
+		if err := opt(c); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("httpclient: %w", err)
+	}
+	return c, nil
+}
+
+// Get fetches path, resolved against the base URL.
+func (c *Client) Get(ctx context.Context, path string) (*http.Response, error) {
+	req, err := c.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.Do(req)
+}
+
+// NewRequest is http.NewRequestWithContext with path resolved against the
+// base URL.
+func (c *Client) NewRequest(ctx context.Context, method, path string, body io.Reader) (*http.Request, error) {
+	u, err := url.Parse(path)
+	if err != nil {
+		return nil, fmt.Errorf("httpclient: %w", err)
+	}
+	if c.baseURL != nil {
+		u = c.baseURL.ResolveReference(u)
+	}
+	return http.NewRequestWithContext(ctx, method, u.String(), body)
+}
+
+// Do sends req with the default headers, retrying as configured. As with
+// http.Client, a 4xx response is not an error; a 429 or 5xx is one only
+// once the retries run out, and then the response is closed.
+func (c *Client) Do(req *http.Request) (*http.Response, error) {
+	for k, vs := range c.header {
+		if _, ok := req.Header[k]; !ok {
+			req.Header[k] = vs
+		}
+	}
+	if c.token != nil && req.Header.Get("Authorization") == "" {
+		tok, err := c.token(req.Context())
+		if err != nil {
+			return nil, fmt.Errorf("httpclient: %s %s: token: %w", req.Method, req.URL, err)
+		}
+		if tok != "" {
+			req.Header.Set("Authorization", "Bearer "+tok)
+		}
+	}
+	if c.retry == nil || !replayable(req) {
+		return c.send(req)
+	}
+
+	p := *c.retry
+	if p.Retryable == nil {
+		// A per-attempt timeout is worth retrying; the caller giving up is
+		// not.
+		p.Retryable = func(error) bool { return req.Context().Err() == nil }
+	}
+	if p.OnRetry == nil {
+		p.OnRetry = func(attempt int, err error, delay time.Duration) {
+			c.logger.LogAttrs(req.Context(), slog.LevelWarn, "retrying",
+				slog.String("method", req.Method), slog.String("url", req.URL.String()),
+				slog.Int("attempt", attempt), slog.Any("err", err), slog.Duration("delay", delay))
+		}
+	}
+	var resp *http.Response
+	err := retry.Do(req.Context(), p, func(ctx context.Context) error {
+		r := req.Clone(ctx)
+		if req.Body != nil && req.Body != http.NoBody {
+			body, err := req.GetBody()
+			if err != nil {
+				return retry.Permanent(err)
+			}
+			r.Body = body
+		}
+		var err error
+		resp, err = c.send(r)
+		if err != nil {
+			return err
+		}
+		if resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500 {
+			io.Copy(io.Discard, io.LimitReader(resp.Body, 4<<10)) // lets the connection be reused
+			resp.Body.Close()
+			secs, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+			return &StatusError{Code: resp.StatusCode, RetryAfterDelay: time.Duration(secs) * time.Second}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("httpclient: %s %s: %w", req.Method, req.URL, err)
+	}
+	return resp, nil
+}
+
+func (c *Client) send(req *http.Request) (*http.Response, error) {
+	start := time.Now()
+	resp, err := c.hc.Do(req)
+	attrs := []slog.Attr{
+		slog.String("method", req.Method),
+		slog.String("url", req.URL.String()),
+		slog.Duration("took", time.Since(start)),
+	}
+	if err != nil {
+		c.logger.LogAttrs(req.Context(), slog.LevelInfo, "request failed", append(attrs, slog.Any("err", err))...)
+		return nil, err
+	}
+	c.logger.LogAttrs(req.Context(), slog.LevelInfo, "request", append(attrs, slog.Int("status", resp.StatusCode))...)
+	return resp, nil
+}
+
+// replayable reports whether req may be sent again.
+func replayable(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodPut, http.MethodDelete:
+	default:
+		return false
+	}
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// StatusError is a 429 or 5xx response that was still failing when the
+// retries ran out.
+type StatusError struct {
+	Code            int
+	RetryAfterDelay time.Duration // from the Retry-After header, in seconds form only
+}
+
+func (e *StatusError) Error() string { return fmt.Sprintf("HTTP %d", e.Code) }
+
+// RetryAfter lets retry.Do wait as long as the server asked.
+func (e *StatusError) RetryAfter() time.Duration { return e.RetryAfterDelay }
@@ -0,0 +1,227 @@
+package httpclient
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang_roadmap/12_operations/03_retry/retry"
+)
+
+var fast = retry.Policy{MaxAttempts: 3, Initial: time.Millisecond, Max: time.Millisecond}
+
+func TestNew_Defaults(t *testing.T) {
+	c, err := New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.hc.Timeout != 10*time.Second || c.retry != nil || c.baseURL != nil {
+		t.Errorf("defaults: timeout=%v retry=%v base=%v", c.hc.Timeout, c.retry, c.baseURL)
+	}
+}
+
+func TestNew_ReportsEveryBadOption(t *testing.T) {
+	_, err := New(
+		WithTimeout(0),
+		WithLogger(nil),
+		WithBaseURL("api.example.com"),
+		WithHeader("", "x"),
+		WithTransport(nil),
+		WithRetry(retry.Policy{Initial: -time.Second}),
+		WithBearerToken(nil),
+	)
+	if err == nil {
+		t.Fatal("want an error")
+	}
+	for _, want := range []string{"WithTimeout", "WithLogger", "WithBaseURL", "WithHeader", "WithTransport", "WithRetry", "WithBearerToken"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v; want it to mention %s", err, want)
+		}
+	}
+}
+
+func TestClient_BaseURLAndHeaders(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.URL.Path + " " + r.Header.Get("Authorization") + " " + r.Header.Get("Accept")))
+	}))
+	defer srv.Close()
+
+	c, err := New(WithBaseURL(srv.URL+"/v1/"), WithHeader("Authorization", "Bearer t"), WithHeader("Accept", "application/json"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req, _ := c.NewRequest(context.Background(), http.MethodGet, "users/7", nil)
+	req.Header.Set("Accept", "text/plain") // the request's own header wins
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var body bytes.Buffer
+	body.ReadFrom(resp.Body)
+	if want := "/v1/users/7 Bearer t text/plain"; body.String() != want {
+		t.Errorf("body = %q; want %q", body.String(), want)
+	}
+}
+
+// flaky answers 503 until it has been called n times.
+func flaky(n int32, calls *atomic.Int32) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= n {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+}
+
+func TestClient_BearerToken(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.Header.Get("Authorization")))
+	}))
+	defer srv.Close()
+
+	token, calls := "first", 0
+	c, err := New(WithBaseURL(srv.URL), WithBearerToken(func(context.Context) (string, error) {
+		calls++
+		if token == "fail" {
+			return "", errors.New("not logged in")
+		}
+		return token, nil
+	}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	get := func(header string) (string, error) {
+		req, _ := c.NewRequest(context.Background(), http.MethodGet, "/", nil)
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+		resp, err := c.Do(req)
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		var b bytes.Buffer
+		b.ReadFrom(resp.Body)
+		return b.String(), nil
+	}
+
+	for _, tc := range []struct{ token, header, want string }{
+		{"first", "", "Bearer first"},
+		{"second", "", "Bearer second"}, // read again for each request
+		{"", "", ""},
+		{"ignored", "Basic abc", "Basic abc"}, // the request's own header wins
+	} {
+		token = tc.token
+		if got, err := get(tc.header); err != nil || got != tc.want {
+			t.Errorf("token %q: sent %q, %v; want %q", tc.token, got, err, tc.want)
+		}
+	}
+	if calls != 3 {
+		t.Errorf("token called %d times; want once per request without its own header", calls)
+	}
+	token = "fail"
+	if _, err := get(""); err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Errorf("err = %v; want the token error", err)
+	}
+}
+
+func TestClient_Retry(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(flaky(2, &calls))
+	defer srv.Close()
+
+	var logs bytes.Buffer
+	c, _ := New(WithBaseURL(srv.URL), WithRetry(fast), WithLogger(slog.New(slog.NewTextHandler(&logs, nil))))
+	resp, err := c.Get(context.Background(), "/")
+	if err != nil || resp.StatusCode != http.StatusOK || calls.Load() != 3 {
+		t.Fatalf("got %v, %v after %d calls; want 200 on the third", resp, err, calls.Load())
+	}
+	resp.Body.Close()
+	if n := strings.Count(logs.String(), "msg=retrying"); n != 2 {
+		t.Errorf("logged %d retries; want 2:\n%s", n, logs.String())
+	}
+
+}
+
+func TestClient_RetryResendsBody(t *testing.T) {
+	var got []string
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var b bytes.Buffer
+		b.ReadFrom(r.Body)
+		got = append(got, b.String())
+		if len(got) == 1 {
+			w.WriteHeader(http.StatusBadGateway)
+		}
+	}))
+	defer srv.Close()
+
+	c, _ := New(WithBaseURL(srv.URL), WithRetry(fast))
+	req, _ := c.NewRequest(context.Background(), http.MethodPut, "/", strings.NewReader("payload"))
+	resp, err := c.Do(req)
+	if err != nil || len(got) != 2 || got[1] != "payload" {
+		t.Fatalf("PUT: %v; bodies %q", err, got)
+	}
+	resp.Body.Close()
+}
+
+func TestClient_RetriesRunOut(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(flaky(100, &calls))
+	defer srv.Close()
+
+	c, _ := New(WithBaseURL(srv.URL), WithRetry(fast))
+	_, err := c.Get(context.Background(), "/")
+	var se *StatusError
+	if !errors.As(err, &se) || se.Code != http.StatusServiceUnavailable || calls.Load() != 3 {
+		t.Errorf("err = %v after %d calls; want a 503 StatusError after 3", err, calls.Load())
+	}
+}
+
+func TestClient_NoRetryForPOST(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(flaky(100, &calls))
+	defer srv.Close()
+
+	c, _ := New(WithBaseURL(srv.URL), WithRetry(fast))
+	req, _ := c.NewRequest(context.Background(), http.MethodPost, "/orders", strings.NewReader("{}"))
+	resp, err := c.Do(req)
+	if err != nil || resp.StatusCode != http.StatusServiceUnavailable || calls.Load() != 1 {
+		t.Fatalf("got %v, %v after %d calls; want the 503 as is after 1", resp, err, calls.Load())
+	}
+	resp.Body.Close()
+}
+
+func TestClient_TimeoutIsRetried(t *testing.T) {
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) == 1 {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		}
+	}))
+	defer srv.Close()
+
+	c, _ := New(WithBaseURL(srv.URL), WithTimeout(50*time.Millisecond), WithRetry(fast))
+	resp, err := c.Get(context.Background(), "/")
+	if err != nil || calls.Load() != 2 {
+		t.Fatalf("got %v after %d calls; want success on the second", err, calls.Load())
+	}
+	resp.Body.Close()
+
+	// A cancelled caller is not retried.
+	calls.Store(0)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if _, err := c.Get(ctx, "/"); !errors.Is(err, context.Canceled) || calls.Load() > 1 {
+		t.Errorf("cancelled: %v after %d calls", err, calls.Load())
+	}
+}
@@ -0,0 +1,88 @@
+// Demonstrates the functional options pattern for API design.
+//
+// This example shows:
+// - A constructor that takes ...Option: defaults, then options in order
+// - Options that validate their values, with every error reported at once
+// - The same Server built from a config struct and from a builder
+// - The pattern applied to an HTTP client: timeout, logger, retries
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"sync/atomic"
+	"time"
+
+	"golang_roadmap/02_core_language/22_functional_options/httpclient"
+	"golang_roadmap/12_operations/03_retry/retry"
+)
+
+func main() {
+	hello := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "hello\n") })
+
+	fmt.Println("=== Defaults, and options on top ===")
+	s, _ := NewServer(hello)
+	fmt.Println("NewServer(h):                       ", s)
+	s, _ = NewServer(hello, WithAddr("127.0.0.1:9000"), WithTimeout(5*time.Second))
+	fmt.Println("NewServer(h, WithAddr, WithTimeout):", s)
+	s, _ = NewServer(hello, WithTimeout(time.Second), WithTimeout(2*time.Second))
+	fmt.Println("later options win:                  ", s)
+
+	fmt.Println("\n=== Invalid options ===")
+	_, err := NewServer(hello, WithAddr("9000"), WithTimeout(-time.Second), WithMaxBodyBytes(0))
+	fmt.Println(err)
+
+	fmt.Println("\n=== The same server three ways ===")
+	a, _ := NewServer(hello, WithAddr(":9000"), WithMaxBodyBytes(4096))
+	b, _ := NewServerFromConfig(hello, ServerConfig{Addr: ":9000", MaxBodyBytes: 4096})
+	c, _ := NewServerBuilder(hello).Addr(":9000").MaxBodyBytes(4096).Build()
+	fmt.Println("options:", a)
+	fmt.Println("config: ", b)
+	fmt.Println("builder:", c)
+
+	fmt.Println("\n=== An HTTP client built with options ===")
+	var calls atomic.Int32
+	api := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if calls.Add(1) <= 2 {
+			http.Error(w, "try later", http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintf(w, "%s for %s\n", r.URL.Path, r.Header.Get("Authorization"))
+	}))
+	defer api.Close()
+
+	logger := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey || a.Key == "took" || a.Key == "delay" {
+				return slog.Attr{} // keep the output stable
+			}
+			return a
+		},
+	}))
+	client, err := httpclient.New(
+		httpclient.WithBaseURL(api.URL),
+		httpclient.WithHeader("Authorization", "Bearer demo"),
+		httpclient.WithTimeout(2*time.Second),
+		httpclient.WithLogger(logger),
+		httpclient.WithRetry(retry.Policy{MaxAttempts: 4, Initial: 10 * time.Millisecond}),
+	)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp, err := client.Get(context.Background(), "/users/7")
+	if err != nil {
+		log.Fatal(err)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	fmt.Printf("%d %s", resp.StatusCode, body)
+
+	_, err = httpclient.New(httpclient.WithBaseURL("api.example.com"), httpclient.WithTimeout(0))
+	fmt.Println("\nbad client options:", err)
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"time"
+)
+
+// Server is configured with functional options:
+//
+//	srv, err := NewServer(handler, WithAddr(":9000"), WithTimeout(5*time.Second))
+//
+// Each option is a function that sets one field. NewServer applies the
+// defaults first, then the options in order, so a later option wins, and
+// an option can refuse a bad value.
+type Server struct {
+	addr         string
+	timeout      time.Duration
+	logger       *slog.Logger
+	maxBodyBytes int64
+	handler      http.Handler
+}
+
+// ServerOption configures a Server.
+type ServerOption func(*Server) error
+
+// WithAddr sets the listen address (default ":8080").
+func WithAddr(addr string) ServerOption {
+	return func(s *Server) error {
+		if _, _, err := net.SplitHostPort(addr); err != nil {
+			return fmt.Errorf("WithAddr: %w", err)
+		}
+		s.addr = addr
+		return nil
+	}
+}
+
+// WithTimeout sets the read and write timeouts (default 15s).
+func WithTimeout(d time.Duration) ServerOption {
+	return func(s *Server) error {
+		if d <= 0 {
+			return fmt.Errorf("WithTimeout: must be positive, got %v", d)
+		}
+		s.timeout = d
+		return nil
+	}
+}
+
+// WithLogger sets the logger for server errors (default: discard).
+func WithLogger(l *slog.Logger) ServerOption {
+	return func(s *Server) error {
+		if l == nil {
+			return errors.New("WithLogger: nil logger")
+		}
+		s.logger = l
+		return nil
+	}
+}
+
+// WithMaxBodyBytes caps request bodies (default 1 MiB).
+func WithMaxBodyBytes(n int64) ServerOption {
+	return func(s *Server) error {
+		if n <= 0 {
+			return fmt.Errorf("WithMaxBodyBytes: must be positive, got %d", n)
+		}
+		s.maxBodyBytes = n
+		return nil
+	}
+}
+
+// NewServer returns a server for h. It reports every invalid option, not
+// just the first.
+func NewServer(h http.Handler, opts ...ServerOption) (*Server, error) {
+	s := &Server{
+		addr:         ":8080",
+		timeout:      15 * time.Second,
+		logger:       slog.New(slog.DiscardHandler),
+		maxBodyBytes: 1 << 20,
+		handler:      h,
+	}
+	var errs []error
+	for _, opt := range opts {
+		if err := opt(s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+// HTTPServer builds the *http.Server to run.
+func (s *Server) HTTPServer() *http.Server {
+	return &http.Server{
+		Addr:         s.addr,
+		Handler:      http.MaxBytesHandler(s.handler, s.maxBodyBytes),
+		ReadTimeout:  s.timeout,
+		WriteTimeout: s.timeout,
+		ErrorLog:     slog.NewLogLogger(s.logger.Handler(), slog.LevelError),
+	}
+}
+
+func (s *Server) String() string {
+	return fmt.Sprintf("addr=%s timeout=%v max_body=%d", s.addr, s.timeout, s.maxBodyBytes)
+}
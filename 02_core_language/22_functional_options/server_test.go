@@ -0,0 +1,69 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+var nop = http.HandlerFunc(func(http.ResponseWriter, *http.Request) {})
+
+func TestNewServer_DefaultsAndOverrides(t *testing.T) {
+	s, err := NewServer(nop)
+	if err != nil || s.addr != ":8080" || s.timeout != 15*time.Second || s.maxBodyBytes != 1<<20 || s.logger == nil {
+		t.Fatalf("defaults: %v, %v", s, err)
+	}
+	s, err = NewServer(nop, WithAddr("localhost:9000"), WithTimeout(time.Second), WithTimeout(2*time.Second))
+	if err != nil || s.addr != "localhost:9000" || s.timeout != 2*time.Second {
+		t.Errorf("overrides: %v, %v; want the last WithTimeout to win", s, err)
+	}
+}
+
+func TestNewServer_InvalidOptions(t *testing.T) {
+	_, err := NewServer(nop, WithAddr("9000"), WithTimeout(0), WithLogger(nil), WithMaxBodyBytes(-1))
+	if err == nil {
+		t.Fatal("want an error")
+	}
+	for _, want := range []string{"WithAddr", "WithTimeout", "WithLogger", "WithMaxBodyBytes"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("err = %v; want it to mention %s", err, want)
+		}
+	}
+}
+
+func TestAlternatives_BuildTheSameServer(t *testing.T) {
+	want, _ := NewServer(nop, WithAddr(":9000"), WithTimeout(time.Second))
+	fromConfig, err := NewServerFromConfig(nop, ServerConfig{Addr: ":9000", Timeout: time.Second})
+	if err != nil || fromConfig.String() != want.String() {
+		t.Errorf("config struct: %v, %v; want %v", fromConfig, err, want)
+	}
+	built, err := NewServerBuilder(nop).Addr(":9000").Timeout(time.Second).Build()
+	if err != nil || built.String() != want.String() {
+		t.Errorf("builder: %v, %v; want %v", built, err, want)
+	}
+	if _, err := NewServerBuilder(nop).Timeout(-1).Build(); err == nil {
+		t.Error("builder: a bad value should fail Build")
+	}
+}
+
+func TestServer_MaxBodyBytes(t *testing.T) {
+	read := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var tooBig *http.MaxBytesError
+		if _, err := io.ReadAll(r.Body); errors.As(err, &tooBig) {
+			http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+		}
+	})
+	s, _ := NewServer(read, WithMaxBodyBytes(10))
+	h := s.HTTPServer().Handler
+	for body, want := range map[string]int{"short": 200, strings.Repeat("x", 11): 413} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("POST", "/", strings.NewReader(body)))
+		if rec.Code != want {
+			t.Errorf("%d-byte body: %d; want %d", len(body), rec.Code, want)
+		}
+	}
+}
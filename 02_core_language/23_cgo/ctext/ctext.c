@@ -0,0 +1,53 @@
+//go:build cgo
+
+#include <stdlib.h>
+#include <string.h>
+
+#include "ctext.h"
+#include "_cgo_export.h" /* goWord, exported from ctext_cgo.go */
+
+uint32_t ctext_fnv1a(const char *buf, size_t len) {
+	uint32_t h = 2166136261u;
+	for (size_t i = 0; i < len; i++) {
+		h ^= (unsigned char)buf[i];
+		h *= 16777619u;
+	}
+	return h;
+}
+
+char *ctext_upper(const char *s) {
+	size_t n = strlen(s);
+	char *out = malloc(n + 1);
+	if (out == NULL) {
+		return NULL;
+	}
+	for (size_t i = 0; i <= n; i++) {
+		char c = s[i];
+		out[i] = (c >= 'a' && c <= 'z') ? c - 'a' + 'A' : c;
+	}
+	return out;
+}
+
+static int is_space(char c) {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\v' || c == '\f' || c == '\r';
+}
+
+int ctext_words(const char *s, uintptr_t handle) {
+	int count = 0;
+	const char *p = s;
+	while (*p) {
+		while (*p && is_space(*p)) {
+			p++;
+		}
+		const char *start = p;
+		while (*p && !is_space(*p)) {
+			p++;
+		}
+		if (p > start) {
+			/* The word points into s: Go copies it before returning. */
+			goWord(handle, (char *)start, (int)(p - start));
+			count++;
+		}
+	}
+	return count;
+}
@@ -0,0 +1,29 @@
+// Package ctext has three small text functions implemented in C and
+// called through cgo, one of which calls back into Go. Built without cgo
+// (CGO_ENABLED=0, or cross-compiling without a C toolchain) the same API
+// is implemented in pure Go, selected by the cgo build tag:
+//
+//	ctext.go        the API and its documentation; no tag
+//	ctext_cgo.go    //go:build cgo     calls the C in ctext.c
+//	ctext.c         //go:build cgo     the C code
+//	ctext_nocgo.go  //go:build !cgo    the pure Go fallback
+//
+// Both must behave the same; the tests run against whichever is built.
+package ctext
+
+// Impl says which implementation this binary uses: "cgo" or "go".
+const Impl = impl
+
+// Hash returns the 32-bit FNV-1a hash of b.
+func Hash(b []byte) uint32 { return hash(b) }
+
+// Upper returns s with the ASCII letters upper-cased. Other bytes,
+// including non-ASCII UTF-8, are left as they are.
+//
+// s must not contain a NUL byte: C strings end at the first one.
+func Upper(s string) string { return upper(s) }
+
+// Words calls fn with each word in s, in order, and returns how many there
+// were. Words are separated by ASCII whitespace. As with Upper, s must
+// not contain a NUL byte.
+func Words(s string, fn func(word string)) int { return words(s, fn) }
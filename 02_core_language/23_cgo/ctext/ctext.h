@@ -0,0 +1,18 @@
+#ifndef CTEXT_H
+#define CTEXT_H
+
+#include <stddef.h>
+#include <stdint.h>
+
+/* FNV-1a hash of len bytes at buf. Reads buf only during the call. */
+uint32_t ctext_fnv1a(const char *buf, size_t len);
+
+/* Returns an ASCII-uppercased copy of s, allocated with malloc.
+   The caller owns it and must free it. NULL if out of memory. */
+char *ctext_upper(const char *s);
+
+/* Calls back into Go with each whitespace-separated word of s.
+   handle is passed through untouched. Returns the number of words. */
+int ctext_words(const char *s, uintptr_t handle);
+
+#endif
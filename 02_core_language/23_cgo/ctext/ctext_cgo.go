@@ -0,0 +1,61 @@
+//go:build cgo
+
+package ctext
+
+/*
+#include <stdlib.h>
+#include "ctext.h"
+*/
+import "C"
+
+import (
+	"runtime/cgo"
+	"unsafe"
+)
+
+const impl = "cgo"
+
+// hash passes Go memory to C without copying. cgo allows that for the
+// length of the call, as long as the memory holds no Go pointers, and C
+// must not keep the pointer after it returns.
+func hash(b []byte) uint32 {
+	return uint32(C.ctext_fnv1a((*C.char)(unsafe.Pointer(unsafe.SliceData(b))), C.size_t(len(b))))
+}
+
+// upper crosses the boundary twice, with an owner each way:
+//   - C.CString copies s into C memory (malloc). Go allocated it through
+//     cgo, so Go frees it.
+//   - ctext_upper returns memory C allocated and hands over to the
+//     caller. C.GoString copies it into a Go string, and Go frees the C copy.
+//
+// The garbage collector sees neither; a missing C.free is a leak.
+func upper(s string) string {
+	cs := C.CString(s)
+	defer C.free(unsafe.Pointer(cs))
+	out := C.ctext_upper(cs)
+	if out == nil {
+		panic("ctext: out of memory")
+	}
+	defer C.free(unsafe.Pointer(out))
+	return C.GoString(out)
+}
+
+// words hands C a cgo.Handle, not fn: C may not hold Go pointers, and a
+// func value is one. The handle is an integer that goWord turns back into
+// fn.
+func words(s string, fn func(string)) int {
+	cs := C.CString(s)
+	defer C.free(unsafe.Pointer(cs))
+	h := cgo.NewHandle(fn)
+	defer h.Delete()
+	return int(C.ctext_words(cs, C.uintptr_t(h)))
+}
+
+// goWord is called from C, once per word. word points into C memory that
+// is freed after words returns, so it is copied with C.GoStringN.
+//
+//export goWord
+func goWord(handle C.uintptr_t, word *C.char, n C.int) {
+	fn := cgo.Handle(handle).Value().(func(string))
+	fn(C.GoStringN(word, n))
+}
@@ -0,0 +1,86 @@
+package ctext
+
+import (
+	"fmt"
+	"hash/fnv"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// The same tests run against both implementations:
+//
+//	go test ./...
+//	CGO_ENABLED=0 go test ./...
+
+func TestHash(t *testing.T) {
+	t.Logf("implementation: %s", Impl)
+	for _, s := range []string{"", "a", "gopher", strings.Repeat("\xff\x00", 1000)} {
+		want := fnv.New32a()
+		want.Write([]byte(s))
+		if got := Hash([]byte(s)); got != want.Sum32() {
+			t.Errorf("Hash(%.10q) = %#x; want %#x", s, got, want.Sum32())
+		}
+	}
+	if got := Hash(nil); got != 2166136261 {
+		t.Errorf("Hash(nil) = %#x; want the FNV offset basis", got)
+	}
+}
+
+func TestUpper(t *testing.T) {
+	tests := map[string]string{
+		"":              "",
+		"hello, world":  "HELLO, WORLD",
+		"Go 1.24":       "GO 1.24",
+		"grüße":         "GRüßE", // ASCII only
+		"already UPPER": "ALREADY UPPER",
+	}
+	for in, want := range tests {
+		if got := Upper(in); got != want {
+			t.Errorf("Upper(%q) = %q; want %q", in, got, want)
+		}
+	}
+}
+
+func TestWords(t *testing.T) {
+	tests := map[string][]string{
+		"":                         nil,
+		" \t\n ":                   nil,
+		"one":                      {"one"},
+		"  the quick\tbrown\nfox ": {"the", "quick", "brown", "fox"},
+		"a b":                      {"a b"}, // no-break space is not ASCII whitespace
+	}
+	for in, want := range tests {
+		var got []string
+		n := Words(in, func(w string) { got = append(got, w) })
+		if !reflect.DeepEqual(got, want) || n != len(want) {
+			t.Errorf("Words(%q) = %q, %d; want %q, %d", in, got, n, want, len(want))
+		}
+	}
+}
+
+// The callback may run Go code of any kind, including calling back into C.
+func TestWords_NestedCall(t *testing.T) {
+	var got []string
+	Words("ab cd", func(w string) { got = append(got, Upper(w)) })
+	if want := []string{"AB", "CD"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q; want %q", got, want)
+	}
+}
+
+// A cgo call costs tens of nanoseconds more than a Go call, which
+// dominates for small inputs. Compare:
+//
+//	go test -bench . ./ctext
+//	CGO_ENABLED=0 go test -bench . ./ctext
+func BenchmarkHash(b *testing.B) {
+	for _, size := range []int{8, 1 << 10, 64 << 10} {
+		buf := make([]byte, size)
+		b.Run(fmt.Sprintf("%s/%dB", Impl, size), func(b *testing.B) {
+			b.SetBytes(int64(size))
+			for i := 0; i < b.N; i++ {
+				Hash(buf)
+			}
+		})
+	}
+}
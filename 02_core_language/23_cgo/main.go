@@ -0,0 +1,35 @@
+// Demonstrates cgo: calling C from Go and Go from C.
+//
+// This example shows:
+// - Calling C functions declared in a header and defined in a .c file
+// - Who owns memory on each side: C.CString, C.GoString and C.free
+// - Passing Go memory to C for the length of a call
+// - Exporting a Go function to C with //export, reached through a cgo.Handle
+// - A pure Go fallback selected by the cgo build tag
+//
+// Run it both ways:
+//
+//	go run .
+//	CGO_ENABLED=0 go run .
+package main
+
+import (
+	"fmt"
+
+	"golang_roadmap/02_core_language/23_cgo/ctext"
+)
+
+func main() {
+	fmt.Println("implementation:", ctext.Impl)
+
+	text := "the quick brown fox jumps over the lazy dog"
+	fmt.Printf("Hash(%q) = %#08x\n", text, ctext.Hash([]byte(text)))
+	fmt.Printf("Upper(%q) = %q\n", text, ctext.Upper(text))
+
+	// With cgo, C walks the string and calls this Go function per word.
+	lengths := map[int]int{}
+	n := ctext.Words(text, func(word string) {
+		lengths[len(word)]++
+	})
+	fmt.Printf("Words: %d words, by length %v\n", n, lengths)
+}
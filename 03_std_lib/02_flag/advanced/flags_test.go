@@ -0,0 +1,116 @@
+package main
+
+import (
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+// newFlagSet declares the flags the tests use on a quiet FlagSet.
+func newFlagSet() (*flag.FlagSet, HeaderFlag, *EnumFlag) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	headers := HeaderFlag{}
+	fs.Var(headers, "H", "")
+	format := NewEnum("text", "text", "json")
+	fs.Var(format, "format", "")
+	fs.Bool("quiet", false, "")
+	fs.Bool("verbose", false, "")
+	fs.String("user", "", "")
+	fs.String("password", "", "")
+	fs.Int("max-retries", 3, "")
+	return fs, headers, format
+}
+
+func TestHeaderFlag(t *testing.T) {
+	fs, headers, _ := newFlagSet()
+	err := fs.Parse([]string{"-H", "accept: a", "-H", "Accept:b", "-H", "X-Empty:"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := headers.String(), "Accept: a, Accept: b, X-Empty: "; got != want {
+		t.Errorf("headers = %q; want %q", got, want)
+	}
+
+	for _, bad := range []string{"no colon", ": no name", "Bad Name: x", "a/b: x"} {
+		fs, _, _ := newFlagSet()
+		if err := fs.Parse([]string{"-H", bad}); err == nil {
+			t.Errorf("-H %q accepted", bad)
+		}
+	}
+}
+
+func TestEnumFlag(t *testing.T) {
+	fs, _, format := newFlagSet()
+	if err := fs.Parse([]string{"-format", "json"}); err != nil || format.Value != "json" {
+		t.Errorf("-format json: %q, %v", format.Value, err)
+	}
+	fs, _, format = newFlagSet()
+	err := fs.Parse([]string{"-format", "JSON"})
+	if err == nil || !strings.Contains(err.Error(), "must be one of text, json") || format.Value != "text" {
+		t.Errorf("-format JSON: %q, %v; want an error and the default kept", format.Value, err)
+	}
+}
+
+func TestGroups(t *testing.T) {
+	for _, tt := range []struct {
+		args    []string
+		wantErr string
+	}{
+		{nil, ""},
+		{[]string{"-quiet"}, ""},
+		{[]string{"-quiet=false", "-verbose"}, "-quiet and -verbose cannot be used together"}, // set, even if false
+		{[]string{"-user", "ada", "-password", "pw"}, ""},
+		{[]string{"-password", "pw"}, "-password also needs -user"},
+	} {
+		fs, _, _ := newFlagSet()
+		if err := fs.Parse(tt.args); err != nil {
+			t.Fatal(err)
+		}
+		err := MutuallyExclusive(fs, "quiet", "verbose")
+		if err == nil {
+			err = RequiredTogether(fs, "user", "password")
+		}
+		if (err == nil) != (tt.wantErr == "") || err != nil && err.Error() != tt.wantErr {
+			t.Errorf("%v: err = %v; want %q", tt.args, err, tt.wantErr)
+		}
+	}
+}
+
+func TestSetFromEnv(t *testing.T) {
+	env := map[string]string{
+		"APP_FORMAT":      "json",
+		"APP_USER":        "from-env",
+		"APP_MAX_RETRIES": "5",
+		"APP_H":           "X-From: env",
+	}
+	lookup := func(k string) (string, bool) { v, ok := env[k]; return v, ok }
+
+	fs, headers, format := newFlagSet()
+	if err := fs.Parse([]string{"-user", "from-flag"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := SetFromEnv(fs, "APP_", lookup); err != nil {
+		t.Fatal(err)
+	}
+	if got := fs.Lookup("user").Value.String(); got != "from-flag" {
+		t.Errorf("user = %q; want the command line to win", got)
+	}
+	if format.Value != "json" || fs.Lookup("max-retries").Value.String() != "5" || headers.String() != "X-From: env" {
+		t.Errorf("format %q, max-retries %s, headers %q; want the environment's", format.Value, fs.Lookup("max-retries").Value, headers)
+	}
+	if fs.Lookup("password").Value.String() != "" {
+		t.Error("password set without an environment variable")
+	}
+	if err := RequiredTogether(fs, "format", "max-retries"); err != nil {
+		t.Errorf("flags from the environment do not count as set: %v", err)
+	}
+
+	env["APP_FORMAT"] = "xml"
+	fs, _, _ = newFlagSet()
+	fs.Parse(nil)
+	if err := SetFromEnv(fs, "APP_", lookup); err == nil || !strings.Contains(err.Error(), "$APP_FORMAT") {
+		t.Errorf("bad environment value: err = %v; want it to name the variable", err)
+	}
+}
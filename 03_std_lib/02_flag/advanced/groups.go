@@ -0,0 +1,78 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"strings"
+)
+
+// setFlags returns the names of the flags in fs that were set, on the
+// command line or with fs.Set. flag.Visit visits only those, which is
+// the one way to tell "-n=1" from the default 1.
+func setFlags(fs *flag.FlagSet) map[string]bool {
+	set := map[string]bool{}
+	fs.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	return set
+}
+
+// MutuallyExclusive reports an error if more than one of names was set.
+// Call it after Parse and SetFromEnv.
+func MutuallyExclusive(fs *flag.FlagSet, names ...string) error {
+	set := setFlags(fs)
+	var used []string
+	for _, name := range names {
+		if set[name] {
+			used = append(used, "-"+name)
+		}
+	}
+	if len(used) > 1 {
+		return fmt.Errorf("%s cannot be used together", strings.Join(used, " and "))
+	}
+	return nil
+}
+
+// RequiredTogether reports an error if some of names were set but not
+// all of them.
+func RequiredTogether(fs *flag.FlagSet, names ...string) error {
+	set := setFlags(fs)
+	var used, missing []string
+	for _, name := range names {
+		if set[name] {
+			used = append(used, "-"+name)
+		} else {
+			missing = append(missing, "-"+name)
+		}
+	}
+	if len(used) > 0 && len(missing) > 0 {
+		return fmt.Errorf("%s also needs %s", strings.Join(used, " and "), strings.Join(missing, " and "))
+	}
+	return nil
+}
+
+// EnvName is the environment variable for a flag: "max-retries" with
+// prefix "FETCH_" is FETCH_MAX_RETRIES.
+func EnvName(prefix, flagName string) string {
+	return prefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// SetFromEnv sets each flag in fs that is not on the command line from
+// its environment variable, if that exists, so the precedence is
+// command line, then environment, then default. Call it after Parse;
+// lookup is os.LookupEnv outside of tests. A flag set this way counts as
+// set for MutuallyExclusive and RequiredTogether.
+func SetFromEnv(fs *flag.FlagSet, prefix string, lookup func(string) (string, bool)) error {
+	onCommandLine := setFlags(fs)
+	var err error
+	fs.VisitAll(func(f *flag.Flag) {
+		if err != nil || onCommandLine[f.Name] {
+			return
+		}
+		env := EnvName(prefix, f.Name)
+		if v, ok := lookup(env); ok {
+			if serr := fs.Set(f.Name, v); serr != nil {
+				err = fmt.Errorf("invalid value %q for $%s: %w", v, env, serr)
+			}
+		}
+	})
+	return err
+}
@@ -0,0 +1,64 @@
+// Demonstrates custom flag.Value types and checks that flag lacks.
+//
+// This example shows:
+// - A repeatable -H flag that collects HTTP headers
+// - An enum flag that rejects values outside a fixed set during Parse
+// - Mutually exclusive flags, and flags that must be used together
+// - Environment variables as a fallback for flags not on the command line
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+)
+
+// envPrefix is prepended to flag names to find their environment
+// variables: -format is FETCH_FORMAT.
+const envPrefix = "FETCH_"
+
+func main() {
+	fs := flag.NewFlagSet("fetch", flag.ExitOnError)
+	headers := HeaderFlag{}
+	fs.Var(headers, "H", `request header "Name: value" (repeatable)`)
+	format := NewEnum("text", "text", "json", "yaml")
+	fs.Var(format, "format", "output format: "+format.Choices())
+	quiet := fs.Bool("quiet", false, "print nothing but errors")
+	verbose := fs.Bool("verbose", false, "print the request too")
+	user := fs.String("user", "", "basic auth user")
+	password := fs.String("password", "", "basic auth password")
+	timeout := fs.Duration("timeout", 10*time.Second, "request timeout")
+	fs.Usage = func() {
+		out := fs.Output()
+		fmt.Fprintln(out, "Usage: fetch [flags] URL")
+		fs.PrintDefaults()
+		fmt.Fprintf(out, "\nEvery flag can also come from the environment, e.g. %s or %s.\n",
+			EnvName(envPrefix, "format"), EnvName(envPrefix, "password"))
+	}
+	fs.Parse(os.Args[1:])
+
+	// Order matters: fill in from the environment first, so that the
+	// group checks see every value, wherever it came from.
+	err := errors.Join(
+		SetFromEnv(fs, envPrefix, os.LookupEnv),
+		MutuallyExclusive(fs, "quiet", "verbose"),
+		RequiredTogether(fs, "user", "password"),
+	)
+	if fs.NArg() != 1 {
+		err = errors.Join(err, errors.New("want exactly one URL"))
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	fmt.Println("url:     ", fs.Arg(0))
+	fmt.Println("headers: ", headers)
+	fmt.Println("format:  ", format)
+	fmt.Println("auth:    ", *user != "" && *password != "", "(user "+*user+")")
+	fmt.Println("timeout: ", *timeout)
+	fmt.Println("quiet:   ", *quiet, "verbose:", *verbose)
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+)
+
+// HeaderFlag collects repeated "-H 'Name: value'" flags, as curl does.
+// Each use adds one header; names are canonicalized, so "-H accept:x"
+// and "-H Accept:y" both go to Accept.
+type HeaderFlag http.Header
+
+func (h HeaderFlag) String() string {
+	var lines []string
+	for _, name := range slices.Sorted(maps.Keys(h)) {
+		for _, v := range h[name] {
+			lines = append(lines, name+": "+v)
+		}
+	}
+	return strings.Join(lines, ", ")
+}
+
+func (h HeaderFlag) Set(s string) error {
+	name, value, ok := strings.Cut(s, ":")
+	if !ok {
+		return errors.New(`want "Name: value"`)
+	}
+	name = strings.TrimSpace(name)
+	if name == "" || strings.ContainsFunc(name, func(r rune) bool {
+		return r <= ' ' || r >= 0x7f || strings.ContainsRune(`"(),/:;<=>?@[\]{}`, r)
+	}) {
+		return fmt.Errorf("invalid header name %q", name)
+	}
+	http.Header(h).Add(name, strings.TrimSpace(value))
+	return nil
+}
+
+// EnumFlag accepts one of a fixed set of values. Unlike a string flag
+// checked after Parse, a bad value fails during Parse, with the flag's
+// name in the message.
+type EnumFlag struct {
+	Value   string
+	Allowed []string
+}
+
+// NewEnum returns an enum flag set to value, which should be one of
+// allowed.
+func NewEnum(value string, allowed ...string) *EnumFlag {
+	return &EnumFlag{Value: value, Allowed: allowed}
+}
+
+func (e *EnumFlag) String() string {
+	if e == nil {
+		return "" // flag.PrintDefaults calls String on a zero value
+	}
+	return e.Value
+}
+
+func (e *EnumFlag) Set(s string) error {
+	if !slices.Contains(e.Allowed, s) {
+		return fmt.Errorf("must be one of %s", strings.Join(e.Allowed, ", "))
+	}
+	e.Value = s
+	return nil
+}
+
+// Choices is the allowed values for a usage string: "text|json|yaml".
+func (e *EnumFlag) Choices() string { return strings.Join(e.Allowed, "|") }
@@ -0,0 +1,254 @@
+// Package command routes a command line to nested subcommands, each with
+// its own flag.FlagSet. It is a small version of what spf13/cobra does:
+//
+//	root := &command.Command{Name: "app", Commands: []*command.Command{remote}}
+//	remote := &command.Command{Name: "remote", Commands: []*command.Command{add, list}}
+//	err := root.Execute(ctx, os.Args[1:]) // app -v remote add -f origin URL
+//
+// Flags in a command's Persistent set are shared with every command below
+// it and may be given before or after the subcommand's name. Help is
+// generated from the tree: "-h" at any level, or "app help remote add".
+package command
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+)
+
+// Command is a node in the command tree.
+type Command struct {
+	Name  string
+	Short string // one line, shown in the parent's list of commands
+	Long  string // shown at the top of the command's own help
+	Args  string // synopsis of the positional arguments, as in "NAME URL"
+
+	// Flags are the command's own flags. Persistent flags are shared with
+	// its subcommands. Either may be nil.
+	Flags      *flag.FlagSet
+	Persistent *flag.FlagSet
+
+	// Run runs the command with its positional arguments. A command with
+	// subcommands may leave it nil, and then requires one of them.
+	Run      func(ctx context.Context, args []string) error
+	Commands []*Command
+
+	// Output is where help goes, by default os.Stderr. It is set on the
+	// root and used by the whole tree.
+	Output io.Writer
+
+	parent    *Command
+	inherited map[string]bool // flags in Flags that come from Persistent sets
+}
+
+// UsageError is returned for a command line that does not fit the tree: a
+// bad flag, an unknown command or a missing one.
+type UsageError struct {
+	Cmd *Command
+	Err error
+}
+
+func (e *UsageError) Error() string { return e.Cmd.Path() + ": " + e.Err.Error() }
+func (e *UsageError) Unwrap() error { return e.Err }
+
+// Path is the command's full name, as in "app remote add".
+func (c *Command) Path() string {
+	if c.parent == nil {
+		return c.Name
+	}
+	return c.parent.Path() + " " + c.Name
+}
+
+// Execute parses args, without the program name, and runs the command
+// they select. For -h or -help it prints that command's help and returns
+// flag.ErrHelp. A command line that does not fit the tree gives a
+// *UsageError; anything else is the error from Run.
+func (c *Command) Execute(ctx context.Context, args []string) error {
+	c.setup(nil)
+	return c.execute(ctx, args)
+}
+
+// setup links the tree and merges persistent flags down into each
+// command's FlagSet, sharing their flag.Values, so a global flag set
+// after the subcommand's name lands in the same variable.
+func (c *Command) setup(parent *Command) {
+	c.parent = parent
+	if c.Flags == nil {
+		c.Flags = flag.NewFlagSet(c.Name, flag.ContinueOnError)
+	}
+	// Errors and help are reported by execute, not by the FlagSet.
+	c.Flags.SetOutput(io.Discard)
+	c.Flags.Usage = func() {}
+	c.inherited = map[string]bool{}
+	for p := c; p != nil; p = p.parent {
+		if p.Persistent == nil {
+			continue
+		}
+		p.Persistent.VisitAll(func(f *flag.Flag) {
+			if c.Flags.Lookup(f.Name) == nil {
+				c.Flags.Var(f.Value, f.Name, f.Usage)
+				// Var records the current value as the default; keep the
+				// real one for help.
+				c.Flags.Lookup(f.Name).DefValue = f.DefValue
+				c.inherited[f.Name] = p != c
+			}
+		})
+	}
+	for _, sub := range c.Commands {
+		sub.setup(c)
+	}
+}
+
+func (c *Command) execute(ctx context.Context, args []string) error {
+	if err := c.Flags.Parse(args); err != nil {
+		if errors.Is(err, flag.ErrHelp) {
+			c.PrintHelp()
+			return flag.ErrHelp
+		}
+		return &UsageError{c, err}
+	}
+	args = c.Flags.Args()
+	if len(c.Commands) > 0 && len(args) > 0 {
+		if args[0] == "help" {
+			return c.help(args[1:])
+		}
+		if sub := c.lookup(args[0]); sub != nil {
+			return sub.execute(ctx, args[1:])
+		}
+		if c.Run == nil {
+			return &UsageError{c, fmt.Errorf("unknown command %q", args[0])}
+		}
+	}
+	if c.Run == nil {
+		return &UsageError{c, errors.New("missing command")}
+	}
+	return c.Run(ctx, args)
+}
+
+func (c *Command) lookup(name string) *Command {
+	for _, sub := range c.Commands {
+		if sub.Name == name {
+			return sub
+		}
+	}
+	return nil
+}
+
+// help handles "help a b": it prints the help of the command at that
+// path below c.
+func (c *Command) help(path []string) error {
+	target := c
+	for _, name := range path {
+		sub := target.lookup(name)
+		if sub == nil {
+			return &UsageError{target, fmt.Errorf("unknown command %q", name)}
+		}
+		target = sub
+	}
+	target.PrintHelp()
+	return nil
+}
+
+func (c *Command) output() io.Writer {
+	for p := c; p != nil; p = p.parent {
+		if p.Output != nil {
+			return p.Output
+		}
+	}
+	return os.Stderr
+}
+
+// PrintHelp writes the command's help: description, usage, subcommands
+// and flags, its own apart from the global ones.
+func (c *Command) PrintHelp() {
+	w := c.output()
+	if text := cmp.Or(c.Long, c.Short); text != "" {
+		fmt.Fprintf(w, "%s\n\n", text)
+	}
+
+	usage := c.Path()
+	if hasFlags(c.Flags) {
+		usage += " [flags]"
+	}
+	if len(c.Commands) > 0 {
+		usage += " <command>"
+	}
+	if c.Args != "" {
+		usage += " " + c.Args
+	}
+	fmt.Fprintf(w, "Usage:\n  %s\n", usage)
+
+	if len(c.Commands) > 0 {
+		fmt.Fprintf(w, "\nCommands:\n")
+		tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+		for _, sub := range c.Commands {
+			fmt.Fprintf(tw, "  %s\t%s\n", sub.Name, sub.Short)
+		}
+		tw.Flush()
+	}
+
+	var local, global []*flag.Flag
+	c.Flags.VisitAll(func(f *flag.Flag) {
+		if c.inherited[f.Name] {
+			global = append(global, f)
+		} else {
+			local = append(local, f)
+		}
+	})
+	printFlags(w, "Flags", local)
+	printFlags(w, "Global flags", global)
+
+	if len(c.Commands) > 0 {
+		fmt.Fprintf(w, "\nRun '%s <command> -h' for more about a command.\n", c.Path())
+	}
+}
+
+func hasFlags(fs *flag.FlagSet) bool {
+	n := 0
+	fs.VisitAll(func(*flag.Flag) { n++ })
+	return n > 0
+}
+
+// printFlags prints flags the way flag.PrintDefaults does. That cannot
+// be used here: it prints all of a FlagSet, and the defaults it shows
+// for inherited flags would be their current values.
+func printFlags(w io.Writer, title string, flags []*flag.Flag) {
+	if len(flags) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "\n%s:\n", title)
+	for _, f := range flags {
+		var b strings.Builder
+		fmt.Fprintf(&b, "  -%s", f.Name)
+		name, usage := flag.UnquoteUsage(f)
+		if name != "" {
+			b.WriteString(" " + name)
+		}
+		b.WriteString("\n    \t" + strings.ReplaceAll(usage, "\n", "\n    \t"))
+		switch {
+		case f.DefValue == "" || f.DefValue == "0" || f.DefValue == "false":
+		case isString(f):
+			fmt.Fprintf(&b, " (default %q)", f.DefValue)
+		default:
+			fmt.Fprintf(&b, " (default %s)", f.DefValue)
+		}
+		fmt.Fprintln(w, b.String())
+	}
+}
+
+// isString reports whether f holds a string, whose default is quoted.
+// The flag package's own values implement flag.Getter.
+func isString(f *flag.Flag) bool {
+	g, ok := f.Value.(flag.Getter)
+	if !ok {
+		return false
+	}
+	_, ok = g.Get().(string)
+	return ok
+}
@@ -0,0 +1,141 @@
+package command
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"flag"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// tree builds "app [-v] [-name NAME]" with "remote add [-f] NAME URL" and
+// "remote list", recording which command ran with what.
+func tree() (root *Command, ran *[]string, verbose *bool, name *string, fetch *bool, help *bytes.Buffer) {
+	ran = new([]string)
+	record := func(path string) func(context.Context, []string) error {
+		return func(_ context.Context, args []string) error {
+			*ran = append([]string{path}, args...)
+			return nil
+		}
+	}
+
+	global := flag.NewFlagSet("app", flag.ContinueOnError)
+	verbose = global.Bool("v", false, "verbose output")
+	name = global.String("name", "world", "a `NAME` to use")
+	addFlags := flag.NewFlagSet("add", flag.ContinueOnError)
+	fetch = addFlags.Bool("f", false, "fetch after adding")
+
+	help = new(bytes.Buffer)
+	root = &Command{
+		Name: "app", Short: "The app", Persistent: global, Output: help,
+		Commands: []*Command{
+			{Name: "version", Short: "Print the version", Run: record("version")},
+			{
+				Name: "remote", Short: "Manage remotes",
+				Commands: []*Command{
+					{Name: "add", Short: "Add a remote", Args: "NAME URL", Flags: addFlags, Run: record("remote add")},
+					{Name: "list", Short: "List remotes", Run: record("remote list")},
+				},
+			},
+		},
+	}
+	return root, ran, verbose, name, fetch, help
+}
+
+func TestExecute_Routes(t *testing.T) {
+	for _, tt := range []struct {
+		args    []string
+		want    []string
+		verbose bool
+		name    string
+		fetch   bool
+	}{
+		{[]string{"version"}, []string{"version"}, false, "world", false},
+		{[]string{"remote", "list"}, []string{"remote list"}, false, "world", false},
+		{[]string{"-v", "remote", "add", "-f", "origin", "u"}, []string{"remote add", "origin", "u"}, true, "world", true},
+		// Global flags after the subcommand's name, and flag parsing
+		// stopping at the first argument.
+		{[]string{"remote", "add", "-name", "x", "-v", "o", "-f"}, []string{"remote add", "o", "-f"}, true, "x", false},
+	} {
+		root, ran, verbose, name, fetch, _ := tree()
+		if err := root.Execute(context.Background(), tt.args); err != nil {
+			t.Errorf("%v: %v", tt.args, err)
+			continue
+		}
+		if !slices.Equal(*ran, tt.want) || *verbose != tt.verbose || *name != tt.name || *fetch != tt.fetch {
+			t.Errorf("%v: ran %v, -v=%v -name=%s -f=%v; want %v, %v %s %v",
+				tt.args, *ran, *verbose, *name, *fetch, tt.want, tt.verbose, tt.name, tt.fetch)
+		}
+	}
+}
+
+func TestExecute_UsageErrors(t *testing.T) {
+	for _, tt := range []struct {
+		args    []string
+		path    string
+		message string
+	}{
+		{nil, "app", "missing command"},
+		{[]string{"remote"}, "app remote", "missing command"},
+		{[]string{"remot"}, "app", `unknown command "remot"`},
+		{[]string{"remote", "add", "-x"}, "app remote add", "flag provided but not defined: -x"},
+		{[]string{"version", "-f"}, "app version", "flag provided but not defined: -f"}, // -f belongs to add only
+		{[]string{"help", "remote", "rm"}, "app remote", `unknown command "rm"`},
+	} {
+		root, ran, _, _, _, _ := tree()
+		err := root.Execute(context.Background(), tt.args)
+		var ue *UsageError
+		if !errors.As(err, &ue) || ue.Cmd.Path() != tt.path || ue.Err.Error() != tt.message {
+			t.Errorf("%v: err = %v; want a usage error from %q: %s", tt.args, err, tt.path, tt.message)
+		}
+		if len(*ran) > 0 {
+			t.Errorf("%v: ran %v", tt.args, *ran)
+		}
+	}
+}
+
+func TestExecute_RunError(t *testing.T) {
+	boom := errors.New("boom")
+	c := &Command{Name: "app", Run: func(context.Context, []string) error { return boom }}
+	if err := c.Execute(context.Background(), nil); err != boom {
+		t.Errorf("err = %v; want Run's error unchanged", err)
+	}
+}
+
+func TestHelp(t *testing.T) {
+	root, _, _, _, _, help := tree()
+	if err := root.Execute(context.Background(), []string{"-name", "changed", "remote", "add", "-h"}); !errors.Is(err, flag.ErrHelp) {
+		t.Fatalf("-h: err = %v; want flag.ErrHelp", err)
+	}
+	want := `Add a remote
+
+Usage:
+  app remote add [flags] NAME URL
+
+Flags:
+  -f
+    	fetch after adding
+
+Global flags:
+  -name NAME
+    	a NAME to use (default "world")
+  -v
+    	verbose output
+`
+	if got := help.String(); got != want {
+		t.Errorf("help =\n%s\nwant\n%s", got, want)
+	}
+
+	// "help remote" prints the same as "remote -h", and is not an error.
+	root, _, _, _, _, help = tree()
+	if err := root.Execute(context.Background(), []string{"help", "remote"}); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"Usage:\n  app remote [flags] <command>\n", "  add    Add a remote\n", "Run 'app remote <command> -h'"} {
+		if !strings.Contains(help.String(), line) {
+			t.Errorf("help remote lacks %q:\n%s", line, help)
+		}
+	}
+}
@@ -5,14 +5,34 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"regexp"
 	"strings"
 	"time"
+
+	"golang_roadmap/08_web_development/04_validation/validate"
 )
 
 // Demonstrates the Go standard library's flag package.
 // Shows basic flags (string, int, bool, duration), parsing, usage, subcommands,
 // custom flag types, and how to access values and handle errors.
 
+// tagRe is what a -tags entry must look like.
+var tagRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+// exitIfInvalid prints every field error from v, then the usage, and exits
+// with status 2, as flag.ExitOnError does for a malformed flag.
+func exitIfInvalid(v *validate.Validator, usage func()) {
+	err := v.Err()
+	if err == nil {
+		return
+	}
+	for _, fe := range validate.Fields(err) {
+		fmt.Println("error:", fe)
+	}
+	usage()
+	os.Exit(2)
+}
+
 // CSVFlag is a simple custom flag.Value that parses comma-separated values.
 type CSVFlag []string
 
@@ -63,6 +83,9 @@ func main() {
 			fmt.Println("subcmd parse error:", err)
 			os.Exit(2)
 		}
+		var v validate.Validator
+		v.Int("-port", *port).Range(1, 65535)
+		exitIfInvalid(&v, sub.Usage)
 		fmt.Println("subcmd running on port:", *port, "debug:", *debug)
 		return
 	}
@@ -70,6 +93,19 @@ func main() {
 	// Parse default flags
 	flag.Parse()
 
+	// Validate flags before using any of them. flag checks only that a
+	// value parses as its type; ranges and formats are up to us. All
+	// problems are reported at once, not one per run.
+	*name = validate.Clean(*name)
+	var v validate.Validator
+	v.String("-name", *name).NonEmpty().MaxLen(50)
+	v.Int("-n", *count).Min(1)
+	validate.Ordered(&v, "-timeout", *timeout).Range(100*time.Millisecond, time.Minute)
+	for _, tag := range tags {
+		v.String(fmt.Sprintf("-tags %q", tag), tag).Matches(tagRe, "lowercase letters, digits and dashes")
+	}
+	exitIfInvalid(&v, flag.Usage)
+
 	// Access leftover positional arguments after parsing flags
 	extra := flag.Args()
 
@@ -86,12 +122,5 @@ func main() {
 	// Simulate using duration flag
 	fmt.Printf("(would wait up to %s for an operation)\n", timeout.String())
 
-	// Demonstrate error handling example: validate flags
-	if *count < 1 {
-		fmt.Println("error: -n must be >= 1")
-		flag.Usage()
-		os.Exit(2)
-	}
-
 	// Show help auto-generation: run `-h` or `--help` to see it
 }
@@ -0,0 +1,20 @@
+module golang_roadmap/03_std_lib/02_flag
+
+go 1.24.11
+
+require (
+	golang_roadmap/07_building_cli_beyond_flag/05_output v0.0.0
+	golang_roadmap/08_web_development/04_validation v0.0.0
+)
+
+require (
+	github.com/charmbracelet/x/term v0.2.2 // indirect
+	golang.org/x/sys v0.36.0 // indirect
+)
+
+// The validate and output packages live in their own modules in this
+// repository.
+replace (
+	golang_roadmap/07_building_cli_beyond_flag/05_output => ../../07_building_cli_beyond_flag/05_output
+	golang_roadmap/08_web_development/04_validation => ../../08_web_development/04_validation
+)
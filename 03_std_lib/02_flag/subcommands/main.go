@@ -0,0 +1,196 @@
+// Demonstrates a command tree built on flag.FlagSet, with the command
+// package.
+//
+// This example shows:
+// - Subcommands, and subcommands of subcommands (kv export env)
+// - Global flags that work before or after the subcommand's name
+// - Help generated from the tree, with -h or "kv help export env"
+// - Exit status 2 for usage errors and 1 for failures
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"golang_roadmap/03_std_lib/02_flag/command"
+	"golang_roadmap/07_building_cli_beyond_flag/05_output/output"
+)
+
+func main() {
+	root := newRoot()
+	err := root.Execute(context.Background(), os.Args[1:])
+	var usageErr *command.UsageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.As(err, &usageErr):
+		fmt.Fprintln(os.Stderr, "error:", err)
+		fmt.Fprintf(os.Stderr, "Run '%s -h' for usage.\n", usageErr.Cmd.Path())
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// newRoot builds the kv command tree. The global flags are variables that
+// every Run closes over.
+func newRoot() *command.Command {
+	global := flag.NewFlagSet("kv", flag.ContinueOnError)
+	file := global.String("file", "kv.json", "the JSON file the keys are stored in")
+	verbose := global.Bool("v", false, "say what is happening")
+
+	logf := func(format string, args ...any) {
+		if *verbose {
+			fmt.Fprintf(os.Stderr, format+"\n", args...)
+		}
+	}
+	load := func() (map[string]string, error) {
+		logf("reading %s", *file)
+		return loadStore(*file)
+	}
+	save := func(kv map[string]string) error {
+		logf("writing %s", *file)
+		return saveStore(*file, kv)
+	}
+
+	set := &command.Command{
+		Name: "set", Short: "Set a key", Args: "KEY VALUE",
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 2 {
+				return errors.New("set needs KEY and VALUE")
+			}
+			kv, err := load()
+			if err != nil {
+				return err
+			}
+			kv[args[0]] = args[1]
+			return save(kv)
+		},
+	}
+	get := &command.Command{
+		Name: "get", Short: "Print a key's value", Args: "KEY",
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("get needs KEY")
+			}
+			kv, err := load()
+			if err != nil {
+				return err
+			}
+			v, ok := kv[args[0]]
+			if !ok {
+				return fmt.Errorf("no key %q", args[0])
+			}
+			fmt.Println(v)
+			return nil
+		},
+	}
+
+	listFlags := flag.NewFlagSet("list", flag.ContinueOnError)
+	prefix := listFlags.String("prefix", "", "only keys starting with this")
+	format := output.Table
+	listFlags.Var(&format, "output", "output format: "+output.Choices)
+	list := &command.Command{
+		Name: "list", Short: "List keys and values", Flags: listFlags,
+		Run: func(ctx context.Context, args []string) error {
+			kv, err := load()
+			if err != nil {
+				return err
+			}
+			var entries []entry
+			for _, k := range slices.Sorted(maps.Keys(kv)) {
+				if strings.HasPrefix(k, *prefix) {
+					entries = append(entries, entry{k, kv[k]})
+				}
+			}
+			return output.Print(output.NewPrinter(os.Stdout, format, "auto"), entries, entrySpec)
+		},
+	}
+
+	envFlags := flag.NewFlagSet("env", flag.ContinueOnError)
+	envPrefix := envFlags.String("prefix", "KV_", "prepended to each variable name")
+	export := &command.Command{
+		Name:  "export",
+		Short: "Print all keys in another format",
+		Long:  "Print all keys as JSON or as shell variable assignments.",
+		Commands: []*command.Command{
+			{
+				Name: "json", Short: "As a JSON object",
+				Run: func(ctx context.Context, args []string) error {
+					kv, err := load()
+					if err != nil {
+						return err
+					}
+					enc := json.NewEncoder(os.Stdout)
+					enc.SetIndent("", "  ")
+					return enc.Encode(kv)
+				},
+			},
+			{
+				Name: "env", Short: "As export lines for a shell", Flags: envFlags,
+				Run: func(ctx context.Context, args []string) error {
+					kv, err := load()
+					if err != nil {
+						return err
+					}
+					for _, k := range slices.Sorted(maps.Keys(kv)) {
+						name := strings.ToUpper(*envPrefix + strings.NewReplacer("-", "_", ".", "_").Replace(k))
+						fmt.Printf("export %s='%s'\n", name, strings.ReplaceAll(kv[k], "'", `'\''`))
+					}
+					return nil
+				},
+			},
+		},
+	}
+
+	return &command.Command{
+		Name:       "kv",
+		Long:       "kv keeps string keys and values in a JSON file.",
+		Persistent: global,
+		Commands:   []*command.Command{set, get, list, export},
+	}
+}
+
+// entry is one key for "kv list".
+type entry struct {
+	Key   string `json:"key"`
+	Value string `json:"value"`
+}
+
+var entrySpec = output.Spec[entry]{
+	Columns: []output.Column[entry]{
+		{Header: "KEY", Value: func(e entry) string { return e.Key }},
+		{Header: "VALUE", Value: func(e entry) string { return e.Value }, Truncate: true},
+	},
+}
+
+func loadStore(path string) (map[string]string, error) {
+	kv := map[string]string{}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return kv, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(data, &kv); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return kv, nil
+}
+
+func saveStore(path string, kv map[string]string) error {
+	data, err := json.MarshalIndent(kv, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
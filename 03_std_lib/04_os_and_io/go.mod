@@ -0,0 +1,3 @@
+module golang_roadmap/03_std_lib/04_os_and_io
+
+go 1.24.11
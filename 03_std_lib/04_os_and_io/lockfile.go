@@ -0,0 +1,122 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrLocked is returned by AcquireLock when another live process holds
+// the lock.
+var ErrLocked = errors.New("lock is held by another process")
+
+// Lock is a lock file: it exists while the lock is held, and records the
+// holder's PID. O_CREATE|O_EXCL makes creating it atomic, so exactly one
+// process can succeed, on every OS and on most network filesystems.
+//
+// Unlike an OS lock (flock, LockFileEx), a lock file outlives a crashed
+// holder. AcquireLock therefore checks whether the holder is still alive
+// before giving up.
+type Lock struct {
+	path string
+}
+
+// AcquireLock takes the lock at path, or returns ErrLocked. A lock is
+// stale, and is broken, when its holder's PID no longer runs or when the
+// file has not been touched for staleAfter (a hung holder, or a PID that
+// was reused by another program). Long-running holders call Refresh to
+// stay fresh. staleAfter <= 0 disables the age check.
+func AcquireLock(path string, staleAfter time.Duration) (*Lock, error) {
+	for attempt := 0; attempt < 2; attempt++ {
+		f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0644)
+		if err == nil {
+			_, werr := fmt.Fprintf(f, "%d\n", os.Getpid())
+			cerr := f.Close()
+			if err := errors.Join(werr, cerr); err != nil {
+				os.Remove(path)
+				return nil, fmt.Errorf("lock %s: %w", path, err)
+			}
+			return &Lock{path: path}, nil
+		}
+		if !errors.Is(err, os.ErrExist) {
+			return nil, fmt.Errorf("lock %s: %w", path, err)
+		}
+		if attempt > 0 {
+			break
+		}
+		if err := breakIfStale(path, staleAfter); err != nil {
+			return nil, err
+		}
+	}
+	return nil, fmt.Errorf("lock %s: %w", path, ErrLocked)
+}
+
+// breakIfStale removes the lock file if its holder is gone. It returns
+// ErrLocked if the holder is alive.
+func breakIfStale(path string, staleAfter time.Duration) error {
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil // released meanwhile: try again
+	}
+	if err != nil {
+		return fmt.Errorf("lock %s: %w", path, err)
+	}
+	info, err := os.Stat(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("lock %s: %w", path, err)
+	}
+
+	old := staleAfter > 0 && time.Since(info.ModTime()) > staleAfter
+	pid, perr := strconv.Atoi(strings.TrimSpace(string(data)))
+	switch {
+	case old:
+	case perr == nil && !processAlive(pid):
+	default:
+		// Alive, or unreadable but young: the holder may be between
+		// creating the file and writing its PID.
+		return fmt.Errorf("lock %s (pid %s): %w", path, strings.TrimSpace(string(data)), ErrLocked)
+	}
+
+	// Two processes may find the same stale lock. Rename is atomic, so
+	// only one of them moves it aside. The winner checks that it moved the
+	// file it judged stale, not a fresh lock taken in between.
+	aside := fmt.Sprintf("%s.stale-%d", path, os.Getpid())
+	if err := os.Rename(path, aside); err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return fmt.Errorf("lock %s: break stale lock: %w", path, err)
+	}
+	moved, err := os.ReadFile(aside)
+	if err == nil && !bytes.Equal(moved, data) {
+		// Put the fresh lock back. Link fails rather than overwrite if
+		// yet another process has created the lock since.
+		os.Link(aside, path)
+		os.Remove(aside)
+		return fmt.Errorf("lock %s: %w", path, ErrLocked)
+	}
+	os.Remove(aside)
+	return nil
+}
+
+// Refresh updates the lock file's modification time, so that other
+// processes do not consider a long-running holder stale.
+func (l *Lock) Refresh() error {
+	now := time.Now()
+	return os.Chtimes(l.path, now, now)
+}
+
+// Release removes the lock file. Only the holder should call it.
+func (l *Lock) Release() error {
+	if err := os.Remove(l.path); err != nil {
+		return fmt.Errorf("unlock %s: %w", l.path, err)
+	}
+	return nil
+}
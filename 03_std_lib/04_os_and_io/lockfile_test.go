@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary double as a second process that takes a
+// lock, so the tests can check exclusion between real processes.
+func TestMain(m *testing.M) {
+	if path := os.Getenv("LOCK_CHILD_PATH"); path != "" {
+		lockChild(path)
+		return
+	}
+	os.Exit(m.Run())
+}
+
+// lockChild takes the lock, reports "locked" on stdout, and holds it until
+// stdin is closed (or until it is killed).
+func lockChild(path string) {
+	lock, err := AcquireLock(path, time.Minute)
+	if err != nil {
+		fmt.Println("error:", err)
+		os.Exit(1)
+	}
+	fmt.Println("locked")
+	bufio.NewReader(os.Stdin).ReadString('\n')
+	lock.Release()
+}
+
+// startHolder runs lockChild in a child process and waits until it holds
+// the lock. Closing the returned stdin makes the child release and exit.
+func startHolder(t *testing.T, path string) (*exec.Cmd, func()) {
+	t.Helper()
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "LOCK_CHILD_PATH="+path)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cmd.Process.Kill(); cmd.Wait() })
+	line, _ := bufio.NewReader(stdout).ReadString('\n')
+	if line != "locked\n" {
+		t.Fatalf("child: %q", line)
+	}
+	return cmd, func() { stdin.Close() }
+}
+
+func TestLockExcludesOtherProcess(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.lock")
+	cmd, release := startHolder(t, path)
+
+	if _, err := AcquireLock(path, time.Minute); !errors.Is(err, ErrLocked) {
+		t.Fatalf("AcquireLock while child holds it: %v, want ErrLocked", err)
+	}
+	data, _ := os.ReadFile(path)
+	if pid, _ := strconv.Atoi(string(data[:len(data)-1])); pid != cmd.Process.Pid {
+		t.Errorf("lock file records pid %q, child is %d", data, cmd.Process.Pid)
+	}
+
+	release()
+	if err := cmd.Wait(); err != nil {
+		t.Fatalf("child: %v", err)
+	}
+	lock, err := AcquireLock(path, time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireLock after child released: %v", err)
+	}
+	lock.Release()
+}
+
+// A holder that dies without releasing leaves the file behind; the next
+// AcquireLock sees that its PID is gone and breaks the lock.
+func TestLockBrokenWhenHolderDies(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.lock")
+	cmd, _ := startHolder(t, path)
+	cmd.Process.Kill()
+	cmd.Wait() // reap it: a zombie still counts as a running PID
+
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("killed child should leave its lock file: %v", err)
+	}
+	lock, err := AcquireLock(path, time.Minute)
+	if err != nil {
+		t.Fatalf("AcquireLock over dead holder: %v", err)
+	}
+	defer lock.Release()
+	assertOnlyFiles(t, filepath.Dir(path), "app.lock")
+}
+
+func TestLockStaleByAge(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.lock")
+	// Our own PID is certainly alive, so only the age can make it stale.
+	if err := os.WriteFile(path, []byte(strconv.Itoa(os.Getpid())+"\n"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AcquireLock(path, time.Hour); !errors.Is(err, ErrLocked) {
+		t.Fatalf("fresh lock of a live pid: %v, want ErrLocked", err)
+	}
+
+	old := time.Now().Add(-2 * time.Hour)
+	os.Chtimes(path, old, old)
+	lock, err := AcquireLock(path, time.Hour)
+	if err != nil {
+		t.Fatalf("AcquireLock over 2h-old lock: %v", err)
+	}
+	// Refresh keeps a long-running holder from looking stale.
+	os.Chtimes(path, old, old)
+	if err := lock.Refresh(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AcquireLock(path, time.Hour); !errors.Is(err, ErrLocked) {
+		t.Fatalf("after Refresh: %v, want ErrLocked", err)
+	}
+	lock.Release()
+}
+
+// An empty lock file may be a holder that has not written its PID yet.
+func TestLockEmptyFileIsHeldUntilOld(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.lock")
+	os.WriteFile(path, nil, 0644)
+	if _, err := AcquireLock(path, time.Minute); !errors.Is(err, ErrLocked) {
+		t.Fatalf("empty young lock: %v, want ErrLocked", err)
+	}
+	old := time.Now().Add(-time.Hour)
+	os.Chtimes(path, old, old)
+	lock, err := AcquireLock(path, time.Minute)
+	if err != nil {
+		t.Fatalf("empty old lock: %v", err)
+	}
+	lock.Release()
+}
@@ -2,11 +2,13 @@ package main
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
 	"log"
 	"os"
 	"path/filepath"
+	"time"
 )
 
 // Consolidated os/io examples — single main that demonstrates common patterns.
@@ -133,5 +135,40 @@ func main() {
 		}
 	}
 
+	// Safe writes: atomic replace and lock files (safe_write.go, lockfile.go)
+	safeWriteExamples(base)
+
 	fmt.Println("os/io examples done")
 }
+
+func safeWriteExamples(base string) {
+	cfg := filepath.Join(base, "config.json")
+	for _, v := range []string{`{"version": 1}`, `{"version": 2}`} {
+		if err := WriteFileAtomic(cfg, []byte(v+"\n"), 0644); err != nil {
+			log.Fatalf("WriteFileAtomic: %v", err)
+		}
+	}
+	data, _ := os.ReadFile(cfg)
+	fmt.Printf("WriteFileAtomic result: %s", data)
+
+	lockPath := filepath.Join(base, "app.lock")
+	lock, err := AcquireLock(lockPath, time.Minute)
+	if err != nil {
+		log.Fatalf("AcquireLock: %v", err)
+	}
+	fmt.Println("Lock acquired:", lockPath)
+	if _, err := AcquireLock(lockPath, time.Minute); errors.Is(err, ErrLocked) {
+		fmt.Println("Second AcquireLock:", err)
+	}
+	if err := lock.Release(); err != nil {
+		log.Printf("Release: %v", err)
+	}
+
+	// A lock left behind by a process that no longer exists is broken.
+	_ = os.WriteFile(lockPath, []byte("999999999\n"), 0644)
+	lock, err = AcquireLock(lockPath, time.Minute)
+	fmt.Printf("AcquireLock over a dead holder's lock: err=%v\n", err)
+	if err == nil {
+		lock.Release()
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// WriteFileAtomic replaces path with data so that readers, and the file
+// after a crash, see either the old content or the new content, never a
+// mix or a truncated file.
+//
+// os.WriteFile truncates the file and then writes it, so a crash or a
+// concurrent reader in between sees a partial file. Instead:
+//
+//  1. write to a temp file in the same directory (rename only replaces
+//     atomically within one filesystem),
+//  2. fsync the temp file, so its data is on disk before its name is,
+//  3. rename it over path; on POSIX this is atomic for readers,
+//  4. fsync the directory, so the rename itself survives a power cut.
+//
+// Without step 2, a crash soon after the rename can leave a zero-length
+// file on some filesystems: the rename was persisted, the data was not.
+func WriteFileAtomic(path string, data []byte, perm os.FileMode) (err error) {
+	dir := filepath.Dir(path)
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".tmp-*")
+	if err != nil {
+		return fmt.Errorf("atomic write %s: %w", path, err)
+	}
+	defer func() {
+		if err != nil {
+			tmp.Close()
+			os.Remove(tmp.Name())
+		}
+	}()
+
+	if _, err = tmp.Write(data); err != nil {
+		return fmt.Errorf("atomic write %s: %w", path, err)
+	}
+	if err = tmp.Sync(); err != nil {
+		return fmt.Errorf("atomic write %s: sync: %w", path, err)
+	}
+	if err = tmp.Close(); err != nil {
+		return fmt.Errorf("atomic write %s: %w", path, err)
+	}
+	// CreateTemp uses 0600; set the mode the caller asked for.
+	if err = os.Chmod(tmp.Name(), perm); err != nil {
+		return fmt.Errorf("atomic write %s: %w", path, err)
+	}
+	if err = os.Rename(tmp.Name(), path); err != nil {
+		return fmt.Errorf("atomic write %s: %w", path, err)
+	}
+	if err = syncDir(dir); err != nil {
+		return fmt.Errorf("atomic write %s: sync dir: %w", path, err)
+	}
+	return nil
+}
+
+// syncDir flushes a directory's entries (names, renames) to disk.
+// Windows cannot open a directory for syncing; there, NTFS journals the
+// rename itself.
+func syncDir(dir string) error {
+	if runtime.GOOS == "windows" {
+		return nil
+	}
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+)
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "config.json")
+	if err := os.WriteFile(path, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := WriteFileAtomic(path, []byte("new"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || string(data) != "new" {
+		t.Fatalf("content = %q, %v", data, err)
+	}
+	if info, _ := os.Stat(path); info.Mode().Perm() != 0644 {
+		t.Errorf("mode = %v, want 0644", info.Mode().Perm())
+	}
+	assertOnlyFiles(t, dir, "config.json")
+}
+
+func TestWriteFileAtomicFailureKeepsOriginal(t *testing.T) {
+	dir := t.TempDir()
+	// A directory in the way makes the final rename fail.
+	path := filepath.Join(dir, "target")
+	if err := os.Mkdir(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(path, "keep"), nil, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := WriteFileAtomic(path, []byte("x"), 0644); err == nil {
+		t.Fatal("want an error renaming over a non-empty directory")
+	}
+	assertOnlyFiles(t, dir, "target") // the temp file was cleaned up
+
+	if err := WriteFileAtomic(filepath.Join(dir, "missing", "f"), []byte("x"), 0644); err == nil {
+		t.Fatal("want an error for a missing directory")
+	}
+}
+
+// Readers racing with writers only ever see a complete version.
+func TestWriteFileAtomicReadersSeeWholeFiles(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "data")
+	versions := map[string]bool{}
+	for _, c := range "abcdefgh" {
+		versions[string(repeat(byte(c), 64<<10))] = true
+	}
+	if err := WriteFileAtomic(path, repeat('a', 64<<10), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		for i := range 50 {
+			if err := WriteFileAtomic(path, repeat(byte("abcdefgh"[i%8]), 64<<10), 0644); err != nil {
+				t.Error(err)
+				return
+			}
+		}
+	}()
+	for range 200 {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !versions[string(data)] {
+			t.Fatalf("read a torn file: %d bytes starting %q", len(data), data[:min(len(data), 8)])
+		}
+	}
+	wg.Wait()
+}
+
+func repeat(b byte, n int) []byte {
+	p := make([]byte, n)
+	for i := range p {
+		p[i] = b
+	}
+	return p
+}
+
+func assertOnlyFiles(t *testing.T, dir string, want ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, e := range entries {
+		got = append(got, e.Name())
+	}
+	if len(got) != len(want) {
+		t.Fatalf("files in dir = %v, want %v", got, want)
+	}
+	for i := range got {
+		if got[i] != want[i] {
+			t.Fatalf("files in dir = %v, want %v", got, want)
+		}
+	}
+}
@@ -13,7 +13,7 @@ var indexHTML string
 //go:embed static/config.json
 var configJSON []byte
 
-//go:embed static/*
+//go:embed static/*.html static/*.json
 var staticFiles embed.FS
 
 func main() {
@@ -0,0 +1,3 @@
+module golang_roadmap/03_std_lib/08_go_embed
+
+go 1.24.11
@@ -0,0 +1,10 @@
+// Package static embeds the files in this directory, so other modules can
+// use them as an fs.FS.
+package static
+
+import "embed"
+
+// FS holds index.html and config.json at its root.
+//
+//go:embed *.html *.json
+var FS embed.FS
@@ -0,0 +1,194 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+func TestLineNumberReader(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"empty", "", ""},
+		{"one line", "a\n", "   1  a\n"},
+		{"no trailing newline", "a\nb", "   1  a\n   2  b"},
+		{"blank lines", "\n\nc\n", "   1  \n   2  \n   3  c\n"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := io.ReadAll(NewLineNumberReader(strings.NewReader(tt.in)))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Fatalf("got %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// A reader must behave with tiny buffers and with a source that returns
+// one byte at a time; iotest.TestReader also checks the io.Reader rules.
+func TestLineNumberReaderContract(t *testing.T) {
+	in := "first\nsecond line\nthird\n"
+	want := "   1  first\n   2  second line\n   3  third\n"
+	if err := iotest.TestReader(NewLineNumberReader(iotest.OneByteReader(strings.NewReader(in))), []byte(want)); err != nil {
+		t.Fatal(err)
+	}
+	got, err := io.ReadAll(iotest.OneByteReader(NewLineNumberReader(strings.NewReader(in))))
+	if err != nil || string(got) != want {
+		t.Fatalf("one byte at a time: got %q, %v", got, err)
+	}
+}
+
+func TestLineNumberReaderError(t *testing.T) {
+	boom := errors.New("boom")
+	r := NewLineNumberReader(io.MultiReader(strings.NewReader("ok\n"), iotest.ErrReader(boom)))
+	got, err := io.ReadAll(r)
+	if !errors.Is(err, boom) || string(got) != "   1  ok\n" {
+		t.Fatalf("got %q, %v; want the first line then boom", got, err)
+	}
+}
+
+func TestRateLimitedWriter(t *testing.T) {
+	var out bytes.Buffer
+	start := time.Unix(0, 0)
+	fake := clock.NewAutoFake(start)     // advances only when the writer sleeps
+	w := NewRateLimitedWriter(&out, 100) // 10-byte chunks
+	w.clock = fake
+
+	data := bytes.Repeat([]byte("x"), 250)
+	n, err := w.Write(data)
+	if err != nil || n != 250 || out.Len() != 250 {
+		t.Fatalf("Write = %d, %v; buffer %d", n, err, out.Len())
+	}
+	// The first chunk goes at once; the last 10 bytes are due at 2.4s.
+	if got, want := fake.Now().Sub(start), 2400*time.Millisecond; got != want {
+		t.Fatalf("elapsed %v, want %v", got, want)
+	}
+
+	// Pauses between writes count: the budget catches up, no sleep needed.
+	fake.Advance(5 * time.Second)
+	before := fake.Now()
+	w.Write([]byte("0123456789"))
+	if fake.Now() != before {
+		t.Fatalf("slept %v after an idle period", fake.Now().Sub(before))
+	}
+}
+
+func TestRateLimitedWriterError(t *testing.T) {
+	w := NewRateLimitedWriter(&shortWriter{limit: 15}, 100)
+	w.clock = clock.NewAutoFake(time.Time{})
+	n, err := w.Write(make([]byte, 30))
+	if n != 15 || !errors.Is(err, io.ErrShortWrite) {
+		t.Fatalf("Write = %d, %v; want 15, ErrShortWrite", n, err)
+	}
+}
+
+// shortWriter accepts limit bytes, then fails.
+type shortWriter struct{ limit, n int }
+
+func (w *shortWriter) Write(p []byte) (int, error) {
+	room := w.limit - w.n
+	if len(p) > room {
+		w.n += room
+		return room, io.ErrShortWrite
+	}
+	w.n += len(p)
+	return len(p), nil
+}
+
+func TestCopyWithChecksum(t *testing.T) {
+	data := strings.Repeat("checksum me ", 10000)
+	var dst bytes.Buffer
+	// OneByteReader forces many small reads through the tee.
+	n, sum, err := CopyWithChecksum(&dst, iotest.OneByteReader(strings.NewReader(data)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := sha256.Sum256([]byte(data))
+	if n != int64(len(data)) || dst.String() != data || sum != hex.EncodeToString(want[:]) {
+		t.Fatalf("n=%d sum=%s; copy intact: %v", n, sum, dst.String() == data)
+	}
+
+	_, _, err = CopyWithChecksum(io.Discard, iotest.TimeoutReader(strings.NewReader(data)))
+	if !errors.Is(err, iotest.ErrTimeout) {
+		t.Fatalf("err = %v, want the source's error", err)
+	}
+}
+
+func TestReadAllCapped(t *testing.T) {
+	tests := []struct {
+		size    int
+		wantErr error
+	}{
+		{0, nil},
+		{99, nil},
+		{100, nil}, // exactly the cap is allowed
+		{101, ErrTooLarge},
+		{1 << 20, ErrTooLarge},
+	}
+	for _, tt := range tests {
+		data, err := ReadAllCapped(strings.NewReader(strings.Repeat("x", tt.size)), 100)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("size %d: err = %v, want %v", tt.size, err, tt.wantErr)
+		}
+		if err == nil && len(data) != tt.size {
+			t.Errorf("size %d: got %d bytes", tt.size, len(data))
+		}
+	}
+}
+
+func TestReadRange(t *testing.T) {
+	src := strings.NewReader("0123456789")
+	tests := []struct {
+		start, end int64
+		want       string
+		wantErr    error
+	}{
+		{0, 0, "0", nil},
+		{2, 5, "2345", nil},
+		{7, 100, "789", nil}, // end past the data is clamped
+		{9, 9, "9", nil},
+		{10, 12, "", ErrBadRange},
+		{5, 4, "", ErrBadRange},
+		{-1, 3, "", ErrBadRange},
+	}
+	for _, tt := range tests {
+		sr, err := ReadRange(src, src.Size(), tt.start, tt.end)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%d-%d: err = %v, want %v", tt.start, tt.end, err, tt.wantErr)
+			continue
+		}
+		if err != nil {
+			continue
+		}
+		got, _ := io.ReadAll(sr)
+		if string(got) != tt.want {
+			t.Errorf("%d-%d: got %q, want %q", tt.start, tt.end, got, tt.want)
+		}
+	}
+}
+
+// Section readers share one io.ReaderAt without sharing an offset.
+func TestReadRangeIndependent(t *testing.T) {
+	src := strings.NewReader("abcdefghij")
+	a, _ := ReadRange(src, src.Size(), 0, 4)
+	b, _ := ReadRange(src, src.Size(), 5, 9)
+	buf := make([]byte, 2)
+	a.Read(buf)
+	b.Read(buf)
+	rest, _ := io.ReadAll(a)
+	if string(rest) != "cde" {
+		t.Fatalf("a after interleaved reads: %q, want %q", rest, "cde")
+	}
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// LineNumberReader prefixes every line read from the underlying reader
+// with its line number. It streams: only one line is held in memory.
+type LineNumberReader struct {
+	src     *bufio.Reader
+	line    int
+	pending []byte // formatted output not yet returned by Read
+	err     error  // error from src, returned once pending is empty
+}
+
+func NewLineNumberReader(r io.Reader) *LineNumberReader {
+	return &LineNumberReader{src: bufio.NewReader(r)}
+}
+
+// Read fills p from the current numbered line, reading the next line from
+// the source when the current one is used up. Like any io.Reader it may
+// return fewer bytes than len(p); callers loop, or use io.Copy.
+func (r *LineNumberReader) Read(p []byte) (int, error) {
+	for len(r.pending) == 0 {
+		if r.err != nil {
+			return 0, r.err
+		}
+		line, err := r.src.ReadBytes('\n')
+		if len(line) > 0 {
+			r.line++
+			r.pending = fmt.Appendf(r.pending[:0], "%4d  %s", r.line, line)
+		}
+		r.err = err
+	}
+	n := copy(p, r.pending)
+	r.pending = r.pending[n:]
+	return n, nil
+}
+
+// ErrTooLarge is returned by ReadAllCapped when the input exceeds the cap.
+var ErrTooLarge = errors.New("input exceeds size limit")
+
+// ReadAllCapped reads r to EOF but refuses more than max bytes, so a
+// client sending an endless body cannot exhaust memory.
+//
+// io.LimitReader alone just stops at max bytes and reports EOF: the caller
+// cannot tell a body of exactly max bytes from a truncated one. Reading
+// max+1 bytes tells them apart.
+func ReadAllCapped(r io.Reader, max int64) ([]byte, error) {
+	data, err := io.ReadAll(io.LimitReader(r, max+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(data)) > max {
+		return nil, fmt.Errorf("%w: more than %d bytes", ErrTooLarge, max)
+	}
+	return data, nil
+}
+
+// CopyWithChecksum copies src to dst and returns the SHA-256 of what was
+// copied. io.TeeReader writes every byte read from src into the hash as
+// well, so the data is read once and never buffered whole.
+func CopyWithChecksum(dst io.Writer, src io.Reader) (int64, string, error) {
+	h := sha256.New()
+	n, err := io.Copy(dst, io.TeeReader(src, h))
+	if err != nil {
+		return n, "", err
+	}
+	return n, hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// ErrBadRange is returned by ReadRange for a range outside the data.
+var ErrBadRange = errors.New("range not satisfiable")
+
+// ReadRange returns a reader over bytes [start, end] (inclusive, as in an
+// HTTP Range header) of ra, whose total size is size. An
+// io.SectionReader reads with ReadAt, so it does not move a shared file
+// offset: many ranges of one *os.File can be read concurrently.
+func ReadRange(ra io.ReaderAt, size, start, end int64) (*io.SectionReader, error) {
+	if start < 0 || start > end || start >= size {
+		return nil, fmt.Errorf("%w: bytes %d-%d of %d", ErrBadRange, start, end, size)
+	}
+	end = min(end, size-1)
+	return io.NewSectionReader(ra, start, end-start+1), nil
+}
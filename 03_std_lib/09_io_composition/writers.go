@@ -0,0 +1,57 @@
+package main
+
+import (
+	"io"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+// RateLimitedWriter passes writes through to the underlying writer at no
+// more than BytesPerSec on average. Large writes are split into chunks,
+// and Write sleeps before each chunk that would be early.
+type RateLimitedWriter struct {
+	w           io.Writer
+	bytesPerSec int
+	chunk       int
+
+	start   time.Time
+	written int64
+
+	// Replaced in tests with a fake clock, so they don't have to sleep.
+	clock clock.Clock
+}
+
+// NewRateLimitedWriter limits w to bytesPerSec. Chunks are a tenth of a
+// second's worth of bytes, so output flows steadily rather than in bursts.
+func NewRateLimitedWriter(w io.Writer, bytesPerSec int) *RateLimitedWriter {
+	return &RateLimitedWriter{
+		w:           w,
+		bytesPerSec: bytesPerSec,
+		chunk:       max(bytesPerSec/10, 1),
+		clock:       clock.Real(),
+	}
+}
+
+func (w *RateLimitedWriter) Write(p []byte) (int, error) {
+	if w.start.IsZero() {
+		w.start = w.clock.Now()
+	}
+	total := 0
+	for len(p) > 0 {
+		n := min(len(p), w.chunk)
+		// Bytes written so far are allowed at start + written/rate.
+		due := w.start.Add(time.Duration(w.written) * time.Second / time.Duration(w.bytesPerSec))
+		if wait := due.Sub(w.clock.Now()); wait > 0 {
+			w.clock.Sleep(wait)
+		}
+		m, err := w.w.Write(p[:n])
+		total += m
+		w.written += int64(m)
+		if err != nil {
+			return total, err
+		}
+		p = p[n:]
+	}
+	return total, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"mime"
+	"path"
+	"slices"
+	"strings"
+)
+
+// Nothing in this file imports os or embed. Both functions take an fs.FS,
+// so the caller decides where files come from: a directory on disk
+// (os.DirFS), files compiled into the binary (embed.FS), or a map in a
+// test (fstest.MapFS).
+
+// Templates parses every file matching pattern in fsys into one template
+// set. Templates are named by their base name, as template.ParseFS does.
+type Templates struct {
+	set *template.Template
+}
+
+func LoadTemplates(fsys fs.FS, pattern string) (*Templates, error) {
+	set, err := template.ParseFS(fsys, pattern)
+	if err != nil {
+		return nil, fmt.Errorf("load templates %q: %w", pattern, err)
+	}
+	return &Templates{set: set}, nil
+}
+
+// Render executes the named template. It renders into a buffer first, so
+// a template error never leaves half a page in w.
+func (t *Templates) Render(w io.Writer, name string, data any) error {
+	var buf bytes.Buffer
+	if err := t.set.ExecuteTemplate(&buf, name, data); err != nil {
+		return fmt.Errorf("render %s: %w", name, err)
+	}
+	_, err := buf.WriteTo(w)
+	return err
+}
+
+// Names lists the loaded templates, sorted.
+func (t *Templates) Names() []string {
+	var names []string
+	for _, tmpl := range t.set.Templates() {
+		names = append(names, tmpl.Name())
+	}
+	slices.Sort(names)
+	return names
+}
+
+// Asset describes one static file, as a web server would need to serve it.
+type Asset struct {
+	Path        string // slash-separated, relative to the scanned root
+	Size        int64
+	ContentType string
+	ETag        string // quoted SHA-256 prefix of the content
+}
+
+// ScanStatic walks root in fsys and describes every regular file under it.
+// Hidden files and directories (starting with ".") are skipped, as are Go
+// source files. Paths in fs.FS are always slash-separated, on every OS.
+func ScanStatic(fsys fs.FS, root string) ([]Asset, error) {
+	var assets []Asset
+	err := fs.WalkDir(fsys, root, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		name := d.Name()
+		if p != root && strings.HasPrefix(name, ".") {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() || path.Ext(name) == ".go" {
+			return nil
+		}
+		data, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		sum := sha256.Sum256(data)
+		rel := strings.TrimPrefix(strings.TrimPrefix(p, root), "/")
+		if root == "." {
+			rel = p
+		}
+		assets = append(assets, Asset{
+			Path:        rel,
+			Size:        int64(len(data)),
+			ContentType: contentType(name),
+			ETag:        `"` + hex.EncodeToString(sum[:8]) + `"`,
+		})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("scan %s: %w", root, err)
+	}
+	return assets, nil
+}
+
+func contentType(name string) string {
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" {
+		return ct
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"testing/fstest"
+
+	"golang_roadmap/03_std_lib/08_go_embed/static"
+)
+
+func TestScanStaticMapFS(t *testing.T) {
+	fsys := fstest.MapFS{
+		"public/app.css":        {Data: []byte("body{}")},
+		"public/img/logo.png":   {Data: []byte("\x89PNG")},
+		"public/.hidden":        {Data: []byte("x")},
+		"public/.git/config":    {Data: []byte("x")},
+		"public/handler.go":     {Data: []byte("package x")},
+		"public/noext":          {Data: []byte("?")},
+		"elsewhere/ignored.txt": {Data: []byte("x")},
+	}
+	assets, err := ScanStatic(fsys, "public")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, a := range assets {
+		paths = append(paths, a.Path)
+	}
+	if want := []string{"app.css", "img/logo.png", "noext"}; !slices.Equal(paths, want) {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+	css := assets[0]
+	if css.Size != 6 || !strings.HasPrefix(css.ContentType, "text/css") || len(css.ETag) != 18 {
+		t.Errorf("app.css = %+v", css)
+	}
+	if assets[2].ContentType != "application/octet-stream" {
+		t.Errorf("noext content type = %q", assets[2].ContentType)
+	}
+}
+
+func TestScanStaticETagChangesWithContent(t *testing.T) {
+	a, _ := ScanStatic(fstest.MapFS{"f.txt": {Data: []byte("one")}}, ".")
+	b, _ := ScanStatic(fstest.MapFS{"f.txt": {Data: []byte("two")}}, ".")
+	if a[0].ETag == b[0].ETag {
+		t.Fatalf("same ETag %s for different content", a[0].ETag)
+	}
+}
+
+func TestScanStaticMissingRoot(t *testing.T) {
+	_, err := ScanStatic(fstest.MapFS{}, "public")
+	if !errors.Is(err, fs.ErrNotExist) {
+		t.Fatalf("err = %v, want fs.ErrNotExist", err)
+	}
+}
+
+// The same function, on real files.
+func TestScanStaticDirFS(t *testing.T) {
+	dir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(dir, "sub"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, data := range map[string]string{"a.txt": "aa", "sub/b.json": "{}"} {
+		if err := os.WriteFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(data), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	assets, err := ScanStatic(os.DirFS(dir), ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(assets) != 2 || assets[0].Path != "a.txt" || assets[1].Path != "sub/b.json" {
+		t.Fatalf("assets = %+v", assets)
+	}
+}
+
+// And on the files 08_go_embed compiles into the binary.
+func TestScanStaticEmbedFS(t *testing.T) {
+	assets, err := ScanStatic(static.FS, ".")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var paths []string
+	for _, a := range assets {
+		paths = append(paths, a.Path)
+	}
+	if want := []string{"config.json", "index.html"}; !slices.Equal(paths, want) {
+		t.Fatalf("paths = %v, want %v", paths, want)
+	}
+}
+
+// fstest.TestFS checks that an fs.FS implementation follows the io/fs
+// rules. Run it on the filesystems handed to the code under test, so a
+// broken fake cannot make the tests pass.
+func TestFilesystemsAreValid(t *testing.T) {
+	if err := fstest.TestFS(static.FS, "config.json", "index.html"); err != nil {
+		t.Error(err)
+	}
+	mem := fstest.MapFS{"templates/home.html": {Data: []byte("hi")}}
+	sub, err := fs.Sub(mem, "templates")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fstest.TestFS(sub, "home.html"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestTemplates(t *testing.T) {
+	fsys := fstest.MapFS{
+		"layout.html": {Data: []byte(`{{define "layout"}}[{{template "body" .}}]{{end}}`)},
+		"body.html":   {Data: []byte(`{{define "body"}}{{.}}{{end}}`)},
+		"notes.txt":   {Data: []byte(`not a template`)},
+	}
+	tmpl, err := LoadTemplates(fsys, "*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := tmpl.Names(); !slices.Contains(got, "layout") || !slices.Contains(got, "body") || slices.Contains(got, "notes.txt") {
+		t.Errorf("Names = %v", got)
+	}
+
+	var sb strings.Builder
+	if err := tmpl.Render(&sb, "layout", "<b>"); err != nil {
+		t.Fatal(err)
+	}
+	if got, want := sb.String(), "[&lt;b&gt;]"; got != want {
+		t.Errorf("Render = %q, want %q (html/template escapes)", got, want)
+	}
+}
+
+func TestTemplatesErrors(t *testing.T) {
+	if _, err := LoadTemplates(fstest.MapFS{}, "*.html"); err == nil {
+		t.Error("no matching files: want an error")
+	}
+	if _, err := LoadTemplates(fstest.MapFS{"bad.html": {Data: []byte("{{.Oops")}}, "*.html"); err == nil {
+		t.Error("syntax error: want an error")
+	}
+
+	tmpl, err := LoadTemplates(fstest.MapFS{"t.html": {Data: []byte(`ok {{.Missing.Field}}`)}}, "*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Render(&sb, "t.html", struct{ Missing *struct{ Field string } }{}); err == nil {
+		t.Fatal("nil pointer in template: want an error")
+	}
+	if sb.Len() != 0 {
+		t.Errorf("partial output %q written on error", sb.String())
+	}
+}
+
+func TestTemplatesFromEmbedFS(t *testing.T) {
+	tmpl, err := LoadTemplates(static.FS, "*.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var sb strings.Builder
+	if err := tmpl.Render(&sb, "index.html", nil); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(sb.String(), "Embedded Index") {
+		t.Errorf("index.html rendered as %q", sb.String())
+	}
+}
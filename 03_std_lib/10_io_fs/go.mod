@@ -0,0 +1,8 @@
+module golang_roadmap/03_std_lib/10_io_fs
+
+go 1.24.11
+
+require golang_roadmap/03_std_lib/08_go_embed v0.0.0
+
+// The embedded static files live in their own module in this repository.
+replace golang_roadmap/03_std_lib/08_go_embed => ../08_go_embed
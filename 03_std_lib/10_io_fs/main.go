@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"testing/fstest"
+
+	"golang_roadmap/03_std_lib/08_go_embed/static"
+)
+
+// Demonstrates code written against io/fs instead of the os package:
+// - A template loader and a static file scanner that take an fs.FS
+// - The same code run on os.DirFS, on the embed.FS from 08_go_embed and
+//   on an in-memory fstest.MapFS
+// - fs.Sub to root a filesystem at a subdirectory
+
+func main() {
+	fmt.Println("io/fs examples starting...")
+
+	// 1) A directory on disk, through os.DirFS.
+	dir, err := os.MkdirTemp("", "io_fs")
+	if err != nil {
+		log.Fatalf("MkdirTemp: %v", err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"public/app.css":      "body { margin: 0 }\n",
+		"public/img/logo.svg": "<svg/>\n",
+		"public/.DS_Store":    "junk",
+	}
+	for name, body := range files {
+		p := filepath.Join(dir, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			log.Fatalf("MkdirAll: %v", err)
+		}
+		if err := os.WriteFile(p, []byte(body), 0644); err != nil {
+			log.Fatalf("WriteFile: %v", err)
+		}
+	}
+
+	// 2) Files compiled into the binary by 08_go_embed.
+	// 3) A map in memory: no disk at all.
+	mem := fstest.MapFS{
+		"site/about.txt": {Data: []byte("about us\n")},
+		"site/404.html":  {Data: []byte("<h1>not found</h1>\n")},
+	}
+
+	fmt.Println("--- ScanStatic on three filesystems ---")
+	for _, src := range []struct {
+		name string
+		fsys fs.FS
+		root string
+	}{
+		{"os.DirFS", os.DirFS(dir), "public"},
+		{"embed.FS", static.FS, "."},
+		{"fstest.MapFS", mem, "site"},
+	} {
+		assets, err := ScanStatic(src.fsys, src.root)
+		if err != nil {
+			log.Fatalf("ScanStatic: %v", err)
+		}
+		fmt.Printf("%s (root %q):\n", src.name, src.root)
+		for _, a := range assets {
+			fmt.Printf("  %-14s %4d bytes  %-26s %s\n", a.Path, a.Size, a.ContentType, a.ETag)
+		}
+	}
+
+	fmt.Println("--- Templates from an fs.FS ---")
+	tmplFS := fstest.MapFS{
+		"templates/layout.html": {Data: []byte(`{{define "layout"}}<title>{{.Title}}</title>{{template "body" .}}{{end}}`)},
+		"templates/home.html":   {Data: []byte(`{{define "body"}}<p>Hello, {{.Name}}</p>{{end}}`)},
+	}
+	// fs.Sub roots the filesystem at templates/, so the loader's pattern
+	// does not need to know where the templates live.
+	sub, err := fs.Sub(tmplFS, "templates")
+	if err != nil {
+		log.Fatalf("Sub: %v", err)
+	}
+	tmpl, err := LoadTemplates(sub, "*.html")
+	if err != nil {
+		log.Fatalf("LoadTemplates: %v", err)
+	}
+	fmt.Println("templates:", tmpl.Names())
+	if err := tmpl.Render(os.Stdout, "layout", map[string]string{"Title": "Home", "Name": "<gopher>"}); err != nil {
+		log.Fatalf("Render: %v", err)
+	}
+	fmt.Println()
+
+	// The embedded index.html from 08_go_embed is a template too.
+	embedded, err := LoadTemplates(static.FS, "*.html")
+	if err != nil {
+		log.Fatalf("LoadTemplates: %v", err)
+	}
+	fmt.Println("embedded templates:", embedded.Names())
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"path/filepath"
+	"runtime"
+	"testing"
+)
+
+// BenchmarkWordCount counts a 64 MiB file with each strategy. MB/s comes
+// from SetBytes; B/op shows each strategy's buffers. The file is in the
+// page cache after the first run, so this measures counting, not the disk.
+//
+//	go test -run xxx -bench . -benchmem -cpu 1,4
+func BenchmarkWordCount(b *testing.B) {
+	const size = 64 << 20
+	path := filepath.Join(b.TempDir(), "words.txt")
+	if err := generate(path, size); err != nil {
+		b.Fatal(err)
+	}
+	workers := runtime.GOMAXPROCS(0)
+	for _, bm := range []struct {
+		name string
+		run  func() (Counts, error)
+	}{
+		{"stream-4KiB", func() (Counts, error) { return CountStream(path, 4<<10) }},
+		{"stream-64KiB", func() (Counts, error) { return CountStream(path, 64<<10) }},
+		{"stream-1MiB", func() (Counts, error) { return CountStream(path, 1<<20) }},
+		{"chunks-1MiB", func() (Counts, error) { return CountChunks(path, 1<<20, workers) }},
+		{"chunks-8MiB", func() (Counts, error) { return CountChunks(path, 8<<20, workers) }},
+		{"mmap", func() (Counts, error) { return CountMmap(path, workers) }},
+	} {
+		b.Run(bm.name, func(b *testing.B) {
+			b.SetBytes(size)
+			b.ReportAllocs()
+			for b.Loop() {
+				if _, err := bm.run(); err != nil {
+					b.Skip(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import "bytes"
+
+// Counts is what wc prints: lines, words and bytes.
+type Counts struct {
+	Lines, Words, Bytes int64
+}
+
+func (c *Counts) Add(o Counts) {
+	c.Lines += o.Lines
+	c.Words += o.Words
+	c.Bytes += o.Bytes
+}
+
+// isSpace is the ASCII whitespace table wc uses in the C locale.
+var isSpace = [256]bool{' ': true, '\t': true, '\n': true, '\v': true, '\f': true, '\r': true}
+
+// count counts p. prevSpace says whether the byte just before p was
+// whitespace (true at the start of the file).
+//
+// A word is counted where it starts: a non-space byte after a space. That
+// makes counts of adjacent pieces add up exactly, whichever way the file
+// was split, as long as each piece knows the byte before it. A word cut in
+// half by a chunk boundary is counted once, by the chunk it starts in.
+func count(p []byte, prevSpace bool) Counts {
+	c := Counts{Bytes: int64(len(p)), Lines: int64(bytes.Count(p, []byte{'\n'}))}
+	for _, b := range p {
+		space := isSpace[b]
+		if prevSpace && !space {
+			c.Words++
+		}
+		prevSpace = space
+	}
+	return c
+}
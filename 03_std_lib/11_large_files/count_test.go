@@ -0,0 +1,95 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// reference counts with the standard library, for comparison.
+func reference(s string) Counts {
+	return Counts{Lines: int64(strings.Count(s, "\n")), Words: int64(len(strings.Fields(s))), Bytes: int64(len(s))}
+}
+
+var inputs = map[string]string{
+	"empty":            "",
+	"one word":         "gopher",
+	"spaces only":      "  \t \n\n ",
+	"no final newline": "a b\nc",
+	"leading spaces":   "   lead\n  ing",
+	"mixed whitespace": "a\tb\vc\fd\re\n\nf  g",
+	"long line":        strings.Repeat("word ", 5000) + "\nend\n",
+	"long word":        strings.Repeat("x", 10000) + " y",
+}
+
+func writeTemp(t testing.TB, s string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "input.txt")
+	if err := os.WriteFile(path, []byte(s), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+// strategies runs each strategy with tiny buffers and chunks, so that
+// lines, words and even single bytes straddle the boundaries.
+func strategies() map[string]func(string) (Counts, error) {
+	return map[string]func(string) (Counts, error){
+		"stream":        func(p string) (Counts, error) { return CountStream(p, 16) },
+		"chunks 1":      func(p string) (Counts, error) { return CountChunks(p, 1, 3) },
+		"chunks 7":      func(p string) (Counts, error) { return CountChunks(p, 7, 2) },
+		"chunks 4096":   func(p string) (Counts, error) { return CountChunks(p, 4096, 0) },
+		"mmap 1 worker": func(p string) (Counts, error) { return CountMmap(p, 1) },
+		"mmap 5":        func(p string) (Counts, error) { return CountMmap(p, 5) },
+	}
+}
+
+func TestStrategiesMatchReference(t *testing.T) {
+	for name, in := range inputs {
+		path := writeTemp(t, in)
+		want := reference(in)
+		for sname, run := range strategies() {
+			got, err := run(path)
+			if errors.Is(err, errors.ErrUnsupported) {
+				continue // no mmap on this OS
+			}
+			if err != nil {
+				t.Fatalf("%s/%s: %v", name, sname, err)
+			}
+			if got != want {
+				t.Errorf("%s/%s: got %+v, want %+v", name, sname, got, want)
+			}
+		}
+	}
+}
+
+func TestStrategiesOnGeneratedFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "words.txt")
+	if err := generate(path, 1<<20+123); err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := reference(string(data))
+	if want.Bytes != 1<<20+123 {
+		t.Fatalf("generated %d bytes", want.Bytes)
+	}
+	for sname, run := range strategies() {
+		if got, err := run(path); err == nil && got != want {
+			t.Errorf("%s: got %+v, want %+v", sname, got, want)
+		}
+	}
+}
+
+func TestMissingFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nope")
+	for sname, run := range strategies() {
+		if _, err := run(path); !errors.Is(err, os.ErrNotExist) {
+			t.Errorf("%s: err = %v, want ErrNotExist", sname, err)
+		}
+	}
+}
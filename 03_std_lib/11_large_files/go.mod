@@ -0,0 +1,3 @@
+module golang_roadmap/03_std_lib/11_large_files
+
+go 1.24.11
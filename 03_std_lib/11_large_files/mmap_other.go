@@ -0,0 +1,15 @@
+//go:build !(linux || darwin || freebsd)
+
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// mmapFile is not implemented here. Windows has CreateFileMapping and
+// MapViewOfFile (see golang.org/x/exp/mmap for a portable reader).
+func mmapFile(f *os.File, size int64) ([]byte, func() error, error) {
+	return nil, nil, fmt.Errorf("mmap %s: %w", f.Name(), errors.ErrUnsupported)
+}
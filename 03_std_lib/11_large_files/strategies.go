@@ -0,0 +1,160 @@
+package main
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// CountStream reads the file front to back through a bufio.Reader, a line
+// at a time. Memory use is the reader's buffer, whatever the file size.
+// This is the default choice: simple, and usually as fast as the disk.
+func CountStream(path string, bufSize int) (Counts, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Counts{}, err
+	}
+	defer f.Close()
+
+	var total Counts
+	prevSpace := true
+	r := bufio.NewReaderSize(f, bufSize)
+	for {
+		// ReadSlice returns a view into the reader's buffer: no copy, no
+		// allocation. A line longer than the buffer comes back in pieces
+		// with ErrBufferFull, which is fine for counting.
+		line, err := r.ReadSlice('\n')
+		if len(line) > 0 {
+			total.Add(count(line, prevSpace))
+			prevSpace = isSpace[line[len(line)-1]]
+		}
+		switch {
+		case err == nil, errors.Is(err, bufio.ErrBufferFull):
+		case err == io.EOF:
+			return total, nil
+		default:
+			return total, err
+		}
+	}
+}
+
+// CountChunks splits the file into fixed-size chunks and counts them on
+// workers goroutines. Each worker reads its chunks with ReadAt, which is
+// safe to call concurrently on one *os.File. Memory use is workers x
+// chunkSize.
+//
+// It only helps when counting, not reading, is the bottleneck: with the
+// file in the page cache and several cores. On a cold disk, parallel
+// reads may even be slower than one sequential stream.
+func CountChunks(path string, chunkSize int64, workers int) (Counts, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Counts{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return Counts{}, err
+	}
+	size := info.Size()
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+
+	offsets := make(chan int64)
+	go func() {
+		defer close(offsets)
+		for off := int64(0); off < size; off += chunkSize {
+			offsets <- off
+		}
+	}()
+
+	var (
+		mu    sync.Mutex
+		total Counts
+		errs  []error
+		wg    sync.WaitGroup
+	)
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// One extra byte in front: the last byte of the previous chunk,
+			// to know whether this chunk starts mid-word.
+			buf := make([]byte, chunkSize+1)
+			var local Counts
+			for off := range offsets {
+				start := max(off-1, 0)
+				n, err := f.ReadAt(buf[:min(off+chunkSize, size)-start], start)
+				if err != nil && err != io.EOF {
+					mu.Lock()
+					errs = append(errs, fmt.Errorf("read at %d: %w", start, err))
+					mu.Unlock()
+					continue
+				}
+				p, prevSpace := buf[:n], true
+				if off > 0 {
+					p, prevSpace = p[1:], isSpace[p[0]]
+				}
+				local.Add(count(p, prevSpace))
+			}
+			mu.Lock()
+			total.Add(local)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	return total, errors.Join(errs...)
+}
+
+// CountMmap maps the whole file into memory and counts it in parallel
+// slices. There is no read call and no copy: the kernel pages the file in
+// as it is touched. The mapping is address space, not Go heap, so it does
+// not show in the GC's numbers, but the touched pages do count towards the
+// process's resident memory (RSS) until unmapped.
+func CountMmap(path string, workers int) (Counts, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Counts{}, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return Counts{}, err
+	}
+	if info.Size() == 0 {
+		return Counts{}, nil // a zero-length mapping is an error
+	}
+	data, unmap, err := mmapFile(f, info.Size())
+	if err != nil {
+		return Counts{}, err
+	}
+	defer unmap()
+
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	part := (len(data) + workers - 1) / workers
+	results := make([]Counts, workers)
+	var wg sync.WaitGroup
+	for i := range workers {
+		start, end := min(i*part, len(data)), min((i+1)*part, len(data))
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			prevSpace := start == 0 || isSpace[data[start-1]]
+			results[i] = count(data[start:end], prevSpace)
+		}()
+	}
+	wg.Wait()
+
+	var total Counts
+	for _, c := range results {
+		total.Add(c)
+	}
+	return total, nil
+}
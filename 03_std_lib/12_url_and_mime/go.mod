@@ -0,0 +1,3 @@
+module golang_roadmap/03_std_lib/12_url_and_mime
+
+go 1.24.11
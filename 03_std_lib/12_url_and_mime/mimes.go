@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"mime"
+	"net/http"
+	"path"
+	"strings"
+	"unicode"
+)
+
+// ContentType names the media type of a file from its name and its first
+// bytes. The extension is a claim made by whoever named the file;
+// http.DetectContentType looks at the content (the WHATWG sniffing
+// algorithm, at most 512 bytes) but only knows a few dozen formats, and
+// reports text of any kind as text/plain. So: extension when it says
+// something specific, the sniffed type otherwise.
+func ContentType(name string, head []byte) (ct, source string) {
+	if ct := mime.TypeByExtension(path.Ext(name)); ct != "" && ct != "application/octet-stream" {
+		return ct, "extension"
+	}
+	return http.DetectContentType(head), "sniffed"
+}
+
+// CheckUpload accepts an uploaded file only if its content, not just its
+// name, is one of the allowed types. A "photo.png" that is really HTML
+// would otherwise be served back as an image, or worse, rendered.
+func CheckUpload(name string, head []byte, allowed ...string) (string, error) {
+	sniffed, _, err := mime.ParseMediaType(http.DetectContentType(head))
+	if err != nil {
+		return "", err
+	}
+	byExt, _, _ := mime.ParseMediaType(mime.TypeByExtension(path.Ext(name)))
+	for _, a := range allowed {
+		if sniffed != a {
+			continue
+		}
+		if byExt != "" && byExt != sniffed {
+			return "", fmt.Errorf("%s: extension says %s, content is %s", name, byExt, sniffed)
+		}
+		return sniffed, nil
+	}
+	return "", fmt.Errorf("%s: content type %s not allowed", name, sniffed)
+}
+
+// AttachmentHeader builds a Content-Disposition header that makes browsers
+// download the file as filename. mime.FormatMediaType quotes the name and,
+// for non-ASCII names, switches to the RFC 2231 filename* parameter,
+// which percent-encodes UTF-8.
+func AttachmentHeader(filename string) string {
+	return mime.FormatMediaType("attachment", map[string]string{"filename": filename})
+}
+
+// ParseContentDisposition returns the disposition ("attachment",
+// "inline", "form-data") and a filename that is safe to use on disk. The
+// name in the header comes from the other side: it may contain a path
+// ("../../etc/passwd", "C:\\Windows\\x"), control characters or nothing.
+func ParseContentDisposition(header string) (disposition, filename string, err error) {
+	disposition, params, err := mime.ParseMediaType(header)
+	if err != nil {
+		return "", "", fmt.Errorf("content-disposition %q: %w", header, err)
+	}
+	return disposition, safeFilename(params["filename"]), nil
+}
+
+func safeFilename(name string) string {
+	name = strings.ReplaceAll(name, `\`, "/")
+	name = path.Base(name)
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." || name == ".." || name == "/" {
+		return "download"
+	}
+	return name
+}
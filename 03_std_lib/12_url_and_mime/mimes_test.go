@@ -0,0 +1,92 @@
+package main
+
+import (
+	"strings"
+	"testing"
+)
+
+var (
+	pngHead  = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR")
+	jpegHead = []byte("\xff\xd8\xff\xe0\x00\x10JFIF")
+	htmlHead = []byte("<!DOCTYPE html><html><body>")
+)
+
+func TestContentType(t *testing.T) {
+	tests := []struct {
+		name       string
+		head       []byte
+		wantPrefix string
+		wantSource string
+	}{
+		{"logo.png", pngHead, "image/png", "extension"},
+		{"LOGO.PNG", pngHead, "image/png", "extension"},
+		{"page.html", htmlHead, "text/html", "extension"},
+		{"no-extension", pngHead, "image/png", "sniffed"},
+		{"data.bin", jpegHead, "image/jpeg", "sniffed"},
+		{"unknown.zzz", []byte("hello\n"), "text/plain", "sniffed"},
+		{"empty", nil, "text/plain", "sniffed"},
+	}
+	for _, tt := range tests {
+		ct, src := ContentType(tt.name, tt.head)
+		if !strings.HasPrefix(ct, tt.wantPrefix) || src != tt.wantSource {
+			t.Errorf("%s: %s (%s), want %s (%s)", tt.name, ct, src, tt.wantPrefix, tt.wantSource)
+		}
+	}
+}
+
+func TestCheckUpload(t *testing.T) {
+	tests := []struct {
+		name    string
+		head    []byte
+		want    string
+		wantErr string
+	}{
+		{"a.png", pngHead, "image/png", ""},
+		{"a.jpg", jpegHead, "image/jpeg", ""},
+		{"noext", pngHead, "image/png", ""},
+		{"a.png", htmlHead, "", "text/html not allowed"},
+		{"a.jpg", pngHead, "", "extension says image/jpeg"},
+		{"a.png", []byte("GIF89a"), "", "image/gif not allowed"},
+	}
+	for _, tt := range tests {
+		got, err := CheckUpload(tt.name, tt.head, "image/png", "image/jpeg")
+		if tt.wantErr == "" {
+			if err != nil || got != tt.want {
+				t.Errorf("%s: %q, %v; want %q", tt.name, got, err, tt.want)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+			t.Errorf("%s: err = %v, want %q", tt.name, err, tt.wantErr)
+		}
+	}
+}
+
+func TestContentDispositionRoundTrip(t *testing.T) {
+	for _, name := range []string{"report.pdf", "report 2024.pdf", `say "hi".txt`, "résumé.pdf", "日本語.txt"} {
+		d, got, err := ParseContentDisposition(AttachmentHeader(name))
+		if err != nil || d != "attachment" || got != name {
+			t.Errorf("%q: got %s %q, %v", name, d, got, err)
+		}
+	}
+}
+
+func TestParseContentDispositionSanitizes(t *testing.T) {
+	tests := map[string]string{
+		`attachment; filename="../../etc/passwd"`:         "passwd",
+		`attachment; filename="C:\\Users\\bob\\evil.exe"`: "evil.exe",
+		`attachment; filename="/abs/path/x.txt"`:          "x.txt",
+		"attachment; filename=\"a\x01b.txt\"":             "ab.txt",
+		`attachment; filename=".."`:                       "download",
+		`attachment; filename="dir/"`:                     "dir",
+		`attachment`:                                      "download",
+		`inline; filename*=UTF-8''%2E%2E%2F%2E%2E%2Fx.sh`: "x.sh",
+	}
+	for header, want := range tests {
+		_, got, err := ParseContentDisposition(header)
+		if err != nil || got != want {
+			t.Errorf("%s: %q, %v; want %q", header, got, err, want)
+		}
+	}
+	if _, _, err := ParseContentDisposition(`attachment; filename=`); err == nil {
+		t.Error("malformed header: want an error")
+	}
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"strconv"
+)
+
+// SearchQuery is what a search page takes in its query string.
+type SearchQuery struct {
+	Term string
+	Tags []string
+	Page int
+}
+
+// BuildSearchURL adds q to base as a query string. url.Values escapes each
+// key and value, so a term like "c++ & go" arrives intact; string
+// concatenation would turn "+" into a space and "&" into a new parameter.
+// Parameters already on base are kept.
+func BuildSearchURL(base string, q SearchQuery) (string, error) {
+	u, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return "", fmt.Errorf("base %q: want an absolute http(s) URL", base)
+	}
+	v := u.Query()
+	v.Set("q", q.Term)
+	for _, t := range q.Tags {
+		v.Add("tag", t) // repeated keys: ?tag=a&tag=b
+	}
+	if q.Page > 1 {
+		v.Set("page", strconv.Itoa(q.Page))
+	}
+	u.RawQuery = v.Encode() // sorted by key, so the output is deterministic
+	return u.String(), nil
+}
+
+// ParseSearchURL is the server side of BuildSearchURL.
+func ParseSearchURL(raw string) (SearchQuery, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return SearchQuery{}, err
+	}
+	// ParseQuery, unlike u.Query(), reports malformed escapes such as %zz
+	// instead of silently dropping the parameter.
+	v, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return SearchQuery{}, err
+	}
+	q := SearchQuery{Term: v.Get("q"), Tags: v["tag"], Page: 1}
+	if p := v.Get("page"); p != "" {
+		if q.Page, err = strconv.Atoi(p); err != nil || q.Page < 1 {
+			return SearchQuery{}, fmt.Errorf("page %q: want a positive integer", p)
+		}
+	}
+	if q.Term == "" {
+		return SearchQuery{}, errors.New("missing q")
+	}
+	return q, nil
+}
+
+// UserPath builds base/users/{name}/files/{file} from untrusted segments.
+// url.JoinPath treats its arguments as already escaped path text: "/"
+// starts a new segment and ".." removes the one before. So each segment
+// is escaped with url.PathEscape ("/" becomes %2F, a space %20), and "."
+// and "..", which escaping leaves alone, are refused.
+func UserPath(base, name, file string) (string, error) {
+	for _, seg := range []string{name, file} {
+		if seg == "" || seg == "." || seg == ".." {
+			return "", fmt.Errorf("path segment %q not allowed", seg)
+		}
+	}
+	return url.JoinPath(base, "users", url.PathEscape(name), "files", url.PathEscape(file))
+}
+
+// Resolve resolves a link found on page base, as a browser or crawler
+// would: relative paths, "..", absolute paths and protocol-relative URLs.
+func Resolve(base, link string) (string, error) {
+	b, err := url.Parse(base)
+	if err != nil {
+		return "", err
+	}
+	l, err := url.Parse(link)
+	if err != nil {
+		return "", err
+	}
+	return b.ResolveReference(l).String(), nil
+}
@@ -0,0 +1,121 @@
+package main
+
+import (
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestBuildSearchURLRoundTrip(t *testing.T) {
+	tests := []SearchQuery{
+		{Term: "go", Page: 1},
+		{Term: "c++ & go/rust", Tags: []string{"lang", "c++"}, Page: 2},
+		{Term: "100% = a+b?#", Tags: []string{"a b", "é"}, Page: 10},
+		{Term: "  spaces  ", Page: 1},
+	}
+	for _, want := range tests {
+		s, err := BuildSearchURL("https://example.com/search", want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := ParseSearchURL(s)
+		if err != nil {
+			t.Fatalf("%s: %v", s, err)
+		}
+		if got.Term != want.Term || got.Page != want.Page || !slices.Equal(got.Tags, want.Tags) {
+			t.Errorf("%s: got %+v, want %+v", s, got, want)
+		}
+	}
+}
+
+func TestBuildSearchURLKeepsExistingQuery(t *testing.T) {
+	s, err := BuildSearchURL("https://example.com/search?lang=en&q=old", SearchQuery{Term: "new"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "https://example.com/search?lang=en&q=new"; s != want {
+		t.Fatalf("got %s, want %s", s, want)
+	}
+}
+
+func TestBuildSearchURLRejectsBadBase(t *testing.T) {
+	for _, base := range []string{"example.com/search", "/search", "javascript:alert(1)", "https://", "http://[::1"} {
+		if _, err := BuildSearchURL(base, SearchQuery{Term: "x"}); err == nil {
+			t.Errorf("%q: want an error", base)
+		}
+	}
+}
+
+// Concatenating instead of using url.Values loses data: the reason the
+// helper exists.
+func TestConcatenationPitfall(t *testing.T) {
+	term := "c++ & go"
+	u, _ := url.Parse("https://example.com/search?q=" + term)
+	if got := u.Query().Get("q"); got == term {
+		t.Fatalf("concatenation kept %q intact; the pitfall no longer applies", got)
+	}
+	u, _ = url.Parse("https://example.com/search?q=" + url.QueryEscape(term))
+	if got := u.Query().Get("q"); got != term {
+		t.Fatalf("QueryEscape: got %q", got)
+	}
+}
+
+func TestParseSearchURLErrors(t *testing.T) {
+	for _, raw := range []string{
+		"https://example.com/search",             // no q
+		"https://example.com/search?q=go&page=0", // page < 1
+		"https://example.com/search?q=go&page=x", // not a number
+		"https://example.com/search?q=%zz",       // bad escape
+		"https://example.com/search?q=go;page=2", // ; is no longer a separator
+	} {
+		if q, err := ParseSearchURL(raw); err == nil {
+			t.Errorf("%s: got %+v, want an error", raw, q)
+		}
+	}
+}
+
+func TestUserPath(t *testing.T) {
+	tests := []struct {
+		name, file, want string
+	}{
+		{"bob", "a.txt", "https://x.com/api/users/bob/files/a.txt"},
+		{"bob smith", "notes/2024.txt", "https://x.com/api/users/bob%20smith/files/notes%2F2024.txt"},
+		{"a?b#c", "100%.txt", "https://x.com/api/users/a%3Fb%23c/files/100%25.txt"},
+		{"..%2F", "x", "https://x.com/api/users/..%252F/files/x"},
+	}
+	for _, tt := range tests {
+		got, err := UserPath("https://x.com/api/", tt.name, tt.file)
+		if err != nil || got != tt.want {
+			t.Errorf("UserPath(%q, %q) = %s, %v; want %s", tt.name, tt.file, got, err, tt.want)
+		}
+		// The server sees the original segments.
+		u, _ := url.Parse(got)
+		if segs := strings.Split(u.EscapedPath(), "/"); len(segs) != 6 {
+			t.Errorf("%s: %d path segments, want 6", got, len(segs))
+		}
+	}
+	for _, bad := range []string{"", ".", ".."} {
+		if _, err := UserPath("https://x.com/api/", "bob", bad); err == nil {
+			t.Errorf("file %q: want an error", bad)
+		}
+	}
+}
+
+func TestResolve(t *testing.T) {
+	base := "https://example.com/docs/guide/intro.html?x=1"
+	tests := map[string]string{
+		"next.html":              "https://example.com/docs/guide/next.html",
+		"../../../../etc/passwd": "https://example.com/etc/passwd",
+		"/about":                 "https://example.com/about",
+		"//cdn.example.com/x.js": "https://cdn.example.com/x.js",
+		"?page=3":                "https://example.com/docs/guide/intro.html?page=3",
+		"#top":                   "https://example.com/docs/guide/intro.html?x=1#top",
+		"http://other.org/":      "http://other.org/",
+	}
+	for link, want := range tests {
+		if got, err := Resolve(base, link); err != nil || got != want {
+			t.Errorf("Resolve(%q) = %s, %v; want %s", link, got, err, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Duration is a time.Duration that travels as "1m30s" instead of
+// 90000000000 nanoseconds. It also accepts a bare number of seconds, which
+// older clients send.
+type Duration time.Duration
+
+// MarshalJSON has a value receiver, so both Duration and *Duration
+// marshal this way. With a pointer receiver, a Duration stored in a map
+// or a non-addressable struct would fall back to the default encoding.
+func (d Duration) MarshalJSON() ([]byte, error) {
+	return json.Marshal(time.Duration(d).String())
+}
+
+// UnmarshalJSON needs a pointer receiver to change the value.
+func (d *Duration) UnmarshalJSON(b []byte) error {
+	var v any
+	if err := json.Unmarshal(b, &v); err != nil {
+		return err
+	}
+	switch v := v.(type) {
+	case string:
+		parsed, err := time.ParseDuration(v)
+		if err != nil {
+			return fmt.Errorf("duration: %w", err)
+		}
+		*d = Duration(parsed)
+	case float64:
+		*d = Duration(v * float64(time.Second))
+	default:
+		return fmt.Errorf("duration: want a string or a number of seconds, got %s", b)
+	}
+	return nil
+}
+
+// Status is an enum that is a number in Go and a name on the wire.
+type Status int
+
+const (
+	StatusPending Status = iota + 1
+	StatusActive
+	StatusClosed
+)
+
+var statusNames = map[Status]string{StatusPending: "pending", StatusActive: "active", StatusClosed: "closed"}
+
+// MarshalText rather than MarshalJSON: encoding/json quotes it, and the
+// same method serves map keys, YAML, XML and flags.
+func (s Status) MarshalText() ([]byte, error) {
+	name, ok := statusNames[s]
+	if !ok {
+		return nil, fmt.Errorf("status: invalid value %d", int(s))
+	}
+	return []byte(name), nil
+}
+
+// UnmarshalText rejects unknown names, so an invalid status never gets
+// past decoding.
+func (s *Status) UnmarshalText(b []byte) error {
+	for v, name := range statusNames {
+		if name == string(b) {
+			*s = v
+			return nil
+		}
+	}
+	return fmt.Errorf("status: unknown value %q", b)
+}
+
+// Task shows a MarshalJSON that adds a computed field. Calling
+// json.Marshal(t) inside it would call MarshalJSON again, forever; the
+// local type alias has Task's fields but none of its methods.
+type Task struct {
+	ID      int       `json:"id"`
+	Status  Status    `json:"status"`
+	Timeout Duration  `json:"timeout"`
+	Started time.Time `json:"started"`
+}
+
+func (t Task) MarshalJSON() ([]byte, error) {
+	type alias Task
+	return json.Marshal(struct {
+		alias
+		Deadline time.Time `json:"deadline"`
+	}{alias(t), t.Started.Add(time.Duration(t.Timeout))})
+}
+
+// UnmarshalJSON uses the same trick to decode, then checks the result.
+// The extra deadline field is ignored: it is derived, not stored.
+func (t *Task) UnmarshalJSON(b []byte) error {
+	type alias Task
+	var a alias
+	if err := json.Unmarshal(b, &a); err != nil {
+		return err
+	}
+	if a.Status == 0 {
+		return errors.New("task: status is required")
+	}
+	*t = Task(a)
+	return nil
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDurationJSON(t *testing.T) {
+	b, err := json.Marshal(map[string]Duration{"d": Duration(90 * time.Second)})
+	if err != nil || string(b) != `{"d":"1m30s"}` {
+		t.Fatalf("Marshal = %s, %v", b, err)
+	}
+	for in, want := range map[string]time.Duration{
+		`"1m30s"`: 90 * time.Second,
+		`"250ms"`: 250 * time.Millisecond,
+		`30`:      30 * time.Second,
+		`0.5`:     500 * time.Millisecond,
+	} {
+		var d Duration
+		if err := json.Unmarshal([]byte(in), &d); err != nil || time.Duration(d) != want {
+			t.Errorf("Unmarshal(%s) = %v, %v; want %v", in, time.Duration(d), err, want)
+		}
+	}
+	for _, in := range []string{`"soon"`, `true`, `[1]`, `null x`} {
+		var d Duration
+		if err := json.Unmarshal([]byte(in), &d); err == nil {
+			t.Errorf("Unmarshal(%s): want an error", in)
+		}
+	}
+}
+
+func TestStatusText(t *testing.T) {
+	for s, name := range statusNames {
+		b, err := json.Marshal(s)
+		if err != nil || string(b) != `"`+name+`"` {
+			t.Errorf("Marshal(%d) = %s, %v", s, b, err)
+		}
+		var back Status
+		if err := json.Unmarshal(b, &back); err != nil || back != s {
+			t.Errorf("round trip of %s = %d, %v", name, back, err)
+		}
+	}
+	if _, err := json.Marshal(Status(42)); err == nil {
+		t.Error("Marshal(Status(42)): want an error")
+	}
+	var s Status
+	if err := json.Unmarshal([]byte(`"Active"`), &s); err == nil {
+		t.Error(`Unmarshal("Active"): names are case-sensitive; want an error`)
+	}
+}
+
+func TestTaskJSON(t *testing.T) {
+	start := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	task := Task{ID: 1, Status: StatusPending, Timeout: Duration(time.Hour), Started: start}
+	b, err := json.Marshal(task)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"deadline":"2024-05-01T10:00:00Z"`) || !strings.Contains(string(b), `"timeout":"1h0m0s"`) {
+		t.Errorf("Marshal = %s; want the computed deadline and a readable timeout", b)
+	}
+	var back Task
+	if err := json.Unmarshal(b, &back); err != nil || back != task {
+		t.Errorf("round trip = %+v, %v; want %+v", back, err, task)
+	}
+	if err := json.Unmarshal([]byte(`{"id":1}`), &back); err == nil || !strings.Contains(err.Error(), "status is required") {
+		t.Errorf("missing status: err = %v", err)
+	}
+}
+
+func TestOmitEmpty(t *testing.T) {
+	b, _ := json.Marshal(Profile{Name: "Ada"})
+	got := string(b)
+	for _, gone := range []string{"nickname", "age", "admin", "tags", "deleted_at"} {
+		if strings.Contains(got, gone) {
+			t.Errorf("%s: %q should be omitted", got, gone)
+		}
+	}
+	if !strings.Contains(got, "created_at") {
+		t.Errorf("%s: omitempty does not drop a zero time.Time; want created_at present", got)
+	}
+}
+
+func TestProfilePatch(t *testing.T) {
+	p := Profile{Nickname: "ada", Age: 36, Admin: true}
+	for _, tt := range []struct {
+		patch string
+		want  Profile
+	}{
+		{`{}`, Profile{Nickname: "ada", Age: 36, Admin: true}},
+		{`{"age":0}`, Profile{Nickname: "ada", Age: 0, Admin: true}},
+		{`{"admin":false,"nickname":""}`, Profile{}},
+		{`{"age":null}`, Profile{}}, // null is "not sent"
+	} {
+		var pp ProfilePatch
+		if err := json.Unmarshal([]byte(tt.patch), &pp); err != nil {
+			t.Fatal(err)
+		}
+		pp.Apply(&p)
+		if p.Nickname != tt.want.Nickname || p.Age != tt.want.Age || p.Admin != tt.want.Admin {
+			t.Errorf("after %s: %+v; want %+v", tt.patch, p, tt.want)
+		}
+	}
+	b, _ := json.Marshal(ProfilePatch{Age: new(int)})
+	if string(b) != `{"age":0}` {
+		t.Errorf("Marshal(&0) = %s; a pointer to zero is not empty", b)
+	}
+}
@@ -0,0 +1,87 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// DecodeStrict decodes exactly one JSON value from r into v. Unlike
+// json.Unmarshal it fails on fields v does not have, so a typo such as
+// "emial" is an error instead of a silently empty Email, and on anything
+// after the value, such as a second object pasted in by mistake.
+func DecodeStrict(r io.Reader, v any) error {
+	dec := json.NewDecoder(r)
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	// Decode stops after one value; it does not look at what follows.
+	if _, err := dec.Token(); !errors.Is(err, io.EOF) {
+		return errors.New("json: unexpected data after the top-level value")
+	}
+	return nil
+}
+
+// StreamArray calls fn for each element of the JSON array in r, holding
+// one element in memory at a time instead of the whole array. It stops at
+// the first error from decoding or from fn.
+func StreamArray[T any](r io.Reader, fn func(T) error) error {
+	return decodeArray(json.NewDecoder(r), "", fn)
+}
+
+// StreamField is StreamArray for an array under a top-level key, as in
+// {"total": 2, "items": [...]}, the usual shape of a paginated API
+// response. Other keys are skipped without building Go values for them.
+func StreamField[T any](r io.Reader, key string, fn func(T) error) error {
+	dec := json.NewDecoder(r)
+	if err := expectDelim(dec, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if tok != key {
+			// Decoding into a RawMessage skips a value of any shape.
+			var skip json.RawMessage
+			if err := dec.Decode(&skip); err != nil {
+				return err
+			}
+			continue
+		}
+		return decodeArray(dec, key, fn)
+	}
+	return fmt.Errorf("json: no %q field", key)
+}
+
+// decodeArray reads an array from dec one element at a time. name prefixes
+// element errors, as in items[3].
+func decodeArray[T any](dec *json.Decoder, name string, fn func(T) error) error {
+	if err := expectDelim(dec, '['); err != nil {
+		return err
+	}
+	for i := 0; dec.More(); i++ {
+		var v T
+		if err := dec.Decode(&v); err != nil {
+			return fmt.Errorf("%s[%d]: %w", name, i, err)
+		}
+		if err := fn(v); err != nil {
+			return err
+		}
+	}
+	return expectDelim(dec, ']')
+}
+
+func expectDelim(dec *json.Decoder, want json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != want {
+		return fmt.Errorf("json: want %v, got %v", want, tok)
+	}
+	return nil
+}
@@ -0,0 +1,116 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestDecodeStrict(t *testing.T) {
+	for in, wantErr := range map[string]string{
+		`{"name":"Ada","age":36}`:       "",
+		` {"name":"Ada"}` + "\n\n":      "",
+		`{"name":"Ada","emial":"x"}`:    `unknown field "emial"`,
+		`{"name":"Ada"} {"name":"Bob"}`: "unexpected data",
+		`{"name":"Ada"},`:               "unexpected data",
+		`{"name":"Ada","age":"thirty"}`: "cannot unmarshal string",
+		``:                              "EOF",
+	} {
+		var p Profile
+		err := DecodeStrict(strings.NewReader(in), &p)
+		if wantErr == "" {
+			if err != nil || p.Name != "Ada" {
+				t.Errorf("DecodeStrict(%q) = %+v, %v", in, p, err)
+			}
+		} else if err == nil || !strings.Contains(err.Error(), wantErr) {
+			t.Errorf("DecodeStrict(%q) err = %v; want %q", in, err, wantErr)
+		}
+	}
+}
+
+// countingReader records how far the decoder has read.
+type countingReader struct {
+	r io.Reader
+	n int
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+	n, err := c.r.Read(p)
+	c.n += n
+	return n, err
+}
+
+func TestStreamArray(t *testing.T) {
+	var got []int
+	err := StreamArray(strings.NewReader(` [1, 2,3] `), func(v int) error { got = append(got, v); return nil })
+	if err != nil || !slices.Equal(got, []int{1, 2, 3}) {
+		t.Errorf("got %v, %v", got, err)
+	}
+
+	for in, want := range map[string]string{
+		`{"a":1}`:  "want [",
+		`[1, "x"]`: "[1]: json: cannot unmarshal string",
+		`[1, 2`:    "unexpected end",
+		``:         "EOF",
+	} {
+		err := StreamArray(strings.NewReader(in), func(int) error { return nil })
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("StreamArray(%q) err = %v; want %q", in, err, want)
+		}
+	}
+}
+
+func TestStreamArray_StopsEarly(t *testing.T) {
+	// A big array, read only as far as needed.
+	var b strings.Builder
+	b.WriteString("[")
+	for i := range 100_000 {
+		if i > 0 {
+			b.WriteString(",")
+		}
+		fmt.Fprintf(&b, `{"id":%d,"email":"user%d@example.com"}`, i, i)
+	}
+	b.WriteString("]")
+	r := &countingReader{r: strings.NewReader(b.String())}
+
+	stop := errors.New("found")
+	err := StreamArray(r, func(u UserCreated) error {
+		if u.ID == 10 {
+			return stop
+		}
+		return nil
+	})
+	if !errors.Is(err, stop) {
+		t.Fatalf("err = %v; want fn's error", err)
+	}
+	if r.n > 64<<10 {
+		t.Errorf("read %d of %d bytes to reach element 10; want the rest left unread", r.n, b.Len())
+	}
+}
+
+func TestStreamField(t *testing.T) {
+	in := `{"total":2,"meta":{"items":[9]},"items":[{"order_id":"A"},{"order_id":"B"}],"next":null}`
+	var ids []string
+	err := StreamField(strings.NewReader(in), "items", func(o OrderPlaced) error {
+		ids = append(ids, o.OrderID)
+		return nil
+	})
+	if err != nil || !slices.Equal(ids, []string{"A", "B"}) {
+		t.Errorf("got %v, %v; want the top-level items only", ids, err)
+	}
+
+	for in, want := range map[string]string{
+		`{"total":0}`:                `no "items" field`,
+		`{"items":{}}`:               "json: want [, got {",
+		`{"items":[{"order_id":1}]}`: "items[0]: json: cannot unmarshal number",
+		`[]`:                         "want {",
+	} {
+		err := StreamField(strings.NewReader(in), "items", func(OrderPlaced) error { return nil })
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("StreamField(%s) err = %v; want %q", in, err, want)
+		}
+	}
+}
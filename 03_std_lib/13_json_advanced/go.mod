@@ -0,0 +1,3 @@
+module golang_roadmap/03_std_lib/13_json_advanced
+
+go 1.24.11
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+)
+
+// Demonstrates encoding/json beyond Marshal and Unmarshal (see
+// 01_fileio_and_json for the basics):
+// - Custom MarshalJSON/UnmarshalJSON and MarshalText/UnmarshalText
+// - The type alias trick for adding fields without infinite recursion
+// - json.RawMessage to decode a payload once its type is known
+// - Polymorphic payloads: an envelope with a type field, and a slice of
+//   interface values with a discriminator in each element
+// - DisallowUnknownFields and rejecting trailing data
+// - Streaming a large array element by element with Decoder.Token
+// - omitempty vs omitzero vs pointer fields
+
+func main() {
+	fmt.Println("json advanced examples starting...")
+
+	// 1) Custom marshalling
+	fmt.Println("--- MarshalJSON / MarshalText ---")
+	task := Task{ID: 1, Status: StatusActive, Timeout: Duration(90 * time.Second),
+		Started: time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)}
+	b, err := json.Marshal(task)
+	if err != nil {
+		log.Fatalf("Marshal: %v", err)
+	}
+	fmt.Println(string(b))
+	var back Task
+	err = json.Unmarshal([]byte(`{"id":2,"status":"closed","timeout":30}`), &back)
+	fmt.Printf("timeout as seconds: %+v err=%v\n", back, err)
+	err = json.Unmarshal([]byte(`{"id":3,"status":"done"}`), &back)
+	fmt.Println("unknown status:", err)
+	b, _ = json.Marshal(map[Status]int{StatusPending: 3, StatusActive: 5})
+	fmt.Println("MarshalText also names map keys:", string(b))
+
+	// 2) Envelopes and json.RawMessage
+	fmt.Println("--- type discriminator + RawMessage ---")
+	for _, msg := range []string{
+		`{"type":"user.created","data":{"id":7,"email":"ada@example.com"}}`,
+		`{"type":"order.placed","data":{"order_id":"A-1","items":["book","pen"],"total":12.5}}`,
+		`{"type":"user.deleted","data":{"id":7}}`,
+	} {
+		ev, err := DecodeEvent([]byte(msg))
+		fmt.Printf("%T %+v err=%v\n", ev, ev, err)
+	}
+
+	// 3) A slice of interface values
+	fmt.Println("--- []Shape ---")
+	d := Drawing{Shapes: []Shape{Circle{R: 1}, Rect{W: 2, H: 3}}}
+	b, _ = json.Marshal(d)
+	fmt.Println(string(b))
+	var d2 Drawing
+	if err := json.Unmarshal(b, &d2); err != nil {
+		log.Fatalf("Unmarshal Drawing: %v", err)
+	}
+	for _, s := range d2.Shapes {
+		fmt.Printf("  %-6s %#v area=%.2f\n", s.Kind(), s, s.Area())
+	}
+
+	// 4) Strict decoding
+	fmt.Println("--- DisallowUnknownFields ---")
+	typo := `{"name":"Ada","nickame":"ada"}`
+	var p Profile
+	fmt.Printf("json.Unmarshal: err=%v nickname=%q (the typo is silently lost)\n", json.Unmarshal([]byte(typo), &p), p.Nickname)
+	fmt.Println("DecodeStrict:  ", DecodeStrict(strings.NewReader(typo), &p))
+	fmt.Println("trailing data: ", DecodeStrict(strings.NewReader(`{"name":"Ada"} {"name":"Bob"}`), &p))
+
+	// 5) Streaming
+	fmt.Println("--- streaming with Decoder.Token ---")
+	var sum float64
+	n := 0
+	err = StreamField(strings.NewReader(`{"total":3,"meta":{"page":1},"items":[{"total":1},{"total":2.5},{"total":4}]}`),
+		"items", func(o OrderPlaced) error { sum += o.Total; n++; return nil })
+	fmt.Printf("%d orders, total %.2f, err=%v\n", n, sum, err)
+
+	// 6) omitempty and pointers
+	fmt.Println("--- omitempty vs omitzero vs pointers ---")
+	b, _ = json.Marshal(Profile{Name: "Ada"})
+	fmt.Println(string(b))
+	prof := Profile{Name: "Ada", Nickname: "ada", Age: 36, Admin: true}
+	for _, patch := range []string{`{}`, `{"age":0}`, `{"admin":false,"nickname":""}`} {
+		var pp ProfilePatch
+		if err := DecodeStrict(strings.NewReader(patch), &pp); err != nil {
+			log.Fatalf("patch: %v", err)
+		}
+		pp.Apply(&prof)
+		fmt.Printf("after %-30s nickname=%q age=%d admin=%v\n", patch, prof.Nickname, prof.Age, prof.Admin)
+	}
+}
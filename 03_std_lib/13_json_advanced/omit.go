@@ -0,0 +1,38 @@
+package main
+
+import "time"
+
+// Profile shows what omitempty drops: false, 0, "", nil pointers, and
+// empty slices and maps. A struct is never "empty", so a zero time.Time
+// is still written out; omitzero (Go 1.24) drops it.
+type Profile struct {
+	Name      string    `json:"name"`
+	Nickname  string    `json:"nickname,omitempty"`
+	Age       int       `json:"age,omitempty"`   // 0 disappears too
+	Admin     bool      `json:"admin,omitempty"` // so does false
+	Tags      []string  `json:"tags,omitempty"`
+	CreatedAt time.Time `json:"created_at,omitempty"` // never omitted
+	DeletedAt time.Time `json:"deleted_at,omitzero"`
+}
+
+// ProfilePatch is a partial update. A pointer tells "not sent" (nil) from
+// "sent as zero" (&0), which a plain int cannot: {"age": 0} sets the age
+// to 0, {} leaves it alone.
+type ProfilePatch struct {
+	Nickname *string `json:"nickname,omitempty"`
+	Age      *int    `json:"age,omitempty"`
+	Admin    *bool   `json:"admin,omitempty"`
+}
+
+// Apply changes only the fields the patch carries.
+func (p ProfilePatch) Apply(to *Profile) {
+	if p.Nickname != nil {
+		to.Nickname = *p.Nickname
+	}
+	if p.Age != nil {
+		to.Age = *p.Age
+	}
+	if p.Admin != nil {
+		to.Admin = *p.Admin
+	}
+}
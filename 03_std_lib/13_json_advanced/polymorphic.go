@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+)
+
+// Event is a polymorphic payload: "type" says what "data" holds. Data
+// stays a json.RawMessage, undecoded bytes, until the type is known.
+//
+//	{"type": "user.created", "data": {"id": 7, "email": "a@example.com"}}
+type Event struct {
+	Type string          `json:"type"`
+	Data json.RawMessage `json:"data"`
+}
+
+type UserCreated struct {
+	ID    int    `json:"id"`
+	Email string `json:"email"`
+}
+
+type OrderPlaced struct {
+	OrderID string   `json:"order_id"`
+	Items   []string `json:"items"`
+	Total   float64  `json:"total"`
+}
+
+// eventTypes maps each discriminator to a constructor for its payload.
+var eventTypes = map[string]func() any{
+	"user.created": func() any { return new(UserCreated) },
+	"order.placed": func() any { return new(OrderPlaced) },
+}
+
+// DecodeEvent decodes the envelope, then the payload into the type the
+// discriminator names. It returns a *UserCreated or an *OrderPlaced.
+func DecodeEvent(b []byte) (any, error) {
+	var e Event
+	if err := json.Unmarshal(b, &e); err != nil {
+		return nil, err
+	}
+	newPayload, ok := eventTypes[e.Type]
+	if !ok {
+		return nil, fmt.Errorf("event: unknown type %q", e.Type)
+	}
+	payload := newPayload()
+	if err := json.Unmarshal(e.Data, payload); err != nil {
+		return nil, fmt.Errorf("event %s: %w", e.Type, err)
+	}
+	return payload, nil
+}
+
+// Shape is the other common form: the discriminator sits beside the
+// fields, {"kind": "circle", "r": 2}, and a slice holds mixed shapes.
+type Shape interface {
+	Kind() string
+	Area() float64
+}
+
+type Circle struct {
+	R float64 `json:"r"`
+}
+
+type Rect struct {
+	W float64 `json:"w"`
+	H float64 `json:"h"`
+}
+
+func (Circle) Kind() string    { return "circle" }
+func (c Circle) Area() float64 { return math.Pi * c.R * c.R }
+func (Rect) Kind() string      { return "rect" }
+func (r Rect) Area() float64   { return r.W * r.H }
+
+// Drawing holds shapes of any kind. encoding/json cannot decode into an
+// interface type it knows nothing about, so Drawing does it by hand.
+type Drawing struct {
+	Shapes []Shape
+}
+
+func (d Drawing) MarshalJSON() ([]byte, error) {
+	out := make([]json.RawMessage, len(d.Shapes))
+	for i, s := range d.Shapes {
+		b, err := marshalShape(s)
+		if err != nil {
+			return nil, err
+		}
+		out[i] = b
+	}
+	return json.Marshal(map[string]any{"shapes": out})
+}
+
+// marshalShape adds the discriminator to the shape's own fields.
+func marshalShape(s Shape) ([]byte, error) {
+	fields, err := json.Marshal(s)
+	if err != nil {
+		return nil, err
+	}
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(fields, &m); err != nil {
+		return nil, err
+	}
+	m["kind"], _ = json.Marshal(s.Kind())
+	return json.Marshal(m)
+}
+
+// shapeKinds maps each discriminator to a decoder for its type.
+var shapeKinds = map[string]func([]byte) (Shape, error){
+	"circle": decodeShape[Circle],
+	"rect":   decodeShape[Rect],
+}
+
+func decodeShape[T Shape](b []byte) (Shape, error) {
+	var v T
+	err := json.Unmarshal(b, &v)
+	return v, err
+}
+
+func (d *Drawing) UnmarshalJSON(b []byte) error {
+	var raw struct {
+		Shapes []json.RawMessage `json:"shapes"`
+	}
+	if err := json.Unmarshal(b, &raw); err != nil {
+		return err
+	}
+	d.Shapes = make([]Shape, 0, len(raw.Shapes))
+	for i, r := range raw.Shapes {
+		// First pass: only the discriminator.
+		var probe struct {
+			Kind string `json:"kind"`
+		}
+		if err := json.Unmarshal(r, &probe); err != nil {
+			return fmt.Errorf("shapes[%d]: %w", i, err)
+		}
+		decode, ok := shapeKinds[probe.Kind]
+		if !ok {
+			return fmt.Errorf("shapes[%d]: unknown kind %q", i, probe.Kind)
+		}
+		s, err := decode(r)
+		if err != nil {
+			return fmt.Errorf("shapes[%d]: %w", i, err)
+		}
+		d.Shapes = append(d.Shapes, s)
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestDecodeEvent(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    any
+		wantErr string
+	}{
+		{`{"type":"user.created","data":{"id":7,"email":"a@example.com"}}`, &UserCreated{ID: 7, Email: "a@example.com"}, ""},
+		{`{"data":{"order_id":"A-1","total":2},"type":"order.placed"}`, &OrderPlaced{OrderID: "A-1", Total: 2}, ""}, // type after data
+		{`{"type":"user.deleted","data":{}}`, nil, `unknown type "user.deleted"`},
+		{`{"data":{}}`, nil, `unknown type ""`},
+		{`{"type":"user.created","data":{"id":"seven"}}`, nil, "event user.created: json: cannot unmarshal string"},
+		{`{"type":`, nil, "unexpected end"},
+	}
+	for _, tt := range tests {
+		got, err := DecodeEvent([]byte(tt.in))
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("DecodeEvent(%s) err = %v; want %q", tt.in, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("DecodeEvent(%s) = %#v, %v; want %#v", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestRawMessageKeepsBytes(t *testing.T) {
+	in := `{"type":"x","data":{"b": 1, "a": [1,2]}}`
+	var e Event
+	if err := json.Unmarshal([]byte(in), &e); err != nil {
+		t.Fatal(err)
+	}
+	if string(e.Data) != `{"b": 1, "a": [1,2]}` {
+		t.Errorf("Data = %s; want the original bytes, spacing and key order included", e.Data)
+	}
+}
+
+func TestDrawingRoundTrip(t *testing.T) {
+	d := Drawing{Shapes: []Shape{Circle{R: 1}, Rect{W: 2, H: 3}, Circle{R: 0.5}}}
+	b, err := json.Marshal(d)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var back Drawing
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(back, d) {
+		t.Errorf("round trip via %s = %#v", b, back)
+	}
+}
+
+func TestDrawingErrors(t *testing.T) {
+	for in, want := range map[string]string{
+		`{"shapes":[{"kind":"circle","r":1},{"kind":"hexagon"}]}`: `shapes[1]: unknown kind "hexagon"`,
+		`{"shapes":[{"r":1}]}`:                    `shapes[0]: unknown kind ""`,
+		`{"shapes":[{"kind":"rect","w":"wide"}]}`: `shapes[0]: json: cannot unmarshal string`,
+		`{"shapes":[42]}`:                         `shapes[0]: json: cannot unmarshal number`,
+	} {
+		var d Drawing
+		if err := json.Unmarshal([]byte(in), &d); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("Unmarshal(%s) err = %v; want %q", in, err, want)
+		}
+	}
+}
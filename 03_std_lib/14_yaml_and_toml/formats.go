@@ -0,0 +1,170 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"golang_roadmap/11_configuration/01_config_loader/config"
+)
+
+// File is the on-disk shape of config.Config. One struct serves all three
+// formats; each decoder reads its own tag.
+type File struct {
+	Server FileServer `json:"server" yaml:"server" toml:"server"`
+	Log    FileLog    `json:"log" yaml:"log" toml:"log"`
+	Auth   FileAuth   `json:"auth" yaml:"auth" toml:"auth"`
+	CORS   FileCORS   `json:"cors" yaml:"cors" toml:"cors"`
+	Jobs   FileJobs   `json:"jobs" yaml:"jobs" toml:"jobs"`
+}
+
+type FileServer struct {
+	Addr            string   `json:"addr" yaml:"addr" toml:"addr"`
+	ReadTimeout     Duration `json:"read_timeout" yaml:"read_timeout" toml:"read_timeout"`
+	WriteTimeout    Duration `json:"write_timeout" yaml:"write_timeout" toml:"write_timeout"`
+	IdleTimeout     Duration `json:"idle_timeout" yaml:"idle_timeout" toml:"idle_timeout"`
+	ShutdownTimeout Duration `json:"shutdown_timeout" yaml:"shutdown_timeout" toml:"shutdown_timeout"`
+	DebugAddr       string   `json:"debug_addr" yaml:"debug_addr" toml:"debug_addr"`
+}
+
+type FileLog struct {
+	Level  string `json:"level" yaml:"level" toml:"level"`
+	Format string `json:"format" yaml:"format" toml:"format"`
+}
+
+type FileAuth struct {
+	APIKey config.Secret `json:"api_key" yaml:"api_key" toml:"api_key"`
+}
+
+type FileCORS struct {
+	AllowedOrigins string `json:"allowed_origins" yaml:"allowed_origins" toml:"allowed_origins"`
+}
+
+type FileJobs struct {
+	Path    string `json:"path" yaml:"path" toml:"path"`
+	Workers int    `json:"workers" yaml:"workers" toml:"workers"`
+}
+
+// Duration reads "15s" in every format. time.Duration itself is an int64
+// of nanoseconds: JSON wants 15000000000, while yaml.v3 and toml accept
+// "15s". A TextUnmarshaler is the one hook all three decoders call.
+type Duration time.Duration
+
+func (d Duration) MarshalText() ([]byte, error) { return []byte(time.Duration(d).String()), nil }
+
+func (d *Duration) UnmarshalText(b []byte) error {
+	v, err := time.ParseDuration(string(b))
+	*d = Duration(v)
+	return err
+}
+
+// fileFrom fills a File from c. Decoding into it overwrites only the keys
+// the document has, so the rest keep c's values.
+func fileFrom(c config.Config) File {
+	return File{
+		Server: FileServer{
+			Addr:            c.Server.Addr,
+			ReadTimeout:     Duration(c.Server.ReadTimeout),
+			WriteTimeout:    Duration(c.Server.WriteTimeout),
+			IdleTimeout:     Duration(c.Server.IdleTimeout),
+			ShutdownTimeout: Duration(c.Server.ShutdownTimeout),
+			DebugAddr:       c.Server.DebugAddr,
+		},
+		Log:  FileLog{Level: c.Log.Level, Format: c.Log.Format},
+		Auth: FileAuth{APIKey: c.Auth.APIKey},
+		CORS: FileCORS{AllowedOrigins: c.CORS.AllowedOrigins},
+		Jobs: FileJobs{Path: c.Jobs.Path, Workers: c.Jobs.Workers},
+	}
+}
+
+// Config converts f to the shared config.Config.
+func (f File) Config() config.Config {
+	return config.Config{
+		Server: config.ServerConfig{
+			Addr:            f.Server.Addr,
+			ReadTimeout:     time.Duration(f.Server.ReadTimeout),
+			WriteTimeout:    time.Duration(f.Server.WriteTimeout),
+			IdleTimeout:     time.Duration(f.Server.IdleTimeout),
+			ShutdownTimeout: time.Duration(f.Server.ShutdownTimeout),
+			DebugAddr:       f.Server.DebugAddr,
+		},
+		Log:  config.LogConfig{Level: f.Log.Level, Format: f.Log.Format},
+		Auth: config.AuthConfig{APIKey: f.Auth.APIKey},
+		CORS: config.CORSConfig{AllowedOrigins: f.CORS.AllowedOrigins},
+		Jobs: config.JobsConfig{Path: f.Jobs.Path, Workers: f.Jobs.Workers},
+	}
+}
+
+// Format is a decoder for one file format.
+type Format func(data []byte, f *File) error
+
+// Formats maps file extensions to decoders. All of them are strict: a key
+// File does not have is an error, not silently ignored.
+var Formats = map[string]Format{
+	".json": decodeJSON,
+	".yaml": decodeYAML,
+	".yml":  decodeYAML,
+	".toml": decodeTOML,
+}
+
+// Parse decodes data in the format of ext on top of config.Default and
+// validates the result.
+func Parse(ext string, data []byte) (config.Config, error) {
+	decode, ok := Formats[ext]
+	if !ok {
+		return config.Config{}, fmt.Errorf("unsupported format %q", ext)
+	}
+	f := fileFrom(config.Default())
+	if err := decode(data, &f); err != nil {
+		return config.Config{}, err
+	}
+	c := f.Config()
+	if err := c.Validate(); err != nil {
+		return config.Config{}, err
+	}
+	return c, nil
+}
+
+func decodeJSON(data []byte, f *File) error {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	return dec.Decode(f)
+}
+
+// decodeYAML uses a Decoder for KnownFields, yaml.v3's strict mode. Its
+// errors carry line numbers: "line 3: field read_timout not found".
+func decodeYAML(data []byte, f *File) error {
+	dec := yaml.NewDecoder(bytes.NewReader(data))
+	dec.KnownFields(true)
+	err := dec.Decode(f)
+	if errors.Is(err, io.EOF) {
+		return nil // an empty document changes nothing
+	}
+	return err
+}
+
+// decodeTOML has no strict switch. Instead the metadata lists the keys
+// nothing was decoded into.
+func decodeTOML(data []byte, f *File) error {
+	md, err := toml.NewDecoder(bytes.NewReader(data)).Decode(f)
+	if err != nil {
+		return err
+	}
+	if und := md.Undecoded(); len(und) > 0 {
+		keys := make([]string, len(und))
+		for i, k := range und {
+			keys[i] = k.String()
+		}
+		slices.Sort(keys)
+		return fmt.Errorf("toml: unknown keys: %s", strings.Join(keys, ", "))
+	}
+	return nil
+}
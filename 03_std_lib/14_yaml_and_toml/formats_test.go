@@ -0,0 +1,83 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/11_configuration/01_config_loader/config"
+)
+
+func TestParse_SameConfigInEveryFormat(t *testing.T) {
+	want := config.Default()
+	want.Server.Addr = ":9090"
+	want.Server.ReadTimeout = 5 * time.Second
+	want.Server.DebugAddr = "127.0.0.1:6060"
+	want.Log = config.LogConfig{Level: "debug", Format: "json"}
+	want.Jobs.Workers = 4
+
+	for _, name := range []string{"config.json", "config.yaml", "config.toml"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := Parse(filepath.Ext(name), data)
+		if err != nil {
+			t.Fatalf("%s: %v", name, err)
+		}
+		if got != want {
+			t.Errorf("%s:\n%v\nwant\n%v", name, got, want)
+		}
+	}
+}
+
+func TestParse_EmptyDocumentIsDefaults(t *testing.T) {
+	for ext, data := range map[string]string{".json": "{}", ".yaml": "", ".yml": "# nothing\n", ".toml": ""} {
+		got, err := Parse(ext, []byte(data))
+		if err != nil || got != config.Default() {
+			t.Errorf("%s %q: %v, %v; want the defaults", ext, data, got, err)
+		}
+	}
+}
+
+func TestParse_Secret(t *testing.T) {
+	for ext, data := range map[string]string{
+		".json": `{"auth": {"api_key": "0123456789abcdef"}}`,
+		".yaml": "auth:\n  api_key: 0123456789abcdef\n",
+		".toml": "[auth]\napi_key = \"0123456789abcdef\"\n",
+	} {
+		got, err := Parse(ext, []byte(data))
+		if err != nil || got.Auth.APIKey.Reveal() != "0123456789abcdef" {
+			t.Errorf("%s: api_key = %v, %v", ext, got.Auth.APIKey, err)
+		}
+	}
+}
+
+func TestParse_Errors(t *testing.T) {
+	tests := []struct {
+		ext, data, want string
+	}{
+		{".json", `{"server": {"read_timout": "5s"}}`, `unknown field "read_timout"`},
+		{".yaml", "server:\n  read_timout: 5s\n", "line 2: field read_timout not found"},
+		{".toml", "[server]\nread_timout = \"5s\"\n", "unknown keys: server.read_timout"},
+		{".toml", "[logs]\nlevel = \"debug\"\n", "unknown keys: logs, logs.level"},
+		{".json", `{"server": {"read_timeout": 5}}`, "cannot unmarshal number"},
+		{".yaml", "server:\n  read_timeout: soon\n", `time: invalid duration "soon"`},
+		{".toml", "[server]\nread_timeout = \"soon\"\n", `time: invalid duration "soon"`},
+		{".yaml", "jobs:\n  workers: \"4\"\n", "cannot unmarshal !!str `4` into int"},
+		{".toml", "[jobs]\nworkers = \"4\"\n", "incompatible types"},
+		{".yaml", "server: [1, 2]\n", "cannot unmarshal !!seq"},
+		{".yaml", "server:\n\taddr: x\n", "found character that cannot start any token"}, // tabs
+		{".toml", "[server]\naddr = :80\n", "line 2"},
+		{".yaml", "log:\n  level: loud\n", "log.level"}, // decodes, then fails validation
+		{".xml", "<config/>", `unsupported format ".xml"`},
+	}
+	for _, tt := range tests {
+		_, err := Parse(tt.ext, []byte(tt.data))
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("Parse(%s, %q) err = %v; want %q", tt.ext, tt.data, err, tt.want)
+		}
+	}
+}
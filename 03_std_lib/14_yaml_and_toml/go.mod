@@ -0,0 +1,18 @@
+module golang_roadmap/03_std_lib/14_yaml_and_toml
+
+go 1.24.11
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	golang_roadmap/11_configuration/01_config_loader v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require golang_roadmap/02_core_language/21_struct_tags v0.0.0 // indirect
+
+// The config and envtag packages live in their own modules in this
+// repository.
+replace (
+	golang_roadmap/02_core_language/21_struct_tags => ../../02_core_language/21_struct_tags
+	golang_roadmap/11_configuration/01_config_loader => ../../11_configuration/01_config_loader
+)
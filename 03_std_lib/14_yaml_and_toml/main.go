@@ -0,0 +1,81 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Demonstrates YAML and TOML next to encoding/json:
+// - One struct with json, yaml and toml tags, converted into config.Config
+// - Strict decoding in each format: DisallowUnknownFields, KnownFields and
+//   toml.MetaData.Undecoded
+// - yaml.Node: line numbers for validation errors, and edits that keep
+//   comments and key order
+// - Typing differences between the formats
+
+func main() {
+	fmt.Println("yaml and toml examples starting...")
+
+	// 1) The same settings in three formats
+	fmt.Println("--- one config, three formats ---")
+	for _, name := range []string{"config.json", "config.yaml", "config.toml"} {
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			log.Fatalf("read: %v", err)
+		}
+		c, err := Parse(filepath.Ext(name), data)
+		if err != nil {
+			log.Fatalf("%s: %v", name, err)
+		}
+		fmt.Printf("%-11s addr=%s read_timeout=%v write_timeout=%v (default) log=%s/%s workers=%d\n",
+			name, c.Server.Addr, c.Server.ReadTimeout, c.Server.WriteTimeout, c.Log.Level, c.Log.Format, c.Jobs.Workers)
+	}
+
+	// 2) Strict mode: a typo in each format
+	fmt.Println("--- unknown keys ---")
+	for _, doc := range []struct{ ext, data string }{
+		{".json", `{"server": {"read_timout": "5s"}}`},
+		{".yaml", "server:\n  addr: \":80\"\n  read_timout: 5s\n"},
+		{".toml", "[server]\nread_timout = \"5s\"\n[logs]\nlevel = \"debug\"\n"},
+	} {
+		_, err := Parse(doc.ext, []byte(doc.data))
+		fmt.Printf("%-5s %v\n", doc.ext, err)
+	}
+
+	// 3) Types: each format checks them differently
+	fmt.Println("--- types ---")
+	for _, doc := range []struct{ ext, data string }{
+		{".json", `{"jobs": {"workers": "4"}}`},
+		{".yaml", "jobs:\n  workers: \"4\"\n"},
+		{".yaml", "jobs:\n  workers: 4\nlog:\n  level: no\n"}, // "no" is a string in yaml.v3 (YAML 1.2)
+		{".toml", "[jobs]\nworkers = \"4\"\n"},
+	} {
+		_, err := Parse(doc.ext, []byte(doc.data))
+		fmt.Printf("%-5s %q\n      %v\n", doc.ext, doc.data, err)
+	}
+	var loose map[string]any
+	_ = yaml.Unmarshal([]byte("version: 1.10\nport: 0x1F90\n"), &loose)
+	fmt.Printf("into map[string]any: %v (quote versions, or they become floats)\n", loose)
+
+	// 4) Validation errors with line numbers
+	fmt.Println("--- yaml.Node positions ---")
+	bad := []byte("server:\n  addr: \":9090\"\n  read_timeout: 0s\nlog:\n  level: loud\n")
+	_, err := Parse(".yaml", bad)
+	fmt.Println(WithPositions("config.yaml", bad, err))
+
+	// 5) Editing without losing comments
+	fmt.Println("--- editing through yaml.Node ---")
+	data, _ := os.ReadFile(filepath.Join("testdata", "config.yaml"))
+	data, err = SetYAML(data, "server.read_timeout", "10s")
+	if err == nil {
+		data, err = SetYAML(data, "auth.api_key", "yes")
+	}
+	if err != nil {
+		log.Fatalf("SetYAML: %v", err)
+	}
+	fmt.Print(string(data))
+}
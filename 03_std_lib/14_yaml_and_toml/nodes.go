@@ -0,0 +1,146 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Decoding into a struct throws away everything but the values. A
+// yaml.Node tree keeps the rest: line and column of every key and value,
+// comments, key order, quoting style. That allows error messages that
+// point into the file, and edits that leave the file recognisable.
+
+// parseNode returns the top-level mapping of a YAML document.
+func parseNode(data []byte) (*yaml.Node, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		return &yaml.Node{Kind: yaml.MappingNode}, nil // empty document
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("yaml: line %d: top level is not a mapping", root.Line)
+	}
+	return root, nil
+}
+
+// lookup finds the value node at a dotted path such as "server.addr".
+func lookup(root *yaml.Node, path string) (*yaml.Node, bool) {
+	n := root
+	for key := range strings.SplitSeq(path, ".") {
+		if n.Kind != yaml.MappingNode {
+			return nil, false
+		}
+		_, v, ok := find(n, key)
+		if !ok {
+			return nil, false
+		}
+		n = v
+	}
+	return n, true
+}
+
+// find returns the key and value nodes for key in a mapping. A mapping's
+// Content alternates keys and values.
+func find(m *yaml.Node, key string) (k, v *yaml.Node, ok bool) {
+	for i := 0; i+1 < len(m.Content); i += 2 {
+		if m.Content[i].Value == key {
+			return m.Content[i], m.Content[i+1], true
+		}
+	}
+	return nil, nil, false
+}
+
+// Position reports the line and column of the value at path.
+func Position(data []byte, path string) (line, col int, ok bool) {
+	root, err := parseNode(data)
+	if err != nil {
+		return 0, 0, false
+	}
+	n, ok := lookup(root, path)
+	if !ok {
+		return 0, 0, false
+	}
+	return n.Line, n.Column, true
+}
+
+// WithPositions prefixes each validation error that starts with a key,
+// such as "log.level: ...", with the line it was set on. Errors for keys
+// the file does not set come from defaults and stay as they are.
+func WithPositions(name string, data []byte, err error) error {
+	if err == nil {
+		return nil
+	}
+	var errs []error
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		errs = j.Unwrap()
+	} else {
+		errs = []error{err}
+	}
+	out := make([]error, len(errs))
+	for i, e := range errs {
+		out[i] = e
+		key, _, found := strings.Cut(e.Error(), ": ")
+		if !found {
+			continue
+		}
+		if line, col, ok := Position(data, key); ok {
+			out[i] = fmt.Errorf("%s:%d:%d: %w", name, line, col, e)
+		}
+	}
+	return errors.Join(out...)
+}
+
+// SetYAML sets the value at path to value and re-encodes the document.
+// Comments and key order survive, which they would not with a decode into
+// a struct and an encode back. Missing keys are added, mappings and all.
+func SetYAML(data []byte, path string, value any) ([]byte, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	if len(doc.Content) == 0 {
+		doc = yaml.Node{Kind: yaml.DocumentNode, Content: []*yaml.Node{{Kind: yaml.MappingNode}}}
+	}
+	n := doc.Content[0]
+	keys := strings.Split(path, ".")
+	for i, key := range keys {
+		if n.Kind != yaml.MappingNode {
+			return nil, fmt.Errorf("yaml: %s is not a mapping", strings.Join(keys[:i], "."))
+		}
+		_, v, ok := find(n, key)
+		if !ok {
+			v = &yaml.Node{Kind: yaml.MappingNode}
+			if i == len(keys)-1 {
+				v = &yaml.Node{Kind: yaml.ScalarNode}
+			}
+			n.Content = append(n.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, v)
+		}
+		n = v
+	}
+	if n.Kind != yaml.ScalarNode {
+		return nil, fmt.Errorf("yaml: %s is not a scalar", path)
+	}
+	// Node.Encode tags and quotes the value by its Go type: the string
+	// "yes" is written quoted, so it does not read back as a bool in
+	// YAML 1.1 parsers, and the int 4 is written bare.
+	head, line, foot := n.HeadComment, n.LineComment, n.FootComment
+	if err := n.Encode(value); err != nil {
+		return nil, err
+	}
+	n.HeadComment, n.LineComment, n.FootComment = head, line, foot
+
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), enc.Close()
+}
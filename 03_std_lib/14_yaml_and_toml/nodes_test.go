@@ -0,0 +1,105 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+const sample = `# header
+server:
+  addr: ":9090" # public
+  read_timeout: 5s
+log:
+  level: debug
+`
+
+func TestPosition(t *testing.T) {
+	for path, want := range map[string][2]int{
+		"server":              {3, 3},
+		"server.addr":         {3, 9},
+		"server.read_timeout": {4, 17},
+		"log.level":           {6, 10},
+	} {
+		line, col, ok := Position([]byte(sample), path)
+		if !ok || line != want[0] || col != want[1] {
+			t.Errorf("Position(%s) = %d:%d, %v; want %d:%d", path, line, col, ok, want[0], want[1])
+		}
+	}
+	for _, path := range []string{"auth", "server.addr.port", "log.level.x", ""} {
+		if _, _, ok := Position([]byte(sample), path); ok {
+			t.Errorf("Position(%q) found something", path)
+		}
+	}
+}
+
+func TestWithPositions(t *testing.T) {
+	err := errors.Join(
+		errors.New("log.level: unknown name"),
+		errors.New("jobs.path: must not be empty"), // not in the file
+		errors.New("no key here"),
+	)
+	got := WithPositions("c.yaml", []byte(sample), err).Error()
+	want := "c.yaml:6:10: log.level: unknown name\njobs.path: must not be empty\nno key here"
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+	if WithPositions("c.yaml", []byte(sample), nil) != nil {
+		t.Error("WithPositions(nil) != nil")
+	}
+	single := WithPositions("c.yaml", []byte(sample), errors.New("server.addr: bad"))
+	if single.Error() != "c.yaml:3:9: server.addr: bad" {
+		t.Errorf("single error: %v", single)
+	}
+}
+
+func TestSetYAML(t *testing.T) {
+	out, err := SetYAML([]byte(sample), "server.addr", ":8080")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := string(out)
+	for _, want := range []string{"# header\n", `addr: :8080 # public`, "read_timeout: 5s"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("output lost %q:\n%s", want, got)
+		}
+	}
+	if strings.Index(got, "server:") > strings.Index(got, "log:") {
+		t.Errorf("key order changed:\n%s", got)
+	}
+	if cfg, err := Parse(".yaml", out); err != nil || cfg.Server.Addr != ":8080" {
+		t.Errorf("edited file parses to %v, %v", cfg.Server.Addr, err)
+	}
+}
+
+func TestSetYAML_AddsKeysAndKeepsTypes(t *testing.T) {
+	out, err := SetYAML([]byte(sample), "jobs.workers", 8)
+	if err == nil {
+		out, err = SetYAML(out, "log.format", "yes")
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"jobs:\n  workers: 8\n", `format: "yes"`} {
+		if !strings.Contains(string(out), want) {
+			t.Errorf("want %q in:\n%s", want, out)
+		}
+	}
+	if out, err := SetYAML(nil, "log.level", "warn"); err != nil || string(out) != "log:\n  level: warn\n" {
+		t.Errorf("empty document: %q, %v", out, err)
+	}
+}
+
+func TestSetYAML_Errors(t *testing.T) {
+	for path, want := range map[string]string{
+		"server":           "server is not a scalar",
+		"server.addr.port": "server.addr is not a mapping",
+	} {
+		if _, err := SetYAML([]byte(sample), path, "x"); err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("SetYAML(%s) err = %v; want %q", path, err, want)
+		}
+	}
+	if _, err := SetYAML([]byte("a: [1,"), "a", 1); err == nil {
+		t.Error("malformed YAML: want an error")
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"strconv"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// html.Parse builds the tree a browser would: it adds the <html>, <head>
+// and <tbody> a page left out, closes unclosed <li> and <p>, and moves
+// misplaced elements where the HTML5 algorithm says. The selectors in this
+// package therefore see the corrected document, not the source text.
+
+// Table is the text of an HTML table.
+type Table struct {
+	Caption string
+	Header  []string   // the first row, if it is all <th>; or the <thead> row
+	Rows    [][]string // the other rows, one string per column
+}
+
+// ParseTable reads the rows of the <table> element t. A cell with
+// colspan="n" is repeated n times, so every row of a regular table has as
+// many cells as the header. Tables nested inside t's cells are left out.
+func ParseTable(t *html.Node) Table {
+	var tab Table
+	var rows []*html.Node
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		for c := range n.ChildNodes() {
+			switch c.DataAtom {
+			case atom.Caption:
+				tab.Caption = Text(c)
+			case atom.Tr:
+				rows = append(rows, c)
+			case atom.Thead, atom.Tbody, atom.Tfoot:
+				walk(c)
+			}
+		}
+	}
+	walk(t)
+
+	for i, tr := range rows {
+		var cells []string
+		allTH := true
+		for c := range tr.ChildNodes() {
+			if c.DataAtom != atom.Td && c.DataAtom != atom.Th {
+				continue
+			}
+			allTH = allTH && c.DataAtom == atom.Th
+			span, err := strconv.Atoi(Attr(c, "colspan"))
+			if err != nil || span < 1 {
+				span = 1
+			}
+			text := Text(c)
+			for range min(span, 1000) { // HTML caps colspan at 1000
+				cells = append(cells, text)
+			}
+		}
+		inHead := tr.Parent != nil && tr.Parent.DataAtom == atom.Thead
+		if i == 0 && (allTH || inHead) && len(cells) > 0 {
+			tab.Header = cells
+			continue
+		}
+		tab.Rows = append(tab.Rows, cells)
+	}
+	return tab
+}
+
+// Records returns the rows as maps from header to cell, the shape most
+// scraped tables end up in. Cells beyond the header are dropped.
+func (t Table) Records() []map[string]string {
+	out := make([]map[string]string, 0, len(t.Rows))
+	for _, row := range t.Rows {
+		rec := make(map[string]string, len(t.Header))
+		for i, h := range t.Header {
+			if i < len(row) {
+				rec[h] = row[i]
+			}
+		}
+		out = append(out, rec)
+	}
+	return out
+}
+
+// KeyValues reads a two-column table of <th>name</th><td>value</td> rows,
+// as product pages use for specifications.
+func KeyValues(t *html.Node) map[string]string {
+	kv := map[string]string{}
+	for _, tr := range Find(t, "tr") {
+		th, td := FindOne(tr, "th"), FindOne(tr, "td")
+		if th != nil && td != nil {
+			kv[Text(th)] = Text(td)
+		}
+	}
+	return kv
+}
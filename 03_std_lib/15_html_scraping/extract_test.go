@@ -0,0 +1,79 @@
+package main
+
+import (
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+func parse(t *testing.T, name string) *html.Node {
+	t.Helper()
+	f, err := os.Open(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	doc, err := html.Parse(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return doc
+}
+
+func TestParseTable(t *testing.T) {
+	doc := parse(t, "testdata/releases.html")
+	tab := ParseTable(FindOne(doc, "#releases"))
+	if tab.Caption != "Go releases" {
+		t.Errorf("Caption = %q", tab.Caption)
+	}
+	if want := []string{"Version", "Released", "Highlights"}; !reflect.DeepEqual(tab.Header, want) {
+		t.Errorf("Header = %q; want %q", tab.Header, want)
+	}
+	if len(tab.Rows) != 8 {
+		t.Fatalf("%d rows; want 8", len(tab.Rows))
+	}
+	if want := []string{"1.20", "2023-02-01", "PGO preview, errors.Join"}; !reflect.DeepEqual(tab.Rows[2], want) {
+		t.Errorf("Rows[2] = %q; want %q (text of <code> included)", tab.Rows[2], want)
+	}
+	// colspan="2" fills both columns.
+	if want := []string{"1.25", "Planned", "Planned"}; !reflect.DeepEqual(tab.Rows[7], want) {
+		t.Errorf("Rows[7] = %q; want %q", tab.Rows[7], want)
+	}
+	if rec := tab.Records()[0]; rec["Released"] != "2022-03-15" {
+		t.Errorf("Records()[0] = %v", rec)
+	}
+}
+
+func TestParseTable_Sloppy(t *testing.T) {
+	// No <tbody>, unclosed cells and rows, a <th> header row and a table
+	// nested in a cell.
+	doc, err := html.Parse(strings.NewReader(`<table id=t>
+<tr><th>a<th>b
+<tr><td>1<td><table><tr><td>inner</table>
+<tr><td colspan=0>x<td>y
+</table>`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tab := ParseTable(FindOne(doc, "#t"))
+	want := Table{Header: []string{"a", "b"}, Rows: [][]string{{"1", "inner"}, {"x", "y"}}}
+	if !reflect.DeepEqual(tab, want) {
+		t.Errorf("ParseTable = %+v; want %+v", tab, want)
+	}
+	// The nested table is not a row of the outer one, but is there to select.
+	if n := len(Find(doc, "#t tr")); n != 4 {
+		t.Errorf("#t tr matched %d rows; want 4, the nested one included", n)
+	}
+}
+
+func TestKeyValues(t *testing.T) {
+	doc := parse(t, "testdata/product.html")
+	got := KeyValues(FindOne(doc, "table.specs"))
+	want := map[string]string{"Height": "30 cm", "Colour": "Blue", "Material": "Polyester & cotton"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("KeyValues = %v; want %v", got, want)
+	}
+}
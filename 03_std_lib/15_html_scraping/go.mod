@@ -0,0 +1,5 @@
+module golang_roadmap/03_std_lib/15_html_scraping
+
+go 1.24.11
+
+require golang.org/x/net v0.47.0
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"strings"
+
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+// The tokenizer reads HTML one token at a time: start tag, text, end tag,
+// comment. It builds no tree, so it uses little memory and can stop as
+// soon as it has what it wants. Here that is the <head>: the title and the
+// meta tags are read, and the rest of the page is not, which matters when
+// r is a response body.
+
+// Head is what a page says about itself in its <head>.
+type Head struct {
+	Title string
+	// Meta maps a <meta> tag's name (or property, for Open Graph tags
+	// such as "og:title") to its content. The first tag with a name wins.
+	Meta      map[string]string
+	Canonical string // <link rel="canonical" href>
+}
+
+// ReadHead tokenizes r up to the end of the <head>, or the start of the
+// <body> for pages that leave </head> out, and stops there.
+func ReadHead(r io.Reader) (Head, error) {
+	h := Head{Meta: map[string]string{}}
+	z := html.NewTokenizer(r)
+	inTitle := false
+	for {
+		tt := z.Next()
+		switch tt {
+		case html.ErrorToken:
+			if errors.Is(z.Err(), io.EOF) {
+				return h, nil
+			}
+			return h, z.Err()
+		case html.TextToken:
+			if inTitle {
+				h.Title += string(z.Text()) // Text unescapes entities
+			}
+		case html.EndTagToken:
+			name, _ := z.TagName()
+			switch atom.Lookup(name) {
+			case atom.Title:
+				inTitle = false
+				h.Title = collapse(h.Title)
+			case atom.Head:
+				return h, nil
+			}
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			a := atom.Lookup(name)
+			switch a {
+			case atom.Title:
+				inTitle = tt == html.StartTagToken
+			case atom.Body:
+				return h, nil
+			case atom.Meta, atom.Link:
+				if !hasAttr {
+					continue
+				}
+				attrs := tagAttrs(z)
+				if a == atom.Link {
+					if hasWord(attrs["rel"], "canonical") {
+						h.Canonical = attrs["href"]
+					}
+					continue
+				}
+				key := attrs["name"]
+				if key == "" {
+					key = attrs["property"]
+				}
+				if _, seen := h.Meta[key]; key != "" && !seen {
+					h.Meta[key] = attrs["content"]
+				}
+			}
+		}
+	}
+}
+
+// tagAttrs reads the current tag's attributes. Keys come back lower case.
+func tagAttrs(z *html.Tokenizer) map[string]string {
+	attrs := map[string]string{}
+	for {
+		key, val, more := z.TagAttr()
+		attrs[string(key)] = string(val)
+		if !more {
+			return attrs
+		}
+	}
+}
+
+func hasWord(list, word string) bool {
+	for _, f := range strings.Fields(list) {
+		if strings.EqualFold(f, word) {
+			return true
+		}
+	}
+	return false
+}
+
+// collapse trims s and turns each run of whitespace into one space, as a
+// browser does when it renders text.
+func collapse(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestReadHead(t *testing.T) {
+	f, err := os.Open("testdata/product.html")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	head, err := ReadHead(f)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Title != "Gopher Plush (Large)" {
+		t.Errorf("Title = %q; want whitespace collapsed", head.Title)
+	}
+	for k, want := range map[string]string{
+		"description":            "A soft, blue, 30 cm gopher.",
+		"og:image":               "https://example.com/img/gopher.png",
+		"product:price:currency": "EUR",
+	} {
+		if got := head.Meta[k]; got != want {
+			t.Errorf("Meta[%q] = %q; want %q", k, got, want)
+		}
+	}
+	if _, ok := head.Meta[""]; ok {
+		t.Error("a meta tag without name or property was kept")
+	}
+}
+
+func TestReadHead_Entities(t *testing.T) {
+	head, err := ReadHead(strings.NewReader(`<title>Fish &amp; Chips &middot; &lt;b&gt;</title><link rel="Canonical nofollow" href="/x">`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if head.Title != "Fish & Chips · <b>" {
+		t.Errorf("Title = %q", head.Title)
+	}
+	if head.Canonical != "/x" {
+		t.Errorf("Canonical = %q", head.Canonical)
+	}
+}
+
+// ReadHead must stop at the end of the head and not read the body.
+func TestReadHead_StopsEarly(t *testing.T) {
+	for _, doc := range []string{
+		"<html><head><title>T</title></head>",
+		"<title>T</title><body>", // no </head>
+	} {
+		r := io.MultiReader(strings.NewReader(doc), errReader{})
+		head, err := ReadHead(r)
+		if err != nil || head.Title != "T" {
+			t.Errorf("ReadHead(%q) = %+v, %v; want to stop before the body", doc, head, err)
+		}
+	}
+}
+
+type errReader struct{}
+
+func (errReader) Read([]byte) (int, error) { return 0, errors.New("read past the head") }
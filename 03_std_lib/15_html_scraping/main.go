@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"text/tabwriter"
+
+	"golang.org/x/net/html"
+)
+
+// Demonstrates reading data out of HTML with golang.org/x/net/html:
+// - The tokenizer: a stream of tokens, stopped once the <head> is read
+// - The parser: the same tree a browser builds, even from sloppy markup
+// - CSS-selector-like queries over the tree: tag, #id, .class, [attr], A B, A > B
+// - A table read into a header and rows, colspan included
+// - Text with whitespace collapsed, and attributes read from elements
+
+func main() {
+	fmt.Println("html scraping examples starting...")
+
+	// 1) Tokenizer: only the <head>, then stop.
+	fmt.Println("\n1) <head> by the tokenizer")
+	for _, name := range []string{"testdata/releases.html", "testdata/product.html"} {
+		f, err := os.Open(name)
+		if err != nil {
+			log.Fatal(err)
+		}
+		head, err := ReadHead(f)
+		f.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("   %s\n     title: %q\n", name, head.Title)
+		for _, k := range []string{"description", "og:title", "product:price:amount"} {
+			if v, ok := head.Meta[k]; ok {
+				fmt.Printf("     %s: %q\n", k, v)
+			}
+		}
+		if head.Canonical != "" {
+			fmt.Printf("     canonical: %s\n", head.Canonical)
+		}
+	}
+
+	// 2) Parser and selectors: the releases table.
+	doc := parseFile("testdata/releases.html")
+	fmt.Println("\n2) table#releases by the parser and selectors")
+	tab := ParseTable(FindOne(doc, "table#releases"))
+	fmt.Printf("   caption: %q, %d rows\n", tab.Caption, len(tab.Rows))
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "   %s\n", strings.Join(tab.Header, "\t"))
+	for _, row := range tab.Rows {
+		fmt.Fprintf(w, "   %s\n", strings.Join(row, "\t"))
+	}
+	w.Flush()
+	fmt.Printf("   as records, the last: %v\n", tab.Records()[len(tab.Rows)-1])
+
+	// 3) More selectors.
+	fmt.Println("\n3) Selectors")
+	for _, sel := range []string{
+		"nav.menu > a",
+		"a[rel=external], a.active",
+		`a[href^="https://"]`,
+		"section.notes li",
+		"tr.future td",
+	} {
+		var found []string
+		for _, n := range Find(doc, sel) {
+			found = append(found, fmt.Sprintf("%s %q", n.Data, Text(n)))
+		}
+		fmt.Printf("   %-28s %s\n", sel, strings.Join(found, ", "))
+	}
+	if _, err := Compile("nav >"); err != nil {
+		fmt.Printf("   a bad selector: %v\n", err)
+	}
+
+	// 4) Structured data from a sloppy product page.
+	fmt.Println("\n4) A product from product.html")
+	doc = parseFile("testdata/product.html")
+	product := FindOne(doc, "div.product")
+	fmt.Printf("   sku %s: %s, %s %s\n", Attr(product, "data-sku"), Text(FindOne(product, "h1.name")),
+		Text(FindOne(product, ".price .amount")), Text(FindOne(product, ".price .currency")))
+	fmt.Printf("   specs: %v\n", KeyValues(FindOne(product, "table.specs")))
+	for _, li := range Find(product, "ul.reviews > li.review") {
+		fmt.Printf("   %s stars from %s: %q\n", Attr(li, "data-stars"), Text(FindOne(li, "b")), Text(li))
+	}
+	fmt.Printf("   five-star reviews: %d\n", len(Find(product, `li[data-stars="5"]`)))
+}
+
+func parseFile(name string) *html.Node {
+	f, err := os.Open(name)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	doc, err := html.Parse(f)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return doc
+}
@@ -0,0 +1,346 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// A small subset of CSS selectors, enough for scraping:
+//
+//	tag  *  #id  .class  [attr]  [attr=v]  [attr~=v]  [attr^=v]  [attr$=v]  [attr*=v]
+//	A B  (B inside A)   A > B  (B a child of A)   A, B  (either)
+//
+// Compound selectors combine: table.data#releases, li.review[data-stars="5"].
+// Pseudo-classes such as :first-child are not supported. For the full
+// language, github.com/andybalholm/cascadia compiles selectors into the
+// same kind of matcher over html.Node.
+
+// Selector is a compiled selector. Use it like a regexp: compile once, then
+// match many documents.
+type Selector struct {
+	groups [][]step // comma-separated alternatives
+}
+
+// step is one compound selector and how it relates to the step before it.
+type step struct {
+	child bool // ">" before it, rather than whitespace
+	tag   string
+	id    string
+	class []string
+	attrs []attrTest
+}
+
+type attrTest struct {
+	key, op, val string // op is "" for presence
+}
+
+// Compile parses a selector.
+func Compile(sel string) (*Selector, error) {
+	s := &Selector{}
+	for group := range strings.SplitSeq(sel, ",") {
+		steps, err := parseGroup(group)
+		if err != nil {
+			return nil, fmt.Errorf("selector %q: %w", sel, err)
+		}
+		s.groups = append(s.groups, steps)
+	}
+	return s, nil
+}
+
+// MustCompile is Compile for selectors known to be valid.
+func MustCompile(sel string) *Selector {
+	s, err := Compile(sel)
+	if err != nil {
+		panic(err)
+	}
+	return s
+}
+
+func parseGroup(g string) ([]step, error) {
+	var steps []step
+	child := false
+	p := &scanner{s: strings.TrimSpace(g)}
+	if p.s == "" {
+		return nil, fmt.Errorf("empty selector")
+	}
+	for !p.done() {
+		if p.skipSpace() {
+			continue // a descendant combinator, unless a '>' follows
+		}
+		if p.peek() == '>' {
+			if len(steps) == 0 || child {
+				return nil, fmt.Errorf("misplaced '>'")
+			}
+			p.i++
+			child = true
+			continue
+		}
+		st, err := p.compound()
+		if err != nil {
+			return nil, err
+		}
+		st.child = child
+		child = false
+		steps = append(steps, st)
+	}
+	if child {
+		return nil, fmt.Errorf("'>' with nothing after it")
+	}
+	return steps, nil
+}
+
+type scanner struct {
+	s string
+	i int
+}
+
+func (p *scanner) done() bool { return p.i >= len(p.s) }
+func (p *scanner) peek() byte { return p.s[p.i] }
+
+// skipSpace skips whitespace and reports whether there was any.
+func (p *scanner) skipSpace() bool {
+	start := p.i
+	for !p.done() && (p.peek() == ' ' || p.peek() == '\t' || p.peek() == '\n') {
+		p.i++
+	}
+	return p.i > start
+}
+
+// ident reads a name: letters, digits, '-' and '_'.
+func (p *scanner) ident() string {
+	start := p.i
+	for !p.done() {
+		c := p.peek()
+		if c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c >= '0' && c <= '9' || c == '-' || c == '_' {
+			p.i++
+			continue
+		}
+		break
+	}
+	return p.s[start:p.i]
+}
+
+// compound reads a tag and its #id, .class and [attr] parts.
+func (p *scanner) compound() (step, error) {
+	var st step
+	if p.peek() == '*' {
+		p.i++
+	} else {
+		st.tag = strings.ToLower(p.ident())
+	}
+	for !p.done() {
+		switch p.peek() {
+		case '#':
+			p.i++
+			if st.id = p.ident(); st.id == "" {
+				return st, fmt.Errorf("'#' without a name")
+			}
+		case '.':
+			p.i++
+			c := p.ident()
+			if c == "" {
+				return st, fmt.Errorf("'.' without a name")
+			}
+			st.class = append(st.class, c)
+		case '[':
+			p.i++
+			a, err := p.attr()
+			if err != nil {
+				return st, err
+			}
+			st.attrs = append(st.attrs, a)
+		case ' ', '\t', '\n', '>':
+			return st, nil
+		default:
+			return st, fmt.Errorf("unexpected %q at offset %d", p.peek(), p.i)
+		}
+	}
+	return st, nil
+}
+
+// attr reads the rest of [key], [key=val] or [key op "val"].
+func (p *scanner) attr() (attrTest, error) {
+	var a attrTest
+	a.key = strings.ToLower(p.ident())
+	if a.key == "" {
+		return a, fmt.Errorf("'[' without an attribute name")
+	}
+	if rest := p.s[p.i:]; strings.HasPrefix(rest, "]") {
+		p.i++
+		return a, nil
+	}
+	for _, op := range []string{"~=", "^=", "$=", "*=", "="} {
+		if strings.HasPrefix(p.s[p.i:], op) {
+			a.op = op
+			p.i += len(op)
+			break
+		}
+	}
+	if a.op == "" {
+		return a, fmt.Errorf("bad attribute selector at offset %d", p.i)
+	}
+	if !p.done() && (p.peek() == '"' || p.peek() == '\'') {
+		q := p.peek()
+		end := strings.IndexByte(p.s[p.i+1:], q)
+		if end < 0 {
+			return a, fmt.Errorf("unterminated string")
+		}
+		a.val = p.s[p.i+1 : p.i+1+end]
+		p.i += end + 2
+	} else {
+		a.val = p.ident()
+	}
+	if p.done() || p.peek() != ']' {
+		return a, fmt.Errorf("missing ']'")
+	}
+	p.i++
+	return a, nil
+}
+
+// Match reports whether the element n matches s.
+func (s *Selector) Match(n *html.Node) bool {
+	for _, steps := range s.groups {
+		if matchFrom(steps, len(steps)-1, n) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchFrom matches steps[:i+1] with steps[i] at n, walking up the tree
+// for the steps before it. Right to left is how browsers do it too: most
+// elements fail the last step at once.
+func matchFrom(steps []step, i int, n *html.Node) bool {
+	if !steps[i].match(n) {
+		return false
+	}
+	if i == 0 {
+		return true
+	}
+	if steps[i].child {
+		return n.Parent != nil && matchFrom(steps, i-1, n.Parent)
+	}
+	for a := n.Parent; a != nil; a = a.Parent {
+		if matchFrom(steps, i-1, a) {
+			return true
+		}
+	}
+	return false
+}
+
+func (st step) match(n *html.Node) bool {
+	if n.Type != html.ElementNode || st.tag != "" && n.Data != st.tag {
+		return false
+	}
+	if st.id != "" && Attr(n, "id") != st.id {
+		return false
+	}
+	for _, c := range st.class {
+		if !hasWord(Attr(n, "class"), c) {
+			return false
+		}
+	}
+	for _, a := range st.attrs {
+		v, ok := attr(n, a.key)
+		if !ok {
+			return false
+		}
+		switch a.op {
+		case "=":
+			ok = v == a.val
+		case "~=":
+			ok = hasWord(v, a.val)
+		case "^=":
+			ok = a.val != "" && strings.HasPrefix(v, a.val)
+		case "$=":
+			ok = a.val != "" && strings.HasSuffix(v, a.val)
+		case "*=":
+			ok = a.val != "" && strings.Contains(v, a.val)
+		}
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
+
+// All returns the elements under root that match s, in document order.
+// root itself is included if it matches.
+func (s *Selector) All(root *html.Node) []*html.Node {
+	var out []*html.Node
+	for n := range root.Descendants() {
+		if s.Match(n) {
+			out = append(out, n)
+		}
+	}
+	if s.Match(root) {
+		out = append([]*html.Node{root}, out...)
+	}
+	return out
+}
+
+// First returns the first element under root that matches s, or nil.
+func (s *Selector) First(root *html.Node) *html.Node {
+	if s.Match(root) {
+		return root
+	}
+	for n := range root.Descendants() {
+		if s.Match(n) {
+			return n
+		}
+	}
+	return nil
+}
+
+// Find is MustCompile(sel).All(root), for selectors written in the code.
+func Find(root *html.Node, sel string) []*html.Node {
+	return MustCompile(sel).All(root)
+}
+
+// FindOne is MustCompile(sel).First(root).
+func FindOne(root *html.Node, sel string) *html.Node {
+	return MustCompile(sel).First(root)
+}
+
+// Attr returns the value of n's attribute key, or "".
+func Attr(n *html.Node, key string) string {
+	v, _ := attr(n, key)
+	return v
+}
+
+func attr(n *html.Node, key string) (string, bool) {
+	if n == nil {
+		return "", false
+	}
+	for _, a := range n.Attr {
+		if a.Namespace == "" && a.Key == key {
+			return a.Val, true
+		}
+	}
+	return "", false
+}
+
+// Text returns the text inside n, with whitespace collapsed as a browser
+// would show it. Script and style contents are not text and are skipped.
+func Text(n *html.Node) string {
+	if n == nil {
+		return ""
+	}
+	var b strings.Builder
+	var walk func(*html.Node)
+	walk = func(n *html.Node) {
+		switch {
+		case n.Type == html.TextNode:
+			b.WriteString(n.Data)
+		case n.Type == html.ElementNode && (n.Data == "script" || n.Data == "style"):
+			return
+		}
+		for c := range n.ChildNodes() {
+			walk(c)
+		}
+	}
+	walk(n)
+	return collapse(b.String())
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"golang.org/x/net/html"
+)
+
+const page = `<!doctype html>
+<div id="main" class="box wide">
+  <p class="intro lead">Hello <b>world</b></p>
+  <ul>
+    <li class="item" data-n="1"><a href="https://go.dev/">go.dev</a></li>
+    <li class="item odd" data-n="2"><a href="/local" rel="nofollow noopener">local</a></li>
+    <li class="item" data-n="3"><span><a href="http://example.com/x.pdf">pdf</a></span></li>
+  </ul>
+</div>
+<p>  outside
+   the   div </p>
+<script>var notText = "<p>";</script>`
+
+func TestSelector(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, tc := range []struct {
+		sel  string
+		want string // text of each match, joined by |
+	}{
+		{"p", "Hello world|outside the div"},
+		{"#main p", "Hello world"},
+		{"div.box.wide > p.intro", "Hello world"},
+		{".box.narrow p", ""},
+		{"li.odd", "local"},
+		{"ul > li > a", "go.dev|local"}, // the third <a> is inside a <span>
+		{"ul a", "go.dev|local|pdf"},    // any depth
+		{"li[data-n]", "go.dev|local|pdf"},
+		{`li[data-n="2"] a`, "local"},
+		{"a[rel~=noopener]", "local"}, // a word in the list
+		{"a[rel=noopener]", ""},       // the whole value
+		{"a[href^=https]", "go.dev"},
+		{"a[href$='.pdf']", "pdf"},
+		{"a[href*=example]", "pdf"},
+		{"b, li.odd a", "world|local"}, // document order, not selector order
+		{"*.intro", "Hello world"},
+		{"DIV#MAIN > UL>li:nth-child", "<error>"}, // unsupported
+		{"div >", "<error>"},
+		{"> p", "<error>"},
+		{"p..x", "<error>"},
+		{"a[href", "<error>"},
+		{"a[href=\"x]", "<error>"},
+		{"a,", "<error>"},
+	} {
+		s, err := Compile(tc.sel)
+		if err != nil {
+			if tc.want != "<error>" {
+				t.Errorf("Compile(%q): %v", tc.sel, err)
+			}
+			continue
+		}
+		if tc.want == "<error>" {
+			t.Errorf("Compile(%q) succeeded; want an error", tc.sel)
+			continue
+		}
+		var got []string
+		for _, n := range s.All(doc) {
+			got = append(got, Text(n))
+		}
+		if g := strings.Join(got, "|"); g != tc.want {
+			t.Errorf("%s matched %q; want %q", tc.sel, g, tc.want)
+		}
+		first := s.First(doc)
+		if (first == nil) != (tc.want == "") || first != nil && Text(first) != got[0] {
+			t.Errorf("%s: First = %v; want the first of All", tc.sel, first)
+		}
+	}
+}
+
+func TestTextAndAttr(t *testing.T) {
+	doc, err := html.Parse(strings.NewReader(page))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := Text(FindOne(doc, "body")); strings.Contains(got, "notText") {
+		t.Errorf("Text includes the script: %q", got)
+	}
+	if got := Attr(FindOne(doc, "li.odd a"), "rel"); got != "nofollow noopener" {
+		t.Errorf("Attr(rel) = %q", got)
+	}
+	if Attr(nil, "x") != "" || Text(nil) != "" {
+		t.Error("Attr and Text must accept nil, for a FindOne that found nothing")
+	}
+}
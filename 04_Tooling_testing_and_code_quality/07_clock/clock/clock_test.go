@@ -0,0 +1,172 @@
+package clock
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// received returns the value waiting on c, if any, without blocking.
+func received(c <-chan time.Time) (time.Time, bool) {
+	select {
+	case v := <-c:
+		return v, true
+	default:
+		return time.Time{}, false
+	}
+}
+
+func TestFake_TimerFiresAtDeadline(t *testing.T) {
+	f := NewFake(epoch)
+	tm := f.NewTimer(time.Minute)
+
+	f.Advance(59 * time.Second)
+	if _, ok := received(tm.C()); ok {
+		t.Fatal("timer fired 1s early")
+	}
+	f.Advance(time.Second)
+	v, ok := received(tm.C())
+	if !ok || !v.Equal(epoch.Add(time.Minute)) {
+		t.Fatalf("timer = %v, %v; want fired at %v", v, ok, epoch.Add(time.Minute))
+	}
+	if tm.Stop() {
+		t.Error("Stop after firing = true; want false")
+	}
+	if f.Waiters() != 0 {
+		t.Errorf("Waiters = %d after firing; want 0", f.Waiters())
+	}
+}
+
+func TestFake_AdvanceFiresInOrder(t *testing.T) {
+	f := NewFake(epoch)
+	late, early := f.NewTimer(3*time.Second), f.NewTimer(time.Second)
+	f.Advance(time.Hour)
+
+	e, _ := received(early.C())
+	l, _ := received(late.C())
+	if !e.Equal(epoch.Add(time.Second)) || !l.Equal(epoch.Add(3*time.Second)) {
+		t.Errorf("fired at %v and %v; want each at its own deadline", e, l)
+	}
+	if !f.Now().Equal(epoch.Add(time.Hour)) {
+		t.Errorf("Now = %v; want %v", f.Now(), epoch.Add(time.Hour))
+	}
+}
+
+func TestFake_StopAndReset(t *testing.T) {
+	f := NewFake(epoch)
+	tm := f.NewTimer(time.Second)
+	if !tm.Stop() {
+		t.Error("Stop of a pending timer = false; want true")
+	}
+	f.Advance(time.Hour)
+	if _, ok := received(tm.C()); ok {
+		t.Error("stopped timer fired")
+	}
+
+	if tm.Reset(time.Minute) {
+		t.Error("Reset of a stopped timer = true; want false")
+	}
+	f.Advance(time.Minute)
+	if v, ok := received(tm.C()); !ok || !v.Equal(epoch.Add(time.Hour+time.Minute)) {
+		t.Errorf("reset timer = %v, %v; want fired a minute after the reset", v, ok)
+	}
+
+	// An unreceived value is discarded by Reset, as with time.Timer.
+	tm.Reset(time.Second)
+	f.Advance(time.Second)
+	tm.Reset(time.Second)
+	if _, ok := received(tm.C()); ok {
+		t.Error("stale value survived Reset")
+	}
+}
+
+func TestFake_Ticker(t *testing.T) {
+	f := NewFake(epoch)
+	tk := f.NewTicker(10 * time.Second)
+	defer tk.Stop()
+
+	var ticks []time.Duration
+	for range 3 {
+		f.Advance(10 * time.Second)
+		v, ok := received(tk.C())
+		if !ok {
+			t.Fatal("no tick after a full period")
+		}
+		ticks = append(ticks, v.Sub(epoch))
+	}
+	if want := []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second}; !slices.Equal(ticks, want) {
+		t.Errorf("ticks at %v; want %v", ticks, want)
+	}
+
+	// A slow receiver gets one tick, not a backlog.
+	f.Advance(time.Minute)
+	if v, _ := received(tk.C()); v.Sub(epoch) != 40*time.Second {
+		t.Errorf("after a minute unread: tick at %v; want the first one, 40s", v.Sub(epoch))
+	}
+	if _, ok := received(tk.C()); ok {
+		t.Error("ticks queued up; want dropped")
+	}
+
+	tk.Reset(time.Hour)
+	f.Advance(59 * time.Minute)
+	if _, ok := received(tk.C()); ok {
+		t.Error("ticked before the new period")
+	}
+	tk.Stop()
+	f.Advance(time.Hour)
+	if _, ok := received(tk.C()); ok {
+		t.Error("stopped ticker ticked")
+	}
+}
+
+func TestFake_SleepAndBlockUntil(t *testing.T) {
+	f := NewFake(epoch)
+	woke := make(chan time.Time)
+	go func() {
+		f.Sleep(time.Hour)
+		woke <- f.Now()
+	}()
+
+	f.BlockUntil(1) // the goroutine is asleep
+	f.Advance(time.Hour)
+	if got := <-woke; !got.Equal(epoch.Add(time.Hour)) {
+		t.Errorf("woke at %v; want %v", got, epoch.Add(time.Hour))
+	}
+}
+
+func TestAutoFake(t *testing.T) {
+	f := NewAutoFake(epoch)
+	f.Sleep(time.Hour) // returns at once: no one else could advance
+	if !f.Now().Equal(epoch.Add(time.Hour)) {
+		t.Errorf("Now after Sleep(1h) = %v; want %v", f.Now(), epoch.Add(time.Hour))
+	}
+	if v := <-f.After(time.Minute); !v.Equal(epoch.Add(time.Hour + time.Minute)) {
+		t.Errorf("After(1m) = %v; want %v", v, epoch.Add(time.Hour+time.Minute))
+	}
+}
+
+func TestFake_ZeroDuration(t *testing.T) {
+	f := NewFake(epoch)
+	if _, ok := received(f.After(0)); !ok {
+		t.Error("After(0) did not fire without Advance")
+	}
+	if f.Waiters() != 0 {
+		t.Errorf("Waiters = %d; want 0", f.Waiters())
+	}
+}
+
+func TestReal(t *testing.T) {
+	c := Real()
+	start := c.Now()
+	<-c.After(time.Millisecond)
+	tm := c.NewTimer(time.Millisecond)
+	<-tm.C()
+	tk := c.NewTicker(time.Millisecond)
+	<-tk.C()
+	tk.Stop()
+	if d := time.Since(start); d < 2*time.Millisecond {
+		t.Errorf("real waits took %v; want at least 2ms", d)
+	}
+}
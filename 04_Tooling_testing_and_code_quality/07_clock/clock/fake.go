@@ -0,0 +1,219 @@
+package clock
+
+import (
+	"sync"
+	"time"
+)
+
+// Fake is a Clock whose time only moves when the test says so. Timers,
+// tickers, After and Sleep wait until Advance moves the time past their
+// deadline. It is safe for concurrent use.
+//
+// Code under test usually waits in another goroutine. Call BlockUntil
+// before Advance, so that the goroutine has started waiting by the time
+// the clock moves; otherwise Advance may run first and the wait starts
+// after the deadline it was meant to catch.
+type Fake struct {
+	mu      sync.Mutex
+	changed *sync.Cond // signalled when waiters are added
+	now     time.Time
+	waiters []*fakeTimer
+	auto    bool
+}
+
+// NewFake returns a Fake set to start.
+func NewFake(start time.Time) *Fake {
+	f := &Fake{now: start}
+	f.changed = sync.NewCond(&f.mu)
+	return f
+}
+
+// NewAutoFake returns a Fake whose Sleep and After move the time forward
+// themselves, by the duration asked for, instead of waiting for Advance.
+// It suits code that waits on the test's own goroutine, where nobody else
+// could call Advance. Timers and tickers still wait for Advance.
+func NewAutoFake(start time.Time) *Fake {
+	f := NewFake(start)
+	f.auto = true
+	return f
+}
+
+// Now returns the fake time.
+func (f *Fake) Now() time.Time {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.now
+}
+
+// After returns a channel that receives the fake time once it reaches
+// Now()+d.
+func (f *Fake) After(d time.Duration) <-chan time.Time {
+	t := f.NewTimer(d)
+	if f.auto {
+		f.Advance(d)
+	}
+	return t.C()
+}
+
+// Sleep blocks until the fake time reaches Now()+d.
+func (f *Fake) Sleep(d time.Duration) { <-f.After(d) }
+
+// NewTimer returns a timer that fires once the fake time reaches Now()+d.
+func (f *Fake) NewTimer(d time.Duration) Timer {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{f: f, c: make(chan time.Time, 1)}
+	f.scheduleLocked(t, d)
+	return t
+}
+
+// NewTicker returns a ticker that fires every d of fake time. Like a real
+// ticker, it drops ticks that the receiver is too slow to take.
+func (f *Fake) NewTicker(d time.Duration) Ticker {
+	if d <= 0 {
+		panic("clock: non-positive interval for NewTicker")
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	t := &fakeTimer{f: f, c: make(chan time.Time, 1), period: d}
+	f.scheduleLocked(t, d)
+	return fakeTicker{t}
+}
+
+// Advance moves the time forward by d. Timers and tickers that fall due
+// fire in deadline order, each seeing the time at its own deadline, so a
+// ticker fires once for every period within d.
+func (f *Fake) Advance(d time.Duration) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	end := f.now.Add(d)
+	for {
+		t := f.nextLocked(end)
+		if t == nil {
+			break
+		}
+		f.now = t.at
+		t.fireLocked()
+	}
+	f.now = end
+}
+
+// BlockUntil waits until n or more timers, tickers, Afters and Sleeps are
+// waiting on the clock.
+func (f *Fake) BlockUntil(n int) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for len(f.waiters) < n {
+		f.changed.Wait()
+	}
+}
+
+// Waiters reports how many timers, tickers, Afters and Sleeps are waiting.
+func (f *Fake) Waiters() int {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return len(f.waiters)
+}
+
+func (f *Fake) scheduleLocked(t *fakeTimer, d time.Duration) {
+	t.at = f.now.Add(d)
+	if !t.active {
+		t.active = true
+		f.waiters = append(f.waiters, t)
+		f.changed.Broadcast()
+	}
+	if d <= 0 {
+		t.fireLocked()
+	}
+}
+
+// nextLocked returns the earliest waiter due by end. Ties go to the one
+// scheduled first.
+func (f *Fake) nextLocked(end time.Time) *fakeTimer {
+	var next *fakeTimer
+	for _, t := range f.waiters {
+		if !t.at.After(end) && (next == nil || t.at.Before(next.at)) {
+			next = t
+		}
+	}
+	return next
+}
+
+func (f *Fake) removeLocked(t *fakeTimer) {
+	for i, w := range f.waiters {
+		if w == t {
+			f.waiters = append(f.waiters[:i], f.waiters[i+1:]...)
+			break
+		}
+	}
+	t.active = false
+}
+
+// fakeTimer is a Timer, or with a period, the inside of a Ticker.
+type fakeTimer struct {
+	f      *Fake
+	c      chan time.Time
+	at     time.Time
+	period time.Duration
+	active bool
+}
+
+func (t *fakeTimer) C() <-chan time.Time { return t.c }
+
+func (t *fakeTimer) fireLocked() {
+	select {
+	case t.c <- t.f.now:
+	default: // the last tick has not been received: drop this one
+	}
+	if t.period > 0 {
+		t.at = t.at.Add(t.period)
+	} else {
+		t.f.removeLocked(t)
+	}
+}
+
+// Stop stops the timer. As with time.Timer since Go 1.23, a value sent
+// before Stop is discarded, so a receive after Stop blocks.
+func (t *fakeTimer) Stop() bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	was := t.active
+	t.f.removeLocked(t)
+	t.drain()
+	return was
+}
+
+// Reset restarts the timer, or changes the ticker's period, to fire d
+// from now. An unreceived value is discarded, as Stop does.
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.f.mu.Lock()
+	defer t.f.mu.Unlock()
+	was := t.active
+	t.drain()
+	if t.period > 0 {
+		t.period = d
+	}
+	t.f.scheduleLocked(t, d)
+	return was
+}
+
+func (t *fakeTimer) drain() {
+	select {
+	case <-t.c:
+	default:
+	}
+}
+
+// fakeTicker adapts fakeTimer to Ticker, whose Stop and Reset return
+// nothing.
+type fakeTicker struct{ t *fakeTimer }
+
+func (t fakeTicker) C() <-chan time.Time { return t.t.c }
+func (t fakeTicker) Stop()               { t.t.Stop() }
+
+func (t fakeTicker) Reset(d time.Duration) {
+	if d <= 0 {
+		panic("clock: non-positive interval for Ticker.Reset")
+	}
+	t.t.Reset(d)
+}
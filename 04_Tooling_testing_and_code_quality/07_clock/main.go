@@ -0,0 +1,71 @@
+// Demonstrates injecting a clock into time-dependent code.
+//
+// This example shows:
+// - A polling loop that takes a clock.Clock instead of calling time directly
+// - The same loop on the real clock and on a fake one
+// - Advancing a fake clock in step with the goroutine that waits on it
+package main
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+var errTimeout = errors.New("timed out")
+
+// pollUntil calls ready every interval until it returns true or timeout
+// passes, and returns the number of calls.
+func pollUntil(c clock.Clock, interval, timeout time.Duration, ready func() bool) (int, error) {
+	deadline := c.NewTimer(timeout)
+	defer deadline.Stop()
+	tick := c.NewTicker(interval)
+	defer tick.Stop()
+	for calls := 1; ; calls++ {
+		if ready() {
+			return calls, nil
+		}
+		select {
+		case <-tick.C():
+		case <-deadline.C():
+			return calls, errTimeout
+		}
+	}
+}
+
+func main() {
+	fmt.Println("=== Real clock: 5 polls, 20ms apart ===")
+	start := time.Now()
+	n := 0
+	calls, err := pollUntil(clock.Real(), 20*time.Millisecond, time.Second, func() bool { n++; return n == 5 })
+	fmt.Printf("calls=%d err=%v took=%v\n", calls, err, time.Since(start).Round(10*time.Millisecond))
+
+	fmt.Println("\n=== Fake clock: polling every 10 minutes, ready on the 10th call ===")
+	fake := clock.NewFake(time.Date(2024, 1, 1, 9, 0, 0, 0, time.UTC))
+	start = time.Now()
+	polled := make(chan int)
+	done := make(chan struct{})
+	go func() {
+		n := 0
+		calls, err = pollUntil(fake, 10*time.Minute, 24*time.Hour, func() bool {
+			n++
+			polled <- n
+			return n == 10
+		})
+		close(done)
+	}()
+	// Step in time with the poller: wait for each call, then move the clock
+	// to the next tick. Advancing in a loop regardless would race ahead,
+	// and a ticker drops ticks nobody received.
+	for n := range polled {
+		fmt.Printf("  call %d at %s\n", n, fake.Now().Format("15:04"))
+		if n == 10 {
+			break
+		}
+		fake.Advance(10 * time.Minute)
+	}
+	<-done
+	fmt.Printf("calls=%d err=%v: 90 fake minutes took %v\n", calls, err, time.Since(start))
+}
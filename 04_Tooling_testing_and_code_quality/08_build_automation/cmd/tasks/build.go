@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// build cross-compiles every main package for every platform into
+// dist/bin/GOOS_GOARCH, laid out like the repository. cgo is off, so the
+// host's C toolchain is not involved and any platform can be built
+// anywhere.
+func build(ctx context.Context, e *env) error {
+	mods, err := e.findModules()
+	if err != nil {
+		return err
+	}
+	var (
+		mu   sync.Mutex
+		pkgs []string
+	)
+	err = each(ctx, e.jobs, len(mods), func(i int) error {
+		dirs, err := mainPackages(ctx, mods[i])
+		if err != nil {
+			return fmt.Errorf("%s: %w", e.rel(mods[i]), err)
+		}
+		mu.Lock()
+		pkgs = append(pkgs, dirs...)
+		mu.Unlock()
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	bin := filepath.Join(e.dist, "bin")
+	if err := os.RemoveAll(bin); err != nil {
+		return err
+	}
+	e.logf("building %d packages for %d platforms, version %s", len(pkgs), len(e.platforms), e.stamp.version)
+	start := time.Now()
+	n := len(pkgs) * len(e.platforms)
+	err = each(ctx, e.jobs, n, func(i int) error {
+		dir, p := pkgs[i/len(e.platforms)], e.platforms[i%len(e.platforms)]
+		out := filepath.Join(bin, p.String(), filepath.FromSlash(e.rel(dir)), filepath.Base(dir))
+		if p.os == "windows" {
+			out += ".exe"
+		}
+		env := []string{"GOOS=" + p.os, "GOARCH=" + p.arch, "CGO_ENABLED=0"}
+		// -trimpath leaves this machine's paths out of the binary, which
+		// reproducible builds need.
+		if msg, err := goCmd(ctx, dir, env, "build", "-trimpath", "-ldflags", e.stamp.ldflags(), "-o", out, "."); err != nil {
+			return fmt.Errorf("%s for %s/%s:\n%s", e.rel(dir), p.os, p.arch, indent(msg))
+		}
+		return nil
+	})
+	return e.summarize("builds", n, time.Since(start), err)
+}
+
+// summarize reports the failures from each, with their output, and
+// returns an error if there were any.
+func (e *env) summarize(what string, n int, took time.Duration, err error) error {
+	lines := failures(err)
+	for _, l := range lines {
+		e.logf("FAIL %s", l)
+	}
+	if len(lines) > 0 {
+		return fmt.Errorf("%d of %d %s failed", len(lines), n, what)
+	}
+	e.logf("%d %s ok in %s", n, what, took.Round(time.Second/10))
+	return nil
+}
@@ -0,0 +1,180 @@
+// Command tasks builds, tests and packages every example in this
+// repository. It does what a Makefile would, in Go: it runs wherever Go
+// does, Windows included, and its logic can be read, tested and debugged
+// like any other Go program.
+//
+//	go run ./cmd/tasks                      # list the tasks
+//	go run ./cmd/tasks test                 # go test -race in every module
+//	go run ./cmd/tasks -only 07_ build      # binaries for every platform
+//	go run ./cmd/tasks release              # test, build and package
+//
+// Tasks name the tasks they depend on, and each runs at most once, as
+// with mage or make.
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"text/tabwriter"
+)
+
+type task struct {
+	name  string
+	usage string
+	deps  []string
+	run   func(ctx context.Context, e *env) error
+}
+
+var tasks = []*task{
+	{name: "build", usage: "cross-compile every main package, with the version stamped in", run: build},
+	{name: "test", usage: "run every module's tests with the race detector", run: test},
+	{name: "package", usage: "archive the binaries per platform and write SHA256SUMS", deps: []string{"build"}, run: pack},
+	{name: "release", usage: "test, build and package", deps: []string{"test", "package"}},
+	{name: "clean", usage: "remove the dist directory", run: clean},
+}
+
+func lookupTask(name string) *task {
+	for _, t := range tasks {
+		if t.name == name {
+			return t
+		}
+	}
+	return nil
+}
+
+// env is what the tasks share: the command line, and what one task
+// works out for the next.
+type env struct {
+	root      string // repository root
+	dist      string
+	platforms []platform
+	only      *regexp.Regexp // nil for every module
+	jobs      int
+	race      bool
+	stamp     stamp
+	stdout    io.Writer
+	stderr    io.Writer
+
+	modules []string   // found on first use
+	mu      sync.Mutex // serializes output from parallel commands
+}
+
+func (e *env) logf(format string, args ...any) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	fmt.Fprintf(e.stdout, format+"\n", args...)
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	if err := run(ctx, os.Args[1:], os.Stdout, os.Stderr); err != nil {
+		if !errors.Is(err, flag.ErrHelp) {
+			fmt.Fprintln(os.Stderr, "tasks:", err)
+		}
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) error {
+	fs := flag.NewFlagSet("tasks", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	root := fs.String("root", "", "repository root (default: the enclosing git checkout)")
+	dist := fs.String("dist", "", "output directory (default: ROOT/dist)")
+	platforms := fs.String("platforms", strings.Join(defaultPlatforms, ","), "GOOS/GOARCH pairs to build for")
+	only := fs.String("only", "", "only modules whose path from the root matches this regexp")
+	jobs := fs.Int("j", runtime.NumCPU(), "commands to run at once")
+	race := fs.Bool("race", true, "run tests with -race, which needs cgo")
+	version := fs.String("version", "", "version to stamp (default: git describe)")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: tasks [flags] task...\n\n")
+		printTasks(stderr)
+		fmt.Fprintf(stderr, "\nFlags:\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if fs.NArg() == 0 {
+		printTasks(stdout)
+		return nil
+	}
+	for _, name := range fs.Args() {
+		if lookupTask(name) == nil {
+			return fmt.Errorf("no task %q; run without arguments for the list", name)
+		}
+	}
+
+	e := &env{jobs: max(*jobs, 1), race: *race, stdout: stdout, stderr: stderr}
+	var err error
+	if e.root = *root; e.root == "" {
+		if e.root, err = findRoot(); err != nil {
+			return err
+		}
+	}
+	if e.dist = *dist; e.dist == "" {
+		e.dist = e.root + string(os.PathSeparator) + "dist"
+	}
+	if e.platforms, err = parsePlatforms(*platforms); err != nil {
+		return err
+	}
+	if *only != "" {
+		if e.only, err = regexp.Compile(*only); err != nil {
+			return fmt.Errorf("-only: %w", err)
+		}
+	}
+	if e.stamp, err = readStamp(ctx, e.root, *version); err != nil {
+		return err
+	}
+
+	done := map[string]bool{}
+	for _, name := range fs.Args() {
+		if err := runTask(ctx, e, lookupTask(name), done); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runTask runs t after its dependencies, skipping any task that has run.
+func runTask(ctx context.Context, e *env, t *task, done map[string]bool) error {
+	if done[t.name] {
+		return nil
+	}
+	done[t.name] = true
+	for _, dep := range t.deps {
+		if err := runTask(ctx, e, lookupTask(dep), done); err != nil {
+			return err
+		}
+	}
+	if t.run == nil {
+		return nil
+	}
+	fmt.Fprintf(e.stdout, "== %s\n", t.name)
+	if err := t.run(ctx, e); err != nil {
+		return fmt.Errorf("%s: %w", t.name, err)
+	}
+	return nil
+}
+
+func printTasks(w io.Writer) {
+	tw := tabwriter.NewWriter(w, 0, 0, 3, ' ', 0)
+	fmt.Fprintln(tw, "Tasks:")
+	for _, t := range tasks {
+		fmt.Fprintf(tw, "  %s\t%s\n", t.name, t.usage)
+	}
+	tw.Flush()
+}
+
+func clean(ctx context.Context, e *env) error {
+	return os.RemoveAll(e.dist)
+}
@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// findRoot walks up from the working directory to the git checkout's
+// top, so the tasks can be run from any module.
+func findRoot() (string, error) {
+	dir, err := os.Getwd()
+	if err != nil {
+		return "", err
+	}
+	for {
+		if _, err := os.Stat(filepath.Join(dir, ".git")); err == nil {
+			return dir, nil
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return "", errors.New("not in a git checkout; use -root")
+		}
+		dir = parent
+	}
+}
+
+// findModules returns the directories under root with a go.mod, skipping
+// dist, hidden directories and the ones the go command ignores.
+func (e *env) findModules() ([]string, error) {
+	if e.modules != nil {
+		return e.modules, nil
+	}
+	var mods []string
+	err := filepath.WalkDir(e.root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != e.root && (path == e.dist || name == "testdata" || name == "vendor" ||
+				strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.Name() != "go.mod" {
+			return nil
+		}
+		dir := filepath.Dir(path)
+		if e.only == nil || e.only.MatchString(e.rel(dir)) {
+			mods = append(mods, dir)
+		}
+		return nil
+	})
+	if err == nil && len(mods) == 0 {
+		err = errors.New("no modules found")
+	}
+	e.modules = mods
+	return mods, err
+}
+
+// rel is path from the root, with slashes, for messages and -only.
+func (e *env) rel(path string) string {
+	r, err := filepath.Rel(e.root, path)
+	if err != nil {
+		return path
+	}
+	return filepath.ToSlash(r)
+}
+
+// mainPackages lists the directories of a module's main packages. go list
+// knows which directories are packages, which belong to a nested module,
+// and which files build tags exclude; walking for "package main" would
+// have to redo all of that. Only stdout is parsed: go list reports the
+// modules it downloads on stderr.
+func mainPackages(ctx context.Context, mod string) ([]string, error) {
+	cmd := exec.CommandContext(ctx, "go", "list", "-e", "-f", "{{if eq .Name \"main\"}}{{.Dir}}{{end}}", "./...")
+	cmd.Dir = mod
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, fmt.Errorf("go list: %w\n%s", err, stderr.Bytes())
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// goCmd runs the go command in dir with env added to the environment and
+// returns its combined output.
+func goCmd(ctx context.Context, dir string, env []string, args ...string) ([]byte, error) {
+	cmd := exec.CommandContext(ctx, "go", args...)
+	cmd.Dir = dir
+	cmd.Env = append(os.Environ(), env...)
+	return cmd.CombinedOutput()
+}
+
+type platform struct{ os, arch string }
+
+func (p platform) String() string { return p.os + "_" + p.arch }
+
+var defaultPlatforms = []string{"linux/amd64", "linux/arm64", "darwin/amd64", "darwin/arm64", "windows/amd64"}
+
+func parsePlatforms(s string) ([]platform, error) {
+	var ps []platform
+	for _, f := range strings.Split(s, ",") {
+		goos, goarch, ok := strings.Cut(strings.TrimSpace(f), "/")
+		if !ok || goos == "" || goarch == "" {
+			return nil, fmt.Errorf("-platforms: %q is not GOOS/GOARCH", f)
+		}
+		ps = append(ps, platform{goos, goarch})
+	}
+	return ps, nil
+}
+
+// stamp is what gets built into every binary.
+type stamp struct {
+	version string
+	commit  string
+	date    time.Time
+}
+
+// readStamp asks git for the version and commit. The date is the
+// commit's, not the clock's, so building the same commit twice gives the
+// same bytes; SOURCE_DATE_EPOCH overrides it, as reproducible-builds.org
+// describes.
+func readStamp(ctx context.Context, root, version string) (stamp, error) {
+	git := func(args ...string) string {
+		out, err := exec.CommandContext(ctx, "git", append([]string{"-C", root}, args...)...).Output()
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(out))
+	}
+	s := stamp{version: version, commit: git("rev-parse", "HEAD")}
+	if s.version == "" {
+		s.version = git("describe", "--tags", "--always", "--dirty")
+	}
+	if s.version == "" {
+		s.version = "dev"
+	}
+	epoch := os.Getenv("SOURCE_DATE_EPOCH")
+	if epoch == "" {
+		epoch = git("log", "-1", "--format=%ct")
+	}
+	if epoch == "" {
+		s.date = time.Now().UTC().Truncate(time.Second)
+		return s, nil
+	}
+	sec, err := strconv.ParseInt(epoch, 10, 64)
+	if err != nil {
+		return stamp{}, fmt.Errorf("SOURCE_DATE_EPOCH: %w", err)
+	}
+	s.date = time.Unix(sec, 0).UTC()
+	return s, nil
+}
+
+// ldflags sets main.version, main.commit and main.date, the variables
+// 07_building_cli_beyond_flag/11_selfupdate reads. -X on a variable a
+// program does not have is ignored, so every binary gets the same flags.
+// -s -w drop the symbol table and DWARF, which only a debugger needs.
+func (s stamp) ldflags() string {
+	return fmt.Sprintf("-s -w -X main.version=%s -X main.commit=%s -X main.date=%s",
+		s.version, s.commit, s.date.Format(time.RFC3339))
+}
+
+// each calls fn for 0..n-1 with at most jobs calls at once, and returns
+// their errors joined. It stops starting new calls once ctx is done.
+func each(ctx context.Context, jobs, n int, fn func(i int) error) error {
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+		sem  = make(chan struct{}, jobs)
+	)
+	for i := range n {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return errors.Join(append(errs, ctx.Err())...)
+		}
+		wg.Add(1)
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			if err := fn(i); err != nil {
+				mu.Lock()
+				errs = append(errs, err)
+				mu.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+// failures turns the joined errors from each into one line per failure,
+// for the summary at the end of a task.
+func failures(err error) []string {
+	var lines []string
+	if j, ok := err.(interface{ Unwrap() []error }); ok {
+		for _, e := range j.Unwrap() {
+			lines = append(lines, e.Error())
+		}
+	} else if err != nil {
+		lines = append(lines, err.Error())
+	}
+	slices.Sort(lines)
+	return lines
+}
+
+// indent prefixes each line of command output, to set it off from the
+// task's own messages.
+func indent(out []byte) string {
+	out = bytes.TrimRight(out, "\n")
+	return "    " + strings.ReplaceAll(string(out), "\n", "\n    ")
+}
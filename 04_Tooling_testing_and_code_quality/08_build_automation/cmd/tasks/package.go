@@ -0,0 +1,138 @@
+package main
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"compress/gzip"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// pack writes one archive per platform from dist/bin, a .zip for Windows
+// and a .tar.gz elsewhere, and dist/SHA256SUMS in the format sha256sum -c
+// reads. Entries are in a fixed order with the stamp's time, so the same
+// binaries always give the same archives.
+func pack(ctx context.Context, e *env) error {
+	var sums strings.Builder
+	for _, p := range e.platforms {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		src := filepath.Join(e.dist, "bin", p.String())
+		if _, err := os.Stat(src); err != nil {
+			return fmt.Errorf("no binaries for %s/%s; run build first: %w", p.os, p.arch, err)
+		}
+		name := fmt.Sprintf("golang_roadmap_%s_%s", e.stamp.version, p)
+		write := writeTarGz
+		if p.os == "windows" {
+			name, write = name+".zip", writeZip
+		} else {
+			name += ".tar.gz"
+		}
+		sum, err := archive(filepath.Join(e.dist, name), src, e.stamp.date, write)
+		if err != nil {
+			return fmt.Errorf("%s: %w", name, err)
+		}
+		fmt.Fprintf(&sums, "%s  %s\n", sum, name)
+		e.logf("wrote %s", e.rel(filepath.Join(e.dist, name)))
+	}
+	return os.WriteFile(filepath.Join(e.dist, "SHA256SUMS"), []byte(sums.String()), 0o644)
+}
+
+// archive creates path with write, which adds the files under src, and
+// returns its SHA-256. A failed archive is removed rather than left
+// looking finished.
+func archive(path, src string, mtime time.Time, write func(w io.Writer, src string, mtime time.Time) error) (sum string, err error) {
+	f, err := os.Create(path)
+	if err != nil {
+		return "", err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+	h := sha256.New()
+	if err := write(io.MultiWriter(f, h), src, mtime); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// walkFiles calls fn for the regular files under src in lexical order,
+// with their slash-separated paths relative to src.
+func walkFiles(src string, fn func(name, path string, size int64) error) error {
+	return filepath.WalkDir(src, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() {
+			return err
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		rel, err := filepath.Rel(src, path)
+		if err != nil {
+			return err
+		}
+		return fn(filepath.ToSlash(rel), path, fi.Size())
+	})
+}
+
+func copyFile(w io.Writer, path string) error {
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	_, err = io.Copy(w, f)
+	return err
+}
+
+func writeTarGz(w io.Writer, src string, mtime time.Time) error {
+	gz := gzip.NewWriter(w)
+	tw := tar.NewWriter(gz)
+	err := walkFiles(src, func(name, path string, size int64) error {
+		// Fixed owner and mode: the builder's user and umask are not part
+		// of the release.
+		hdr := &tar.Header{Name: name, Size: size, Mode: 0o755, ModTime: mtime, Typeflag: tar.TypeReg, Format: tar.FormatPAX}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		return copyFile(tw, path)
+	})
+	if err != nil {
+		return err
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gz.Close()
+}
+
+func writeZip(w io.Writer, src string, mtime time.Time) error {
+	zw := zip.NewWriter(w)
+	err := walkFiles(src, func(name, path string, size int64) error {
+		hdr := &zip.FileHeader{Name: name, Method: zip.Deflate, Modified: mtime}
+		hdr.SetMode(0o755)
+		fw, err := zw.CreateHeader(hdr)
+		if err != nil {
+			return err
+		}
+		return copyFile(fw, path)
+	})
+	if err != nil {
+		return err
+	}
+	return zw.Close()
+}
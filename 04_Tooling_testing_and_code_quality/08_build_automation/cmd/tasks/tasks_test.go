@@ -0,0 +1,203 @@
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestFindModules(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"01_a/go.mod":                "module a\n",
+		"02_b/c/go.mod":              "module c\n",
+		"02_b/c/testdata/x/go.mod":   "module x\n",
+		"dist/bin/go.mod":            "module stale\n",
+		".cache/go.mod":              "module hidden\n",
+		"03_no_module/main.go":       "package main\n",
+		"02_b/c/vendor/v/go.mod":     "module v\n",
+		"04_d/_scratch/s/go.mod":     "module s\n",
+		"04_d/nested/module/go.mod":  "module n\n",
+		"04_d/nested/module/main.go": "package main\n",
+	})
+	e := &env{root: root, dist: filepath.Join(root, "dist")}
+	mods, err := e.findModules()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []string
+	for _, m := range mods {
+		got = append(got, e.rel(m))
+	}
+	if want := []string{"01_a", "02_b/c", "04_d/nested/module"}; !slices.Equal(got, want) {
+		t.Errorf("modules = %v; want %v", got, want)
+	}
+}
+
+func TestParsePlatforms(t *testing.T) {
+	ps, err := parsePlatforms("linux/amd64, windows/arm64")
+	if err != nil || len(ps) != 2 || ps[1] != (platform{"windows", "arm64"}) || ps[0].String() != "linux_amd64" {
+		t.Errorf("parsePlatforms = %v, %v", ps, err)
+	}
+	for _, bad := range []string{"linux", "linux/", "/amd64", "linux/amd64,"} {
+		if _, err := parsePlatforms(bad); err == nil {
+			t.Errorf("parsePlatforms(%q): no error", bad)
+		}
+	}
+}
+
+func TestRunTask_DepsOnce(t *testing.T) {
+	var ran []string
+	record := func(name string) func(context.Context, *env) error {
+		return func(context.Context, *env) error { ran = append(ran, name); return nil }
+	}
+	old := tasks
+	tasks = []*task{
+		{name: "build", run: record("build")},
+		{name: "test", run: record("test")},
+		{name: "package", deps: []string{"build"}, run: record("package")},
+		{name: "release", deps: []string{"test", "package"}},
+	}
+	t.Cleanup(func() { tasks = old })
+
+	e := &env{stdout: io.Discard}
+	done := map[string]bool{}
+	for _, name := range []string{"build", "release"} {
+		if err := runTask(context.Background(), e, lookupTask(name), done); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if want := []string{"build", "test", "package"}; !slices.Equal(ran, want) {
+		t.Errorf("ran %v; want %v", ran, want)
+	}
+}
+
+func TestEach(t *testing.T) {
+	var (
+		mu            sync.Mutex
+		running, peak int
+	)
+	err := each(context.Background(), 3, 10, func(i int) error {
+		mu.Lock()
+		running++
+		peak = max(peak, running)
+		mu.Unlock()
+		time.Sleep(5 * time.Millisecond)
+		mu.Lock()
+		running--
+		mu.Unlock()
+		if i%4 == 0 {
+			return errors.New("boom")
+		}
+		return nil
+	})
+	if n := len(failures(err)); n != 3 {
+		t.Errorf("%d failures; want 3 (0, 4 and 8)", n)
+	}
+	if peak > 3 {
+		t.Errorf("%d calls at once; want at most 3", peak)
+	}
+}
+
+func TestWriteTarGz_Reproducible(t *testing.T) {
+	src := t.TempDir()
+	writeFiles(t, src, map[string]string{"b/tool": "binary b", "a/tool": "binary a"})
+	mtime := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+
+	var first, second bytes.Buffer
+	if err := writeTarGz(&first, src, mtime); err != nil {
+		t.Fatal(err)
+	}
+	// Other file times and modes on disk must not change the archive.
+	os.Chtimes(filepath.Join(src, "a/tool"), time.Now(), time.Now())
+	os.Chmod(filepath.Join(src, "b/tool"), 0o600)
+	if err := writeTarGz(&second, src, mtime); err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(first.Bytes(), second.Bytes()) {
+		t.Error("archives of the same files differ")
+	}
+
+	gz, err := gzip.NewReader(&first)
+	if err != nil {
+		t.Fatal(err)
+	}
+	tr := tar.NewReader(gz)
+	var names []string
+	for {
+		hdr, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if hdr.Mode != 0o755 || !hdr.ModTime.Equal(mtime) {
+			t.Errorf("%s: mode %o, time %v", hdr.Name, hdr.Mode, hdr.ModTime)
+		}
+		names = append(names, hdr.Name)
+	}
+	if !slices.Equal(names, []string{"a/tool", "b/tool"}) {
+		t.Errorf("entries = %v; want sorted", names)
+	}
+}
+
+// TestRun builds and packages a one-module repository with the real go
+// command.
+func TestRun(t *testing.T) {
+	if testing.Short() {
+		t.Skip("runs go build")
+	}
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"01_hello/go.mod":     "module hello\n\ngo 1.24\n",
+		"01_hello/main.go":    "package main\n\nvar version = \"dev\"\n\nfunc main() { println(version) }\n",
+		"01_hello/lib/lib.go": "package lib\n",
+	})
+	var out bytes.Buffer
+	args := []string{"-root", root, "-platforms", "linux/amd64,windows/amd64", "-version", "v1.2.3", "package"}
+	if err := run(context.Background(), args, &out, &out); err != nil {
+		t.Fatalf("%v\n%s", err, &out)
+	}
+	for _, name := range []string{
+		"bin/linux_amd64/01_hello/01_hello",
+		"bin/windows_amd64/01_hello/01_hello.exe",
+		"golang_roadmap_v1.2.3_linux_amd64.tar.gz",
+		"golang_roadmap_v1.2.3_windows_amd64.zip",
+	} {
+		if _, err := os.Stat(filepath.Join(root, "dist", name)); err != nil {
+			t.Error(err)
+		}
+	}
+	bin, _ := os.ReadFile(filepath.Join(root, "dist/bin/linux_amd64/01_hello/01_hello"))
+	if !bytes.Contains(bin, []byte("v1.2.3")) {
+		t.Error("version not stamped into the binary")
+	}
+	sums, _ := os.ReadFile(filepath.Join(root, "dist/SHA256SUMS"))
+	if lines := strings.Split(strings.TrimSpace(string(sums)), "\n"); len(lines) != 2 {
+		t.Errorf("SHA256SUMS:\n%s", sums)
+	}
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// test runs go test in each module, in parallel, printing a line per
+// module and the output of the ones that fail. The race detector needs
+// cgo, so it is turned on for these runs whatever the environment says.
+func test(ctx context.Context, e *env) error {
+	mods, err := e.findModules()
+	if err != nil {
+		return err
+	}
+	args := []string{"test"}
+	var env []string
+	if e.race {
+		args = append(args, "-race")
+		env = append(env, "CGO_ENABLED=1")
+	}
+	args = append(args, "./...")
+
+	start := time.Now()
+	err = each(ctx, e.jobs, len(mods), func(i int) error {
+		t := time.Now()
+		out, err := goCmd(ctx, mods[i], env, args...)
+		if err != nil {
+			return fmt.Errorf("%s:\n%s", e.rel(mods[i]), indent(out))
+		}
+		e.logf("ok   %s (%s)", e.rel(mods[i]), time.Since(t).Round(time.Second/10))
+		return nil
+	})
+	return e.summarize("modules", len(mods), time.Since(start), err)
+}
@@ -0,0 +1,116 @@
+// Package clockcheck defines an analyzer that reports direct uses of the
+// time package's clock in packages that take a clock.Clock instead.
+//
+// A package that imports the clock package from
+// 04_Tooling_testing_and_code_quality/07_clock has chosen to get the
+// current time from an injected Clock, so that tests can fake it. One
+// time.Now or time.After left behind reads the real clock anyway, and the
+// test that advances a fake clock by an hour then waits forever or sees
+// the wrong time. The analyzer finds those:
+//
+//	retry.go:88:13: time.After in a package that uses clock.Clock; use the Clock's After
+//
+// Packages that do not import the clock package are not checked, nor are
+// _test.go files, which often want a real timeout. A use that must stay,
+// such as a context deadline, is kept with a comment on its line:
+//
+//	deadline := time.Now().Add(d) //clockcheck:ignore the context deadline is real time
+package clockcheck
+
+import (
+	"go/ast"
+	"go/types"
+	"strings"
+
+	"golang.org/x/tools/go/analysis"
+	"golang.org/x/tools/go/analysis/passes/inspect"
+	"golang.org/x/tools/go/ast/inspector"
+)
+
+var Analyzer = &analysis.Analyzer{
+	Name:     "clockcheck",
+	Doc:      "report time.Now, time.Sleep and friends in packages that use an injected clock.Clock",
+	Requires: []*analysis.Analyzer{inspect.Analyzer},
+	Run:      run,
+}
+
+// clockPath is the import path that marks a package as using the clock.
+var clockPath = "golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+
+func init() {
+	Analyzer.Flags.StringVar(&clockPath, "clock", clockPath, "import path of the clock package")
+}
+
+// readers are the time package functions that read or wait on the real
+// clock, with what to use instead.
+var readers = map[string]string{
+	"Now":       "use the Clock's Now",
+	"Since":     "use Clock.Now().Sub",
+	"Until":     "use t.Sub(Clock.Now())",
+	"Sleep":     "use the Clock's Sleep",
+	"After":     "use the Clock's After",
+	"Tick":      "use the Clock's NewTicker",
+	"NewTimer":  "use the Clock's NewTimer",
+	"NewTicker": "use the Clock's NewTicker",
+	"AfterFunc": "use the Clock's NewTimer and a goroutine",
+}
+
+const ignoreDirective = "//clockcheck:ignore"
+
+func run(pass *analysis.Pass) (any, error) {
+	if !importsClock(pass.Pkg) {
+		return nil, nil
+	}
+	ignored := ignoredLines(pass)
+
+	insp := pass.ResultOf[inspect.Analyzer].(*inspector.Inspector)
+	// An *ast.Ident covers calls, method values like f := time.Now,
+	// and dot imports alike: what matters is which object it refers to.
+	insp.Preorder([]ast.Node{(*ast.Ident)(nil)}, func(n ast.Node) {
+		id := n.(*ast.Ident)
+		fn, ok := pass.TypesInfo.Uses[id].(*types.Func)
+		if !ok || fn.Pkg() == nil || fn.Pkg().Path() != "time" {
+			return
+		}
+		hint, ok := readers[fn.Name()]
+		if !ok || fn.Signature().Recv() != nil {
+			return // a method, such as Time.Sub, or not a reader
+		}
+		pos := pass.Fset.Position(id.Pos())
+		if strings.HasSuffix(pos.Filename, "_test.go") || ignored[lineKey{pos.Filename, pos.Line}] {
+			return
+		}
+		pass.Reportf(id.Pos(), "time.%s in a package that uses clock.Clock; %s", fn.Name(), hint)
+	})
+	return nil, nil
+}
+
+func importsClock(pkg *types.Package) bool {
+	for _, imp := range pkg.Imports() {
+		if imp.Path() == clockPath {
+			return true
+		}
+	}
+	return false
+}
+
+type lineKey struct {
+	file string
+	line int
+}
+
+// ignoredLines returns the lines that carry an ignore directive.
+func ignoredLines(pass *analysis.Pass) map[lineKey]bool {
+	lines := map[lineKey]bool{}
+	for _, f := range pass.Files {
+		for _, group := range f.Comments {
+			for _, c := range group.List {
+				if strings.HasPrefix(c.Text, ignoreDirective) {
+					pos := pass.Fset.Position(c.Pos())
+					lines[lineKey{pos.Filename, pos.Line}] = true
+				}
+			}
+		}
+	}
+	return lines
+}
@@ -0,0 +1,15 @@
+package clockcheck
+
+import (
+	"testing"
+
+	"golang.org/x/tools/go/analysis/analysistest"
+)
+
+func TestAnalyzer(t *testing.T) {
+	// The packages under testdata/src import a stand-in clock package.
+	if err := Analyzer.Flags.Set("clock", "clock"); err != nil {
+		t.Fatal(err)
+	}
+	analysistest.Run(t, analysistest.TestData(), Analyzer, "uses", "plain", "dotimport")
+}
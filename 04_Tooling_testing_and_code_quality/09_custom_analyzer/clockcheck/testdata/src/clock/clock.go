@@ -0,0 +1,10 @@
+package clock
+
+import "time"
+
+type Clock interface {
+	Now() time.Time
+	After(d time.Duration) <-chan time.Time
+}
+
+func Real() Clock { return nil }
@@ -0,0 +1,13 @@
+package dotimport
+
+import (
+	. "time"
+
+	"clock"
+)
+
+var _ clock.Clock
+
+func Started() Time {
+	return Now() // want `time.Now`
+}
@@ -0,0 +1,9 @@
+// Package plain does not use the clock package, so it may use time freely.
+package plain
+
+import "time"
+
+func Stamp() time.Time {
+	time.Sleep(time.Millisecond)
+	return time.Now()
+}
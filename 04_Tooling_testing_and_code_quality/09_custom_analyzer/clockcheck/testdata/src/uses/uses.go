@@ -0,0 +1,45 @@
+package uses
+
+import (
+	"context"
+	"time"
+
+	"clock"
+)
+
+type Poller struct {
+	Clock clock.Clock
+}
+
+func (p *Poller) Wait(ctx context.Context, d time.Duration) error {
+	start := time.Now() // want `time.Now in a package that uses clock.Clock; use the Clock's Now`
+	select {
+	case <-time.After(d): // want `time.After in a package that uses clock.Clock`
+	case <-p.Clock.After(d):
+	case <-ctx.Done():
+	}
+	_ = time.Since(start) // want `use Clock.Now\(\).Sub`
+	time.Sleep(d)         // want `time.Sleep`
+	return nil
+}
+
+// A function value reads the clock as much as a call does.
+var now = time.Now // want `time.Now`
+
+func Fine(p *Poller, t time.Time) time.Duration {
+	// Methods and constructors that take a time, not read one, are fine.
+	later := t.Add(time.Minute)
+	_ = time.Unix(0, 0)
+	_, _ = time.ParseDuration("1s")
+	return later.Sub(p.Clock.Now())
+}
+
+func Ignored(ctx context.Context) (context.Context, context.CancelFunc) {
+	return context.WithDeadline(ctx, time.Now().Add(time.Second)) //clockcheck:ignore context deadlines are real time
+}
+
+func Ticking() {
+	t := time.NewTicker(time.Second) // want `time.NewTicker`
+	defer t.Stop()
+	time.AfterFunc(time.Second, func() {}) // want `time.AfterFunc`
+}
@@ -0,0 +1,12 @@
+package uses
+
+import (
+	"testing"
+	"time"
+)
+
+// Tests are not checked: a real timeout guards against a hung test.
+func TestWait(t *testing.T) {
+	deadline := time.Now().Add(time.Second)
+	_ = deadline
+}
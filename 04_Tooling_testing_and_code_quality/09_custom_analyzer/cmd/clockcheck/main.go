@@ -0,0 +1,15 @@
+// Command clockcheck runs the clockcheck analyzer, on its own or as a
+// go vet tool:
+//
+//	go install ./cmd/clockcheck
+//	clockcheck ./...
+//	go vet -vettool=$(which clockcheck) ./...
+package main
+
+import (
+	"golang.org/x/tools/go/analysis/singlechecker"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/09_custom_analyzer/clockcheck"
+)
+
+func main() { singlechecker.Main(clockcheck.Analyzer) }
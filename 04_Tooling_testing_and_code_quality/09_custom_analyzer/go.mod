@@ -0,0 +1,10 @@
+module golang_roadmap/04_Tooling_testing_and_code_quality/09_custom_analyzer
+
+go 1.24.11
+
+require golang.org/x/tools v0.41.0
+
+require (
+	golang.org/x/mod v0.32.0 // indirect
+	golang.org/x/sync v0.19.0 // indirect
+)
@@ -0,0 +1,74 @@
+// Demonstrates walking Go syntax trees with go/parser and go/ast.
+//
+// This example shows:
+// - Parsing every Go file in a repository, comments included, without type checking
+// - Telling functions, methods and exported declarations apart in an *ast.File
+// - Computing cyclomatic complexity with ast.Inspect
+// - Finding TODO and FIXME comments with their line numbers
+// - A JSON or markdown report, per module and in total
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/10_code_metrics/metrics"
+)
+
+func main() {
+	os.Exit(run(os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("metrics", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	format := fs.String("format", "markdown", "output format: markdown or json")
+	top := fs.Int("top", 15, "number of most complex functions to list; 0 for all")
+	tests := fs.Bool("tests", false, "count the functions in _test.go files too")
+	out := fs.String("o", "", "write the report to this file instead of stdout")
+	fs.Usage = func() {
+		fmt.Fprintf(stderr, "usage: metrics [flags] [dir]\n\nMeasures the Go files under dir, by default the repository root.\n\n")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *format != "markdown" && *format != "json" {
+		fmt.Fprintf(stderr, "metrics: -format %q: want markdown or json\n", *format)
+		return 2
+	}
+	root := fs.Arg(0)
+	if root == "" {
+		// This module sits two levels below the root.
+		root = filepath.Join("..", "..")
+	}
+
+	report, err := metrics.Scan(root, metrics.Options{Tests: *tests})
+	if err != nil {
+		fmt.Fprintln(stderr, "metrics:", err)
+		return 1
+	}
+	w := stdout
+	if *out != "" {
+		f, err := os.Create(*out)
+		if err != nil {
+			fmt.Fprintln(stderr, "metrics:", err)
+			return 1
+		}
+		defer f.Close()
+		w = f
+	}
+	if *format == "json" {
+		err = report.WriteJSON(w, *top)
+	} else {
+		err = report.WriteMarkdown(w, *top)
+	}
+	if err != nil {
+		fmt.Fprintln(stderr, "metrics:", err)
+		return 1
+	}
+	return 0
+}
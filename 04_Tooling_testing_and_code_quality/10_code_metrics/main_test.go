@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	os.WriteFile(filepath.Join(dir, "go.mod"), []byte("module m\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, "m.go"), []byte("package m\n\n// TODO(ann): more.\nfunc F(a bool) { if a {} }\n"), 0o644)
+
+	var out, errOut bytes.Buffer
+	if code := run([]string{"-format", "json", dir}, &out, &errOut); code != 0 {
+		t.Fatalf("exit %d: %s", code, &errOut)
+	}
+	var report struct {
+		Totals struct{ Funcs, Exported, TODOs int }
+	}
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil || report.Totals.Funcs != 1 || report.Totals.TODOs != 1 {
+		t.Errorf("report = %+v, %v\n%s", report, err, &out)
+	}
+
+	path := filepath.Join(dir, "METRICS.md")
+	if code := run([]string{"-o", path, dir}, &out, &errOut); code != 0 {
+		t.Fatalf("exit %d: %s", code, &errOut)
+	}
+	if md, _ := os.ReadFile(path); !strings.HasPrefix(string(md), "# Code metrics") {
+		t.Errorf("METRICS.md:\n%s", md)
+	}
+
+	if code := run([]string{"-format", "xml"}, &out, &errOut); code != 2 {
+		t.Errorf("-format xml: exit %d; want 2", code)
+	}
+}
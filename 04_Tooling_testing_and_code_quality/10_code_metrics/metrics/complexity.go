@@ -0,0 +1,40 @@
+package metrics
+
+import (
+	"go/ast"
+	"go/token"
+)
+
+// Complexity is the cyclomatic complexity of a function body: one, plus
+// one for each place control can branch. That is the number of
+// independent paths through the function, and so a lower bound on the
+// tests needed to run every branch.
+//
+// It counts if, for and range, each case of a switch or select other
+// than default, and each && and ||, which branch too. Function literals
+// count toward the function they are in, as in gocyclo.
+func Complexity(body *ast.BlockStmt) int {
+	n := 1
+	// ast.Inspect visits every node below body, depth first; returning
+	// true descends into the node's children.
+	ast.Inspect(body, func(node ast.Node) bool {
+		switch x := node.(type) {
+		case *ast.IfStmt, *ast.ForStmt, *ast.RangeStmt:
+			n++
+		case *ast.CaseClause: // in switch and type switch; List is nil for default
+			if x.List != nil {
+				n++
+			}
+		case *ast.CommClause: // in select; Comm is nil for default
+			if x.Comm != nil {
+				n++
+			}
+		case *ast.BinaryExpr:
+			if x.Op == token.LAND || x.Op == token.LOR {
+				n++
+			}
+		}
+		return true
+	})
+	return n
+}
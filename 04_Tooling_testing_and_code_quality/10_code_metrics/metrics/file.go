@@ -0,0 +1,154 @@
+// Package metrics measures Go source with nothing but the syntax tree:
+// go/parser turns a file into an *ast.File, and the counts come from
+// walking it. There is no type checking, so it works on code that does
+// not build, and it is fast enough to run over a whole repository.
+//
+// What it counts, per file:
+//   - functions and methods, with their cyclomatic complexity and length
+//   - exported top-level identifiers: the package's API
+//   - TODO and FIXME comments
+package metrics
+
+import (
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"regexp"
+	"strings"
+)
+
+// File is what one source file contains.
+type File struct {
+	Path     string // slash-separated, relative to the scanned root
+	Package  string
+	Test     bool // a _test.go file
+	Lines    int
+	Funcs    []Func
+	Exported []string // top-level names, methods as Type.Method
+	TODOs    []TODO
+}
+
+type Func struct {
+	Name       string `json:"name"` // Name, or Type.Name for a method
+	File       string `json:"file"`
+	Line       int    `json:"line"`
+	Lines      int    `json:"lines"`
+	Complexity int    `json:"complexity"`
+}
+
+type TODO struct {
+	File string `json:"file"`
+	Line int    `json:"line"`
+	Text string `json:"text"`
+}
+
+// ParseFile parses src, which is read from path if nil, and measures it.
+// rel is the path recorded in the results.
+func ParseFile(fset *token.FileSet, path, rel string, src any) (*File, error) {
+	// ParseComments keeps the comments, which TODOs need; without it the
+	// parser drops them.
+	f, err := parser.ParseFile(fset, path, src, parser.ParseComments|parser.SkipObjectResolution)
+	if err != nil {
+		return nil, err
+	}
+	m := &File{
+		Path:    rel,
+		Package: f.Name.Name,
+		Test:    strings.HasSuffix(path, "_test.go"),
+		Lines:   fset.File(f.Pos()).LineCount(),
+	}
+
+	// The top level of a file is a list of declarations: *ast.FuncDecl
+	// for functions and methods, and *ast.GenDecl for import, const, var
+	// and type, each with a list of specs.
+	for _, decl := range f.Decls {
+		switch d := decl.(type) {
+		case *ast.FuncDecl:
+			name := d.Name.Name
+			if recv := receiverType(d); recv != "" {
+				name = recv + "." + name
+				if ast.IsExported(recv) && d.Name.IsExported() {
+					m.Exported = append(m.Exported, name)
+				}
+			} else if d.Name.IsExported() {
+				m.Exported = append(m.Exported, name)
+			}
+			if d.Body == nil {
+				continue // declared in assembly
+			}
+			start, end := fset.Position(d.Pos()), fset.Position(d.End())
+			m.Funcs = append(m.Funcs, Func{
+				Name:       name,
+				File:       m.Path,
+				Line:       start.Line,
+				Lines:      end.Line - start.Line + 1,
+				Complexity: Complexity(d.Body),
+			})
+		case *ast.GenDecl:
+			for _, spec := range d.Specs {
+				switch s := spec.(type) {
+				case *ast.TypeSpec:
+					if s.Name.IsExported() {
+						m.Exported = append(m.Exported, s.Name.Name)
+					}
+				case *ast.ValueSpec: // const and var; one spec can declare several names
+					for _, id := range s.Names {
+						if id.IsExported() {
+							m.Exported = append(m.Exported, id.Name)
+						}
+					}
+				}
+			}
+		}
+	}
+
+	// f.Comments has every comment in the file, doc comments included.
+	for _, group := range f.Comments {
+		for _, c := range group.List {
+			for i, line := range strings.Split(c.Text, "\n") {
+				if text, ok := todo(line); ok {
+					m.TODOs = append(m.TODOs, TODO{File: m.Path, Line: fset.Position(c.Pos()).Line + i, Text: text})
+				}
+			}
+		}
+	}
+	return m, nil
+}
+
+// receiverType is the name of a method's receiver type, without pointer
+// or type parameters: T for (t *T) and (l List[E]).
+func receiverType(d *ast.FuncDecl) string {
+	if d.Recv == nil || len(d.Recv.List) == 0 {
+		return ""
+	}
+	typ := d.Recv.List[0].Type
+	for {
+		switch t := typ.(type) {
+		case *ast.StarExpr:
+			typ = t.X
+		case *ast.IndexExpr: // one type parameter
+			typ = t.X
+		case *ast.IndexListExpr: // several
+			typ = t.X
+		case *ast.ParenExpr:
+			typ = t.X
+		case *ast.Ident:
+			return t.Name
+		default:
+			return ""
+		}
+	}
+}
+
+// todoRE matches a TODO or FIXME that starts a comment line, as in
+// "// TODO: x", "// TODO(ann): x" or "/* FIXME x */", and not the word in
+// the middle of a sentence.
+var todoRE = regexp.MustCompile(`^\s*(?://|/\*)?\s*((?:TODO|FIXME)\b.*?)\s*(?:\*/)?$`)
+
+func todo(line string) (string, bool) {
+	sub := todoRE.FindStringSubmatch(line)
+	if sub == nil {
+		return "", false
+	}
+	return sub[1], true
+}
@@ -0,0 +1,217 @@
+package metrics
+
+import (
+	"bytes"
+	"encoding/json"
+	"go/ast"
+	"go/parser"
+	"go/token"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+const src = `// Package shapes is a test input.
+package shapes
+
+import "errors"
+
+// TODO: add Triangle.
+const Pi, tau = 3.14, 6.28
+
+var ErrNegative = errors.New("negative")
+
+type Circle struct{ R float64 }
+
+type list[E any] struct{ items []E }
+
+func (c *Circle) Area() float64 { return Pi * c.R * c.R }
+
+func (l list[E]) Len() int { return len(l.items) }
+
+func (c Circle) scale(f float64) Circle { return Circle{c.R * f} }
+
+/* FIXME handle NaN */
+func Classify(n int, ok bool) string {
+	if n < 0 || !ok { // +2: if, ||
+		return "bad"
+	}
+	for i := range n { // +1
+		switch { // no branch of its own
+		case i%2 == 0 && i > 2: // +2: case, &&
+		case i == 1: // +1
+		default:
+		}
+	}
+	check := func() bool { return n > 10 } // literal: counted in Classify
+	if check() { // +1
+		return "big"
+	}
+	return "ok" // the word TODO here is not a TODO
+}
+`
+
+func parse(t *testing.T) *File {
+	t.Helper()
+	f, err := ParseFile(token.NewFileSet(), "shapes.go", "pkg/shapes.go", src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+func TestParseFile(t *testing.T) {
+	f := parse(t)
+	if f.Package != "shapes" || f.Test || f.Lines != strings.Count(src, "\n") {
+		t.Errorf("file = %+v", f)
+	}
+	want := []string{"Pi", "ErrNegative", "Circle", "Circle.Area", "Classify"}
+	if !slices.Equal(f.Exported, want) {
+		t.Errorf("Exported = %v; want %v", f.Exported, want)
+	}
+	var names []string
+	for _, fn := range f.Funcs {
+		names = append(names, fn.Name)
+	}
+	if want := []string{"Circle.Area", "list.Len", "Circle.scale", "Classify"}; !slices.Equal(names, want) {
+		t.Errorf("funcs = %v; want %v", names, want)
+	}
+	classify := f.Funcs[3]
+	if classify.Complexity != 8 || classify.Line != 22 || classify.Lines != 17 || classify.File != "pkg/shapes.go" {
+		t.Errorf("Classify = %+v; want complexity 8 at line 22, 17 lines", classify)
+	}
+	if f.Funcs[0].Complexity != 1 {
+		t.Errorf("Area complexity = %d; want 1", f.Funcs[0].Complexity)
+	}
+	wantTODOs := []TODO{{"pkg/shapes.go", 6, "TODO: add Triangle."}, {"pkg/shapes.go", 21, "FIXME handle NaN"}}
+	if !slices.Equal(f.TODOs, wantTODOs) {
+		t.Errorf("TODOs = %v; want %v", f.TODOs, wantTODOs)
+	}
+}
+
+func TestComplexity_Select(t *testing.T) {
+	const body = `package p
+func f(a, b chan int) {
+	select {
+	case <-a:
+	case v := <-b:
+		_ = v
+	default:
+	}
+	switch x := any(1).(type) {
+	case int, string:
+		_ = x
+	}
+}`
+	file, err := parser.ParseFile(token.NewFileSet(), "p.go", body, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 1 + two select cases + one type switch case.
+	if got := Complexity(file.Decls[0].(*ast.FuncDecl).Body); got != 4 {
+		t.Errorf("Complexity = %d; want 4", got)
+	}
+}
+
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestScan(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"a/go.mod":                "module a\n",
+		"a/shapes.go":             src,
+		"a/shapes_test.go":        "package shapes\n\nfunc TestX() { if true {} }\n",
+		"a/testdata/skip.go":      "package skip\n\nfunc Skipped() {}\n",
+		"a/nested/go.mod":         "module nested\n",
+		"a/nested/n.go":           "package n\n\nfunc N() {}\n",
+		"a/sub/s.go":              "package sub\n\nfunc S() {}\n",
+		"b_standalone/main.go":    "package main\n\nfunc main() {}\n",
+		"b_standalone/broken.go":  "package main\n\nfunc {\n",
+		".hidden/h.go":            "package h\n",
+		"z_after_module/go.mod":   "module z\n",
+		"z_after_module/after.go": "package z\n",
+	})
+	r, err := Scan(root, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := map[string]Summary{}
+	for _, s := range r.Modules {
+		got[s.Module] = *s
+	}
+	if a := got["a"]; a.Files != 3 || a.TestFiles != 1 || a.Funcs != 5 || a.Exported != 6 || a.TODOs != 2 || a.MaxComplexity != 8 {
+		t.Errorf("module a = %+v", a)
+	}
+	if n := got["a/nested"]; n.Files != 1 || n.Funcs != 1 {
+		t.Errorf("nested module = %+v", n)
+	}
+	if s := got["b_standalone"]; s.Files != 1 {
+		t.Errorf("standalone program = %+v", s)
+	}
+	if z := got["z_after_module"]; z.Files != 1 {
+		t.Errorf("z = %+v", z)
+	}
+	if len(r.Modules) != 4 || r.Totals.Files != 6 {
+		t.Errorf("%d modules, %d files in all; want 4 and 6", len(r.Modules), r.Totals.Files)
+	}
+	if len(r.Errors) != 1 || !strings.Contains(r.Errors[0], "broken.go") {
+		t.Errorf("Errors = %v", r.Errors)
+	}
+	if r.Funcs[0].Name != "Classify" {
+		t.Errorf("most complex = %+v", r.Funcs[0])
+	}
+
+	withTests, _ := Scan(root, Options{Tests: true})
+	if n := withTests.Totals.Funcs; n != r.Totals.Funcs+1 {
+		t.Errorf("with tests: %d funcs; want %d", n, r.Totals.Funcs+1)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{"go.mod": "module m\n", "shapes.go": src})
+	r, err := Scan(root, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var md bytes.Buffer
+	if err := r.WriteMarkdown(&md, 2); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"1 Go files (0 of them tests) in 1 modules and standalone programs: 38 lines, 4 functions, 5 exported identifiers, 2 TODOs.",
+		"| . | 1 | 0 | 38 | 4 | 5 | 2.8 | 8 | 2 |",
+		"| 8 | 17 | `Classify` | shapes.go:22 |",
+		"- shapes.go:6 — TODO: add Triangle.",
+	} {
+		if !strings.Contains(md.String(), want) {
+			t.Errorf("markdown lacks %q:\n%s", want, &md)
+		}
+	}
+	if n := strings.Count(md.String(), "| `"); n != 2 {
+		t.Errorf("%d functions listed; want the top 2", n)
+	}
+
+	var js bytes.Buffer
+	if err := r.WriteJSON(&js, 1); err != nil {
+		t.Fatal(err)
+	}
+	var decoded Report
+	if err := json.Unmarshal(js.Bytes(), &decoded); err != nil || len(decoded.Funcs) != 1 || decoded.Totals.Exported != 5 {
+		t.Errorf("JSON: %v\n%s", err, &js)
+	}
+}
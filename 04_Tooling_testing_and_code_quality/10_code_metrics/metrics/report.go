@@ -0,0 +1,69 @@
+package metrics
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// WriteJSON writes the report with at most top functions, most complex
+// first; zero or less means all of them.
+func (r *Report) WriteJSON(w io.Writer, top int) error {
+	out := *r
+	out.Funcs = limit(r.Funcs, top)
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// WriteMarkdown writes the report as a page for a repository: a summary
+// line, a table of modules, the top most complex functions and the TODOs.
+func (r *Report) WriteMarkdown(w io.Writer, top int) error {
+	t := r.Totals
+	var b strings.Builder
+	fmt.Fprintf(&b, "# Code metrics\n\n")
+	fmt.Fprintf(&b, "%d Go files (%d of them tests) in %d modules and standalone programs: %d lines, %d functions, %d exported identifiers, %d TODOs. "+
+		"Mean cyclomatic complexity %.1f, highest %d.\n",
+		t.Files, t.TestFiles, len(r.Modules), t.Lines, t.Funcs, t.Exported, t.TODOs, t.MeanComplexity, t.MaxComplexity)
+
+	fmt.Fprintf(&b, "\n## Modules\n\n")
+	fmt.Fprintf(&b, "| Module | Files | Tests | Lines | Functions | Exported | Mean complexity | Max | TODOs |\n")
+	fmt.Fprintf(&b, "|--------|------:|------:|------:|----------:|---------:|----------------:|----:|------:|\n")
+	for _, s := range r.Modules {
+		fmt.Fprintf(&b, "| %s | %d | %d | %d | %d | %d | %.1f | %d | %d |\n",
+			s.Module, s.Files, s.TestFiles, s.Lines, s.Funcs, s.Exported, s.MeanComplexity, s.MaxComplexity, s.TODOs)
+	}
+
+	if funcs := limit(r.Funcs, top); len(funcs) > 0 {
+		fmt.Fprintf(&b, "\n## Most complex functions\n\n")
+		fmt.Fprintf(&b, "| Complexity | Lines | Function | Where |\n")
+		fmt.Fprintf(&b, "|-----------:|------:|----------|-------|\n")
+		for _, f := range funcs {
+			fmt.Fprintf(&b, "| %d | %d | `%s` | %s:%d |\n", f.Complexity, f.Lines, f.Name, f.File, f.Line)
+		}
+	}
+
+	if len(r.TODOs) > 0 {
+		fmt.Fprintf(&b, "\n## TODOs\n\n")
+		for _, td := range r.TODOs {
+			fmt.Fprintf(&b, "- %s:%d — %s\n", td.File, td.Line, strings.ReplaceAll(td.Text, "|", `\|`))
+		}
+	}
+
+	if len(r.Errors) > 0 {
+		fmt.Fprintf(&b, "\n## Files that did not parse\n\n")
+		for _, e := range r.Errors {
+			fmt.Fprintf(&b, "- %s\n", e)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+	return err
+}
+
+func limit(funcs []Func, n int) []Func {
+	if n > 0 && len(funcs) > n {
+		return funcs[:n]
+	}
+	return funcs
+}
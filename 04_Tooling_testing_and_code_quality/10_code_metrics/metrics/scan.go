@@ -0,0 +1,157 @@
+package metrics
+
+import (
+	"cmp"
+	"go/token"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+)
+
+// Report is the result of a Scan.
+type Report struct {
+	Root    string     `json:"root"`
+	Totals  Summary    `json:"totals"`
+	Modules []*Summary `json:"modules"`
+	Funcs   []Func     `json:"funcs"` // most complex first
+	TODOs   []TODO     `json:"todos"`
+	Errors  []string   `json:"errors,omitempty"` // files that did not parse
+}
+
+// Summary adds up the files of one module, or of all of them.
+type Summary struct {
+	Module         string  `json:"module"` // directory of its go.mod, relative to the root, or "total"
+	Files          int     `json:"files"`
+	TestFiles      int     `json:"test_files"`
+	Lines          int     `json:"lines"`
+	Funcs          int     `json:"funcs"`
+	Exported       int     `json:"exported"`
+	TODOs          int     `json:"todos"`
+	MeanComplexity float64 `json:"mean_complexity"`
+	MaxComplexity  int     `json:"max_complexity"`
+
+	complexity int // sum, for the mean
+}
+
+type Options struct {
+	// Tests includes the functions of _test.go files in Funcs and the
+	// complexity figures. Their files, lines and TODOs always count.
+	Tests bool
+}
+
+// Scan measures the .go files under root, grouped by the module they
+// belong to. It skips testdata, vendor and hidden directories, as the go
+// command does. A file that does not parse is listed in Errors, not
+// fatal: one broken file should not hide the rest.
+func Scan(root string, opts Options) (*Report, error) {
+	r := &Report{Root: root, Totals: Summary{Module: "total"}}
+	modules := map[string]*Summary{}
+	fset := token.NewFileSet()
+
+	// The walk is depth first, so the modules enclosing the current path
+	// are a stack: leaving a module's directory pops it.
+	var modStack []string // innermost last
+	popTo := func(path string) {
+		for len(modStack) > 0 && !within(path, modStack[len(modStack)-1]) {
+			modStack = modStack[:len(modStack)-1]
+		}
+	}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			name := d.Name()
+			if path != root && (name == "testdata" || name == "vendor" || strings.HasPrefix(name, ".") || strings.HasPrefix(name, "_")) {
+				return filepath.SkipDir
+			}
+			popTo(path)
+			if _, err := os.Stat(filepath.Join(path, "go.mod")); err == nil {
+				modStack = append(modStack, path)
+			}
+			return nil
+		}
+		if !strings.HasSuffix(path, ".go") {
+			return nil
+		}
+		rel := relSlash(root, path)
+		f, err := ParseFile(fset, path, rel, nil)
+		if err != nil {
+			r.Errors = append(r.Errors, err.Error())
+			return nil
+		}
+
+		// A file outside any module is a program for "go run file.go";
+		// its directory stands in for the module.
+		popTo(path)
+		mod := relSlash(root, filepath.Dir(path))
+		if len(modStack) > 0 {
+			mod = relSlash(root, modStack[len(modStack)-1])
+		}
+		s := modules[mod]
+		if s == nil {
+			s = &Summary{Module: mod}
+			modules[mod] = s
+			r.Modules = append(r.Modules, s)
+		}
+		for _, sum := range []*Summary{s, &r.Totals} {
+			sum.add(f, opts)
+		}
+		if !f.Test || opts.Tests {
+			r.Funcs = append(r.Funcs, f.Funcs...)
+		}
+		r.TODOs = append(r.TODOs, f.TODOs...)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for _, s := range append(r.Modules, &r.Totals) {
+		if s.Funcs > 0 {
+			s.MeanComplexity = float64(s.complexity) / float64(s.Funcs)
+		}
+	}
+	slices.SortFunc(r.Modules, func(a, b *Summary) int { return strings.Compare(a.Module, b.Module) })
+	slices.SortStableFunc(r.Funcs, func(a, b Func) int {
+		return cmp.Or(cmp.Compare(b.Complexity, a.Complexity), cmp.Compare(b.Lines, a.Lines))
+	})
+	return r, nil
+}
+
+func (s *Summary) add(f *File, opts Options) {
+	s.Files++
+	if f.Test {
+		s.TestFiles++
+	}
+	s.Lines += f.Lines
+	s.TODOs += len(f.TODOs)
+	if f.Test {
+		if !opts.Tests {
+			return
+		}
+	} else {
+		s.Exported += len(f.Exported)
+	}
+	for _, fn := range f.Funcs {
+		s.Funcs++
+		s.complexity += fn.Complexity
+		s.MaxComplexity = max(s.MaxComplexity, fn.Complexity)
+	}
+}
+
+// within reports whether path is dir or below it.
+func within(path, dir string) bool {
+	rel, err := filepath.Rel(dir, path)
+	return err == nil && rel != ".." && !strings.HasPrefix(rel, ".."+string(filepath.Separator))
+}
+
+func relSlash(root, path string) string {
+	rel, err := filepath.Rel(root, path)
+	if err != nil {
+		return filepath.ToSlash(path)
+	}
+	return filepath.ToSlash(rel)
+}
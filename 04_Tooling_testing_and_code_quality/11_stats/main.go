@@ -0,0 +1,67 @@
+// Demonstrates summarizing timing samples without keeping them.
+//
+// This example shows:
+// - Welford's running mean and standard deviation
+// - An HDR-style histogram: percentiles to a fixed relative precision in fixed memory
+// - Per-goroutine histograms merged at the end, with no lock while recording
+// - A text histogram of a latency distribution
+package main
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/11_stats/stats"
+)
+
+func main() {
+	// Time sorting 1,000 ints, 40,000 times across 4 goroutines, each
+	// with its own histogram.
+	const workers, runs = 4, 10000
+	hists := make([]*stats.Histogram, workers)
+	var wg sync.WaitGroup
+	for w := range hists {
+		hists[w] = stats.MustHistogram(int64(time.Second), 2)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			data := make([]int, 1000)
+			for range runs {
+				for i := range data {
+					data[i] = rand.IntN(1 << 20)
+				}
+				start := time.Now()
+				slices.Sort(data)
+				hists[w].Record(int64(time.Since(start)))
+			}
+		}()
+	}
+	wg.Wait()
+
+	total := stats.MustHistogram(int64(time.Second), 2)
+	for _, h := range hists {
+		total.Merge(h)
+	}
+
+	fmt.Printf("slices.Sort of 1,000 ints, %d runs\n\n", total.Count())
+	fmt.Printf("  mean   %v ± %v\n", time.Duration(total.Mean()).Round(time.Microsecond/10), time.Duration(total.StdDev()).Round(time.Microsecond/10))
+	for _, p := range []float64{50, 90, 99, 99.9} {
+		fmt.Printf("  p%-5v %v\n", p, time.Duration(total.Percentile(p)))
+	}
+	fmt.Printf("  max    %v\n\n", time.Duration(total.Max()))
+
+	buckets := total.Buckets(12)
+	var most int64
+	for _, b := range buckets {
+		most = max(most, b.Count)
+	}
+	for _, b := range buckets {
+		fmt.Printf("  %10v %6d  %s\n", time.Duration(b.From).Round(time.Microsecond), b.Count, strings.Repeat("■", int(b.Count*40/most)))
+	}
+	fmt.Printf("\n%d samples in %d counters; keeping them all would take %d bytes.\n",
+		total.Count(), total.Counters(), total.Count()*8)
+}
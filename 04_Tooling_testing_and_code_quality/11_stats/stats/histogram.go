@@ -0,0 +1,208 @@
+package stats
+
+import (
+	"fmt"
+	"math"
+	"math/bits"
+)
+
+// Histogram counts integer samples, such as latencies in nanoseconds, in
+// buckets laid out like HdrHistogram's: each power of two is split into
+// the same number of equal sub-buckets, enough for the requested number of
+// significant decimal digits. A percentile read from it is within that
+// relative error of the exact one, at any magnitude: with 2 digits, 1.00ms
+// and 1.00s are both known to within 1%.
+//
+// Memory is fixed by the range and the precision, not by the number of
+// samples: 1ns to 1h with 2 digits is about 4,600 counters.
+type Histogram struct {
+	highest int64 // largest value recorded as itself
+	digits  int
+
+	subBucketCount   int64 // sub-buckets per power of two, itself a power of two
+	subBucketHalfMag uint  // log2(subBucketCount / 2)
+	subBucketHalf    int64
+	subBucketMask    int64
+	counts           []int64
+	total            int64
+	saturated        int64 // samples above highest, counted as highest
+	sum              Summary
+}
+
+// NewHistogram returns a histogram for values from 0 to highest, kept to
+// digits significant decimal digits (1 to 5). Larger values are counted as
+// highest; Saturated says how many were.
+func NewHistogram(highest int64, digits int) (*Histogram, error) {
+	if highest < 2 {
+		return nil, fmt.Errorf("stats: histogram highest value %d; want at least 2", highest)
+	}
+	if digits < 1 || digits > 5 {
+		return nil, fmt.Errorf("stats: histogram precision %d digits; want 1 to 5", digits)
+	}
+	// Enough sub-buckets that the widest one in each power of two is at
+	// most 10^-digits of its values.
+	largestSingleUnit := 2 * int64(math.Pow10(digits))
+	subBucketCount := int64(1) << bits.Len64(uint64(largestSingleUnit-1))
+	h := &Histogram{
+		highest:          highest,
+		digits:           digits,
+		subBucketCount:   subBucketCount,
+		subBucketHalfMag: uint(bits.Len64(uint64(subBucketCount))) - 2,
+		subBucketHalf:    subBucketCount / 2,
+		subBucketMask:    subBucketCount - 1,
+	}
+	buckets := 1
+	for smallestUntracked := subBucketCount; smallestUntracked <= highest; smallestUntracked <<= 1 {
+		buckets++
+	}
+	h.counts = make([]int64, (buckets+1)*int(h.subBucketHalf))
+	return h, nil
+}
+
+// MustHistogram is NewHistogram for arguments known to be valid.
+func MustHistogram(highest int64, digits int) *Histogram {
+	h, err := NewHistogram(highest, digits)
+	if err != nil {
+		panic(err)
+	}
+	return h
+}
+
+// Record adds one sample. Negative values are counted as 0.
+func (h *Histogram) Record(v int64) {
+	h.RecordN(v, 1)
+}
+
+// RecordN adds n samples of the same value.
+func (h *Histogram) RecordN(v, n int64) {
+	if n <= 0 {
+		return
+	}
+	v = max(v, 0)
+	if v > h.highest {
+		v = h.highest
+		h.saturated += n
+	}
+	h.counts[h.index(v)] += n
+	h.total += n
+	h.sum.Merge(Summary{n: n, mean: float64(v), min: float64(v), max: float64(v)})
+}
+
+// index returns the counter for v. The first power of two's sub-buckets
+// each hold one value; in the next, each holds two; and so on.
+func (h *Histogram) index(v int64) int {
+	bucket := int64(bits.Len64(uint64(v|h.subBucketMask))) - int64(h.subBucketHalfMag) - 1
+	sub := v >> uint(bucket)
+	return int(((bucket + 1) << h.subBucketHalfMag) + (sub - h.subBucketHalf))
+}
+
+// valueRange returns the smallest value counted at index i and the width
+// of its sub-bucket.
+func (h *Histogram) valueRange(i int) (lowest, width int64) {
+	bucket := int64(i>>h.subBucketHalfMag) - 1
+	sub := int64(i)&(h.subBucketHalf-1) + h.subBucketHalf
+	if bucket < 0 {
+		sub -= h.subBucketHalf
+		bucket = 0
+	}
+	return sub << uint(bucket), 1 << uint(bucket)
+}
+
+// Merge adds o's samples to h. The two must have the same range and
+// precision.
+func (h *Histogram) Merge(o *Histogram) error {
+	if o.highest != h.highest || o.digits != h.digits {
+		return fmt.Errorf("stats: merging a histogram of %d/%d digits into one of %d/%d", o.highest, o.digits, h.highest, h.digits)
+	}
+	for i, c := range o.counts {
+		h.counts[i] += c
+	}
+	h.total += o.total
+	h.saturated += o.saturated
+	h.sum.Merge(o.sum)
+	return nil
+}
+
+// Reset empties h, keeping its range and precision.
+func (h *Histogram) Reset() {
+	clear(h.counts)
+	h.total, h.saturated, h.sum = 0, 0, Summary{}
+}
+
+// Count returns the number of samples.
+func (h *Histogram) Count() int64 { return h.total }
+
+// Counters returns the number of counters, 8 bytes each. It depends only
+// on the range and precision.
+func (h *Histogram) Counters() int { return len(h.counts) }
+
+// Saturated returns the number of samples that were above the highest
+// value and counted as it.
+func (h *Histogram) Saturated() int64 { return h.saturated }
+
+// Min, Max, Mean and StdDev are exact, from the samples as recorded
+// (after clamping to the range).
+func (h *Histogram) Min() int64       { return int64(h.sum.Min()) }
+func (h *Histogram) Max() int64       { return int64(h.sum.Max()) }
+func (h *Histogram) Mean() float64    { return h.sum.Mean() }
+func (h *Histogram) StdDev() float64  { return h.sum.StdDev() }
+func (h *Histogram) Summary() Summary { return h.sum }
+
+// Percentile returns the value that p percent of the samples are at or
+// below, by the nearest-rank method, to the histogram's precision: the
+// highest value of the sub-bucket holding that sample, but never more than
+// Max. It returns 0 for an empty histogram.
+func (h *Histogram) Percentile(p float64) int64 {
+	if h.total == 0 {
+		return 0
+	}
+	p = math.Max(0, math.Min(p, 100))
+	rank := max(int64(math.Ceil(p/100*float64(h.total))), 1)
+	var seen int64
+	for i, c := range h.counts {
+		if seen += c; seen >= rank {
+			lowest, width := h.valueRange(i)
+			return min(lowest+width-1, h.Max())
+		}
+	}
+	return h.Max()
+}
+
+// Bucket is a range of values [From, To) and how many samples fell in it.
+type Bucket struct {
+	From, To int64
+	Count    int64
+}
+
+// Buckets returns n equal-width buckets from Min to Max, for drawing the
+// distribution; the last one also takes the remainder of the division.
+// With a single distinct value, it returns one bucket. Samples are placed
+// by their sub-bucket, so the counts are as precise as the histogram.
+func (h *Histogram) Buckets(n int) []Bucket {
+	if h.total == 0 || n < 1 {
+		return nil
+	}
+	lo, hi := h.Min(), h.Max()
+	width := (hi - lo) / int64(n)
+	if width == 0 {
+		if hi == lo {
+			return []Bucket{{From: lo, To: hi + 1, Count: h.total}}
+		}
+		width, n = 1, int(hi-lo+1)
+	}
+	out := make([]Bucket, n)
+	for i := range out {
+		out[i].From = lo + int64(i)*width
+		out[i].To = out[i].From + width
+	}
+	out[n-1].To = hi + 1
+	for i, c := range h.counts {
+		if c == 0 {
+			continue
+		}
+		v, _ := h.valueRange(i)
+		v = max(v, lo)
+		out[min(int((v-lo)/width), n-1)].Count += c
+	}
+	return out
+}
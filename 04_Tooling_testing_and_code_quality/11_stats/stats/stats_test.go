@@ -0,0 +1,267 @@
+package stats
+
+import (
+	"math"
+	"math/rand/v2"
+	"slices"
+	"testing"
+	"testing/quick"
+)
+
+// The reference implementations: keep every sample, sort, and count.
+
+func exactPercentile(samples []int64, p float64) int64 {
+	sorted := slices.Clone(samples)
+	slices.Sort(sorted)
+	rank := max(int(math.Ceil(p/100*float64(len(sorted)))), 1)
+	return sorted[rank-1]
+}
+
+func twoPass(samples []float64) (mean, variance float64) {
+	for _, x := range samples {
+		mean += x
+	}
+	mean /= float64(len(samples))
+	for _, x := range samples {
+		variance += (x - mean) * (x - mean)
+	}
+	if len(samples) > 1 {
+		variance /= float64(len(samples) - 1)
+	}
+	return mean, variance
+}
+
+func approx(got, want, tol float64) bool {
+	return math.Abs(got-want) <= tol*math.Max(1, math.Abs(want))
+}
+
+// withinPrecision reports whether got is an acceptable histogram answer
+// for the exact value want: not below it, and above by at most the
+// relative error of the precision.
+func withinPrecision(got, want int64, digits int) bool {
+	return got >= want && float64(got-want) <= float64(want)*math.Pow10(-digits)
+}
+
+var percentiles = []float64{0, 1, 25, 50, 90, 95, 99, 99.9, 100}
+
+func TestHistogram_MatchesReference(t *testing.T) {
+	r := rand.New(rand.NewPCG(1, 2))
+	dists := map[string]func() int64{
+		"uniform":     func() int64 { return r.Int64N(1000) },
+		"exponential": func() int64 { return int64(r.ExpFloat64() * 2e6) },
+		"lognormal":   func() int64 { return int64(math.Exp(r.NormFloat64()*2 + 14)) },
+		"bimodal": func() int64 {
+			if r.IntN(100) < 3 {
+				return 50e6 + r.Int64N(1e6)
+			}
+			return 1e6 + r.Int64N(1e5)
+		},
+		"constant": func() int64 { return 123456789 },
+	}
+	for name, next := range dists {
+		for _, digits := range []int{1, 2, 3} {
+			h := MustHistogram(1e12, digits)
+			samples := make([]int64, 10000)
+			for i := range samples {
+				samples[i] = next()
+				h.Record(samples[i])
+			}
+			for _, p := range percentiles {
+				got, want := h.Percentile(p), exactPercentile(samples, p)
+				if !withinPrecision(got, want, digits) {
+					t.Errorf("%s, %d digits: p%v = %d; exact %d", name, digits, p, got, want)
+				}
+			}
+			if h.Min() != slices.Min(samples) || h.Max() != slices.Max(samples) || h.Count() != int64(len(samples)) {
+				t.Errorf("%s: min, max, count = %d, %d, %d", name, h.Min(), h.Max(), h.Count())
+			}
+		}
+	}
+}
+
+// The same property, on inputs chosen by testing/quick.
+func TestHistogram_Property(t *testing.T) {
+	f := func(raw []uint32, pct uint16) bool {
+		if len(raw) == 0 {
+			return true
+		}
+		p := float64(pct%1001) / 10 // 0.0 to 100.0
+		h := MustHistogram(math.MaxUint32, 2)
+		samples := make([]int64, len(raw))
+		for i, v := range raw {
+			samples[i] = int64(v)
+			h.Record(samples[i])
+		}
+		return withinPrecision(h.Percentile(p), exactPercentile(samples, p), 2)
+	}
+	if err := quick.Check(f, &quick.Config{MaxCount: 500}); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestHistogram_Merge(t *testing.T) {
+	f := func(a, b []uint32) bool {
+		all, ha, hb := MustHistogram(math.MaxUint32, 2), MustHistogram(math.MaxUint32, 2), MustHistogram(math.MaxUint32, 2)
+		for _, v := range a {
+			ha.Record(int64(v))
+			all.Record(int64(v))
+		}
+		for _, v := range b {
+			hb.Record(int64(v))
+			all.Record(int64(v))
+		}
+		if err := ha.Merge(hb); err != nil {
+			return false
+		}
+		if !slices.Equal(ha.counts, all.counts) || ha.Count() != all.Count() || ha.Min() != all.Min() || ha.Max() != all.Max() {
+			return false
+		}
+		return approx(ha.Mean(), all.Mean(), 1e-9) && approx(ha.StdDev(), all.StdDev(), 1e-9)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+	if err := MustHistogram(1000, 2).Merge(MustHistogram(1000, 3)); err == nil {
+		t.Error("merged histograms of different precision")
+	}
+}
+
+func TestHistogram_SmallValuesExact(t *testing.T) {
+	// Below the sub-bucket count, every value has its own counter.
+	h := MustHistogram(1e9, 2)
+	for v := int64(0); v < 256; v++ {
+		h.Reset()
+		h.Record(v)
+		if got := h.Percentile(50); got != v {
+			t.Fatalf("Percentile of a single %d = %d", v, got)
+		}
+	}
+}
+
+func TestHistogram_Edges(t *testing.T) {
+	h := MustHistogram(1000, 2)
+	if h.Percentile(50) != 0 || h.Buckets(10) != nil {
+		t.Error("empty histogram: want 0 and no buckets")
+	}
+	h.Record(-5)   // counted as 0
+	h.Record(5000) // counted as 1000
+	h.RecordN(10, 3)
+	h.RecordN(10, 0)
+	if h.Count() != 5 || h.Saturated() != 1 || h.Min() != 0 || h.Max() != 1000 {
+		t.Errorf("count, saturated, min, max = %d, %d, %d, %d", h.Count(), h.Saturated(), h.Min(), h.Max())
+	}
+	if h.Percentile(100) != 1000 || h.Percentile(150) != 1000 || h.Percentile(-1) != 0 {
+		t.Errorf("p100, p150, p-1 = %d, %d, %d", h.Percentile(100), h.Percentile(150), h.Percentile(-1))
+	}
+
+	var total int64
+	for _, b := range h.Buckets(7) {
+		total += b.Count
+	}
+	if total != h.Count() {
+		t.Errorf("buckets hold %d samples; want %d", total, h.Count())
+	}
+
+	for _, bad := range [][2]int64{{1, 2}, {1000, 0}, {1000, 6}} {
+		if _, err := NewHistogram(bad[0], int(bad[1])); err == nil {
+			t.Errorf("NewHistogram(%d, %d) succeeded", bad[0], bad[1])
+		}
+	}
+}
+
+func TestSummary_MatchesReference(t *testing.T) {
+	f := func(xs []float64) bool {
+		var s Summary
+		for i := range xs {
+			xs[i] = math.Mod(xs[i], 1e6) // keep sums finite
+			s.Add(xs[i])
+		}
+		if len(xs) == 0 {
+			return s.Count() == 0 && s.Mean() == 0 && s.StdDev() == 0
+		}
+		mean, variance := twoPass(xs)
+		return s.Count() == int64(len(xs)) &&
+			approx(s.Mean(), mean, 1e-9) && approx(s.Variance(), variance, 1e-6) &&
+			s.Min() == slices.Min(xs) && s.Max() == slices.Max(xs)
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+// Samples far from zero: sum(x²)/n - mean² loses every digit here.
+func TestSummary_Stable(t *testing.T) {
+	var s Summary
+	for _, d := range []float64{4, 7, 13, 16} {
+		s.Add(1e9 + d)
+	}
+	if !approx(s.Variance(), 30, 1e-9) || !approx(s.Mean(), 1e9+10, 1e-12) {
+		t.Errorf("mean, variance = %v, %v; want 1e9+10, 30", s.Mean(), s.Variance())
+	}
+
+	var sum, sumSq float64
+	for _, d := range []float64{4, 7, 13, 16} {
+		x := 1e9 + d
+		sum += x
+		sumSq += x * x
+	}
+	naive := (sumSq - sum*sum/4) / 3
+	t.Logf("textbook formula: %v; Welford: %v", naive, s.Variance())
+}
+
+func TestSummary_Merge(t *testing.T) {
+	f := func(a, b []int16) bool {
+		var sa, sb, all Summary
+		for _, x := range a {
+			sa.Add(float64(x))
+			all.Add(float64(x))
+		}
+		for _, x := range b {
+			sb.Add(float64(x))
+			all.Add(float64(x))
+		}
+		sa.Merge(sb)
+		return sa.Count() == all.Count() && approx(sa.Mean(), all.Mean(), 1e-9) &&
+			approx(sa.Variance(), all.Variance(), 1e-9) && sa.Min() == all.Min() && sa.Max() == all.Max()
+	}
+	if err := quick.Check(f, nil); err != nil {
+		t.Error(err)
+	}
+}
+
+func BenchmarkHistogram_Record(b *testing.B) {
+	h := MustHistogram(3.6e12, 2)
+	r := rand.New(rand.NewPCG(1, 2))
+	vals := make([]int64, 1024)
+	for i := range vals {
+		vals[i] = int64(r.ExpFloat64() * 2e6)
+	}
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		h.Record(vals[i%len(vals)])
+	}
+}
+
+func TestHistogram_Buckets(t *testing.T) {
+	h := MustHistogram(1000, 3)
+	for _, v := range []int64{30, 10, 20, 10, 110} {
+		h.Record(v)
+	}
+	want := []Bucket{{10, 20, 2}, {20, 30, 1}, {30, 40, 1}, {40, 50, 0}, {50, 60, 0}, {60, 70, 0}, {70, 80, 0}, {80, 90, 0}, {90, 100, 0}, {100, 111, 1}}
+	if got := h.Buckets(10); !slices.Equal(got, want) {
+		t.Errorf("Buckets(10) =\n%v\nwant\n%v", got, want)
+	}
+
+	// Fewer distinct values than buckets: one bucket per value.
+	h.Reset()
+	h.RecordN(7, 2)
+	h.Record(9)
+	if got, want := h.Buckets(10), []Bucket{{7, 8, 2}, {8, 9, 0}, {9, 10, 1}}; !slices.Equal(got, want) {
+		t.Errorf("Buckets(10) of 7,7,9 = %v; want %v", got, want)
+	}
+	h.Reset()
+	h.RecordN(7, 2)
+	if got, want := h.Buckets(10), []Bucket{{7, 8, 2}}; !slices.Equal(got, want) {
+		t.Errorf("Buckets(10) of 7,7 = %v; want %v", got, want)
+	}
+}
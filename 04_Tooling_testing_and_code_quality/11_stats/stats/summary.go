@@ -0,0 +1,82 @@
+// Package stats summarizes samples such as latencies one at a time,
+// without keeping them: Summary for count, mean, standard deviation, min
+// and max, and Histogram for percentiles in a fixed amount of memory.
+//
+// Both can be merged, so each worker of a load test or each shard of a
+// server can keep its own and the totals are computed at the end, without
+// a lock on the hot path. Neither is safe for concurrent use; give each
+// goroutine its own, or guard one with a mutex.
+package stats
+
+import "math"
+
+// Summary is a running count, mean, variance, min and max. It uses
+// Welford's algorithm: the mean and the sum of squared differences from it
+// are updated with each sample. The textbook formula, sum(x²)/n - mean²,
+// subtracts two large, nearly equal numbers and can lose every significant
+// digit, or go negative, for samples far from zero.
+//
+// The zero Summary is empty and ready to use.
+type Summary struct {
+	n        int64
+	mean     float64
+	m2       float64 // sum of squared differences from the mean
+	min, max float64
+}
+
+// Add records one sample.
+func (s *Summary) Add(x float64) {
+	s.n++
+	if s.n == 1 {
+		s.min, s.max = x, x
+	} else {
+		s.min = math.Min(s.min, x)
+		s.max = math.Max(s.max, x)
+	}
+	d := x - s.mean
+	s.mean += d / float64(s.n)
+	s.m2 += d * (x - s.mean)
+}
+
+// Merge adds the samples summarized by o, as if each had been added to s
+// (Chan et al.'s parallel form of Welford's algorithm).
+func (s *Summary) Merge(o Summary) {
+	switch {
+	case o.n == 0:
+		return
+	case s.n == 0:
+		*s = o
+		return
+	}
+	n := s.n + o.n
+	d := o.mean - s.mean
+	s.mean += d * float64(o.n) / float64(n)
+	s.m2 += o.m2 + d*d*float64(s.n)*float64(o.n)/float64(n)
+	s.n = n
+	s.min = math.Min(s.min, o.min)
+	s.max = math.Max(s.max, o.max)
+}
+
+// Count returns the number of samples.
+func (s *Summary) Count() int64 { return s.n }
+
+// Mean returns the mean, or 0 with no samples.
+func (s *Summary) Mean() float64 { return s.mean }
+
+// Min returns the smallest sample, or 0 with no samples.
+func (s *Summary) Min() float64 { return s.min }
+
+// Max returns the largest sample, or 0 with no samples.
+func (s *Summary) Max() float64 { return s.max }
+
+// Variance returns the sample variance (divided by n-1), or 0 with fewer
+// than two samples.
+func (s *Summary) Variance() float64 {
+	if s.n < 2 {
+		return 0
+	}
+	return s.m2 / float64(s.n-1)
+}
+
+// StdDev returns the sample standard deviation.
+func (s *Summary) StdDev() float64 { return math.Sqrt(s.Variance()) }
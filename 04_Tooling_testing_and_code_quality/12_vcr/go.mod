@@ -0,0 +1,3 @@
+module golang_roadmap/04_Tooling_testing_and_code_quality/12_vcr
+
+go 1.24.11
@@ -0,0 +1,163 @@
+// Demonstrates recording HTTP exchanges to a cassette and replaying them.
+//
+// This example shows:
+// - A recording http.RoundTripper in front of a live server
+// - The same requests replayed after the server is gone, byte for byte
+// - A request the cassette cannot answer, and a header that was redacted
+// - The cassette served as a mock server for any HTTP client
+//
+// With -record or -replay it runs as a proxy instead, for recording a
+// client that is not written in Go:
+//
+//	go run . -record https://api.example.com -cassette api.json
+//	go run . -replay api.json
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/12_vcr/vcr"
+)
+
+func main() {
+	record := flag.String("record", "", "run a recording proxy to this URL")
+	replay := flag.String("replay", "", "run a mock server answering from this cassette")
+	cassette := flag.String("cassette", "cassette.json", "cassette written by -record")
+	addr := flag.String("addr", "localhost:8081", "proxy listen address")
+	flag.Parse()
+
+	switch {
+	case *record != "":
+		target, err := url.Parse(*record)
+		if err != nil || target.Host == "" {
+			log.Fatalf("-record: %q is not an absolute URL", *record)
+		}
+		rec, _ := vcr.New(*cassette, vcr.Options{Mode: vcr.ModeRecord})
+		serve(*addr, rec.Handler(target))
+		if err := rec.Save(); err != nil {
+			log.Fatal(err)
+		}
+		log.Printf("Saved %s", *cassette)
+	case *replay != "":
+		rec, err := vcr.New(*replay, vcr.Options{})
+		if err != nil {
+			log.Fatal(err)
+		}
+		serve(*addr, rec.Handler(nil))
+	default:
+		demo()
+	}
+}
+
+// serve runs h on addr until Ctrl-C.
+func serve(addr string, h http.Handler) {
+	srv := &http.Server{Addr: addr, Handler: h, ReadHeaderTimeout: 5 * time.Second}
+	go func() {
+		log.Printf("Listening on http://%s; Ctrl-C to stop", addr)
+		if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+			log.Fatal(err)
+		}
+	}()
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	<-ctx.Done()
+	shutdown, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	srv.Shutdown(shutdown)
+}
+
+func demo() {
+	fmt.Println("VCR examples starting...")
+	dir, err := os.MkdirTemp("", "vcr")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "quotes.json")
+
+	// A server whose answers change on every call, as real ones do.
+	var calls atomic.Int64
+	upstream := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := calls.Add(1)
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"call":%d,"path":%q,"at":%q}`+"\n", n, r.URL.Path, time.Now().Format(time.RFC3339Nano))
+	}))
+
+	fmt.Println("\n1) Record: requests go to the server and into the cassette")
+	rec, _ := vcr.New(path, vcr.Options{Mode: vcr.ModeRecord})
+	client := &http.Client{Transport: rec}
+	recorded := []string{
+		get(client, upstream.URL+"/quotes/1", "Authorization", "Bearer s3cret"),
+		get(client, upstream.URL+"/quotes/2"),
+		get(client, upstream.URL+"/quotes/1"),
+	}
+	if err := rec.Save(); err != nil {
+		log.Fatal(err)
+	}
+	upstream.Close()
+	data, _ := os.ReadFile(path)
+	fmt.Printf("   %s holds %d bytes, starting:\n", filepath.Base(path), len(data))
+	for _, line := range strings.SplitN(string(data), "\n", 12)[:11] {
+		fmt.Println("   " + line)
+	}
+
+	fmt.Println("\n2) Replay: the server is gone, the answers are the same")
+	rec, err = vcr.New(path, vcr.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	client = &http.Client{Transport: rec}
+	for i, u := range []string{"/quotes/1", "/quotes/2", "/quotes/1"} {
+		got := get(client, upstream.URL+u)
+		fmt.Printf("   same as recorded: %v\n", got == recorded[i])
+	}
+
+	fmt.Println("\n3) A request the cassette has no answer for")
+	_, err = client.Get(upstream.URL + "/quotes/3")
+	fmt.Printf("   err: %v\n   is ErrNoInteraction: %v\n", err, errors.Is(err, vcr.ErrNoInteraction))
+
+	fmt.Println("\n4) The cassette as a mock server, for any client")
+	rec, _ = vcr.New(path, vcr.Options{})
+	mock := httptest.NewServer(rec.Handler(nil))
+	defer mock.Close()
+	get(http.DefaultClient, mock.URL+"/quotes/2")
+	resp, err := http.Get(mock.URL + "/quotes/2") // used up
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Body.Close()
+	fmt.Printf("   again: %s\n", resp.Status)
+	fmt.Printf("   unused: %d recorded exchanges\n", len(rec.Unused()))
+
+	fmt.Printf("\nThe server answered %d requests; the rest came from the cassette.\n", calls.Load())
+}
+
+// get fetches u with an optional header and returns the body.
+func get(c *http.Client, u string, header ...string) string {
+	req, _ := http.NewRequest(http.MethodGet, u, nil)
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	fmt.Printf("   GET %s -> %d %s", req.URL.Path, resp.StatusCode, body)
+	return string(body)
+}
@@ -0,0 +1,235 @@
+package vcr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+)
+
+// ErrNoInteraction is returned in replay mode for a request the cassette
+// has no unused answer for.
+var ErrNoInteraction = errors.New("no recorded interaction")
+
+// Mode says where responses come from.
+type Mode int
+
+const (
+	// ModeReplay answers from the cassette and never uses the network.
+	ModeReplay Mode = iota
+	// ModeRecord sends every request and records the exchange, replacing
+	// what the cassette held.
+	ModeRecord
+)
+
+func (m Mode) String() string {
+	if m == ModeRecord {
+		return "record"
+	}
+	return "replay"
+}
+
+// Redacted replaces the values of the Redact headers in a cassette.
+const Redacted = "REDACTED"
+
+// Options configures a Recorder. Zero values select the defaults.
+type Options struct {
+	Mode Mode
+	// Transport sends requests in record mode (default
+	// http.DefaultTransport).
+	Transport http.RoundTripper
+	// Match picks the recorded request for a request (default
+	// DefaultMatcher).
+	Match Matcher
+	// Redact lists headers, of requests and responses, whose values are
+	// not saved (default Authorization, Proxy-Authorization, Cookie,
+	// Set-Cookie and X-API-Key). Replayed, they read "REDACTED".
+	Redact []string
+}
+
+var defaultRedact = []string{"Authorization", "Proxy-Authorization", "Cookie", "Set-Cookie", "X-API-Key"}
+
+// Recorder is an http.RoundTripper that records or replays. It is safe
+// for concurrent use.
+type Recorder struct {
+	path string
+	opts Options
+
+	mu       sync.Mutex
+	cassette *Cassette
+	used     []bool
+}
+
+// New returns a recorder for the cassette at path. In replay mode the
+// file must exist; in record mode it is written by Save.
+func New(path string, opts Options) (*Recorder, error) {
+	if opts.Transport == nil {
+		opts.Transport = http.DefaultTransport
+	}
+	if opts.Match == nil {
+		opts.Match = DefaultMatcher
+	}
+	if opts.Redact == nil {
+		opts.Redact = defaultRedact
+	}
+	r := &Recorder{path: path, opts: opts, cassette: &Cassette{}}
+	if opts.Mode == ModeReplay {
+		c, err := Load(path)
+		if err != nil {
+			return nil, err
+		}
+		r.cassette = c
+		r.used = make([]bool, len(c.Interactions))
+	}
+	return r, nil
+}
+
+// Mode returns the recorder's mode.
+func (r *Recorder) Mode() Mode { return r.opts.Mode }
+
+// RoundTrip answers req from the cassette or, in record mode, from the
+// network.
+func (r *Recorder) RoundTrip(req *http.Request) (*http.Response, error) {
+	body, err := readBody(req)
+	if err != nil {
+		return nil, err
+	}
+	if r.opts.Mode == ModeReplay {
+		rec, err := r.replay(&Request{Method: req.Method, URL: req.URL.String(), Body: body})
+		if err != nil {
+			return nil, err
+		}
+		return rec.response(req), nil
+	}
+
+	out := req.Clone(req.Context())
+	if body != nil {
+		out.Body = io.NopCloser(bytes.NewReader(body))
+	}
+	resp, err := r.opts.Transport.RoundTrip(out)
+	if err != nil {
+		return nil, err // not recorded: there is nothing to replay
+	}
+	respBody, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(respBody))
+	r.mu.Lock()
+	r.cassette.Interactions = append(r.cassette.Interactions, Interaction{
+		Request:  Request{Method: req.Method, URL: req.URL.String(), Header: r.redact(req.Header.Clone()), Body: body},
+		Response: Response{Status: resp.StatusCode, Header: r.redact(resp.Header.Clone()), Body: respBody},
+	})
+	r.mu.Unlock()
+	return resp, nil
+}
+
+// replay returns the first unused recorded response whose request
+// matches got. Identical requests, such as a list fetched before and
+// after a change, get their responses in the order they were recorded.
+func (r *Recorder) replay(got *Request) (*Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for i := range r.cassette.Interactions {
+		it := &r.cassette.Interactions[i]
+		if !r.used[i] && r.opts.Match(got, &it.Request) {
+			r.used[i] = true
+			return &it.Response, nil
+		}
+	}
+	return nil, fmt.Errorf("vcr: %s %s in %s: %w", got.Method, got.URL, r.path, ErrNoInteraction)
+}
+
+// Save writes the recorded exchanges to the cassette file. In replay
+// mode it does nothing.
+func (r *Recorder) Save() error {
+	if r.opts.Mode == ModeReplay {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.cassette.Save(r.path)
+}
+
+// Unused returns the recorded exchanges that were not replayed: requests
+// the code under test no longer makes.
+func (r *Recorder) Unused() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []Interaction
+	for i, it := range r.cassette.Interactions {
+		if i < len(r.used) && !r.used[i] {
+			out = append(out, it)
+		}
+	}
+	return out
+}
+
+// ForTest returns a recorder for testdata/cassettes/<name>.json, in
+// record mode if the VCR_MODE environment variable is "record" and in
+// replay mode otherwise. When the test ends, a recording is saved, and a
+// replay that left exchanges unused fails the test.
+func ForTest(t testing.TB, name string, opts Options) *Recorder {
+	t.Helper()
+	opts.Mode = ModeReplay
+	if os.Getenv("VCR_MODE") == "record" {
+		opts.Mode = ModeRecord
+	}
+	path := filepath.Join("testdata", "cassettes", name+".json")
+	r, err := New(path, opts)
+	if errors.Is(err, os.ErrNotExist) {
+		t.Fatalf("vcr: no cassette %s; record it with VCR_MODE=record go test -run '^%s$'", path, t.Name())
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		if err := r.Save(); err != nil {
+			t.Errorf("vcr: saving %s: %v", path, err)
+		}
+		if unused := r.Unused(); len(unused) > 0 && !t.Failed() {
+			t.Errorf("vcr: %d recorded requests in %s were not made, the first %s %s; re-record it with VCR_MODE=record",
+				len(unused), path, unused[0].Request.Method, unused[0].Request.URL)
+		}
+	})
+	return r
+}
+
+func (r *Recorder) redact(h http.Header) http.Header {
+	for _, k := range r.opts.Redact {
+		if _, ok := h[http.CanonicalHeaderKey(k)]; ok {
+			h.Set(k, Redacted)
+		}
+	}
+	return h
+}
+
+// readBody reads and closes req's body.
+func readBody(req *http.Request) ([]byte, error) {
+	if req.Body == nil || req.Body == http.NoBody {
+		return nil, nil
+	}
+	defer req.Body.Close()
+	return io.ReadAll(req.Body)
+}
+
+func (rec *Response) response(req *http.Request) *http.Response {
+	return &http.Response{
+		Status:        strconv.Itoa(rec.Status) + " " + http.StatusText(rec.Status),
+		StatusCode:    rec.Status,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        rec.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(rec.Body)),
+		ContentLength: int64(len(rec.Body)),
+		Request:       req,
+	}
+}
@@ -0,0 +1,34 @@
+package vcr
+
+import (
+	"log"
+	"net/http"
+	"net/http/httputil"
+	"net/url"
+)
+
+// Handler serves HTTP through r. In record mode it is a recording proxy
+// to target: point a client, or a program that is not written in Go, at
+// it and the exchanges land in the cassette. In replay mode it is a mock
+// server that answers from the cassette, and target may be nil.
+//
+// A request the cassette cannot answer gets a 502 that names it.
+func (r *Recorder) Handler(target *url.URL) http.Handler {
+	return &httputil.ReverseProxy{
+		Rewrite: func(pr *httputil.ProxyRequest) {
+			if target != nil {
+				pr.SetURL(target)
+				return
+			}
+			// Replay only: the recorder needs an absolute URL, and the
+			// matcher ignores the host.
+			pr.Out.URL.Scheme = "http"
+			pr.Out.URL.Host = pr.In.Host
+		},
+		Transport: r,
+		ErrorHandler: func(w http.ResponseWriter, req *http.Request, err error) {
+			log.Print(err)
+			http.Error(w, err.Error(), http.StatusBadGateway)
+		},
+	}
+}
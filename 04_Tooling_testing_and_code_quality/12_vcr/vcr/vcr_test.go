@@ -0,0 +1,230 @@
+package vcr
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+)
+
+// counter answers every request with its method, path, body and a call
+// number, so a replayed answer can be told from a fresh one.
+func counter(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	var calls atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := io.ReadAll(r.Body)
+		http.SetCookie(w, &http.Cookie{Name: "session", Value: "abc"})
+		w.Header().Set("X-Call", fmt.Sprint(calls.Add(1)))
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+		}
+		fmt.Fprintf(w, "%s %s %s", r.Method, r.URL.RequestURI(), body)
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &calls
+}
+
+func do(t *testing.T, c *http.Client, method, u, body string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, u, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Authorization", "Bearer s3cret")
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatalf("%s %s: %v", method, u, err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, string(b)
+}
+
+func TestRecordThenReplay(t *testing.T) {
+	srv, calls := counter(t)
+	path := filepath.Join(t.TempDir(), "c.json")
+
+	rec, err := New(path, Options{Mode: ModeRecord})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c := &http.Client{Transport: rec}
+	type exchange struct {
+		method, path, body string
+	}
+	script := []exchange{
+		{"GET", "/users?b=2&a=1", ""},
+		{"POST", "/users", `{"name":"Alice"}`},
+		{"GET", "/users?b=2&a=1", ""}, // same request, new answer
+		{"GET", "/missing", ""},
+	}
+	var want []string
+	var wantCalls []string
+	for _, e := range script {
+		resp, body := do(t, c, e.method, srv.URL+e.path, e.body)
+		want = append(want, body)
+		wantCalls = append(wantCalls, resp.Header.Get("X-Call"))
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	srv.Close()
+
+	rec, err = New(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	c = &http.Client{Transport: rec}
+	// Another host and the query in another order still match.
+	for i, e := range script {
+		p := strings.Replace(e.path, "b=2&a=1", "a=1&b=2", 1)
+		resp, body := do(t, c, e.method, "http://replay.test"+p, e.body)
+		if body != want[i] || resp.Header.Get("X-Call") != wantCalls[i] {
+			t.Errorf("%s %s: replayed %q (call %s), recorded %q (call %s)", e.method, p, body, resp.Header.Get("X-Call"), want[i], wantCalls[i])
+		}
+		if e.path == "/missing" && resp.StatusCode != http.StatusNotFound {
+			t.Errorf("%s: status %d, want 404", e.path, resp.StatusCode)
+		}
+		if got := resp.Header.Get("Set-Cookie"); got != Redacted {
+			t.Errorf("Set-Cookie replayed as %q, want %q", got, Redacted)
+		}
+	}
+	if calls.Load() != int64(len(script)) {
+		t.Errorf("server got %d calls, want %d: replay used the network", calls.Load(), len(script))
+	}
+	if n := len(rec.Unused()); n != 0 {
+		t.Errorf("%d exchanges unused", n)
+	}
+
+	// Each recorded answer is used once, and a different body is a
+	// different request.
+	for _, e := range []exchange{{"GET", "/users?a=1&b=2", ""}, {"POST", "/users", `{"name":"Bob"}`}} {
+		req, _ := http.NewRequest(e.method, "http://replay.test"+e.path, strings.NewReader(e.body))
+		if _, err := c.Do(req); !errors.Is(err, ErrNoInteraction) {
+			t.Errorf("%s %s: %v, want ErrNoInteraction", e.method, e.path, err)
+		}
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains(data, []byte("s3cret")) || bytes.Contains(data, []byte("session=abc")) {
+		t.Errorf("cassette holds a secret:\n%s", data)
+	}
+}
+
+func TestBinaryBody(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G', 0xff, 0x00}
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write(png) }))
+	defer srv.Close()
+	path := filepath.Join(t.TempDir(), "c.json")
+
+	rec, _ := New(path, Options{Mode: ModeRecord})
+	if _, err := (&http.Client{Transport: rec}).Get(srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+	if data, _ := os.ReadFile(path); !bytes.Contains(data, []byte(`"base64"`)) {
+		t.Errorf("binary body not saved as base64:\n%s", data)
+	}
+
+	rec, err := New(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := (&http.Client{Transport: rec}).Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, _ := io.ReadAll(resp.Body)
+	if !bytes.Equal(got, png) {
+		t.Errorf("replayed % x, want % x", got, png)
+	}
+}
+
+func TestRecordingErrorsAreNotSaved(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "c.json")
+	rec, _ := New(path, Options{Mode: ModeRecord})
+	if _, err := (&http.Client{Transport: rec}).Get("http://127.0.0.1:1/"); err == nil {
+		t.Fatal("no error from a closed port")
+	}
+	rec.Save()
+	c, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(c.Interactions) != 0 {
+		t.Errorf("saved %d interactions, want none", len(c.Interactions))
+	}
+}
+
+func TestMissingCassette(t *testing.T) {
+	_, err := New(filepath.Join(t.TempDir(), "none.json"), Options{})
+	if !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("New: %v, want ErrNotExist", err)
+	}
+}
+
+func TestHandlerProxyAndMock(t *testing.T) {
+	srv, calls := counter(t)
+	target, _ := url.Parse(srv.URL)
+	path := filepath.Join(t.TempDir(), "c.json")
+
+	rec, _ := New(path, Options{Mode: ModeRecord})
+	proxy := httptest.NewServer(rec.Handler(target))
+	_, recorded := do(t, http.DefaultClient, "POST", proxy.URL+"/hooks", "ping")
+	proxy.Close()
+	if err := rec.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	rec, err := New(path, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	mock := httptest.NewServer(rec.Handler(nil))
+	defer mock.Close()
+	if _, got := do(t, http.DefaultClient, "POST", mock.URL+"/hooks", "ping"); got != recorded {
+		t.Errorf("mock answered %q, proxy recorded %q", got, recorded)
+	}
+	resp, _ := do(t, http.DefaultClient, "POST", mock.URL+"/hooks", "ping")
+	if resp.StatusCode != http.StatusBadGateway {
+		t.Errorf("used-up request: %d, want 502", resp.StatusCode)
+	}
+	if calls.Load() != 1 {
+		t.Errorf("server got %d calls, want 1", calls.Load())
+	}
+}
+
+// TestForTest replays testdata/cassettes/greeting.json. With
+// VCR_MODE=record it records it again from the server below.
+func TestForTest(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"greeting":"hello, %s"}`, r.URL.Query().Get("name"))
+	}))
+	defer srv.Close()
+
+	rec := ForTest(t, "greeting", Options{})
+	c := &http.Client{Transport: rec}
+	for _, name := range []string{"Ada", "Grace"} {
+		_, body := do(t, c, "GET", srv.URL+"/greet?name="+name, "")
+		if want := `{"greeting":"hello, ` + name + `"}`; body != want {
+			t.Errorf("got %s, want %s", body, want)
+		}
+	}
+}
@@ -0,0 +1,240 @@
+package chaos
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"math"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"testing/iotest"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+// roundTripFunc answers without a network.
+type roundTripFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(r *http.Request) (*http.Response, error) { return f(r) }
+
+// ok answers 200 "ok" and counts the requests that reached it.
+func ok(sent *atomic.Int64) http.RoundTripper {
+	return roundTripFunc(func(r *http.Request) (*http.Response, error) {
+		sent.Add(1)
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader("ok")), Request: r}, nil
+	})
+}
+
+// outcomes sends n requests through tr and names what happened to each.
+func outcomes(t *testing.T, tr http.RoundTripper, n int) string {
+	t.Helper()
+	var b strings.Builder
+	for range n {
+		req, _ := http.NewRequest(http.MethodGet, "http://example.test/", nil)
+		resp, err := tr.RoundTrip(req)
+		switch {
+		case errors.Is(err, ErrInjected) && strings.Contains(err.Error(), "reset"):
+			b.WriteByte('L')
+		case errors.Is(err, ErrInjected):
+			b.WriteByte('E')
+		case err != nil:
+			t.Fatal(err)
+		case resp.StatusCode == http.StatusServiceUnavailable:
+			b.WriteByte('S')
+		default:
+			b.WriteByte('.')
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+	}
+	return b.String()
+}
+
+func TestTransportIsDeterministic(t *testing.T) {
+	var sent atomic.Int64
+	opts := Options{Seed: 42, ErrorRate: 0.2, LostResponseRate: 0.1, StatusRate: 0.2}
+	a := outcomes(t, NewTransport(ok(&sent), opts), 200)
+	b := outcomes(t, NewTransport(ok(&sent), opts), 200)
+	if a != b {
+		t.Errorf("same seed, different faults:\n%s\n%s", a, b)
+	}
+	opts.Seed = 43
+	if c := outcomes(t, NewTransport(ok(&sent), opts), 200); c == a {
+		t.Error("seeds 42 and 43 gave the same faults")
+	}
+
+	// Raising one rate does not move the faults of another: requests
+	// that failed unsent still do.
+	opts.Seed = 42
+	opts.StatusRate = 0.4
+	d := outcomes(t, NewTransport(ok(&sent), opts), 200)
+	for i := range a {
+		if (a[i] == 'E') != (d[i] == 'E') {
+			t.Fatalf("request %d: %c with the lower status rate, %c with the higher", i, a[i], d[i])
+		}
+	}
+}
+
+func TestTransportRates(t *testing.T) {
+	const n = 20000
+	var sent atomic.Int64
+	tr := NewTransport(ok(&sent), Options{Seed: 1, ErrorRate: 0.1, LostResponseRate: 0.05, StatusRate: 0.2})
+	got := outcomes(t, tr, n)
+	for _, c := range []struct {
+		kind byte
+		rate float64
+	}{{'E', 0.1}, {'L', 0.05}, {'S', 0.2}, {'.', 0.65}} {
+		frac := float64(strings.Count(got, string(c.kind))) / n
+		if math.Abs(frac-c.rate) > 0.01 {
+			t.Errorf("%c: %.3f of requests, want %.2f", c.kind, frac, c.rate)
+		}
+	}
+
+	s := tr.Stats()
+	if s.Requests != n || s.Errors != strings.Count(got, "E") || s.LostResponses != strings.Count(got, "L") || s.Statuses != strings.Count(got, "S") {
+		t.Errorf("Stats = %+v, outcomes disagree", s)
+	}
+	// Lost responses were sent; errors and statuses were not.
+	if want := int64(n - s.Errors - s.Statuses); sent.Load() != want {
+		t.Errorf("%d requests reached the base, want %d", sent.Load(), want)
+	}
+}
+
+func TestTransportLatency(t *testing.T) {
+	var sent atomic.Int64
+	fake := clock.NewAutoFake(time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC))
+	start := fake.Now()
+	tr := NewTransport(ok(&sent), Options{Seed: 3, LatencyRate: 1, MaxLatency: time.Second, Clock: fake})
+	outcomes(t, tr, 10)
+	waited := fake.Now().Sub(start)
+	if tr.Stats().Delays != 10 || waited <= 0 || waited > 10*time.Second {
+		t.Errorf("%d delays, %v in total; want 10 of at most 1s", tr.Stats().Delays, waited)
+	}
+
+	// A cancelled request stops waiting.
+	tr = NewTransport(ok(&sent), Options{LatencyRate: 1, MaxLatency: time.Hour})
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "http://example.test/", nil)
+	if _, err := tr.RoundTrip(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("cancelled: %v, want DeadlineExceeded", err)
+	}
+}
+
+func TestTransportShortReads(t *testing.T) {
+	body := bytes.Repeat([]byte("0123456789"), 1000)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { w.Write(body) }))
+	defer srv.Close()
+	c := &http.Client{Transport: NewTransport(nil, Options{Seed: 5, ShortReads: true})}
+	resp, err := c.Get(srv.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	buf := make([]byte, 4096)
+	n, _ := resp.Body.Read(buf)
+	if n == 0 || n == len(buf) {
+		t.Errorf("first Read of a 10000-byte body into 4096: %d bytes, want a short read", n)
+	}
+	rest, err := io.ReadAll(resp.Body)
+	if err != nil || !bytes.Equal(append(buf[:n], rest...), body) {
+		t.Errorf("body changed by short reads (err %v)", err)
+	}
+}
+
+func TestShortReader(t *testing.T) {
+	data := []byte(strings.Repeat("chaos ", 500))
+	for seed := range uint64(20) {
+		if err := iotest.TestReader(ShortReader(bytes.NewReader(data), seed), data); err != nil {
+			t.Errorf("seed %d: %v", seed, err)
+		}
+	}
+	r := ShortReader(bytes.NewReader(data), 1)
+	sizes := map[int]bool{}
+	for range 50 {
+		n, _ := r.Read(make([]byte, 64))
+		sizes[n] = true
+	}
+	if len(sizes) < 10 {
+		t.Errorf("50 reads of up to 64 bytes came in %d sizes, want a spread", len(sizes))
+	}
+}
+
+func pipe(t *testing.T, opts ConnOptions) (net.Conn, net.Conn) {
+	a, b := net.Pipe()
+	t.Cleanup(func() { a.Close(); b.Close() })
+	return Conn(a, opts), b
+}
+
+func TestConnFailAfter(t *testing.T) {
+	c, peer := pipe(t, ConnOptions{FailAfter: 10})
+	go io.Copy(io.Discard, peer)
+	n, err := c.Write([]byte("0123456789abcdef"))
+	if n != 10 || !errors.Is(err, ErrInjected) || !errors.Is(err, syscall.ECONNRESET) {
+		t.Errorf("Write of 16 bytes with FailAfter 10: %d, %v", n, err)
+	}
+	if _, err := c.Write([]byte("x")); !errors.Is(err, ErrInjected) {
+		t.Errorf("Write after the break: %v", err)
+	}
+	if _, err := c.Read(make([]byte, 1)); !errors.Is(err, ErrInjected) {
+		t.Errorf("Read after the break: %v", err)
+	}
+	var ne net.Error
+	if !errors.As(err, &ne) {
+		t.Errorf("%T is not a net.Error", err)
+	}
+}
+
+func TestConnResetAndShortReads(t *testing.T) {
+	c, peer := pipe(t, ConnOptions{Seed: 9, ShortReads: true})
+	go peer.Write([]byte(strings.Repeat("x", 100)))
+	n, err := c.Read(make([]byte, 100))
+	if err != nil || n == 0 || n == 100 {
+		t.Errorf("short read: %d, %v", n, err)
+	}
+
+	c, _ = pipe(t, ConnOptions{ResetRate: 1})
+	if _, err := c.Write([]byte("x")); !errors.Is(err, ErrInjected) {
+		t.Errorf("ResetRate 1: %v", err)
+	}
+}
+
+func TestDialerAndListener(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ln = Listener(ln, ConnOptions{FailAfter: 5})
+	defer ln.Close()
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go c.Write([]byte("hello, world"))
+		}
+	}()
+
+	dial := Dialer((&net.Dialer{}).DialContext, ConnOptions{Seed: 1, ShortReads: true})
+	c, err := dial(context.Background(), "tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer c.Close()
+	if _, ok := c.(*conn); !ok {
+		t.Fatalf("Dialer returned %T", c)
+	}
+	got, _ := io.ReadAll(c)
+	if string(got) != "hello" {
+		t.Errorf("greeting from a server conn that breaks after 5 bytes: %q", got)
+	}
+}
@@ -0,0 +1,147 @@
+package chaos
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// ConnOptions says how a Conn misbehaves.
+type ConnOptions struct {
+	// Seed picks the sequence of faults. A Dialer or Listener gives its
+	// n-th connection Seed+n.
+	Seed uint64
+	// ShortReads makes each Read return between 1 and len(p) bytes.
+	ShortReads bool
+	// ResetRate is the probability, per Read and per Write, that the
+	// connection breaks.
+	ResetRate float64
+	// FailAfter breaks the connection once this many bytes have been
+	// read and written in total (0: never), for a drop at an exact point
+	// in a message.
+	FailAfter int64
+}
+
+// Conn wraps c. Once broken, as by a reset from the peer, it closes c,
+// and every Read and Write fails with an error that matches ErrInjected
+// and syscall.ECONNRESET.
+func Conn(c net.Conn, opts ConnOptions) net.Conn {
+	return &conn{Conn: c, opts: opts, dice: newDice(opts.Seed)}
+}
+
+type conn struct {
+	net.Conn
+	opts ConnOptions
+	dice *dice
+
+	mu     sync.Mutex
+	bytes  int64
+	broken bool
+}
+
+// budget returns how many of n bytes may pass before the connection
+// breaks, and false if it is broken already or breaks now.
+func (c *conn) budget(n int) (int, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.broken || c.dice.roll(c.opts.ResetRate) {
+		c.breakLocked()
+		return 0, false
+	}
+	if c.opts.FailAfter > 0 {
+		if left := c.opts.FailAfter - c.bytes; int64(n) > left {
+			return int(left), true
+		}
+	}
+	return n, true
+}
+
+func (c *conn) breakLocked() {
+	if !c.broken {
+		c.broken = true
+		c.Conn.Close()
+	}
+}
+
+// used records n bytes through, breaking the connection at FailAfter.
+func (c *conn) used(n int) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.bytes += int64(n)
+	if c.opts.FailAfter > 0 && c.bytes >= c.opts.FailAfter {
+		c.breakLocked()
+	}
+}
+
+func (c *conn) reset(op string) error {
+	return &net.OpError{Op: op, Net: "tcp", Source: c.LocalAddr(), Addr: c.RemoteAddr(),
+		Err: fmt.Errorf("%w: %w", ErrInjected, syscall.ECONNRESET)}
+}
+
+func (c *conn) Read(p []byte) (int, error) {
+	if c.opts.ShortReads && len(p) > 1 {
+		p = p[:1+c.dice.intN(len(p))]
+	}
+	allowed, ok := c.budget(len(p))
+	if !ok || allowed == 0 && len(p) > 0 {
+		return 0, c.reset("read")
+	}
+	n, err := c.Conn.Read(p[:allowed])
+	c.used(n)
+	return n, err
+}
+
+func (c *conn) Write(p []byte) (int, error) {
+	allowed, ok := c.budget(len(p))
+	if !ok {
+		return 0, c.reset("write")
+	}
+	n, err := c.Conn.Write(p[:allowed])
+	c.used(n)
+	if err == nil && n < len(p) {
+		err = c.reset("write")
+	}
+	return n, err
+}
+
+// Dialer wraps a dial function, such as (&net.Dialer{}).DialContext, so
+// that every connection it makes is a Conn. Put it in an
+// http.Transport's DialContext to test a client against a network that
+// drops connections.
+func Dialer(dial func(ctx context.Context, network, addr string) (net.Conn, error), opts ConnOptions) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	var n atomic.Uint64
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		c, err := dial(ctx, network, addr)
+		if err != nil {
+			return nil, err
+		}
+		o := opts
+		o.Seed += n.Add(1) - 1
+		return Conn(c, o), nil
+	}
+}
+
+// Listener wraps l so that every connection it accepts is a Conn, to
+// test a server against clients that go away.
+func Listener(l net.Listener, opts ConnOptions) net.Listener {
+	return &listener{Listener: l, opts: opts}
+}
+
+type listener struct {
+	net.Listener
+	opts ConnOptions
+	n    atomic.Uint64
+}
+
+func (l *listener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	o := l.opts
+	o.Seed += l.n.Add(1) - 1
+	return Conn(c, o), nil
+}
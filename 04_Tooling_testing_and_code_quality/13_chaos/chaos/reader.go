@@ -0,0 +1,28 @@
+package chaos
+
+import "io"
+
+// ShortReader returns a reader that passes r through in small pieces:
+// each Read returns between 1 and len(p) bytes, at random. Code that
+// assumes one Read fills the buffer, or that a header arrives in one
+// piece, breaks on it; code that uses io.ReadFull, bufio or a decoder
+// does not.
+//
+// testing/iotest.OneByteReader is the extreme case; ShortReader also
+// covers the reads in between, which is where off-by-one errors at
+// buffer boundaries live.
+func ShortReader(r io.Reader, seed uint64) io.Reader {
+	return &shortReader{r: r, dice: newDice(seed)}
+}
+
+type shortReader struct {
+	r    io.Reader
+	dice *dice
+}
+
+func (s *shortReader) Read(p []byte) (int, error) {
+	if len(p) > 1 {
+		p = p[:1+s.dice.intN(len(p))]
+	}
+	return s.r.Read(p)
+}
@@ -0,0 +1,158 @@
+package chaos
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+// Options says which faults a Transport injects. Rates are probabilities
+// per request, from 0 to 1. At most one of ErrorRate, LostResponseRate
+// and StatusRate hits a request, so together they should not exceed 1;
+// latency comes on top of any of them.
+type Options struct {
+	// Seed picks the sequence of faults.
+	Seed uint64
+
+	// ErrorRate fails requests before they are sent, as a refused
+	// connection or a DNS failure would.
+	ErrorRate float64
+	// LostResponseRate sends requests and then fails them, as a
+	// connection reset after the server has acted would. Only idempotent
+	// requests can be retried safely after one.
+	LostResponseRate float64
+	// StatusRate answers requests with Status without sending them, as an
+	// overloaded server or proxy would.
+	StatusRate float64
+	// Status is the injected status code (default 503).
+	Status int
+
+	// LatencyRate delays requests by up to MaxLatency before sending.
+	LatencyRate float64
+	MaxLatency  time.Duration
+
+	// ShortReads makes response bodies return a few bytes per Read, as
+	// a slow connection does.
+	ShortReads bool
+
+	// Clock times the delays (default clock.Real).
+	Clock clock.Clock
+}
+
+// Stats counts what a Transport did.
+type Stats struct {
+	Requests      int // RoundTrip calls
+	Errors        int // failed unsent
+	LostResponses int // sent, then failed
+	Statuses      int // answered with Options.Status
+	Delays        int // delayed
+}
+
+// Transport is an http.RoundTripper that injects faults into requests
+// before passing them to its base. It is safe for concurrent use.
+type Transport struct {
+	base http.RoundTripper
+	opts Options
+	dice *dice
+
+	mu    sync.Mutex
+	stats Stats
+}
+
+// NewTransport returns a Transport over base (nil: http.DefaultTransport).
+func NewTransport(base http.RoundTripper, opts Options) *Transport {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	if opts.Status == 0 {
+		opts.Status = http.StatusServiceUnavailable
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real()
+	}
+	return &Transport{base: base, opts: opts, dice: newDice(opts.Seed)}
+}
+
+// Stats returns the counts so far.
+func (t *Transport) Stats() Stats {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.stats
+}
+
+func (t *Transport) count(f func(*Stats)) {
+	t.mu.Lock()
+	f(&t.stats)
+	t.mu.Unlock()
+}
+
+// RoundTrip draws the same three numbers for every request, whatever
+// the rates, so changing one rate does not reshuffle the other faults.
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	delay, fault, size := t.dice.float(), t.dice.float(), t.dice.float()
+	t.count(func(s *Stats) { s.Requests++ })
+
+	if delay < t.opts.LatencyRate && t.opts.MaxLatency > 0 {
+		t.count(func(s *Stats) { s.Delays++ })
+		d := time.Duration(size*float64(t.opts.MaxLatency)) + 1
+		select {
+		case <-t.opts.Clock.After(d):
+		case <-req.Context().Done():
+			closeBody(req)
+			return nil, req.Context().Err()
+		}
+	}
+
+	switch o := t.opts; {
+	case fault < o.ErrorRate:
+		closeBody(req)
+		t.count(func(s *Stats) { s.Errors++ })
+		return nil, fmt.Errorf("%w: %s %s: connection refused", ErrInjected, req.Method, req.URL)
+	case fault < o.ErrorRate+o.StatusRate:
+		closeBody(req)
+		t.count(func(s *Stats) { s.Statuses++ })
+		body := "chaos: injected " + strconv.Itoa(o.Status) + "\n"
+		return &http.Response{
+			Status:        strconv.Itoa(o.Status) + " " + http.StatusText(o.Status),
+			StatusCode:    o.Status,
+			Proto:         "HTTP/1.1",
+			ProtoMajor:    1,
+			ProtoMinor:    1,
+			Header:        http.Header{"Content-Type": {"text/plain; charset=utf-8"}},
+			Body:          io.NopCloser(strings.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	case fault < o.ErrorRate+o.StatusRate+o.LostResponseRate:
+		resp, err := t.base.RoundTrip(req)
+		if err != nil {
+			return nil, err
+		}
+		resp.Body.Close()
+		t.count(func(s *Stats) { s.LostResponses++ })
+		return nil, fmt.Errorf("%w: %s %s: connection reset after the request was sent", ErrInjected, req.Method, req.URL)
+	}
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil || !t.opts.ShortReads {
+		return resp, err
+	}
+	resp.Body = struct {
+		io.Reader
+		io.Closer
+	}{&shortReader{r: resp.Body, dice: t.dice}, resp.Body}
+	return resp, nil
+}
+
+// closeBody honours the RoundTripper contract for requests not sent.
+func closeBody(req *http.Request) {
+	if req.Body != nil {
+		req.Body.Close()
+	}
+}
@@ -0,0 +1,346 @@
+package main
+
+// These tests run the retry, httpclient and breaker packages against
+// injected faults, over many seeds. They live here rather than in those modules so
+// that the modules importing retry do not need this one to build.
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang_roadmap/02_core_language/22_functional_options/httpclient"
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+	"golang_roadmap/04_Tooling_testing_and_code_quality/13_chaos/chaos"
+	"golang_roadmap/12_operations/03_retry/retry"
+	"golang_roadmap/12_operations/08_circuit_breaker/breaker"
+)
+
+var t0 = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// countingServer answers {"n": <hit number>} and counts the requests
+// that reached it.
+func countingServer(t *testing.T) (*httptest.Server, *atomic.Int64) {
+	var hits atomic.Int64
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"n":%d,"padding":%q}`, hits.Add(1), strings.Repeat("x", 2000))
+	}))
+	t.Cleanup(srv.Close)
+	return srv, &hits
+}
+
+// TestRetryUnderInjectedFaults runs retry.Do over requests that fail at
+// random: connection errors, 503s and responses lost after the server
+// acted. Whatever the sequence, Do must stop at the first success, make
+// exactly MaxAttempts attempts before giving up, and say so.
+func TestRetryUnderInjectedFaults(t *testing.T) {
+	srv, _ := countingServer(t)
+	p := retry.Policy{MaxAttempts: 4, Clock: clock.NewAutoFake(t0)}
+	failures := 0
+	for seed := range uint64(200) {
+		tr := chaos.NewTransport(nil, chaos.Options{Seed: seed, ErrorRate: 0.25, StatusRate: 0.15, LostResponseRate: 0.1})
+		client := &http.Client{Transport: tr}
+		attempts, lastOK := 0, false
+		err := retry.Do(context.Background(), p, func(ctx context.Context) error {
+			attempts++
+			lastOK = false
+			req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL, nil)
+			resp, err := client.Do(req)
+			if err != nil {
+				return err
+			}
+			resp.Body.Close()
+			if resp.StatusCode >= 500 {
+				return errors.New(resp.Status)
+			}
+			lastOK = true
+			return nil
+		})
+		if s := tr.Stats(); s.Requests != attempts {
+			t.Fatalf("seed %d: %d attempts, the transport saw %d", seed, attempts, s.Requests)
+		}
+		switch {
+		case err == nil && !lastOK:
+			t.Errorf("seed %d: nil error after a failed attempt", seed)
+		case err != nil && (attempts != p.MaxAttempts || !strings.Contains(err.Error(), "after 4 attempts")):
+			t.Errorf("seed %d: gave up after %d attempts with %v", seed, attempts, err)
+		case err != nil:
+			failures++
+		}
+	}
+	// An attempt fails with probability 0.5, so about 1 seed in 16 runs
+	// out of attempts.
+	if failures == 0 || failures > 30 {
+		t.Errorf("%d of 200 seeds ran out of attempts, want about 12", failures)
+	}
+}
+
+// TestHTTPClientUnderInjectedFaults sends GETs through httpclient with
+// retries over a transport that fails a third of the requests and reads
+// bodies in small pieces. Every GET must succeed and decode.
+func TestHTTPClientUnderInjectedFaults(t *testing.T) {
+	srv, hits := countingServer(t)
+	for seed := range uint64(50) {
+		tr := chaos.NewTransport(nil, chaos.Options{Seed: seed, ErrorRate: 0.15, StatusRate: 0.1, LostResponseRate: 0.1, ShortReads: true})
+		c, err := httpclient.New(
+			httpclient.WithBaseURL(srv.URL),
+			httpclient.WithTransport(tr),
+			httpclient.WithRetry(retry.Policy{MaxAttempts: 12, Clock: clock.NewAutoFake(t0)}),
+		)
+		if err != nil {
+			t.Fatal(err)
+		}
+		before := hits.Load()
+		for i := range 10 {
+			resp, err := c.Get(context.Background(), "/")
+			if err != nil {
+				t.Fatalf("seed %d, GET %d: %v", seed, i, err)
+			}
+			var body struct{ N int64 }
+			err = json.NewDecoder(resp.Body).Decode(&body)
+			resp.Body.Close()
+			if err != nil || body.N <= before {
+				t.Fatalf("seed %d, GET %d: decoded %+v, %v", seed, i, body, err)
+			}
+		}
+		// The server saw the successes and the lost responses, nothing else.
+		s := tr.Stats()
+		if got, want := hits.Load()-before, int64(10+s.LostResponses); got != want {
+			t.Errorf("seed %d: server saw %d requests, want %d (stats %+v)", seed, got, want, s)
+		}
+	}
+}
+
+// TestHTTPClientDoesNotRetryLostPOST checks the rule that makes retries
+// safe: a POST whose response was lost may have done its work, so it is
+// reported, not sent again.
+func TestHTTPClientDoesNotRetryLostPOST(t *testing.T) {
+	srv, hits := countingServer(t)
+	tr := chaos.NewTransport(nil, chaos.Options{LostResponseRate: 1})
+	c, _ := httpclient.New(
+		httpclient.WithBaseURL(srv.URL),
+		httpclient.WithTransport(tr),
+		httpclient.WithRetry(retry.Policy{MaxAttempts: 5, Clock: clock.NewAutoFake(t0)}),
+	)
+	req, _ := c.NewRequest(context.Background(), http.MethodPost, "/orders", strings.NewReader("{}"))
+	if _, err := c.Do(req); !errors.Is(err, chaos.ErrInjected) {
+		t.Errorf("POST: %v, want the injected fault", err)
+	}
+	if hits.Load() != 1 {
+		t.Errorf("server saw %d POSTs, want 1", hits.Load())
+	}
+
+	// A GET in the same situation is retried until the attempts run out.
+	if _, err := c.Get(context.Background(), "/"); !errors.Is(err, chaos.ErrInjected) {
+		t.Errorf("GET: %v, want the injected fault", err)
+	}
+	if hits.Load() != 6 {
+		t.Errorf("server saw %d requests, want 1 POST and 5 GETs", hits.Load())
+	}
+}
+
+// TestHTTPClientOverFlakyConnections breaks the TCP connections under a
+// real http.Transport: resets in the middle of a request or a response.
+// Retries on fresh connections must get every GET through.
+func TestHTTPClientOverFlakyConnections(t *testing.T) {
+	srv, _ := countingServer(t)
+	var dials atomic.Int64
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		dials.Add(1)
+		return (&net.Dialer{}).DialContext(ctx, network, addr)
+	}
+	bodyErrors := 0
+	for seed := range uint64(20) {
+		base := &http.Transport{
+			DialContext: chaos.Dialer(dial, chaos.ConnOptions{Seed: seed * 1000, ResetRate: 0.1, ShortReads: true}),
+		}
+		c, _ := httpclient.New(
+			httpclient.WithBaseURL(srv.URL),
+			httpclient.WithTransport(base),
+			httpclient.WithTimeout(5*time.Second),
+			httpclient.WithRetry(retry.Policy{MaxAttempts: 10, Clock: clock.NewAutoFake(t0)}),
+		)
+		for i := range 10 {
+			resp, err := c.Get(context.Background(), "/")
+			if err != nil {
+				t.Fatalf("seed %d, GET %d: %v", seed, i, err)
+			}
+			var body struct{ N int64 }
+			err = json.NewDecoder(resp.Body).Decode(&body)
+			resp.Body.Close()
+			if err != nil {
+				// A reset while reading the body reaches the caller:
+				// httpclient retries requests, not reads. The test
+				// tolerates it, as callers must.
+				if !errors.Is(err, chaos.ErrInjected) {
+					t.Fatalf("seed %d, GET %d: %v", seed, i, err)
+				}
+				bodyErrors++
+			}
+		}
+		base.CloseIdleConnections()
+	}
+	// One connection per seed if nothing broke.
+	t.Logf("%d connections for 20 clients, %d of 200 bodies cut off", dials.Load(), bodyErrors)
+	if dials.Load() <= 20 {
+		t.Errorf("%d connections for 20 clients: no connection broke", dials.Load())
+	}
+}
+
+// TestBreakerUnderInjectedFaults sends requests one second apart through
+// a breaker over a transport that fails at random, and replays the
+// outcomes through a model of the breaker's rules: open after Threshold
+// consecutive failures, reject everything for OpenFor, then let one probe
+// decide. Every state change must happen where the model says, and no
+// request may be sent while the breaker is open.
+func TestBreakerUnderInjectedFaults(t *testing.T) {
+	srv, _ := countingServer(t)
+	const threshold, openFor = 3, 5 * time.Second
+	opens := 0
+	for seed := range uint64(200) {
+		fake := clock.NewFake(t0)
+		tr := chaos.NewTransport(nil, chaos.Options{Seed: seed, ErrorRate: 0.3, StatusRate: 0.15, LostResponseRate: 0.05})
+		var changes []string
+		call := 0
+		b := breaker.New(breaker.Settings{
+			Threshold: threshold,
+			OpenFor:   openFor,
+			Clock:     fake,
+			OnStateChange: func(from, to breaker.State) {
+				changes = append(changes, fmt.Sprintf("%d:%v->%v", call, from, to))
+			},
+		})
+		client := &http.Client{Transport: breaker.Transport(b, tr)}
+
+		// R: rejected by the breaker, F: failed, S: succeeded.
+		var outcomes []byte
+		for call = range 100 {
+			resp, err := client.Get(srv.URL)
+			switch {
+			case errors.Is(err, breaker.ErrOpen):
+				outcomes = append(outcomes, 'R')
+			case err != nil:
+				outcomes = append(outcomes, 'F')
+			default:
+				resp.Body.Close()
+				if resp.StatusCode >= 500 {
+					outcomes = append(outcomes, 'F')
+				} else {
+					outcomes = append(outcomes, 'S')
+				}
+			}
+			fake.Advance(time.Second)
+		}
+
+		want := breakerModel(outcomes, threshold, int(openFor/time.Second))
+		if got := strings.Join(changes, " "); got != strings.Join(want, " ") {
+			t.Fatalf("seed %d: outcomes %s\n got changes %s\nwant changes %s", seed, outcomes, got, strings.Join(want, " "))
+		}
+		if sent, rejected := tr.Stats().Requests, strings.Count(string(outcomes), "R"); sent != 100-rejected {
+			t.Fatalf("seed %d: %d requests sent, %d rejected, out of 100", seed, sent, rejected)
+		}
+		opens += strings.Count(strings.Join(changes, " "), "->open")
+	}
+	if opens == 0 {
+		t.Error("the breaker never opened: the faults are too rare to test it")
+	}
+}
+
+// breakerModel returns the state changes a breaker should make for the
+// given outcomes of calls one second apart. A rejection ('R') while the
+// model says closed, or a call sent while it says open, shows up as a
+// "!" entry, so the comparison fails at that call.
+func breakerModel(outcomes []byte, threshold, openFor int) []string {
+	var changes []string
+	state, streak, openedAt := breaker.Closed, 0, 0
+	set := func(i int, to breaker.State) {
+		changes = append(changes, fmt.Sprintf("%d:%v->%v", i, state, to))
+		state, streak = to, 0
+	}
+	for i, o := range outcomes {
+		if state == breaker.Open && i-openedAt >= openFor {
+			set(i, breaker.HalfOpen)
+		}
+		switch {
+		case state == breaker.Open && o != 'R', state != breaker.Open && o == 'R':
+			changes = append(changes, fmt.Sprintf("%d:!%c while %v", i, o, state))
+		case o == 'S' && state == breaker.HalfOpen:
+			set(i, breaker.Closed)
+		case o == 'S':
+			streak = 0
+		case o == 'F' && state == breaker.HalfOpen:
+			set(i, breaker.Open)
+			openedAt = i
+		case o == 'F':
+			if streak++; streak == threshold {
+				set(i, breaker.Open)
+				openedAt = i
+			}
+		}
+	}
+	return changes
+}
+
+// TestBreakerWithRetryOverFlakyConnections puts the whole stack over TCP
+// connections that reset at random: httpclient retrying over a breaker
+// over a real http.Transport. Every GET must get through, and the retries
+// must wait for the breaker's next probe instead of spending their
+// attempts on rejections: at most one rejection per time it opened.
+func TestBreakerWithRetryOverFlakyConnections(t *testing.T) {
+	srv, _ := countingServer(t)
+	var opens, rejections int
+	for seed := range uint64(20) {
+		fake := clock.NewAutoFake(t0)
+		b := breaker.New(breaker.Settings{
+			Threshold: 2,
+			OpenFor:   time.Second,
+			Clock:     fake,
+			OnStateChange: func(_, to breaker.State) {
+				if to == breaker.Open {
+					opens++
+				}
+			},
+		})
+		base := &http.Transport{
+			DialContext: chaos.Dialer((&net.Dialer{}).DialContext, chaos.ConnOptions{Seed: seed * 1000, ResetRate: 0.2}),
+		}
+		c, _ := httpclient.New(
+			httpclient.WithBaseURL(srv.URL),
+			httpclient.WithTransport(breaker.Transport(b, base)),
+			httpclient.WithTimeout(5*time.Second),
+			httpclient.WithRetry(retry.Policy{
+				MaxAttempts: 20,
+				Clock:       fake,
+				OnRetry: func(_ int, err error, _ time.Duration) {
+					if errors.Is(err, breaker.ErrOpen) {
+						rejections++
+					}
+				},
+			}),
+		)
+		for i := range 10 {
+			resp, err := c.Get(context.Background(), "/")
+			if err != nil {
+				t.Fatalf("seed %d, GET %d: %v", seed, i, err)
+			}
+			resp.Body.Close()
+		}
+		base.CloseIdleConnections()
+	}
+	t.Logf("the breaker opened %d times; %d retries met it open", opens, rejections)
+	if opens == 0 {
+		t.Error("the breaker never opened: the faults are too rare to test it")
+	}
+	if rejections > opens {
+		t.Errorf("%d rejections for %d openings: retries did not wait for the probe", rejections, opens)
+	}
+}
@@ -0,0 +1,108 @@
+// Demonstrates injecting network faults with seeded, reproducible chaos.
+//
+// This example shows:
+// - An http.RoundTripper that fails, answers 503 or loses responses at random
+// - The same GETs with and without retries through httpclient
+// - One seed giving the same faults on every run
+// - A short-reading io.Reader that breaks code assuming full reads
+// - A net.Conn that drops at an exact byte
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"golang_roadmap/02_core_language/22_functional_options/httpclient"
+	"golang_roadmap/04_Tooling_testing_and_code_quality/13_chaos/chaos"
+	"golang_roadmap/12_operations/03_retry/retry"
+)
+
+func main() {
+	fmt.Println("Chaos examples starting...")
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "ok")
+	}))
+	defer srv.Close()
+	faults := chaos.Options{Seed: 1, ErrorRate: 0.2, StatusRate: 0.1, LostResponseRate: 0.1}
+
+	fmt.Println("\n1) 20 GETs through a transport that fails 40% of requests")
+	for _, attempts := range []int{1, 4} {
+		tr := chaos.NewTransport(nil, faults)
+		opts := []httpclient.Option{httpclient.WithBaseURL(srv.URL), httpclient.WithTransport(tr)}
+		if attempts > 1 {
+			opts = append(opts, httpclient.WithRetry(retry.Policy{MaxAttempts: attempts, Initial: time.Millisecond, Max: 5 * time.Millisecond}))
+		}
+		c, err := httpclient.New(opts...)
+		if err != nil {
+			log.Fatal(err)
+		}
+		succeeded := 0
+		for range 20 {
+			if resp, err := c.Get(context.Background(), "/"); err == nil {
+				if resp.StatusCode == http.StatusOK {
+					succeeded++
+				}
+				resp.Body.Close()
+			}
+		}
+		s := tr.Stats()
+		fmt.Printf("   %d attempt(s): %2d of 20 succeeded; %d sent, %d errors, %d lost responses, %d 503s\n",
+			attempts, succeeded, s.Requests, s.Errors, s.LostResponses, s.Statuses)
+	}
+
+	fmt.Println("\n2) A seed is a script: . ok, E error, L lost response, S 503")
+	for _, seed := range []uint64{1, 1, 2} {
+		faults.Seed = seed
+		fmt.Printf("   seed %d: %s\n", seed, script(chaos.NewTransport(nil, faults), srv.URL, 30))
+	}
+
+	fmt.Println("\n3) Short reads: a 12-byte header read with one Read call, and with io.ReadFull")
+	msg := []byte("HEADER-00042payload")
+	for seed := range uint64(3) {
+		buf := make([]byte, 12)
+		n, _ := chaos.ShortReader(bytes.NewReader(msg), seed).Read(buf)
+		_, err := io.ReadFull(chaos.ShortReader(bytes.NewReader(msg), seed), buf)
+		fmt.Printf("   seed %d: Read got %q, ReadFull got %q (err %v)\n", seed, msg[:n], buf, err)
+	}
+
+	fmt.Println("\n4) A connection that breaks after 10 bytes")
+	a, b := net.Pipe()
+	defer b.Close()
+	go io.Copy(io.Discard, b)
+	conn := chaos.Conn(a, chaos.ConnOptions{FailAfter: 10})
+	n, err := conn.Write([]byte("a message of 26 bytes...\r\n"))
+	fmt.Printf("   wrote %d bytes, err: %v\n", n, err)
+	fmt.Printf("   injected: %v\n", errors.Is(err, chaos.ErrInjected))
+}
+
+// script sends n GETs through tr and returns one letter per outcome.
+func script(tr http.RoundTripper, url string, n int) string {
+	var b strings.Builder
+	for range n {
+		req, _ := http.NewRequest(http.MethodGet, url, nil)
+		resp, err := tr.RoundTrip(req)
+		switch {
+		case err != nil && strings.Contains(err.Error(), "reset"):
+			b.WriteByte('L')
+		case err != nil:
+			b.WriteByte('E')
+		case resp.StatusCode != http.StatusOK:
+			b.WriteByte('S')
+		default:
+			b.WriteByte('.')
+		}
+		if resp != nil {
+			resp.Body.Close()
+		}
+	}
+	return b.String()
+}
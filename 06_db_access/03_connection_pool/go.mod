@@ -0,0 +1,5 @@
+module golang_roadmap/06_db_access/03_connection_pool
+
+go 1.24.11
+
+require github.com/mattn/go-sqlite3 v1.14.33
@@ -0,0 +1,113 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"log"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver (import for side effects)
+)
+
+// PoolConfig holds the database/sql connection pool knobs.
+// Zero values keep the database/sql defaults.
+type PoolConfig struct {
+	MaxOpenConns    int           // upper bound on open connections (in use + idle)
+	MaxIdleConns    int           // connections kept around for reuse
+	ConnMaxLifetime time.Duration // recycle connections older than this
+	ConnMaxIdleTime time.Duration // close connections idle for longer than this
+}
+
+// openDB opens a SQLite database and applies the pool settings.
+func openDB(dsn string, cfg PoolConfig) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", dsn)
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(cfg.MaxOpenConns)
+	db.SetMaxIdleConns(cfg.MaxIdleConns)
+	db.SetConnMaxLifetime(cfg.ConnMaxLifetime)
+	db.SetConnMaxIdleTime(cfg.ConnMaxIdleTime)
+
+	// sql.Open does not connect; Ping verifies the DSN actually works.
+	if err := db.Ping(); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// seed creates the users table and inserts a few rows.
+func seed(ctx context.Context, db *sql.DB) error {
+	_, err := db.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS users (
+		id INTEGER PRIMARY KEY AUTOINCREMENT,
+		name TEXT,
+		age INTEGER
+	)`)
+	if err != nil {
+		return err
+	}
+	for _, u := range []struct {
+		name string
+		age  int
+	}{{"Alice", 30}, {"Bob", 25}, {"Carol", 41}} {
+		if _, err := db.ExecContext(ctx, `INSERT INTO users (name, age) VALUES (?, ?)`, u.name, u.age); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// firstUserLeaky returns the first user name but never closes rows.
+// Because the loop exits early, rows.Next never reaches the end of the
+// result set, so the connection stays checked out of the pool forever.
+func firstUserLeaky(ctx context.Context, db *sql.DB) (string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name FROM users ORDER BY id`)
+	if err != nil {
+		return "", err
+	}
+	// BUG: missing defer rows.Close()
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+	return "", rows.Err()
+}
+
+// firstUser is the fixed version: rows.Close releases the connection
+// back to the pool no matter how the function returns.
+func firstUser(ctx context.Context, db *sql.DB) (string, error) {
+	rows, err := db.QueryContext(ctx, `SELECT name FROM users ORDER BY id`)
+	if err != nil {
+		return "", err
+	}
+	defer rows.Close() // Always close rows when done
+
+	for rows.Next() {
+		var name string
+		if err := rows.Scan(&name); err != nil {
+			return "", err
+		}
+		return name, nil
+	}
+	return "", rows.Err()
+}
+
+// logStats prints db.Stats() every interval until ctx is cancelled.
+func logStats(ctx context.Context, db *sql.DB, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			s := db.Stats()
+			log.Printf("pool: open=%d inUse=%d idle=%d waitCount=%d waitDuration=%v maxIdleClosed=%d maxLifetimeClosed=%d",
+				s.OpenConnections, s.InUse, s.Idle, s.WaitCount, s.WaitDuration, s.MaxIdleClosed, s.MaxLifetimeClosed)
+		}
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func newTestDB(t *testing.T, maxOpen int) *sql.DB {
+	t.Helper()
+	db, err := openDB(filepath.Join(t.TempDir(), "test.db"), PoolConfig{MaxOpenConns: maxOpen, MaxIdleConns: maxOpen})
+	if err != nil {
+		t.Fatalf("openDB: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if err := seed(context.Background(), db); err != nil {
+		t.Fatalf("seed: %v", err)
+	}
+	return db
+}
+
+func TestOpenDB_AppliesPoolConfig(t *testing.T) {
+	db := newTestDB(t, 3)
+	if got := db.Stats().MaxOpenConnections; got != 3 {
+		t.Fatalf("MaxOpenConnections = %d; want 3", got)
+	}
+}
+
+func TestFirstUserLeaky_ExhaustsPool(t *testing.T) {
+	db := newTestDB(t, 2)
+	ctx := context.Background()
+
+	for i := 0; i < 2; i++ {
+		if _, err := firstUserLeaky(ctx, db); err != nil {
+			t.Fatalf("leaky call %d: %v", i, err)
+		}
+	}
+	if got := db.Stats().InUse; got != 2 {
+		t.Fatalf("InUse = %d after two leaks; want 2", got)
+	}
+
+	// Every connection is stuck, so the next call can only time out.
+	ctx, cancel := context.WithTimeout(ctx, 100*time.Millisecond)
+	defer cancel()
+	_, err := firstUserLeaky(ctx, db)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got %v", err)
+	}
+	if db.Stats().WaitCount == 0 {
+		t.Fatalf("expected WaitCount > 0 once the pool is exhausted")
+	}
+}
+
+func TestFirstUser_ReleasesConnection(t *testing.T) {
+	db := newTestDB(t, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	for i := 0; i < 10; i++ {
+		name, err := firstUser(ctx, db)
+		if err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if name != "Alice" {
+			t.Fatalf("call %d: name = %q; want Alice", i, name)
+		}
+	}
+	if got := db.Stats().InUse; got != 0 {
+		t.Fatalf("InUse = %d; want 0", got)
+	}
+}
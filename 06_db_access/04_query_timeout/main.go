@@ -0,0 +1,67 @@
+// Demonstrates query timeouts and cancellation with database/sql.
+//
+// This example shows:
+// - Running a deliberately slow SQLite query (recursive CTE)
+// - Bounding it with QueryRowContext and a short deadline
+// - Verifying the query really stopped (elapsed time, connection reusable)
+// - Mapping context.DeadlineExceeded to a typed *TimeoutError
+// - Caller cancellation, which is not a timeout
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+func main() {
+	db, err := sql.Open("sqlite3", "file::memory:")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1) // one connection makes "is it reusable?" meaningful
+
+	ctx := context.Background()
+
+	fmt.Println("=== Fast query ===")
+	n, err := countSeq(ctx, db, 1_000, time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("count:", n)
+
+	fmt.Println("\n=== Slow query with a 200ms deadline ===")
+	start := time.Now()
+	_, err = countSeq(ctx, db, 1_000_000_000, 200*time.Millisecond)
+	elapsed := time.Since(start)
+
+	var te *TimeoutError
+	switch {
+	case errors.As(err, &te):
+		fmt.Printf("timeout error: %v (returned after %v)\n", te, elapsed.Round(time.Millisecond))
+		fmt.Println("errors.Is(err, context.DeadlineExceeded):", errors.Is(err, context.DeadlineExceeded))
+	case err != nil:
+		log.Fatal(err)
+	default:
+		log.Fatal("expected the slow query to time out")
+	}
+
+	// If the query were still running, this would block on the single connection.
+	n, err = countSeq(ctx, db, 10, time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("connection reused after timeout, count:", n)
+
+	fmt.Println("\n=== Caller cancellation ===")
+	cctx, cancel := context.WithCancel(ctx)
+	time.AfterFunc(100*time.Millisecond, cancel)
+	_, err = countSeq(cctx, db, 1_000_000_000, 10*time.Second)
+	fmt.Printf("error: %v\n", err)
+	fmt.Println("is *TimeoutError:", errors.As(err, &te))
+	fmt.Println("errors.Is(err, context.Canceled):", errors.Is(err, context.Canceled))
+}
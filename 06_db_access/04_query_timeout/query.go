@@ -0,0 +1,64 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver (import for side effects)
+)
+
+// TimeoutError reports a query that was stopped because its deadline passed.
+// It wraps the original error, so errors.Is(err, context.DeadlineExceeded)
+// still holds for callers that only care about the context error.
+type TimeoutError struct {
+	Op    string
+	After time.Duration
+	Err   error
+}
+
+func (e *TimeoutError) Error() string {
+	return fmt.Sprintf("%s: query timed out after %v: %v", e.Op, e.After, e.Err)
+}
+
+func (e *TimeoutError) Unwrap() error { return e.Err }
+
+// Timeout lets callers assert behavior instead of type (same as net.Error).
+func (e *TimeoutError) Timeout() bool { return true }
+
+// slowCountQuery generates 1..n with a recursive CTE and counts the rows.
+// For large n it keeps SQLite busy for seconds without needing any data.
+const slowCountQuery = `
+WITH RECURSIVE seq(x) AS (
+	SELECT 1
+	UNION ALL
+	SELECT x + 1 FROM seq WHERE x < ?
+)
+SELECT count(*) FROM seq`
+
+// countSeq runs slowCountQuery with a per-query timeout.
+// A deadline hit becomes a *TimeoutError; cancellation by the caller is
+// returned as-is because it is not a timeout.
+func countSeq(ctx context.Context, db *sql.DB, n int64, timeout time.Duration) (int64, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var count int64
+	err := db.QueryRowContext(ctx, slowCountQuery, n).Scan(&count)
+	if err != nil {
+		return 0, mapContextErr(ctx, "countSeq", timeout, err)
+	}
+	return count, nil
+}
+
+// mapContextErr turns driver errors caused by an expired deadline into a
+// *TimeoutError. Drivers differ in what they return after an interrupt, so
+// the context is the source of truth, not the error text.
+func mapContextErr(ctx context.Context, op string, timeout time.Duration, err error) error {
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		return &TimeoutError{Op: op, After: timeout, Err: context.DeadlineExceeded}
+	}
+	return fmt.Errorf("%s: %w", op, err)
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"testing"
+	"time"
+)
+
+func newTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	db, err := sql.Open("sqlite3", "file::memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+	return db
+}
+
+func TestCountSeq_Completes(t *testing.T) {
+	db := newTestDB(t)
+	got, err := countSeq(context.Background(), db, 500, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != 500 {
+		t.Fatalf("count = %d; want 500", got)
+	}
+}
+
+func TestCountSeq_DeadlineCancelsQuery(t *testing.T) {
+	db := newTestDB(t)
+
+	start := time.Now()
+	_, err := countSeq(context.Background(), db, 1_000_000_000, 50*time.Millisecond)
+	elapsed := time.Since(start)
+
+	var te *TimeoutError
+	if !errors.As(err, &te) {
+		t.Fatalf("expected *TimeoutError, got %T: %v", err, err)
+	}
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected error to wrap context.DeadlineExceeded")
+	}
+	if te.After != 50*time.Millisecond {
+		t.Fatalf("After = %v; want 50ms", te.After)
+	}
+	// The full query takes minutes; returning quickly means it was interrupted.
+	if elapsed > 2*time.Second {
+		t.Fatalf("query returned after %v; it was not cancelled", elapsed)
+	}
+
+	// With one connection, a still-running query would block this one.
+	if _, err := countSeq(context.Background(), db, 10, time.Second); err != nil {
+		t.Fatalf("connection not reusable after timeout: %v", err)
+	}
+	if got := db.Stats().InUse; got != 0 {
+		t.Fatalf("InUse = %d; want 0", got)
+	}
+}
+
+func TestCountSeq_CallerCancelIsNotTimeout(t *testing.T) {
+	db := newTestDB(t)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	_, err := countSeq(ctx, db, 1_000_000_000, 10*time.Second)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got %v", err)
+	}
+	var te *TimeoutError
+	if errors.As(err, &te) {
+		t.Fatalf("caller cancellation must not be reported as a timeout")
+	}
+}
@@ -0,0 +1,75 @@
+// Demonstrates full-text search with SQLite FTS5.
+//
+// This example shows:
+// - Creating an FTS5 virtual table with a stemming tokenizer
+// - Indexing documents
+// - MATCH queries: terms, phrases, prefixes, boolean operators, column filters
+// - Ranking with bm25() and highlighting with snippet()
+// - Hiding all of it behind a SearchStore interface
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"log"
+)
+
+var sampleDocs = []Document{
+	{Title: "Goroutines", Body: "A goroutine is a lightweight thread managed by the Go runtime. Start one with the go keyword."},
+	{Title: "Channels", Body: "Channels connect goroutines. Send and receive values with the channel operator."},
+	{Title: "Worker pools", Body: "A worker pool runs a fixed number of goroutines that read jobs from a channel and send results back."},
+	{Title: "Context", Body: "Package context carries deadlines, cancellation signals and request-scoped values across API boundaries."},
+	{Title: "Mutexes", Body: "sync.Mutex protects shared state when channels would make the design awkward."},
+	{Title: "Database timeouts", Body: "Pass a context with a deadline to QueryContext so a slow query cannot hold a connection forever."},
+	{Title: "Error handling", Body: "Errors are values. Wrap them with %w and inspect them with errors.Is and errors.As."},
+	{Title: "Table-driven tests", Body: "Loop over a slice of cases and run each one as a subtest with t.Run."},
+	{Title: "Modules", Body: "go.mod declares the module path, the Go version and the dependencies."},
+}
+
+// printSearch only knows about the SearchStore interface.
+func printSearch(ctx context.Context, store SearchStore, query string) {
+	results, err := store.Search(ctx, query, 5)
+	if err != nil {
+		fmt.Printf("%-28s error: %v\n", query, err)
+		return
+	}
+	fmt.Printf("%-28s %d hit(s)\n", query, len(results))
+	for _, r := range results {
+		fmt.Printf("    #%d %-18s score=%6.2f  %s\n", r.ID, r.Title, r.Score, r.Snippet)
+	}
+}
+
+func main() {
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+	db.SetMaxOpenConns(1) // each :memory: connection is a separate database
+
+	ctx := context.Background()
+	store, err := NewFTSStore(ctx, db)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, d := range sampleDocs {
+		if _, err := store.Index(ctx, d); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	queries := []string{
+		`goroutine`,             // single term (stemmed: matches "goroutines" too)
+		`"worker pool"`,         // phrase
+		`cancel*`,               // prefix
+		`channel NOT goroutine`, // boolean operators
+		`title:context`,         // column filter
+		`deadline OR mutex`,     // OR
+		`"unterminated`,         // syntax error surfaces as an error
+	}
+	for _, q := range queries {
+		printSearch(ctx, store, q)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite" // pure-Go SQLite driver with FTS5 compiled in
+)
+
+// Document is a piece of text to index.
+type Document struct {
+	ID    int64
+	Title string
+	Body  string
+}
+
+// Result is a single search hit.
+type Result struct {
+	ID      int64
+	Title   string
+	Snippet string  // body excerpt with matches wrapped in [ ]
+	Score   float64 // bm25 score, lower is better
+}
+
+// SearchStore is what the rest of an application depends on.
+// Callers never see SQL, so the backend can change (FTS5, Bleve, Elasticsearch...).
+type SearchStore interface {
+	Index(ctx context.Context, doc Document) (int64, error)
+	Search(ctx context.Context, query string, limit int) ([]Result, error)
+}
+
+// FTSStore implements SearchStore with an SQLite FTS5 virtual table.
+type FTSStore struct {
+	db *sql.DB
+}
+
+// NewFTSStore creates the FTS5 table if needed.
+//
+// tokenize='porter unicode61' folds case and diacritics and stems English
+// words, so "channels" matches "channel".
+func NewFTSStore(ctx context.Context, db *sql.DB) (*FTSStore, error) {
+	_, err := db.ExecContext(ctx, `CREATE VIRTUAL TABLE IF NOT EXISTS docs USING fts5(
+		title,
+		body,
+		tokenize = 'porter unicode61'
+	)`)
+	if err != nil {
+		return nil, fmt.Errorf("create fts5 table: %w", err)
+	}
+	return &FTSStore{db: db}, nil
+}
+
+// Index adds a document and returns its rowid. A zero doc.ID lets SQLite pick one.
+func (s *FTSStore) Index(ctx context.Context, doc Document) (int64, error) {
+	var res sql.Result
+	var err error
+	if doc.ID == 0 {
+		res, err = s.db.ExecContext(ctx, `INSERT INTO docs (title, body) VALUES (?, ?)`, doc.Title, doc.Body)
+	} else {
+		res, err = s.db.ExecContext(ctx, `INSERT INTO docs (rowid, title, body) VALUES (?, ?, ?)`, doc.ID, doc.Title, doc.Body)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("index %q: %w", doc.Title, err)
+	}
+	return res.LastInsertId()
+}
+
+// Search runs an FTS5 MATCH query ordered by relevance.
+//
+// bm25(docs, 10.0, 1.0) weights title hits ten times higher than body hits.
+// snippet(docs, 1, ...) extracts up to 8 tokens of column 1 (body) around the match.
+func (s *FTSStore) Search(ctx context.Context, query string, limit int) ([]Result, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT rowid, title, snippet(docs, 1, '[', ']', '...', 8), bm25(docs, 10.0, 1.0) AS score
+		FROM docs
+		WHERE docs MATCH ?
+		ORDER BY score
+		LIMIT ?`, query, limit)
+	if err != nil {
+		return nil, fmt.Errorf("search %q: %w", query, err)
+	}
+	defer rows.Close()
+
+	var results []Result
+	for rows.Next() {
+		var r Result
+		if err := rows.Scan(&r.ID, &r.Title, &r.Snippet, &r.Score); err != nil {
+			return nil, err
+		}
+		results = append(results, r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("search %q: %w", query, err)
+	}
+	return results, nil
+}
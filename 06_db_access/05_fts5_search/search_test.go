@@ -0,0 +1,126 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+	"testing"
+)
+
+func newTestStore(t *testing.T) *FTSStore {
+	t.Helper()
+	db, err := sql.Open("sqlite", ":memory:")
+	if err != nil {
+		t.Fatalf("open: %v", err)
+	}
+	db.SetMaxOpenConns(1)
+	t.Cleanup(func() { db.Close() })
+
+	ctx := context.Background()
+	s, err := NewFTSStore(ctx, db)
+	if err != nil {
+		t.Fatalf("NewFTSStore: %v", err)
+	}
+	for _, d := range sampleDocs {
+		if _, err := s.Index(ctx, d); err != nil {
+			t.Fatalf("Index: %v", err)
+		}
+	}
+	return s
+}
+
+func titles(rs []Result) []string {
+	out := make([]string, len(rs))
+	for i, r := range rs {
+		out[i] = r.Title
+	}
+	return out
+}
+
+func TestFTSStore_Search(t *testing.T) {
+	s := newTestStore(t)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string // expected titles, any order (ranking is tested separately)
+	}{
+		{"stemmed term", "channels", []string{"Channels", "Worker pools", "Mutexes"}},
+		{"phrase", `"worker pool"`, []string{"Worker pools"}},
+		{"prefix", "cancel*", []string{"Context"}},
+		{"not", "channel NOT goroutine", []string{"Mutexes"}},
+		{"column filter", "title:context", []string{"Context"}},
+		{"no match", "kubernetes", nil},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := s.Search(context.Background(), tc.query, 10)
+			if err != nil {
+				t.Fatalf("Search(%q): %v", tc.query, err)
+			}
+			gotTitles := titles(got)
+			sort.Strings(gotTitles)
+			sort.Strings(tc.want)
+			if strings.Join(gotTitles, ",") != strings.Join(tc.want, ",") {
+				t.Fatalf("Search(%q) = %v; want %v", tc.query, titles(got), tc.want)
+			}
+		})
+	}
+}
+
+func TestFTSStore_TitleMatchesRankHigher(t *testing.T) {
+	s := newTestStore(t)
+	// "context" is the title of one doc and appears in the body of another.
+	got, err := s.Search(context.Background(), "context", 10)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 2 {
+		t.Fatalf("got %d results; want 2", len(got))
+	}
+	if got[0].Title != "Context" {
+		t.Fatalf("first result = %q; want the title match first", got[0].Title)
+	}
+	if got[0].Score >= got[1].Score {
+		t.Fatalf("scores not ordered: %v >= %v", got[0].Score, got[1].Score)
+	}
+}
+
+func TestFTSStore_Snippet(t *testing.T) {
+	s := newTestStore(t)
+	got, err := s.Search(context.Background(), "lightweight", 1)
+	if err != nil {
+		t.Fatalf("Search: %v", err)
+	}
+	if len(got) != 1 || !strings.Contains(got[0].Snippet, "[lightweight]") {
+		t.Fatalf("snippet = %v; want highlighted [lightweight]", got)
+	}
+}
+
+func TestFTSStore_IndexWithID(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	id, err := s.Index(ctx, Document{ID: 100, Title: "Generics", Body: "Type parameters and constraints"})
+	if err != nil {
+		t.Fatalf("Index: %v", err)
+	}
+	if id != 100 {
+		t.Fatalf("id = %d; want 100", id)
+	}
+	got, err := s.Search(ctx, "generics", 1)
+	if err != nil || len(got) != 1 || got[0].ID != 100 {
+		t.Fatalf("Search = %v, %v; want doc 100", got, err)
+	}
+}
+
+func TestFTSStore_InvalidQuery(t *testing.T) {
+	s := newTestStore(t)
+	if _, err := s.Search(context.Background(), `"unterminated`, 10); err == nil {
+		t.Fatalf("expected a syntax error for an unterminated phrase")
+	}
+}
+
+// SearchStore is satisfied at compile time.
+var _ SearchStore = (*FTSStore)(nil)
@@ -1,7 +1,26 @@
 module golang_roadmap/07_building_cli_beyond_flag/02_urfave_cli
 
-go 1.22
+go 1.24.11
 
-toolchain go1.24.11
+require (
+	github.com/urfave/cli/v3 v3.4.0
+	golang_roadmap/07_building_cli_beyond_flag/05_output v0.0.0
+	modernc.org/sqlite v1.38.2
+)
 
-require github.com/urfave/cli/v3 v3.4.0
+require (
+	github.com/charmbracelet/x/term v0.2.2 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
+	golang.org/x/sys v0.36.0 // indirect
+	modernc.org/libc v1.66.3 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.11.0 // indirect
+)
+
+// The output package lives in its own module in this repository.
+replace golang_roadmap/07_building_cli_beyond_flag/05_output => ../05_output
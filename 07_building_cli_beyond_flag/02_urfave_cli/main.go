@@ -2,77 +2,181 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
+	"io"
 	"log"
 	"os"
 	"strings"
 
 	"github.com/urfave/cli/v3"
+
+	"golang_roadmap/07_building_cli_beyond_flag/02_urfave_cli/userstore"
+	"golang_roadmap/07_building_cli_beyond_flag/05_output/output"
 )
 
-// simpleArgsLookup is a tiny helper to read flag-like args without relying
-// on the library's parsing APIs (keeps the example robust across minor API changes).
-func simpleArgsLookup(name string, short string, args []string) string {
-	for i, a := range args {
-		if a == "--"+name || a == short {
-			if i+1 < len(args) {
-				return args[i+1]
-			}
-		}
-		if strings.HasPrefix(a, "--"+name+"=") {
-			return strings.SplitN(a, "=", 2)[1]
-		}
+func main() {
+	if err := newApp(os.Stdout).Run(context.Background(), os.Args); err != nil {
+		log.Fatal(err)
 	}
-	return ""
 }
 
-func main() {
-	root := &cli.Command{
-		Name:  "example-cli",
-		Usage: "A small demo of urfave/cli v3",
+// newApp builds the command tree, writing to w.
+func newApp(w io.Writer) *cli.Command {
+	return &cli.Command{
+		Name:   "example-cli",
+		Usage:  "A small demo of urfave/cli v3",
+		Writer: w,
+		// Adds a hidden "completion" command that prints a script for
+		// bash, zsh, fish or PowerShell, and makes every command answer
+		// --generate-shell-completion, which those scripts call.
+		EnableShellCompletion: true,
 		Flags: []cli.Flag{
 			&cli.StringFlag{Name: "name", Aliases: []string{"n"}, Value: "World", Usage: "name to greet"},
 		},
 		Action: func(ctx context.Context, cmd *cli.Command) error {
-			// fallback to simple arg parsing for this small example
-			name := simpleArgsLookup("name", "-n", os.Args)
-			if name == "" {
-				name = "World"
-			}
-			fmt.Printf("Hello, %s!\n", name)
+			fmt.Fprintf(cmd.Root().Writer, "Hello, %s!\n", cmd.String("name"))
 			return nil
 		},
 		Commands: []*cli.Command{
 			{
-				Name:    "greet",
-				Aliases: []string{"g"},
-				Usage:   "greet someone",
+				Name:      "greet",
+				Aliases:   []string{"g"},
+				Usage:     "greet someone",
+				ArgsUsage: "[NAME]",
 				Action: func(ctx context.Context, cmd *cli.Command) error {
-					// positional args: use os.Args to find the command's positional argument
-					// naive: last arg if not a flag
-					target := ""
-					for i := len(os.Args) - 1; i >= 0; i-- {
-						a := os.Args[i]
-						if strings.HasPrefix(a, "-") {
-							continue
-						}
-						if a == "greet" || a == "g" || strings.HasSuffix(a, "main.go") {
-							break
-						}
-						target = a
-						break
-					}
+					target := cmd.Args().First()
 					if target == "" {
 						target = "stranger"
 					}
-					fmt.Printf("Greetings, %s!\n", target)
+					fmt.Fprintf(cmd.Root().Writer, "Greetings, %s!\n", target)
 					return nil
 				},
 			},
+			usersCommand(),
 		},
 	}
+}
 
-	if err := root.Run(context.Background(), os.Args); err != nil {
-		log.Fatal(err)
+// usersCommand manages users in SQLite. "show" and "delete" complete
+// their argument with the IDs in the database.
+func usersCommand() *cli.Command {
+	return &cli.Command{
+		Name:  "users",
+		Usage: "manage users in a SQLite database",
+		Flags: []cli.Flag{
+			&cli.StringFlag{
+				Name: "db", Value: "users.db", Usage: "SQLite database file",
+				Sources: cli.EnvVars("EXAMPLE_CLI_DB"), TakesFile: true,
+			},
+		},
+		Commands: []*cli.Command{
+			{
+				Name: "add", Usage: "add a user", ArgsUsage: "NAME EMAIL",
+				Action: withStore(func(ctx context.Context, cmd *cli.Command, s *userstore.Store) error {
+					if cmd.NArg() != 2 {
+						return cli.Exit("add needs NAME and EMAIL", 2)
+					}
+					u, err := s.Add(ctx, cmd.Args().Get(0), cmd.Args().Get(1))
+					if err != nil {
+						return err
+					}
+					fmt.Fprintln(cmd.Root().Writer, u.ID)
+					return nil
+				}),
+			},
+			{
+				Name: "list", Usage: "list users",
+				Flags: []cli.Flag{
+					&cli.StringFlag{
+						Name: "output", Aliases: []string{"o"}, Value: string(output.Table), Usage: output.Choices,
+						Validator: func(s string) error { _, err := output.ParseFormat(s); return err },
+					},
+				},
+				Action: withStore(func(ctx context.Context, cmd *cli.Command, s *userstore.Store) error {
+					users, err := s.List(ctx, "")
+					if err != nil {
+						return err
+					}
+					format, _ := output.ParseFormat(cmd.String("output"))
+					return output.Print(output.NewPrinter(cmd.Root().Writer, format, "auto"), users, userSpec)
+				}),
+			},
+			{
+				Name: "show", Usage: "show a user", ArgsUsage: "ID",
+				ShellComplete: completeUserIDs,
+				Action: withStore(func(ctx context.Context, cmd *cli.Command, s *userstore.Store) error {
+					u, err := s.Get(ctx, cmd.Args().First())
+					if err != nil {
+						return err
+					}
+					fmt.Fprintf(cmd.Root().Writer, "%s\t%s\t%s\n", u.ID, u.Name, u.Email)
+					return nil
+				}),
+			},
+			{
+				Name: "delete", Usage: "delete a user", ArgsUsage: "ID",
+				ShellComplete: completeUserIDs,
+				Action: withStore(func(ctx context.Context, cmd *cli.Command, s *userstore.Store) error {
+					return s.Delete(ctx, cmd.Args().First())
+				}),
+			},
+		},
+	}
+}
+
+var userSpec = output.Spec[userstore.User]{
+	Columns: []output.Column[userstore.User]{
+		{Header: "ID", Value: func(u userstore.User) string { return u.ID }},
+		{Header: "NAME", Value: func(u userstore.User) string { return u.Name }, Truncate: true},
+		{Header: "EMAIL", Value: func(u userstore.User) string { return u.Email }, Truncate: true},
+	},
+}
+
+func withStore(fn func(context.Context, *cli.Command, *userstore.Store) error) cli.ActionFunc {
+	return func(ctx context.Context, cmd *cli.Command) error {
+		s, err := userstore.Open(cmd.String("db"))
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+		return fn(ctx, cmd, s)
+	}
+}
+
+// completeUserIDs prints the user IDs, one per line, for the completion
+// script; the shell keeps those that match the word being typed. zsh also
+// shows a description after a colon. Errors print nothing: a completion
+// must never fill the terminal with messages.
+func completeUserIDs(ctx context.Context, cmd *cli.Command) {
+	args := cmd.Args().Slice()
+	if len(args) > 0 && strings.HasPrefix(args[len(args)-1], "-") {
+		cli.DefaultCompleteWithFlags(ctx, cmd)
+		return
+	}
+	if len(args) > 0 {
+		return // the ID is already there
+	}
+	// Do not let a completion create an empty database.
+	path := cmd.String("db")
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return
+	}
+	s, err := userstore.Open(path)
+	if err != nil {
+		return
+	}
+	defer s.Close()
+	users, err := s.List(ctx, "")
+	if err != nil {
+		return
+	}
+	zsh := strings.HasSuffix(os.Getenv("SHELL"), "zsh")
+	for _, u := range users {
+		if zsh {
+			fmt.Fprintf(cmd.Root().Writer, "%s:%s <%s>\n", u.ID, u.Name, u.Email)
+		} else {
+			fmt.Fprintln(cmd.Root().Writer, u.ID)
+		}
 	}
 }
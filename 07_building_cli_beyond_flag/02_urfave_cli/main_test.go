@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"golang_roadmap/07_building_cli_beyond_flag/02_urfave_cli/userstore"
+)
+
+func complete(t *testing.T, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	args = append(append([]string{"example-cli"}, args...), "--generate-shell-completion")
+	if err := newApp(&out).Run(context.Background(), args); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestCompleteUserIDs(t *testing.T) {
+	t.Setenv("SHELL", "/bin/bash")
+	db := filepath.Join(t.TempDir(), "users.db")
+	s, err := userstore.Open(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Ada", "Grace"} {
+		if _, err := s.Add(context.Background(), name, name+"@example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+
+	if got := complete(t, "users", "--db", db, "show"); got != "u1001\nu1002\n" {
+		t.Errorf("show completes %q; want both IDs", got)
+	}
+	if got := complete(t, "users", "--db", db, "delete", "u1001"); got != "" {
+		t.Errorf("delete with an ID completes %q; want nothing", got)
+	}
+
+	t.Setenv("SHELL", "/usr/bin/zsh")
+	if got := complete(t, "users", "--db", db, "show"); got != "u1001:Ada <Ada@example.com>\nu1002:Grace <Grace@example.com>\n" {
+		t.Errorf("zsh completion = %q; want descriptions", got)
+	}
+}
+
+func TestCompleteUserIDs_MissingDatabase(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "users.db")
+	if got := complete(t, "users", "--db", db, "show"); got != "" {
+		t.Errorf("completion without a database = %q", got)
+	}
+	if _, err := os.Stat(db); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("completion created the database: %v", err)
+	}
+}
+
+func TestCompleteSubcommands(t *testing.T) {
+	if got := complete(t, "users"); got != "add\nlist\nshow\ndelete\nhelp\n" {
+		t.Errorf("users completes %q", got)
+	}
+}
+
+func run(t *testing.T, args ...string) string {
+	t.Helper()
+	var out bytes.Buffer
+	if err := newApp(&out).Run(context.Background(), append([]string{"example-cli"}, args...)); err != nil {
+		t.Fatal(err)
+	}
+	return out.String()
+}
+
+func TestGreetings(t *testing.T) {
+	for _, c := range []struct {
+		args []string
+		want string
+	}{
+		{nil, "Hello, World!\n"},
+		{[]string{"--name", "Ada"}, "Hello, Ada!\n"},
+		{[]string{"-n=Grace"}, "Hello, Grace!\n"},
+		{[]string{"greet", "Bob"}, "Greetings, Bob!\n"},
+		{[]string{"--name", "Ada", "g"}, "Greetings, stranger!\n"},
+	} {
+		if got := run(t, c.args...); got != c.want {
+			t.Errorf("%q printed %q; want %q", c.args, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,102 @@
+// Package userstore keeps users in SQLite for the CLI examples. Its
+// IDs are what the shell completion scripts complete.
+package userstore
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	_ "modernc.org/sqlite"
+)
+
+var ErrNotFound = errors.New("user not found")
+
+type User struct {
+	ID    string `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+type Store struct {
+	db *sql.DB
+}
+
+const schema = `CREATE TABLE IF NOT EXISTS users (
+	id    TEXT PRIMARY KEY,
+	name  TEXT NOT NULL,
+	email TEXT NOT NULL
+)`
+
+// Open opens or creates the database at path.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("userstore: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error { return s.db.Close() }
+
+// Add stores a user. IDs are "u" and a number one past the highest so
+// far: short enough to type, and worth completing.
+func (s *Store) Add(ctx context.Context, name, email string) (User, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COALESCE(MAX(CAST(SUBSTR(id, 2) AS INTEGER)), 1000) + 1 FROM users`).Scan(&n)
+	if err != nil {
+		return User{}, err
+	}
+	u := User{ID: fmt.Sprintf("u%d", n), Name: name, Email: email}
+	_, err = s.db.ExecContext(ctx, `INSERT INTO users (id, name, email) VALUES (?, ?, ?)`, u.ID, u.Name, u.Email)
+	return u, err
+}
+
+func (s *Store) Get(ctx context.Context, id string) (User, error) {
+	var u User
+	err := s.db.QueryRowContext(ctx, `SELECT id, name, email FROM users WHERE id = ?`, id).Scan(&u.ID, &u.Name, &u.Email)
+	if errors.Is(err, sql.ErrNoRows) {
+		return User{}, fmt.Errorf("%s: %w", id, ErrNotFound)
+	}
+	return u, err
+}
+
+func (s *Store) Delete(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx, `DELETE FROM users WHERE id = ?`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return fmt.Errorf("%s: %w", id, ErrNotFound)
+	}
+	return nil
+}
+
+// List returns the users whose IDs start with prefix, by ID. Completion
+// passes the word being typed as the prefix.
+func (s *Store) List(ctx context.Context, prefix string) ([]User, error) {
+	// Escape LIKE's wildcards, so a typed "_" matches only "_".
+	esc := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(prefix)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, name, email FROM users WHERE id LIKE ? ESCAPE '\' ORDER BY LENGTH(id), id`, esc+"%")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
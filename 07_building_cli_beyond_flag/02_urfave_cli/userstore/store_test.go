@@ -0,0 +1,49 @@
+package userstore
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+)
+
+func TestStore(t *testing.T) {
+	ctx := context.Background()
+	s, err := Open(filepath.Join(t.TempDir(), "users.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+
+	var ids []string
+	for _, name := range []string{"Ada", "Grace", "Linus"} {
+		u, err := s.Add(ctx, name, name+"@example.com")
+		if err != nil {
+			t.Fatal(err)
+		}
+		ids = append(ids, u.ID)
+	}
+	if ids[0] != "u1001" || ids[2] != "u1003" {
+		t.Errorf("IDs = %v; want u1001 upwards", ids)
+	}
+
+	if u, err := s.Get(ctx, "u1002"); err != nil || u.Name != "Grace" {
+		t.Errorf("Get = %+v, %v", u, err)
+	}
+	if err := s.Delete(ctx, "u1002"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Get(ctx, "u1002"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: %v; want ErrNotFound", err)
+	}
+	if err := s.Delete(ctx, "u1002"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("second Delete: %v; want ErrNotFound", err)
+	}
+
+	for prefix, want := range map[string]int{"": 2, "u100": 2, "u1003": 1, "u2": 0, "u_": 0, "%": 0} {
+		users, err := s.List(ctx, prefix)
+		if err != nil || len(users) != want {
+			t.Errorf("List(%q) = %d users, %v; want %d", prefix, len(users), err, want)
+		}
+	}
+}
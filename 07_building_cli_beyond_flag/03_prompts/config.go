@@ -0,0 +1,106 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Config is what the wizard writes.
+type Config struct {
+	Name     string   `json:"name"`
+	Driver   string   `json:"driver"`
+	Features []string `json:"features"`
+	Password string   `json:"db_password,omitempty"`
+}
+
+var (
+	drivers  = []string{"sqlite", "postgres", "mysql"}
+	features = []string{"metrics", "tracing", "auth", "cache"}
+	nameRe   = regexp.MustCompile(`^[a-z][a-z0-9-]{1,30}$`)
+)
+
+// The checks are separate functions so the prompts can run them on each
+// answer and the non-interactive path on each flag.
+
+func checkName(s string) error {
+	if !nameRe.MatchString(s) {
+		return errors.New("use 2-31 lowercase letters, digits and dashes, starting with a letter")
+	}
+	return nil
+}
+
+func checkDriver(s string) error {
+	if !slices.Contains(drivers, s) {
+		return fmt.Errorf("must be one of %s", strings.Join(drivers, ", "))
+	}
+	return nil
+}
+
+func checkFeatures(fs []string) error {
+	for _, f := range fs {
+		if !slices.Contains(features, f) {
+			return fmt.Errorf("unknown feature %q; choose from %s", f, strings.Join(features, ", "))
+		}
+	}
+	return nil
+}
+
+// checkPassword applies to the servers; SQLite has no password.
+func checkPassword(driver, pw string) error {
+	if driver != "sqlite" && len(pw) < 8 {
+		return errors.New("must be at least 8 characters")
+	}
+	return nil
+}
+
+// Validate checks the whole config, reporting every problem.
+func (c *Config) Validate() error {
+	var errs []error
+	wrap := func(field string, err error) {
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", field, err))
+		}
+	}
+	wrap("name", checkName(c.Name))
+	wrap("driver", checkDriver(c.Driver))
+	wrap("features", checkFeatures(c.Features))
+	wrap("password", checkPassword(c.Driver, c.Password))
+	return errors.Join(errs...)
+}
+
+// Write saves c as JSON. The file holds a password, so it is readable by
+// its owner only, and written to a temporary file first, so an existing
+// config is replaced whole or not at all.
+func (c *Config) Write(path string) error {
+	out := *c
+	if out.Features == nil {
+		out.Features = []string{} // [], not null
+	}
+	data, err := json.MarshalIndent(out, "", "  ")
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".config-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(append(data, '\n')); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	// CreateTemp already uses 0600; keep it that way on purpose.
+	if err := os.Chmod(tmp.Name(), 0o600); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
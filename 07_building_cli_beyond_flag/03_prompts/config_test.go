@@ -0,0 +1,69 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestConfig_Validate(t *testing.T) {
+	for _, tt := range []struct {
+		cfg     Config
+		wantErr []string
+	}{
+		{Config{Name: "svc", Driver: "sqlite"}, nil},
+		{Config{Name: "svc", Driver: "postgres", Password: "12345678", Features: []string{"auth"}}, nil},
+		{Config{Name: "svc", Driver: "postgres", Password: "short"}, []string{"password"}},
+		{Config{Name: "Svc", Driver: "oracle", Features: []string{"metrics", "ai"}}, []string{"name", "driver", `"ai"`}},
+	} {
+		err := tt.cfg.Validate()
+		if len(tt.wantErr) == 0 && err != nil {
+			t.Errorf("%+v: %v", tt.cfg, err)
+		}
+		for _, want := range tt.wantErr {
+			if err == nil || !strings.Contains(err.Error(), want) {
+				t.Errorf("%+v: err = %v; want it to mention %s", tt.cfg, err, want)
+			}
+		}
+	}
+}
+
+func TestConfig_Write(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.json")
+	os.WriteFile(path, []byte("old"), 0o644)
+
+	if err := (&Config{Name: "svc", Driver: "mysql", Password: "12345678"}).Write(path); err != nil {
+		t.Fatal(err)
+	}
+	fi, err := os.Stat(path)
+	if err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("mode = %v, %v; want 0600 for a file with a password", fi.Mode(), err)
+	}
+	data, _ := os.ReadFile(path)
+	var got map[string]any
+	if err := json.Unmarshal(data, &got); err != nil || got["db_password"] != "12345678" {
+		t.Errorf("wrote %s, %v", data, err)
+	}
+	if fs, ok := got["features"].([]any); !ok || len(fs) != 0 {
+		t.Errorf("features = %v; want []", got["features"])
+	}
+}
+
+func TestReadPassword(t *testing.T) {
+	t.Setenv(passwordEnv, "from-env")
+	if pw, ok, err := readPassword("", nil); pw != "from-env" || !ok || err != nil {
+		t.Errorf("env: %q, %v, %v", pw, ok, err)
+	}
+	if pw, ok, err := readPassword("-", strings.NewReader("from stdin\r\n")); pw != "from stdin" || !ok || err != nil {
+		t.Errorf("stdin: %q, %v, %v; want the line without its ending", pw, ok, err)
+	}
+	if _, _, err := readPassword(filepath.Join(t.TempDir(), "nope"), nil); err == nil {
+		t.Error("missing file: no error")
+	}
+	os.Unsetenv(passwordEnv)
+	if _, ok, _ := readPassword("", nil); ok {
+		t.Error("no env, no file: reported as given")
+	}
+}
@@ -0,0 +1,137 @@
+// Demonstrates interactive prompts with charmbracelet/huh, in an
+// init-wizard that writes a config file.
+//
+// This example shows:
+// - Text input with validation, a select, a multi-select and a confirm
+// - Password input that is not echoed
+// - A question that is skipped depending on an earlier answer
+// - Flags that answer questions in advance
+// - A non-interactive mode for scripts, used when stdin is not a terminal
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+	"github.com/charmbracelet/x/term"
+)
+
+// passwordEnv is read when no -password-file is given. Passwords are not
+// taken as flags: they would show in ps and in shell history.
+const passwordEnv = "INIT_DB_PASSWORD"
+
+func main() {
+	out := flag.String("out", "app.json", "config file to write")
+	name := flag.String("name", "", "project name")
+	driver := flag.String("driver", "", "database: "+strings.Join(drivers, ", "))
+	feats := flag.String("features", "", "comma-separated features: "+strings.Join(features, ", "))
+	pwFile := flag.String("password-file", "", "read the database password from this file, or - for stdin (default $"+passwordEnv+")")
+	yes := flag.Bool("yes", false, "do not ask for confirmation; overwrite an existing file")
+	noInput := flag.Bool("no-input", false, "never prompt; fail if something is missing")
+	accessible := flag.Bool("accessible", os.Getenv("ACCESSIBLE") != "", "plain-text prompts, for screen readers")
+	flag.Parse()
+
+	cfg := Config{Name: *name, Driver: *driver}
+	set := map[string]bool{}
+	flag.Visit(func(f *flag.Flag) { set[f.Name] = true })
+	if set["features"] {
+		cfg.Features = splitList(*feats)
+	}
+	pw, pwGiven, err := readPassword(*pwFile, os.Stdin)
+	if err != nil {
+		fail(err)
+	}
+	cfg.Password = pw
+
+	_, err = os.Stat(*out)
+	exists := err == nil
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		fail(err)
+	}
+
+	// Prompting needs a person at a terminal. A pipe or a CI job gets the
+	// non-interactive path, as if -no-input were given.
+	if *noInput || !isTerminal(os.Stdin) || !isTerminal(os.Stdout) || *pwFile == "-" {
+		if cfg.Driver == "" {
+			cfg.Driver = "sqlite"
+		}
+		if exists && !*yes {
+			fail(fmt.Errorf("%s exists; use -yes to overwrite it", *out))
+		}
+	} else {
+		ask := missing{
+			name:     cfg.Name == "",
+			driver:   cfg.Driver == "",
+			features: !set["features"],
+			password: !pwGiven,
+		}
+		ok, err := runWizard(&cfg, ask, *out, exists, !*yes, *accessible)
+		if errors.Is(err, huh.ErrUserAborted) {
+			fmt.Fprintln(os.Stderr, "aborted")
+			os.Exit(130)
+		}
+		if err != nil {
+			fail(err)
+		}
+		if !ok {
+			fmt.Println("Nothing written.")
+			return
+		}
+	}
+
+	if cfg.Driver == "sqlite" {
+		cfg.Password = ""
+	}
+	if err := cfg.Validate(); err != nil {
+		fail(err)
+	}
+	if err := cfg.Write(*out); err != nil {
+		fail(err)
+	}
+	fmt.Printf("Wrote %s (%s).\n", *out, summary(&cfg))
+}
+
+// readPassword reads the password from file, "-" meaning stdin, or else
+// from $INIT_DB_PASSWORD. It reports whether one was given at all.
+func readPassword(file string, stdin io.Reader) (string, bool, error) {
+	var data []byte
+	var err error
+	switch file {
+	case "":
+		pw, ok := os.LookupEnv(passwordEnv)
+		return pw, ok, nil
+	case "-":
+		data, err = io.ReadAll(stdin)
+	default:
+		data, err = os.ReadFile(file)
+	}
+	if err != nil {
+		return "", false, err
+	}
+	return strings.TrimRight(string(data), "\r\n"), true, nil
+}
+
+func splitList(s string) []string {
+	var out []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.TrimSpace(f); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// isTerminal reports whether f is a terminal rather than a file, a pipe
+// or /dev/null.
+func isTerminal(f *os.File) bool { return term.IsTerminal(f.Fd()) }
+
+func fail(err error) {
+	fmt.Fprintln(os.Stderr, "error:", err)
+	os.Exit(1)
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/huh"
+)
+
+// missing says which settings the flags left out; the wizard asks only
+// for those.
+type missing struct {
+	name, driver, features, password bool
+}
+
+// runWizard prompts for the missing settings, storing the answers in cfg,
+// then asks to confirm writing path unless confirm is false. It returns
+// false if the user declines. In accessible mode the prompts are plain
+// numbered questions instead of a full-screen form, for screen readers.
+func runWizard(cfg *Config, ask missing, path string, exists, confirm, accessible bool) (bool, error) {
+	var groups []*huh.Group
+	if ask.name || ask.driver || ask.features {
+		var fields []huh.Field
+		if ask.name {
+			fields = append(fields, huh.NewInput().
+				Title("Project name").
+				Placeholder("my-service").
+				Value(&cfg.Name).
+				Validate(checkName))
+		}
+		if ask.driver {
+			fields = append(fields, huh.NewSelect[string]().
+				Title("Database").
+				Options(huh.NewOptions(drivers...)...).
+				Value(&cfg.Driver))
+		}
+		if ask.features {
+			fields = append(fields, huh.NewMultiSelect[string]().
+				Title("Features").
+				Description("space to toggle, enter to continue").
+				Options(huh.NewOptions(features...)...).
+				Value(&cfg.Features))
+		}
+		groups = append(groups, huh.NewGroup(fields...))
+	}
+	if ask.password {
+		// The driver may be chosen in the group above, so whether this
+		// group shows is decided when it is reached.
+		groups = append(groups, huh.NewGroup(
+			huh.NewInput().
+				Title("Database password").
+				Description("not shown as you type").
+				EchoMode(huh.EchoModePassword).
+				Value(&cfg.Password).
+				Validate(func(pw string) error { return checkPassword(cfg.Driver, pw) }),
+		).WithHideFunc(func() bool { return cfg.Driver == "sqlite" }))
+	}
+
+	ok := true
+	if confirm {
+		title := "Write " + path + "?"
+		if exists {
+			title = path + " exists. Overwrite it?"
+		}
+		groups = append(groups, huh.NewGroup(
+			huh.NewConfirm().
+				Title(title).
+				DescriptionFunc(func() string { return summary(cfg) }, cfg).
+				Affirmative("Write").
+				Negative("Cancel").
+				Value(&ok),
+		))
+	}
+	if len(groups) == 0 {
+		return true, nil
+	}
+	if err := huh.NewForm(groups...).WithAccessible(accessible).Run(); err != nil {
+		return false, err
+	}
+	return ok, nil
+}
+
+func summary(cfg *Config) string {
+	fs := strings.Join(cfg.Features, ", ")
+	if fs == "" {
+		fs = "none"
+	}
+	return fmt.Sprintf("%s on %s, features: %s", cfg.Name, cfg.Driver, fs)
+}
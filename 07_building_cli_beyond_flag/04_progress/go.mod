@@ -0,0 +1,7 @@
+module golang_roadmap/07_building_cli_beyond_flag/04_progress
+
+go 1.24.11
+
+require github.com/charmbracelet/x/term v0.2.2
+
+require golang.org/x/sys v0.36.0 // indirect
@@ -0,0 +1,200 @@
+// Demonstrates progress reporting for long command-line operations.
+//
+// This example shows:
+// - A progress bar fed by an io.Writer wrapper during a file copy
+// - A spinner for work whose length is unknown
+// - One bar per download for concurrent downloads, redrawn together
+// - Plain log lines instead of redrawing when output is not a terminal
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang_roadmap/07_building_cli_beyond_flag/04_progress/progress"
+)
+
+func main() {
+	sizeMiB := flag.Int64("size", 64, "size of the file to copy, in MiB")
+	rateMiB := flag.Int64("rate", 32, "copy speed limit in MiB/s, so the bar can be seen; 0 for none")
+	downloads := flag.Int("downloads", 4, "number of concurrent downloads")
+	flag.Parse()
+
+	dir, err := os.MkdirTemp("", "progress-demo-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Progress goes to stderr, so "go run . > out.txt" still shows it
+	// while stdout gets only the results.
+	src := filepath.Join(dir, "source.bin")
+	spin := progress.Spin(os.Stderr, "Preparing a test file")
+	if err := makeFile(src, *sizeMiB<<20); err != nil {
+		spin.Stop("failed")
+		log.Fatal(err)
+	}
+	spin.Stop("done")
+
+	n, err := copyFile(filepath.Join(dir, "copy.bin"), src, *rateMiB<<20)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("copied %d bytes\n", n)
+
+	total, err := downloadAll(*downloads)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("downloaded %d bytes in %d files\n", total, *downloads)
+}
+
+func makeFile(path string, size int64) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := io.CopyN(f, rand.Reader, size); err != nil {
+		f.Close()
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// copyFile copies src to dst with a bar. The bar sees the bytes through
+// io.MultiWriter, so the copy itself is an ordinary io.Copy.
+func copyFile(dst, src string, limit int64) (int64, error) {
+	in, err := os.Open(src)
+	if err != nil {
+		return 0, err
+	}
+	defer in.Close()
+	fi, err := in.Stat()
+	if err != nil {
+		return 0, err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return 0, err
+	}
+
+	bar := progress.NewBar(os.Stderr, "copy", fi.Size())
+	var r io.Reader = in
+	if limit > 0 {
+		r = &throttledReader{r: in, perSecond: limit, start: time.Now()}
+	}
+	n, err := io.Copy(io.MultiWriter(out, bar), r)
+	bar.Finish()
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	return n, err
+}
+
+// throttledReader limits reads to perSecond bytes on average, by sleeping
+// whenever it is ahead of schedule.
+type throttledReader struct {
+	r         io.Reader
+	perSecond int64
+	start     time.Time
+	n         int64
+}
+
+func (t *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > 256<<10 {
+		p = p[:256<<10]
+	}
+	n, err := t.r.Read(p)
+	t.n += int64(n)
+	due := time.Duration(float64(t.n) / float64(t.perSecond) * float64(time.Second))
+	if wait := due - time.Since(t.start); wait > 0 {
+		time.Sleep(wait)
+	}
+	return n, err
+}
+
+// downloadAll downloads count files at once from a local test server that
+// sends each at a different speed. The last file has no Content-Length,
+// so its bar cannot show a percentage.
+func downloadAll(count int) (int64, error) {
+	srv := httptest.NewServer(http.HandlerFunc(serveFile))
+	defer srv.Close()
+
+	multi := progress.NewMulti(os.Stderr)
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		total int64
+		errs  []error
+	)
+	for i := range count {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			n, err := download(context.Background(), multi, srv.URL, i, i == count-1)
+			mu.Lock()
+			defer mu.Unlock()
+			total += n
+			errs = append(errs, err)
+		}()
+	}
+	wg.Wait()
+	multi.Wait()
+	return total, errors.Join(errs...)
+}
+
+func download(ctx context.Context, multi *progress.Multi, base string, i int, chunked bool) (int64, error) {
+	url := fmt.Sprintf("%s/file-%d.bin?size=%d&speed=%d", base, i, (4+2*i)<<20, (2+i)<<20)
+	if chunked {
+		url += "&chunked=1"
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// ContentLength is -1 when the server does not say; the bar then
+	// shows bytes and speed only.
+	bar := multi.Add(fmt.Sprintf("file-%d.bin", i), resp.ContentLength)
+	defer bar.Finish()
+	return io.Copy(io.Discard, io.TeeReader(resp.Body, bar))
+}
+
+// serveFile sends size bytes at about speed bytes per second.
+func serveFile(w http.ResponseWriter, r *http.Request) {
+	size, _ := strconv.ParseInt(r.FormValue("size"), 10, 64)
+	speed, _ := strconv.ParseInt(r.FormValue("speed"), 10, 64)
+	if r.FormValue("chunked") == "" {
+		w.Header().Set("Content-Length", strconv.FormatInt(size, 10))
+	}
+	chunk := make([]byte, speed/20)
+	for sent := int64(0); sent < size; sent += int64(len(chunk)) {
+		if _, err := w.Write(chunk[:min(int64(len(chunk)), size-sent)]); err != nil {
+			return
+		}
+		if f, ok := w.(http.Flusher); ok {
+			f.Flush()
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+}
@@ -0,0 +1,166 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// redrawEvery limits how often a bar redraws; a copy calls Write far more
+// often than a person can read.
+const redrawEvery = 100 * time.Millisecond
+
+// Bar shows the progress of work of a known size, such as a copy. Its
+// methods are safe for concurrent use.
+type Bar struct {
+	label string
+	total int64 // 0 or less: unknown
+	d     display
+	start time.Time
+	end   time.Time // set by Finish
+	now   func() time.Time
+	n     atomic.Int64
+
+	mu       sync.Mutex
+	lastDraw time.Time
+	lastStep int64 // last tenth reported when not on a terminal
+	done     bool
+	multi    bool // drawn by a Multi, not by itself
+}
+
+// NewBar returns a bar writing to w, usually os.Stderr, so that stdout
+// stays free for the program's real output. total is the expected number
+// of bytes; 0 if unknown.
+func NewBar(w io.Writer, label string, total int64) *Bar {
+	return newBar(detect(w), label, total)
+}
+
+func newBar(d display, label string, total int64) *Bar {
+	return &Bar{label: label, total: total, d: d, start: time.Now(), now: time.Now}
+}
+
+// Write counts len(p) bytes. It never fails, so in an io.MultiWriter it
+// cannot break the copy it is watching.
+func (b *Bar) Write(p []byte) (int, error) {
+	b.Add(int64(len(p)))
+	return len(p), nil
+}
+
+// Add counts n more bytes done.
+func (b *Bar) Add(n int64) {
+	cur := b.n.Add(n)
+	if b.multi {
+		return
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return
+	}
+	if b.d.tty {
+		if now := b.now(); now.Sub(b.lastDraw) >= redrawEvery {
+			b.lastDraw = now
+			fmt.Fprint(b.d.w, clearLine+b.line(b.d.width))
+		}
+		return
+	}
+	// Not a terminal: a line per 10%, or nothing if the total is unknown.
+	if b.total > 0 {
+		if step := min(cur*10/b.total, 10); step > b.lastStep && step < 10 {
+			b.lastStep = step
+			fmt.Fprintf(b.d.w, "%s: %d%% (%s of %s)\n", b.label, step*10, formatBytes(cur), formatBytes(b.total))
+		}
+	}
+}
+
+// Current returns the bytes counted so far.
+func (b *Bar) Current() int64 { return b.n.Load() }
+
+// Finish draws the bar a last time, with the total time and average
+// speed, and ends its line.
+func (b *Bar) Finish() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.done {
+		return
+	}
+	b.done = true
+	b.end = b.now()
+	if b.multi {
+		return
+	}
+	if b.d.tty {
+		fmt.Fprint(b.d.w, clearLine)
+	}
+	fmt.Fprintln(b.d.w, b.summary())
+}
+
+func (b *Bar) summary() string {
+	elapsed := b.end.Sub(b.start)
+	return fmt.Sprintf("%s: done, %s in %s (%s/s)", b.label, formatBytes(b.n.Load()),
+		elapsed.Round(10*time.Millisecond), formatBytes(rate(b.n.Load(), elapsed)))
+}
+
+// line renders the bar to fit width columns:
+//
+//	copy [=========>          ]  45%  12.0 MiB/26.7 MiB  4.1 MiB/s  ETA 4s
+//
+// With an unknown total, it shows the count and speed only.
+func (b *Bar) line(width int) string {
+	cur := b.n.Load()
+	elapsed := b.now().Sub(b.start)
+	speed := rate(cur, elapsed)
+	if b.total <= 0 {
+		return fmt.Sprintf("%s  %s  %s/s", b.label, formatBytes(cur), formatBytes(speed))
+	}
+
+	frac := min(float64(cur)/float64(b.total), 1)
+	eta := "ETA --"
+	if speed > 0 {
+		eta = "ETA " + time.Duration(float64(b.total-cur)/float64(speed)*float64(time.Second)).Round(time.Second).String()
+	}
+	stats := fmt.Sprintf(" %3.0f%%  %s/%s  %s/s  %s", frac*100, formatBytes(cur), formatBytes(b.total), formatBytes(speed), eta)
+
+	// Whatever room is left goes to the bar itself.
+	barWidth := width - len(b.label) - len(stats) - 3
+	if barWidth < 10 {
+		return truncate(b.label+stats, width)
+	}
+	filled := int(frac * float64(barWidth))
+	bar := strings.Repeat("=", filled)
+	if filled < barWidth {
+		bar += ">" + strings.Repeat(" ", barWidth-filled-1)
+	}
+	return b.label + " [" + bar + "]" + stats
+}
+
+// rate is bytes per second, 0 before any time has passed.
+func rate(n int64, elapsed time.Duration) int64 {
+	if elapsed <= 0 {
+		return 0
+	}
+	return int64(float64(n) / elapsed.Seconds())
+}
+
+func truncate(s string, width int) string {
+	if len(s) <= width {
+		return s
+	}
+	return s[:max(width-1, 0)] + "…"
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
@@ -0,0 +1,103 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Multi draws several bars at once, one line each, for concurrent work.
+// Bars must not write on their own then, or the lines would interleave;
+// a single goroutine redraws them all together instead.
+type Multi struct {
+	d    display
+	mu   sync.Mutex
+	bars []*Bar
+	// reported holds the bars whose "done" line was printed, when not on
+	// a terminal.
+	reported map[*Bar]bool
+	drawn    int // lines drawn last time, to move the cursor back up
+	stop     chan struct{}
+	wg       sync.WaitGroup
+}
+
+// NewMulti starts redrawing on w until Wait.
+func NewMulti(w io.Writer) *Multi {
+	return newMulti(detect(w))
+}
+
+func newMulti(d display) *Multi {
+	m := &Multi{d: d, reported: map[*Bar]bool{}, stop: make(chan struct{})}
+	m.wg.Add(1)
+	go m.run()
+	return m
+}
+
+// Add creates a bar drawn by m.
+func (m *Multi) Add(label string, total int64) *Bar {
+	b := newBar(m.d, label, total)
+	b.multi = true
+	m.mu.Lock()
+	m.bars = append(m.bars, b)
+	m.mu.Unlock()
+	return b
+}
+
+// Wait stops redrawing, after drawing every bar one last time. Call it
+// when all bars are finished.
+func (m *Multi) Wait() {
+	close(m.stop)
+	m.wg.Wait()
+}
+
+func (m *Multi) run() {
+	defer m.wg.Done()
+	t := time.NewTicker(redrawEvery)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			m.draw()
+		case <-m.stop:
+			m.draw()
+			return
+		}
+	}
+}
+
+func (m *Multi) draw() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if !m.d.tty {
+		// A line per finished bar, in the order they finish.
+		for _, b := range m.bars {
+			b.mu.Lock()
+			done := b.done
+			b.mu.Unlock()
+			if done && !m.reported[b] {
+				m.reported[b] = true
+				fmt.Fprintln(m.d.w, b.summary())
+			}
+		}
+		return
+	}
+
+	var out strings.Builder
+	if m.drawn > 0 {
+		fmt.Fprintf(&out, "\x1b[%dA", m.drawn) // cursor up to the first bar
+	}
+	for _, b := range m.bars {
+		b.mu.Lock()
+		line := b.line(m.d.width)
+		if b.done {
+			line = b.summary()
+		}
+		b.mu.Unlock()
+		out.WriteString(clearLine + truncate(line, m.d.width) + "\n")
+	}
+	m.drawn = len(m.bars)
+	// One write per frame, so the terminal never shows half of one.
+	io.WriteString(m.d.w, out.String())
+}
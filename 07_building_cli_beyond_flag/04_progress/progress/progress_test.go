@@ -0,0 +1,154 @@
+package progress
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// fakeClock returns a bar clock that moves only when told.
+func fakeClock(b *Bar) *time.Time {
+	now := b.start
+	b.now = func() time.Time { return now }
+	return &now
+}
+
+func TestBar_PlainOutput(t *testing.T) {
+	var buf bytes.Buffer
+	b := newBar(display{w: &buf, width: 80}, "copy", 1000)
+	now := fakeClock(b)
+
+	n, err := io.Copy(b, strings.NewReader(strings.Repeat("x", 250)))
+	if n != 250 || err != nil || b.Current() != 250 {
+		t.Fatalf("Copy = %d, %v; Current = %d", n, err, b.Current())
+	}
+	b.Add(10) // still 26%: no new line
+	b.Add(740)
+	*now = now.Add(2 * time.Second)
+	b.Finish()
+	b.Finish()
+
+	want := "copy: 20% (250 B of 1000 B)\n" +
+		"copy: done, 1000 B in 2s (500 B/s)\n"
+	if got := buf.String(); got != want {
+		t.Errorf("output =\n%s\nwant\n%s", got, want)
+	}
+	b.Add(1)
+	if buf.String() != want {
+		t.Error("bar wrote after Finish")
+	}
+}
+
+func TestBar_Line(t *testing.T) {
+	b := newBar(display{w: io.Discard, width: 80}, "copy", 4<<20)
+	now := fakeClock(b)
+	b.Add(1 << 20)
+	*now = now.Add(time.Second)
+
+	got := b.line(80)
+	want := "copy [========>                       ]  25%  1.0 MiB/4.0 MiB  1.0 MiB/s  ETA 3s"
+	if got != want || len(got) != 80 {
+		t.Errorf("line =\n%q\nwant\n%q, filling all 80 columns", got, want)
+	}
+	if got := b.line(30); !strings.HasPrefix(got, "copy  25%") || len([]rune(got)) > 30 {
+		t.Errorf("narrow line = %q; want the stats only, cut to 30 columns", got)
+	}
+
+	unknown := newBar(display{w: io.Discard}, "get", 0)
+	now = fakeClock(unknown)
+	unknown.Add(2048)
+	*now = now.Add(2 * time.Second)
+	if got := unknown.line(80); got != "get  2.0 KiB  1.0 KiB/s" {
+		t.Errorf("unknown total: %q", got)
+	}
+}
+
+func TestBar_TerminalRedrawsThrottled(t *testing.T) {
+	var buf bytes.Buffer
+	b := newBar(display{w: &buf, tty: true, width: 80}, "copy", 100)
+	now := fakeClock(b)
+	for range 10 {
+		b.Add(1) // all within the same instant: one draw
+	}
+	*now = now.Add(redrawEvery)
+	b.Add(1)
+	if got := strings.Count(buf.String(), clearLine); got != 2 {
+		t.Errorf("%d redraws; want 2:\n%q", got, buf.String())
+	}
+	b.Finish()
+	if !strings.HasSuffix(buf.String(), clearLine+"copy: done, 11 B in 100ms (110 B/s)\n") {
+		t.Errorf("final line: %q", buf.String())
+	}
+}
+
+func TestSpinner_Plain(t *testing.T) {
+	var buf bytes.Buffer
+	s := spin(display{w: &buf}, "Resolving")
+	s.Stop("ok")
+	s.Stop("again")
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || lines[0] != "Resolving..." || !strings.HasPrefix(lines[1], "Resolving: ok (") {
+		t.Errorf("output = %q", buf.String())
+	}
+}
+
+func TestSpinner_Terminal(t *testing.T) {
+	var buf bytes.Buffer
+	s := spin(display{w: &buf, tty: true}, "Resolving")
+	time.Sleep(3 * redrawEvery)
+	s.Stop("ok")
+	out := buf.String()
+	if !strings.Contains(out, frames[0]+" Resolving") || !strings.Contains(out, frames[1]+" Resolving") {
+		t.Errorf("no animation: %q", out)
+	}
+	if !strings.Contains(out, clearLine+"Resolving: ok") || !strings.HasSuffix(out, "\n") {
+		t.Errorf("final line: %q", out)
+	}
+}
+
+func TestMulti(t *testing.T) {
+	var plain bytes.Buffer
+	m := newMulti(display{w: &plain})
+	a, b := m.Add("a", 10), m.Add("b", 0)
+	a.Add(10)
+	b.Add(5)
+	b.Finish()
+	a.Finish()
+	m.Wait()
+	if got := plain.String(); strings.Count(got, "\n") != 2 || !strings.Contains(got, "a: done, 10 B") || !strings.Contains(got, "b: done, 5 B") {
+		t.Errorf("plain output = %q; want a line per bar", got)
+	}
+
+	// On a terminal, each redraw after the first moves up over the bars.
+	var tty syncBuffer
+	m = newMulti(display{w: &tty, tty: true, width: 60})
+	m.Add("a", 10).Add(3)
+	m.Add("b", 10).Add(7)
+	time.Sleep(3 * redrawEvery)
+	m.Wait()
+	out := tty.String()
+	if !strings.Contains(out, "\x1b[2A") || !strings.Contains(out, " 30%") || !strings.Contains(out, " 70%") {
+		t.Errorf("terminal output = %q", out)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe to read while Multi writes to it.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (s *syncBuffer) Write(p []byte) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.Write(p)
+}
+
+func (s *syncBuffer) String() string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.buf.String()
+}
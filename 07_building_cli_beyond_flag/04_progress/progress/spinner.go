@@ -0,0 +1,68 @@
+package progress
+
+import (
+	"fmt"
+	"io"
+	"sync"
+	"time"
+)
+
+var frames = []string{"⠋", "⠙", "⠹", "⠸", "⠼", "⠴", "⠦", "⠧", "⠇", "⠏"}
+
+// Spinner shows that work of unknown length is going on. On a terminal it
+// animates with the elapsed time; elsewhere it prints the label once when
+// it starts and once when it stops.
+type Spinner struct {
+	label string
+	d     display
+	start time.Time
+	stop  chan struct{}
+	wg    sync.WaitGroup
+	once  sync.Once
+}
+
+// Spin starts a spinner on w. Call Stop when the work is done.
+func Spin(w io.Writer, label string) *Spinner {
+	return spin(detect(w), label)
+}
+
+func spin(d display, label string) *Spinner {
+	s := &Spinner{label: label, d: d, start: time.Now(), stop: make(chan struct{})}
+	if !d.tty {
+		fmt.Fprintf(d.w, "%s...\n", label)
+		return s
+	}
+	s.wg.Add(1)
+	go s.run()
+	return s
+}
+
+func (s *Spinner) run() {
+	defer s.wg.Done()
+	t := time.NewTicker(redrawEvery)
+	defer t.Stop()
+	for i := 0; ; i++ {
+		fmt.Fprintf(s.d.w, "%s%s %s (%s)", clearLine, frames[i%len(frames)], s.label,
+			time.Since(s.start).Truncate(time.Second))
+		select {
+		case <-t.C:
+		case <-s.stop:
+			return
+		}
+	}
+}
+
+// Stop ends the spinner, replacing it with "label: result (time)". It
+// may be called more than once; only the first call prints.
+func (s *Spinner) Stop(result string) {
+	s.once.Do(func() {
+		close(s.stop)
+		s.wg.Wait() // the last frame is drawn before the final line
+		prefix := ""
+		if s.d.tty {
+			prefix = clearLine
+		}
+		fmt.Fprintf(s.d.w, "%s%s: %s (%s)\n", prefix, s.label, result,
+			time.Since(s.start).Round(10*time.Millisecond))
+	})
+}
@@ -0,0 +1,44 @@
+// Package progress draws progress bars and spinners on a terminal, and
+// falls back to plain log lines when the output is not one.
+//
+// A Bar is an io.Writer that counts what is written to it, so it plugs
+// into a copy with io.MultiWriter or io.TeeReader:
+//
+//	bar := progress.NewBar(os.Stderr, "copy", size)
+//	_, err := io.Copy(io.MultiWriter(dst, bar), src)
+//	bar.Finish()
+package progress
+
+import (
+	"io"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// display is where and how output goes.
+type display struct {
+	w     io.Writer
+	tty   bool // redraw in place with \r and ANSI codes
+	width int
+}
+
+// detect checks whether w is a terminal that understands ANSI codes.
+// Redirected to a file or a pipe, or with TERM=dumb, output becomes one
+// plain line per step, which reads well in a log.
+func detect(w io.Writer) display {
+	d := display{w: w, width: 80}
+	f, ok := w.(*os.File)
+	if !ok || !term.IsTerminal(f.Fd()) || os.Getenv("TERM") == "dumb" {
+		return d
+	}
+	d.tty = true
+	if width, _, err := term.GetSize(f.Fd()); err == nil && width > 0 {
+		d.width = width
+	}
+	return d
+}
+
+// clearLine is carriage return plus "erase to end of line", so a shorter
+// line does not leave the end of a longer one behind.
+const clearLine = "\r\x1b[K"
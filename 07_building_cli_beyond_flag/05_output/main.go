@@ -0,0 +1,63 @@
+// Demonstrates output for people and for programs from one command.
+//
+// This example shows:
+// - An -output flag choosing between a table, JSON and plain lines
+// - Aligned columns with text/tabwriter
+// - Cutting long values to fit the terminal's width
+// - Color only on a terminal, and never with NO_COLOR set
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	"golang_roadmap/07_building_cli_beyond_flag/05_output/output"
+)
+
+type Service struct {
+	Name        string        `json:"name"`
+	Status      string        `json:"status"`
+	Uptime      time.Duration `json:"uptime_ns"`
+	Description string        `json:"description"`
+}
+
+var services = []Service{
+	{"api", "ok", 73*time.Hour + 12*time.Minute, "Public REST API behind the load balancer, serving the web and mobile clients"},
+	{"worker", "degraded", 5*time.Hour + 3*time.Minute, "Background jobs: emails, exports and nightly reports; the export queue is backing up"},
+	{"search", "down", 0, "Full-text search over products and orders, rebuilt from the database every hour"},
+	{"auth", "ok", 401 * time.Hour, "Sessions, passwords and OAuth logins"},
+}
+
+var spec = output.Spec[Service]{
+	Columns: []output.Column[Service]{
+		{Header: "NAME", Value: func(s Service) string { return s.Name }},
+		{Header: "STATUS", Value: func(s Service) string { return s.Status }},
+		{Header: "UPTIME", Value: func(s Service) string { return s.Uptime.Truncate(time.Minute).String() }},
+		{Header: "DESCRIPTION", Value: func(s Service) string { return s.Description }, Truncate: true},
+	},
+	RowStyle: func(s Service) output.Style {
+		switch s.Status {
+		case "down":
+			return output.Red
+		case "degraded":
+			return output.Yellow
+		}
+		return output.None
+	},
+}
+
+func main() {
+	format := output.Table
+	color := output.ColorMode("auto")
+	flag.Var(&format, "output", "output format: "+output.Choices)
+	flag.Var(&color, "color", "color: auto, always or never")
+	flag.Parse()
+
+	p := output.NewPrinter(os.Stdout, format, color)
+	if err := output.Print(p, services, spec); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
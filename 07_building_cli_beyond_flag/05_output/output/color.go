@@ -0,0 +1,76 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// Style is an ANSI text style.
+type Style string
+
+const (
+	None   Style = ""
+	Bold   Style = "1"
+	Dim    Style = "2"
+	Red    Style = "31"
+	Green  Style = "32"
+	Yellow Style = "33"
+)
+
+// Palette applies styles, or does nothing if color is off.
+type Palette struct {
+	Enabled bool
+}
+
+// Paint wraps s in the escape codes for style.
+func (p Palette) Paint(style Style, s string) string {
+	if !p.Enabled || style == None || s == "" {
+		return s
+	}
+	return "\x1b[" + string(style) + "m" + s + "\x1b[0m"
+}
+
+// ColorMode is the value of a -color flag: "auto", "always" or "never".
+// *ColorMode implements flag.Value.
+type ColorMode string
+
+func (m *ColorMode) String() string {
+	if m == nil {
+		return ""
+	}
+	return string(*m)
+}
+
+func (m *ColorMode) Set(s string) error {
+	switch s {
+	case "auto", "always", "never":
+		*m = ColorMode(s)
+		return nil
+	}
+	return fmt.Errorf("want auto, always or never")
+}
+
+// NewPalette decides whether to color output to w. "always" and "never"
+// are what they say. "auto", or an empty mode, colors only a terminal,
+// and not when NO_COLOR is set to anything (https://no-color.org) or
+// TERM is "dumb".
+func NewPalette(w io.Writer, mode ColorMode) Palette {
+	switch mode {
+	case "always":
+		return Palette{Enabled: true}
+	case "never":
+		return Palette{}
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return Palette{}
+	}
+	return Palette{Enabled: isTerminal(w)}
+}
+
+func isTerminal(w io.Writer) bool {
+	f, ok := w.(*os.File)
+	return ok && term.IsTerminal(f.Fd())
+}
@@ -0,0 +1,59 @@
+// Package output prints a command's results as a table for people, as
+// JSON for programs, or as plain tab-separated lines for shell pipelines,
+// chosen with an -output flag:
+//
+//	format := output.Table
+//	flag.Var(&format, "output", "output format: "+output.Choices)
+//	...
+//	output.Print(os.Stdout, format, users, []output.Column[User]{
+//		{Header: "ID", Value: func(u User) string { return u.ID }},
+//		{Header: "EMAIL", Value: func(u User) string { return u.Email }, Truncate: true},
+//	})
+//
+// Tables are colored only on a terminal and only if NO_COLOR is unset,
+// and cut to fit the terminal's width.
+package output
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Format is an output format. *Format implements flag.Value.
+type Format string
+
+const (
+	Table Format = "table" // aligned columns with a header, for people
+	JSON  Format = "json"  // the records themselves, for programs
+	Plain Format = "plain" // tab-separated, no header, no color, for cut and awk
+)
+
+var formats = []Format{Table, JSON, Plain}
+
+// Choices lists the formats for a usage string.
+const Choices = "table|json|plain"
+
+// ParseFormat checks s, for use with flag packages other than flag.
+func ParseFormat(s string) (Format, error) {
+	if f := Format(strings.ToLower(s)); slices.Contains(formats, f) {
+		return f, nil
+	}
+	return "", fmt.Errorf("unknown output format %q; want %s", s, strings.ReplaceAll(Choices, "|", ", "))
+}
+
+func (f *Format) String() string {
+	if f == nil {
+		return ""
+	}
+	return string(*f)
+}
+
+func (f *Format) Set(s string) error {
+	parsed, err := ParseFormat(s)
+	if err != nil {
+		return err
+	}
+	*f = parsed
+	return nil
+}
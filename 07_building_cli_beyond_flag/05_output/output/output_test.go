@@ -0,0 +1,134 @@
+package output
+
+import (
+	"bytes"
+	"flag"
+	"io"
+	"strings"
+	"testing"
+)
+
+type row struct {
+	ID   string `json:"id"`
+	Note string `json:"note"`
+	Bad  bool   `json:"bad"`
+}
+
+var rows = []row{
+	{"a1", "short", false},
+	{"b22", "a much longer note that will not fit", true},
+	{"c3", "tab\there, newline\nthere", false},
+}
+
+var rowSpec = Spec[row]{
+	Columns: []Column[row]{
+		{Header: "ID", Value: func(r row) string { return r.ID }},
+		{Header: "NOTE", Value: func(r row) string { return r.Note }, Truncate: true},
+	},
+	RowStyle: func(r row) Style {
+		if r.Bad {
+			return Red
+		}
+		return None
+	},
+}
+
+func printRows(t *testing.T, p Printer) string {
+	t.Helper()
+	var buf bytes.Buffer
+	p.W = &buf
+	if err := Print(p, rows, rowSpec); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestPrint_Table(t *testing.T) {
+	want := "" +
+		"ID   NOTE\n" +
+		"a1   short\n" +
+		"b22  a much longer note that will not fit\n" +
+		"c3   tab here, newline there\n"
+	if got := printRows(t, Printer{Format: Table}); got != want {
+		t.Errorf("table =\n%s\nwant\n%s", got, want)
+	}
+
+	// 20 columns: ID keeps its 3, NOTE gets 20-3-2 = 15.
+	want = "" +
+		"ID   NOTE\n" +
+		"a1   short\n" +
+		"b22  a much longer …\n" +
+		"c3   tab here, newl…\n"
+	if got := printRows(t, Printer{Format: Table, Width: 20}); got != want {
+		t.Errorf("20 columns =\n%s\nwant\n%s", got, want)
+	}
+
+	// Too narrow even at the minimum: lines overflow rather than lose IDs.
+	if got := printRows(t, Printer{Format: Table, Width: 4}); !strings.Contains(got, "b22  a mu…\n") {
+		t.Errorf("4 columns =\n%s", got)
+	}
+}
+
+func TestPrint_Colors(t *testing.T) {
+	got := printRows(t, Printer{Format: Table, Palette: Palette{Enabled: true}})
+	lines := strings.Split(got, "\n")
+	if lines[0] != "\x1b[1mID   NOTE\x1b[0m" {
+		t.Errorf("header = %q; want bold", lines[0])
+	}
+	if lines[1] != "a1   short" || !strings.HasPrefix(lines[2], "\x1b[31mb22  ") {
+		t.Errorf("rows = %q; want only the bad one red", lines[1:3])
+	}
+	// Plain and JSON never have color.
+	for _, f := range []Format{Plain, JSON} {
+		if got := printRows(t, Printer{Format: f, Palette: Palette{Enabled: true}}); strings.Contains(got, "\x1b") {
+			t.Errorf("%s has escape codes: %q", f, got)
+		}
+	}
+}
+
+func TestPrint_PlainAndJSON(t *testing.T) {
+	want := "a1\tshort\nb22\ta much longer note that will not fit\nc3\ttab here, newline there\n"
+	if got := printRows(t, Printer{Format: Plain, Width: 10}); got != want {
+		t.Errorf("plain = %q; want %q, never cut", got, want)
+	}
+	if got := printRows(t, Printer{Format: JSON}); !strings.Contains(got, `"id": "b22"`) || !strings.Contains(got, `"bad": true`) {
+		t.Errorf("json = %s; want the records with their tags", got)
+	}
+
+	var buf bytes.Buffer
+	Print(Printer{W: &buf, Format: JSON}, []row(nil), rowSpec)
+	if got := strings.TrimSpace(buf.String()); got != "[]" {
+		t.Errorf("json of nil = %s; want []", got)
+	}
+}
+
+func TestFormatFlag(t *testing.T) {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	format := Table
+	fs.Var(&format, "output", "")
+	if err := fs.Parse([]string{"-output", "JSON"}); err != nil || format != JSON {
+		t.Errorf("-output JSON: %q, %v", format, err)
+	}
+	if err := fs.Parse([]string{"-output", "yaml"}); err == nil {
+		t.Error("-output yaml accepted")
+	}
+}
+
+func TestNewPalette(t *testing.T) {
+	var buf bytes.Buffer
+	t.Setenv("NO_COLOR", "")
+	if NewPalette(&buf, "auto").Enabled {
+		t.Error("auto: colored a buffer, which is not a terminal")
+	}
+	if !NewPalette(&buf, "always").Enabled {
+		t.Error("always: not colored")
+	}
+	t.Setenv("NO_COLOR", "1")
+	if !NewPalette(&buf, "always").Enabled || NewPalette(&buf, "auto").Enabled {
+		t.Error("NO_COLOR: want it to turn off auto only")
+	}
+	if (Palette{}).Paint(Red, "x") != "x" {
+		t.Error("disabled palette painted")
+	}
+}
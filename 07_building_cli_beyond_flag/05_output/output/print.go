@@ -0,0 +1,187 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"unicode/utf8"
+
+	"github.com/charmbracelet/x/term"
+)
+
+// Printer is where and how to print.
+type Printer struct {
+	W       io.Writer
+	Format  Format
+	Palette Palette
+	Width   int // tables are cut to this many columns; 0 for no limit
+}
+
+// NewPrinter prints to w in format f. Color follows mode, as in
+// NewPalette. Tables are cut to the terminal's width, or to $COLUMNS if
+// it is set; output that is not to a terminal is never cut, so no data is
+// lost in a pipe.
+func NewPrinter(w io.Writer, f Format, mode ColorMode) Printer {
+	p := Printer{W: w, Format: f, Palette: NewPalette(w, mode)}
+	if n, err := strconv.Atoi(os.Getenv("COLUMNS")); err == nil && n > 0 {
+		p.Width = n
+	} else if f, ok := w.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		if n, _, err := term.GetSize(f.Fd()); err == nil {
+			p.Width = n
+		}
+	}
+	return p
+}
+
+// Column is one column of a table.
+type Column[T any] struct {
+	Header string
+	Value  func(T) string
+	// Truncate allows cutting the column's values, with "…", to fit the
+	// width. Columns that identify a record, such as IDs, should not set
+	// it.
+	Truncate bool
+}
+
+// Spec describes how to show records of type T.
+type Spec[T any] struct {
+	Columns []Column[T]
+	// RowStyle, if set, styles a table row, such as red for a failure.
+	RowStyle func(T) Style
+}
+
+// padding is the space between table columns.
+const padding = 2
+
+// Print writes items in p's format. JSON is the items themselves,
+// marshaled with their struct tags; the other formats use spec's columns.
+func Print[T any](p Printer, items []T, spec Spec[T]) error {
+	switch p.Format {
+	case JSON:
+		enc := json.NewEncoder(p.W)
+		enc.SetIndent("", "  ")
+		if items == nil {
+			items = []T{} // [], not null
+		}
+		return enc.Encode(items)
+	case Plain:
+		var b strings.Builder
+		for _, item := range items {
+			for i, col := range spec.Columns {
+				if i > 0 {
+					b.WriteByte('\t')
+				}
+				b.WriteString(clean(col.Value(item)))
+			}
+			b.WriteByte('\n')
+		}
+		_, err := io.WriteString(p.W, b.String())
+		return err
+	default:
+		return printTable(p, items, spec)
+	}
+}
+
+func printTable[T any](p Printer, items []T, spec Spec[T]) error {
+	rows := make([][]string, 0, len(items)+1)
+	header := make([]string, len(spec.Columns))
+	for i, col := range spec.Columns {
+		header[i] = col.Header
+	}
+	rows = append(rows, header)
+	for _, item := range items {
+		row := make([]string, len(spec.Columns))
+		for i, col := range spec.Columns {
+			row[i] = clean(col.Value(item))
+		}
+		rows = append(rows, row)
+	}
+	if p.Width > 0 {
+		fit(rows, spec.Columns, p.Width)
+	}
+
+	// tabwriter aligns plain text; colors are added to whole lines after,
+	// because tabwriter would count their escape codes as width.
+	var buf bytes.Buffer
+	tw := tabwriter.NewWriter(&buf, 0, 8, padding, ' ', 0)
+	for _, row := range rows {
+		io.WriteString(tw, strings.Join(row, "\t")+"\n")
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	var out strings.Builder
+	for i, line := range strings.SplitAfter(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		line = strings.TrimRight(line, " \n")
+		style := Bold
+		if i > 0 {
+			style = None
+			if spec.RowStyle != nil {
+				style = spec.RowStyle(items[i-1])
+			}
+		}
+		out.WriteString(p.Palette.Paint(style, line) + "\n")
+	}
+	_, err := io.WriteString(p.W, out.String())
+	return err
+}
+
+// fit shortens the values of Truncate columns, widest first, until rows
+// fit in width. A column keeps at least its header's width, and at least
+// 5 characters. If that is not enough, the lines stay too long: better
+// than hiding data.
+func fit[T any](rows [][]string, cols []Column[T], width int) {
+	widths := make([]int, len(cols))
+	for _, row := range rows {
+		for i, cell := range row {
+			widths[i] = max(widths[i], utf8.RuneCountInString(cell))
+		}
+	}
+	total := padding * (len(cols) - 1)
+	for _, w := range widths {
+		total += w
+	}
+	for total > width {
+		widest := -1
+		for i, col := range cols {
+			if col.Truncate && widths[i] > max(len(col.Header), 5) && (widest < 0 || widths[i] > widths[widest]) {
+				widest = i
+			}
+		}
+		if widest < 0 {
+			break
+		}
+		cut := min(total-width, widths[widest]-max(len(cols[widest].Header), 5))
+		widths[widest] -= cut
+		total -= cut
+	}
+	for _, row := range rows[1:] {
+		for i := range row {
+			row[i] = truncate(row[i], widths[i])
+		}
+	}
+}
+
+// truncate cuts s to width characters, the last one an ellipsis.
+func truncate(s string, width int) string {
+	if utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	r := []rune(s)
+	return string(r[:width-1]) + "…"
+}
+
+// clean keeps a value on one line and in one column.
+func clean(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, s)
+}
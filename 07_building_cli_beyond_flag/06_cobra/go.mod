@@ -0,0 +1,32 @@
+module golang_roadmap/07_building_cli_beyond_flag/06_cobra
+
+go 1.24.11
+
+require (
+	github.com/spf13/cobra v1.9.1
+	golang_roadmap/07_building_cli_beyond_flag/02_urfave_cli v0.0.0
+	golang_roadmap/07_building_cli_beyond_flag/05_output v0.0.0
+)
+
+require (
+	github.com/charmbracelet/x/term v0.2.2 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/inconshreveable/mousetrap v1.1.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	github.com/spf13/pflag v1.0.6 // indirect
+	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
+	golang.org/x/sys v0.36.0 // indirect
+	modernc.org/libc v1.66.3 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.11.0 // indirect
+	modernc.org/sqlite v1.38.2 // indirect
+)
+
+// The userstore and output packages live in their own modules in this repository.
+replace (
+	golang_roadmap/07_building_cli_beyond_flag/02_urfave_cli => ../02_urfave_cli
+	golang_roadmap/07_building_cli_beyond_flag/05_output => ../05_output
+)
@@ -0,0 +1,171 @@
+// Demonstrates spf13/cobra with shell completion.
+//
+// This example shows:
+// - A command tree with a persistent --db flag
+// - The built-in "completion" command for bash, zsh, fish and PowerShell
+// - Completing arguments from a SQLite store with ValidArgsFunction
+// - Completing flag values with RegisterFlagCompletionFunc
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+
+	"golang_roadmap/07_building_cli_beyond_flag/02_urfave_cli/userstore"
+	"golang_roadmap/07_building_cli_beyond_flag/05_output/output"
+)
+
+func main() {
+	if err := newRootCmd().ExecuteContext(context.Background()); err != nil {
+		os.Exit(1)
+	}
+}
+
+func newRootCmd() *cobra.Command {
+	root := &cobra.Command{
+		Use:   "users",
+		Short: "Manage users in a SQLite database",
+		// A failed lookup is not a usage mistake: print only the error.
+		SilenceUsage: true,
+	}
+	root.PersistentFlags().String("db", envOr("USERS_DB", "users.db"), "SQLite database file (env USERS_DB)")
+
+	add := &cobra.Command{
+		Use:   "add NAME EMAIL",
+		Short: "Add a user",
+		Args:  cobra.ExactArgs(2),
+		// No completion for free text; without this the shell offers files.
+		ValidArgsFunction: cobra.NoFileCompletions,
+		RunE: withStore(func(cmd *cobra.Command, s *userstore.Store, args []string) error {
+			u, err := s.Add(cmd.Context(), args[0], args[1])
+			if err != nil {
+				return err
+			}
+			cmd.Println(u.ID)
+			return nil
+		}),
+	}
+
+	format := output.Table
+	list := &cobra.Command{
+		Use:               "list [PREFIX]",
+		Short:             "List users, optionally only IDs starting with PREFIX",
+		Args:              cobra.MaximumNArgs(1),
+		ValidArgsFunction: completeUserIDs,
+		RunE: withStore(func(cmd *cobra.Command, s *userstore.Store, args []string) error {
+			prefix := ""
+			if len(args) == 1 {
+				prefix = args[0]
+			}
+			users, err := s.List(cmd.Context(), prefix)
+			if err != nil {
+				return err
+			}
+			return output.Print(output.NewPrinter(cmd.OutOrStdout(), format, "auto"), users, userSpec)
+		}),
+	}
+	list.Flags().VarP(formatFlag{&format}, "output", "o", "output format: "+output.Choices)
+	list.RegisterFlagCompletionFunc("output", cobra.FixedCompletions(
+		[]cobra.Completion{
+			cobra.CompletionWithDesc(string(output.Table), "aligned columns for people"),
+			cobra.CompletionWithDesc(string(output.JSON), "records for programs"),
+			cobra.CompletionWithDesc(string(output.Plain), "tab-separated, for pipelines"),
+		},
+		cobra.ShellCompDirectiveNoFileComp))
+
+	show := &cobra.Command{
+		Use:               "show ID",
+		Short:             "Show a user",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeUserIDs,
+		RunE: withStore(func(cmd *cobra.Command, s *userstore.Store, args []string) error {
+			u, err := s.Get(cmd.Context(), args[0])
+			if err != nil {
+				return err
+			}
+			fmt.Fprintf(cmd.OutOrStdout(), "%s\t%s\t%s\n", u.ID, u.Name, u.Email)
+			return nil
+		}),
+	}
+
+	del := &cobra.Command{
+		Use:               "delete ID",
+		Short:             "Delete a user",
+		Args:              cobra.ExactArgs(1),
+		ValidArgsFunction: completeUserIDs,
+		RunE: withStore(func(cmd *cobra.Command, s *userstore.Store, args []string) error {
+			return s.Delete(cmd.Context(), args[0])
+		}),
+	}
+
+	root.AddCommand(add, list, show, del)
+	return root
+}
+
+var userSpec = output.Spec[userstore.User]{
+	Columns: []output.Column[userstore.User]{
+		{Header: "ID", Value: func(u userstore.User) string { return u.ID }},
+		{Header: "NAME", Value: func(u userstore.User) string { return u.Name }, Truncate: true},
+		{Header: "EMAIL", Value: func(u userstore.User) string { return u.Email }, Truncate: true},
+	},
+}
+
+func withStore(fn func(*cobra.Command, *userstore.Store, []string) error) func(*cobra.Command, []string) error {
+	return func(cmd *cobra.Command, args []string) error {
+		path, _ := cmd.Flags().GetString("db")
+		s, err := userstore.Open(path)
+		if err != nil {
+			return err
+		}
+		defer s.Close()
+		return fn(cmd, s, args)
+	}
+}
+
+// completeUserIDs completes the first argument with the IDs starting with
+// toComplete, described by the user's name. Shells that show descriptions
+// (zsh, fish, PowerShell) print them; bash shows only the IDs. Errors
+// complete nothing rather than offering file names.
+func completeUserIDs(cmd *cobra.Command, args []string, toComplete string) ([]cobra.Completion, cobra.ShellCompDirective) {
+	if len(args) > 0 {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	path, _ := cmd.Flags().GetString("db")
+	// Do not let a completion create an empty database.
+	if _, err := os.Stat(path); errors.Is(err, os.ErrNotExist) {
+		return nil, cobra.ShellCompDirectiveNoFileComp
+	}
+	s, err := userstore.Open(path)
+	if err != nil {
+		cobra.CompErrorln(err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	defer s.Close()
+	users, err := s.List(cmd.Context(), toComplete)
+	if err != nil {
+		cobra.CompErrorln(err.Error())
+		return nil, cobra.ShellCompDirectiveError
+	}
+	comps := make([]cobra.Completion, len(users))
+	for i, u := range users {
+		comps[i] = cobra.CompletionWithDesc(u.ID, u.Name)
+	}
+	return comps, cobra.ShellCompDirectiveNoFileComp
+}
+
+// formatFlag adapts output.Format, a flag.Value, to pflag, which also
+// wants a type name for the help text.
+type formatFlag struct{ *output.Format }
+
+func (formatFlag) Type() string { return "format" }
+
+func envOr(key, fallback string) string {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		return v
+	}
+	return fallback
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang_roadmap/07_building_cli_beyond_flag/02_urfave_cli/userstore"
+)
+
+func run(t *testing.T, args ...string) string {
+	t.Helper()
+	root := newRootCmd()
+	var out bytes.Buffer
+	root.SetOut(&out)
+	root.SetErr(&out)
+	root.SetArgs(args)
+	if err := root.ExecuteContext(context.Background()); err != nil {
+		t.Fatalf("%v: %v\n%s", args, err, out.String())
+	}
+	return out.String()
+}
+
+func TestCompleteUserIDs(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "users.db")
+	s, err := userstore.Open(db)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, name := range []string{"Ada", "Grace"} {
+		if _, err := s.Add(context.Background(), name, name+"@example.com"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.Close()
+
+	for _, tc := range []struct {
+		args []string
+		want string
+	}{
+		{[]string{"show", ""}, "u1001\tAda\nu1002\tGrace\n:4\n"},
+		{[]string{"delete", "u1002"}, "u1002\tGrace\n:4\n"},
+		{[]string{"show", "u9"}, ":4\n"},
+		{[]string{"show", "u1001", ""}, ":4\n"},
+		{[]string{"list", "-o", ""}, "table\taligned columns for people\njson\trecords for programs\nplain\ttab-separated, for pipelines\n:4\n"},
+	} {
+		args := append([]string{"__complete", "--db", db}, tc.args...)
+		got := run(t, args...)
+		// Drop cobra's debug line, written to stderr.
+		got = got[:strings.LastIndex(got, "Completion ended")]
+		if got != tc.want {
+			t.Errorf("%q completes %q; want %q", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestCompletionScripts(t *testing.T) {
+	for _, shell := range []string{"bash", "zsh", "fish", "powershell"} {
+		if got := run(t, "completion", shell); !strings.Contains(got, "__complete") {
+			t.Errorf("%s script does not call back into the program:\n%.200s", shell, got)
+		}
+	}
+}
+
+func TestUsers(t *testing.T) {
+	db := filepath.Join(t.TempDir(), "users.db")
+	if got := run(t, "--db", db, "add", "Ada", "ada@example.com"); got != "u1001\n" {
+		t.Errorf("add printed %q", got)
+	}
+	if got := run(t, "--db", db, "list", "-o", "plain"); got != "u1001\tAda\tada@example.com\n" {
+		t.Errorf("list printed %q", got)
+	}
+}
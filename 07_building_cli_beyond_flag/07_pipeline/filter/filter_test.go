@@ -0,0 +1,206 @@
+package filter
+
+import (
+	"bytes"
+	"errors"
+	"regexp"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestLines(t *testing.T) {
+	in := "alpha\r\nbeta\ngamma\nALPHABET" // CRLF, and no newline at the end
+	for _, tc := range []struct {
+		name string
+		opts LineOptions
+		want string
+		n    int
+	}{
+		{"match", LineOptions{Pattern: regexp.MustCompile("a$")}, "alpha\nbeta\ngamma\n", 3},
+		{"invert", LineOptions{Pattern: regexp.MustCompile("^[a-z]"), Invert: true}, "ALPHABET\n", 1},
+		{"number", LineOptions{Pattern: regexp.MustCompile("(?i)alpha"), Number: true}, "1:alpha\n4:ALPHABET\n", 2},
+		{"highlight", LineOptions{
+			Pattern:   regexp.MustCompile("a"),
+			Highlight: func(s string) string { return "[" + s + "]" },
+		}, "[a]lph[a]\nbet[a]\ng[a]mm[a]\n", 3},
+		{"none", LineOptions{Pattern: regexp.MustCompile("zeta")}, "", 0},
+	} {
+		var out bytes.Buffer
+		w := NewWriter(&out, false)
+		n, err := Lines(strings.NewReader(in), w, tc.opts)
+		if err == nil {
+			err = w.Flush()
+		}
+		if err != nil || n != tc.n || out.String() != tc.want {
+			t.Errorf("%s: wrote %q (%d lines), %v; want %q", tc.name, out.String(), n, err, tc.want)
+		}
+	}
+}
+
+func TestLines_LongLine(t *testing.T) {
+	long := strings.Repeat("x", 1<<20) + "needle"
+	var out bytes.Buffer
+	w := NewWriter(&out, false)
+	n, err := Lines(strings.NewReader("short\n"+long+"\n"), w, LineOptions{Pattern: regexp.MustCompile("needle")})
+	w.Flush()
+	if err != nil || n != 1 || out.Len() != len(long)+1 {
+		t.Errorf("matched %d lines, wrote %d bytes, %v; want the 1 MiB line", n, out.Len(), err)
+	}
+}
+
+// endless is input that never ends, such as "yes" or "tail -f".
+type endless struct{ reads int }
+
+func (e *endless) Read(p []byte) (int, error) {
+	e.reads++
+	for i := range p {
+		p[i] = "line\n"[i%5]
+	}
+	return len(p) - len(p)%5, nil
+}
+
+// closedPipe fails like a pipe whose reader has exited after taking
+// limit bytes.
+type closedPipe struct{ limit int }
+
+func (c *closedPipe) Write(p []byte) (int, error) {
+	if len(p) > c.limit {
+		n := c.limit
+		c.limit = 0
+		return n, syscall.EPIPE
+	}
+	c.limit -= len(p)
+	return len(p), nil
+}
+
+func TestBrokenPipeStopsReading(t *testing.T) {
+	for _, interactive := range []bool{false, true} {
+		in := new(endless)
+		_, err := Lines(in, NewWriter(&closedPipe{limit: 100}, interactive), LineOptions{Pattern: regexp.MustCompile("")})
+		var werr *WriteError
+		if !IsBrokenPipe(err) || !errors.As(err, &werr) {
+			t.Errorf("interactive=%v: err = %v; want a broken pipe WriteError", interactive, err)
+		}
+		if in.reads > 10 {
+			t.Errorf("interactive=%v: read the input %d times after the pipe closed", interactive, in.reads)
+		}
+	}
+}
+
+func TestWriterStickyError(t *testing.T) {
+	w := NewWriter(&closedPipe{}, true)
+	if err := w.Line("a"); !IsBrokenPipe(err) {
+		t.Fatalf("first write: %v", err)
+	}
+	if err := w.Line("b"); !IsBrokenPipe(err) {
+		t.Errorf("write after failure: %v", err)
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	v := map[string]any{"items": []any{map[string]any{"id": "a"}, map[string]any{"id": "b"}, map[string]any{}}}
+	for path, want := range map[string]string{
+		".":            `[{"items":[{"id":"a"},{"id":"b"},{}]}]`,
+		".items[0].id": `["a"]`,
+		".items[-1]":   `[{}]`,
+		".items[].id":  `["a","b"]`,
+		".items[9]":    `null`,
+		".missing.x":   `null`,
+	} {
+		p, err := ParsePath(path)
+		if err != nil {
+			t.Errorf("%s: %v", path, err)
+			continue
+		}
+		if got := mustJSON(t, p.Eval(v)); got != want {
+			t.Errorf("%s selects %s; want %s", path, got, want)
+		}
+	}
+	for _, bad := range []string{"items", ".items[", ".items[x]", ".9", ".a..b"} {
+		if _, err := ParsePath(bad); err == nil {
+			t.Errorf("ParsePath(%q) did not fail", bad)
+		}
+	}
+}
+
+func TestCond(t *testing.T) {
+	var v any
+	if err := parseValue(`{"n":1.0,"s":"ok","tags":["x","y"],"big":12345678901234567890}`, &v); err != nil {
+		t.Fatal(err)
+	}
+	for cond, want := range map[string]bool{
+		".n==1":                      true,
+		".n!=1":                      false,
+		".s==ok":                     true,
+		`.s=="ok"`:                   true,
+		".s==true":                   false,
+		".tags[]==y":                 true,
+		".tags[]!=z":                 true,
+		".tags[]~^[xy]$":             true,
+		".n~1":                       false, // regexps match strings only
+		".missing==null":             false,
+		".missing!=1":                true,
+		".big==12345678901234567890": true,
+	} {
+		c, err := ParseCond(cond)
+		if err != nil {
+			t.Errorf("%s: %v", cond, err)
+			continue
+		}
+		if got := c.Match(v); got != want {
+			t.Errorf("%s = %v; want %v", cond, got, want)
+		}
+	}
+	for _, bad := range []string{".a", ".a~(", "a==1"} {
+		if _, err := ParseCond(bad); err == nil {
+			t.Errorf("ParseCond(%q) did not fail", bad)
+		}
+	}
+}
+
+func TestJSON(t *testing.T) {
+	in := `{"id": 1, "user": {"name": "ada"}, "html": "<b>"}
+{"id": 2, "user": {"name": "bob"}}
+[1, 2] "text" 98765432109876543210`
+	where, _ := ParseCond(".user.name~^a")
+	for _, tc := range []struct {
+		name string
+		opts JSONOptions
+		want string
+	}{
+		{"all", JSONOptions{}, `{"html":"<b>","id":1,"user":{"name":"ada"}}` + "\n" +
+			`{"id":2,"user":{"name":"bob"}}` + "\n[1,2]\n\"text\"\n98765432109876543210\n"},
+		{"select", JSONOptions{Select: Path{{kind: member, key: "id"}}}, "1\n2\n"},
+		{"where", JSONOptions{Where: []Cond{where}, Select: Path{{kind: member, key: "html"}}, Raw: true}, "<b>\n"},
+		{"indent", JSONOptions{Where: []Cond{where}, Select: Path{{kind: member, key: "user"}}, Indent: true},
+			"{\n  \"name\": \"ada\"\n}\n"},
+	} {
+		var out bytes.Buffer
+		w := NewWriter(&out, false)
+		_, err := JSON(strings.NewReader(in), w, tc.opts)
+		w.Flush()
+		if err != nil || out.String() != tc.want {
+			t.Errorf("%s: wrote %q, %v; want %q", tc.name, out.String(), err, tc.want)
+		}
+	}
+}
+
+func TestJSON_SyntaxError(t *testing.T) {
+	var out bytes.Buffer
+	w := NewWriter(&out, false)
+	n, err := JSON(strings.NewReader(`{"ok":1} {"ok":}`), w, JSONOptions{})
+	w.Flush()
+	if n != 1 || out.String() != "{\"ok\":1}\n" || err == nil || !strings.Contains(err.Error(), "at byte 16") {
+		t.Errorf("wrote %q, %v; want the first value, then an error with the offset", out.String(), err)
+	}
+}
+
+func mustJSON(t *testing.T, v []any) string {
+	t.Helper()
+	s, err := format(v, JSONOptions{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
@@ -0,0 +1,275 @@
+package filter
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Path picks values out of a JSON value, with a small part of jq's
+// syntax:
+//
+//	.               the value itself
+//	.user.name      an object member
+//	.items[0]       an array element; negative indexes count from the end
+//	.items[].id     every element
+//
+// Missing members and out-of-range indexes select nothing rather than
+// failing, so one odd record does not stop a stream.
+type Path []step
+
+type step struct {
+	key   string
+	index int
+	kind  stepKind
+}
+
+type stepKind int
+
+const (
+	member stepKind = iota
+	element
+	each
+)
+
+var keyRe = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_-]*`)
+
+func ParsePath(s string) (Path, error) {
+	if s == "" || s == "." {
+		return nil, nil
+	}
+	var p Path
+	rest := s
+	for rest != "" {
+		switch {
+		case strings.HasPrefix(rest, "[]"):
+			p = append(p, step{kind: each})
+			rest = rest[2:]
+		case rest[0] == '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("path %q: missing ]", s)
+			}
+			i, err := strconv.Atoi(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("path %q: index %q is not a number", s, rest[1:end])
+			}
+			p = append(p, step{kind: element, index: i})
+			rest = rest[end+1:]
+		case rest[0] == '.':
+			key := keyRe.FindString(rest[1:])
+			if key == "" {
+				return nil, fmt.Errorf("path %q: want a member name after the dot at %q", s, rest)
+			}
+			p = append(p, step{kind: member, key: key})
+			rest = rest[1+len(key):]
+		default:
+			return nil, fmt.Errorf("path %q: unexpected %q", s, rest)
+		}
+	}
+	return p, nil
+}
+
+// Eval returns the values p selects from v.
+func (p Path) Eval(v any) []any {
+	vals := []any{v}
+	for _, st := range p {
+		var next []any
+		for _, v := range vals {
+			switch st.kind {
+			case member:
+				if obj, ok := v.(map[string]any); ok {
+					if m, ok := obj[st.key]; ok {
+						next = append(next, m)
+					}
+				}
+			case element:
+				if arr, ok := v.([]any); ok {
+					i := st.index
+					if i < 0 {
+						i += len(arr)
+					}
+					if i >= 0 && i < len(arr) {
+						next = append(next, arr[i])
+					}
+				}
+			case each:
+				switch c := v.(type) {
+				case []any:
+					next = append(next, c...)
+				case map[string]any:
+					for _, k := range sortedKeys(c) {
+						next = append(next, c[k])
+					}
+				}
+			}
+		}
+		vals = next
+	}
+	return vals
+}
+
+// Cond is a test on a JSON value, parsed from "PATH==VALUE",
+// "PATH!=VALUE" or "PATH~REGEXP". VALUE is JSON if it parses as JSON
+// (42, true, null, "quoted") and a string otherwise, so .status==ok and
+// .status=="ok" mean the same. A condition holds if any value the path
+// selects satisfies it; "!=" holds if none equals VALUE.
+type Cond struct {
+	path  Path
+	op    string
+	value any
+	re    *regexp.Regexp
+}
+
+var condRe = regexp.MustCompile(`^([^=!~]*)(==|!=|~)(.*)$`)
+
+func ParseCond(s string) (Cond, error) {
+	m := condRe.FindStringSubmatch(s)
+	if m == nil {
+		return Cond{}, fmt.Errorf("condition %q: want PATH==VALUE, PATH!=VALUE or PATH~REGEXP", s)
+	}
+	path, err := ParsePath(m[1])
+	if err != nil {
+		return Cond{}, err
+	}
+	c := Cond{path: path, op: m[2]}
+	if c.op == "~" {
+		if c.re, err = regexp.Compile(m[3]); err != nil {
+			return Cond{}, fmt.Errorf("condition %q: %w", s, err)
+		}
+		return c, nil
+	}
+	if err := parseValue(m[3], &c.value); err != nil {
+		c.value = m[3]
+	}
+	return c, nil
+}
+
+func (c Cond) Match(v any) bool {
+	for _, got := range c.path.Eval(v) {
+		if c.re != nil {
+			if s, ok := got.(string); ok && c.re.MatchString(s) {
+				return true
+			}
+		} else if equal(got, c.value) {
+			return c.op == "=="
+		}
+	}
+	return c.op == "!="
+}
+
+// equal compares decoded JSON values; numbers compare by value, so 1 and
+// 1.0 are equal.
+func equal(a, b any) bool {
+	if x, ok := a.(json.Number); ok {
+		y, ok := b.(json.Number)
+		if !ok {
+			return false
+		}
+		fx, err1 := x.Float64()
+		fy, err2 := y.Float64()
+		return err1 == nil && err2 == nil && fx == fy
+	}
+	// Everything else marshals the same way whenever it is equal:
+	// encoding/json sorts object keys.
+	ja, err1 := json.Marshal(a)
+	jb, err2 := json.Marshal(b)
+	return err1 == nil && err2 == nil && bytes.Equal(ja, jb)
+}
+
+// JSONOptions select and print values from a stream of JSON values, like
+// jq.
+type JSONOptions struct {
+	Select Path
+	Where  []Cond // all must hold for a value to be selected from
+	Indent bool   // indent output with two spaces instead of one value per line
+	Raw    bool   // write strings without quotes, like jq -r
+}
+
+// JSON reads JSON values from r one after another, separated by any
+// whitespace (a JSON Lines file is such a stream), and writes the values
+// opts selects from each to w. It returns how many it wrote. Numbers are
+// copied exactly, not through float64, so large IDs survive.
+func JSON(r io.Reader, w *Writer, opts JSONOptions) (int, error) {
+	dec := json.NewDecoder(r)
+	dec.UseNumber()
+	written := 0
+	for {
+		var v any
+		if err := dec.Decode(&v); err != nil {
+			if err == io.EOF {
+				return written, nil
+			}
+			var syn *json.SyntaxError
+			if errors.As(err, &syn) {
+				return written, fmt.Errorf("input is not JSON at byte %d: %w", syn.Offset, err)
+			}
+			return written, err
+		}
+		if !matchAll(opts.Where, v) {
+			continue
+		}
+		for _, sel := range opts.Select.Eval(v) {
+			s, err := format(sel, opts)
+			if err != nil {
+				return written, err
+			}
+			if err := w.Line(s); err != nil {
+				return written, &WriteError{err}
+			}
+			written++
+		}
+	}
+}
+
+func matchAll(conds []Cond, v any) bool {
+	for _, c := range conds {
+		if !c.Match(v) {
+			return false
+		}
+	}
+	return true
+}
+
+func format(v any, opts JSONOptions) (string, error) {
+	if s, ok := v.(string); ok && opts.Raw {
+		return s, nil
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if opts.Indent {
+		enc.SetIndent("", "  ")
+	}
+	if err := enc.Encode(v); err != nil {
+		return "", err
+	}
+	return strings.TrimSuffix(buf.String(), "\n"), nil
+}
+
+func parseValue(s string, v *any) error {
+	dec := json.NewDecoder(strings.NewReader(s))
+	dec.UseNumber()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	if dec.More() {
+		return errors.New("trailing data")
+	}
+	return nil
+}
+
+func sortedKeys(m map[string]any) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	return keys
+}
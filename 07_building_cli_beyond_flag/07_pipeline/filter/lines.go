@@ -0,0 +1,50 @@
+package filter
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// LineOptions select lines, like grep.
+type LineOptions struct {
+	Pattern *regexp.Regexp
+	Invert  bool // write lines that do not match
+	Number  bool // prefix each line with its number and a colon
+
+	// Highlight, if set, wraps each match in a line, to color it.
+	Highlight func(string) string
+}
+
+// Lines copies the lines of r that match to w and returns how many
+// matched. Lines may be of any length; "\r\n" endings are accepted and
+// written as "\n".
+func Lines(r io.Reader, w *Writer, opts LineOptions) (int, error) {
+	br := bufio.NewReaderSize(r, 64<<10)
+	matched := 0
+	for n := 1; ; n++ {
+		line, err := br.ReadString('\n')
+		if line == "" && err != nil {
+			if err == io.EOF {
+				err = nil
+			}
+			return matched, err
+		}
+		line = strings.TrimSuffix(strings.TrimSuffix(line, "\n"), "\r")
+		if opts.Pattern.MatchString(line) == opts.Invert {
+			continue
+		}
+		matched++
+		if opts.Highlight != nil && !opts.Invert {
+			line = opts.Pattern.ReplaceAllStringFunc(line, opts.Highlight)
+		}
+		if opts.Number {
+			line = strconv.Itoa(n) + ":" + line
+		}
+		if werr := w.Line(line); werr != nil {
+			return matched, &WriteError{werr}
+		}
+	}
+}
@@ -0,0 +1,64 @@
+// Package filter reads text or JSON from one stream and writes what
+// matches to another, the way grep and jq do in a shell pipeline.
+//
+// Reading and writing are both streaming: a line or JSON value is
+// written as soon as it has been read, so the filter works on endless
+// input such as "tail -f", and memory does not grow with the input.
+package filter
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"syscall"
+)
+
+// Writer buffers output for a pipe or a file, and flushes after every
+// line for a terminal, where a person is waiting to see each match.
+// The first write error sticks: later writes do nothing and return it.
+type Writer struct {
+	bw          *bufio.Writer
+	interactive bool
+	err         error
+}
+
+func NewWriter(w io.Writer, interactive bool) *Writer {
+	return &Writer{bw: bufio.NewWriterSize(w, 64<<10), interactive: interactive}
+}
+
+// Line writes s and a newline.
+func (w *Writer) Line(s string) error {
+	if w.err != nil {
+		return w.err
+	}
+	w.bw.WriteString(s)
+	if err := w.bw.WriteByte('\n'); err != nil {
+		w.err = err
+		return err
+	}
+	if w.interactive {
+		return w.Flush()
+	}
+	return nil
+}
+
+func (w *Writer) Flush() error {
+	if w.err == nil {
+		w.err = w.bw.Flush()
+	}
+	return w.err
+}
+
+// WriteError is a failure to write output, as opposed to one reading or
+// parsing input.
+type WriteError struct{ Err error }
+
+func (e *WriteError) Error() string { return "write: " + e.Err.Error() }
+func (e *WriteError) Unwrap() error { return e.Err }
+
+// IsBrokenPipe reports whether err comes from writing to a pipe whose
+// reader has gone, as in "filter | head -1". That is the reader saying
+// it has seen enough, not a failure: the filter should stop quietly.
+func IsBrokenPipe(err error) bool {
+	return errors.Is(err, syscall.EPIPE)
+}
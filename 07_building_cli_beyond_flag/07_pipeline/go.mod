@@ -0,0 +1,13 @@
+module golang_roadmap/07_building_cli_beyond_flag/07_pipeline
+
+go 1.24.11
+
+require (
+	github.com/charmbracelet/x/term v0.2.2
+	golang_roadmap/07_building_cli_beyond_flag/05_output v0.0.0
+)
+
+require golang.org/x/sys v0.36.0 // indirect
+
+// The output package lives in its own module in this repository.
+replace golang_roadmap/07_building_cli_beyond_flag/05_output => ../05_output
@@ -0,0 +1,144 @@
+// Demonstrates a command built to sit in a shell pipeline, like grep and jq.
+//
+// This example shows:
+// - Reading stdin and writing stdout as streams, line by line or one JSON value at a time
+// - Stopping quietly when the reader goes away, as in "pf ... | head -1"
+// - Different output for a terminal and a pipe: flushing, color and JSON indentation
+// - grep's exit codes: 0 when something was written, 1 when nothing was, 2 on errors
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"regexp"
+	"syscall"
+
+	"github.com/charmbracelet/x/term"
+
+	"golang_roadmap/07_building_cli_beyond_flag/05_output/output"
+	"golang_roadmap/07_building_cli_beyond_flag/07_pipeline/filter"
+)
+
+const usage = `usage: pf [flags] PATTERN          select lines matching a regexp
+       pf -json [flags] [PATH]     select from a stream of JSON values
+
+PATH is .key, .key.sub, .list[0], .list[].key; "." (the default) is the whole value.
+
+`
+
+// conds is a repeatable -where flag.
+type conds []filter.Cond
+
+func (c *conds) String() string { return fmt.Sprint(len(*c), " conditions") }
+
+func (c *conds) Set(s string) error {
+	cond, err := filter.ParseCond(s)
+	if err != nil {
+		return err
+	}
+	*c = append(*c, cond)
+	return nil
+}
+
+// terminal records which streams are terminals rather than pipes or
+// files.
+type terminal struct {
+	stdin, stdout bool
+}
+
+func main() {
+	// By default a write to a closed pipe kills a Go program with SIGPIPE
+	// if it is stdout, and the shell may report it. Ignoring the signal
+	// turns it into an EPIPE error from Write, which run treats as the
+	// end of the output.
+	signal.Ignore(syscall.SIGPIPE)
+	tty := terminal{stdin: term.IsTerminal(os.Stdin.Fd()), stdout: term.IsTerminal(os.Stdout.Fd())}
+	os.Exit(run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr, tty))
+}
+
+func run(args []string, stdin io.Reader, stdout, stderr io.Writer, tty terminal) int {
+	fs := flag.NewFlagSet("pf", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	jsonMode := fs.Bool("json", false, "read a stream of JSON values instead of lines")
+	ignoreCase := fs.Bool("i", false, "lines: ignore case")
+	invert := fs.Bool("v", false, "lines: select lines that do not match")
+	number := fs.Bool("n", false, "lines: prefix lines with their line number")
+	var where conds
+	fs.Var(&where, "where", "JSON: only values where `COND` holds: PATH==VALUE, PATH!=VALUE or PATH~REGEXP (repeatable)")
+	raw := fs.Bool("raw", false, "JSON: write strings without quotes")
+	compact := fs.Bool("compact", false, "JSON: one value per line even on a terminal")
+	color := output.ColorMode("auto")
+	fs.Var(&color, "color", "highlight matches: auto, always or never")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+
+	if tty.stdin {
+		fmt.Fprintln(stderr, "pf: reading from the terminal; end the input with Ctrl-D")
+	}
+	// A person at a terminal wants each match as soon as it is found; a
+	// pipe is faster with large writes.
+	w := filter.NewWriter(stdout, tty.stdout)
+
+	var n int
+	var err error
+	if *jsonMode {
+		if fs.NArg() > 1 {
+			fs.Usage()
+			return 2
+		}
+		path, perr := filter.ParsePath(fs.Arg(0))
+		if perr != nil {
+			fmt.Fprintln(stderr, "pf:", perr)
+			return 2
+		}
+		n, err = filter.JSON(stdin, w, filter.JSONOptions{
+			Select: path, Where: where, Raw: *raw,
+			Indent: tty.stdout && !*compact,
+		})
+	} else {
+		if fs.NArg() != 1 {
+			fs.Usage()
+			return 2
+		}
+		expr := fs.Arg(0)
+		if *ignoreCase {
+			expr = "(?i)" + expr
+		}
+		re, rerr := regexp.Compile(expr)
+		if rerr != nil {
+			fmt.Fprintln(stderr, "pf:", rerr)
+			return 2
+		}
+		opts := filter.LineOptions{Pattern: re, Invert: *invert, Number: *number}
+		if p := output.NewPalette(stdout, color); p.Enabled {
+			opts.Highlight = func(s string) string { return p.Paint(output.Red+";1", s) }
+		}
+		n, err = filter.Lines(stdin, w, opts)
+	}
+	if err == nil {
+		err = w.Flush()
+	}
+
+	switch {
+	case filter.IsBrokenPipe(err):
+		// The reader has what it wants; so do we.
+		return 0
+	case err != nil:
+		fmt.Fprintln(stderr, "pf:", err)
+		return 2
+	case n == 0:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func pf(t *testing.T, stdin string, tty terminal, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	code = run(args, strings.NewReader(stdin), &out, &errOut, tty)
+	return code, out.String(), errOut.String()
+}
+
+func TestRun(t *testing.T) {
+	logs := "INFO start\nERROR disk full\ninfo retry\nERROR timeout\n"
+	events := `{"level":"error","msg":"disk full","code":28}
+{"level":"info","msg":"retry"}
+{"level":"error","msg":"timeout","code":110}
+`
+	for _, tc := range []struct {
+		name   string
+		stdin  string
+		tty    terminal
+		args   []string
+		code   int
+		stdout string
+	}{
+		{"grep", logs, terminal{}, []string{"ERROR"}, 0, "ERROR disk full\nERROR timeout\n"},
+		{"ignore case", logs, terminal{}, []string{"-i", "-n", "^info"}, 0, "1:INFO start\n3:info retry\n"},
+		{"no match", logs, terminal{}, []string{"WARN"}, 1, ""},
+		{"color", logs, terminal{}, []string{"-color", "always", "disk"}, 0, "ERROR \x1b[31;1mdisk\x1b[0m full\n"},
+		{"json raw", events, terminal{}, []string{"-json", "-where", ".level==error", "-raw", ".msg"}, 0, "disk full\ntimeout\n"},
+		{"json compact in a pipe", events, terminal{}, []string{"-json", "-where", ".code==110"}, 0,
+			`{"code":110,"level":"error","msg":"timeout"}` + "\n"},
+		{"json indented on a terminal", events, terminal{stdout: true}, []string{"-json", "-where", ".code==110"}, 0,
+			"{\n  \"code\": 110,\n  \"level\": \"error\",\n  \"msg\": \"timeout\"\n}\n"},
+		{"json -compact on a terminal", events, terminal{stdout: true}, []string{"-json", "-compact", ".code"}, 0, "28\n110\n"},
+		{"bad json", "{", terminal{}, []string{"-json"}, 2, ""},
+		{"bad regexp", logs, terminal{}, []string{"("}, 2, ""},
+		{"bad path", events, terminal{}, []string{"-json", "msg"}, 2, ""},
+		{"no pattern", logs, terminal{}, nil, 2, ""},
+	} {
+		code, stdout, stderr := pf(t, tc.stdin, tc.tty, tc.args...)
+		if code != tc.code || stdout != tc.stdout {
+			t.Errorf("%s: exit %d, stdout %q; want %d, %q (stderr %q)", tc.name, code, stdout, tc.code, tc.stdout, stderr)
+		}
+	}
+}
+
+func TestRun_TerminalInputHint(t *testing.T) {
+	_, _, stderr := pf(t, "x\n", terminal{stdin: true}, "x")
+	if !strings.Contains(stderr, "Ctrl-D") {
+		t.Errorf("stderr = %q; want a hint on ending the input", stderr)
+	}
+	if _, _, stderr := pf(t, "x\n", terminal{}, "x"); stderr != "" {
+		t.Errorf("piped input printed %q to stderr", stderr)
+	}
+}
+
+// brokenPipe is stdout after the reader, such as head, has exited.
+type brokenPipe struct{}
+
+func (brokenPipe) Write([]byte) (int, error) { return 0, syscall.EPIPE }
+
+func TestRun_BrokenPipeIsQuiet(t *testing.T) {
+	var errOut bytes.Buffer
+	input := strings.Repeat("match\n", 100000)
+	if code := run([]string{"match"}, strings.NewReader(input), brokenPipe{}, &errOut, terminal{}); code != 0 || errOut.Len() != 0 {
+		t.Errorf("exit %d, stderr %q; want 0 and nothing", code, errOut.String())
+	}
+}
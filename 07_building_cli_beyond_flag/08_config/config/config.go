@@ -0,0 +1,239 @@
+// Package config resolves a command-line tool's settings and edits its
+// config file.
+//
+// Precedence, lowest to highest:
+//
+//	defaults → config file → environment → flags
+//
+// Every setting has one dotted key, such as sync.timeout. The key names
+// the setting in all sources: timeout under [sync] in the TOML file, the
+// environment variable NOTES_SYNC_TIMEOUT, and the flag -sync.timeout.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Kind is the type of a setting's value. Values are kept as strings and
+// checked against their kind when they are read from any source.
+type Kind int
+
+const (
+	String Kind = iota
+	Int
+	Bool
+	Duration
+)
+
+// Setting describes one key.
+type Setting struct {
+	Key     string
+	Kind    Kind
+	Default string
+	Choices []string // if set, the only values allowed
+	Usage   string
+}
+
+// Check reports whether v is a valid value for the setting.
+func (s Setting) Check(v string) error {
+	var err error
+	want := ""
+	switch s.Kind {
+	case Int:
+		_, err = strconv.Atoi(v)
+		want = "an integer"
+	case Bool:
+		_, err = strconv.ParseBool(v)
+		want = "true or false"
+	case Duration:
+		_, err = time.ParseDuration(v)
+		want = "a duration such as 30s or 1m"
+	}
+	if err == nil && len(s.Choices) > 0 && !slices.Contains(s.Choices, v) {
+		err = errors.New("not allowed")
+		want = "one of " + strings.Join(s.Choices, ", ")
+	}
+	if err != nil {
+		return fmt.Errorf("%s: invalid value %q: want %s", s.Key, v, want)
+	}
+	return nil
+}
+
+// typed converts a checked value to what the TOML file should hold, so
+// page_size = 20 is written as a number and not as "20".
+func (s Setting) typed(v string) any {
+	switch s.Kind {
+	case Int:
+		n, _ := strconv.ParseInt(v, 10, 64)
+		return n
+	case Bool:
+		b, _ := strconv.ParseBool(v)
+		return b
+	}
+	return v
+}
+
+// Schema is the set of settings a tool has.
+type Schema struct {
+	EnvPrefix string // NOTES makes sync.timeout NOTES_SYNC_TIMEOUT
+	Settings  []Setting
+}
+
+func (s *Schema) Lookup(key string) (Setting, bool) {
+	i := slices.IndexFunc(s.Settings, func(st Setting) bool { return st.Key == key })
+	if i < 0 {
+		return Setting{}, false
+	}
+	return s.Settings[i], true
+}
+
+// Keys lists the keys, for error messages.
+func (s *Schema) Keys() string {
+	keys := make([]string, len(s.Settings))
+	for i, st := range s.Settings {
+		keys[i] = st.Key
+	}
+	return strings.Join(keys, ", ")
+}
+
+// EnvName maps a key to its variable: sync.timeout is NOTES_SYNC_TIMEOUT.
+func (s *Schema) EnvName(key string) string {
+	return s.EnvPrefix + "_" + strings.ToUpper(strings.NewReplacer(".", "_", "-", "_").Replace(key))
+}
+
+// Flags defines one flag per setting on fs and returns a function that
+// reports the flags that were given. Unset flags must not override the
+// lower layers with their defaults, so each flag records whether Set was
+// called; flag.Visit would miss flags that the command package copies
+// into a subcommand's FlagSet.
+func (s *Schema) Flags(fs *flag.FlagSet) func() map[string]string {
+	values := map[string]*flagValue{}
+	for _, st := range s.Settings {
+		v := &flagValue{setting: st}
+		values[st.Key] = v
+		fs.Var(v, st.Key, st.Usage)
+	}
+	return func() map[string]string {
+		given := map[string]string{}
+		for key, v := range values {
+			if v.set {
+				given[key] = v.value
+			}
+		}
+		return given
+	}
+}
+
+type flagValue struct {
+	setting Setting
+	value   string
+	set     bool
+}
+
+func (v *flagValue) String() string {
+	if v == nil {
+		return ""
+	}
+	return v.value
+}
+
+func (v *flagValue) Set(s string) error {
+	if err := v.setting.Check(s); err != nil {
+		return err
+	}
+	v.value, v.set = s, true
+	return nil
+}
+
+// Value is a resolved setting and the layer it came from.
+type Value struct {
+	Setting
+	Value  string
+	Source string // "default", "file PATH", "env NOTES_EDITOR" or "flag -editor"
+}
+
+// Config is the result of Resolve.
+type Config struct {
+	values []Value
+}
+
+// Resolve layers the defaults, the file, the environment (read with
+// lookup) and the given flags. Every invalid value is reported, as is
+// any key in the file the schema does not know: a typo like "edtior"
+// would otherwise be ignored without a word.
+func (s *Schema) Resolve(file *File, lookup func(string) (string, bool), flags map[string]string) (*Config, error) {
+	var errs []error
+	for _, key := range file.Keys() {
+		if _, ok := s.Lookup(key); !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown key %q", file.Path, key))
+		}
+	}
+	cfg := &Config{}
+	for _, st := range s.Settings {
+		v := Value{Setting: st, Value: st.Default, Source: "default"}
+		if fv, ok := file.Get(st.Key); ok {
+			v.Value, v.Source = fv, "file "+file.Path
+		}
+		if ev, ok := lookup(s.EnvName(st.Key)); ok {
+			v.Value, v.Source = ev, "env "+s.EnvName(st.Key)
+		}
+		if fv, ok := flags[st.Key]; ok {
+			v.Value, v.Source = fv, "flag -"+st.Key
+		}
+		if err := st.Check(v.Value); err != nil {
+			errs = append(errs, fmt.Errorf("%s (from %s)", err, v.Source))
+		}
+		cfg.values = append(cfg.values, v)
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// All returns every setting in schema order.
+func (c *Config) All() []Value { return c.values }
+
+// Get returns one setting.
+func (c *Config) Get(key string) (Value, bool) {
+	i := slices.IndexFunc(c.values, func(v Value) bool { return v.Key == key })
+	if i < 0 {
+		return Value{}, false
+	}
+	return c.values[i], true
+}
+
+// The typed getters panic on a key the schema does not have, which is a
+// bug in the program and not in its input. Values were checked by
+// Resolve, so they parse.
+
+func (c *Config) String(key string) string { return c.must(key) }
+
+func (c *Config) Int(key string) int {
+	n, _ := strconv.Atoi(c.must(key))
+	return n
+}
+
+func (c *Config) Bool(key string) bool {
+	b, _ := strconv.ParseBool(c.must(key))
+	return b
+}
+
+func (c *Config) Duration(key string) time.Duration {
+	d, _ := time.ParseDuration(c.must(key))
+	return d
+}
+
+func (c *Config) must(key string) string {
+	v, ok := c.Get(key)
+	if !ok {
+		panic("config: unknown key " + key)
+	}
+	return v.Value
+}
@@ -0,0 +1,168 @@
+package config
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+var testSchema = &Schema{
+	EnvPrefix: "APP",
+	Settings: []Setting{
+		{Key: "editor", Default: "vi"},
+		{Key: "page_size", Kind: Int, Default: "20"},
+		{Key: "color", Default: "auto", Choices: []string{"auto", "never"}},
+		{Key: "sync.enabled", Kind: Bool, Default: "false"},
+		{Key: "sync.timeout", Kind: Duration, Default: "10s"},
+	},
+}
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) { v, ok := vars[k]; return v, ok }
+}
+
+func writeFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.toml")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestResolve_Precedence(t *testing.T) {
+	path := writeFile(t, `
+editor = "nano"
+page_size = 30
+[sync]
+enabled = true
+timeout = "5s"
+`)
+	f, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	given := testSchema.Flags(fs)
+	if err := fs.Parse([]string{"-page_size", "50"}); err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := testSchema.Resolve(f, env(map[string]string{
+		"APP_PAGE_SIZE": "40", "APP_SYNC_TIMEOUT": "1m", "OTHER": "x",
+	}), given())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want := map[string]string{
+		"editor":       "nano file " + path,
+		"page_size":    "50 flag -page_size",
+		"color":        "auto default",
+		"sync.enabled": "true file " + path,
+		"sync.timeout": "1m env APP_SYNC_TIMEOUT",
+	}
+	for _, v := range cfg.All() {
+		if got := v.Value + " " + v.Source; got != want[v.Key] {
+			t.Errorf("%s = %s; want %s", v.Key, got, want[v.Key])
+		}
+	}
+	if cfg.Int("page_size") != 50 || !cfg.Bool("sync.enabled") || cfg.Duration("sync.timeout") != time.Minute {
+		t.Error("typed getters disagree with the values")
+	}
+}
+
+func TestResolve_ReportsEveryError(t *testing.T) {
+	f, err := LoadFile(writeFile(t, "edtior = \"x\"\ncolor = \"pink\"\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = testSchema.Resolve(f, env(map[string]string{"APP_PAGE_SIZE": "lots"}), nil)
+	for _, want := range []string{
+		`unknown key "edtior"`,
+		`color: invalid value "pink": want one of auto, never (from file`,
+		`page_size: invalid value "lots": want an integer (from env APP_PAGE_SIZE)`,
+	} {
+		if err == nil || !strings.Contains(err.Error(), want) {
+			t.Errorf("error %v; want it to contain %q", err, want)
+		}
+	}
+}
+
+func TestFlags_RejectBadValues(t *testing.T) {
+	fs := flag.NewFlagSet("app", flag.ContinueOnError)
+	fs.SetOutput(new(strings.Builder))
+	testSchema.Flags(fs)
+	if err := fs.Parse([]string{"-sync.timeout", "soon"}); err == nil {
+		t.Error("-sync.timeout soon was accepted")
+	}
+}
+
+func TestFile_SaveRoundTrip(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "new", "app")
+	path := filepath.Join(dir, "config.toml")
+	f, err := LoadFile(path)
+	if err != nil || len(f.Keys()) != 0 {
+		t.Fatalf("missing file: %v, %v; want an empty config", f.Keys(), err)
+	}
+	// A key from a newer version of the program, to be kept.
+	f.values["future.key"] = "kept"
+	for key, v := range map[string]string{"page_size": "25", "sync.enabled": "true", "sync.timeout": "3s"} {
+		st, _ := testSchema.Lookup(key)
+		if err := f.Set(st, v); err != nil {
+			t.Fatal(err)
+		}
+	}
+	st, _ := testSchema.Lookup("page_size")
+	if err := f.Set(st, "x"); err == nil {
+		t.Error("Set accepted page_size x")
+	}
+	if err := f.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	data, _ := os.ReadFile(path)
+	want := "page_size = 25\n\n[future]\n  key = \"kept\"\n\n[sync]\n  enabled = true\n  timeout = \"3s\"\n"
+	if string(data) != want {
+		t.Errorf("file:\n%s\nwant:\n%s", data, want)
+	}
+	if fi, err := os.Stat(path); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("file mode %v, %v; want 0600", fi.Mode(), err)
+	}
+	if entries, _ := os.ReadDir(dir); len(entries) != 1 {
+		t.Errorf("directory holds %d files; want no temporary file left", len(entries))
+	}
+
+	again, err := LoadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if v, _ := again.Get("sync.timeout"); v != "3s" || !again.Unset("future.key") || again.Unset("future.key") {
+		t.Errorf("reloaded sync.timeout = %q, or Unset misreported", v)
+	}
+}
+
+func TestLoadFile_Invalid(t *testing.T) {
+	path := writeFile(t, "editor = \n")
+	if _, err := LoadFile(path); err == nil || !strings.Contains(err.Error(), path) {
+		t.Errorf("err = %v; want a TOML error naming the file", err)
+	}
+}
+
+func TestDir(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", filepath.Join(t.TempDir(), "xdg"))
+	if got, err := Dir("app"); err != nil || got != filepath.Join(os.Getenv("XDG_CONFIG_HOME"), "app") {
+		t.Errorf("Dir = %s, %v; want under XDG_CONFIG_HOME", got, err)
+	}
+
+	t.Setenv("XDG_CONFIG_HOME", "")
+	base, err := os.UserConfigDir()
+	if err != nil {
+		t.Skip("no user config directory:", err)
+	}
+	if got, _ := Dir("app"); got != filepath.Join(base, "app") {
+		t.Errorf("Dir without XDG_CONFIG_HOME = %s; want %s", got, filepath.Join(base, "app"))
+	}
+}
@@ -0,0 +1,167 @@
+package config
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/BurntSushi/toml"
+)
+
+// Dir returns the directory for app's configuration:
+//
+//   - $XDG_CONFIG_HOME/app when XDG_CONFIG_HOME is an absolute path, on
+//     every OS, so people who set it on macOS get what they asked for.
+//   - Otherwise os.UserConfigDir()/app: ~/.config/app on Linux and BSD,
+//     ~/Library/Application Support/app on macOS and %AppData%\app on
+//     Windows. On Linux and BSD os.UserConfigDir fails for a relative
+//     XDG_CONFIG_HOME, rather than guess what it is relative to.
+func Dir(app string) (string, error) {
+	if x := os.Getenv("XDG_CONFIG_HOME"); filepath.IsAbs(x) {
+		return filepath.Join(x, app), nil
+	}
+	base, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(base, app), nil
+}
+
+// File is a TOML config file, flattened to dotted keys:
+//
+//	editor = "vim"
+//	[sync]
+//	timeout = "5s"
+//
+// holds editor and sync.timeout. Keys the program does not know are kept
+// on Save, so an older version does not delete a newer one's settings.
+type File struct {
+	Path   string
+	values map[string]any
+}
+
+// LoadFile reads path. A missing file is an empty config, not an error:
+// most users never create one.
+func LoadFile(path string) (*File, error) {
+	f := &File{Path: path, values: map[string]any{}}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return f, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var tree map[string]any
+	if err := toml.Unmarshal(data, &tree); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	flatten("", tree, f.values)
+	return f, nil
+}
+
+func flatten(prefix string, tree map[string]any, out map[string]any) {
+	for k, v := range tree {
+		if prefix != "" {
+			k = prefix + "." + k
+		}
+		if sub, ok := v.(map[string]any); ok {
+			flatten(k, sub, out)
+		} else {
+			out[k] = v
+		}
+	}
+}
+
+// Keys returns the keys in the file, sorted.
+func (f *File) Keys() []string { return slices.Sorted(maps.Keys(f.values)) }
+
+// Get returns a key's value as a string.
+func (f *File) Get(key string) (string, bool) {
+	v, ok := f.values[key]
+	if !ok {
+		return "", false
+	}
+	return fmt.Sprint(v), true
+}
+
+// Set checks v against the setting and stores it with its TOML type.
+func (f *File) Set(st Setting, v string) error {
+	if err := st.Check(v); err != nil {
+		return err
+	}
+	f.values[st.Key] = st.typed(v)
+	return nil
+}
+
+// Unset removes key and reports whether it was there.
+func (f *File) Unset(key string) bool {
+	_, ok := f.values[key]
+	delete(f.values, key)
+	return ok
+}
+
+// Save writes the file atomically: to a temporary file in the same
+// directory, synced, then renamed over the old one. A crash or a full
+// disk leaves the old file or the new one, never half of each. The file
+// is private to the user (0600), since config files tend to collect
+// tokens; its directory is created 0700.
+func (f *File) Save() error {
+	tree, err := f.tree()
+	if err != nil {
+		return err
+	}
+	var buf bytes.Buffer
+	if err := toml.NewEncoder(&buf).Encode(tree); err != nil {
+		return err
+	}
+
+	dir := filepath.Dir(f.Path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(f.Path)+".*.tmp")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // fails harmlessly after the rename
+	if _, err := tmp.Write(buf.Bytes()); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), f.Path)
+}
+
+// tree nests the dotted keys back into tables.
+func (f *File) tree() (map[string]any, error) {
+	tree := map[string]any{}
+	for _, key := range f.Keys() {
+		parts := strings.Split(key, ".")
+		t := tree
+		for _, p := range parts[:len(parts)-1] {
+			sub, ok := t[p].(map[string]any)
+			if !ok {
+				if _, taken := t[p]; taken {
+					return nil, fmt.Errorf("%s: key %q is both a value and a table", f.Path, key)
+				}
+				sub = map[string]any{}
+				t[p] = sub
+			}
+			t = sub
+		}
+		t[parts[len(parts)-1]] = f.values[key]
+	}
+	return tree, nil
+}
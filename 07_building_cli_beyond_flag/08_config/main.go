@@ -0,0 +1,244 @@
+// Demonstrates where a command-line tool finds its configuration, and
+// how "config set" changes it.
+//
+// This example shows:
+// - Precedence: flags > environment > $XDG_CONFIG_HOME/notes/config.toml > defaults
+// - The config directory per OS with os.UserConfigDir, and XDG_CONFIG_HOME honored everywhere
+// - config get, set, unset and list subcommands that say where each value came from
+// - Saving the file atomically, with a temporary file and a rename
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"golang_roadmap/03_std_lib/02_flag/command"
+	"golang_roadmap/07_building_cli_beyond_flag/05_output/output"
+	"golang_roadmap/07_building_cli_beyond_flag/08_config/config"
+)
+
+var schema = &config.Schema{
+	EnvPrefix: "NOTES",
+	Settings: []config.Setting{
+		{Key: "editor", Default: "vi", Usage: "program that opens a note"},
+		{Key: "page_size", Kind: config.Int, Default: "20", Usage: "notes listed per page"},
+		{Key: "color", Default: "auto", Choices: []string{"auto", "always", "never"}, Usage: "color output: auto, always or never"},
+		{Key: "sync.enabled", Kind: config.Bool, Default: "false", Usage: "sync notes to the server"},
+		{Key: "sync.url", Default: "https://sync.example.com", Usage: "sync server"},
+		{Key: "sync.timeout", Kind: config.Duration, Default: "10s", Usage: "sync request timeout"},
+	},
+}
+
+func main() {
+	err := newRoot(os.Stdout, os.Stderr, os.LookupEnv).Execute(context.Background(), os.Args[1:])
+	var usageErr *command.UsageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.As(err, &usageErr):
+		fmt.Fprintln(os.Stderr, "error:", err)
+		fmt.Fprintf(os.Stderr, "Run '%s -h' for usage.\n", usageErr.Cmd.Path())
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// newRoot builds the command tree, writing results to stdout and notes to
+// stderr, and reading the environment with lookup.
+func newRoot(stdout, stderr io.Writer, lookup func(string) (string, bool)) *command.Command {
+	global := flag.NewFlagSet("notes", flag.ContinueOnError)
+	configFlag := global.String("config", "", "config file (default: $NOTES_CONFIG, or config.toml in the user config directory)")
+	givenFlags := schema.Flags(global)
+
+	// path picks the file: -config, then NOTES_CONFIG, then the default.
+	path := func() (string, error) {
+		if *configFlag != "" {
+			return *configFlag, nil
+		}
+		if p, ok := lookup("NOTES_CONFIG"); ok && p != "" {
+			return p, nil
+		}
+		dir, err := config.Dir("notes")
+		if err != nil {
+			return "", fmt.Errorf("no config directory: %w; use -config", err)
+		}
+		return filepath.Join(dir, "config.toml"), nil
+	}
+	loadFile := func() (*config.File, error) {
+		p, err := path()
+		if err != nil {
+			return nil, err
+		}
+		return config.LoadFile(p)
+	}
+	resolve := func() (*config.Config, error) {
+		f, err := loadFile()
+		if err != nil {
+			return nil, err
+		}
+		return schema.Resolve(f, lookup, givenFlags())
+	}
+
+	getFlags := flag.NewFlagSet("get", flag.ContinueOnError)
+	showSource := getFlags.Bool("source", false, "also print where the value came from")
+	get := &command.Command{
+		Name: "get", Short: "Print a setting's effective value", Args: "KEY", Flags: getFlags,
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("get needs KEY")
+			}
+			cfg, err := resolve()
+			if err != nil {
+				return err
+			}
+			v, ok := cfg.Get(args[0])
+			if !ok {
+				return fmt.Errorf("unknown key %q; want one of %s", args[0], schema.Keys())
+			}
+			if *showSource {
+				fmt.Fprintf(stdout, "%s\t(%s)\n", v.Value, v.Source)
+			} else {
+				fmt.Fprintln(stdout, v.Value)
+			}
+			return nil
+		},
+	}
+
+	set := &command.Command{
+		Name: "set", Short: "Save a setting in the config file", Args: "KEY VALUE",
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 2 {
+				return errors.New("set needs KEY and VALUE")
+			}
+			st, ok := schema.Lookup(args[0])
+			if !ok {
+				return fmt.Errorf("unknown key %q; want one of %s", args[0], schema.Keys())
+			}
+			f, err := loadFile()
+			if err != nil {
+				return err
+			}
+			if err := f.Set(st, args[1]); err != nil {
+				return err
+			}
+			if err := f.Save(); err != nil {
+				return err
+			}
+			// The file is below the environment and flags: say so, or the
+			// change looks like it did nothing.
+			if env := schema.EnvName(st.Key); hasEnv(lookup, env) {
+				fmt.Fprintf(stderr, "note: %s is set and overrides the file\n", env)
+			}
+			return nil
+		},
+	}
+
+	unset := &command.Command{
+		Name: "unset", Short: "Remove a setting from the config file", Args: "KEY",
+		Run: func(ctx context.Context, args []string) error {
+			if len(args) != 1 {
+				return errors.New("unset needs KEY")
+			}
+			f, err := loadFile()
+			if err != nil {
+				return err
+			}
+			if !f.Unset(args[0]) {
+				return nil
+			}
+			return f.Save()
+		},
+	}
+
+	listFlags := flag.NewFlagSet("list", flag.ContinueOnError)
+	format := output.Table
+	listFlags.Var(&format, "output", "output format: "+output.Choices)
+	list := &command.Command{
+		Name: "list", Short: "List every setting, its value and its source", Flags: listFlags,
+		Run: func(ctx context.Context, args []string) error {
+			cfg, err := resolve()
+			if err != nil {
+				return err
+			}
+			return output.Print(output.NewPrinter(stdout, format, "auto"), toEntries(cfg), entrySpec)
+		},
+	}
+
+	pathCmd := &command.Command{
+		Name: "path", Short: "Print the config file's path",
+		Run: func(ctx context.Context, args []string) error {
+			p, err := path()
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(stdout, p)
+			return nil
+		},
+	}
+
+	return &command.Command{
+		Name:       "notes",
+		Long:       "notes is a stand-in for a real tool; it prints the settings it would run with.",
+		Persistent: global,
+		Run: func(ctx context.Context, args []string) error {
+			cfg, err := resolve()
+			if err != nil {
+				return err
+			}
+			sync := "off"
+			if cfg.Bool("sync.enabled") {
+				sync = fmt.Sprintf("to %s (timeout %s)", cfg.String("sync.url"), cfg.Duration("sync.timeout"))
+			}
+			fmt.Fprintf(stdout, "editing with %s, %d notes per page, color %s, sync %s\n",
+				cfg.String("editor"), cfg.Int("page_size"), cfg.String("color"), sync)
+			return nil
+		},
+		Commands: []*command.Command{
+			{
+				Name: "config", Short: "Show or change settings",
+				Long:     "Show or change settings. set and unset edit the config file only.",
+				Commands: []*command.Command{get, set, unset, list, pathCmd},
+			},
+		},
+	}
+}
+
+func hasEnv(lookup func(string) (string, bool), name string) bool {
+	_, ok := lookup(name)
+	return ok
+}
+
+// entry is one row of "config list".
+type entry struct {
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	Source string `json:"source"`
+}
+
+func toEntries(cfg *config.Config) []entry {
+	var entries []entry
+	for _, v := range cfg.All() {
+		entries = append(entries, entry{v.Key, v.Value, v.Source})
+	}
+	return entries
+}
+
+var entrySpec = output.Spec[entry]{
+	Columns: []output.Column[entry]{
+		{Header: "KEY", Value: func(e entry) string { return e.Key }},
+		{Header: "VALUE", Value: func(e entry) string { return e.Value }, Truncate: true},
+		{Header: "SOURCE", Value: func(e entry) string { return e.Source }, Truncate: true},
+	},
+	RowStyle: func(e entry) output.Style {
+		if e.Source == "default" {
+			return output.Dim
+		}
+		return output.None
+	},
+}
@@ -0,0 +1,98 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// notes runs the command with env as the whole environment, apart from
+// XDG_CONFIG_HOME, which points into a temporary directory.
+func notes(t *testing.T, env map[string]string, args ...string) (string, string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	lookup := func(k string) (string, bool) { v, ok := env[k]; return v, ok }
+	err := newRoot(&stdout, &stderr, lookup).Execute(context.Background(), args)
+	return stdout.String(), stderr.String(), err
+}
+
+func TestConfigCommands(t *testing.T) {
+	xdg := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", xdg)
+	file := filepath.Join(xdg, "notes", "config.toml")
+
+	if out, _, err := notes(t, nil, "config", "path"); err != nil || out != file+"\n" {
+		t.Errorf("config path = %q, %v; want %s", out, err, file)
+	}
+	if out, _, _ := notes(t, nil); out != "editing with vi, 20 notes per page, color auto, sync off\n" {
+		t.Errorf("defaults: %q", out)
+	}
+
+	for _, kv := range [][2]string{{"editor", "nvim"}, {"sync.enabled", "true"}, {"sync.timeout", "5s"}} {
+		if _, _, err := notes(t, nil, "config", "set", kv[0], kv[1]); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := os.Stat(file); err != nil {
+		t.Fatalf("set did not write the file: %v", err)
+	}
+	if out, _, _ := notes(t, nil); out != "editing with nvim, 20 notes per page, color auto, sync to https://sync.example.com (timeout 5s)\n" {
+		t.Errorf("after set: %q", out)
+	}
+
+	env := map[string]string{"NOTES_EDITOR": "nano"}
+	if out, _, _ := notes(t, env, "config", "get", "-source", "editor"); out != "nano\t(env NOTES_EDITOR)\n" {
+		t.Errorf("env over file: %q", out)
+	}
+	if out, _, _ := notes(t, env, "config", "get", "-editor", "ed", "editor"); out != "ed\n" {
+		t.Errorf("flag over env: %q", out)
+	}
+	if _, stderr, _ := notes(t, env, "config", "set", "editor", "emacs"); !strings.Contains(stderr, "NOTES_EDITOR is set") {
+		t.Errorf("set under an env override said %q; want a note", stderr)
+	}
+
+	if _, _, err := notes(t, nil, "config", "unset", "sync.timeout"); err != nil {
+		t.Fatal(err)
+	}
+	out, _, _ := notes(t, nil, "config", "list", "-output", "plain")
+	want := "editor\temacs\tfile " + file + "\n" +
+		"page_size\t20\tdefault\n" +
+		"color\tauto\tdefault\n" +
+		"sync.enabled\ttrue\tfile " + file + "\n" +
+		"sync.url\thttps://sync.example.com\tdefault\n" +
+		"sync.timeout\t10s\tdefault\n"
+	if out != want {
+		t.Errorf("config list:\n%s\nwant:\n%s", out, want)
+	}
+}
+
+func TestConfigErrors(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	for _, args := range [][]string{
+		{"config", "set", "colour", "never"},
+		{"config", "set", "page_size", "many"},
+		{"config", "get", "nope"},
+		{"-sync.timeout", "soon"},
+	} {
+		if _, _, err := notes(t, nil, args...); err == nil {
+			t.Errorf("%q did not fail", args)
+		}
+	}
+	if _, _, err := notes(t, map[string]string{"NOTES_COLOR": "pink"}); err == nil || !strings.Contains(err.Error(), "env NOTES_COLOR") {
+		t.Errorf("bad env value: %v; want an error naming the variable", err)
+	}
+}
+
+func TestConfigFlagAndEnvPath(t *testing.T) {
+	t.Setenv("XDG_CONFIG_HOME", t.TempDir())
+	custom := filepath.Join(t.TempDir(), "custom.toml")
+	if _, _, err := notes(t, map[string]string{"NOTES_CONFIG": custom}, "config", "set", "page_size", "9"); err != nil {
+		t.Fatal(err)
+	}
+	if out, _, _ := notes(t, nil, "-config", custom, "config", "get", "page_size"); out != "9\n" {
+		t.Errorf("-config: page_size = %q; want 9 from %s", out, custom)
+	}
+}
@@ -0,0 +1,43 @@
+// Demonstrates a Bubble Tea dashboard fed by goroutines.
+//
+// This example shows:
+// - Panels that update live: worker statuses, a throughput sparkline and a log tail
+// - tea.Cmd subscriptions that turn channel reads into messages
+// - A background worker pool that knows nothing about the UI
+// - Batching bursts of log lines, and dropping them rather than stalling workers
+// - Stopping the pool cleanly when the program quits
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"golang_roadmap/07_building_cli_beyond_flag/09_dashboard/pool"
+)
+
+func main() {
+	workers := flag.Int("workers", 6, "number of workers")
+	jobTime := flag.Duration("job", 800*time.Millisecond, "mean job length")
+	failRate := flag.Float64("fail", 0.1, "fraction of jobs that fail")
+	flag.Parse()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := pool.Start(ctx, pool.Config{Workers: *workers, JobTime: *jobTime, FailRate: *failRate, Interval: 500 * time.Millisecond})
+
+	_, err := tea.NewProgram(newModel(p, *workers, cancel), tea.WithAltScreen()).Run()
+	cancel()
+	// Every worker has returned once Wait does: nothing is left running
+	// behind the shell prompt.
+	p.Wait()
+	if err != nil {
+		fmt.Fprintln(os.Stderr, "dashboard:", err)
+		os.Exit(1)
+	}
+	fmt.Printf("%d jobs done.\n", p.Completed())
+}
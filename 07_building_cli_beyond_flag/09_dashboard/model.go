@@ -0,0 +1,296 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"golang_roadmap/07_building_cli_beyond_flag/09_dashboard/pool"
+)
+
+// Messages carry what arrived on the pool's channels into Update.
+type (
+	logsMsg   []pool.LogLine
+	statusMsg pool.Status
+	sampleMsg pool.Sample
+	closedMsg struct{} // a channel was closed: the pool has stopped
+)
+
+// The wait functions are the subscriptions. Each returns a tea.Cmd that
+// blocks on a channel in a goroutine Bubble Tea manages, and turns what
+// arrives into a message. Update issues the same command again after
+// handling the message, so there is always exactly one reader per
+// channel, and the model itself is only touched by Update.
+
+// maxBatch caps how many log lines one message carries.
+const maxBatch = 100
+
+// waitLogs takes what is buffered, not just one line, so a burst of
+// lines costs one Update and one redraw.
+func waitLogs(ch <-chan pool.LogLine) tea.Cmd {
+	return func() tea.Msg {
+		line, ok := <-ch
+		if !ok {
+			return closedMsg{}
+		}
+		batch := logsMsg{line}
+		for len(batch) < maxBatch {
+			select {
+			case line, ok := <-ch:
+				if !ok {
+					return batch
+				}
+				batch = append(batch, line)
+			default:
+				return batch
+			}
+		}
+		return batch
+	}
+}
+
+func waitStatus(ch <-chan pool.Status) tea.Cmd {
+	return func() tea.Msg {
+		st, ok := <-ch
+		if !ok {
+			return closedMsg{}
+		}
+		return statusMsg(st)
+	}
+}
+
+func waitSample(ch <-chan pool.Sample) tea.Cmd {
+	return func() tea.Msg {
+		s, ok := <-ch
+		if !ok {
+			return closedMsg{}
+		}
+		return sampleMsg(s)
+	}
+}
+
+// tickMsg redraws the elapsed times of busy workers when nothing else
+// happens.
+type tickMsg time.Time
+
+func tick() tea.Cmd {
+	return tea.Tick(250*time.Millisecond, func(t time.Time) tea.Msg { return tickMsg(t) })
+}
+
+const (
+	maxLogs    = 500
+	maxSamples = 120
+)
+
+// source is what the model needs from the pool, so tests can use a fake.
+type source interface {
+	SetPaused(bool)
+	Paused() bool
+	Completed() int64
+	Dropped() int64
+}
+
+type model struct {
+	pool    source
+	logsCh  <-chan pool.LogLine
+	stCh    <-chan pool.Status
+	smplCh  <-chan pool.Sample
+	stop    func() // cancels the pool's context
+	workers []pool.Status
+	logs    []pool.LogLine
+	samples []float64
+	width   int
+	height  int
+	now     time.Time
+}
+
+func newModel(p *pool.Pool, workers int, stop func()) model {
+	return model{
+		pool: p, logsCh: p.Logs, stCh: p.Status, smplCh: p.Metrics, stop: stop,
+		workers: make([]pool.Status, workers),
+		width:   80, height: 24,
+		now: time.Now(),
+	}
+}
+
+func (m model) Init() tea.Cmd {
+	return tea.Batch(waitLogs(m.logsCh), waitStatus(m.stCh), waitSample(m.smplCh), tick())
+}
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.KeyMsg:
+		switch msg.String() {
+		case "q", "ctrl+c", "esc":
+			m.stop()
+			return m, tea.Quit
+		case "p", " ":
+			m.pool.SetPaused(!m.pool.Paused())
+		}
+	case tea.WindowSizeMsg:
+		m.width, m.height = msg.Width, msg.Height
+	case logsMsg:
+		m.logs = append(m.logs, msg...)
+		if len(m.logs) > maxLogs {
+			m.logs = m.logs[len(m.logs)-maxLogs:]
+		}
+		return m, waitLogs(m.logsCh)
+	case statusMsg:
+		// Copy on write: the old model may still be in use elsewhere,
+		// and must not see this change through a shared array.
+		if i := msg.Worker - 1; i >= 0 && i < len(m.workers) {
+			m.workers = append([]pool.Status(nil), m.workers...)
+			m.workers[i] = pool.Status(msg)
+		}
+		return m, waitStatus(m.stCh)
+	case sampleMsg:
+		m.samples = append(m.samples, msg.JobsPerSec)
+		if len(m.samples) > maxSamples {
+			m.samples = m.samples[len(m.samples)-maxSamples:]
+		}
+		return m, waitSample(m.smplCh)
+	case tickMsg:
+		m.now = time.Time(msg)
+		return m, tick()
+	case closedMsg:
+		// That channel's subscription ends by not issuing it again.
+	}
+	return m, nil
+}
+
+var (
+	panel    = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).BorderForeground(lipgloss.Color("8")).Padding(0, 1)
+	title    = lipgloss.NewStyle().Bold(true)
+	dim      = lipgloss.NewStyle().Foreground(lipgloss.Color("8"))
+	errStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("9"))
+	busy     = lipgloss.NewStyle().Foreground(lipgloss.Color("10"))
+	paused   = lipgloss.NewStyle().Foreground(lipgloss.Color("11"))
+)
+
+// A panel's border and padding take 4 columns and its border 2 rows.
+const (
+	frameW = 4
+	frameH = 2
+)
+
+func (m model) View() string {
+	leftW := m.width / 2
+	rightW := m.width - leftW
+	topH := len(m.workers) + 1 // title line and one row per worker
+	workers := m.box(m.viewWorkers(leftW-frameW), leftW, topH)
+	metrics := m.box(m.viewMetrics(rightW-frameW, topH), rightW, topH)
+	top := lipgloss.JoinHorizontal(lipgloss.Top, workers, metrics)
+
+	logH := max(m.height-lipgloss.Height(top)-frameH-1, 1)
+	logs := m.box(m.viewLogs(m.width-frameW, logH), m.width, logH)
+	return lipgloss.JoinVertical(lipgloss.Left, top, logs, m.footer())
+}
+
+// box draws lines in a panel of the given outer width and inner height,
+// cutting lines that are too long so that they never wrap.
+func (m model) box(lines []string, width, height int) string {
+	for i, l := range lines {
+		lines[i] = ansi.Truncate(l, width-frameW, "…")
+	}
+	return panel.Width(width - frameH).Height(height).MaxHeight(height + frameH).Render(strings.Join(lines, "\n"))
+}
+
+func (m model) viewWorkers(width int) []string {
+	lines := []string{title.Render("Workers")}
+	for i, w := range m.workers {
+		state := dim.Render(w.State.String())
+		detail := ""
+		switch w.State {
+		case pool.Busy:
+			state = busy.Render("busy")
+			detail = fmt.Sprintf("%s %4.1fs", w.Job, m.now.Sub(w.Since).Seconds())
+		case pool.Paused:
+			state = paused.Render("paused")
+		}
+		counts := fmt.Sprintf("%3d ok", w.Done)
+		if w.Failed > 0 {
+			counts += errStyle.Render(fmt.Sprintf(" %d failed", w.Failed))
+		}
+		lines = append(lines, fmt.Sprintf("#%-2d %s %-16s %s", i+1, padRight(state, 7), detail, counts))
+	}
+	return lines
+}
+
+func (m model) viewMetrics(width, height int) []string {
+	cur, peak, sum := 0.0, 0.0, 0.0
+	for _, s := range m.samples {
+		peak = max(peak, s)
+		sum += s
+	}
+	avg := 0.0
+	if n := len(m.samples); n > 0 {
+		cur, avg = m.samples[n-1], sum/float64(n)
+	}
+	lines := []string{
+		title.Render("Throughput") + dim.Render(fmt.Sprintf("  now %.1f/s  avg %.1f/s  peak %.1f/s", cur, avg, peak)),
+	}
+	// The sparkline fills the rest of the panel.
+	spark := sparkline(m.samples, width)
+	for range max(height-2, 1) {
+		lines = append(lines, "")
+	}
+	return append(lines, busy.Render(spark))
+}
+
+func (m model) viewLogs(width, height int) []string {
+	lines := []string{title.Render("Log")}
+	start := max(len(m.logs)-(height-1), 0)
+	for _, l := range m.logs[start:] {
+		level := dim.Render(string(l.Level))
+		if l.Level == pool.Error {
+			level = errStyle.Render(string(l.Level))
+		}
+		lines = append(lines, fmt.Sprintf("%s #%-2d %s %s", dim.Render(l.Time.Format("15:04:05")), l.Worker, padRight(level, 5), l.Text))
+	}
+	return lines
+}
+
+func (m model) footer() string {
+	state := "running"
+	if m.pool.Paused() {
+		state = "paused"
+	}
+	s := fmt.Sprintf(" %s · %d jobs done", state, m.pool.Completed())
+	if d := m.pool.Dropped(); d > 0 {
+		s += fmt.Sprintf(" · %d log lines dropped", d)
+	}
+	s += " · p pause · q quit"
+	return dim.Render(ansi.Truncate(s, m.width, "…"))
+}
+
+var bars = []rune(" ▁▂▃▄▅▆▇█")
+
+// sparkline draws the last width values as bars, scaled to the largest.
+func sparkline(values []float64, width int) string {
+	if width <= 0 {
+		return ""
+	}
+	values = values[max(len(values)-width, 0):]
+	peak := 0.0
+	for _, v := range values {
+		peak = max(peak, v)
+	}
+	var b strings.Builder
+	for _, v := range values {
+		i := 0
+		if peak > 0 {
+			i = int(v / peak * float64(len(bars)-1))
+		}
+		b.WriteRune(bars[i])
+	}
+	return b.String()
+}
+
+// padRight pads a styled string to n visible columns.
+func padRight(s string, n int) string {
+	return s + strings.Repeat(" ", max(n-lipgloss.Width(s), 0))
+}
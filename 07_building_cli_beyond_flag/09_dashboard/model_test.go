@@ -0,0 +1,151 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/charmbracelet/x/ansi"
+
+	"golang_roadmap/07_building_cli_beyond_flag/09_dashboard/pool"
+)
+
+type fakePool struct {
+	paused    bool
+	completed int64
+	dropped   int64
+}
+
+func (f *fakePool) SetPaused(p bool) { f.paused = p }
+func (f *fakePool) Paused() bool     { return f.paused }
+func (f *fakePool) Completed() int64 { return f.completed }
+func (f *fakePool) Dropped() int64   { return f.dropped }
+
+func testModel() (model, chan pool.LogLine, chan pool.Status, chan pool.Sample, *fakePool) {
+	logs, status, samples := make(chan pool.LogLine, 10), make(chan pool.Status, 1), make(chan pool.Sample, 1)
+	fake := &fakePool{completed: 42}
+	m := model{
+		pool: fake, logsCh: logs, stCh: status, smplCh: samples, stop: func() {},
+		workers: make([]pool.Status, 2), width: 100, height: 20,
+		now: time.Date(2026, 1, 1, 12, 0, 5, 0, time.UTC),
+	}
+	return m, logs, status, samples, fake
+}
+
+func update(t *testing.T, m model, msg tea.Msg) (model, tea.Cmd) {
+	t.Helper()
+	next, cmd := m.Update(msg)
+	return next.(model), cmd
+}
+
+func TestWaitLogsBatches(t *testing.T) {
+	ch := make(chan pool.LogLine, 3*maxBatch)
+	for range 3 * maxBatch {
+		ch <- pool.LogLine{Text: "x"}
+	}
+	if got := waitLogs(ch)(); len(got.(logsMsg)) != maxBatch {
+		t.Errorf("first batch has %d lines; want %d", len(got.(logsMsg)), maxBatch)
+	}
+	close(ch)
+	waitLogs(ch)()
+	if got := waitLogs(ch)(); len(got.(logsMsg)) != maxBatch {
+		t.Errorf("last batch before close has %d lines", len(got.(logsMsg)))
+	}
+	if _, ok := waitLogs(ch)().(closedMsg); !ok {
+		t.Error("closed channel did not give closedMsg")
+	}
+}
+
+func TestUpdateResubscribes(t *testing.T) {
+	m, logs, status, samples, _ := testModel()
+	at := m.now.Add(-2 * time.Second)
+
+	status <- pool.Status{Worker: 2, State: pool.Busy, Job: "job-0007", Since: at, Done: 3, Failed: 1}
+	msg := waitStatus(status)()
+	m, cmd := update(t, m, msg)
+	if m.workers[1].Job != "job-0007" {
+		t.Fatalf("worker 2 = %+v", m.workers[1])
+	}
+	// The command returned must read the same channel again.
+	status <- pool.Status{Worker: 1, State: pool.Idle}
+	if next, ok := cmd().(statusMsg); !ok || next.Worker != 1 {
+		t.Errorf("resubscription gave %v", next)
+	}
+
+	logs <- pool.LogLine{Time: at, Worker: 2, Level: pool.Error, Text: "job-0006 failed after 1s: upstream timeout"}
+	m, cmd = update(t, m, waitLogs(logs)())
+	if cmd == nil {
+		t.Error("no resubscription after logs")
+	}
+	for _, v := range []float64{1, 4, 2} {
+		samples <- pool.Sample{JobsPerSec: v}
+		m, _ = update(t, m, waitSample(samples)())
+	}
+	if _, cmd := update(t, m, closedMsg{}); cmd != nil {
+		t.Error("closedMsg resubscribed")
+	}
+
+	view := ansi.Strip(m.View())
+	for _, want := range []string{
+		"#2  busy    job-0007  2.0s     3 ok 1 failed",
+		"12:00:03 #2  ERROR job-0006 failed after 1s",
+		"now 2.0/s  avg 2.3/s  peak 4.0/s",
+		" ▂█▄",
+		"42 jobs done",
+	} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestViewFitsTheWindow(t *testing.T) {
+	m, _, _, _, fake := testModel()
+	fake.dropped = 5
+	for i := range 50 {
+		m, _ = update(t, m, logsMsg{{Worker: 1, Level: pool.Info, Text: strings.Repeat("long ", 40) + string(rune('a'+i%26))}})
+	}
+	for _, size := range []tea.WindowSizeMsg{{Width: 60, Height: 15}, {Width: 120, Height: 40}} {
+		m, _ = update(t, m, size)
+		view := m.View()
+		if w, h := lipgloss.Width(view), lipgloss.Height(view); w > size.Width || h > size.Height {
+			t.Errorf("%dx%d window: view is %dx%d", size.Width, size.Height, w, h)
+		}
+		if !strings.Contains(ansi.Strip(view), "5 log lines dropped") {
+			t.Error("dropped lines not shown")
+		}
+	}
+}
+
+func TestKeys(t *testing.T) {
+	m, _, _, _, fake := testModel()
+	m, _ = update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("p")})
+	if !fake.paused {
+		t.Error("p did not pause the pool")
+	}
+	stopped := false
+	m.stop = func() { stopped = true }
+	_, cmd := update(t, m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("q")})
+	if _, ok := cmd().(tea.QuitMsg); !ok || !stopped {
+		t.Error("q did not stop the pool and quit")
+	}
+}
+
+func TestSparkline(t *testing.T) {
+	for _, tc := range []struct {
+		values []float64
+		width  int
+		want   string
+	}{
+		{nil, 5, ""},
+		{[]float64{0, 0}, 5, "  "},
+		{[]float64{0, 1, 2, 4, 8}, 5, " ▁▂▄█"},
+		{[]float64{8, 1, 8}, 2, "▁█"},
+	} {
+		if got := sparkline(tc.values, tc.width); got != tc.want {
+			t.Errorf("sparkline(%v, %d) = %q; want %q", tc.values, tc.width, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,250 @@
+// Package pool runs a pool of workers on simulated jobs and reports what
+// they do on three channels: log lines, worker status changes and
+// throughput samples. It stands in for any background work a terminal UI
+// might watch, and knows nothing about the UI.
+//
+// The channels are closed once every goroutine has stopped, after the
+// context is cancelled, so a reader can range over them.
+package pool
+
+import (
+	"context"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+type State int
+
+const (
+	Idle State = iota
+	Busy
+	Paused
+	Stopped
+)
+
+func (s State) String() string {
+	switch s {
+	case Idle:
+		return "idle"
+	case Busy:
+		return "busy"
+	case Paused:
+		return "paused"
+	}
+	return "stopped"
+}
+
+// Status is a worker's state after a change. Done and Failed count its
+// jobs so far.
+type Status struct {
+	Worker int // from 1
+	State  State
+	Job    string // the job it is running, when busy
+	Since  time.Time
+	Done   int
+	Failed int
+}
+
+type Level string
+
+const (
+	Info  Level = "INFO"
+	Error Level = "ERROR"
+)
+
+type LogLine struct {
+	Time   time.Time
+	Worker int
+	Level  Level
+	Text   string
+}
+
+// Sample is the pool's throughput over the last interval.
+type Sample struct {
+	Time       time.Time
+	JobsPerSec float64
+}
+
+type Config struct {
+	Workers  int
+	JobTime  time.Duration // mean job length; each job takes 0.2 to 1.8 times it
+	FailRate float64       // fraction of jobs that fail, 0 to 1
+	Interval time.Duration // between throughput samples
+}
+
+// Pool is a running pool.
+//
+// Status and Metrics are sent with back-pressure: a worker waits until
+// the reader takes its update, so the latest state is never lost. Logs
+// are sent without waiting, and dropped when the buffer is full: a burst
+// of log lines must not stall the work it describes. Dropped counts them.
+type Pool struct {
+	Logs    <-chan LogLine
+	Status  <-chan Status
+	Metrics <-chan Sample
+
+	logs      chan LogLine
+	status    chan Status
+	metrics   chan Sample
+	cfg       Config
+	paused    atomic.Bool
+	completed atomic.Int64
+	dropped   atomic.Int64
+	jobs      atomic.Int64
+	wg        sync.WaitGroup
+}
+
+// Start starts the workers and the sampler. They run until ctx is done.
+func Start(ctx context.Context, cfg Config) *Pool {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.JobTime <= 0 {
+		cfg.JobTime = time.Second
+	}
+	if cfg.Interval <= 0 {
+		cfg.Interval = time.Second
+	}
+	p := &Pool{
+		logs:    make(chan LogLine, 256),
+		status:  make(chan Status, cfg.Workers),
+		metrics: make(chan Sample, 1),
+		cfg:     cfg,
+	}
+	p.Logs, p.Status, p.Metrics = p.logs, p.status, p.metrics
+
+	p.wg.Add(cfg.Workers + 1)
+	for id := 1; id <= cfg.Workers; id++ {
+		go func() {
+			defer p.wg.Done()
+			p.work(ctx, id)
+		}()
+	}
+	go func() {
+		defer p.wg.Done()
+		p.sample(ctx)
+	}()
+	go func() {
+		p.wg.Wait()
+		close(p.logs)
+		close(p.status)
+		close(p.metrics)
+	}()
+	return p
+}
+
+// Wait blocks until every goroutine of the pool has stopped.
+func (p *Pool) Wait() { p.wg.Wait() }
+
+// SetPaused stops workers from taking new jobs, or lets them again. Jobs
+// already running finish.
+func (p *Pool) SetPaused(paused bool) { p.paused.Store(paused) }
+
+func (p *Pool) Paused() bool { return p.paused.Load() }
+
+// Completed is the number of jobs finished, failed or not.
+func (p *Pool) Completed() int64 { return p.completed.Load() }
+
+// Dropped is the number of log lines no one was reading in time.
+func (p *Pool) Dropped() int64 { return p.dropped.Load() }
+
+func (p *Pool) work(ctx context.Context, id int) {
+	st := Status{Worker: id, State: Idle, Since: time.Now()}
+	report := func(state State, job string) bool {
+		st.State, st.Job, st.Since = state, job, time.Now()
+		select {
+		case p.status <- st:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	defer func() {
+		// A last update, if anyone is still reading.
+		st.State, st.Job = Stopped, ""
+		select {
+		case p.status <- st:
+		default:
+		}
+	}()
+
+	if !report(Idle, "") {
+		return
+	}
+	for {
+		if p.paused.Load() {
+			if st.State != Paused && !report(Paused, "") {
+				return
+			}
+			if !sleep(ctx, 100*time.Millisecond) {
+				return
+			}
+			continue
+		}
+
+		job := fmt.Sprintf("job-%04d", p.jobs.Add(1))
+		if !report(Busy, job) {
+			return
+		}
+		d := time.Duration(float64(p.cfg.JobTime) * (0.2 + 1.6*rand.Float64()))
+		if !sleep(ctx, d) {
+			return
+		}
+		p.completed.Add(1)
+		if rand.Float64() < p.cfg.FailRate {
+			st.Failed++
+			p.log(id, Error, fmt.Sprintf("%s failed after %s: upstream timeout", job, d.Round(time.Millisecond)))
+		} else {
+			st.Done++
+			p.log(id, Info, fmt.Sprintf("%s done in %s", job, d.Round(time.Millisecond)))
+		}
+		if !report(Idle, "") {
+			return
+		}
+	}
+}
+
+func (p *Pool) log(worker int, level Level, text string) {
+	select {
+	case p.logs <- LogLine{Time: time.Now(), Worker: worker, Level: level, Text: text}:
+	default:
+		p.dropped.Add(1)
+	}
+}
+
+// sample sends the throughput every interval.
+func (p *Pool) sample(ctx context.Context) {
+	t := time.NewTicker(p.cfg.Interval)
+	defer t.Stop()
+	last, lastTime := p.completed.Load(), time.Now()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case now := <-t.C:
+			n := p.completed.Load()
+			s := Sample{Time: now, JobsPerSec: float64(n-last) / now.Sub(lastTime).Seconds()}
+			last, lastTime = n, now
+			select {
+			case p.metrics <- s:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}
+}
+
+// sleep waits for d, or returns false if ctx is done first.
+func sleep(ctx context.Context, d time.Duration) bool {
+	t := time.NewTimer(d)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return true
+	case <-ctx.Done():
+		return false
+	}
+}
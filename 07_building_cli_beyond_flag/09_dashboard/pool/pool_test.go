@@ -0,0 +1,109 @@
+package pool
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// drain reads status and metrics, as a UI would, until the pool stops,
+// and returns the last status of each worker.
+func drain(p *Pool) map[int]Status {
+	last := map[int]Status{}
+	status, metrics := p.Status, p.Metrics
+	for status != nil || metrics != nil {
+		select {
+		case st, ok := <-status:
+			if !ok {
+				status = nil
+				continue
+			}
+			last[st.Worker] = st
+		case _, ok := <-metrics:
+			if !ok {
+				metrics = nil
+			}
+		}
+	}
+	return last
+}
+
+func TestPool_ReportsAndStops(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := Start(ctx, Config{Workers: 3, JobTime: 5 * time.Millisecond, FailRate: 1, Interval: 10 * time.Millisecond})
+	done := make(chan map[int]Status)
+	go func() { done <- drain(p) }()
+
+	var lines []LogLine
+	for len(lines) < 10 {
+		lines = append(lines, <-p.Logs)
+	}
+	cancel()
+	last := <-done
+	for range p.Logs {
+		// Closed once the pool has stopped.
+	}
+
+	for _, l := range lines {
+		if l.Level != Error || l.Worker < 1 || l.Worker > 3 {
+			t.Errorf("log line %+v; want an ERROR from workers 1 to 3 with FailRate 1", l)
+		}
+	}
+	if len(last) != 3 {
+		t.Fatalf("heard from %d workers; want 3", len(last))
+	}
+	failed := 0
+	for _, st := range last {
+		failed += st.Failed
+		if st.Done != 0 {
+			t.Errorf("worker %d: %d jobs done with FailRate 1", st.Worker, st.Done)
+		}
+	}
+	if failed < 10 || int64(failed) > p.Completed() {
+		t.Errorf("workers report %d failures, pool %d completed jobs", failed, p.Completed())
+	}
+}
+
+func TestPool_Pause(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	p := Start(ctx, Config{Workers: 2, JobTime: 2 * time.Millisecond})
+	go drain(p)
+	go func() {
+		for range p.Logs {
+		}
+	}()
+
+	p.SetPaused(true)
+	time.Sleep(50 * time.Millisecond) // let running jobs finish
+	before := p.Completed()
+	time.Sleep(50 * time.Millisecond)
+	if after := p.Completed(); after != before {
+		t.Errorf("%d jobs completed while paused", after-before)
+	}
+	p.SetPaused(false)
+	deadline := time.Now().Add(2 * time.Second)
+	for p.Completed() == before && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if p.Completed() == before {
+		t.Error("no jobs completed after resuming")
+	}
+}
+
+func TestPool_DropsLogsInsteadOfBlocking(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	p := Start(ctx, Config{Workers: 4, JobTime: 100 * time.Microsecond, Interval: time.Hour})
+	go drain(p)
+	// No one reads Logs: workers must keep going.
+	deadline := time.Now().Add(5 * time.Second)
+	for p.Dropped() == 0 && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	p.Wait()
+	if p.Dropped() == 0 || p.Completed() <= int64(cap(p.logs)) {
+		t.Errorf("completed %d jobs, dropped %d lines; want workers to outrun the %d-line buffer",
+			p.Completed(), p.Dropped(), cap(p.logs))
+	}
+}
@@ -0,0 +1,26 @@
+module golang_roadmap/07_building_cli_beyond_flag/10_credentials
+
+go 1.24.11
+
+require (
+	github.com/charmbracelet/x/term v0.2.2
+	golang_roadmap/02_core_language/22_functional_options v0.0.0
+	golang_roadmap/03_std_lib/02_flag v0.0.0
+)
+
+require (
+	golang.org/x/sys v0.36.0 // indirect
+	golang_roadmap/04_Tooling_testing_and_code_quality/07_clock v0.0.0 // indirect
+	golang_roadmap/12_operations/03_retry v0.0.0 // indirect
+)
+
+// The httpclient and command packages live in their own modules in this
+// repository, and so do the packages they use.
+replace (
+	golang_roadmap/02_core_language/22_functional_options => ../../02_core_language/22_functional_options
+	golang_roadmap/03_std_lib/02_flag => ../../03_std_lib/02_flag
+	golang_roadmap/04_Tooling_testing_and_code_quality/07_clock => ../../04_Tooling_testing_and_code_quality/07_clock
+	golang_roadmap/07_building_cli_beyond_flag/05_output => ../05_output
+	golang_roadmap/08_web_development/04_validation => ../../08_web_development/04_validation
+	golang_roadmap/12_operations/03_retry => ../../12_operations/03_retry
+)
@@ -0,0 +1,222 @@
+package keyring
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// ErrWrongPassphrase is returned when the file cannot be decrypted: the
+// passphrase is wrong, or the file was changed.
+var ErrWrongPassphrase = errors.New("keyring: wrong passphrase, or the file is damaged")
+
+// DefaultIterations is the PBKDF2-SHA256 iteration count for new files,
+// as OWASP recommends in 2023. It makes each guess of the passphrase
+// cost about as much as a legitimate unlock.
+const DefaultIterations = 600_000
+
+// FileStore keeps secrets in one file, encrypted with AES-256-GCM under
+// a key derived from a passphrase with PBKDF2. The file is rewritten
+// with a new salt and nonce on every change, atomically and with mode
+// 0600.
+//
+// Encryption protects the secrets in backups and on a stolen disk. It
+// does not protect them from other programs of the same user, which can
+// read the passphrase wherever it comes from; the system stores do
+// better there.
+type FileStore struct {
+	Path string
+	// Passphrase is called once, when the file is first read or written.
+	Passphrase func() ([]byte, error)
+	// Iterations for new files; zero means DefaultIterations. The count
+	// is stored in the file, so changing it does not lock out old files.
+	Iterations int
+
+	passphrase []byte
+}
+
+func (f *FileStore) String() string { return "encrypted file " + f.Path }
+
+// fileFormat is what is on disk. Everything but the ciphertext is
+// needed to decrypt it, and none of it is secret.
+type fileFormat struct {
+	Version    int    `json:"version"`
+	KDF        string `json:"kdf"`
+	Iterations int    `json:"iterations"`
+	Salt       []byte `json:"salt"`
+	Nonce      []byte `json:"nonce"`
+	Ciphertext []byte `json:"ciphertext"`
+}
+
+// secrets maps service, then account, to the secret.
+type secrets map[string]map[string]string
+
+func (f *FileStore) Get(service, account string) (string, error) {
+	all, err := f.load()
+	if err != nil {
+		return "", err
+	}
+	s, ok := all[service][account]
+	if !ok {
+		return "", ErrNotFound
+	}
+	return s, nil
+}
+
+func (f *FileStore) Set(service, account, secret string) error {
+	all, err := f.load()
+	if err != nil {
+		return err
+	}
+	if all[service] == nil {
+		all[service] = map[string]string{}
+	}
+	all[service][account] = secret
+	return f.save(all)
+}
+
+func (f *FileStore) Delete(service, account string) error {
+	all, err := f.load()
+	if err != nil {
+		return err
+	}
+	if _, ok := all[service][account]; !ok {
+		return nil
+	}
+	delete(all[service], account)
+	if len(all[service]) == 0 {
+		delete(all, service)
+	}
+	return f.save(all)
+}
+
+func (f *FileStore) getPassphrase() ([]byte, error) {
+	if f.passphrase != nil {
+		return f.passphrase, nil
+	}
+	if f.Passphrase == nil {
+		return nil, errors.New("keyring: no passphrase for " + f.Path)
+	}
+	p, err := f.Passphrase()
+	if err != nil {
+		return nil, err
+	}
+	if len(p) == 0 {
+		return nil, errors.New("keyring: empty passphrase")
+	}
+	f.passphrase = p
+	return p, nil
+}
+
+// load decrypts the file. A missing file holds no secrets, and needs no
+// passphrase yet.
+func (f *FileStore) load() (secrets, error) {
+	data, err := os.ReadFile(f.Path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return secrets{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var ff fileFormat
+	if err := json.Unmarshal(data, &ff); err != nil {
+		return nil, fmt.Errorf("keyring: %s: %w", f.Path, err)
+	}
+	if ff.Version != 1 || ff.KDF != "pbkdf2-sha256" {
+		return nil, fmt.Errorf("keyring: %s: unsupported format version %d, %s", f.Path, ff.Version, ff.KDF)
+	}
+	pass, err := f.getPassphrase()
+	if err != nil {
+		return nil, err
+	}
+	aead, err := newAEAD(pass, ff.Salt, ff.Iterations)
+	if err != nil {
+		return nil, err
+	}
+	if len(ff.Nonce) != aead.NonceSize() {
+		return nil, ErrWrongPassphrase
+	}
+	plain, err := aead.Open(nil, ff.Nonce, ff.Ciphertext, nil)
+	if err != nil {
+		return nil, ErrWrongPassphrase
+	}
+	all := secrets{}
+	if err := json.Unmarshal(plain, &all); err != nil {
+		return nil, fmt.Errorf("keyring: %s: %w", f.Path, err)
+	}
+	return all, nil
+}
+
+func (f *FileStore) save(all secrets) error {
+	pass, err := f.getPassphrase()
+	if err != nil {
+		return err
+	}
+	ff := fileFormat{Version: 1, KDF: "pbkdf2-sha256", Iterations: f.Iterations, Salt: make([]byte, 16)}
+	if ff.Iterations <= 0 {
+		ff.Iterations = DefaultIterations
+	}
+	rand.Read(ff.Salt)
+	aead, err := newAEAD(pass, ff.Salt, ff.Iterations)
+	if err != nil {
+		return err
+	}
+	ff.Nonce = make([]byte, aead.NonceSize())
+	rand.Read(ff.Nonce)
+	plain, err := json.Marshal(all)
+	if err != nil {
+		return err
+	}
+	ff.Ciphertext = aead.Seal(nil, ff.Nonce, plain, nil)
+	data, err := json.MarshalIndent(ff, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(f.Path, append(data, '\n'))
+}
+
+func newAEAD(pass, salt []byte, iterations int) (cipher.AEAD, error) {
+	key, err := pbkdf2.Key(sha256.New, string(pass), salt, iterations, 32)
+	if err != nil {
+		return nil, err
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+// writeFileAtomic writes a temporary file next to path and renames it
+// over path, so a crash leaves the old secrets or the new ones.
+func writeFileAtomic(path string, data []byte) error {
+	dir := filepath.Dir(path)
+	if err := os.MkdirAll(dir, 0o700); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(dir, "."+filepath.Base(path)+".*.tmp") // mode 0600
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), path)
+}
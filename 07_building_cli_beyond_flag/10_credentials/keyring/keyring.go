@@ -0,0 +1,82 @@
+// Package keyring keeps secrets, such as a CLI's API token, in the
+// operating system's credential store:
+//
+//   - macOS: the login keychain, through /usr/bin/security
+//   - Linux and BSD: the Secret Service (GNOME Keyring, KWallet), through
+//     secret-tool from libsecret
+//   - Windows: the Credential Manager, through advapi32.dll
+//
+// Where there is none, as on a server without a desktop session, secrets
+// go to a file encrypted with a passphrase instead (FileStore).
+//
+// Secrets are addressed by service, the program's name, and account,
+// such as a user or a server's host name.
+package keyring
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrNotFound = errors.New("keyring: secret not found")
+	// ErrUnsupported means this system has no credential store that
+	// the package can use.
+	ErrUnsupported = errors.New("keyring: no system credential store")
+)
+
+// Store keeps secrets. Deleting a secret that is not there is not an
+// error, so logging out twice works.
+type Store interface {
+	Get(service, account string) (string, error)
+	Set(service, account, secret string) error
+	Delete(service, account string) error
+	// String names the store for messages, as in "macOS keychain".
+	String() string
+}
+
+// Backend chooses a Store in Open.
+type Backend string
+
+const (
+	Auto   Backend = "auto"   // the system store if it works, else the file
+	System Backend = "system" // the system store or an error
+	File   Backend = "file"   // the encrypted file
+)
+
+// Set implements flag.Value.
+func (b *Backend) Set(s string) error {
+	switch Backend(s) {
+	case Auto, System, File:
+		*b = Backend(s)
+		return nil
+	}
+	return fmt.Errorf("want auto, system or file")
+}
+
+func (b *Backend) String() string {
+	if b == nil {
+		return ""
+	}
+	return string(*b)
+}
+
+// Open returns the store for backend. Auto tries the system store with
+// a lookup, since a store can exist and still not work: secret-tool is
+// installed but there is no D-Bus session over SSH, for example.
+func Open(backend Backend, file *FileStore) (Store, error) {
+	switch backend {
+	case File:
+		return file, nil
+	case System:
+		return system()
+	}
+	s, err := system()
+	if err != nil {
+		return file, nil
+	}
+	if _, err := s.Get("keyring-probe", "probe"); err != nil && !errors.Is(err, ErrNotFound) {
+		return file, nil
+	}
+	return s, nil
+}
@@ -0,0 +1,122 @@
+package keyring
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func newFileStore(t *testing.T, pass string) *FileStore {
+	t.Helper()
+	return &FileStore{
+		Path:       filepath.Join(t.TempDir(), "sub", "credentials.json"),
+		Passphrase: func() ([]byte, error) { return []byte(pass), nil },
+		Iterations: 1000, // the default is slow on purpose
+	}
+}
+
+func TestFileStore(t *testing.T) {
+	s := newFileStore(t, "correct horse")
+	if _, err := s.Get("app", "api.example.com"); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get from no file: %v; want ErrNotFound", err)
+	}
+	if err := s.Set("app", "api.example.com", "tok-1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Set("app", "staging.example.com", "tok-2"); err != nil {
+		t.Fatal(err)
+	}
+
+	// A new store, as in the next run of the program.
+	again := &FileStore{Path: s.Path, Passphrase: s.Passphrase}
+	for account, want := range map[string]string{"api.example.com": "tok-1", "staging.example.com": "tok-2"} {
+		if got, err := again.Get("app", account); err != nil || got != want {
+			t.Errorf("Get(%s) = %q, %v; want %q", account, got, err, want)
+		}
+	}
+	if err := again.Delete("app", "api.example.com"); err != nil {
+		t.Fatal(err)
+	}
+	if err := again.Delete("app", "api.example.com"); err != nil {
+		t.Errorf("second Delete: %v; want nil", err)
+	}
+	if _, err := again.Get("app", "api.example.com"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get after Delete: %v", err)
+	}
+
+	data, err := os.ReadFile(s.Path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(data), "tok-2") || strings.Contains(string(data), "staging") {
+		t.Errorf("file holds plaintext:\n%s", data)
+	}
+	if fi, _ := os.Stat(s.Path); fi.Mode().Perm() != 0o600 {
+		t.Errorf("file mode %v; want 0600", fi.Mode().Perm())
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(s.Path)); len(entries) != 1 {
+		t.Errorf("%d files in the directory; want no temporary file left", len(entries))
+	}
+}
+
+func TestFileStore_WrongPassphraseOrDamage(t *testing.T) {
+	s := newFileStore(t, "right")
+	if err := s.Set("app", "host", "secret"); err != nil {
+		t.Fatal(err)
+	}
+	wrong := &FileStore{Path: s.Path, Passphrase: func() ([]byte, error) { return []byte("wrong"), nil }}
+	if _, err := wrong.Get("app", "host"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("wrong passphrase: %v", err)
+	}
+	if err := wrong.Set("app", "other", "x"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("Set with the wrong passphrase: %v; must not overwrite the file", err)
+	}
+
+	// Flip one bit of the ciphertext: GCM notices.
+	data, _ := os.ReadFile(s.Path)
+	var ff fileFormat
+	json.Unmarshal(data, &ff)
+	ff.Ciphertext[0] ^= 1
+	data, _ = json.Marshal(ff)
+	os.WriteFile(s.Path, data, 0o600)
+	fresh := &FileStore{Path: s.Path, Passphrase: s.Passphrase}
+	if _, err := fresh.Get("app", "host"); !errors.Is(err, ErrWrongPassphrase) {
+		t.Errorf("damaged file: %v", err)
+	}
+}
+
+func TestFileStore_PassphraseAskedOnce(t *testing.T) {
+	s := newFileStore(t, "p")
+	asked := 0
+	s.Passphrase = func() ([]byte, error) { asked++; return []byte("p"), nil }
+	s.Set("app", "a", "1")
+	s.Set("app", "b", "2")
+	s.Get("app", "a")
+	if asked != 1 {
+		t.Errorf("passphrase asked %d times; want once", asked)
+	}
+
+	empty := newFileStore(t, "")
+	if err := empty.Set("app", "a", "1"); err == nil {
+		t.Error("empty passphrase accepted")
+	}
+}
+
+func TestOpen(t *testing.T) {
+	file := newFileStore(t, "p")
+	if s, err := Open(File, file); err != nil || s != Store(file) {
+		t.Errorf("Open(File) = %v, %v", s, err)
+	}
+	// Auto gives the system store where it works and the file elsewhere,
+	// so all that holds everywhere is that it gives a store.
+	if s, err := Open(Auto, file); err != nil || s == nil {
+		t.Errorf("Open(Auto) = %v, %v", s, err)
+	}
+	var b Backend
+	if err := b.Set("vault"); err == nil {
+		t.Error("Backend accepted vault")
+	}
+}
@@ -0,0 +1,72 @@
+package keyring
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// keychain uses the security command, as the Keychain Access app's
+// command-line counterpart. Calling it avoids cgo and the Security
+// framework.
+type keychain struct{}
+
+func system() (Store, error) {
+	if _, err := exec.LookPath("security"); err != nil {
+		return nil, ErrUnsupported
+	}
+	return keychain{}, nil
+}
+
+func (keychain) String() string { return "macOS keychain" }
+
+// errItemNotFound is security's exit status for a missing item.
+const errItemNotFound = 44
+
+func (keychain) Get(service, account string) (string, error) {
+	out, err := exec.Command("security", "find-generic-password", "-s", service, "-a", account, "-w").Output()
+	if err != nil {
+		return "", securityError(err)
+	}
+	return strings.TrimSuffix(string(out), "\n"), nil
+}
+
+// Set passes the secret on stdin, in security's interactive mode, and
+// hex-encoded: on the command line it would be visible to every user in
+// ps.
+func (keychain) Set(service, account, secret string) error {
+	cmd := exec.Command("security", "-i")
+	cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %s -X %s\n",
+		quote(service), quote(account), hex.EncodeToString([]byte(secret))))
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("keyring: security: %v: %s", err, out)
+	}
+	return nil
+}
+
+func (keychain) Delete(service, account string) error {
+	err := exec.Command("security", "delete-generic-password", "-s", service, "-a", account).Run()
+	if err := securityError(err); err != nil && !errors.Is(err, ErrNotFound) {
+		return err
+	}
+	return nil
+}
+
+func securityError(err error) error {
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == errItemNotFound {
+		return ErrNotFound
+	}
+	if err != nil {
+		return fmt.Errorf("keyring: security: %w", err)
+	}
+	return nil
+}
+
+// quote makes s one word for security -i, which splits its input like a
+// shell.
+func quote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
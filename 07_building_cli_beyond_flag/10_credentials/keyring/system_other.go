@@ -0,0 +1,5 @@
+//go:build !darwin && !windows && !linux && !freebsd && !openbsd && !netbsd && !dragonfly
+
+package keyring
+
+func system() (Store, error) { return nil, ErrUnsupported }
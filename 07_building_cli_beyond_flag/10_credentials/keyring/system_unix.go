@@ -0,0 +1,69 @@
+//go:build linux || freebsd || openbsd || netbsd || dragonfly
+
+package keyring
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// secretService uses secret-tool, libsecret's command-line client, which
+// talks D-Bus to whatever implements the Secret Service: GNOME Keyring,
+// KWallet or KeePassXC.
+type secretService struct{}
+
+func system() (Store, error) {
+	if _, err := exec.LookPath("secret-tool"); err != nil {
+		return nil, ErrUnsupported
+	}
+	// Without a session bus there is no one to talk to.
+	if os.Getenv("DBUS_SESSION_BUS_ADDRESS") == "" {
+		return nil, ErrUnsupported
+	}
+	return secretService{}, nil
+}
+
+func (secretService) String() string { return "Secret Service (secret-tool)" }
+
+func (secretService) Get(service, account string) (string, error) {
+	var stderr bytes.Buffer
+	cmd := exec.Command("secret-tool", "lookup", "service", service, "account", account)
+	cmd.Stderr = &stderr
+	out, err := cmd.Output()
+	var exit *exec.ExitError
+	switch {
+	// A missing item is exit status 1 with nothing said.
+	case errors.As(err, &exit) && exit.ExitCode() == 1 && stderr.Len() == 0:
+		return "", ErrNotFound
+	case err != nil:
+		return "", fmt.Errorf("keyring: secret-tool: %v: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return string(out), nil
+}
+
+// Set passes the secret on stdin, never in the arguments, which every
+// user can see in ps.
+func (secretService) Set(service, account, secret string) error {
+	cmd := exec.Command("secret-tool", "store", "--label", service+" ("+account+")", "service", service, "account", account)
+	cmd.Stdin = strings.NewReader(secret)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("keyring: secret-tool: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
+
+func (secretService) Delete(service, account string) error {
+	out, err := exec.Command("secret-tool", "clear", "service", service, "account", account).CombinedOutput()
+	var exit *exec.ExitError
+	if errors.As(err, &exit) && exit.ExitCode() == 1 && len(out) == 0 {
+		return nil // nothing to clear
+	}
+	if err != nil {
+		return fmt.Errorf("keyring: secret-tool: %v: %s", err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,269 @@
+// Demonstrates keeping a CLI's API token in the OS credential store.
+//
+// This example shows:
+// - login, logout and whoami commands, in the style of gh auth
+// - The macOS keychain, the Secret Service or the Windows Credential Manager, with the keyring package
+// - An encrypted file when there is no credential store, as over SSH
+// - Reading a token without echo, or from stdin for scripts
+// - Sending the stored token with every request through httpclient.WithBearerToken
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/charmbracelet/x/term"
+
+	"golang_roadmap/02_core_language/22_functional_options/httpclient"
+	"golang_roadmap/03_std_lib/02_flag/command"
+	"golang_roadmap/07_building_cli_beyond_flag/10_credentials/keyring"
+)
+
+// service names this program's secrets in the credential store; the
+// account is the API's host, so each server has its own token.
+const service = "apictl"
+
+var errRejected = errors.New("token rejected")
+
+func main() {
+	err := newRoot(os.Stdin, os.Stdout, os.Stderr, os.LookupEnv).Execute(context.Background(), os.Args[1:])
+	var usageErr *command.UsageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.As(err, &usageErr):
+		fmt.Fprintln(os.Stderr, "error:", err)
+		fmt.Fprintf(os.Stderr, "Run '%s -h' for usage.\n", usageErr.Cmd.Path())
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func newRoot(stdin io.Reader, stdout, stderr io.Writer, lookup func(string) (string, bool)) *command.Command {
+	env := func(key, fallback string) string {
+		if v, ok := lookup(key); ok && v != "" {
+			return v
+		}
+		return fallback
+	}
+	global := flag.NewFlagSet("apictl", flag.ContinueOnError)
+	api := global.String("api", env("APICTL_API", "http://localhost:8080"), "API base URL (env APICTL_API)")
+	backend := keyring.Auto
+	global.Var(&backend, "keyring", "where to keep the token: auto, system or file")
+	keyFile := global.String("keyring-file", "", "encrypted file used without a system store (default: credentials.json in the user config directory)")
+
+	// in is stdin as a terminal, or nil when it is a pipe or a file.
+	var in *os.File
+	if f, ok := stdin.(*os.File); ok && term.IsTerminal(f.Fd()) {
+		in = f
+	}
+
+	host := func() (string, error) {
+		u, err := url.Parse(*api)
+		if err != nil || u.Host == "" {
+			return "", fmt.Errorf("-api %q is not a URL", *api)
+		}
+		return u.Host, nil
+	}
+	store := func() (keyring.Store, error) {
+		path := *keyFile
+		if path == "" {
+			dir, err := os.UserConfigDir()
+			if err != nil {
+				return nil, fmt.Errorf("no config directory: %w; use -keyring-file", err)
+			}
+			path = filepath.Join(dir, service, "credentials.json")
+		}
+		return keyring.Open(backend, &keyring.FileStore{
+			Path: path,
+			Passphrase: func() ([]byte, error) {
+				if p, ok := lookup("APICTL_KEYRING_PASSPHRASE"); ok {
+					return []byte(p), nil
+				}
+				if in == nil {
+					return nil, errors.New("no system credential store: set APICTL_KEYRING_PASSPHRASE to use the encrypted file " + path)
+				}
+				fmt.Fprintf(stderr, "Passphrase for %s: ", path)
+				p, err := term.ReadPassword(in.Fd())
+				fmt.Fprintln(stderr)
+				return p, err
+			},
+		})
+	}
+	// token is what every request sends: APICTL_TOKEN for CI, where there
+	// is no keychain to log in to, or else the stored token.
+	token := func(ctx context.Context) (string, error) {
+		if t, ok := lookup("APICTL_TOKEN"); ok && t != "" {
+			return t, nil
+		}
+		h, err := host()
+		if err != nil {
+			return "", err
+		}
+		s, err := store()
+		if err != nil {
+			return "", err
+		}
+		t, err := s.Get(service, h)
+		if errors.Is(err, keyring.ErrNotFound) {
+			return "", fmt.Errorf("not logged in to %s; run 'apictl login'", h)
+		}
+		return t, err
+	}
+	whoami := func(ctx context.Context, tok func(context.Context) (string, error)) (string, error) {
+		c, err := httpclient.New(httpclient.WithBaseURL(*api), httpclient.WithBearerToken(tok))
+		if err != nil {
+			return "", err
+		}
+		resp, err := c.Get(ctx, "/me")
+		if err != nil {
+			return "", err
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode == http.StatusUnauthorized {
+			return "", errRejected
+		}
+		if resp.StatusCode != http.StatusOK {
+			return "", fmt.Errorf("GET /me: %s", resp.Status)
+		}
+		var me struct {
+			User string `json:"user"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&me); err != nil {
+			return "", fmt.Errorf("GET /me: %w", err)
+		}
+		return me.User, nil
+	}
+
+	loginFlags := flag.NewFlagSet("login", flag.ContinueOnError)
+	withToken := loginFlags.Bool("with-token", false, "read the token from stdin, as in: apictl login -with-token < token.txt")
+	login := &command.Command{
+		Name: "login", Short: "Check a token and store it", Flags: loginFlags,
+		Run: func(ctx context.Context, args []string) error {
+			var tok string
+			if *withToken || in == nil {
+				line, err := bufio.NewReader(stdin).ReadString('\n')
+				if err != nil && err != io.EOF {
+					return err
+				}
+				tok = strings.TrimSpace(line)
+			} else {
+				// Not echoed: the token would stay on screen and in the
+				// terminal's scrollback.
+				fmt.Fprint(stderr, "Paste your API token: ")
+				b, err := term.ReadPassword(in.Fd())
+				fmt.Fprintln(stderr)
+				if err != nil {
+					return err
+				}
+				tok = strings.TrimSpace(string(b))
+			}
+			if tok == "" {
+				return errors.New("empty token")
+			}
+			// Check the token before storing it, so a typo fails now
+			// and not on the next command.
+			user, err := whoami(ctx, func(context.Context) (string, error) { return tok, nil })
+			if errors.Is(err, errRejected) {
+				return errors.New("the API rejected this token; nothing was stored")
+			}
+			if err != nil {
+				return err
+			}
+			h, err := host()
+			if err != nil {
+				return err
+			}
+			s, err := store()
+			if err != nil {
+				return err
+			}
+			if err := s.Set(service, h, tok); err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "Logged in to %s as %s. Token stored in the %s.\n", h, user, s)
+			return nil
+		},
+	}
+
+	logout := &command.Command{
+		Name: "logout", Short: "Remove the stored token",
+		Run: func(ctx context.Context, args []string) error {
+			h, err := host()
+			if err != nil {
+				return err
+			}
+			s, err := store()
+			if err != nil {
+				return err
+			}
+			if err := s.Delete(service, h); err != nil {
+				return err
+			}
+			fmt.Fprintf(stdout, "Logged out of %s.\n", h)
+			return nil
+		},
+	}
+
+	who := &command.Command{
+		Name: "whoami", Short: "Call the API with the stored token",
+		Run: func(ctx context.Context, args []string) error {
+			user, err := whoami(ctx, token)
+			if errors.Is(err, errRejected) {
+				if t, ok := lookup("APICTL_TOKEN"); ok && t != "" {
+					return errors.New("the API rejected the token in APICTL_TOKEN")
+				}
+				return errors.New("the API rejected the stored token; run 'apictl login' again")
+			}
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(stdout, user)
+			return nil
+		},
+	}
+
+	serveFlags := flag.NewFlagSet("serve", flag.ContinueOnError)
+	addr := serveFlags.String("addr", "localhost:8080", "listen address")
+	serveToken := serveFlags.String("token", "demo-token", "the token the API accepts")
+	serve := &command.Command{
+		Name: "serve", Short: "Run a demo API to log in to", Flags: serveFlags,
+		Run: func(ctx context.Context, args []string) error {
+			fmt.Fprintf(stderr, "Demo API on http://%s, accepting the token %q\n", *addr, *serveToken)
+			return http.ListenAndServe(*addr, demoAPI(*serveToken))
+		},
+	}
+
+	return &command.Command{
+		Name:       "apictl",
+		Long:       "apictl logs in to an API and keeps the token in the OS credential store.",
+		Persistent: global,
+		Commands:   []*command.Command{login, logout, who, serve},
+	}
+}
+
+// demoAPI answers GET /me for requests that carry token.
+func demoAPI(token string) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /me", func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer "+token {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte(`{"user": "demo"}` + "\n"))
+	})
+	return mux
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+type env map[string]string
+
+func (e env) lookup(k string) (string, bool) { v, ok := e[k]; return v, ok }
+
+func apictl(t *testing.T, e env, stdin string, args ...string) (string, error) {
+	t.Helper()
+	var stdout, stderr bytes.Buffer
+	err := newRoot(strings.NewReader(stdin), &stdout, &stderr, e.lookup).Execute(context.Background(), args)
+	return stdout.String(), err
+}
+
+func TestLoginWhoamiLogout(t *testing.T) {
+	srv := httptest.NewServer(demoAPI("s3cret"))
+	defer srv.Close()
+	e := env{"APICTL_API": srv.URL, "APICTL_KEYRING_PASSPHRASE": "pass"}
+	file := filepath.Join(t.TempDir(), "credentials.json")
+	flags := []string{"-keyring", "file", "-keyring-file", file}
+	run := func(stdin string, args ...string) (string, error) {
+		return apictl(t, e, stdin, append(flags, args...)...)
+	}
+
+	if _, err := run("", "whoami"); err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Errorf("whoami before login: %v", err)
+	}
+	if _, err := run("wrong\n", "login"); err == nil || !strings.Contains(err.Error(), "rejected this token") {
+		t.Errorf("login with a bad token: %v", err)
+	}
+	out, err := run("s3cret\n", "login", "-with-token")
+	if err != nil || !strings.Contains(out, "as demo") || !strings.Contains(out, "encrypted file") {
+		t.Fatalf("login: %q, %v", out, err)
+	}
+	if out, err := run("", "whoami"); err != nil || out != "demo\n" {
+		t.Errorf("whoami: %q, %v", out, err)
+	}
+
+	e["APICTL_KEYRING_PASSPHRASE"] = "guess"
+	if _, err := run("", "whoami"); err == nil || !strings.Contains(err.Error(), "wrong passphrase") {
+		t.Errorf("whoami with the wrong passphrase: %v", err)
+	}
+	e["APICTL_KEYRING_PASSPHRASE"] = "pass"
+
+	if _, err := run("", "logout"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := run("", "whoami"); err == nil || !strings.Contains(err.Error(), "not logged in") {
+		t.Errorf("whoami after logout: %v", err)
+	}
+}
+
+func TestTokenFromEnvironment(t *testing.T) {
+	srv := httptest.NewServer(demoAPI("ci-token"))
+	defer srv.Close()
+	e := env{"APICTL_API": srv.URL, "APICTL_TOKEN": "ci-token"}
+	// No keyring is touched: there is no passphrase, and it is not needed.
+	out, err := apictl(t, e, "", "-keyring", "file", "-keyring-file", filepath.Join(t.TempDir(), "none.json"), "whoami")
+	if err != nil || out != "demo\n" {
+		t.Errorf("whoami with APICTL_TOKEN: %q, %v", out, err)
+	}
+	e["APICTL_TOKEN"] = "stale"
+	if _, err := apictl(t, e, "", "whoami"); err == nil || !strings.Contains(err.Error(), "rejected the token in APICTL_TOKEN") {
+		t.Errorf("rejected token: %v", err)
+	}
+}
@@ -0,0 +1,138 @@
+// Command release signs the manifest that selfup updates from.
+//
+//	go run ./cmd/release -genkey                 # release.key and release.pub
+//	go run ./cmd/release -version v1.1.0 dist    # dist/manifest.json for dist/selfup_*
+//	go run ./cmd/release -serve localhost:8000 dist
+//
+// The private key signs; keep it out of the repository, as a CI secret.
+// The public key is built into selfup with -ldflags.
+package main
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"golang_roadmap/07_building_cli_beyond_flag/11_selfupdate/update"
+)
+
+func main() {
+	log.SetFlags(0)
+	log.SetPrefix("release: ")
+	genkey := flag.Bool("genkey", false, "write a new key pair: the private key to -key, the public one to -key plus .pub")
+	keyPath := flag.String("key", "release.key", "private key file")
+	version := flag.String("version", "", "version being released, as in v1.1.0")
+	notes := flag.String("notes", "", "release notes shown by 'selfup update'")
+	name := flag.String("name", "selfup", "binary name; assets are NAME_GOOS_GOARCH[.exe]")
+	serve := flag.String("serve", "", "serve DIR over HTTP on this address, for trying updates out")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: release -genkey | -version V DIR | -serve ADDR DIR\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+
+	var err error
+	switch {
+	case *genkey:
+		err = generate(*keyPath)
+	case flag.NArg() != 1:
+		flag.Usage()
+		os.Exit(2)
+	case *serve != "":
+		log.Printf("serving %s on http://%s", flag.Arg(0), *serve)
+		err = http.ListenAndServe(*serve, http.FileServer(http.Dir(flag.Arg(0))))
+	default:
+		err = sign(*keyPath, flag.Arg(0), *name, *version, *notes)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func generate(path string) error {
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return err
+	}
+	if err := os.WriteFile(path, []byte(base64.StdEncoding.EncodeToString(priv.Seed())+"\n"), 0o600); err != nil {
+		return err
+	}
+	pubText := base64.StdEncoding.EncodeToString(pub)
+	if err := os.WriteFile(path+".pub", []byte(pubText+"\n"), 0o644); err != nil {
+		return err
+	}
+	fmt.Println(pubText)
+	return nil
+}
+
+func readKey(path string) (ed25519.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	seed, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil || len(seed) != ed25519.SeedSize {
+		return nil, fmt.Errorf("%s is not a key written by -genkey", path)
+	}
+	return ed25519.NewKeyFromSeed(seed), nil
+}
+
+// sign writes DIR/manifest.json listing the binaries in dir. Their URLs
+// are relative, so the directory can be uploaded anywhere.
+func sign(keyPath, dir, name, version, notes string) error {
+	if version == "" {
+		return errors.New("-version is required")
+	}
+	key, err := readKey(keyPath)
+	if err != nil {
+		return err
+	}
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return err
+	}
+	m := &update.Manifest{Version: version, Notes: notes}
+	for _, e := range entries {
+		platform, ok := strings.CutPrefix(strings.TrimSuffix(e.Name(), ".exe"), name+"_")
+		goos, goarch, ok2 := strings.Cut(platform, "_")
+		if !ok || !ok2 || e.IsDir() {
+			continue
+		}
+		sum, size, err := hashFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			return err
+		}
+		m.Assets = append(m.Assets, update.Asset{OS: goos, Arch: goarch, URL: e.Name(), SHA256: sum, Size: size})
+		log.Printf("%s/%s: %s", goos, goarch, e.Name())
+	}
+	if len(m.Assets) == 0 {
+		return fmt.Errorf("no %s_GOOS_GOARCH binaries in %s", name, dir)
+	}
+	data, err := update.Sign(m, key)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "manifest.json"), append(data, '\n'), 0o644)
+}
+
+func hashFile(path string) (string, int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", 0, err
+	}
+	defer f.Close()
+	h := sha256.New()
+	n, err := io.Copy(h, f)
+	return hex.EncodeToString(h.Sum(nil)), n, err
+}
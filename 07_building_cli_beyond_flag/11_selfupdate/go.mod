@@ -0,0 +1,16 @@
+module golang_roadmap/07_building_cli_beyond_flag/11_selfupdate
+
+go 1.24.11
+
+require (
+	golang.org/x/mod v0.26.0
+	golang_roadmap/03_std_lib/02_flag v0.0.0
+)
+
+// The command package lives in its own module in this repository, and so
+// do the packages it uses.
+replace (
+	golang_roadmap/03_std_lib/02_flag => ../../03_std_lib/02_flag
+	golang_roadmap/07_building_cli_beyond_flag/05_output => ../05_output
+	golang_roadmap/08_web_development/04_validation => ../../08_web_development/04_validation
+)
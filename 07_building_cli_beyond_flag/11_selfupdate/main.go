@@ -0,0 +1,215 @@
+// Demonstrates a CLI that updates itself.
+//
+// This example shows:
+// - Stamping the version, commit and build date in with -ldflags "-X main.version=..."
+// - Falling back to the module version and VCS stamps in debug.ReadBuildInfo
+// - Checking a release manifest over HTTPS, signed with ed25519
+// - Verifying the downloaded binary's SHA-256 before using it
+// - Replacing the running binary with two renames, and rolling back to the previous one
+// - A dry run that downloads and verifies without installing
+package main
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"golang_roadmap/03_std_lib/02_flag/command"
+	"golang_roadmap/07_building_cli_beyond_flag/11_selfupdate/update"
+)
+
+// Set at release time:
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse HEAD)
+//	  -X main.date=$(date -u +%FT%TZ) -X main.releaseKey=$(cat release.pub)"
+//
+// -X only sets string variables, initialized to a constant or not at all.
+var (
+	version     = "dev"
+	commit      = ""
+	date        = ""
+	releaseKey  = "" // base64 ed25519 public key; without one, update is refused
+	manifestURL = "https://releases.example.com/selfup/manifest.json"
+)
+
+type buildInfo struct {
+	Version  string `json:"version"`
+	Commit   string `json:"commit,omitempty"`
+	Date     string `json:"date,omitempty"`
+	Modified bool   `json:"modified,omitempty"` // built from a tree with uncommitted changes
+	Go       string `json:"go"`
+	Platform string `json:"platform"`
+}
+
+// readBuildInfo returns the stamped values, filling in what -ldflags did
+// not set from what the go command records: the module version for
+// "go install ...@v1.2.0", and the VCS revision for a build in a checkout.
+func readBuildInfo() buildInfo {
+	b := buildInfo{Version: version, Commit: commit, Date: date,
+		Go: runtime.Version(), Platform: runtime.GOOS + "/" + runtime.GOARCH}
+	info, ok := debug.ReadBuildInfo()
+	if !ok {
+		return b
+	}
+	if b.Version == "dev" && info.Main.Version != "" && info.Main.Version != "(devel)" {
+		b.Version = info.Main.Version
+	}
+	// The VCS stamps describe the checkout; with a stamped commit they
+	// may not match it, so they are used together or not at all.
+	if b.Commit != "" {
+		return b
+	}
+	for _, s := range info.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			b.Commit = s.Value
+		case "vcs.time":
+			if b.Date == "" {
+				b.Date = s.Value
+			}
+		case "vcs.modified":
+			b.Modified = s.Value == "true"
+		}
+	}
+	return b
+}
+
+func main() {
+	exe, err := executable()
+	if err == nil {
+		err = newRoot(os.Stdout, os.LookupEnv, exe).Execute(context.Background(), os.Args[1:])
+	}
+	var usageErr *command.UsageError
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.As(err, &usageErr):
+		fmt.Fprintln(os.Stderr, "error:", err)
+		fmt.Fprintf(os.Stderr, "Run '%s -h' for usage.\n", usageErr.Cmd.Path())
+		os.Exit(2)
+	default:
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+// executable is the path of the running binary, through any symlinks, so
+// an update replaces the file and not a link to it.
+func executable() (string, error) {
+	exe, err := os.Executable()
+	if err != nil {
+		return "", err
+	}
+	return filepath.EvalSymlinks(exe)
+}
+
+func newRoot(stdout io.Writer, lookup func(string) (string, bool), exe string) *command.Command {
+	env := func(key, fallback string) string {
+		if v, ok := lookup(key); ok && v != "" {
+			return v
+		}
+		return fallback
+	}
+	versionFlags := flag.NewFlagSet("version", flag.ContinueOnError)
+	asJSON := versionFlags.Bool("json", false, "print JSON")
+
+	updateFlags := flag.NewFlagSet("update", flag.ContinueOnError)
+	check := updateFlags.Bool("check", false, "only report whether an update is available")
+	dryRun := updateFlags.Bool("dry-run", false, "download and verify the update without installing it")
+	rollback := updateFlags.Bool("rollback", false, "go back to the version the last update replaced")
+	manifest := updateFlags.String("manifest", env("SELFUP_MANIFEST", manifestURL), "release manifest URL (env SELFUP_MANIFEST)")
+	timeout := updateFlags.Duration("timeout", 5*time.Minute, "give up on the download after this long")
+
+	return &command.Command{
+		Name:  "selfup",
+		Short: "A program that keeps itself up to date",
+		Commands: []*command.Command{
+			{
+				Name:  "version",
+				Short: "Print the version and how this binary was built",
+				Flags: versionFlags,
+				Run: func(ctx context.Context, args []string) error {
+					b := readBuildInfo()
+					if *asJSON {
+						enc := json.NewEncoder(stdout)
+						enc.SetIndent("", "  ")
+						return enc.Encode(b)
+					}
+					fmt.Fprintf(stdout, "selfup %s\n", b.Version)
+					if b.Commit != "" {
+						dirty := ""
+						if b.Modified {
+							dirty = " (modified)"
+						}
+						fmt.Fprintf(stdout, "  commit: %s%s\n", b.Commit, dirty)
+					}
+					if b.Date != "" {
+						fmt.Fprintf(stdout, "  built:  %s\n", b.Date)
+					}
+					fmt.Fprintf(stdout, "  go:     %s %s\n", b.Go, b.Platform)
+					return nil
+				},
+			},
+			{
+				Name:  "update",
+				Short: "Install the latest release over this binary",
+				Long: "Update fetches the release manifest, checks its signature against the key\n" +
+					"built into this binary, downloads the release for this platform, checks\n" +
+					"its SHA-256 and swaps it in. The replaced binary is kept beside the new\n" +
+					"one for -rollback.",
+				Flags: updateFlags,
+				Run: func(ctx context.Context, args []string) error {
+					if *rollback {
+						if err := update.Rollback(exe); err != nil {
+							return err
+						}
+						fmt.Fprintf(stdout, "Rolled back %s to the previous version.\n", exe)
+						return nil
+					}
+					key, err := base64.StdEncoding.DecodeString(releaseKey)
+					if err != nil || len(key) != ed25519.PublicKeySize {
+						return errors.New("this build has no release key and cannot verify updates; build it with -ldflags \"-X main.releaseKey=...\"")
+					}
+					ctx, cancel := context.WithTimeout(ctx, *timeout)
+					defer cancel()
+
+					u := &update.Updater{ManifestURL: *manifest, PublicKey: key}
+					current := readBuildInfo().Version
+					rel, err := u.Check(ctx, current)
+					if err != nil {
+						return err
+					}
+					if rel == nil {
+						fmt.Fprintf(stdout, "selfup %s is up to date.\n", current)
+						return nil
+					}
+					fmt.Fprintf(stdout, "selfup %s is available (this is %s).\n", rel.Manifest.Version, current)
+					if rel.Manifest.Notes != "" {
+						fmt.Fprintf(stdout, "\n%s\n\n", rel.Manifest.Notes)
+					}
+					if *check {
+						return nil
+					}
+					if err := u.Apply(ctx, rel, exe, update.Options{DryRun: *dryRun}); err != nil {
+						return err
+					}
+					if *dryRun {
+						fmt.Fprintf(stdout, "Dry run: downloaded and verified %s; %s is unchanged.\n", rel.Asset.URL, exe)
+						return nil
+					}
+					fmt.Fprintf(stdout, "Updated %s to %s. 'selfup update -rollback' undoes it.\n", exe, rel.Manifest.Version)
+					return nil
+				},
+			},
+		},
+	}
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"golang_roadmap/07_building_cli_beyond_flag/11_selfupdate/update"
+)
+
+func run(t *testing.T, exe string, env map[string]string, args ...string) (string, error) {
+	t.Helper()
+	var out bytes.Buffer
+	lookup := func(k string) (string, bool) { v, ok := env[k]; return v, ok }
+	err := newRoot(&out, lookup, exe).Execute(context.Background(), args)
+	return out.String(), err
+}
+
+// stamp sets the variables -ldflags would, for one test.
+func stamp(t *testing.T, v, key string) {
+	oldV, oldK := version, releaseKey
+	version, releaseKey = v, key
+	t.Cleanup(func() { version, releaseKey = oldV, oldK })
+}
+
+func TestVersion(t *testing.T) {
+	stamp(t, "v1.0.0", "")
+	commit, date = "abc123", "2026-01-02T03:04:05Z"
+	t.Cleanup(func() { commit, date = "", "" })
+
+	out, err := run(t, "", nil, "version")
+	if err != nil || !strings.HasPrefix(out, "selfup v1.0.0\n  commit: abc123\n  built:  2026-01-02T03:04:05Z\n") {
+		t.Errorf("version printed %q, %v", out, err)
+	}
+	out, _ = run(t, "", nil, "version", "-json")
+	var b buildInfo
+	if err := json.Unmarshal([]byte(out), &b); err != nil || b.Version != "v1.0.0" || b.Platform != runtime.GOOS+"/"+runtime.GOARCH {
+		t.Errorf("version -json = %q, %v", out, err)
+	}
+}
+
+func TestUpdate(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	stamp(t, "v1.0.0", base64.StdEncoding.EncodeToString(pub))
+
+	binary := []byte("new build")
+	h := sha256.Sum256(binary)
+	manifest, _ := update.Sign(&update.Manifest{Version: "v1.1.0", Notes: "Faster.", Assets: []update.Asset{
+		{OS: runtime.GOOS, Arch: runtime.GOARCH, URL: "selfup_new", SHA256: hex.EncodeToString(h[:])},
+	}}, priv)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) { w.Write(manifest) })
+	mux.HandleFunc("/selfup_new", func(w http.ResponseWriter, r *http.Request) { w.Write(binary) })
+	srv := httptest.NewServer(mux) // plain HTTP is allowed to 127.0.0.1
+	defer srv.Close()
+	env := map[string]string{"SELFUP_MANIFEST": srv.URL + "/manifest.json"}
+
+	exe := filepath.Join(t.TempDir(), "selfup")
+	os.WriteFile(exe, []byte("old build"), 0o755)
+	contents := func() string { data, _ := os.ReadFile(exe); return string(data) }
+
+	out, err := run(t, exe, env, "update", "-check")
+	if err != nil || !strings.Contains(out, "v1.1.0 is available") || !strings.Contains(out, "Faster.") || contents() != "old build" {
+		t.Errorf("update -check: %q, %v", out, err)
+	}
+	out, err = run(t, exe, env, "update", "-dry-run")
+	if err != nil || !strings.Contains(out, "Dry run") || contents() != "old build" {
+		t.Errorf("update -dry-run: %q, %v", out, err)
+	}
+	out, err = run(t, exe, env, "update")
+	if err != nil || contents() != "new build" {
+		t.Fatalf("update: %q, %v; binary is %q", out, err, contents())
+	}
+	if _, err := run(t, exe, env, "update", "-rollback"); err != nil || contents() != "old build" {
+		t.Errorf("update -rollback: %v; binary is %q", err, contents())
+	}
+
+	stamp(t, "v1.1.0", releaseKey)
+	if out, err := run(t, exe, env, "update"); err != nil || !strings.Contains(out, "up to date") {
+		t.Errorf("update at the latest version: %q, %v", out, err)
+	}
+}
+
+func TestUpdate_WithoutReleaseKey(t *testing.T) {
+	stamp(t, "v1.0.0", "")
+	if _, err := run(t, "", nil, "update"); err == nil || !strings.Contains(err.Error(), "release key") {
+		t.Errorf("err = %v; want update refused", err)
+	}
+}
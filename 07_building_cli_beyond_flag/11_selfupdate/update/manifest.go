@@ -0,0 +1,97 @@
+// Package update replaces a running program with a newer release.
+//
+// A release is described by a manifest, published next to the binaries:
+//
+//	{"payload": "<base64 manifest JSON>", "signature": "<base64 ed25519>"}
+//
+// The payload lists the version and, per OS and architecture, a URL and
+// the SHA-256 of the binary. The program checks the signature with a
+// public key built into it, then checks the binary against the SHA-256.
+// A server, a CDN or a network in between can then withhold an update,
+// but not replace one: only the holder of the private key can say which
+// bytes are the next version.
+package update
+
+import (
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"golang.org/x/mod/semver"
+)
+
+// Manifest describes a release: its version and its binaries.
+type Manifest struct {
+	Version string  `json:"version"` // semantic version, as in v1.4.0
+	Notes   string  `json:"notes,omitempty"`
+	Assets  []Asset `json:"assets"`
+}
+
+// Asset is one binary.
+type Asset struct {
+	OS     string `json:"os"`
+	Arch   string `json:"arch"`
+	URL    string `json:"url"` // absolute, or relative to the manifest's URL
+	SHA256 string `json:"sha256"`
+	Size   int64  `json:"size"`
+}
+
+type envelope struct {
+	Payload   []byte `json:"payload"`
+	Signature []byte `json:"signature"`
+}
+
+// ErrBadSignature means the manifest was not signed with the release key:
+// it was tampered with, or signed by someone else.
+var ErrBadSignature = errors.New("update: manifest signature does not verify")
+
+// Sign encodes m and signs it with key, for the release tool.
+func Sign(m *Manifest, key ed25519.PrivateKey) ([]byte, error) {
+	payload, err := json.Marshal(m)
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(envelope{Payload: payload, Signature: ed25519.Sign(key, payload)}, "", "  ")
+}
+
+// ParseManifest verifies data's signature with key and decodes the
+// manifest. Nothing in the payload is looked at before the signature
+// checks out.
+func ParseManifest(data []byte, key ed25519.PublicKey) (*Manifest, error) {
+	if len(key) != ed25519.PublicKeySize {
+		return nil, errors.New("update: no release key")
+	}
+	var env envelope
+	if err := json.Unmarshal(data, &env); err != nil {
+		return nil, fmt.Errorf("update: manifest: %w", err)
+	}
+	if !ed25519.Verify(key, env.Payload, env.Signature) {
+		return nil, ErrBadSignature
+	}
+	var m Manifest
+	if err := json.Unmarshal(env.Payload, &m); err != nil {
+		return nil, fmt.Errorf("update: manifest payload: %w", err)
+	}
+	if !semver.IsValid(m.Version) {
+		return nil, fmt.Errorf("update: manifest version %q is not a semantic version", m.Version)
+	}
+	return &m, nil
+}
+
+// NewerThan reports whether the manifest's version is newer than
+// current. A current version that is not a semantic version, such as
+// "dev" for a local build, is never updated.
+func (m *Manifest) NewerThan(current string) bool {
+	return semver.IsValid(current) && semver.Compare(m.Version, current) > 0
+}
+
+// Asset returns the binary for an OS and architecture.
+func (m *Manifest) Asset(goos, goarch string) (Asset, bool) {
+	for _, a := range m.Assets {
+		if a.OS == goos && a.Arch == goarch {
+			return a, true
+		}
+	}
+	return Asset{}, false
+}
@@ -0,0 +1,235 @@
+package update
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"runtime"
+)
+
+// maxBinary caps a download, so a broken or hostile server cannot fill
+// the disk.
+const maxBinary = 512 << 20
+
+// Updater checks for and installs releases.
+type Updater struct {
+	ManifestURL string
+	PublicKey   ed25519.PublicKey
+	Client      *http.Client // nil means http.DefaultClient
+	GOOS        string       // empty means runtime.GOOS
+	GOARCH      string       // empty means runtime.GOARCH
+}
+
+// Release is a newer version and its binary for this platform.
+type Release struct {
+	Manifest *Manifest
+	Asset    Asset
+}
+
+// Check fetches the manifest and returns the release to install, or nil
+// if current is up to date.
+func (u *Updater) Check(ctx context.Context, current string) (*Release, error) {
+	base, err := checkURL(u.ManifestURL)
+	if err != nil {
+		return nil, err
+	}
+	data, err := u.get(ctx, base.String(), 1<<20)
+	if err != nil {
+		return nil, err
+	}
+	m, err := ParseManifest(data, u.PublicKey)
+	if err != nil {
+		return nil, err
+	}
+	if !m.NewerThan(current) {
+		return nil, nil
+	}
+	goos, goarch := cmp.Or(u.GOOS, runtime.GOOS), cmp.Or(u.GOARCH, runtime.GOARCH)
+	a, ok := m.Asset(goos, goarch)
+	if !ok {
+		return nil, fmt.Errorf("update: %s has no binary for %s/%s", m.Version, goos, goarch)
+	}
+	ref, err := url.Parse(a.URL)
+	if err != nil {
+		return nil, fmt.Errorf("update: asset URL: %w", err)
+	}
+	a.URL = base.ResolveReference(ref).String()
+	if _, err := checkURL(a.URL); err != nil {
+		return nil, err
+	}
+	return &Release{Manifest: m, Asset: a}, nil
+}
+
+// Options for Apply.
+type Options struct {
+	// DryRun downloads and verifies the binary, then deletes it instead
+	// of installing it.
+	DryRun bool
+}
+
+// Apply downloads r's binary next to exe, checks its SHA-256, and swaps
+// it in:
+//
+//	exe      → exe.old   (the backup, for Rollback)
+//	exe.new  → exe
+//
+// Both steps are renames in one directory, so each is atomic: at every
+// moment a complete binary is at exe, apart from between the two renames
+// — and if the second one fails, the first is undone. Renaming works on
+// a running program on Unix and Windows alike, where overwriting it does
+// not on Windows.
+func (u *Updater) Apply(ctx context.Context, r *Release, exe string, opts Options) error {
+	// Decoded, so that upper- and lower-case hex both match.
+	want, err := hex.DecodeString(r.Asset.SHA256)
+	if err != nil || len(want) != sha256.Size {
+		return errors.New("update: asset has no valid SHA-256")
+	}
+	fi, err := os.Stat(exe)
+	if err != nil {
+		return err
+	}
+	newPath := exe + ".new"
+	if err := u.download(ctx, r.Asset, want, newPath, fi.Mode().Perm()); err != nil {
+		return err
+	}
+	if opts.DryRun {
+		return os.Remove(newPath)
+	}
+
+	oldPath := exe + ".old"
+	os.Remove(oldPath) // an older backup; Windows will not rename over it
+	if err := os.Rename(exe, oldPath); err != nil {
+		os.Remove(newPath)
+		return fmt.Errorf("update: %w", err)
+	}
+	if err := os.Rename(newPath, exe); err != nil {
+		if rerr := os.Rename(oldPath, exe); rerr != nil {
+			return fmt.Errorf("update: %w; and restoring the old binary failed: %v; it is at %s", err, rerr, oldPath)
+		}
+		os.Remove(newPath)
+		return fmt.Errorf("update: %w", err)
+	}
+	return nil
+}
+
+// Rollback puts back the binary Apply replaced.
+func Rollback(exe string) error {
+	oldPath := exe + ".old"
+	if _, err := os.Stat(oldPath); err != nil {
+		return fmt.Errorf("update: no previous version to roll back to: %w", err)
+	}
+	// The same dance as Apply, so a failure leaves a working binary.
+	discard := exe + ".rollback"
+	if err := os.Rename(exe, discard); err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+	if err := os.Rename(oldPath, exe); err != nil {
+		os.Rename(discard, exe)
+		return fmt.Errorf("update: %w", err)
+	}
+	// On Windows the running binary cannot be deleted; it is left for
+	// the next update to replace.
+	os.Remove(discard)
+	return nil
+}
+
+// download writes the asset to path, hashing it on the way, and removes
+// it unless the size matches and the hash is want.
+func (u *Updater) download(ctx context.Context, a Asset, want []byte, path string, perm os.FileMode) (err error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, a.URL, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := u.client().Do(req)
+	if err != nil {
+		return fmt.Errorf("update: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("update: GET %s: %s", a.URL, resp.Status)
+	}
+
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, perm|0o700)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if cerr := f.Close(); err == nil {
+			err = cerr
+		}
+		if err != nil {
+			os.Remove(path)
+		}
+	}()
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(f, h), io.LimitReader(resp.Body, maxBinary+1))
+	if err != nil {
+		return fmt.Errorf("update: download: %w", err)
+	}
+	if n > maxBinary || a.Size > 0 && n != a.Size {
+		return fmt.Errorf("update: downloaded %d bytes; want %d", n, a.Size)
+	}
+	if got := h.Sum(nil); !bytes.Equal(got, want) {
+		return fmt.Errorf("update: SHA-256 of %s is %x; the manifest says %s", filepath.Base(a.URL), got, a.SHA256)
+	}
+	return f.Sync()
+}
+
+func (u *Updater) get(ctx context.Context, rawURL string, limit int64) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := u.client().Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("update: GET %s: %s", rawURL, resp.Status)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, limit))
+}
+
+func (u *Updater) client() *http.Client {
+	if u.Client != nil {
+		return u.Client
+	}
+	return http.DefaultClient
+}
+
+// checkURL accepts https, and http only to this machine, for trying
+// releases out locally. The signature protects the content either way;
+// HTTPS also keeps what is being downloaded private.
+func checkURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return nil, fmt.Errorf("update: %w", err)
+	}
+	switch {
+	case u.Scheme == "https":
+	case u.Scheme == "http" && isLoopback(u.Hostname()):
+	default:
+		return nil, fmt.Errorf("update: %s: only https URLs are allowed", raw)
+	}
+	return u, nil
+}
+
+func isLoopback(host string) bool {
+	if host == "localhost" {
+		return true
+	}
+	ip := net.ParseIP(host)
+	return ip != nil && ip.IsLoopback()
+}
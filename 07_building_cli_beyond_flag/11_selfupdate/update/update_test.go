@@ -0,0 +1,203 @@
+package update
+
+import (
+	"context"
+	"crypto/ed25519"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+var newBinary = []byte("#!/bin/sh\necho v1.1.0\n")
+
+func sum(b []byte) string {
+	h := sha256.Sum256(b)
+	return hex.EncodeToString(h[:])
+}
+
+// release serves a signed manifest for v1.1.0 at /manifest.json, and
+// the binary at /bin, over TLS.
+func release(t *testing.T, m *Manifest) (*Updater, ed25519.PrivateKey) {
+	t.Helper()
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m == nil {
+		m = &Manifest{Version: "v1.1.0", Assets: []Asset{
+			{OS: "linux", Arch: "amd64", URL: "bin", SHA256: sum(newBinary), Size: int64(len(newBinary))},
+		}}
+	}
+	manifest, err := Sign(m, priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/manifest.json", func(w http.ResponseWriter, r *http.Request) { w.Write(manifest) })
+	mux.HandleFunc("/bin", func(w http.ResponseWriter, r *http.Request) { w.Write(newBinary) })
+	srv := httptest.NewTLSServer(mux)
+	t.Cleanup(srv.Close)
+	return &Updater{
+		ManifestURL: srv.URL + "/manifest.json",
+		PublicKey:   pub,
+		Client:      srv.Client(),
+		GOOS:        "linux",
+		GOARCH:      "amd64",
+	}, priv
+}
+
+func installed(t *testing.T) string {
+	t.Helper()
+	exe := filepath.Join(t.TempDir(), "selfup")
+	if err := os.WriteFile(exe, []byte("old"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return exe
+}
+
+func read(t *testing.T, path string) string {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(data)
+}
+
+func TestCheck(t *testing.T) {
+	u, _ := release(t, nil)
+	for current, want := range map[string]bool{"v1.0.0": true, "v1.1.0": false, "v2.0.0": false, "dev": false} {
+		rel, err := u.Check(context.Background(), current)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := rel != nil; got != want {
+			t.Errorf("Check(%q) found an update: %v; want %v", current, got, want)
+		}
+	}
+	rel, _ := u.Check(context.Background(), "v1.0.0")
+	if !strings.HasPrefix(rel.Asset.URL, "https://") || !strings.HasSuffix(rel.Asset.URL, "/bin") {
+		t.Errorf("asset URL = %q; want it resolved against the manifest's", rel.Asset.URL)
+	}
+
+	u.GOOS = "plan9"
+	if _, err := u.Check(context.Background(), "v1.0.0"); err == nil {
+		t.Error("no error for a platform without a binary")
+	}
+}
+
+func TestCheck_RejectsWrongKeyAndPlainHTTP(t *testing.T) {
+	u, _ := release(t, nil)
+	other, _, _ := ed25519.GenerateKey(nil)
+	u.PublicKey = other
+	if _, err := u.Check(context.Background(), "v1.0.0"); !errors.Is(err, ErrBadSignature) {
+		t.Errorf("err = %v; want ErrBadSignature", err)
+	}
+
+	u.ManifestURL = "http://releases.example.com/manifest.json"
+	if _, err := u.Check(context.Background(), "v1.0.0"); err == nil || !strings.Contains(err.Error(), "https") {
+		t.Errorf("err = %v; want plain HTTP refused", err)
+	}
+	for _, ok := range []string{"http://localhost:8000/m", "http://127.0.0.1/m", "http://[::1]/m", "https://x.example/m"} {
+		if _, err := checkURL(ok); err != nil {
+			t.Errorf("checkURL(%q) = %v", ok, err)
+		}
+	}
+}
+
+func TestParseManifest_Tampered(t *testing.T) {
+	pub, priv, _ := ed25519.GenerateKey(nil)
+	data, _ := Sign(&Manifest{Version: "v1.1.0"}, priv)
+	// The payload is base64 in JSON; change a character of it.
+	i := strings.Index(string(data), `"payload": "`) + len(`"payload": "`) + 4
+	tampered := []byte(string(data[:i]) + string(data[i]^1) + string(data[i+1:]))
+	if _, err := ParseManifest(tampered, pub); err == nil {
+		t.Error("tampered manifest accepted")
+	}
+	if _, err := ParseManifest(data, nil); err == nil {
+		t.Error("manifest accepted without a key")
+	}
+}
+
+func TestApply_AndRollback(t *testing.T) {
+	u, _ := release(t, nil)
+	exe := installed(t)
+	rel, err := u.Check(context.Background(), "v1.0.0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := u.Apply(context.Background(), rel, exe, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, exe); got != string(newBinary) {
+		t.Errorf("binary after update = %q", got)
+	}
+	if got := read(t, exe+".old"); got != "old" {
+		t.Errorf("backup = %q; want the old binary", got)
+	}
+	if fi, _ := os.Stat(exe); fi.Mode().Perm()&0o100 == 0 {
+		t.Errorf("updated binary has mode %v; want it executable", fi.Mode())
+	}
+
+	if err := Rollback(exe); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, exe); got != "old" {
+		t.Errorf("binary after rollback = %q", got)
+	}
+	if err := Rollback(exe); err == nil {
+		t.Error("second rollback succeeded; there is nothing left to go back to")
+	}
+}
+
+func TestApply_DryRun(t *testing.T) {
+	u, _ := release(t, nil)
+	exe := installed(t)
+	rel, _ := u.Check(context.Background(), "v1.0.0")
+	if err := u.Apply(context.Background(), rel, exe, Options{DryRun: true}); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := os.ReadDir(filepath.Dir(exe))
+	if got := read(t, exe); got != "old" || len(entries) != 1 {
+		t.Errorf("dry run left %q and %d files; want the old binary alone", got, len(entries))
+	}
+}
+
+func TestApply_UpperCaseChecksum(t *testing.T) {
+	u, _ := release(t, &Manifest{Version: "v1.1.0", Assets: []Asset{
+		{OS: "linux", Arch: "amd64", URL: "bin", SHA256: strings.ToUpper(sum(newBinary))},
+	}})
+	exe := installed(t)
+	rel, _ := u.Check(context.Background(), "v1.0.0")
+	if err := u.Apply(context.Background(), rel, exe, Options{}); err != nil {
+		t.Fatal(err)
+	}
+	if got := read(t, exe); got != string(newBinary) {
+		t.Errorf("binary = %q; want the new one", got)
+	}
+}
+
+func TestApply_ChecksumMismatch(t *testing.T) {
+	u, _ := release(t, &Manifest{Version: "v1.1.0", Assets: []Asset{
+		{OS: "linux", Arch: "amd64", URL: "bin", SHA256: sum([]byte("something else"))},
+	}})
+	exe := installed(t)
+	rel, _ := u.Check(context.Background(), "v1.0.0")
+	err := u.Apply(context.Background(), rel, exe, Options{})
+	if err == nil || !strings.Contains(err.Error(), "SHA-256") {
+		t.Fatalf("err = %v; want a checksum error", err)
+	}
+	if got := read(t, exe); got != "old" {
+		t.Errorf("binary = %q after a failed update", got)
+	}
+	if _, err := os.Stat(exe + ".new"); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("download left behind: %v", err)
+	}
+}
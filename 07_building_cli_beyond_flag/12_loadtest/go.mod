@@ -0,0 +1,8 @@
+module golang_roadmap/07_building_cli_beyond_flag/12_loadtest
+
+go 1.24.11
+
+require golang_roadmap/04_Tooling_testing_and_code_quality/11_stats v0.0.0
+
+// The stats package lives in its own module in this repository.
+replace golang_roadmap/04_Tooling_testing_and_code_quality/11_stats => ../../04_Tooling_testing_and_code_quality/11_stats
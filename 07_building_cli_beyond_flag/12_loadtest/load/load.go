@@ -0,0 +1,218 @@
+// Package load fires HTTP requests at a target from a pool of workers and
+// measures how it answers, in the way of hey and vegeta.
+//
+// Run starts Concurrency workers. A feeder hands them one token per
+// request: as fast as they take them, or at a fixed Rate. The run ends
+// after Requests requests, after Duration, or when ctx is canceled,
+// whichever comes first, and Run returns a Report of everything that
+// finished.
+package load
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptrace"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Config describes a run.
+type Config struct {
+	URL     string
+	Method  string // default GET
+	Header  http.Header
+	Body    []byte
+	Timeout time.Duration // per request; default 10s
+
+	// Concurrency is the number of workers, and so the most requests in
+	// flight at once. Default 10.
+	Concurrency int
+	// Requests is the total number of requests. Zero means no limit, for a
+	// run bounded by Duration or ctx.
+	Requests int
+	// Duration bounds the run. Requests in flight when it ends are
+	// allowed to finish.
+	Duration time.Duration
+	// Rate is the number of requests per second, across all workers. Zero
+	// means as fast as the workers go.
+	Rate float64
+
+	// DisableKeepAlives opens a new connection for every request, to see
+	// what the handshakes cost.
+	DisableKeepAlives bool
+	// Client replaces the client Run builds from the settings above.
+	Client *http.Client
+}
+
+// Result is one request.
+type Result struct {
+	Start   time.Duration // since the run started
+	Latency time.Duration // until the body was read
+	Status  int           // 0 if the request failed
+	Bytes   int64         // body bytes read
+	Reused  bool          // sent on a kept-alive connection
+	Err     string        // why it failed
+}
+
+// Run performs the run described by cfg. It returns an error only for a
+// bad Config; failed requests are part of the Report.
+func Run(ctx context.Context, cfg Config) (*Report, error) {
+	if err := cfg.setDefaults(); err != nil {
+		return nil, err
+	}
+	client := cfg.Client
+	if client == nil {
+		client = newClient(cfg)
+	}
+
+	// The feeder stops at the deadline; requests already sent keep ctx,
+	// so they finish instead of being counted as errors.
+	feedCtx := ctx
+	if cfg.Duration > 0 {
+		var cancel context.CancelFunc
+		feedCtx, cancel = context.WithTimeout(ctx, cfg.Duration)
+		defer cancel()
+	}
+	tokens := make(chan struct{})
+	go feed(feedCtx, tokens, cfg.Requests, cfg.Rate)
+
+	start := time.Now()
+	perWorker := make([][]Result, cfg.Concurrency)
+	var wg sync.WaitGroup
+	for i := range perWorker {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range tokens {
+				perWorker[i] = append(perWorker[i], do(ctx, client, cfg, start))
+			}
+		}()
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+
+	var results []Result
+	for _, rs := range perWorker {
+		results = append(results, rs...)
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Start < results[j].Start })
+	return newReport(results, elapsed), nil
+}
+
+func (cfg *Config) setDefaults() error {
+	if cfg.URL == "" {
+		return errors.New("load: no URL")
+	}
+	if cfg.Requests < 0 || cfg.Duration < 0 || cfg.Rate < 0 || cfg.Concurrency < 0 {
+		return errors.New("load: negative Requests, Duration, Rate or Concurrency")
+	}
+	if cfg.Requests == 0 && cfg.Duration == 0 {
+		return errors.New("load: set Requests, Duration or both")
+	}
+	if cfg.Method == "" {
+		cfg.Method = http.MethodGet
+	}
+	if cfg.Timeout == 0 {
+		cfg.Timeout = 10 * time.Second
+	}
+	if cfg.Concurrency == 0 {
+		cfg.Concurrency = 10
+	}
+	if cfg.Requests > 0 && cfg.Concurrency > cfg.Requests {
+		cfg.Concurrency = cfg.Requests
+	}
+	return nil
+}
+
+// newClient keeps one idle connection per worker. The default transport
+// keeps only two per host, so with more workers most connections would
+// be closed after each request and reopened for the next one, and the
+// test would measure TCP handshakes.
+func newClient(cfg Config) *http.Client {
+	t := http.DefaultTransport.(*http.Transport).Clone()
+	t.MaxIdleConns = cfg.Concurrency
+	t.MaxIdleConnsPerHost = cfg.Concurrency
+	t.DisableKeepAlives = cfg.DisableKeepAlives
+	return &http.Client{Transport: t, Timeout: cfg.Timeout}
+}
+
+// feed sends one token per request, paced by rate, and closes tokens when
+// the run is over.
+func feed(ctx context.Context, tokens chan<- struct{}, requests int, rate float64) {
+	defer close(tokens)
+	var tick <-chan time.Time
+	if rate > 0 {
+		t := time.NewTicker(time.Duration(float64(time.Second) / rate))
+		defer t.Stop()
+		tick = t.C
+	}
+	for i := 0; requests == 0 || i < requests; i++ {
+		if tick != nil && i > 0 {
+			select {
+			case <-tick:
+			case <-ctx.Done():
+				return
+			}
+		}
+		select {
+		case tokens <- struct{}{}:
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func do(ctx context.Context, client *http.Client, cfg Config, runStart time.Time) Result {
+	var res Result
+	req, err := http.NewRequestWithContext(ctx, cfg.Method, cfg.URL, bytes.NewReader(cfg.Body))
+	if err != nil {
+		res.Err = err.Error()
+		return res
+	}
+	for k, vs := range cfg.Header {
+		req.Header[k] = vs
+	}
+	trace := &httptrace.ClientTrace{GotConn: func(info httptrace.GotConnInfo) { res.Reused = info.Reused }}
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), trace))
+
+	start := time.Now()
+	res.Start = start.Sub(runStart)
+	resp, err := client.Do(req)
+	if err != nil {
+		res.Latency = time.Since(start)
+		res.Err = errorClass(err)
+		return res
+	}
+	// Reading the body to the end is what lets the connection be reused.
+	res.Bytes, err = io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+	res.Latency = time.Since(start)
+	res.Status = resp.StatusCode
+	if err != nil {
+		res.Status = 0
+		res.Err = fmt.Sprintf("reading body: %v", errorClass(err))
+	}
+	return res
+}
+
+// errorClass shortens an error to what it has in common with others of
+// its kind: "connection refused" rather than the whole dial error with
+// its port numbers, so the report can group them.
+func errorClass(err error) string {
+	var nerr interface{ Timeout() bool }
+	switch {
+	case errors.Is(err, context.Canceled):
+		return "canceled"
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &nerr) && nerr.Timeout():
+		return "timeout"
+	}
+	for u := errors.Unwrap(err); u != nil; u = errors.Unwrap(err) {
+		err = u
+	}
+	return err.Error()
+}
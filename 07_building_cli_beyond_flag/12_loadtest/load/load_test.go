@@ -0,0 +1,168 @@
+package load
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestRun(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	var n atomic.Int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		cur := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for p := peak.Load(); cur > p && !peak.CompareAndSwap(p, cur); p = peak.Load() {
+		}
+		if r.Header.Get("X-Test") != "yes" || r.Method != http.MethodPost {
+			http.Error(w, "bad request", http.StatusBadRequest)
+			return
+		}
+		time.Sleep(2 * time.Millisecond)
+		if n.Add(1)%10 == 0 {
+			http.Error(w, "no", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte("ok"))
+	}))
+	defer ts.Close()
+
+	r, err := Run(context.Background(), Config{
+		URL: ts.URL, Method: http.MethodPost, Header: http.Header{"X-Test": {"yes"}}, Body: []byte("{}"),
+		Requests: 100, Concurrency: 5,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Requests != 100 || len(r.Results) != 100 || r.Errors != 0 {
+		t.Fatalf("Requests, Results, Errors = %d, %d, %d; want 100, 100, 0", r.Requests, len(r.Results), r.Errors)
+	}
+	if r.StatusCodes[200] != 90 || r.StatusCodes[503] != 10 {
+		t.Errorf("StatusCodes = %v; want 90 200s and 10 503s", r.StatusCodes)
+	}
+	if p := peak.Load(); p > 5 {
+		t.Errorf("%d requests in flight at once; Concurrency is 5", p)
+	}
+	// One connection per worker, kept alive for the rest.
+	if r.NewConns > 5 || r.ReusedConns < 95 {
+		t.Errorf("NewConns, ReusedConns = %d, %d; want at most 5 new", r.NewConns, r.ReusedConns)
+	}
+	if r.Latency.Min < 2*time.Millisecond || r.Latency.P50 > r.Latency.P99 || r.Latency.P99 > r.Latency.Max {
+		t.Errorf("implausible latency: %+v", r.Latency)
+	}
+	for i := 1; i < len(r.Results); i++ {
+		if r.Results[i].Start < r.Results[i-1].Start {
+			t.Fatal("Results are not sorted by start time")
+		}
+	}
+}
+
+func TestRun_DisableKeepAlives(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	r, err := Run(context.Background(), Config{URL: ts.URL, Requests: 20, Concurrency: 2, DisableKeepAlives: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.NewConns != 20 || r.ReusedConns != 0 {
+		t.Errorf("NewConns, ReusedConns = %d, %d; want 20, 0", r.NewConns, r.ReusedConns)
+	}
+}
+
+func TestRun_Rate(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer ts.Close()
+	// 11 requests at 100/s: the first at once, then one every 10ms.
+	r, err := Run(context.Background(), Config{URL: ts.URL, Requests: 11, Concurrency: 4, Rate: 100})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Elapsed < 95*time.Millisecond {
+		t.Errorf("11 requests at 100/s took %v; want about 100ms", r.Elapsed)
+	}
+	if last := r.Results[10].Start; last < 95*time.Millisecond {
+		t.Errorf("last request started at %v; want about 100ms", last)
+	}
+}
+
+func TestRun_Duration(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+	}))
+	defer ts.Close()
+	r, err := Run(context.Background(), Config{URL: ts.URL, Duration: 100 * time.Millisecond, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Requests in flight at the deadline finish rather than fail.
+	if r.Errors != 0 || r.Requests < 4 || r.Requests > 10 {
+		t.Errorf("Requests, Errors = %d, %d; want about 8 and none", r.Requests, r.Errors)
+	}
+}
+
+func TestRun_Canceled(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+	}))
+	defer ts.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	r, err := Run(ctx, Config{URL: ts.URL, Requests: 1000, Concurrency: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Requests == 0 || r.Requests >= 1000 {
+		t.Errorf("Requests = %d; want the part that ran before cancel", r.Requests)
+	}
+}
+
+func TestRun_ConnectionRefused(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	r, err := Run(context.Background(), Config{URL: "http://" + addr, Requests: 3})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.Errors != 3 || r.ErrorCounts["connection refused"] != 3 {
+		t.Errorf("Errors = %d, %v; want 3 grouped as connection refused", r.Errors, r.ErrorCounts)
+	}
+	if len(r.Histogram) != 0 {
+		t.Errorf("failed requests in the latency histogram: %v", r.Histogram)
+	}
+}
+
+func TestRun_Timeout(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(200 * time.Millisecond)
+	}))
+	defer ts.Close()
+	r, err := Run(context.Background(), Config{URL: ts.URL, Requests: 2, Timeout: 20 * time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r.ErrorCounts["timeout"] != 2 {
+		t.Errorf("ErrorCounts = %v; want 2 timeouts", r.ErrorCounts)
+	}
+}
+
+func TestRun_BadConfig(t *testing.T) {
+	for _, cfg := range []Config{
+		{},
+		{URL: "http://x"},
+		{URL: "http://x", Requests: -1},
+		{URL: "http://x", Requests: 1, Rate: -5},
+	} {
+		if _, err := Run(context.Background(), cfg); err == nil {
+			t.Errorf("Run(%+v) succeeded", cfg)
+		}
+	}
+}
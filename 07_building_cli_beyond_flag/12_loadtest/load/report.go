@@ -0,0 +1,240 @@
+package load
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/11_stats/stats"
+)
+
+// Report summarizes a run.
+type Report struct {
+	Requests int           // requests that finished, successfully or not
+	Errors   int           // requests with no response
+	Elapsed  time.Duration // wall time of the run
+	RPS      float64       // Requests / Elapsed
+	BytesIn  int64
+
+	Latency   Latency
+	Histogram []Bucket
+
+	StatusCodes map[int]int
+	ErrorCounts map[string]int
+	NewConns    int // requests that opened a connection
+	ReusedConns int // requests sent on a kept-alive one
+
+	Results []Result // every request, by start time
+}
+
+// Latency is the latency distribution of the requests that got a
+// response. Failed requests are left out: a refused connection is fast,
+// and would make the target look better than it is.
+type Latency struct {
+	Min, Mean, Max     time.Duration
+	StdDev             time.Duration
+	P50, P90, P95, P99 time.Duration
+}
+
+// Bucket counts latencies in [From, To).
+type Bucket struct {
+	From, To time.Duration
+	Count    int
+}
+
+// Latencies are recorded into a histogram with 3 significant digits, so
+// percentiles are within 0.1%, from 1ns up to an hour, in a fixed 270 KB
+// however long the run.
+const (
+	histHighest = int64(time.Hour)
+	histDigits  = 3
+)
+
+func newReport(results []Result, elapsed time.Duration) *Report {
+	r := &Report{
+		Requests:    len(results),
+		Elapsed:     elapsed,
+		StatusCodes: map[int]int{},
+		ErrorCounts: map[string]int{},
+		Results:     results,
+	}
+	if elapsed > 0 {
+		r.RPS = float64(len(results)) / elapsed.Seconds()
+	}
+	h := stats.MustHistogram(histHighest, histDigits)
+	for _, res := range results {
+		r.BytesIn += res.Bytes
+		if res.Reused {
+			r.ReusedConns++
+		} else if res.Status != 0 {
+			r.NewConns++
+		}
+		if res.Status == 0 {
+			r.Errors++
+			r.ErrorCounts[res.Err]++
+			continue
+		}
+		r.StatusCodes[res.Status]++
+		h.Record(int64(res.Latency))
+	}
+	r.Latency, r.Histogram = latencies(h, 10)
+	return r
+}
+
+func latencies(h *stats.Histogram, buckets int) (Latency, []Bucket) {
+	if h.Count() == 0 {
+		return Latency{}, nil
+	}
+	l := Latency{
+		Min:    time.Duration(h.Min()),
+		Max:    time.Duration(h.Max()),
+		Mean:   time.Duration(h.Mean()),
+		StdDev: time.Duration(h.StdDev()),
+		P50:    time.Duration(h.Percentile(50)),
+		P90:    time.Duration(h.Percentile(90)),
+		P95:    time.Duration(h.Percentile(95)),
+		P99:    time.Duration(h.Percentile(99)),
+	}
+	var hist []Bucket
+	for _, b := range h.Buckets(buckets) {
+		hist = append(hist, Bucket{From: time.Duration(b.From), To: time.Duration(b.To), Count: int(b.Count)})
+	}
+	return l, hist
+}
+
+// WriteText writes the report for a person.
+func (r *Report) WriteText(w io.Writer) error {
+	b := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(b, "Summary:\n")
+	fmt.Fprintf(b, "  Requests:\t%d (%d errors)\n", r.Requests, r.Errors)
+	fmt.Fprintf(b, "  Elapsed:\t%v\n", r.Elapsed.Round(time.Millisecond))
+	fmt.Fprintf(b, "  Rate:\t%.1f req/s\n", r.RPS)
+	fmt.Fprintf(b, "  Received:\t%d bytes\n", r.BytesIn)
+	fmt.Fprintf(b, "  Connections:\t%d new, %d reused\n", r.NewConns, r.ReusedConns)
+
+	if len(r.Histogram) > 0 {
+		l := r.Latency
+		fmt.Fprintf(b, "\nLatency:\n")
+		for _, row := range []struct {
+			name string
+			d    time.Duration
+		}{{"min", l.Min}, {"mean", l.Mean}, {"stddev", l.StdDev}, {"p50", l.P50}, {"p90", l.P90}, {"p95", l.P95}, {"p99", l.P99}, {"max", l.Max}} {
+			fmt.Fprintf(b, "  %s\t%v\n", row.name, roundLatency(row.d))
+		}
+
+		fmt.Fprintf(b, "\nHistogram:\n")
+		most := 0
+		for _, bk := range r.Histogram {
+			most = max(most, bk.Count)
+		}
+		for _, bk := range r.Histogram {
+			bar := strings.Repeat("■", bk.Count*40/most)
+			fmt.Fprintf(b, "  %10v [%d]\t%s\n", roundLatency(bk.From), bk.Count, bar)
+		}
+	}
+
+	if len(r.StatusCodes) > 0 {
+		fmt.Fprintf(b, "\nStatus codes:\n")
+		for _, code := range sortedKeys(r.StatusCodes) {
+			fmt.Fprintf(b, "  %d\t%d\n", code, r.StatusCodes[code])
+		}
+	}
+	if len(r.ErrorCounts) > 0 {
+		fmt.Fprintf(b, "\nErrors:\n")
+		for _, e := range sortedKeys(r.ErrorCounts) {
+			fmt.Fprintf(b, "  %d\t%s\n", r.ErrorCounts[e], e)
+		}
+	}
+	return b.Flush()
+}
+
+func roundLatency(d time.Duration) time.Duration {
+	if d >= time.Second {
+		return d.Round(time.Millisecond)
+	}
+	return d.Round(time.Microsecond)
+}
+
+func sortedKeys[K int | string](m map[K]int) []K {
+	keys := make([]K, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Slice(keys, func(i, j int) bool { return keys[i] < keys[j] })
+	return keys
+}
+
+// jsonReport is the JSON form of Report: durations in milliseconds, and
+// no per-request results, which WriteCSV is for.
+type jsonReport struct {
+	Requests    int            `json:"requests"`
+	Errors      int            `json:"errors"`
+	ElapsedMS   float64        `json:"elapsed_ms"`
+	RPS         float64        `json:"rps"`
+	BytesIn     int64          `json:"bytes_in"`
+	NewConns    int            `json:"new_conns"`
+	ReusedConns int            `json:"reused_conns"`
+	LatencyMS   map[string]any `json:"latency_ms"`
+	Histogram   []jsonBucket   `json:"histogram"`
+	StatusCodes map[string]int `json:"status_codes"`
+	ErrorCounts map[string]int `json:"errors_by_kind"`
+}
+
+type jsonBucket struct {
+	FromMS float64 `json:"from_ms"`
+	ToMS   float64 `json:"to_ms"`
+	Count  int     `json:"count"`
+}
+
+func ms(d time.Duration) float64 { return math.Round(float64(d)/1e3) / 1e3 }
+
+// WriteJSON writes the summary as one JSON object.
+func (r *Report) WriteJSON(w io.Writer) error {
+	l := r.Latency
+	out := jsonReport{
+		Requests: r.Requests, Errors: r.Errors,
+		ElapsedMS: ms(r.Elapsed), RPS: math.Round(r.RPS*10) / 10, BytesIn: r.BytesIn,
+		NewConns: r.NewConns, ReusedConns: r.ReusedConns,
+		LatencyMS: map[string]any{
+			"min": ms(l.Min), "mean": ms(l.Mean), "stddev": ms(l.StdDev), "max": ms(l.Max),
+			"p50": ms(l.P50), "p90": ms(l.P90), "p95": ms(l.P95), "p99": ms(l.P99),
+		},
+		Histogram:   []jsonBucket{},
+		StatusCodes: map[string]int{},
+		ErrorCounts: r.ErrorCounts,
+	}
+	for _, bk := range r.Histogram {
+		out.Histogram = append(out.Histogram, jsonBucket{ms(bk.From), ms(bk.To), bk.Count})
+	}
+	for code, n := range r.StatusCodes {
+		out.StatusCodes[strconv.Itoa(code)] = n
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// WriteCSV writes one row per request, for a spreadsheet or a plot.
+func (r *Report) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"start_ms", "latency_ms", "status", "bytes", "reused", "error"})
+	for _, res := range r.Results {
+		cw.Write([]string{
+			strconv.FormatFloat(ms(res.Start), 'f', 3, 64),
+			strconv.FormatFloat(ms(res.Latency), 'f', 3, 64),
+			strconv.Itoa(res.Status),
+			strconv.FormatInt(res.Bytes, 10),
+			strconv.FormatBool(res.Reused),
+			res.Err,
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,109 @@
+package load
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/11_stats/stats"
+)
+
+func TestLatencies(t *testing.T) {
+	h := stats.MustHistogram(histHighest, histDigits)
+	for _, d := range []time.Duration{30, 10, 20, 10, 110} {
+		h.Record(int64(d))
+	}
+	l, hist := latencies(h, 10)
+	if l.Min != 10 || l.Max != 110 || l.Mean != 36 || l.P50 != 20 || l.P99 != 110 {
+		t.Errorf("Latency = %+v", l)
+	}
+	total := 0
+	for _, b := range hist {
+		total += b.Count
+	}
+	if len(hist) != 10 || total != 5 || hist[0].Count != 2 || hist[9].Count != 1 {
+		t.Errorf("histogram = %v", hist)
+	}
+
+	// Percentiles of large latencies are within the histogram's 0.1%.
+	h.Reset()
+	for i := 1; i <= 100; i++ {
+		h.Record(int64(time.Duration(i) * time.Millisecond))
+	}
+	l, _ = latencies(h, 10)
+	if l.P95 < 95*time.Millisecond || l.P95 > 95095*time.Microsecond {
+		t.Errorf("p95 of 1..100ms = %v; want 95ms within 0.1%%", l.P95)
+	}
+}
+
+func testReport() *Report {
+	return newReport([]Result{
+		{Start: 0, Latency: 2 * time.Millisecond, Status: 200, Bytes: 2},
+		{Start: time.Millisecond, Latency: 4 * time.Millisecond, Status: 200, Bytes: 2, Reused: true},
+		{Start: 2 * time.Millisecond, Latency: time.Millisecond, Err: "connection refused"},
+	}, 10*time.Millisecond)
+}
+
+func TestReport(t *testing.T) {
+	r := testReport()
+	if r.Requests != 3 || r.Errors != 1 || r.RPS != 300 || r.BytesIn != 4 || r.NewConns != 1 || r.ReusedConns != 1 {
+		t.Errorf("report = %+v", r)
+	}
+
+	var text bytes.Buffer
+	if err := r.WriteText(&text); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{"3 (1 errors)", "p99", "200  2", "1  connection refused", "1 new, 1 reused", "stddev"} {
+		if !strings.Contains(text.String(), want) {
+			t.Errorf("text report lacks %q:\n%s", want, text.String())
+		}
+	}
+}
+
+func TestReport_JSON(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().WriteJSON(&buf); err != nil {
+		t.Fatal(err)
+	}
+	var got struct {
+		Requests    int                `json:"requests"`
+		Latency     map[string]float64 `json:"latency_ms"`
+		StatusCodes map[string]int     `json:"status_codes"`
+		Errors      map[string]int     `json:"errors_by_kind"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	if got.Requests != 3 || got.Latency["p99"] != 4 || got.StatusCodes["200"] != 2 || got.Errors["connection refused"] != 1 {
+		t.Errorf("JSON report = %s", buf.String())
+	}
+}
+
+func TestReport_CSV(t *testing.T) {
+	var buf bytes.Buffer
+	if err := testReport().WriteCSV(&buf); err != nil {
+		t.Fatal(err)
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := [][]string{
+		{"start_ms", "latency_ms", "status", "bytes", "reused", "error"},
+		{"0.000", "2.000", "200", "2", "false", ""},
+		{"1.000", "4.000", "200", "2", "true", ""},
+		{"2.000", "1.000", "0", "0", "false", "connection refused"},
+	}
+	if len(rows) != len(want) {
+		t.Fatalf("CSV rows = %q", rows)
+	}
+	for i := range want {
+		if strings.Join(rows[i], ",") != strings.Join(want[i], ",") {
+			t.Errorf("row %d = %q; want %q", i, rows[i], want[i])
+		}
+	}
+}
@@ -0,0 +1,133 @@
+// Demonstrates an HTTP load tester, in the way of hey and vegeta.
+//
+// This example shows:
+// - A worker pool fed by a pacer, for a fixed concurrency or a fixed rate
+// - Stopping after N requests, after a duration, or on Ctrl-C with a partial report
+// - Latency percentiles (p50/p95/p99) and a histogram
+// - Connection reuse, and what turning keep-alive off costs
+// - Text, JSON and CSV reports
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"golang_roadmap/07_building_cli_beyond_flag/12_loadtest/load"
+)
+
+const usage = `usage: lt [flags] [URL]
+
+Sends requests to URL and reports latency and status codes. Without a URL,
+lt starts a local demo server and tests that.
+
+`
+
+// headers is a repeatable -H flag.
+type headers http.Header
+
+func (h headers) String() string { return fmt.Sprint(len(h), " headers") }
+
+func (h headers) Set(s string) error {
+	k, v, ok := strings.Cut(s, ":")
+	if !ok {
+		return fmt.Errorf("header %q: want Key: Value", s)
+	}
+	http.Header(h).Add(strings.TrimSpace(k), strings.TrimSpace(v))
+	return nil
+}
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	os.Exit(run(ctx, os.Args[1:], os.Stdout, os.Stderr))
+}
+
+func run(ctx context.Context, args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("lt", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	var cfg load.Config
+	fs.IntVar(&cfg.Requests, "n", 200, "number of requests; 0 for no limit (with -z)")
+	fs.IntVar(&cfg.Concurrency, "c", 10, "number of workers")
+	fs.DurationVar(&cfg.Duration, "z", 0, "stop after this long, e.g. 30s")
+	fs.Float64Var(&cfg.Rate, "q", 0, "requests per second across all workers; 0 for no limit")
+	fs.StringVar(&cfg.Method, "m", "GET", "HTTP method")
+	body := fs.String("d", "", "request body")
+	hdr := headers{}
+	fs.Var(hdr, "H", "request header `Key: Value` (repeatable)")
+	fs.DurationVar(&cfg.Timeout, "t", 10*time.Second, "timeout of each request")
+	fs.BoolVar(&cfg.DisableKeepAlives, "disable-keepalive", false, "open a new connection for every request")
+	format := fs.String("o", "text", "report format: text, json, or csv (one row per request)")
+	fs.Usage = func() {
+		fmt.Fprint(stderr, usage)
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		if err == flag.ErrHelp {
+			return 0
+		}
+		return 2
+	}
+	if fs.NArg() > 1 {
+		fs.Usage()
+		return 2
+	}
+	write := map[string]func(*load.Report, io.Writer) error{
+		"text": (*load.Report).WriteText,
+		"json": (*load.Report).WriteJSON,
+		"csv":  (*load.Report).WriteCSV,
+	}[*format]
+	if write == nil {
+		fmt.Fprintf(stderr, "lt: unknown report format %q\n", *format)
+		return 2
+	}
+	cfg.Header = http.Header(hdr)
+	cfg.Body = []byte(*body)
+
+	cfg.URL = fs.Arg(0)
+	if cfg.URL == "" {
+		srv := httptest.NewServer(demoHandler())
+		defer srv.Close()
+		cfg.URL = srv.URL
+		fmt.Fprintf(stderr, "lt: no URL; testing a demo server at %s\n", srv.URL)
+	}
+
+	report, err := load.Run(ctx, cfg)
+	if err != nil {
+		fmt.Fprintln(stderr, "lt:", err)
+		return 2
+	}
+	if ctx.Err() != nil {
+		fmt.Fprintln(stderr, "lt: interrupted; reporting the requests that finished")
+	}
+	if err := write(report, stdout); err != nil {
+		fmt.Fprintln(stderr, "lt:", err)
+		return 2
+	}
+	return 0
+}
+
+// demoHandler answers in 1-5ms, with a slow tail: 2% of requests take
+// 50ms and 1% fail with 503.
+func demoHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		d := time.Millisecond + rand.N(4*time.Millisecond)
+		switch p := rand.IntN(100); {
+		case p < 1:
+			http.Error(w, "overloaded", http.StatusServiceUnavailable)
+			return
+		case p < 3:
+			d = 50 * time.Millisecond
+		}
+		time.Sleep(d)
+		fmt.Fprintf(w, "served in %v\n", d)
+	})
+}
@@ -0,0 +1,49 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func lt(t *testing.T, args ...string) (code int, stdout, stderr string) {
+	t.Helper()
+	var out, errOut bytes.Buffer
+	code = run(context.Background(), args, &out, &errOut)
+	return code, out.String(), errOut.String()
+}
+
+func TestRun(t *testing.T) {
+	var got http.Header
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { got = r.Header.Clone() }))
+	defer ts.Close()
+
+	code, stdout, stderr := lt(t, "-n", "5", "-c", "2", "-H", "Authorization: Bearer t", "-o", "json", ts.URL)
+	if code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr)
+	}
+	var report struct{ Requests int }
+	if err := json.Unmarshal([]byte(stdout), &report); err != nil || report.Requests != 5 {
+		t.Fatalf("report = %s (%v)", stdout, err)
+	}
+	if got.Get("Authorization") != "Bearer t" {
+		t.Errorf("header not sent: %v", got)
+	}
+}
+
+func TestRun_Usage(t *testing.T) {
+	for _, args := range [][]string{
+		{"-o", "xml"},
+		{"-H", "no colon", "http://x"},
+		{"http://a", "http://b"},
+		{"-n", "0", "http://x"},
+	} {
+		if code, _, _ := lt(t, args...); code != 2 {
+			t.Errorf("lt %s: exit %d; want 2", strings.Join(args, " "), code)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+// Package api holds the JSON types of the users API. The server encodes
+// them and usersclient decodes them, so a field renamed on one side is
+// renamed on the other; the contract tests catch what the types cannot,
+// such as status codes and headers.
+package api
+
+// User is a user as the API returns it.
+type User struct {
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+}
+
+// NewUser is the body of POST /users.
+type NewUser struct {
+	Name string `json:"name"`
+}
+
+// ImportResult is the 201 body of POST /users/import.
+type ImportResult struct {
+	Imported int    `json:"imported"`
+	Users    []User `json:"users"`
+}
+
+// RowError is one problem with one row of an import. Row is the line's
+// record number in the file, counting the header as 1.
+type RowError struct {
+	Row     int    `json:"row"`
+	Field   string `json:"field,omitempty"`
+	Message string `json:"message"`
+}
+
+// ImportError is the 422 body of POST /users/import: the import was
+// refused, and Errors lists every invalid row.
+type ImportError struct {
+	Error  string     `json:"error"`
+	Errors []RowError `json:"errors"`
+}
+
+// Pagination of GET /users. Without these parameters the whole list is
+// returned. With them, at most Limit users with IDs above After, and a
+// Link header with rel="next" when there are more.
+const (
+	ParamLimit = "limit"
+	ParamAfter = "after"
+	// MaxLimit is the largest page.
+	MaxLimit = 1000
+	// DefaultLimit is the page size when only after is given.
+	DefaultLimit = 100
+)
@@ -1,3 +1,74 @@
 module golang_roadmap/08_web_development/01_net_http
 
 go 1.24.11
+
+require (
+	github.com/xuri/excelize/v2 v2.9.1
+	golang.org/x/text v0.28.0
+	golang_roadmap/02_core_language/22_functional_options v0.0.0
+	golang_roadmap/04_Tooling_testing_and_code_quality/12_vcr v0.0.0
+	golang_roadmap/08_web_development/03_i18n v0.0.0
+	golang_roadmap/08_web_development/04_validation v0.0.0
+	golang_roadmap/08_web_development/06_images v0.0.0
+	golang_roadmap/08_web_development/15_cors v0.0.0
+	golang_roadmap/08_web_development/17_hardening v0.0.0
+	golang_roadmap/08_web_development/19_api_keys v0.0.0
+	golang_roadmap/08_web_development/21_idempotency v0.0.0
+	golang_roadmap/10_messaging/05_jobs v0.0.0
+	golang_roadmap/11_configuration/01_config_loader v0.0.0
+	golang_roadmap/12_operations/01_health v0.0.0
+	golang_roadmap/12_operations/03_retry v0.0.0
+	golang_roadmap/12_operations/05_runtime_metrics v0.0.0
+	golang_roadmap/13_concurrency/01_cache v0.0.0
+)
+
+require (
+	github.com/BurntSushi/toml v1.6.0 // indirect
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	github.com/richardlehane/mscfb v1.0.4 // indirect
+	github.com/richardlehane/msoleps v1.0.4 // indirect
+	github.com/tiendc/go-deepcopy v1.6.0 // indirect
+	github.com/xuri/efp v0.0.1 // indirect
+	github.com/xuri/nfp v0.0.1 // indirect
+	golang.org/x/crypto v0.38.0 // indirect
+	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
+	golang.org/x/net v0.40.0 // indirect
+	golang.org/x/sync v0.16.0 // indirect
+	golang.org/x/sys v0.34.0 // indirect
+	golang_roadmap/02_core_language/21_struct_tags v0.0.0 // indirect
+	golang_roadmap/04_Tooling_testing_and_code_quality/07_clock v0.0.0 // indirect
+	golang_roadmap/04_Tooling_testing_and_code_quality/11_stats v0.0.0 // indirect
+	gopkg.in/yaml.v3 v3.0.1 // indirect
+	modernc.org/libc v1.66.3 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.11.0 // indirect
+	modernc.org/sqlite v1.38.2 // indirect
+)
+
+// The i18n, validate, imaging, cors, harden, apikeys, idempotency, config,
+// envtag, jobs, clock, health, debugvars, stats, cache, httpclient, retry
+// and vcr packages live in their own modules in this repository.
+replace (
+	golang_roadmap/02_core_language/21_struct_tags => ../../02_core_language/21_struct_tags
+	golang_roadmap/02_core_language/22_functional_options => ../../02_core_language/22_functional_options
+	golang_roadmap/04_Tooling_testing_and_code_quality/07_clock => ../../04_Tooling_testing_and_code_quality/07_clock
+	golang_roadmap/04_Tooling_testing_and_code_quality/11_stats => ../../04_Tooling_testing_and_code_quality/11_stats
+	golang_roadmap/04_Tooling_testing_and_code_quality/12_vcr => ../../04_Tooling_testing_and_code_quality/12_vcr
+	golang_roadmap/08_web_development/03_i18n => ../../08_web_development/03_i18n
+	golang_roadmap/08_web_development/04_validation => ../../08_web_development/04_validation
+	golang_roadmap/08_web_development/06_images => ../../08_web_development/06_images
+	golang_roadmap/08_web_development/15_cors => ../../08_web_development/15_cors
+	golang_roadmap/08_web_development/17_hardening => ../../08_web_development/17_hardening
+	golang_roadmap/08_web_development/19_api_keys => ../../08_web_development/19_api_keys
+	golang_roadmap/08_web_development/21_idempotency => ../../08_web_development/21_idempotency
+	golang_roadmap/10_messaging/05_jobs => ../../10_messaging/05_jobs
+	golang_roadmap/11_configuration/01_config_loader => ../../11_configuration/01_config_loader
+	golang_roadmap/12_operations/01_health => ../../12_operations/01_health
+	golang_roadmap/12_operations/03_retry => ../../12_operations/03_retry
+	golang_roadmap/12_operations/05_runtime_metrics => ../../12_operations/05_runtime_metrics
+	golang_roadmap/13_concurrency/01_cache => ../../13_concurrency/01_cache
+)
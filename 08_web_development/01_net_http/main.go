@@ -2,78 +2,295 @@ package main
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/json"
+	"errors"
+	"expvar"
+	"flag"
+	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
+
+	"golang.org/x/text/language"
+
+	"golang_roadmap/08_web_development/01_net_http/api"
+	"golang_roadmap/08_web_development/03_i18n/i18n"
+	"golang_roadmap/08_web_development/04_validation/validate"
+	"golang_roadmap/08_web_development/06_images/imaging"
+	"golang_roadmap/08_web_development/15_cors/cors"
+	"golang_roadmap/08_web_development/17_hardening/harden"
+	"golang_roadmap/08_web_development/19_api_keys/apikeys"
+	"golang_roadmap/08_web_development/21_idempotency/idempotency"
+	"golang_roadmap/10_messaging/05_jobs/jobs"
+	"golang_roadmap/11_configuration/01_config_loader/config"
+	"golang_roadmap/12_operations/01_health/health"
+	"golang_roadmap/12_operations/05_runtime_metrics/debugvars"
+	"golang_roadmap/13_concurrency/01_cache/cache"
 )
 
-type User struct {
-	ID   int    `json:"id"`
-	Name string `json:"name"`
-}
+// User is the stored user; its JSON form is shared with usersclient.
+type User = api.User
 
 var (
 	users  = []User{{ID: 1, Name: "Bob"}}
 	nextID = 2
 	mu     sync.Mutex
+
+	// queue runs background jobs, such as the welcome email for a new user.
+	queue *jobs.Queue
+
+	// userCache sits in front of the users store for GET /users/{id}.
+	// Users never change once created, so entries only leave by age or
+	// to make room.
+	userCache = cache.New(cache.Options[int, User]{
+		MaxEntries: 1000,
+		TTL:        time.Minute,
+		OnLoad: func(id int, took time.Duration, err error) {
+			slog.Debug("User cache load", "id", id, "took", took, "err", err)
+		},
+	})
+
+	errUserNotFound = errors.New("user not found")
 )
 
+// welcomeEmail is the payload of a send_welcome_email job.
+type welcomeEmail struct {
+	UserID int    `json:"user_id"`
+	Name   string `json:"name"`
+}
+
+// sendWelcomeEmail stands in for talking to a mail server. It runs in a
+// queue worker, after the request that created the user has returned.
+func sendWelcomeEmail(ctx context.Context, job *jobs.Job) error {
+	var p welcomeEmail
+	if err := job.Decode(&p); err != nil {
+		return fmt.Errorf("decode: %w", jobs.ErrPermanent)
+	}
+	log.Printf("Sending welcome email to user %d (%s), attempt %d", p.UserID, p.Name, job.Attempt)
+	return nil
+}
+
 // loggingMiddleware wraps handlers to log requests
 func loggingMiddleware(next http.HandlerFunc) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		start := time.Now()
-		log.Printf("Started %s %s", r.Method, r.URL.Path)
+		slog.Debug("Started", "method", r.Method, "path", r.URL.Path)
 		next(w, r)
 		log.Printf("Completed %s %s in %v", r.Method, r.URL.Path, time.Since(start))
 	}
 }
 
-// getUsersHandler returns all users as JSON
+// requireAPIKey rejects write requests without the configured bearer
+// token. The key is read from cfg on every request, so rotating it with a
+// config reload (SIGHUP) takes effect immediately. An empty key disables
+// the check.
+func requireAPIKey(cfg *config.Manager, next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		key := cfg.Current().Auth.APIKey.Reveal()
+		if key != "" && r.Method != http.MethodGet {
+			got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(key)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				i18n.Error(w, r, "Unauthorized", http.StatusUnauthorized)
+				return
+			}
+		}
+		next(w, r)
+	}
+}
+
+// requireAdmin rejects requests of any method without the configured
+// bearer token. It guards key management, which must not be reachable
+// with an API key.
+func requireAdmin(cfg *config.Manager, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := cfg.Current().Auth.APIKey.Reveal()
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if key == "" || !ok || subtle.ConstantTimeCompare([]byte(got), []byte(key)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			i18n.Error(w, r, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// newLogger builds the default logger from the log settings. The log
+// package's output goes through it too.
+func newLogger(c config.LogConfig) *slog.Logger {
+	level, _ := c.SlogLevel() // validated on load
+	opts := &slog.HandlerOptions{Level: level}
+	if c.Format == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, opts))
+}
+
+// getUsersHandler returns all users as JSON, or one page of them with
+// ?limit=N&after=ID. Pages are ordered by ID, and a Link header points to
+// the next one, so clients follow it instead of building URLs.
 func getUsersHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodGet {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		i18n.Error(w, r, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
+	q := r.URL.Query()
+	paged := q.Has(api.ParamLimit) || q.Has(api.ParamAfter)
+	limit, after := api.DefaultLimit, 0
+	if q.Has(api.ParamLimit) {
+		n, err := strconv.Atoi(q.Get(api.ParamLimit))
+		if err != nil || n < 1 || n > api.MaxLimit {
+			i18n.Error(w, r, "Invalid limit", http.StatusBadRequest)
+			return
+		}
+		limit = n
+	}
+	if q.Has(api.ParamAfter) {
+		n, err := strconv.Atoi(q.Get(api.ParamAfter))
+		if err != nil || n < 0 {
+			i18n.Error(w, r, "Invalid cursor", http.StatusBadRequest)
+			return
+		}
+		after = n
+	}
+
 	mu.Lock()
 	defer mu.Unlock()
 
+	page := users
+	if paged {
+		// users is in ID order: IDs are handed out in order and users are
+		// only appended.
+		start, _ := slices.BinarySearchFunc(users, after+1, func(u User, id int) int { return u.ID - id })
+		end := min(start+limit, len(users))
+		page = users[start:end]
+		if end < len(users) {
+			next := url.Values{api.ParamLimit: {strconv.Itoa(limit)}, api.ParamAfter: {strconv.Itoa(page[len(page)-1].ID)}}
+			w.Header().Set("Link", `</users?`+next.Encode()+`>; rel="next"`)
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	if err := json.NewEncoder(w).Encode(users); err != nil {
+	if err := json.NewEncoder(w).Encode(page); err != nil {
 		log.Printf("Error encoding users: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		i18n.Error(w, r, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
+// findUser looks a user up in the store. It is the cache's loader.
+func findUser(_ context.Context, id int) (User, error) {
+	mu.Lock()
+	defer mu.Unlock()
+	for _, u := range users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return User{}, errUserNotFound
+}
+
+// getUserHandler returns one user by ID, through the cache
+func getUserHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		i18n.Error(w, r, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	u, err := userCache.GetOrLoad(r.Context(), id, findUser)
+	if errors.Is(err, errUserNotFound) {
+		i18n.Error(w, r, "User not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("Error loading user %d: %v", id, err)
+		i18n.Error(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(w).Encode(u); err != nil {
+		log.Printf("Error encoding user: %v", err)
+	}
+}
+
+// avatarHandler serves a user's identicon as a PNG, ?size=16 to 512 pixels
+// (default 128). The image depends only on the ID, so browsers may keep it
+// for a day and then revalidate with its ETag.
+func avatarHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		i18n.Error(w, r, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	size, err := imaging.SizeParam(r, "size", 128, 16, 512)
+	if err != nil {
+		i18n.Error(w, r, "Invalid avatar size", http.StatusBadRequest)
+		return
+	}
+	if _, err := userCache.GetOrLoad(r.Context(), id, findUser); errors.Is(err, errUserNotFound) {
+		i18n.Error(w, r, "User not found", http.StatusNotFound)
+		return
+	} else if err != nil {
+		log.Printf("Error loading user %d: %v", id, err)
+		i18n.Error(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	imaging.Serve(w, r, imaging.Identicon("user:"+strconv.Itoa(id), size), "png", 24*time.Hour)
+}
+
+// validateUser checks a user from a request body, after cleaning.
+func validateUser(u User) error {
+	var v validate.Validator
+	v.String("name", u.Name).NonEmpty().MaxLen(100)
+	return v.Err()
+}
+
+// validationError writes a 400 with one line per invalid field, each
+// message in the client's language.
+func validationError(w http.ResponseWriter, r *http.Request, err error) {
+	p := i18n.FromContext(r.Context())
+	var lines []string
+	for _, fe := range validate.Fields(err) {
+		lines = append(lines, fe.Field+": "+p.Sprintf(fe.Format, fe.Args...))
+	}
+	http.Error(w, strings.Join(lines, "\n"), http.StatusBadRequest)
+}
+
 // createUserHandler creates a new user from JSON body
 func createUserHandler(w http.ResponseWriter, r *http.Request) {
 	if r.Method != http.MethodPost {
-		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		i18n.Error(w, r, "Method not allowed", http.StatusMethodNotAllowed)
 		return
 	}
 
 	// Check content type
 	contentType := r.Header.Get("Content-Type")
 	if contentType != "application/json" {
-		http.Error(w, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+		i18n.Error(w, r, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
 		return
 	}
 
 	var u User
 	if err := json.NewDecoder(r.Body).Decode(&u); err != nil {
 		log.Printf("Error decoding user: %v", err)
-		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		i18n.Error(w, r, "Invalid JSON", http.StatusBadRequest)
 		return
 	}
 
-	// Basic validation
-	if u.Name == "" {
-		http.Error(w, "Name is required", http.StatusBadRequest)
+	u.Name = validate.Clean(u.Name)
+	if err := validateUser(u); err != nil {
+		validationError(w, r, err)
 		return
 	}
 
@@ -83,38 +300,180 @@ func createUserHandler(w http.ResponseWriter, r *http.Request) {
 	users = append(users, u)
 	mu.Unlock()
 
+	// Sending email is slow and can fail; the job queue retries it in the
+	// background so the client does not wait for it.
+	if _, err := queue.Enqueue(r.Context(), "send_welcome_email", welcomeEmail{UserID: u.ID, Name: u.Name}); err != nil {
+		log.Printf("Error enqueueing welcome email for user %d: %v", u.ID, err)
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	if err := json.NewEncoder(w).Encode(u); err != nil {
 		log.Printf("Error encoding created user: %v", err)
-		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		i18n.Error(w, r, "Internal server error", http.StatusInternalServerError)
 		return
 	}
 }
 
-func main() {
+// routes registers the users API and, with keys, the key management
+// endpoints. main adds the probes; the contract tests of usersclient
+// serve it as it is.
+func routes(cfgs *config.Manager, keys *apikeys.Store) *http.ServeMux {
+	// With per-client keys, every /users request needs one. Without
+	// them, auth.api_key guards writes.
+	authenticate := func(h http.HandlerFunc) http.HandlerFunc { return requireAPIKey(cfgs, h) }
+	if keys != nil {
+		authenticate = func(h http.HandlerFunc) http.HandlerFunc { return keys.Middleware(h).ServeHTTP }
+	}
+
+	// POST /users with an Idempotency-Key header creates the user once:
+	// a retry gets the first response back. The users are in memory, so
+	// the stored responses are too; after a restart both are gone.
+	createUser := idempotency.New(idempotency.Options{
+		Store:   idempotency.NewMemoryStore(10_000, nil),
+		OnError: i18n.Error,
+	}).Handler(http.HandlerFunc(createUserHandler))
+
 	// Create a new ServeMux
 	mux := http.NewServeMux()
 
 	// Set up routes with middleware
-	mux.HandleFunc("/users", loggingMiddleware(func(w http.ResponseWriter, r *http.Request) {
+	mux.HandleFunc("/users", loggingMiddleware(authenticate(func(w http.ResponseWriter, r *http.Request) {
 		switch r.Method {
 		case http.MethodGet:
 			getUsersHandler(w, r)
 		case http.MethodPost:
-			createUserHandler(w, r)
+			createUser.ServeHTTP(w, r)
 		default:
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			i18n.Error(w, r, "Method not allowed", http.StatusMethodNotAllowed)
 		}
+	})))
+	if keys != nil {
+		admin := loggingMiddleware(requireAdmin(cfgs, keys.AdminHandler()).ServeHTTP)
+		mux.HandleFunc("/api-keys", admin)
+		mux.HandleFunc("/api-keys/", admin)
+	}
+	mux.HandleFunc("GET /users/{id}", loggingMiddleware(authenticate(getUserHandler)))
+	mux.HandleFunc("GET /users/export", loggingMiddleware(authenticate(exportUsersHandler)))
+	mux.HandleFunc("POST /users/import", loggingMiddleware(authenticate(importUsersHandler)))
+	mux.HandleFunc("GET /users/{id}/avatar.png", loggingMiddleware(authenticate(avatarHandler)))
+	return mux
+}
+
+// apiHandler wraps mux with error messages in the client's language
+// (Accept-Language), security headers for a JSON API, and no request body
+// over the largest upload, the CSV import.
+func apiHandler(mux http.Handler) (http.Handler, error) {
+	messages, err := i18n.NewBundle(language.English, translations)
+	if err != nil {
+		return nil, fmt.Errorf("loading translations: %w", err)
+	}
+	return harden.ForAPI().Middleware(harden.LimitBody(messages.Middleware(mux), maxImportBytes)), nil
+}
+
+func main() {
+	// Configuration: defaults, then -config file / APP_CONFIG, then APP_*
+	// environment variables, then flags. See 11_configuration.
+	cfgs, _, err := config.NewManager(func() (config.Config, config.Sources, error) {
+		return config.Load(config.Options{Args: os.Args[1:]})
+	})
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg := cfgs.Current()
+	slog.SetDefault(newLogger(cfg.Log))
+	for _, line := range strings.Split(strings.TrimSpace(cfg.String()), "\n") {
+		log.Printf("config: %s", line) // secrets are redacted
+	}
+
+	// kill -HUP <pid> reloads the configuration. Log settings and the API
+	// key apply at once; the listen address and timeouts need a restart.
+	cfgs.OnChange(func(old, new config.Config) {
+		slog.SetDefault(newLogger(new.Log))
+		log.Printf("Configuration reloaded: %v changed", config.Changed(old, new))
+		if old.Server != new.Server {
+			log.Printf("Server settings changed; restart to apply them")
+		}
+	})
+	reloadCtx, stopReload := context.WithCancel(context.Background())
+	defer stopReload()
+	cfgs.WatchSIGHUP(reloadCtx)
+
+	// Background jobs: persisted in SQLite, so they survive a restart.
+	queue, err = jobs.Open(cfg.Jobs.Path, jobs.Options{})
+	if err != nil {
+		log.Fatalf("Opening job queue: %v", err)
+	}
+	defer queue.Close()
+	queue.Handle("send_welcome_email", sendWelcomeEmail)
+	workersCtx, stopWorkers := context.WithCancel(context.Background())
+	workersDone := make(chan struct{})
+	go func() {
+		queue.Run(workersCtx, cfg.Jobs.Workers)
+		close(workersDone)
+	}()
+
+	// Per-client API keys, when auth.keys_path is set: every /users
+	// request then needs a key in X-API-Key, limited to the key's rate,
+	// and auth.api_key becomes the admin token for /api-keys.
+	var keys *apikeys.Store
+	if cfg.Auth.KeysPath != "" {
+		keys, err = apikeys.Open(cfg.Auth.KeysPath, apikeys.Options{OnError: i18n.Error})
+		if err != nil {
+			log.Fatalf("Opening API keys: %v", err)
+		}
+		defer keys.Close()
+	}
+	mux := routes(cfgs, keys)
+
+	// Probes: no auth and no request logging, they arrive every few seconds.
+	// The users store is in memory; readiness guards the disk and the
+	// job queue's database.
+	checks := health.New()
+	checks.Register(health.Readiness, "disk", health.DiskSpace(".", 50<<20), health.WithCacheTTL(30*time.Second))
+	checks.Register(health.Readiness, "jobs", health.CheckerFunc(func(ctx context.Context) error {
+		_, err := queue.Stats(ctx)
+		return err
 	}))
+	checks.Mount(mux)
+
+	// Request counters by status code and an in-flight gauge, published
+	// with expvar as "http".
+	stats := debugvars.NewHTTPStats("http")
+
+	// CORS for browser apps on other origins, such as a frontend served
+	// from its own domain. Only the origins in cors.allowed_origins may
+	// read responses; there are none by default. It wraps the mux, which
+	// would answer a preflight OPTIONS with 405.
+	corsPolicy, err := cors.New(cors.Options{
+		AllowedOrigins: cfg.CORS.Origins(),
+		AllowedMethods: []string{http.MethodGet, http.MethodHead, http.MethodPost},
+		AllowedHeaders: []string{"Authorization", "Content-Type", apikeys.Header, idempotency.Header},
+		ExposedHeaders: []string{"Content-Disposition", "ETag", "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", idempotency.ReplayedHeader},
+		MaxAge:         10 * time.Minute,
+	})
+	if err != nil {
+		log.Fatalf("CORS: %v", err)
+	}
 
-	// Create server with timeouts
+	handler, err := apiHandler(mux)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Create server with timeouts. ReadHeaderTimeout and MaxHeaderBytes
+	// stop clients that send headers slowly or without end.
 	server := &http.Server{
-		Addr:         ":8080",
-		Handler:      mux,
-		ReadTimeout:  15 * time.Second,
-		WriteTimeout: 15 * time.Second,
-		IdleTimeout:  60 * time.Second,
+		Addr:              cfg.Server.Addr,
+		Handler:           stats.Middleware(corsPolicy.Handler(handler)),
+		ReadHeaderTimeout: harden.ReadHeaderTimeout,
+		ReadTimeout:       cfg.Server.ReadTimeout,
+		WriteTimeout:      cfg.Server.WriteTimeout,
+		IdleTimeout:       cfg.Server.IdleTimeout,
+		MaxHeaderBytes:    harden.MaxHeaderBytes,
 	}
 
 	// Channel to listen for interrupt signal (only SIGINT for manual shutdown)
@@ -123,22 +482,47 @@ func main() {
 
 	// Start server in a goroutine
 	go func() {
-		log.Println("Server starting on :8080")
+		log.Printf("Server starting on %s", server.Addr)
 		if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
 			log.Fatalf("Server failed: %v", err)
 		}
 	}()
 
+	// Optional debug listener: /debug/vars with the request counters and
+	// runtime samples (goroutines, heap, GC pauses), logged every minute.
+	// It has its own address so it can stay off the public network.
+	var debugServer *http.Server
+	if cfg.Server.DebugAddr != "" {
+		sampler := &debugvars.Sampler{Interval: time.Minute}
+		expvar.Publish("runtime", sampler.Var())
+		go sampler.Run(reloadCtx)
+		debugServer = debugvars.NewServer(cfg.Server.DebugAddr)
+		go func() {
+			log.Printf("Debug server starting on %s", debugServer.Addr)
+			if err := debugServer.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+				log.Fatalf("Debug server failed: %v", err)
+			}
+		}()
+	}
+
 	// Wait for interrupt signal
 	<-done
 	log.Println("Shutting down server...")
+	checks.MarkShuttingDown() // /readyz now fails, /livez still passes
 
 	// Graceful shutdown with timeout
-	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.Server.ShutdownTimeout)
 	defer cancel()
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("Server shutdown failed: %v", err)
 	}
+	if debugServer != nil {
+		debugServer.Close() // nothing there worth draining
+	}
+
+	// No new jobs can arrive now; let running ones finish.
+	stopWorkers()
+	<-workersDone
 
 	log.Println("Server stopped")
 }
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"golang.org/x/text/language"
+	"golang.org/x/text/message/catalog"
+
+	"golang_roadmap/08_web_development/03_i18n/i18n"
+)
+
+// translations of the API's error messages. Handlers write them with
+// i18n.Error in the language the client asks for with Accept-Language;
+// English is the source text and the fallback. The lower-case keys are
+// the validate package's message formats, shown after the field name.
+var translations = map[language.Tag]i18n.Messages{
+	language.English: {
+		"Unauthorized":                          catalog.String("Unauthorized"),
+		"Method not allowed":                    catalog.String("Method not allowed"),
+		"Internal server error":                 catalog.String("Internal server error"),
+		"Invalid user ID":                       catalog.String("Invalid user ID"),
+		"Invalid limit":                         catalog.String("Invalid limit"),
+		"Invalid cursor":                        catalog.String("Invalid cursor"),
+		"User not found":                        catalog.String("User not found"),
+		"Invalid avatar size":                   catalog.String("Invalid avatar size"),
+		"Content-Type must be application/json": catalog.String("Content-Type must be application/json"),
+		"Invalid JSON":                          catalog.String("Invalid JSON"),
+		"Unsupported export format":             catalog.String("Unsupported export format"),
+		"Content-Type must be text/csv or multipart/form-data": catalog.String("Content-Type must be text/csv or multipart/form-data"),
+		"Invalid CSV upload":                              catalog.String("Invalid CSV upload"),
+		"CSV must have a name column":                     catalog.String("CSV must have a name column"),
+		"Upload too large":                                catalog.String("Upload too large"),
+		"Too many rows":                                   catalog.String("Too many rows"),
+		"Invalid rows, no users imported":                 catalog.String("Invalid rows, no users imported"),
+		"Missing API key":                                 catalog.String("Missing API key"),
+		"Invalid API key":                                 catalog.String("Invalid API key"),
+		"Rate limit exceeded":                             catalog.String("Rate limit exceeded"),
+		"API key not found":                               catalog.String("API key not found"),
+		"Idempotency-Key reused with a different request": catalog.String("Idempotency-Key reused with a different request"),
+		"Invalid Idempotency-Key":                         catalog.String("Invalid Idempotency-Key"),
+		"malformed CSV: %s":                               catalog.String("malformed CSV: %s"),
+		"must not be empty":                               catalog.String("must not be empty"),
+		"must be at most %d characters":                   catalog.String("must be at most %d characters"),
+	},
+	language.German: {
+		"Unauthorized":                          catalog.String("Nicht autorisiert"),
+		"Method not allowed":                    catalog.String("Methode nicht erlaubt"),
+		"Internal server error":                 catalog.String("Interner Serverfehler"),
+		"Invalid user ID":                       catalog.String("Ungültige Benutzer-ID"),
+		"Invalid limit":                         catalog.String("Ungültiges Limit"),
+		"Invalid cursor":                        catalog.String("Ungültiger Cursor"),
+		"User not found":                        catalog.String("Benutzer nicht gefunden"),
+		"Invalid avatar size":                   catalog.String("Ungültige Avatargröße"),
+		"Content-Type must be application/json": catalog.String("Content-Type muss application/json sein"),
+		"Invalid JSON":                          catalog.String("Ungültiges JSON"),
+		"Unsupported export format":             catalog.String("Nicht unterstütztes Exportformat"),
+		"Content-Type must be text/csv or multipart/form-data": catalog.String("Content-Type muss text/csv oder multipart/form-data sein"),
+		"Invalid CSV upload":                              catalog.String("Ungültiger CSV-Upload"),
+		"CSV must have a name column":                     catalog.String("CSV muss eine Spalte name haben"),
+		"Upload too large":                                catalog.String("Upload zu groß"),
+		"Too many rows":                                   catalog.String("Zu viele Zeilen"),
+		"Invalid rows, no users imported":                 catalog.String("Ungültige Zeilen, keine Benutzer importiert"),
+		"Missing API key":                                 catalog.String("API-Schlüssel fehlt"),
+		"Invalid API key":                                 catalog.String("Ungültiger API-Schlüssel"),
+		"Rate limit exceeded":                             catalog.String("Anfragelimit überschritten"),
+		"API key not found":                               catalog.String("API-Schlüssel nicht gefunden"),
+		"Idempotency-Key reused with a different request": catalog.String("Idempotency-Key für eine andere Anfrage wiederverwendet"),
+		"Invalid Idempotency-Key":                         catalog.String("Ungültiger Idempotency-Key"),
+		"malformed CSV: %s":                               catalog.String("fehlerhaftes CSV: %s"),
+		"must not be empty":                               catalog.String("darf nicht leer sein"),
+		"must be at most %d characters":                   catalog.String("darf höchstens %d Zeichen lang sein"),
+	},
+	language.French: {
+		"Unauthorized":                          catalog.String("Non autorisé"),
+		"Method not allowed":                    catalog.String("Méthode non autorisée"),
+		"Internal server error":                 catalog.String("Erreur interne du serveur"),
+		"Invalid user ID":                       catalog.String("Identifiant d'utilisateur invalide"),
+		"Invalid limit":                         catalog.String("Limite invalide"),
+		"Invalid cursor":                        catalog.String("Curseur invalide"),
+		"User not found":                        catalog.String("Utilisateur introuvable"),
+		"Invalid avatar size":                   catalog.String("Taille d'avatar invalide"),
+		"Content-Type must be application/json": catalog.String("Content-Type doit être application/json"),
+		"Invalid JSON":                          catalog.String("JSON invalide"),
+		"Unsupported export format":             catalog.String("Format d'export non pris en charge"),
+		"Content-Type must be text/csv or multipart/form-data": catalog.String("Content-Type doit être text/csv ou multipart/form-data"),
+		"Invalid CSV upload":                              catalog.String("Envoi CSV invalide"),
+		"CSV must have a name column":                     catalog.String("Le CSV doit avoir une colonne name"),
+		"Upload too large":                                catalog.String("Envoi trop volumineux"),
+		"Too many rows":                                   catalog.String("Trop de lignes"),
+		"Invalid rows, no users imported":                 catalog.String("Lignes invalides, aucun utilisateur importé"),
+		"Missing API key":                                 catalog.String("Clé d'API manquante"),
+		"Invalid API key":                                 catalog.String("Clé d'API invalide"),
+		"Rate limit exceeded":                             catalog.String("Limite de requêtes dépassée"),
+		"API key not found":                               catalog.String("Clé d'API introuvable"),
+		"Idempotency-Key reused with a different request": catalog.String("Idempotency-Key réutilisée pour une autre requête"),
+		"Invalid Idempotency-Key":                         catalog.String("Idempotency-Key invalide"),
+		"malformed CSV: %s":                               catalog.String("CSV mal formé : %s"),
+		"must not be empty":                               catalog.String("ne doit pas être vide"),
+		"must be at most %d characters":                   catalog.String("doit comporter au plus %d caractères"),
+	},
+}
@@ -0,0 +1,225 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang_roadmap/08_web_development/01_net_http/api"
+	"golang_roadmap/08_web_development/03_i18n/i18n"
+	"golang_roadmap/08_web_development/04_validation/validate"
+)
+
+// Limits on POST /users/import.
+const (
+	maxImportBytes = 1 << 20
+	maxImportRows  = 1000
+)
+
+// snapshotUsers copies the users, so an export can be written to a slow
+// client without holding the lock.
+func snapshotUsers() []User {
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]User(nil), users...)
+}
+
+// exportUsersHandler streams all users as a CSV or Excel download:
+// GET /users/export?format=csv (the default) or ?format=xlsx.
+func exportUsersHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = "csv"
+	}
+	if format != "csv" && format != "xlsx" {
+		i18n.Error(w, r, "Unsupported export format", http.StatusBadRequest)
+		return
+	}
+	all := snapshotUsers()
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": "users." + format}))
+
+	var err error
+	if format == "csv" {
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		err = writeUsersCSV(w, all)
+	} else {
+		w.Header().Set("Content-Type", "application/vnd.openxmlformats-officedocument.spreadsheetml.sheet")
+		err = writeUsersXLSX(w, all)
+	}
+	// The status is already sent; a broken download is all the client
+	// will see.
+	if err != nil {
+		log.Printf("Error exporting users as %s: %v", format, err)
+	}
+}
+
+// writeUsersCSV writes a header and one row per user. The csv.Writer
+// buffers 4 KB at a time, so a large export goes out as it is written.
+func writeUsersCSV(w io.Writer, all []User) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "name"})
+	for _, u := range all {
+		cw.Write([]string{strconv.Itoa(u.ID), csvSafe(u.Name)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// csvSafe defuses formula injection: a spreadsheet opening the CSV would
+// run a cell such as =HYPERLINK(...) typed in as a user name. A leading
+// apostrophe makes it text, and the spreadsheet does not show it. A name
+// that starts with an apostrophe gets one more, so that csvUnsafe can
+// tell the added one from the user's: '=x is exported with two leading
+// apostrophes, and a spreadsheet shows it as '=x.
+func csvSafe(s string) string {
+	if s != "" && strings.ContainsRune(escapedStart, rune(s[0])) {
+		return "'" + s
+	}
+	return s
+}
+
+// formulaStart holds the characters that make a spreadsheet read a cell
+// as a formula.
+const formulaStart = "=+-@\t\r"
+
+// escapedStart holds the first characters csvSafe puts an apostrophe
+// before: those of a formula, and the apostrophe itself.
+const escapedStart = formulaStart + "'"
+
+// csvUnsafe undoes csvSafe. It removes only an apostrophe csvSafe could
+// have added, so 'quoted, typed into a spreadsheet, is imported as it is.
+func csvUnsafe(s string) string {
+	if len(s) > 1 && s[0] == '\'' && strings.ContainsRune(escapedStart, rune(s[1])) {
+		return s[1:]
+	}
+	return s
+}
+
+// importUsersHandler creates users from a CSV upload with a name column,
+// sent as the body (Content-Type: text/csv) or as the "file" field of a
+// form (multipart/form-data). Other columns, such as the id of an
+// export, are ignored.
+//
+// The import is all or nothing: if any row is invalid, no user is added
+// and the response is a 422 listing every invalid row, so the client can
+// fix the file and send it again.
+func importUsersHandler(w http.ResponseWriter, r *http.Request) {
+	r.Body = http.MaxBytesReader(w, r.Body, maxImportBytes)
+	var body io.Reader
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	switch mediaType {
+	case "text/csv":
+		body = r.Body
+	case "multipart/form-data":
+		file, _, err := r.FormFile("file")
+		if err != nil {
+			importReadError(w, r, err)
+			return
+		}
+		defer file.Close()
+		body = file
+	default:
+		i18n.Error(w, r, "Content-Type must be text/csv or multipart/form-data", http.StatusUnsupportedMediaType)
+		return
+	}
+
+	p := i18n.FromContext(r.Context())
+	cr := csv.NewReader(body)
+	cr.FieldsPerRecord = -1 // short rows are reported per row, not as a failed file
+	header, err := cr.Read()
+	if err != nil {
+		importReadError(w, r, err)
+		return
+	}
+	nameCol := -1
+	for i, h := range header {
+		if strings.EqualFold(strings.TrimSpace(strings.TrimPrefix(h, "\ufeff")), "name") {
+			nameCol = i
+		}
+	}
+	if nameCol < 0 {
+		i18n.Error(w, r, "CSV must have a name column", http.StatusBadRequest)
+		return
+	}
+
+	var added []User
+	var problems []api.RowError
+	for row := 2; ; row++ {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		var perr *csv.ParseError
+		if errors.As(err, &perr) {
+			problems = append(problems, api.RowError{Row: row, Message: p.Sprintf("malformed CSV: %s", perr.Err.Error())})
+			continue
+		}
+		if err != nil {
+			importReadError(w, r, err)
+			return
+		}
+		if len(added)+len(problems) == maxImportRows {
+			i18n.Error(w, r, "Too many rows", http.StatusRequestEntityTooLarge)
+			return
+		}
+		var name string
+		if nameCol < len(rec) {
+			name = csvUnsafe(rec[nameCol])
+		}
+		u := User{Name: validate.Clean(name)}
+		if err := validateUser(u); err != nil {
+			for _, fe := range validate.Fields(err) {
+				problems = append(problems, api.RowError{Row: row, Field: fe.Field, Message: p.Sprintf(fe.Format, fe.Args...)})
+			}
+			continue
+		}
+		added = append(added, u)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	if len(problems) > 0 {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		json.NewEncoder(w).Encode(api.ImportError{
+			Error:  p.Sprintf("Invalid rows, no users imported"),
+			Errors: problems,
+		})
+		return
+	}
+
+	mu.Lock()
+	for i := range added {
+		added[i].ID = nextID
+		nextID++
+	}
+	users = append(users, added...)
+	mu.Unlock()
+	for _, u := range added {
+		if _, err := queue.Enqueue(r.Context(), "send_welcome_email", welcomeEmail{UserID: u.ID, Name: u.Name}); err != nil {
+			log.Printf("Error enqueueing welcome email for user %d: %v", u.ID, err)
+		}
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(api.ImportResult{Imported: len(added), Users: added})
+}
+
+// importReadError answers an upload that could not be read: too large,
+// empty, or not a file.
+func importReadError(w http.ResponseWriter, r *http.Request, err error) {
+	var tooLarge *http.MaxBytesError
+	switch {
+	case errors.As(err, &tooLarge):
+		i18n.Error(w, r, "Upload too large", http.StatusRequestEntityTooLarge)
+	case err == io.EOF:
+		i18n.Error(w, r, "CSV must have a name column", http.StatusBadRequest)
+	default:
+		log.Printf("Error reading import: %v", err)
+		i18n.Error(w, r, "Invalid CSV upload", http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,251 @@
+package main
+
+import (
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/xuri/excelize/v2"
+
+	"golang_roadmap/08_web_development/01_net_http/api"
+)
+
+// names start like formulas, with apostrophes, and with XML's special
+// characters.
+var names = []string{"Bob", "=HYPERLINK(\"http://evil\")", "+1", "-2", "@home", "'=x", "''=y", "'quoted", "<Tom & Jerry>"}
+
+// withUsers serves the API with names as users 1, 2, ...
+func withUsers(t *testing.T) *httptest.Server {
+	t.Helper()
+	srv := serve(t, nil)
+	mu.Lock()
+	users = nil
+	for i, n := range names {
+		users = append(users, User{ID: i + 1, Name: n})
+	}
+	nextID = len(names) + 1
+	mu.Unlock()
+	return srv
+}
+
+func export(t *testing.T, srv *httptest.Server, format string) (*http.Response, []byte) {
+	t.Helper()
+	resp, err := http.Get(srv.URL + "/users/export?format=" + format)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, body
+}
+
+func importCSV(t *testing.T, srv *httptest.Server, contentType string, body io.Reader) (*http.Response, []byte) {
+	t.Helper()
+	req, _ := http.NewRequest(http.MethodPost, srv.URL+"/users/import", body)
+	req.Header.Set("Content-Type", contentType)
+	req.Header.Set("Authorization", "Bearer "+adminToken)
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	out, _ := io.ReadAll(resp.Body)
+	return resp, out
+}
+
+func storedNames() []string {
+	mu.Lock()
+	defer mu.Unlock()
+	var out []string
+	for _, u := range users {
+		out = append(out, u.Name)
+	}
+	return out
+}
+
+func TestCSVSafe(t *testing.T) {
+	for in, want := range map[string]string{
+		"Bob":     "Bob",
+		"=1+1":    "'=1+1",
+		"\tx":     "'\tx",
+		"'=x":     "''=x",
+		"'quoted": "''quoted",
+		"a'=b":    "a'=b",
+		"":        "",
+	} {
+		got := csvSafe(in)
+		if got != want {
+			t.Errorf("csvSafe(%q) = %q; want %q", in, got, want)
+		}
+		if back := csvUnsafe(got); back != in {
+			t.Errorf("csvUnsafe(csvSafe(%q)) = %q", in, back)
+		}
+	}
+	// An apostrophe csvSafe did not add is kept.
+	for _, s := range []string{"'quoted", "'", "it's"} {
+		if got := csvUnsafe(s); got != s {
+			t.Errorf("csvUnsafe(%q) = %q; want it unchanged", s, got)
+		}
+	}
+}
+
+func TestExportCSV(t *testing.T) {
+	srv := withUsers(t)
+	resp, body := export(t, srv, "csv")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Type") != "text/csv; charset=utf-8" {
+		t.Fatalf("export: %s, %s", resp.Status, resp.Header.Get("Content-Type"))
+	}
+	if cd := resp.Header.Get("Content-Disposition"); cd != "attachment; filename=users.csv" {
+		t.Errorf("Content-Disposition = %q", cd)
+	}
+	rows, err := csv.NewReader(bytes.NewReader(body)).ReadAll()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(names)+1 || !slices.Equal(rows[0], []string{"id", "name"}) {
+		t.Fatalf("rows = %q", rows)
+	}
+	for _, row := range rows[1:] {
+		if row[1] != "" && strings.ContainsRune(formulaStart, rune(row[1][0])) {
+			t.Errorf("row %q starts like a formula", row)
+		}
+	}
+	if rows[2][1] != `'=HYPERLINK("http://evil")` || rows[6][1] != "''=x" {
+		t.Errorf("escaped names: %q and %q", rows[2][1], rows[6][1])
+	}
+}
+
+func TestExportXLSX(t *testing.T) {
+	srv := withUsers(t)
+	resp, body := export(t, srv, "xlsx")
+	if resp.StatusCode != http.StatusOK || resp.Header.Get("Content-Disposition") != "attachment; filename=users.xlsx" {
+		t.Fatalf("export: %s, %s", resp.Status, resp.Header.Get("Content-Disposition"))
+	}
+	f, err := excelize.OpenReader(bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	rows, err := f.GetRows("Users")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != len(names)+1 || !slices.Equal(rows[0], []string{"id", "name"}) {
+		t.Fatalf("rows = %q", rows)
+	}
+	// Cells hold the names as they are: strings, never formulas.
+	for i, n := range names {
+		if rows[i+1][0] != strconv.Itoa(i+1) || rows[i+1][1] != n {
+			t.Errorf("row %d = %q; want %d, %q", i+2, rows[i+1], i+1, n)
+		}
+		if formula, _ := f.GetCellFormula("Users", "B"+strconv.Itoa(i+2)); formula != "" {
+			t.Errorf("B%d holds a formula: %q", i+2, formula)
+		}
+	}
+	if bold, _ := f.GetCellStyle("Users", "A1"); bold == 0 {
+		t.Error("the header is not styled")
+	}
+
+	if resp, _ := export(t, srv, "pdf"); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("format=pdf: %s; want 400", resp.Status)
+	}
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	srv := withUsers(t)
+	_, body := export(t, srv, "csv")
+	resp, out := importCSV(t, srv, "text/csv", bytes.NewReader(body))
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("import: %s %s", resp.Status, out)
+	}
+	var res api.ImportResult
+	json.Unmarshal(out, &res)
+	var got []string
+	for _, u := range res.Users {
+		got = append(got, u.Name)
+	}
+	if !slices.Equal(got, names) {
+		t.Errorf("imported %q;\nwant %q", got, names)
+	}
+}
+
+func TestImportIsAllOrNothing(t *testing.T) {
+	srv := serve(t, nil)
+	csvBody := "id,name\n1,Carol\n2,\n3,Dave\n4," + strings.Repeat("x", 101) + "\n5,\"broken\n"
+	resp, out := importCSV(t, srv, "text/csv; charset=utf-8", strings.NewReader(csvBody))
+	if resp.StatusCode != http.StatusUnprocessableEntity {
+		t.Fatalf("import: %s %s; want 422", resp.Status, out)
+	}
+	var ie api.ImportError
+	if err := json.Unmarshal(out, &ie); err != nil {
+		t.Fatal(err)
+	}
+	var rows []int
+	for _, e := range ie.Errors {
+		rows = append(rows, e.Row)
+		if e.Message == "" {
+			t.Errorf("row %d: no message", e.Row)
+		}
+	}
+	if ie.Error != "Invalid rows, no users imported" || !slices.Equal(rows, []int{3, 5, 6}) {
+		t.Errorf("ImportError = %+v; want rows 3, 5 and 6", ie)
+	}
+	if ie.Errors[0].Field != "name" {
+		t.Errorf("row 3 field = %q; want name", ie.Errors[0].Field)
+	}
+	if got := storedNames(); !slices.Equal(got, []string{"Bob"}) {
+		t.Errorf("users after a failed import: %q; want only Bob", got)
+	}
+}
+
+func TestImportMultipart(t *testing.T) {
+	srv := serve(t, nil)
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, _ := mw.CreateFormFile("file", "users.csv")
+	io.WriteString(fw, "\ufeffName\nCarol\n") // a BOM, as Excel writes
+	mw.Close()
+	if resp, out := importCSV(t, srv, mw.FormDataContentType(), &body); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("import: %s %s", resp.Status, out)
+	}
+	if got := storedNames(); !slices.Equal(got, []string{"Bob", "Carol"}) {
+		t.Errorf("users = %q", got)
+	}
+
+	if resp, _ := importCSV(t, srv, "application/json", strings.NewReader("{}")); resp.StatusCode != http.StatusUnsupportedMediaType {
+		t.Errorf("JSON body: %s; want 415", resp.Status)
+	}
+	if resp, _ := importCSV(t, srv, "text/csv", strings.NewReader("id,email\n1,a@b\n")); resp.StatusCode != http.StatusBadRequest {
+		t.Errorf("no name column: %s; want 400", resp.Status)
+	}
+}
+
+func TestImportMaxRows(t *testing.T) {
+	srv := serve(t, nil)
+	rows := func(n int) io.Reader {
+		return strings.NewReader("name\n" + strings.Repeat("Carol\n", n))
+	}
+	if resp, out := importCSV(t, srv, "text/csv", rows(maxImportRows+1)); resp.StatusCode != http.StatusRequestEntityTooLarge {
+		t.Fatalf("%d rows: %s %s; want 413", maxImportRows+1, resp.Status, out)
+	}
+	if n := len(storedNames()); n != 1 {
+		t.Fatalf("%d users after a rejected import; want 1", n)
+	}
+	if resp, out := importCSV(t, srv, "text/csv", rows(maxImportRows)); resp.StatusCode != http.StatusCreated {
+		t.Fatalf("%d rows: %s %s; want 201", maxImportRows, resp.Status, out)
+	}
+	if n := len(storedNames()); n != maxImportRows+1 {
+		t.Errorf("%d users; want %d", n, maxImportRows+1)
+	}
+}
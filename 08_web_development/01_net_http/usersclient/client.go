@@ -0,0 +1,317 @@
+// Package usersclient calls the users API of 01_net_http with typed
+// methods, so programs do not build URLs, set headers or parse error
+// bodies themselves.
+//
+//	c, err := usersclient.New(
+//		usersclient.WithBaseURL("http://localhost:8080"),
+//		usersclient.WithAPIKey(os.Getenv("USERS_API_KEY")),
+//		usersclient.WithRetry(retry.Policy{MaxAttempts: 4}),
+//	)
+//	u, err := c.CreateUser(ctx, "Alice")
+//	for u, err := range c.Users(ctx, 100) { ... }
+//
+// It is built on httpclient (02_core_language/22_functional_options),
+// which does the base URL, headers, logging and the retries of reads.
+// The contract tests that keep it in step with the server are in the
+// server's package: they run it against the real handlers.
+package usersclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"golang_roadmap/02_core_language/22_functional_options/httpclient"
+	"golang_roadmap/08_web_development/01_net_http/api"
+	"golang_roadmap/12_operations/03_retry/retry"
+)
+
+// Headers the API reads. They match the server's apikeys.Header and
+// idempotency.Header, which this package does not import so that a
+// client does not pull in the server's SQLite stores.
+const (
+	apiKeyHeader      = "X-API-Key"
+	idempotencyHeader = "Idempotency-Key"
+)
+
+// Client calls the users API. It is safe for concurrent use.
+type Client struct {
+	hc    *httpclient.Client
+	retry *retry.Policy // nil: one attempt
+}
+
+type settings struct {
+	http    []httpclient.Option
+	baseURL bool
+	retry   *retry.Policy
+}
+
+// Option configures a Client.
+type Option func(*settings) error
+
+// WithBaseURL sets the server's address, such as "http://localhost:8080".
+// It is required.
+func WithBaseURL(raw string) Option {
+	return func(s *settings) error {
+		s.http = append(s.http, httpclient.WithBaseURL(raw))
+		s.baseURL = true
+		return nil
+	}
+}
+
+// WithAPIKey sends key in X-API-Key, for a server with per-client keys
+// (auth.keys_path).
+func WithAPIKey(key string) Option {
+	return func(s *settings) error {
+		if key == "" {
+			return errors.New("WithAPIKey: empty key")
+		}
+		s.http = append(s.http, httpclient.WithHeader(apiKeyHeader, key))
+		return nil
+	}
+}
+
+// WithBearerToken sends "Authorization: Bearer <token>", for a server
+// whose writes are guarded by auth.api_key.
+func WithBearerToken(token string) Option {
+	return func(s *settings) error {
+		if token == "" {
+			return errors.New("WithBearerToken: empty token")
+		}
+		s.http = append(s.http, httpclient.WithBearerToken(func(context.Context) (string, error) { return token, nil }))
+		return nil
+	}
+}
+
+// WithRetry retries network errors, 429 and 5xx responses. Reads are
+// always safe to retry; CreateUser is too, as it sends an Idempotency-Key
+// that makes the server create the user once. ImportUsers is never
+// retried: the server does not deduplicate imports.
+func WithRetry(p retry.Policy) Option {
+	return func(s *settings) error {
+		s.http = append(s.http, httpclient.WithRetry(p))
+		s.retry = &p
+		return nil
+	}
+}
+
+// WithLanguage asks for error messages in lang, an Accept-Language value
+// such as "de" or "fr-CH, fr;q=0.9" (default: the server's, English).
+func WithLanguage(lang string) Option {
+	return func(s *settings) error {
+		s.http = append(s.http, httpclient.WithHeader("Accept-Language", lang))
+		return nil
+	}
+}
+
+// WithHTTPOptions passes options to the underlying httpclient, such as
+// httpclient.WithTimeout, WithLogger or WithTransport.
+func WithHTTPOptions(opts ...httpclient.Option) Option {
+	return func(s *settings) error {
+		s.http = append(s.http, opts...)
+		return nil
+	}
+}
+
+// New returns a client configured by opts. It reports every invalid
+// option, not just the first.
+func New(opts ...Option) (*Client, error) {
+	var s settings
+	var errs []error
+	for _, opt := range opts {
+		if err := opt(&s); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if !s.baseURL {
+		errs = append(errs, errors.New("WithBaseURL is required"))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, fmt.Errorf("usersclient: %w", err)
+	}
+	hc, err := httpclient.New(s.http...)
+	if err != nil {
+		return nil, fmt.Errorf("usersclient: %w", err)
+	}
+	return &Client{hc: hc, retry: s.retry}, nil
+}
+
+// ListUsers returns every user, in one response.
+func (c *Client) ListUsers(ctx context.Context) ([]api.User, error) {
+	var all []api.User
+	if _, err := c.get(ctx, "/users", &all); err != nil {
+		return nil, fmt.Errorf("usersclient: list users: %w", err)
+	}
+	return all, nil
+}
+
+// Users returns the users page by page, pageSize (1 to api.MaxLimit) at
+// a time, fetching the next page only when the loop gets to it. It
+// follows the server's Link headers. An error ends the sequence:
+//
+//	for u, err := range c.Users(ctx, 100) {
+//		if err != nil {
+//			return err
+//		}
+//		...
+//	}
+func (c *Client) Users(ctx context.Context, pageSize int) iter.Seq2[api.User, error] {
+	return func(yield func(api.User, error) bool) {
+		if pageSize < 1 || pageSize > api.MaxLimit {
+			yield(api.User{}, fmt.Errorf("usersclient: page size must be 1 to %d, got %d", api.MaxLimit, pageSize))
+			return
+		}
+		next := "/users?" + url.Values{api.ParamLimit: {strconv.Itoa(pageSize)}}.Encode()
+		for next != "" {
+			var page []api.User
+			resp, err := c.get(ctx, next, &page)
+			if err != nil {
+				yield(api.User{}, fmt.Errorf("usersclient: list users: %w", err))
+				return
+			}
+			for _, u := range page {
+				if !yield(u, nil) {
+					return
+				}
+			}
+			next = nextLink(resp)
+		}
+	}
+}
+
+// GetUser returns the user with id. A missing user is an error that
+// matches ErrNotFound.
+func (c *Client) GetUser(ctx context.Context, id int) (api.User, error) {
+	var u api.User
+	if _, err := c.get(ctx, "/users/"+strconv.Itoa(id), &u); err != nil {
+		return api.User{}, fmt.Errorf("usersclient: get user %d: %w", id, err)
+	}
+	return u, nil
+}
+
+// CreateUser creates a user named name. With WithRetry, a failed attempt
+// is sent again with the same Idempotency-Key, so however many attempts
+// reach the server, it creates one user. An invalid name is an error that
+// matches ErrInvalid, with the server's message.
+func (c *Client) CreateUser(ctx context.Context, name string) (api.User, error) {
+	body, err := json.Marshal(api.NewUser{Name: name})
+	if err != nil {
+		return api.User{}, fmt.Errorf("usersclient: create user: %w", err)
+	}
+	key := rand.Text()
+	var u api.User
+	err = c.retrying(ctx, func(ctx context.Context) error {
+		req, err := c.hc.NewRequest(ctx, http.MethodPost, "/users", bytes.NewReader(body))
+		if err != nil {
+			return retry.Permanent(err)
+		}
+		req.Header.Set("Content-Type", "application/json")
+		req.Header.Set(idempotencyHeader, key)
+		_, err = c.do(req, http.StatusCreated, &u)
+		return err
+	})
+	if err != nil {
+		return api.User{}, fmt.Errorf("usersclient: create user: %w", err)
+	}
+	return u, nil
+}
+
+// ImportUsers creates a user for every row of csv, which needs a name
+// column. The import is all or nothing: if any row is invalid, no user is
+// created and the error, which matches ErrInvalid, is an *Error whose
+// Rows say what is wrong with which row.
+func (c *Client) ImportUsers(ctx context.Context, csv io.Reader) (api.ImportResult, error) {
+	req, err := c.hc.NewRequest(ctx, http.MethodPost, "/users/import", csv)
+	if err != nil {
+		return api.ImportResult{}, fmt.Errorf("usersclient: import users: %w", err)
+	}
+	req.Header.Set("Content-Type", "text/csv")
+	var res api.ImportResult
+	if _, err := c.do(req, http.StatusCreated, &res); err != nil {
+		return api.ImportResult{}, fmt.Errorf("usersclient: import users: %w", err)
+	}
+	return res, nil
+}
+
+// get fetches path into v. httpclient retries it as configured.
+func (c *Client) get(ctx context.Context, path string, v any) (*http.Response, error) {
+	req, err := c.hc.NewRequest(ctx, http.MethodGet, path, nil)
+	if err != nil {
+		return nil, err
+	}
+	return c.do(req, http.StatusOK, v)
+}
+
+// do sends req and decodes a want response into v, or any other response
+// into an *Error.
+func (c *Client) do(req *http.Request, want int, v any) (*http.Response, error) {
+	req.Header.Set("Accept", "application/json")
+	resp, err := c.hc.Do(req)
+	var se *httpclient.StatusError
+	if errors.As(err, &se) {
+		// A 429 or 5xx after httpclient's retries: the body is gone.
+		return nil, &Error{StatusCode: se.Code, Message: http.StatusText(se.Code), retryAfter: se.RetryAfterDelay, err: err}
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != want {
+		return nil, decodeError(resp)
+	}
+	if err := json.NewDecoder(resp.Body).Decode(v); err != nil {
+		return nil, retry.Permanent(fmt.Errorf("decoding response: %w", err))
+	}
+	return resp, nil
+}
+
+// retrying runs fn with the retry policy, if there is one. Only network
+// errors, 429 and 5xx are retried.
+func (c *Client) retrying(ctx context.Context, fn func(context.Context) error) error {
+	if c.retry == nil {
+		return fn(ctx)
+	}
+	p := *c.retry
+	if p.Retryable == nil {
+		p.Retryable = func(err error) bool {
+			var e *Error
+			if errors.As(err, &e) {
+				return e.Temporary()
+			}
+			return ctx.Err() == nil
+		}
+	}
+	return retry.Do(ctx, p, fn)
+}
+
+// nextLink returns the target of resp's Link header with rel="next",
+// resolved against the request URL, or "" on the last page.
+func nextLink(resp *http.Response) string {
+	for _, v := range resp.Header.Values("Link") {
+		for _, link := range strings.Split(v, ",") {
+			target, params, ok := strings.Cut(strings.TrimSpace(link), ";")
+			if !ok || !strings.HasPrefix(target, "<") || !strings.HasSuffix(target, ">") {
+				continue
+			}
+			for _, p := range strings.Split(params, ";") {
+				if strings.TrimSpace(p) == `rel="next"` || strings.TrimSpace(p) == "rel=next" {
+					u, err := resp.Request.URL.Parse(target[1 : len(target)-1])
+					if err != nil {
+						return ""
+					}
+					return u.String()
+				}
+			}
+		}
+	}
+	return ""
+}
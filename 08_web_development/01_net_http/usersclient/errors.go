@@ -0,0 +1,105 @@
+package usersclient
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang_roadmap/08_web_development/01_net_http/api"
+)
+
+// Kinds of API errors, for errors.Is. Every *Error matches the one for
+// its status code, if any.
+var (
+	ErrInvalid      = errors.New("invalid request") // 400, 422
+	ErrUnauthorized = errors.New("unauthorized")    // 401, 403
+	ErrNotFound     = errors.New("not found")       // 404
+	ErrRateLimited  = errors.New("rate limited")    // 429
+	ErrServer       = errors.New("server error")    // 5xx
+)
+
+// Error is a response the API refused: a 4xx, or a 429 or 5xx still
+// failing after the retries.
+type Error struct {
+	StatusCode int
+	// Message is the server's message, in the language asked for with
+	// WithLanguage. A validation error has one line per invalid field.
+	Message string
+	// Rows lists the invalid rows of a refused import.
+	Rows []api.RowError
+
+	retryAfter time.Duration
+	err        error // httpclient's error, when it ran the retries
+}
+
+func (e *Error) Error() string {
+	msg := fmt.Sprintf("HTTP %d: %s", e.StatusCode, strings.ReplaceAll(e.Message, "\n", "; "))
+	for _, r := range e.Rows {
+		msg += fmt.Sprintf("; row %d", r.Row)
+		if r.Field != "" {
+			msg += " " + r.Field
+		}
+		msg += ": " + r.Message
+	}
+	return msg
+}
+
+// Is makes errors.Is(err, ErrNotFound) and the like work.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrInvalid:
+		return e.StatusCode == http.StatusBadRequest || e.StatusCode == http.StatusUnprocessableEntity
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized || e.StatusCode == http.StatusForbidden
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrServer:
+		return e.StatusCode >= 500
+	}
+	return false
+}
+
+// Unwrap returns the *httpclient.StatusError behind a 429 or 5xx that was
+// retried, or nil.
+func (e *Error) Unwrap() error { return e.err }
+
+// Temporary reports whether the request may succeed if sent again later.
+func (e *Error) Temporary() bool {
+	return e.StatusCode == http.StatusTooManyRequests || e.StatusCode >= 500
+}
+
+// RetryAfter is the server's Retry-After, in seconds form only; retry.Do
+// waits at least that long.
+func (e *Error) RetryAfter() time.Duration { return e.retryAfter }
+
+// decodeError reads an error response. The API answers most errors with
+// a plain text line and a refused import with api.ImportError.
+func decodeError(resp *http.Response) error {
+	e := &Error{StatusCode: resp.StatusCode}
+	secs, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+	e.retryAfter = time.Duration(secs) * time.Second
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, 64<<10))
+	if err != nil {
+		return fmt.Errorf("HTTP %d: reading body: %w", resp.StatusCode, err)
+	}
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	var ie api.ImportError
+	if mediaType == "application/json" && json.Unmarshal(body, &ie) == nil && ie.Error != "" {
+		e.Message, e.Rows = ie.Error, ie.Errors
+	} else {
+		e.Message = strings.TrimSpace(string(body))
+	}
+	if e.Message == "" {
+		e.Message = http.StatusText(resp.StatusCode)
+	}
+	return e
+}
@@ -0,0 +1,412 @@
+package main
+
+// Contract tests for usersclient: the client against the real handlers,
+// wrapped as main wraps them, so a change to a status code, a header or
+// a JSON field on either side fails here.
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang_roadmap/02_core_language/22_functional_options/httpclient"
+	"golang_roadmap/08_web_development/01_net_http/api"
+	"golang_roadmap/08_web_development/01_net_http/usersclient"
+	"golang_roadmap/08_web_development/19_api_keys/apikeys"
+	"golang_roadmap/08_web_development/21_idempotency/idempotency"
+	"golang_roadmap/10_messaging/05_jobs/jobs"
+	"golang_roadmap/11_configuration/01_config_loader/config"
+	"golang_roadmap/12_operations/03_retry/retry"
+)
+
+const adminToken = "s3cret"
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard) // request logging
+	os.Exit(m.Run())
+}
+
+// serve starts the API with a fresh store holding only Bob (ID 1). With
+// keys, /users needs an API key; without, writes need adminToken.
+func serve(t *testing.T, keys *apikeys.Store) *httptest.Server {
+	t.Helper()
+	mu.Lock()
+	users = []User{{ID: 1, Name: "Bob"}}
+	nextID = 2
+	mu.Unlock()
+	userCache.Clear()
+
+	q, err := jobs.Open(filepath.Join(t.TempDir(), "jobs.db"), jobs.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { q.Close() })
+	queue = q
+
+	cfgs, _, err := config.NewManager(func() (config.Config, config.Sources, error) {
+		c := config.Default()
+		c.Auth.APIKey = adminToken
+		return c, nil, nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h, err := apiHandler(routes(cfgs, keys))
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newClient(t *testing.T, srv *httptest.Server, opts ...usersclient.Option) *usersclient.Client {
+	t.Helper()
+	c, err := usersclient.New(append([]usersclient.Option{usersclient.WithBaseURL(srv.URL)}, opts...)...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+// apiError returns err's *usersclient.Error, failing the test if there is
+// none.
+func apiError(t *testing.T, err error) *usersclient.Error {
+	t.Helper()
+	var e *usersclient.Error
+	if !errors.As(err, &e) {
+		t.Fatalf("err = %v, want a *usersclient.Error", err)
+	}
+	return e
+}
+
+func TestClientCreateGetList(t *testing.T) {
+	srv := serve(t, nil)
+	c := newClient(t, srv, usersclient.WithBearerToken(adminToken))
+	ctx := context.Background()
+
+	u, err := c.CreateUser(ctx, "  Alice ")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u != (api.User{ID: 2, Name: "Alice"}) {
+		t.Errorf("CreateUser = %+v, want ID 2 and the cleaned name", u)
+	}
+	got, err := c.GetUser(ctx, 2)
+	if err != nil || got != u {
+		t.Errorf("GetUser(2) = %+v, %v; want %+v", got, err, u)
+	}
+	all, err := c.ListUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []api.User{{ID: 1, Name: "Bob"}, u}; !slices.Equal(all, want) {
+		t.Errorf("ListUsers = %+v, want %+v", all, want)
+	}
+}
+
+func TestClientUsersPages(t *testing.T) {
+	srv := serve(t, nil)
+	c := newClient(t, srv, usersclient.WithBearerToken(adminToken))
+	ctx := context.Background()
+	for i := range 6 {
+		if _, err := c.CreateUser(ctx, fmt.Sprintf("user %d", i)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	for _, size := range []int{1, 2, 3, 7, api.MaxLimit} {
+		var ids []int
+		for u, err := range c.Users(ctx, size) {
+			if err != nil {
+				t.Fatalf("size %d: %v", size, err)
+			}
+			ids = append(ids, u.ID)
+		}
+		if want := []int{1, 2, 3, 4, 5, 6, 7}; !slices.Equal(ids, want) {
+			t.Errorf("size %d: IDs %v, want %v", size, ids, want)
+		}
+	}
+
+	for _, size := range []int{0, api.MaxLimit + 1} {
+		for _, err := range c.Users(ctx, size) {
+			if err == nil {
+				t.Errorf("size %d: no error", size)
+			}
+		}
+	}
+}
+
+// TestPaginationParams checks the server side of pagination that the
+// client does not exercise: the Link header and bad parameters.
+func TestPaginationParams(t *testing.T) {
+	srv := serve(t, nil)
+	users = append(users, User{ID: 2, Name: "Alice"})
+	for _, tc := range []struct {
+		query  string
+		status int
+		link   string
+	}{
+		{"", http.StatusOK, ""},
+		{"?limit=1", http.StatusOK, `</users?after=1&limit=1>; rel="next"`},
+		{"?limit=1&after=1", http.StatusOK, ""},
+		{"?limit=5&after=99", http.StatusOK, ""},
+		{"?after=1", http.StatusOK, ""},
+		{"?limit=0", http.StatusBadRequest, ""},
+		{"?limit=1001", http.StatusBadRequest, ""},
+		{"?limit=x", http.StatusBadRequest, ""},
+		{"?after=-1", http.StatusBadRequest, ""},
+	} {
+		resp, err := http.Get(srv.URL + "/users" + tc.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != tc.status || resp.Header.Get("Link") != tc.link {
+			t.Errorf("GET /users%s: %d, Link %q; want %d, %q", tc.query, resp.StatusCode, resp.Header.Get("Link"), tc.status, tc.link)
+		}
+	}
+}
+
+func TestClientErrors(t *testing.T) {
+	srv := serve(t, nil)
+	ctx := context.Background()
+	c := newClient(t, srv, usersclient.WithBearerToken(adminToken))
+
+	_, err := c.GetUser(ctx, 99)
+	if !errors.Is(err, usersclient.ErrNotFound) {
+		t.Errorf("GetUser(99): %v, want ErrNotFound", err)
+	}
+	if e := apiError(t, err); e.StatusCode != http.StatusNotFound || e.Message != "User not found" {
+		t.Errorf("GetUser(99): %d %q", e.StatusCode, e.Message)
+	}
+
+	_, err = c.CreateUser(ctx, " ")
+	if !errors.Is(err, usersclient.ErrInvalid) {
+		t.Errorf("CreateUser(blank): %v, want ErrInvalid", err)
+	}
+	if e := apiError(t, err); e.Message != "name: must not be empty" {
+		t.Errorf("CreateUser(blank): message %q", e.Message)
+	}
+
+	_, err = newClient(t, srv).CreateUser(ctx, "Mallory")
+	if !errors.Is(err, usersclient.ErrUnauthorized) {
+		t.Errorf("CreateUser without token: %v, want ErrUnauthorized", err)
+	}
+	_, err = newClient(t, srv, usersclient.WithBearerToken("wrong")).CreateUser(ctx, "Mallory")
+	if !errors.Is(err, usersclient.ErrUnauthorized) {
+		t.Errorf("CreateUser with wrong token: %v, want ErrUnauthorized", err)
+	}
+}
+
+func TestClientLanguage(t *testing.T) {
+	srv := serve(t, nil)
+	for lang, want := range map[string]string{
+		"de":              "Benutzer nicht gefunden",
+		"fr-CH, fr;q=0.9": "Utilisateur introuvable",
+		"ja":              "User not found",
+		"en-GB, de;q=0.5": "User not found",
+	} {
+		_, err := newClient(t, srv, usersclient.WithLanguage(lang)).GetUser(context.Background(), 99)
+		if e := apiError(t, err); e.Message != want {
+			t.Errorf("%s: message %q, want %q", lang, e.Message, want)
+		}
+	}
+}
+
+func TestClientImport(t *testing.T) {
+	srv := serve(t, nil)
+	c := newClient(t, srv, usersclient.WithBearerToken(adminToken), usersclient.WithLanguage("de"))
+	ctx := context.Background()
+
+	_, err := c.ImportUsers(ctx, strings.NewReader("name\nCarol\n \n"+strings.Repeat("x", 101)+"\n"))
+	if !errors.Is(err, usersclient.ErrInvalid) {
+		t.Fatalf("ImportUsers: %v, want ErrInvalid", err)
+	}
+	e := apiError(t, err)
+	if e.StatusCode != http.StatusUnprocessableEntity || e.Message != "Ungültige Zeilen, keine Benutzer importiert" {
+		t.Errorf("ImportUsers: %d %q", e.StatusCode, e.Message)
+	}
+	if len(e.Rows) != 2 || e.Rows[0].Row != 3 || e.Rows[1].Row != 4 || e.Rows[0].Field != "name" {
+		t.Errorf("ImportUsers rows = %+v, want rows 3 and 4, field name", e.Rows)
+	}
+
+	res, err := c.ImportUsers(ctx, strings.NewReader("id,name\n7,Carol\n8,Dave\n"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := api.ImportResult{Imported: 2, Users: []api.User{{ID: 2, Name: "Carol"}, {ID: 3, Name: "Dave"}}}
+	if res.Imported != want.Imported || !slices.Equal(res.Users, want.Users) {
+		t.Errorf("ImportUsers = %+v, want %+v", res, want)
+	}
+}
+
+// flaky sends requests on, but loses the response of the first fail
+// POSTs after the server has handled them, as a dropped connection
+// would. It records the Idempotency-Key of each POST.
+type flaky struct {
+	fail int
+
+	mu   sync.Mutex
+	keys []string
+}
+
+func (f *flaky) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := http.DefaultTransport.RoundTrip(req)
+	if req.Method != http.MethodPost {
+		return resp, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.keys = append(f.keys, req.Header.Get(idempotency.Header))
+	if err == nil && len(f.keys) <= f.fail {
+		resp.Body.Close()
+		return nil, errors.New("connection reset by peer")
+	}
+	return resp, err
+}
+
+func TestClientCreateRetriesOnce(t *testing.T) {
+	srv := serve(t, nil)
+	ctx := context.Background()
+	tr := &flaky{fail: 2}
+	fast := retry.Policy{MaxAttempts: 4, Initial: time.Millisecond, Max: 2 * time.Millisecond}
+	c := newClient(t, srv,
+		usersclient.WithBearerToken(adminToken),
+		usersclient.WithRetry(fast),
+		usersclient.WithHTTPOptions(httpclient.WithTransport(tr)),
+	)
+
+	u, err := c.CreateUser(ctx, "Alice")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(tr.keys) != 3 || tr.keys[0] == "" || tr.keys[1] != tr.keys[0] || tr.keys[2] != tr.keys[0] {
+		t.Errorf("Idempotency-Keys %q, want the same key three times", tr.keys)
+	}
+	all, err := c.ListUsers(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []api.User{{ID: 1, Name: "Bob"}, u}; !slices.Equal(all, want) {
+		t.Errorf("after 3 attempts, users = %+v, want one created: %+v", all, want)
+	}
+
+	// A new call is a new operation, with a new key.
+	if _, err := c.CreateUser(ctx, "Alice"); err != nil {
+		t.Fatal(err)
+	}
+	if tr.keys[3] == tr.keys[0] {
+		t.Error("second CreateUser reused the first one's key")
+	}
+
+	// Without retries the lost response is an error, though the user was
+	// created.
+	tr = &flaky{fail: 1}
+	c = newClient(t, srv, usersclient.WithBearerToken(adminToken), usersclient.WithHTTPOptions(httpclient.WithTransport(tr)))
+	if _, err := c.CreateUser(ctx, "Carol"); err == nil {
+		t.Error("CreateUser without retries: no error")
+	}
+}
+
+func TestClientServerErrors(t *testing.T) {
+	var calls int
+	var mu sync.Mutex
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		calls++
+		mu.Unlock()
+		http.Error(w, "database down", http.StatusServiceUnavailable)
+	}))
+	defer srv.Close()
+	ctx := context.Background()
+
+	_, err := newClient(t, srv).GetUser(ctx, 1)
+	if !errors.Is(err, usersclient.ErrServer) || apiError(t, err).Message != "database down" {
+		t.Errorf("without retries: %v, want ErrServer with the body", err)
+	}
+
+	fast := retry.Policy{MaxAttempts: 3, Initial: time.Millisecond, Max: 2 * time.Millisecond}
+	calls = 0
+	_, err = newClient(t, srv, usersclient.WithRetry(fast)).GetUser(ctx, 1)
+	var se *httpclient.StatusError
+	if !errors.Is(err, usersclient.ErrServer) || !errors.As(err, &se) || se.Code != http.StatusServiceUnavailable {
+		t.Errorf("with retries: %v, want ErrServer wrapping httpclient's StatusError", err)
+	}
+	if calls != 3 {
+		t.Errorf("GET with retries: %d calls, want 3", calls)
+	}
+
+	calls = 0
+	_, err = newClient(t, srv, usersclient.WithRetry(fast)).CreateUser(ctx, "Alice")
+	if !errors.Is(err, usersclient.ErrServer) || calls != 3 {
+		t.Errorf("CreateUser with retries: %v after %d calls, want ErrServer after 3", err, calls)
+	}
+
+	calls = 0
+	_, err = newClient(t, srv, usersclient.WithRetry(fast)).ImportUsers(ctx, strings.NewReader("name\nAlice\n"))
+	if !errors.Is(err, usersclient.ErrServer) || calls != 1 {
+		t.Errorf("ImportUsers with retries: %v after %d calls, want ErrServer after 1", err, calls)
+	}
+}
+
+func TestClientAPIKeys(t *testing.T) {
+	keys, err := apikeys.Open(filepath.Join(t.TempDir(), "keys.db"), apikeys.Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer keys.Close()
+	srv := serve(t, keys)
+	ctx := context.Background()
+	_, secret, err := keys.Create(ctx, "test", 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := newClient(t, srv, usersclient.WithAPIKey(secret))
+	if _, err := c.CreateUser(ctx, "Alice"); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := c.GetUser(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	// With keys, reads need one too, and the admin token is not one.
+	for _, other := range []*usersclient.Client{newClient(t, srv), newClient(t, srv, usersclient.WithBearerToken(adminToken))} {
+		if _, err := other.ListUsers(ctx); !errors.Is(err, usersclient.ErrUnauthorized) {
+			t.Errorf("ListUsers without a key: %v, want ErrUnauthorized", err)
+		}
+	}
+
+	// The third request a minute is the key's last.
+	if _, err := c.ListUsers(ctx); err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.ListUsers(ctx)
+	if !errors.Is(err, usersclient.ErrRateLimited) || apiError(t, err).RetryAfter() <= 0 {
+		t.Errorf("fourth request: %v, want ErrRateLimited with a Retry-After", err)
+	}
+}
+
+func TestClientOptions(t *testing.T) {
+	for name, opts := range map[string][]usersclient.Option{
+		"no base URL":  nil,
+		"bad base URL": {usersclient.WithBaseURL("localhost:8080")},
+		"empty key":    {usersclient.WithBaseURL("http://localhost"), usersclient.WithAPIKey("")},
+		"empty token":  {usersclient.WithBaseURL("http://localhost"), usersclient.WithBearerToken("")},
+	} {
+		if _, err := usersclient.New(opts...); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+// Package email renders templated emails, builds MIME messages with
+// attachments, and sends them over SMTP with retries.
+package email
+
+import (
+	"bytes"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// Message is one email. HTML and Text are alternative renderings of the
+// same body; mail clients show the best one they support. Set both: some
+// clients and many spam filters distrust HTML-only mail.
+type Message struct {
+	From        string // "Name <addr>" or "addr"
+	To          []string
+	Subject     string
+	Text        string
+	HTML        string
+	Attachments []Attachment
+}
+
+// Attachment is a file sent with a message. An empty ContentType is
+// guessed from the file extension.
+type Attachment struct {
+	Filename    string
+	ContentType string
+	Data        []byte
+}
+
+// Envelope returns the SMTP envelope: the bare sender and recipient
+// addresses, which may differ from the display headers.
+func (m *Message) Envelope() (from string, to []string, err error) {
+	f, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return "", nil, fmt.Errorf("from %q: %w", m.From, err)
+	}
+	if len(m.To) == 0 {
+		return "", nil, errors.New("no recipients")
+	}
+	for _, addr := range m.To {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return "", nil, fmt.Errorf("to %q: %w", addr, err)
+		}
+		to = append(to, a.Address)
+	}
+	return f.Address, to, nil
+}
+
+// Bytes encodes the message in MIME format, ready for SMTP DATA:
+//
+//	multipart/mixed            (only with attachments)
+//	├── multipart/alternative
+//	│   ├── text/plain         (quoted-printable)
+//	│   └── text/html          (quoted-printable)
+//	└── attachment             (base64)
+func (m *Message) Bytes() ([]byte, error) {
+	from, err := mail.ParseAddress(m.From)
+	if err != nil {
+		return nil, fmt.Errorf("from %q: %w", m.From, err)
+	}
+	var to []string
+	for _, addr := range m.To {
+		a, err := mail.ParseAddress(addr)
+		if err != nil {
+			return nil, fmt.Errorf("to %q: %w", addr, err)
+		}
+		to = append(to, a.String()) // encodes non-ASCII display names
+	}
+	if len(to) == 0 {
+		return nil, errors.New("no recipients")
+	}
+	if strings.ContainsAny(m.Subject, "\r\n") {
+		// A newline in a header starts a new header: "Subject: hi\r\nBcc: ..."
+		return nil, errors.New("subject contains a line break")
+	}
+
+	var buf bytes.Buffer
+	header := textproto.MIMEHeader{}
+	header.Set("From", from.String())
+	header.Set("To", strings.Join(to, ", "))
+	header.Set("Subject", mime.QEncoding.Encode("utf-8", m.Subject))
+	header.Set("Date", time.Now().Format(time.RFC1123Z))
+	header.Set("Message-Id", messageID(from.Address))
+	header.Set("MIME-Version", "1.0")
+
+	body := multipart.NewWriter(&buf) // alternative, or mixed around it
+	if len(m.Attachments) == 0 {
+		header.Set("Content-Type", "multipart/alternative; boundary="+body.Boundary())
+		writeHeader(&buf, header)
+		if err := writeAlternatives(body, m); err != nil {
+			return nil, err
+		}
+		if err := body.Close(); err != nil { // writes the closing boundary
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+
+	header.Set("Content-Type", "multipart/mixed; boundary="+body.Boundary())
+	writeHeader(&buf, header)
+	// The nested part's header must name its boundary before the part is
+	// written, so pick the boundary first.
+	altBoundary := multipart.NewWriter(io.Discard).Boundary()
+	part, err := body.CreatePart(textproto.MIMEHeader{
+		"Content-Type": {"multipart/alternative; boundary=" + altBoundary},
+	})
+	if err != nil {
+		return nil, err
+	}
+	alt := multipart.NewWriter(part)
+	if err := alt.SetBoundary(altBoundary); err != nil {
+		return nil, err
+	}
+	if err := writeAlternatives(alt, m); err != nil {
+		return nil, err
+	}
+	if err := alt.Close(); err != nil {
+		return nil, err
+	}
+	for _, a := range m.Attachments {
+		if err := writeAttachment(body, a); err != nil {
+			return nil, err
+		}
+	}
+	if err := body.Close(); err != nil { // writes the closing boundary
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeHeader(w io.Writer, h textproto.MIMEHeader) {
+	for _, k := range []string{"From", "To", "Subject", "Date", "Message-Id", "MIME-Version", "Content-Type"} {
+		fmt.Fprintf(w, "%s: %s\r\n", k, h.Get(k))
+	}
+	io.WriteString(w, "\r\n")
+}
+
+// writeAlternatives writes the plain-text and HTML bodies, plainest first
+// as RFC 2046 asks: clients show the last part they can display.
+func writeAlternatives(w *multipart.Writer, m *Message) error {
+	for _, p := range []struct{ contentType, body string }{
+		{"text/plain; charset=utf-8", m.Text},
+		{"text/html; charset=utf-8", m.HTML},
+	} {
+		if p.body == "" {
+			continue
+		}
+		part, err := w.CreatePart(textproto.MIMEHeader{
+			"Content-Type":              {p.contentType},
+			"Content-Transfer-Encoding": {"quoted-printable"},
+		})
+		if err != nil {
+			return err
+		}
+		// Quoted-printable keeps lines under SMTP's 1000-byte limit and
+		// survives 7-bit relays.
+		qp := quotedprintable.NewWriter(part)
+		if _, err := io.WriteString(qp, p.body); err != nil {
+			return err
+		}
+		if err := qp.Close(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeAttachment(w *multipart.Writer, a Attachment) error {
+	contentType := a.ContentType
+	if contentType == "" {
+		contentType = mime.TypeByExtension(filepath.Ext(a.Filename))
+	}
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	part, err := w.CreatePart(textproto.MIMEHeader{
+		"Content-Type":              {contentType},
+		"Content-Transfer-Encoding": {"base64"},
+		// FormatMediaType quotes the name, and RFC 2231-encodes it if it
+		// is not ASCII.
+		"Content-Disposition": {mime.FormatMediaType("attachment", map[string]string{"filename": a.Filename})},
+	})
+	if err != nil {
+		return err
+	}
+	// Base64 in 76-character lines, as MIME requires.
+	enc := base64.StdEncoding.EncodeToString(a.Data)
+	for len(enc) > 76 {
+		io.WriteString(part, enc[:76]+"\r\n")
+		enc = enc[76:]
+	}
+	_, err = io.WriteString(part, enc+"\r\n")
+	return err
+}
+
+// messageID returns a unique Message-ID in the sender's domain.
+func messageID(from string) string {
+	_, domain, ok := strings.Cut(from, "@")
+	if !ok {
+		domain = "localhost"
+	}
+	return "<" + rand.Text() + "@" + domain + ">"
+}
@@ -0,0 +1,202 @@
+package email
+
+import (
+	"bytes"
+	"encoding/base64"
+	"io"
+	"mime"
+	"mime/multipart"
+	"net/mail"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+// part is a decoded leaf of a MIME tree.
+type part struct {
+	contentType string
+	filename    string
+	body        string
+}
+
+// parse reads raw the way a mail client would and returns the header and
+// the leaf parts in order, decoded.
+func parse(t *testing.T, raw []byte) (mail.Header, []part) {
+	t.Helper()
+	m, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		t.Fatalf("ReadMessage: %v", err)
+	}
+	var parts []part
+	var walk func(contentType string, r io.Reader)
+	walk = func(contentType string, r io.Reader) {
+		mediaType, params, err := mime.ParseMediaType(contentType)
+		if err != nil {
+			t.Fatalf("content type %q: %v", contentType, err)
+		}
+		if !strings.HasPrefix(mediaType, "multipart/") {
+			body, _ := io.ReadAll(r)
+			// Text travels with CRLF line endings, whatever the sender used.
+			parts = append(parts, part{contentType: mediaType, body: strings.ReplaceAll(string(body), "\r\n", "\n")})
+			return
+		}
+		mr := multipart.NewReader(r, params["boundary"])
+		for {
+			p, err := mr.NextPart() // decodes quoted-printable itself
+			if err == io.EOF {
+				return
+			}
+			if err != nil {
+				t.Fatalf("NextPart: %v", err)
+			}
+			if p.FileName() != "" {
+				data, _ := io.ReadAll(base64Reader(p))
+				parts = append(parts, part{contentType: p.Header.Get("Content-Type"), filename: p.FileName(), body: string(data)})
+				continue
+			}
+			walk(p.Header.Get("Content-Type"), p)
+		}
+	}
+	walk(m.Header.Get("Content-Type"), m.Body)
+	return m.Header, parts
+}
+
+func TestMessage_Bytes(t *testing.T) {
+	pdf := bytes.Repeat([]byte("%PDF-1.7 binary \x00\xff "), 20) // longer than one base64 line
+	msg := &Message{
+		From:    "Shop <shop@example.com>",
+		To:      []string{"Zoë <zoe@example.com>", "bob@example.com"},
+		Subject: "Your invoice — März",
+		Text:    "Hi Zoë, see attached.\n" + strings.Repeat("long line ", 20),
+		HTML:    "<p>Hi Zoë, see attached.</p>",
+		Attachments: []Attachment{
+			{Filename: "invoice.pdf", Data: pdf},
+			{Filename: "notes.txt", ContentType: "text/plain", Data: []byte("thanks")},
+		},
+	}
+	raw, err := msg.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range strings.Split(string(raw), "\r\n") {
+		if len(line) > 998 {
+			t.Fatalf("line of %d bytes; SMTP allows 998", len(line))
+		}
+	}
+
+	header, parts := parse(t, raw)
+	subject, _ := new(mime.WordDecoder).DecodeHeader(header.Get("Subject"))
+	if subject != msg.Subject {
+		t.Errorf("subject = %q; want %q", subject, msg.Subject)
+	}
+	to, err := header.AddressList("To")
+	if err != nil || len(to) != 2 || to[0].Name != "Zoë" || to[1].Address != "bob@example.com" {
+		t.Errorf("To = %v, %v; want both recipients with Zoë's name decoded", to, err)
+	}
+	if !strings.Contains(header.Get("Message-Id"), "@example.com>") {
+		t.Errorf("Message-Id = %q; want one in the sender's domain", header.Get("Message-Id"))
+	}
+
+	want := []part{
+		{contentType: "text/plain", body: msg.Text},
+		{contentType: "text/html", body: msg.HTML},
+		{contentType: "application/pdf", filename: "invoice.pdf", body: string(pdf)},
+		{contentType: "text/plain", filename: "notes.txt", body: "thanks"},
+	}
+	if len(parts) != len(want) {
+		t.Fatalf("got %d parts; want %d: %+v", len(parts), len(want), parts)
+	}
+	for i, w := range want {
+		if parts[i] != w {
+			t.Errorf("part %d = %+v; want %+v", i, parts[i], w)
+		}
+	}
+}
+
+func TestMessage_BytesWithoutAttachments(t *testing.T) {
+	msg := &Message{From: "a@example.com", To: []string{"b@example.com"}, Subject: "hi", Text: "plain", HTML: "<b>html</b>"}
+	raw, err := msg.Bytes()
+	if err != nil {
+		t.Fatal(err)
+	}
+	header, parts := parse(t, raw)
+	if !strings.HasPrefix(header.Get("Content-Type"), "multipart/alternative") || len(parts) != 2 {
+		t.Fatalf("Content-Type %q with %d parts; want multipart/alternative with 2", header.Get("Content-Type"), len(parts))
+	}
+}
+
+func TestMessage_RejectsHeaderInjection(t *testing.T) {
+	tests := map[string]*Message{
+		"subject": {From: "a@example.com", To: []string{"b@example.com"}, Subject: "hi\r\nBcc: everyone@example.com"},
+		"to":      {From: "a@example.com", To: []string{"b@example.com\r\nBcc: everyone@example.com"}},
+		"from":    {From: "a@example.com\nBcc: x@example.com", To: []string{"b@example.com"}},
+		"no rcpt": {From: "a@example.com"},
+	}
+	for name, msg := range tests {
+		if _, err := msg.Bytes(); err == nil {
+			t.Errorf("%s: Bytes accepted %+v", name, msg)
+		}
+	}
+}
+
+func TestRenderer_Welcome(t *testing.T) {
+	r, err := NewRenderer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var msg Message
+	data := map[string]any{
+		"Name":        `Eve <script>alert(1)</script> & co`,
+		"Site":        "Example",
+		"LoginURL":    "javascript:alert(1)",
+		"Attachments": []string{"guide.pdf"},
+	}
+	if err := r.Render(&msg, "welcome", data); err != nil {
+		t.Fatal(err)
+	}
+	if msg.Subject != `Welcome to Example, Eve <script>alert(1)</script> & co!` {
+		t.Errorf("subject = %q; want it unescaped: it is not HTML", msg.Subject)
+	}
+	if strings.Contains(msg.HTML, "<script>") || !strings.Contains(msg.HTML, "&lt;script&gt;") {
+		t.Errorf("HTML does not escape the name:\n%s", msg.HTML)
+	}
+	if strings.Contains(msg.HTML, "javascript:") {
+		t.Errorf("HTML keeps a javascript: URL:\n%s", msg.HTML)
+	}
+	if !strings.Contains(msg.Text, "Eve <script>alert(1)</script> & co") || !strings.Contains(msg.Text, "Attached: guide.pdf.") {
+		t.Errorf("text body:\n%s", msg.Text)
+	}
+}
+
+func TestRenderer_Errors(t *testing.T) {
+	r, err := NewRenderer(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg := Message{Subject: "unchanged"}
+	if err := r.Render(&msg, "welcome", map[string]any{"Name": "x"}); err == nil {
+		t.Error("missing Site and LoginURL: want an error")
+	}
+	if msg.Subject != "unchanged" {
+		t.Errorf("subject = %q; a failed render must not touch the message", msg.Subject)
+	}
+	if err := r.Render(&msg, "nope", nil); err == nil {
+		t.Error("unknown email: want an error")
+	}
+
+	onlyHTML := fstest.MapFS{
+		"layout.html": {Data: []byte(`{{block "content" .}}{{end}}`)},
+		"reset.html":  {Data: []byte(`{{define "subject"}}x{{end}}{{define "content"}}y{{end}}`)},
+	}
+	r, err = NewRenderer(onlyHTML)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := r.Render(&msg, "reset", nil); err == nil || !strings.Contains(err.Error(), "reset.txt") {
+		t.Errorf("err = %v; want it to name the missing text template", err)
+	}
+}
+
+func base64Reader(r io.Reader) io.Reader {
+	return base64.NewDecoder(base64.StdEncoding, r)
+}
@@ -0,0 +1,130 @@
+package email
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"time"
+
+	"golang_roadmap/12_operations/03_retry/retry"
+)
+
+// Sender delivers messages to one SMTP server (a relay such as SES,
+// Postmark or a local Postfix, not the recipients' servers).
+type Sender struct {
+	Addr string    // host:port
+	Auth smtp.Auth // nil: no authentication
+	// TLS, if set, is used for STARTTLS when the server offers it.
+	// smtp.PlainAuth refuses to send credentials without TLS except to
+	// localhost.
+	TLS *tls.Config
+	// Timeout bounds one attempt, from dial to QUIT (default 30s).
+	Timeout time.Duration
+	// Retry is the policy for transient failures. The zero value makes 3
+	// attempts.
+	Retry retry.Policy
+}
+
+// Send delivers msg, retrying transient failures: network errors and 4xx
+// replies ("try again later"). 5xx replies ("mailbox does not exist") fail
+// at once.
+//
+// A retry after a lost reply to DATA can deliver the message twice, since
+// the server may have accepted it. Mail clients tolerate this better than
+// a lost email; it is the same at-least-once trade-off as any queue.
+func (s *Sender) Send(ctx context.Context, msg *Message) error {
+	from, to, err := msg.Envelope()
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	body, err := msg.Bytes()
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	return retry.Do(ctx, s.Retry, func(ctx context.Context) error {
+		err := s.sendOnce(ctx, from, to, body)
+		if err != nil && ctx.Err() != nil {
+			return ctx.Err() // closing the connection on cancel shows up as a network error
+		}
+		return classify(err)
+	})
+}
+
+// classify marks errors that retrying cannot fix.
+func classify(err error) error {
+	var reply *textproto.Error
+	if errors.As(err, &reply) && reply.Code >= 500 {
+		return retry.Permanent(err)
+	}
+	return err
+}
+
+// sendOnce is smtp.SendMail with a context and a deadline: SendMail has
+// neither, and a stalled server would hang it forever.
+func (s *Sender) sendOnce(ctx context.Context, from string, to []string, body []byte) error {
+	timeout := s.Timeout
+	if timeout <= 0 {
+		timeout = 30 * time.Second
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", s.Addr)
+	if err != nil {
+		return err
+	}
+	deadline, _ := ctx.Deadline()
+	conn.SetDeadline(deadline)
+	stop := context.AfterFunc(ctx, func() { conn.Close() }) // cancellation, not just the deadline
+	defer stop()
+
+	host, _, _ := net.SplitHostPort(s.Addr)
+	c, err := smtp.NewClient(conn, host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer c.Close()
+	if err := c.Hello("localhost"); err != nil {
+		return err
+	}
+	if ok, _ := c.Extension("STARTTLS"); ok && s.TLS != nil {
+		if err := c.StartTLS(s.TLS); err != nil {
+			return err
+		}
+	}
+	if s.Auth != nil {
+		if ok, _ := c.Extension("AUTH"); !ok {
+			return retry.Permanent(errors.New("smtp: server does not support AUTH"))
+		}
+		if err := c.Auth(s.Auth); err != nil {
+			return err
+		}
+	}
+	if err := c.Mail(from); err != nil {
+		return err
+	}
+	for _, rcpt := range to {
+		if err := c.Rcpt(rcpt); err != nil {
+			return fmt.Errorf("rcpt %s: %w", rcpt, err)
+		}
+	}
+	w, err := c.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(body); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil { // the server's verdict on the message
+		return err
+	}
+	// Accepted. A failed QUIT must not trigger a retry and a duplicate.
+	c.Quit()
+	return nil
+}
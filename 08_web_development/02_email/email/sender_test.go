@@ -0,0 +1,163 @@
+package email
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/smtp"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/08_web_development/02_email/email/smtptest"
+	"golang_roadmap/12_operations/03_retry/retry"
+)
+
+func newTestSender(t *testing.T) (*Sender, *smtptest.Server, *[]error) {
+	t.Helper()
+	srv := smtptest.NewServer()
+	t.Cleanup(srv.Close)
+	var retried []error
+	s := &Sender{
+		Addr:    srv.Addr,
+		Timeout: time.Second,
+		Retry: retry.Policy{
+			MaxAttempts: 3,
+			Initial:     time.Millisecond,
+			OnRetry:     func(_ int, err error, _ time.Duration) { retried = append(retried, err) },
+		},
+	}
+	return s, srv, &retried
+}
+
+func testMessage() *Message {
+	return &Message{
+		From:        "Shop <shop@example.com>",
+		To:          []string{"Ann <ann@example.com>"},
+		Subject:     "Hello",
+		Text:        "hi\n.\nA line with only a dot must survive.",
+		HTML:        "<p>hi</p>",
+		Attachments: []Attachment{{Filename: "a.txt", Data: []byte("attached")}},
+	}
+}
+
+func TestSend_Delivers(t *testing.T) {
+	s, srv, retried := newTestSender(t)
+	srv.RequireAuth("shop", "s3cret")
+	s.Auth = smtp.PlainAuth("", "shop", "s3cret", "127.0.0.1")
+
+	if err := s.Send(context.Background(), testMessage()); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	got := srv.Messages()
+	if len(got) != 1 || len(*retried) != 0 {
+		t.Fatalf("delivered %d, retried %v; want 1 delivery first time", len(got), *retried)
+	}
+	m := got[0]
+	if m.From != "shop@example.com" || len(m.To) != 1 || m.To[0] != "ann@example.com" || m.User != "shop" {
+		t.Errorf("envelope from=%q to=%v user=%q; want bare addresses and the authenticated user", m.From, m.To, m.User)
+	}
+	_, parts := parse(t, m.Data)
+	if len(parts) != 3 || !strings.Contains(parts[0].body, "\n.\n") || parts[2].body != "attached" {
+		t.Errorf("parts = %+v; want text (dot line intact), html and the attachment", parts)
+	}
+}
+
+func TestSend_RetriesTransientFailures(t *testing.T) {
+	tests := []struct {
+		name    string
+		command string
+		reply   string
+	}{
+		{"greylisted", "RCPT", "451 4.7.1 greylisted, try again later"},
+		{"server busy", "CONNECT", "421 4.3.2 too busy"},
+		{"connection dropped during DATA", "DATA", ""},
+		{"mailbox full", "DATA", "452 4.2.2 mailbox full"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, srv, retried := newTestSender(t)
+			srv.FailNext(tt.command, tt.reply)
+			if err := s.Send(context.Background(), testMessage()); err != nil {
+				t.Fatalf("Send: %v", err)
+			}
+			if len(srv.Messages()) != 1 || len(*retried) != 1 {
+				t.Fatalf("delivered %d after %d retries; want 1 after 1", len(srv.Messages()), len(*retried))
+			}
+		})
+	}
+}
+
+func TestSend_PermanentFailureIsNotRetried(t *testing.T) {
+	s, srv, retried := newTestSender(t)
+	srv.FailNext("RCPT", "550 5.1.1 no such user")
+	err := s.Send(context.Background(), testMessage())
+	var reply *textproto.Error
+	if !errors.As(err, &reply) || reply.Code != 550 {
+		t.Fatalf("err = %v; want the 550 reply", err)
+	}
+	if len(*retried) != 0 || len(srv.Messages()) != 0 {
+		t.Fatalf("retried %d times; a 5xx reply must not be retried", len(*retried))
+	}
+}
+
+func TestSend_GivesUpAfterMaxAttempts(t *testing.T) {
+	s, srv, retried := newTestSender(t)
+	for range 3 {
+		srv.FailNext("MAIL", "451 4.3.0 local error")
+	}
+	if err := s.Send(context.Background(), testMessage()); err == nil || !strings.Contains(err.Error(), "after 3 attempts") {
+		t.Fatalf("err = %v; want failure after 3 attempts", err)
+	}
+	if len(*retried) != 2 {
+		t.Fatalf("retried %d times; want 2", len(*retried))
+	}
+}
+
+func TestSend_BadAuthIsPermanent(t *testing.T) {
+	s, srv, retried := newTestSender(t)
+	srv.RequireAuth("shop", "s3cret")
+	s.Auth = smtp.PlainAuth("", "shop", "wrong", "127.0.0.1")
+	if err := s.Send(context.Background(), testMessage()); err == nil || len(*retried) != 0 {
+		t.Fatalf("err = %v after %d retries; want an immediate failure", err, len(*retried))
+	}
+}
+
+func TestSend_StalledServerTimesOut(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+	go func() { // accepts, never greets
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			defer c.Close()
+		}
+	}()
+	s := &Sender{Addr: ln.Addr().String(), Timeout: 50 * time.Millisecond, Retry: retry.Policy{MaxAttempts: 2, Initial: time.Millisecond}}
+
+	start := time.Now()
+	if err := s.Send(context.Background(), testMessage()); err == nil {
+		t.Fatal("Send to a stalled server succeeded")
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("Send took %v; want each attempt bounded by the 50ms timeout", took)
+	}
+}
+
+func TestSend_ContextCancelled(t *testing.T) {
+	s, srv, _ := newTestSender(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := s.Send(ctx, testMessage()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v; want context.Canceled", err)
+	}
+	if len(srv.Messages()) != 0 {
+		t.Fatal("delivered despite a cancelled context")
+	}
+}
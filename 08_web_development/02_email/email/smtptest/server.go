@@ -0,0 +1,230 @@
+// Package smtptest runs an in-memory SMTP server for tests, in the spirit
+// of net/http/httptest. It accepts every message, or fails on demand.
+package smtptest
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"sync"
+)
+
+// Message is one message the server accepted.
+type Message struct {
+	From string
+	To   []string
+	Data []byte // as sent after DATA, dot-unstuffed
+	User string // from AUTH PLAIN, if any
+}
+
+// Server is a minimal SMTP server: EHLO, AUTH PLAIN, MAIL, RCPT, DATA,
+// RSET, NOOP and QUIT. Enough for net/smtp, not a mail server.
+type Server struct {
+	Addr string // host:port to dial
+
+	ln net.Listener
+	wg sync.WaitGroup
+
+	mu       sync.Mutex
+	messages []Message
+	faults   []fault
+	password map[string]string // user -> password; nil: any credentials
+}
+
+type fault struct {
+	command string // "MAIL", "RCPT", "DATA" (after the body) or "CONNECT"
+	reply   string // e.g. "451 try again later"; "" drops the connection
+}
+
+// NewServer starts a server on a random local port. Close it when done.
+func NewServer() *Server {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(fmt.Sprintf("smtptest: %v", err))
+	}
+	s := &Server{Addr: ln.Addr().String(), ln: ln}
+	s.wg.Add(1)
+	go s.serve()
+	return s
+}
+
+// Close stops the server and waits for open sessions to end.
+func (s *Server) Close() {
+	s.ln.Close()
+	s.wg.Wait()
+}
+
+// RequireAuth makes AUTH PLAIN accept only user and password.
+func (s *Server) RequireAuth(user, password string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.password = map[string]string{user: password}
+}
+
+// FailNext queues a failure: the next time a client sends command, the
+// server answers with reply instead of accepting it. An empty reply
+// closes the connection without answering. Command is MAIL, RCPT, DATA
+// (fails after the body is received) or CONNECT (the greeting).
+func (s *Server) FailNext(command, reply string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.faults = append(s.faults, fault{command, reply})
+}
+
+// Messages returns the accepted messages in order.
+func (s *Server) Messages() []Message {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]Message(nil), s.messages...)
+}
+
+func (s *Server) serve() {
+	defer s.wg.Done()
+	for {
+		conn, err := s.ln.Accept()
+		if err != nil {
+			return
+		}
+		s.wg.Add(1)
+		go func() {
+			defer s.wg.Done()
+			defer conn.Close()
+			s.session(textproto.NewConn(conn))
+		}()
+	}
+}
+
+// takeFault removes and returns the first queued fault for command.
+func (s *Server) takeFault(command string) (fault, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i, f := range s.faults {
+		if f.command == command {
+			s.faults = append(s.faults[:i], s.faults[i+1:]...)
+			return f, true
+		}
+	}
+	return fault{}, false
+}
+
+// errDrop ends a session without a reply.
+var errDrop = errors.New("drop connection")
+
+// inject answers with a queued fault for command, if there is one. It
+// reports whether it did.
+func (s *Server) inject(c *textproto.Conn, command string) (bool, error) {
+	f, ok := s.takeFault(command)
+	if !ok {
+		return false, nil
+	}
+	if f.reply == "" {
+		return true, errDrop
+	}
+	return true, c.PrintfLine("%s", f.reply)
+}
+
+func (s *Server) session(c *textproto.Conn) {
+	if injected, _ := s.inject(c, "CONNECT"); injected {
+		return // a refused or dropped connection
+	}
+	c.PrintfLine("220 smtptest ready")
+	var msg Message
+	var user string
+	for {
+		line, err := c.ReadLine()
+		if err != nil {
+			return
+		}
+		verb, arg, _ := strings.Cut(line, " ")
+		switch strings.ToUpper(verb) {
+		case "EHLO", "HELO":
+			c.PrintfLine("250-smtptest greets %s", arg)
+			c.PrintfLine("250-8BITMIME")
+			c.PrintfLine("250 AUTH PLAIN")
+		case "AUTH":
+			u, ok := s.checkPlain(arg)
+			if !ok {
+				c.PrintfLine("535 5.7.8 authentication failed")
+				continue
+			}
+			user = u
+			c.PrintfLine("235 2.7.0 authenticated")
+		case "MAIL":
+			if injected, err := s.inject(c, "MAIL"); err != nil {
+				return
+			} else if injected {
+				continue
+			}
+			msg = Message{From: address(arg), User: user}
+			c.PrintfLine("250 2.1.0 ok")
+		case "RCPT":
+			if injected, err := s.inject(c, "RCPT"); err != nil {
+				return
+			} else if injected {
+				continue
+			}
+			msg.To = append(msg.To, address(arg))
+			c.PrintfLine("250 2.1.5 ok")
+		case "DATA":
+			c.PrintfLine("354 end data with <CR><LF>.<CR><LF>")
+			data, err := io.ReadAll(c.DotReader())
+			if err != nil {
+				return
+			}
+			if injected, err := s.inject(c, "DATA"); err != nil {
+				return
+			} else if injected {
+				continue
+			}
+			msg.Data = data
+			s.mu.Lock()
+			s.messages = append(s.messages, msg)
+			s.mu.Unlock()
+			msg = Message{}
+			c.PrintfLine("250 2.0.0 queued")
+		case "RSET":
+			msg = Message{}
+			c.PrintfLine("250 ok")
+		case "NOOP":
+			c.PrintfLine("250 ok")
+		case "QUIT":
+			c.PrintfLine("221 bye")
+			return
+		default:
+			c.PrintfLine("502 5.5.2 command not implemented")
+		}
+	}
+}
+
+// checkPlain checks "PLAIN <base64(authzid NUL user NUL password)>".
+func (s *Server) checkPlain(arg string) (string, bool) {
+	mech, resp, _ := strings.Cut(arg, " ")
+	if !strings.EqualFold(mech, "PLAIN") {
+		return "", false
+	}
+	raw, err := base64.StdEncoding.DecodeString(resp)
+	if err != nil {
+		return "", false
+	}
+	parts := strings.Split(string(raw), "\x00")
+	if len(parts) != 3 {
+		return "", false
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.password != nil && s.password[parts[1]] != parts[2] {
+		return "", false
+	}
+	return parts[1], true
+}
+
+// address extracts the path from "FROM:<a@b> SIZE=..." or "TO:<a@b>".
+func address(arg string) string {
+	_, rest, _ := strings.Cut(arg, ":")
+	rest, _, _ = strings.Cut(strings.TrimSpace(rest), " ")
+	return strings.Trim(rest, "<>")
+}
@@ -0,0 +1,92 @@
+package email
+
+import (
+	"bytes"
+	"embed"
+	"fmt"
+	htmltemplate "html/template"
+	"io/fs"
+	"path"
+	"strings"
+	texttemplate "text/template"
+)
+
+//go:embed templates
+var templates embed.FS
+
+// Renderer renders named emails from templates. Each email has two files:
+//
+//	NAME.html  html/template, wrapped in layout.html; defines "subject" and "content"
+//	NAME.txt   text/template; defines "subject", the rest is the body
+//
+// The HTML goes through html/template, so data is escaped for its context:
+// a user named <script> gets &lt;script&gt;, and a javascript: URL in an
+// href is replaced. The subject and plain text use text/template, because
+// HTML escaping would show up as literal &amp; in them.
+type Renderer struct {
+	html map[string]*htmltemplate.Template
+	text map[string]*texttemplate.Template
+}
+
+// NewRenderer parses the templates in fsys. Pass nil for the built-in ones.
+func NewRenderer(fsys fs.FS) (*Renderer, error) {
+	if fsys == nil {
+		sub, err := fs.Sub(templates, "templates")
+		if err != nil {
+			return nil, err
+		}
+		fsys = sub
+	}
+	r := &Renderer{html: map[string]*htmltemplate.Template{}, text: map[string]*texttemplate.Template{}}
+	htmlFiles, err := fs.Glob(fsys, "*.html")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range htmlFiles {
+		if f == "layout.html" {
+			continue
+		}
+		t, err := htmltemplate.New("layout.html").Option("missingkey=error").ParseFS(fsys, "layout.html", f)
+		if err != nil {
+			return nil, err
+		}
+		r.html[strings.TrimSuffix(f, ".html")] = t
+	}
+	textFiles, err := fs.Glob(fsys, "*.txt")
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range textFiles {
+		t, err := texttemplate.New(path.Base(f)).Option("missingkey=error").ParseFS(fsys, f)
+		if err != nil {
+			return nil, err
+		}
+		r.text[strings.TrimSuffix(f, ".txt")] = t
+	}
+	return r, nil
+}
+
+// Render fills in msg.Subject, msg.HTML and msg.Text from the email called
+// name. Templates are executed in full before msg is touched, so a
+// template error leaves msg unchanged.
+func (r *Renderer) Render(msg *Message, name string, data any) error {
+	h, ok := r.html[name]
+	t, ok2 := r.text[name]
+	if !ok || !ok2 {
+		return fmt.Errorf("email %q: need both %[1]s.html and %[1]s.txt", name)
+	}
+	var subject, html, text bytes.Buffer
+	if err := t.ExecuteTemplate(&subject, "subject", data); err != nil {
+		return fmt.Errorf("email %q subject: %w", name, err)
+	}
+	if err := h.Execute(&html, data); err != nil {
+		return fmt.Errorf("email %q html: %w", name, err)
+	}
+	if err := t.Execute(&text, data); err != nil {
+		return fmt.Errorf("email %q text: %w", name, err)
+	}
+	msg.Subject = strings.TrimSpace(subject.String())
+	msg.HTML = html.String()
+	msg.Text = strings.TrimSpace(text.String()) + "\n"
+	return nil
+}
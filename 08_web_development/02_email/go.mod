@@ -0,0 +1,13 @@
+module golang_roadmap/08_web_development/02_email
+
+go 1.24.11
+
+require golang_roadmap/12_operations/03_retry v0.0.0
+
+require golang_roadmap/04_Tooling_testing_and_code_quality/07_clock v0.0.0 // indirect
+
+// The retry and clock packages live in their own modules in this repository.
+replace (
+	golang_roadmap/04_Tooling_testing_and_code_quality/07_clock => ../../04_Tooling_testing_and_code_quality/07_clock
+	golang_roadmap/12_operations/03_retry => ../../12_operations/03_retry
+)
@@ -0,0 +1,102 @@
+// Demonstrates rendering and sending email with net/smtp.
+//
+// This example shows:
+// - An html/template body inside a shared layout, plus a plain-text part
+// - multipart/alternative and attachments with mime/multipart
+// - Retrying transient SMTP failures (4xx replies, dropped connections)
+// - Failing fast on permanent ones (5xx replies)
+// - An in-memory SMTP server for tests and demos
+//
+// By default it sends to the in-memory server. To see the message in a
+// real inbox UI, start Mailpit and point SMTP_ADDR at it:
+//
+//	docker run -d --name mailpit -p 1025:1025 -p 8025:8025 axllent/mailpit
+//	SMTP_ADDR=localhost:1025 go run .
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"golang_roadmap/08_web_development/02_email/email"
+	"golang_roadmap/08_web_development/02_email/email/smtptest"
+	"golang_roadmap/12_operations/03_retry/retry"
+)
+
+func main() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+
+	addr := os.Getenv("SMTP_ADDR")
+	var srv *smtptest.Server
+	if addr == "" {
+		srv = smtptest.NewServer()
+		defer srv.Close()
+		addr = srv.Addr
+	}
+	sender := &email.Sender{
+		Addr:    addr,
+		Timeout: 5 * time.Second,
+		Retry: retry.Policy{
+			MaxAttempts: 4,
+			Initial:     200 * time.Millisecond,
+			OnRetry: func(attempt int, err error, wait time.Duration) {
+				log.Printf("attempt %d failed: %v; retrying in %v", attempt, err, wait.Round(time.Millisecond))
+			},
+		},
+	}
+
+	renderer, err := email.NewRenderer(nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	msg := &email.Message{
+		From: "Roadmap Shop <shop@example.com>",
+		To:   []string{"Ann Smith <ann@example.com>"},
+		Attachments: []email.Attachment{{
+			Filename:    "getting-started.txt",
+			ContentType: "text/plain; charset=utf-8",
+			Data:        []byte("1. Log in\n2. Fill in your profile\n3. Have fun\n"),
+		}},
+	}
+	err = renderer.Render(msg, "welcome", map[string]any{
+		"Name":        "Ann",
+		"Site":        "Roadmap Shop",
+		"LoginURL":    "https://shop.example.com/login",
+		"Attachments": []string{"getting-started.txt"},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("=== Transient failures are retried ===")
+	if srv != nil {
+		srv.FailNext("RCPT", "451 4.7.1 greylisted, try again later")
+		srv.FailNext("DATA", "") // drop the connection mid-send
+	}
+	if err := sender.Send(ctx, msg); err != nil {
+		log.Fatalf("send: %v", err)
+	}
+	fmt.Printf("  sent %q to %v\n", msg.Subject, msg.To)
+
+	if srv == nil {
+		fmt.Println("  open http://localhost:8025 to read it")
+		return
+	}
+
+	fmt.Println("\n=== Permanent failures are not ===")
+	srv.FailNext("RCPT", "550 5.1.1 no such user")
+	bounced := *msg
+	bounced.To = []string{"nobody@example.com"}
+	start := time.Now()
+	err = sender.Send(ctx, &bounced)
+	fmt.Printf("  %v (after %v, no retries)\n", err, time.Since(start).Round(time.Millisecond))
+
+	fmt.Println("\n=== What the server received ===")
+	for _, m := range srv.Messages() {
+		fmt.Printf("MAIL FROM:<%s> RCPT TO:%v\n\n%s\n", m.From, m.To, m.Data)
+	}
+}
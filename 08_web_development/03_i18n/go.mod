@@ -0,0 +1,5 @@
+module golang_roadmap/08_web_development/03_i18n
+
+go 1.24.11
+
+require golang.org/x/text v0.28.0
@@ -0,0 +1,93 @@
+// Package i18n translates user-facing text with golang.org/x/text: a
+// message catalog per language, plural forms, locale-aware numbers, and
+// the choice of language from an HTTP Accept-Language header.
+//
+// Messages are keyed by their source text, the English format string, as
+// x/text does it: code calls p.Sprintf("Invalid user ID") and reads the
+// same as it did before translation. A key with no translation prints in
+// the fallback language.
+package i18n
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+	"golang.org/x/text/message/catalog"
+)
+
+// Messages maps source keys to their translations in one language. A
+// plain translation is catalog.String("..."); one that depends on a
+// number is plural.Selectf(...).
+type Messages map[string]catalog.Message
+
+// Bundle holds the translations of an application and chooses among them.
+type Bundle struct {
+	tags    []language.Tag // tags[0] is the fallback
+	matcher language.Matcher
+	cat     *catalog.Builder
+}
+
+// NewBundle builds a bundle from translations, which must include the
+// fallback language. Every key must exist in the fallback's messages: a
+// key that is only in a translation is a typo, and NewBundle reports it.
+// Keys a language lacks are filled in from the fallback.
+func NewBundle(fallback language.Tag, translations map[language.Tag]Messages) (*Bundle, error) {
+	base, ok := translations[fallback]
+	if !ok {
+		return nil, fmt.Errorf("i18n: no messages for the fallback language %s", fallback)
+	}
+	tags := []language.Tag{fallback}
+	for t := range translations {
+		if t != fallback {
+			tags = append(tags, t)
+		}
+	}
+	slices.SortFunc(tags[1:], func(a, b language.Tag) int { return strings.Compare(a.String(), b.String()) })
+
+	cat := catalog.NewBuilder(catalog.Fallback(fallback))
+	for _, t := range tags {
+		msgs := translations[t]
+		for key, m := range msgs {
+			if _, ok := base[key]; !ok {
+				return nil, fmt.Errorf("i18n: %s: %q is not a %s message", t, key, fallback)
+			}
+			if err := cat.Set(t, key, m); err != nil {
+				return nil, fmt.Errorf("i18n: %s: %q: %w", t, key, err)
+			}
+		}
+		// The catalog's own fallback only covers languages it has no
+		// messages for at all, so fill in the gaps explicitly.
+		for key, m := range base {
+			if _, ok := msgs[key]; !ok {
+				if err := cat.Set(t, key, m); err != nil {
+					return nil, fmt.Errorf("i18n: %s: %q: %w", t, key, err)
+				}
+			}
+		}
+	}
+	return &Bundle{tags: tags, matcher: language.NewMatcher(tags), cat: cat}, nil
+}
+
+// Languages returns the supported languages, the fallback first.
+func (b *Bundle) Languages() []language.Tag { return slices.Clone(b.tags) }
+
+// Match picks the supported language that best fits an Accept-Language
+// header ("de-AT,de;q=0.9,en;q=0.5"). It understands regional variants and
+// related languages: de-AT gets German, and with no match at all, or a
+// malformed header, it returns the fallback.
+func (b *Bundle) Match(acceptLanguage string) language.Tag {
+	// A malformed header carries no usable preference; ParseAcceptLanguage
+	// returns no tags for it, and the matcher then picks the fallback.
+	prefs, _, _ := language.ParseAcceptLanguage(acceptLanguage)
+	_, i, _ := b.matcher.Match(prefs...)
+	return b.tags[i]
+}
+
+// Printer returns a printer for tag. Besides translating, its Sprintf
+// formats numbers for the language: 1,234.5 in English, 1.234,5 in German.
+func (b *Bundle) Printer(tag language.Tag) *message.Printer {
+	return message.NewPrinter(tag, message.Catalog(b.cat))
+}
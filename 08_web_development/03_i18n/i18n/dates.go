@@ -0,0 +1,48 @@
+package i18n
+
+import (
+	"fmt"
+	"time"
+
+	"golang.org/x/text/language"
+)
+
+// x/text formats numbers but not dates: CLDR date patterns and month
+// names are not part of it. For the few languages an application
+// supports, a table is enough.
+var dateFormats = map[language.Base]func(t time.Time) string{
+	mustBase("en"): func(t time.Time) string { return t.Format("January 2, 2006") },
+	mustBase("de"): func(t time.Time) string {
+		return fmt.Sprintf("%d. %s %d", t.Day(), monthsDE[t.Month()-1], t.Year())
+	},
+	mustBase("fr"): func(t time.Time) string {
+		day := fmt.Sprint(t.Day())
+		if t.Day() == 1 {
+			day = "1er" // premier
+		}
+		return fmt.Sprintf("%s %s %d", day, monthsFR[t.Month()-1], t.Year())
+	},
+	mustBase("pl"): func(t time.Time) string {
+		return fmt.Sprintf("%d %s %d", t.Day(), monthsPL[t.Month()-1], t.Year())
+	},
+}
+
+var (
+	monthsDE = [12]string{"Januar", "Februar", "März", "April", "Mai", "Juni", "Juli", "August", "September", "Oktober", "November", "Dezember"}
+	monthsFR = [12]string{"janvier", "février", "mars", "avril", "mai", "juin", "juillet", "août", "septembre", "octobre", "novembre", "décembre"}
+	// Polish dates put the month in the genitive: 2 stycznia, not 2 styczeń.
+	monthsPL = [12]string{"stycznia", "lutego", "marca", "kwietnia", "maja", "czerwca", "lipca", "sierpnia", "września", "października", "listopada", "grudnia"}
+)
+
+// FormatDate writes t as a long date in the conventions of tag's
+// language: "March 5, 2024", "5. März 2024", "5 mars 2024", "5 marca
+// 2024". Languages without an entry get ISO 8601, which no one misreads.
+func FormatDate(tag language.Tag, t time.Time) string {
+	base, _ := tag.Base()
+	if f, ok := dateFormats[base]; ok {
+		return f(t)
+	}
+	return t.Format(time.DateOnly)
+}
+
+func mustBase(s string) language.Base { return language.MustParseBase(s) }
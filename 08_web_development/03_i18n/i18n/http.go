@@ -0,0 +1,40 @@
+package i18n
+
+import (
+	"context"
+	"net/http"
+
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+type printerKey struct{}
+
+// Middleware picks the language of each request from its Accept-Language
+// header and stores a Printer for it in the request context. Responses
+// say which language they are in (Content-Language) and that they depend
+// on the header (Vary), so caches keep one copy per language.
+func (b *Bundle) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tag := b.Match(r.Header.Get("Accept-Language"))
+		w.Header().Add("Vary", "Accept-Language")
+		w.Header().Set("Content-Language", tag.String())
+		ctx := context.WithValue(r.Context(), printerKey{}, b.Printer(tag))
+		next.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
+
+// FromContext returns the Printer that Middleware stored in ctx. Outside
+// the middleware it returns an English printer with no translations, so
+// messages come out as their keys.
+func FromContext(ctx context.Context) *message.Printer {
+	if p, ok := ctx.Value(printerKey{}).(*message.Printer); ok {
+		return p
+	}
+	return message.NewPrinter(language.English)
+}
+
+// Error is http.Error with msg translated for the request.
+func Error(w http.ResponseWriter, r *http.Request, msg string, code int) {
+	http.Error(w, FromContext(r.Context()).Sprintf(msg), code)
+}
@@ -0,0 +1,162 @@
+package i18n
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message/catalog"
+)
+
+func testBundle(t *testing.T) *Bundle {
+	t.Helper()
+	b, err := NewBundle(language.English, map[language.Tag]Messages{
+		language.English: {
+			"Not found": catalog.String("Not found"),
+			"Goodbye":   catalog.String("Goodbye"),
+			"%d files": plural.Selectf(1, "%d",
+				plural.One, "%d file",
+				plural.Other, "%d files"),
+		},
+		language.German: {
+			"Not found": catalog.String("Nicht gefunden"),
+			"%d files": plural.Selectf(1, "%d",
+				plural.One, "%d Datei",
+				plural.Other, "%d Dateien"),
+		},
+		language.Polish: {
+			"Not found": catalog.String("Nie znaleziono"),
+			"%d files": plural.Selectf(1, "%d",
+				plural.One, "%d plik",
+				plural.Few, "%d pliki",
+				plural.Many, "%d plików",
+				plural.Other, "%d pliku"),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+func TestMatch(t *testing.T) {
+	b := testBundle(t)
+	for header, want := range map[string]string{
+		"de":                      "de",
+		"de-CH":                   "de",
+		"pl-PL,pl;q=0.9,en;q=0.8": "pl",
+		"fr,de;q=0.5":             "de", // no French: the next preference
+		"en;q=0.1,pl":             "pl", // q-values, not order, rank
+		"ja":                      "en",
+		"":                        "en",
+		"*":                       "en",
+		"de;q=x,,;":               "en", // malformed
+	} {
+		if got := b.Match(header); got.String() != want {
+			t.Errorf("Match(%q) = %s; want %s", header, got, want)
+		}
+	}
+}
+
+func TestPrinter_Plurals(t *testing.T) {
+	b := testBundle(t)
+	tests := []struct {
+		tag  language.Tag
+		n    int
+		want string
+	}{
+		{language.English, 1, "1 file"},
+		{language.English, 2, "2 files"},
+		{language.German, 1, "1 Datei"},
+		{language.German, 1000, "1.000 Dateien"},
+		{language.Polish, 1, "1 plik"},
+		{language.Polish, 3, "3 pliki"},
+		{language.Polish, 5, "5 plików"},
+		{language.Polish, 12, "12 plików"}, // teens are "many"
+		{language.Polish, 22, "22 pliki"},
+	}
+	for _, tt := range tests {
+		if got := b.Printer(tt.tag).Sprintf("%d files", tt.n); got != tt.want {
+			t.Errorf("%s: Sprintf(%d) = %q; want %q", tt.tag, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestPrinter_FallsBackForMissingKeys(t *testing.T) {
+	b := testBundle(t)
+	if got := b.Printer(language.German).Sprintf("Goodbye"); got != "Goodbye" {
+		t.Errorf("untranslated key = %q; want the English text", got)
+	}
+	if got := b.Printer(language.German).Sprintf("Never registered %d", 1234); got != "Never registered 1.234" {
+		t.Errorf("unknown key = %q; want the key, formatted for German", got)
+	}
+}
+
+func TestNewBundle_Errors(t *testing.T) {
+	_, err := NewBundle(language.English, map[language.Tag]Messages{
+		language.German: {"Hallo": catalog.String("Hallo")},
+	})
+	if err == nil || !strings.Contains(err.Error(), "fallback") {
+		t.Errorf("no fallback messages: err = %v", err)
+	}
+	_, err = NewBundle(language.English, map[language.Tag]Messages{
+		language.English: {"Not found": catalog.String("Not found")},
+		language.German:  {"Not fund": catalog.String("Nicht gefunden")},
+	})
+	if err == nil || !strings.Contains(err.Error(), `"Not fund"`) {
+		t.Errorf("typo in a key: err = %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	b := testBundle(t)
+	h := b.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Error(w, r, "Not found", http.StatusNotFound)
+	}))
+
+	for header, want := range map[string]string{"pl": "Nie znaleziono", "de-AT": "Nicht gefunden", "": "Not found"} {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest("GET", "/", nil)
+		req.Header.Set("Accept-Language", header)
+		h.ServeHTTP(rec, req)
+		if got := strings.TrimSpace(rec.Body.String()); got != want || rec.Code != http.StatusNotFound {
+			t.Errorf("Accept-Language %q: %d %q; want 404 %q", header, rec.Code, got, want)
+		}
+		if rec.Header().Get("Vary") != "Accept-Language" || rec.Header().Get("Content-Language") == "" {
+			t.Errorf("Accept-Language %q: headers %v; want Vary and Content-Language", header, rec.Header())
+		}
+	}
+
+	// Without the middleware, messages come out untranslated.
+	rec := httptest.NewRecorder()
+	Error(rec, httptest.NewRequest("GET", "/", nil), "Not found", http.StatusNotFound)
+	if got := strings.TrimSpace(rec.Body.String()); got != "Not found" {
+		t.Errorf("without middleware: %q; want the key", got)
+	}
+}
+
+func TestFormatDate(t *testing.T) {
+	d := time.Date(2024, time.March, 5, 12, 0, 0, 0, time.UTC)
+	first := time.Date(2024, time.May, 1, 0, 0, 0, 0, time.UTC)
+	for _, tt := range []struct {
+		tag  string
+		t    time.Time
+		want string
+	}{
+		{"en", d, "March 5, 2024"},
+		{"en-GB", d, "March 5, 2024"}, // by language only: no regional tables
+		{"de-AT", d, "5. März 2024"},
+		{"fr", d, "5 mars 2024"},
+		{"fr", first, "1er mai 2024"},
+		{"pl", d, "5 marca 2024"},
+		{"ja", d, "2024-03-05"},
+	} {
+		if got := FormatDate(language.MustParse(tt.tag), tt.t); got != tt.want {
+			t.Errorf("FormatDate(%s, %v) = %q; want %q", tt.tag, tt.t.Format(time.DateOnly), got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+// Demonstrates internationalization with golang.org/x/text.
+//
+// This example shows:
+// - A message catalog in English, German, French and Polish
+// - Choosing a language from an Accept-Language header
+// - Plural forms, including Polish with its one/few/many
+// - Numbers, percentages and currency amounts per locale
+// - Long dates per locale
+// - Translated error messages from an HTTP handler
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"golang.org/x/text/currency"
+	"golang.org/x/text/feature/plural"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message/catalog"
+	"golang.org/x/text/number"
+
+	"golang_roadmap/08_web_development/03_i18n/i18n"
+)
+
+// translations are keyed by the English text the code uses.
+var translations = map[language.Tag]i18n.Messages{
+	language.English: {
+		"Hello, %s!": catalog.String("Hello, %s!"),
+		"%d new messages": plural.Selectf(1, "%d",
+			"=0", "No new messages",
+			plural.One, "%d new message",
+			plural.Other, "%d new messages"),
+		"User not found": catalog.String("User not found"),
+	},
+	language.German: {
+		"Hello, %s!": catalog.String("Hallo, %s!"),
+		"%d new messages": plural.Selectf(1, "%d",
+			"=0", "Keine neuen Nachrichten",
+			plural.One, "%d neue Nachricht",
+			plural.Other, "%d neue Nachrichten"),
+		"User not found": catalog.String("Benutzer nicht gefunden"),
+	},
+	language.French: {
+		"Hello, %s!": catalog.String("Bonjour, %s !"),
+		// In French, 0 and 1 are both singular.
+		"%d new messages": plural.Selectf(1, "%d",
+			plural.One, "%d nouveau message",
+			plural.Other, "%d nouveaux messages"),
+		// "User not found" is missing: it falls back to English.
+	},
+	language.Polish: {
+		"Hello, %s!": catalog.String("Cześć, %s!"),
+		// 1 wiadomość; 2-4, 22-24... wiadomości; 0, 5-21, 25... wiadomości.
+		"%d new messages": plural.Selectf(1, "%d",
+			plural.One, "%d nowa wiadomość",
+			plural.Few, "%d nowe wiadomości",
+			plural.Many, "%d nowych wiadomości",
+			plural.Other, "%d nowej wiadomości"), // fractions
+		"User not found": catalog.String("Nie znaleziono użytkownika"),
+	},
+}
+
+func main() {
+	bundle, err := i18n.NewBundle(language.English, translations)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("=== Accept-Language negotiation ===")
+	for _, header := range []string{
+		"de-AT,de;q=0.9,en;q=0.5",
+		"fr-CA",
+		"ja,pl;q=0.8",
+		"nl-BE,nl;q=0.9",
+		"",
+		"not a header;;",
+	} {
+		fmt.Printf("%-26q -> %s\n", header, bundle.Match(header))
+	}
+
+	fmt.Println("\n=== Plurals ===")
+	for _, tag := range bundle.Languages() {
+		p := bundle.Printer(tag)
+		fmt.Printf("%s: %s\n", tag, p.Sprintf("Hello, %s!", "Ada"))
+		for _, n := range []int{0, 1, 2, 5, 22, 1000} {
+			fmt.Printf("  %s\n", p.Sprintf("%d new messages", n))
+		}
+	}
+
+	fmt.Println("\n=== Numbers and dates ===")
+	when := time.Date(2024, time.March, 1, 0, 0, 0, 0, time.UTC)
+	for _, tag := range bundle.Languages() {
+		p := bundle.Printer(tag)
+		fmt.Printf("%s: %-14s %-6s %-14s %s\n", tag,
+			p.Sprintf("%.2f", 1234567.891),
+			p.Sprint(number.Percent(0.256)),
+			p.Sprint(currency.Symbol(currency.EUR.Amount(1234.5))),
+			i18n.FormatDate(tag, when))
+	}
+
+	fmt.Println("\n=== HTTP errors in the client's language ===")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		i18n.Error(w, r, "User not found", http.StatusNotFound)
+	})
+	srv := httptest.NewServer(bundle.Middleware(mux))
+	defer srv.Close()
+	for _, lang := range []string{"en-US", "de", "fr", "pl-PL"} {
+		req, _ := http.NewRequest(http.MethodGet, srv.URL+"/users/7", nil)
+		req.Header.Set("Accept-Language", lang)
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("%-6s %d Content-Language=%s %s", lang, resp.StatusCode, resp.Header.Get("Content-Language"), body)
+	}
+}
@@ -0,0 +1,85 @@
+// Demonstrates input validation and sanitization with the validate package.
+//
+// This example shows:
+// - Fluent rules per field: NonEmpty, MinLen/MaxLen, Range, Matches, OneOf, Custom
+// - Every failing field reported at once through errors.Join
+// - Field errors as data: a JSON error response built from them
+// - Cleaning names and text before validating them
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+	"time"
+
+	"golang_roadmap/08_web_development/04_validation/validate"
+)
+
+var usernameRe = regexp.MustCompile(`^[a-z][a-z0-9_]*$`)
+
+type Signup struct {
+	Username string        `json:"username"`
+	Name     string        `json:"name"`
+	Age      int           `json:"age"`
+	Plan     string        `json:"plan"`
+	Password string        `json:"password"`
+	Session  time.Duration `json:"session"`
+}
+
+// Validate cleans the free-text fields and checks them all.
+func (s *Signup) Validate() error {
+	s.Name = validate.Clean(s.Name)
+
+	var v validate.Validator
+	v.String("username", s.Username).NonEmpty().MinLen(3).MaxLen(20).
+		Matches(usernameRe, "lowercase letters, digits and underscores, starting with a letter")
+	v.String("name", s.Name).NonEmpty().MaxLen(100)
+	v.Int("age", s.Age).Range(13, 130)
+	v.String("plan", s.Plan).OneOf("free", "pro", "team")
+	v.String("password", s.Password).MinLen(12).
+		Custom(func(p string) bool { return p != s.Username }, "must differ from the username")
+	validate.Ordered(&v, "session", s.Session).Range(5*time.Minute, 24*time.Hour)
+	return v.Err()
+}
+
+func main() {
+	fmt.Println("=== A valid signup ===")
+	ok := Signup{Username: "ada", Name: "  Ada\u200b  Lovelace\n", Age: 36, Plan: "pro", Password: "correct horse battery", Session: time.Hour}
+	fmt.Printf("err=%v, name cleaned to %q\n", ok.Validate(), ok.Name)
+
+	fmt.Println("\n=== Every problem at once ===")
+	bad := Signup{Username: "Ada!", Name: "\t", Age: 7, Plan: "gold", Password: "Ada!", Session: time.Minute}
+	err := bad.Validate()
+	fmt.Println(err)
+
+	fmt.Println("\n=== As a JSON error response ===")
+	type fieldJSON struct {
+		Field   string `json:"field"`
+		Message string `json:"message"`
+	}
+	var body struct {
+		Error  string      `json:"error"`
+		Fields []fieldJSON `json:"fields"`
+	}
+	body.Error = "validation failed"
+	for _, fe := range validate.Fields(err) {
+		body.Fields = append(body.Fields, fieldJSON{fe.Field, fe.Message()})
+	}
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	_ = enc.Encode(body)
+
+	fmt.Println("\n=== Cleaning text ===")
+	for _, s := range []string{
+		"  Grace   Hopper ",
+		"invoice\u202egpj.exe",
+		"pay\u200bpal",
+		"caf\xe9 latte",
+		"bell\a and escape \x1b[2J",
+	} {
+		fmt.Printf("%-28q -> %q\n", s, validate.Clean(s))
+	}
+	fmt.Printf("%q\n", validate.CleanText("First line   \r\n\r\n\r\n\r\nSecond line\r\n\r\n"))
+}
@@ -0,0 +1,63 @@
+package validate
+
+import (
+	"strings"
+	"unicode"
+)
+
+// Sanitizing normalises input before it is validated and stored. It does
+// not make input safe for HTML, SQL or a shell; escaping for the output
+// (html/template, query parameters, exec without a shell) does that.
+
+const (
+	zwj  = '\u200D' // joins emoji: 👩 ZWJ 💻 is one picture
+	zwnj = '\u200C' // needed for correct spelling in Persian and Hindi
+)
+
+// Clean prepares a single-line value such as a name or a title: invalid
+// UTF-8 is dropped, as are control characters and invisible format
+// characters other than the joiners (zero-width spaces, bidi overrides
+// that make "exe.txt" show as "txt.exe"); runs of whitespace, newlines included, become one space;
+// and the ends are trimmed.
+func Clean(s string) string {
+	var b strings.Builder
+	b.Grow(len(s))
+	space := false
+	for _, r := range strings.ToValidUTF8(s, "") {
+		switch {
+		case unicode.IsSpace(r):
+			space = true
+		case unicode.IsControl(r):
+		case unicode.Is(unicode.Cf, r) && r != zwj && r != zwnj:
+		default:
+			if space && b.Len() > 0 {
+				b.WriteByte(' ')
+			}
+			space = false
+			b.WriteRune(r)
+		}
+	}
+	return b.String()
+}
+
+// CleanText prepares multi-line text such as a comment: each line is
+// cleaned as by Clean, line breaks become "\n", runs of blank lines become
+// one, and blank lines at the start and end are dropped.
+func CleanText(s string) string {
+	s = strings.ReplaceAll(s, "\r\n", "\n")
+	var lines []string
+	blank := 0
+	for line := range strings.SplitSeq(s, "\n") {
+		line = Clean(line)
+		if line == "" {
+			blank++
+			if blank > 1 || len(lines) == 0 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		lines = append(lines, line)
+	}
+	return strings.TrimRight(strings.Join(lines, "\n"), "\n")
+}
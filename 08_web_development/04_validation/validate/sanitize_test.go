@@ -0,0 +1,52 @@
+package validate
+
+import "testing"
+
+func TestClean(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"empty", "", ""},
+		{"plain", "Ada Lovelace", "Ada Lovelace"},
+		{"trims", "  Ada  ", "Ada"},
+		{"collapses spaces", "Ada \t  Lovelace", "Ada Lovelace"},
+		{"newlines become spaces", "Ada\r\nLovelace\n", "Ada Lovelace"},
+		{"non-breaking and ideographic spaces", "Ada\u00a0Lovelace\u3000", "Ada Lovelace"},
+		{"control characters", "Ada\x00\x07\x1b[31m", "Ada[31m"},
+		{"DEL and C1 controls", "a\x7fb\u0085c", "ab c"}, // U+0085 NEL is also a space
+		{"zero-width", "pay\u200bpal", "paypal"},
+		{"bidi override", "invoice\u202egpj.exe", "invoicegpj.exe"},
+		{"BOM", "\ufeffname", "name"},
+		{"invalid UTF-8", "caf\xc3\x28e", "caf(e"},
+		{"keeps accents and emoji", "Zoë 👍", "Zoë 👍"},
+		{"keeps combining marks", "Zoë", "Zoë"},
+		{"keeps joiners", "👩\u200d💻 \u0645\u06cc\u200c\u062e\u0648\u0627\u0647\u0645", "👩\u200d💻 \u0645\u06cc\u200c\u062e\u0648\u0627\u0647\u0645"},
+		{"only junk", "\u200b \x00\t", ""},
+	}
+	for _, tt := range tests {
+		if got := Clean(tt.in); got != tt.want {
+			t.Errorf("%s: Clean(%q) = %q; want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
+
+func TestCleanText(t *testing.T) {
+	tests := []struct {
+		name, in, want string
+	}{
+		{"empty", "", ""},
+		{"single line", "  hi  ", "hi"},
+		{"keeps line breaks", "one\ntwo", "one\ntwo"},
+		{"CRLF", "one\r\ntwo\r\n", "one\ntwo"},
+		{"trailing spaces", "one   \ntwo\t", "one\ntwo"},
+		{"one blank line kept", "one\n\ntwo", "one\n\ntwo"},
+		{"blank runs collapse", "one\n\n\n\n  \ntwo", "one\n\ntwo"},
+		{"leading and trailing blanks dropped", "\n\n one \n\n", "one"},
+		{"controls removed per line", "a\x00b\nc\u200bd", "ab\ncd"},
+	}
+	for _, tt := range tests {
+		if got := CleanText(tt.in); got != tt.want {
+			t.Errorf("%s: CleanText(%q) = %q; want %q", tt.name, tt.in, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,186 @@
+// Package validate checks input field by field and reports every problem
+// at once:
+//
+//	var v validate.Validator
+//	v.String("name", u.Name).NonEmpty().MaxLen(100)
+//	v.Int("age", u.Age).Range(0, 150)
+//	v.String("email", u.Email).Matches(emailRe, "an email address")
+//	if err := v.Err(); err != nil {
+//		// err joins one *FieldError per failing field
+//	}
+//
+// Rules on one field stop at its first failure, so an empty name is
+// reported as empty and not also as too short.
+//
+// Messages are format strings with arguments ("must be at most %d
+// characters", 100) rather than finished text, so a caller can translate
+// them with a message.Printer before formatting.
+package validate
+
+import (
+	"cmp"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError is one field's failure.
+type FieldError struct {
+	Field  string
+	Format string // fmt format of the message, also its translation key
+	Args   []any
+}
+
+// Message formats the message without the field name.
+func (e *FieldError) Message() string { return fmt.Sprintf(e.Format, e.Args...) }
+
+func (e *FieldError) Error() string { return e.Field + ": " + e.Message() }
+
+// Fields returns the field errors in err, in the order they were found.
+// It looks through errors.Join and %w wrapping.
+func Fields(err error) []*FieldError {
+	var out []*FieldError
+	var walk func(error)
+	walk = func(err error) {
+		switch e := err.(type) {
+		case nil:
+		case *FieldError:
+			out = append(out, e)
+		case interface{ Unwrap() []error }:
+			for _, inner := range e.Unwrap() {
+				walk(inner)
+			}
+		default:
+			walk(errors.Unwrap(err))
+		}
+	}
+	walk(err)
+	return out
+}
+
+// Validator collects field errors. The zero value is ready to use.
+type Validator struct {
+	errs []error
+}
+
+// Fail records a failure for field directly, for checks no rule covers,
+// such as one field depending on another.
+func (v *Validator) Fail(field, format string, args ...any) {
+	v.errs = append(v.errs, &FieldError{Field: field, Format: format, Args: args})
+}
+
+// Err returns the failures joined with errors.Join, or nil.
+func (v *Validator) Err() error { return errors.Join(v.errs...) }
+
+// Valid reports whether nothing has failed so far.
+func (v *Validator) Valid() bool { return len(v.errs) == 0 }
+
+// String starts the rules for a string field.
+func (v *Validator) String(field, value string) *StringRule {
+	return &StringRule{rule: rule{v: v, field: field}, value: value}
+}
+
+// Int starts the rules for an int field.
+func (v *Validator) Int(field string, value int) *OrderedRule[int] {
+	return Ordered(v, field, value)
+}
+
+// Ordered starts the rules for a field of any ordered type, such as
+// float64 or time.Duration. It is a function, not a method, because
+// methods cannot have type parameters.
+func Ordered[T cmp.Ordered](v *Validator, field string, value T) *OrderedRule[T] {
+	return &OrderedRule[T]{rule: rule{v: v, field: field}, value: value}
+}
+
+// rule is what every rule type shares: where to report, and whether the
+// field has already failed.
+type rule struct {
+	v      *Validator
+	field  string
+	failed bool
+}
+
+// check records a failure unless ok or the field already failed.
+func (r *rule) check(ok bool, format string, args ...any) {
+	if r.failed || ok {
+		return
+	}
+	r.failed = true
+	r.v.Fail(r.field, format, args...)
+}
+
+// StringRule holds the rules for one string field. Lengths count runes,
+// not bytes, so "Zoë" is 3 characters long.
+type StringRule struct {
+	rule
+	value string
+}
+
+func (r *StringRule) NonEmpty() *StringRule {
+	r.check(strings.TrimSpace(r.value) != "", "must not be empty")
+	return r
+}
+
+func (r *StringRule) MinLen(n int) *StringRule {
+	r.check(utf8.RuneCountInString(r.value) >= n, "must be at least %d characters", n)
+	return r
+}
+
+func (r *StringRule) MaxLen(n int) *StringRule {
+	r.check(utf8.RuneCountInString(r.value) <= n, "must be at most %d characters", n)
+	return r
+}
+
+// Matches requires re to match; want describes a match for the message,
+// as in "must be a slug". Anchor re with ^ and $ to match the whole value.
+func (r *StringRule) Matches(re *regexp.Regexp, want string) *StringRule {
+	r.check(re.MatchString(r.value), "must be %s", want)
+	return r
+}
+
+func (r *StringRule) OneOf(allowed ...string) *StringRule {
+	r.check(slices.Contains(allowed, r.value), "must be one of %s", strings.Join(allowed, ", "))
+	return r
+}
+
+// Custom applies a check the other rules do not cover. ok is not called
+// once the field has failed, so it may rely on the earlier rules.
+func (r *StringRule) Custom(ok func(string) bool, format string, args ...any) *StringRule {
+	if !r.failed {
+		r.check(ok(r.value), format, args...)
+	}
+	return r
+}
+
+// OrderedRule holds the rules for one numeric, or otherwise ordered,
+// field.
+type OrderedRule[T cmp.Ordered] struct {
+	rule
+	value T
+}
+
+// Range requires min <= value <= max.
+func (r *OrderedRule[T]) Range(min, max T) *OrderedRule[T] {
+	r.check(r.value >= min && r.value <= max, "must be between %v and %v", min, max)
+	return r
+}
+
+func (r *OrderedRule[T]) Min(min T) *OrderedRule[T] {
+	r.check(r.value >= min, "must be at least %v", min)
+	return r
+}
+
+func (r *OrderedRule[T]) Max(max T) *OrderedRule[T] {
+	r.check(r.value <= max, "must be at most %v", max)
+	return r
+}
+
+func (r *OrderedRule[T]) Custom(ok func(T) bool, format string, args ...any) *OrderedRule[T] {
+	if !r.failed {
+		r.check(ok(r.value), format, args...)
+	}
+	return r
+}
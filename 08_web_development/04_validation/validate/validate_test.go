@@ -0,0 +1,181 @@
+package validate
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+)
+
+var slugRe = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func TestStringRules(t *testing.T) {
+	tests := []struct {
+		name  string
+		value string
+		rules func(*StringRule)
+		want  string // "" for valid
+	}{
+		{"non-empty ok", "x", func(r *StringRule) { r.NonEmpty() }, ""},
+		{"empty", "", func(r *StringRule) { r.NonEmpty() }, "must not be empty"},
+		{"only spaces", " \t\n", func(r *StringRule) { r.NonEmpty() }, "must not be empty"},
+		{"min len ok", "abc", func(r *StringRule) { r.MinLen(3) }, ""},
+		{"min len", "ab", func(r *StringRule) { r.MinLen(3) }, "must be at least 3 characters"},
+		{"max len ok", "abc", func(r *StringRule) { r.MaxLen(3) }, ""},
+		{"max len", "abcd", func(r *StringRule) { r.MaxLen(3) }, "must be at most 3 characters"},
+		{"max len counts runes", "Zoë", func(r *StringRule) { r.MaxLen(3) }, ""},
+		{"max len emoji", "👍👍👍👍", func(r *StringRule) { r.MaxLen(3) }, "must be at most 3 characters"},
+		{"matches ok", "hello-world", func(r *StringRule) { r.Matches(slugRe, "a slug") }, ""},
+		{"matches", "Hello World", func(r *StringRule) { r.Matches(slugRe, "a slug") }, "must be a slug"},
+		{"matches anchored", "ok-slug!", func(r *StringRule) { r.Matches(slugRe, "a slug") }, "must be a slug"},
+		{"one of ok", "json", func(r *StringRule) { r.OneOf("text", "json") }, ""},
+		{"one of", "xml", func(r *StringRule) { r.OneOf("text", "json") }, "must be one of text, json"},
+		{"one of is case-sensitive", "JSON", func(r *StringRule) { r.OneOf("text", "json") }, "must be one of text, json"},
+		{"custom ok", "even", func(r *StringRule) { r.Custom(func(s string) bool { return len(s)%2 == 0 }, "must have even length") }, ""},
+		{"custom", "odd", func(r *StringRule) { r.Custom(func(s string) bool { return len(s)%2 == 0 }, "must have even length") }, "must have even length"},
+		{"custom args", "a", func(r *StringRule) { r.Custom(func(string) bool { return false }, "must not be %q", "a") }, `must not be "a"`},
+		{"chain ok", "go-lang", func(r *StringRule) { r.NonEmpty().MinLen(2).MaxLen(10).Matches(slugRe, "a slug") }, ""},
+		{"chain stops at first failure", "", func(r *StringRule) { r.NonEmpty().MinLen(2).Matches(slugRe, "a slug") }, "must not be empty"},
+		{"chain second rule", "a", func(r *StringRule) { r.NonEmpty().MinLen(2).Matches(slugRe, "a slug") }, "must be at least 2 characters"},
+		{"chain last rule", "A B", func(r *StringRule) { r.NonEmpty().MinLen(2).Matches(slugRe, "a slug") }, "must be a slug"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v Validator
+			tt.rules(v.String("f", tt.value))
+			checkOne(t, v.Err(), tt.want)
+		})
+	}
+}
+
+func TestOrderedRules(t *testing.T) {
+	even := func(n int) bool { return n%2 == 0 }
+	tests := []struct {
+		name  string
+		value int
+		rules func(*OrderedRule[int])
+		want  string
+	}{
+		{"range low edge", 1, func(r *OrderedRule[int]) { r.Range(1, 10) }, ""},
+		{"range high edge", 10, func(r *OrderedRule[int]) { r.Range(1, 10) }, ""},
+		{"below range", 0, func(r *OrderedRule[int]) { r.Range(1, 10) }, "must be between 1 and 10"},
+		{"above range", 11, func(r *OrderedRule[int]) { r.Range(1, 10) }, "must be between 1 and 10"},
+		{"negative range", -5, func(r *OrderedRule[int]) { r.Range(-10, -1) }, ""},
+		{"min ok", 3, func(r *OrderedRule[int]) { r.Min(3) }, ""},
+		{"min", 2, func(r *OrderedRule[int]) { r.Min(3) }, "must be at least 3"},
+		{"max ok", 3, func(r *OrderedRule[int]) { r.Max(3) }, ""},
+		{"max", 4, func(r *OrderedRule[int]) { r.Max(3) }, "must be at most 3"},
+		{"custom ok", 4, func(r *OrderedRule[int]) { r.Custom(even, "must be even") }, ""},
+		{"custom", 3, func(r *OrderedRule[int]) { r.Custom(even, "must be even") }, "must be even"},
+		{"chain stops at first failure", 11, func(r *OrderedRule[int]) { r.Max(10).Custom(even, "must be even") }, "must be at most 10"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var v Validator
+			tt.rules(v.Int("f", tt.value))
+			checkOne(t, v.Err(), tt.want)
+		})
+	}
+}
+
+func TestOrdered_OtherTypes(t *testing.T) {
+	var v Validator
+	Ordered(&v, "timeout", 90*time.Second).Range(time.Second, time.Minute)
+	Ordered(&v, "ratio", 0.5).Range(0, 1)
+	Ordered(&v, "grade", "C").Range("A", "B")
+	want := "timeout: must be between 1s and 1m0s\ngrade: must be between A and B"
+	if err := v.Err(); err == nil || err.Error() != want {
+		t.Errorf("err = %v; want\n%s", err, want)
+	}
+}
+
+func TestCustom_NotCalledAfterFailure(t *testing.T) {
+	var v Validator
+	called := false
+	v.String("f", "").NonEmpty().Custom(func(string) bool { called = true; return true }, "x")
+	if called {
+		t.Error("Custom ran after NonEmpty failed")
+	}
+}
+
+// checkOne asserts err is nil when want is "", else a single field error
+// for "f" with message want.
+func checkOne(t *testing.T, err error, want string) {
+	t.Helper()
+	fields := Fields(err)
+	if want == "" {
+		if err != nil {
+			t.Fatalf("err = %v; want valid", err)
+		}
+		return
+	}
+	if len(fields) != 1 {
+		t.Fatalf("got %d field errors (%v); want 1", len(fields), err)
+	}
+	if fields[0].Field != "f" || fields[0].Message() != want {
+		t.Errorf("got %q: %q; want f: %q", fields[0].Field, fields[0].Message(), want)
+	}
+	if err.Error() != "f: "+want {
+		t.Errorf("Error() = %q", err.Error())
+	}
+}
+
+func TestValidator_ReportsEveryField(t *testing.T) {
+	var v Validator
+	if !v.Valid() || v.Err() != nil {
+		t.Fatal("zero Validator is not valid")
+	}
+	v.String("name", "").NonEmpty()
+	v.String("slug", "ok").Matches(slugRe, "a slug")
+	v.Int("age", -1).Min(0)
+	v.Fail("password", "must differ from %s", "name")
+	if v.Valid() {
+		t.Error("Valid() = true after failures")
+	}
+
+	fields := Fields(v.Err())
+	var got []string
+	for _, f := range fields {
+		got = append(got, f.Error())
+	}
+	want := []string{"name: must not be empty", "age: must be at least 0", "password: must differ from name"}
+	if strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Errorf("fields = %q; want %q", got, want)
+	}
+}
+
+func TestFields(t *testing.T) {
+	a := &FieldError{Field: "a", Format: "bad"}
+	b := &FieldError{Field: "b", Format: "worse"}
+	tests := []struct {
+		name string
+		err  error
+		want []*FieldError
+	}{
+		{"nil", nil, nil},
+		{"single", a, []*FieldError{a}},
+		{"joined", errors.Join(a, b), []*FieldError{a, b}},
+		{"wrapped", fmt.Errorf("create user: %w", a), []*FieldError{a}},
+		{"wrapped join", fmt.Errorf("create user: %w", errors.Join(a, b)), []*FieldError{a, b}},
+		{"nested join", errors.Join(errors.Join(a), errors.New("other"), b), []*FieldError{a, b}},
+		{"other errors", errors.New("boom"), nil},
+	}
+	for _, tt := range tests {
+		got := Fields(tt.err)
+		if len(got) != len(tt.want) {
+			t.Errorf("%s: got %v; want %v", tt.name, got, tt.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != tt.want[i] {
+				t.Errorf("%s: [%d] = %v; want %v", tt.name, i, got[i], tt.want[i])
+			}
+		}
+	}
+	var fe *FieldError
+	if !errors.As(fmt.Errorf("x: %w", errors.Join(a, b)), &fe) || fe != a {
+		t.Errorf("errors.As found %v; want the first field error", fe)
+	}
+}
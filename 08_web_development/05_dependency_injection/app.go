@@ -0,0 +1,42 @@
+package main
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+
+	"golang_roadmap/08_web_development/05_dependency_injection/handler"
+	"golang_roadmap/08_web_development/05_dependency_injection/service"
+	"golang_roadmap/08_web_development/05_dependency_injection/store"
+)
+
+// App is the assembled application.
+type App struct {
+	Handler http.Handler
+}
+
+func newApp(h *handler.Handler) *App {
+	return &App{Handler: h.Routes()}
+}
+
+// logNotifier stands in for an email sender.
+type logNotifier struct{ log *slog.Logger }
+
+func newLogNotifier(log *slog.Logger) *logNotifier { return &logNotifier{log: log} }
+
+func (n *logNotifier) Welcome(_ context.Context, u store.User) error {
+	n.log.Info("Welcome email", "to", u.Email, "name", u.Name)
+	return nil
+}
+
+// buildApp is the composition root: the one place that knows every
+// concrete type and wires them together, bottom-up. Each layer receives
+// its dependencies through its constructor and sees them only as the
+// interfaces it declared. Swapping the in-memory store for a database
+// changes this function and nothing else.
+func buildApp(log *slog.Logger) *App {
+	users := store.NewMemory()
+	svc := service.NewUsers(users, newLogNotifier(log), log)
+	h := handler.New(svc, log)
+	return newApp(h)
+}
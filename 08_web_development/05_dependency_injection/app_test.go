@@ -0,0 +1,28 @@
+package main
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// Both composition roots must build a working app: a request goes through
+// the handler, the service and the store and comes back.
+func TestAssembly(t *testing.T) {
+	log := slog.New(slog.DiscardHandler)
+	for name, build := range map[string]func(*slog.Logger) *App{"buildApp": buildApp, "wire": initializeApp} {
+		app := build(log)
+		rec := httptest.NewRecorder()
+		app.Handler.ServeHTTP(rec, httptest.NewRequest("POST", "/users", strings.NewReader(`{"name":"Ada","email":"ada@example.com"}`)))
+		if rec.Code != http.StatusCreated {
+			t.Fatalf("%s: POST %d %s", name, rec.Code, rec.Body)
+		}
+		rec = httptest.NewRecorder()
+		app.Handler.ServeHTTP(rec, httptest.NewRequest("GET", "/users/1", nil))
+		if rec.Code != http.StatusOK || !strings.Contains(rec.Body.String(), `"ada@example.com"`) {
+			t.Errorf("%s: GET %d %s", name, rec.Code, rec.Body)
+		}
+	}
+}
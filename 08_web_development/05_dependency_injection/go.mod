@@ -0,0 +1,5 @@
+module golang_roadmap/08_web_development/05_dependency_injection
+
+go 1.24.11
+
+require github.com/google/wire v0.7.0
@@ -0,0 +1,107 @@
+// Package handler serves the users API over HTTP. It decodes requests,
+// calls the service and maps its errors to status codes; the rules live in
+// the service.
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+
+	"golang_roadmap/08_web_development/05_dependency_injection/service"
+	"golang_roadmap/08_web_development/05_dependency_injection/store"
+)
+
+// UserService is the part of *service.Users the handlers call. Declaring
+// it here keeps the handlers testable with a fake, and documents exactly
+// what they depend on.
+type UserService interface {
+	List(ctx context.Context) ([]store.User, error)
+	Get(ctx context.Context, id int) (store.User, error)
+	Register(ctx context.Context, name, email string) (store.User, error)
+}
+
+type Handler struct {
+	users UserService
+	log   *slog.Logger
+}
+
+func New(users UserService, log *slog.Logger) *Handler {
+	return &Handler{users: users, log: log}
+}
+
+// Routes returns a mux with the API's routes.
+func (h *Handler) Routes() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", h.list)
+	mux.HandleFunc("GET /users/{id}", h.get)
+	mux.HandleFunc("POST /users", h.create)
+	return mux
+}
+
+func (h *Handler) list(w http.ResponseWriter, r *http.Request) {
+	users, err := h.users.List(r.Context())
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.json(w, http.StatusOK, users)
+}
+
+func (h *Handler) get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(r.PathValue("id"))
+	if err != nil {
+		http.Error(w, "Invalid user ID", http.StatusBadRequest)
+		return
+	}
+	u, err := h.users.Get(r.Context(), id)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.json(w, http.StatusOK, u)
+}
+
+func (h *Handler) create(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name  string `json:"name"`
+		Email string `json:"email"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	u, err := h.users.Register(r.Context(), body.Name, body.Email)
+	if err != nil {
+		h.fail(w, r, err)
+		return
+	}
+	h.json(w, http.StatusCreated, u)
+}
+
+// fail maps service errors to responses. Anything unexpected is logged
+// and hidden from the client.
+func (h *Handler) fail(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, service.ErrNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, service.ErrInvalid):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, service.ErrEmailTaken):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		h.log.Error("Request failed", "method", r.Method, "path", r.URL.Path, "err", err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+}
+
+func (h *Handler) json(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		h.log.Error("Encoding response", "err", err)
+	}
+}
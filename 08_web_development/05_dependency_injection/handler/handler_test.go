@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang_roadmap/08_web_development/05_dependency_injection/service"
+	"golang_roadmap/08_web_development/05_dependency_injection/store"
+)
+
+// fakeUsers returns canned results, so each test controls exactly what
+// the service "did".
+type fakeUsers struct {
+	users []store.User
+	err   error
+}
+
+func (f *fakeUsers) List(context.Context) ([]store.User, error) { return f.users, f.err }
+
+func (f *fakeUsers) Get(_ context.Context, id int) (store.User, error) {
+	if f.err != nil {
+		return store.User{}, f.err
+	}
+	return store.User{ID: id, Name: "Ada"}, nil
+}
+
+func (f *fakeUsers) Register(_ context.Context, name, email string) (store.User, error) {
+	if f.err != nil {
+		return store.User{}, f.err
+	}
+	return store.User{ID: 1, Name: name, Email: email}, nil
+}
+
+func TestRoutes(t *testing.T) {
+	for _, tt := range []struct {
+		method, path, body string
+		err                error
+		code               int
+		want               string
+	}{
+		{"GET", "/users", "", nil, 200, "[]"},
+		{"GET", "/users/7", "", nil, 200, `{"id":7,"name":"Ada","email":""}`},
+		{"GET", "/users/x", "", nil, 400, "Invalid user ID"},
+		{"GET", "/users/7", "", fmt.Errorf("user 7: %w", service.ErrNotFound), 404, "user 7: user not found"},
+		{"POST", "/users", `{"name":"Ada","email":"a@b.c"}`, nil, 201, `{"id":1,"name":"Ada","email":"a@b.c"}`},
+		{"POST", "/users", `{"name":`, nil, 400, "Invalid JSON"},
+		{"POST", "/users", `{}`, service.ErrInvalid, 400, "invalid user"},
+		{"POST", "/users", `{}`, service.ErrEmailTaken, 409, "email already registered"},
+		{"POST", "/users", `{}`, errors.New("disk on fire"), 500, "Internal server error"}, // not leaked
+		{"DELETE", "/users", "", nil, 405, "Method Not Allowed"},
+	} {
+		h := New(&fakeUsers{users: []store.User{}, err: tt.err}, slog.New(slog.DiscardHandler))
+		rec := httptest.NewRecorder()
+		h.Routes().ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if got := strings.TrimSpace(rec.Body.String()); rec.Code != tt.code || got != tt.want {
+			t.Errorf("%s %s (service err %v) = %d %q; want %d %q", tt.method, tt.path, tt.err, rec.Code, got, tt.code, tt.want)
+		}
+	}
+}
@@ -0,0 +1,59 @@
+// Demonstrates dependency injection by hand, with wire as an alternative.
+//
+// This example shows:
+// - Layers that take their dependencies in constructors: store, service, handler
+// - Interfaces declared by the code that uses them, not by the implementer
+// - A composition root, buildApp, that is the only place naming concrete types
+// - The same graph generated by google/wire from a list of providers
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+)
+
+func main() {
+	useWire := flag.Bool("wire", false, "assemble the app with the wire-generated injector")
+	flag.Parse()
+
+	log := slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{
+		ReplaceAttr: func(_ []string, a slog.Attr) slog.Attr {
+			if a.Key == slog.TimeKey {
+				return slog.Attr{} // keep the output stable
+			}
+			return a
+		},
+	}))
+	app, how := buildApp(log), "buildApp"
+	if *useWire {
+		app, how = initializeApp(log), "wire"
+	}
+	fmt.Printf("=== Assembled with %s ===\n", how)
+
+	srv := httptest.NewServer(app.Handler)
+	defer srv.Close()
+	for _, c := range []struct{ method, path, body string }{
+		{"POST", "/users", `{"name":"Ada","email":"ada@example.com"}`},
+		{"POST", "/users", `{"name":"Ada again","email":"ADA@example.com"}`},
+		{"POST", "/users", `{"name":"","email":"nobody"}`},
+		{"GET", "/users", ""},
+		{"GET", "/users/1", ""},
+		{"GET", "/users/9", ""},
+	} {
+		req, _ := http.NewRequest(c.method, srv.URL+c.path, strings.NewReader(c.body))
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("%-4s %-9s %d %s", c.method, c.path, resp.StatusCode, body)
+	}
+}
@@ -0,0 +1,93 @@
+// Package service holds the rules for users, between the HTTP handlers and
+// the store.
+//
+// It declares the interfaces it needs, Store and Notifier, rather than
+// importing concrete types: the caller decides what to plug in, and tests
+// plug in fakes. Users is a concrete type; whoever needs an interface for
+// it declares one, as the handler package does.
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/mail"
+	"strings"
+
+	"golang_roadmap/08_web_development/05_dependency_injection/store"
+)
+
+// Store is what Users needs from storage. *store.Memory satisfies it.
+type Store interface {
+	List(ctx context.Context) ([]store.User, error)
+	Get(ctx context.Context, id int) (store.User, error)
+	FindByEmail(ctx context.Context, email string) (store.User, error)
+	Create(ctx context.Context, u store.User) (store.User, error)
+}
+
+// Notifier tells a new user they are registered, e.g. by email.
+type Notifier interface {
+	Welcome(ctx context.Context, u store.User) error
+}
+
+var (
+	ErrNotFound   = errors.New("user not found")
+	ErrInvalid    = errors.New("invalid user")
+	ErrEmailTaken = errors.New("email already registered")
+)
+
+// Users registers and looks up users.
+type Users struct {
+	store  Store
+	notify Notifier
+	log    *slog.Logger
+}
+
+// NewUsers takes every dependency as an argument: no globals, no
+// package-level defaults, nothing constructed inside.
+func NewUsers(s Store, n Notifier, log *slog.Logger) *Users {
+	return &Users{store: s, notify: n, log: log}
+}
+
+func (s *Users) List(ctx context.Context) ([]store.User, error) {
+	return s.store.List(ctx)
+}
+
+func (s *Users) Get(ctx context.Context, id int) (store.User, error) {
+	u, err := s.store.Get(ctx, id)
+	if errors.Is(err, store.ErrNotFound) {
+		return store.User{}, fmt.Errorf("user %d: %w", id, ErrNotFound)
+	}
+	return u, err
+}
+
+// Register creates a user with a unique email and sends the welcome
+// message. A failed notification is logged, not returned: the user
+// exists either way.
+func (s *Users) Register(ctx context.Context, name, email string) (store.User, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return store.User{}, fmt.Errorf("%w: name must not be empty", ErrInvalid)
+	}
+	addr, err := mail.ParseAddress(email)
+	if err != nil || addr.Name != "" {
+		return store.User{}, fmt.Errorf("%w: %q is not an email address", ErrInvalid, email)
+	}
+	_, err = s.store.FindByEmail(ctx, addr.Address)
+	switch {
+	case err == nil:
+		return store.User{}, fmt.Errorf("%s: %w", addr.Address, ErrEmailTaken)
+	case !errors.Is(err, store.ErrNotFound):
+		return store.User{}, err
+	}
+
+	u, err := s.store.Create(ctx, store.User{Name: name, Email: addr.Address})
+	if err != nil {
+		return store.User{}, err
+	}
+	if err := s.notify.Welcome(ctx, u); err != nil {
+		s.log.Warn("Welcome message failed", "user", u.ID, "err", err)
+	}
+	return u, nil
+}
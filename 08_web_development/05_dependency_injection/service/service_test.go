@@ -0,0 +1,88 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"golang_roadmap/08_web_development/05_dependency_injection/store"
+)
+
+// The service is tested against the real in-memory store and a fake
+// notifier; a fake store covers the failure the real one cannot produce.
+
+type fakeNotifier struct {
+	sent []store.User
+	err  error
+}
+
+func (n *fakeNotifier) Welcome(_ context.Context, u store.User) error {
+	n.sent = append(n.sent, u)
+	return n.err
+}
+
+// brokenStore fails lookups by email.
+type brokenStore struct{ Store }
+
+var errDown = errors.New("database down")
+
+func (brokenStore) FindByEmail(context.Context, string) (store.User, error) {
+	return store.User{}, errDown
+}
+
+func TestRegister(t *testing.T) {
+	ctx := context.Background()
+	n := &fakeNotifier{}
+	s := NewUsers(store.NewMemory(), n, slog.New(slog.DiscardHandler))
+
+	u, err := s.Register(ctx, "  Ada ", "ada@example.com")
+	if err != nil || u.ID != 1 || u.Name != "Ada" {
+		t.Fatalf("Register = %+v, %v", u, err)
+	}
+	if len(n.sent) != 1 || n.sent[0] != u {
+		t.Errorf("notified %+v; want the new user once", n.sent)
+	}
+
+	for _, tt := range []struct {
+		name, email string
+		want        error
+	}{
+		{"Ada", "ADA@example.com", ErrEmailTaken},
+		{"", "bob@example.com", ErrInvalid},
+		{"Bob", "bob", ErrInvalid},
+		{"Bob", "Bob <bob@example.com>", ErrInvalid},
+	} {
+		if _, err := s.Register(ctx, tt.name, tt.email); !errors.Is(err, tt.want) {
+			t.Errorf("Register(%q, %q) = %v; want %v", tt.name, tt.email, err, tt.want)
+		}
+	}
+	if len(n.sent) != 1 {
+		t.Errorf("notified %d times; want no messages for rejected users", len(n.sent))
+	}
+}
+
+func TestRegister_NotifierFailureKeepsUser(t *testing.T) {
+	ctx := context.Background()
+	s := NewUsers(store.NewMemory(), &fakeNotifier{err: errors.New("smtp down")}, slog.New(slog.DiscardHandler))
+	u, err := s.Register(ctx, "Ada", "ada@example.com")
+	if err != nil {
+		t.Fatalf("Register: %v; want the user created anyway", err)
+	}
+	if _, err := s.Get(ctx, u.ID); err != nil {
+		t.Errorf("Get: %v", err)
+	}
+}
+
+func TestStoreErrors(t *testing.T) {
+	ctx := context.Background()
+	s := NewUsers(store.NewMemory(), &fakeNotifier{}, slog.New(slog.DiscardHandler))
+	if _, err := s.Get(ctx, 7); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(missing) = %v; want ErrNotFound", err)
+	}
+
+	s = NewUsers(brokenStore{}, &fakeNotifier{}, slog.New(slog.DiscardHandler))
+	if _, err := s.Register(ctx, "Ada", "ada@example.com"); !errors.Is(err, errDown) {
+		t.Errorf("Register with a broken store = %v; want its error", err)
+	}
+}
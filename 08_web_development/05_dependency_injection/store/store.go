@@ -0,0 +1,70 @@
+// Package store keeps users in memory. It is the bottom layer of the app:
+// it knows nothing of HTTP or of the rules for a valid user.
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// User is a stored user.
+type User struct {
+	ID    int    `json:"id"`
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// ErrNotFound is returned for a user that does not exist.
+var ErrNotFound = errors.New("user not found")
+
+// Memory is a store safe for concurrent use. A database-backed store
+// would have the same methods.
+type Memory struct {
+	mu     sync.Mutex
+	users  []User
+	nextID int
+}
+
+func NewMemory() *Memory { return &Memory{nextID: 1} }
+
+func (m *Memory) List(_ context.Context) ([]User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return slices.Clone(m.users), nil
+}
+
+func (m *Memory) Get(_ context.Context, id int) (User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range m.users {
+		if u.ID == id {
+			return u, nil
+		}
+	}
+	return User{}, ErrNotFound
+}
+
+// FindByEmail looks a user up by email, ignoring case.
+func (m *Memory) FindByEmail(_ context.Context, email string) (User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, u := range m.users {
+		if strings.EqualFold(u.Email, email) {
+			return u, nil
+		}
+	}
+	return User{}, ErrNotFound
+}
+
+// Create stores u with a new ID and returns it.
+func (m *Memory) Create(_ context.Context, u User) (User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	u.ID = m.nextID
+	m.nextID++
+	m.users = append(m.users, u)
+	return u, nil
+}
@@ -0,0 +1,31 @@
+//go:build wireinject
+
+package main
+
+import (
+	"log/slog"
+
+	"github.com/google/wire"
+
+	"golang_roadmap/08_web_development/05_dependency_injection/handler"
+	"golang_roadmap/08_web_development/05_dependency_injection/service"
+	"golang_roadmap/08_web_development/05_dependency_injection/store"
+)
+
+// initializeApp is the wire version of buildApp. wire reads the providers
+// below, works out the order from their parameter and result types, and
+// writes the body to wire_gen.go. This file is only compiled by wire
+// itself (the wireinject tag); run go generate after editing it.
+func initializeApp(log *slog.Logger) *App {
+	wire.Build(
+		store.NewMemory,
+		wire.Bind(new(service.Store), new(*store.Memory)),
+		newLogNotifier,
+		wire.Bind(new(service.Notifier), new(*logNotifier)),
+		service.NewUsers,
+		wire.Bind(new(handler.UserService), new(*service.Users)),
+		handler.New,
+		newApp,
+	)
+	return nil
+}
@@ -0,0 +1,29 @@
+// Code generated by Wire. DO NOT EDIT.
+
+//go:generate go run -mod=mod github.com/google/wire/cmd/wire
+//go:build !wireinject
+// +build !wireinject
+
+package main
+
+import (
+	"golang_roadmap/08_web_development/05_dependency_injection/handler"
+	"golang_roadmap/08_web_development/05_dependency_injection/service"
+	"golang_roadmap/08_web_development/05_dependency_injection/store"
+	"log/slog"
+)
+
+// Injectors from wire.go:
+
+// initializeApp is the wire version of buildApp. wire reads the providers
+// below, works out the order from their parameter and result types, and
+// writes the body to wire_gen.go. This file is only compiled by wire
+// itself (the wireinject tag); run go generate after editing it.
+func initializeApp(log *slog.Logger) *App {
+	memory := store.NewMemory()
+	mainLogNotifier := newLogNotifier(log)
+	users := service.NewUsers(memory, mainLogNotifier, log)
+	handlerHandler := handler.New(users, log)
+	app := newApp(handlerHandler)
+	return app
+}
@@ -0,0 +1,78 @@
+package imaging
+
+import (
+	"crypto/sha256"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// identiconGrid is the number of cells across; the left half, plus the
+// middle column, comes from the hash and is mirrored to the right.
+const identiconGrid = 5
+
+// Identicon returns a size × size avatar drawn from a hash of key, like
+// the default avatars on GitHub: a symmetric 5 × 5 pattern in one colour
+// on a light background. The same key always gives the same image, so it
+// needs no storage and can be cached forever.
+//
+// The image is paletted with two colours, which makes its PNG a few
+// hundred bytes.
+func Identicon(key string, size int) *image.Paletted {
+	sum := sha256.Sum256([]byte(key))
+
+	// The first two bytes pick the hue; saturation and lightness stay in a
+	// range that reads well on the background.
+	hue := float64(uint16(sum[0])<<8|uint16(sum[1])) / 65536 * 360
+	sat := 0.45 + float64(sum[2])/255*0.25
+	fg := hsl(hue, sat, 0.55)
+	bg := color.RGBA{R: 0xf0, G: 0xf0, B: 0xf0, A: 0xff}
+	img := image.NewPaletted(image.Rect(0, 0, size, size), color.Palette{bg, fg})
+
+	// Cells fill the image with a margin of half a cell on each side.
+	cell := size / (identiconGrid + 1)
+	if cell == 0 {
+		return img
+	}
+	margin := (size - cell*identiconGrid) / 2
+	ink := image.NewUniform(fg)
+	half := (identiconGrid + 1) / 2
+	for row := range identiconGrid {
+		for col := range half {
+			bit := row*half + col
+			if sum[3+bit/8]>>(bit%8)&1 == 0 {
+				continue
+			}
+			for _, c := range []int{col, identiconGrid - 1 - col} {
+				r := image.Rect(margin+c*cell, margin+row*cell, margin+(c+1)*cell, margin+(row+1)*cell)
+				draw.Draw(img, r, ink, image.Point{}, draw.Src)
+			}
+		}
+	}
+	return img
+}
+
+// hsl converts hue (degrees), saturation and lightness (0 to 1) to RGB.
+func hsl(h, s, l float64) color.RGBA {
+	c := (1 - math.Abs(2*l-1)) * s
+	x := c * (1 - math.Abs(math.Mod(h/60, 2)-1))
+	m := l - c/2
+	var r, g, b float64
+	switch {
+	case h < 60:
+		r, g, b = c, x, 0
+	case h < 120:
+		r, g, b = x, c, 0
+	case h < 180:
+		r, g, b = 0, c, x
+	case h < 240:
+		r, g, b = 0, x, c
+	case h < 300:
+		r, g, b = x, 0, c
+	default:
+		r, g, b = c, 0, x
+	}
+	to8 := func(v float64) uint8 { return uint8(math.Round((v + m) * 255)) }
+	return color.RGBA{R: to8(r), G: to8(g), B: to8(b), A: 0xff}
+}
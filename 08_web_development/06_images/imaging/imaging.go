@@ -0,0 +1,115 @@
+// Package imaging resizes, watermarks and generates images with the
+// standard library alone (image, image/draw, image/png, image/jpeg), and
+// serves them over HTTP with caching headers.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/jpeg"
+	"image/png"
+	"io"
+)
+
+// ErrTooLarge is returned by Decode for an image with more pixels than
+// allowed.
+var ErrTooLarge = errors.New("imaging: image too large")
+
+// Decode reads a PNG or JPEG image, refusing it before decoding if it has
+// more than maxPixels pixels. A 100 KB file can declare 30,000 × 30,000
+// pixels, and decoding it would allocate 3.6 GB: the header is checked
+// first, from the same bytes.
+func Decode(r io.Reader, maxPixels int) (image.Image, string, error) {
+	var head bytes.Buffer
+	cfg, format, err := image.DecodeConfig(io.TeeReader(r, &head))
+	if err != nil {
+		return nil, "", err
+	}
+	if cfg.Width*cfg.Height > maxPixels {
+		return nil, format, fmt.Errorf("%w: %d×%d", ErrTooLarge, cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(io.MultiReader(&head, r))
+	return img, format, err
+}
+
+// Encode writes img as "png" or "jpeg". quality is for JPEG, 1 to 100;
+// 0 means 85.
+func Encode(w io.Writer, img image.Image, format string, quality int) error {
+	switch format {
+	case "png":
+		enc := png.Encoder{CompressionLevel: png.BestCompression}
+		return enc.Encode(w, img)
+	case "jpeg", "jpg":
+		if quality == 0 {
+			quality = 85
+		}
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: quality})
+	}
+	return fmt.Errorf("imaging: unknown format %q", format)
+}
+
+// Thumbnail returns src scaled down to fit within maxW × maxH, keeping its
+// aspect ratio. Each output pixel is the average of the source pixels it
+// covers, which is slower than picking the nearest one but does not
+// shimmer on fine detail. An image that already fits is copied, never
+// enlarged.
+func Thumbnail(src image.Image, maxW, maxH int) *image.RGBA {
+	sb := src.Bounds()
+	w, h := fit(sb.Dx(), sb.Dy(), maxW, maxH)
+	in := toRGBA(src)
+	if w == sb.Dx() && h == sb.Dy() {
+		return in
+	}
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+	sw, sh := sb.Dx(), sb.Dy()
+	for y := range h {
+		y0, y1 := y*sh/h, max((y+1)*sh/h, y*sh/h+1)
+		for x := range w {
+			x0, x1 := x*sw/w, max((x+1)*sw/w, x*sw/w+1)
+			var r, g, b, a, n uint32
+			for sy := y0; sy < y1; sy++ {
+				row := in.Pix[sy*in.Stride:]
+				for sx := x0; sx < x1; sx++ {
+					p := row[sx*4 : sx*4+4]
+					r += uint32(p[0])
+					g += uint32(p[1])
+					b += uint32(p[2])
+					a += uint32(p[3])
+					n++
+				}
+			}
+			o := out.PixOffset(x, y)
+			// Rounded averages of premultiplied values stay premultiplied.
+			out.Pix[o+0] = uint8((r + n/2) / n)
+			out.Pix[o+1] = uint8((g + n/2) / n)
+			out.Pix[o+2] = uint8((b + n/2) / n)
+			out.Pix[o+3] = uint8((a + n/2) / n)
+		}
+	}
+	return out
+}
+
+// fit returns the size of a w × h image scaled to fit maxW × maxH, never
+// larger than it was and at least 1 × 1.
+func fit(w, h, maxW, maxH int) (int, int) {
+	if w <= maxW && h <= maxH {
+		return w, h
+	}
+	// Compare w/maxW with h/maxH without floating point.
+	if w*maxH > h*maxW {
+		return maxW, max(h*maxW/w, 1)
+	}
+	return max(w*maxH/h, 1), maxH
+}
+
+// toRGBA returns a copy of img as *image.RGBA with its origin at 0,0, the
+// one layout the loops in this package read directly.
+func toRGBA(img image.Image) *image.RGBA {
+	b := img.Bounds()
+	out := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Bounds(), img, b.Min, draw.Src)
+	return out
+}
@@ -0,0 +1,206 @@
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func solid(w, h int, c color.Color) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(c), image.Point{}, draw.Src)
+	return img
+}
+
+func TestThumbnailSize(t *testing.T) {
+	tests := []struct {
+		w, h, maxW, maxH int
+		wantW, wantH     int
+	}{
+		{800, 600, 200, 200, 200, 150},
+		{600, 800, 200, 200, 150, 200},
+		{1000, 10, 100, 100, 100, 1},
+		{100, 50, 400, 400, 100, 50}, // never enlarged
+		{300, 300, 100, 50, 50, 50},
+	}
+	for _, tt := range tests {
+		got := Thumbnail(image.NewRGBA(image.Rect(0, 0, tt.w, tt.h)), tt.maxW, tt.maxH).Bounds()
+		if got.Dx() != tt.wantW || got.Dy() != tt.wantH {
+			t.Errorf("%d×%d in %d×%d: got %d×%d, want %d×%d",
+				tt.w, tt.h, tt.maxW, tt.maxH, got.Dx(), got.Dy(), tt.wantW, tt.wantH)
+		}
+	}
+}
+
+func TestThumbnailAverages(t *testing.T) {
+	c := color.RGBA{R: 200, G: 100, B: 50, A: 255}
+	if got := Thumbnail(solid(90, 60, c), 30, 30).RGBAAt(5, 5); got != c {
+		t.Errorf("solid colour: got %v, want %v", got, c)
+	}
+
+	// A 1-pixel black and white checkerboard averages to mid grey, where
+	// nearest-neighbour would give all black or all white.
+	src := image.NewRGBA(image.Rect(0, 0, 64, 64))
+	for y := range 64 {
+		for x := range 64 {
+			v := uint8(255 * ((x + y) % 2))
+			src.SetRGBA(x, y, color.RGBA{R: v, G: v, B: v, A: 255})
+		}
+	}
+	got := Thumbnail(src, 16, 16).RGBAAt(3, 7)
+	if got.R < 126 || got.R > 129 {
+		t.Errorf("checkerboard: got %v, want grey", got)
+	}
+}
+
+func TestThumbnailOffsetBounds(t *testing.T) {
+	// A sub-image keeps its parent's coordinates; the thumbnail must start
+	// at 0,0 and hold the sub-image's pixels.
+	parent := solid(100, 100, color.White)
+	draw.Draw(parent, image.Rect(50, 50, 100, 100), image.NewUniform(color.Black), image.Point{}, draw.Src)
+	sub := parent.SubImage(image.Rect(50, 50, 100, 100))
+	thumb := Thumbnail(sub, 10, 10)
+	if thumb.Bounds() != image.Rect(0, 0, 10, 10) {
+		t.Fatalf("bounds = %v", thumb.Bounds())
+	}
+	if got := thumb.RGBAAt(0, 0); got.R != 0 {
+		t.Errorf("pixel = %v, want black", got)
+	}
+}
+
+func TestWatermark(t *testing.T) {
+	dst := solid(100, 50, color.RGBA{B: 255, A: 255})
+	mark := solid(10, 10, color.RGBA{R: 255, A: 255})
+	Watermark(dst, mark, BottomRight, 5, 0.5)
+
+	// Inside the mark: half red over blue.
+	got := dst.RGBAAt(90, 40)
+	if got.R < 126 || got.R > 129 || got.B < 126 || got.B > 129 {
+		t.Errorf("inside = %v, want half red and half blue", got)
+	}
+	// Just outside it, and in the margin: untouched.
+	for _, p := range []image.Point{{84, 40}, {90, 46}, {0, 0}} {
+		if got := dst.RGBAAt(p.X, p.Y); got != (color.RGBA{B: 255, A: 255}) {
+			t.Errorf("%v = %v, want blue", p, got)
+		}
+	}
+
+	// A transparent part of the mark leaves dst alone at any opacity.
+	dst = solid(20, 20, color.White)
+	Watermark(dst, image.NewRGBA(image.Rect(0, 0, 20, 20)), TopLeft, 0, 1)
+	if got := dst.RGBAAt(10, 10); got != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("transparent mark changed dst to %v", got)
+	}
+}
+
+func TestIdenticon(t *testing.T) {
+	a := Identicon("user:1", 120)
+	if a.Bounds() != image.Rect(0, 0, 120, 120) {
+		t.Fatalf("bounds = %v", a.Bounds())
+	}
+	if !bytes.Equal(a.Pix, Identicon("user:1", 120).Pix) {
+		t.Error("same key gave different images")
+	}
+	if bytes.Equal(a.Pix, Identicon("user:2", 120).Pix) {
+		t.Error("different keys gave the same image")
+	}
+	for y := range 120 {
+		for x := range 60 {
+			if a.ColorIndexAt(x, y) != a.ColorIndexAt(119-x, y) {
+				t.Fatalf("not mirrored at %d,%d", x, y)
+			}
+		}
+	}
+	// The half-cell margin is always background.
+	if a.ColorIndexAt(0, 0) != 0 || a.ColorIndexAt(119, 119) != 0 {
+		t.Error("margin is not background")
+	}
+	if len(Identicon("x", 3).Pix) != 9 {
+		t.Error("tiny identicon has the wrong size")
+	}
+}
+
+func TestDecodeLimit(t *testing.T) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, solid(40, 30, color.White)); err != nil {
+		t.Fatal(err)
+	}
+	img, format, err := Decode(bytes.NewReader(buf.Bytes()), 40*30)
+	if err != nil || format != "png" || img.Bounds().Dx() != 40 {
+		t.Fatalf("Decode = %v, %q, %v", img, format, err)
+	}
+	if _, _, err := Decode(bytes.NewReader(buf.Bytes()), 40*30-1); !errors.Is(err, ErrTooLarge) {
+		t.Errorf("over the limit: err = %v, want ErrTooLarge", err)
+	}
+	if _, _, err := Decode(bytes.NewReader([]byte("not an image")), 1000); err == nil {
+		t.Error("garbage decoded without error")
+	}
+}
+
+func TestServe(t *testing.T) {
+	img := Identicon("user:1", 64)
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Serve(w, r, img, "png", 24*time.Hour)
+	})
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest("GET", "/avatar.png", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d", rec.Code)
+	}
+	h := rec.Header()
+	if h.Get("Content-Type") != "image/png" || h.Get("Cache-Control") != "public, max-age=86400" {
+		t.Errorf("headers = %v", h)
+	}
+	etag := h.Get("ETag")
+	if len(etag) < 3 || etag[0] != '"' {
+		t.Fatalf("ETag = %q", etag)
+	}
+	got, err := png.Decode(rec.Body)
+	if err != nil || got.Bounds().Dx() != 64 {
+		t.Fatalf("body: %v, %v", got, err)
+	}
+
+	req := httptest.NewRequest("GET", "/avatar.png", nil)
+	req.Header.Set("If-None-Match", etag)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusNotModified || rec.Body.Len() != 0 {
+		t.Errorf("revalidation: status %d, %d bytes; want 304 and none", rec.Code, rec.Body.Len())
+	}
+
+	req = httptest.NewRequest("GET", "/avatar.png", nil)
+	req.Header.Set("If-None-Match", `"stale"`)
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Errorf("stale ETag: status %d, want 200", rec.Code)
+	}
+}
+
+func TestSizeParam(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    int
+		wantErr bool
+	}{
+		{"", 128, false},
+		{"?size=64", 64, false},
+		{"?size=4", 16, false},
+		{"?size=9000", 512, false},
+		{"?size=big", 0, true},
+	}
+	for _, tt := range tests {
+		got, err := SizeParam(httptest.NewRequest("GET", "/a"+tt.query, nil), "size", 128, 16, 512)
+		if got != tt.want || (err != nil) != tt.wantErr {
+			t.Errorf("%q: got %d, %v", tt.query, got, err)
+		}
+	}
+}
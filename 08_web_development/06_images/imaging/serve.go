@@ -0,0 +1,51 @@
+package imaging
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"image"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// Serve encodes img as "png" or "jpeg" and writes it as the response,
+// with caching headers:
+//
+//   - Cache-Control: public, max-age=maxAge, so browsers and CDNs keep it.
+//   - ETag: a hash of the encoded bytes. A client that revalidates with
+//     If-None-Match gets 304 Not Modified and no body.
+//
+// http.ServeContent does the conditional and Range handling, and sets
+// Content-Length. HEAD requests get the headers only.
+func Serve(w http.ResponseWriter, r *http.Request, img image.Image, format string, maxAge time.Duration) {
+	var buf bytes.Buffer
+	if err := Encode(&buf, img, format, 0); err != nil {
+		http.Error(w, err.Error(), http.StatusInternalServerError)
+		return
+	}
+	sum := sha256.Sum256(buf.Bytes())
+	h := w.Header()
+	h.Set("Content-Type", "image/"+format)
+	h.Set("ETag", `"`+hex.EncodeToString(sum[:12])+`"`)
+	h.Set("Cache-Control", "public, max-age="+strconv.Itoa(int(maxAge.Seconds())))
+	// A zero modtime leaves out Last-Modified: the ETag is stronger.
+	http.ServeContent(w, r, "", time.Time{}, bytes.NewReader(buf.Bytes()))
+}
+
+// SizeParam reads an image size from the query parameter name, clamped to
+// [lo, hi], or def when the parameter is absent. An unparseable value is
+// an error, for a 400.
+func SizeParam(r *http.Request, name string, def, lo, hi int) (int, error) {
+	s := r.URL.Query().Get(name)
+	if s == "" {
+		return def, nil
+	}
+	n, err := strconv.Atoi(s)
+	if err != nil {
+		return 0, fmt.Errorf("%s must be a number", name)
+	}
+	return min(max(n, lo), hi), nil
+}
@@ -0,0 +1,45 @@
+package imaging
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// Corner says where Watermark puts the mark.
+type Corner int
+
+const (
+	BottomRight Corner = iota
+	BottomLeft
+	TopRight
+	TopLeft
+	Center
+)
+
+// Watermark draws mark over dst, margin pixels in from the given corner,
+// at opacity from 0 (invisible) to 1 (as drawn). The mark's own alpha
+// still applies: its transparent parts leave dst alone. A mark larger than
+// dst is clipped.
+func Watermark(dst draw.Image, mark image.Image, corner Corner, margin int, opacity float64) {
+	db, mb := dst.Bounds(), mark.Bounds()
+	var at image.Point
+	switch corner {
+	case TopLeft:
+		at = image.Pt(db.Min.X+margin, db.Min.Y+margin)
+	case TopRight:
+		at = image.Pt(db.Max.X-margin-mb.Dx(), db.Min.Y+margin)
+	case BottomLeft:
+		at = image.Pt(db.Min.X+margin, db.Max.Y-margin-mb.Dy())
+	case BottomRight:
+		at = image.Pt(db.Max.X-margin-mb.Dx(), db.Max.Y-margin-mb.Dy())
+	case Center:
+		at = image.Pt(db.Min.X+(db.Dx()-mb.Dx())/2, db.Min.Y+(db.Dy()-mb.Dy())/2)
+	}
+	opacity = min(max(opacity, 0), 1)
+	// A uniform mask scales every source pixel's alpha: Over with mask m
+	// is dst = src*m + dst*(1 - src.alpha*m).
+	mask := image.NewUniform(color.Alpha{A: uint8(opacity*255 + 0.5)})
+	r := image.Rectangle{Min: at, Max: at.Add(mb.Size())}
+	draw.DrawMask(dst, r, mark, mb.Min, mask, image.Point{}, draw.Over)
+}
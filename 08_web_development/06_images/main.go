@@ -0,0 +1,166 @@
+// Demonstrates image processing with the standard library.
+//
+// This example shows:
+// - Decoding PNG and JPEG with a pixel limit checked before decoding
+// - Thumbnails that keep the aspect ratio, by area averaging
+// - Compositing a watermark with draw.DrawMask at partial opacity
+// - Identicon avatars: a symmetric pattern from a hash, no storage needed
+// - Serving images with Cache-Control and an ETag, and 304 on revalidation
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"log"
+	"math"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"time"
+
+	"golang_roadmap/08_web_development/06_images/imaging"
+)
+
+func main() {
+	out := flag.String("out", os.TempDir(), "directory for the generated files")
+	addr := flag.String("serve", "", "after the demo, serve images on this address, e.g. :8080")
+	flag.Parse()
+	log.SetFlags(0)
+
+	fmt.Println("images examples starting...")
+
+	// 1) A source photo: JPEG-encoded, then decoded back, as an upload would be.
+	fmt.Println("\n1) Decode with a pixel limit")
+	var upload bytes.Buffer
+	if err := imaging.Encode(&upload, gradient(1600, 1000), "jpeg", 90); err != nil {
+		log.Fatal(err)
+	}
+	photo, format, err := imaging.Decode(bytes.NewReader(upload.Bytes()), 4_000_000)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("   %s, %v, %d KB\n", format, photo.Bounds().Size(), upload.Len()/1024)
+	if _, _, err := imaging.Decode(bytes.NewReader(upload.Bytes()), 1_000_000); err != nil {
+		fmt.Printf("   with a 1 MP limit: %v\n", err)
+	}
+
+	// 2) Thumbnails.
+	fmt.Println("\n2) Thumbnails")
+	for _, box := range []image.Point{{320, 320}, {100, 200}, {4000, 4000}} {
+		start := time.Now()
+		thumb := imaging.Thumbnail(photo, box.X, box.Y)
+		fmt.Printf("   fit %v: %v in %v\n", box, thumb.Bounds().Size(), time.Since(start).Round(time.Microsecond))
+	}
+	thumb := imaging.Thumbnail(photo, 320, 320)
+	save(*out, "thumbnail.jpg", thumb, "jpeg")
+
+	// 3) Watermark: a semi-transparent badge in the bottom-right corner.
+	fmt.Println("\n3) Watermark")
+	imaging.Watermark(thumb, badge(60, 24), imaging.BottomRight, 8, 0.6)
+	save(*out, "watermarked.png", thumb, "png")
+
+	// 4) Identicons.
+	fmt.Println("\n4) Identicons")
+	for _, key := range []string{"user:1", "user:2", "alice@example.com"} {
+		img := imaging.Identicon(key, 120)
+		var buf bytes.Buffer
+		imaging.Encode(&buf, img, "png", 0)
+		fmt.Printf("   %-18s colour %v, %d-byte PNG\n", key, img.Palette[1], buf.Len())
+	}
+	save(*out, "identicon.png", imaging.Identicon("user:1", 240), "png")
+
+	// 5) Over HTTP: the second request revalidates with the ETag.
+	fmt.Println("\n5) HTTP with caching headers")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /avatar/{key}", func(w http.ResponseWriter, r *http.Request) {
+		size, err := imaging.SizeParam(r, "size", 128, 16, 512)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		imaging.Serve(w, r, imaging.Identicon(r.PathValue("key"), size), "png", 24*time.Hour)
+	})
+	mux.HandleFunc("GET /thumbnail.jpg", func(w http.ResponseWriter, r *http.Request) {
+		imaging.Serve(w, r, thumb, "jpeg", time.Hour)
+	})
+	demo(mux)
+
+	if *addr != "" {
+		log.Printf("\nserving on %s: /avatar/{key}?size=N and /thumbnail.jpg", *addr)
+		log.Fatal(http.ListenAndServe(*addr, mux))
+	}
+}
+
+// demo requests an avatar twice, the second time with If-None-Match.
+func demo(h http.Handler) {
+	srv := &http.Server{Handler: h}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	url := "http://" + ln.Addr().String() + "/avatar/user:1?size=64"
+	resp, err := http.Get(url)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Body.Close()
+	etag := resp.Header.Get("ETag")
+	fmt.Printf("   GET  %s, %s, %s bytes\n", resp.Status, resp.Header.Get("Content-Type"), resp.Header.Get("Content-Length"))
+	fmt.Printf("        Cache-Control: %s\n        ETag: %s\n", resp.Header.Get("Cache-Control"), etag)
+
+	req, _ := http.NewRequest("GET", url, nil)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Body.Close()
+	fmt.Printf("   GET with If-None-Match: %s\n", resp.Status)
+}
+
+// gradient stands in for a photo: smooth colour with fine stripes that
+// show the difference between averaging and dropping pixels.
+func gradient(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		for x := range w {
+			stripe := 40 * (x / 2 % 2)
+			img.SetRGBA(x, y, color.RGBA{
+				R: uint8(max(255*x/w-stripe, 0)),
+				G: uint8(128 + 127*math.Sin(float64(y)/60)),
+				B: uint8(255*y/h) / 2,
+				A: 255,
+			})
+		}
+	}
+	return img
+}
+
+// badge is the watermark: a white bar with a dark border.
+func badge(w, h int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{A: 255}), image.Point{}, draw.Src)
+	draw.Draw(img, img.Bounds().Inset(2), image.White, image.Point{}, draw.Src)
+	return img
+}
+
+func save(dir, name string, img image.Image, format string) {
+	path := filepath.Join(dir, name)
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer f.Close()
+	if err := imaging.Encode(f, img, format, 0); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("   wrote %s\n", path)
+}
@@ -0,0 +1,11 @@
+module golang_roadmap/08_web_development/07_qrcode
+
+go 1.24.11
+
+require (
+	github.com/skip2/go-qrcode v0.0.0-20200617195104-da1b6568686e
+	golang_roadmap/08_web_development/06_images v0.0.0
+)
+
+// The imaging package lives in its own module in this repository.
+replace golang_roadmap/08_web_development/06_images => ../06_images
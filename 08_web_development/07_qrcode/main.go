@@ -0,0 +1,95 @@
+// Demonstrates QR code generation with a pure Go encoder.
+//
+// This example shows:
+// - Encoding a URL at each error-correction level, L, M, Q and H
+// - How the content length and level set the version and module count
+// - Sizes rounded to whole pixels per module, so every module is square
+// - Printing a code to the terminal with half-block characters
+// - Serving codes as PNG with ?size= and ?level=, with caching headers
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang_roadmap/08_web_development/06_images/imaging"
+	"golang_roadmap/08_web_development/07_qrcode/qr"
+)
+
+func main() {
+	content := flag.String("text", "https://go.dev/", "content to encode")
+	out := flag.String("out", os.TempDir(), "directory for the generated PNGs")
+	addr := flag.String("serve", "", "after the demo, serve /qr?text=...&size=N&level=L|M|Q|H on this address")
+	flag.Parse()
+	log.SetFlags(0)
+
+	fmt.Println("qrcode examples starting...")
+
+	// 1) The terminal: scan it with a phone.
+	fmt.Printf("\n1) %s in the terminal, level L\n", *content)
+	s, err := qr.Terminal(*content, qr.L)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Print(s)
+
+	// 2) Levels and lengths: more correction or more content, more modules.
+	fmt.Println("\n2) Modules across, by content length and level")
+	fmt.Printf("   %-7s %4s %4s %4s %4s\n", "bytes", "L", "M", "Q", "H")
+	for _, n := range []int{len(*content), 100, 500, 1200} {
+		text := (*content + strings.Repeat("x", n))[:n]
+		fmt.Printf("   %-7d", n)
+		for _, level := range []qr.Level{qr.L, qr.M, qr.Q, qr.H} {
+			if m, err := qr.Modules(text, level); err != nil {
+				fmt.Printf(" %4s", "-")
+			} else {
+				fmt.Printf(" %4d", m)
+			}
+		}
+		fmt.Println()
+	}
+	if _, err := qr.Modules(strings.Repeat("x", 3000), qr.L); err != nil {
+		fmt.Printf("   3000 bytes: %v\n", err)
+	}
+
+	// 3) PNGs: the size asked for is rounded down to whole modules.
+	fmt.Println("\n3) PNG files")
+	for _, opt := range []qr.Options{{Size: 256, Level: qr.M}, {Size: 256, Level: qr.H}, {Size: 1000, Level: qr.Q}} {
+		img, err := qr.Image(*content, opt)
+		if err != nil {
+			log.Fatal(err)
+		}
+		path := filepath.Join(*out, fmt.Sprintf("qr-%s-%d.png", opt.Level, opt.Size))
+		f, err := os.Create(path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := imaging.Encode(f, img, "png", 0); err != nil {
+			log.Fatal(err)
+		}
+		info, _ := f.Stat()
+		f.Close()
+		fmt.Printf("   asked %d at %s, got %d px, %d bytes: %s\n", opt.Size, opt.Level, img.Bounds().Dx(), info.Size(), path)
+	}
+
+	if *addr != "" {
+		http.HandleFunc("GET /qr", func(w http.ResponseWriter, r *http.Request) {
+			opt, err := qr.ParseOptions(r.URL.Query())
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := qr.Serve(w, r, r.URL.Query().Get("text"), opt, 24*time.Hour); err != nil {
+				http.Error(w, err.Error(), http.StatusRequestEntityTooLarge)
+			}
+		})
+		log.Printf("\nserving on %s: /qr?text=hello&size=300&level=H", *addr)
+		log.Fatal(http.ListenAndServe(*addr, nil))
+	}
+}
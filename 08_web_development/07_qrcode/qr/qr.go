@@ -0,0 +1,146 @@
+// Package qr draws QR codes with github.com/skip2/go-qrcode, a pure Go
+// encoder, and serves them as PNG with the size and error-correction level
+// taken from query parameters.
+package qr
+
+import (
+	"fmt"
+	"image"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	qrcode "github.com/skip2/go-qrcode"
+
+	"golang_roadmap/08_web_development/06_images/imaging"
+)
+
+// Level is the error-correction level: the share of the code that can be
+// damaged or covered, by a logo for example, and still scan. Higher
+// levels need more modules for the same content.
+type Level int
+
+const (
+	L Level = iota // about 7% recoverable
+	M              // about 15%
+	Q              // about 25%
+	H              // about 30%
+)
+
+var levelNames = [...]string{L: "L", M: "M", Q: "Q", H: "H"}
+
+func (l Level) String() string {
+	if l < L || l > H {
+		return "Level(" + strconv.Itoa(int(l)) + ")"
+	}
+	return levelNames[l]
+}
+
+// ParseLevel reads a level as its letter, in either case.
+func ParseLevel(s string) (Level, error) {
+	for l, name := range levelNames {
+		if strings.EqualFold(s, name) {
+			return Level(l), nil
+		}
+	}
+	return 0, fmt.Errorf("level must be one of L, M, Q or H, not %q", s)
+}
+
+func (l Level) recovery() qrcode.RecoveryLevel {
+	// skip2/go-qrcode names the levels Low, Medium, High and Highest.
+	return [...]qrcode.RecoveryLevel{qrcode.Low, qrcode.Medium, qrcode.High, qrcode.Highest}[l]
+}
+
+// Options are the size and level of a code.
+type Options struct {
+	// Size is the width and height in pixels, including the quiet zone of
+	// four modules around the code. It is rounded down to a whole number
+	// of pixels per module.
+	Size  int
+	Level Level
+}
+
+// Limits on the size query parameter. Below 64 pixels, a phone camera
+// struggles; above 1024, the image only costs bandwidth.
+const (
+	DefaultSize = 256
+	MinSize     = 64
+	MaxSize     = 1024
+)
+
+// ParseOptions reads ?size= and ?level= from a query, with DefaultSize and
+// M when absent. A size out of range or a level that is not L, M, Q or H
+// is an error, for a 400: unlike an avatar, a QR code that is silently
+// smaller than asked for may not scan from across a room.
+func ParseOptions(q url.Values) (Options, error) {
+	opt := Options{Size: DefaultSize, Level: M}
+	if s := q.Get("size"); s != "" {
+		n, err := strconv.Atoi(s)
+		if err != nil || n < MinSize || n > MaxSize {
+			return opt, fmt.Errorf("size must be a number from %d to %d", MinSize, MaxSize)
+		}
+		opt.Size = n
+	}
+	if s := q.Get("level"); s != "" {
+		l, err := ParseLevel(s)
+		if err != nil {
+			return opt, err
+		}
+		opt.Level = l
+	}
+	return opt, nil
+}
+
+// Image encodes content as a QR code. It fails only if content is too long
+// for any QR version at the level: about 2,900 bytes at L, 1,200 at H.
+//
+// The encoder maps pixels to modules by rounding, so a size that is not a
+// multiple of the module count draws some modules a pixel wider than
+// others. Image picks the largest multiple that fits instead; every module
+// is then the same square, which is what scanners expect. A size smaller
+// than the code gives one pixel per module.
+func Image(content string, opt Options) (image.Image, error) {
+	code, err := qrcode.New(content, opt.Level.recovery())
+	if err != nil {
+		return nil, err
+	}
+	modules := len(code.Bitmap()) // quiet zone included
+	scale := max(opt.Size/modules, 1)
+	return code.Image(modules * scale), nil
+}
+
+// Modules returns the number of modules across the code for content at a
+// level, without drawing it: 21 for version 1, plus 4 per version, plus 8
+// for the quiet zone.
+func Modules(content string, level Level) (int, error) {
+	code, err := qrcode.New(content, level.recovery())
+	if err != nil {
+		return 0, err
+	}
+	return len(code.Bitmap()), nil
+}
+
+// Terminal returns the code drawn with half-block characters, two modules
+// per line, for printing to a terminal.
+func Terminal(content string, level Level) (string, error) {
+	code, err := qrcode.New(content, level.recovery())
+	if err != nil {
+		return "", err
+	}
+	return code.ToSmallString(false), nil
+}
+
+// Serve writes the QR code for content as a PNG, with Cache-Control for
+// maxAge and an ETag, through imaging.Serve. If content cannot be encoded
+// it returns the error and writes nothing, so the caller can answer in its
+// own error format.
+func Serve(w http.ResponseWriter, r *http.Request, content string, opt Options, maxAge time.Duration) error {
+	img, err := Image(content, opt)
+	if err != nil {
+		return err
+	}
+	imaging.Serve(w, r, img, "png", maxAge)
+	return nil
+}
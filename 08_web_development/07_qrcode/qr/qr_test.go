@@ -0,0 +1,157 @@
+package qr
+
+import (
+	"image"
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+)
+
+const shortURL = "https://sho.rt/Xk3pQ9a"
+
+func TestParseOptions(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    Options
+		wantErr string
+	}{
+		{"", Options{DefaultSize, M}, ""},
+		{"size=512&level=h", Options{512, H}, ""},
+		{"level=Q", Options{DefaultSize, Q}, ""},
+		{"size=64", Options{64, M}, ""},
+		{"size=63", Options{}, "size must be"},
+		{"size=2000", Options{}, "size must be"},
+		{"size=big", Options{}, "size must be"},
+		{"level=X", Options{}, "level must be"},
+	}
+	for _, tt := range tests {
+		q, _ := url.ParseQuery(tt.query)
+		got, err := ParseOptions(q)
+		if tt.wantErr != "" {
+			if err == nil || !strings.Contains(err.Error(), tt.wantErr) {
+				t.Errorf("%q: err = %v, want %q", tt.query, err, tt.wantErr)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%q: got %+v, %v; want %+v", tt.query, got, err, tt.want)
+		}
+	}
+}
+
+func TestLevelString(t *testing.T) {
+	for _, l := range []Level{L, M, Q, H} {
+		back, err := ParseLevel(l.String())
+		if err != nil || back != l {
+			t.Errorf("%v round-tripped to %v, %v", l, back, err)
+		}
+	}
+	if got := Level(9).String(); got != "Level(9)" {
+		t.Errorf("Level(9) = %q", got)
+	}
+}
+
+func TestImageWholeModules(t *testing.T) {
+	modules, err := Modules(shortURL, M)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, size := range []int{64, 100, 256, 300} {
+		img, err := Image(shortURL, Options{Size: size, Level: M})
+		if err != nil {
+			t.Fatal(err)
+		}
+		b := img.Bounds()
+		if b.Dx() != b.Dy() || b.Dx() > max(size, modules) || b.Dx()%modules != 0 {
+			t.Errorf("size %d: got %v for %d modules", size, b.Size(), modules)
+			continue
+		}
+		scale := b.Dx() / modules
+		if size >= modules && b.Dx()+modules <= size {
+			t.Errorf("size %d: %d px per module, one more would fit", size, scale)
+		}
+		// Every module is a solid square.
+		for my := range modules {
+			for mx := range modules {
+				want := dark(img, mx*scale, my*scale)
+				for y := my * scale; y < (my+1)*scale; y++ {
+					for x := mx * scale; x < (mx+1)*scale; x++ {
+						if dark(img, x, y) != want {
+							t.Fatalf("size %d: module %d,%d is not solid", size, mx, my)
+						}
+					}
+				}
+			}
+		}
+	}
+}
+
+func TestImageLayout(t *testing.T) {
+	img, err := Image(shortURL, Options{Size: 1, Level: L})
+	if err != nil {
+		t.Fatal(err)
+	}
+	n := img.Bounds().Dx()
+	// Four modules of quiet zone, then the top-left finder pattern: a dark
+	// ring 7 modules across, a light ring, and a dark 3 × 3 centre.
+	for i := range n {
+		if dark(img, i, 0) || dark(img, 0, i) || dark(img, i, 3) {
+			t.Fatalf("quiet zone has a dark module at row or column %d", i)
+		}
+	}
+	for i := range 7 {
+		if !dark(img, 4+i, 4) || !dark(img, 4, 4+i) {
+			t.Fatalf("finder ring missing at %d", i)
+		}
+	}
+	if dark(img, 5, 5) || !dark(img, 7, 7) {
+		t.Error("finder centre is wrong")
+	}
+}
+
+func TestLevelsAndLimits(t *testing.T) {
+	low, _ := Modules(strings.Repeat("a", 100), L)
+	high, _ := Modules(strings.Repeat("a", 100), H)
+	if high <= low {
+		t.Errorf("H has %d modules, L has %d; H should need more", high, low)
+	}
+	if _, err := Image(strings.Repeat("x", 3000), Options{Size: 256, Level: L}); err == nil {
+		t.Error("3000 bytes encoded without error")
+	}
+}
+
+func TestServe(t *testing.T) {
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest("GET", "/qr.png", nil)
+	if err := Serve(rec, req, shortURL, Options{Size: 200, Level: Q}, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if rec.Code != http.StatusOK || rec.Header().Get("Content-Type") != "image/png" ||
+		rec.Header().Get("ETag") == "" || rec.Header().Get("Cache-Control") != "public, max-age=3600" {
+		t.Fatalf("status %d, headers %v", rec.Code, rec.Header())
+	}
+	img, err := png.Decode(rec.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() > 200 || img.Bounds().Dx() < 150 {
+		t.Errorf("size = %v", img.Bounds().Size())
+	}
+
+	rec = httptest.NewRecorder()
+	if err := Serve(rec, req, strings.Repeat("x", 3000), Options{Size: 200, Level: H}, time.Hour); err == nil {
+		t.Error("no error for content that does not fit")
+	}
+	if rec.Body.Len() != 0 || len(rec.Header()) != 0 {
+		t.Error("Serve wrote a response along with its error")
+	}
+}
+
+func dark(img image.Image, x, y int) bool {
+	r, _, _, _ := img.At(x, y).RGBA()
+	return r < 0x8000
+}
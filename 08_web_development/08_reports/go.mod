@@ -0,0 +1,5 @@
+module golang_roadmap/08_web_development/08_reports
+
+go 1.24.11
+
+require github.com/go-pdf/fpdf v0.9.0
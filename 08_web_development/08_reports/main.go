@@ -0,0 +1,102 @@
+// Demonstrates rendering a table as a text report and as a PDF.
+//
+// This example shows:
+// - A report layout in text/template, aligned by text/tabwriter
+// - The same report as an A4 PDF with github.com/go-pdf/fpdf
+// - A repeated table header, striped rows and "Page n of m" on every page
+// - Cutting text to fit its column, measured in the PDF font
+// - Serving a report as a download with Content-Disposition
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	"golang_roadmap/08_web_development/08_reports/report"
+)
+
+func main() {
+	rows := flag.Int("rows", 80, "number of sample users")
+	out := flag.String("out", os.TempDir(), "directory for users.pdf")
+	addr := flag.String("serve", "", "after the demo, serve /users.pdf and /users.txt on this address")
+	flag.Parse()
+	log.SetFlags(0)
+
+	fmt.Println("reports examples starting...")
+	rep := sampleReport(*rows)
+
+	// 1) Text, first lines only.
+	fmt.Println("\n1) Text, through text/template and tabwriter")
+	short := *rep
+	short.Rows = rep.Rows[:min(8, len(rep.Rows))]
+	short.Footer = fmt.Sprintf("(%d of %d rows shown)", len(short.Rows), len(rep.Rows))
+	if err := short.WriteText(os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+
+	// 2) PDF.
+	fmt.Println("\n2) PDF")
+	path := filepath.Join(*out, "users.pdf")
+	f, err := os.Create(path)
+	if err != nil {
+		log.Fatal(err)
+	}
+	start := time.Now()
+	if err := rep.WritePDF(f); err != nil {
+		log.Fatal(err)
+	}
+	info, _ := f.Stat()
+	f.Close()
+	fmt.Printf("   %d rows, %d KB in %v: %s\n", len(rep.Rows), info.Size()/1024, time.Since(start).Round(time.Millisecond), path)
+
+	if *addr != "" {
+		http.HandleFunc("GET /users.pdf", func(w http.ResponseWriter, r *http.Request) {
+			report.Serve(w, rep, report.PDF, "users.pdf")
+		})
+		http.HandleFunc("GET /users.txt", func(w http.ResponseWriter, r *http.Request) {
+			report.Serve(w, rep, report.Text, "users.txt")
+		})
+		log.Printf("\nserving on %s: /users.pdf and /users.txt", *addr)
+		log.Fatal(http.ListenAndServe(*addr, nil))
+	}
+}
+
+// sampleReport lists made-up users, with one name too long for its
+// column and a few accented ones.
+func sampleReport(n int) *report.Report {
+	first := []string{"Ada", "Zoë", "Grace", "Edsger", "Barbara", "Ken", "Frances", "Niklaus", "Radia", "Dennis"}
+	last := []string{"Lovelace", "Brontë", "Hopper", "Dijkstra", "Liskov", "Thompson", "Allen", "Wirth", "Perlman", "Ritchie"}
+	rep := &report.Report{
+		Title:    "Users",
+		Subtitle: "Generated " + time.Now().UTC().Format("2006-01-02 15:04 MST"),
+		Columns: []report.Column{
+			{Title: "ID", Width: 0.6, Right: true},
+			{Title: "Name", Width: 2},
+			{Title: "Email", Width: 2.5},
+			{Title: "Logins", Width: 0.8, Right: true},
+			{Title: "Registered", Width: 1.2},
+		},
+		Footer: fmt.Sprintf("%d users", n),
+	}
+	day := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	for i := range n {
+		name := first[i%len(first)] + " " + last[i*7%len(last)]
+		if i == 3 {
+			name = "Maria Magdalena Elisabeth von und zu Ausführlichen-Namensgebung"
+		}
+		rep.Rows = append(rep.Rows, []string{
+			strconv.Itoa(i + 1),
+			name,
+			fmt.Sprintf("user%d@example.com", i+1),
+			strconv.Itoa(i * 37 % 500),
+			day.AddDate(0, 0, i*3).Format(time.DateOnly),
+		})
+	}
+	return rep
+}
@@ -0,0 +1,145 @@
+package report
+
+import (
+	"io"
+	"strconv"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+// PDF layout, in millimetres and points.
+const (
+	margin    = 15.0
+	rowHeight = 7.0
+	cellPad   = 1.5
+	bodySize  = 9.0
+)
+
+// WritePDF writes the report as an A4 PDF: the title, the table with its
+// header repeated on every page, the footer line, and page numbers.
+// Text that does not fit its column is cut short with an ellipsis.
+//
+// It uses the PDF core font Helvetica, which needs no font file but
+// covers Windows-1252 only: accented Latin letters print, other scripts
+// print as ".". Embed a TrueType font with AddUTF8Font for those.
+func (r *Report) WritePDF(w io.Writer) error {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetMargins(margin, margin, margin)
+	pdf.SetAutoPageBreak(false, margin)
+	pdf.SetCellMargin(cellPad)
+	pdf.SetTitle(r.Title, true)
+	created := r.Created
+	if created.IsZero() {
+		created = time.Now()
+	}
+	pdf.SetCreationDate(created)
+	pdf.SetModificationDate(created)
+	pdf.SetCatalogSort(true)
+	pdf.AliasNbPages("")
+	tr := pdf.UnicodeTranslatorFromDescriptor("") // UTF-8 to Windows-1252
+
+	pageW, pageH := pdf.GetPageSize()
+	widths := columnWidths(r.Columns, pageW-2*margin)
+
+	pdf.SetFooterFunc(func() {
+		pdf.SetY(-margin + 3)
+		pdf.SetFont("Helvetica", "", 8)
+		pdf.SetTextColor(120, 120, 120)
+		pdf.CellFormat(0, 5, "Page "+strconv.Itoa(pdf.PageNo())+" of {nb}", "", 0, "R", false, 0, "")
+	})
+
+	header := func() {
+		pdf.SetFont("Helvetica", "B", bodySize)
+		pdf.SetFillColor(225, 228, 235)
+		pdf.SetTextColor(0, 0, 0)
+		for i, c := range r.Columns {
+			pdf.CellFormat(widths[i], rowHeight, fit(pdf, tr(c.Title), widths[i]), "B", 0, align(c), true, 0, "")
+		}
+		pdf.Ln(-1)
+		pdf.SetFont("Helvetica", "", bodySize)
+	}
+
+	pdf.AddPage()
+	pdf.SetFont("Helvetica", "B", 16)
+	pdf.CellFormat(0, 10, tr(r.Title), "", 1, "L", false, 0, "")
+	if r.Subtitle != "" {
+		pdf.SetFont("Helvetica", "", 10)
+		pdf.SetTextColor(100, 100, 100)
+		pdf.CellFormat(0, 6, tr(r.Subtitle), "", 1, "L", false, 0, "")
+	}
+	pdf.Ln(4)
+	header()
+
+	bottom := pageH - margin - 6 // room for the page number
+	for n, row := range r.Rows {
+		if pdf.GetY()+rowHeight > bottom {
+			pdf.AddPage()
+			header()
+		}
+		// Light stripes on every other row help the eye along wide rows.
+		stripe := n%2 == 1
+		pdf.SetFillColor(245, 246, 248)
+		for i, c := range r.Columns {
+			var text string
+			if i < len(row) {
+				text = fit(pdf, tr(row[i]), widths[i])
+			}
+			pdf.CellFormat(widths[i], rowHeight, text, "", 0, align(c), stripe, 0, "")
+		}
+		pdf.Ln(-1)
+	}
+
+	if r.Footer != "" {
+		if pdf.GetY()+2*rowHeight > bottom {
+			pdf.AddPage()
+		}
+		pdf.Ln(3)
+		pdf.SetFont("Helvetica", "I", bodySize)
+		pdf.SetTextColor(0, 0, 0)
+		pdf.CellFormat(0, rowHeight, tr(r.Footer), "T", 1, "L", false, 0, "")
+	}
+	return pdf.Output(w)
+}
+
+// columnWidths shares total between the columns by their Width.
+func columnWidths(cols []Column, total float64) []float64 {
+	var sum float64
+	for _, c := range cols {
+		sum += weight(c)
+	}
+	widths := make([]float64, len(cols))
+	for i, c := range cols {
+		widths[i] = total * weight(c) / sum
+	}
+	return widths
+}
+
+func weight(c Column) float64 {
+	if c.Width <= 0 {
+		return 1
+	}
+	return c.Width
+}
+
+func align(c Column) string {
+	if c.Right {
+		return "R"
+	}
+	return "L"
+}
+
+// fit shortens s, already in the PDF's encoding, until it fits in width
+// less the cell padding, ending it with an ellipsis (0x85 in
+// Windows-1252).
+func fit(pdf *fpdf.Fpdf, s string, width float64) string {
+	room := width - 2*cellPad
+	if pdf.GetStringWidth(s) <= room {
+		return s
+	}
+	const ellipsis = "\x85"
+	for len(s) > 0 && pdf.GetStringWidth(s+ellipsis) > room {
+		s = s[:len(s)-1] // one byte is one character in Windows-1252
+	}
+	return s + ellipsis
+}
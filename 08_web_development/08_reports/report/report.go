@@ -0,0 +1,118 @@
+// Package report renders a titled table as aligned plain text, through
+// text/template and text/tabwriter, or as a PDF with github.com/go-pdf/fpdf,
+// and serves either as a download.
+package report
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"text/template"
+	"time"
+)
+
+// Report is a table with a title, and optional lines around it.
+type Report struct {
+	Title    string
+	Subtitle string // under the title, such as when it was generated
+	Columns  []Column
+	Rows     [][]string
+	Footer   string // after the table, such as a total
+
+	// Created is the PDF's creation date in its metadata. The zero value
+	// uses the time of rendering; a fixed one makes the output
+	// reproducible.
+	Created time.Time
+}
+
+// Column is a column heading and its layout in the PDF. Text output
+// aligns every column left, as tabwriter aligns all columns one way.
+type Column struct {
+	Title string
+	// Width is the column's share of the page width, relative to the
+	// other columns. Zero counts as 1.
+	Width float64
+	Right bool // align right, for numbers
+}
+
+// textTemplate lays out the report; the table lines have their cells
+// separated by tabs, for the tabwriter to align.
+var textTemplate = template.Must(template.New("report").Funcs(template.FuncMap{
+	"cells": func(cells []string) string { return strings.Join(cells, "\t") },
+	"rule": func(cols []Column) string {
+		dashes := make([]string, len(cols))
+		for i, c := range cols {
+			dashes[i] = strings.Repeat("-", max(len([]rune(c.Title)), 3))
+		}
+		return strings.Join(dashes, "\t")
+	},
+	"titles": func(cols []Column) []string {
+		t := make([]string, len(cols))
+		for i, c := range cols {
+			t[i] = c.Title
+		}
+		return t
+	},
+	"underline": func(s string) string { return strings.Repeat("=", len([]rune(s))) },
+}).Parse(`{{.Title}}
+{{underline .Title}}
+{{with .Subtitle}}{{.}}
+{{end}}
+{{cells (titles .Columns)}}
+{{rule .Columns}}
+{{range .Rows}}{{cells .}}
+{{end}}{{with .Footer}}
+{{.}}
+{{end}}`))
+
+// WriteText writes the report as plain text with aligned columns. Cell
+// text is written as is: a tab or newline in it breaks the layout.
+func (r *Report) WriteText(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if err := textTemplate.Execute(tw, r); err != nil {
+		return err
+	}
+	return tw.Flush()
+}
+
+// Format is an output format for Serve.
+type Format int
+
+const (
+	Text Format = iota
+	PDF
+)
+
+// Serve renders the report and sends it as a download named filename,
+// with Content-Disposition: attachment. The report is rendered into
+// memory first, so a rendering error is still a clean 500 rather than a
+// truncated file.
+func Serve(w http.ResponseWriter, rep *Report, f Format, filename string) {
+	var buf bytes.Buffer
+	var err error
+	contentType := "text/plain; charset=utf-8"
+	if f == PDF {
+		contentType = "application/pdf"
+		err = rep.WritePDF(&buf)
+	} else {
+		err = rep.WriteText(&buf)
+	}
+	if err != nil {
+		http.Error(w, fmt.Sprintf("render report: %v", err), http.StatusInternalServerError)
+		return
+	}
+	h := w.Header()
+	h.Set("Content-Type", contentType)
+	// FormatMediaType quotes the name, and switches to the RFC 2231
+	// encoding for non-ASCII names.
+	h.Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": filename}))
+	h.Set("Content-Length", strconv.Itoa(buf.Len()))
+	// Reports hold personal data and are current only when fetched.
+	h.Set("Cache-Control", "no-store")
+	w.Write(buf.Bytes())
+}
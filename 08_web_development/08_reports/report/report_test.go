@@ -0,0 +1,153 @@
+package report
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/go-pdf/fpdf"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files in testdata")
+
+func usersReport(rows int) *Report {
+	r := &Report{
+		Title:    "Users",
+		Subtitle: "Generated 2024-03-01 12:00 UTC",
+		Columns: []Column{
+			{Title: "ID", Width: 0.6, Right: true},
+			{Title: "Name", Width: 2},
+			{Title: "Email", Width: 2.5},
+			{Title: "Registered", Width: 1.2},
+		},
+		Created: time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+	people := [][2]string{{"Ada Lovelace", "ada"}, {"Zoë Brontë", "zoe"}, {"Grace Hopper", "grace"}, {"Edsger W. Dijkstra", "edsger"}}
+	for i := range rows {
+		name := people[i%len(people)][0]
+		email := fmt.Sprintf("%s%d@example.com", people[i%len(people)][1], i+1)
+		day := time.Date(2024, 1, 1+i, 0, 0, 0, 0, time.UTC).Format(time.DateOnly)
+		r.Rows = append(r.Rows, []string{fmt.Sprint(i + 1), name, email, day})
+	}
+	r.Footer = fmt.Sprintf("%d users", rows)
+	return r
+}
+
+// TestWriteTextGolden compares the text report with testdata. After an
+// intended change to the layout, run go test -update and review the diff.
+func TestWriteTextGolden(t *testing.T) {
+	for _, tt := range []struct {
+		name string
+		rep  *Report
+	}{
+		{"users", usersReport(5)},
+		{"empty", &Report{Title: "Users", Columns: usersReport(0).Columns}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			if err := tt.rep.WriteText(&buf); err != nil {
+				t.Fatal(err)
+			}
+			golden := filepath.Join("testdata", tt.name+".golden")
+			if *update {
+				if err := os.WriteFile(golden, buf.Bytes(), 0o644); err != nil {
+					t.Fatal(err)
+				}
+			}
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got := buf.String(); got != string(want) {
+				t.Errorf("text report differs from %s\n--- got\n%s--- want\n%s", golden, got, want)
+			}
+		})
+	}
+}
+
+func TestWritePDF(t *testing.T) {
+	render := func(rep *Report) []byte {
+		t.Helper()
+		var buf bytes.Buffer
+		if err := rep.WritePDF(&buf); err != nil {
+			t.Fatal(err)
+		}
+		return buf.Bytes()
+	}
+
+	small := render(usersReport(5))
+	if !bytes.HasPrefix(small, []byte("%PDF-1.")) || !bytes.HasSuffix(bytes.TrimSpace(small), []byte("%%EOF")) {
+		t.Fatalf("not a PDF: %.20q ... %.20q", small, small[max(len(small)-20, 0):])
+	}
+	if got := pages(small); got != 1 {
+		t.Errorf("5 rows: %d pages, want 1", got)
+	}
+	// A fixed creation date makes the output reproducible.
+	if !bytes.Equal(small, render(usersReport(5))) {
+		t.Error("two renderings of the same report differ")
+	}
+
+	// About 34 rows fit on the first page and 36 on the others.
+	if got := pages(render(usersReport(100))); got != 3 {
+		t.Errorf("100 rows: %d pages, want 3", got)
+	}
+}
+
+// pages counts the page objects. Object dictionaries are not compressed,
+// only the content streams.
+func pages(pdf []byte) int {
+	return bytes.Count(pdf, []byte("/Type /Page\n"))
+}
+
+func TestFit(t *testing.T) {
+	rep := usersReport(1)
+	rep.Rows[0][1] = strings.Repeat("Very Long Name ", 20)
+	if err := rep.WritePDF(io.Discard); err != nil {
+		t.Fatal(err)
+	}
+	pdfDoc := newTestPDF()
+	got := fit(pdfDoc, strings.Repeat("W", 100), 30)
+	if !strings.HasSuffix(got, "\x85") || pdfDoc.GetStringWidth(got) > 30-2*cellPad {
+		t.Errorf("fit = %q, width %.1f", got, pdfDoc.GetStringWidth(got))
+	}
+	if got := fit(pdfDoc, "Ada", 30); got != "Ada" {
+		t.Errorf("short text changed to %q", got)
+	}
+}
+
+func TestServe(t *testing.T) {
+	for _, tt := range []struct {
+		format   Format
+		filename string
+		ctype    string
+		disp     string
+		prefix   string
+	}{
+		{PDF, "users-2024-03-01.pdf", "application/pdf", `attachment; filename=users-2024-03-01.pdf`, "%PDF-"},
+		{Text, "users.txt", "text/plain; charset=utf-8", `attachment; filename=users.txt`, "Users\n"},
+		{Text, "utilisateurs été.txt", "text/plain; charset=utf-8", `attachment; filename*=utf-8''utilisateurs%20%C3%A9t%C3%A9.txt`, "Users\n"},
+	} {
+		rec := httptest.NewRecorder()
+		Serve(rec, usersReport(3), tt.format, tt.filename)
+		h := rec.Header()
+		if rec.Code != 200 || h.Get("Content-Type") != tt.ctype || h.Get("Content-Disposition") != tt.disp {
+			t.Errorf("%s: %d, Content-Type %q, Content-Disposition %q", tt.filename, rec.Code, h.Get("Content-Type"), h.Get("Content-Disposition"))
+		}
+		if h.Get("Content-Length") != fmt.Sprint(rec.Body.Len()) || !strings.HasPrefix(rec.Body.String(), tt.prefix) {
+			t.Errorf("%s: Content-Length %s for %d bytes starting %.10q", tt.filename, h.Get("Content-Length"), rec.Body.Len(), rec.Body.String())
+		}
+	}
+}
+
+func newTestPDF() *fpdf.Fpdf {
+	pdf := fpdf.New("P", "mm", "A4", "")
+	pdf.SetFont("Helvetica", "", bodySize)
+	return pdf
+}
@@ -0,0 +1,23 @@
+module golang_roadmap/08_web_development/09_static_site
+
+go 1.24.11
+
+require (
+	github.com/fsnotify/fsnotify v1.9.0
+	golang_roadmap/08_web_development/10_markdown v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+require (
+	github.com/alecthomas/chroma/v2 v2.23.1 // indirect
+	github.com/aymerick/douceur v0.2.0 // indirect
+	github.com/dlclark/regexp2 v1.11.5 // indirect
+	github.com/gorilla/css v1.0.1 // indirect
+	github.com/microcosm-cc/bluemonday v1.0.27 // indirect
+	github.com/yuin/goldmark v1.8.6 // indirect
+	golang.org/x/net v0.26.0 // indirect
+	golang.org/x/sys v0.21.0 // indirect
+)
+
+// The markdown package lives in its own module in this repository.
+replace golang_roadmap/08_web_development/10_markdown => ../10_markdown
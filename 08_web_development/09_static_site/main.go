@@ -0,0 +1,77 @@
+// Demonstrates a static site generator.
+//
+// This example shows:
+// - Markdown pages with YAML front matter, converted to HTML with highlighted code
+// - Layouts in html/template: a base page and one template per layout
+// - Section pages that list their posts, newest first, and drafts left out
+// - Copying static assets and writing the site to a new public/ directory
+// - Swapping the new output in at the end, so a failed build changes nothing
+// - A -serve mode that serves public/ and rebuilds when a file changes
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"time"
+
+	"golang_roadmap/08_web_development/09_static_site/site"
+)
+
+func main() {
+	cfg := site.Config{}
+	flag.StringVar(&cfg.Dir, "dir", "example", "site directory with content/, layouts/ and static/")
+	flag.StringVar(&cfg.Out, "out", "public", "output directory, replaced on every build")
+	flag.StringVar(&cfg.Title, "title", "Static site example", "site title")
+	flag.StringVar(&cfg.BaseURL, "base-url", "", "site URL for absolute links, such as https://example.com")
+	flag.BoolVar(&cfg.Drafts, "drafts", false, "build draft pages too")
+	addr := flag.String("serve", "", "serve the output on this address and rebuild on change, such as :8080")
+	flag.Parse()
+	log.SetFlags(log.Ltime)
+
+	st, err := site.Build(cfg)
+	report(st, err)
+	if err != nil && *addr == "" {
+		os.Exit(1)
+	}
+	if *addr == "" {
+		return
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go func() {
+		if err := site.Watch(ctx, cfg, report); err != nil {
+			log.Fatalf("watch: %v", err)
+		}
+	}()
+
+	// The output directory is swapped on every build, so the server opens
+	// files by name each time and must not cache them.
+	files := http.FileServer(http.Dir(cfg.Out))
+	srv := &http.Server{Addr: *addr, Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Cache-Control", "no-cache")
+		files.ServeHTTP(w, r)
+	})}
+	go func() {
+		<-ctx.Done()
+		srv.Close()
+	}()
+	log.Printf("serving %s on %s, watching %s", cfg.Out, *addr, cfg.Dir)
+	if err := srv.ListenAndServe(); err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+// report logs the result of a build. In -serve mode a failed build is
+// logged and the last good site stays up until the next change.
+func report(st site.Stats, err error) {
+	if err != nil {
+		log.Printf("build failed: %v", err)
+		return
+	}
+	log.Printf("built %d pages, %d files, %d drafts skipped in %v", st.Pages, st.Files, st.Drafts, st.Took.Round(time.Millisecond))
+}
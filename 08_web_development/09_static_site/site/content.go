@@ -0,0 +1,191 @@
+package site
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"golang_roadmap/08_web_development/10_markdown/markdown"
+)
+
+// Page is one Markdown file, and the data its layout sees as ".".
+type Page struct {
+	// From the front matter.
+	Title   string         `yaml:"title"`
+	Date    time.Time      `yaml:"date"`
+	Draft   bool           `yaml:"draft"`
+	Layout  string         `yaml:"layout"`  // default "list" for index.md, else "page"
+	Summary string         `yaml:"summary"` // for lists and meta descriptions
+	Params  map[string]any `yaml:"-"`       // every front matter key, for custom ones
+
+	Source  string        `yaml:"-"` // path under content/, with slashes
+	URL     string        `yaml:"-"` // the site path: /, /about/, /blog/hello/
+	Content template.HTML `yaml:"-"` // the rendered Markdown
+	// Pages are the pages in an index page's section: the other pages in
+	// its directory, and the index pages of the directories below it.
+	// Newest first; nil for other pages.
+	Pages []*Page `yaml:"-"`
+	Site  *Site   `yaml:"-"`
+}
+
+// IsIndex reports whether p is a section's index.md.
+func (p *Page) IsIndex() bool { return path.Base(p.Source) == "index.md" }
+
+// dir is the content directory p belongs to, with slashes: "." or "blog".
+func (p *Page) dir() string { return path.Dir(p.Source) }
+
+// outPath is where p is written under the output directory. Every page
+// becomes an index.html, so URLs end in a slash and not in .html.
+func (p *Page) outPath() string { return strings.TrimPrefix(p.URL, "/") + "index.html" }
+
+// loadContent reads every Markdown file under dir. It returns the
+// published pages newest first, the paths of the other files to copy,
+// and the number of drafts left out.
+func loadContent(dir string, md *markdown.Renderer, drafts bool) (pages []*Page, files []string, skipped int, err error) {
+	err = filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && p != dir {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, _ := filepath.Rel(dir, p)
+		rel = filepath.ToSlash(rel)
+		if path.Ext(rel) != ".md" {
+			files = append(files, rel)
+			return nil
+		}
+		page, err := loadPage(p, rel, md)
+		if err != nil {
+			return err
+		}
+		if page.Draft && !drafts {
+			skipped++
+			return nil
+		}
+		pages = append(pages, page)
+		return nil
+	})
+	sortPages(pages)
+	return pages, files, skipped, err
+}
+
+// loadPage reads one Markdown file: front matter, then body.
+func loadPage(name, rel string, md *markdown.Renderer) (*Page, error) {
+	src, err := os.ReadFile(name)
+	if err != nil {
+		return nil, err
+	}
+	front, body := splitFrontMatter(src)
+	p := &Page{Source: rel}
+	if err := yaml.Unmarshal(front, p); err != nil {
+		return nil, fmt.Errorf("%s: front matter: %w", rel, err)
+	}
+	if err := yaml.Unmarshal(front, &p.Params); err != nil {
+		return nil, fmt.Errorf("%s: front matter: %w", rel, err)
+	}
+
+	base := strings.TrimSuffix(path.Base(rel), ".md")
+	switch {
+	case p.IsIndex() && p.dir() == ".":
+		p.URL = "/"
+	case p.IsIndex():
+		p.URL = "/" + p.dir() + "/"
+	default:
+		p.URL = path.Join("/", p.dir(), base) + "/"
+	}
+	if p.Layout == "" {
+		p.Layout = "page"
+		if p.IsIndex() {
+			p.Layout = "list"
+		}
+	}
+	if p.Title == "" {
+		p.Title = titleFromName(base)
+	}
+
+	// The Markdown is the site author's own, so it is not sanitized; the
+	// renderer's options decide whether raw HTML in it is kept.
+	if p.Content, err = md.HTML(body); err != nil {
+		return nil, fmt.Errorf("%s: %w", rel, err)
+	}
+	return p, nil
+}
+
+// splitFrontMatter separates a leading block between "---" lines from
+// the rest. A file without one has empty front matter.
+func splitFrontMatter(src []byte) (front, body []byte) {
+	src = bytes.TrimPrefix(src, []byte("\ufeff"))
+	rest, ok := bytes.CutPrefix(src, []byte("---\n"))
+	if !ok {
+		rest, ok = bytes.CutPrefix(src, []byte("---\r\n"))
+	}
+	if !ok {
+		return nil, src
+	}
+	for i := 0; i < len(rest); {
+		line, next, _ := bytes.Cut(rest[i:], []byte("\n"))
+		if string(bytes.TrimRight(line, "\r")) == "---" {
+			return rest[:i], next
+		}
+		i += len(line) + 1
+	}
+	return nil, src // no closing line: it was not front matter
+}
+
+// titleFromName turns "first-post" into "First post".
+func titleFromName(name string) string {
+	if name == "index" {
+		return ""
+	}
+	s := strings.NewReplacer("-", " ", "_", " ").Replace(name)
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// linkSections gives each index page the pages of its section.
+func linkSections(pages []*Page) {
+	index := map[string]*Page{}
+	for _, p := range pages {
+		if p.IsIndex() {
+			index[p.dir()] = p
+		}
+	}
+	for _, p := range pages {
+		parent := p.dir()
+		if p.IsIndex() {
+			if parent == "." {
+				continue
+			}
+			parent = path.Dir(parent)
+		}
+		if s, ok := index[parent]; ok {
+			s.Pages = append(s.Pages, p)
+		}
+	}
+}
+
+// sortPages orders pages newest first; undated pages go last, by title.
+func sortPages(pages []*Page) {
+	sort.SliceStable(pages, func(i, j int) bool {
+		a, b := pages[i], pages[j]
+		if !a.Date.Equal(b.Date) {
+			return a.Date.After(b.Date)
+		}
+		return a.Title < b.Title
+	})
+}
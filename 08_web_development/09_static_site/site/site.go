@@ -0,0 +1,249 @@
+// Package site builds a static website: Markdown pages from a content
+// directory, rendered to HTML by the markdown package of this repository,
+// with highlighted code and heading anchors, laid out with html/template,
+// and written with the static assets to an output directory that any
+// file server can serve.
+//
+// A site directory looks like this:
+//
+//	content/       Markdown pages, with optional YAML front matter
+//	  index.md     -> public/index.html
+//	  about.md     -> public/about/index.html
+//	  blog/
+//	    index.md   -> public/blog/index.html, a list of the posts below
+//	    hello.md   -> public/blog/hello/index.html
+//	layouts/       base.html, plus one file per layout: page.html, list.html
+//	static/        copied as is: CSS, images, fonts
+package site
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"html/template"
+	"io"
+	"io/fs"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang_roadmap/08_web_development/10_markdown/markdown"
+)
+
+// Config says where a site is and how to build it.
+type Config struct {
+	Dir     string // the site: content/, layouts/ and static/
+	Out     string // the output directory, replaced on every build
+	Title   string // the site title, .Site.Title in layouts
+	BaseURL string // such as https://example.com, for absolute links
+	Drafts  bool   // build pages marked draft too
+
+	// Markdown converts page bodies; nil means markdown.New with the
+	// zero Options, which leaves raw HTML out.
+	Markdown *markdown.Renderer
+}
+
+// Stats describes a build.
+type Stats struct {
+	Pages  int // pages written
+	Drafts int // drafts skipped
+	Files  int // static and content files copied
+	Took   time.Duration
+}
+
+// Site is the data every layout sees as .Site.
+type Site struct {
+	Title   string
+	BaseURL string
+	Pages   []*Page // every published page, newest first
+	Built   time.Time
+}
+
+// Build renders the site from cfg.Dir into cfg.Out. It writes to a new
+// directory next to cfg.Out and swaps it in at the end, so a server
+// reading cfg.Out never sees half a site, and a failed build leaves the
+// last good one in place.
+func Build(cfg Config) (Stats, error) {
+	start := time.Now()
+	var st Stats
+	if cfg.Markdown == nil {
+		cfg.Markdown = markdown.New(markdown.Options{})
+	}
+	site := &Site{Title: cfg.Title, BaseURL: strings.TrimSuffix(cfg.BaseURL, "/"), Built: start}
+
+	pages, files, drafts, err := loadContent(filepath.Join(cfg.Dir, "content"), cfg.Markdown, cfg.Drafts)
+	if err != nil {
+		return st, err
+	}
+	st.Drafts = drafts
+	for _, p := range pages {
+		p.Site = site
+	}
+	site.Pages = pages
+	linkSections(pages)
+
+	layouts, err := loadLayouts(filepath.Join(cfg.Dir, "layouts"))
+	if err != nil {
+		return st, err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filepath.Clean(cfg.Out)), 0o755); err != nil {
+		return st, err
+	}
+	stage, err := os.MkdirTemp(filepath.Dir(filepath.Clean(cfg.Out)), "."+filepath.Base(cfg.Out)+"-")
+	if err != nil {
+		return st, err
+	}
+	defer os.RemoveAll(stage) // a no-op once it has been swapped in
+	// MkdirTemp makes the directory 0700; a site is for everyone to read.
+	if err := os.Chmod(stage, 0o755); err != nil {
+		return st, err
+	}
+
+	for _, p := range pages {
+		t, ok := layouts[p.Layout]
+		if !ok {
+			return st, fmt.Errorf("%s: no layout %q in layouts/", p.Source, p.Layout)
+		}
+		var buf bytes.Buffer
+		if err := t.ExecuteTemplate(&buf, "base", p); err != nil {
+			return st, fmt.Errorf("%s: %w", p.Source, err)
+		}
+		if err := writeFile(filepath.Join(stage, filepath.FromSlash(p.outPath())), buf.Bytes()); err != nil {
+			return st, err
+		}
+		st.Pages++
+	}
+
+	static := filepath.Join(cfg.Dir, "static")
+	n, err := copyTree(static, stage)
+	if err != nil {
+		return st, err
+	}
+	st.Files += n
+	for _, f := range files {
+		if err := copyFile(filepath.Join(cfg.Dir, "content", filepath.FromSlash(f)), filepath.Join(stage, filepath.FromSlash(f))); err != nil {
+			return st, err
+		}
+		st.Files++
+	}
+
+	if err := swap(stage, cfg.Out); err != nil {
+		return st, err
+	}
+	st.Took = time.Since(start)
+	return st, nil
+}
+
+// loadLayouts parses base.html with each other file in dir, one template
+// set per layout, named after the file: page.html is layout "page". A
+// layout defines "main", and may define "title"; base.html calls them.
+func loadLayouts(dir string) (map[string]*template.Template, error) {
+	base := filepath.Join(dir, "base.html")
+	names, err := filepath.Glob(filepath.Join(dir, "*.html"))
+	if err != nil {
+		return nil, err
+	}
+	layouts := map[string]*template.Template{}
+	for _, name := range names {
+		if name == base {
+			continue
+		}
+		t, err := template.New("").Funcs(funcs).ParseFiles(base, name)
+		if err != nil {
+			return nil, fmt.Errorf("layouts: %w", err)
+		}
+		layouts[strings.TrimSuffix(filepath.Base(name), ".html")] = t
+	}
+	if len(layouts) == 0 {
+		return nil, fmt.Errorf("layouts: no layouts in %s", dir)
+	}
+	return layouts, nil
+}
+
+// funcs are available in every layout.
+var funcs = template.FuncMap{
+	// date formats a page date, or returns "" for none.
+	"date": func(t time.Time) string {
+		if t.IsZero() {
+			return ""
+		}
+		return t.Format("2 January 2006")
+	},
+	// absURL joins the site's BaseURL with a site path, for feeds and
+	// canonical links.
+	"absURL": func(s *Site, p string) string {
+		return s.BaseURL + path.Clean("/"+p)
+	},
+}
+
+// swap puts the directory stage at out. The old out is renamed away
+// first, and removed only after the new one is in place.
+func swap(stage, out string) error {
+	old := stage + ".old"
+	if err := os.Rename(out, old); err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+	if err := os.Rename(stage, out); err != nil {
+		os.Rename(old, out)
+		return err
+	}
+	return os.RemoveAll(old)
+}
+
+// copyTree copies every regular file under src into dst, keeping the
+// layout, and returns how many it copied. A missing src is not an error;
+// dot files are skipped.
+func copyTree(src, dst string) (int, error) {
+	n := 0
+	err := filepath.WalkDir(src, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && p == src {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		if strings.HasPrefix(d.Name(), ".") && p != src {
+			if d.IsDir() {
+				return fs.SkipDir
+			}
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			return nil
+		}
+		rel, _ := filepath.Rel(src, p)
+		n++
+		return copyFile(p, filepath.Join(dst, rel))
+	})
+	return n, err
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return err
+	}
+	out, err := os.Create(dst)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+func writeFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	return os.WriteFile(name, data, 0o644)
+}
@@ -0,0 +1,217 @@
+package site
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeSite creates a site directory from a map of paths to contents.
+func writeSite(t *testing.T, files map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, data := range files {
+		if err := writeFile(filepath.Join(dir, filepath.FromSlash(name)), []byte(data)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func smallSite() map[string]string {
+	return map[string]string{
+		"layouts/base.html":        `{{define "base"}}<title>{{.Title}} - {{.Site.Title}}</title>{{template "main" .}}{{end}}`,
+		"layouts/page.html":        `{{define "main"}}<h1>{{.Title}}</h1>{{with date .Date}}<time>{{.}}</time>{{end}}{{.Content}}{{end}}`,
+		"layouts/list.html":        `{{define "main"}}{{.Content}}{{range .Pages}}<li>{{.URL}}</li>{{end}}{{end}}`,
+		"content/index.md":         "Home *page*.\n",
+		"content/about.md":         "---\ntitle: About us\n---\nSee <script>alert(1)</script> [us](/about/).\n\n## Team\n\n```go\nvar team []string\n```\n",
+		"content/blog/index.md":    "---\ntitle: Blog\n---\n",
+		"content/blog/old-post.md": "---\ndate: 2024-01-02\n---\nOld.\n",
+		"content/blog/new.md":      "---\ntitle: New\ndate: 2024-06-01\n---\nNew.\n",
+		"content/blog/wip.md":      "---\ndate: 2024-07-01\ndraft: true\n---\nNot yet.\n",
+		"content/blog/photo.png":   "PNG",
+		"static/css/site.css":      "body{}",
+		"static/.DS_Store":         "junk",
+	}
+}
+
+func read(t *testing.T, name string) string {
+	t.Helper()
+	b, err := os.ReadFile(name)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(b)
+}
+
+func TestBuild(t *testing.T) {
+	dir := writeSite(t, smallSite())
+	out := filepath.Join(t.TempDir(), "public")
+	st, err := Build(Config{Dir: dir, Out: out, Title: "Test"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Pages != 5 || st.Drafts != 1 || st.Files != 2 {
+		t.Errorf("stats = %+v, want 5 pages, 1 draft, 2 files", st)
+	}
+
+	for _, tt := range []struct{ name, want string }{
+		{"index.html", "<p>Home <em>page</em>.</p>"},
+		{"about/index.html", "<title>About us - Test</title>"},
+		{"about/index.html", `<span class="kd">var</span>`},
+		{"about/index.html", `<h2 id="team">Team <a class="anchor" href="#team"`},
+		{"blog/old-post/index.html", "<h1>Old post</h1><time>2 January 2024</time>"},
+		{"blog/index.html", "<li>/blog/new/</li><li>/blog/old-post/</li>"},
+		{"blog/photo.png", "PNG"},
+		{"css/site.css", "body{}"},
+	} {
+		if got := read(t, filepath.Join(out, tt.name)); !strings.Contains(got, tt.want) {
+			t.Errorf("%s = %q, want it to contain %q", tt.name, got, tt.want)
+		}
+	}
+	if about := read(t, filepath.Join(out, "about/index.html")); strings.Contains(about, "<script>") {
+		t.Errorf("raw HTML in Markdown was kept: %q", about)
+	}
+	for _, name := range []string{"blog/wip/index.html", ".DS_Store"} {
+		if _, err := os.Stat(filepath.Join(out, name)); err == nil {
+			t.Errorf("%s was written", name)
+		}
+	}
+	if fi, err := os.Stat(out); err != nil || fi.Mode().Perm() != 0o755 {
+		t.Errorf("output directory: %v, %v; want mode 0755", fi, err)
+	}
+
+	st, err = Build(Config{Dir: dir, Out: out, Drafts: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Pages != 6 || st.Drafts != 0 {
+		t.Errorf("with drafts: stats = %+v, want 6 pages", st)
+	}
+	if got := read(t, filepath.Join(out, "blog/index.html")); !strings.HasPrefix(got[strings.Index(got, "<li>"):], "<li>/blog/wip/</li>") {
+		t.Errorf("with drafts: blog = %q, want the draft first", got)
+	}
+}
+
+func TestBuildErrors(t *testing.T) {
+	for _, tt := range []struct {
+		name, file, data, want string
+	}{
+		{"front matter", "content/bad.md", "---\ntitle: [unclosed\n---\n", "bad.md: front matter"},
+		{"layout", "content/odd.md", "---\nlayout: gallery\n---\n", `odd.md: no layout "gallery"`},
+		{"template", "layouts/page.html", `{{define "main"}}{{.Missing}}{{end}}`, `.md: template: page.html`},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			files := smallSite()
+			files[tt.file] = tt.data
+			dir := writeSite(t, files)
+			out := filepath.Join(t.TempDir(), "public")
+			_, err := Build(Config{Dir: dir, Out: out})
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("err = %v, want one containing %q", err, tt.want)
+			}
+			if _, err := os.Stat(out); err == nil {
+				t.Error("a failed build wrote the output directory")
+			}
+		})
+	}
+}
+
+// TestBuildKeepsLastGoodSite checks that a failed build leaves the
+// previous output alone, and no staging directories behind.
+func TestBuildKeepsLastGoodSite(t *testing.T) {
+	dir := writeSite(t, smallSite())
+	parent := t.TempDir()
+	out := filepath.Join(parent, "public")
+	if _, err := Build(Config{Dir: dir, Out: out}); err != nil {
+		t.Fatal(err)
+	}
+	writeFile(filepath.Join(dir, "content/about.md"), []byte("---\ntitle: [\n---\n"))
+	if _, err := Build(Config{Dir: dir, Out: out}); err == nil {
+		t.Fatal("build with bad front matter succeeded")
+	}
+	if got := read(t, filepath.Join(out, "about/index.html")); !strings.Contains(got, "About us") {
+		t.Errorf("about = %q, want the last good build", got)
+	}
+	if entries, _ := os.ReadDir(parent); len(entries) != 1 {
+		t.Errorf("%d entries next to the output, want 1: %v", len(entries), entries)
+	}
+}
+
+func TestSplitFrontMatter(t *testing.T) {
+	for _, tt := range []struct{ in, front, body string }{
+		{"---\ntitle: A\n---\nBody\n", "title: A\n", "Body\n"},
+		{"\ufeff---\r\ntitle: A\r\n---\r\nBody\r\n", "title: A\r\n", "Body\r\n"},
+		{"Body only\n", "", "Body only\n"},
+		{"---\nno closing line\n", "", "---\nno closing line\n"},
+		{"Text\n---\nafter a rule\n", "", "Text\n---\nafter a rule\n"},
+	} {
+		front, body := splitFrontMatter([]byte(tt.in))
+		if string(front) != tt.front || string(body) != tt.body {
+			t.Errorf("splitFrontMatter(%q) = %q, %q; want %q, %q", tt.in, front, body, tt.front, tt.body)
+		}
+	}
+}
+
+func TestWatch(t *testing.T) {
+	dir := writeSite(t, smallSite())
+	cfg := Config{Dir: dir, Out: filepath.Join(t.TempDir(), "public")}
+	if _, err := Build(cfg); err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	builds := make(chan error, 10)
+	done := make(chan error)
+	go func() {
+		done <- Watch(ctx, cfg, func(_ Stats, err error) {
+			select {
+			case builds <- err:
+			default:
+			}
+		})
+	}()
+
+	// Watch adds its watches before it waits for events, but has no way to
+	// say when; give it a moment, then make changes until one is seen.
+	timeout := time.After(5 * time.Second)
+	for {
+		time.Sleep(50 * time.Millisecond)
+		writeFile(filepath.Join(dir, "content/blog/new.md"), []byte("---\ntitle: Changed\n---\n"))
+		select {
+		case err := <-builds:
+			if err != nil {
+				t.Fatal(err)
+			}
+		case <-time.After(500 * time.Millisecond):
+			continue
+		case <-timeout:
+			t.Fatal("no rebuild after a change")
+		}
+		break
+	}
+	if got := read(t, filepath.Join(cfg.Out, "blog/new/index.html")); !strings.Contains(got, "<h1>Changed</h1>") {
+		t.Errorf("after the change: %q", got)
+	}
+
+	// A file in a directory created after Watch started.
+	writeFile(filepath.Join(dir, "content/docs/intro.md"), []byte("Intro.\n"))
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		if _, err := os.Stat(filepath.Join(cfg.Out, "docs/intro/index.html")); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("no rebuild after a file in a new directory")
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+
+	cancel()
+	if err := <-done; err != nil {
+		t.Errorf("Watch = %v", err)
+	}
+}
@@ -0,0 +1,81 @@
+package site
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// debounce is how long Watch waits after a change for more: an editor's
+// save is often several events, and a git checkout is hundreds.
+const debounce = 100 * time.Millisecond
+
+// Watch rebuilds the site whenever a file under content/, layouts/ or
+// static/ changes, calling built with each result, until ctx is done.
+// It does not build first: call Build for that.
+//
+// fsnotify watches single directories, so Watch adds every directory in
+// the tree, and new ones as they appear.
+func Watch(ctx context.Context, cfg Config, built func(Stats, error)) error {
+	w, err := fsnotify.NewWatcher()
+	if err != nil {
+		return err
+	}
+	defer w.Close()
+	for _, sub := range []string{"content", "layouts", "static"} {
+		if err := addTree(w, filepath.Join(cfg.Dir, sub)); err != nil {
+			return err
+		}
+	}
+
+	var timer <-chan time.Time
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case ev, ok := <-w.Events:
+			if !ok {
+				return nil
+			}
+			if ev.Has(fsnotify.Chmod) && !ev.Has(fsnotify.Write) {
+				continue
+			}
+			if ev.Has(fsnotify.Create) {
+				if fi, err := os.Stat(ev.Name); err == nil && fi.IsDir() {
+					addTree(w, ev.Name)
+				}
+			}
+			timer = time.After(debounce)
+		case err, ok := <-w.Errors:
+			if !ok {
+				return nil
+			}
+			built(Stats{}, err)
+		case <-timer:
+			timer = nil
+			built(Build(cfg))
+		}
+	}
+}
+
+// addTree watches dir and every directory below it. A missing dir is
+// skipped: a site need not have static files.
+func addTree(w *fsnotify.Watcher, dir string) error {
+	return filepath.WalkDir(dir, func(p string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && p == dir {
+			return fs.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		if d.IsDir() {
+			return w.Add(p)
+		}
+		return nil
+	})
+}
@@ -0,0 +1,16 @@
+module golang_roadmap/08_web_development/10_markdown
+
+go 1.24.11
+
+require (
+	github.com/alecthomas/chroma/v2 v2.23.1
+	github.com/microcosm-cc/bluemonday v1.0.27
+	github.com/yuin/goldmark v1.8.6
+)
+
+require (
+	github.com/aymerick/douceur v0.2.0 // indirect
+	github.com/dlclark/regexp2 v1.11.5 // indirect
+	github.com/gorilla/css v1.0.1 // indirect
+	golang.org/x/net v0.26.0 // indirect
+)
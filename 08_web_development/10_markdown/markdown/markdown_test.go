@@ -0,0 +1,155 @@
+package markdown
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func convert(t *testing.T, r *Renderer, src string) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := r.Convert([]byte(src), &buf); err != nil {
+		t.Fatal(err)
+	}
+	return buf.String()
+}
+
+func TestCodeHighlighter(t *testing.T) {
+	r := New(Options{})
+	for _, tt := range []struct {
+		name, src string
+		want      []string
+	}{
+		{"go", "```go\nfunc main() {}\n```\n", []string{
+			`<pre class="chroma"><code class="language-go">`,
+			`<span class="kd">func</span>`,
+			"</code></pre>",
+		}},
+		{"escaped", "```go\ns := \"<b>\"\n```\n", []string{`&lt;b&gt;`}},
+		{"unknown language", "```klingon\nQapla' <b>\n```\n", []string{
+			`<pre><code class="language-klingon">Qapla&#39; &lt;b&gt;`,
+		}},
+		{"no language", "```\nplain <b>\n```\n", []string{`<pre><code>plain &lt;b&gt;`}},
+		{"indented", "    indented <b>\n", []string{`<pre><code>indented &lt;b&gt;`}},
+		{"quoted language", "```\"><script>\nx\n```\n", []string{`class="language-&#34;&gt;&lt;script&gt;"`}},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := convert(t, r, tt.src)
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("got %q, want it to contain %q", got, want)
+				}
+			}
+			if strings.Contains(got, "<b>") || strings.Contains(got, "<script>") {
+				t.Errorf("got %q: code was not escaped", got)
+			}
+		})
+	}
+}
+
+func TestHeadingAnchors(t *testing.T) {
+	got := convert(t, New(Options{}), "# Hello *world*\n\n## Hello *world*\n")
+	want := `<h1 id="hello-world">Hello <em>world</em> <a class="anchor" href="#hello-world" aria-label="Link to this section">#</a></h1>
+<h2 id="hello-world-1">Hello <em>world</em> <a class="anchor" href="#hello-world-1" aria-label="Link to this section">#</a></h2>
+`
+	if got != want {
+		t.Errorf("got\n%s\nwant\n%s", got, want)
+	}
+
+	got = convert(t, New(Options{IDPrefix: "user-content-"}), "## Setup\n")
+	if !strings.Contains(got, `<h2 id="user-content-setup">`) || !strings.Contains(got, `href="#user-content-setup"`) {
+		t.Errorf("with prefix: %q", got)
+	}
+}
+
+func TestRawHTML(t *testing.T) {
+	src := "Hi <kbd>Ctrl</kbd>\n\n<div class=\"x\">block</div>\n"
+	if got := convert(t, New(Options{}), src); strings.Contains(got, "<kbd>") || strings.Contains(got, "<div") {
+		t.Errorf("without RawHTML: %q", got)
+	}
+	if got := convert(t, New(Options{RawHTML: true}), src); !strings.Contains(got, "<kbd>Ctrl</kbd>") || !strings.Contains(got, `<div class="x">`) {
+		t.Errorf("with RawHTML: %q", got)
+	}
+}
+
+// TestSanitize feeds a sanitizing Renderer the usual ways to get script
+// into a page, and some markup that must survive.
+func TestSanitize(t *testing.T) {
+	r := New(Options{RawHTML: true, Sanitize: true, IDPrefix: "user-content-"})
+	for _, tt := range []struct {
+		name, src string
+		want      string // must be in the output
+		bad       string // must not be
+	}{
+		{"script", "<script>alert(1)</script>", "", "<script"},
+		{"inline script", "Hi <script>alert(1)</script> there", "Hi", "alert"},
+		{"event handler", `<img src="/a.png" onerror="alert(1)">`, `<img src="/a.png">`, "onerror"},
+		{"javascript link", "[x](javascript:alert(1))", "x", "javascript:"},
+		{"javascript link html", `<a href="JaVaScRiPt:alert(1)">x</a>`, "x", "alert"},
+		{"data link", `<a href="data:text/html;base64,PHNjcmlwdD4=">x</a>`, "x", "data:"},
+		{"style", `<p style="position:fixed">x</p>`, "<p>x</p>", "style"},
+		{"iframe", `<iframe src="https://evil.example"></iframe>`, "", "iframe"},
+		{"form", `<form action="/login"><input name="pw"></form>`, "", "form"},
+		{"svg", `<svg onload="alert(1)"><circle/></svg>`, "", "onload"},
+		{"site class", `<span class="admin-badge">Admin</span>`, "Admin", "admin-badge"},
+		{"unprefixed id", `<h2 id="login">x</h2>`, "<h2>x</h2>", `id="login"`},
+		{"link", "[Go](https://go.dev)", `<a href="https://go.dev" rel="nofollow">Go</a>`, ""},
+		{"highlighting", "```go\nvar x\n```", `<span class="kd">var</span>`, ""},
+		{"anchor", "## Setup", `<h2 id="user-content-setup">Setup <a class="anchor" href="#user-content-setup"`, ""},
+		{"task list", "- [x] done", `<input checked="" disabled="" type="checkbox">`, ""},
+		{"table", "| a |\n|---|\n| b |", "<td>b</td>", ""},
+		{"details", "<details><summary>More</summary>\n\nHidden\n\n</details>", "<details><summary>More</summary>", ""},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			got := convert(t, r, tt.src)
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("got %q, want it to contain %q", got, tt.want)
+			}
+			if tt.bad != "" && strings.Contains(strings.ToLower(got), strings.ToLower(tt.bad)) {
+				t.Errorf("got %q, which contains %q", got, tt.bad)
+			}
+		})
+	}
+}
+
+func TestHTML(t *testing.T) {
+	got, err := New(Options{}).HTML([]byte("*hi*"))
+	if err != nil || got != "<p><em>hi</em></p>\n" {
+		t.Errorf("HTML = %q, %v", got, err)
+	}
+}
+
+func TestCSS(t *testing.T) {
+	var buf bytes.Buffer
+	if err := CSS(&buf, "github"); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), ".chroma .kd") {
+		t.Errorf("CSS has no rule for keywords: %.200s", buf.String())
+	}
+	if err := CSS(&buf, "no-such-style"); err == nil {
+		t.Error("unknown style: no error")
+	}
+}
+
+func TestPreviewHandler(t *testing.T) {
+	h := PreviewHandler(New(Options{RawHTML: true, Sanitize: true}))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/preview", strings.NewReader("**hi** <script>x</script>")))
+	if rec.Code != http.StatusOK || rec.Body.String() != "<p><strong>hi</strong> </p>\n" {
+		t.Errorf("got %d %q", rec.Code, rec.Body)
+	}
+	if ct := rec.Header().Get("Content-Type"); ct != "text/html; charset=utf-8" {
+		t.Errorf("Content-Type = %q", ct)
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/preview", strings.NewReader(strings.Repeat("x", MaxPreviewBytes+1))))
+	if rec.Code != http.StatusRequestEntityTooLarge {
+		t.Errorf("too large: got %d", rec.Code)
+	}
+}
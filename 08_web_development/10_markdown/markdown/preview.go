@@ -0,0 +1,36 @@
+package markdown
+
+import (
+	"errors"
+	"io"
+	"log"
+	"net/http"
+)
+
+// MaxPreviewBytes limits the Markdown a preview request may send.
+const MaxPreviewBytes = 256 << 10
+
+// PreviewHandler renders the Markdown in a request body, such as a
+// comment being typed, and answers with the HTML fragment. r should
+// sanitize: the Markdown comes from whoever sends the request, and the
+// page showing the preview inserts it as HTML.
+func PreviewHandler(r *Renderer) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		src, err := io.ReadAll(http.MaxBytesReader(w, req.Body, MaxPreviewBytes))
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Markdown too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		if err != nil {
+			http.Error(w, "Cannot read request body", http.StatusBadRequest)
+			return
+		}
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		w.Header().Set("X-Content-Type-Options", "nosniff")
+		w.Header().Set("Cache-Control", "no-store")
+		if err := r.Convert(src, w); err != nil {
+			log.Printf("Error rendering preview: %v", err)
+		}
+	})
+}
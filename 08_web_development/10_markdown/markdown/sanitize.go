@@ -0,0 +1,52 @@
+package markdown
+
+import (
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+)
+
+// Policy is the allowlist a sanitizing Renderer applies. It keeps what
+// Markdown produces, and the few HTML elements people write in Markdown
+// on purpose, such as <details> and <kbd>:
+//
+//   - text formatting, headings, lists, quotes, tables and rules
+//   - http, https and mailto links with rel="nofollow", and images
+//   - chroma's token classes on the spans of highlighted code
+//   - a language class on code blocks, and the anchor links on headings
+//   - ids, but only with idPrefix
+//   - the disabled checkboxes of task lists
+//
+// Everything else goes: scripts, event handlers, style attributes,
+// frames, forms and javascript: URLs. Classes are limited to the shapes
+// above, so a user cannot borrow the page's own classes to make their
+// text look like part of the site.
+//
+// bluemonday.UGCPolicy is close, but it allows any id on any element,
+// and a bluemonday policy can only be widened, never narrowed.
+func Policy(idPrefix string) *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowElements("p", "br", "hr", "blockquote", "pre", "code", "span",
+		"h1", "h2", "h3", "h4", "h5", "h6",
+		"em", "strong", "del", "s", "sub", "sup", "kbd", "mark", "abbr",
+		"details", "summary")
+	p.AllowLists()
+	p.AllowTables()
+	p.AllowStandardURLs()
+	p.AllowAttrs("href").OnElements("a")
+	p.AllowImages()
+	p.AllowAttrs("title").Matching(bluemonday.Paragraph).Globally()
+	p.AllowAttrs("open").Matching(regexp.MustCompile(`^(|open)$`)).OnElements("details")
+
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^chroma$`)).OnElements("pre")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+#.-]+$`)).OnElements("code")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^[a-z]{1,2}[0-9]?$|^line$`)).OnElements("span")
+
+	p.AllowAttrs("id").Matching(regexp.MustCompile(`^` + regexp.QuoteMeta(idPrefix) + `[\p{L}\p{N}_-]+$`)).Globally()
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^anchor$`)).OnElements("a")
+	p.AllowAttrs("aria-label").Matching(bluemonday.Paragraph).OnElements("a")
+
+	p.AllowAttrs("type").Matching(regexp.MustCompile(`^checkbox$`)).OnElements("input")
+	p.AllowAttrs("checked", "disabled").OnElements("input")
+	return p
+}
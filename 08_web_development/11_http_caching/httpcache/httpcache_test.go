@@ -0,0 +1,283 @@
+package httpcache
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestNotModified(t *testing.T) {
+	mod := time.Date(2024, 3, 1, 12, 0, 0, 500e6, time.UTC) // half a second past
+	before, at, after := "Fri, 01 Mar 2024 11:59:59 GMT", "Fri, 01 Mar 2024 12:00:00 GMT", "Fri, 01 Mar 2024 12:00:01 GMT"
+	for _, tt := range []struct {
+		name    string
+		method  string
+		etag    string
+		headers map[string]string
+		want    bool
+	}{
+		{"no conditions", "GET", `"a"`, nil, false},
+		{"same tag", "GET", `"a"`, map[string]string{"If-None-Match": `"a"`}, true},
+		{"other tag", "GET", `"a"`, map[string]string{"If-None-Match": `"b"`}, false},
+		{"tag in list", "GET", `"a"`, map[string]string{"If-None-Match": `"b", "a" ,"c"`}, true},
+		{"star", "GET", `"a"`, map[string]string{"If-None-Match": "*"}, true},
+		{"weak request tag", "GET", `"a"`, map[string]string{"If-None-Match": `W/"a"`}, true},
+		{"weak current tag", "GET", `W/"a"`, map[string]string{"If-None-Match": `"a"`}, true},
+		{"unquoted is another tag", "GET", `"a"`, map[string]string{"If-None-Match": `a`}, false},
+		{"no current tag", "GET", "", map[string]string{"If-None-Match": `"a"`}, false},
+		{"HEAD", "HEAD", `"a"`, map[string]string{"If-None-Match": `"a"`}, true},
+		{"POST", "POST", `"a"`, map[string]string{"If-None-Match": `"a"`}, false},
+		{"modified since", "GET", "", map[string]string{"If-Modified-Since": before}, false},
+		{"same second", "GET", "", map[string]string{"If-Modified-Since": at}, true},
+		{"later date", "GET", "", map[string]string{"If-Modified-Since": after}, true},
+		{"bad date", "GET", "", map[string]string{"If-Modified-Since": "yesterday"}, false},
+		{"tag wins over date", "GET", `"a"`, map[string]string{"If-None-Match": `"b"`, "If-Modified-Since": after}, false},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest(tt.method, "/", nil)
+			for k, v := range tt.headers {
+				r.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			w.Header().Set("Content-Type", "application/json")
+			got := NotModified(w, r, tt.etag, mod)
+			if got != tt.want {
+				t.Fatalf("NotModified = %v, want %v", got, tt.want)
+			}
+			if tt.etag != "" && w.Header().Get("ETag") != tt.etag {
+				t.Errorf("ETag = %q", w.Header().Get("ETag"))
+			}
+			if w.Header().Get("Last-Modified") != at {
+				t.Errorf("Last-Modified = %q, want %q", w.Header().Get("Last-Modified"), at)
+			}
+			if got && (w.Code != http.StatusNotModified || w.Header().Get("Content-Type") != "") {
+				t.Errorf("304: code %d, Content-Type %q", w.Code, w.Header().Get("Content-Type"))
+			}
+		})
+	}
+}
+
+func TestETag(t *testing.T) {
+	a, b := ETag([]byte("hello")), ETag([]byte("hello!"))
+	if a != ETag([]byte("hello")) || a == b || !strings.HasPrefix(a, `"`) || !strings.HasSuffix(a, `"`) {
+		t.Errorf("ETag: %s, %s", a, b)
+	}
+}
+
+func TestPolicy(t *testing.T) {
+	for _, tt := range []struct {
+		p    Policy
+		want string
+	}{
+		{NoStore, "no-store"},
+		{Revalidate, "no-cache"},
+		{Static, "public, max-age=31536000, immutable"},
+		{Policy{Private: true, MaxAge: time.Minute}, "private, max-age=60"},
+		{Policy{Public: true, MaxAge: 30 * time.Second, SharedMaxAge: 5 * time.Minute, StaleWhileRevalidate: time.Minute}, "public, max-age=30, s-maxage=300, stale-while-revalidate=60"},
+		{Policy{NoStore: true, MaxAge: time.Hour}, "no-store"},
+		{Policy{MaxAge: time.Hour, MustRevalidate: true}, "max-age=3600, must-revalidate"},
+	} {
+		if got := tt.p.String(); got != tt.want {
+			t.Errorf("%+v = %q, want %q", tt.p, got, tt.want)
+		}
+	}
+
+	h := Static.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	for method, want := range map[string]string{"GET": Static.String(), "HEAD": Static.String(), "POST": ""} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(method, "/", nil))
+		if got := w.Header().Get("Cache-Control"); got != want {
+			t.Errorf("%s: Cache-Control = %q, want %q", method, got, want)
+		}
+	}
+}
+
+// api is a handler for the Memo tests: a counter of handler calls per
+// path, and a few paths with special responses.
+type api struct{ calls atomic.Int32 }
+
+func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	a.calls.Add(1)
+	switch r.URL.Path {
+	case "/private":
+		w.Header().Set("Cache-Control", "private, max-age=60")
+	case "/nostore":
+		w.Header().Set("Cache-Control", NoStore.String())
+	case "/cookie":
+		http.SetCookie(w, &http.Cookie{Name: "s", Value: "1"})
+	case "/missing":
+		http.NotFound(w, r)
+		return
+	case "/lang":
+		w.Header().Set("Vary", "Accept-Language")
+		fmt.Fprint(w, r.Header.Get("Accept-Language"))
+		return
+	case "/fail":
+		http.Error(w, "no", http.StatusConflict)
+		return
+	}
+	if r.Method == http.MethodGet || r.Method == http.MethodHead {
+		fmt.Fprintf(w, "%s at call %d", r.URL.RequestURI(), a.calls.Load())
+	}
+}
+
+func do(t *testing.T, h http.Handler, method, target string, headers ...string) *httptest.ResponseRecorder {
+	t.Helper()
+	r := httptest.NewRequest(method, target, nil)
+	for i := 0; i+1 < len(headers); i += 2 {
+		r.Header.Set(headers[i], headers[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestMemo(t *testing.T) {
+	a := &api{}
+	m := NewMemo(0, 0)
+	h := m.Middleware(a)
+
+	first := do(t, h, "GET", "/articles/1")
+	if first.Header().Get("X-Cache") != "MISS" || first.Body.String() != "/articles/1 at call 1" {
+		t.Fatalf("first: %s %q", first.Header().Get("X-Cache"), first.Body)
+	}
+	etag := first.Header().Get("ETag")
+	if etag != ETag(first.Body.Bytes()) {
+		t.Errorf("ETag = %q, want the hash of the body", etag)
+	}
+
+	second := do(t, h, "GET", "/articles/1")
+	if second.Header().Get("X-Cache") != "HIT" || second.Body.String() != first.Body.String() || a.calls.Load() != 1 {
+		t.Errorf("second: %s %q after %d calls", second.Header().Get("X-Cache"), second.Body, a.calls.Load())
+	}
+	if w := do(t, h, "GET", "/articles/1", "If-None-Match", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("revalidation: %d %q", w.Code, w.Body)
+	}
+	if w := do(t, h, "HEAD", "/articles/1"); w.Code != http.StatusOK || w.Body.Len() != 0 || w.Header().Get("X-Cache") != "HIT" {
+		t.Errorf("HEAD: %d %q %s", w.Code, w.Body, w.Header().Get("X-Cache"))
+	}
+	if w := do(t, h, "GET", "/articles/1?x=1"); w.Header().Get("X-Cache") != "MISS" {
+		t.Error("another query was a hit")
+	}
+	if s := m.Stats(); s.Hits != 3 || s.Misses != 2 || s.Entries != 2 {
+		t.Errorf("stats = %+v", s)
+	}
+}
+
+func TestMemoInvalidate(t *testing.T) {
+	a := &api{}
+	m := NewMemo(0, 0)
+	h := m.Middleware(a)
+	for _, target := range []string{"/articles", "/articles?page=2", "/articles/7", "/articles/7/comments", "/articles/70", "/users/7"} {
+		do(t, h, "GET", target)
+	}
+
+	if w := do(t, h, "PUT", "/articles/7/comments/x"); w.Code != http.StatusOK {
+		t.Fatal(w.Code)
+	}
+	do(t, h, "PUT", "/fail") // a failed write invalidates nothing
+	if s := m.Stats(); s.Entries != 5 {
+		t.Errorf("after a write below /articles/7/comments: %d entries, want 5", s.Entries)
+	}
+
+	do(t, h, "PUT", "/articles/7")
+	for target, want := range map[string]string{
+		"/articles": "MISS", "/articles?page=2": "MISS", "/articles/7": "MISS", "/articles/7/comments": "MISS",
+		"/articles/70": "HIT", "/users/7": "HIT",
+	} {
+		if got := do(t, h, "GET", target).Header().Get("X-Cache"); got != want {
+			t.Errorf("GET %s after PUT /articles/7: %s, want %s", target, got, want)
+		}
+	}
+}
+
+func TestMemoSkips(t *testing.T) {
+	a := &api{}
+	m := NewMemo(0, 0)
+	h := m.Middleware(a)
+	for _, tt := range []struct {
+		name, target string
+		headers      []string
+	}{
+		{"private", "/private", nil},
+		{"no-store", "/nostore", nil},
+		{"Set-Cookie", "/cookie", nil},
+		{"404", "/missing", nil},
+		{"Authorization", "/articles", []string{"Authorization", "Bearer x"}},
+	} {
+		do(t, h, "GET", tt.target, tt.headers...)
+		if got := do(t, h, "GET", tt.target, tt.headers...).Header().Get("X-Cache"); got != "MISS" {
+			t.Errorf("%s: second request was a %s", tt.name, got)
+		}
+	}
+	if w := do(t, h, "GET", "/missing"); w.Code != http.StatusNotFound {
+		t.Errorf("404 replayed as %d", w.Code)
+	}
+}
+
+func TestMemoVary(t *testing.T) {
+	h := NewMemo(0, 0).Middleware(&api{})
+	do(t, h, "GET", "/lang", "Accept-Language", "de")
+	if w := do(t, h, "GET", "/lang", "Accept-Language", "de"); w.Header().Get("X-Cache") != "HIT" {
+		t.Error("same language: not a hit")
+	}
+	if w := do(t, h, "GET", "/lang", "Accept-Language", "fr"); w.Header().Get("X-Cache") != "MISS" || w.Body.String() != "fr" {
+		t.Errorf("other language: %s %q", w.Header().Get("X-Cache"), w.Body)
+	}
+}
+
+func TestMemoExpiryAndSize(t *testing.T) {
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := NewMemo(time.Minute, 2)
+	m.now = func() time.Time { return now }
+	h := m.Middleware(&api{})
+
+	do(t, h, "GET", "/a")
+	now = now.Add(59 * time.Second)
+	if do(t, h, "GET", "/a").Header().Get("X-Cache") != "HIT" {
+		t.Error("fresh entry: not a hit")
+	}
+	now = now.Add(time.Second)
+	if do(t, h, "GET", "/a").Header().Get("X-Cache") != "MISS" {
+		t.Error("expired entry: not a miss")
+	}
+
+	now = now.Add(time.Second)
+	do(t, h, "GET", "/b")
+	now = now.Add(time.Second)
+	do(t, h, "GET", "/c") // full: drops /a, the oldest
+	if s := m.Stats(); s.Entries != 2 {
+		t.Errorf("%d entries, want 2", s.Entries)
+	}
+	if do(t, h, "GET", "/b").Header().Get("X-Cache") != "HIT" {
+		t.Error("/b was dropped, not /a")
+	}
+}
+
+// TestMemoWriteDuringRead checks that a GET that started before a write
+// does not store what it read, which may be from before the write.
+func TestMemoWriteDuringRead(t *testing.T) {
+	m := NewMemo(0, 0)
+	reading, wrote := make(chan bool), make(chan bool)
+	h := m.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet {
+			reading <- true
+			<-wrote
+		}
+		io.WriteString(w, "old")
+	}))
+	go func() {
+		<-reading
+		do(t, h, "PUT", "/a")
+		wrote <- true
+	}()
+	do(t, h, "GET", "/a")
+	if s := m.Stats(); s.Entries != 0 {
+		t.Errorf("the read was stored after a write: %+v", s)
+	}
+}
@@ -0,0 +1,277 @@
+package httpcache
+
+import (
+	"bytes"
+	"net/http"
+	"path"
+	"strings"
+	"sync"
+	"time"
+)
+
+// maxMemoBody is the largest response body a Memo stores.
+const maxMemoBody = 1 << 20
+
+// Memo is a middleware that keeps the responses to GET requests in
+// memory, so that repeated reads of the same URL skip the handler, and
+// answers conditional requests from the stored ETag; a response stored
+// without one gets one hashed from its body. A successful write
+// to a URL (POST, PUT, PATCH, DELETE) drops what was stored for it; see
+// Invalidate.
+//
+// A Memo stores a response only if it is a 200 with no Set-Cookie, and
+// its Cache-Control does not say no-store or private, and the request
+// carried no Authorization: one user's data must not be served to
+// another. It keeps one variant per URL: a response with a Vary header
+// is served only to requests with the same values for those headers.
+//
+// The Memo sees only the headers set inside it, so put Policy.Handler
+// inside the Memo, not around it.
+//
+// A Memo is for a single server process. Behind several, each has its
+// own, and a write invalidates only the one it reached.
+type Memo struct {
+	ttl        time.Duration
+	maxEntries int
+	now        func() time.Time // for tests
+
+	mu      sync.Mutex
+	entries map[string]*memoEntry // by request URI
+	gen     int                   // counts Invalidate calls
+	stats   MemoStats
+}
+
+// MemoStats counts what a Memo did.
+type MemoStats struct {
+	Hits, Misses, Invalidations int
+	Entries                     int
+}
+
+type memoEntry struct {
+	status int
+	header http.Header
+	body   []byte
+	vary   map[string]string // request header values the response depends on
+	stored time.Time
+}
+
+// NewMemo returns a Memo that keeps a response for at most ttl, or until
+// it is invalidated when ttl is 0, and at most maxEntries responses,
+// dropping the oldest when full.
+func NewMemo(ttl time.Duration, maxEntries int) *Memo {
+	return &Memo{ttl: ttl, maxEntries: maxEntries, now: time.Now, entries: map[string]*memoEntry{}}
+}
+
+// Stats returns the counts so far.
+func (m *Memo) Stats() MemoStats {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	s := m.stats
+	s.Entries = len(m.entries)
+	return s
+}
+
+// Middleware wraps next with the Memo.
+func (m *Memo) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead:
+			m.serveRead(w, r, next)
+		case http.MethodOptions, http.MethodTrace:
+			next.ServeHTTP(w, r)
+		default:
+			sw := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(sw, r)
+			if sw.status < 400 {
+				m.Invalidate(r.URL.Path)
+			}
+		}
+	})
+}
+
+func (m *Memo) serveRead(w http.ResponseWriter, r *http.Request, next http.Handler) {
+	key := r.URL.RequestURI()
+	e, gen := m.lookup(key, r)
+	if e != nil {
+		w.Header().Set("X-Cache", "HIT")
+		e.serve(w, r)
+		return
+	}
+
+	// Record the whole response, store it if allowed, and then send it
+	// the same way as a hit, so that a stored response has the same ETag
+	// the first time as on every hit after.
+	rec := &recorder{header: http.Header{}}
+	next.ServeHTTP(rec, r)
+	e = &memoEntry{status: rec.status(), header: rec.header, body: rec.body.Bytes(), stored: m.now()}
+	if m.storable(e, r) {
+		if e.header.Get("ETag") == "" {
+			e.header.Set("ETag", ETag(e.body))
+		}
+		e.vary = varyValues(e.header, r)
+		m.store(key, e, gen)
+	}
+	w.Header().Set("X-Cache", "MISS")
+	e.serve(w, r)
+}
+
+// lookup returns the fresh entry for key that matches r's Vary headers,
+// counting a hit or a miss, and the current generation, for store.
+func (m *Memo) lookup(key string, r *http.Request) (*memoEntry, int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	e, ok := m.entries[key]
+	if ok && m.ttl > 0 && m.now().Sub(e.stored) >= m.ttl {
+		delete(m.entries, key)
+		ok = false
+	}
+	if ok {
+		for name, v := range e.vary {
+			if r.Header.Get(name) != v {
+				ok = false
+			}
+		}
+	}
+	if !ok {
+		m.stats.Misses++
+		return nil, m.gen
+	}
+	m.stats.Hits++
+	return e, m.gen
+}
+
+func (m *Memo) storable(e *memoEntry, r *http.Request) bool {
+	if r.Method != http.MethodGet || e.status != http.StatusOK || len(e.body) > maxMemoBody {
+		return false
+	}
+	if r.Header.Get("Authorization") != "" || e.header.Get("Set-Cookie") != "" {
+		return false
+	}
+	cc := strings.ToLower(strings.Join(e.header.Values("Cache-Control"), ","))
+	return !strings.Contains(cc, "no-store") && !strings.Contains(cc, "private") && e.header.Get("Vary") != "*"
+}
+
+// store keeps e under key, unless there was an Invalidate since lookup
+// returned gen: the handler may have read the data before a write
+// changed it, and e would bring the old data back.
+func (m *Memo) store(key string, e *memoEntry, gen int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if gen != m.gen {
+		return
+	}
+	if _, ok := m.entries[key]; !ok && m.maxEntries > 0 && len(m.entries) >= m.maxEntries {
+		// Dropping the oldest is a scan, fine for the hundreds of entries
+		// a Memo is for. A large cache would keep a list in LRU order.
+		var oldest string
+		for k, o := range m.entries {
+			if oldest == "" || o.stored.Before(m.entries[oldest].stored) {
+				oldest = k
+			}
+		}
+		delete(m.entries, oldest)
+	}
+	m.entries[key] = e
+}
+
+// Invalidate drops the stored responses a write to urlPath may have made
+// stale: those for urlPath itself, with any query, for the URLs below it,
+// and for its parent, the collection it is listed in. A PUT to
+// /articles/7 drops /articles/7, /articles/7/comments and
+// /articles?page=2.
+func (m *Memo) Invalidate(urlPath string) {
+	urlPath = path.Clean("/" + urlPath)
+	parent := path.Dir(urlPath)
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.gen++
+	for key := range m.entries {
+		p, _, _ := strings.Cut(key, "?")
+		if p == urlPath || strings.HasPrefix(p, strings.TrimSuffix(urlPath, "/")+"/") || (urlPath != "/" && p == parent) {
+			delete(m.entries, key)
+			m.stats.Invalidations++
+		}
+	}
+}
+
+// serve writes e as the response to r: a 304 when r's validators match,
+// and no body for HEAD.
+func (e *memoEntry) serve(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	for k, v := range e.header {
+		h[k] = append([]string(nil), v...) // middleware around the Memo may change them
+	}
+	if e.status == http.StatusOK {
+		var modTime time.Time
+		if lm := e.header.Get("Last-Modified"); lm != "" {
+			modTime, _ = http.ParseTime(lm)
+		}
+		if NotModified(w, r, e.header.Get("ETag"), modTime) {
+			return
+		}
+	}
+	w.WriteHeader(e.status)
+	if r.Method != http.MethodHead {
+		w.Write(e.body)
+	}
+}
+
+// varyValues returns the request headers named in the response's Vary
+// header, with r's values.
+func varyValues(h http.Header, r *http.Request) map[string]string {
+	var vary map[string]string
+	for _, line := range h.Values("Vary") {
+		for _, name := range strings.Split(line, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				if vary == nil {
+					vary = map[string]string{}
+				}
+				vary[http.CanonicalHeaderKey(name)] = r.Header.Get(name)
+			}
+		}
+	}
+	return vary
+}
+
+// recorder is an http.ResponseWriter that keeps the response. The whole
+// body is held in memory before it is sent: a Memo is for API responses,
+// not for downloads or streams.
+type recorder struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (r *recorder) Header() http.Header { return r.header }
+
+func (r *recorder) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+		r.header = r.header.Clone() // later changes to the map do not count
+	}
+}
+
+func (r *recorder) Write(b []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(b)
+}
+
+func (r *recorder) status() int {
+	if r.code == 0 {
+		return http.StatusOK
+	}
+	return r.code
+}
+
+// statusWriter remembers the status of a response it passes through.
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
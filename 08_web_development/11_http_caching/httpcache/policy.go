@@ -0,0 +1,83 @@
+package httpcache
+
+import (
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy is a Cache-Control response header: who may store a response,
+// and for how long it may be used without asking the server again.
+type Policy struct {
+	Public  bool // shared caches (CDNs, proxies) may store it, even for an authenticated request
+	Private bool // only the user's browser may store it
+	NoCache bool // may be stored, but must be revalidated before every use
+	NoStore bool // must not be stored anywhere
+
+	MaxAge       time.Duration // fresh for this long
+	SharedMaxAge time.Duration // s-maxage: overrides MaxAge in shared caches
+
+	// StaleWhileRevalidate lets a cache answer with a stale copy for
+	// this long after MaxAge while it fetches a fresh one.
+	StaleWhileRevalidate time.Duration
+
+	MustRevalidate bool // never use it stale, even when the server is down
+	Immutable      bool // it never changes: do not revalidate even on reload
+}
+
+// Common policies.
+var (
+	// NoStore is for personal or sensitive data: account pages, tokens.
+	NoStore = Policy{NoStore: true}
+
+	// Revalidate stores responses but checks each use with the server,
+	// which answers 304 while the ETag matches. For data that changes at
+	// any moment and must never be seen stale.
+	Revalidate = Policy{NoCache: true}
+
+	// Static is for files with a content hash in the name, such as
+	// app.3f2a1c.js: a new version gets a new URL, so the old one can be
+	// kept for a year.
+	Static = Policy{Public: true, MaxAge: 365 * 24 * time.Hour, Immutable: true}
+)
+
+// String formats p as the value of a Cache-Control header.
+func (p Policy) String() string {
+	var d []string
+	flag := func(on bool, name string) {
+		if on {
+			d = append(d, name)
+		}
+	}
+	seconds := func(v time.Duration, name string) {
+		if v > 0 {
+			d = append(d, name+"="+strconv.FormatInt(int64(v/time.Second), 10))
+		}
+	}
+	flag(p.Public, "public")
+	flag(p.Private, "private")
+	flag(p.NoCache, "no-cache")
+	flag(p.NoStore, "no-store")
+	if !p.NoStore {
+		seconds(p.MaxAge, "max-age")
+		seconds(p.SharedMaxAge, "s-maxage")
+		seconds(p.StaleWhileRevalidate, "stale-while-revalidate")
+	}
+	flag(p.MustRevalidate, "must-revalidate")
+	flag(p.Immutable, "immutable")
+	return strings.Join(d, ", ")
+}
+
+// Handler sets p as the Cache-Control of GET and HEAD responses from
+// next, unless next sets its own. Other methods' responses are not
+// cached by default, and are left alone.
+func (p Policy) Handler(next http.Handler) http.Handler {
+	value := p.String()
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodGet || r.Method == http.MethodHead {
+			w.Header().Set("Cache-Control", value)
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,188 @@
+// Demonstrates HTTP caching for an API.
+//
+// This example shows:
+// - ETag and Last-Modified validators, from a version number and a timestamp
+// - Answering If-None-Match and If-Modified-Since with 304 Not Modified
+// - A Cache-Control policy per route: no-store, no-cache, max-age, immutable
+// - A middleware that memoizes GET responses and drops them on writes
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang_roadmap/08_web_development/11_http_caching/httpcache"
+)
+
+// Article is the resource of the demo API.
+type Article struct {
+	ID      int       `json:"id"`
+	Title   string    `json:"title"`
+	Version int       `json:"version"`
+	Updated time.Time `json:"updated"`
+}
+
+type store struct {
+	mu       sync.Mutex
+	articles map[int]*Article
+	reads    int // handler calls that built a response, to show the memo working
+}
+
+func newStore() *store {
+	t := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	return &store{articles: map[int]*Article{
+		1: {ID: 1, Title: "HTTP caching", Version: 1, Updated: t},
+		2: {ID: 2, Title: "Conditional requests", Version: 1, Updated: t.Add(time.Hour)},
+	}}
+}
+
+// getArticle revalidates with a version-based ETag: comparing a number
+// is cheaper than building the JSON to hash it.
+func (s *store) getArticle(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	s.mu.Lock()
+	a, ok := s.articles[id]
+	var copy Article
+	if ok {
+		copy = *a
+		s.reads++
+	}
+	s.mu.Unlock()
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	if httpcache.NotModified(w, r, fmt.Sprintf(`"v%d"`, copy.Version), copy.Updated) {
+		return
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(copy)
+}
+
+// listArticles sets no validators; the memo adds an ETag hashed from the
+// body.
+func (s *store) listArticles(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	list := make([]Article, 0, len(s.articles))
+	for id := 1; id <= len(s.articles); id++ {
+		list = append(list, *s.articles[id])
+	}
+	s.reads++
+	s.mu.Unlock()
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+}
+
+func (s *store) putArticle(w http.ResponseWriter, r *http.Request) {
+	id, _ := strconv.Atoi(r.PathValue("id"))
+	var in struct{ Title string }
+	if err := json.NewDecoder(r.Body).Decode(&in); err != nil || in.Title == "" {
+		http.Error(w, "Invalid article", http.StatusBadRequest)
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	a, ok := s.articles[id]
+	if !ok {
+		http.NotFound(w, r)
+		return
+	}
+	a.Title, a.Version, a.Updated = in.Title, a.Version+1, time.Now().UTC().Truncate(time.Second)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (s *store) me(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	io.WriteString(w, `{"user":"ada","email":"ada@example.com"}`+"\n")
+}
+
+func newHandler(s *store, memo *httpcache.Memo) http.Handler {
+	mux := http.NewServeMux()
+	// Lists may be a little out of date: shared caches keep them for 30s,
+	// then serve them stale for a minute more while they refresh.
+	list := httpcache.Policy{Public: true, MaxAge: 30 * time.Second, StaleWhileRevalidate: time.Minute}
+	mux.Handle("GET /articles", list.Handler(http.HandlerFunc(s.listArticles)))
+	// One article must never be seen stale: check every time.
+	mux.Handle("GET /articles/{id}", httpcache.Revalidate.Handler(http.HandlerFunc(s.getArticle)))
+	mux.HandleFunc("PUT /articles/{id}", s.putArticle)
+	// Personal data: nowhere, not even in the memo.
+	mux.Handle("GET /me", httpcache.NoStore.Handler(http.HandlerFunc(s.me)))
+	// A file with a hash in its name never changes.
+	mux.Handle("GET /static/app.3f2a1c.js", httpcache.Static.Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/javascript")
+		io.WriteString(w, "console.log('hello')\n")
+	})))
+	return memo.Middleware(mux)
+}
+
+func main() {
+	addr := flag.String("serve", "", "after the demo, serve the API on this address, such as :8080")
+	flag.Parse()
+
+	fmt.Println("HTTP caching examples starting...")
+	s := newStore()
+	memo := httpcache.NewMemo(5*time.Minute, 1000)
+	srv := httptest.NewServer(newHandler(s, memo))
+	defer srv.Close()
+
+	req := func(method, path string, body string, headers ...string) *http.Response {
+		r, _ := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+		for i := 0; i+1 < len(headers); i += 2 {
+			r.Header.Set(headers[i], headers[i+1])
+		}
+		resp, err := http.DefaultClient.Do(r)
+		if err != nil {
+			log.Fatal(err)
+		}
+		b, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("   %-4s %-24s %d %-13s", method, path, resp.StatusCode, resp.Header.Get("X-Cache"))
+		for _, h := range []string{"ETag", "Last-Modified", "Cache-Control"} {
+			if v := resp.Header.Get(h); v != "" {
+				fmt.Printf(" %s: %s;", h, v)
+			}
+		}
+		fmt.Printf(" %d bytes\n", len(bytes.TrimSpace(b)))
+		return resp
+	}
+
+	// 1) Validators and 304.
+	fmt.Println("\n1) ETag and Last-Modified, then conditional requests")
+	first := req("GET", "/articles/1", "")
+	req("GET", "/articles/1", "", "If-None-Match", first.Header.Get("ETag"))
+	req("GET", "/articles/1", "", "If-Modified-Since", first.Header.Get("Last-Modified"))
+	req("GET", "/articles/1", "", "If-None-Match", `"v0"`)
+
+	// 2) Per-route Cache-Control.
+	fmt.Println("\n2) Cache-Control per route")
+	req("GET", "/articles", "")
+	req("GET", "/me", "")
+	req("GET", "/static/app.3f2a1c.js", "")
+
+	// 3) The memo, and invalidation on a write.
+	fmt.Println("\n3) Memoized GETs, invalidated by a PUT")
+	before := s.reads
+	list := req("GET", "/articles", "")
+	req("GET", "/articles", "")
+	req("PUT", "/articles/1", `{"title":"HTTP caching, revised"}`)
+	req("GET", "/articles", "")
+	after := req("GET", "/articles/1", "", "If-None-Match", first.Header.Get("ETag"))
+	req("GET", "/articles", "", "If-None-Match", list.Header.Get("ETag"))
+	fmt.Printf("   the handlers built %d responses for those 5 GETs; the old ETag %s is now %s\n", s.reads-before, first.Header.Get("ETag"), after.Header.Get("ETag"))
+	fmt.Printf("   memo: %+v\n", memo.Stats())
+
+	if *addr != "" {
+		log.Printf("serving on %s: GET /articles, GET and PUT /articles/{id}, GET /me", *addr)
+		log.Fatal(http.ListenAndServe(*addr, newHandler(s, memo)))
+	}
+}
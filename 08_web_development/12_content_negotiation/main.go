@@ -0,0 +1,150 @@
+// Demonstrates content negotiation with the Accept header.
+//
+// This example shows:
+// - Parsing Accept headers with quality values, wildcards and parameters
+// - Picking the best of the server's formats, with the server's order breaking ties
+// - One handler answering in JSON, XML or plain text through an encoder registry
+// - Adding a format, CSV, to the registry
+// - 406 Not Acceptable, and Vary: Accept for caches
+package main
+
+import (
+	"encoding/csv"
+	"encoding/xml"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+
+	"golang_roadmap/08_web_development/12_content_negotiation/negotiate"
+)
+
+// User is the resource of the demo API.
+type User struct {
+	XMLName xml.Name `json:"-" xml:"user"`
+	ID      int      `json:"id" xml:"id,attr"`
+	Name    string   `json:"name" xml:"name"`
+	Email   string   `json:"email" xml:"email"`
+}
+
+func (u User) String() string { return fmt.Sprintf("%d\t%s <%s>", u.ID, u.Name, u.Email) }
+
+// Users wraps a list for XML, which needs a root element.
+type Users struct {
+	XMLName xml.Name `json:"-" xml:"users"`
+	Users   []User   `json:"users" xml:"user"`
+}
+
+func (u Users) String() string {
+	lines := make([]string, len(u.Users))
+	for i, user := range u.Users {
+		lines[i] = user.String()
+	}
+	return strings.Join(lines, "\n")
+}
+
+var users = []User{
+	{ID: 1, Name: "Ada Lovelace", Email: "ada@example.com"},
+	{ID: 2, Name: "Grace Hopper", Email: "grace@example.com"},
+}
+
+// writeCSV is an encoder for the registry: any format is a function.
+func writeCSV(w io.Writer, v any) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "name", "email"})
+	switch v := v.(type) {
+	case Users:
+		for _, u := range v.Users {
+			cw.Write([]string{strconv.Itoa(u.ID), u.Name, u.Email})
+		}
+	case User:
+		cw.Write([]string{strconv.Itoa(v.ID), v.Name, v.Email})
+	default:
+		return fmt.Errorf("cannot write %T as CSV", v)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func newHandler(reg *negotiate.Registry) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		reg.Respond(w, r, http.StatusOK, Users{Users: users})
+	})
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.Atoi(r.PathValue("id"))
+		for _, u := range users {
+			if u.ID == id {
+				reg.Respond(w, r, http.StatusOK, u)
+				return
+			}
+		}
+		http.NotFound(w, r)
+	})
+	return reg.Middleware(mux)
+}
+
+func main() {
+	addr := flag.String("serve", "", "after the demo, serve the API on this address, such as :8080")
+	flag.Parse()
+
+	fmt.Println("content negotiation examples starting...")
+
+	// 1) Parsing: most preferred first.
+	fmt.Println("\n1) Parsing Accept headers")
+	for _, h := range []string{
+		"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8",
+		"text/*;q=0.3, text/plain;format=flowed, */*;q=0.5",
+		"application/json;q=high, text/plain; q=.2, *",
+	} {
+		fmt.Printf("   %-66s -> %v\n", h, negotiate.ParseAccept(h))
+	}
+
+	// 2) Choosing among the server's formats.
+	fmt.Println("\n2) The best offer")
+	offers := []string{"application/json", "text/plain"}
+	for _, h := range []string{"", "text/*", "*/*;q=0.5, text/plain", "application/json;q=0.5, text/plain;q=0.5", "*/*, application/json;q=0", "image/*"} {
+		best, ok := negotiate.Best(h, offers)
+		fmt.Printf("   %-42q -> %q %v\n", h, best, ok)
+	}
+
+	// 3) One handler, many formats.
+	fmt.Println("\n3) GET /users/1 and /users with different Accept headers")
+	reg := negotiate.NewRegistry().
+		Register("application/json", negotiate.JSON).
+		Register("application/xml; charset=utf-8", negotiate.XML).
+		Register("text/plain; charset=utf-8", negotiate.Text).
+		Register("text/csv; charset=utf-8", writeCSV)
+	srv := httptest.NewServer(newHandler(reg))
+	defer srv.Close()
+	for _, tt := range []struct{ path, accept string }{
+		{"/users/1", ""},
+		{"/users/1", "application/xml"},
+		{"/users/1", "text/plain"},
+		{"/users", "text/csv"},
+		{"/users", "text/*;q=0.9, text/plain;q=0.1"},
+		{"/users/1", "image/png"},
+	} {
+		req, _ := http.NewRequest("GET", srv.URL+tt.path, nil)
+		if tt.accept != "" {
+			req.Header.Set("Accept", tt.accept)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		if err != nil {
+			log.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("\n   GET %s, Accept: %q\n   %d %s\n", tt.path, tt.accept, resp.StatusCode, resp.Header.Get("Content-Type"))
+		fmt.Println("   " + strings.ReplaceAll(strings.TrimSpace(string(body)), "\n", "\n   "))
+	}
+
+	if *addr != "" {
+		log.Printf("serving on %s: try curl -H 'Accept: text/csv' localhost%s/users", *addr, *addr)
+		log.Fatal(http.ListenAndServe(*addr, newHandler(reg)))
+	}
+}
@@ -0,0 +1,208 @@
+// Package negotiate picks a response format from a request's Accept
+// header (RFC 9110 section 12.5.1), so one handler can answer in JSON,
+// XML or plain text, and encodes the response in it.
+package negotiate
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// MediaRange is one entry of an Accept header, such as text/* or
+// application/json;q=0.8.
+type MediaRange struct {
+	Type, Subtype string            // lower case; "*" for a wildcard
+	Params        map[string]string // parameters before q, names in lower case
+	Q             float64           // quality, 0 to 1; 0 means "not acceptable"
+}
+
+// String formats r as it could appear in an Accept header.
+func (r MediaRange) String() string {
+	var b strings.Builder
+	b.WriteString(r.Type + "/" + r.Subtype)
+	names := make([]string, 0, len(r.Params))
+	for k := range r.Params {
+		names = append(names, k)
+	}
+	sort.Strings(names)
+	for _, k := range names {
+		b.WriteString(";" + k + "=" + r.Params[k])
+	}
+	if r.Q != 1 {
+		b.WriteString(";q=" + strconv.FormatFloat(r.Q, 'f', -1, 64))
+	}
+	return b.String()
+}
+
+// specificity ranks how closely r names a type: */* is 0, text/* 1,
+// text/plain 2, and each parameter one more.
+func (r MediaRange) specificity() int {
+	n := 0
+	if r.Type != "*" {
+		n++
+	}
+	if r.Subtype != "*" {
+		n++
+	}
+	return n + len(r.Params)
+}
+
+// matches reports whether r covers the media type t/sub with params.
+func (r MediaRange) matches(t, sub string, params map[string]string) bool {
+	if r.Type != "*" && r.Type != t {
+		return false
+	}
+	if r.Subtype != "*" && r.Subtype != sub {
+		return false
+	}
+	for k, v := range r.Params {
+		if !strings.EqualFold(params[k], v) {
+			return false
+		}
+	}
+	return true
+}
+
+// ParseAccept parses an Accept header into its media ranges, most
+// preferred first: by quality, then by specificity, then in the order
+// given. It is lenient, as real clients are sloppy:
+//
+//   - an empty header, or one with no valid range, means */*
+//   - a lone "*", as old Java clients send, means */*
+//   - q=.5, without the leading zero, is accepted
+//   - a range with a q that is not a number from 0 to 1 is dropped,
+//     as are ranges with no subtype, such as "text"
+//   - quoted parameter values may contain commas and semicolons
+//   - parameters after q are accept-extensions, and ignored
+func ParseAccept(header string) []MediaRange {
+	var ranges []MediaRange
+	for _, part := range splitQuoted(header, ',') {
+		if r, ok := parseRange(part); ok {
+			ranges = append(ranges, r)
+		}
+	}
+	if len(ranges) == 0 {
+		return []MediaRange{{Type: "*", Subtype: "*", Q: 1}}
+	}
+	sort.SliceStable(ranges, func(i, j int) bool {
+		if ranges[i].Q != ranges[j].Q {
+			return ranges[i].Q > ranges[j].Q
+		}
+		return ranges[i].specificity() > ranges[j].specificity()
+	})
+	return ranges
+}
+
+func parseRange(s string) (MediaRange, bool) {
+	fields := splitQuoted(s, ';')
+	mt := strings.ToLower(strings.TrimSpace(fields[0]))
+	if mt == "" {
+		return MediaRange{}, false
+	}
+	if mt == "*" {
+		mt = "*/*"
+	}
+	t, sub, ok := strings.Cut(mt, "/")
+	if !ok || t == "" || sub == "" || strings.ContainsAny(sub, "/ ") || (t == "*" && sub != "*") {
+		return MediaRange{}, false
+	}
+	r := MediaRange{Type: t, Subtype: sub, Q: 1}
+	for _, f := range fields[1:] {
+		k, v, _ := strings.Cut(f, "=")
+		k = strings.ToLower(strings.TrimSpace(k))
+		v = strings.TrimSpace(v)
+		if k == "" {
+			continue
+		}
+		if k == "q" {
+			q, err := strconv.ParseFloat(v, 64)
+			if err != nil || q < 0 || q > 1 {
+				return MediaRange{}, false
+			}
+			r.Q = q
+			break // the rest are accept-extensions
+		}
+		if r.Params == nil {
+			r.Params = map[string]string{}
+		}
+		r.Params[k] = unquote(v)
+	}
+	return r, true
+}
+
+// splitQuoted splits s at sep, except inside double quotes.
+func splitQuoted(s string, sep byte) []string {
+	var parts []string
+	quoted, escaped, start := false, false, 0
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case escaped:
+			escaped = false
+		case c == '\\' && quoted:
+			escaped = true
+		case c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// unquote removes the quotes and backslash escapes of a quoted string.
+func unquote(v string) string {
+	if len(v) < 2 || v[0] != '"' || v[len(v)-1] != '"' {
+		return v
+	}
+	var b strings.Builder
+	for i := 1; i < len(v)-1; i++ {
+		if v[i] == '\\' && i+1 < len(v)-1 {
+			i++
+		}
+		b.WriteByte(v[i])
+	}
+	return b.String()
+}
+
+// Best returns the offer, a media type such as "application/json", that
+// the Accept header prefers, and false when it accepts none of them.
+//
+// Each offer gets the quality of the most specific range that matches
+// it, so "text/*;q=0.5, text/plain" gives text/plain 1 and text/html
+// 0.5, and "*/*, text/html;q=0" rules text/html out. Among offers of
+// equal quality, the first offered wins: list the server's preference
+// first.
+func Best(accept string, offers []string) (string, bool) {
+	ranges := ParseAccept(accept)
+	best, bestQ := "", 0.0
+	for _, offer := range offers {
+		t, sub, params := splitMediaType(offer)
+		q, spec := 0.0, -1
+		for _, r := range ranges {
+			if r.matches(t, sub, params) && r.specificity() > spec {
+				q, spec = r.Q, r.specificity()
+			}
+		}
+		if q > bestQ {
+			best, bestQ = offer, q
+		}
+	}
+	return best, bestQ > 0
+}
+
+// splitMediaType splits "text/plain; charset=utf-8" into its lower-case
+// type, subtype and parameters.
+func splitMediaType(s string) (t, sub string, params map[string]string) {
+	fields := splitQuoted(s, ';')
+	t, sub, _ = strings.Cut(strings.ToLower(strings.TrimSpace(fields[0])), "/")
+	for _, f := range fields[1:] {
+		k, v, _ := strings.Cut(f, "=")
+		if params == nil {
+			params = map[string]string{}
+		}
+		params[strings.ToLower(strings.TrimSpace(k))] = unquote(strings.TrimSpace(v))
+	}
+	return t, sub, params
+}
@@ -0,0 +1,162 @@
+package negotiate
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func formatRanges(rs []MediaRange) string {
+	s := make([]string, len(rs))
+	for i, r := range rs {
+		s[i] = r.String()
+	}
+	return strings.Join(s, ", ")
+}
+
+func TestParseAccept(t *testing.T) {
+	for _, tt := range []struct{ header, want string }{
+		{"", "*/*"},
+		{"application/json", "application/json"},
+		{"  Application/JSON  ", "application/json"},
+		{"text/*;q=0.3, text/html;q=0.7, text/html;level=1, text/html;level=2;q=0.4, */*;q=0.5",
+			"text/html;level=1, text/html;q=0.7, */*;q=0.5, text/html;level=2;q=0.4, text/*;q=0.3"},
+		{"text/plain, text/*, */*", "text/plain, text/*, */*"},
+		{"*/*, text/*, text/plain", "text/plain, text/*, */*"},
+		{"application/xml;q=0.9,application/json", "application/json, application/xml;q=0.9"},
+		{"a/a;q=0.5, b/b;q=0.5", "a/a;q=0.5, b/b;q=0.5"},
+		{"text/html, image/gif, image/jpeg, *; q=.2, */*; q=.2", "text/html, image/gif, image/jpeg, */*;q=0.2, */*;q=0.2"},
+		{"text/plain; q=0", "text/plain;q=0"},
+		{"text/plain;q=1.0", "text/plain"},
+		{"text/plain;Q=0.5", "text/plain;q=0.5"},
+		{"text/plain;q=2, application/json", "application/json"},
+		{"text/plain;q=-1, application/json", "application/json"},
+		{"text/plain;q=high, application/json", "application/json"},
+		{"text, application/json", "application/json"},
+		{"*/json, application/json", "application/json"},
+		{"text/plain/extra", "*/*"},
+		{",,, ,", "*/*"},
+		{"garbage", "*/*"},
+		{`text/plain;format="a,b;c", application/json;q=0.5`, `text/plain;format=a,b;c, application/json;q=0.5`},
+		{`text/plain;x="a\"b"`, `text/plain;x=a"b`},
+		{"text/plain;q=0.5;ext=1", "text/plain;q=0.5"},
+		{"text/plain;charset=UTF-8", "text/plain;charset=UTF-8"},
+		{"text/plain;;q=0.5", "text/plain;q=0.5"},
+	} {
+		if got := formatRanges(ParseAccept(tt.header)); got != tt.want {
+			t.Errorf("ParseAccept(%q)\n got %s\nwant %s", tt.header, got, tt.want)
+		}
+	}
+}
+
+func TestBest(t *testing.T) {
+	offers := []string{"application/json", "application/xml; charset=utf-8", "text/plain; charset=utf-8"}
+	for _, tt := range []struct {
+		accept string
+		want   string // "" for none
+	}{
+		{"", "application/json"},
+		{"*/*", "application/json"},
+		{"application/xml", "application/xml; charset=utf-8"},
+		{"text/plain", "text/plain; charset=utf-8"},
+		{"TEXT/PLAIN", "text/plain; charset=utf-8"},
+		{"text/*", "text/plain; charset=utf-8"},
+		{"application/*", "application/json"},
+		{"application/json;q=0.5, application/xml", "application/xml; charset=utf-8"},
+		{"application/json;q=0.5, text/plain;q=0.5", "application/json"}, // a tie: the server's order
+		{"text/plain;q=0.5, application/json;q=0.5", "application/json"}, // the client's order does not break it
+		{"*/*, application/json;q=0", "application/xml; charset=utf-8"},  // excluded despite */*
+		{"application/json;q=0, application/xml;q=0, */*;q=0.1", "text/plain; charset=utf-8"},
+		{"text/*;q=0.5, text/plain;q=0", ""}, // the specific range wins
+		{"text/plain;q=0.1, */*;q=0", "text/plain; charset=utf-8"},
+		{"text/plain;charset=utf-8", "text/plain; charset=utf-8"},
+		{"text/plain;charset=iso-8859-1", ""},
+		{"text/html", ""},
+		{"image/png, image/*;q=0.8", ""},
+		{"application/json;q=0", ""},
+		{"*/*;q=0", ""},
+		{"text/html,application/xhtml+xml,application/xml;q=0.9,*/*;q=0.8", "application/xml; charset=utf-8"}, // a browser
+		{"application/json, text/javascript, */*; q=0.01", "application/json"},                                // jQuery
+	} {
+		got, ok := Best(tt.accept, offers)
+		if got != tt.want || ok != (tt.want != "") {
+			t.Errorf("Best(%q) = %q, %v; want %q", tt.accept, got, ok, tt.want)
+		}
+	}
+}
+
+type point struct {
+	X int `json:"x" xml:"x,attr"`
+	Y int `json:"y" xml:"y,attr"`
+}
+
+func (p point) String() string { return fmt.Sprintf("(%d, %d)", p.X, p.Y) }
+
+func TestRespond(t *testing.T) {
+	h := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		Default.Respond(w, r, http.StatusCreated, point{1, 2})
+	})
+	for _, tt := range []struct {
+		accept, contentType, body string
+		code                      int
+	}{
+		{"", "application/json", `{"x":1,"y":2}` + "\n", http.StatusCreated},
+		{"application/xml", "application/xml; charset=utf-8", xmlHeader + `<point x="1" y="2"></point>` + "\n", http.StatusCreated},
+		{"text/plain", "text/plain; charset=utf-8", "(1, 2)\n", http.StatusCreated},
+		{"text/html", "text/plain; charset=utf-8", "Not acceptable. Available: application/json, application/xml; charset=utf-8, text/plain; charset=utf-8\n", http.StatusNotAcceptable},
+	} {
+		for name, handler := range map[string]http.Handler{"Respond": h, "Middleware": Default.Middleware(h)} {
+			r := httptest.NewRequest("GET", "/", nil)
+			if tt.accept != "" {
+				r.Header.Set("Accept", tt.accept)
+			}
+			w := httptest.NewRecorder()
+			handler.ServeHTTP(w, r)
+			if w.Code != tt.code || w.Header().Get("Content-Type") != tt.contentType || w.Body.String() != tt.body {
+				t.Errorf("%s, Accept %q: %d %q %q", name, tt.accept, w.Code, w.Header().Get("Content-Type"), w.Body)
+			}
+			if w.Header().Get("Vary") != "Accept" {
+				t.Errorf("%s, Accept %q: Vary = %q", name, tt.accept, w.Header().Values("Vary"))
+			}
+		}
+	}
+}
+
+const xmlHeader = `<?xml version="1.0" encoding="UTF-8"?>` + "\n"
+
+func TestMiddlewareSkipsHandler(t *testing.T) {
+	called := false
+	h := Default.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }))
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("Accept", "image/png")
+	h.ServeHTTP(httptest.NewRecorder(), r)
+	if called {
+		t.Error("the handler ran for an unacceptable request")
+	}
+}
+
+func TestRespondEncodeError(t *testing.T) {
+	reg := NewRegistry().Register("application/x-broken", func(w io.Writer, v any) error {
+		io.WriteString(w, "half a bo")
+		return errors.New("broken")
+	})
+	w := httptest.NewRecorder()
+	reg.Respond(w, httptest.NewRequest("GET", "/", nil), http.StatusOK, 1)
+	if w.Code != http.StatusInternalServerError || strings.Contains(w.Body.String(), "half") {
+		t.Errorf("got %d %q", w.Code, w.Body)
+	}
+}
+
+func TestText(t *testing.T) {
+	var b strings.Builder
+	Text(&b, []point{{1, 2}, {3, 4}})
+	Text(&b, []byte("raw"))
+	Text(&b, 42)
+	if want := "(1, 2)\n(3, 4)\nraw\n42\n"; b.String() != want {
+		t.Errorf("got %q, want %q", b.String(), want)
+	}
+}
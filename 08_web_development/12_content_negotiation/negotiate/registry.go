@@ -0,0 +1,150 @@
+package negotiate
+
+import (
+	"bytes"
+	"context"
+	"encoding"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"reflect"
+	"strings"
+)
+
+// EncodeFunc writes v in one format.
+type EncodeFunc func(w io.Writer, v any) error
+
+// Registry maps response formats to their encoders. Register them in the
+// order the server prefers: when a client accepts several equally, as
+// with */*, the first wins.
+type Registry struct {
+	types    []string // content types, in order of registration
+	encoders map[string]EncodeFunc
+}
+
+// NewRegistry returns an empty Registry.
+func NewRegistry() *Registry {
+	return &Registry{encoders: map[string]EncodeFunc{}}
+}
+
+// Default serves JSON, XML and plain text, in that order of preference.
+var Default = NewRegistry().
+	Register("application/json", JSON).
+	Register("application/xml; charset=utf-8", XML).
+	Register("text/plain; charset=utf-8", Text)
+
+// Register adds an encoder for contentType, the value of the
+// Content-Type header it writes, and returns r.
+func (r *Registry) Register(contentType string, enc EncodeFunc) *Registry {
+	if _, ok := r.encoders[contentType]; !ok {
+		r.types = append(r.types, contentType)
+	}
+	r.encoders[contentType] = enc
+	return r
+}
+
+// Types returns the registered content types, in order of preference.
+func (r *Registry) Types() []string { return append([]string(nil), r.types...) }
+
+// Negotiate returns the content type to answer req in, and false when
+// its Accept header accepts none of them.
+func (r *Registry) Negotiate(req *http.Request) (string, bool) {
+	return Best(strings.Join(req.Header.Values("Accept"), ","), r.types)
+}
+
+type chosenKey struct{}
+
+// Middleware negotiates the format of each request before next runs,
+// and answers 406 Not Acceptable itself, so the handler does no work for
+// a response no one can read. Respond finds the choice in the context.
+func (r *Registry) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		w.Header().Add("Vary", "Accept")
+		ct, ok := r.Negotiate(req)
+		if !ok {
+			r.notAcceptable(w)
+			return
+		}
+		next.ServeHTTP(w, req.WithContext(context.WithValue(req.Context(), chosenKey{}, ct)))
+	})
+}
+
+// Respond writes v with status in the format req asks for. Behind
+// Middleware it uses the format chosen there; otherwise it negotiates
+// itself, and answers 406 if it must. v is encoded before anything is
+// sent, so an encoding error becomes a 500, logged, and not half a body.
+func (r *Registry) Respond(w http.ResponseWriter, req *http.Request, status int, v any) {
+	ct, ok := req.Context().Value(chosenKey{}).(string)
+	if !ok {
+		w.Header().Add("Vary", "Accept")
+		if ct, ok = r.Negotiate(req); !ok {
+			r.notAcceptable(w)
+			return
+		}
+	}
+	var buf bytes.Buffer
+	if err := r.encoders[ct](&buf, v); err != nil {
+		log.Printf("Error encoding %T as %s: %v", v, ct, err)
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	w.Header().Set("Content-Type", ct)
+	w.WriteHeader(status)
+	w.Write(buf.Bytes())
+}
+
+// notAcceptable answers 406 with the formats on offer, so the client
+// can fix its Accept header.
+func (r *Registry) notAcceptable(w http.ResponseWriter) {
+	http.Error(w, "Not acceptable. Available: "+strings.Join(r.types, ", "), http.StatusNotAcceptable)
+}
+
+// JSON encodes v with encoding/json.
+func JSON(w io.Writer, v any) error {
+	return json.NewEncoder(w).Encode(v)
+}
+
+// XML encodes v with encoding/xml, after the XML declaration. v should
+// be a struct, with xml tags as needed: encoding/xml cannot encode maps,
+// and a slice comes out as elements with no root, so wrap it in a struct.
+func XML(w io.Writer, v any) error {
+	io.WriteString(w, xml.Header)
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(w, "\n")
+	return err
+}
+
+// Text writes v as text: a []byte as it is, a value with a MarshalText
+// method as that returns, and anything else with fmt's %v, which uses a
+// String method. The elements of other slices go one per line.
+func Text(w io.Writer, v any) error {
+	switch v := v.(type) {
+	case []byte:
+		_, err := fmt.Fprintf(w, "%s\n", v)
+		return err
+	case encoding.TextMarshaler:
+		b, err := v.MarshalText()
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintf(w, "%s\n", b)
+		return err
+	}
+	if rv := reflect.ValueOf(v); rv.Kind() == reflect.Slice {
+		for i := range rv.Len() {
+			if err := Text(w, rv.Index(i).Interface()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	_, err := fmt.Fprintln(w, v)
+	return err
+}
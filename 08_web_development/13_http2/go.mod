@@ -0,0 +1,7 @@
+module golang_roadmap/08_web_development/13_http2
+
+go 1.24.11
+
+require golang.org/x/net v0.47.0
+
+require golang.org/x/text v0.31.0 // indirect
@@ -0,0 +1,177 @@
+package h2
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptrace"
+	"net/textproto"
+	"sync"
+	"time"
+
+	"golang.org/x/net/http2"
+)
+
+// NewClient returns a client that trusts pool. With useHTTP2 it asks for
+// HTTP/2 and uses it when the server agrees; without, it speaks only
+// HTTP/1.1, and opens at most six connections per host, as browsers do.
+//
+// Setting TLSClientConfig on a Transport turns its automatic HTTP/2 off,
+// so Protocols turns it back on.
+func NewClient(pool *x509.CertPool, useHTTP2 bool) *http.Client {
+	var protos http.Protocols
+	protos.SetHTTP1(true)
+	protos.SetHTTP2(useHTTP2)
+	t := &http.Transport{
+		TLSClientConfig: &tls.Config{RootCAs: pool},
+		Protocols:       &protos,
+	}
+	if !useHTTP2 {
+		t.MaxConnsPerHost = 6
+	}
+	return &http.Client{Transport: t, Timeout: 30 * time.Second}
+}
+
+// NewH2CClient returns a client that speaks HTTP/2 over plain TCP from
+// the first byte, to a server wrapped in H2C. It cannot fall back:
+// an HTTP/1.1-only server fails its requests.
+func NewH2CClient() *http.Client {
+	return &http.Client{
+		Transport: &http2.Transport{
+			AllowHTTP: true,
+			// Called for http:// URLs with AllowHTTP: dial without TLS.
+			DialTLSContext: func(ctx context.Context, network, addr string, _ *tls.Config) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, addr)
+			},
+		},
+		Timeout: 30 * time.Second,
+	}
+}
+
+// Trace records, with httptrace, what happened on the wire for the
+// requests made with its context. Read it after they finish.
+type Trace struct {
+	mu         sync.Mutex
+	conns      map[net.Conn]bool
+	NewConns   int      // connections dialed
+	Reused     int      // requests sent on a connection already open
+	ALPN       []string // protocol agreed in each TLS handshake: "h2" or "http/1.1"
+	EarlyHints []string // Link headers of 103 Early Hints responses
+}
+
+// WithContext returns ctx with hooks that record into t.
+func (t *Trace) WithContext(ctx context.Context) context.Context {
+	return httptrace.WithClientTrace(ctx, &httptrace.ClientTrace{
+		GotConn: func(info httptrace.GotConnInfo) {
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			if t.conns == nil {
+				t.conns = map[net.Conn]bool{}
+			}
+			t.conns[info.Conn] = true
+			if info.Reused {
+				t.Reused++
+			} else {
+				t.NewConns++
+			}
+		},
+		TLSHandshakeDone: func(cs tls.ConnectionState, err error) {
+			if err != nil {
+				return
+			}
+			t.mu.Lock()
+			defer t.mu.Unlock()
+			proto := cs.NegotiatedProtocol
+			if proto == "" {
+				proto = "http/1.1" // the server did not do ALPN
+			}
+			t.ALPN = append(t.ALPN, proto)
+		},
+		Got1xxResponse: func(code int, header textproto.MIMEHeader) error {
+			if code == http.StatusEarlyHints {
+				t.mu.Lock()
+				defer t.mu.Unlock()
+				t.EarlyHints = append(t.EarlyHints, header.Values("Link")...)
+			}
+			return nil
+		},
+	})
+}
+
+// Conns returns the number of connections the requests used, new or
+// reused.
+func (t *Trace) Conns() int {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return len(t.conns)
+}
+
+// BurstResult is the outcome of Burst.
+type BurstResult struct {
+	Requests int
+	Elapsed  time.Duration // until the last response had been read
+	Proto    string        // of the responses, such as "HTTP/2.0"
+	Conns    int           // connections used
+}
+
+func (r BurstResult) String() string {
+	return fmt.Sprintf("%d requests in %v over %d connection(s), %s", r.Requests, r.Elapsed.Round(time.Millisecond), r.Conns, r.Proto)
+}
+
+// Burst sends n GET requests for url at once, as a page does for its
+// images, and waits for all the responses. HTTP/1.1 sends one request
+// per connection at a time, so the requests queue for connections;
+// HTTP/2 multiplexes them all over one.
+func Burst(ctx context.Context, c *http.Client, url string, n int) (BurstResult, error) {
+	var tr Trace
+	ctx = tr.WithContext(ctx)
+	var (
+		wg    sync.WaitGroup
+		mu    sync.Mutex
+		proto string
+		first error
+	)
+	start := time.Now()
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			p, err := get(ctx, c, url)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil && first == nil {
+				first = err
+			}
+			proto = p
+		}()
+	}
+	wg.Wait()
+	if first != nil {
+		return BurstResult{}, first
+	}
+	return BurstResult{Requests: n, Elapsed: time.Since(start), Proto: proto, Conns: tr.Conns()}, nil
+}
+
+func get(ctx context.Context, c *http.Client, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if _, err := io.Copy(io.Discard, resp.Body); err != nil {
+		return "", err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("GET %s: %s", url, resp.Status)
+	}
+	return resp.Proto, nil
+}
@@ -0,0 +1,140 @@
+package h2
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func proto(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, r.Proto) }
+
+// startTLS serves h with NewServer on a random local port.
+func startTLS(t testing.TB, h http.Handler) (url string, c1, c2 *http.Client) {
+	t.Helper()
+	cert, pool, err := SelfSigned("127.0.0.1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer(h, cert)
+	go srv.ServeTLS(l, "", "")
+	t.Cleanup(func() { srv.Close() })
+	return "https://" + l.Addr().String(), NewClient(pool, false), NewClient(pool, true)
+}
+
+func TestTLS(t *testing.T) {
+	url, http1, http2 := startTLS(t, http.HandlerFunc(proto))
+	for _, tt := range []struct {
+		client     *http.Client
+		want, alpn string
+	}{
+		{http1, "HTTP/1.1", "http/1.1"},
+		{http2, "HTTP/2.0", "h2"},
+	} {
+		var tr Trace
+		got, err := get(tr.WithContext(context.Background()), tt.client, url)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want || len(tr.ALPN) != 1 || tr.ALPN[0] != tt.alpn {
+			t.Errorf("got %s, ALPN %q; want %s, %s", got, tr.ALPN, tt.want, tt.alpn)
+		}
+	}
+}
+
+func TestH2C(t *testing.T) {
+	srv := httptest.NewServer(H2C(http.HandlerFunc(proto)))
+	defer srv.Close()
+	for _, tt := range []struct {
+		client *http.Client
+		want   string
+	}{
+		{http.DefaultClient, "HTTP/1.1"},
+		{NewH2CClient(), "HTTP/2.0"},
+	} {
+		got, err := get(context.Background(), tt.client, srv.URL)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != tt.want {
+			t.Errorf("got %s, want %s", got, tt.want)
+		}
+	}
+
+	// Without H2C, prior knowledge fails.
+	plain := httptest.NewServer(http.HandlerFunc(proto))
+	defer plain.Close()
+	if _, err := get(context.Background(), NewH2CClient(), plain.URL); err == nil {
+		t.Error("h2c request to an HTTP/1.1 server succeeded")
+	}
+}
+
+func TestEarlyHints(t *testing.T) {
+	url, http1, http2 := startTLS(t, EarlyHints(http.HandlerFunc(proto), "/app.css", "/app.js"))
+	want := []string{"</app.css>; rel=preload; as=style", "</app.js>; rel=preload; as=script"}
+	for _, c := range []*http.Client{http1, http2} {
+		var tr Trace
+		if _, err := get(tr.WithContext(context.Background()), c, url); err != nil {
+			t.Fatal(err)
+		}
+		if fmt.Sprint(tr.EarlyHints) != fmt.Sprint(want) {
+			t.Errorf("hints %q, want %q", tr.EarlyHints, want)
+		}
+	}
+}
+
+func TestBurst(t *testing.T) {
+	url, http1, http2 := startTLS(t, http.HandlerFunc(proto))
+	r1, err := Burst(context.Background(), http1, url, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	r2, err := Burst(context.Background(), http2, url, 20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r1.Proto != "HTTP/1.1" || r1.Conns < 2 || r1.Conns > 6 {
+		t.Errorf("HTTP/1.1: %v", r1)
+	}
+	if r2.Proto != "HTTP/2.0" || r2.Conns != 1 {
+		t.Errorf("HTTP/2: %v", r2)
+	}
+}
+
+// BenchmarkBurst sends bursts of 50 requests to a handler that takes
+// 5ms, as a page does for its images. HTTP/1.1 queues them on six
+// connections; HTTP/2 and h2c multiplex them on one.
+func BenchmarkBurst(b *testing.B) {
+	slow := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(5 * time.Millisecond)
+		proto(w, r)
+	})
+	url, http1, http2 := startTLS(b, slow)
+	h2cSrv := httptest.NewServer(H2C(slow))
+	defer h2cSrv.Close()
+
+	for _, bb := range []struct {
+		name   string
+		client *http.Client
+		url    string
+	}{
+		{"HTTP1.1", http1, url},
+		{"HTTP2", http2, url},
+		{"h2c", NewH2CClient(), h2cSrv.URL},
+	} {
+		b.Run(bb.name, func(b *testing.B) {
+			for b.Loop() {
+				if _, err := Burst(context.Background(), bb.client, bb.url, 50); err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,114 @@
+// Package h2 sets up HTTP/2 on both sides of a connection: over TLS,
+// where net/http negotiates it with ALPN, and over cleartext TCP (h2c),
+// which needs golang.org/x/net/http2. It also has the tools to check
+// which protocol a request used and to measure what multiplexing buys.
+package h2
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"math/big"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http2"
+	"golang.org/x/net/http2/h2c"
+)
+
+// SelfSigned returns a certificate for hosts, names or IP addresses,
+// valid for a day, and a pool that trusts it. It is for demos and tests;
+// use a real certificate, such as one from Let's Encrypt, in production.
+func SelfSigned(hosts ...string) (tls.Certificate, *x509.CertPool, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber:          big.NewInt(time.Now().UnixNano()),
+		Subject:               pkix.Name{CommonName: hosts[0]},
+		NotBefore:             time.Now().Add(-time.Minute),
+		NotAfter:              time.Now().Add(24 * time.Hour),
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageCertSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+	}
+	for _, h := range hosts {
+		if ip := net.ParseIP(h); ip != nil {
+			tmpl.IPAddresses = append(tmpl.IPAddresses, ip)
+		} else {
+			tmpl.DNSNames = append(tmpl.DNSNames, h)
+		}
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	leaf, err := x509.ParseCertificate(der)
+	if err != nil {
+		return tls.Certificate{}, nil, err
+	}
+	pool := x509.NewCertPool()
+	pool.AddCert(leaf)
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key, Leaf: leaf}, pool, nil
+}
+
+// NewServer returns a server for h that offers HTTP/2 and HTTP/1.1 over
+// TLS with cert. Start it with ServeTLS(l, "", "") or
+// ListenAndServeTLS("", ""): net/http adds "h2" to the ALPN protocols
+// itself, and clients that do not ask for it get HTTP/1.1.
+func NewServer(h http.Handler, cert tls.Certificate) *http.Server {
+	return &http.Server{
+		Handler: h,
+		TLSConfig: &tls.Config{
+			Certificates: []tls.Certificate{cert},
+			MinVersion:   tls.VersionTLS12, // HTTP/2 requires 1.2 or later
+		},
+		ReadHeaderTimeout: 10 * time.Second,
+		IdleTimeout:       2 * time.Minute,
+	}
+}
+
+// H2C wraps h so a plain TCP server also speaks HTTP/2: to clients that
+// start with the HTTP/2 preface ("prior knowledge"), and to HTTP/1.1
+// requests with Upgrade: h2c. Others get HTTP/1.1 as before. It is for
+// traffic behind a proxy that terminates TLS, or between services in a
+// trusted network, such as gRPC without TLS.
+func H2C(h http.Handler) http.Handler {
+	return h2c.NewHandler(h, &http2.Server{IdleTimeout: 2 * time.Minute})
+}
+
+// EarlyHints sends 103 Early Hints with a Link preload header for each
+// path before h runs, so a browser fetches a page's CSS and scripts
+// while the server is still building it. It replaces HTTP/2 server push,
+// which browsers have dropped: the client decides, and its cache is used.
+func EarlyHints(h http.Handler, paths ...string) http.Handler {
+	links := make([]string, len(paths))
+	for i, p := range paths {
+		links[i] = "<" + p + ">; rel=preload; as=" + preloadAs(p)
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		for _, l := range links {
+			w.Header().Add("Link", l)
+		}
+		w.WriteHeader(http.StatusEarlyHints)
+		h.ServeHTTP(w, r)
+	})
+}
+
+// preloadAs returns the as= attribute a preload of path needs.
+func preloadAs(path string) string {
+	switch {
+	case strings.HasSuffix(path, ".css"):
+		return "style"
+	case strings.HasSuffix(path, ".js"):
+		return "script"
+	}
+	return "fetch"
+}
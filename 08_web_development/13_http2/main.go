@@ -0,0 +1,141 @@
+// Demonstrates HTTP/2 in Go.
+//
+// This example shows:
+// - A TLS server that offers HTTP/2, and clients with and without it
+// - Checking the protocol with httptrace: ALPN, new and reused connections
+// - h2c, HTTP/2 without TLS, with golang.org/x/net/http2/h2c
+// - 103 Early Hints, the replacement for HTTP/2 server push
+// - A burst of requests over HTTP/1.1, HTTP/2 and h2c
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	"golang_roadmap/08_web_development/13_http2/h2"
+)
+
+func newMux() *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /proto", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, "%s, TLS: %v\n", r.Proto, r.TLS != nil)
+	})
+	// A slow resource, such as an image or an API call.
+	mux.HandleFunc("GET /slow", func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(10 * time.Millisecond)
+		fmt.Fprintln(w, "done")
+	})
+	mux.Handle("GET /page", h2.EarlyHints(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(20 * time.Millisecond) // building the page
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintln(w, `<link rel="stylesheet" href="/app.css"><script src="/app.js"></script>`)
+	}), "/app.css", "/app.js"))
+	return mux
+}
+
+func main() {
+	tlsAddr := flag.String("serve", "", "after the demo, serve HTTP/2 over TLS with a self-signed certificate on this address, such as :8443")
+	h2cAddr := flag.String("h2c", "", "with -serve, also serve h2c on this address, such as :8080")
+	flag.Parse()
+
+	fmt.Println("HTTP/2 examples starting...")
+	ctx := context.Background()
+	mux := newMux()
+
+	cert, pool, err := h2.SelfSigned("localhost", "127.0.0.1")
+	if err != nil {
+		log.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	srv := h2.NewServer(mux, cert)
+	go srv.ServeTLS(l, "", "")
+	defer srv.Close()
+	base := "https://" + l.Addr().String()
+	http1, http2 := h2.NewClient(pool, false), h2.NewClient(pool, true)
+
+	// 1) TLS: the protocol is agreed in the handshake (ALPN).
+	fmt.Println("\n1) HTTP/2 over TLS")
+	for _, c := range []struct {
+		name   string
+		client *http.Client
+	}{{"HTTP/1.1 client", http1}, {"HTTP/2 client", http2}} {
+		var tr h2.Trace
+		body := get(tr.WithContext(ctx), c.client, base+"/proto")
+		get(tr.WithContext(ctx), c.client, base+"/proto")
+		fmt.Printf("   %-16s ALPN %q, %d new connection, %d reused: %s", c.name, tr.ALPN, tr.NewConns, tr.Reused, body)
+	}
+
+	// 2) h2c: HTTP/2 without TLS, for clients that know to use it.
+	fmt.Println("\n2) h2c")
+	h2cSrv := httptest.NewServer(h2.H2C(mux))
+	defer h2cSrv.Close()
+	fmt.Print("   HTTP/1.1 client: ", get(ctx, http.DefaultClient, h2cSrv.URL+"/proto"))
+	fmt.Print("   h2c client:      ", get(ctx, h2.NewH2CClient(), h2cSrv.URL+"/proto"))
+
+	// 3) Early hints: the client learns what to preload before the page is ready.
+	fmt.Println("\n3) 103 Early Hints")
+	var tr h2.Trace
+	get(tr.WithContext(ctx), http2, base+"/page")
+	for _, link := range tr.EarlyHints {
+		fmt.Println("   Link:", link)
+	}
+
+	// 4) Multiplexing: 60 requests for a resource that takes 10ms.
+	fmt.Println("\n4) A burst of 60 requests to /slow")
+	h2cClient := h2.NewH2CClient()
+	for _, b := range []struct {
+		name   string
+		client *http.Client
+		url    string
+	}{
+		{"HTTP/1.1", http1, base + "/slow"},
+		{"HTTP/2", http2, base + "/slow"},
+		{"h2c", h2cClient, h2cSrv.URL + "/slow"},
+	} {
+		r, err := h2.Burst(ctx, b.client, b.url, 60)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("   %-9s %v\n", b.name, r)
+	}
+
+	if *tlsAddr != "" {
+		if *h2cAddr != "" {
+			go func() {
+				log.Printf("serving h2c on %s: try curl --http2-prior-knowledge localhost%s/proto", *h2cAddr, *h2cAddr)
+				log.Fatal(http.ListenAndServe(*h2cAddr, h2.H2C(mux)))
+			}()
+		}
+		srv := h2.NewServer(mux, cert)
+		srv.Addr = *tlsAddr
+		log.Printf("serving on %s: try curl -k https://localhost%s/proto", *tlsAddr, *tlsAddr)
+		log.Fatal(srv.ListenAndServeTLS("", ""))
+	}
+}
+
+func get(ctx context.Context, c *http.Client, url string) string {
+	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return string(body)
+}
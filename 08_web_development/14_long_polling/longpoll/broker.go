@@ -0,0 +1,93 @@
+// Package longpoll delivers events to HTTP clients as they happen, two
+// ways: long polling, where each request waits until there is something
+// to return, and Server-Sent Events, where one response stays open. Both
+// read from a Broker, which numbers events so a client that reconnects
+// can ask for what it missed.
+package longpoll
+
+import (
+	"context"
+	"sync"
+)
+
+// Event is one message, numbered from 1 in the order published.
+type Event struct {
+	ID   uint64 `json:"id"`
+	Data string `json:"data"`
+}
+
+// Broker keeps the latest events and wakes the requests waiting for them.
+// It is safe for concurrent use.
+type Broker struct {
+	mu      sync.Mutex
+	events  []Event // the latest, oldest first, at most size
+	size    int
+	last    uint64
+	changed chan struct{} // closed and replaced on each Publish
+}
+
+// NewBroker returns a Broker that keeps the last size events. A client
+// that falls further behind than that misses the oldest, and sees a gap
+// in the IDs.
+func NewBroker(size int) *Broker {
+	return &Broker{size: size, changed: make(chan struct{})}
+}
+
+// Publish adds an event with data and wakes every waiting request.
+func (b *Broker) Publish(data string) Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.last++
+	e := Event{ID: b.last, Data: data}
+	b.events = append(b.events, e)
+	if len(b.events) > b.size {
+		b.events = b.events[len(b.events)-b.size:]
+	}
+	// Closing a channel wakes every receiver at once, however many.
+	close(b.changed)
+	b.changed = make(chan struct{})
+	return e
+}
+
+// Last returns the ID of the latest event, 0 if there is none. A client
+// that only wants new events starts after it.
+func (b *Broker) Last() uint64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.last
+}
+
+// Since returns the events still kept with IDs after after.
+func (b *Broker) Since(after uint64) []Event {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.since(after)
+}
+
+func (b *Broker) since(after uint64) []Event {
+	for i, e := range b.events {
+		if e.ID > after {
+			return append([]Event(nil), b.events[i:]...)
+		}
+	}
+	return nil
+}
+
+// Wait returns the events after after, waiting until there is at least
+// one or ctx ends. Pass a context with a deadline to bound the wait: it
+// returns ctx.Err() then, and no events.
+func (b *Broker) Wait(ctx context.Context, after uint64) ([]Event, error) {
+	for {
+		b.mu.Lock()
+		events, changed := b.since(after), b.changed
+		b.mu.Unlock()
+		if len(events) > 0 {
+			return events, nil
+		}
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
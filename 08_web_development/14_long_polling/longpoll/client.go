@@ -0,0 +1,93 @@
+package longpoll
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"golang_roadmap/12_operations/03_retry/retry"
+)
+
+// Client follows a PollHandler endpoint, polling again as soon as each
+// poll returns, and reconnecting with backoff when one fails.
+type Client struct {
+	URL     string        // of the endpoint
+	HTTP    *http.Client  // default http.DefaultClient; its Timeout must be 0 or above Wait
+	Wait    time.Duration // asked of the server per poll (default 30s)
+	Backoff retry.Policy  // delays between failed polls; MaxAttempts is ignored
+
+	// OnError, if set, is called for each failed poll, e.g. to log.
+	OnError func(err error, delay time.Duration)
+}
+
+// Run polls from the event after after until ctx ends, calling fn for
+// each event in order, and returns ctx's error. It keeps its place
+// across failures, so a server restart or a dropped connection loses
+// nothing the broker still holds.
+func (c *Client) Run(ctx context.Context, after uint64, fn func(Event)) error {
+	wait := c.Wait
+	if wait <= 0 {
+		wait = 30 * time.Second
+	}
+	failures := 0
+	for {
+		events, err := c.poll(ctx, after, wait)
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		if err != nil {
+			failures++
+			delay := c.Backoff.Delay(failures)
+			if c.OnError != nil {
+				c.OnError(err, delay)
+			}
+			select {
+			case <-time.After(delay):
+				continue
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+		failures = 0
+		for _, e := range events {
+			fn(e)
+			after = e.ID
+		}
+	}
+}
+
+// poll makes one request. A 204 is no events and no error.
+func (c *Client) poll(ctx context.Context, after uint64, wait time.Duration) ([]Event, error) {
+	// Bound the request a little past the wait, in case the server or
+	// the network hangs; a plain Client.Timeout would need the same.
+	ctx, cancel := context.WithTimeout(ctx, wait+10*time.Second)
+	defer cancel()
+	u := fmt.Sprintf("%s?after=%d&wait=%s", c.URL, after, url.QueryEscape(wait.String()))
+	req, err := http.NewRequestWithContext(ctx, "GET", u, nil)
+	if err != nil {
+		return nil, err
+	}
+	hc := c.HTTP
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	resp, err := hc.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var body struct{ Events []Event }
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			return nil, fmt.Errorf("decoding events: %w", err)
+		}
+		return body.Events, nil
+	case http.StatusNoContent:
+		return nil, nil
+	}
+	return nil, fmt.Errorf("poll: %s", resp.Status)
+}
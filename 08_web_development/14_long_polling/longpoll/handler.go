@@ -0,0 +1,126 @@
+package longpoll
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PollHandler serves long polls: GET ?after=ID&wait=30s. It answers at
+// once if there are events after ID, and otherwise holds the request
+// until one is published or the wait is over.
+//
+//   - 200 with {"events": [...]} when there are events
+//   - 204 No Content when the wait ran out; poll again with the same ID
+//   - 400 for a bad after or wait
+//
+// wait defaults to, and is capped at, maxWait. If the client goes away,
+// the request's context ends the wait and nothing is written.
+func PollHandler(b *Broker, maxWait time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		after, wait, err := pollParams(r, maxWait)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		// The server's WriteTimeout counts from the start of the request,
+		// and would cut a long wait off. Extend it for this one.
+		// (Under TimeoutHandler or in a test recorder this is not
+		// supported, which is fine.)
+		http.NewResponseController(w).SetWriteDeadline(time.Now().Add(wait + 10*time.Second))
+
+		ctx, cancel := context.WithTimeout(r.Context(), wait)
+		defer cancel()
+		events, err := b.Wait(ctx, after)
+		switch {
+		case errors.Is(err, context.DeadlineExceeded):
+			// The wait ran out, or a deadline on the request did.
+			w.Header().Set("Cache-Control", "no-store")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		case err != nil:
+			return // the client left
+		}
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(map[string][]Event{"events": events})
+	})
+}
+
+func pollParams(r *http.Request, maxWait time.Duration) (after uint64, wait time.Duration, err error) {
+	q := r.URL.Query()
+	if s := q.Get("after"); s != "" {
+		if after, err = strconv.ParseUint(s, 10, 64); err != nil {
+			return 0, 0, fmt.Errorf("after: not an event ID: %q", s)
+		}
+	}
+	wait = maxWait
+	if s := q.Get("wait"); s != "" {
+		if wait, err = time.ParseDuration(s); err != nil || wait <= 0 {
+			return 0, 0, fmt.Errorf("wait: not a positive duration: %q", s)
+		}
+		wait = min(wait, maxWait)
+	}
+	return after, wait, nil
+}
+
+// SSEHandler streams events as Server-Sent Events over one long response,
+// for browsers' EventSource. Each event carries its ID, and a browser
+// that reconnects sends the last one in Last-Event-ID, so it resumes
+// where it left off; ?after=ID does the same for the first connection.
+// A comment line every keepAlive keeps proxies from closing an idle
+// stream.
+func SSEHandler(b *Broker, keepAlive time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get("Last-Event-ID")
+		if id == "" {
+			id = r.URL.Query().Get("after")
+		}
+		var after uint64
+		if id != "" {
+			var err error
+			if after, err = strconv.ParseUint(id, 10, 64); err != nil {
+				http.Error(w, "Bad event ID", http.StatusBadRequest)
+				return
+			}
+		}
+		rc := http.NewResponseController(w)
+		// No write deadline: the stream is meant to stay open.
+		rc.SetWriteDeadline(time.Time{})
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+		if err := rc.Flush(); err != nil {
+			// Behind a middleware that buffers, such as TimeoutHandler,
+			// nothing would reach the client until the handler returned.
+			log.Printf("SSE: cannot flush: %v", err)
+			return
+		}
+
+		for {
+			ctx, cancel := context.WithTimeout(r.Context(), keepAlive)
+			events, err := b.Wait(ctx, after)
+			cancel()
+			switch {
+			case r.Context().Err() != nil:
+				return
+			case err != nil:
+				fmt.Fprint(w, ": keep-alive\n\n")
+			}
+			for _, e := range events {
+				// A line break in the data needs a data: line of its own.
+				fmt.Fprintf(w, "id: %d\ndata: %s\n\n", e.ID, strings.ReplaceAll(e.Data, "\n", "\ndata: "))
+				after = e.ID
+			}
+			if err := rc.Flush(); err != nil {
+				return
+			}
+		}
+	})
+}
@@ -0,0 +1,181 @@
+package longpoll
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang_roadmap/12_operations/03_retry/retry"
+)
+
+func TestBroker(t *testing.T) {
+	b := NewBroker(3)
+	for i := range 5 {
+		b.Publish(fmt.Sprint("e", i+1))
+	}
+	if got := fmt.Sprint(b.Since(0)); got != "[{3 e3} {4 e4} {5 e5}]" {
+		t.Errorf("Since(0) = %s; want the last 3", got)
+	}
+	if got := b.Since(5); got != nil {
+		t.Errorf("Since(5) = %v", got)
+	}
+
+	got := make(chan []Event)
+	for range 2 {
+		go func() {
+			events, _ := b.Wait(context.Background(), 5)
+			got <- events
+		}()
+	}
+	time.Sleep(10 * time.Millisecond)
+	b.Publish("e6")
+	for range 2 {
+		if events := <-got; len(events) != 1 || events[0].ID != 6 {
+			t.Errorf("waiter got %v", events)
+		}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if events, err := b.Wait(ctx, 6); events != nil || err != context.DeadlineExceeded {
+		t.Errorf("Wait with nothing new = %v, %v", events, err)
+	}
+}
+
+func do(h http.Handler, target string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", target, nil))
+	return w
+}
+
+func TestPollHandler(t *testing.T) {
+	b := NewBroker(10)
+	b.Publish("hello")
+	h := PollHandler(b, time.Second)
+
+	if w := do(h, "/events?after=0"); w.Code != 200 || w.Body.String() != `{"events":[{"id":1,"data":"hello"}]}`+"\n" {
+		t.Errorf("after=0: %d %s", w.Code, w.Body)
+	}
+	start := time.Now()
+	if w := do(h, "/events?after=1&wait=20ms"); w.Code != http.StatusNoContent || w.Body.Len() != 0 {
+		t.Errorf("nothing new: %d %q", w.Code, w.Body)
+	}
+	if d := time.Since(start); d < 20*time.Millisecond || d > 500*time.Millisecond {
+		t.Errorf("waited %v for wait=20ms", d)
+	}
+	for _, q := range []string{"after=x", "after=-1", "wait=soon", "wait=-1s"} {
+		if w := do(h, "/events?"+q); w.Code != http.StatusBadRequest {
+			t.Errorf("%s: %d", q, w.Code)
+		}
+	}
+
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		b.Publish("later")
+	}()
+	if w := do(h, "/events?after=1"); w.Code != 200 || !strings.Contains(w.Body.String(), `"later"`) {
+		t.Errorf("woken poll: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestPollHandlerClientGone(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	w := httptest.NewRecorder()
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		cancel()
+	}()
+	PollHandler(NewBroker(1), time.Minute).ServeHTTP(w, httptest.NewRequest("GET", "/", nil).WithContext(ctx))
+	if w.Body.Len() != 0 || w.Code != 200 || w.Flushed {
+		t.Errorf("wrote a response to a client that left: %d %q", w.Code, w.Body)
+	}
+}
+
+// A poll that outlives the request timeout: TimeoutHandler turns it into
+// an error, Deadline into a normal empty poll.
+func TestTimeouts(t *testing.T) {
+	poll := PollHandler(NewBroker(1), time.Minute)
+	if w := do(http.TimeoutHandler(poll, 20*time.Millisecond, "too slow"), "/"); w.Code != http.StatusServiceUnavailable || w.Body.String() != "too slow" {
+		t.Errorf("TimeoutHandler: %d %q", w.Code, w.Body)
+	}
+	if w := do(Deadline(poll, 20*time.Millisecond), "/"); w.Code != http.StatusNoContent {
+		t.Errorf("Deadline: %d %q", w.Code, w.Body)
+	}
+}
+
+func TestSSE(t *testing.T) {
+	b := NewBroker(10)
+	b.Publish("one")
+	b.Publish("two\nlines")
+	srv := httptest.NewServer(SSEHandler(b, 20*time.Millisecond))
+	defer srv.Close()
+
+	req, _ := http.NewRequest("GET", srv.URL, nil)
+	req.Header.Set("Last-Event-ID", "1") // a reconnect: resume after 1
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	if ct := resp.Header.Get("Content-Type"); ct != "text/event-stream" {
+		t.Errorf("Content-Type %q", ct)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond) // after at least one keep-alive
+		b.Publish("three")
+	}()
+
+	var lines []string
+	sc := bufio.NewScanner(resp.Body)
+	for sc.Scan() && sc.Text() != "data: three" {
+		lines = append(lines, sc.Text())
+	}
+	got := strings.Join(lines, "|")
+	if want := "id: 2|data: two|data: lines||: keep-alive||"; !strings.HasPrefix(got, want) || !strings.HasSuffix(got, "|id: 3") {
+		t.Errorf("stream %q, want %q...id: 3", got, want)
+	}
+}
+
+func TestClientReconnects(t *testing.T) {
+	b := NewBroker(10)
+	poll := PollHandler(b, time.Second)
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if n := requests.Add(1); n == 2 || n == 3 { // a restart, say
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		poll.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	var failures int
+	c := &Client{
+		URL:     srv.URL,
+		Wait:    time.Second,
+		Backoff: retry.Policy{Initial: time.Millisecond, Max: 5 * time.Millisecond},
+		OnError: func(error, time.Duration) { failures++ },
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	b.Publish("a")
+	var got []string
+	err := c.Run(ctx, 0, func(e Event) {
+		got = append(got, e.Data)
+		switch e.Data {
+		case "a":
+			b.Publish("b") // published while the server is down
+		case "b":
+			cancel()
+		}
+	})
+	if err != context.Canceled || fmt.Sprint(got) != "[a b]" || failures != 2 {
+		t.Errorf("Run = %v, got %v, %d failures", err, got, failures)
+	}
+}
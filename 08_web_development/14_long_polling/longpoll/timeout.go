@@ -0,0 +1,23 @@
+package longpoll
+
+import (
+	"context"
+	"net/http"
+	"time"
+)
+
+// Deadline gives each request d to finish, as a deadline on its context,
+// and leaves the response to the handler. It is the manual alternative
+// to http.TimeoutHandler, which, when d passes, answers 503 itself and
+// throws away whatever the handler wrote. TimeoutHandler is the safe
+// default for handlers that ignore their context. Deadline suits those
+// that watch it: they can return partial results, pick their own status,
+// or, like PollHandler, treat the deadline as a normal outcome. It also
+// does not buffer the response, so streaming still works.
+func Deadline(h http.Handler, d time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		ctx, cancel := context.WithTimeout(r.Context(), d)
+		defer cancel()
+		h.ServeHTTP(w, r.WithContext(ctx))
+	})
+}
@@ -0,0 +1,163 @@
+// Demonstrates long polling, request timeouts, and Server-Sent Events.
+//
+// This example shows:
+// - A long-poll /events endpoint that waits on the request's context
+// - A client that polls in a loop and reconnects with backoff
+// - http.TimeoutHandler against a deadline on the context
+// - The same events as Server-Sent Events, resumed with Last-Event-ID
+package main
+
+import (
+	"bufio"
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"time"
+
+	"golang_roadmap/08_web_development/14_long_polling/longpoll"
+	"golang_roadmap/12_operations/03_retry/retry"
+)
+
+// report stands in for slow work that checks its context between steps,
+// and returns what it finished when the context ends.
+func report(w http.ResponseWriter, r *http.Request) {
+	rows := 0
+	for ; rows < 6; rows++ {
+		select {
+		case <-time.After(50 * time.Millisecond):
+		case <-r.Context().Done():
+			w.Header().Set("X-Partial", "true")
+			fmt.Fprintf(w, "partial report: %d of 6 rows\n", rows)
+			return
+		}
+	}
+	fmt.Fprintln(w, "full report: 6 of 6 rows")
+}
+
+func main() {
+	addr := flag.String("serve", "", "after the demo, serve /events and /stream on this address, such as :8080, with an event each second")
+	flag.Parse()
+
+	fmt.Println("long polling examples starting...")
+
+	broker := longpoll.NewBroker(100)
+	var down atomic.Bool // makes /events fail, as during a restart
+	mux := http.NewServeMux()
+	poll := longpoll.PollHandler(broker, 2*time.Second)
+	mux.Handle("GET /events", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			http.Error(w, "Service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		poll.ServeHTTP(w, r)
+	}))
+	mux.Handle("GET /stream", longpoll.SSEHandler(broker, 15*time.Second))
+	mux.Handle("GET /report/timeout-handler", http.TimeoutHandler(http.HandlerFunc(report), 120*time.Millisecond, "Report timed out\n"))
+	mux.Handle("GET /report/deadline", longpoll.Deadline(http.HandlerFunc(report), 120*time.Millisecond))
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	// 1) A poll with nothing to report waits, then answers 204.
+	fmt.Println("\n1) An empty long poll")
+	t0 := time.Now()
+	resp, err := http.Get(srv.URL + "/events?after=0&wait=200ms")
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Body.Close()
+	fmt.Printf("   GET /events?after=0&wait=200ms -> %s after %v\n", resp.Status, time.Since(t0).Round(10*time.Millisecond))
+
+	// 2) A client follows the events, and rides out an outage.
+	fmt.Println("\n2) A polling client, with the server down for a while")
+	start := time.Now()
+	since := func() string { return fmt.Sprintf("%4dms", time.Since(start).Milliseconds()) }
+	client := &longpoll.Client{
+		URL:     srv.URL + "/events",
+		Wait:    time.Second,
+		Backoff: retry.Policy{Initial: 50 * time.Millisecond, Max: 400 * time.Millisecond},
+		OnError: func(err error, delay time.Duration) {
+			fmt.Printf("   %s  poll failed (%v), retrying in %v\n", since(), err, delay.Round(time.Millisecond))
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		client.Run(ctx, broker.Last(), func(e longpoll.Event) {
+			fmt.Printf("   %s  event %d: %s\n", since(), e.ID, e.Data)
+			if e.ID == 4 {
+				cancel()
+			}
+		})
+	}()
+	time.Sleep(100 * time.Millisecond)
+	broker.Publish("order 1001 paid")
+	time.Sleep(100 * time.Millisecond)
+	broker.Publish("order 1002 paid")
+	time.Sleep(50 * time.Millisecond)
+	down.Store(true)
+	broker.Publish("order 1001 shipped") // answers the waiting poll; the next one fails
+	time.Sleep(50 * time.Millisecond)
+	broker.Publish("order 1003 paid") // during the outage, and not lost
+	time.Sleep(300 * time.Millisecond)
+	fmt.Printf("   %s  server back\n", since())
+	down.Store(false)
+	<-done
+
+	// 3) Timeouts: TimeoutHandler replaces the response, a deadline lets
+	// the handler choose.
+	fmt.Println("\n3) A 300ms report with a 120ms timeout")
+	for _, path := range []string{"/report/timeout-handler", "/report/deadline"} {
+		resp, err := http.Get(srv.URL + path)
+		if err != nil {
+			log.Fatal(err)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		resp.Body.Close()
+		fmt.Printf("   GET %-24s -> %s: %s", path, resp.Status, body)
+	}
+
+	// 4) SSE: one response, events pushed down it. A reconnect resumes
+	// from Last-Event-ID.
+	fmt.Println("\n4) Server-Sent Events, resuming after event 2")
+	req, _ := http.NewRequest("GET", srv.URL+"/stream", nil)
+	req.Header.Set("Last-Event-ID", "2")
+	resp, err = http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		broker.Publish("order 1002 shipped")
+	}()
+	sc := bufio.NewScanner(resp.Body)
+	for n := 0; n < 3 && sc.Scan(); {
+		if line := sc.Text(); line != "" {
+			fmt.Println("   " + line)
+		} else {
+			n++ // a blank line ends an event
+		}
+	}
+	resp.Body.Close()
+
+	if *addr != "" {
+		go func() {
+			for t := range time.Tick(time.Second) {
+				broker.Publish("tick " + t.Format(time.TimeOnly))
+			}
+		}()
+		log.Printf("serving on %s: try curl 'localhost%s/events?after=0' or curl -N localhost%s/stream", *addr, *addr, *addr)
+		s := &http.Server{
+			Addr:              *addr,
+			Handler:           mux,
+			ReadHeaderTimeout: 5 * time.Second,
+			WriteTimeout:      10 * time.Second, // the handlers extend it per request
+		}
+		log.Fatal(s.ListenAndServe())
+	}
+}
@@ -0,0 +1,254 @@
+// Package cors is middleware for Cross-Origin Resource Sharing: it tells
+// browsers which other origins may call an API from JavaScript, and
+// answers their preflight OPTIONS requests.
+//
+// CORS is enforced by the browser, not the server. A request from an
+// origin that is not allowed still reaches the handler; the browser only
+// hides the response from the page. Authentication and CSRF protection
+// still have to be done separately.
+package cors
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Options configure a Policy.
+type Options struct {
+	// AllowedOrigins lists the origins that may read responses, each a
+	// scheme and host with an optional port, such as
+	// "https://app.example.com". Wildcards:
+	//
+	//   - "*" allows any origin (not with AllowCredentials)
+	//   - "https://*.example.com" allows any subdomain, at any depth, but
+	//     not example.com itself
+	//   - "http://localhost:*" allows any port
+	AllowedOrigins []string
+
+	// AllowedMethods may be used in requests; default GET, HEAD and POST.
+	AllowedMethods []string
+
+	// AllowedHeaders may be sent in requests, beyond those browsers
+	// always allow, such as Accept and Accept-Language. "*" allows any
+	// (not with AllowCredentials).
+	AllowedHeaders []string
+
+	// ExposedHeaders may be read by the page from responses, beyond the
+	// safe few, such as Content-Type, that it can always read.
+	ExposedHeaders []string
+
+	// AllowCredentials lets pages send cookies and HTTP authentication
+	// and read the response. It requires listing origins.
+	AllowCredentials bool
+
+	// MaxAge is how long a browser may cache a preflight answer. Zero
+	// leaves it to the browser, often 5 seconds; browsers cap it, Chrome
+	// at 2 hours.
+	MaxAge time.Duration
+}
+
+// Policy is a validated set of Options. It is safe for concurrent use.
+type Policy struct {
+	anyOrigin      bool
+	origins        []originPattern
+	methods        []string
+	anyHeader      bool
+	headers        []string // lower case
+	allowMethods   string   // the Access-Control-Allow-Methods value
+	exposedHeaders string
+	credentials    bool
+	maxAge         string
+}
+
+// New validates opts and returns the Policy. It rejects malformed origins
+// and wildcards combined with credentials, which would let any site act
+// as the logged-in user.
+func New(opts Options) (*Policy, error) {
+	p := &Policy{credentials: opts.AllowCredentials}
+	var errs []error
+	for _, o := range opts.AllowedOrigins {
+		if o == "*" {
+			p.anyOrigin = true
+			continue
+		}
+		pat, err := parseOrigin(o)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		p.origins = append(p.origins, pat)
+	}
+	if p.anyOrigin && p.credentials {
+		errs = append(errs, errors.New(`cors: origin "*" cannot be used with credentials`))
+	}
+
+	methods := opts.AllowedMethods
+	if len(methods) == 0 {
+		methods = []string{http.MethodGet, http.MethodHead, http.MethodPost}
+	}
+	for _, m := range methods {
+		p.methods = append(p.methods, strings.ToUpper(m))
+	}
+	p.allowMethods = strings.Join(p.methods, ", ")
+
+	for _, h := range opts.AllowedHeaders {
+		if h == "*" {
+			p.anyHeader = true
+			continue
+		}
+		p.headers = append(p.headers, strings.ToLower(h))
+	}
+	if p.anyHeader && p.credentials {
+		errs = append(errs, errors.New(`cors: header "*" cannot be used with credentials`))
+	}
+	p.exposedHeaders = strings.Join(opts.ExposedHeaders, ", ")
+	if opts.MaxAge > 0 {
+		p.maxAge = strconv.Itoa(int(opts.MaxAge.Seconds()))
+	}
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// Handler applies the policy to requests for next. Put it outside the
+// router: preflights are OPTIONS requests, which routes for GET or POST
+// would not match. It answers them itself, with 204 when the request
+// would be allowed and 403 when not. Other requests go on to next, with
+// CORS headers when their origin is allowed.
+func (p *Policy) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			p.preflight(w, r)
+			return
+		}
+		// Unless every origin gets "*", the response differs by Origin,
+		// and a shared cache must not serve one origin's to another.
+		if !p.anyOrigin || p.credentials {
+			w.Header().Add("Vary", "Origin")
+		}
+		if origin := r.Header.Get("Origin"); origin != "" && p.originAllowed(origin) {
+			p.allowOrigin(w, origin)
+			if p.exposedHeaders != "" {
+				w.Header().Set("Access-Control-Expose-Headers", p.exposedHeaders)
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (p *Policy) preflight(w http.ResponseWriter, r *http.Request) {
+	h := w.Header()
+	h.Add("Vary", "Origin")
+	h.Add("Vary", "Access-Control-Request-Method")
+	h.Add("Vary", "Access-Control-Request-Headers")
+
+	origin := r.Header.Get("Origin")
+	if !p.originAllowed(origin) {
+		http.Error(w, "CORS: origin not allowed", http.StatusForbidden)
+		return
+	}
+	method := r.Header.Get("Access-Control-Request-Method")
+	if !slices.Contains(p.methods, method) {
+		http.Error(w, "CORS: method not allowed: "+method, http.StatusForbidden)
+		return
+	}
+	requested := parseList(r.Header.Get("Access-Control-Request-Headers"))
+	for _, name := range requested {
+		if !p.anyHeader && !slices.Contains(p.headers, name) {
+			http.Error(w, "CORS: header not allowed: "+name, http.StatusForbidden)
+			return
+		}
+	}
+
+	p.allowOrigin(w, origin)
+	h.Set("Access-Control-Allow-Methods", p.allowMethods)
+	if len(requested) > 0 {
+		// Echo what was asked for: it is all allowed, and it is shorter
+		// than the full list.
+		h.Set("Access-Control-Allow-Headers", strings.Join(requested, ", "))
+	}
+	if p.maxAge != "" {
+		h.Set("Access-Control-Max-Age", p.maxAge)
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (p *Policy) allowOrigin(w http.ResponseWriter, origin string) {
+	if p.anyOrigin && !p.credentials {
+		w.Header().Set("Access-Control-Allow-Origin", "*")
+		return
+	}
+	w.Header().Set("Access-Control-Allow-Origin", origin)
+	if p.credentials {
+		w.Header().Set("Access-Control-Allow-Credentials", "true")
+	}
+}
+
+func (p *Policy) originAllowed(origin string) bool {
+	if origin == "" {
+		return false
+	}
+	if p.anyOrigin {
+		return true
+	}
+	o, err := parseOrigin(origin)
+	if err != nil || o.host == "" || strings.Contains(o.host, "*") || o.port == "*" {
+		return false // "null", from sandboxed frames and files, lands here too
+	}
+	for _, pat := range p.origins {
+		if pat.matches(o) {
+			return true
+		}
+	}
+	return false
+}
+
+// originPattern is a parsed allowed origin. host may start with "*." and
+// port may be "*".
+type originPattern struct {
+	scheme, host, port string
+}
+
+func parseOrigin(s string) (originPattern, error) {
+	rest, anyPort := strings.CutSuffix(strings.ToLower(s), ":*")
+	u, err := url.Parse(rest)
+	if err != nil || u.Scheme == "" || u.Host == "" || u.Path != "" || u.RawQuery != "" || u.User != nil || u.Fragment != "" || (anyPort && u.Port() != "") {
+		return originPattern{}, fmt.Errorf("cors: origin %q is not scheme://host[:port]", s)
+	}
+	host, port := u.Hostname(), u.Port()
+	if anyPort {
+		port = "*"
+	}
+	if strings.Contains(strings.TrimPrefix(host, "*."), "*") {
+		return originPattern{}, fmt.Errorf("cors: origin %q: a wildcard must be the whole first label, as in https://*.example.com", s)
+	}
+	return originPattern{scheme: u.Scheme, host: host, port: port}, nil
+}
+
+func (pat originPattern) matches(o originPattern) bool {
+	if pat.scheme != o.scheme || (pat.port != "*" && pat.port != o.port) {
+		return false
+	}
+	if suffix, ok := strings.CutPrefix(pat.host, "*"); ok {
+		return strings.HasSuffix(o.host, suffix) && len(o.host) > len(suffix)
+	}
+	return pat.host == o.host
+}
+
+// parseList splits a comma-separated header value into lower-case names.
+func parseList(s string) []string {
+	var out []string
+	for _, f := range strings.Split(s, ",") {
+		if f = strings.ToLower(strings.TrimSpace(f)); f != "" {
+			out = append(out, f)
+		}
+	}
+	return out
+}
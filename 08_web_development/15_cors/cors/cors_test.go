@@ -0,0 +1,188 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func mustNew(t *testing.T, opts Options) *Policy {
+	t.Helper()
+	p, err := New(opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+var ok = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("X-Request-Id", "42")
+	w.Write([]byte("body"))
+})
+
+func serve(h http.Handler, method string, header map[string]string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(method, "/users", nil)
+	for k, v := range header {
+		r.Header.Set(k, v)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestOriginMatching(t *testing.T) {
+	p := mustNew(t, Options{AllowedOrigins: []string{
+		"https://app.example.com",
+		"https://*.example.org",
+		"http://localhost:*",
+		"HTTP://Upper.Example.NET",
+	}})
+	for origin, want := range map[string]bool{
+		"https://app.example.com":       true,
+		"https://APP.example.com":       true,
+		"http://app.example.com":        false, // another scheme
+		"https://app.example.com:8443":  false, // another port
+		"https://app.example.com.evil":  false,
+		"https://evilapp.example.com":   false,
+		"https://a.example.org":         true,
+		"https://a.b.example.org":       true,
+		"https://example.org":           false, // the wildcard needs a subdomain
+		"https://evil-example.org":      false,
+		"https://a.example.org.evil.io": false,
+		"http://localhost":              true,
+		"http://localhost:3000":         true,
+		"https://localhost:3000":        false,
+		"http://upper.example.net":      true,
+		"null":                          false,
+		"":                              false,
+		"https://*.example.org":         false, // an origin is never a pattern
+		"https://app.example.com/":      false,
+		"app.example.com":               false,
+	} {
+		if got := p.originAllowed(origin); got != want {
+			t.Errorf("originAllowed(%q) = %v, want %v", origin, got, want)
+		}
+	}
+}
+
+func TestNewErrors(t *testing.T) {
+	for _, tt := range []struct {
+		opts Options
+		want string
+	}{
+		{Options{AllowedOrigins: []string{"*"}, AllowCredentials: true}, `origin "*" cannot be used with credentials`},
+		{Options{AllowedOrigins: []string{"https://a.b"}, AllowedHeaders: []string{"*"}, AllowCredentials: true}, `header "*" cannot be used with credentials`},
+		{Options{AllowedOrigins: []string{"example.com"}}, `"example.com" is not scheme://host[:port]`},
+		{Options{AllowedOrigins: []string{"https://example.com/app"}}, `is not scheme://host[:port]`},
+		{Options{AllowedOrigins: []string{"https://example.com:80:*"}}, `is not scheme://host[:port]`},
+		{Options{AllowedOrigins: []string{"https://api.*.example.com"}}, `a wildcard must be the whole first label`},
+		{Options{AllowedOrigins: []string{"https://*example.com"}}, `a wildcard must be the whole first label`},
+	} {
+		_, err := New(tt.opts)
+		if err == nil || !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("New(%+v) = %v, want %q", tt.opts, err, tt.want)
+		}
+	}
+}
+
+func TestPreflight(t *testing.T) {
+	h := mustNew(t, Options{
+		AllowedOrigins: []string{"https://app.example.com"},
+		AllowedMethods: []string{"get", "POST", "DELETE"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		MaxAge:         10 * time.Minute,
+	}).Handler(ok)
+
+	for _, tt := range []struct {
+		name    string
+		header  map[string]string
+		code    int
+		allowed string // Access-Control-Allow-Headers
+	}{
+		{"allowed", map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "DELETE", "Access-Control-Request-Headers": "content-type, Authorization"}, 204, "content-type, authorization"},
+		{"no headers", map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "GET"}, 204, ""},
+		{"origin", map[string]string{"Origin": "https://evil.example", "Access-Control-Request-Method": "GET"}, 403, ""},
+		{"no origin", map[string]string{"Access-Control-Request-Method": "GET"}, 403, ""},
+		{"method", map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "PATCH"}, 403, ""},
+		{"method case", map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "delete"}, 403, ""},
+		{"header", map[string]string{"Origin": "https://app.example.com", "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "content-type, x-debug"}, 403, ""},
+	} {
+		w := serve(h, "OPTIONS", tt.header)
+		if w.Code != tt.code {
+			t.Errorf("%s: %d %q, want %d", tt.name, w.Code, w.Body, tt.code)
+		}
+		if got := w.Header().Values("Vary"); strings.Join(got, ", ") != "Origin, Access-Control-Request-Method, Access-Control-Request-Headers" {
+			t.Errorf("%s: Vary %q", tt.name, got)
+		}
+		if w.Body.String() == "body" {
+			t.Errorf("%s: the preflight reached the handler", tt.name)
+		}
+		acao, acam := w.Header().Get("Access-Control-Allow-Origin"), w.Header().Get("Access-Control-Allow-Methods")
+		if tt.code == 204 {
+			if acao != "https://app.example.com" || acam != "GET, POST, DELETE" || w.Header().Get("Access-Control-Max-Age") != "600" {
+				t.Errorf("%s: ACAO %q, ACAM %q, Max-Age %q", tt.name, acao, acam, w.Header().Get("Access-Control-Max-Age"))
+			}
+			if got := w.Header().Get("Access-Control-Allow-Headers"); got != tt.allowed {
+				t.Errorf("%s: ACAH %q, want %q", tt.name, got, tt.allowed)
+			}
+		} else if acao != "" || acam != "" {
+			t.Errorf("%s: a rejected preflight got ACAO %q, ACAM %q", tt.name, acao, acam)
+		}
+	}
+
+	// OPTIONS without Access-Control-Request-Method is not a preflight.
+	if w := serve(h, "OPTIONS", map[string]string{"Origin": "https://app.example.com"}); w.Body.String() != "body" {
+		t.Errorf("plain OPTIONS: %d %q", w.Code, w.Body)
+	}
+}
+
+func TestActualRequest(t *testing.T) {
+	listed := mustNew(t, Options{
+		AllowedOrigins: []string{"https://app.example.com"},
+		ExposedHeaders: []string{"X-Request-Id"},
+	}).Handler(ok)
+	credentials := mustNew(t, Options{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true}).Handler(ok)
+	public := mustNew(t, Options{AllowedOrigins: []string{"*"}}).Handler(ok)
+
+	for _, tt := range []struct {
+		name               string
+		h                  http.Handler
+		origin             string
+		acao, acac, expose string
+		vary               string
+	}{
+		{"allowed", listed, "https://app.example.com", "https://app.example.com", "", "X-Request-Id", "Origin"},
+		{"disallowed", listed, "https://evil.example", "", "", "", "Origin"},
+		// Same-origin and non-browser requests: no Origin, but the same
+		// URL may be requested cross-origin later, so still Vary.
+		{"no origin", listed, "", "", "", "", "Origin"},
+		{"credentials", credentials, "https://app.example.com", "https://app.example.com", "true", "", "Origin"},
+		{"public", public, "https://anyone.example", "*", "", "", ""},
+		{"public, no origin", public, "", "", "", "", ""},
+	} {
+		header := map[string]string{}
+		if tt.origin != "" {
+			header["Origin"] = tt.origin
+		}
+		w := serve(tt.h, "GET", header)
+		if w.Code != 200 || w.Body.String() != "body" {
+			t.Errorf("%s: %d %q; the handler should always run", tt.name, w.Code, w.Body)
+		}
+		h := w.Header()
+		if h.Get("Access-Control-Allow-Origin") != tt.acao || h.Get("Access-Control-Allow-Credentials") != tt.acac ||
+			h.Get("Access-Control-Expose-Headers") != tt.expose || strings.Join(h.Values("Vary"), ", ") != tt.vary {
+			t.Errorf("%s: ACAO %q, ACAC %q, expose %q, Vary %q", tt.name, h.Get("Access-Control-Allow-Origin"),
+				h.Get("Access-Control-Allow-Credentials"), h.Get("Access-Control-Expose-Headers"), h.Values("Vary"))
+		}
+	}
+}
+
+func TestAnyHeader(t *testing.T) {
+	h := mustNew(t, Options{AllowedOrigins: []string{"*"}, AllowedHeaders: []string{"*"}}).Handler(ok)
+	w := serve(h, "OPTIONS", map[string]string{"Origin": "https://x.example", "Access-Control-Request-Method": "POST", "Access-Control-Request-Headers": "X-Anything"})
+	if w.Code != 204 || w.Header().Get("Access-Control-Allow-Headers") != "x-anything" || w.Header().Get("Access-Control-Allow-Origin") != "*" {
+		t.Errorf("%d %v", w.Code, w.Header())
+	}
+}
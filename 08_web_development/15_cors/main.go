@@ -0,0 +1,149 @@
+// Demonstrates CORS middleware with preflight handling.
+//
+// This example shows:
+// - Allowed origins with exact matches and wildcards for subdomains and ports
+// - Answering preflight OPTIONS requests before the router sees them
+// - Headers on actual requests: Allow-Origin, Allow-Credentials, Expose-Headers
+// - Vary, so caches keep each origin's response apart
+// - Rejected preflights, and why a disallowed request still reaches the handler
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"golang_roadmap/08_web_development/15_cors/cors"
+)
+
+func newAPI() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("X-Total-Count", "2")
+		fmt.Fprintln(w, `[{"id":1,"name":"Bob"},{"id":2,"name":"Alice"}]`)
+	})
+	mux.HandleFunc("DELETE /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+// show prints a request a browser would make and the CORS-related parts of
+// the response.
+func show(h http.Handler, method, path string, header ...string) {
+	r := httptest.NewRequest(method, path, nil)
+	var sent []string
+	for i := 0; i+1 < len(header); i += 2 {
+		r.Header.Set(header[i], header[i+1])
+		sent = append(sent, header[i]+": "+header[i+1])
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	fmt.Printf("\n   %s %s\n", method, r.URL.Path)
+	for _, s := range sent {
+		fmt.Println("     > " + s)
+	}
+	fmt.Printf("     < %d %s\n", w.Code, http.StatusText(w.Code))
+	for _, k := range []string{"Access-Control-Allow-Origin", "Access-Control-Allow-Credentials", "Access-Control-Allow-Methods",
+		"Access-Control-Allow-Headers", "Access-Control-Max-Age", "Access-Control-Expose-Headers", "Vary"} {
+		if v := w.Header().Values(k); len(v) > 0 {
+			fmt.Printf("     < %s: %s\n", k, strings.Join(v, ", "))
+		}
+	}
+	if w.Code >= 400 {
+		fmt.Printf("     < %s", w.Body)
+	}
+}
+
+func main() {
+	apiAddr := flag.String("serve", "", "after the demo, serve the API on this address, such as :8080")
+	pageAddr := flag.String("page", ":3000", "with -serve, serve a page that calls the API from this other origin")
+	flag.Parse()
+
+	fmt.Println("CORS examples starting...")
+
+	policy, err := cors.New(cors.Options{
+		AllowedOrigins: []string{"https://app.example.com", "https://*.preview.example.com", "http://localhost:*"},
+		AllowedMethods: []string{"GET", "POST", "DELETE"},
+		AllowedHeaders: []string{"Authorization", "Content-Type"},
+		ExposedHeaders: []string{"X-Total-Count"},
+		MaxAge:         10 * time.Minute,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	api := policy.Handler(newAPI())
+
+	// 1) A simple GET: no preflight, the browser checks the answer.
+	fmt.Println("\n1) A cross-origin GET")
+	show(api, "GET", "/users", "Origin", "https://app.example.com")
+	show(api, "GET", "/users", "Origin", "https://pr-42.preview.example.com")
+
+	// 2) DELETE with a bearer token: the browser asks first.
+	fmt.Println("\n2) Preflight, then the DELETE")
+	show(api, "OPTIONS", "/users/1", "Origin", "https://app.example.com", "Access-Control-Request-Method", "DELETE", "Access-Control-Request-Headers", "authorization")
+	show(api, "DELETE", "/users/1", "Origin", "https://app.example.com", "Authorization", "Bearer token")
+
+	// 3) Rejections.
+	fmt.Println("\n3) Rejected preflights")
+	show(api, "OPTIONS", "/users/1", "Origin", "https://evil.example", "Access-Control-Request-Method", "DELETE")
+	show(api, "OPTIONS", "/users/1", "Origin", "https://app.example.com", "Access-Control-Request-Method", "PUT")
+	show(api, "OPTIONS", "/users/1", "Origin", "https://app.example.com", "Access-Control-Request-Method", "POST", "Access-Control-Request-Headers", "x-debug")
+
+	// 4) A disallowed GET still runs: the browser hides the response from
+	// the page, but the server did the work. CORS is not access control.
+	fmt.Println("\n4) A GET from a disallowed origin")
+	show(api, "GET", "/users", "Origin", "https://evil.example")
+
+	// 5) Configuration mistakes are caught up front.
+	fmt.Println("\n5) Invalid options")
+	for _, opts := range []cors.Options{
+		{AllowedOrigins: []string{"*"}, AllowCredentials: true},
+		{AllowedOrigins: []string{"app.example.com", "https://api.*.example.com"}},
+	} {
+		_, err := cors.New(opts)
+		fmt.Printf("   %v\n", strings.ReplaceAll(err.Error(), "\n", "\n   "))
+	}
+
+	if *apiAddr != "" {
+		go func() {
+			log.Printf("serving a page on %s that calls the API on %s", *pageAddr, *apiAddr)
+			log.Fatal(http.ListenAndServe(*pageAddr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				page.Execute(w, "http://localhost"+*apiAddr)
+			})))
+		}()
+		log.Printf("serving the API on %s", *apiAddr)
+		log.Fatal(http.ListenAndServe(*apiAddr, api))
+	}
+}
+
+// page runs in the browser on another origin (another port is enough)
+// and calls the API, so the browser's CORS checks can be watched in its
+// developer tools.
+var page = template.Must(template.New("page").Parse(`<!doctype html>
+<meta charset="utf-8">
+<title>CORS demo</title>
+<button id="get">GET /users</button>
+<button id="del">DELETE /users/1 (preflighted)</button>
+<pre id="out"></pre>
+<script>
+const api = {{.}};
+const out = document.getElementById("out");
+async function call(method, path, headers) {
+  try {
+    const resp = await fetch(api + path, {method, headers});
+    out.textContent = resp.status + " X-Total-Count: " + resp.headers.get("X-Total-Count") + "\n" + await resp.text();
+  } catch (e) {
+    out.textContent = "Blocked: " + e;
+  }
+}
+document.getElementById("get").onclick = () => call("GET", "/users", {});
+document.getElementById("del").onclick = () => call("DELETE", "/users/1", {"Authorization": "Bearer token"});
+</script>
+`))
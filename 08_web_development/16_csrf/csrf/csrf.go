@@ -0,0 +1,142 @@
+// Package csrf protects form handlers from cross-site request forgery
+// with synchronizer tokens: each session gets a secret token, every form
+// carries it in a hidden field, and a POST without it is refused. Another
+// site can make a browser send a POST with the user's cookies, but it
+// cannot read the page, so it cannot know the token.
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"html/template"
+	"log"
+	"net/http"
+
+	"golang_roadmap/08_web_development/16_csrf/session"
+)
+
+const (
+	// FieldName is the form field that carries the token.
+	FieldName = "csrf_token"
+	// HeaderName carries it for requests from JavaScript.
+	HeaderName = "X-CSRF-Token"
+
+	sessionKey = "csrf_token"
+	tokenLen   = 32
+)
+
+var (
+	// ErrNoSession is returned when the request has no session to check against.
+	ErrNoSession = errors.New("no session")
+	// ErrMissing is returned when the request sends no token.
+	ErrMissing = errors.New("CSRF token missing")
+	// ErrInvalid covers forged tokens and stale ones, from a form
+	// rendered before the token was rotated, such as at login.
+	ErrInvalid = errors.New("CSRF token invalid")
+)
+
+// Protect checks the token on every request that can change state: any
+// method but GET, HEAD, OPTIONS and TRACE. Those must not change state,
+// or they need no token to be forged. A request that fails gets 403.
+// It needs a session, so wrap it in session.Store.Middleware.
+func Protect(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		default:
+			if err := Verify(r); err != nil {
+				log.Printf("CSRF: %s %s refused: %v", r.Method, r.URL.Path, err)
+				http.Error(w, "Forbidden: "+err.Error()+". Reload the page and try again.", http.StatusForbidden)
+				return
+			}
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Verify checks the token in r's form field or header against its
+// session's.
+func Verify(r *http.Request) error {
+	s := session.FromContext(r.Context())
+	if s == nil {
+		return ErrNoSession
+	}
+	sent := r.Header.Get(HeaderName)
+	if sent == "" {
+		sent = r.PostFormValue(FieldName)
+	}
+	if sent == "" {
+		return ErrMissing
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(s.Get(sessionKey))
+	if err != nil || len(secret) != tokenLen {
+		return ErrInvalid // the session has no token yet: nothing to match
+	}
+	masked, err := base64.RawURLEncoding.DecodeString(sent)
+	if err != nil || len(masked) != 2*tokenLen {
+		return ErrInvalid
+	}
+	if subtle.ConstantTimeCompare(unmask(masked), secret) != 1 {
+		return ErrInvalid
+	}
+	return nil
+}
+
+// Token returns the token to put in a form or a header for r's session,
+// creating the session's secret on first use. Each call returns a
+// different string for the same secret (see mask), so pages that embed
+// it do not repeat it byte for byte.
+func Token(r *http.Request) string {
+	s := session.FromContext(r.Context())
+	if s == nil {
+		panic("csrf: Token called without a session; wrap the handler in session.Store.Middleware")
+	}
+	secret, err := base64.RawURLEncoding.DecodeString(s.Get(sessionKey))
+	if err != nil || len(secret) != tokenLen {
+		secret = randomBytes(tokenLen)
+		s.Set(sessionKey, base64.RawURLEncoding.EncodeToString(secret))
+	}
+	return base64.RawURLEncoding.EncodeToString(mask(secret))
+}
+
+// Field returns a hidden input with the token, for html/template:
+// {{.CSRFField}} inside each <form method="post">.
+func Field(r *http.Request) template.HTML {
+	return template.HTML(`<input type="hidden" name="` + FieldName + `" value="` + Token(r) + `">`)
+}
+
+// Rotate replaces the session's secret, so every token handed out before
+// stops working. Call it when privileges change, at login and logout,
+// along with session.Store.Renew.
+func Rotate(r *http.Request) {
+	if s := session.FromContext(r.Context()); s != nil {
+		s.Delete(sessionKey)
+	}
+}
+
+// mask returns a random pad followed by secret XOR pad. Compression
+// attacks such as BREACH recover a secret that repeats in compressed
+// HTTPS responses; a masked token never repeats.
+func mask(secret []byte) []byte {
+	out := randomBytes(2 * tokenLen)
+	for i := range tokenLen {
+		out[tokenLen+i] = out[i] ^ secret[i]
+	}
+	return out
+}
+
+func unmask(masked []byte) []byte {
+	secret := make([]byte, tokenLen)
+	for i := range tokenLen {
+		secret[i] = masked[i] ^ masked[tokenLen+i]
+	}
+	return secret
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
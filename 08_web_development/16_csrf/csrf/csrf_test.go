@@ -0,0 +1,148 @@
+package csrf
+
+import (
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"golang_roadmap/08_web_development/16_csrf/session"
+)
+
+func newApp(t *testing.T) *httptest.Server {
+	store := &session.Store{}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /token", func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, Token(r)) })
+	mux.HandleFunc("POST /login", func(w http.ResponseWriter, r *http.Request) {
+		store.Renew(w, session.FromContext(r.Context()))
+		Rotate(r)
+	})
+	mux.HandleFunc("/transfer", func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, "done") })
+	srv := httptest.NewServer(store.Middleware(Protect(mux)))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newBrowser() *http.Client {
+	jar, _ := cookiejar.New(nil)
+	return &http.Client{Jar: jar}
+}
+
+func do(t *testing.T, c *http.Client, method, target string, form url.Values, header http.Header) (int, string) {
+	t.Helper()
+	var body io.Reader
+	if form != nil {
+		body = strings.NewReader(form.Encode())
+	}
+	req, _ := http.NewRequest(method, target, body)
+	if form != nil {
+		req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	}
+	for k, v := range header {
+		req.Header[k] = v
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b)
+}
+
+func token(t *testing.T, c *http.Client, base string) string {
+	t.Helper()
+	_, tok := do(t, c, "GET", base+"/token", nil, nil)
+	return tok
+}
+
+func TestProtect(t *testing.T) {
+	srv := newApp(t)
+	alice, mallory := newBrowser(), newBrowser()
+	tok := token(t, alice, srv.URL)
+	malloryTok := token(t, mallory, srv.URL)
+	u := srv.URL + "/transfer"
+
+	for _, tt := range []struct {
+		name   string
+		method string
+		form   url.Values
+		header http.Header
+		query  string
+		code   int
+		body   string
+	}{
+		{"form field", "POST", url.Values{FieldName: {tok}}, nil, "", 200, "done"},
+		{"header", "POST", url.Values{}, http.Header{HeaderName: {tok}}, "", 200, "done"},
+		{"header for DELETE", "DELETE", nil, http.Header{HeaderName: {tok}}, "", 200, "done"},
+		{"GET needs none", "GET", nil, nil, "", 200, "done"},
+		{"missing", "POST", url.Values{"amount": {"100"}}, nil, "", 403, ErrMissing.Error()},
+		{"missing on PUT", "PUT", nil, nil, "", 403, ErrMissing.Error()},
+		{"empty", "POST", url.Values{FieldName: {""}}, nil, "", 403, ErrMissing.Error()},
+		{"garbage", "POST", url.Values{FieldName: {"not-a-token"}}, nil, "", 403, ErrInvalid.Error()},
+		{"truncated", "POST", url.Values{FieldName: {tok[:len(tok)-4]}}, nil, "", 403, ErrInvalid.Error()},
+		{"another session's", "POST", url.Values{FieldName: {malloryTok}}, nil, "", 403, ErrInvalid.Error()},
+		// Tokens in URLs leak into logs and Referer headers, so only the
+		// body and the header count.
+		{"in the query string", "POST", nil, nil, FieldName + "=" + url.QueryEscape(tok), 403, ErrMissing.Error()},
+	} {
+		code, body := do(t, alice, tt.method, u+"?"+tt.query, tt.form, tt.header)
+		if code != tt.code || !strings.Contains(body, tt.body) {
+			t.Errorf("%s: %d %q, want %d %q", tt.name, code, body, tt.code, tt.body)
+		}
+	}
+}
+
+func TestStaleToken(t *testing.T) {
+	srv := newApp(t)
+	b := newBrowser()
+	before := token(t, b, srv.URL)
+	if code, body := do(t, b, "POST", srv.URL+"/login", url.Values{FieldName: {before}}, nil); code != 200 {
+		t.Fatalf("login: %d %s", code, body)
+	}
+	// A form rendered before login, still open in another tab.
+	if code, body := do(t, b, "POST", srv.URL+"/transfer", url.Values{FieldName: {before}}, nil); code != 403 || !strings.Contains(body, ErrInvalid.Error()) {
+		t.Errorf("stale token: %d %q", code, body)
+	}
+	after := token(t, b, srv.URL)
+	if code, body := do(t, b, "POST", srv.URL+"/transfer", url.Values{FieldName: {after}}, nil); code != 200 {
+		t.Errorf("fresh token: %d %q", code, body)
+	}
+}
+
+func TestNoTokenYet(t *testing.T) {
+	// A session that never rendered a form has no secret: nothing matches,
+	// not even an empty or zero one.
+	srv := newApp(t)
+	b := newBrowser()
+	zero := strings.Repeat("A", 86) // 64 zero bytes in base64
+	if code, _ := do(t, b, "POST", srv.URL+"/transfer", url.Values{FieldName: {zero}}, nil); code != 403 {
+		t.Errorf("zero token accepted: %d", code)
+	}
+}
+
+func TestMasking(t *testing.T) {
+	srv := newApp(t)
+	b := newBrowser()
+	t1, t2 := token(t, b, srv.URL), token(t, b, srv.URL)
+	if t1 == t2 {
+		t.Error("two renders gave the same token")
+	}
+	for _, tok := range []string{t1, t2} {
+		if code, body := do(t, b, "POST", srv.URL+"/transfer", url.Values{FieldName: {tok}}, nil); code != 200 {
+			t.Errorf("%s: %d %q", tok, code, body)
+		}
+	}
+}
+
+func TestWithoutSession(t *testing.T) {
+	h := Protect(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", nil))
+	if w.Code != 403 {
+		t.Errorf("got %d", w.Code)
+	}
+}
@@ -0,0 +1,252 @@
+// Demonstrates CSRF protection for HTML forms with synchronizer tokens.
+//
+// This example shows:
+// - A session cookie with HttpOnly and SameSite=Lax
+// - A secret token per session, sent in a hidden field of every form
+// - Middleware that refuses state-changing requests without the token
+// - Rotating the session ID and the token at login, and stale tokens after it
+// - A forged cross-site POST, refused
+// - The token in a header, for fetch()
+package main
+
+import (
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+
+	"golang_roadmap/08_web_development/16_csrf/csrf"
+	"golang_roadmap/08_web_development/16_csrf/session"
+)
+
+var page = template.Must(template.New("page").Parse(`<!doctype html>
+<meta charset="utf-8">
+<title>Bank</title>
+{{with .Flash}}<p><strong>{{.}}</strong></p>{{end}}
+{{if .User}}
+<p>Logged in as {{.User}}. Balance: {{.Balance}}</p>
+<form method="post" action="/transfer">
+  {{.CSRFField}}
+  <input name="to" placeholder="to"> <input name="amount" placeholder="amount">
+  <button>Send</button>
+</form>
+<form method="post" action="/logout">{{.CSRFField}}<button>Log out</button></form>
+{{else}}
+<form method="post" action="/login">
+  {{.CSRFField}}
+  <input name="user" placeholder="user"> <button>Log in</button>
+</form>
+{{end}}
+`))
+
+// bank is the application: balances by user.
+type bank struct {
+	store    *session.Store
+	mu       sync.Mutex
+	balances map[string]int
+}
+
+func (b *bank) routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /{$}", b.home)
+	mux.HandleFunc("POST /login", b.login)
+	mux.HandleFunc("POST /logout", b.logout)
+	mux.HandleFunc("POST /transfer", b.transfer)
+	// The session must exist before csrf.Protect looks for its token.
+	return b.store.Middleware(csrf.Protect(mux))
+}
+
+func (b *bank) home(w http.ResponseWriter, r *http.Request) {
+	s := session.FromContext(r.Context())
+	user := s.Get("user")
+	flash := s.Get("flash")
+	s.Delete("flash")
+	b.mu.Lock()
+	balance := b.balances[user]
+	b.mu.Unlock()
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	page.Execute(w, map[string]any{
+		"User": user, "Balance": balance, "Flash": flash, "CSRFField": csrf.Field(r),
+	})
+}
+
+func (b *bank) login(w http.ResponseWriter, r *http.Request) {
+	s := session.FromContext(r.Context())
+	user := strings.TrimSpace(r.PostFormValue("user")) // a real app checks a password here
+	if user == "" {
+		http.Error(w, "Missing user", http.StatusBadRequest)
+		return
+	}
+	// New privileges, new session ID and token: an ID or token obtained
+	// before login is worthless after it.
+	b.store.Renew(w, s)
+	csrf.Rotate(r)
+	s.Set("user", user)
+	b.mu.Lock()
+	if _, ok := b.balances[user]; !ok {
+		b.balances[user] = 1000
+	}
+	b.mu.Unlock()
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (b *bank) logout(w http.ResponseWriter, r *http.Request) {
+	b.store.Destroy(w, session.FromContext(r.Context()))
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+func (b *bank) transfer(w http.ResponseWriter, r *http.Request) {
+	s := session.FromContext(r.Context())
+	user := s.Get("user")
+	if user == "" {
+		http.Error(w, "Log in first", http.StatusUnauthorized)
+		return
+	}
+	var amount int
+	if _, err := fmt.Sscan(r.PostFormValue("amount"), &amount); err != nil || amount <= 0 {
+		http.Error(w, "Bad amount", http.StatusBadRequest)
+		return
+	}
+	to := r.PostFormValue("to")
+	b.mu.Lock()
+	b.balances[user] -= amount
+	b.balances[to] += amount
+	b.mu.Unlock()
+	s.Set("flash", fmt.Sprintf("Sent %d to %s", amount, to))
+	// Post/Redirect/Get: a reload does not send the transfer again.
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+var (
+	tokenRE   = regexp.MustCompile(`name="csrf_token" value="([^"]+)"`)
+	summaryRE = regexp.MustCompile(`<strong>.*?</strong>|Logged in as \w+|Balance: \d+`)
+)
+
+// browser is a client with a cookie jar that follows redirects, as a
+// browser does (though it does not apply SameSite).
+type browser struct{ *http.Client }
+
+func newBrowser() browser {
+	jar, _ := cookiejar.New(nil)
+	return browser{&http.Client{Jar: jar}}
+}
+
+// token fetches the page and returns the CSRF token in its first form.
+func (b browser) token(u string) string {
+	resp, err := b.Get(u)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	m := tokenRE.FindSubmatch(body)
+	if m == nil {
+		log.Fatalf("no token in %s", body)
+	}
+	return string(m[1])
+}
+
+// post submits a form and prints the outcome.
+func (b browser) post(u string, form url.Values, header ...string) {
+	req, _ := http.NewRequest("POST", u, strings.NewReader(form.Encode()))
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := b.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	result := strings.TrimSpace(string(body))
+	if resp.StatusCode == http.StatusOK { // followed the redirect home
+		result = strings.Join(summaryRE.FindAllString(result, -1), ", ")
+	}
+	fmt.Printf("   POST %s -> %d: %s\n", req.URL.Path, resp.StatusCode, result)
+}
+
+func main() {
+	addr := flag.String("serve", "", "after the demo, serve the bank on this address, such as :8080")
+	evilAddr := flag.String("evil", ":8081", "with -serve, serve a page that forges a transfer from this address")
+	flag.Parse()
+
+	fmt.Println("CSRF examples starting...")
+	app := &bank{store: &session.Store{}, balances: map[string]int{}}
+	srv := httptest.NewServer(app.routes())
+	defer srv.Close()
+	alice := newBrowser()
+
+	// 1) The first visit starts a session; the login form carries a token
+	// tied to it.
+	fmt.Println("\n1) Logging in")
+	resp, err := http.Get(srv.URL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Body.Close()
+	fmt.Printf("   Set-Cookie: %s\n", resp.Header.Get("Set-Cookie"))
+	preLogin := alice.token(srv.URL)
+	fmt.Printf("   token in the login form: %s...\n", preLogin[:16])
+	alice.post(srv.URL+"/login", url.Values{"user": {"alice"}, "csrf_token": {preLogin}})
+
+	// 2) A form rendered before login holds the old token.
+	fmt.Println("\n2) A stale token, from before login")
+	alice.post(srv.URL+"/transfer", url.Values{"to": {"bob"}, "amount": {"10"}, "csrf_token": {preLogin}})
+
+	// 3) The current form works.
+	fmt.Println("\n3) A transfer from the current form")
+	token := alice.token(srv.URL)
+	alice.post(srv.URL+"/transfer", url.Values{"to": {"bob"}, "amount": {"100"}, "csrf_token": {token}})
+
+	// 4) Another site submits a form to the bank. The browser sends
+	// alice's cookie (this client ignores SameSite), but the attacker
+	// cannot read the bank's pages, so has no token.
+	fmt.Println("\n4) A forged POST from another site")
+	alice.post(srv.URL+"/transfer", url.Values{"to": {"mallory"}, "amount": {"900"}})
+	alice.post(srv.URL+"/transfer", url.Values{"to": {"mallory"}, "amount": {"900"}, "csrf_token": {"guessed"}})
+
+	// 5) JavaScript sends the token in a header instead.
+	fmt.Println("\n5) The token in a header, as from fetch()")
+	token = alice.token(srv.URL)
+	alice.post(srv.URL+"/transfer", url.Values{"to": {"carol"}, "amount": {"50"}}, csrf.HeaderName, token)
+
+	// 6) Every render masks the token differently, and all are valid.
+	fmt.Println("\n6) Masked tokens")
+	t1, t2 := alice.token(srv.URL), alice.token(srv.URL)
+	fmt.Printf("   %s...\n   %s...\n", t1[:24], t2[:24])
+	alice.post(srv.URL+"/transfer", url.Values{"to": {"bob"}, "amount": {"1"}, "csrf_token": {t1}})
+
+	if *addr != "" {
+		go func() {
+			target := "http://localhost" + *addr + "/transfer"
+			log.Printf("serving a forging page on %s", *evilAddr)
+			log.Fatal(http.ListenAndServe(*evilAddr, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				evil.Execute(w, target)
+			})))
+		}()
+		log.Printf("serving the bank on %s: open http://localhost%s, log in, then open http://localhost%s", *addr, *addr, *evilAddr)
+		log.Fatal(http.ListenAndServe(*addr, app.routes()))
+	}
+}
+
+// evil is the attacker's page: it posts a transfer to the bank as soon
+// as it loads. localhost on another port is the same site, so SameSite
+// does not stop the cookie, and only the token does.
+var evil = template.Must(template.New("evil").Parse(`<!doctype html>
+<meta charset="utf-8">
+<title>You won a prize!</title>
+<form id="f" method="post" action="{{.}}">
+  <input type="hidden" name="to" value="mallory">
+  <input type="hidden" name="amount" value="900">
+</form>
+<script>document.getElementById("f").submit()</script>
+`))
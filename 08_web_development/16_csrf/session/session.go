@@ -0,0 +1,166 @@
+// Package session keeps per-browser state on the server, found by a
+// random ID in a cookie. It is a small in-memory store, enough for the
+// CSRF example; a real deployment with several servers keeps sessions
+// in a shared store such as Redis or a database table.
+package session
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// Session holds the values of one browser session. It is safe for
+// concurrent use.
+type Session struct {
+	mu      sync.Mutex
+	id      string
+	values  map[string]string
+	expires time.Time
+}
+
+// Get returns the value for key, "" if none.
+func (s *Session) Get(key string) string {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.values[key]
+}
+
+// Set stores value under key.
+func (s *Session) Set(key, value string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.values[key] = value
+}
+
+// Delete removes key.
+func (s *Session) Delete(key string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.values, key)
+}
+
+// Store keeps sessions in memory, each for TTL after it was last used.
+type Store struct {
+	CookieName string        // default "session"
+	TTL        time.Duration // default 24h
+	// Secure marks the cookie HTTPS-only. Leave it off only for local
+	// development over plain HTTP.
+	Secure bool
+
+	mu       sync.Mutex
+	sessions map[string]*Session
+}
+
+type ctxKey struct{}
+
+// FromContext returns the session Middleware loaded for the request.
+func FromContext(ctx context.Context) *Session {
+	s, _ := ctx.Value(ctxKey{}).(*Session)
+	return s
+}
+
+// Middleware finds the request's session by its cookie, or starts one,
+// and puts it in the request's context.
+func (st *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s := st.load(r)
+		if s == nil {
+			s = st.create()
+			st.setCookie(w, s.id)
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), ctxKey{}, s)))
+	})
+}
+
+// Renew gives the session a new ID, keeping its values, and sends the new
+// cookie. Call it when the user logs in: an ID an attacker planted in the
+// browser before ("session fixation") then stops working.
+func (st *Store) Renew(w http.ResponseWriter, s *Session) {
+	st.mu.Lock()
+	s.mu.Lock()
+	delete(st.sessions, s.id)
+	s.id = newID()
+	st.sessions[s.id] = s
+	s.mu.Unlock()
+	st.mu.Unlock()
+	st.setCookie(w, s.id)
+}
+
+// Destroy ends the session and deletes the cookie, as on logout.
+func (st *Store) Destroy(w http.ResponseWriter, s *Session) {
+	st.mu.Lock()
+	delete(st.sessions, s.id)
+	st.mu.Unlock()
+	http.SetCookie(w, &http.Cookie{Name: st.cookieName(), Path: "/", MaxAge: -1, HttpOnly: true, Secure: st.Secure, SameSite: http.SameSiteLaxMode})
+}
+
+func (st *Store) load(r *http.Request) *Session {
+	c, err := r.Cookie(st.cookieName())
+	if err != nil {
+		return nil
+	}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	s := st.sessions[c.Value]
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if time.Now().After(s.expires) {
+		delete(st.sessions, s.id)
+		return nil
+	}
+	s.expires = time.Now().Add(st.ttl())
+	return s
+}
+
+func (st *Store) create() *Session {
+	s := &Session{id: newID(), values: map[string]string{}, expires: time.Now().Add(st.ttl())}
+	st.mu.Lock()
+	defer st.mu.Unlock()
+	if st.sessions == nil {
+		st.sessions = map[string]*Session{}
+	}
+	st.sessions[s.id] = s
+	return s
+}
+
+// setCookie sends the session cookie. HttpOnly keeps scripts, and so
+// XSS, away from it. SameSite=Lax stops browsers from sending it on
+// cross-site POSTs, a first line of defence against CSRF.
+func (st *Store) setCookie(w http.ResponseWriter, id string) {
+	http.SetCookie(w, &http.Cookie{
+		Name:     st.cookieName(),
+		Value:    id,
+		Path:     "/",
+		HttpOnly: true,
+		Secure:   st.Secure,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
+
+func (st *Store) cookieName() string {
+	if st.CookieName == "" {
+		return "session"
+	}
+	return st.CookieName
+}
+
+func (st *Store) ttl() time.Duration {
+	if st.TTL <= 0 {
+		return 24 * time.Hour
+	}
+	return st.TTL
+}
+
+// newID returns 256 random bits, too many to guess.
+func newID() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
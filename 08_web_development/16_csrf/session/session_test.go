@@ -0,0 +1,78 @@
+package session
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// request runs one request through st with the cookie, if any, and
+// returns the session the handler saw and the cookie set, if any.
+func request(st *Store, cookie *http.Cookie, handle func(w http.ResponseWriter, s *Session)) (*Session, *http.Cookie) {
+	var seen *Session
+	h := st.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = FromContext(r.Context())
+		if handle != nil {
+			handle(w, seen)
+		}
+	}))
+	r := httptest.NewRequest("GET", "/", nil)
+	if cookie != nil {
+		r.AddCookie(cookie)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	cookies := w.Result().Cookies()
+	if len(cookies) == 0 {
+		return seen, nil
+	}
+	return seen, cookies[len(cookies)-1]
+}
+
+func TestSession(t *testing.T) {
+	st := &Store{Secure: true}
+	s1, c := request(st, nil, func(_ http.ResponseWriter, s *Session) { s.Set("user", "alice") })
+	if c == nil || !c.HttpOnly || !c.Secure || c.SameSite != http.SameSiteLaxMode {
+		t.Fatalf("cookie %+v", c)
+	}
+	s2, again := request(st, c, nil)
+	if s2 != s1 || s2.Get("user") != "alice" || again != nil {
+		t.Errorf("second request: same session %v, user %q, new cookie %v", s2 == s1, s2.Get("user"), again)
+	}
+	if s3, _ := request(st, &http.Cookie{Name: "session", Value: "forged"}, nil); s3 == s1 {
+		t.Error("a forged ID found the session")
+	}
+}
+
+func TestRenew(t *testing.T) {
+	st := &Store{}
+	s, old := request(st, nil, func(_ http.ResponseWriter, s *Session) { s.Set("cart", "3 items") })
+	_, renewed := request(st, old, func(w http.ResponseWriter, s *Session) { st.Renew(w, s) })
+	if renewed == nil || renewed.Value == old.Value {
+		t.Fatalf("Renew sent %v", renewed)
+	}
+	if got, _ := request(st, renewed, nil); got != s || got.Get("cart") != "3 items" {
+		t.Error("the new ID lost the session")
+	}
+	if got, _ := request(st, old, nil); got == s {
+		t.Error("the old ID still works")
+	}
+}
+
+func TestExpiry(t *testing.T) {
+	st := &Store{TTL: 20 * time.Millisecond}
+	s, c := request(st, nil, nil)
+	time.Sleep(10 * time.Millisecond)
+	if got, _ := request(st, c, nil); got != s {
+		t.Fatal("expired early")
+	}
+	time.Sleep(15 * time.Millisecond) // the use above extended it
+	if got, _ := request(st, c, nil); got != s {
+		t.Fatal("use did not extend the session")
+	}
+	time.Sleep(30 * time.Millisecond)
+	if got, _ := request(st, c, nil); got == s {
+		t.Error("an expired session was used")
+	}
+}
@@ -0,0 +1,3 @@
+module golang_roadmap/08_web_development/17_hardening
+
+go 1.24.11
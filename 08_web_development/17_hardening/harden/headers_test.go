@@ -0,0 +1,63 @@
+package harden
+
+import (
+	"crypto/tls"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+func serve(h Headers, r *http.Request) http.Header {
+	w := httptest.NewRecorder()
+	h.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})).ServeHTTP(w, r)
+	return w.Header()
+}
+
+func TestHeaders(t *testing.T) {
+	https := httptest.NewRequest("GET", "https://example.com/", nil)
+	https.TLS = &tls.ConnectionState{}
+	got := serve(ForPages(), https)
+	for name, want := range map[string]string{
+		"Strict-Transport-Security": "max-age=63072000; includeSubDomains",
+		"X-Content-Type-Options":    "nosniff",
+		"Content-Security-Policy":   "default-src 'self'; object-src 'none'; base-uri 'self'; form-action 'self'; frame-ancestors 'none'",
+		"X-Frame-Options":           "DENY",
+		"Referrer-Policy":           "strict-origin-when-cross-origin",
+	} {
+		if got.Get(name) != want {
+			t.Errorf("%s = %q, want %q", name, got.Get(name), want)
+		}
+	}
+
+	if csp := serve(ForAPI(), https).Get("Content-Security-Policy"); csp != "default-src 'none'; frame-ancestors 'none'" {
+		t.Errorf("API CSP = %q", csp)
+	}
+}
+
+func TestHSTS(t *testing.T) {
+	plain := httptest.NewRequest("GET", "http://example.com/", nil)
+	if v := serve(ForPages(), plain).Get("Strict-Transport-Security"); v != "" {
+		t.Errorf("HSTS over plain HTTP: %q", v)
+	}
+	proxied := Headers{HSTSMaxAge: 5 * time.Minute, BehindTLSProxy: true}
+	if v := serve(proxied, plain).Get("Strict-Transport-Security"); v != "max-age=300" {
+		t.Errorf("HSTS behind a proxy: %q", v)
+	}
+	// The zero Headers still sets nosniff, and nothing else.
+	got := serve(Headers{}, plain)
+	if len(got) != 1 || got.Get("X-Content-Type-Options") != "nosniff" {
+		t.Errorf("zero Headers set %v", got)
+	}
+}
+
+func TestHandlerOverrides(t *testing.T) {
+	h := ForPages().Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("X-Frame-Options", "SAMEORIGIN")
+	}))
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if v := w.Header().Get("X-Frame-Options"); v != "SAMEORIGIN" {
+		t.Errorf("X-Frame-Options = %q", v)
+	}
+}
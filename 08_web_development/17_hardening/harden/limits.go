@@ -0,0 +1,57 @@
+package harden
+
+import (
+	"net/http"
+	"time"
+)
+
+// LimitBody refuses request bodies over max bytes. A declared
+// Content-Length over the limit gets 413 before next runs. Otherwise the
+// body is wrapped in http.MaxBytesReader, so reading past the limit fails
+// with *http.MaxBytesError and the connection is closed after the
+// response; handlers answer that with 413 themselves. Without a limit, one
+// client can fill the server's memory with a single JSON body.
+func LimitBody(next http.Handler, max int64) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.ContentLength > max {
+			w.Header().Set("Connection", "close")
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		r.Body = http.MaxBytesReader(w, r.Body, max)
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Timeouts and limits of NewServer.
+const (
+	// ReadHeaderTimeout bounds the request line and headers. Without it, a
+	// client that sends a header byte every few seconds holds a connection,
+	// and a goroutine, forever ("slowloris"); a few thousand such clients
+	// exhaust the server.
+	ReadHeaderTimeout = 5 * time.Second
+	// ReadTimeout bounds the whole request, body included.
+	ReadTimeout = 30 * time.Second
+	// WriteTimeout bounds the handler and the response. Streaming handlers
+	// extend it with http.ResponseController.SetWriteDeadline.
+	WriteTimeout = 30 * time.Second
+	// IdleTimeout closes keep-alive connections that have nothing to do.
+	IdleTimeout = 2 * time.Minute
+	// MaxHeaderBytes bounds the request line and headers, in place of the
+	// default of 1 MB.
+	MaxHeaderBytes = 64 << 10
+)
+
+// NewServer returns a server for addr with every timeout set. The zero
+// http.Server, used by http.ListenAndServe, has none.
+func NewServer(addr string, h http.Handler) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: ReadHeaderTimeout,
+		ReadTimeout:       ReadTimeout,
+		WriteTimeout:      WriteTimeout,
+		IdleTimeout:       IdleTimeout,
+		MaxHeaderBytes:    MaxHeaderBytes,
+	}
+}
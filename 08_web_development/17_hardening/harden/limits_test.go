@@ -0,0 +1,134 @@
+package harden
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// echoLen reads the whole body and answers its length, or 413 as a
+// handler should when the limit is hit.
+var echoLen = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+	b, err := io.ReadAll(r.Body)
+	var tooLarge *http.MaxBytesError
+	if errors.As(err, &tooLarge) {
+		http.Error(w, "too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	fmt.Fprint(w, len(b))
+})
+
+func TestLimitBody(t *testing.T) {
+	h := LimitBody(echoLen, 10)
+	for _, tt := range []struct {
+		name    string
+		body    string
+		chunked bool // no Content-Length, so only reading finds the size
+		code    int
+	}{
+		{"under", "12345", false, 200},
+		{"at the limit", "1234567890", false, 200},
+		{"declared over", "12345678901", false, 413},
+		{"chunked under", "12345", true, 200},
+		{"chunked over", "12345678901", true, 413},
+	} {
+		r := httptest.NewRequest("POST", "/", strings.NewReader(tt.body))
+		if tt.chunked {
+			r.ContentLength = -1
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != tt.code {
+			t.Errorf("%s: %d, want %d", tt.name, w.Code, tt.code)
+		}
+	}
+}
+
+func TestLimitBodyNotRead(t *testing.T) {
+	// A declared size over the limit is refused before the handler runs.
+	called := false
+	h := LimitBody(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { called = true }), 10)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest("POST", "/", strings.NewReader(strings.Repeat("x", 100))))
+	if w.Code != 413 || called {
+		t.Errorf("got %d, handler called %v", w.Code, called)
+	}
+}
+
+func TestNewServer(t *testing.T) {
+	srv := NewServer(":0", http.NotFoundHandler())
+	if srv.ReadHeaderTimeout == 0 || srv.ReadTimeout == 0 || srv.WriteTimeout == 0 || srv.IdleTimeout == 0 {
+		t.Errorf("a timeout is unset: %+v", srv)
+	}
+	if srv.MaxHeaderBytes != MaxHeaderBytes {
+		t.Errorf("MaxHeaderBytes = %d", srv.MaxHeaderBytes)
+	}
+}
+
+// start runs a NewServer on a random port with a shorter header timeout.
+func start(t *testing.T, readHeader time.Duration) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := NewServer("", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { fmt.Fprint(w, "ok") }))
+	srv.ReadHeaderTimeout = readHeader
+	go srv.Serve(ln)
+	t.Cleanup(func() { srv.Close() })
+	return ln.Addr().String()
+}
+
+func TestSlowloris(t *testing.T) {
+	addr := start(t, 200*time.Millisecond)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	// Trickle one header line every 50ms and never finish. The server
+	// must hang up, possibly after a 408, within the header timeout.
+	began := time.Now()
+	fmt.Fprint(conn, "GET / HTTP/1.1\r\nHost: x\r\n")
+	go func() {
+		for i := 0; ; i++ {
+			time.Sleep(50 * time.Millisecond)
+			if _, err := fmt.Fprintf(conn, "X-Slow-%d: 1\r\n", i); err != nil {
+				return
+			}
+		}
+	}()
+	conn.SetReadDeadline(time.Now().Add(3 * time.Second))
+	_, err = io.ReadAll(conn)
+	if ne, ok := err.(net.Error); ok && ne.Timeout() {
+		t.Fatal("the connection is still open after 3s")
+	}
+	if took := time.Since(began); took < 200*time.Millisecond {
+		t.Errorf("closed after %v, before the timeout", took)
+	}
+}
+
+func TestMaxHeaderBytes(t *testing.T) {
+	addr := start(t, time.Second)
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "GET / HTTP/1.1\r\nHost: x\r\nX-Big: %s\r\n\r\n", strings.Repeat("a", 2*MaxHeaderBytes))
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.StatusCode != http.StatusRequestHeaderFieldsTooLarge {
+		t.Errorf("got %d", resp.StatusCode)
+	}
+}
@@ -0,0 +1,87 @@
+package harden
+
+import (
+	"errors"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"path"
+	"strings"
+)
+
+// Static serves the files in a directory and nothing outside it.
+//
+// The classic mistake is filepath.Join(dir, r.URL.Path): a path of
+// /../../etc/passwd, or ..%2f..%2fetc%2fpasswd once decoded, walks out of
+// dir. Static refuses such paths outright, and opens files through an
+// os.Root, which also refuses symlinks that lead outside the directory.
+// It hides dotfiles, such as .env and .git, and lists no directories: a
+// directory is served only through its index.html.
+type Static struct {
+	root  *os.Root
+	files http.Handler
+}
+
+// OpenStatic opens dir for serving. Close releases it.
+func OpenStatic(dir string) (*Static, error) {
+	root, err := os.OpenRoot(dir)
+	if err != nil {
+		return nil, err
+	}
+	return &Static{root: root, files: http.FileServerFS(noListing{root.FS()})}, nil
+}
+
+// Close closes the directory.
+func (s *Static) Close() error { return s.root.Close() }
+
+func (s *Static) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	p := r.URL.Path
+	// A backslash is a separator on Windows, and a NUL ends the name for
+	// some system calls.
+	if !strings.HasPrefix(p, "/") || strings.ContainsAny(p, "\\\x00") {
+		http.Error(w, "Bad path", http.StatusBadRequest)
+		return
+	}
+	for seg := range strings.SplitSeq(p[1:], "/") {
+		if seg == ".." {
+			log.Printf("static: refused %q from %s", p, r.RemoteAddr)
+			http.Error(w, "Bad path", http.StatusBadRequest)
+			return
+		}
+		if strings.HasPrefix(seg, ".") {
+			http.NotFound(w, r)
+			return
+		}
+	}
+	s.files.ServeHTTP(w, r)
+}
+
+// noListing is a file system whose directories cannot be opened unless
+// they have an index.html, which http.FileServer then serves in place of
+// a listing. Errors other than a missing file, such as a symlink that
+// escapes the root, are logged and reported as missing, so a client
+// cannot tell what is there.
+type noListing struct{ fsys fs.FS }
+
+func (n noListing) Open(name string) (fs.File, error) {
+	f, err := n.fsys.Open(name)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			log.Printf("static: %v", err)
+		}
+		return nil, fs.ErrNotExist
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return nil, fs.ErrNotExist
+	}
+	if info.IsDir() {
+		if _, err := fs.Stat(n.fsys, path.Join(name, "index.html")); err != nil {
+			f.Close()
+			return nil, fs.ErrNotExist
+		}
+	}
+	return f, nil
+}
@@ -0,0 +1,119 @@
+package harden
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// site lays out:
+//
+//	secret.txt              outside the served directory
+//	public/index.html
+//	public/app.js
+//	public/.env
+//	public/.git/config
+//	public/docs/guide.txt   a directory without index.html
+//	public/escape.txt       a symlink to ../secret.txt
+//	public/inside.txt       a symlink to app.js
+func site(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	public := filepath.Join(dir, "public")
+	for name, body := range map[string]string{
+		"secret.txt":            "SECRET",
+		"public/index.html":     "<h1>home</h1>",
+		"public/app.js":         "console.log(1)",
+		"public/.env":           "DB_PASSWORD=x",
+		"public/docs/guide.txt": "guide",
+		"public/.git/config":    "[core]",
+	} {
+		p := filepath.Join(dir, name)
+		os.MkdirAll(filepath.Dir(p), 0o755)
+		if err := os.WriteFile(p, []byte(body), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.Symlink("../secret.txt", filepath.Join(public, "escape.txt")); err != nil {
+		t.Skip("no symlinks:", err)
+	}
+	os.Symlink("app.js", filepath.Join(public, "inside.txt"))
+	return public
+}
+
+// rawGet sends path exactly as given, which http.Client and
+// httptest.NewRequest would clean or reject.
+func rawGet(t *testing.T, addr, path string) (int, string) {
+	t.Helper()
+	conn, err := net.Dial("tcp", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	fmt.Fprintf(conn, "GET %s HTTP/1.1\r\nHost: x\r\nConnection: close\r\n\r\n", path)
+	resp, err := http.ReadResponse(bufio.NewReader(conn), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, string(b)
+}
+
+func TestStatic(t *testing.T) {
+	s, err := OpenStatic(site(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	// No mux in front, so nothing cleans the path before Static sees it.
+	srv := httptest.NewServer(s)
+	defer srv.Close()
+	addr := srv.Listener.Addr().String()
+
+	for _, tt := range []struct {
+		path string
+		code int
+		body string
+	}{
+		{"/", 200, "<h1>home</h1>"},
+		{"/app.js", 200, "console.log(1)"},
+		{"/inside.txt", 200, "console.log(1)"},
+		{"/missing.js", 404, ""},
+		// Traversal, plain and encoded.
+		{"/../secret.txt", 400, ""},
+		{"/docs/../../secret.txt", 400, ""},
+		{"/%2e%2e/secret.txt", 400, ""},
+		{"/..%2fsecret.txt", 400, ""},
+		{"/..%5csecret.txt", 400, ""},
+		{"/app.js%00.png", 400, ""},
+		// A symlink out of the directory.
+		{"/escape.txt", 404, ""},
+		// Dotfiles and directories without an index.
+		{"/.env", 404, ""},
+		{"/.git/config", 404, ""},
+		{"/docs/", 404, ""},
+		{"/docs/guide.txt", 200, "guide"},
+	} {
+		code, body := rawGet(t, addr, tt.path)
+		if code != tt.code || (tt.body != "" && body != tt.body) {
+			t.Errorf("%s: %d %q, want %d %q", tt.path, code, body, tt.code, tt.body)
+		}
+		if strings.Contains(body, "SECRET") || strings.Contains(body, "DB_PASSWORD") {
+			t.Errorf("%s leaked %q", tt.path, body)
+		}
+	}
+}
+
+func TestOpenStaticMissing(t *testing.T) {
+	if _, err := OpenStatic(filepath.Join(t.TempDir(), "nope")); err == nil {
+		t.Error("opened a missing directory")
+	}
+}
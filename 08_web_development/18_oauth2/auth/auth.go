@@ -0,0 +1,247 @@
+// Package auth logs users in with OpenID Connect: the authorization-code
+// flow with PKCE, state and nonce checks, and the identity and tokens kept
+// in the server-side session from the session package. It refreshes the
+// access token when it expires and saves the new one back.
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"golang.org/x/oauth2"
+
+	"golang_roadmap/08_web_development/16_csrf/session"
+	"golang_roadmap/08_web_development/18_oauth2/oidc"
+)
+
+var (
+	ErrNotLoggedIn = errors.New("not logged in")
+	// ErrLoginExpired means the provider refused the refresh token: the
+	// user revoked access, or the login is too old. They must log in
+	// again.
+	ErrLoginExpired = errors.New("login expired")
+)
+
+// Session keys. The login keys live only between /login and /callback.
+const (
+	keyState    = "oidc_state"
+	keyNonce    = "oidc_nonce"
+	keyVerifier = "oidc_verifier"
+	keyNext     = "oidc_next"
+	keySubject  = "user_sub"
+	keyEmail    = "user_email"
+	keyName     = "user_name"
+	keyToken    = "oauth_token"
+)
+
+// Auth handles logins for one client of one provider.
+type Auth struct {
+	provider *oidc.Provider
+	config   oauth2.Config
+	store    *session.Store
+}
+
+// New returns an Auth for the client registered with the provider as
+// clientID, whose callback is at redirectURL. It asks for the openid,
+// profile and email scopes, and for offline_access, which asks for a
+// refresh token.
+func New(p *oidc.Provider, clientID, clientSecret, redirectURL string, store *session.Store) *Auth {
+	return &Auth{
+		provider: p,
+		config: oauth2.Config{
+			ClientID:     clientID,
+			ClientSecret: clientSecret,
+			Endpoint:     p.Endpoint(),
+			RedirectURL:  redirectURL,
+			Scopes:       []string{"openid", "profile", "email", "offline_access"},
+		},
+		store: store,
+	}
+}
+
+// Identity is the logged-in user, from the verified ID token.
+type Identity struct {
+	Subject string
+	Email   string
+	Name    string
+}
+
+// User returns the logged-in user of r's session.
+func User(r *http.Request) (Identity, bool) {
+	s := session.FromContext(r.Context())
+	if s == nil || s.Get(keySubject) == "" {
+		return Identity{}, false
+	}
+	return Identity{Subject: s.Get(keySubject), Email: s.Get(keyEmail), Name: s.Get(keyName)}, true
+}
+
+// Login starts a login: it remembers a random state, nonce and PKCE
+// verifier in the session and redirects to the provider. The next query
+// parameter, a local path, is where Callback sends the user afterwards.
+func (a *Auth) Login(w http.ResponseWriter, r *http.Request) {
+	s := session.FromContext(r.Context())
+	state, nonce, verifier := randomString(), randomString(), oauth2.GenerateVerifier()
+	s.Set(keyState, state)
+	s.Set(keyNonce, nonce)
+	s.Set(keyVerifier, verifier)
+	s.Set(keyNext, localPath(r.URL.Query().Get("next")))
+	u := a.config.AuthCodeURL(state,
+		oauth2.SetAuthURLParam("nonce", nonce),
+		oauth2.S256ChallengeOption(verifier),
+	)
+	http.Redirect(w, r, u, http.StatusFound)
+}
+
+// Callback finishes a login at the redirect URL. It checks that state is
+// the one this session sent, so that a callback link forged by someone
+// else cannot log the user in as the attacker. It exchanges the code,
+// with the PKCE verifier, and verifies the ID token with the nonce. Then
+// it renews the session ID and stores the identity and tokens.
+func (a *Auth) Callback(w http.ResponseWriter, r *http.Request) {
+	s := session.FromContext(r.Context())
+	state, nonce, verifier, next := s.Get(keyState), s.Get(keyNonce), s.Get(keyVerifier), s.Get(keyNext)
+	// One attempt per login: a replayed callback finds nothing.
+	for _, k := range []string{keyState, keyNonce, keyVerifier, keyNext} {
+		s.Delete(k)
+	}
+
+	q := r.URL.Query()
+	if state == "" {
+		http.Error(w, "No login in progress. Start again from the login page.", http.StatusBadRequest)
+		return
+	}
+	if subtle.ConstantTimeCompare([]byte(q.Get("state")), []byte(state)) != 1 {
+		log.Printf("auth: state mismatch from %s", r.RemoteAddr)
+		http.Error(w, "Login state mismatch. Start again from the login page.", http.StatusBadRequest)
+		return
+	}
+	if e := q.Get("error"); e != "" {
+		// access_denied is the user saying no, not a failure.
+		http.Error(w, "Login cancelled: "+e, http.StatusForbidden)
+		return
+	}
+
+	tok, err := a.config.Exchange(r.Context(), q.Get("code"), oauth2.VerifierOption(verifier))
+	if err != nil {
+		log.Printf("auth: exchange: %v", err)
+		http.Error(w, "Login failed", http.StatusBadGateway)
+		return
+	}
+	raw, _ := tok.Extra("id_token").(string)
+	id, err := a.provider.Verify(r.Context(), raw, a.config.ClientID, nonce)
+	if err != nil {
+		log.Printf("auth: %v", err)
+		http.Error(w, "Login failed", http.StatusUnauthorized)
+		return
+	}
+
+	a.store.Renew(w, s) // new privileges, new session ID
+	s.Set(keySubject, id.Subject)
+	s.Set(keyEmail, id.Email)
+	s.Set(keyName, id.Name)
+	if err := saveToken(s, tok); err != nil {
+		log.Printf("auth: saving token: %v", err)
+	}
+	http.Redirect(w, r, next, http.StatusSeeOther)
+}
+
+// Logout ends the session. It does not log the user out of the provider,
+// where they may still be signed in for other apps.
+func (a *Auth) Logout(w http.ResponseWriter, r *http.Request) {
+	a.store.Destroy(w, session.FromContext(r.Context()))
+	http.Redirect(w, r, "/", http.StatusSeeOther)
+}
+
+// Require sends users who are not logged in to loginPath, with the page
+// they asked for as next.
+func Require(loginPath string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := User(r); !ok {
+			http.Redirect(w, r, loginPath+"?next="+url.QueryEscape(r.URL.RequestURI()), http.StatusFound)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// Client returns an HTTP client for the length of r that sends the
+// session's access token. If the token has expired, or expires within
+// ten seconds, it is refreshed first and the new one saved in the
+// session. A refused refresh returns ErrLoginExpired and logs the user
+// out.
+func (a *Auth) Client(r *http.Request) (*http.Client, error) {
+	s := session.FromContext(r.Context())
+	old, err := loadToken(s)
+	if err != nil {
+		return nil, err
+	}
+	tok, err := a.config.TokenSource(r.Context(), old).Token()
+	var re *oauth2.RetrieveError
+	if errors.As(err, &re) && re.ErrorCode == "invalid_grant" {
+		for _, k := range []string{keySubject, keyEmail, keyName, keyToken} {
+			s.Delete(k)
+		}
+		return nil, ErrLoginExpired
+	}
+	if err != nil {
+		return nil, fmt.Errorf("refreshing token: %w", err)
+	}
+	// A refresh returns a new access token and, as providers rotate them,
+	// a new refresh token; the old one no longer works.
+	if tok.AccessToken != old.AccessToken {
+		if err := saveToken(s, tok); err != nil {
+			return nil, err
+		}
+	}
+	return oauth2.NewClient(r.Context(), oauth2.StaticTokenSource(tok)), nil
+}
+
+// saveToken stores the parts of tok the session needs. The ID token is
+// not kept: it was for the login, and was checked then.
+func saveToken(s *session.Session, tok *oauth2.Token) error {
+	b, err := json.Marshal(&oauth2.Token{
+		AccessToken:  tok.AccessToken,
+		TokenType:    tok.TokenType,
+		RefreshToken: tok.RefreshToken,
+		Expiry:       tok.Expiry,
+	})
+	if err != nil {
+		return err
+	}
+	s.Set(keyToken, string(b))
+	return nil
+}
+
+func loadToken(s *session.Session) (*oauth2.Token, error) {
+	if s == nil || s.Get(keyToken) == "" {
+		return nil, ErrNotLoggedIn
+	}
+	var tok oauth2.Token
+	if err := json.Unmarshal([]byte(s.Get(keyToken)), &tok); err != nil {
+		return nil, err
+	}
+	return &tok, nil
+}
+
+// localPath returns p if it is a path on this site, or "/". An absolute
+// URL, or //host, would make the login an open redirect.
+func localPath(p string) string {
+	if !strings.HasPrefix(p, "/") || strings.HasPrefix(p, "//") || strings.HasPrefix(p, "/\\") {
+		return "/"
+	}
+	return p
+}
+
+func randomString() string {
+	b := make([]byte, 32)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
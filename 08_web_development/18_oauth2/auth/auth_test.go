@@ -0,0 +1,235 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/08_web_development/16_csrf/session"
+	"golang_roadmap/08_web_development/18_oauth2/oidc"
+	"golang_roadmap/08_web_development/18_oauth2/oidctest"
+)
+
+// setup starts a provider and an app that logs in with it. The app has
+// /me, for logged-in users only, and /profile, which calls the
+// provider's userinfo endpoint with the user's access token.
+func setup(t *testing.T) (*oidctest.Server, *httptest.Server) {
+	t.Helper()
+	op := oidctest.NewServer("app", "s3cret")
+	t.Cleanup(op.Close)
+	p, err := oidc.Discover(context.Background(), op.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	store := &session.Store{}
+	mux := http.NewServeMux()
+	app := httptest.NewServer(store.Middleware(mux))
+	t.Cleanup(app.Close)
+	a := New(p, "app", "s3cret", app.URL+"/callback", store)
+
+	mux.HandleFunc("GET /login", a.Login)
+	mux.HandleFunc("GET /callback", a.Callback)
+	mux.HandleFunc("POST /logout", a.Logout)
+	mux.Handle("GET /me", Require("/login", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, _ := User(r)
+		fmt.Fprintf(w, "%s <%s>", u.Name, u.Email)
+	})))
+	mux.HandleFunc("GET /profile", func(w http.ResponseWriter, r *http.Request) {
+		c, err := a.Client(r)
+		if errors.Is(err, ErrLoginExpired) || errors.Is(err, ErrNotLoggedIn) {
+			http.Error(w, err.Error(), http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		resp, err := c.Get(p.UserInfoURL)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		io.Copy(w, resp.Body)
+	})
+	return op, app
+}
+
+func newBrowser(follow bool) *http.Client {
+	jar, _ := cookiejar.New(nil)
+	c := &http.Client{Jar: jar}
+	if !follow {
+		c.CheckRedirect = func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }
+	}
+	return c
+}
+
+func get(t *testing.T, c *http.Client, u string) (*http.Response, string) {
+	t.Helper()
+	resp, err := c.Get(u)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp, strings.TrimSpace(string(b))
+}
+
+func TestLogin(t *testing.T) {
+	_, app := setup(t)
+	b := newBrowser(true)
+	resp, body := get(t, b, app.URL+"/me?tab=2")
+	if resp.StatusCode != 200 || body != "Alice <alice@example.com>" {
+		t.Fatalf("%d %q", resp.StatusCode, body)
+	}
+	// Back where the user started.
+	if got := resp.Request.URL.RequestURI(); got != "/me?tab=2" {
+		t.Errorf("ended at %s", got)
+	}
+	if resp, _ := get(t, b, app.URL+"/me"); resp.Request.URL.Path != "/me" {
+		t.Error("the second visit was sent to log in again")
+	}
+}
+
+// callbackURL runs a login up to the provider's redirect and returns the
+// callback URL it would send the browser to.
+func callbackURL(t *testing.T, b *http.Client, app string) *url.URL {
+	t.Helper()
+	resp, _ := get(t, b, app+"/login")
+	authURL := resp.Header.Get("Location")
+	for _, p := range []string{"state=", "nonce=", "code_challenge=", "code_challenge_method=S256", "scope=openid"} {
+		if !strings.Contains(authURL, p) {
+			t.Errorf("authorization URL lacks %s: %s", p, authURL)
+		}
+	}
+	resp, _ = get(t, b, authURL)
+	u, err := url.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return u
+}
+
+func TestState(t *testing.T) {
+	_, app := setup(t)
+	b := newBrowser(false)
+	cb := callbackURL(t, b, app.URL)
+
+	forged := *cb
+	q := forged.Query()
+	q.Set("state", "attacker")
+	forged.RawQuery = q.Encode()
+	if resp, body := get(t, b, forged.String()); resp.StatusCode != 400 || !strings.Contains(body, "state mismatch") {
+		t.Errorf("forged state: %d %q", resp.StatusCode, body)
+	}
+	// The failed attempt used up the login: the real callback now fails too.
+	if resp, body := get(t, b, cb.String()); resp.StatusCode != 400 || !strings.Contains(body, "No login in progress") {
+		t.Errorf("after a mismatch: %d %q", resp.StatusCode, body)
+	}
+}
+
+func TestCallbackReplay(t *testing.T) {
+	_, app := setup(t)
+	b := newBrowser(false)
+	cb := callbackURL(t, b, app.URL)
+	if resp, body := get(t, b, cb.String()); resp.StatusCode != http.StatusSeeOther {
+		t.Fatalf("callback: %d %q", resp.StatusCode, body)
+	}
+	if resp, _ := get(t, b, cb.String()); resp.StatusCode != 400 {
+		t.Errorf("replayed callback: %d", resp.StatusCode)
+	}
+	// An attacker's callback, in a browser that never started a login.
+	if resp, _ := get(t, newBrowser(false), cb.String()); resp.StatusCode != 400 {
+		t.Errorf("callback without a login: %d", resp.StatusCode)
+	}
+}
+
+func TestProviderFailures(t *testing.T) {
+	for _, tt := range []struct {
+		name  string
+		setup func(op *oidctest.Server)
+		code  int
+	}{
+		{"denied", func(op *oidctest.Server) { op.Deny = true }, http.StatusForbidden},
+		{"wrong nonce", func(op *oidctest.Server) { op.Tamper = func(c map[string]any) { c["nonce"] = "other" } }, http.StatusUnauthorized},
+		{"wrong audience", func(op *oidctest.Server) { op.Tamper = func(c map[string]any) { c["aud"] = "other-app" } }, http.StatusUnauthorized},
+	} {
+		op, app := setup(t)
+		tt.setup(op)
+		resp, body := get(t, newBrowser(true), app.URL+"/me")
+		if resp.StatusCode != tt.code {
+			t.Errorf("%s: %d %q, want %d", tt.name, resp.StatusCode, body, tt.code)
+		}
+	}
+}
+
+func TestRefresh(t *testing.T) {
+	op, app := setup(t)
+	b := newBrowser(true)
+	get(t, b, app.URL+"/me")
+
+	// A fresh token is used as is.
+	if resp, body := get(t, b, app.URL+"/profile"); resp.StatusCode != 200 || !strings.Contains(body, `"sub":"248289761001"`) {
+		t.Fatalf("profile: %d %q", resp.StatusCode, body)
+	}
+	if n := op.Refreshes(); n != 0 {
+		t.Errorf("%d refreshes with a fresh token", n)
+	}
+
+	// Access tokens now expire within oauth2's 10 second margin, so every
+	// use refreshes. Each refresh rotates the refresh token, so the second
+	// only works if the first one's was saved to the session.
+	op.AccessTokenTTL = time.Second
+	get(t, b, app.URL+"/logout")
+	b = newBrowser(true)
+	get(t, b, app.URL+"/me")
+	for i := range 2 {
+		if resp, body := get(t, b, app.URL+"/profile"); resp.StatusCode != 200 {
+			t.Fatalf("profile %d: %d %q", i, resp.StatusCode, body)
+		}
+	}
+	if n := op.Refreshes(); n != 2 {
+		t.Errorf("%d refreshes, want 2", n)
+	}
+}
+
+func TestRevoked(t *testing.T) {
+	op, app := setup(t)
+	op.AccessTokenTTL = time.Second
+	b := newBrowser(true)
+	get(t, b, app.URL+"/me")
+	op.RevokeAll()
+
+	if resp, body := get(t, b, app.URL+"/profile"); resp.StatusCode != 401 || body != ErrLoginExpired.Error() {
+		t.Errorf("profile: %d %q", resp.StatusCode, body)
+	}
+	// The identity went with the tokens.
+	nb := newBrowser(false)
+	nb.Jar = b.Jar
+	if resp, _ := get(t, nb, app.URL+"/me"); resp.StatusCode != http.StatusFound {
+		t.Errorf("/me after revocation: %d", resp.StatusCode)
+	}
+}
+
+func TestLocalPath(t *testing.T) {
+	for in, want := range map[string]string{
+		"":                     "/",
+		"/me?tab=2":            "/me?tab=2",
+		"https://evil.example": "/",
+		"//evil.example/x":     "/",
+		"/\\evil.example":      "/",
+		"me":                   "/",
+	} {
+		if got := localPath(in); got != want {
+			t.Errorf("localPath(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
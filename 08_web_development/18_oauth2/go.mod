@@ -0,0 +1,11 @@
+module golang_roadmap/08_web_development/18_oauth2
+
+go 1.24.11
+
+require (
+	golang.org/x/oauth2 v0.34.0
+	golang_roadmap/08_web_development/16_csrf v0.0.0
+)
+
+// The session package lives in its own module in this repository.
+replace golang_roadmap/08_web_development/16_csrf => ../../08_web_development/16_csrf
@@ -0,0 +1,216 @@
+// Demonstrates logging in with OAuth2 and OpenID Connect.
+//
+// This example shows:
+// - The authorization-code flow with golang.org/x/oauth2, step by step
+// - state against forged callbacks, nonce against replayed ID tokens, PKCE against stolen codes
+// - Verifying the ID token and keeping the identity in a server-side session
+// - Refreshing an expired access token, and a revoked login
+// - A fake provider in-process, for tests and this demo
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"html/template"
+	"io"
+	"log"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"time"
+
+	"golang_roadmap/08_web_development/16_csrf/session"
+	"golang_roadmap/08_web_development/18_oauth2/auth"
+	"golang_roadmap/08_web_development/18_oauth2/oidc"
+	"golang_roadmap/08_web_development/18_oauth2/oidctest"
+)
+
+var home = template.Must(template.New("home").Parse(`<!doctype html>
+<meta charset="utf-8">
+<title>App</title>
+{{with .}}
+<p>Hello, {{.Name}} ({{.Email}}). <a href="/profile">Profile from the provider</a></p>
+<form method="post" action="/logout"><button>Log out</button></form>
+{{else}}
+<p><a href="/login">Log in</a></p>
+{{end}}
+`))
+
+func routes(a *auth.Auth, p *oidc.Provider, store *session.Store) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /login", a.Login)
+	mux.HandleFunc("GET /callback", a.Callback)
+	mux.HandleFunc("POST /logout", a.Logout)
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) {
+		var u *auth.Identity
+		if id, ok := auth.User(r); ok {
+			u = &id
+		}
+		home.Execute(w, u)
+	})
+	mux.Handle("GET /me", auth.Require("/login", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		u, _ := auth.User(r)
+		fmt.Fprintf(w, "Hello, %s <%s>\n", u.Name, u.Email)
+	})))
+	// /profile calls the provider's API with the user's access token.
+	mux.Handle("GET /profile", auth.Require("/login", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		c, err := a.Client(r)
+		if errors.Is(err, auth.ErrLoginExpired) {
+			http.Error(w, "Your login expired. Log in again.", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			log.Printf("profile: %v", err)
+			http.Error(w, "Provider unavailable", http.StatusBadGateway)
+			return
+		}
+		resp, err := c.Get(p.UserInfoURL)
+		if err != nil {
+			log.Printf("profile: %v", err)
+			http.Error(w, "Provider unavailable", http.StatusBadGateway)
+			return
+		}
+		defer resp.Body.Close()
+		w.Header().Set("Content-Type", "application/json")
+		io.Copy(w, resp.Body)
+	})))
+	return store.Middleware(mux)
+}
+
+// step makes one request without following redirects, prints it, and
+// returns the response's Location and body.
+func step(c *http.Client, u string) (string, string) {
+	resp, err := c.Get(u)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	loc := resp.Header.Get("Location")
+	pu, _ := url.Parse(u)
+	fmt.Printf("   GET %s%s -> %d", pu.Host, abbreviate(pu.RequestURI()), resp.StatusCode)
+	if loc != "" {
+		fmt.Printf(" to %s", abbreviate(loc))
+	} else {
+		fmt.Printf(": %s", strings.TrimSpace(string(body)))
+	}
+	fmt.Println()
+	return loc, string(body)
+}
+
+// abbreviate shortens long random query values so the flow fits on a line.
+func abbreviate(u string) string {
+	pu, err := url.Parse(u)
+	if err != nil || pu.RawQuery == "" {
+		return u
+	}
+	q := pu.Query()
+	for k, vs := range q {
+		if len(vs[0]) > 12 && k != "redirect_uri" && k != "scope" {
+			q.Set(k, vs[0][:8]+"...")
+		}
+	}
+	pu.RawQuery = strings.NewReplacer("%2F", "/", "%3A", ":", "+", " ").Replace(q.Encode())
+	return pu.String()
+}
+
+// location returns where u redirects to.
+func location(c *http.Client, u string) string {
+	resp, err := c.Get(u)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.Header.Get("Location")
+}
+
+// follow makes requests from u, following redirects silently, as a
+// browser does.
+func follow(c *http.Client, u, base string) {
+	for u != "" {
+		resp, err := c.Get(u)
+		if err != nil {
+			log.Fatal(err)
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		u = resp.Header.Get("Location")
+		if strings.HasPrefix(u, "/") {
+			u = base + u
+		}
+	}
+}
+
+func newBrowser() *http.Client {
+	jar, _ := cookiejar.New(nil)
+	return &http.Client{Jar: jar, CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+}
+
+func main() {
+	addr := flag.String("serve", "", "after the demo, serve the app on this address, such as :8080, with the fake provider")
+	flag.Parse()
+	log.SetFlags(0)
+
+	fmt.Println("OAuth2/OIDC examples starting...")
+	op := oidctest.NewServer("demo-app", "demo-secret")
+	defer op.Close()
+	provider, err := oidc.Discover(context.Background(), op.URL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	store := &session.Store{}
+	mux := http.NewServeMux()
+	app := httptest.NewServer(mux)
+	defer app.Close()
+	a := auth.New(provider, "demo-app", "demo-secret", app.URL+"/callback", store)
+	mux.Handle("/", routes(a, provider, store))
+
+	// 1) Every hop of a login, from a protected page back to it.
+	fmt.Println("\n1) The authorization-code flow")
+	b := newBrowser()
+	for u := app.URL + "/me"; u != ""; {
+		u, _ = step(b, u)
+		if strings.HasPrefix(u, "/") {
+			u = app.URL + u
+		}
+	}
+
+	// 2) A callback link made by an attacker: their own code, for their
+	// own account, sent to the victim. Without state, the victim would be
+	// logged in as the attacker and type their data into it.
+	fmt.Println("\n2) A forged callback")
+	attacker := newBrowser()
+	cb := location(attacker, location(attacker, app.URL+"/login")) // the attacker stops before using it
+	step(newBrowser(), cb)
+
+	// 3) The access token expires; the next call refreshes it.
+	fmt.Println("\n3) Refreshing the access token")
+	op.AccessTokenTTL = 11 * time.Second // x/oauth2 refreshes within 10s of expiry
+	b = newBrowser()
+	follow(b, app.URL+"/login", app.URL)
+	step(b, app.URL+"/profile")
+	fmt.Printf("   refreshes so far: %d\n", op.Refreshes())
+	time.Sleep(1500 * time.Millisecond)
+	step(b, app.URL+"/profile")
+	fmt.Printf("   refreshes so far: %d\n", op.Refreshes())
+
+	// 4) The user removes the app at the provider.
+	fmt.Println("\n4) A revoked login")
+	op.RevokeAll()
+	time.Sleep(1500 * time.Millisecond)
+	step(b, app.URL+"/profile")
+	step(b, app.URL+"/me")
+
+	if *addr != "" {
+		op.AccessTokenTTL = time.Minute
+		srv := &http.Server{Addr: *addr, ReadHeaderTimeout: 5 * time.Second}
+		live := auth.New(provider, "demo-app", "demo-secret", "http://localhost"+*addr+"/callback", store)
+		srv.Handler = routes(live, provider, store)
+		log.Printf("serving on %s with the fake provider at %s: open http://localhost%s", *addr, op.URL, *addr)
+		log.Fatal(srv.ListenAndServe())
+	}
+}
@@ -0,0 +1,88 @@
+package oidc
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/08_web_development/18_oauth2/oidctest"
+)
+
+func TestDiscover(t *testing.T) {
+	op := oidctest.NewServer("app", "secret")
+	defer op.Close()
+	p, err := Discover(context.Background(), op.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.TokenURL != op.URL+"/token" || p.Endpoint().AuthURL != op.URL+"/authorize" {
+		t.Errorf("got %+v", p)
+	}
+	// The document must name the issuer it was fetched from.
+	if _, err := Discover(context.Background(), strings.Replace(op.URL, "127.0.0.1", "localhost", 1)); err == nil {
+		t.Error("accepted a document for another issuer")
+	}
+}
+
+func TestVerify(t *testing.T) {
+	op := oidctest.NewServer("app", "secret")
+	defer op.Close()
+	p, err := Discover(context.Background(), op.URL)
+	if err != nil {
+		t.Fatal(err)
+	}
+	now := time.Now()
+	claims := func(edit func(map[string]any)) map[string]any {
+		c := map[string]any{
+			"iss": op.URL, "sub": "42", "aud": "app", "nonce": "n1",
+			"iat": now.Unix(), "exp": now.Add(time.Hour).Unix(),
+			"email": "alice@example.com", "name": "Alice",
+		}
+		if edit != nil {
+			edit(c)
+		}
+		return c
+	}
+	rs256 := map[string]any{"alg": "RS256", "kid": "key-1"}
+
+	good := op.Sign(rs256, claims(nil))
+	id, err := p.Verify(context.Background(), good, "app", "n1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if id.Subject != "42" || id.Email != "alice@example.com" || id.Name != "Alice" {
+		t.Errorf("got %+v", id)
+	}
+
+	parts := strings.Split(good, ".")
+	for _, tt := range []struct {
+		name  string
+		token string
+		want  string
+	}{
+		{"not a JWT", "abc", "not a JWT"},
+		{"alg none", op.Sign(map[string]any{"alg": "none", "kid": "key-1"}, claims(nil)), `algorithm "none"`},
+		{"HS256", op.Sign(map[string]any{"alg": "HS256", "kid": "key-1"}, claims(nil)), `algorithm "HS256"`},
+		{"unknown key", op.Sign(map[string]any{"alg": "RS256", "kid": "key-9"}, claims(nil)), `unknown key "key-9"`},
+		{"edited claims", parts[0] + "." + strings.Split(op.Sign(rs256, claims(func(c map[string]any) { c["sub"] = "1" })), ".")[1] + "." + parts[2], "bad signature"},
+		{"other issuer", op.Sign(rs256, claims(func(c map[string]any) { c["iss"] = "https://evil.example" })), "issuer"},
+		{"other audience", op.Sign(rs256, claims(func(c map[string]any) { c["aud"] = "other-app" })), "issued to"},
+		{"audience list", op.Sign(rs256, claims(func(c map[string]any) { c["aud"] = []string{"x", "app"} })), ""},
+		{"no subject", op.Sign(rs256, claims(func(c map[string]any) { delete(c, "sub") })), "no subject"},
+		{"expired", op.Sign(rs256, claims(func(c map[string]any) { c["exp"] = now.Add(-2 * time.Minute).Unix() })), "expired"},
+		{"within leeway", op.Sign(rs256, claims(func(c map[string]any) { c["exp"] = now.Add(-30 * time.Second).Unix() })), ""},
+		{"from the future", op.Sign(rs256, claims(func(c map[string]any) { c["iat"] = now.Add(time.Hour).Unix() })), "future"},
+		{"other nonce", op.Sign(rs256, claims(func(c map[string]any) { c["nonce"] = "n2" })), "nonce"},
+		{"no nonce", op.Sign(rs256, claims(func(c map[string]any) { delete(c, "nonce") })), "nonce"},
+	} {
+		_, err := p.Verify(context.Background(), tt.token, "app", "n1")
+		switch {
+		case tt.want == "" && err != nil:
+			t.Errorf("%s: %v", tt.name, err)
+		case tt.want != "" && (!errors.Is(err, ErrInvalidToken) || !strings.Contains(err.Error(), tt.want)):
+			t.Errorf("%s: got %v, want %q", tt.name, err, tt.want)
+		}
+	}
+}
@@ -0,0 +1,296 @@
+// Package oidctest is a fake OpenID provider that runs in-process, for
+// tests and demos, as net/http/httptest is a fake server. It implements
+// the authorization-code flow with PKCE, refresh tokens, a key set and a
+// userinfo endpoint. Its authorization endpoint approves every request
+// for User at once, where a real provider shows a login page.
+package oidctest
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// User is the identity the provider logs in.
+type User struct {
+	Subject string
+	Email   string
+	Name    string
+}
+
+// Server is a running fake provider. Set its fields before the first
+// login.
+type Server struct {
+	*httptest.Server
+
+	ClientID     string
+	ClientSecret string
+	User         User
+	// AccessTokenTTL is how long access tokens last; default an hour.
+	AccessTokenTTL time.Duration
+	// Deny answers the next logins with error=access_denied, as when the
+	// user clicks Cancel.
+	Deny bool
+	// Tamper, if set, edits the claims of each ID token before it is
+	// signed, to test what the client refuses.
+	Tamper func(claims map[string]any)
+
+	key *rsa.PrivateKey
+	kid string
+
+	mu        sync.Mutex
+	codes     map[string]grant // authorization code -> grant, used once
+	refresh   map[string]grant // refresh token -> grant
+	access    map[string]grant // access token -> grant
+	refreshes int
+}
+
+type grant struct {
+	user        User
+	nonce       string
+	redirectURI string
+	challenge   string
+	expires     time.Time
+}
+
+// NewServer starts a provider that accepts one client.
+func NewServer(clientID, clientSecret string) *Server {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		panic(err)
+	}
+	s := &Server{
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		User:         User{Subject: "248289761001", Email: "alice@example.com", Name: "Alice"},
+		key:          key,
+		kid:          "key-1",
+		codes:        map[string]grant{},
+		refresh:      map[string]grant{},
+		access:       map[string]grant{},
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /.well-known/openid-configuration", s.discovery)
+	mux.HandleFunc("GET /authorize", s.authorize)
+	mux.HandleFunc("POST /token", s.token)
+	mux.HandleFunc("GET /keys", s.keys)
+	mux.HandleFunc("GET /userinfo", s.userinfo)
+	s.Server = httptest.NewServer(mux)
+	return s
+}
+
+// RevokeAll revokes every refresh and access token, as when the user
+// removes the app from their account.
+func (s *Server) RevokeAll() {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	clear(s.refresh)
+	clear(s.access)
+}
+
+// Refreshes returns the number of refresh-token grants so far.
+func (s *Server) Refreshes() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.refreshes
+}
+
+func (s *Server) discovery(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, map[string]any{
+		"issuer":                                s.URL,
+		"authorization_endpoint":                s.URL + "/authorize",
+		"token_endpoint":                        s.URL + "/token",
+		"userinfo_endpoint":                     s.URL + "/userinfo",
+		"jwks_uri":                              s.URL + "/keys",
+		"response_types_supported":              []string{"code"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+	})
+}
+
+func (s *Server) authorize(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	redirectURI := q.Get("redirect_uri")
+	// Never redirect to an unchecked URI: that would hand the code to
+	// whoever wrote the link. A real provider compares it with the URIs
+	// registered for the client.
+	if q.Get("client_id") != s.ClientID || redirectURI == "" {
+		http.Error(w, "unknown client or redirect_uri", http.StatusBadRequest)
+		return
+	}
+	back, err := url.Parse(redirectURI)
+	if err != nil {
+		http.Error(w, "bad redirect_uri", http.StatusBadRequest)
+		return
+	}
+	params := url.Values{"state": {q.Get("state")}}
+	switch {
+	case s.Deny:
+		params.Set("error", "access_denied")
+	case q.Get("response_type") != "code":
+		params.Set("error", "unsupported_response_type")
+	case q.Get("code_challenge_method") != "S256":
+		params.Set("error", "invalid_request")
+	default:
+		code := randomString()
+		s.mu.Lock()
+		s.codes[code] = grant{user: s.User, nonce: q.Get("nonce"), redirectURI: redirectURI, challenge: q.Get("code_challenge"), expires: time.Now().Add(time.Minute)}
+		s.mu.Unlock()
+		params.Set("code", code)
+	}
+	back.RawQuery = params.Encode()
+	http.Redirect(w, r, back.String(), http.StatusFound)
+}
+
+func (s *Server) token(w http.ResponseWriter, r *http.Request) {
+	id, secret, ok := r.BasicAuth()
+	if ok {
+		id, _ = url.QueryUnescape(id)
+		secret, _ = url.QueryUnescape(secret)
+	} else {
+		id, secret = r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	}
+	if id != s.ClientID || subtle.ConstantTimeCompare([]byte(secret), []byte(s.ClientSecret)) != 1 {
+		tokenError(w, http.StatusUnauthorized, "invalid_client")
+		return
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	var g grant
+	switch r.PostFormValue("grant_type") {
+	case "authorization_code":
+		code := r.PostFormValue("code")
+		g, ok = s.codes[code]
+		delete(s.codes, code) // a code works once, even if the exchange fails
+		if !ok || time.Now().After(g.expires) || r.PostFormValue("redirect_uri") != g.redirectURI {
+			tokenError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		// PKCE: only the client that started the login knows the verifier
+		// behind the challenge, so a stolen code is useless.
+		sum := sha256.Sum256([]byte(r.PostFormValue("code_verifier")))
+		if base64.RawURLEncoding.EncodeToString(sum[:]) != g.challenge {
+			tokenError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+	case "refresh_token":
+		old := r.PostFormValue("refresh_token")
+		g, ok = s.refresh[old]
+		delete(s.refresh, old) // rotated: each refresh token works once
+		if !ok {
+			tokenError(w, http.StatusBadRequest, "invalid_grant")
+			return
+		}
+		g.nonce = "" // only the ID token from the login carries the nonce
+		s.refreshes++
+	default:
+		tokenError(w, http.StatusBadRequest, "unsupported_grant_type")
+		return
+	}
+
+	ttl := s.AccessTokenTTL
+	if ttl <= 0 {
+		ttl = time.Hour
+	}
+	access, refresh := randomString(), randomString()
+	g.expires = time.Now().Add(ttl)
+	s.access[access] = g
+	s.refresh[refresh] = g
+	w.Header().Set("Cache-Control", "no-store")
+	writeJSON(w, http.StatusOK, map[string]any{
+		"access_token":  access,
+		"token_type":    "Bearer",
+		"expires_in":    int(ttl / time.Second),
+		"refresh_token": refresh,
+		"id_token":      s.idToken(g),
+	})
+}
+
+func (s *Server) idToken(g grant) string {
+	now := time.Now()
+	claims := map[string]any{
+		"iss":            s.URL,
+		"sub":            g.user.Subject,
+		"aud":            s.ClientID,
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+		"email":          g.user.Email,
+		"email_verified": true,
+		"name":           g.user.Name,
+	}
+	if g.nonce != "" {
+		claims["nonce"] = g.nonce
+	}
+	if s.Tamper != nil {
+		s.Tamper(claims)
+	}
+	return s.Sign(map[string]any{"alg": "RS256", "typ": "JWT", "kid": s.kid}, claims)
+}
+
+// Sign returns a JWT with header and claims, signed with the provider's
+// key whatever the header says, so tests can forge tokens too.
+func (s *Server) Sign(header, claims map[string]any) string {
+	h, _ := json.Marshal(header)
+	c, _ := json.Marshal(claims)
+	signed := base64.RawURLEncoding.EncodeToString(h) + "." + base64.RawURLEncoding.EncodeToString(c)
+	digest := sha256.Sum256([]byte(signed))
+	sig, err := rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest[:])
+	if err != nil {
+		panic(err)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(sig)
+}
+
+func (s *Server) keys(w http.ResponseWriter, r *http.Request) {
+	pub := s.key.PublicKey
+	writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+		"kty": "RSA",
+		"use": "sig",
+		"alg": "RS256",
+		"kid": s.kid,
+		"n":   base64.RawURLEncoding.EncodeToString(pub.N.Bytes()),
+		"e":   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(pub.E)).Bytes()),
+	}}})
+}
+
+func (s *Server) userinfo(w http.ResponseWriter, r *http.Request) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	s.mu.Lock()
+	g, found := s.access[token]
+	s.mu.Unlock()
+	if !ok || !found || time.Now().After(g.expires) {
+		w.Header().Set("WWW-Authenticate", `Bearer error="invalid_token"`)
+		http.Error(w, "invalid token", http.StatusUnauthorized)
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"sub": g.user.Subject, "email": g.user.Email, "name": g.user.Name})
+}
+
+func tokenError(w http.ResponseWriter, status int, code string) {
+	writeJSON(w, status, map[string]string{"error": code})
+}
+
+func writeJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(v)
+}
+
+func randomString() string {
+	b := make([]byte, 24)
+	rand.Read(b)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
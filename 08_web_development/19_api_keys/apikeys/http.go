@@ -0,0 +1,151 @@
+package apikeys
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log"
+	"math"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+// Header is the request header that carries the key.
+const Header = "X-API-Key"
+
+type contextKey struct{}
+
+// FromContext returns the key that authenticated the request, set by
+// Middleware.
+func FromContext(ctx context.Context) (Key, bool) {
+	k, ok := ctx.Value(contextKey{}).(Key)
+	return k, ok
+}
+
+// Middleware lets through requests with a valid key in the X-API-Key
+// header, within the key's rate limit, and puts the key in the request's
+// context. Missing, wrong and revoked keys get 401, all with the same
+// message; keys over their limit get 429 with Retry-After.
+func (s *Store) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		full := r.Header.Get(Header)
+		if full == "" {
+			s.opts.OnError(w, r, "Missing API key", http.StatusUnauthorized)
+			return
+		}
+		k, err := s.Authenticate(r.Context(), full)
+		if errors.Is(err, ErrInvalidKey) || errors.Is(err, ErrRevoked) {
+			// Revoked gets the same answer as wrong: the client should stop
+			// either way, and an attacker holding an old key learns nothing.
+			s.opts.OnError(w, r, "Invalid API key", http.StatusUnauthorized)
+			return
+		}
+		if err != nil {
+			log.Printf("apikeys: %v", err)
+			s.opts.OnError(w, r, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+
+		ok, remaining, retryAfter := s.limiter.allow(k.ID, k.RateLimit)
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(k.RateLimit))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		if !ok {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(retryAfter.Seconds()))))
+			s.opts.OnError(w, r, "Rate limit exceeded", http.StatusTooManyRequests)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), contextKey{}, k)))
+	})
+}
+
+// AdminHandler serves key management under /api-keys:
+//
+//	POST   /api-keys       {"name": "...", "rate_limit": 120} -> 201 with the full key
+//	GET    /api-keys       -> every key, without secrets
+//	DELETE /api-keys/{id}  -> 204, or 404
+//
+// It does no authentication of its own: mount it behind an admin check,
+// not behind Middleware, or any client could mint keys.
+func (s *Store) AdminHandler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api-keys", s.create)
+	mux.HandleFunc("GET /api-keys", s.list)
+	mux.HandleFunc("DELETE /api-keys/{id}", s.revoke)
+	return mux
+}
+
+// created is the response to a create: the stored key and, this once,
+// the full key.
+type created struct {
+	Key
+	Secret string `json:"key"`
+}
+
+func (s *Store) create(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Name      string `json:"name"`
+		RateLimit int    `json:"rate_limit"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		s.opts.OnError(w, r, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	req.Name = strings.TrimSpace(req.Name)
+	if req.Name == "" {
+		s.opts.OnError(w, r, "name is required", http.StatusBadRequest)
+		return
+	}
+	if req.RateLimit < 0 {
+		s.opts.OnError(w, r, "rate_limit must not be negative", http.StatusBadRequest)
+		return
+	}
+	k, full, err := s.Create(r.Context(), req.Name, req.RateLimit)
+	if err != nil {
+		log.Printf("apikeys: create: %v", err)
+		s.opts.OnError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("apikeys: created key %s (%s)", k.ID, k.Name)
+	// The response holds a secret: no cache may keep it.
+	w.Header().Set("Cache-Control", "no-store")
+	w.Header().Set("Location", "/api-keys/"+k.ID)
+	writeJSON(w, http.StatusCreated, created{k, full})
+}
+
+func (s *Store) list(w http.ResponseWriter, r *http.Request) {
+	keys, err := s.List(r.Context())
+	if err != nil {
+		log.Printf("apikeys: list: %v", err)
+		s.opts.OnError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	if keys == nil {
+		keys = []Key{}
+	}
+	writeJSON(w, http.StatusOK, keys)
+}
+
+func (s *Store) revoke(w http.ResponseWriter, r *http.Request) {
+	id := r.PathValue("id")
+	err := s.Revoke(r.Context(), id)
+	if errors.Is(err, ErrNotFound) {
+		s.opts.OnError(w, r, "API key not found", http.StatusNotFound)
+		return
+	}
+	if err != nil {
+		log.Printf("apikeys: revoke: %v", err)
+		s.opts.OnError(w, r, "Internal server error", http.StatusInternalServerError)
+		return
+	}
+	log.Printf("apikeys: revoked key %s", id)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("apikeys: encoding response: %v", err)
+	}
+}
@@ -0,0 +1,220 @@
+package apikeys
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+// setup returns a server with the admin endpoints under /api-keys and,
+// behind Middleware, /whoami, which answers with the caller's key name.
+func setup(t *testing.T, opts Options) (*Store, *httptest.Server) {
+	t.Helper()
+	s := newTestStore(t, opts)
+	mux := http.NewServeMux()
+	admin := s.AdminHandler()
+	mux.Handle("/api-keys", admin)
+	mux.Handle("/api-keys/", admin)
+	mux.Handle("/whoami", s.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		k, _ := FromContext(r.Context())
+		w.Write([]byte(k.Name))
+	})))
+	srv := httptest.NewServer(mux)
+	t.Cleanup(srv.Close)
+	return s, srv
+}
+
+func do(t *testing.T, method, target, key, body string) (*http.Response, string) {
+	t.Helper()
+	req, err := http.NewRequest(method, target, strings.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if key != "" {
+		req.Header.Set(Header, key)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return resp, strings.TrimSpace(string(b))
+}
+
+// create makes a key through the admin endpoint and returns the response.
+func create(t *testing.T, srv *httptest.Server, body string) created {
+	t.Helper()
+	resp, b := do(t, "POST", srv.URL+"/api-keys", "", body)
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("create: %d %s", resp.StatusCode, b)
+	}
+	var c created
+	if err := json.Unmarshal([]byte(b), &c); err != nil {
+		t.Fatal(err)
+	}
+	return c
+}
+
+func TestMiddleware(t *testing.T) {
+	_, srv := setup(t, Options{})
+	c := create(t, srv, `{"name":"billing"}`)
+
+	for _, tt := range []struct {
+		name, key string
+		code      int
+		body      string
+	}{
+		{"valid", c.Secret, 200, "billing"},
+		{"missing", "", 401, "Missing API key"},
+		{"wrong", c.Secret[:len(c.Secret)-2] + "AA", 401, "Invalid API key"},
+		{"malformed", "hunter2", 401, "Invalid API key"},
+	} {
+		resp, body := do(t, "GET", srv.URL+"/whoami", tt.key, "")
+		if resp.StatusCode != tt.code || body != tt.body {
+			t.Errorf("%s: %d %q, want %d %q", tt.name, resp.StatusCode, body, tt.code, tt.body)
+		}
+	}
+}
+
+func TestRateLimit(t *testing.T) {
+	fc := clock.NewFake(t0)
+	_, srv := setup(t, Options{Clock: fc})
+	c := create(t, srv, `{"name":"billing","rate_limit":3}`)
+	other := create(t, srv, `{"name":"reports","rate_limit":3}`)
+
+	for i := range 3 {
+		resp, _ := do(t, "GET", srv.URL+"/whoami", c.Secret, "")
+		if resp.StatusCode != 200 {
+			t.Fatalf("request %d: %d", i, resp.StatusCode)
+		}
+		if got, want := resp.Header.Get("X-RateLimit-Remaining"), strconv.Itoa(2-i); got != want {
+			t.Errorf("request %d: remaining %s, want %s", i, got, want)
+		}
+	}
+	resp, body := do(t, "GET", srv.URL+"/whoami", c.Secret, "")
+	if resp.StatusCode != http.StatusTooManyRequests || body != "Rate limit exceeded" {
+		t.Fatalf("over the limit: %d %q", resp.StatusCode, body)
+	}
+	// 3 a minute is one every 20 seconds.
+	if got := resp.Header.Get("Retry-After"); got != "20" {
+		t.Errorf("Retry-After %q, want 20", got)
+	}
+	if got := resp.Header.Get("X-RateLimit-Limit"); got != "3" {
+		t.Errorf("X-RateLimit-Limit %q", got)
+	}
+
+	// Limits are per key.
+	if resp, _ := do(t, "GET", srv.URL+"/whoami", other.Secret, ""); resp.StatusCode != 200 {
+		t.Errorf("other key: %d", resp.StatusCode)
+	}
+
+	fc.Advance(20 * time.Second)
+	if resp, _ := do(t, "GET", srv.URL+"/whoami", c.Secret, ""); resp.StatusCode != 200 {
+		t.Errorf("after refilling one: %d", resp.StatusCode)
+	}
+	if resp, _ := do(t, "GET", srv.URL+"/whoami", c.Secret, ""); resp.StatusCode != 429 {
+		t.Errorf("after using it: %d", resp.StatusCode)
+	}
+	// The bucket holds at most a minute's worth.
+	fc.Advance(time.Hour)
+	for i := range 4 {
+		resp, _ := do(t, "GET", srv.URL+"/whoami", c.Secret, "")
+		want := 200
+		if i == 3 {
+			want = 429
+		}
+		if resp.StatusCode != want {
+			t.Errorf("after an hour, request %d: %d, want %d", i, resp.StatusCode, want)
+		}
+	}
+}
+
+func TestAdmin(t *testing.T) {
+	_, srv := setup(t, Options{})
+
+	resp, body := do(t, "POST", srv.URL+"/api-keys", "", `{"name":"billing","rate_limit":120}`)
+	if resp.StatusCode != 201 || resp.Header.Get("Cache-Control") != "no-store" {
+		t.Fatalf("create: %d %v", resp.StatusCode, resp.Header)
+	}
+	var c created
+	json.Unmarshal([]byte(body), &c)
+	if c.Name != "billing" || c.RateLimit != 120 || !strings.HasPrefix(c.Secret, "uk_"+c.ID+"_") {
+		t.Errorf("created %s", body)
+	}
+	if got := resp.Header.Get("Location"); got != "/api-keys/"+c.ID {
+		t.Errorf("Location %q", got)
+	}
+
+	for _, tt := range []struct{ body, want string }{
+		{`{"name":" "}`, "name is required"},
+		{`{"name":"x","rate_limit":-1}`, "rate_limit must not be negative"},
+		{`{"name":`, "Invalid JSON"},
+	} {
+		if resp, body := do(t, "POST", srv.URL+"/api-keys", "", tt.body); resp.StatusCode != 400 || body != tt.want {
+			t.Errorf("create %s: %d %q", tt.body, resp.StatusCode, body)
+		}
+	}
+
+	// The list never shows the secret, or its hash.
+	resp, body = do(t, "GET", srv.URL+"/api-keys", "", "")
+	if resp.StatusCode != 200 || strings.Contains(body, c.Secret[len(c.Secret)-43:]) || strings.Contains(body, "hash") {
+		t.Fatalf("list: %d %s", resp.StatusCode, body)
+	}
+	var keys []Key
+	json.Unmarshal([]byte(body), &keys)
+	if len(keys) != 1 || keys[0].ID != c.ID {
+		t.Errorf("listed %s", body)
+	}
+
+	if resp, body := do(t, "DELETE", srv.URL+"/api-keys/0000000000000000", "", ""); resp.StatusCode != 404 {
+		t.Errorf("revoke unknown: %d %q", resp.StatusCode, body)
+	}
+	if resp, _ := do(t, "PUT", srv.URL+"/api-keys", "", ""); resp.StatusCode != 405 {
+		t.Errorf("PUT: %d", resp.StatusCode)
+	}
+}
+
+func TestRevokeStopsKeyAtOnce(t *testing.T) {
+	_, srv := setup(t, Options{})
+	c := create(t, srv, `{"name":"billing"}`)
+	if resp, _ := do(t, "GET", srv.URL+"/whoami", c.Secret, ""); resp.StatusCode != 200 {
+		t.Fatalf("before revoking: %d", resp.StatusCode)
+	}
+
+	if resp, _ := do(t, "DELETE", srv.URL+"/api-keys/"+c.ID, "", ""); resp.StatusCode != 204 {
+		t.Fatalf("revoke: %d", resp.StatusCode)
+	}
+	if resp, body := do(t, "GET", srv.URL+"/whoami", c.Secret, ""); resp.StatusCode != 401 || body != "Invalid API key" {
+		t.Errorf("after revoking: %d %q", resp.StatusCode, body)
+	}
+
+	_, body := do(t, "GET", srv.URL+"/api-keys", "", "")
+	var keys []Key
+	json.Unmarshal([]byte(body), &keys)
+	if len(keys) != 1 || !keys[0].Revoked() || keys[0].LastUsedAt.IsZero() {
+		t.Errorf("listed %s", body)
+	}
+}
+
+func TestOnError(t *testing.T) {
+	var got string
+	_, srv := setup(t, Options{OnError: func(w http.ResponseWriter, _ *http.Request, msg string, code int) {
+		got = msg
+		http.Error(w, "translated", code)
+	}})
+	resp, body := do(t, "GET", srv.URL+"/whoami", "", "")
+	if resp.StatusCode != 401 || body != "translated" || got != "Missing API key" {
+		t.Errorf("%d %q, OnError got %q", resp.StatusCode, body, got)
+	}
+}
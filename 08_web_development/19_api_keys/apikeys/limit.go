@@ -0,0 +1,51 @@
+package apikeys
+
+import (
+	"math"
+	"sync"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+// limiter is a token bucket per key. A key's bucket holds up to a minute's
+// worth of requests and refills at its rate, so a key may burst to its
+// full limit and then gets a steady trickle.
+type limiter struct {
+	clock clock.Clock
+
+	mu      sync.Mutex
+	buckets map[string]*bucket
+}
+
+type bucket struct {
+	tokens float64
+	at     time.Time // when tokens was last brought up to date
+}
+
+func newLimiter(c clock.Clock) *limiter {
+	return &limiter{clock: c, buckets: map[string]*bucket{}}
+}
+
+// allow takes a token from id's bucket, which refills at perMinute a
+// minute. It returns whether there was one, how many are left, and, when
+// there was none, how long until the next.
+func (l *limiter) allow(id string, perMinute int) (ok bool, remaining int, retryAfter time.Duration) {
+	now := l.clock.Now()
+	size := float64(perMinute)
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	b, found := l.buckets[id]
+	if !found {
+		b = &bucket{tokens: size, at: now}
+		l.buckets[id] = b
+	}
+	b.tokens = min(size, b.tokens+now.Sub(b.at).Minutes()*size)
+	b.at = now
+	if b.tokens < 1 {
+		wait := time.Duration((1 - b.tokens) / size * float64(time.Minute))
+		return false, 0, wait
+	}
+	b.tokens--
+	return true, int(math.Floor(b.tokens)), 0
+}
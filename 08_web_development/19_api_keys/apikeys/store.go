@@ -0,0 +1,258 @@
+// Package apikeys issues and checks API keys for an HTTP API. Keys are
+// stored hashed in SQLite, so a leaked database does not leak usable
+// keys; each key has its own rate limit, and the time it was last used is
+// recorded so stale keys can be found and revoked.
+//
+// A key looks like uk_3f9c2a7b1e0d4c5a_<43 characters>. The middle part is
+// the key's ID, stored in the clear to find the row; the last part is the
+// secret, of which only a SHA-256 hash is stored. The full key is shown
+// once, when it is created.
+package apikeys
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"database/sql"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+var (
+	// ErrInvalidKey covers malformed, unknown and wrong keys alike, so a
+	// caller learns nothing about which IDs exist.
+	ErrInvalidKey = errors.New("invalid API key")
+	ErrRevoked    = errors.New("API key revoked")
+	ErrNotFound   = errors.New("API key not found")
+)
+
+const (
+	prefix    = "uk_"
+	idLen     = 8  // random bytes in the ID
+	secretLen = 32 // random bytes in the secret
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS api_keys (
+	id           TEXT    PRIMARY KEY,
+	name         TEXT    NOT NULL,
+	hash         BLOB    NOT NULL, -- SHA-256 of the secret
+	rate_limit   INTEGER NOT NULL, -- requests per minute
+	created_at   INTEGER NOT NULL, -- unix ms
+	last_used_at INTEGER,
+	revoked_at   INTEGER
+);`
+
+// Options configures a Store. Zero values select the defaults.
+type Options struct {
+	// RateLimit is the requests per minute of keys created without one
+	// (default 60).
+	RateLimit int
+	// LastUsedEvery is how stale last_used_at may get before a request
+	// updates it (default a minute). Writing it on every request would
+	// turn every read into a database write.
+	LastUsedEvery time.Duration
+	// Clock says when keys are used and how fast rate limits refill
+	// (default clock.Real).
+	Clock clock.Clock
+	// OnError writes the error responses of Middleware and AdminHandler
+	// (default http.Error). The users API passes i18n.Error to translate
+	// them.
+	OnError func(w http.ResponseWriter, r *http.Request, msg string, code int)
+}
+
+// Key is an API key as stored, without its secret.
+type Key struct {
+	ID         string    `json:"id"`
+	Name       string    `json:"name"`
+	RateLimit  int       `json:"rate_limit"` // requests per minute
+	CreatedAt  time.Time `json:"created_at"`
+	LastUsedAt time.Time `json:"last_used_at,omitzero"`
+	RevokedAt  time.Time `json:"revoked_at,omitzero"`
+}
+
+// Revoked reports whether the key was revoked.
+func (k Key) Revoked() bool { return !k.RevokedAt.IsZero() }
+
+// Store is safe for concurrent use.
+type Store struct {
+	db      *sql.DB
+	opts    Options
+	limiter *limiter
+
+	mu       sync.Mutex
+	lastUsed map[string]time.Time // last write of last_used_at, by ID
+}
+
+// Open opens (creating if needed) the key database at path.
+func Open(path string, opts Options) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	if opts.RateLimit <= 0 {
+		opts.RateLimit = 60
+	}
+	if opts.LastUsedEvery <= 0 {
+		opts.LastUsedEvery = time.Minute
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real()
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(w http.ResponseWriter, _ *http.Request, msg string, code int) { http.Error(w, msg, code) }
+	}
+	return &Store{db: db, opts: opts, limiter: newLimiter(opts.Clock), lastUsed: map[string]time.Time{}}, nil
+}
+
+// Close closes the database.
+func (s *Store) Close() error { return s.db.Close() }
+
+// Create stores a new key named name, allowed rateLimit requests a
+// minute (0 for the default), and returns it with the full key. The full
+// key cannot be recovered later.
+func (s *Store) Create(ctx context.Context, name string, rateLimit int) (Key, string, error) {
+	if rateLimit <= 0 {
+		rateLimit = s.opts.RateLimit
+	}
+	id := hex.EncodeToString(randomBytes(idLen))
+	secret := base64.RawURLEncoding.EncodeToString(randomBytes(secretLen))
+	hash := sha256.Sum256([]byte(secret))
+	k := Key{ID: id, Name: name, RateLimit: rateLimit, CreatedAt: s.opts.Clock.Now().Truncate(time.Millisecond)}
+	_, err := s.db.ExecContext(ctx,
+		`INSERT INTO api_keys (id, name, hash, rate_limit, created_at) VALUES (?, ?, ?, ?, ?)`,
+		k.ID, k.Name, hash[:], k.RateLimit, k.CreatedAt.UnixMilli())
+	if err != nil {
+		return Key{}, "", err
+	}
+	return k, prefix + id + "_" + secret, nil
+}
+
+// List returns every key, revoked ones included, oldest first.
+func (s *Store) List(ctx context.Context) ([]Key, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, name, rate_limit, created_at, last_used_at, revoked_at FROM api_keys ORDER BY created_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Key
+	for rows.Next() {
+		k, err := scanKey(rows.Scan)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, k)
+	}
+	return out, rows.Err()
+}
+
+// Revoke stops the key with the given ID from working, at once. The row
+// stays, so the key still shows in List with the time it was revoked.
+// Revoking a revoked key does nothing.
+func (s *Store) Revoke(ctx context.Context, id string) error {
+	res, err := s.db.ExecContext(ctx,
+		`UPDATE api_keys SET revoked_at = coalesce(revoked_at, ?) WHERE id = ?`, s.opts.Clock.Now().UnixMilli(), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Authenticate returns the key for full, a key as sent by a client, and
+// records that it was used.
+func (s *Store) Authenticate(ctx context.Context, full string) (Key, error) {
+	rest, ok := strings.CutPrefix(full, prefix)
+	id, secret, ok2 := strings.Cut(rest, "_")
+	if !ok || !ok2 || len(id) != 2*idLen {
+		return Key{}, ErrInvalidKey
+	}
+	row := s.db.QueryRowContext(ctx,
+		`SELECT id, name, rate_limit, created_at, last_used_at, revoked_at, hash FROM api_keys WHERE id = ?`, id)
+	var hash []byte
+	k, err := scanKey(row.Scan, &hash)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Key{}, ErrInvalidKey
+	}
+	if err != nil {
+		return Key{}, err
+	}
+	// The secret has 256 random bits, so a fast hash is enough: there is
+	// no dictionary to try. Passwords need a slow one such as bcrypt. The
+	// comparison takes the same time wherever the hashes differ.
+	sum := sha256.Sum256([]byte(secret))
+	if subtle.ConstantTimeCompare(sum[:], hash) != 1 {
+		return Key{}, ErrInvalidKey
+	}
+	if k.Revoked() {
+		return Key{}, ErrRevoked
+	}
+	if err := s.touch(ctx, &k); err != nil {
+		return Key{}, err
+	}
+	return k, nil
+}
+
+// touch sets last_used_at, at most once per LastUsedEvery for each key.
+func (s *Store) touch(ctx context.Context, k *Key) error {
+	now := s.opts.Clock.Now()
+	s.mu.Lock()
+	last, ok := s.lastUsed[k.ID]
+	if !ok {
+		last = k.LastUsedAt
+	}
+	due := now.Sub(last) >= s.opts.LastUsedEvery
+	if due {
+		s.lastUsed[k.ID] = now
+	}
+	s.mu.Unlock()
+	if !due {
+		return nil
+	}
+	k.LastUsedAt = now.Truncate(time.Millisecond)
+	_, err := s.db.ExecContext(ctx, `UPDATE api_keys SET last_used_at = ? WHERE id = ?`, now.UnixMilli(), k.ID)
+	return err
+}
+
+// scanKey scans the key columns, in the order of List's query, then
+// extra.
+func scanKey(scan func(...any) error, extra ...any) (Key, error) {
+	var k Key
+	var created int64
+	var lastUsed, revoked sql.NullInt64
+	if err := scan(append([]any{&k.ID, &k.Name, &k.RateLimit, &created, &lastUsed, &revoked}, extra...)...); err != nil {
+		return Key{}, err
+	}
+	k.CreatedAt = time.UnixMilli(created)
+	if lastUsed.Valid {
+		k.LastUsedAt = time.UnixMilli(lastUsed.Int64)
+	}
+	if revoked.Valid {
+		k.RevokedAt = time.UnixMilli(revoked.Int64)
+	}
+	return k, nil
+}
+
+func randomBytes(n int) []byte {
+	b := make([]byte, n)
+	rand.Read(b)
+	return b
+}
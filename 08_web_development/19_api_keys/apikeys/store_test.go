@@ -0,0 +1,164 @@
+package apikeys
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+var t0 = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestStore(t *testing.T, opts Options) *Store {
+	t.Helper()
+	s, err := Open(filepath.Join(t.TempDir(), "keys.db"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func TestCreateAuthenticate(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t, Options{Clock: clock.NewFake(t0)})
+	k, full, err := s.Create(ctx, "billing", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if k.RateLimit != 60 || !k.CreatedAt.Equal(t0) {
+		t.Errorf("created %+v", k)
+	}
+	if !strings.HasPrefix(full, "uk_"+k.ID+"_") || len(full) != len("uk_")+16+1+43 {
+		t.Errorf("full key %q", full)
+	}
+
+	got, err := s.Authenticate(ctx, full)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.ID != k.ID || got.Name != "billing" || !got.LastUsedAt.Equal(t0) {
+		t.Errorf("authenticated %+v", got)
+	}
+}
+
+func TestAuthenticateRejects(t *testing.T) {
+	ctx := context.Background()
+	s := newTestStore(t, Options{})
+	k, full, err := s.Create(ctx, "billing", 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, other, _ := s.Create(ctx, "reports", 0)
+	otherSecret := other[len("uk_")+16+1:]
+
+	for name, key := range map[string]string{
+		"empty":             "",
+		"no prefix":         strings.TrimPrefix(full, "uk_"),
+		"short ID":          "uk_abc_" + otherSecret,
+		"unknown ID":        "uk_0000000000000000_" + otherSecret,
+		"another's secret":  "uk_" + k.ID + "_" + otherSecret,
+		"truncated secret":  full[:len(full)-1],
+		"no secret":         "uk_" + k.ID,
+		"secret with extra": full + "x",
+	} {
+		if _, err := s.Authenticate(ctx, key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("%s: %v, want ErrInvalidKey", name, err)
+		}
+	}
+}
+
+func TestRevoke(t *testing.T) {
+	ctx := context.Background()
+	fc := clock.NewFake(t0)
+	s := newTestStore(t, Options{Clock: fc})
+	k, full, _ := s.Create(ctx, "billing", 0)
+
+	fc.Advance(time.Hour)
+	if err := s.Revoke(ctx, k.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.Authenticate(ctx, full); !errors.Is(err, ErrRevoked) {
+		t.Errorf("revoked key: %v", err)
+	}
+	// Revoking again keeps the first time.
+	fc.Advance(time.Hour)
+	if err := s.Revoke(ctx, k.ID); err != nil {
+		t.Fatal(err)
+	}
+	keys, _ := s.List(ctx)
+	if len(keys) != 1 || !keys[0].RevokedAt.Equal(t0.Add(time.Hour)) {
+		t.Errorf("listed %+v", keys)
+	}
+	if err := s.Revoke(ctx, "0000000000000000"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("unknown ID: %v", err)
+	}
+}
+
+func TestLastUsedThrottled(t *testing.T) {
+	ctx := context.Background()
+	fc := clock.NewFake(t0)
+	path := filepath.Join(t.TempDir(), "keys.db")
+	s, err := Open(path, Options{Clock: fc, LastUsedEvery: time.Minute})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	k, full, _ := s.Create(ctx, "billing", 0)
+
+	lastUsed := func() time.Time {
+		t.Helper()
+		keys, err := s.List(ctx)
+		if err != nil || len(keys) != 1 {
+			t.Fatal(keys, err)
+		}
+		return keys[0].LastUsedAt
+	}
+	if !lastUsed().IsZero() {
+		t.Error("a new key has been used")
+	}
+
+	s.Authenticate(ctx, full)
+	fc.Advance(30 * time.Second)
+	s.Authenticate(ctx, full) // too soon to write again
+	if got := lastUsed(); !got.Equal(t0) {
+		t.Errorf("last used %v, want %v", got, t0)
+	}
+	fc.Advance(30 * time.Second)
+	s.Authenticate(ctx, full)
+	if got := lastUsed(); !got.Equal(t0.Add(time.Minute)) {
+		t.Errorf("last used %v, want %v", got, t0.Add(time.Minute))
+	}
+
+	// A new Store, as after a restart, goes by the stored time.
+	s2, err := Open(path, Options{Clock: fc})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s2.Close()
+	fc.Advance(10 * time.Second)
+	if got, _ := s2.Authenticate(ctx, full); got.ID != k.ID || !got.LastUsedAt.Equal(t0.Add(time.Minute)) {
+		t.Errorf("after reopening: %+v", got)
+	}
+}
+
+func TestList(t *testing.T) {
+	ctx := context.Background()
+	fc := clock.NewFake(t0)
+	s := newTestStore(t, Options{Clock: fc})
+	s.Create(ctx, "first", 10)
+	fc.Advance(time.Second)
+	s.Create(ctx, "second", 0)
+
+	keys, err := s.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(keys) != 2 || keys[0].Name != "first" || keys[0].RateLimit != 10 || keys[1].Name != "second" {
+		t.Errorf("listed %+v", keys)
+	}
+}
@@ -0,0 +1,147 @@
+// Demonstrates API keys for an HTTP API.
+//
+// This example shows:
+// - Issuing keys through admin endpoints, with the full key shown once
+// - Storing only a SHA-256 hash of each key's secret, in SQLite
+// - An X-API-Key middleware with constant-time comparison
+// - Per-key rate limits with Retry-After, and last-used tracking
+// - Revoking a key, which stops it at once
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang_roadmap/08_web_development/19_api_keys/apikeys"
+)
+
+// requireAdmin lets through requests with the admin token as a bearer
+// token. Key management must not be reachable with an API key.
+func requireAdmin(token string, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(got), []byte(token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func routes(keys *apikeys.Store, adminToken string) http.Handler {
+	mux := http.NewServeMux()
+	admin := requireAdmin(adminToken, keys.AdminHandler())
+	mux.Handle("/api-keys", admin)
+	mux.Handle("/api-keys/", admin)
+	mux.Handle("GET /reports", keys.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		k, _ := apikeys.FromContext(r.Context())
+		fmt.Fprintf(w, "Reports for %s\n", k.Name)
+	})))
+	return mux
+}
+
+// client calls the demo server.
+type client struct{ base string }
+
+// call makes a request and prints its status, the headers that matter
+// here, and the body.
+func (c client) call(method, path, header, value, body string) string {
+	req, err := http.NewRequest(method, c.base+path, strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if header != "" {
+		req.Header.Set(header, value)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	fmt.Printf("   %s %s", method, path)
+	if header != "" {
+		fmt.Printf(" with %s", header)
+	}
+	fmt.Printf(" -> %d", resp.StatusCode)
+	for _, h := range []string{"X-RateLimit-Remaining", "Retry-After"} {
+		if v := resp.Header.Get(h); v != "" {
+			fmt.Printf(" %s: %s", h, v)
+		}
+	}
+	if s := strings.TrimSpace(string(b)); s != "" {
+		fmt.Printf("\n      %s", s)
+	}
+	fmt.Println()
+	return string(b)
+}
+
+func main() {
+	addr := flag.String("serve", "", "after the demo, serve the API on this address, such as :8080")
+	flag.Parse()
+	log.SetFlags(0)
+
+	fmt.Println("API key examples starting...")
+	dir, err := os.MkdirTemp("", "apikeys")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	keys, err := apikeys.Open(filepath.Join(dir, "keys.db"), apikeys.Options{})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer keys.Close()
+	const adminToken = "admin-secret"
+	srv := httptest.NewServer(routes(keys, adminToken))
+	defer srv.Close()
+	auth := "Bearer " + adminToken
+	c := client{srv.URL}
+
+	fmt.Println("\n1) Issuing a key")
+	c.call("POST", "/api-keys", "Authorization", "Bearer wrong", `{"name":"billing"}`)
+	created := c.call("POST", "/api-keys", "Authorization", auth, `{"name":"billing","rate_limit":3}`)
+	var issued struct{ ID, Key string }
+	if err := json.Unmarshal([]byte(created), &issued); err != nil {
+		log.Fatal(err)
+	}
+	full := issued.Key
+	fmt.Println("   the full key is in this response only; the database has a hash of its secret")
+
+	fmt.Println("\n2) Using it")
+	c.call("GET", "/reports", "", "", "")
+	c.call("GET", "/reports", apikeys.Header, full[:len(full)-4]+"AAAA", "")
+	c.call("GET", "/reports", apikeys.Header, full, "")
+
+	fmt.Println("\n3) Its rate limit, 3 a minute")
+	for range 3 {
+		c.call("GET", "/reports", apikeys.Header, full, "")
+	}
+
+	fmt.Println("\n4) Revoking it")
+	c.call("DELETE", "/api-keys/"+issued.ID, "Authorization", auth, "")
+	c.call("GET", "/reports", apikeys.Header, full, "")
+	c.call("GET", "/api-keys", "Authorization", auth, "")
+
+	if *addr != "" {
+		live, err := apikeys.Open("apikeys.db", apikeys.Options{})
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer live.Close()
+		log.Printf("serving on %s with keys in apikeys.db; the admin token is %q", *addr, adminToken)
+		srv := &http.Server{Addr: *addr, Handler: routes(live, adminToken), ReadHeaderTimeout: 5 * time.Second}
+		log.Fatal(srv.ListenAndServe())
+	}
+}
@@ -0,0 +1,119 @@
+// Demonstrates scoping an API's requests and data to tenants.
+//
+// This example shows:
+// - Finding the tenant from the subdomain, or from a header
+// - Carrying it in the request context under an unexported key type
+// - A repository whose every query gets tenant_id = ? added for it
+// - Another tenant's records answering 404, even with their IDs known
+// - Failing closed when a context has no tenant
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"golang_roadmap/08_web_development/20_multi_tenant/notes"
+	"golang_roadmap/08_web_development/20_multi_tenant/tenant"
+)
+
+var resolver = tenant.Resolver{
+	Domain: "localhost",
+	Header: "X-Tenant-ID",
+	Known:  func(id tenant.ID) bool { return id == "acme" || id == "globex" },
+}
+
+// call sends a request to srv as if to host, and prints the result.
+func call(srv *httptest.Server, host, method, path, body string) {
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	req.Host = host
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	fmt.Printf("   %-6s %s%s -> %d %s\n", method, host, path, resp.StatusCode, strings.TrimSpace(string(b)))
+}
+
+func main() {
+	addr := flag.String("serve", "", "after the demo, serve the notes API on this address, such as :8080")
+	flag.Parse()
+	log.SetFlags(0)
+
+	fmt.Println("Multi-tenant examples starting...")
+	dir, err := os.MkdirTemp("", "tenants")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	repo, err := notes.Open(filepath.Join(dir, "notes.db"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer repo.Close()
+	srv := httptest.NewServer(resolver.Middleware(notes.Handler(repo)))
+	defer srv.Close()
+
+	// 1) Where the tenant comes from.
+	fmt.Println("\n1) Resolving tenants")
+	for _, c := range []struct{ host, header string }{
+		{"acme.localhost:8080", ""},
+		{"localhost:8080", "globex"},
+		{"acme.localhost:8080", "globex"},
+		{"localhost:8080", ""},
+		{"initech.localhost:8080", ""},
+		{"x.acme.localhost:8080", ""},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Host = c.host
+		if c.header != "" {
+			r.Header.Set("X-Tenant-ID", c.header)
+		}
+		id, err := resolver.Resolve(r)
+		fmt.Printf("   host %-23s header %-8q -> %q %v\n", c.host, c.header, id, err)
+	}
+
+	// 2) One table, two tenants, each seeing only its own rows.
+	fmt.Println("\n2) Each tenant's notes")
+	call(srv, "acme.localhost", "POST", "/notes", `{"title":"Merger","body":"Buying globex"}`)
+	call(srv, "globex.localhost", "POST", "/notes", `{"title":"Picnic","body":"Friday"}`)
+	call(srv, "acme.localhost", "GET", "/notes", "")
+	call(srv, "globex.localhost", "GET", "/notes", "")
+
+	// 3) IDs are shared, so globex can guess acme's. The added condition
+	// makes the note invisible, not forbidden: 404, as if it did not exist.
+	fmt.Println("\n3) Globex tries acme's note")
+	call(srv, "globex.localhost", "GET", "/notes/1", "")
+	call(srv, "globex.localhost", "PUT", "/notes/1", `{"title":"Merger","body":"Cancelled"}`)
+	call(srv, "globex.localhost", "DELETE", "/notes/1", "")
+	call(srv, "acme.localhost", "GET", "/notes/1", "")
+
+	// 4) Code that lost the context, such as a background job started with
+	// context.Background, gets an error rather than every tenant's rows.
+	fmt.Println("\n4) No tenant in the context")
+	_, err = repo.List(context.Background())
+	fmt.Printf("   List(context.Background()): %v\n", err)
+
+	if *addr != "" {
+		live, err := notes.Open("notes.db")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer live.Close()
+		log.Printf("serving on %s: try curl http://acme.localhost%s/notes", *addr, *addr)
+		srv := &http.Server{Addr: *addr, Handler: resolver.Middleware(notes.Handler(live)), ReadHeaderTimeout: 5 * time.Second}
+		log.Fatal(srv.ListenAndServe())
+	}
+}
@@ -0,0 +1,132 @@
+package notes
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+)
+
+// Handler serves the notes API:
+//
+//	GET    /notes       the tenant's notes
+//	POST   /notes       {"title": "...", "body": "..."} -> 201
+//	GET    /notes/{id}
+//	PUT    /notes/{id}  {"title": "...", "body": "..."}
+//	DELETE /notes/{id}  -> 204
+//
+// It takes the tenant from the request's context, so it must sit behind
+// tenant.Resolver's Middleware. Another tenant's note is a 404, like a
+// missing one.
+func Handler(repo *Repo) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /notes", func(w http.ResponseWriter, r *http.Request) {
+		list, err := repo.List(r.Context())
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		if list == nil {
+			list = []Note{}
+		}
+		writeJSON(w, http.StatusOK, list)
+	})
+	mux.HandleFunc("POST /notes", func(w http.ResponseWriter, r *http.Request) {
+		var in Note
+		if !decode(w, r, &in) {
+			return
+		}
+		n, err := repo.Create(r.Context(), in.Title, in.Body)
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, n)
+	})
+	mux.HandleFunc("GET /notes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := pathID(w, r)
+		if !ok {
+			return
+		}
+		n, err := repo.Get(r.Context(), id)
+		if respondErr(w, err) {
+			return
+		}
+		writeJSON(w, http.StatusOK, n)
+	})
+	mux.HandleFunc("PUT /notes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := pathID(w, r)
+		if !ok {
+			return
+		}
+		var n Note
+		if !decode(w, r, &n) {
+			return
+		}
+		n.ID = id
+		if respondErr(w, repo.Update(r.Context(), n)) {
+			return
+		}
+		writeJSON(w, http.StatusOK, n)
+	})
+	mux.HandleFunc("DELETE /notes/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, ok := pathID(w, r)
+		if !ok {
+			return
+		}
+		if respondErr(w, repo.Delete(r.Context(), id)) {
+			return
+		}
+		w.WriteHeader(http.StatusNoContent)
+	})
+	return mux
+}
+
+func pathID(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		http.Error(w, "Invalid note ID", http.StatusBadRequest)
+		return 0, false
+	}
+	return id, true
+}
+
+func decode(w http.ResponseWriter, r *http.Request, n *Note) bool {
+	if err := json.NewDecoder(r.Body).Decode(n); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return false
+	}
+	if n.Title == "" {
+		http.Error(w, "title is required", http.StatusBadRequest)
+		return false
+	}
+	return true
+}
+
+// respondErr writes the response for a non-nil err and reports whether
+// it did.
+func respondErr(w http.ResponseWriter, err error) bool {
+	switch {
+	case err == nil:
+		return false
+	case errors.Is(err, ErrNotFound):
+		http.Error(w, "Note not found", http.StatusNotFound)
+	default:
+		serverError(w, err)
+	}
+	return true
+}
+
+func serverError(w http.ResponseWriter, err error) {
+	log.Printf("notes: %v", err)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("notes: encoding response: %v", err)
+	}
+}
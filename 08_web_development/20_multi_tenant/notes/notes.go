@@ -0,0 +1,122 @@
+// Package notes is a repository of notes shared by many tenants in one
+// SQLite table. Every method reads the tenant from its context, through
+// tenant.Table, so no method can see or change another tenant's notes.
+package notes
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+
+	"golang_roadmap/08_web_development/20_multi_tenant/tenant"
+)
+
+// ErrNotFound is returned for notes that do not exist and for notes of
+// other tenants alike, so IDs reveal nothing across tenants.
+var ErrNotFound = errors.New("note not found")
+
+// IDs are shared by all tenants, so one tenant can guess another's. Only
+// the tenant condition keeps them apart, which is what the tests check.
+const schema = `
+CREATE TABLE IF NOT EXISTS notes (
+	id        INTEGER PRIMARY KEY AUTOINCREMENT,
+	tenant_id TEXT    NOT NULL,
+	title     TEXT    NOT NULL,
+	body      TEXT    NOT NULL
+);
+CREATE INDEX IF NOT EXISTS notes_tenant ON notes (tenant_id, id);`
+
+type Note struct {
+	ID    int64  `json:"id"`
+	Title string `json:"title"`
+	Body  string `json:"body"`
+}
+
+// Repo stores notes.
+type Repo struct {
+	db    *sql.DB
+	notes *tenant.Table
+}
+
+// Open opens (creating if needed) the notes database at path.
+func Open(path string) (*Repo, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &Repo{db: db, notes: tenant.NewTable(db, "notes")}, nil
+}
+
+// Close closes the database.
+func (r *Repo) Close() error { return r.db.Close() }
+
+// Create stores a note for the tenant in ctx.
+func (r *Repo) Create(ctx context.Context, title, body string) (Note, error) {
+	res, err := r.notes.Insert(ctx, "title, body", title, body)
+	if err != nil {
+		return Note{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Note{}, err
+	}
+	return Note{ID: id, Title: title, Body: body}, nil
+}
+
+// Get returns the tenant's note with the given ID.
+func (r *Repo) Get(ctx context.Context, id int64) (Note, error) {
+	n := Note{ID: id}
+	err := r.notes.QueryRow(ctx, "title, body", "id = ?", id).Scan(&n.Title, &n.Body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Note{}, ErrNotFound
+	}
+	return n, err
+}
+
+// List returns the tenant's notes, oldest first.
+func (r *Repo) List(ctx context.Context) ([]Note, error) {
+	rows, err := r.notes.Query(ctx, "id, title, body", "", "id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Note
+	for rows.Next() {
+		var n Note
+		if err := rows.Scan(&n.ID, &n.Title, &n.Body); err != nil {
+			return nil, err
+		}
+		out = append(out, n)
+	}
+	return out, rows.Err()
+}
+
+// Update replaces the title and body of the tenant's note.
+func (r *Repo) Update(ctx context.Context, n Note) error {
+	res, err := r.notes.Update(ctx, "title = ?, body = ?", "id = ?", n.Title, n.Body, n.ID)
+	return affected(res, err)
+}
+
+// Delete deletes the tenant's note.
+func (r *Repo) Delete(ctx context.Context, id int64) error {
+	res, err := r.notes.Delete(ctx, "id = ?", id)
+	return affected(res, err)
+}
+
+// affected turns a statement that changed no rows into ErrNotFound.
+func affected(res sql.Result, err error) error {
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,155 @@
+package notes
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"golang_roadmap/08_web_development/20_multi_tenant/tenant"
+)
+
+var (
+	acme   = tenant.WithID(context.Background(), "acme")
+	globex = tenant.WithID(context.Background(), "globex")
+)
+
+func newTestRepo(t *testing.T) *Repo {
+	t.Helper()
+	r, err := Open(filepath.Join(t.TempDir(), "notes.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestRepo(t *testing.T) {
+	r := newTestRepo(t)
+	n, err := r.Create(acme, "Q3 plan", "Ship it")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, err := r.Get(acme, n.ID); err != nil || got != n {
+		t.Errorf("Get = %+v, %v", got, err)
+	}
+	n.Body = "Ship it twice"
+	if err := r.Update(acme, n); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := r.Get(acme, n.ID); got.Body != n.Body {
+		t.Errorf("after Update: %+v", got)
+	}
+	if err := r.Delete(acme, n.ID); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Get(acme, n.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("after Delete: %v", err)
+	}
+}
+
+// TestCrossTenant has globex try every operation on acme's note, whose
+// ID it knows. Each must act as if the note did not exist, and leave it
+// unchanged.
+func TestCrossTenant(t *testing.T) {
+	r := newTestRepo(t)
+	secret, _ := r.Create(acme, "Merger", "Buying globex")
+	mine, _ := r.Create(globex, "Picnic", "Friday")
+
+	if _, err := r.Get(globex, secret.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get: %v", err)
+	}
+	if list, _ := r.List(globex); len(list) != 1 || list[0] != mine {
+		t.Errorf("List: %+v", list)
+	}
+	if err := r.Update(globex, Note{ID: secret.ID, Title: "Merger", Body: "Cancelled"}); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Update: %v", err)
+	}
+	if err := r.Delete(globex, secret.ID); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Delete: %v", err)
+	}
+	if got, err := r.Get(acme, secret.ID); err != nil || got != secret {
+		t.Errorf("acme's note afterwards: %+v, %v", got, err)
+	}
+}
+
+func TestNoTenant(t *testing.T) {
+	r := newTestRepo(t)
+	r.Create(acme, "Merger", "Buying globex")
+	ctx := context.Background()
+	if _, err := r.Create(ctx, "x", "y"); !errors.Is(err, tenant.ErrNoTenant) {
+		t.Errorf("Create: %v", err)
+	}
+	if list, err := r.List(ctx); !errors.Is(err, tenant.ErrNoTenant) || list != nil {
+		t.Errorf("List: %v, %v", list, err)
+	}
+	if _, err := r.Get(ctx, 1); !errors.Is(err, tenant.ErrNoTenant) {
+		t.Errorf("Get: %v", err)
+	}
+	if err := r.Delete(ctx, 1); !errors.Is(err, tenant.ErrNoTenant) {
+		t.Errorf("Delete: %v", err)
+	}
+}
+
+// TestHTTP runs the same attack through the API, with the tenant taken
+// from the subdomain or the header.
+func TestHTTP(t *testing.T) {
+	r := newTestRepo(t)
+	res := tenant.Resolver{
+		Domain: "example.com",
+		Header: "X-Tenant-ID",
+		Known:  func(id tenant.ID) bool { return id == "acme" || id == "globex" },
+	}
+	h := res.Middleware(Handler(r))
+
+	do := func(host, header, method, path, body string) (int, string) {
+		t.Helper()
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Host = host
+		if header != "" {
+			req.Header.Set("X-Tenant-ID", header)
+		}
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, req)
+		b, _ := io.ReadAll(w.Body)
+		return w.Code, strings.TrimSpace(string(b))
+	}
+
+	code, body := do("acme.example.com", "", "POST", "/notes", `{"title":"Merger","body":"Buying globex"}`)
+	if code != http.StatusCreated || !strings.HasPrefix(body, `{"id":1,`) {
+		t.Fatalf("create: %d %s", code, body)
+	}
+	if code, body := do("acme.example.com", "", "GET", "/notes/1", ""); code != 200 || !strings.Contains(body, "Buying globex") {
+		t.Errorf("acme reads its note: %d %s", code, body)
+	}
+
+	for _, tt := range []struct{ host, header, method, path, body string }{
+		{"globex.example.com", "", "GET", "/notes/1", ""},
+		{"globex.example.com", "", "PUT", "/notes/1", `{"title":"Merger","body":"Cancelled"}`},
+		{"globex.example.com", "", "DELETE", "/notes/1", ""},
+		{"api.example.net", "globex", "GET", "/notes/1", ""},
+	} {
+		if code, body := do(tt.host, tt.header, tt.method, tt.path, tt.body); code != http.StatusNotFound {
+			t.Errorf("globex %s %s via %s%s: %d %s", tt.method, tt.path, tt.host, tt.header, code, body)
+		}
+	}
+	if code, body := do("globex.example.com", "", "GET", "/notes", ""); code != 200 || body != "[]" {
+		t.Errorf("globex lists: %d %s", code, body)
+	}
+	// The header cannot override the subdomain.
+	if code, _ := do("globex.example.com", "acme", "GET", "/notes/1", ""); code != http.StatusBadRequest {
+		t.Errorf("conflicting header: %d", code)
+	}
+	if code, _ := do("example.com", "", "GET", "/notes/1", ""); code != http.StatusBadRequest {
+		t.Errorf("no tenant: %d", code)
+	}
+
+	n, _ := r.Get(acme, 1)
+	if n.Body != "Buying globex" {
+		t.Errorf("acme's note afterwards: %+v", n)
+	}
+}
@@ -0,0 +1,114 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+)
+
+// Table runs statements on one table whose rows carry a tenant_id column,
+// and adds tenant_id = ? with the tenant from the context to every one of
+// them. Repository code never writes the tenant condition, so it cannot
+// forget it or pass another tenant's ID; a context without a tenant gets
+// ErrNoTenant, not every tenant's rows.
+//
+// The table, column and condition strings are pasted into the SQL. They
+// must be constants in code, never input; values go in args, as ?.
+type Table struct {
+	db   *sql.DB
+	name string
+}
+
+// NewTable returns the Table called name in db.
+func NewTable(db *sql.DB, name string) *Table {
+	return &Table{db: db, name: name}
+}
+
+// scope returns "(where) AND tenant_id = ?" and args with the tenant
+// appended. The parentheses keep an OR in where from escaping the tenant
+// condition.
+func scope(ctx context.Context, where string, args []any) (string, []any, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return "", nil, ErrNoTenant
+	}
+	if where == "" {
+		where = "1"
+	}
+	return "(" + where + ") AND tenant_id = ?", append(args[:len(args):len(args)], string(id)), nil
+}
+
+// Query runs SELECT columns FROM the table WHERE where, for the tenant,
+// ordered by orderBy if it is not empty.
+func (t *Table) Query(ctx context.Context, columns, where, orderBy string, args ...any) (*sql.Rows, error) {
+	cond, args, err := scope(ctx, where, args)
+	if err != nil {
+		return nil, err
+	}
+	q := "SELECT " + columns + " FROM " + t.name + " WHERE " + cond
+	if orderBy != "" {
+		q += " ORDER BY " + orderBy
+	}
+	return t.db.QueryContext(ctx, q, args...)
+}
+
+// QueryRow is Query for at most one row. Its error, ErrNoTenant included,
+// is returned by Scan.
+func (t *Table) QueryRow(ctx context.Context, columns, where string, args ...any) *Row {
+	cond, args, err := scope(ctx, where, args)
+	if err != nil {
+		return &Row{err: err}
+	}
+	return &Row{row: t.db.QueryRowContext(ctx, "SELECT "+columns+" FROM "+t.name+" WHERE "+cond, args...)}
+}
+
+// Row is the result of QueryRow.
+type Row struct {
+	row *sql.Row
+	err error
+}
+
+// Scan copies the row's columns into dest, as sql.Row.Scan does.
+func (r *Row) Scan(dest ...any) error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.row.Scan(dest...)
+}
+
+// Insert adds a row with values for columns, a comma-separated list, and
+// the tenant.
+func (t *Table) Insert(ctx context.Context, columns string, values ...any) (sql.Result, error) {
+	id, ok := FromContext(ctx)
+	if !ok {
+		return nil, ErrNoTenant
+	}
+	marks := strings.Repeat("?, ", len(values))
+	return t.db.ExecContext(ctx,
+		"INSERT INTO "+t.name+" ("+columns+", tenant_id) VALUES ("+marks+"?)",
+		append(values[:len(values):len(values)], string(id))...)
+}
+
+// Update runs UPDATE the table SET set WHERE where, for the tenant. args
+// fill the ? in set, then those in where. set may not assign tenant_id,
+// which would hand rows to another tenant.
+func (t *Table) Update(ctx context.Context, set, where string, args ...any) (sql.Result, error) {
+	if strings.Contains(set, "tenant_id") {
+		return nil, errors.New("tenant: Update may not set tenant_id")
+	}
+	cond, args, err := scope(ctx, where, args)
+	if err != nil {
+		return nil, err
+	}
+	return t.db.ExecContext(ctx, "UPDATE "+t.name+" SET "+set+" WHERE "+cond, args...)
+}
+
+// Delete runs DELETE FROM the table WHERE where, for the tenant.
+func (t *Table) Delete(ctx context.Context, where string, args ...any) (sql.Result, error) {
+	cond, args, err := scope(ctx, where, args)
+	if err != nil {
+		return nil, err
+	}
+	return t.db.ExecContext(ctx, "DELETE FROM "+t.name+" WHERE "+cond, args...)
+}
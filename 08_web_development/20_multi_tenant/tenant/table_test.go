@@ -0,0 +1,103 @@
+package tenant
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	_ "modernc.org/sqlite"
+)
+
+func newTestTable(t *testing.T) *Table {
+	t.Helper()
+	db, err := sql.Open("sqlite", filepath.Join(t.TempDir(), "t.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	if _, err := db.Exec(`CREATE TABLE items (id INTEGER PRIMARY KEY, tenant_id TEXT NOT NULL, name TEXT NOT NULL)`); err != nil {
+		t.Fatal(err)
+	}
+	return NewTable(db, "items")
+}
+
+func names(t *testing.T, tb *Table, ctx context.Context, where string, args ...any) []string {
+	t.Helper()
+	rows, err := tb.Query(ctx, "name", where, "id", args...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var n string
+		rows.Scan(&n)
+		out = append(out, n)
+	}
+	return out
+}
+
+func TestTableScopes(t *testing.T) {
+	tb := newTestTable(t)
+	acme := WithID(context.Background(), "acme")
+	globex := WithID(context.Background(), "globex")
+	tb.Insert(acme, "name", "anvil")
+	tb.Insert(globex, "name", "gizmo")
+	tb.Insert(acme, "name", "rocket")
+
+	if got := names(t, tb, acme, ""); len(got) != 2 || got[0] != "anvil" || got[1] != "rocket" {
+		t.Errorf("acme sees %v", got)
+	}
+	// An OR in the condition stays inside its parentheses.
+	if got := names(t, tb, globex, "name = ? OR 1 = 1", "anvil"); len(got) != 1 || got[0] != "gizmo" {
+		t.Errorf("globex with OR sees %v", got)
+	}
+
+	var name string
+	if err := tb.QueryRow(globex, "name", "id = ?", 1).Scan(&name); !errors.Is(err, sql.ErrNoRows) {
+		t.Errorf("globex read acme's row: %q, %v", name, err)
+	}
+	if res, _ := tb.Update(globex, "name = ?", "id = ?", "stolen", 1); rowsAffected(res) != 0 {
+		t.Error("globex updated acme's row")
+	}
+	if res, _ := tb.Delete(globex, "1"); rowsAffected(res) != 1 {
+		t.Error("globex's delete-all did not delete exactly its one row")
+	}
+	if got := names(t, tb, acme, ""); len(got) != 2 {
+		t.Errorf("acme's rows after globex's delete-all: %v", got)
+	}
+	if _, err := tb.Update(acme, "tenant_id = ?", "id = ?", "globex", 1); err == nil {
+		t.Error("Update moved a row to another tenant")
+	}
+}
+
+func TestTableWithoutTenant(t *testing.T) {
+	tb := newTestTable(t)
+	ctx := context.Background()
+	if _, err := tb.Insert(ctx, "name", "anvil"); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Insert: %v", err)
+	}
+	if _, err := tb.Query(ctx, "name", "", ""); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Query: %v", err)
+	}
+	var n string
+	if err := tb.QueryRow(ctx, "name", "id = ?", 1).Scan(&n); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("QueryRow: %v", err)
+	}
+	if _, err := tb.Update(ctx, "name = ?", "", "x"); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Update: %v", err)
+	}
+	if _, err := tb.Delete(ctx, ""); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("Delete: %v", err)
+	}
+}
+
+func rowsAffected(res sql.Result) int64 {
+	if res == nil {
+		return -1
+	}
+	n, _ := res.RowsAffected()
+	return n
+}
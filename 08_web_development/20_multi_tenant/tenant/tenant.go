@@ -0,0 +1,130 @@
+// Package tenant scopes requests to a tenant: Resolver finds the tenant
+// of a request, from its subdomain or a header, and puts it in the
+// request's context; Table runs SQL that only sees that tenant's rows.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"strings"
+)
+
+var (
+	// ErrNoTenant means a request or context has no tenant. Code that
+	// needs one fails rather than falling back to all tenants.
+	ErrNoTenant = errors.New("no tenant")
+	// ErrInvalid is returned when the request names a tenant with a
+	// malformed ID.
+	ErrInvalid = errors.New("invalid tenant ID")
+	// ErrUnknown is returned when the ID is valid but Resolver.Known
+	// does not know it.
+	ErrUnknown = errors.New("unknown tenant")
+	// ErrConflict means the subdomain and the header name different
+	// tenants.
+	ErrConflict = errors.New("subdomain and header name different tenants")
+)
+
+// ID identifies a tenant. Valid IDs are DNS labels: 1 to 63 lower-case
+// letters, digits and hyphens, not starting or ending with a hyphen.
+type ID string
+
+// Valid reports whether id is a well-formed tenant ID.
+func (id ID) Valid() bool {
+	if len(id) == 0 || len(id) > 63 || id[0] == '-' || id[len(id)-1] == '-' {
+		return false
+	}
+	for _, c := range []byte(id) {
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// contextKey is unexported, so only this package can set the tenant: no
+// other package can build a key that collides with it.
+type contextKey struct{}
+
+// WithID returns a copy of ctx carrying id.
+func WithID(ctx context.Context, id ID) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the tenant in ctx.
+func FromContext(ctx context.Context) (ID, bool) {
+	id, ok := ctx.Value(contextKey{}).(ID)
+	return id, ok && id != ""
+}
+
+// Resolver finds the tenant of a request.
+type Resolver struct {
+	// Domain is the parent of the tenant subdomains: with "example.com",
+	// a request to acme.example.com is for tenant acme.
+	Domain string
+	// Header, if set, names a request header that may carry the tenant,
+	// for API clients that call one shared host. Anyone can set a header,
+	// so the handlers must still check that the caller belongs to the
+	// tenant.
+	Header string
+	// Known reports whether a tenant exists. Nil accepts every valid ID.
+	Known func(ID) bool
+}
+
+// Resolve returns the tenant of r. If both the subdomain and the header
+// name one, they must agree.
+func (res Resolver) Resolve(r *http.Request) (ID, error) {
+	var fromHost, fromHeader ID
+	if res.Domain != "" {
+		host := r.Host
+		if h, _, err := net.SplitHostPort(host); err == nil {
+			host = h
+		}
+		host = strings.TrimSuffix(strings.ToLower(host), ".")
+		if sub, ok := strings.CutSuffix(host, "."+strings.ToLower(res.Domain)); ok {
+			if strings.Contains(sub, ".") {
+				return "", ErrInvalid // a.b.example.com
+			}
+			fromHost = ID(sub)
+		}
+	}
+	if res.Header != "" {
+		fromHeader = ID(strings.TrimSpace(r.Header.Get(res.Header)))
+	}
+
+	id := fromHost
+	if id == "" {
+		id = fromHeader
+	} else if fromHeader != "" && fromHeader != id {
+		return "", ErrConflict
+	}
+	if id == "" {
+		return "", ErrNoTenant
+	}
+	if !id.Valid() {
+		return "", ErrInvalid
+	}
+	if res.Known != nil && !res.Known(id) {
+		return "", ErrUnknown
+	}
+	return id, nil
+}
+
+// Middleware puts the tenant of each request in its context, and refuses
+// requests without one: 400 for a missing, malformed or conflicting
+// tenant, 404 for an unknown one.
+func (res Resolver) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, err := res.Resolve(r)
+		switch {
+		case errors.Is(err, ErrUnknown):
+			http.Error(w, "Unknown tenant", http.StatusNotFound)
+			return
+		case err != nil:
+			http.Error(w, "Tenant required: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		next.ServeHTTP(w, r.WithContext(WithID(r.Context(), id)))
+	})
+}
@@ -0,0 +1,114 @@
+package tenant
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestValid(t *testing.T) {
+	for id, want := range map[ID]bool{
+		"acme":                      true,
+		"acme-2":                    true,
+		"7eleven":                   true,
+		"":                          false,
+		"-acme":                     false,
+		"acme-":                     false,
+		"Acme":                      false,
+		"acme.corp":                 false,
+		"acme corp":                 false,
+		ID(strings.Repeat("a", 64)): false,
+	} {
+		if got := id.Valid(); got != want {
+			t.Errorf("ID(%q).Valid() = %v, want %v", id, got, want)
+		}
+	}
+}
+
+func TestContext(t *testing.T) {
+	if _, ok := FromContext(context.Background()); ok {
+		t.Error("tenant in an empty context")
+	}
+	if _, ok := FromContext(WithID(context.Background(), "")); ok {
+		t.Error("empty ID counted as a tenant")
+	}
+	// A plain string key, as a careless package might use, does not set it.
+	ctx := context.WithValue(context.Background(), "tenant", ID("acme"))
+	if _, ok := FromContext(ctx); ok {
+		t.Error("string key set the tenant")
+	}
+	if id, ok := FromContext(WithID(context.Background(), "acme")); !ok || id != "acme" {
+		t.Errorf("FromContext = %q, %v", id, ok)
+	}
+}
+
+func TestResolve(t *testing.T) {
+	res := Resolver{
+		Domain: "example.com",
+		Header: "X-Tenant-ID",
+		Known:  func(id ID) bool { return id == "acme" || id == "globex" },
+	}
+	for _, tt := range []struct {
+		name, host, header string
+		want               ID
+		err                error
+	}{
+		{"subdomain", "acme.example.com", "", "acme", nil},
+		{"subdomain with port", "acme.example.com:8080", "", "acme", nil},
+		{"upper case", "ACME.Example.COM", "", "acme", nil},
+		{"trailing dot", "acme.example.com.", "", "acme", nil},
+		{"header", "api.example.net", "globex", "globex", nil},
+		{"both agree", "acme.example.com", "acme", "acme", nil},
+		{"both differ", "acme.example.com", "globex", "", ErrConflict},
+		{"neither", "example.com", "", "", ErrNoTenant},
+		{"other domain", "acme.example.net", "", "", ErrNoTenant},
+		{"lookalike domain", "acme.notexample.com", "", "", ErrNoTenant},
+		{"nested subdomain", "x.acme.example.com", "", "", ErrInvalid},
+		{"malformed header", "api.example.net", "acme'--", "", ErrInvalid},
+		{"unknown", "initech.example.com", "", "", ErrUnknown},
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Host = tt.host
+		if tt.header != "" {
+			r.Header.Set("X-Tenant-ID", tt.header)
+		}
+		got, err := res.Resolve(r)
+		if got != tt.want || !errors.Is(err, tt.err) {
+			t.Errorf("%s: Resolve = %q, %v; want %q, %v", tt.name, got, err, tt.want, tt.err)
+		}
+	}
+
+	// Without Header, the header is ignored.
+	r := httptest.NewRequest("GET", "/", nil)
+	r.Header.Set("X-Tenant-ID", "acme")
+	if _, err := (Resolver{Domain: "example.com"}).Resolve(r); !errors.Is(err, ErrNoTenant) {
+		t.Errorf("header without Resolver.Header: %v", err)
+	}
+}
+
+func TestMiddleware(t *testing.T) {
+	res := Resolver{Domain: "example.com", Known: func(id ID) bool { return id == "acme" }}
+	h := res.Middleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id, _ := FromContext(r.Context())
+		w.Write([]byte(id))
+	}))
+	for host, want := range map[string]int{
+		"acme.example.com":    200,
+		"example.com":         400,
+		"initech.example.com": 404,
+	} {
+		r := httptest.NewRequest("GET", "/", nil)
+		r.Host = host
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != want {
+			t.Errorf("%s: %d %q, want %d", host, w.Code, w.Body, want)
+		}
+		if want == 200 && w.Body.String() != "acme" {
+			t.Errorf("%s: tenant %q", host, w.Body)
+		}
+	}
+}
@@ -0,0 +1,233 @@
+// Package idempotency makes POST requests safe to retry. A client sends
+// an Idempotency-Key header with a unique value per operation; the first
+// request with a key runs, and its response is stored. Retries with the
+// same key get the stored response back instead of running again, so a
+// timeout followed by a retry does not create two users or charge a card
+// twice.
+//
+// Concurrent requests with the same key, such as a retry sent while the
+// first attempt is still running, share one run through singleflight.
+package idempotency
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"net/http"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// Header is the request header that carries the key.
+	Header = "Idempotency-Key"
+	// ReplayedHeader is set to "true" on responses served from the store.
+	ReplayedHeader = "Idempotent-Replayed"
+)
+
+// Response is a stored response.
+type Response struct {
+	Status int
+	Header http.Header
+	Body   []byte
+	// Fingerprint identifies the request that produced the response: a
+	// hash of its method, URL and body.
+	Fingerprint string
+}
+
+// Store keeps responses by key until they expire.
+type Store interface {
+	// Get returns the response stored under key, or nil if there is none
+	// or it has expired.
+	Get(ctx context.Context, key string) (*Response, error)
+	// Put stores resp under key for ttl.
+	Put(ctx context.Context, key string, resp *Response, ttl time.Duration) error
+}
+
+// Options configures a Replayer. Zero values select the defaults.
+type Options struct {
+	// Store holds the responses. Required.
+	Store Store
+	// TTL is how long a response is replayed (default 24 hours). Retries
+	// come within minutes; a day also covers clients that retry a failed
+	// batch the next morning.
+	TTL time.Duration
+	// Required rejects POST and PATCH requests without a key.
+	Required bool
+	// MaxBody is the largest request body read to fingerprint the request
+	// (default 1 MB). Larger requests get 413.
+	MaxBody int64
+	// Scope returns who is calling. Keys are only unique per client, and
+	// one client must not get another's stored response by sending the
+	// same key. The default uses the Authorization and X-API-Key headers.
+	Scope func(r *http.Request) string
+	// OnError writes error responses (default http.Error).
+	OnError func(w http.ResponseWriter, r *http.Request, msg string, code int)
+}
+
+// Replayer is the middleware. It is safe for concurrent use.
+type Replayer struct {
+	opts  Options
+	group singleflight.Group
+}
+
+// New returns a Replayer. It panics if opts.Store is nil.
+func New(opts Options) *Replayer {
+	if opts.Store == nil {
+		panic("idempotency: nil Store")
+	}
+	if opts.TTL <= 0 {
+		opts.TTL = 24 * time.Hour
+	}
+	if opts.MaxBody <= 0 {
+		opts.MaxBody = 1 << 20
+	}
+	if opts.Scope == nil {
+		opts.Scope = func(r *http.Request) string {
+			return r.Header.Get("Authorization") + "\n" + r.Header.Get("X-API-Key")
+		}
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(w http.ResponseWriter, _ *http.Request, msg string, code int) { http.Error(w, msg, code) }
+	}
+	return &Replayer{opts: opts}
+}
+
+// Handler runs POST and PATCH requests with an Idempotency-Key once per
+// key, and replays the stored response for later requests with the same
+// key. Other methods are idempotent already and pass through, as do
+// requests without a key unless Required is set.
+//
+// Responses with a 5xx status, or 429, are not stored: the failure may be
+// temporary, so a retry runs again. A key sent again with a different
+// request gets 422.
+func (p *Replayer) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost && r.Method != http.MethodPatch {
+			next.ServeHTTP(w, r)
+			return
+		}
+		key := r.Header.Get(Header)
+		if key == "" {
+			if p.opts.Required {
+				p.opts.OnError(w, r, "Idempotency-Key header required", http.StatusBadRequest)
+				return
+			}
+			next.ServeHTTP(w, r)
+			return
+		}
+		if !validKey(key) {
+			p.opts.OnError(w, r, "Invalid Idempotency-Key", http.StatusBadRequest)
+			return
+		}
+		body, err := io.ReadAll(io.LimitReader(r.Body, p.opts.MaxBody+1))
+		if err != nil {
+			p.opts.OnError(w, r, "Invalid request body", http.StatusBadRequest)
+			return
+		}
+		if int64(len(body)) > p.opts.MaxBody {
+			p.opts.OnError(w, r, "Request body too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		fp := fingerprint(r, body)
+		scope := sha256.Sum256([]byte(p.opts.Scope(r)))
+		storeKey := hex.EncodeToString(scope[:]) + ":" + key
+
+		ran := false // set by this request's closure only if it is the one that runs
+		ch := p.group.DoChan(storeKey, func() (any, error) {
+			if resp, err := p.opts.Store.Get(r.Context(), storeKey); err != nil || resp != nil {
+				return resp, err
+			}
+			ran = true
+			// The run is shared: a client that hangs up must not cancel it
+			// for the others, and its result is stored either way.
+			ctx := context.WithoutCancel(r.Context())
+			rr := r.Clone(ctx)
+			rr.Body = io.NopCloser(bytes.NewReader(body))
+			rec := &recorder{header: http.Header{}}
+			next.ServeHTTP(rec, rr)
+			resp := rec.response(fp)
+			if resp.Status < 500 && resp.Status != http.StatusTooManyRequests {
+				if err := p.opts.Store.Put(ctx, storeKey, resp, p.opts.TTL); err != nil {
+					log.Printf("idempotency: storing response: %v", err)
+				}
+			}
+			return resp, nil
+		})
+
+		var res singleflight.Result
+		select {
+		case res = <-ch:
+		case <-r.Context().Done():
+			return
+		}
+		if res.Err != nil {
+			log.Printf("idempotency: %v", res.Err)
+			p.opts.OnError(w, r, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		resp := res.Val.(*Response)
+		if resp.Fingerprint != fp {
+			p.opts.OnError(w, r, "Idempotency-Key reused with a different request", http.StatusUnprocessableEntity)
+			return
+		}
+		for k, v := range resp.Header {
+			w.Header()[k] = v
+		}
+		if !ran {
+			w.Header().Set(ReplayedHeader, "true")
+		}
+		w.WriteHeader(resp.Status)
+		w.Write(resp.Body)
+	})
+}
+
+// validKey accepts 1 to 255 printable ASCII characters, enough for a
+// UUID or a client's own scheme.
+func validKey(k string) bool {
+	if len(k) == 0 || len(k) > 255 {
+		return false
+	}
+	for _, c := range []byte(k) {
+		if c < 0x20 || c > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+func fingerprint(r *http.Request, body []byte) string {
+	h := sha256.New()
+	io.WriteString(h, r.Method+" "+r.URL.RequestURI()+"\n")
+	h.Write(body)
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// recorder captures a response in memory.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *recorder) Header() http.Header { return rec.header }
+
+func (rec *recorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(b)
+}
+
+func (rec *recorder) response(fp string) *Response {
+	rec.WriteHeader(http.StatusOK) // a handler that wrote nothing
+	return &Response{Status: rec.status, Header: rec.header, Body: rec.body.Bytes(), Fingerprint: fp}
+}
@@ -0,0 +1,234 @@
+package idempotency
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+var t0 = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// counter is a handler that creates a numbered resource per run.
+type counter struct {
+	runs    atomic.Int64
+	status  atomic.Int64  // response status; 0 for 201
+	release chan struct{} // if set, each run waits for it
+}
+
+func (c *counter) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := c.runs.Add(1)
+	if c.release != nil {
+		<-c.release
+	}
+	body, _ := io.ReadAll(r.Body)
+	code := int(c.status.Load())
+	if code == 0 {
+		code = http.StatusCreated
+	}
+	w.Header().Set("Location", fmt.Sprintf("/things/%d", n))
+	w.WriteHeader(code)
+	fmt.Fprintf(w, "thing %d from %s", n, body)
+}
+
+func newTestHandler(t *testing.T, opts Options) (*counter, http.Handler) {
+	t.Helper()
+	if opts.Store == nil {
+		opts.Store = NewMemoryStore(0, nil)
+	}
+	c := &counter{}
+	return c, New(opts).Handler(c)
+}
+
+type result struct {
+	code     int
+	body     string
+	location string
+	replayed bool
+}
+
+func send(h http.Handler, method, key, auth, body string) result {
+	r := httptest.NewRequest(method, "/things", strings.NewReader(body))
+	if key != "" {
+		r.Header.Set(Header, key)
+	}
+	if auth != "" {
+		r.Header.Set("Authorization", auth)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return result{w.Code, strings.TrimSpace(w.Body.String()), w.Header().Get("Location"), w.Header().Get(ReplayedHeader) == "true"}
+}
+
+func TestReplay(t *testing.T) {
+	c, h := newTestHandler(t, Options{})
+	first := send(h, "POST", "k1", "", "a")
+	if first.code != 201 || first.body != "thing 1 from a" || first.replayed {
+		t.Fatalf("first: %+v", first)
+	}
+	again := send(h, "POST", "k1", "", "a")
+	if again.code != 201 || again.body != first.body || again.location != "/things/1" || !again.replayed {
+		t.Errorf("retry: %+v", again)
+	}
+	if other := send(h, "POST", "k2", "", "a"); other.body != "thing 2 from a" {
+		t.Errorf("another key: %+v", other)
+	}
+	if n := c.runs.Load(); n != 2 {
+		t.Errorf("%d runs, want 2", n)
+	}
+}
+
+func TestPassThrough(t *testing.T) {
+	c, h := newTestHandler(t, Options{})
+	send(h, "POST", "", "", "a")
+	send(h, "POST", "", "", "a")
+	send(h, "GET", "k1", "", "")
+	send(h, "GET", "k1", "", "")
+	if n := c.runs.Load(); n != 4 {
+		t.Errorf("%d runs, want 4", n)
+	}
+}
+
+func TestRejects(t *testing.T) {
+	_, h := newTestHandler(t, Options{Required: true, MaxBody: 10})
+	for _, tt := range []struct {
+		name, key, body string
+		code            int
+	}{
+		{"no key", "", "a", 400},
+		{"control character", "k\x01", "a", 400},
+		{"too long", strings.Repeat("k", 256), "a", 400},
+		{"large body", "k1", strings.Repeat("a", 11), 413},
+	} {
+		if got := send(h, "POST", tt.key, "", tt.body); got.code != tt.code {
+			t.Errorf("%s: %+v, want %d", tt.name, got, tt.code)
+		}
+	}
+}
+
+func TestKeyReusedWithDifferentRequest(t *testing.T) {
+	c, h := newTestHandler(t, Options{})
+	send(h, "POST", "k1", "", "a")
+	if got := send(h, "POST", "k1", "", "b"); got.code != http.StatusUnprocessableEntity {
+		t.Errorf("different body: %+v", got)
+	}
+	if n := c.runs.Load(); n != 1 {
+		t.Errorf("%d runs, want 1", n)
+	}
+}
+
+func TestScope(t *testing.T) {
+	c, h := newTestHandler(t, Options{})
+	alice := send(h, "POST", "k1", "Bearer alice", "a")
+	bob := send(h, "POST", "k1", "Bearer bob", "a")
+	if bob.replayed || bob.body == alice.body || c.runs.Load() != 2 {
+		t.Errorf("bob got alice's response: %+v", bob)
+	}
+}
+
+func TestErrorsAreRetried(t *testing.T) {
+	c, h := newTestHandler(t, Options{})
+	c.status.Store(http.StatusServiceUnavailable)
+	if got := send(h, "POST", "k1", "", "a"); got.code != 503 {
+		t.Fatalf("first: %+v", got)
+	}
+	c.status.Store(0)
+	if got := send(h, "POST", "k1", "", "a"); got.code != 201 || got.replayed {
+		t.Errorf("retry after 503: %+v", got)
+	}
+	// A 4xx is the answer, and is replayed.
+	c.status.Store(http.StatusBadRequest)
+	send(h, "POST", "k2", "", "a")
+	c.status.Store(0)
+	if got := send(h, "POST", "k2", "", "a"); got.code != 400 || !got.replayed {
+		t.Errorf("retry after 400: %+v", got)
+	}
+}
+
+func TestTTL(t *testing.T) {
+	fc := clock.NewFake(t0)
+	c, h := newTestHandler(t, Options{Store: NewMemoryStore(0, fc), TTL: time.Hour})
+	send(h, "POST", "k1", "", "a")
+	fc.Advance(59 * time.Minute)
+	if got := send(h, "POST", "k1", "", "a"); !got.replayed {
+		t.Errorf("within the TTL: %+v", got)
+	}
+	fc.Advance(time.Minute)
+	if got := send(h, "POST", "k1", "", "a"); got.replayed || c.runs.Load() != 2 {
+		t.Errorf("after the TTL: %+v", got)
+	}
+}
+
+// TestConcurrentDuplicates sends the same request many times while the
+// first run is still going. They must share that run.
+func TestConcurrentDuplicates(t *testing.T) {
+	c, h := newTestHandler(t, Options{})
+	c.release = make(chan struct{})
+
+	const n = 10
+	results := make(chan result, n)
+	var wg sync.WaitGroup
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results <- send(h, "POST", "k1", "", "a")
+		}()
+	}
+	for c.runs.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	time.Sleep(20 * time.Millisecond) // let the others join the run
+	close(c.release)
+	wg.Wait()
+	close(results)
+
+	fresh := 0
+	for r := range results {
+		if r.code != 201 || r.body != "thing 1 from a" {
+			t.Errorf("got %+v", r)
+		}
+		if !r.replayed {
+			fresh++
+		}
+	}
+	if runs := c.runs.Load(); runs != 1 {
+		t.Errorf("%d runs, want 1", runs)
+	}
+	if fresh != 1 {
+		t.Errorf("%d responses not marked replayed, want 1", fresh)
+	}
+}
+
+func TestClientHangsUp(t *testing.T) {
+	c, h := newTestHandler(t, Options{})
+	c.release = make(chan struct{})
+	ctx, cancel := context.WithCancel(context.Background())
+	r := httptest.NewRequest("POST", "/things", strings.NewReader("a")).WithContext(ctx)
+	r.Header.Set(Header, "k1")
+	done := make(chan struct{})
+	go func() {
+		h.ServeHTTP(httptest.NewRecorder(), r)
+		close(done)
+	}()
+	for c.runs.Load() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	cancel()
+	<-done // the request returns at once
+	close(c.release)
+
+	// The run went on without the client. The retry joins it, or finds
+	// its stored result.
+	if got := send(h, "POST", "k1", "", "a"); !got.replayed || got.body != "thing 1 from a" || c.runs.Load() != 1 {
+		t.Errorf("retry: %+v after %d runs", got, c.runs.Load())
+	}
+}
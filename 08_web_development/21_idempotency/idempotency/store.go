@@ -0,0 +1,122 @@
+package idempotency
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+	"golang_roadmap/13_concurrency/01_cache/cache"
+)
+
+// MemoryStore keeps responses in an LRU cache. They are lost on restart,
+// and each server has its own, so it suits a single process whose data
+// is in memory too.
+type MemoryStore struct {
+	c *cache.Cache[string, *Response]
+}
+
+// NewMemoryStore returns a MemoryStore holding at most maxEntries
+// responses (0: unbounded). c is the clock for expiry (nil: clock.Real).
+func NewMemoryStore(maxEntries int, c clock.Clock) *MemoryStore {
+	if c == nil {
+		c = clock.Real()
+	}
+	return &MemoryStore{c: cache.New(cache.Options[string, *Response]{MaxEntries: maxEntries, Now: c.Now})}
+}
+
+func (s *MemoryStore) Get(_ context.Context, key string) (*Response, error) {
+	resp, _ := s.c.Get(key)
+	return resp, nil
+}
+
+func (s *MemoryStore) Put(_ context.Context, key string, resp *Response, ttl time.Duration) error {
+	s.c.SetWithTTL(key, resp, ttl)
+	return nil
+}
+
+const schema = `
+CREATE TABLE IF NOT EXISTS idempotency (
+	key         TEXT    PRIMARY KEY,
+	fingerprint TEXT    NOT NULL,
+	status      INTEGER NOT NULL,
+	header      TEXT    NOT NULL, -- JSON
+	body        BLOB    NOT NULL,
+	expires_at  INTEGER NOT NULL  -- unix ms
+);
+CREATE INDEX IF NOT EXISTS idempotency_expires ON idempotency (expires_at);`
+
+// SQLiteStore keeps responses in SQLite, so they survive a restart and
+// can be shared by processes on one machine.
+type SQLiteStore struct {
+	db    *sql.DB
+	clock clock.Clock
+}
+
+// OpenSQLite opens (creating if needed) the store at path. c is the
+// clock for expiry (nil: clock.Real).
+func OpenSQLite(path string, c clock.Clock) (*SQLiteStore, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	if c == nil {
+		c = clock.Real()
+	}
+	return &SQLiteStore{db: db, clock: c}, nil
+}
+
+// Close closes the database.
+func (s *SQLiteStore) Close() error { return s.db.Close() }
+
+func (s *SQLiteStore) Get(ctx context.Context, key string) (*Response, error) {
+	var resp Response
+	var header string
+	err := s.db.QueryRowContext(ctx,
+		`SELECT fingerprint, status, header, body FROM idempotency WHERE key = ? AND expires_at > ?`,
+		key, s.clock.Now().UnixMilli()).Scan(&resp.Fingerprint, &resp.Status, &header, &resp.Body)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal([]byte(header), &resp.Header); err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Put stores resp, and deletes expired responses while it is at it.
+func (s *SQLiteStore) Put(ctx context.Context, key string, resp *Response, ttl time.Duration) error {
+	header, err := json.Marshal(resp.Header)
+	if err != nil {
+		return err
+	}
+	body := resp.Body
+	if body == nil {
+		body = []byte{} // NULL would break NOT NULL
+	}
+	now := s.clock.Now()
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM idempotency WHERE expires_at <= ?`, now.UnixMilli()); err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT OR REPLACE INTO idempotency (key, fingerprint, status, header, body, expires_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		key, resp.Fingerprint, resp.Status, string(header), body, now.Add(ttl).UnixMilli())
+	return err
+}
+
+var (
+	_ Store = (*MemoryStore)(nil)
+	_ Store = (*SQLiteStore)(nil)
+)
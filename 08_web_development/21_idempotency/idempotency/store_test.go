@@ -0,0 +1,69 @@
+package idempotency
+
+import (
+	"context"
+	"net/http"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+func TestStores(t *testing.T) {
+	for name, open := range map[string]func(t *testing.T, c clock.Clock) Store{
+		"memory": func(t *testing.T, c clock.Clock) Store { return NewMemoryStore(0, c) },
+		"sqlite": func(t *testing.T, c clock.Clock) Store {
+			s, err := OpenSQLite(filepath.Join(t.TempDir(), "idem.db"), c)
+			if err != nil {
+				t.Fatal(err)
+			}
+			t.Cleanup(func() { s.Close() })
+			return s
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			fc := clock.NewFake(t0)
+			s := open(t, fc)
+			if resp, err := s.Get(ctx, "k"); resp != nil || err != nil {
+				t.Fatalf("empty store: %v, %v", resp, err)
+			}
+			want := &Response{Status: 201, Header: http.Header{"Location": {"/things/1"}}, Body: []byte("thing 1"), Fingerprint: "f"}
+			if err := s.Put(ctx, "k", want, time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			if err := s.Put(ctx, "empty", &Response{Status: 204, Fingerprint: "f"}, time.Minute); err != nil {
+				t.Fatal(err)
+			}
+			got, err := s.Get(ctx, "k")
+			if err != nil || got == nil || got.Status != 201 || got.Header.Get("Location") != "/things/1" || string(got.Body) != "thing 1" || got.Fingerprint != "f" {
+				t.Fatalf("Get = %+v, %v", got, err)
+			}
+			fc.Advance(time.Minute)
+			if resp, err := s.Get(ctx, "k"); resp != nil || err != nil {
+				t.Errorf("expired: %+v, %v", resp, err)
+			}
+		})
+	}
+}
+
+func TestSQLiteSurvivesRestart(t *testing.T) {
+	ctx := context.Background()
+	path := filepath.Join(t.TempDir(), "idem.db")
+	s, err := OpenSQLite(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Put(ctx, "k", &Response{Status: 201, Body: []byte("thing 1"), Fingerprint: "f"}, time.Hour)
+	s.Close()
+
+	s, err = OpenSQLite(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer s.Close()
+	if got, err := s.Get(ctx, "k"); err != nil || got == nil || string(got.Body) != "thing 1" {
+		t.Errorf("after reopening: %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,157 @@
+// Demonstrates idempotency keys for POST requests.
+//
+// This example shows:
+// - A retried POST without a key running twice
+// - The Idempotency-Key header: one run per key, the stored response replayed
+// - A key reused for a different request refused with 422
+// - Concurrent duplicates sharing one run through singleflight
+// - Responses stored in SQLite, replayed after a restart
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang_roadmap/08_web_development/21_idempotency/idempotency"
+)
+
+// payments stands in for an endpoint with a side effect that must not
+// happen twice.
+type payments struct {
+	charges atomic.Int64
+	delay   time.Duration
+}
+
+func (p *payments) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var req struct {
+		Amount int `json:"amount"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil || req.Amount <= 0 {
+		http.Error(w, "Invalid payment", http.StatusBadRequest)
+		return
+	}
+	time.Sleep(p.delay)
+	n := p.charges.Add(1)
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	fmt.Fprintf(w, `{"charge":"ch_%d","amount":%d}`+"\n", n, req.Amount)
+}
+
+func pay(srv *httptest.Server, key, body string) string {
+	req, err := http.NewRequest("POST", srv.URL+"/payments", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if key != "" {
+		req.Header.Set(idempotency.Header, key)
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	out := fmt.Sprintf("%d %s", resp.StatusCode, strings.TrimSpace(string(b)))
+	if resp.Header.Get(idempotency.ReplayedHeader) != "" {
+		out += " (replayed)"
+	}
+	return out
+}
+
+func main() {
+	addr := flag.String("serve", "", "after the demo, serve POST /payments on this address, such as :8080")
+	flag.Parse()
+	log.SetFlags(0)
+
+	fmt.Println("Idempotency key examples starting...")
+	p := &payments{}
+	mem := idempotency.New(idempotency.Options{Store: idempotency.NewMemoryStore(10_000, nil)})
+	srv := httptest.NewServer(mem.Handler(p))
+	defer srv.Close()
+
+	// 1) The response to a payment is lost on the way back, so the client
+	// retries. Without a key, the server cannot tell it is a retry.
+	fmt.Println("\n1) A retry without a key")
+	fmt.Println("   ", pay(srv, "", `{"amount":500}`))
+	fmt.Println("   ", pay(srv, "", `{"amount":500}`))
+	fmt.Printf("    charges: %d\n", p.charges.Load())
+
+	// 2) The client makes one key per payment and sends it with every
+	// attempt.
+	fmt.Println("\n2) A retry with a key")
+	p.charges.Store(0)
+	fmt.Println("   ", pay(srv, "order-42", `{"amount":500}`))
+	fmt.Println("   ", pay(srv, "order-42", `{"amount":500}`))
+	fmt.Printf("    charges: %d\n", p.charges.Load())
+
+	// 3) A client bug: the same key for another payment.
+	fmt.Println("\n3) The key reused for another request")
+	fmt.Println("   ", pay(srv, "order-42", `{"amount":900}`))
+
+	// 4) Retries sent while the first attempt is still running.
+	fmt.Println("\n4) Concurrent duplicates")
+	p.charges.Store(0)
+	p.delay = 200 * time.Millisecond
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var lines []string
+	for range 5 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			line := pay(srv, "order-43", `{"amount":700}`)
+			mu.Lock()
+			lines = append(lines, line)
+			mu.Unlock()
+		}()
+	}
+	wg.Wait()
+	for _, l := range lines {
+		fmt.Println("   ", l)
+	}
+	fmt.Printf("    charges: %d\n", p.charges.Load())
+	p.delay = 0
+
+	// 5) With SQLite, stored responses survive a restart.
+	fmt.Println("\n5) After a restart")
+	dir, err := os.MkdirTemp("", "idempotency")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "idempotency.db")
+	for i := range 2 {
+		store, err := idempotency.OpenSQLite(path, nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		s := httptest.NewServer(idempotency.New(idempotency.Options{Store: store}).Handler(p))
+		fmt.Printf("    process %d: %s\n", i+1, pay(s, "order-44", `{"amount":300}`))
+		s.Close()
+		store.Close()
+	}
+
+	if *addr != "" {
+		store, err := idempotency.OpenSQLite("idempotency.db", nil)
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer store.Close()
+		mux := http.NewServeMux()
+		mux.Handle("POST /payments", idempotency.New(idempotency.Options{Store: store, Required: true}).Handler(p))
+		log.Printf("serving on %s: curl -d '{\"amount\":500}' -H 'Idempotency-Key: k1' localhost%s/payments", *addr, *addr)
+		srv := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		log.Fatal(srv.ListenAndServe())
+	}
+}
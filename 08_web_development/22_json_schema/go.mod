@@ -0,0 +1,8 @@
+module golang_roadmap/08_web_development/22_json_schema
+
+go 1.24.11
+
+require (
+	github.com/santhosh-tekuri/jsonschema/v6 v6.0.3
+	golang.org/x/text v0.14.0
+)
@@ -0,0 +1,131 @@
+// Demonstrates validating JSON request and response bodies against JSON
+// Schema documents embedded in the binary.
+//
+// This example shows:
+// - Schemas embedded with go:embed, compiled once, sharing definitions through $ref
+// - A request body that breaks its schema answered with 422 and every violation
+// - Response bodies checked too, which catches a handler sending null for []
+// - Strict mode for tests and development, log-only mode for production
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"golang_roadmap/08_web_development/22_json_schema/schema"
+	"golang_roadmap/08_web_development/22_json_schema/schemas"
+)
+
+type User struct {
+	ID        int       `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	Tags      []string  `json:"tags"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// users is a small in-memory users API. The schema has checked the body
+// before create runs, so it only decodes.
+type users struct {
+	mu     sync.Mutex
+	nextID int
+	// nilTags leaves Tags nil when the request has none, the bug the
+	// response schema catches: a nil slice encodes as null, not [].
+	nilTags bool
+}
+
+func (u *users) create(w http.ResponseWriter, r *http.Request) {
+	var user User
+	if err := json.NewDecoder(r.Body).Decode(&user); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+	if user.Tags == nil && !u.nilTags {
+		user.Tags = []string{}
+	}
+	u.mu.Lock()
+	u.nextID++
+	user.ID = u.nextID
+	u.mu.Unlock()
+	user.CreatedAt = time.Now().UTC().Truncate(time.Second)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(user)
+}
+
+func post(srv *httptest.Server, body string) {
+	resp, err := srv.Client().Post(srv.URL+"/users", "application/json", strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	fmt.Printf("    %s -> %d %s\n", body, resp.StatusCode, strings.TrimSpace(string(b)))
+}
+
+func newServer(set *schema.Set, u *users, strict bool) *httptest.Server {
+	mux := http.NewServeMux()
+	mux.Handle("POST /users", set.Handler(schema.Options{Request: "user.create", Response: "user", Strict: strict}, http.HandlerFunc(u.create)))
+	return httptest.NewServer(mux)
+}
+
+func main() {
+	addr := flag.String("serve", "", "after the demo, serve POST /users on this address, such as :8080")
+	flag.Parse()
+	log.SetFlags(0)
+
+	fmt.Println("JSON Schema examples starting...")
+	set, err := schema.Compile(schemas.FS)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("\n1) The embedded schemas")
+	for _, name := range set.Names() {
+		fmt.Printf("    %s\n", name)
+	}
+
+	u := &users{}
+	srv := newServer(set, u, true)
+	defer srv.Close()
+
+	fmt.Println("\n2) Valid requests")
+	post(srv, `{"name":"Ada Lovelace","email":"ada@example.com"}`)
+	post(srv, `{"name":"Grace Hopper","email":"grace@example.com","tags":["admin"]}`)
+
+	// 3) The handler never sees these. Every problem is listed, each with
+	// the field it is about, so a form can mark them all at once.
+	fmt.Println("\n3) Requests that break the schema")
+	post(srv, `{"name":"Ada"}`)
+	post(srv, `{"id":1,"name":"  ","email":"ada.example.com","tags":["Admin","Admin"]}`)
+	post(srv, `{"name":"Ada","email":`)
+
+	// 4) A bug in the handler: a user without tags is sent with
+	// "tags": null. Clients that loop over tags would crash on it.
+	fmt.Println("\n4) A response that breaks the schema, strict")
+	u.nilTags = true
+	post(srv, `{"name":"Alan Turing","email":"alan@example.com"}`)
+
+	fmt.Println("\n5) The same response in production: logged, and sent")
+	lenient := newServer(set, u, false)
+	post(lenient, `{"name":"Alan Turing","email":"alan@example.com"}`)
+	lenient.Close()
+	u.nilTags = false
+
+	if *addr != "" {
+		mux := http.NewServeMux()
+		mux.Handle("POST /users", set.Handler(schema.Options{Request: "user.create", Response: "user"}, http.HandlerFunc(u.create)))
+		log.Printf("serving on %s: curl -H 'Content-Type: application/json' -d '{\"name\":\"Ada\"}' localhost%s/users", *addr, *addr)
+		srv := &http.Server{Addr: *addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+		log.Fatal(srv.ListenAndServe())
+	}
+}
@@ -0,0 +1,154 @@
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"strings"
+)
+
+// Options configures Handler. Zero values select the defaults.
+type Options struct {
+	// Request is the schema for request bodies ("": not checked).
+	Request string
+	// Response is the schema for 2xx JSON response bodies ("": not
+	// checked).
+	Response string
+	// MaxBody is the largest request body read (default 1 MB). Larger
+	// requests get 413.
+	MaxBody int64
+	// Strict replaces a response that breaks its schema with a 500 that
+	// lists the violations. Use it in development and tests. Otherwise
+	// the violation is logged and the response sent as it is: the client
+	// may well cope, and the handler already did its work.
+	Strict bool
+	// OnError writes error responses other than the 422 (default
+	// http.Error).
+	OnError func(w http.ResponseWriter, r *http.Request, msg string, code int)
+}
+
+// problem is the body of a 422, and of a 500 for a response that breaks
+// its schema in strict mode.
+type problem struct {
+	Error  string      `json:"error"`
+	Schema string      `json:"schema"`
+	Errors []Violation `json:"errors"`
+}
+
+// Handler checks the request body against opts.Request before calling
+// next, and next's response against opts.Response. A request that is not
+// JSON gets 415 or 400; one that breaks the schema gets 422 with a JSON
+// body listing every violation:
+//
+//	{"error": "Request body does not match the schema", "schema": "user.create",
+//	 "errors": [{"field": "/email", "keyword": "format", "message": "..."}]}
+//
+// next reads the same body, so it can decode into its own types and skip
+// the checks the schema already made. Handler panics if a schema in opts
+// is not in the set, so a typo fails at startup.
+func (s *Set) Handler(opts Options, next http.Handler) http.Handler {
+	for _, name := range []string{opts.Request, opts.Response} {
+		if name != "" && !s.Has(name) {
+			panic("schema: unknown schema " + name)
+		}
+	}
+	if opts.MaxBody <= 0 {
+		opts.MaxBody = 1 << 20
+	}
+	if opts.OnError == nil {
+		opts.OnError = func(w http.ResponseWriter, _ *http.Request, msg string, code int) { http.Error(w, msg, code) }
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if opts.Request != "" {
+			if !isJSON(r.Header.Get("Content-Type")) {
+				opts.OnError(w, r, "Content-Type must be application/json", http.StatusUnsupportedMediaType)
+				return
+			}
+			body, err := io.ReadAll(io.LimitReader(r.Body, opts.MaxBody+1))
+			if err != nil {
+				opts.OnError(w, r, "Invalid request body", http.StatusBadRequest)
+				return
+			}
+			if int64(len(body)) > opts.MaxBody {
+				opts.OnError(w, r, "Request body too large", http.StatusRequestEntityTooLarge)
+				return
+			}
+			var serr *Error
+			if err := s.Validate(opts.Request, body); errors.As(err, &serr) {
+				writeProblem(w, "Request body does not match the schema", serr, http.StatusUnprocessableEntity)
+				return
+			} else if err != nil {
+				opts.OnError(w, r, "Invalid JSON", http.StatusBadRequest)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+		}
+		if opts.Response == "" {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		// The response is held back until it has been checked: once
+		// the status is sent, it cannot be turned into a 500.
+		rec := &recorder{header: http.Header{}}
+		next.ServeHTTP(rec, r)
+		rec.WriteHeader(http.StatusOK) // a handler that wrote nothing
+		if rec.status/100 == 2 && rec.body.Len() > 0 && isJSON(rec.header.Get("Content-Type")) {
+			if err := s.Validate(opts.Response, rec.body.Bytes()); err != nil {
+				log.Printf("schema: response to %s %s: %v", r.Method, r.URL.Path, err)
+				if opts.Strict {
+					var serr *Error
+					if errors.As(err, &serr) {
+						writeProblem(w, "Response body does not match the schema", serr, http.StatusInternalServerError)
+					} else {
+						opts.OnError(w, r, "Internal server error", http.StatusInternalServerError)
+					}
+					return
+				}
+			}
+		}
+		for k, v := range rec.header {
+			w.Header()[k] = v
+		}
+		w.WriteHeader(rec.status)
+		w.Write(rec.body.Bytes())
+	})
+}
+
+func writeProblem(w http.ResponseWriter, msg string, err *Error, code int) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(problem{Error: msg, Schema: err.Schema, Errors: err.Violations})
+}
+
+// isJSON accepts application/json and the +json types, such as
+// application/merge-patch+json, with any parameters.
+func isJSON(contentType string) bool {
+	mt, _, err := mime.ParseMediaType(contentType)
+	return err == nil && (mt == "application/json" || strings.HasPrefix(mt, "application/") && strings.HasSuffix(mt, "+json"))
+}
+
+// recorder captures a response in memory.
+type recorder struct {
+	header http.Header
+	status int
+	body   bytes.Buffer
+}
+
+func (rec *recorder) Header() http.Header { return rec.header }
+
+func (rec *recorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+}
+
+func (rec *recorder) Write(b []byte) (int, error) {
+	rec.WriteHeader(http.StatusOK)
+	return rec.body.Write(b)
+}
@@ -0,0 +1,139 @@
+package schema
+
+import (
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// echo answers with the status and body it is given, and records the
+// request body it read.
+type echo struct {
+	status int
+	body   string
+	got    string
+	calls  int
+}
+
+func (e *echo) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	e.calls++
+	b, _ := io.ReadAll(r.Body)
+	e.got = string(b)
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", "/things/1")
+	w.WriteHeader(e.status)
+	io.WriteString(w, e.body)
+}
+
+func send(h http.Handler, contentType, body string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest("POST", "/things", strings.NewReader(body))
+	if contentType != "" {
+		r.Header.Set("Content-Type", contentType)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestHandlerRequest(t *testing.T) {
+	next := &echo{status: 201, body: `{"ok": true}`}
+	h := newTestSet(t).Handler(Options{Request: "thing", MaxBody: 100}, next)
+
+	for _, tt := range []struct {
+		name, contentType, body string
+		code                    int
+	}{
+		{"valid", "application/json", `{"count": 1}`, 201},
+		{"charset", "application/json; charset=utf-8", `{"count": 1}`, 201},
+		{"+json", "application/merge-patch+json", `{"count": 1}`, 201},
+		{"no content type", "", `{"count": 1}`, 415},
+		{"form", "application/x-www-form-urlencoded", `count=1`, 415},
+		{"not JSON", "application/json", `{"count": `, 400},
+		{"too large", "application/json", `{"count": 1, "a/b": "` + strings.Repeat("x", 100) + `"}`, 413},
+		{"violations", "application/json", `{"count": -1, "extra": true}`, 422},
+	} {
+		next.calls = 0
+		w := send(h, tt.contentType, tt.body)
+		if w.Code != tt.code {
+			t.Errorf("%s: got %d, want %d: %s", tt.name, w.Code, tt.code, w.Body)
+		}
+		if ran := next.calls == 1; ran != (tt.code == 201) {
+			t.Errorf("%s: next ran %d times", tt.name, next.calls)
+		}
+	}
+}
+
+// TestHandlerPassesBody checks that next can read the body the middleware
+// has already read.
+func TestHandlerPassesBody(t *testing.T) {
+	next := &echo{status: 201}
+	h := newTestSet(t).Handler(Options{Request: "thing"}, next)
+	send(h, "application/json", `{"count": 2}`)
+	if next.got != `{"count": 2}` {
+		t.Errorf("next read %q", next.got)
+	}
+}
+
+func TestHandler422Body(t *testing.T) {
+	h := newTestSet(t).Handler(Options{Request: "thing"}, &echo{status: 201})
+	w := send(h, "application/json", `{"extra": 1}`)
+	if ct := w.Header().Get("Content-Type"); ct != "application/json" {
+		t.Errorf("Content-Type %q", ct)
+	}
+	var got problem
+	if err := json.Unmarshal(w.Body.Bytes(), &got); err != nil {
+		t.Fatal(err)
+	}
+	want := problem{
+		Error:  "Request body does not match the schema",
+		Schema: "thing",
+		Errors: []Violation{
+			{"/count", "required", "is required"},
+			{"/extra", "additionalProperties", "is not allowed"},
+		},
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %+v\nwant %+v", got, want)
+	}
+}
+
+func TestHandlerResponse(t *testing.T) {
+	s := newTestSet(t)
+	for _, tt := range []struct {
+		name   string
+		strict bool
+		status int
+		body   string
+		code   int
+	}{
+		{"valid", true, 200, `{"ok": true}`, 200},
+		{"invalid, strict", true, 200, `{"ok": false}`, 500},
+		{"not JSON, strict", true, 200, `{"ok": `, 500},
+		{"invalid, lenient", false, 200, `{"ok": false}`, 200},
+		{"error responses are not checked", true, 404, `{"error": "not found"}`, 404},
+		{"empty", true, 204, ``, 204},
+	} {
+		h := s.Handler(Options{Response: "nested/reply", Strict: tt.strict}, &echo{status: tt.status, body: tt.body})
+		w := send(h, "", "")
+		if w.Code != tt.code {
+			t.Errorf("%s: got %d, want %d: %s", tt.name, w.Code, tt.code, w.Body)
+			continue
+		}
+		if w.Code == tt.status && (w.Body.String() != tt.body || w.Header().Get("Location") != "/things/1") {
+			t.Errorf("%s: response changed: %q %v", tt.name, w.Body, w.Header())
+		}
+	}
+}
+
+func TestHandlerUnknownSchema(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("no panic")
+		}
+	}()
+	newTestSet(t).Handler(Options{Request: "nope"}, &echo{})
+}
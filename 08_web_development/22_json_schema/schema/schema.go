@@ -0,0 +1,202 @@
+// Package schema validates JSON documents against JSON Schema (draft
+// 2020-12) with github.com/santhosh-tekuri/jsonschema, and turns the
+// result into a flat list of violations a client can act on: which field,
+// which rule, and why.
+//
+// The schemas come from an fs.FS, usually an embed.FS, so they ship
+// inside the binary and are compiled once at startup. Schemas refer to
+// each other with relative $refs such as "common.json#/$defs/email".
+package schema
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strings"
+
+	"github.com/santhosh-tekuri/jsonschema/v6"
+	"github.com/santhosh-tekuri/jsonschema/v6/kind"
+	"golang.org/x/text/language"
+	"golang.org/x/text/message"
+)
+
+// baseURL is where the schemas pretend to live. Relative $refs resolve
+// against it; nothing is fetched from it.
+const baseURL = "schema:///"
+
+// printer formats the library's messages.
+var printer = message.NewPrinter(language.English)
+
+// Violation is one way a document breaks its schema.
+type Violation struct {
+	// Field is a JSON Pointer to the offending value, such as "/email"
+	// or "/tags/2". "" is the whole document.
+	Field string `json:"field"`
+	// Keyword is the schema keyword that failed, such as "required",
+	// "format" or "maxLength".
+	Keyword string `json:"keyword"`
+	Message string `json:"message"`
+}
+
+func (v Violation) String() string {
+	field := v.Field
+	if field == "" {
+		field = "(document)"
+	}
+	return field + ": " + v.Message
+}
+
+// Error is returned for a document that does not match its schema.
+type Error struct {
+	Schema     string
+	Violations []Violation
+}
+
+func (e *Error) Error() string {
+	lines := make([]string, len(e.Violations))
+	for i, v := range e.Violations {
+		lines[i] = v.String()
+	}
+	return fmt.Sprintf("does not match schema %s: %s", e.Schema, strings.Join(lines, "; "))
+}
+
+// Set is a set of compiled schemas, looked up by name. It is safe for
+// concurrent use.
+type Set struct {
+	schemas map[string]*jsonschema.Schema
+}
+
+// Compile compiles every .json file in fsys. A schema's name is its path
+// without the extension: "users/create.json" is "users/create". Format
+// keywords such as "email" and "date-time" are enforced, not just
+// annotations, and a $ref to anything outside fsys is an error.
+func Compile(fsys fs.FS) (*Set, error) {
+	c := jsonschema.NewCompiler()
+	c.DefaultDraft(jsonschema.Draft2020)
+	c.AssertFormat()
+	c.UseLoader(jsonschema.SchemeURLLoader{}) // no network, no disk
+
+	var names []string
+	err := fs.WalkDir(fsys, ".", func(p string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || path.Ext(p) != ".json" {
+			return err
+		}
+		b, err := fs.ReadFile(fsys, p)
+		if err != nil {
+			return err
+		}
+		doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(b))
+		if err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		if err := c.AddResource(baseURL+p, doc); err != nil {
+			return fmt.Errorf("%s: %w", p, err)
+		}
+		names = append(names, p)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s := &Set{schemas: make(map[string]*jsonschema.Schema, len(names))}
+	for _, p := range names {
+		sch, err := c.Compile(baseURL + p)
+		if err != nil {
+			return nil, fmt.Errorf("compile %s: %w", p, err)
+		}
+		s.schemas[strings.TrimSuffix(p, ".json")] = sch
+	}
+	return s, nil
+}
+
+// Names returns the names of the schemas, sorted.
+func (s *Set) Names() []string {
+	names := make([]string, 0, len(s.schemas))
+	for name := range s.schemas {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Has reports whether the set has a schema called name.
+func (s *Set) Has(name string) bool {
+	_, ok := s.schemas[name]
+	return ok
+}
+
+// Validate checks the JSON document data against the named schema. It
+// returns an *Error listing the violations if the document does not match,
+// and a different error if data is not JSON or there is no such schema.
+func (s *Set) Validate(name string, data []byte) error {
+	sch, ok := s.schemas[name]
+	if !ok {
+		return fmt.Errorf("schema: unknown schema %q", name)
+	}
+	// UnmarshalJSON keeps numbers as json.Number, so large integers are
+	// checked exactly, and rejects anything after the document.
+	doc, err := jsonschema.UnmarshalJSON(bytes.NewReader(data))
+	if err != nil {
+		return err
+	}
+	err = sch.Validate(doc)
+	var ve *jsonschema.ValidationError
+	if errors.As(err, &ve) {
+		vs := violations(ve, nil)
+		// The library walks objects in map order; sorted, the same
+		// document always gets the same answer.
+		sort.SliceStable(vs, func(i, j int) bool { return vs[i].Field < vs[j].Field })
+		return &Error{Schema: name, Violations: vs}
+	}
+	return err
+}
+
+// violations flattens the library's error tree into its leaves. The inner
+// nodes only say "a $ref failed" or "allOf failed"; the leaves say why.
+func violations(e *jsonschema.ValidationError, out []Violation) []Violation {
+	if len(e.Causes) > 0 {
+		for _, c := range e.Causes {
+			out = violations(c, out)
+		}
+		return out
+	}
+	field := pointer(e.InstanceLocation)
+	keyword := ""
+	if kp := e.ErrorKind.KeywordPath(); len(kp) > 0 {
+		keyword = kp[0]
+	}
+	// Missing and unknown properties are reported on their parent object.
+	// Pointing at the property itself is what a form needs to mark the
+	// right input.
+	switch k := e.ErrorKind.(type) {
+	case *kind.Required:
+		for _, prop := range k.Missing {
+			out = append(out, Violation{Field: field + "/" + escape(prop), Keyword: keyword, Message: "is required"})
+		}
+		return out
+	case *kind.AdditionalProperties:
+		for _, prop := range k.Properties {
+			out = append(out, Violation{Field: field + "/" + escape(prop), Keyword: keyword, Message: "is not allowed"})
+		}
+		return out
+	}
+	return append(out, Violation{Field: field, Keyword: keyword, Message: e.ErrorKind.LocalizedString(printer)})
+}
+
+// pointer builds a JSON Pointer (RFC 6901) from path tokens.
+func pointer(tokens []string) string {
+	var sb strings.Builder
+	for _, t := range tokens {
+		sb.WriteByte('/')
+		sb.WriteString(escape(t))
+	}
+	return sb.String()
+}
+
+func escape(token string) string {
+	return strings.NewReplacer("~", "~0", "/", "~1").Replace(token)
+}
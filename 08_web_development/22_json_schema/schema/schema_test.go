@@ -0,0 +1,127 @@
+package schema
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func testFS() fstest.MapFS {
+	return fstest.MapFS{
+		"defs.json": {Data: []byte(`{"$defs": {"count": {"type": "integer", "minimum": 0}}}`)},
+		"thing.json": {Data: []byte(`{
+			"type": "object",
+			"properties": {
+				"count": {"$ref": "defs.json#/$defs/count"},
+				"a/b": {"type": "string"},
+				"list": {"type": "array", "items": {"type": "string", "format": "email"}}
+			},
+			"required": ["count"],
+			"additionalProperties": false
+		}`)},
+		"nested/reply.json": {Data: []byte(`{"type": "object", "required": ["ok"], "properties": {"ok": {"const": true}}}`)},
+		"README.md":         {Data: []byte("not a schema")},
+	}
+}
+
+func newTestSet(t *testing.T) *Set {
+	t.Helper()
+	s, err := Compile(testFS())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestCompile(t *testing.T) {
+	s := newTestSet(t)
+	if got, want := s.Names(), []string{"defs", "nested/reply", "thing"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Names() = %v, want %v", got, want)
+	}
+}
+
+func TestCompileErrors(t *testing.T) {
+	for name, data := range map[string]string{
+		"not JSON":      `{"type": `,
+		"bad keyword":   `{"type": "integr"}`,
+		"bad regexp":    `{"pattern": "("}`,
+		"external $ref": `{"$ref": "https://example.com/schema.json"}`,
+		"missing $ref":  `{"$ref": "nope.json"}`,
+		"missing $defs": `{"$ref": "#/$defs/nope"}`,
+	} {
+		if _, err := Compile(fstest.MapFS{"s.json": {Data: []byte(data)}}); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}
+
+func TestValidate(t *testing.T) {
+	s := newTestSet(t)
+	for _, tt := range []struct {
+		doc  string
+		want []Violation
+	}{
+		{`{"count": 3}`, nil},
+		{`{"count": 3, "a/b": "x", "list": ["a@example.com"]}`, nil},
+		{`{}`, []Violation{{"/count", "required", "is required"}}},
+		{`{"count": -1}`, []Violation{{"/count", "minimum", "minimum: got -1, want 0"}}},
+		{`{"count": 1, "extra": 1, "more": 2}`, []Violation{
+			{"/extra", "additionalProperties", "is not allowed"},
+			{"/more", "additionalProperties", "is not allowed"},
+		}},
+		{`{"count": 1, "a/b": 7}`, []Violation{{"/a~1b", "type", "got number, want string"}}},
+		{`{"count": 1, "list": ["a@example.com", "nope"]}`, []Violation{{"/list/1", "format", "'nope' is not valid email: missing @"}}},
+		{`[]`, []Violation{{"", "type", "got array, want object"}}},
+	} {
+		err := s.Validate("thing", []byte(tt.doc))
+		if tt.want == nil {
+			if err != nil {
+				t.Errorf("%s: %v", tt.doc, err)
+			}
+			continue
+		}
+		var serr *Error
+		if !errors.As(err, &serr) {
+			t.Errorf("%s: got %v, want violations", tt.doc, err)
+			continue
+		}
+		if serr.Schema != "thing" || !reflect.DeepEqual(serr.Violations, tt.want) {
+			t.Errorf("%s: got %+v, want %+v", tt.doc, serr.Violations, tt.want)
+		}
+	}
+}
+
+func TestValidateNotJSON(t *testing.T) {
+	s := newTestSet(t)
+	for _, doc := range []string{``, `{"count": 1`, `{"count": 1} {}`, `nope`} {
+		err := s.Validate("thing", []byte(doc))
+		var serr *Error
+		if err == nil || errors.As(err, &serr) {
+			t.Errorf("%q: got %v, want a syntax error", doc, err)
+		}
+	}
+}
+
+func TestValidateUnknownSchema(t *testing.T) {
+	err := newTestSet(t).Validate("nope", []byte(`{}`))
+	if err == nil || !strings.Contains(err.Error(), "unknown schema") {
+		t.Errorf("got %v", err)
+	}
+}
+
+// TestLargeIntegers checks that numbers are not squeezed through float64,
+// which would round 2^53+1 and accept it as an integer below the maximum.
+func TestLargeIntegers(t *testing.T) {
+	s, err := Compile(fstest.MapFS{"n.json": {Data: []byte(`{"type": "integer", "maximum": 9007199254740992}`)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Validate("n", []byte(`9007199254740992`)); err != nil {
+		t.Errorf("2^53: %v", err)
+	}
+	if err := s.Validate("n", []byte(`9007199254740993`)); err == nil {
+		t.Error("2^53+1: no error")
+	}
+}
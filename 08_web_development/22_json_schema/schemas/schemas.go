@@ -0,0 +1,14 @@
+// Package schemas holds the JSON Schema documents of the demo API,
+// embedded in the binary.
+//
+// testdata has example documents for each schema, valid and invalid. The
+// tests check every schema against them, so a change to a schema that
+// lets a bad document through, or starts refusing a good one, fails.
+package schemas
+
+import "embed"
+
+// FS holds the schemas. Compile it with schema.Compile.
+//
+//go:embed *.json
+var FS embed.FS
@@ -0,0 +1,93 @@
+package schemas
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"golang_roadmap/08_web_development/22_json_schema/schema"
+)
+
+// example is a file in testdata/<schema name>/: a document, whether the
+// schema should accept it, and if not, the fields it should report.
+type example struct {
+	Description string          `json:"description"`
+	Valid       bool            `json:"valid"`
+	Errors      []string        `json:"errors"`
+	Data        json.RawMessage `json:"data"`
+}
+
+func TestExamples(t *testing.T) {
+	set, err := schema.Compile(FS)
+	if err != nil {
+		t.Fatal(err)
+	}
+	dirs, err := os.ReadDir("testdata")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, dir := range dirs {
+		name := dir.Name()
+		if !set.Has(name) {
+			t.Errorf("testdata/%s: no schema %s.json", name, name)
+			continue
+		}
+		files, err := filepath.Glob(filepath.Join("testdata", name, "*.json"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		var valid, invalid int
+		for _, file := range files {
+			var ex example
+			b, err := os.ReadFile(file)
+			if err == nil {
+				err = json.Unmarshal(b, &ex)
+			}
+			if err != nil {
+				t.Fatalf("%s: %v", file, err)
+			}
+			if ex.Valid {
+				valid++
+			} else {
+				invalid++
+			}
+			t.Run(name+"/"+strings.TrimSuffix(filepath.Base(file), ".json"), func(t *testing.T) {
+				checkExample(t, set, name, ex)
+			})
+		}
+		// Without both kinds, a schema that accepts everything, or
+		// nothing, would pass.
+		if valid == 0 || invalid == 0 {
+			t.Errorf("testdata/%s: %d valid and %d invalid examples, want at least one of each", name, valid, invalid)
+		}
+	}
+}
+
+func checkExample(t *testing.T, set *schema.Set, name string, ex example) {
+	err := set.Validate(name, ex.Data)
+	if ex.Valid {
+		if err != nil {
+			t.Errorf("%s: %v", ex.Description, err)
+		}
+		return
+	}
+	var serr *schema.Error
+	if !errors.As(err, &serr) {
+		t.Fatalf("%s: got %v, want violations of %v", ex.Description, err, ex.Errors)
+	}
+	var fields []string
+	for _, v := range serr.Violations {
+		if !slices.Contains(fields, v.Field) {
+			fields = append(fields, v.Field)
+		}
+	}
+	slices.Sort(fields)
+	want := slices.Sorted(slices.Values(ex.Errors))
+	if !slices.Equal(fields, want) {
+		t.Errorf("%s: violations on %q, want %q\n%v", ex.Description, fields, want, err)
+	}
+}
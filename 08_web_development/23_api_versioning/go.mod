@@ -0,0 +1,8 @@
+module golang_roadmap/08_web_development/23_api_versioning
+
+go 1.24.11
+
+require golang_roadmap/04_Tooling_testing_and_code_quality/07_clock v0.0.0
+
+// The clock package lives in its own module in this repository.
+replace golang_roadmap/04_Tooling_testing_and_code_quality/07_clock => ../../04_Tooling_testing_and_code_quality/07_clock
@@ -0,0 +1,132 @@
+// Demonstrates versioning a JSON API while it evolves.
+//
+// This example shows:
+// - /api/v1/users and /api/v2/users: different shapes, one service layer
+// - Version packages that map the service's types to their own DTOs
+// - The version chosen by header instead: API-Version or a vendor type in Accept
+// - Deprecation, Sunset and Link headers on v1, and 410 Gone after the sunset
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+	"golang_roadmap/08_web_development/23_api_versioning/users"
+	v1 "golang_roadmap/08_web_development/23_api_versioning/v1"
+	v2 "golang_roadmap/08_web_development/23_api_versioning/v2"
+	"golang_roadmap/08_web_development/23_api_versioning/version"
+)
+
+// routes mounts both versions under /api/v1 and /api/v2, and under /api
+// with the version chosen by header.
+func routes(svc *users.Service, c clock.Clock, now time.Time) http.Handler {
+	old := version.Deprecate(version.Deprecation{
+		Since:  now.AddDate(0, -1, 0),
+		Sunset: now.AddDate(0, 3, 0),
+		// Each version sees paths without its prefix, such as /users/1.
+		Successor: func(r *http.Request) string { return "/api/v2" + r.URL.Path },
+		Info:      "https://example.com/docs/api/v2-migration",
+		Clock:     c,
+	}, v1.Handler(svc))
+	cur := v2.Handler(svc)
+
+	mux := http.NewServeMux()
+	mux.Handle("/api/v1/", http.StripPrefix("/api/v1", old))
+	mux.Handle("/api/v2/", http.StripPrefix("/api/v2", cur))
+	mux.Handle("/api/", http.StripPrefix("/api", &version.Negotiator{
+		Versions: map[int]http.Handler{1: old, 2: cur},
+		Default:  1,
+		Vendor:   "application/vnd.roadmap",
+	}))
+	return mux
+}
+
+func call(srv *httptest.Server, method, path, body string, header ...string) {
+	req, err := http.NewRequest(method, srv.URL+path, strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	for i := 0; i+1 < len(header); i += 2 {
+		req.Header.Set(header[i], header[i+1])
+	}
+	resp, err := srv.Client().Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	label := method + " " + path
+	for i := 0; i+1 < len(header); i += 2 {
+		label += fmt.Sprintf(" (%s: %s)", header[i], header[i+1])
+	}
+	fmt.Printf("    %s -> %d %s\n", label, resp.StatusCode, strings.TrimSpace(string(b)))
+	for _, h := range []string{version.Header, "Deprecation", "Sunset", "Link"} {
+		for _, v := range resp.Header.Values(h) {
+			fmt.Printf("        %s: %s\n", h, v)
+		}
+	}
+}
+
+func main() {
+	addr := flag.String("serve", "", "after the demo, serve the API on this address, such as :8080")
+	flag.Parse()
+	log.SetFlags(0)
+
+	fmt.Println("API versioning examples starting...")
+	now := time.Now().UTC().Truncate(24 * time.Hour)
+	fc := clock.NewFake(now)
+	svc := users.New(fc)
+	srv := httptest.NewServer(routes(svc, fc, now))
+	defer srv.Close()
+
+	// 1) Version 1 was built around a single name. Version 2 has the two
+	// parts, a status and the creation time.
+	fmt.Println("\n1) One user, two shapes")
+	call(srv, "POST", "/api/v1/users", `{"name":"Ada Lovelace","email":"ada@example.com"}`)
+	call(srv, "GET", "/api/v1/users/1", "")
+	call(srv, "GET", "/api/v2/users/1", "")
+
+	// 2) The service has one model. Both versions read and write it.
+	fmt.Println("\n2) Written through v2, read through v1")
+	call(srv, "POST", "/api/v2/users", `{"given_name":"Grace","family_name":"Hopper","email":"grace@example.com"}`)
+	svc.Suspend(context.Background(), 2)
+	call(srv, "GET", "/api/v1/users", "")
+	call(srv, "GET", "/api/v2/users", "")
+
+	// 3) Errors changed shape too: plain text in v1, JSON in v2.
+	fmt.Println("\n3) Errors")
+	call(srv, "GET", "/api/v1/users/9", "")
+	call(srv, "GET", "/api/v2/users/9", "")
+	call(srv, "POST", "/api/v2/users", `{"name":"Alan Turing","email":"alan@example.com"}`)
+
+	// 4) The same URL for every version; a header picks one.
+	fmt.Println("\n4) The version from a header")
+	call(srv, "GET", "/api/users/1", "", version.Header, "2")
+	call(srv, "GET", "/api/users/1", "", "Accept", "application/vnd.roadmap.v2+json")
+	call(srv, "GET", "/api/users/1", "")
+	call(srv, "GET", "/api/users/1", "", version.Header, "3")
+
+	// 5) Three months on, v1 is switched off. The headers still say where
+	// to go.
+	fmt.Println("\n5) After the sunset")
+	fc.Advance(now.AddDate(0, 3, 0).Sub(now))
+	call(srv, "GET", "/api/v1/users/1", "")
+	call(srv, "GET", "/api/v2/users/1", "")
+
+	if *addr != "" {
+		log.Printf("serving on %s: curl -H 'API-Version: 2' localhost%s/api/users", *addr, *addr)
+		srv := &http.Server{Addr: *addr, Handler: routes(users.New(nil), clock.Real(), time.Now()), ReadHeaderTimeout: 5 * time.Second}
+		log.Fatal(srv.ListenAndServe())
+	}
+}
@@ -0,0 +1,70 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+var t0 = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func TestCreate(t *testing.T) {
+	ctx := context.Background()
+	s := New(clock.NewFake(t0))
+	u, err := s.Create(ctx, NewUser{GivenName: " Ada ", FamilyName: "Lovelace", Email: "ada@example.com"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := User{ID: 1, GivenName: "Ada", FamilyName: "Lovelace", Email: "ada@example.com", Status: Active, CreatedAt: t0}
+	if u != want {
+		t.Errorf("got %+v, want %+v", u, want)
+	}
+	if got, err := s.Get(ctx, 1); err != nil || got != want {
+		t.Errorf("Get: %+v, %v", got, err)
+	}
+}
+
+func TestCreateInvalid(t *testing.T) {
+	s := New(nil)
+	for _, in := range []NewUser{
+		{GivenName: "", Email: "ada@example.com"},
+		{GivenName: "Ada", Email: "ada"},
+		{GivenName: "Ada", Email: "Ada <ada@example.com>"},
+	} {
+		if _, err := s.Create(context.Background(), in); !errors.Is(err, ErrInvalid) {
+			t.Errorf("%+v: got %v, want ErrInvalid", in, err)
+		}
+	}
+}
+
+func TestListAndSuspend(t *testing.T) {
+	ctx := context.Background()
+	s := New(nil)
+	for _, name := range []string{"Ada", "Grace", "Alan"} {
+		if _, err := s.Create(ctx, NewUser{GivenName: name, Email: name + "@example.com"}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := s.Suspend(ctx, 2); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Suspend(ctx, 9); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Suspend(9): %v", err)
+	}
+	list, err := s.List(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(list) != 3 || list[0].GivenName != "Ada" || list[2].GivenName != "Alan" {
+		t.Fatalf("List: %+v", list)
+	}
+	if list[1].Status != Suspended || list[0].Status != Active {
+		t.Errorf("statuses: %s, %s", list[0].Status, list[1].Status)
+	}
+	if _, err := s.Get(ctx, 9); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Get(9): %v", err)
+	}
+}
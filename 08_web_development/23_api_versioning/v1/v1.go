@@ -0,0 +1,122 @@
+// Package v1 is version 1 of the users API, the shape the first clients
+// were built against. It is frozen: the service behind it has moved on,
+// and this package maps the new model back to the old shape.
+package v1
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"golang_roadmap/08_web_development/23_api_versioning/users"
+)
+
+// User is a user in version 1: one name, and active as a boolean.
+type User struct {
+	ID     int64  `json:"id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+	Active bool   `json:"active"`
+}
+
+// NewUser is the body of POST /users in version 1.
+type NewUser struct {
+	Name  string `json:"name"`
+	Email string `json:"email"`
+}
+
+// FromUser maps a service user to the version 1 shape. The name is the
+// two parts joined again; a user without a family name has just the
+// given name.
+func FromUser(u users.User) User {
+	return User{
+		ID:     u.ID,
+		Name:   strings.TrimSpace(u.GivenName + " " + u.FamilyName),
+		Email:  u.Email,
+		Active: u.Status == users.Active,
+	}
+}
+
+// ToNewUser maps a version 1 request to the service's input. A single
+// name has to be split, and the split is a guess: the last word is taken
+// as the family name. That is why version 2 asks for the parts.
+func (n NewUser) ToNewUser() users.NewUser {
+	name := strings.Join(strings.Fields(n.Name), " ")
+	given, family := name, ""
+	if i := strings.LastIndexByte(name, ' '); i >= 0 {
+		given, family = name[:i], name[i+1:]
+	}
+	return users.NewUser{GivenName: given, FamilyName: family, Email: n.Email}
+}
+
+// Handler serves version 1:
+//
+//	GET  /users       a JSON array of users
+//	POST /users       {"name": "...", "email": "..."} -> 201
+//	GET  /users/{id}
+//
+// Errors are plain text, as they always were in version 1.
+func Handler(svc *users.Service) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		list, err := svc.List(r.Context())
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		out := make([]User, len(list))
+		for i, u := range list {
+			out[i] = FromUser(u)
+		}
+		writeJSON(w, http.StatusOK, out)
+	})
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+		var in NewUser
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		u, err := svc.Create(r.Context(), in.ToNewUser())
+		if errors.Is(err, users.ErrInvalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		} else if err != nil {
+			serverError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusCreated, FromUser(u))
+	})
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			http.Error(w, "Invalid user ID", http.StatusBadRequest)
+			return
+		}
+		u, err := svc.Get(r.Context(), id)
+		if errors.Is(err, users.ErrNotFound) {
+			http.Error(w, "User not found", http.StatusNotFound)
+			return
+		} else if err != nil {
+			serverError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, FromUser(u))
+	})
+	return mux
+}
+
+func serverError(w http.ResponseWriter, err error) {
+	log.Printf("v1: %v", err)
+	http.Error(w, "Internal server error", http.StatusInternalServerError)
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("v1: encoding response: %v", err)
+	}
+}
@@ -0,0 +1,87 @@
+package v1
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"golang_roadmap/08_web_development/23_api_versioning/users"
+)
+
+func TestToNewUser(t *testing.T) {
+	for _, tt := range []struct {
+		name, given, family string
+	}{
+		{"Ada Lovelace", "Ada", "Lovelace"},
+		{"  Grace   Brewster  Hopper ", "Grace Brewster", "Hopper"},
+		{"Cher", "Cher", ""},
+		{"", "", ""},
+	} {
+		got := NewUser{Name: tt.name}.ToNewUser()
+		if got.GivenName != tt.given || got.FamilyName != tt.family {
+			t.Errorf("%q: got %q %q, want %q %q", tt.name, got.GivenName, got.FamilyName, tt.given, tt.family)
+		}
+	}
+}
+
+func TestFromUser(t *testing.T) {
+	for _, tt := range []struct {
+		u    users.User
+		want User
+	}{
+		{users.User{ID: 1, GivenName: "Ada", FamilyName: "Lovelace", Email: "a@x.org", Status: users.Active}, User{1, "Ada Lovelace", "a@x.org", true}},
+		{users.User{ID: 2, GivenName: "Cher", Email: "c@x.org", Status: users.Suspended}, User{2, "Cher", "c@x.org", false}},
+	} {
+		if got := FromUser(tt.u); got != tt.want {
+			t.Errorf("got %+v, want %+v", got, tt.want)
+		}
+	}
+}
+
+func do(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+// TestHandler pins the version 1 wire format. It must not change while
+// version 1 is served, whatever happens to the service.
+func TestHandler(t *testing.T) {
+	svc := users.New(nil)
+	h := Handler(svc)
+
+	w := do(h, "POST", "/users", `{"name": "Ada Lovelace", "email": "ada@example.com", "ignored": true}`)
+	if w.Code != 201 || strings.TrimSpace(w.Body.String()) != `{"id":1,"name":"Ada Lovelace","email":"ada@example.com","active":true}` {
+		t.Fatalf("create: %d %s", w.Code, w.Body)
+	}
+	svc.Suspend(context.Background(), 1)
+	if w := do(h, "GET", "/users", ""); w.Code != 200 || strings.TrimSpace(w.Body.String()) != `[{"id":1,"name":"Ada Lovelace","email":"ada@example.com","active":false}]` {
+		t.Errorf("list: %d %s", w.Code, w.Body)
+	}
+	if w := do(h, "GET", "/users/1", ""); w.Code != 200 || !strings.Contains(w.Body.String(), `"name":"Ada Lovelace"`) {
+		t.Errorf("get: %d %s", w.Code, w.Body)
+	}
+	for _, tt := range []struct {
+		method, path, body string
+		code               int
+		text               string
+	}{
+		{"GET", "/users/2", "", 404, "User not found"},
+		{"GET", "/users/x", "", 400, "Invalid user ID"},
+		{"POST", "/users", `{`, 400, "Invalid JSON"},
+		{"POST", "/users", `{"name": "Ada", "email": "nope"}`, 400, "invalid user"},
+	} {
+		w := do(h, tt.method, tt.path, tt.body)
+		if w.Code != tt.code || !strings.HasPrefix(w.Header().Get("Content-Type"), "text/plain") || !strings.Contains(w.Body.String(), tt.text) {
+			t.Errorf("%s %s %s: %d %q", tt.method, tt.path, tt.body, w.Code, w.Body)
+		}
+	}
+}
+
+func TestEmptyList(t *testing.T) {
+	if w := do(Handler(users.New(nil)), "GET", "/users", ""); strings.TrimSpace(w.Body.String()) != "[]" {
+		t.Errorf("got %s, want []", w.Body)
+	}
+}
@@ -0,0 +1,148 @@
+// Package v2 is version 2 of the users API. Compared with version 1 it
+// has a name in two parts, a status instead of an "active" flag, the
+// creation time, lists wrapped in an object, and errors as JSON. Each of
+// those would break a version 1 client, which is why they come as a new
+// version rather than as changes to the old one.
+package v2
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang_roadmap/08_web_development/23_api_versioning/users"
+)
+
+// User is a user in version 2.
+type User struct {
+	ID         int64     `json:"id"`
+	GivenName  string    `json:"given_name"`
+	FamilyName string    `json:"family_name"`
+	Email      string    `json:"email"`
+	Status     string    `json:"status"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// NewUser is the body of POST /users in version 2.
+type NewUser struct {
+	GivenName  string `json:"given_name"`
+	FamilyName string `json:"family_name"`
+	Email      string `json:"email"`
+}
+
+// List is the body of GET /users. An object, unlike version 1's bare
+// array, has room for fields such as a next-page cursor without another
+// version.
+type List struct {
+	Data []User `json:"data"`
+}
+
+// Error is the body of every error response.
+type Error struct {
+	Error struct {
+		Code    string `json:"code"`
+		Message string `json:"message"`
+	} `json:"error"`
+}
+
+// FromUser maps a service user to the version 2 shape.
+func FromUser(u users.User) User {
+	return User{
+		ID:         u.ID,
+		GivenName:  u.GivenName,
+		FamilyName: u.FamilyName,
+		Email:      u.Email,
+		Status:     string(u.Status),
+		CreatedAt:  u.CreatedAt,
+	}
+}
+
+// ToNewUser maps a version 2 request to the service's input.
+func (n NewUser) ToNewUser() users.NewUser {
+	return users.NewUser{GivenName: n.GivenName, FamilyName: n.FamilyName, Email: n.Email}
+}
+
+// Handler serves version 2:
+//
+//	GET  /users       {"data": [...]}
+//	POST /users       {"given_name": "...", "family_name": "...", "email": "..."} -> 201
+//	GET  /users/{id}
+func Handler(svc *users.Service) http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", func(w http.ResponseWriter, r *http.Request) {
+		list, err := svc.List(r.Context())
+		if err != nil {
+			serverError(w, err)
+			return
+		}
+		out := List{Data: make([]User, len(list))}
+		for i, u := range list {
+			out.Data[i] = FromUser(u)
+		}
+		writeJSON(w, http.StatusOK, out)
+	})
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+		var in NewUser
+		dec := json.NewDecoder(r.Body)
+		// Version 1 ignored unknown fields, so a client sending "name"
+		// here would create a user without one and never know.
+		dec.DisallowUnknownFields()
+		if err := dec.Decode(&in); err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_json", "Invalid JSON: "+err.Error())
+			return
+		}
+		u, err := svc.Create(r.Context(), in.ToNewUser())
+		if errors.Is(err, users.ErrInvalid) {
+			writeError(w, http.StatusUnprocessableEntity, "invalid_user", err.Error())
+			return
+		} else if err != nil {
+			serverError(w, err)
+			return
+		}
+		// Relative to /users, so it is right under whatever prefix the
+		// version is mounted.
+		w.Header().Set("Location", fmt.Sprintf("users/%d", u.ID))
+		writeJSON(w, http.StatusCreated, FromUser(u))
+	})
+	mux.HandleFunc("GET /users/{id}", func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, "invalid_id", "Invalid user ID")
+			return
+		}
+		u, err := svc.Get(r.Context(), id)
+		if errors.Is(err, users.ErrNotFound) {
+			writeError(w, http.StatusNotFound, "not_found", "User not found")
+			return
+		} else if err != nil {
+			serverError(w, err)
+			return
+		}
+		writeJSON(w, http.StatusOK, FromUser(u))
+	})
+	return mux
+}
+
+func writeError(w http.ResponseWriter, code int, errCode, msg string) {
+	var e Error
+	e.Error.Code = errCode
+	e.Error.Message = msg
+	writeJSON(w, code, e)
+}
+
+func serverError(w http.ResponseWriter, err error) {
+	log.Printf("v2: %v", err)
+	writeError(w, http.StatusInternalServerError, "internal", "Internal server error")
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	if err := json.NewEncoder(w).Encode(v); err != nil {
+		log.Printf("v2: encoding response: %v", err)
+	}
+}
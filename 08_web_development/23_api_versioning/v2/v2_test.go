@@ -0,0 +1,89 @@
+package v2
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+	"golang_roadmap/08_web_development/23_api_versioning/users"
+	v1 "golang_roadmap/08_web_development/23_api_versioning/v1"
+)
+
+var t0 = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func do(h http.Handler, method, path, body string) *httptest.ResponseRecorder {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return w
+}
+
+// TestHandler pins the version 2 wire format.
+func TestHandler(t *testing.T) {
+	h := Handler(users.New(clock.NewFake(t0)))
+
+	w := do(h, "POST", "/users", `{"given_name": "Ada", "family_name": "Lovelace", "email": "ada@example.com"}`)
+	want := `{"id":1,"given_name":"Ada","family_name":"Lovelace","email":"ada@example.com","status":"active","created_at":"2024-05-01T12:00:00Z"}`
+	if w.Code != 201 || strings.TrimSpace(w.Body.String()) != want || w.Header().Get("Location") != "users/1" {
+		t.Fatalf("create: %d %s %v", w.Code, w.Body, w.Header())
+	}
+	if w := do(h, "GET", "/users", ""); w.Code != 200 || strings.TrimSpace(w.Body.String()) != `{"data":[`+want+`]}` {
+		t.Errorf("list: %d %s", w.Code, w.Body)
+	}
+	if w := do(h, "GET", "/users/1", ""); w.Code != 200 || strings.TrimSpace(w.Body.String()) != want {
+		t.Errorf("get: %d %s", w.Code, w.Body)
+	}
+}
+
+func TestEmptyList(t *testing.T) {
+	if w := do(Handler(users.New(nil)), "GET", "/users", ""); strings.TrimSpace(w.Body.String()) != `{"data":[]}` {
+		t.Errorf("got %s", w.Body)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	h := Handler(users.New(nil))
+	for _, tt := range []struct {
+		method, path, body string
+		code               int
+		errCode            string
+	}{
+		{"GET", "/users/1", "", 404, "not_found"},
+		{"GET", "/users/x", "", 400, "invalid_id"},
+		{"POST", "/users", `{`, 400, "invalid_json"},
+		// The version 1 body. Version 2 refuses it instead of creating a
+		// user with no name.
+		{"POST", "/users", `{"name": "Ada Lovelace", "email": "ada@example.com"}`, 400, "invalid_json"},
+		{"POST", "/users", `{"given_name": "Ada", "email": "nope"}`, 422, "invalid_user"},
+	} {
+		w := do(h, tt.method, tt.path, tt.body)
+		var e Error
+		if err := json.Unmarshal(w.Body.Bytes(), &e); err != nil || w.Code != tt.code || e.Error.Code != tt.errCode || e.Error.Message == "" {
+			t.Errorf("%s %s %s: %d %s", tt.method, tt.path, tt.body, w.Code, w.Body)
+		}
+	}
+}
+
+// TestSameData checks that both versions show the same users: one
+// created through version 1 is read through version 2, and back.
+func TestSameData(t *testing.T) {
+	svc := users.New(nil)
+	old, cur := v1.Handler(svc), Handler(svc)
+
+	do(old, "POST", "/users", `{"name": "Grace Brewster Hopper", "email": "grace@example.com"}`)
+	var u User
+	json.Unmarshal(do(cur, "GET", "/users/1", "").Body.Bytes(), &u)
+	if u.GivenName != "Grace Brewster" || u.FamilyName != "Hopper" || u.Status != "active" {
+		t.Errorf("v1 user in v2: %+v", u)
+	}
+
+	do(cur, "POST", "/users", `{"given_name": "Alan", "family_name": "Turing", "email": "alan@example.com"}`)
+	var o v1.User
+	json.Unmarshal(do(old, "GET", "/users/2", "").Body.Bytes(), &o)
+	if o != (v1.User{ID: 2, Name: "Alan Turing", Email: "alan@example.com", Active: true}) {
+		t.Errorf("v2 user in v1: %+v", o)
+	}
+}
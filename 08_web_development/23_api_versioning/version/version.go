@@ -0,0 +1,163 @@
+// Package version routes a request to one version of an API by header,
+// as an alternative to a version in the URL, and marks old versions
+// deprecated with the Deprecation (RFC 9745), Sunset (RFC 8594) and Link
+// headers.
+package version
+
+import (
+	"fmt"
+	"maps"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+// Header is the request header that asks for a version, and the response
+// header that says which version answered.
+const Header = "API-Version"
+
+// Negotiator serves each request with the version it asks for, through
+// the API-Version header ("API-Version: 2") or a vendor media type in
+// Accept ("Accept: application/vnd.example.v2+json"). The URL stays the
+// same across versions.
+type Negotiator struct {
+	// Versions maps version numbers to their handlers.
+	Versions map[int]http.Handler
+	// Default serves requests that ask for no version. Keep it at the
+	// oldest version still served: moving it changes the API under every
+	// client that never chose.
+	Default int
+	// Vendor is the media type prefix in Accept, such as
+	// "application/vnd.example" for "application/vnd.example.v2+json".
+	// Empty: Accept is not used.
+	Vendor string
+}
+
+func (n *Negotiator) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	// Caches must keep one copy per version for the same URL.
+	w.Header().Add("Vary", "Accept, "+Header)
+	v, err := n.requested(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	h, ok := n.Versions[v]
+	if !ok {
+		// Asked for in Accept: "I cannot produce that" is 406. Asked
+		// for in the header, it is a bad request.
+		code := http.StatusBadRequest
+		if r.Header.Get(Header) == "" {
+			code = http.StatusNotAcceptable
+		}
+		http.Error(w, fmt.Sprintf("Unsupported API version %d; supported: %s", v, n.supported()), code)
+		return
+	}
+	w.Header().Set(Header, strconv.Itoa(v))
+	h.ServeHTTP(w, r)
+}
+
+// requested returns the version r asks for, or Default.
+func (n *Negotiator) requested(r *http.Request) (int, error) {
+	fromHeader, fromAccept := 0, 0
+	if h := r.Header.Get(Header); h != "" {
+		v, err := strconv.Atoi(strings.TrimPrefix(strings.TrimSpace(h), "v"))
+		if err != nil || v <= 0 {
+			return 0, fmt.Errorf("%s %q is not a version number", Header, h)
+		}
+		fromHeader = v
+	}
+	if n.Vendor != "" {
+		fromAccept = FromAccept(r.Header.Get("Accept"), n.Vendor)
+	}
+	switch {
+	case fromHeader != 0 && fromAccept != 0 && fromHeader != fromAccept:
+		return 0, fmt.Errorf("%s %d and Accept version %d disagree", Header, fromHeader, fromAccept)
+	case fromHeader != 0:
+		return fromHeader, nil
+	case fromAccept != 0:
+		return fromAccept, nil
+	}
+	return n.Default, nil
+}
+
+func (n *Negotiator) supported() string {
+	var vs []string
+	for _, v := range slices.Sorted(maps.Keys(n.Versions)) {
+		vs = append(vs, strconv.Itoa(v))
+	}
+	return strings.Join(vs, ", ")
+}
+
+// FromAccept returns the version in the first media type of accept that
+// is vendor's, such as 2 for "application/vnd.example.v2+json" with
+// vendor "application/vnd.example", or 0 if there is none. Quality values
+// are ignored: a client names one version, not a preference between
+// several.
+func FromAccept(accept, vendor string) int {
+	prefix := strings.ToLower(vendor) + ".v"
+	for _, part := range strings.Split(accept, ",") {
+		mt, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil || !strings.HasPrefix(mt, prefix) {
+			continue
+		}
+		num := strings.TrimSuffix(mt[len(prefix):], "+json")
+		if v, err := strconv.Atoi(num); err == nil && v > 0 {
+			return v
+		}
+	}
+	return 0
+}
+
+// Deprecation describes a version that is on its way out.
+type Deprecation struct {
+	// Since is when the version was deprecated.
+	Since time.Time
+	// Sunset is when it stops working (zero: not decided yet). From
+	// then on, requests get 410 Gone.
+	Sunset time.Time
+	// Successor returns the URL of the same resource in the version that
+	// replaces this one (nil: no link).
+	Successor func(r *http.Request) string
+	// Info is a page that explains the change (optional).
+	Info string
+	// Clock tells when the sunset has come (nil: clock.Real).
+	Clock clock.Clock
+}
+
+// Deprecate adds the deprecation headers to every response from next:
+//
+//	Deprecation: @1717200000
+//	Sunset: Sun, 01 Dec 2024 00:00:00 GMT
+//	Link: </api/v2/users>; rel="successor-version", <https://...>; rel="deprecation"
+//
+// Clients and their tooling can warn from these long before the version
+// goes. After Sunset, next is no longer called and requests get 410 Gone,
+// with the same headers to say where to go.
+func Deprecate(d Deprecation, next http.Handler) http.Handler {
+	if d.Clock == nil {
+		d.Clock = clock.Real()
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		h := w.Header()
+		h.Set("Deprecation", "@"+strconv.FormatInt(d.Since.Unix(), 10))
+		if !d.Sunset.IsZero() {
+			h.Set("Sunset", d.Sunset.UTC().Format(http.TimeFormat))
+		}
+		if d.Successor != nil {
+			h.Add("Link", fmt.Sprintf(`<%s>; rel="successor-version"`, d.Successor(r)))
+		}
+		if d.Info != "" {
+			h.Add("Link", fmt.Sprintf(`<%s>; rel="deprecation"`, d.Info))
+		}
+		if !d.Sunset.IsZero() && !d.Clock.Now().Before(d.Sunset) {
+			http.Error(w, "This API version was retired on "+d.Sunset.UTC().Format(time.DateOnly), http.StatusGone)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
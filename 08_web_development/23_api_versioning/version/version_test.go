@@ -0,0 +1,136 @@
+package version
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+var t0 = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+// answer is a handler that writes its name.
+func answer(name string) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { io.WriteString(w, name) })
+}
+
+func TestFromAccept(t *testing.T) {
+	const vendor = "application/vnd.example"
+	for accept, want := range map[string]int{
+		"":                                0,
+		"application/json":                0,
+		"application/vnd.example.v2+json": 2,
+		"application/vnd.example.v3":      3,
+		"Application/VND.Example.V2+JSON": 2, // media types ignore case
+		"text/html, application/vnd.example.v12+json; q=0.9":               12,
+		"application/vnd.example.v1+json, application/vnd.example.v2+json": 1,
+		"application/vnd.example.vx+json":                                  0,
+		"application/vnd.example.v0+json":                                  0,
+		"application/vnd.other.v2+json":                                    0,
+		"application/vnd.example.v2+json;;bad":                             0,
+	} {
+		if got := FromAccept(accept, vendor); got != want {
+			t.Errorf("FromAccept(%q) = %d, want %d", accept, got, want)
+		}
+	}
+}
+
+func TestNegotiator(t *testing.T) {
+	n := &Negotiator{
+		Versions: map[int]http.Handler{1: answer("one"), 2: answer("two")},
+		Default:  1,
+		Vendor:   "application/vnd.example",
+	}
+	for _, tt := range []struct {
+		name, header, accept string
+		code                 int
+		body                 string
+	}{
+		{"nothing asked", "", "", 200, "one"},
+		{"header", "2", "", 200, "two"},
+		{"header with v", "v2", "", 200, "two"},
+		{"accept", "", "application/vnd.example.v2+json", 200, "two"},
+		{"plain accept", "", "application/json", 200, "one"},
+		{"both agree", "2", "application/vnd.example.v2+json", 200, "two"},
+		{"both disagree", "1", "application/vnd.example.v2+json", 400, "disagree"},
+		{"bad header", "latest", "", 400, "not a version number"},
+		{"unknown in header", "3", "", 400, "supported: 1, 2"},
+		{"unknown in accept", "", "application/vnd.example.v3+json", 406, "supported: 1, 2"},
+	} {
+		r := httptest.NewRequest("GET", "/users", nil)
+		if tt.header != "" {
+			r.Header.Set(Header, tt.header)
+		}
+		if tt.accept != "" {
+			r.Header.Set("Accept", tt.accept)
+		}
+		w := httptest.NewRecorder()
+		n.ServeHTTP(w, r)
+		if w.Code != tt.code || !strings.Contains(w.Body.String(), tt.body) {
+			t.Errorf("%s: %d %q, want %d %q", tt.name, w.Code, w.Body, tt.code, tt.body)
+		}
+		if vary := w.Header().Get("Vary"); vary != "Accept, API-Version" {
+			t.Errorf("%s: Vary %q", tt.name, vary)
+		}
+		if want := map[string]string{"one": "1", "two": "2"}[tt.body]; w.Header().Get(Header) != want {
+			t.Errorf("%s: %s %q, want %q", tt.name, Header, w.Header().Get(Header), want)
+		}
+	}
+}
+
+func TestDeprecate(t *testing.T) {
+	fc := clock.NewFake(t0)
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	sunset := time.Date(2024, 12, 1, 0, 0, 0, 0, time.UTC)
+	h := Deprecate(Deprecation{
+		Since:     since,
+		Sunset:    sunset,
+		Successor: func(r *http.Request) string { return "/api/v2" + r.URL.Path },
+		Info:      "https://example.com/docs/v2-migration",
+		Clock:     fc,
+	}, answer("one"))
+
+	get := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest("GET", "/users/1", nil))
+		return w
+	}
+	w := get()
+	if w.Code != 200 || w.Body.String() != "one" {
+		t.Fatalf("before the sunset: %d %s", w.Code, w.Body)
+	}
+	want := http.Header{
+		"Deprecation": {"@1704067200"},
+		"Sunset":      {"Sun, 01 Dec 2024 00:00:00 GMT"},
+		"Link": {
+			`</api/v2/users/1>; rel="successor-version"`,
+			`<https://example.com/docs/v2-migration>; rel="deprecation"`,
+		},
+	}
+	for k, v := range want {
+		if got := w.Header().Values(k); strings.Join(got, "|") != strings.Join(v, "|") {
+			t.Errorf("%s: %q, want %q", k, got, v)
+		}
+	}
+
+	fc.Advance(sunset.Sub(t0) - time.Second)
+	if w := get(); w.Code != 200 {
+		t.Errorf("a second before the sunset: %d", w.Code)
+	}
+	fc.Advance(time.Second)
+	if w := get(); w.Code != http.StatusGone || w.Header().Get("Link") == "" {
+		t.Errorf("at the sunset: %d %v", w.Code, w.Header())
+	}
+}
+
+func TestDeprecateWithoutSunset(t *testing.T) {
+	w := httptest.NewRecorder()
+	Deprecate(Deprecation{Since: t0}, answer("one")).ServeHTTP(w, httptest.NewRequest("GET", "/", nil))
+	if w.Code != 200 || w.Header().Get("Deprecation") == "" || w.Header().Get("Sunset") != "" || w.Header().Get("Link") != "" {
+		t.Errorf("%d %v", w.Code, w.Header())
+	}
+}
@@ -0,0 +1,273 @@
+package graph
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+	"golang_roadmap/08_web_development/24_graphql/repo"
+)
+
+var t0 = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+type gqlError struct {
+	Message string `json:"message"`
+	Path    []any  `json:"path"`
+}
+
+type response struct {
+	Data   json.RawMessage `json:"data"`
+	Errors []gqlError      `json:"errors"`
+}
+
+type env struct {
+	t    *testing.T
+	repo *repo.Repo
+	h    http.Handler
+}
+
+func setup(t *testing.T, maxComplexity int) *env {
+	t.Helper()
+	r, err := repo.Open(filepath.Join(t.TempDir(), "shop.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return &env{t: t, repo: r, h: Handler(&Resolver{Repo: r, Clock: clock.NewFake(t0)}, maxComplexity)}
+}
+
+// do posts query and returns the response and how many SQL statements
+// it ran.
+func (e *env) do(query string, vars map[string]any) (response, int64) {
+	e.t.Helper()
+	body, _ := json.Marshal(map[string]any{"query": query, "variables": vars})
+	req := httptest.NewRequest("POST", "/query", bytes.NewReader(body))
+	req.Header.Set("Content-Type", "application/json")
+	rec := httptest.NewRecorder()
+	before := e.repo.Queries()
+	e.h.ServeHTTP(rec, req)
+	var resp response
+	if err := json.Unmarshal(rec.Body.Bytes(), &resp); err != nil {
+		e.t.Fatalf("%s: %v", rec.Body, err)
+	}
+	return resp, e.repo.Queries() - before
+}
+
+// ok is do for queries that must succeed; it decodes data into v.
+func (e *env) ok(query string, vars map[string]any, v any) int64 {
+	e.t.Helper()
+	resp, n := e.do(query, vars)
+	if len(resp.Errors) > 0 {
+		e.t.Fatalf("errors: %+v", resp.Errors)
+	}
+	if err := json.Unmarshal(resp.Data, v); err != nil {
+		e.t.Fatal(err)
+	}
+	return n
+}
+
+// fails runs query and checks that its only error contains want.
+func (e *env) fails(query string, vars map[string]any, want string) {
+	e.t.Helper()
+	resp, _ := e.do(query, vars)
+	if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, want) {
+		e.t.Fatalf("errors = %+v, want one containing %q", resp.Errors, want)
+	}
+}
+
+// seed makes users users with orders orders each.
+func (e *env) seed(users, orders int) {
+	ctx := context.Background()
+	for i := range users {
+		u, err := e.repo.CreateUser(ctx, "User", "user"+string(rune('a'+i))+"@example.com")
+		if err != nil {
+			e.t.Fatal(err)
+		}
+		for j := range orders {
+			if _, err := e.repo.CreateOrder(ctx, u.ID, 100*(j+1), t0); err != nil {
+				e.t.Fatal(err)
+			}
+		}
+	}
+}
+
+func TestMutationsAndQueries(t *testing.T) {
+	e := setup(t, 1000)
+	var created struct {
+		CreateUser struct{ ID, Name, Email string }
+	}
+	e.ok(`mutation($in: NewUser!) { createUser(input: $in) { id name email } }`,
+		map[string]any{"in": map[string]any{"name": " Ada Lovelace ", "email": "ada@example.com"}}, &created)
+	if created.CreateUser.ID != "1" || created.CreateUser.Name != "Ada Lovelace" {
+		t.Fatalf("createUser = %+v", created.CreateUser)
+	}
+
+	var order struct {
+		CreateOrder struct {
+			ID, Status, CreatedAt string
+			TotalCents            int
+			User                  struct{ Name string }
+		}
+	}
+	e.ok(`mutation { createOrder(input: {userId: 1, totalCents: 1250}) { id totalCents status createdAt user { name } } }`, nil, &order)
+	o := order.CreateOrder
+	if o.ID != "1" || o.TotalCents != 1250 || o.Status != "PENDING" || o.CreatedAt != "2024-05-01T12:00:00Z" || o.User.Name != "Ada Lovelace" {
+		t.Fatalf("createOrder = %+v", o)
+	}
+
+	var set struct {
+		SetOrderStatus struct{ Status string }
+	}
+	e.ok(`mutation { createOrder(input: {userId: 1, totalCents: 5}) { id } setOrderStatus(id: 1, status: PAID) { status } }`, nil, &set)
+	if set.SetOrderStatus.Status != "PAID" {
+		t.Fatalf("setOrderStatus = %+v", set)
+	}
+
+	var got struct {
+		User struct {
+			Orders []struct{ ID, Status string }
+		}
+		Missing *struct{ ID string }
+	}
+	e.ok(`{ user(id: 1) { orders { id status } } missing: user(id: 99) { id } }`, nil, &got)
+	if len(got.User.Orders) != 2 || got.User.Orders[0].Status != "PAID" || got.Missing != nil {
+		t.Fatalf("user = %+v", got)
+	}
+}
+
+func TestStatusChangeClearsLoader(t *testing.T) {
+	e := setup(t, 1000)
+	e.seed(1, 1)
+	// Mutation fields run one after the other. The first leaves order 1
+	// in the request's loader; the second must not answer from that copy.
+	var got struct {
+		First, Second struct{ Status string }
+	}
+	e.ok(`mutation { first: setOrderStatus(id: 1, status: PAID) { status } second: setOrderStatus(id: 1, status: SHIPPED) { status } }`, nil, &got)
+	if got.First.Status != "PAID" || got.Second.Status != "SHIPPED" {
+		t.Fatalf("statuses = %+v, want PAID then SHIPPED", got)
+	}
+}
+
+func TestInputErrors(t *testing.T) {
+	e := setup(t, 1000)
+	e.seed(1, 1)
+	e.fails(`mutation { createUser(input: {name: "", email: "x@example.com"}) { id } }`, nil, "name must be")
+	e.fails(`mutation { createUser(input: {name: "Ada", email: "not an address"}) { id } }`, nil, "is not an address")
+	e.fails(`mutation { createUser(input: {name: "Ada", email: "usera@example.com"}) { id } }`, nil, "already in use")
+	e.fails(`mutation { createOrder(input: {userId: 9, totalCents: 100}) { id } }`, nil, "no user 9")
+	e.fails(`mutation { createOrder(input: {userId: 1, totalCents: 0}) { id } }`, nil, "must be positive")
+	e.fails(`mutation { setOrderStatus(id: 9, status: PAID) { id } }`, nil, "no order 9")
+	e.fails(`{ users(first: 0) { id } }`, nil, "first must be between 1 and 100")
+	e.fails(`{ orders(first: 101) { id } }`, nil, "first must be between 1 and 100")
+	// Errors from gqlgen itself are shown as they are.
+	e.fails(`mutation { setOrderStatus(id: 1, status: LOST) { id } }`, nil, "LOST")
+	e.fails(`{ user(id: 1) { password } }`, nil, `Cannot query field "password"`)
+}
+
+func TestInternalErrorsAreHidden(t *testing.T) {
+	var logs bytes.Buffer
+	prev := log.Writer()
+	log.SetOutput(&logs)
+	t.Cleanup(func() { log.SetOutput(prev) })
+
+	e := setup(t, 1000)
+	e.seed(1, 0)
+	e.repo.Close()
+	resp, _ := e.do(`{ users { id } }`, nil)
+	if len(resp.Errors) != 1 || resp.Errors[0].Message != "internal error" {
+		t.Fatalf("errors = %+v, want one internal error", resp.Errors)
+	}
+	if !strings.Contains(logs.String(), "database is closed") {
+		t.Errorf("log = %q, want the real error", logs.String())
+	}
+}
+
+func TestBatching(t *testing.T) {
+	e := setup(t, 10000)
+	e.seed(20, 3)
+	// With the default 2ms wait, a field resolved late misses its batch
+	// and the counts below vary with scheduling. A long wait makes every
+	// level one batch.
+	e.h = Handler(&Resolver{Repo: e.repo, Clock: clock.NewFake(t0), LoaderWait: 200 * time.Millisecond}, 10000)
+
+	// One query for the users and one for all their orders, instead of
+	// one per user.
+	var users struct {
+		Users []struct {
+			Orders []struct{ ID string }
+		}
+	}
+	n := e.ok(`{ users(first: 20) { id orders { id } } }`, nil, &users)
+	if len(users.Users) != 20 || len(users.Users[0].Orders) != 3 {
+		t.Fatalf("users = %+v", users)
+	}
+	if n != 2 {
+		t.Errorf("users with orders ran %d queries, want 2", n)
+	}
+
+	// Each level of nesting adds one query, however many objects it has.
+	var orders struct {
+		Orders []struct {
+			User struct {
+				Orders []struct{ User struct{ Email string } }
+			}
+		}
+	}
+	n = e.ok(`{ orders(first: 60) { user { orders { user { email } } } } }`, nil, &orders)
+	if len(orders.Orders) != 60 {
+		t.Fatalf("got %d orders", len(orders.Orders))
+	}
+	if n != 3 {
+		t.Errorf("orders with users and their orders ran %d queries, want 3", n)
+	}
+}
+
+func TestLoadersArePerRequest(t *testing.T) {
+	e := setup(t, 1000)
+	e.seed(1, 0)
+	var got struct{ User struct{ Name string } }
+	e.ok(`{ user(id: 1) { name } }`, nil, &got)
+	if _, err := e.repo.CreateUser(context.Background(), "Grace", "grace@example.com"); err != nil {
+		t.Fatal(err)
+	}
+	// A cache shared between requests would still say user 2 is missing.
+	var again struct{ User *struct{ Name string } }
+	e.ok(`{ user(id: 2) { name } }`, nil, &again)
+	e.ok(`{ user(id: 2) { name } }`, nil, &again)
+	if again.User == nil || again.User.Name != "Grace" {
+		t.Fatalf("user 2 = %+v", again.User)
+	}
+}
+
+func TestComplexityLimit(t *testing.T) {
+	e := setup(t, 500)
+	e.seed(2, 2)
+	var ok struct{ Users []struct{ ID string } }
+	e.ok(`{ users(first: 100) { id } }`, nil, &ok)
+
+	// 100 users, each with an assumed 10 orders, each with a user: too
+	// much, and refused before any SQL runs.
+	resp, n := e.do(`{ users(first: 100) { orders { user { name } } } }`, nil)
+	if len(resp.Errors) != 1 || !strings.Contains(resp.Errors[0].Message, "complexity") {
+		t.Fatalf("errors = %+v, want a complexity error", resp.Errors)
+	}
+	if n != 0 {
+		t.Errorf("refused query ran %d SQL statements", n)
+	}
+
+	// The same shape with a small page is allowed.
+	var small struct{ Users []struct{ ID string } }
+	e.ok(`{ users(first: 2) { id orders { user { name } } } }`, nil, &small)
+	if len(small.Users) != 2 {
+		t.Fatalf("users = %+v", small.Users)
+	}
+}
@@ -0,0 +1,36 @@
+package model
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/99designs/gqlgen/graphql"
+)
+
+// MarshalID writes an int64 ID as a GraphQL ID. IDs are strings on the
+// wire, as the GraphQL spec says, so clients do not treat them as numbers.
+func MarshalID(id int64) graphql.Marshaler {
+	return graphql.WriterFunc(func(w io.Writer) {
+		io.WriteString(w, strconv.Quote(strconv.FormatInt(id, 10)))
+	})
+}
+
+// UnmarshalID reads an ID argument, which clients may send as a string
+// or a number, as an int64.
+func UnmarshalID(v any) (int64, error) {
+	switch v := v.(type) {
+	case string:
+		id, err := strconv.ParseInt(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%q is not an ID", v)
+		}
+		return id, nil
+	case int64:
+		return v, nil
+	case int:
+		return int64(v), nil
+	default:
+		return graphql.UnmarshalInt64(v)
+	}
+}
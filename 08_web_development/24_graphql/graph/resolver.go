@@ -0,0 +1,32 @@
+// Package graph is the GraphQL API over the repo package. The schema is
+// in schema.graphqls; gqlgen generates the executor (generated.go) and
+// the input and enum types (model), and the resolvers in
+// schema.resolvers.go call the repository through the dataloaders.
+package graph
+
+//go:generate go tool gqlgen generate
+
+import (
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+	"golang_roadmap/08_web_development/24_graphql/repo"
+)
+
+// Resolver holds what the resolvers need. One is shared by all requests;
+// per-request state, such as the dataloaders, is in the context.
+type Resolver struct {
+	Repo *repo.Repo
+	// Clock stamps new orders (nil: clock.Real).
+	Clock clock.Clock
+	// LoaderWait is how long the dataloaders collect keys before a batch
+	// (0: loader.DefaultWait).
+	LoaderWait time.Duration
+}
+
+func (r *Resolver) now() time.Time {
+	if r.Clock == nil {
+		return time.Now()
+	}
+	return r.Clock.Now()
+}
@@ -0,0 +1,129 @@
+package graph
+
+// This file will be automatically regenerated based on the schema, any resolver implementations
+// will be copied through when generating and any unknown code will be moved to the end.
+// Code generated by github.com/99designs/gqlgen version v0.17.81
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"golang_roadmap/08_web_development/24_graphql/graph/model"
+	"golang_roadmap/08_web_development/24_graphql/loader"
+	"golang_roadmap/08_web_development/24_graphql/repo"
+	"net/mail"
+	"strings"
+	"unicode/utf8"
+)
+
+// CreateUser is the resolver for the createUser field.
+func (r *mutationResolver) CreateUser(ctx context.Context, input model.NewUser) (*repo.User, error) {
+	name := strings.TrimSpace(input.Name)
+	if name == "" || utf8.RuneCountInString(name) > 100 {
+		return nil, inputErrorf("name must be 1 to 100 characters")
+	}
+	if a, err := mail.ParseAddress(input.Email); err != nil || a.Address != input.Email {
+		return nil, inputErrorf("email %q is not an address", input.Email)
+	}
+	u, err := r.Repo.CreateUser(ctx, name, input.Email)
+	if errors.Is(err, repo.ErrEmailTaken) {
+		return nil, inputErrorf("email %s is already in use", input.Email)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+// CreateOrder is the resolver for the createOrder field.
+func (r *mutationResolver) CreateOrder(ctx context.Context, input model.NewOrder) (*repo.Order, error) {
+	if input.TotalCents <= 0 {
+		return nil, inputErrorf("totalCents must be positive")
+	}
+	o, err := r.Repo.CreateOrder(ctx, input.UserID, input.TotalCents, r.now())
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, inputErrorf("no user %d", input.UserID)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &o, nil
+}
+
+// SetOrderStatus is the resolver for the setOrderStatus field.
+func (r *mutationResolver) SetOrderStatus(ctx context.Context, id int64, status model.OrderStatus) (*repo.Order, error) {
+	err := r.Repo.SetStatus(ctx, id, repo.Status(strings.ToLower(status.String())))
+	if errors.Is(err, repo.ErrNotFound) {
+		return nil, inputErrorf("no order %d", id)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// The loader may hold the order as it was before the change, if this
+	// request read it already.
+	l := loader.For(ctx)
+	l.OrderByID.Clear(ctx, id)
+	return l.Order(ctx, id)
+}
+
+// User is the resolver for the user field.
+func (r *orderResolver) User(ctx context.Context, obj *repo.Order) (*repo.User, error) {
+	u, err := loader.For(ctx).User(ctx, obj.UserID)
+	if err == nil && u == nil {
+		err = fmt.Errorf("order %d: user %d not found", obj.ID, obj.UserID)
+	}
+	return u, err
+}
+
+// Status is the resolver for the status field.
+func (r *orderResolver) Status(ctx context.Context, obj *repo.Order) (model.OrderStatus, error) {
+	return model.OrderStatus(strings.ToUpper(string(obj.Status))), nil
+}
+
+// User is the resolver for the user field.
+func (r *queryResolver) User(ctx context.Context, id int64) (*repo.User, error) {
+	return loader.For(ctx).User(ctx, id)
+}
+
+// Users is the resolver for the users field.
+func (r *queryResolver) Users(ctx context.Context, first int) ([]repo.User, error) {
+	if err := checkFirst(first); err != nil {
+		return nil, err
+	}
+	return r.Repo.Users(ctx, first)
+}
+
+// Order is the resolver for the order field.
+func (r *queryResolver) Order(ctx context.Context, id int64) (*repo.Order, error) {
+	return loader.For(ctx).Order(ctx, id)
+}
+
+// Orders is the resolver for the orders field.
+func (r *queryResolver) Orders(ctx context.Context, first int) ([]repo.Order, error) {
+	if err := checkFirst(first); err != nil {
+		return nil, err
+	}
+	return r.Repo.Orders(ctx, first)
+}
+
+// Orders is the resolver for the orders field.
+func (r *userResolver) Orders(ctx context.Context, obj *repo.User) ([]repo.Order, error) {
+	return loader.For(ctx).OrdersOf(ctx, obj.ID)
+}
+
+// Mutation returns MutationResolver implementation.
+func (r *Resolver) Mutation() MutationResolver { return &mutationResolver{r} }
+
+// Order returns OrderResolver implementation.
+func (r *Resolver) Order() OrderResolver { return &orderResolver{r} }
+
+// Query returns QueryResolver implementation.
+func (r *Resolver) Query() QueryResolver { return &queryResolver{r} }
+
+// User returns UserResolver implementation.
+func (r *Resolver) User() UserResolver { return &userResolver{r} }
+
+type mutationResolver struct{ *Resolver }
+type orderResolver struct{ *Resolver }
+type queryResolver struct{ *Resolver }
+type userResolver struct{ *Resolver }
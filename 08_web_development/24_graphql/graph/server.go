@@ -0,0 +1,85 @@
+package graph
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+
+	"github.com/99designs/gqlgen/graphql"
+	"github.com/99designs/gqlgen/graphql/handler"
+	"github.com/99designs/gqlgen/graphql/handler/extension"
+	"github.com/99designs/gqlgen/graphql/handler/lru"
+	"github.com/99designs/gqlgen/graphql/handler/transport"
+	"github.com/vektah/gqlparser/v2/ast"
+	"github.com/vektah/gqlparser/v2/gqlerror"
+
+	"golang_roadmap/08_web_development/24_graphql/loader"
+)
+
+const (
+	// maxFirst is the largest page a list field returns.
+	maxFirst = 100
+	// ordersPerUser is what User.orders is assumed to cost: how many
+	// orders a user has is not known until the query runs.
+	ordersPerUser = 10
+)
+
+// Handler returns the GraphQL endpoint for res. It takes queries by POST
+// and GET, refuses queries that would cost more than maxComplexity before
+// running them, and gives each request its own dataloaders.
+func Handler(res *Resolver, maxComplexity int) http.Handler {
+	srv := handler.New(NewExecutableSchema(Config{Resolvers: res, Complexity: complexity()}))
+	srv.AddTransport(transport.GET{})
+	srv.AddTransport(transport.POST{})
+	srv.SetQueryCache(lru.New[*ast.QueryDocument](1000))
+	srv.Use(extension.Introspection{})
+	srv.Use(extension.FixedComplexityLimit(maxComplexity))
+	srv.SetErrorPresenter(presentError)
+	return loader.Middleware(res.Repo, res.LoaderWait, srv)
+}
+
+// complexity makes lists cost what they may return. By default every
+// field costs 1 plus its children, so "users(first: 100) { orders { user
+// { orders ... } } }" would look cheap while fetching thousands of rows.
+func complexity() ComplexityRoot {
+	var c ComplexityRoot
+	page := func(child, first int) int { return 1 + min(max(first, 0), maxFirst)*child }
+	c.Query.Users = page
+	c.Query.Orders = page
+	c.User.Orders = func(child int) int { return 1 + ordersPerUser*child }
+	return c
+}
+
+// inputError is an error the client can fix. Its message is shown;
+// other errors from resolvers are logged and shown as "internal error",
+// so SQL and file paths do not leak to clients.
+type inputError struct{ msg string }
+
+func (e *inputError) Error() string { return e.msg }
+
+func inputErrorf(format string, args ...any) error {
+	return &inputError{msg: fmt.Sprintf(format, args...)}
+}
+
+func presentError(ctx context.Context, err error) *gqlerror.Error {
+	var ie *inputError
+	var ge *gqlerror.Error
+	switch {
+	case errors.As(err, &ie):
+		return graphql.DefaultErrorPresenter(ctx, err)
+	case errors.As(err, &ge) && ge.Err == nil:
+		return ge // gqlgen's own, such as a bad argument
+	}
+	log.Printf("graphql: %v: %v", graphql.GetPath(ctx), err)
+	return gqlerror.ErrorPathf(graphql.GetPath(ctx), "internal error")
+}
+
+// checkFirst refuses page sizes the complexity estimate does not cover.
+func checkFirst(first int) error {
+	if first < 1 || first > maxFirst {
+		return inputErrorf("first must be between 1 and %d", maxFirst)
+	}
+	return nil
+}
@@ -0,0 +1,127 @@
+// Package loader batches the repository lookups of one GraphQL request.
+//
+// Without it, a query for 50 orders and each order's user runs one query
+// for the orders and 50 for the users: the N+1 problem. gqlgen resolves
+// the 50 user fields concurrently; each asks its loader for one user, the
+// loader waits a moment to collect the other keys, and fetches them all
+// with one UsersByIDs call.
+//
+// Loaders cache what they fetch, so they are made per request: a cache
+// shared by all requests would serve stale data and mix up clients.
+package loader
+
+import (
+	"context"
+	"net/http"
+	"time"
+
+	"github.com/graph-gophers/dataloader/v7"
+
+	"golang_roadmap/08_web_development/24_graphql/repo"
+)
+
+// DefaultWait is how long a loader collects keys before it runs the
+// batch. The fields of one list are resolved at about the same time, so a
+// short wait catches them and adds little latency.
+const DefaultWait = 2 * time.Millisecond
+
+// Loaders are the loaders of one request.
+type Loaders struct {
+	UserByID       *dataloader.Loader[int64, *repo.User]
+	OrderByID      *dataloader.Loader[int64, *repo.Order]
+	OrdersByUserID *dataloader.Loader[int64, []repo.Order]
+}
+
+// New returns fresh Loaders reading from r that collect keys for wait
+// (0: DefaultWait). Tests of batching pass a long wait, so that a slow
+// goroutine cannot miss its batch.
+func New(r *repo.Repo, wait time.Duration) *Loaders {
+	if wait <= 0 {
+		wait = DefaultWait
+	}
+	return &Loaders{
+		UserByID: dataloader.NewBatchedLoader(byID(r.UsersByIDs, func(u repo.User) int64 { return u.ID }),
+			dataloader.WithWait[int64, *repo.User](wait)),
+		OrderByID: dataloader.NewBatchedLoader(byID(r.OrdersByIDs, func(o repo.Order) int64 { return o.ID }),
+			dataloader.WithWait[int64, *repo.Order](wait)),
+		OrdersByUserID: dataloader.NewBatchedLoader(ordersByUserID(r),
+			dataloader.WithWait[int64, []repo.Order](wait)),
+	}
+}
+
+type contextKey struct{}
+
+// Middleware gives each request its own Loaders, made by New(r, wait).
+func Middleware(r *repo.Repo, wait time.Duration, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		next.ServeHTTP(w, req.WithContext(WithLoaders(req.Context(), New(r, wait))))
+	})
+}
+
+// WithLoaders returns a copy of ctx that carries l.
+func WithLoaders(ctx context.Context, l *Loaders) context.Context {
+	return context.WithValue(ctx, contextKey{}, l)
+}
+
+// For returns the Loaders in ctx. It panics if there are none: the
+// handler is mounted without Middleware.
+func For(ctx context.Context) *Loaders {
+	l, ok := ctx.Value(contextKey{}).(*Loaders)
+	if !ok {
+		panic("loader: no Loaders in context; wrap the handler in loader.Middleware")
+	}
+	return l
+}
+
+// User returns the user with the given ID, or nil if there is none.
+func (l *Loaders) User(ctx context.Context, id int64) (*repo.User, error) {
+	return l.UserByID.Load(ctx, id)()
+}
+
+// Order returns the order with the given ID, or nil if there is none.
+func (l *Loaders) Order(ctx context.Context, id int64) (*repo.Order, error) {
+	return l.OrderByID.Load(ctx, id)()
+}
+
+// OrdersOf returns the user's orders, oldest first.
+func (l *Loaders) OrdersOf(ctx context.Context, userID int64) ([]repo.Order, error) {
+	return l.OrdersByUserID.Load(ctx, userID)()
+}
+
+// byID makes a batch function from a lookup by many IDs. The batch
+// function must return one result per key, in the order of the keys; the
+// lookup returns rows in any order and leaves out missing ones, which
+// become nil.
+func byID[V any](fetch func(context.Context, []int64) ([]V, error), id func(V) int64) dataloader.BatchFunc[int64, *V] {
+	return func(ctx context.Context, keys []int64) []*dataloader.Result[*V] {
+		rows, err := fetch(ctx, keys)
+		results := make([]*dataloader.Result[*V], len(keys))
+		found := make(map[int64]*V, len(rows))
+		for i := range rows {
+			found[id(rows[i])] = &rows[i]
+		}
+		for i, k := range keys {
+			results[i] = &dataloader.Result[*V]{Data: found[k], Error: err}
+		}
+		return results
+	}
+}
+
+func ordersByUserID(r *repo.Repo) dataloader.BatchFunc[int64, []repo.Order] {
+	return func(ctx context.Context, userIDs []int64) []*dataloader.Result[[]repo.Order] {
+		orders, err := r.OrdersByUserIDs(ctx, userIDs)
+		byUser := make(map[int64][]repo.Order, len(userIDs))
+		for _, o := range orders {
+			byUser[o.UserID] = append(byUser[o.UserID], o)
+		}
+		results := make([]*dataloader.Result[[]repo.Order], len(userIDs))
+		for i, id := range userIDs {
+			list := byUser[id]
+			if list == nil {
+				list = []repo.Order{} // a user without orders, not an unknown one
+			}
+			results[i] = &dataloader.Result[[]repo.Order]{Data: list, Error: err}
+		}
+		return results
+	}
+}
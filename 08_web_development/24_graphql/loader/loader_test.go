@@ -0,0 +1,70 @@
+package loader
+
+import (
+	"context"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"golang_roadmap/08_web_development/24_graphql/repo"
+)
+
+func TestBatchesConcurrentLoads(t *testing.T) {
+	ctx := context.Background()
+	r, err := repo.Open(filepath.Join(t.TempDir(), "shop.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer r.Close()
+	for _, email := range []string{"ada@example.com", "grace@example.com", "alan@example.com"} {
+		u, _ := r.CreateUser(ctx, "User", email)
+		r.CreateOrder(ctx, u.ID, 100, time.Now())
+	}
+	r.CreateOrder(ctx, 1, 200, time.Now())
+
+	// A long wait: with the default, a goroutine scheduled late would
+	// miss the batch and make the count flaky.
+	l := New(r, 200*time.Millisecond)
+	before := r.Queries()
+	ids := []int64{3, 1, 99, 2, 1}
+	users := make([]*repo.User, len(ids))
+	orders := make([][]repo.Order, len(ids))
+	var wg sync.WaitGroup
+	for i, id := range ids {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			users[i], _ = l.User(ctx, id)
+			orders[i], _ = l.OrdersOf(ctx, id)
+		}()
+	}
+	wg.Wait()
+
+	if n := r.Queries() - before; n != 2 {
+		t.Errorf("%d loads ran %d queries, want 2", 2*len(ids), n)
+	}
+	for i, id := range ids {
+		if id == 99 {
+			if users[i] != nil || orders[i] == nil || len(orders[i]) != 0 {
+				t.Errorf("unknown user: %+v, %v; want nil and no orders", users[i], orders[i])
+			}
+			continue
+		}
+		if users[i] == nil || users[i].ID != id {
+			t.Errorf("User(%d) = %+v", id, users[i])
+		}
+	}
+	if len(orders[1]) != 2 || len(orders[0]) != 1 {
+		t.Errorf("orders of users 3 and 1: %d and %d, want 1 and 2", len(orders[0]), len(orders[1]))
+	}
+}
+
+func TestForPanicsWithoutMiddleware(t *testing.T) {
+	defer func() {
+		if recover() == nil {
+			t.Error("For did not panic")
+		}
+	}()
+	For(context.Background())
+}
@@ -0,0 +1,121 @@
+// Demonstrates a GraphQL server with gqlgen.
+//
+// This example shows:
+// - A schema of users and orders, with resolvers over a SQLite repository
+// - Dataloaders that batch lookups, so nested lists cost one query per level
+// - Query complexity limits that refuse expensive queries before they run
+// - Client-fixable errors shown to clients, internal ones logged and hidden
+// - The GraphQL playground at / with -serve
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/99designs/gqlgen/graphql/playground"
+
+	"golang_roadmap/08_web_development/24_graphql/graph"
+	"golang_roadmap/08_web_development/24_graphql/repo"
+)
+
+// maxComplexity allows a page of 100 users with their orders, but not
+// their orders' users as well.
+const maxComplexity = 2000
+
+func routes(r *repo.Repo) http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("/", playground.Handler("GraphQL playground", "/query"))
+	mux.Handle("/query", graph.Handler(&graph.Resolver{Repo: r}, maxComplexity))
+	return mux
+}
+
+func call(srv *httptest.Server, r *repo.Repo, query string) {
+	body, _ := json.Marshal(map[string]string{"query": query})
+	before := r.Queries()
+	resp, err := srv.Client().Post(srv.URL+"/query", "application/json", bytes.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	if len(b) > 200 {
+		b = append(b[:200], "..."...)
+	}
+	fmt.Printf("    %s\n    -> %d %s\n    (%d SQL queries)\n", query, resp.StatusCode, bytes.TrimSpace(b), r.Queries()-before)
+}
+
+func seed(ctx context.Context, r *repo.Repo) {
+	for i := range 50 {
+		u, err := r.CreateUser(ctx, fmt.Sprintf("User %d", i+1), fmt.Sprintf("user%d@example.com", i+1))
+		if err != nil {
+			log.Fatal(err)
+		}
+		for j := range 3 {
+			if _, err := r.CreateOrder(ctx, u.ID, 500*(j+1), time.Now()); err != nil {
+				log.Fatal(err)
+			}
+		}
+	}
+}
+
+func main() {
+	addr := flag.String("serve", "", "after the demo, serve the API and playground on this address, such as :8080")
+	flag.Parse()
+	log.SetFlags(0)
+
+	fmt.Println("GraphQL examples starting...")
+	dir, err := os.MkdirTemp("", "graphql")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	r, err := repo.Open(filepath.Join(dir, "shop.db"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer r.Close()
+	seed(context.Background(), r)
+	srv := httptest.NewServer(routes(r))
+	defer srv.Close()
+
+	// 1) Mutations write through the repository.
+	fmt.Println("\n1) Mutations")
+	call(srv, r, `mutation { createUser(input: {name: "Ada Lovelace", email: "ada@example.com"}) { id name } }`)
+	call(srv, r, `mutation { createOrder(input: {userId: 51, totalCents: 1250}) { id status user { name } } }`)
+	call(srv, r, `mutation { setOrderStatus(id: 151, status: PAID) { id status } }`)
+
+	// 2) 50 users and their orders: without dataloaders, 1 query for the
+	// users and 50 for the orders. With them, the 50 lookups are one.
+	fmt.Println("\n2) Nested lists without N+1 queries")
+	call(srv, r, `{ users(first: 50) { name orders { totalCents } } }`)
+	call(srv, r, `{ orders(first: 100) { id user { name orders { id } } } }`)
+
+	// 3) Each level of nesting multiplies the work. The limit is checked
+	// on the query alone, before anything runs.
+	fmt.Println("\n3) Complexity limits")
+	call(srv, r, `{ users(first: 100) { orders { user { orders { id } } } } }`)
+
+	// 4) Input errors say what to fix; the rest say "internal error" and
+	// the details go to the log.
+	fmt.Println("\n4) Errors")
+	call(srv, r, `mutation { createUser(input: {name: "Ada", email: "ada@example.com"}) { id } }`)
+	call(srv, r, `mutation { createOrder(input: {userId: 999, totalCents: 100}) { id } }`)
+	call(srv, r, `{ users(first: 1000) { id } }`)
+	call(srv, r, `{ user(id: 1) { password } }`)
+
+	if *addr != "" {
+		log.Printf("serving on %s: open http://localhost%s/ for the playground", *addr, *addr)
+		srv := &http.Server{Addr: *addr, Handler: routes(r), ReadHeaderTimeout: 5 * time.Second}
+		log.Fatal(srv.ListenAndServe())
+	}
+}
@@ -0,0 +1,225 @@
+// Package repo stores users and their orders in SQLite. It is the layer
+// the GraphQL resolvers call; it knows nothing about GraphQL.
+//
+// Besides lookups by one ID it has batch lookups by many IDs, which the
+// dataloaders use to turn one query per object into one query per list.
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+var (
+	ErrNotFound   = errors.New("not found")
+	ErrEmailTaken = errors.New("email already in use")
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS users (
+	id    INTEGER PRIMARY KEY AUTOINCREMENT,
+	name  TEXT    NOT NULL,
+	email TEXT    NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS orders (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	user_id     INTEGER NOT NULL REFERENCES users (id),
+	total_cents INTEGER NOT NULL,
+	status      TEXT    NOT NULL,
+	created_at  INTEGER NOT NULL -- unix ms
+);
+CREATE INDEX IF NOT EXISTS orders_user ON orders (user_id, id);`
+
+// Status is where an order stands.
+type Status string
+
+const (
+	Pending   Status = "pending"
+	Paid      Status = "paid"
+	Shipped   Status = "shipped"
+	Cancelled Status = "cancelled"
+)
+
+type User struct {
+	ID    int64
+	Name  string
+	Email string
+}
+
+type Order struct {
+	ID         int64
+	UserID     int64
+	TotalCents int
+	Status     Status
+	CreatedAt  time.Time
+}
+
+// Repo stores users and orders. It is safe for concurrent use.
+type Repo struct {
+	db      *sql.DB
+	queries atomic.Int64
+}
+
+// Open opens (creating if needed) the database at path.
+func Open(path string) (*Repo, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &Repo{db: db}, nil
+}
+
+// Close closes the database.
+func (r *Repo) Close() error { return r.db.Close() }
+
+// Queries returns the number of SQL statements run so far. The tests and
+// the demo use it to show how many queries a GraphQL request costs.
+func (r *Repo) Queries() int64 { return r.queries.Load() }
+
+func (r *Repo) exec(ctx context.Context, query string, args ...any) (sql.Result, error) {
+	r.queries.Add(1)
+	return r.db.ExecContext(ctx, query, args...)
+}
+
+func (r *Repo) query(ctx context.Context, query string, args ...any) (*sql.Rows, error) {
+	r.queries.Add(1)
+	return r.db.QueryContext(ctx, query, args...)
+}
+
+// CreateUser stores a new user.
+func (r *Repo) CreateUser(ctx context.Context, name, email string) (User, error) {
+	res, err := r.exec(ctx, `INSERT INTO users (name, email) VALUES (?, ?)`, name, email)
+	if err != nil && strings.Contains(err.Error(), "UNIQUE constraint failed") {
+		return User{}, ErrEmailTaken
+	}
+	if err != nil {
+		return User{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return User{}, err
+	}
+	return User{ID: id, Name: name, Email: email}, nil
+}
+
+// CreateOrder stores a new pending order for the user. It returns
+// ErrNotFound if there is no such user.
+func (r *Repo) CreateOrder(ctx context.Context, userID int64, totalCents int, at time.Time) (Order, error) {
+	res, err := r.exec(ctx, `INSERT INTO orders (user_id, total_cents, status, created_at) VALUES (?, ?, ?, ?)`,
+		userID, totalCents, Pending, at.UnixMilli())
+	if err != nil && strings.Contains(err.Error(), "FOREIGN KEY constraint failed") {
+		return Order{}, ErrNotFound
+	}
+	if err != nil {
+		return Order{}, err
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return Order{}, err
+	}
+	return Order{ID: id, UserID: userID, TotalCents: totalCents, Status: Pending, CreatedAt: at.UTC().Truncate(time.Millisecond)}, nil
+}
+
+// SetStatus changes an order's status.
+func (r *Repo) SetStatus(ctx context.Context, orderID int64, s Status) error {
+	res, err := r.exec(ctx, `UPDATE orders SET status = ? WHERE id = ?`, s, orderID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Users returns up to limit users, oldest first.
+func (r *Repo) Users(ctx context.Context, limit int) ([]User, error) {
+	return r.users(ctx, `SELECT id, name, email FROM users ORDER BY id LIMIT ?`, limit)
+}
+
+// UsersByIDs returns the users with the given IDs, in no particular
+// order. IDs without a user are left out.
+func (r *Repo) UsersByIDs(ctx context.Context, ids []int64) ([]User, error) {
+	return r.users(ctx, `SELECT id, name, email FROM users WHERE id IN (`+placeholders(len(ids))+`)`, int64s(ids)...)
+}
+
+// Orders returns up to limit orders, oldest first.
+func (r *Repo) Orders(ctx context.Context, limit int) ([]Order, error) {
+	return r.orders(ctx, `SELECT id, user_id, total_cents, status, created_at FROM orders ORDER BY id LIMIT ?`, limit)
+}
+
+// OrdersByIDs returns the orders with the given IDs, in no particular
+// order.
+func (r *Repo) OrdersByIDs(ctx context.Context, ids []int64) ([]Order, error) {
+	return r.orders(ctx, `SELECT id, user_id, total_cents, status, created_at FROM orders WHERE id IN (`+placeholders(len(ids))+`)`, int64s(ids)...)
+}
+
+// OrdersByUserIDs returns the orders of all the given users, each user's
+// oldest first.
+func (r *Repo) OrdersByUserIDs(ctx context.Context, userIDs []int64) ([]Order, error) {
+	return r.orders(ctx, `SELECT id, user_id, total_cents, status, created_at FROM orders WHERE user_id IN (`+placeholders(len(userIDs))+`) ORDER BY user_id, id`, int64s(userIDs)...)
+}
+
+func (r *Repo) users(ctx context.Context, query string, args ...any) ([]User, error) {
+	rows, err := r.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email); err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repo) orders(ctx context.Context, query string, args ...any) ([]Order, error) {
+	rows, err := r.query(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Order
+	for rows.Next() {
+		var o Order
+		var at int64
+		if err := rows.Scan(&o.ID, &o.UserID, &o.TotalCents, &o.Status, &at); err != nil {
+			return nil, err
+		}
+		o.CreatedAt = time.UnixMilli(at).UTC()
+		out = append(out, o)
+	}
+	return out, rows.Err()
+}
+
+// placeholders returns "?, ?, ?" for n values. An IN list is built per
+// call because SQLite has no array parameter.
+func placeholders(n int) string {
+	if n == 0 {
+		return "NULL" // IN (NULL) matches nothing
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
+
+func int64s(ids []int64) []any {
+	args := make([]any, len(ids))
+	for i, id := range ids {
+		args[i] = id
+	}
+	return args
+}
@@ -0,0 +1,94 @@
+package repo
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var t0 = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func open(t *testing.T) *Repo {
+	t.Helper()
+	r, err := Open(filepath.Join(t.TempDir(), "shop.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { r.Close() })
+	return r
+}
+
+func TestCreateAndLookUp(t *testing.T) {
+	ctx := context.Background()
+	r := open(t)
+	ada, err := r.CreateUser(ctx, "Ada", "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	grace, _ := r.CreateUser(ctx, "Grace", "grace@example.com")
+	if _, err := r.CreateUser(ctx, "Ada again", "ada@example.com"); !errors.Is(err, ErrEmailTaken) {
+		t.Fatalf("duplicate email: err = %v, want ErrEmailTaken", err)
+	}
+
+	o1, err := r.CreateOrder(ctx, ada.ID, 1250, t0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if o1.Status != Pending || !o1.CreatedAt.Equal(t0) {
+		t.Errorf("new order = %+v", o1)
+	}
+	r.CreateOrder(ctx, grace.ID, 999, t0.Add(time.Minute))
+	r.CreateOrder(ctx, ada.ID, 300, t0.Add(2*time.Minute))
+	if _, err := r.CreateOrder(ctx, 99, 100, t0); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("order for unknown user: err = %v, want ErrNotFound", err)
+	}
+
+	if err := r.SetStatus(ctx, o1.ID, Paid); err != nil {
+		t.Fatal(err)
+	}
+	if err := r.SetStatus(ctx, 99, Paid); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("SetStatus(99): err = %v, want ErrNotFound", err)
+	}
+
+	users, _ := r.UsersByIDs(ctx, []int64{grace.ID, 42, ada.ID})
+	if len(users) != 2 {
+		t.Errorf("UsersByIDs = %+v, want Ada and Grace", users)
+	}
+	orders, _ := r.OrdersByIDs(ctx, []int64{o1.ID})
+	if len(orders) != 1 || orders[0] != (Order{ID: o1.ID, UserID: ada.ID, TotalCents: 1250, Status: Paid, CreatedAt: t0}) {
+		t.Errorf("OrdersByIDs = %+v", orders)
+	}
+	byUser, _ := r.OrdersByUserIDs(ctx, []int64{ada.ID, grace.ID})
+	var got []int64
+	for _, o := range byUser {
+		got = append(got, o.UserID)
+	}
+	if len(got) != 3 || got[0] != ada.ID || got[1] != ada.ID || got[2] != grace.ID {
+		t.Errorf("OrdersByUserIDs: user IDs %v, want each user's orders together", got)
+	}
+	if page, _ := r.Orders(ctx, 2); len(page) != 2 || page[0].ID != o1.ID {
+		t.Errorf("Orders(2) = %+v", page)
+	}
+}
+
+func TestEmptyBatch(t *testing.T) {
+	r := open(t)
+	users, err := r.UsersByIDs(context.Background(), nil)
+	if err != nil || len(users) != 0 {
+		t.Fatalf("UsersByIDs(nil) = %v, %v", users, err)
+	}
+}
+
+func TestQueriesCounts(t *testing.T) {
+	ctx := context.Background()
+	r := open(t)
+	before := r.Queries()
+	r.CreateUser(ctx, "Ada", "ada@example.com")
+	r.Users(ctx, 10)
+	r.UsersByIDs(ctx, []int64{1, 2, 3})
+	if n := r.Queries() - before; n != 3 {
+		t.Errorf("Queries went up by %d, want 3", n)
+	}
+}
@@ -0,0 +1,256 @@
+// Demonstrates delivering webhooks for user events.
+//
+// This example shows:
+// - A user service whose changes become events, delivered to subscribed endpoints
+// - Deliveries stored in SQLite before they are sent, so a restart loses none
+// - Requests signed with HMAC-SHA256 per the Standard Webhooks specification
+// - Retries with backoff, and dead letters after the last attempt
+// - A receiver that verifies signatures and handles each message once
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"golang_roadmap/08_web_development/25_webhooks/receiver"
+	"golang_roadmap/08_web_development/25_webhooks/users"
+	"golang_roadmap/08_web_development/25_webhooks/webhook"
+)
+
+// endpoint is a receiver run in this process, so the demo needs no
+// second program.
+type endpoint struct {
+	name string
+	srv  *httptest.Server
+	rc   *receiver.Receiver
+}
+
+func newEndpoint(name string, fail func() bool) *endpoint {
+	e := &endpoint{name: name}
+	e.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if fail != nil && fail() {
+			http.Error(w, "service unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		e.rc.ServeHTTP(w, r)
+	}))
+	return e
+}
+
+// listen registers the endpoint and starts verifying with its secret.
+func (e *endpoint) listen(ctx context.Context, d *webhook.Dispatcher, events ...string) {
+	reg, err := d.Register(ctx, e.srv.URL, events...)
+	if err != nil {
+		log.Fatal(err)
+	}
+	e.rc = receiver.New(receiver.Options{Secrets: []string{reg.Secret}}, func(_ context.Context, id string, m webhook.Message) error {
+		fmt.Printf("    %-6s got %s %s %s\n", e.name, id, m.Type, m.Data)
+		return nil
+	})
+}
+
+// wait returns once no delivery is pending.
+func wait(ctx context.Context, d *webhook.Dispatcher) {
+	for {
+		pending, err := d.Deliveries(ctx, webhook.Pending)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if len(pending) == 0 {
+			return
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func main() {
+	addr := flag.String("serve", "", "after the demo, serve POST /users and the delivery log on this address, such as :8080")
+	flag.Parse()
+	log.SetFlags(0)
+
+	fmt.Println("Webhooks examples starting...")
+	dir, err := os.MkdirTemp("", "webhooks")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	d, err := webhook.Open(filepath.Join(dir, "webhooks.db"), webhook.Options{
+		MaxAttempts:  4,
+		Backoff:      func(n int) time.Duration { return time.Duration(n) * 100 * time.Millisecond },
+		Timeout:      2 * time.Second,
+		PollInterval: 50 * time.Millisecond,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer d.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() { d.Run(ctx, 2); close(stopped) }()
+	defer func() { cancel(); <-stopped }()
+
+	// 1) Two receivers: the CRM wants new users only, the audit log
+	// everything. Each gets its own secret.
+	fmt.Println("\n1) User events to subscribed endpoints")
+	crm, audit := newEndpoint("crm", nil), newEndpoint("audit", nil)
+	defer crm.srv.Close()
+	defer audit.srv.Close()
+	crm.listen(ctx, d, users.Created)
+	audit.listen(ctx, d, "*")
+	svc := users.New(d)
+	ada, _ := svc.Create(ctx, "Ada Lovelace", "ada@example.com")
+	svc.UpdateEmail(ctx, ada.ID, "ada@lovelace.org")
+	wait(ctx, d)
+
+	// 2) An endpoint that is down for its first two attempts. The
+	// dispatcher retries with backoff; the message ID stays the same.
+	fmt.Println("\n2) Retries")
+	var calls atomic.Int32
+	flaky := newEndpoint("flaky", func() bool { return calls.Add(1) <= 2 })
+	defer flaky.srv.Close()
+	flaky.listen(ctx, d, users.Deleted)
+	svc.Delete(ctx, ada.ID)
+	wait(ctx, d)
+	for _, dl := range must(d.Deliveries(ctx, webhook.Delivered)) {
+		if dl.Type == users.Deleted && dl.Attempts > 1 {
+			fmt.Printf("    delivery %d to endpoint %d: delivered on attempt %d\n", dl.ID, dl.EndpointID, dl.Attempts)
+		}
+	}
+
+	// 3) An endpoint that is always down. After 4 attempts the delivery is
+	// a dead letter; fixed, it can be sent again.
+	fmt.Println("\n3) Dead letters")
+	var broken atomic.Bool
+	broken.Store(true)
+	down := newEndpoint("down", broken.Load)
+	defer down.srv.Close()
+	down.listen(ctx, d, users.Created)
+	svc.Create(ctx, "Grace Hopper", "grace@example.com")
+	wait(ctx, d)
+	for _, dl := range must(d.Deliveries(ctx, webhook.Dead)) {
+		fmt.Printf("    dead: delivery %d (%s) after %d attempts: %s\n", dl.ID, dl.Type, dl.Attempts, dl.LastError)
+		broken.Store(false)
+		if err := d.Redeliver(ctx, dl.ID); err != nil {
+			log.Fatal(err)
+		}
+	}
+	wait(ctx, d)
+
+	// 4) Requests the receiver refuses, although the body is fine.
+	fmt.Println("\n4) Forged and replayed requests")
+	msg := `{"type":"user.created","timestamp":"2024-05-01T12:00:00Z","data":{"id":99}}`
+	secret := must(d.Endpoints(ctx))[0].Secret
+	fmt.Printf("    wrong secret -> %d\n", send(crm.srv.URL, webhook.NewSecret(), "msg_forged", time.Now(), msg))
+	fmt.Printf("    an hour old  -> %d\n", send(crm.srv.URL, secret, "msg_old", time.Now().Add(-time.Hour), msg))
+	fmt.Printf("    valid        -> %d\n", send(crm.srv.URL, secret, "msg_new", time.Now(), msg))
+
+	if *addr != "" {
+		// Only the receiver that serve registers gets events from now on.
+		for _, e := range must(d.Endpoints(ctx)) {
+			d.Disable(ctx, e.ID)
+		}
+		serve(*addr, d, svc)
+	}
+}
+
+// send posts a message signed by hand, as an attacker might.
+func send(url, secret, id string, ts time.Time, body string) int {
+	req, err := http.NewRequest("POST", url, strings.NewReader(body))
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := webhook.SetHeaders(req.Header, secret, id, ts, []byte(body)); err != nil {
+		log.Fatal(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		log.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// serve runs the user API, the delivery log and a receiver that prints
+// what it gets, registered for every event.
+func serve(addr string, d *webhook.Dispatcher, svc *users.Service) {
+	ctx := context.Background()
+	reg, err := d.Register(ctx, "http://localhost"+addr+"/hooks", "*")
+	if err != nil {
+		log.Fatal(err)
+	}
+	mux := http.NewServeMux()
+	mux.Handle("POST /hooks", receiver.New(receiver.Options{Secrets: []string{reg.Secret}}, func(_ context.Context, id string, m webhook.Message) error {
+		log.Printf("hooks: %s %s %s", id, m.Type, m.Data)
+		return nil
+	}))
+	mux.HandleFunc("POST /users", func(w http.ResponseWriter, r *http.Request) {
+		var in struct{ Name, Email string }
+		if err := json.NewDecoder(r.Body).Decode(&in); err != nil {
+			http.Error(w, "Invalid JSON", http.StatusBadRequest)
+			return
+		}
+		u, err := svc.Create(r.Context(), in.Name, in.Email)
+		if errors.Is(err, users.ErrInvalid) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			log.Printf("users: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusCreated, u)
+	})
+	mux.HandleFunc("GET /deliveries", func(w http.ResponseWriter, r *http.Request) {
+		ds, err := d.Deliveries(r.Context(), webhook.Status(r.URL.Query().Get("status")))
+		if err != nil {
+			log.Printf("deliveries: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, http.StatusOK, ds)
+	})
+	mux.HandleFunc("POST /deliveries/{id}/redeliver", func(w http.ResponseWriter, r *http.Request) {
+		id, _ := strconv.ParseInt(r.PathValue("id"), 10, 64)
+		err := d.Redeliver(r.Context(), id)
+		if errors.Is(err, webhook.ErrNotFound) {
+			http.Error(w, "No dead delivery with that ID", http.StatusNotFound)
+			return
+		}
+		if err != nil {
+			log.Printf("redeliver: %v", err)
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+			return
+		}
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	log.Printf("serving on %s: curl -d '{\"name\":\"Alan\",\"email\":\"alan@example.com\"}' localhost%s/users", addr, addr)
+	srv := &http.Server{Addr: addr, Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	log.Fatal(srv.ListenAndServe())
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+func must[T any](v T, err error) T {
+	if err != nil {
+		log.Fatal(err)
+	}
+	return v
+}
@@ -0,0 +1,171 @@
+// Package receiver is the other end of a webhook: an HTTP handler that
+// checks the signature and the timestamp, drops messages it has already
+// handled, and passes the rest to a function.
+//
+// A sender that got no answer in time sends the message again, with the
+// same Webhook-Id. The receiver remembers handled IDs for a while, so a
+// retry of a message that was in fact handled is acknowledged without
+// handling it twice. The memory here is a map; a service with several
+// instances keeps the IDs in its database instead.
+package receiver
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+	"golang_roadmap/08_web_development/25_webhooks/webhook"
+)
+
+// Options configures a Receiver. Zero values select the defaults, except
+// Secrets, which is required.
+type Options struct {
+	// Secrets are the endpoint's signing secrets. During a rotation both
+	// the old and the new one are listed.
+	Secrets []string
+	// Tolerance is how far the Webhook-Timestamp may be from now (default
+	// 5m). It limits how long a captured request can be replayed.
+	Tolerance time.Duration
+	// Remember is how long handled message IDs are kept (default 24h). It
+	// should cover the sender's whole retry schedule.
+	Remember time.Duration
+	// MaxBody limits the request body (default 1 MiB).
+	MaxBody int64
+	// Clock is used for the timestamp check (default clock.Real).
+	Clock clock.Clock
+}
+
+// HandlerFunc handles one verified message. Returning an error makes the
+// sender retry it later. It should be quick: the sender waits, and gives
+// up after its timeout.
+type HandlerFunc func(ctx context.Context, id string, m webhook.Message) error
+
+// Receiver is an http.Handler for webhook requests. It is safe for
+// concurrent use.
+type Receiver struct {
+	opts   Options
+	handle HandlerFunc
+
+	mu        sync.Mutex
+	seen      map[string]entry
+	lastSweep time.Time
+}
+
+type entry struct {
+	done bool // false while the message is being handled
+	at   time.Time
+}
+
+// New returns a Receiver that passes verified, new messages to handle.
+func New(opts Options, handle HandlerFunc) *Receiver {
+	if opts.Tolerance <= 0 {
+		opts.Tolerance = 5 * time.Minute
+	}
+	if opts.Remember <= 0 {
+		opts.Remember = 24 * time.Hour
+	}
+	if opts.MaxBody <= 0 {
+		opts.MaxBody = 1 << 20
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real()
+	}
+	return &Receiver{opts: opts, handle: handle, seen: map[string]entry{}}
+}
+
+func (rc *Receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, rc.opts.MaxBody))
+	var tooBig *http.MaxBytesError
+	if errors.As(err, &tooBig) {
+		http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+	if err != nil {
+		http.Error(w, "Bad request", http.StatusBadRequest)
+		return
+	}
+	// Verify before anything else looks at the body.
+	if err := webhook.Verify(r.Header, body, rc.opts.Clock.Now(), rc.opts.Tolerance, rc.opts.Secrets...); err != nil {
+		log.Printf("receiver: rejected %s from %s: %v", r.Header.Get(webhook.HeaderID), r.RemoteAddr, err)
+		http.Error(w, "Invalid signature", http.StatusUnauthorized)
+		return
+	}
+	var m webhook.Message
+	if err := json.Unmarshal(body, &m); err != nil {
+		http.Error(w, "Invalid JSON", http.StatusBadRequest)
+		return
+	}
+
+	id := r.Header.Get(webhook.HeaderID)
+	switch rc.begin(id) {
+	case duplicate:
+		w.WriteHeader(http.StatusNoContent) // handled before; the sender missed our answer
+		return
+	case inProgress:
+		http.Error(w, "Message is being handled", http.StatusConflict)
+		return
+	}
+	if err := rc.handle(r.Context(), id, m); err != nil {
+		rc.end(id, false)
+		log.Printf("receiver: %s %s: %v", id, m.Type, err)
+		http.Error(w, "Could not handle the message", http.StatusInternalServerError)
+		return
+	}
+	rc.end(id, true)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+type state int
+
+const (
+	fresh state = iota
+	inProgress
+	duplicate
+)
+
+// begin marks id as being handled, unless it is handled or being handled
+// already.
+func (rc *Receiver) begin(id string) state {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	now := rc.opts.Clock.Now()
+	if now.Sub(rc.lastSweep) > time.Minute {
+		for k, e := range rc.seen {
+			if e.done && now.Sub(e.at) > rc.opts.Remember {
+				delete(rc.seen, k)
+			}
+		}
+		rc.lastSweep = now
+	}
+	if e, ok := rc.seen[id]; ok {
+		if e.done {
+			return duplicate
+		}
+		return inProgress
+	}
+	rc.seen[id] = entry{at: now}
+	return fresh
+}
+
+// end records the outcome: a handled id is remembered, a failed one is
+// forgotten so the retry is handled.
+func (rc *Receiver) end(id string, ok bool) {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	if ok {
+		rc.seen[id] = entry{done: true, at: rc.opts.Clock.Now()}
+	} else {
+		delete(rc.seen, id)
+	}
+}
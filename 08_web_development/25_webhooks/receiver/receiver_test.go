@@ -0,0 +1,189 @@
+package receiver
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+	"golang_roadmap/08_web_development/25_webhooks/webhook"
+)
+
+var t0 = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+const body = `{"type":"user.created","timestamp":"2024-05-01T12:00:00Z","data":{"id":1}}`
+
+func signed(t *testing.T, secret, id string, ts time.Time, b string) *http.Request {
+	t.Helper()
+	r := httptest.NewRequest("POST", "/hooks", strings.NewReader(b))
+	if err := webhook.SetHeaders(r.Header, secret, id, ts, []byte(b)); err != nil {
+		t.Fatal(err)
+	}
+	return r
+}
+
+func serve(h http.Handler, r *http.Request) int {
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w.Code
+}
+
+func TestReceiver(t *testing.T) {
+	secret := webhook.NewSecret()
+	fc := clock.NewFake(t0)
+	var handled []string
+	fail := true
+	rc := New(Options{Secrets: []string{secret}, Clock: fc}, func(_ context.Context, id string, m webhook.Message) error {
+		if m.Type != "user.created" || string(m.Data) != `{"id":1}` {
+			t.Errorf("message = %+v", m)
+		}
+		if id == "msg_flaky" && fail {
+			fail = false
+			return errors.New("database is down")
+		}
+		handled = append(handled, id)
+		return nil
+	})
+
+	steps := []struct {
+		name string
+		req  *http.Request
+		want int
+	}{
+		{"new", signed(t, secret, "msg_1", t0, body), 204},
+		{"retry of a handled message", signed(t, secret, "msg_1", t0.Add(time.Second), body), 204},
+		{"handler fails", signed(t, secret, "msg_flaky", t0, body), 500},
+		{"retry after a failure", signed(t, secret, "msg_flaky", t0, body), 204},
+		{"wrong secret", signed(t, webhook.NewSecret(), "msg_2", t0, body), 401},
+		{"tampered body", func() *http.Request {
+			r := signed(t, secret, "msg_2", t0, body)
+			r.Body = httptest.NewRequest("POST", "/", strings.NewReader(strings.Replace(body, `"id":1`, `"id":2`, 1))).Body
+			return r
+		}(), 401},
+		{"replayed an hour later", signed(t, secret, "msg_3", t0.Add(-time.Hour), body), 401},
+		{"unsigned", httptest.NewRequest("POST", "/hooks", strings.NewReader(body)), 401},
+		{"not JSON", signed(t, secret, "msg_4", t0, "{"), 400},
+		{"GET", httptest.NewRequest("GET", "/hooks", nil), 405},
+	}
+	for _, s := range steps {
+		if got := serve(rc, s.req); got != s.want {
+			t.Errorf("%s: status %d, want %d", s.name, got, s.want)
+		}
+	}
+	if strings.Join(handled, " ") != "msg_1 msg_flaky" {
+		t.Errorf("handled %v, want msg_1 and msg_flaky once each", handled)
+	}
+}
+
+func TestReceiver_ConcurrentDuplicate(t *testing.T) {
+	secret := webhook.NewSecret()
+	started, release := make(chan struct{}), make(chan struct{})
+	rc := New(Options{Secrets: []string{secret}, Clock: clock.NewFake(t0)}, func(context.Context, string, webhook.Message) error {
+		close(started)
+		<-release
+		return nil
+	})
+	done := make(chan int)
+	go func() { done <- serve(rc, signed(t, secret, "msg_1", t0, body)) }()
+	<-started
+	if got := serve(rc, signed(t, secret, "msg_1", t0, body)); got != http.StatusConflict {
+		t.Errorf("duplicate while handling: status %d, want 409", got)
+	}
+	close(release)
+	if got := <-done; got != 204 {
+		t.Errorf("first: status %d, want 204", got)
+	}
+}
+
+func TestReceiver_ForgetsAfterRemember(t *testing.T) {
+	secret := webhook.NewSecret()
+	fc := clock.NewFake(t0)
+	n := 0
+	rc := New(Options{Secrets: []string{secret}, Clock: fc, Remember: time.Hour}, func(context.Context, string, webhook.Message) error {
+		n++
+		return nil
+	})
+	serve(rc, signed(t, secret, "msg_1", t0, body))
+	fc.Advance(2 * time.Hour)
+	serve(rc, signed(t, secret, "msg_1", fc.Now(), body))
+	if n != 2 || len(rc.seen) != 1 {
+		t.Fatalf("handled %d times with %d IDs remembered; want 2 and 1", n, len(rc.seen))
+	}
+}
+
+// TestWithDispatcher runs the whole path: the dispatcher signs and sends,
+// a receiver whose handler fails every other call answers 500, and every
+// message is handled exactly once in the end.
+func TestWithDispatcher(t *testing.T) {
+	d, err := webhook.Open(filepath.Join(t.TempDir(), "webhooks.db"), webhook.Options{
+		Backoff:      func(int) time.Duration { return 5 * time.Millisecond },
+		PollInterval: 5 * time.Millisecond,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+
+	var mu sync.Mutex
+	calls := 0
+	handled := map[string]int{}
+	var rc *Receiver
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) { rc.ServeHTTP(w, r) }))
+	defer srv.Close()
+	e, err := d.Register(context.Background(), srv.URL, "user.created")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rc = New(Options{Secrets: []string{e.Secret}}, func(_ context.Context, id string, _ webhook.Message) error {
+		mu.Lock()
+		defer mu.Unlock()
+		calls++
+		if calls%2 == 1 {
+			return errors.New("flaky")
+		}
+		handled[id]++
+		return nil
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	stopped := make(chan struct{})
+	go func() { d.Run(ctx, 3); close(stopped) }()
+	defer func() { cancel(); <-stopped }()
+
+	const messages = 10
+	for i := range messages {
+		if _, err := d.Publish(context.Background(), "user.created", map[string]int{"id": i}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for {
+		ds, err := d.Deliveries(context.Background(), webhook.Delivered)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(ds) == messages {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("%d of %d delivered", len(ds), messages)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(handled) != messages {
+		t.Fatalf("handled %d messages, want %d", len(handled), messages)
+	}
+	for id, n := range handled {
+		if n != 1 {
+			t.Errorf("%s handled %d times", id, n)
+		}
+	}
+}
@@ -0,0 +1,116 @@
+// Package users is a small user service that announces its changes as
+// events: user.created, user.updated and user.deleted. It knows nothing
+// about webhooks; it calls a Publisher, which the demo wires to the
+// webhook dispatcher.
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	ErrNotFound = errors.New("user not found")
+	ErrInvalid  = errors.New("name and email are required")
+)
+
+// The event types the service publishes.
+const (
+	Created = "user.created"
+	Updated = "user.updated"
+	Deleted = "user.deleted"
+)
+
+// Publisher records an event. webhook.Dispatcher is one.
+type Publisher interface {
+	Publish(ctx context.Context, typ string, data any) (string, error)
+}
+
+type User struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Service keeps users in memory. It is safe for concurrent use.
+type Service struct {
+	pub Publisher
+
+	mu     sync.Mutex
+	users  map[int64]User
+	nextID int64
+}
+
+func New(pub Publisher) *Service {
+	return &Service{pub: pub, users: map[int64]User{}}
+}
+
+// Create adds a user and publishes user.created.
+func (s *Service) Create(ctx context.Context, name, email string) (User, error) {
+	name, email = strings.TrimSpace(name), strings.TrimSpace(email)
+	if name == "" || email == "" {
+		return User{}, ErrInvalid
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextID++
+	u := User{ID: s.nextID, Name: name, Email: email, CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	// Publishing under the lock keeps events in the order of the changes.
+	// If it fails, the change is not made: a change nobody hears about is
+	// worse than one that failed.
+	if _, err := s.pub.Publish(ctx, Created, u); err != nil {
+		return User{}, fmt.Errorf("create user: %w", err)
+	}
+	s.users[u.ID] = u
+	return u, nil
+}
+
+// UpdateEmail changes a user's email and publishes user.updated.
+func (s *Service) UpdateEmail(ctx context.Context, id int64, email string) (User, error) {
+	email = strings.TrimSpace(email)
+	if email == "" {
+		return User{}, ErrInvalid
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	u.Email = email
+	if _, err := s.pub.Publish(ctx, Updated, u); err != nil {
+		return User{}, fmt.Errorf("update user %d: %w", id, err)
+	}
+	s.users[id] = u
+	return u, nil
+}
+
+// Delete removes a user and publishes user.deleted with the user's ID.
+func (s *Service) Delete(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.users[id]; !ok {
+		return ErrNotFound
+	}
+	if _, err := s.pub.Publish(ctx, Deleted, map[string]int64{"id": id}); err != nil {
+		return fmt.Errorf("delete user %d: %w", id, err)
+	}
+	delete(s.users, id)
+	return nil
+}
+
+// Get returns one user.
+func (s *Service) Get(id int64) (User, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u, ok := s.users[id]
+	if !ok {
+		return User{}, ErrNotFound
+	}
+	return u, nil
+}
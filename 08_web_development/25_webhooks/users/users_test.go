@@ -0,0 +1,70 @@
+package users
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+)
+
+type recorder struct {
+	events []string
+	err    error
+}
+
+func (r *recorder) Publish(_ context.Context, typ string, data any) (string, error) {
+	if r.err != nil {
+		return "", r.err
+	}
+	r.events = append(r.events, fmt.Sprintf("%s %v", typ, data))
+	return fmt.Sprintf("msg_%d", len(r.events)), nil
+}
+
+func TestService_PublishesChanges(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{}
+	s := New(rec)
+	u, err := s.Create(ctx, " Ada ", "ada@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.UpdateEmail(ctx, u.ID, "ada@lovelace.org"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.Delete(ctx, u.ID); err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"user.created {1 Ada ada@example.com", "user.updated {1 Ada ada@lovelace.org", "user.deleted map[id:1]"}
+	if len(rec.events) != len(want) {
+		t.Fatalf("events = %q", rec.events)
+	}
+	for i, w := range want {
+		if len(rec.events[i]) < len(w) || rec.events[i][:len(w)] != w {
+			t.Errorf("event %d = %q, want it to start with %q", i, rec.events[i], w)
+		}
+	}
+}
+
+func TestService_NoChangeWithoutEvent(t *testing.T) {
+	ctx := context.Background()
+	rec := &recorder{}
+	s := New(rec)
+	u, _ := s.Create(ctx, "Ada", "ada@example.com")
+
+	rec.err = errors.New("database is locked")
+	if _, err := s.Create(ctx, "Grace", "grace@example.com"); err == nil {
+		t.Fatal("Create succeeded without its event")
+	}
+	if _, err := s.UpdateEmail(ctx, u.ID, "ada@lovelace.org"); err == nil {
+		t.Fatal("UpdateEmail succeeded without its event")
+	}
+	if err := s.Delete(ctx, u.ID); err == nil {
+		t.Fatal("Delete succeeded without its event")
+	}
+	if got, err := s.Get(u.ID); err != nil || got.Email != "ada@example.com" {
+		t.Fatalf("Get = %+v, %v; want Ada unchanged", got, err)
+	}
+	if _, err := s.Get(2); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Get(2): err = %v, want ErrNotFound", err)
+	}
+}
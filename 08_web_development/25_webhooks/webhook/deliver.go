@@ -0,0 +1,198 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	crand "crypto/rand"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Run starts n workers and blocks until ctx is cancelled and every
+// request in flight has finished.
+func (d *Dispatcher) Run(ctx context.Context, n int) {
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			d.work(ctx, fmt.Sprintf("webhook worker-%d", i))
+		}()
+	}
+	wg.Wait()
+}
+
+func (d *Dispatcher) work(ctx context.Context, name string) {
+	for {
+		ok, err := d.deliverNext(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("%s: %v", name, err)
+		}
+		if ok {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-d.wake:
+		case <-d.opts.Clock.After(d.opts.PollInterval):
+		}
+	}
+}
+
+// attempt is a claimed delivery with what is needed to send it.
+type attempt struct {
+	id         int64
+	messageID  string
+	body       []byte
+	endpointID int64
+	url        string
+	secret     string
+	disabled   bool
+	n          int // 1 on the first attempt
+	lease      string
+}
+
+// deliverNext sends the next due delivery, if any, and records the
+// outcome. It reports whether there was one.
+func (d *Dispatcher) deliverNext(ctx context.Context) (bool, error) {
+	a, err := d.claim(ctx)
+	if a == nil || err != nil {
+		return false, err
+	}
+	// The outcome is recorded even if ctx is cancelled meanwhile: the
+	// request was sent, and a shutdown is not a failure of the receiver.
+	ctx = context.WithoutCancel(ctx)
+	if a.disabled {
+		return true, d.finish(ctx, a, Dead, 0, "endpoint disabled")
+	}
+	code, err := d.send(ctx, a)
+	switch {
+	case err == nil:
+		return true, d.finish(ctx, a, Delivered, code, "")
+	case code == http.StatusGone:
+		// The receiver says the endpoint is gone for good; retrying for
+		// hours would only add load.
+		if derr := d.Disable(ctx, a.endpointID); derr != nil && !errors.Is(derr, ErrNotFound) {
+			return true, derr
+		}
+		return true, d.finish(ctx, a, Dead, code, err.Error())
+	case a.n >= d.opts.MaxAttempts:
+		return true, d.finish(ctx, a, Dead, code, err.Error())
+	default:
+		return true, d.retry(ctx, a, code, err.Error())
+	}
+}
+
+// claim leases the next due delivery, as the jobs queue claims jobs: one
+// UPDATE ... RETURNING, so two workers never send the same delivery at
+// the same time. The lease outlasts the request timeout, so a worker that
+// dies mid-request leaves the delivery to be sent again.
+func (d *Dispatcher) claim(ctx context.Context) (*attempt, error) {
+	now := d.now()
+	a := &attempt{lease: crand.Text()}
+	err := d.db.QueryRowContext(ctx, `
+		UPDATE deliveries
+		SET lease = ?, leased_until = ?, attempts = attempts + 1
+		WHERE id = (
+			SELECT id FROM deliveries
+			WHERE status = ? AND next_attempt_at <= ? AND (leased_until IS NULL OR leased_until <= ?)
+			ORDER BY next_attempt_at, id
+			LIMIT 1
+		)
+		RETURNING id, message_id, endpoint_id, attempts`,
+		a.lease, now.Add(d.opts.Timeout+5*time.Second).UnixMilli(), Pending, now.UnixMilli(), now.UnixMilli(),
+	).Scan(&a.id, &a.messageID, &a.endpointID, &a.n)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("claim: %w", err)
+	}
+	var disabled sql.NullInt64
+	err = d.db.QueryRowContext(ctx, `
+		SELECT m.body, e.url, e.secret, e.disabled_at
+		FROM messages m, endpoints e WHERE m.id = ? AND e.id = ?`, a.messageID, a.endpointID,
+	).Scan(&a.body, &a.url, &a.secret, &disabled)
+	if err != nil {
+		return nil, fmt.Errorf("delivery %d: %w", a.id, err)
+	}
+	a.disabled = disabled.Valid
+	return a, nil
+}
+
+// send POSTs the message and returns the response status. Any status but
+// 2xx is an error, with the start of the response body for the log.
+func (d *Dispatcher) send(ctx context.Context, a *attempt) (int, error) {
+	ctx, cancel := context.WithTimeout(ctx, d.opts.Timeout)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, a.url, bytes.NewReader(a.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if err := SetHeaders(req.Header, a.secret, a.messageID, d.now(), a.body); err != nil {
+		return 0, err
+	}
+	resp, err := d.opts.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	// Read a little of the body to report, and drain some more so the
+	// connection can be reused.
+	snippet, _ := io.ReadAll(io.LimitReader(resp.Body, 256))
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(snippet)))
+	}
+	return resp.StatusCode, nil
+}
+
+// finish records a delivery as delivered or dead, if this worker still
+// holds its lease.
+func (d *Dispatcher) finish(ctx context.Context, a *attempt, s Status, code int, errText string) error {
+	res, err := d.db.ExecContext(ctx, `UPDATE deliveries
+		SET status = ?, lease = NULL, leased_until = NULL, last_status = ?, last_error = ?, finished_at = ?
+		WHERE id = ? AND lease = ?`,
+		s, nullInt(code), nullString(errText), d.now().UnixMilli(), a.id, a.lease)
+	if err != nil {
+		return err
+	}
+	return checkLease(res)
+}
+
+// retry schedules the next attempt after the backoff.
+func (d *Dispatcher) retry(ctx context.Context, a *attempt, code int, errText string) error {
+	next := d.now().Add(d.opts.Backoff(a.n))
+	res, err := d.db.ExecContext(ctx, `UPDATE deliveries
+		SET next_attempt_at = ?, lease = NULL, leased_until = NULL, last_status = ?, last_error = ?
+		WHERE id = ? AND lease = ?`,
+		next.UnixMilli(), nullInt(code), errText, a.id, a.lease)
+	if err != nil {
+		return err
+	}
+	return checkLease(res)
+}
+
+func checkLease(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
+
+func nullInt(n int) sql.NullInt64 { return sql.NullInt64{Int64: int64(n), Valid: n != 0} }
+
+func nullString(s string) sql.NullString { return sql.NullString{String: s, Valid: s != ""} }
@@ -0,0 +1,371 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+var t0 = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func open(t *testing.T, opts Options) *Dispatcher {
+	t.Helper()
+	d, err := Open(filepath.Join(t.TempDir(), "webhooks.db"), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { d.Close() })
+	return d
+}
+
+func register(t *testing.T, d *Dispatcher, url string, events ...string) Endpoint {
+	t.Helper()
+	e, err := d.Register(context.Background(), url, events...)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return e
+}
+
+func publish(t *testing.T, d *Dispatcher, typ string, data any) string {
+	t.Helper()
+	id, err := d.Publish(context.Background(), typ, data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return id
+}
+
+func deliveries(t *testing.T, d *Dispatcher, s Status) []Delivery {
+	t.Helper()
+	ds, err := d.Deliveries(context.Background(), s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ds
+}
+
+// step sends the next due delivery and fails the test if there was none
+// or recording it failed.
+func step(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	ok, err := d.deliverNext(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !ok {
+		t.Fatal("no delivery was due")
+	}
+}
+
+func idle(t *testing.T, d *Dispatcher) {
+	t.Helper()
+	if ok, err := d.deliverNext(context.Background()); ok || err != nil {
+		t.Fatalf("deliverNext = %v, %v; want nothing due", ok, err)
+	}
+}
+
+func TestDeliver_SignedRequest(t *testing.T) {
+	fc := clock.NewFake(t0)
+	var got *http.Request
+	var body []byte
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = r
+		body, _ = io.ReadAll(r.Body)
+	}))
+	defer srv.Close()
+
+	d := open(t, Options{Clock: fc})
+	e := register(t, d, srv.URL+"/hooks")
+	id := publish(t, d, "user.created", map[string]any{"id": 1, "name": "Ada"})
+	step(t, d)
+
+	if got.Method != "POST" || got.URL.Path != "/hooks" || got.Header.Get("Content-Type") != "application/json" {
+		t.Errorf("request = %s %s (%s)", got.Method, got.URL.Path, got.Header.Get("Content-Type"))
+	}
+	if got.Header.Get(HeaderID) != id {
+		t.Errorf("%s = %q, want %q", HeaderID, got.Header.Get(HeaderID), id)
+	}
+	if err := Verify(got.Header, body, t0, time.Minute, e.Secret); err != nil {
+		t.Errorf("Verify: %v", err)
+	}
+	var m Message
+	if err := json.Unmarshal(body, &m); err != nil {
+		t.Fatal(err)
+	}
+	if m.Type != "user.created" || !m.Timestamp.Equal(t0) || string(m.Data) != `{"id":1,"name":"Ada"}` {
+		t.Errorf("message = %+v", m)
+	}
+
+	ds := deliveries(t, d, "")
+	if len(ds) != 1 || ds[0].Status != Delivered || ds[0].Attempts != 1 || ds[0].LastStatus != 200 || !ds[0].FinishedAt.Equal(t0) {
+		t.Fatalf("deliveries = %+v", ds)
+	}
+	idle(t, d)
+}
+
+func TestDeliver_OnlySubscribedEndpoints(t *testing.T) {
+	var created, all atomic.Int32
+	count := func(n *atomic.Int32) *httptest.Server {
+		return httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { n.Add(1) }))
+	}
+	a, b := count(&created), count(&all)
+	defer a.Close()
+	defer b.Close()
+
+	d := open(t, Options{Clock: clock.NewFake(t0)})
+	register(t, d, a.URL, "user.created")
+	register(t, d, b.URL)
+	gone := register(t, d, b.URL)
+	if err := d.Disable(context.Background(), gone.ID); err != nil {
+		t.Fatal(err)
+	}
+	publish(t, d, "user.created", 1)
+	publish(t, d, "user.deleted", 1)
+	for range 3 {
+		step(t, d)
+	}
+	idle(t, d)
+	if created.Load() != 1 || all.Load() != 2 {
+		t.Fatalf("user.created endpoint got %d, * endpoint got %d; want 1 and 2", created.Load(), all.Load())
+	}
+}
+
+func TestDeliver_RetriesWithBackoffThenDeadLetters(t *testing.T) {
+	fc := clock.NewFake(t0)
+	var fail atomic.Bool
+	fail.Store(true)
+	var calls atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		if fail.Load() {
+			http.Error(w, "database is down", http.StatusServiceUnavailable)
+		}
+	}))
+	defer srv.Close()
+
+	d := open(t, Options{
+		Clock:       fc,
+		MaxAttempts: 3,
+		Backoff:     func(n int) time.Duration { return time.Duration(n) * time.Minute },
+	})
+	register(t, d, srv.URL)
+	publish(t, d, "user.created", 1)
+
+	step(t, d)
+	dl := deliveries(t, d, Pending)[0]
+	if dl.Attempts != 1 || dl.LastStatus != 503 || dl.LastError != "HTTP 503: database is down" || !dl.NextAttemptAt.Equal(t0.Add(time.Minute)) {
+		t.Fatalf("after attempt 1: %+v", dl)
+	}
+	// Not due until the backoff has passed.
+	fc.Advance(59 * time.Second)
+	idle(t, d)
+	fc.Advance(time.Second)
+	step(t, d)
+	fc.Advance(2 * time.Minute)
+	step(t, d)
+	idle(t, d)
+
+	dead := deliveries(t, d, Dead)
+	if len(dead) != 1 || dead[0].Attempts != 3 || dead[0].LastStatus != 503 || calls.Load() != 3 {
+		t.Fatalf("dead = %+v after %d calls", dead, calls.Load())
+	}
+
+	// Once the receiver is fixed, the dead letter can be sent again.
+	fail.Store(false)
+	if err := d.Redeliver(context.Background(), dead[0].ID); err != nil {
+		t.Fatal(err)
+	}
+	step(t, d)
+	if dl, _ := d.Delivery(context.Background(), dead[0].ID); dl.Status != Delivered || dl.Attempts != 1 {
+		t.Fatalf("after Redeliver: %+v", dl)
+	}
+	if err := d.Redeliver(context.Background(), dead[0].ID); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Redeliver of a delivered delivery: err = %v, want ErrNotFound", err)
+	}
+}
+
+func TestDeliver_FailuresThatAreRetried(t *testing.T) {
+	tests := map[string]http.HandlerFunc{
+		"redirect": func(w http.ResponseWriter, r *http.Request) {
+			http.Redirect(w, r, "https://example.com/", http.StatusFound)
+		},
+		"client error": func(w http.ResponseWriter, r *http.Request) { http.Error(w, "bad", http.StatusBadRequest) },
+		"timeout": func(w http.ResponseWriter, r *http.Request) {
+			io.ReadAll(r.Body) // so the server notices when the client hangs up
+			<-r.Context().Done()
+		},
+		"dropped connection": func(w http.ResponseWriter, r *http.Request) {
+			conn, _, _ := w.(http.Hijacker).Hijack()
+			conn.Close()
+		},
+	}
+	for name, h := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(h)
+			defer srv.Close()
+			d := open(t, Options{Clock: clock.NewFake(t0), Timeout: 100 * time.Millisecond})
+			register(t, d, srv.URL)
+			publish(t, d, "user.created", 1)
+			step(t, d)
+			if ds := deliveries(t, d, Pending); len(ds) != 1 || ds[0].Attempts != 1 || ds[0].LastError == "" {
+				t.Fatalf("deliveries = %+v, want one pending with an error", ds)
+			}
+		})
+	}
+}
+
+func TestDeliver_GoneDisablesEndpoint(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "no such hook", http.StatusGone)
+	}))
+	defer srv.Close()
+
+	d := open(t, Options{Clock: clock.NewFake(t0)})
+	register(t, d, srv.URL)
+	publish(t, d, "user.created", 1)
+	publish(t, d, "user.created", 2)
+	step(t, d)
+	step(t, d) // dead-lettered without a request
+	if dead := deliveries(t, d, Dead); len(dead) != 2 || dead[0].LastStatus != 410 || dead[1].LastError != "endpoint disabled" {
+		t.Fatalf("dead = %+v", dead)
+	}
+	eps, _ := d.Endpoints(context.Background())
+	if eps[0].DisabledAt.IsZero() {
+		t.Fatal("endpoint not disabled")
+	}
+	publish(t, d, "user.created", 3)
+	if ds := deliveries(t, d, ""); len(ds) != 2 {
+		t.Fatalf("%d deliveries after disabling, want no new ones", len(ds))
+	}
+}
+
+func TestRegister_InvalidURL(t *testing.T) {
+	d := open(t, Options{})
+	for _, u := range []string{"", "example.com/hooks", "ftp://example.com/", "http://"} {
+		if _, err := d.Register(context.Background(), u); !errors.Is(err, ErrInvalidURL) {
+			t.Errorf("Register(%q): err = %v, want ErrInvalidURL", u, err)
+		}
+	}
+}
+
+func TestPublish_SurvivesRestart(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "webhooks.db")
+	d, err := Open(path, Options{Clock: clock.NewFake(t0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	register(t, d, "http://127.0.0.1:1/never")
+	publish(t, d, "user.created", 1)
+	d.Close()
+
+	var got atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) { got.Add(1) }))
+	defer srv.Close()
+	d, err = Open(path, Options{Clock: clock.NewFake(t0)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer d.Close()
+	if _, err := d.db.Exec(`UPDATE endpoints SET url = ?`, srv.URL); err != nil {
+		t.Fatal(err)
+	}
+	step(t, d)
+	if got.Load() != 1 {
+		t.Fatal("the delivery stored before the restart was not sent")
+	}
+}
+
+// TestRun_FlakyReceivers sends many messages to receivers that fail a
+// third of the time in different ways, with several workers, and checks
+// that every message arrives at every receiver.
+func TestRun_FlakyReceivers(t *testing.T) {
+	type receiver struct {
+		srv  *httptest.Server
+		mu   sync.Mutex
+		seen map[string]int // handled message IDs
+	}
+	var n atomic.Int64
+	newReceiver := func() *receiver {
+		rc := &receiver{seen: map[string]int{}}
+		rc.srv = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			switch n.Add(1) % 6 {
+			case 1:
+				http.Error(w, "overloaded", http.StatusServiceUnavailable)
+				return
+			case 3:
+				conn, _, _ := w.(http.Hijacker).Hijack()
+				conn.Close()
+				return
+			}
+			rc.mu.Lock()
+			rc.seen[r.Header.Get(HeaderID)]++
+			rc.mu.Unlock()
+		}))
+		return rc
+	}
+	a, b := newReceiver(), newReceiver()
+	defer a.srv.Close()
+	defer b.srv.Close()
+
+	d := open(t, Options{
+		MaxAttempts:  20,
+		Backoff:      func(int) time.Duration { return 5 * time.Millisecond },
+		PollInterval: 5 * time.Millisecond,
+		Timeout:      time.Second,
+	})
+	register(t, d, a.srv.URL)
+	register(t, d, b.srv.URL)
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() { d.Run(ctx, 4); close(done) }()
+	defer func() { cancel(); <-done }()
+
+	const messages = 30
+	ids := map[string]bool{}
+	for i := range messages {
+		ids[publish(t, d, "user.created", i)] = true
+	}
+	deadline := time.Now().Add(10 * time.Second)
+	for len(deliveries(t, d, Delivered)) < 2*messages {
+		if time.Now().After(deadline) {
+			t.Fatalf("only %d of %d delivered", len(deliveries(t, d, Delivered)), 2*messages)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	retried := 0
+	for _, dl := range deliveries(t, d, "") {
+		if dl.Attempts > 1 {
+			retried++
+		}
+	}
+	if retried == 0 {
+		t.Error("no delivery needed a retry; the receivers are not flaky")
+	}
+	for name, rc := range map[string]*receiver{"a": a, "b": b} {
+		rc.mu.Lock()
+		if len(rc.seen) != messages {
+			t.Errorf("receiver %s got %d messages, want %d", name, len(rc.seen), messages)
+		}
+		for id, count := range rc.seen {
+			if !ids[id] || count != 1 {
+				t.Errorf("receiver %s got %s %d times", name, id, count)
+			}
+		}
+		rc.mu.Unlock()
+	}
+	if dead := deliveries(t, d, Dead); len(dead) != 0 {
+		t.Errorf("dead letters: %+v", dead)
+	}
+}
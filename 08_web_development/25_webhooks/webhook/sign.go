@@ -0,0 +1,112 @@
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// The headers of a webhook request, as in the Standard Webhooks
+// specification (https://www.standardwebhooks.com).
+const (
+	HeaderID        = "Webhook-Id"        // the message ID, the same on every retry
+	HeaderTimestamp = "Webhook-Timestamp" // unix seconds, when this attempt was signed
+	HeaderSignature = "Webhook-Signature" // "v1,<base64 HMAC-SHA256>", space separated if several
+)
+
+const secretPrefix = "whsec_"
+
+var (
+	ErrNoSignature  = errors.New("webhook: missing signature headers")
+	ErrBadSignature = errors.New("webhook: no matching signature")
+	ErrBadSecret    = errors.New("webhook: malformed secret")
+	// ErrTimestamp is returned for a timestamp outside the tolerance. A
+	// captured request replayed later fails this check even though its
+	// signature is valid.
+	ErrTimestamp = errors.New("webhook: timestamp too old or in the future")
+)
+
+// NewSecret returns a new signing secret: "whsec_" and 32 random bytes in
+// base64.
+func NewSecret() string {
+	key := make([]byte, 32)
+	rand.Read(key)
+	return secretPrefix + base64.StdEncoding.EncodeToString(key)
+}
+
+func secretKey(secret string) ([]byte, error) {
+	key, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(secret, secretPrefix))
+	if err != nil || len(key) == 0 {
+		return nil, ErrBadSecret
+	}
+	return key, nil
+}
+
+// Sign returns the signature header value for a message. The signature
+// covers the ID and the timestamp as well as the body, so neither can be
+// changed without the secret.
+func Sign(secret, id string, ts time.Time, body []byte) (string, error) {
+	key, err := secretKey(secret)
+	if err != nil {
+		return "", err
+	}
+	return "v1," + base64.StdEncoding.EncodeToString(mac(key, id, ts.Unix(), body)), nil
+}
+
+func mac(key []byte, id string, ts int64, body []byte) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(id + "." + strconv.FormatInt(ts, 10) + "."))
+	h.Write(body)
+	return h.Sum(nil)
+}
+
+// SetHeaders signs body and sets the three webhook headers on h.
+func SetHeaders(h http.Header, secret, id string, ts time.Time, body []byte) error {
+	sig, err := Sign(secret, id, ts, body)
+	if err != nil {
+		return err
+	}
+	h.Set(HeaderID, id)
+	h.Set(HeaderTimestamp, strconv.FormatInt(ts.Unix(), 10))
+	h.Set(HeaderSignature, sig)
+	return nil
+}
+
+// Verify checks the webhook headers in h against body. It accepts the
+// message if any signature in the header matches any of the secrets, so
+// a secret can be rotated by trusting the old and new one for a while,
+// and if the timestamp is within tolerance of now.
+func Verify(h http.Header, body []byte, now time.Time, tolerance time.Duration, secrets ...string) error {
+	id, tsText, sigs := h.Get(HeaderID), h.Get(HeaderTimestamp), h.Get(HeaderSignature)
+	if id == "" || tsText == "" || sigs == "" {
+		return ErrNoSignature
+	}
+	ts, err := strconv.ParseInt(tsText, 10, 64)
+	if err != nil {
+		return ErrNoSignature
+	}
+	if d := now.Sub(time.Unix(ts, 0)); d > tolerance || d < -tolerance {
+		return ErrTimestamp
+	}
+	for _, secret := range secrets {
+		key, err := secretKey(secret)
+		if err != nil {
+			return err
+		}
+		want := mac(key, id, ts, body)
+		for _, sig := range strings.Fields(sigs) {
+			version, b64, _ := strings.Cut(sig, ",")
+			got, err := base64.StdEncoding.DecodeString(b64)
+			if version == "v1" && err == nil && hmac.Equal(got, want) {
+				return nil
+			}
+		}
+	}
+	return ErrBadSignature
+}
@@ -0,0 +1,81 @@
+package webhook
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+// The example from the Standard Webhooks specification, so signatures
+// from this package verify with any of its libraries.
+func TestSign_SpecExample(t *testing.T) {
+	sig, err := Sign("whsec_MfKQ9r8GKYqrTwjUPD8ILPZIo2LaLaSw", "msg_p5jXN8AQM9LWM0D4loKWxJek",
+		time.Unix(1614265330, 0), []byte(`{"test": 2432232314}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "v1,g0hM9SsE+OTPJTGt/tmIKtSyZlE3uFJELVlNIOLJ1OE="; sig != want {
+		t.Fatalf("Sign = %s, want %s", sig, want)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	t0 := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	secret, other := NewSecret(), NewSecret()
+	body := []byte(`{"type":"user.created"}`)
+	signed := func() http.Header {
+		h := http.Header{}
+		if err := SetHeaders(h, secret, "msg_1", t0, body); err != nil {
+			t.Fatal(err)
+		}
+		return h
+	}
+
+	tests := []struct {
+		name    string
+		edit    func(h http.Header)
+		body    string
+		now     time.Time
+		secrets []string
+		want    error
+	}{
+		{name: "valid", secrets: []string{secret}},
+		{name: "clock skew within tolerance", now: t0.Add(-4 * time.Minute), secrets: []string{secret}},
+		{name: "one of several secrets", secrets: []string{other, secret}},
+		{name: "one of several signatures", edit: func(h http.Header) {
+			h.Set(HeaderSignature, "v1,AAAA "+h.Get(HeaderSignature))
+		}, secrets: []string{secret}},
+		{name: "wrong secret", secrets: []string{other}, want: ErrBadSignature},
+		{name: "changed body", body: `{"type":"user.deleted"}`, secrets: []string{secret}, want: ErrBadSignature},
+		{name: "changed id", edit: func(h http.Header) { h.Set(HeaderID, "msg_2") }, secrets: []string{secret}, want: ErrBadSignature},
+		{name: "changed timestamp", edit: func(h http.Header) { h.Set(HeaderTimestamp, "1714565000") },
+			now: time.Unix(1714565000, 0), secrets: []string{secret}, want: ErrBadSignature},
+		{name: "unknown version", edit: func(h http.Header) { h.Set(HeaderSignature, "v2"+h.Get(HeaderSignature)[2:]) },
+			secrets: []string{secret}, want: ErrBadSignature},
+		{name: "replayed later", now: t0.Add(6 * time.Minute), secrets: []string{secret}, want: ErrTimestamp},
+		{name: "from the future", now: t0.Add(-6 * time.Minute), secrets: []string{secret}, want: ErrTimestamp},
+		{name: "no signature", edit: func(h http.Header) { h.Del(HeaderSignature) }, secrets: []string{secret}, want: ErrNoSignature},
+		{name: "bad timestamp", edit: func(h http.Header) { h.Set(HeaderTimestamp, "yesterday") }, secrets: []string{secret}, want: ErrNoSignature},
+		{name: "bad secret", secrets: []string{"whsec_!!"}, want: ErrBadSecret},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := signed()
+			if tt.edit != nil {
+				tt.edit(h)
+			}
+			b := body
+			if tt.body != "" {
+				b = []byte(tt.body)
+			}
+			now := tt.now
+			if now.IsZero() {
+				now = t0
+			}
+			if err := Verify(h, b, now, 5*time.Minute, tt.secrets...); !errors.Is(err, tt.want) {
+				t.Fatalf("Verify = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,376 @@
+// Package webhook delivers events to the HTTP endpoints that subscribed
+// to them.
+//
+// Publish stores the event and one delivery per subscribed endpoint in
+// SQLite, in one transaction, and returns. Workers started by Run POST
+// each delivery, signed with the endpoint's secret, and retry failures
+// with backoff. After MaxAttempts the delivery is dead-lettered: it stays
+// in the table with its last error and can be sent again with Redeliver.
+//
+// Deliveries are at least once. A retry carries the same Webhook-Id, so
+// receivers can drop duplicates.
+package webhook
+
+import (
+	"context"
+	crand "crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+var (
+	ErrNotFound   = errors.New("webhook: not found")
+	ErrInvalidURL = errors.New("webhook: endpoint URL must be absolute http or https")
+	// ErrLeaseLost is returned when recording the outcome of a delivery
+	// whose lease expired, and which another worker may have taken.
+	ErrLeaseLost = errors.New("webhook: delivery lease lost")
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS endpoints (
+	id          INTEGER PRIMARY KEY AUTOINCREMENT,
+	url         TEXT    NOT NULL,
+	secret      TEXT    NOT NULL, -- needed in the clear to sign
+	events      TEXT    NOT NULL, -- space separated event types, or *
+	created_at  INTEGER NOT NULL, -- unix ms
+	disabled_at INTEGER
+);
+CREATE TABLE IF NOT EXISTS messages (
+	id         TEXT    PRIMARY KEY, -- the Webhook-Id header
+	type       TEXT    NOT NULL,
+	body       BLOB    NOT NULL,
+	created_at INTEGER NOT NULL
+);
+CREATE TABLE IF NOT EXISTS deliveries (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	message_id      TEXT    NOT NULL REFERENCES messages (id),
+	endpoint_id     INTEGER NOT NULL REFERENCES endpoints (id),
+	status          TEXT    NOT NULL, -- pending, delivered or dead
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at INTEGER NOT NULL,
+	lease           TEXT,             -- token of the worker sending it
+	leased_until    INTEGER,
+	last_status     INTEGER,          -- HTTP status of the last attempt
+	last_error      TEXT,
+	created_at      INTEGER NOT NULL,
+	finished_at     INTEGER
+);
+CREATE INDEX IF NOT EXISTS deliveries_due ON deliveries (status, next_attempt_at, id);
+CREATE INDEX IF NOT EXISTS deliveries_endpoint ON deliveries (endpoint_id, id);`
+
+// Options configures a Dispatcher. Zero values select the defaults.
+type Options struct {
+	// MaxAttempts is how many times a delivery is tried before it is
+	// dead-lettered (default 8).
+	MaxAttempts int
+	// Backoff returns the delay before retry n (n >= 1). The default is
+	// exponential from 10s, capped at 1h, with full jitter: 8 attempts
+	// span about two hours.
+	Backoff func(n int) time.Duration
+	// Timeout bounds one attempt, from connecting to reading the status
+	// (default 10s). A receiver should answer at once and do slow work
+	// later.
+	Timeout time.Duration
+	// PollInterval is how often idle workers look for due retries
+	// (default 1s). Publish wakes them at once.
+	PollInterval time.Duration
+	// Client sends the requests (default: a client that does not follow
+	// redirects, so a 3xx counts as a failure).
+	Client *http.Client
+	// Clock says when deliveries are due and stamps the signatures
+	// (default clock.Real).
+	Clock clock.Clock
+}
+
+// Status is where a delivery stands.
+type Status string
+
+const (
+	Pending   Status = "pending"
+	Delivered Status = "delivered"
+	Dead      Status = "dead"
+)
+
+// Endpoint is a registered receiver.
+type Endpoint struct {
+	ID         int64     `json:"id"`
+	URL        string    `json:"url"`
+	Secret     string    `json:"secret"`
+	Events     []string  `json:"events"`
+	CreatedAt  time.Time `json:"created_at"`
+	DisabledAt time.Time `json:"disabled_at,omitzero"`
+}
+
+// Wants reports whether the endpoint subscribed to events of type typ.
+func (e Endpoint) Wants(typ string) bool {
+	return e.DisabledAt.IsZero() && (slices.Contains(e.Events, "*") || slices.Contains(e.Events, typ))
+}
+
+// Message is the body of every webhook request.
+type Message struct {
+	Type      string          `json:"type"`
+	Timestamp time.Time       `json:"timestamp"` // when the event happened
+	Data      json.RawMessage `json:"data"`
+}
+
+// Delivery is one message on its way to one endpoint.
+type Delivery struct {
+	ID            int64     `json:"id"`
+	MessageID     string    `json:"message_id"`
+	Type          string    `json:"type"`
+	EndpointID    int64     `json:"endpoint_id"`
+	Status        Status    `json:"status"`
+	Attempts      int       `json:"attempts"`
+	NextAttemptAt time.Time `json:"next_attempt_at,omitzero"`
+	LastStatus    int       `json:"last_status,omitempty"`
+	LastError     string    `json:"last_error,omitempty"`
+	CreatedAt     time.Time `json:"created_at"`
+	FinishedAt    time.Time `json:"finished_at,omitzero"`
+}
+
+// Dispatcher stores and sends deliveries. It is safe for concurrent use.
+type Dispatcher struct {
+	db   *sql.DB
+	opts Options
+	wake chan struct{}
+}
+
+// Open opens (creating if needed) the webhook database at path.
+func Open(path string, opts Options) (*Dispatcher, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// As in the jobs queue: every claim writes, and one connection makes
+	// workers take turns fairly instead of in SQLite's busy handler.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 8
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = defaultBackoff
+	}
+	if opts.Timeout <= 0 {
+		opts.Timeout = 10 * time.Second
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Client == nil {
+		opts.Client = &http.Client{
+			CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+		}
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real()
+	}
+	return &Dispatcher{db: db, opts: opts, wake: make(chan struct{}, 1)}, nil
+}
+
+// Close closes the database. Stop the workers first.
+func (d *Dispatcher) Close() error { return d.db.Close() }
+
+func (d *Dispatcher) now() time.Time { return d.opts.Clock.Now() }
+
+func defaultBackoff(n int) time.Duration {
+	b := min(10*time.Second<<min(n-1, 20), time.Hour)
+	return rand.N(b) + 1 // full jitter: endpoints that were down together do not all retry together
+}
+
+// Register adds an endpoint for the given event types ("*" for all) with
+// a new secret. The receiver needs the secret to verify signatures.
+func (d *Dispatcher) Register(ctx context.Context, rawURL string, events ...string) (Endpoint, error) {
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return Endpoint{}, ErrInvalidURL
+	}
+	if len(events) == 0 {
+		events = []string{"*"}
+	}
+	e := Endpoint{URL: rawURL, Secret: NewSecret(), Events: events, CreatedAt: d.now().UTC().Truncate(time.Millisecond)}
+	res, err := d.db.ExecContext(ctx, `INSERT INTO endpoints (url, secret, events, created_at) VALUES (?, ?, ?, ?)`,
+		e.URL, e.Secret, strings.Join(events, " "), e.CreatedAt.UnixMilli())
+	if err != nil {
+		return Endpoint{}, err
+	}
+	e.ID, err = res.LastInsertId()
+	return e, err
+}
+
+// Disable stops new deliveries to an endpoint. Pending ones are
+// dead-lettered when they come up.
+func (d *Dispatcher) Disable(ctx context.Context, id int64) error {
+	res, err := d.db.ExecContext(ctx, `UPDATE endpoints SET disabled_at = ? WHERE id = ? AND disabled_at IS NULL`, d.now().UnixMilli(), id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	return nil
+}
+
+// Endpoints returns every endpoint, disabled ones included.
+func (d *Dispatcher) Endpoints(ctx context.Context) ([]Endpoint, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT id, url, secret, events, created_at, disabled_at FROM endpoints ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Endpoint
+	for rows.Next() {
+		var e Endpoint
+		var events string
+		var created int64
+		var disabled sql.NullInt64
+		if err := rows.Scan(&e.ID, &e.URL, &e.Secret, &events, &created, &disabled); err != nil {
+			return nil, err
+		}
+		e.Events = strings.Fields(events)
+		e.CreatedAt = time.UnixMilli(created).UTC()
+		if disabled.Valid {
+			e.DisabledAt = time.UnixMilli(disabled.Int64).UTC()
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// Publish records an event of type typ with data marshalled to JSON, and
+// a pending delivery for every endpoint that wants it. It returns the
+// message ID. The deliveries are durable once Publish returns; the
+// requests are sent by the workers.
+func (d *Dispatcher) Publish(ctx context.Context, typ string, data any) (string, error) {
+	raw, err := json.Marshal(data)
+	if err != nil {
+		return "", fmt.Errorf("publish %s: %w", typ, err)
+	}
+	now := d.now()
+	body, err := json.Marshal(Message{Type: typ, Timestamp: now.UTC(), Data: raw})
+	if err != nil {
+		return "", fmt.Errorf("publish %s: %w", typ, err)
+	}
+	endpoints, err := d.Endpoints(ctx)
+	if err != nil {
+		return "", fmt.Errorf("publish %s: %w", typ, err)
+	}
+
+	id := "msg_" + crand.Text()
+	tx, err := d.db.BeginTx(ctx, nil)
+	if err != nil {
+		return "", err
+	}
+	defer tx.Rollback()
+	if _, err := tx.ExecContext(ctx, `INSERT INTO messages (id, type, body, created_at) VALUES (?, ?, ?, ?)`,
+		id, typ, body, now.UnixMilli()); err != nil {
+		return "", fmt.Errorf("publish %s: %w", typ, err)
+	}
+	for _, e := range endpoints {
+		if !e.Wants(typ) {
+			continue
+		}
+		if _, err := tx.ExecContext(ctx,
+			`INSERT INTO deliveries (message_id, endpoint_id, status, next_attempt_at, created_at) VALUES (?, ?, ?, ?, ?)`,
+			id, e.ID, Pending, now.UnixMilli(), now.UnixMilli()); err != nil {
+			return "", fmt.Errorf("publish %s: %w", typ, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return "", fmt.Errorf("publish %s: %w", typ, err)
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return id, nil
+}
+
+const deliveryColumns = `d.id, d.message_id, m.type, d.endpoint_id, d.status, d.attempts, d.next_attempt_at,
+	d.last_status, d.last_error, d.created_at, d.finished_at`
+
+// Deliveries returns the deliveries with the given status, or all of them
+// if status is "", oldest first.
+func (d *Dispatcher) Deliveries(ctx context.Context, status Status) ([]Delivery, error) {
+	rows, err := d.db.QueryContext(ctx, `SELECT `+deliveryColumns+`
+		FROM deliveries d JOIN messages m ON m.id = d.message_id
+		WHERE ? = '' OR d.status = ? ORDER BY d.id`, status, status)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Delivery
+	for rows.Next() {
+		dl, err := scanDelivery(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, dl)
+	}
+	return out, rows.Err()
+}
+
+// Delivery returns one delivery.
+func (d *Dispatcher) Delivery(ctx context.Context, id int64) (Delivery, error) {
+	dl, err := scanDelivery(d.db.QueryRowContext(ctx, `SELECT `+deliveryColumns+`
+		FROM deliveries d JOIN messages m ON m.id = d.message_id WHERE d.id = ?`, id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return Delivery{}, ErrNotFound
+	}
+	return dl, err
+}
+
+func scanDelivery(row interface{ Scan(...any) error }) (Delivery, error) {
+	var dl Delivery
+	var next, created int64
+	var lastStatus, finished sql.NullInt64
+	var lastError sql.NullString
+	if err := row.Scan(&dl.ID, &dl.MessageID, &dl.Type, &dl.EndpointID, &dl.Status, &dl.Attempts, &next,
+		&lastStatus, &lastError, &created, &finished); err != nil {
+		return Delivery{}, err
+	}
+	if dl.Status == Pending {
+		dl.NextAttemptAt = time.UnixMilli(next).UTC()
+	}
+	dl.LastStatus = int(lastStatus.Int64)
+	dl.LastError = lastError.String
+	dl.CreatedAt = time.UnixMilli(created).UTC()
+	if finished.Valid {
+		dl.FinishedAt = time.UnixMilli(finished.Int64).UTC()
+	}
+	return dl, nil
+}
+
+// Redeliver makes a dead delivery pending again, with a fresh set of
+// attempts, once the receiver is fixed. The message keeps its ID.
+func (d *Dispatcher) Redeliver(ctx context.Context, id int64) error {
+	res, err := d.db.ExecContext(ctx, `UPDATE deliveries
+		SET status = ?, attempts = 0, next_attempt_at = ?, lease = NULL, leased_until = NULL, finished_at = NULL
+		WHERE id = ? AND status = ?`, Pending, d.now().UnixMilli(), id, Dead)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return ErrNotFound
+	}
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/rpc"
+)
+
+// CallContext is client.Call that gives up when ctx is done.
+//
+// net/rpc has no cancellation: once a request is sent, the server runs the
+// method to completion no matter what the client does. CallContext only
+// stops *waiting*. The abandoned call stays in the client's pending table
+// until the server replies or the connection closes, and its late reply is
+// still decoded into reply, so callers must not reuse reply after an error.
+func CallContext(ctx context.Context, client *rpc.Client, serviceMethod string, args, reply any) error {
+	// Buffered so the client's reader goroutine never blocks delivering a
+	// reply nobody is waiting for.
+	call := client.Go(serviceMethod, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-call.Done:
+		return call.Error
+	case <-ctx.Done():
+		go reapAbandoned(call)
+		return fmt.Errorf("%s: %w", serviceMethod, ctx.Err())
+	}
+}
+
+// reapAbandoned waits for the late result of an abandoned call and drops it.
+// It returns when the server replies or, at the latest, when the client is
+// closed, which fails every pending call.
+func reapAbandoned(call *rpc.Call) {
+	done := <-call.Done
+	log.Printf("Abandoned call %s finished late (err=%v); result discarded", done.ServiceMethod, done.Error)
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/rpc"
+	"testing"
+	"time"
+)
+
+func newPipeClient(t *testing.T, slow *SlowService) *rpc.Client {
+	t.Helper()
+	srv := rpc.NewServer()
+	if err := srv.Register(new(ArithService)); err != nil {
+		t.Fatal(err)
+	}
+	if err := srv.Register(slow); err != nil {
+		t.Fatal(err)
+	}
+	serverConn, clientConn := net.Pipe()
+	go srv.ServeConn(serverConn)
+	client := rpc.NewClient(clientConn)
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestCallContext_Completes(t *testing.T) {
+	client := newPipeClient(t, new(SlowService))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var reply int
+	if err := CallContext(ctx, client, "SlowService.Sleep", &Args{A: 10}, &reply); err != nil || reply != 10 {
+		t.Fatalf("Sleep(10ms) = %d, %v; want 10", reply, err)
+	}
+}
+
+func TestCallContext_DeadlineExpires(t *testing.T) {
+	slow := new(SlowService)
+	client := newPipeClient(t, slow)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	var late int
+	err := CallContext(ctx, client, "SlowService.Sleep", &Args{A: 200}, &late)
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("err = %v; want DeadlineExceeded", err)
+	}
+	if elapsed := time.Since(start); elapsed > 150*time.Millisecond {
+		t.Fatalf("CallContext returned after %v; want about 20ms", elapsed)
+	}
+
+	// The connection is still usable while the abandoned call runs.
+	var sum int
+	if err := CallContext(context.Background(), client, "ArithService.Add", &Args{2, 3}, &sum); err != nil || sum != 5 {
+		t.Fatalf("Add after timeout = %d, %v; want 5", sum, err)
+	}
+
+	// net/rpc cannot cancel server-side work: the method still completes.
+	deadline := time.Now().Add(2 * time.Second)
+	for slow.completed.Load() == 0 {
+		if time.Now().After(deadline) {
+			t.Fatalf("abandoned call never completed on the server")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCallContext_AlreadyCanceled(t *testing.T) {
+	client := newPipeClient(t, new(SlowService))
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var reply int
+	if err := CallContext(ctx, client, "SlowService.Sleep", &Args{A: 100}, &reply); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v; want context.Canceled", err)
+	}
+}
+
+func TestCallContext_CloseReleasesAbandonedCalls(t *testing.T) {
+	client := newPipeClient(t, new(SlowService))
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	var reply int
+	call := client.Go("SlowService.Sleep", &Args{A: 1000}, &reply, make(chan *rpc.Call, 1))
+	<-ctx.Done()
+
+	// Closing the client fails every pending call, so reapers never leak.
+	client.Close()
+	select {
+	case c := <-call.Done:
+		if c.Error == nil {
+			t.Fatalf("pending call succeeded after Close; want an error")
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("pending call not released by Close")
+	}
+}
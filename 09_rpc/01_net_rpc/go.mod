@@ -1,3 +1,8 @@
 module net-rpc-example
 
 go 1.24.11
+
+require golang_roadmap/12_operations/01_health v0.0.0
+
+// The health package lives in its own module in this repository.
+replace golang_roadmap/12_operations/01_health => ../../12_operations/01_health
@@ -0,0 +1,54 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"net/rpc"
+	"time"
+
+	"golang_roadmap/12_operations/01_health/health"
+)
+
+// rpcRoundTrip checks the RPC server the way a client would: dial, call a
+// cheap method, check the answer. A listening socket alone proves little;
+// the accept loop or the codec can be broken behind it.
+func rpcRoundTrip(addr string) health.Checker {
+	return health.CheckerFunc(func(ctx context.Context) error {
+		var d net.Dialer
+		conn, err := d.DialContext(ctx, "tcp", addr)
+		if err != nil {
+			return err
+		}
+		client := rpc.NewClient(conn)
+		defer client.Close()
+		var reply int
+		if err := CallContext(ctx, client, "ArithService.Multiply", &Args{A: 6, B: 7}, &reply); err != nil {
+			return err
+		}
+		if reply != 42 {
+			return fmt.Errorf("ArithService.Multiply(6, 7) = %d", reply)
+		}
+		return nil
+	})
+}
+
+// serveHealth exposes /livez and /readyz for the RPC server on a separate
+// HTTP port, since probes speak HTTP and the RPC port does not.
+func serveHealth(addr, rpcAddr string) *health.Registry {
+	checks := health.New()
+	checks.Register(health.Readiness, "rpc", rpcRoundTrip(rpcAddr),
+		health.WithTimeout(time.Second), health.WithCacheTTL(5*time.Second))
+
+	mux := http.NewServeMux()
+	checks.Mount(mux)
+	go func() {
+		log.Printf("Health endpoints on %s (/livez, /readyz)", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("Health server: %v", err)
+		}
+	}()
+	return checks
+}
@@ -0,0 +1,29 @@
+package main
+
+import (
+	"context"
+	"net"
+	"net/rpc"
+	"testing"
+)
+
+func TestRPCRoundTrip(t *testing.T) {
+	srv := rpc.NewServer()
+	if err := srv.Register(new(ArithService)); err != nil {
+		t.Fatal(err)
+	}
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	go srv.Accept(ln)
+	check := rpcRoundTrip(ln.Addr().String())
+
+	if err := check.Check(context.Background()); err != nil {
+		t.Fatalf("server up: %v", err)
+	}
+	ln.Close()
+	if err := check.Check(context.Background()); err == nil {
+		t.Fatal("server down: check passed")
+	}
+}
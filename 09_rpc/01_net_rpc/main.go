@@ -1,6 +1,8 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"log"
 	"net"
@@ -8,6 +10,7 @@ import (
 	"os"
 	"os/signal"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -67,6 +70,22 @@ func (s *StringService) Length(args *Args, reply *int) error {
 	return nil
 }
 
+// SlowService simulates a method that can take a long time
+type SlowService struct {
+	completed atomic.Int64
+}
+
+// Sleep waits for A milliseconds, then replies with A
+func (s *SlowService) Sleep(args *Args, reply *int) error {
+	time.Sleep(time.Duration(args.A) * time.Millisecond)
+	s.completed.Add(1)
+	log.Printf("Server finished Sleep(%dms)", args.A)
+	*reply = args.A
+	return nil
+}
+
+var slowSvc = new(SlowService)
+
 func startServer(wg *sync.WaitGroup) {
 	defer wg.Done()
 
@@ -76,6 +95,7 @@ func startServer(wg *sync.WaitGroup) {
 
 	rpc.Register(arith)
 	rpc.Register(stringSvc)
+	rpc.Register(slowSvc)
 
 	// Start listening
 	listener, err := net.Listen("tcp", ":1234")
@@ -193,6 +213,35 @@ func runClient() {
 		fmt.Printf("Async Multiply(7, 8) = %d\n", reply)
 	}
 
+	// Calls with a deadline
+	fmt.Println("\n=== RPC Calls with a Deadline ===")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 200*time.Millisecond)
+	err = CallContext(ctx, client, "SlowService.Sleep", &Args{A: 50}, &reply)
+	cancel()
+	if err != nil {
+		log.Printf("Sleep(50ms) error: %v", err)
+	} else {
+		fmt.Printf("Sleep(50ms) within 200ms deadline = %d\n", reply)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 100*time.Millisecond)
+	start := time.Now()
+	var lateReply int // not reused: the late reply is still written into it
+	err = CallContext(ctx, client, "SlowService.Sleep", &Args{A: 500}, &lateReply)
+	cancel()
+	if errors.Is(err, context.DeadlineExceeded) {
+		fmt.Printf("Sleep(500ms) with 100ms deadline gave up after %v: %v\n", time.Since(start).Round(10*time.Millisecond), err)
+	}
+
+	// The client is still usable while the abandoned call runs on.
+	err = CallContext(context.Background(), client, "ArithService.Add", &Args{1, 2}, &reply)
+	fmt.Printf("Add(1, 2) right after the timeout = %d (err=%v)\n", reply, err)
+
+	// The server was never told to stop: it finishes the work anyway.
+	time.Sleep(500 * time.Millisecond)
+	fmt.Printf("Server completed %d Sleep calls, including the abandoned one\n", slowSvc.completed.Load())
+
 	fmt.Println("\nRPC client finished")
 }
 
@@ -206,12 +255,17 @@ func main() {
 	// Wait a bit for server to start
 	time.Sleep(100 * time.Millisecond)
 
+	// Liveness and readiness probes for orchestrators and load balancers.
+	serveHealth(":1235", "localhost:1234")
+
 	// Run client
 	runClient()
 
 	// Wait for server to finish (it won't in this case)
 	// wg.Wait()
 
+	fmt.Println("\nServer still running. Try: curl -i localhost:1235/readyz")
+
 	// Graceful shutdown
 	c := make(chan os.Signal, 1)
 	signal.Notify(c, os.Interrupt)
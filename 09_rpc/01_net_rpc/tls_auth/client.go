@@ -0,0 +1,35 @@
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"net"
+	"net/rpc"
+	"time"
+)
+
+// dialAuth opens a TLS connection, performs the token handshake and returns
+// an rpc.Client that uses the authenticated connection.
+func dialAuth(addr, name, token string, tlsConfig *tls.Config) (*rpc.Client, error) {
+	dialer := &net.Dialer{Timeout: 5 * time.Second}
+	conn, err := tls.DialWithDialer(dialer, "tcp", addr, tlsConfig)
+	if err != nil {
+		return nil, err
+	}
+	conn.SetDeadline(time.Now().Add(5 * time.Second))
+	if _, err := fmt.Fprintf(conn, "%s %s %s\n", authCommand, name, token); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	reply, err := readLine(conn, maxAuthLine)
+	if err != nil {
+		conn.Close()
+		return nil, fmt.Errorf("reading auth reply: %w", err)
+	}
+	if reply != replyOK {
+		conn.Close()
+		return nil, fmt.Errorf("%w: server said %q", errUnauthorized, reply)
+	}
+	conn.SetDeadline(time.Time{})
+	return rpc.NewClient(conn), nil
+}
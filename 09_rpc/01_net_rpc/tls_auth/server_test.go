@@ -0,0 +1,114 @@
+package main
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"testing"
+	"time"
+)
+
+const testToken = "test-token"
+
+// startServer runs an authServer on a random port and returns its address
+// and a client TLS config that trusts it.
+func startServer(t *testing.T, handshakeTimeout time.Duration) (string, *tls.Config) {
+	t.Helper()
+	cert, pool, err := selfSignedCert()
+	if err != nil {
+		t.Fatalf("selfSignedCert: %v", err)
+	}
+	srv, err := newAuthServer(testToken, &tls.Config{Certificates: []tls.Certificate{cert}})
+	if err != nil {
+		t.Fatalf("newAuthServer: %v", err)
+	}
+	if handshakeTimeout > 0 {
+		srv.handshakeTimeout = handshakeTimeout
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go srv.Serve(l)
+	return l.Addr().String(), &tls.Config{RootCAs: pool, ServerName: "localhost"}
+}
+
+func TestAuthorizedClient(t *testing.T) {
+	addr, clientTLS := startServer(t, 0)
+	client, err := dialAuth(addr, "tester", testToken, clientTLS)
+	if err != nil {
+		t.Fatalf("dialAuth: %v", err)
+	}
+	defer client.Close()
+
+	var sum int
+	if err := client.Call("ArithService.Add", &Args{2, 3}, &sum); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	if sum != 5 {
+		t.Fatalf("Add(2, 3) = %d; want 5", sum)
+	}
+}
+
+func TestWrongTokenRejected(t *testing.T) {
+	addr, clientTLS := startServer(t, 0)
+	_, err := dialAuth(addr, "tester", "wrong", clientTLS)
+	if !errors.Is(err, errUnauthorized) {
+		t.Fatalf("dialAuth with wrong token: err = %v; want errUnauthorized", err)
+	}
+}
+
+func TestMalformedAuthLineRejected(t *testing.T) {
+	addr, clientTLS := startServer(t, 0)
+	conn, err := tls.Dial("tcp", addr, clientTLS)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+	io.WriteString(conn, "HELLO\n")
+	line, err := readLine(conn, maxAuthLine)
+	if err != nil || line != replyRejected {
+		t.Fatalf("reply = %q, %v; want %q", line, err, replyRejected)
+	}
+}
+
+func TestPlaintextClientRejected(t *testing.T) {
+	addr, _ := startServer(t, 0)
+	client, err := rpc.Dial("tcp", addr)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer client.Close()
+	var sum int
+	if err := client.Call("ArithService.Add", &Args{1, 1}, &sum); err == nil {
+		t.Fatalf("plaintext call succeeded; want it to fail")
+	}
+}
+
+func TestUntrustedCertificateRejected(t *testing.T) {
+	addr, _ := startServer(t, 0)
+	_, err := dialAuth(addr, "tester", testToken, &tls.Config{ServerName: "localhost"})
+	var unknownAuthority x509.UnknownAuthorityError
+	if !errors.As(err, &unknownAuthority) {
+		t.Fatalf("err = %v; want x509.UnknownAuthorityError", err)
+	}
+}
+
+func TestSilentClientTimesOut(t *testing.T) {
+	addr, clientTLS := startServer(t, 100*time.Millisecond)
+	conn, err := tls.Dial("tcp", addr, clientTLS)
+	if err != nil {
+		t.Fatalf("dial: %v", err)
+	}
+	defer conn.Close()
+
+	// Never send the AUTH line: the server must hang up on its own.
+	conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+	if _, err := conn.Read(make([]byte, 1)); err != io.EOF {
+		t.Fatalf("read = %v; want io.EOF from the server closing", err)
+	}
+}
@@ -0,0 +1,107 @@
+// Demonstrates the transports net/rpc can run on, side by side.
+//
+// This example shows:
+// - Raw TCP with rpc.Accept and rpc.Dial
+// - RPC over HTTP with rpc.HandleHTTP and rpc.DialHTTP
+// - The /debug/rpc status page registered by rpc.HandleHTTP
+// - Unix domain sockets, including stale socket-file cleanup
+// - Removing the socket file on shutdown (Ctrl+C included)
+package main
+
+import (
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"net/rpc"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+)
+
+func main() {
+	tcpL, err := net.Listen("tcp", "localhost:1236")
+	if err != nil {
+		log.Fatal("Listen error:", err)
+	}
+	defer tcpL.Close()
+	go serveRaw(tcpL)
+
+	httpL, err := net.Listen("tcp", "localhost:1237")
+	if err != nil {
+		log.Fatal("Listen error:", err)
+	}
+	defer httpL.Close()
+	go serveHTTP(httpL)
+
+	sockPath := filepath.Join(os.TempDir(), "net-rpc-example.sock")
+	unixL, err := listenUnix(sockPath)
+	if err != nil {
+		log.Fatal("Listen error:", err)
+	}
+	go serveRaw(unixL)
+
+	// Deferred calls do not run when the process is killed by a signal, so
+	// close the Unix listener (and unlink its file) on Ctrl+C ourselves.
+	sigs := make(chan os.Signal, 1)
+	signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-sigs
+		unixL.Close()
+		os.Exit(1)
+	}()
+
+	log.Printf("RPC over tcp=%s http=%s unix=%s", tcpL.Addr(), httpL.Addr(), sockPath)
+
+	transports := []struct {
+		name string
+		dial func() (*rpc.Client, error)
+	}{
+		{"tcp", func() (*rpc.Client, error) { return rpc.Dial("tcp", tcpL.Addr().String()) }},
+		{"http", func() (*rpc.Client, error) { return rpc.DialHTTP("tcp", httpL.Addr().String()) }},
+		{"unix", func() (*rpc.Client, error) { return rpc.Dial("unix", sockPath) }},
+	}
+
+	fmt.Println("\n=== Same service, three transports ===")
+	const calls = 2000
+	for _, tr := range transports {
+		client, err := tr.dial()
+		if err != nil {
+			log.Fatalf("%s: %v", tr.name, err)
+		}
+		var sum int
+		start := time.Now()
+		for i := 0; i < calls; i++ {
+			if err := client.Call("ArithService.Add", &Args{i, 1}, &sum); err != nil {
+				log.Fatalf("%s: %v", tr.name, err)
+			}
+		}
+		elapsed := time.Since(start)
+		client.Close()
+		fmt.Printf("%-5s last Add = %d, %d calls in %v (%v/call)\n",
+			tr.name, sum, calls, elapsed.Round(time.Millisecond), (elapsed / calls).Round(time.Microsecond))
+	}
+
+	fmt.Println("\n=== /debug/rpc (registered by rpc.HandleHTTP) ===")
+	resp, err := http.Get("http://" + httpL.Addr().String() + rpc.DefaultDebugPath)
+	if err != nil {
+		log.Fatal(err)
+	}
+	page, _ := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	// The page is an HTML table of services, methods and call counts.
+	text := regexp.MustCompile(`<[^>]*>`).ReplaceAllString(string(page), " ")
+	fmt.Println(strings.Join(strings.Fields(text), " "))
+
+	fmt.Println("\n=== Socket file cleanup ===")
+	_, err = os.Stat(sockPath)
+	fmt.Printf("before Close: exists=%v\n", err == nil)
+	unixL.Close()
+	_, err = os.Stat(sockPath)
+	fmt.Printf("after Close:  exists=%v\n", err == nil)
+}
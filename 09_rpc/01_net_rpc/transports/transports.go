@@ -0,0 +1,106 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"net"
+	"net/http"
+	"net/rpc"
+	"os"
+	"sync"
+	"time"
+)
+
+// Args represents the arguments for RPC calls
+type Args struct {
+	A, B int
+}
+
+// ArithService provides arithmetic operations
+type ArithService struct{}
+
+// Add performs addition
+func (a *ArithService) Add(args *Args, reply *int) error {
+	*reply = args.A + args.B
+	return nil
+}
+
+var registerOnce sync.Once
+
+// register puts ArithService on rpc.DefaultServer and mounts it on
+// http.DefaultServeMux at rpc.DefaultRPCPath ("/_goRPC_") together with the
+// debug page at rpc.DefaultDebugPath ("/debug/rpc"). Both calls panic when
+// repeated, hence the sync.Once.
+func register() {
+	registerOnce.Do(func() {
+		if err := rpc.Register(new(ArithService)); err != nil {
+			panic(err)
+		}
+		rpc.HandleHTTP()
+	})
+}
+
+// serveRaw serves the RPC protocol directly on a stream listener (TCP or
+// Unix). It is rpc.Accept without the log line when the listener is closed.
+func serveRaw(l net.Listener) {
+	register()
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				log.Printf("Accept error: %v", err)
+			}
+			return
+		}
+		go rpc.ServeConn(conn)
+	}
+}
+
+// serveHTTP serves RPC over HTTP: the client sends "CONNECT /_goRPC_", the
+// server answers "200 Connected to Go RPC" and the connection then carries
+// the normal RPC stream. Everything else on the mux keeps working.
+func serveHTTP(l net.Listener) error {
+	register()
+	srv := &http.Server{ReadHeaderTimeout: 5 * time.Second} // nil Handler: DefaultServeMux
+	return srv.Serve(l)
+}
+
+// listenUnix listens on a Unix domain socket at path. A socket file left by
+// a crashed process makes net.Listen fail with "address already in use",
+// so a stale socket is removed first. It refuses to remove anything that is
+// not a socket, or a socket that another process is still serving on.
+func listenUnix(path string) (net.Listener, error) {
+	fi, err := os.Lstat(path)
+	switch {
+	case errors.Is(err, fs.ErrNotExist):
+		// nothing to clean up
+	case err != nil:
+		return nil, err
+	case fi.Mode()&fs.ModeSocket == 0:
+		return nil, fmt.Errorf("%s exists and is not a socket", path)
+	default:
+		if conn, err := net.DialTimeout("unix", path, time.Second); err == nil {
+			conn.Close()
+			return nil, fmt.Errorf("%s: another server is listening", path)
+		}
+		if err := os.Remove(path); err != nil {
+			return nil, fmt.Errorf("removing stale socket: %w", err)
+		}
+	}
+
+	l, err := net.Listen("unix", path)
+	if err != nil {
+		return nil, err
+	}
+	// Owner-only access: file permissions are the access control for a
+	// Unix socket.
+	if err := os.Chmod(path, 0o600); err != nil {
+		l.Close()
+		return nil, err
+	}
+	// A *net.UnixListener created by net.Listen unlinks the file on Close,
+	// so a normal shutdown leaves nothing behind.
+	return l, nil
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"net"
+	"net/rpc"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func listenTCP(t *testing.T) net.Listener {
+	t.Helper()
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	t.Cleanup(func() { l.Close() })
+	return l
+}
+
+func TestTransports(t *testing.T) {
+	tcpL := listenTCP(t)
+	go serveRaw(tcpL)
+	httpL := listenTCP(t)
+	go serveHTTP(httpL)
+	sock := filepath.Join(t.TempDir(), "rpc.sock")
+	unixL, err := listenUnix(sock)
+	if err != nil {
+		t.Fatalf("listenUnix: %v", err)
+	}
+	t.Cleanup(func() { unixL.Close() })
+	go serveRaw(unixL)
+
+	tests := []struct {
+		name string
+		dial func() (*rpc.Client, error)
+	}{
+		{"tcp", func() (*rpc.Client, error) { return rpc.Dial("tcp", tcpL.Addr().String()) }},
+		{"http", func() (*rpc.Client, error) { return rpc.DialHTTP("tcp", httpL.Addr().String()) }},
+		{"unix", func() (*rpc.Client, error) { return rpc.Dial("unix", sock) }},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client, err := tc.dial()
+			if err != nil {
+				t.Fatalf("dial: %v", err)
+			}
+			defer client.Close()
+			var sum int
+			if err := client.Call("ArithService.Add", &Args{2, 3}, &sum); err != nil {
+				t.Fatalf("Add: %v", err)
+			}
+			if sum != 5 {
+				t.Fatalf("Add(2, 3) = %d; want 5", sum)
+			}
+		})
+	}
+}
+
+func TestListenUnix_CloseRemovesSocketFile(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "rpc.sock")
+	l, err := listenUnix(sock)
+	if err != nil {
+		t.Fatalf("listenUnix: %v", err)
+	}
+	fi, err := os.Stat(sock)
+	if err != nil {
+		t.Fatalf("socket file missing while listening: %v", err)
+	}
+	if perm := fi.Mode().Perm(); perm != 0o600 {
+		t.Fatalf("socket permissions = %v; want 0600", perm)
+	}
+	l.Close()
+	if _, err := os.Stat(sock); !os.IsNotExist(err) {
+		t.Fatalf("socket file still exists after Close (err=%v)", err)
+	}
+}
+
+func TestListenUnix_RemovesStaleSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "rpc.sock")
+	// Simulate a crashed server: the file stays behind with nobody listening.
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Fatalf("listen: %v", err)
+	}
+	l.(*net.UnixListener).SetUnlinkOnClose(false)
+	l.Close()
+
+	l2, err := listenUnix(sock)
+	if err != nil {
+		t.Fatalf("listenUnix over a stale socket: %v", err)
+	}
+	l2.Close()
+}
+
+func TestListenUnix_RefusesLiveSocket(t *testing.T) {
+	sock := filepath.Join(t.TempDir(), "rpc.sock")
+	l, err := listenUnix(sock)
+	if err != nil {
+		t.Fatalf("listenUnix: %v", err)
+	}
+	defer l.Close()
+	go serveRaw(l)
+
+	if _, err := listenUnix(sock); err == nil || !strings.Contains(err.Error(), "another server") {
+		t.Fatalf("second listenUnix err = %v; want another server is listening", err)
+	}
+	// The first server must still be reachable.
+	client, err := rpc.Dial("unix", sock)
+	if err != nil {
+		t.Fatalf("first server unreachable after refused takeover: %v", err)
+	}
+	client.Close()
+}
+
+func TestListenUnix_RefusesRegularFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "not-a-socket")
+	if err := os.WriteFile(path, []byte("data"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := listenUnix(path); err == nil {
+		t.Fatalf("listenUnix removed a regular file")
+	}
+	if _, err := os.Stat(path); err != nil {
+		t.Fatalf("regular file was deleted: %v", err)
+	}
+}
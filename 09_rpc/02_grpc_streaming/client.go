@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/keepalive"
+
+	pb "golang_roadmap/09_rpc/02_grpc_streaming/streampb"
+)
+
+// dialOptions configures keepalive on the client side. Time must not be
+// shorter than the server's EnforcementPolicy.MinTime.
+func dialOptions() []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithKeepaliveParams(keepalive.ClientParameters{
+			Time:                20 * time.Second, // ping the server after this much inactivity
+			Timeout:             5 * time.Second,  // consider the connection dead after this
+			PermitWithoutStream: true,             // ping even with no active RPCs
+		}),
+	}
+}
+
+// echo calls Echo with a per-RPC deadline.
+func echo(ctx context.Context, c pb.StreamServiceClient, msg string, delay, timeout time.Duration) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	resp, err := c.Echo(ctx, &pb.EchoRequest{Message: msg, DelayMs: delay.Milliseconds()})
+	if err != nil {
+		return "", err
+	}
+	return resp.GetMessage(), nil
+}
+
+// upload streams r to the server in chunkSize pieces and returns the summary.
+func upload(ctx context.Context, c pb.StreamServiceClient, filename string, r io.Reader, chunkSize int) (*pb.UploadSummary, error) {
+	stream, err := c.Upload(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	buf := make([]byte, chunkSize)
+	first := true
+	for {
+		n, err := r.Read(buf)
+		if n > 0 {
+			chunk := &pb.UploadChunk{Data: buf[:n]}
+			if first {
+				chunk.Filename = filename
+				first = false
+			}
+			if err := stream.Send(chunk); err != nil {
+				// The real reason (e.g. a status from the server) comes from CloseAndRecv.
+				if errors.Is(err, io.EOF) {
+					_, err = stream.CloseAndRecv()
+				}
+				return nil, err
+			}
+		}
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return stream.CloseAndRecv()
+}
+
+// chat sends every message on a bidirectional stream and collects the replies.
+// Sending runs in its own goroutine so a server that replies late (or not
+// in lockstep) can't deadlock the client.
+func chat(ctx context.Context, c pb.StreamServiceClient, from string, texts []string) ([]string, error) {
+	stream, err := c.Chat(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	sendErr := make(chan error, 1)
+	go func() {
+		for _, t := range texts {
+			if err := stream.Send(&pb.ChatMessage{From: from, Text: t}); err != nil {
+				sendErr <- err
+				return
+			}
+		}
+		sendErr <- stream.CloseSend()
+	}()
+
+	var replies []string
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			break // server finished the stream
+		}
+		if err != nil {
+			return replies, err
+		}
+		replies = append(replies, msg.GetText())
+	}
+	return replies, <-sendErr
+}
@@ -0,0 +1,76 @@
+// Demonstrates gRPC streaming RPCs, per-RPC deadlines, and keepalive.
+//
+// This example shows:
+// - Client-streaming (Upload): many chunks in, one summary out
+// - Bidirectional streaming (Chat): independent send/receive on one stream
+// - Per-RPC deadlines with context.WithTimeout and codes.DeadlineExceeded
+// - Server-side cancellation: the handler stops when the client gives up
+// - Keepalive settings on both server and client
+package main
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+
+	pb "golang_roadmap/09_rpc/02_grpc_streaming/streampb"
+)
+
+func main() {
+	lis, err := net.Listen("tcp", "localhost:50051")
+	if err != nil {
+		log.Fatal("Listen error:", err)
+	}
+	srv := newServer()
+	go func() {
+		log.Println("gRPC server starting on", lis.Addr())
+		if err := srv.Serve(lis); err != nil {
+			log.Printf("Serve error: %v", err)
+		}
+	}()
+	defer srv.GracefulStop()
+
+	conn, err := grpc.NewClient(lis.Addr().String(),
+		append(dialOptions(), grpc.WithTransportCredentials(insecure.NewCredentials()))...)
+	if err != nil {
+		log.Fatal("Dial error:", err)
+	}
+	defer conn.Close()
+	client := pb.NewStreamServiceClient(conn)
+	ctx := context.Background()
+
+	fmt.Println("\n=== Unary call with deadlines ===")
+	msg, err := echo(ctx, client, "fast", 10*time.Millisecond, time.Second)
+	fmt.Printf("Echo(fast) = %q, err = %v\n", msg, err)
+
+	_, err = echo(ctx, client, "slow", 2*time.Second, 200*time.Millisecond)
+	fmt.Printf("Echo(slow) code = %s, err = %v\n", status.Code(err), err)
+
+	fmt.Println("\n=== Client-streaming upload ===")
+	data := bytes.Repeat([]byte("gopher "), 10_000)
+	summary, err := upload(ctx, client, "gophers.txt", bytes.NewReader(data), 16*1024)
+	if err != nil {
+		log.Fatal("Upload error:", err)
+	}
+	fmt.Printf("uploaded %s: %d bytes in %d chunks, sha256=%s...\n",
+		summary.GetFilename(), summary.GetSize(), summary.GetChunks(), summary.GetSha256()[:16])
+
+	fmt.Println("\n=== Bidirectional chat ===")
+	replies, err := chat(ctx, client, "alice", []string{"hi", "how are streams?", "bye"})
+	if err != nil {
+		log.Fatal("Chat error:", err)
+	}
+	for _, r := range replies {
+		fmt.Println("server:", r)
+	}
+
+	// Give the server log a moment to print the abandoned Echo.
+	time.Sleep(100 * time.Millisecond)
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/keepalive"
+	"google.golang.org/grpc/status"
+
+	pb "golang_roadmap/09_rpc/02_grpc_streaming/streampb"
+)
+
+// streamServer implements pb.StreamServiceServer.
+type streamServer struct {
+	pb.UnimplementedStreamServiceServer
+}
+
+// Echo waits delay_ms before answering, unless the client gives up first.
+// The client's deadline arrives here as ctx; checking it stops wasted work.
+func (s *streamServer) Echo(ctx context.Context, req *pb.EchoRequest) (*pb.EchoResponse, error) {
+	if dl, ok := ctx.Deadline(); ok {
+		log.Printf("Echo: %q, client deadline in %v", req.GetMessage(), time.Until(dl).Round(time.Millisecond))
+	}
+	select {
+	case <-time.After(time.Duration(req.GetDelayMs()) * time.Millisecond):
+		return &pb.EchoResponse{Message: req.GetMessage()}, nil
+	case <-ctx.Done():
+		log.Printf("Echo: abandoned: %v", ctx.Err())
+		return nil, status.FromContextError(ctx.Err()).Err()
+	}
+}
+
+// Upload reads chunks until the client closes its side, then replies once.
+func (s *streamServer) Upload(stream grpc.ClientStreamingServer[pb.UploadChunk, pb.UploadSummary]) error {
+	h := sha256.New()
+	var summary pb.UploadSummary
+	for {
+		chunk, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			// Client called CloseAndRecv: all chunks are in.
+			summary.Sha256 = hex.EncodeToString(h.Sum(nil))
+			log.Printf("Upload: %s, %d bytes in %d chunks", summary.Filename, summary.Size, summary.Chunks)
+			return stream.SendAndClose(&summary)
+		}
+		if err != nil {
+			return err
+		}
+		if summary.Chunks == 0 {
+			if chunk.GetFilename() == "" {
+				return status.Error(codes.InvalidArgument, "first chunk must carry a filename")
+			}
+			summary.Filename = chunk.GetFilename()
+		}
+		h.Write(chunk.GetData())
+		summary.Size += int64(len(chunk.GetData()))
+		summary.Chunks++
+	}
+}
+
+// Chat answers every message on the same stream. Receiving and sending
+// are independent; here the server simply replies as messages arrive.
+func (s *streamServer) Chat(stream grpc.BidiStreamingServer[pb.ChatMessage, pb.ChatMessage]) error {
+	for {
+		msg, err := stream.Recv()
+		if errors.Is(err, io.EOF) {
+			return nil // client called CloseSend
+		}
+		if err != nil {
+			return err
+		}
+		reply := &pb.ChatMessage{
+			From: "server",
+			Text: fmt.Sprintf("%s said %q (%d chars)", msg.GetFrom(), msg.GetText(), len(msg.GetText())),
+		}
+		if strings.EqualFold(msg.GetText(), "bye") {
+			reply.Text = "bye, " + msg.GetFrom()
+		}
+		if err := stream.Send(reply); err != nil {
+			return err
+		}
+	}
+}
+
+// serverOptions configures keepalive on the server side.
+//
+// ServerParameters: ping idle clients so dead connections are noticed.
+// EnforcementPolicy: reject clients that ping more often than MinTime
+// (they get GOAWAY "too_many_pings"); it must be looser than the client's Time.
+func serverOptions() []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			MaxConnectionIdle: 5 * time.Minute,  // close connections with no RPCs for this long
+			Time:              30 * time.Second, // ping the client after this much inactivity
+			Timeout:           10 * time.Second, // wait this long for the ping ack
+		}),
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             10 * time.Second,
+			PermitWithoutStream: true,
+		}),
+	}
+}
+
+// newServer builds a gRPC server with the service registered.
+func newServer() *grpc.Server {
+	s := grpc.NewServer(serverOptions()...)
+	pb.RegisterStreamServiceServer(s, &streamServer{})
+	return s
+}
@@ -0,0 +1,159 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"net"
+	"testing"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	pb "golang_roadmap/09_rpc/02_grpc_streaming/streampb"
+)
+
+// newBufconnClient starts the server on an in-memory listener, so tests
+// exercise the full gRPC stack (HTTP/2 framing, codecs, deadlines) without
+// opening a port.
+func newBufconnClient(t *testing.T) pb.StreamServiceClient {
+	t.Helper()
+	lis := bufconn.Listen(1 << 20)
+	srv := newServer()
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	if err != nil {
+		t.Fatalf("NewClient: %v", err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return pb.NewStreamServiceClient(conn)
+}
+
+func TestEcho_WithinDeadline(t *testing.T) {
+	c := newBufconnClient(t)
+	got, err := echo(context.Background(), c, "hello", 0, time.Second)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if got != "hello" {
+		t.Fatalf("echo = %q; want hello", got)
+	}
+}
+
+func TestEcho_DeadlineExceeded(t *testing.T) {
+	c := newBufconnClient(t)
+
+	start := time.Now()
+	_, err := echo(context.Background(), c, "slow", 5*time.Second, 50*time.Millisecond)
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("code = %v; want DeadlineExceeded (err=%v)", status.Code(err), err)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("call returned after %v; deadline was not enforced", elapsed)
+	}
+}
+
+func TestEcho_ServerSeesCancellation(t *testing.T) {
+	s := &streamServer{}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	_, err := s.Echo(ctx, &pb.EchoRequest{Message: "x", DelayMs: 5000})
+	if status.Code(err) != codes.Canceled {
+		t.Fatalf("code = %v; want Canceled", status.Code(err))
+	}
+}
+
+func TestUpload(t *testing.T) {
+	c := newBufconnClient(t)
+
+	data := bytes.Repeat([]byte("0123456789"), 1000) // 10,000 bytes
+	sum := sha256.Sum256(data)
+
+	tests := []struct {
+		name       string
+		chunkSize  int
+		wantChunks int32
+	}{
+		{"single chunk", 64 * 1024, 1},
+		{"even split", 1000, 10},
+		{"uneven split", 3000, 4},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, err := upload(context.Background(), c, "data.bin", bytes.NewReader(data), tc.chunkSize)
+			if err != nil {
+				t.Fatalf("upload: %v", err)
+			}
+			if got.GetFilename() != "data.bin" || got.GetSize() != int64(len(data)) || got.GetChunks() != tc.wantChunks {
+				t.Fatalf("summary = %+v; want data.bin, %d bytes, %d chunks", got, len(data), tc.wantChunks)
+			}
+			if got.GetSha256() != hex.EncodeToString(sum[:]) {
+				t.Fatalf("sha256 mismatch")
+			}
+		})
+	}
+}
+
+func TestUpload_MissingFilename(t *testing.T) {
+	c := newBufconnClient(t)
+	_, err := upload(context.Background(), c, "", bytes.NewReader([]byte("data")), 2)
+	if status.Code(err) != codes.InvalidArgument {
+		t.Fatalf("code = %v; want InvalidArgument (err=%v)", status.Code(err), err)
+	}
+}
+
+func TestChat(t *testing.T) {
+	c := newBufconnClient(t)
+	replies, err := chat(context.Background(), c, "bob", []string{"one", "two", "bye"})
+	if err != nil {
+		t.Fatalf("chat: %v", err)
+	}
+	want := []string{
+		`bob said "one" (3 chars)`,
+		`bob said "two" (3 chars)`,
+		"bye, bob",
+	}
+	if len(replies) != len(want) {
+		t.Fatalf("replies = %q; want %q", replies, want)
+	}
+	for i := range want {
+		if replies[i] != want[i] {
+			t.Fatalf("reply %d = %q; want %q", i, replies[i], want[i])
+		}
+	}
+}
+
+func TestChat_StreamDeadline(t *testing.T) {
+	c := newBufconnClient(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	// A stream deadline covers the whole stream, not each message.
+	stream, err := c.Chat(ctx)
+	if err != nil {
+		t.Fatalf("Chat: %v", err)
+	}
+	if err := stream.Send(&pb.ChatMessage{From: "carol", Text: "hi"}); err != nil {
+		t.Fatalf("Send: %v", err)
+	}
+	if _, err := stream.Recv(); err != nil {
+		t.Fatalf("first Recv: %v", err)
+	}
+	// Nothing more is sent, so the next Recv blocks until the deadline.
+	_, err = stream.Recv()
+	if status.Code(err) != codes.DeadlineExceeded {
+		t.Fatalf("code = %v; want DeadlineExceeded", status.Code(err))
+	}
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"log"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+// Metadata keys. gRPC metadata keys are lower-case HTTP/2 headers.
+const (
+	requestIDKey     = "x-request-id"
+	authorizationKey = "authorization"
+)
+
+// ctxKey is unexported so no other package can collide with our context values.
+type ctxKey struct{}
+
+// withRequestID stores the request ID in ctx.
+func withRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// requestIDFrom returns the request ID stored in ctx, if any.
+func requestIDFrom(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// wrappedStream lets stream interceptors replace the stream's context,
+// the streaming equivalent of r.WithContext(ctx) in HTTP middleware.
+type wrappedStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (w *wrappedStream) Context() context.Context { return w.ctx }
+
+// ---- Server interceptors ----
+
+// recoveryUnary turns a panic in the handler into codes.Internal instead of
+// crashing the whole server. It should be the outermost interceptor.
+func recoveryUnary(logger *log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				logger.Printf("panic in %s: %v\n%s", info.FullMethod, p, debug.Stack())
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+// recoveryStream is recoveryUnary for streaming RPCs.
+func recoveryStream(logger *log.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				logger.Printf("panic in %s: %v\n%s", info.FullMethod, p, debug.Stack())
+				err = status.Error(codes.Internal, "internal error")
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+// serverRequestID reads x-request-id from the incoming metadata (or makes
+// one up), puts it in the context, and echoes it in the response header.
+func serverRequestID(ctx context.Context) context.Context {
+	id := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if v := md.Get(requestIDKey); len(v) > 0 {
+			id = v[0]
+		}
+	}
+	if id == "" {
+		id = newRequestID()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDKey, id))
+	return withRequestID(ctx, id)
+}
+
+func requestIDUnary() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		return handler(serverRequestID(ctx), req)
+	}
+}
+
+func requestIDStream() grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		return handler(srv, &wrappedStream{ServerStream: ss, ctx: serverRequestID(ss.Context())})
+	}
+}
+
+// loggingUnary logs method, status code and duration, like loggingMiddleware
+// in 08_web_development.
+func loggingUnary(logger *log.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logger.Printf("unary %s id=%s code=%s in %v", info.FullMethod, requestIDFrom(ctx), status.Code(err), time.Since(start))
+		return resp, err
+	}
+}
+
+func loggingStream(logger *log.Logger) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logger.Printf("stream %s id=%s code=%s in %v", info.FullMethod, requestIDFrom(ss.Context()), status.Code(err), time.Since(start))
+		return err
+	}
+}
+
+// checkToken validates "authorization: Bearer <token>" in the incoming metadata.
+func checkToken(ctx context.Context, want string) error {
+	md, ok := metadata.FromIncomingContext(ctx)
+	if !ok {
+		return status.Error(codes.Unauthenticated, "missing metadata")
+	}
+	v := md.Get(authorizationKey)
+	if len(v) == 0 {
+		return status.Error(codes.Unauthenticated, "missing authorization token")
+	}
+	got := strings.TrimPrefix(v[0], "Bearer ")
+	// Constant-time comparison so response timing doesn't leak the token.
+	if subtle.ConstantTimeCompare([]byte(got), []byte(want)) != 1 {
+		return status.Error(codes.Unauthenticated, "invalid authorization token")
+	}
+	return nil
+}
+
+func authUnary(token string) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		if err := checkToken(ctx, token); err != nil {
+			return nil, err
+		}
+		return handler(ctx, req)
+	}
+}
+
+func authStream(token string) grpc.StreamServerInterceptor {
+	return func(srv any, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		if err := checkToken(ss.Context(), token); err != nil {
+			return err
+		}
+		return handler(srv, ss)
+	}
+}
+
+// serverInterceptors returns the chain in execution order, outermost first:
+// recovery wraps everything, the request ID is set before logging reads it,
+// and auth runs last so rejected calls are still logged with their ID.
+func serverInterceptors(token string, logger *log.Logger) []grpc.ServerOption {
+	return []grpc.ServerOption{
+		grpc.ChainUnaryInterceptor(
+			recoveryUnary(logger),
+			requestIDUnary(),
+			loggingUnary(logger),
+			authUnary(token),
+		),
+		grpc.ChainStreamInterceptor(
+			recoveryStream(logger),
+			requestIDStream(),
+			loggingStream(logger),
+			authStream(token),
+		),
+	}
+}
+
+// ---- Client interceptors ----
+
+// outgoing adds the bearer token and the request ID (from ctx, or a new one)
+// to the outgoing metadata.
+func outgoing(ctx context.Context, token string) context.Context {
+	id := requestIDFrom(ctx)
+	if id == "" {
+		id = newRequestID()
+	}
+	return metadata.AppendToOutgoingContext(ctx,
+		authorizationKey, "Bearer "+token,
+		requestIDKey, id,
+	)
+}
+
+func clientUnary(token string, logger *log.Logger) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply any, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		start := time.Now()
+		err := invoker(outgoing(ctx, token), method, req, reply, cc, opts...)
+		logger.Printf("client unary %s code=%s in %v", method, status.Code(err), time.Since(start))
+		return err
+	}
+}
+
+func clientStream(token string, logger *log.Logger) grpc.StreamClientInterceptor {
+	return func(ctx context.Context, desc *grpc.StreamDesc, cc *grpc.ClientConn, method string, streamer grpc.Streamer, opts ...grpc.CallOption) (grpc.ClientStream, error) {
+		logger.Printf("client stream %s opened", method)
+		return streamer(outgoing(ctx, token), desc, cc, method, opts...)
+	}
+}
+
+// clientInterceptors returns the dial options installing the client chain.
+func clientInterceptors(token string, logger *log.Logger) []grpc.DialOption {
+	return []grpc.DialOption{
+		grpc.WithChainUnaryInterceptor(clientUnary(token, logger)),
+		grpc.WithChainStreamInterceptor(clientStream(token, logger)),
+	}
+}
@@ -0,0 +1,48 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+
+	"google.golang.org/grpc"
+
+	pb "golang_roadmap/09_rpc/03_grpc_interceptors/greeterpb"
+)
+
+// greeter implements pb.GreeterServer. It contains no logging, auth or
+// recovery code: all of that lives in the interceptors.
+type greeter struct {
+	pb.UnimplementedGreeterServer
+}
+
+func (g *greeter) SayHello(ctx context.Context, req *pb.HelloRequest) (*pb.HelloReply, error) {
+	if req.GetName() == "panic" {
+		panic("handler bug triggered by name=panic")
+	}
+	return &pb.HelloReply{
+		Message:   "Hello, " + req.GetName(),
+		RequestId: requestIDFrom(ctx),
+	}, nil
+}
+
+func (g *greeter) StreamGreetings(req *pb.StreamRequest, stream grpc.ServerStreamingServer[pb.HelloReply]) error {
+	id := requestIDFrom(stream.Context())
+	for i := 1; i <= int(req.GetCount()); i++ {
+		if req.GetName() == "panic" && i == 2 {
+			panic("stream handler bug")
+		}
+		msg := &pb.HelloReply{Message: fmt.Sprintf("Hello #%d, %s", i, req.GetName()), RequestId: id}
+		if err := stream.Send(msg); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newServer builds a gRPC server with the interceptor chain installed.
+func newServer(token string, logger *log.Logger) *grpc.Server {
+	s := grpc.NewServer(serverInterceptors(token, logger)...)
+	pb.RegisterGreeterServer(s, &greeter{})
+	return s
+}
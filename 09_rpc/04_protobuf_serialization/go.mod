@@ -0,0 +1,5 @@
+module golang_roadmap/09_rpc/04_protobuf_serialization
+
+go 1.24.11
+
+require google.golang.org/protobuf v1.36.6
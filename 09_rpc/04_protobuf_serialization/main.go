@@ -0,0 +1,88 @@
+// Demonstrates Protocol Buffers as a serialization format, without gRPC.
+//
+// This example shows:
+// - Marshaling/unmarshaling generated types with proto.Marshal
+// - Payload size compared with JSON (protojson)
+// - Unknown-field tolerance: an old reader passes new fields through
+// - Schema evolution: added fields, reserved numbers, deprecated fields
+package main
+
+import (
+	"fmt"
+	"log"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	userv1 "golang_roadmap/09_rpc/04_protobuf_serialization/gen/user/v1"
+	userv2 "golang_roadmap/09_rpc/04_protobuf_serialization/gen/user/v2"
+)
+
+func main() {
+	fmt.Println("=== Round trip ===")
+	u := newUserV2(42)
+	b, err := proto.Marshal(u)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var decoded userv2.User
+	if err := proto.Unmarshal(b, &decoded); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("%d bytes, equal after round trip: %v\n", len(b), proto.Equal(u, &decoded))
+	fmt.Printf("wire bytes: % x...\n", b[:16])
+
+	fmt.Println("\n=== Size vs JSON ===")
+	var binTotal, jsonTotal int
+	for id := int64(1); id <= 1000; id++ {
+		bin, js, err := encodedSizes(newUserV2(id))
+		if err != nil {
+			log.Fatal(err)
+		}
+		binTotal += bin
+		jsonTotal += js
+	}
+	fmt.Printf("1000 users: protobuf %d bytes, JSON %d bytes (%.0f%% of JSON)\n",
+		binTotal, jsonTotal, 100*float64(binTotal)/float64(jsonTotal))
+
+	fmt.Println("\n=== New writer, old reader (v2 -> v1 -> v2) ===")
+	old, relayed, err := relayThroughV1(b)
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("v1 sees name=%q role=%v (unknown enum value kept as a number)\n", old.GetName(), old.GetRole())
+	fmt.Printf("v1 keeps %d bytes of unknown fields (phone, address)\n", len(unknownBytes(old)))
+	var back userv2.User
+	if err := proto.Unmarshal(relayed, &back); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("after relay through v1: phone=%q city=%q equal=%v\n",
+		back.GetPhone(), back.GetAddress().GetCity(), proto.Equal(u, &back))
+
+	fmt.Println("\n=== Old writer, new reader (v1 -> v2) ===")
+	ob, err := proto.Marshal(newUserV1(7))
+	if err != nil {
+		log.Fatal(err)
+	}
+	var nu userv2.User
+	if err := proto.Unmarshal(ob, &nu); err != nil {
+		log.Fatal(err)
+	}
+	// Reading a deprecated field still works; linters (staticcheck SA1019) flag it.
+	fmt.Printf("name=%q nickname(deprecated)=%q phone=%q (zero value: never sent)\n",
+		nu.GetName(), nu.GetNickname(), nu.GetPhone())
+	fmt.Printf("removed field 4 (tags) kept as %d unknown bytes\n", len(unknownBytes(&nu)))
+
+	fmt.Println("\n=== JSON is stricter about unknown fields ===")
+	ju := newUserV2(42)
+	ju.Role = userv2.User_ROLE_ADMIN // known to v1, so only the new fields are "unknown"
+	js, err := protojson.Marshal(ju)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var v1FromJSON userv1.User
+	err = protojson.Unmarshal(js, &v1FromJSON)
+	fmt.Println("protojson.Unmarshal into v1:", err)
+	err = protojson.UnmarshalOptions{DiscardUnknown: true}.Unmarshal(js, &v1FromJSON)
+	fmt.Printf("with DiscardUnknown: err=%v name=%q\n", err, v1FromJSON.GetName())
+}
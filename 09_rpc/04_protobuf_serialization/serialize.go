@@ -0,0 +1,72 @@
+package main
+
+import (
+	"fmt"
+	"time"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	userv1 "golang_roadmap/09_rpc/04_protobuf_serialization/gen/user/v1"
+	userv2 "golang_roadmap/09_rpc/04_protobuf_serialization/gen/user/v2"
+)
+
+// newUserV2 builds a fully populated v2 user.
+func newUserV2(id int64) *userv2.User {
+	return &userv2.User{
+		Id:        id,
+		Name:      fmt.Sprintf("User %d", id),
+		Email:     fmt.Sprintf("user%d@example.com", id),
+		Role:      userv2.User_ROLE_OWNER,
+		CreatedAt: timestamppb.New(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)),
+		Phone:     "+1-555-0100",
+		Address:   &userv2.User_Address{City: "Singapore", Country: "SG"},
+	}
+}
+
+// newUserV1 builds a v1 user, including the tags field that v2 removed.
+func newUserV1(id int64) *userv1.User {
+	return &userv1.User{
+		Id:        id,
+		Name:      fmt.Sprintf("User %d", id),
+		Email:     fmt.Sprintf("user%d@example.com", id),
+		Tags:      []string{"beta", "newsletter"},
+		Nickname:  "gopher",
+		Role:      userv1.User_ROLE_ADMIN,
+		CreatedAt: timestamppb.New(time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)),
+	}
+}
+
+// encodedSizes returns the binary and protojson sizes of m.
+func encodedSizes(m proto.Message) (binary, json int, err error) {
+	b, err := proto.Marshal(m)
+	if err != nil {
+		return 0, 0, err
+	}
+	j, err := protojson.Marshal(m)
+	if err != nil {
+		return 0, 0, err
+	}
+	return len(b), len(j), nil
+}
+
+// relayThroughV1 simulates an old service that decodes a message with the
+// v1 schema and forwards it by re-encoding. Fields it doesn't know about are
+// kept as unknown fields and written back out, so nothing is lost.
+func relayThroughV1(in []byte) (*userv1.User, []byte, error) {
+	var old userv1.User
+	if err := proto.Unmarshal(in, &old); err != nil {
+		return nil, nil, err
+	}
+	out, err := proto.Marshal(&old)
+	if err != nil {
+		return nil, nil, err
+	}
+	return &old, out, nil
+}
+
+// unknownBytes returns the raw unknown fields retained by m.
+func unknownBytes(m proto.Message) []byte {
+	return m.ProtoReflect().GetUnknown()
+}
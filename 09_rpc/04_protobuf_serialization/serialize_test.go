@@ -0,0 +1,127 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+
+	userv1 "golang_roadmap/09_rpc/04_protobuf_serialization/gen/user/v1"
+	userv2 "golang_roadmap/09_rpc/04_protobuf_serialization/gen/user/v2"
+)
+
+func TestRoundTrip(t *testing.T) {
+	u := newUserV2(1)
+	b, err := proto.Marshal(u)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var got userv2.User
+	if err := proto.Unmarshal(b, &got); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if !proto.Equal(u, &got) {
+		t.Fatalf("round trip mismatch:\n got  %v\n want %v", &got, u)
+	}
+}
+
+func TestBinarySmallerThanJSON(t *testing.T) {
+	bin, js, err := encodedSizes(newUserV2(1))
+	if err != nil {
+		t.Fatalf("encodedSizes: %v", err)
+	}
+	if bin >= js {
+		t.Fatalf("binary %d bytes >= JSON %d bytes", bin, js)
+	}
+}
+
+func TestUnknownFieldsSurviveOldReader(t *testing.T) {
+	u := newUserV2(1)
+	b, err := proto.Marshal(u)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+
+	old, relayed, err := relayThroughV1(b)
+	if err != nil {
+		t.Fatalf("relayThroughV1: %v", err)
+	}
+	if old.GetName() != u.GetName() || old.GetEmail() != u.GetEmail() {
+		t.Fatalf("v1 lost known fields: %v", old)
+	}
+	if len(unknownBytes(old)) == 0 {
+		t.Fatalf("v1 should retain phone/address as unknown fields")
+	}
+	// ROLE_OWNER (3) doesn't exist in v1, but the number is preserved.
+	if old.GetRole() != userv1.User_Role(3) {
+		t.Fatalf("v1 role = %v; want numeric 3", old.GetRole())
+	}
+
+	var back userv2.User
+	if err := proto.Unmarshal(relayed, &back); err != nil {
+		t.Fatalf("Unmarshal relayed: %v", err)
+	}
+	if !proto.Equal(u, &back) {
+		t.Fatalf("data lost relaying through v1:\n got  %v\n want %v", &back, u)
+	}
+}
+
+func TestOldWriterNewReader(t *testing.T) {
+	b, err := proto.Marshal(newUserV1(7))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var u userv2.User
+	if err := proto.Unmarshal(b, &u); err != nil {
+		t.Fatalf("Unmarshal: %v", err)
+	}
+	if u.GetName() != "User 7" || u.GetRole() != userv2.User_ROLE_ADMIN {
+		t.Fatalf("known fields not decoded: %v", &u)
+	}
+	if u.GetNickname() != "gopher" {
+		t.Fatalf("deprecated field must still decode, got %q", u.GetNickname())
+	}
+	if u.GetPhone() != "" || u.GetAddress() != nil {
+		t.Fatalf("new fields should be zero values, got phone=%q address=%v", u.GetPhone(), u.GetAddress())
+	}
+	// Field 4 is reserved in v2: the data is kept as unknown, never misread.
+	if len(unknownBytes(&u)) == 0 {
+		t.Fatalf("removed tags field should be kept as unknown bytes")
+	}
+}
+
+func TestProtoJSONUnknownFields(t *testing.T) {
+	u := newUserV2(1)
+	u.Role = userv2.User_ROLE_ADMIN
+	js, err := protojson.Marshal(u)
+	if err != nil {
+		t.Fatalf("protojson.Marshal: %v", err)
+	}
+	var old userv1.User
+	if err := protojson.Unmarshal(js, &old); err == nil || !strings.Contains(err.Error(), "unknown field") {
+		t.Fatalf("protojson should reject unknown fields by default, got %v", err)
+	}
+	if err := (protojson.UnmarshalOptions{DiscardUnknown: true}).Unmarshal(js, &old); err != nil {
+		t.Fatalf("DiscardUnknown: %v", err)
+	}
+	if old.GetName() != "User 1" {
+		t.Fatalf("name = %q; want User 1", old.GetName())
+	}
+}
+
+func TestDeterministicMarshal(t *testing.T) {
+	u := newUserV2(1)
+	opts := proto.MarshalOptions{Deterministic: true}
+	a, err := opts.Marshal(u)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	b, err := opts.Marshal(proto.Clone(u))
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	if string(a) != string(b) {
+		t.Fatalf("deterministic marshal produced different bytes")
+	}
+}
@@ -0,0 +1,8 @@
+module golang_roadmap/09_rpc/05_connect_go
+
+go 1.24.11
+
+require (
+	connectrpc.com/connect v1.18.1
+	google.golang.org/protobuf v1.36.6
+)
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"connectrpc.com/connect"
+
+	usersv1 "golang_roadmap/09_rpc/05_connect_go/gen/users/v1"
+	"golang_roadmap/09_rpc/05_connect_go/gen/users/v1/usersv1connect"
+)
+
+// userStore is the same in-memory store as 08_web_development/01_net_http,
+// wrapped in a type so the RPC and REST handlers can share it.
+type userStore struct {
+	mu     sync.Mutex
+	users  []*usersv1.User
+	nextID int64
+}
+
+func newUserStore() *userStore {
+	return &userStore{users: []*usersv1.User{{Id: 1, Name: "Bob"}}, nextID: 2}
+}
+
+func (s *userStore) get(id int64) (*usersv1.User, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, u := range s.users {
+		if u.GetId() == id {
+			return u, true
+		}
+	}
+	return nil, false
+}
+
+func (s *userStore) create(name string) *usersv1.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	u := &usersv1.User{Id: s.nextID, Name: name}
+	s.nextID++
+	s.users = append(s.users, u)
+	return u
+}
+
+func (s *userStore) list() []*usersv1.User {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return append([]*usersv1.User(nil), s.users...)
+}
+
+// userServer implements usersv1connect.UserServiceHandler. One implementation
+// serves the Connect, gRPC and gRPC-Web protocols.
+type userServer struct {
+	store *userStore
+}
+
+func (s *userServer) GetUser(ctx context.Context, req *connect.Request[usersv1.GetUserRequest]) (*connect.Response[usersv1.GetUserResponse], error) {
+	u, ok := s.store.get(req.Msg.GetId())
+	if !ok {
+		// Maps to gRPC status NOT_FOUND and, for Connect, HTTP 404.
+		return nil, connect.NewError(connect.CodeNotFound, fmt.Errorf("user %d not found", req.Msg.GetId()))
+	}
+	return connect.NewResponse(&usersv1.GetUserResponse{User: u}), nil
+}
+
+func (s *userServer) CreateUser(ctx context.Context, req *connect.Request[usersv1.CreateUserRequest]) (*connect.Response[usersv1.CreateUserResponse], error) {
+	name := strings.TrimSpace(req.Msg.GetName())
+	if name == "" {
+		return nil, connect.NewError(connect.CodeInvalidArgument, errors.New("name is required"))
+	}
+	u := s.store.create(name)
+	res := connect.NewResponse(&usersv1.CreateUserResponse{User: u})
+	// Headers work the same way regardless of protocol.
+	res.Header().Set("X-Protocol", req.Peer().Protocol)
+	return res, nil
+}
+
+func (s *userServer) ListUsers(ctx context.Context, req *connect.Request[usersv1.ListUsersRequest]) (*connect.Response[usersv1.ListUsersResponse], error) {
+	return connect.NewResponse(&usersv1.ListUsersResponse{Users: s.store.list()}), nil
+}
+
+// restUsersHandler is a plain REST endpoint (GET /users) on the same mux,
+// showing that Connect handlers are ordinary http.Handlers.
+func restUsersHandler(store *userStore) http.HandlerFunc {
+	type user struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	return func(w http.ResponseWriter, r *http.Request) {
+		var out []user
+		for _, u := range store.list() {
+			out = append(out, user{ID: u.GetId(), Name: u.GetName()})
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(out); err != nil {
+			log.Printf("Error encoding users: %v", err)
+		}
+	}
+}
+
+// loggingMiddleware wraps handlers to log requests, as in 08_web_development.
+func loggingMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		next.ServeHTTP(w, r)
+		log.Printf("%s %s %s (%s) in %v", r.Proto, r.Method, r.URL.Path, r.Header.Get("Content-Type"), time.Since(start))
+	})
+}
+
+// newMux mounts the RPC service next to the REST handlers.
+func newMux(store *userStore) *http.ServeMux {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", restUsersHandler(store))
+
+	// path is "/users.v1.UserService/"; every RPC lives under it.
+	path, handler := usersv1connect.NewUserServiceHandler(&userServer{store: store})
+	mux.Handle(path, handler)
+	return mux
+}
+
+// newServer returns an http.Server that also speaks HTTP/2 without TLS (h2c),
+// which gRPC clients need when there is no TLS.
+func newServer(addr string, handler http.Handler) *http.Server {
+	var protocols http.Protocols
+	protocols.SetHTTP1(true)
+	protocols.SetUnencryptedHTTP2(true)
+	return &http.Server{
+		Addr:              addr,
+		Handler:           loggingMiddleware(handler),
+		Protocols:         &protocols,
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+}
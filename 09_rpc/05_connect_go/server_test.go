@@ -0,0 +1,145 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"connectrpc.com/connect"
+
+	usersv1 "golang_roadmap/09_rpc/05_connect_go/gen/users/v1"
+	"golang_roadmap/09_rpc/05_connect_go/gen/users/v1/usersv1connect"
+)
+
+// newTestServer starts the mux with h2c enabled so gRPC clients work too.
+func newTestServer(t *testing.T) *httptest.Server {
+	t.Helper()
+	ts := httptest.NewUnstartedServer(newMux(newUserStore()))
+	ts.Config.Protocols = newServer("", nil).Protocols
+	ts.Start()
+	t.Cleanup(ts.Close)
+	return ts
+}
+
+func TestAllProtocols(t *testing.T) {
+	ts := newTestServer(t)
+
+	tests := []struct {
+		name     string
+		opts     []connect.ClientOption
+		protocol string
+	}{
+		{"connect", nil, connect.ProtocolConnect},
+		{"connect json", []connect.ClientOption{connect.WithProtoJSON()}, connect.ProtocolConnect},
+		{"grpc", []connect.ClientOption{connect.WithGRPC()}, connect.ProtocolGRPC},
+		{"grpc-web", []connect.ClientOption{connect.WithGRPCWeb()}, connect.ProtocolGRPCWeb},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			client := usersv1connect.NewUserServiceClient(h2cClient(), ts.URL, tc.opts...)
+			ctx := context.Background()
+
+			created, err := client.CreateUser(ctx, connect.NewRequest(&usersv1.CreateUserRequest{Name: tc.name}))
+			if err != nil {
+				t.Fatalf("CreateUser: %v", err)
+			}
+			if got := created.Header().Get("X-Protocol"); got != tc.protocol {
+				t.Fatalf("server saw protocol %q; want %q", got, tc.protocol)
+			}
+
+			got, err := client.GetUser(ctx, connect.NewRequest(&usersv1.GetUserRequest{Id: created.Msg.GetUser().GetId()}))
+			if err != nil {
+				t.Fatalf("GetUser: %v", err)
+			}
+			if got.Msg.GetUser().GetName() != tc.name {
+				t.Fatalf("name = %q; want %q", got.Msg.GetUser().GetName(), tc.name)
+			}
+		})
+	}
+}
+
+func TestErrorCodes(t *testing.T) {
+	ts := newTestServer(t)
+	for _, opts := range [][]connect.ClientOption{nil, {connect.WithGRPC()}, {connect.WithGRPCWeb()}} {
+		client := usersv1connect.NewUserServiceClient(h2cClient(), ts.URL, opts...)
+
+		_, err := client.GetUser(context.Background(), connect.NewRequest(&usersv1.GetUserRequest{Id: 999}))
+		if connect.CodeOf(err) != connect.CodeNotFound {
+			t.Fatalf("GetUser(999) code = %v; want not_found", connect.CodeOf(err))
+		}
+		_, err = client.CreateUser(context.Background(), connect.NewRequest(&usersv1.CreateUserRequest{Name: "  "}))
+		if connect.CodeOf(err) != connect.CodeInvalidArgument {
+			t.Fatalf("CreateUser(blank) code = %v; want invalid_argument", connect.CodeOf(err))
+		}
+	}
+}
+
+func TestPlainJSONPost(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, err := http.Post(ts.URL+usersv1connect.UserServiceGetUserProcedure, "application/json", strings.NewReader(`{"id": 1}`))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("status = %d; want 200", resp.StatusCode)
+	}
+	var body struct {
+		User struct {
+			ID   string `json:"id"` // protojson encodes int64 as a string
+			Name string `json:"name"`
+		} `json:"user"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if body.User.ID != "1" || body.User.Name != "Bob" {
+		t.Fatalf("body = %+v; want user 1 Bob", body)
+	}
+}
+
+func TestPlainJSONPost_NotFoundIsHTTP404(t *testing.T) {
+	ts := newTestServer(t)
+
+	resp, err := http.Post(ts.URL+usersv1connect.UserServiceGetUserProcedure, "application/json", strings.NewReader(`{"id": 999}`))
+	if err != nil {
+		t.Fatalf("post: %v", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusNotFound {
+		t.Fatalf("status = %d; want 404", resp.StatusCode)
+	}
+	body, _ := io.ReadAll(resp.Body)
+	if !strings.Contains(string(body), `"code":"not_found"`) {
+		t.Fatalf("body = %s; want a Connect error with code not_found", body)
+	}
+}
+
+func TestRESTHandlerSharesStore(t *testing.T) {
+	ts := newTestServer(t)
+	client := usersv1connect.NewUserServiceClient(h2cClient(), ts.URL)
+	if _, err := client.CreateUser(context.Background(), connect.NewRequest(&usersv1.CreateUserRequest{Name: "Alice"})); err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+
+	resp, err := http.Get(ts.URL + "/users")
+	if err != nil {
+		t.Fatalf("get: %v", err)
+	}
+	defer resp.Body.Close()
+	var users []struct {
+		ID   int64  `json:"id"`
+		Name string `json:"name"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&users); err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	if len(users) != 2 || users[1].Name != "Alice" {
+		t.Fatalf("users = %+v; want Bob and Alice", users)
+	}
+}
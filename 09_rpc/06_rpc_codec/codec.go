@@ -0,0 +1,131 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"net/rpc"
+)
+
+// request and response are the JSON envelopes carried in each frame. The
+// header and body that net/rpc reads separately travel together, with the
+// body kept raw until ReadRequestBody/ReadResponseBody decodes it into the
+// type net/rpc asks for.
+type request struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type response struct {
+	ID     uint64          `json:"id"`
+	Result json.RawMessage `json:"result,omitempty"`
+	Error  string          `json:"error,omitempty"`
+}
+
+// serverCodec implements rpc.ServerCodec. net/rpc calls ReadRequestHeader
+// and ReadRequestBody in turn from one goroutine, and serializes
+// WriteResponse calls itself, so the codec needs no locking.
+type serverCodec struct {
+	conn   io.ReadWriteCloser
+	params json.RawMessage
+}
+
+// NewServerCodec returns a length-prefixed JSON codec for rpc.ServeCodec.
+func NewServerCodec(conn io.ReadWriteCloser) rpc.ServerCodec {
+	return &serverCodec{conn: conn}
+}
+
+func (c *serverCodec) ReadRequestHeader(r *rpc.Request) error {
+	frame, err := readFrame(c.conn)
+	if err != nil {
+		return err
+	}
+	var req request
+	if err := json.Unmarshal(frame, &req); err != nil {
+		return err
+	}
+	r.Seq = req.ID
+	r.ServiceMethod = req.Method
+	c.params = req.Params
+	return nil
+}
+
+// ReadRequestBody is called with a nil body when net/rpc wants to discard
+// it, e.g. for an unknown method.
+func (c *serverCodec) ReadRequestBody(body any) error {
+	params := c.params
+	c.params = nil
+	if body == nil || len(params) == 0 {
+		return nil
+	}
+	return json.Unmarshal(params, body)
+}
+
+func (c *serverCodec) WriteResponse(r *rpc.Response, body any) error {
+	res := response{ID: r.Seq, Error: r.Error}
+	if r.Error == "" {
+		result, err := json.Marshal(body)
+		if err != nil {
+			return err
+		}
+		res.Result = result
+	}
+	payload, err := json.Marshal(res)
+	if err != nil {
+		return err
+	}
+	return writeFrame(c.conn, payload)
+}
+
+func (c *serverCodec) Close() error { return c.conn.Close() }
+
+// clientCodec implements rpc.ClientCodec. rpc.Client holds a mutex around
+// WriteRequest and reads responses from a single goroutine.
+type clientCodec struct {
+	conn   io.ReadWriteCloser
+	result json.RawMessage
+}
+
+// NewClientCodec returns a length-prefixed JSON codec for
+// rpc.NewClientWithCodec.
+func NewClientCodec(conn io.ReadWriteCloser) rpc.ClientCodec {
+	return &clientCodec{conn: conn}
+}
+
+func (c *clientCodec) WriteRequest(r *rpc.Request, body any) error {
+	params, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	payload, err := json.Marshal(request{ID: r.Seq, Method: r.ServiceMethod, Params: params})
+	if err != nil {
+		return err
+	}
+	return writeFrame(c.conn, payload)
+}
+
+func (c *clientCodec) ReadResponseHeader(r *rpc.Response) error {
+	frame, err := readFrame(c.conn)
+	if err != nil {
+		return err
+	}
+	var res response
+	if err := json.Unmarshal(frame, &res); err != nil {
+		return err
+	}
+	r.Seq = res.ID
+	r.Error = res.Error
+	c.result = res.Result
+	return nil
+}
+
+func (c *clientCodec) ReadResponseBody(body any) error {
+	result := c.result
+	c.result = nil
+	if body == nil || len(result) == 0 {
+		return nil
+	}
+	return json.Unmarshal(result, body)
+}
+
+func (c *clientCodec) Close() error { return c.conn.Close() }
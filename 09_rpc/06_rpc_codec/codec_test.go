@@ -0,0 +1,166 @@
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/rpc"
+	"strings"
+	"sync"
+	"testing"
+)
+
+func TestWriteFrame_WireFormat(t *testing.T) {
+	var buf bytes.Buffer
+	if err := writeFrame(&buf, []byte(`{"id":7}`)); err != nil {
+		t.Fatalf("writeFrame: %v", err)
+	}
+	want := append([]byte{0, 0, 0, 8}, `{"id":7}`...)
+	if !bytes.Equal(buf.Bytes(), want) {
+		t.Fatalf("wire bytes = % x; want % x", buf.Bytes(), want)
+	}
+}
+
+func TestFrameRoundTrip(t *testing.T) {
+	var buf bytes.Buffer
+	payloads := []string{`{"a":1}`, ``, strings.Repeat("x", 70000)}
+	for _, p := range payloads {
+		if err := writeFrame(&buf, []byte(p)); err != nil {
+			t.Fatalf("writeFrame: %v", err)
+		}
+	}
+	for _, p := range payloads {
+		got, err := readFrame(&buf)
+		if err != nil {
+			t.Fatalf("readFrame: %v", err)
+		}
+		if string(got) != p {
+			t.Fatalf("payload = %.20q...; want %.20q...", got, p)
+		}
+	}
+	if _, err := readFrame(&buf); err != io.EOF {
+		t.Fatalf("readFrame at end = %v; want io.EOF", err)
+	}
+}
+
+func TestReadFrame_Errors(t *testing.T) {
+	tooLarge := make([]byte, 4)
+	binary.BigEndian.PutUint32(tooLarge, maxFrameSize+1)
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  error
+	}{
+		{"truncated header", []byte{0, 0}, io.ErrUnexpectedEOF},
+		{"truncated payload", []byte{0, 0, 0, 5, '{', '}'}, io.ErrUnexpectedEOF},
+		{"length over limit", tooLarge, ErrFrameTooLarge},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			_, err := readFrame(bytes.NewReader(tc.input))
+			if !errors.Is(err, tc.want) {
+				t.Fatalf("readFrame = %v; want %v", err, tc.want)
+			}
+		})
+	}
+}
+
+func TestWriteFrame_TooLarge(t *testing.T) {
+	err := writeFrame(io.Discard, make([]byte, maxFrameSize+1))
+	if !errors.Is(err, ErrFrameTooLarge) {
+		t.Fatalf("writeFrame = %v; want ErrFrameTooLarge", err)
+	}
+}
+
+// newPipeClient serves ArithService on one end of an in-memory pipe and
+// returns a client on the other end.
+func newPipeClient(t *testing.T) *rpc.Client {
+	t.Helper()
+	srv := rpc.NewServer()
+	if err := srv.Register(new(ArithService)); err != nil {
+		t.Fatal(err)
+	}
+	serverConn, clientConn := net.Pipe()
+	go srv.ServeCodec(NewServerCodec(serverConn))
+	client := rpc.NewClientWithCodec(NewClientCodec(clientConn))
+	t.Cleanup(func() { client.Close() })
+	return client
+}
+
+func TestCodec_Calls(t *testing.T) {
+	client := newPipeClient(t)
+
+	var sum int
+	if err := client.Call("ArithService.Add", &Args{2, 3}, &sum); err != nil || sum != 5 {
+		t.Fatalf("Add(2, 3) = %d, %v; want 5", sum, err)
+	}
+
+	var q float64
+	err := client.Call("ArithService.Divide", &Args{1, 0}, &q)
+	var serverErr rpc.ServerError
+	if !errors.As(err, &serverErr) || serverErr != "division by zero" {
+		t.Fatalf("Divide by zero err = %#v; want rpc.ServerError", err)
+	}
+
+	// An unknown method makes net/rpc discard the body (ReadRequestBody(nil));
+	// the connection must stay usable afterwards.
+	if err := client.Call("ArithService.Nope", &Args{}, &sum); err == nil {
+		t.Fatalf("unknown method succeeded")
+	}
+	if err := client.Call("ArithService.Add", &Args{4, 4}, &sum); err != nil || sum != 8 {
+		t.Fatalf("Add after error = %d, %v; want 8", sum, err)
+	}
+}
+
+func TestCodec_ConcurrentCalls(t *testing.T) {
+	client := newPipeClient(t)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			var sum int
+			if err := client.Call("ArithService.Add", &Args{i, i}, &sum); err != nil {
+				t.Errorf("Add(%d, %d): %v", i, i, err)
+				return
+			}
+			// Responses are matched to calls by id; a mix-up shows here.
+			if sum != 2*i {
+				t.Errorf("Add(%d, %d) = %d", i, i, sum)
+			}
+		}(i)
+	}
+	wg.Wait()
+}
+
+func TestServerCodec_ExactResponseBytes(t *testing.T) {
+	srv := rpc.NewServer()
+	if err := srv.Register(new(ArithService)); err != nil {
+		t.Fatal(err)
+	}
+	serverConn, raw := net.Pipe()
+	defer raw.Close()
+	go srv.ServeCodec(NewServerCodec(serverConn))
+
+	go writeFrame(raw, []byte(`{"id":9,"method":"ArithService.Add","params":{"A":2,"B":40}}`))
+	got, err := readFrame(raw)
+	if err != nil {
+		t.Fatalf("readFrame: %v", err)
+	}
+	if want := `{"id":9,"result":42}`; string(got) != want {
+		t.Fatalf("response = %s; want %s", got, want)
+	}
+
+	go writeFrame(raw, []byte(`{"id":10,"method":"ArithService.Divide","params":{"A":1,"B":0}}`))
+	got, err = readFrame(raw)
+	if err != nil {
+		t.Fatalf("readFrame: %v", err)
+	}
+	if want := `{"id":10,"error":"division by zero"}`; string(got) != want {
+		t.Fatalf("response = %s; want %s", got, want)
+	}
+}
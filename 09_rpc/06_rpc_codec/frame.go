@@ -0,0 +1,60 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+)
+
+// Wire format: every message is one frame.
+//
+//	+----------------------+---------------------+
+//	| length (uint32, BE)  | JSON payload        |
+//	+----------------------+---------------------+
+//
+// The prefix makes message boundaries explicit, so a reader never has to
+// scan JSON to find where one message ends, and any language can implement
+// it with a 4-byte read followed by an N-byte read.
+const (
+	frameHeaderSize = 4
+	maxFrameSize    = 1 << 20 // 1 MiB; rejects garbage lengths before allocating
+)
+
+// ErrFrameTooLarge is returned for a length prefix above maxFrameSize.
+var ErrFrameTooLarge = errors.New("frame too large")
+
+// writeFrame writes payload with its length prefix in a single Write call,
+// so concurrent writers on a connection cannot interleave half frames.
+func writeFrame(w io.Writer, payload []byte) error {
+	if len(payload) > maxFrameSize {
+		return fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, len(payload))
+	}
+	buf := make([]byte, frameHeaderSize+len(payload))
+	binary.BigEndian.PutUint32(buf, uint32(len(payload)))
+	copy(buf[frameHeaderSize:], payload)
+	_, err := w.Write(buf)
+	return err
+}
+
+// readFrame reads one frame. A clean EOF before the header is returned as
+// io.EOF (the peer hung up between messages); an EOF inside a frame is
+// io.ErrUnexpectedEOF.
+func readFrame(r io.Reader) ([]byte, error) {
+	var header [frameHeaderSize]byte
+	if _, err := io.ReadFull(r, header[:]); err != nil {
+		return nil, err
+	}
+	n := binary.BigEndian.Uint32(header[:])
+	if n > maxFrameSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrFrameTooLarge, n)
+	}
+	payload := make([]byte, n)
+	if _, err := io.ReadFull(r, payload); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return payload, nil
+}
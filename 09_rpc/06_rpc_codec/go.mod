@@ -0,0 +1,3 @@
+module golang_roadmap/09_rpc/06_rpc_codec
+
+go 1.24.11
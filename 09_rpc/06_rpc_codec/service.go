@@ -0,0 +1,26 @@
+package main
+
+import "fmt"
+
+// Args represents the arguments for RPC calls
+type Args struct {
+	A, B int
+}
+
+// ArithService provides arithmetic operations
+type ArithService struct{}
+
+// Add performs addition
+func (a *ArithService) Add(args *Args, reply *int) error {
+	*reply = args.A + args.B
+	return nil
+}
+
+// Divide performs division with error handling
+func (a *ArithService) Divide(args *Args, reply *float64) error {
+	if args.B == 0 {
+		return fmt.Errorf("division by zero")
+	}
+	*reply = float64(args.A) / float64(args.B)
+	return nil
+}
@@ -0,0 +1,3 @@
+module golang_roadmap/09_rpc/07_rpc_client_pool
+
+go 1.24.11
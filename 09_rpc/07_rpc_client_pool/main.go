@@ -0,0 +1,95 @@
+// Demonstrates a resilient net/rpc client: a pool that survives restarts.
+//
+// This example shows:
+// - Lazy dialing: no connection until the first call
+// - A bounded pool shared by many concurrent callers
+// - Detecting dead connections and reconnecting with exponential backoff
+// - Failing fast while the server is down instead of hanging
+// - Background health checks that evict dead idle connections
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+func main() {
+	const addr = "localhost:1239"
+	srv, err := startServer(addr)
+	if err != nil {
+		log.Fatal("Listen error:", err)
+	}
+
+	pool := NewPool(PoolConfig{
+		Addr:           addr,
+		Size:           3,
+		MinBackoff:     50 * time.Millisecond,
+		MaxBackoff:     400 * time.Millisecond,
+		HealthInterval: 100 * time.Millisecond,
+	})
+	defer pool.Close()
+	fmt.Printf("pool created, dials so far: %d (lazy)\n", pool.Stats().Dials)
+
+	var ok, unavailable, other atomic.Int64
+	ctx, cancel := context.WithTimeout(context.Background(), 2500*time.Millisecond)
+	defer cancel()
+
+	// Eight callers share three connections.
+	var wg sync.WaitGroup
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func(w int) {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				callCtx, callCancel := context.WithTimeout(ctx, 500*time.Millisecond)
+				var sum int
+				err := pool.Call(callCtx, "ArithService.Add", &Args{w, 1}, &sum)
+				callCancel()
+				switch {
+				case err == nil:
+					ok.Add(1)
+				case errors.Is(err, ErrUnavailable):
+					unavailable.Add(1)
+				case ctx.Err() == nil:
+					other.Add(1)
+					log.Printf("worker %d: %v", w, err)
+				}
+				time.Sleep(10 * time.Millisecond)
+			}
+		}(w)
+	}
+
+	// Crash the server for a while, then bring it back on the same address.
+	restarted := make(chan *server, 1)
+	go func() {
+		time.Sleep(700 * time.Millisecond)
+		srv.Stop()
+		time.Sleep(800 * time.Millisecond)
+		s, err := startServer(addr)
+		if err != nil {
+			log.Fatal("Restart error:", err)
+		}
+		log.Println("Server restarted on", addr)
+		restarted <- s
+	}()
+
+	ticker := time.NewTicker(250 * time.Millisecond)
+	defer ticker.Stop()
+	start := time.Now()
+	for ctx.Err() == nil {
+		<-ticker.C
+		fmt.Printf("t=%4dms ok=%-5d unavailable=%-4d other=%d\n",
+			time.Since(start).Milliseconds(), ok.Swap(0), unavailable.Swap(0), other.Swap(0))
+	}
+	wg.Wait()
+	(<-restarted).Stop()
+
+	st := pool.Stats()
+	fmt.Printf("\ndials=%d dialFailures=%d retries=%d brokenDetected=%d healthEvicted=%d\n",
+		st.Dials, st.DialFailures, st.Retries, st.BrokenDetected, st.HealthEvicted)
+}
@@ -0,0 +1,303 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"net/rpc"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrPoolClosed is returned by Call after Close.
+	ErrPoolClosed = errors.New("rpc pool: closed")
+	// ErrUnavailable is returned while a connection is backing off after
+	// failed dials. It is returned immediately, so callers fail fast during
+	// an outage instead of each waiting for a dial timeout.
+	ErrUnavailable = errors.New("rpc pool: server unavailable")
+)
+
+// PoolConfig configures a Pool. Zero values get the defaults below.
+type PoolConfig struct {
+	Addr           string
+	Size           int           // max connections, and so max concurrent calls (default 4)
+	DialTimeout    time.Duration // default 2s
+	MinBackoff     time.Duration // first retry delay after a failed dial (default 50ms)
+	MaxBackoff     time.Duration // cap for the exponential backoff (default 2s)
+	HealthInterval time.Duration // how often idle connections are pinged; 0 disables
+	HealthTimeout  time.Duration // default 1s
+}
+
+// PoolStats counts connection events since the pool was created.
+type PoolStats struct {
+	Dials          int64
+	DialFailures   int64
+	Retries        int64 // calls resent on a fresh connection
+	HealthEvicted  int64 // connections closed by a failed health check
+	BrokenDetected int64 // connections closed after a call failed
+}
+
+// conn is one pool slot. Whoever holds it (taken from Pool.idle) owns it
+// exclusively, so its fields need no lock.
+type conn struct {
+	client   *rpc.Client // nil until first use, and after a failure
+	failures int         // consecutive failed dials
+	nextDial time.Time   // no dial before this time
+}
+
+// Pool is a bounded set of net/rpc connections to one server. Connections
+// are dialed lazily on first use, re-dialed with exponential backoff after
+// failures, and optionally health-checked in the background.
+type Pool struct {
+	cfg  PoolConfig
+	sem  chan struct{} // one token per idle slot; callers block here when all are busy
+	done chan struct{}
+
+	mu     sync.Mutex
+	idle   []*conn // a stack: the most recently used, likely connected, slot is reused first
+	closed bool
+
+	dials, dialFailures, retries, healthEvicted, broken atomic.Int64
+}
+
+// NewPool creates a pool. It does not dial: the first Call does.
+func NewPool(cfg PoolConfig) *Pool {
+	if cfg.Size <= 0 {
+		cfg.Size = 4
+	}
+	if cfg.DialTimeout <= 0 {
+		cfg.DialTimeout = 2 * time.Second
+	}
+	if cfg.MinBackoff <= 0 {
+		cfg.MinBackoff = 50 * time.Millisecond
+	}
+	if cfg.MaxBackoff <= 0 {
+		cfg.MaxBackoff = 2 * time.Second
+	}
+	if cfg.HealthTimeout <= 0 {
+		cfg.HealthTimeout = time.Second
+	}
+	p := &Pool{
+		cfg:  cfg,
+		sem:  make(chan struct{}, cfg.Size),
+		done: make(chan struct{}),
+	}
+	for i := 0; i < cfg.Size; i++ {
+		p.idle = append(p.idle, &conn{})
+		p.sem <- struct{}{}
+	}
+	if cfg.HealthInterval > 0 {
+		go p.healthLoop()
+	}
+	return p
+}
+
+// Call invokes serviceMethod on a pooled connection. It waits for a free
+// connection, dials if needed, and gives up when ctx is done.
+//
+// A call is resent on a new connection only when net/rpc reports
+// rpc.ErrShutdown, meaning the request was never written. Calls that fail
+// mid-flight are not retried, because the server may have executed them.
+func (p *Pool) Call(ctx context.Context, serviceMethod string, args, reply any) error {
+	c, err := p.acquire(ctx)
+	if err != nil {
+		return err
+	}
+	defer p.release(c)
+
+	for attempt := 0; ; attempt++ {
+		if err := p.ensureConnected(ctx, c); err != nil {
+			return err
+		}
+		err := call(ctx, c.client, serviceMethod, args, reply)
+		if err == nil || !isBroken(err) {
+			return err
+		}
+		// The connection is dead: drop it so the next use re-dials.
+		c.client.Close()
+		c.client = nil
+		p.broken.Add(1)
+		if !errors.Is(err, rpc.ErrShutdown) || attempt > 0 {
+			return err
+		}
+		p.retries.Add(1)
+	}
+}
+
+// Stats returns a snapshot of the counters.
+func (p *Pool) Stats() PoolStats {
+	return PoolStats{
+		Dials:          p.dials.Load(),
+		DialFailures:   p.dialFailures.Load(),
+		Retries:        p.retries.Load(),
+		HealthEvicted:  p.healthEvicted.Load(),
+		BrokenDetected: p.broken.Load(),
+	}
+}
+
+// Close closes idle connections and makes later calls fail. Connections in
+// use are closed when their caller returns them.
+func (p *Pool) Close() error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil
+	}
+	p.closed = true
+	close(p.done)
+	for _, c := range p.idle {
+		if c.client != nil {
+			c.client.Close()
+		}
+	}
+	p.idle = nil
+	return nil
+}
+
+func (p *Pool) acquire(ctx context.Context) (*conn, error) {
+	select {
+	case <-p.done:
+		return nil, ErrPoolClosed
+	default:
+	}
+	select {
+	case <-p.sem:
+		return p.pop()
+	case <-p.done:
+		return nil, ErrPoolClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
+
+// pop takes the most recently released slot. The caller must hold a token
+// from p.sem, which guarantees there is one.
+func (p *Pool) pop() (*conn, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return nil, ErrPoolClosed
+	}
+	c := p.idle[len(p.idle)-1]
+	p.idle = p.idle[:len(p.idle)-1]
+	return c, nil
+}
+
+func (p *Pool) release(c *conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		if c.client != nil {
+			c.client.Close()
+		}
+		return
+	}
+	p.idle = append(p.idle, c)
+	p.sem <- struct{}{} // never blocks: there are exactly Size tokens
+}
+
+// ensureConnected dials c if it has no client, honoring its backoff.
+func (p *Pool) ensureConnected(ctx context.Context, c *conn) error {
+	if c.client != nil {
+		return nil
+	}
+	if wait := time.Until(c.nextDial); wait > 0 {
+		return fmt.Errorf("%w: retrying in %v", ErrUnavailable, wait.Round(time.Millisecond))
+	}
+	p.dials.Add(1)
+	d := net.Dialer{Timeout: p.cfg.DialTimeout}
+	nc, err := d.DialContext(ctx, "tcp", p.cfg.Addr)
+	if err != nil {
+		p.dialFailures.Add(1)
+		c.failures++
+		c.nextDial = time.Now().Add(p.backoff(c.failures))
+		return fmt.Errorf("%w: %v", ErrUnavailable, err)
+	}
+	c.failures = 0
+	c.client = rpc.NewClient(nc)
+	return nil
+}
+
+// backoff doubles from MinBackoff up to MaxBackoff, keeping a random half of
+// each step ("equal jitter") so pooled connections don't re-dial in lockstep.
+func (p *Pool) backoff(failures int) time.Duration {
+	d := p.cfg.MaxBackoff
+	if failures < 30 { // avoid overflowing the shift
+		d = min(p.cfg.MinBackoff<<(failures-1), p.cfg.MaxBackoff)
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+func (p *Pool) healthLoop() {
+	ticker := time.NewTicker(p.cfg.HealthInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			return
+		case <-ticker.C:
+			p.checkIdle()
+		}
+	}
+}
+
+// checkIdle pings every idle connection and evicts the ones that fail, so
+// the next caller re-dials instead of discovering the failure itself. Busy
+// connections are skipped: a call in progress is its own health check.
+func (p *Pool) checkIdle() {
+	var idle []*conn
+collect:
+	for len(idle) < p.cfg.Size {
+		select {
+		case <-p.sem:
+			c, err := p.pop()
+			if err != nil {
+				return
+			}
+			idle = append(idle, c)
+		default:
+			break collect
+		}
+	}
+	for _, c := range idle {
+		if c.client != nil {
+			ctx, cancel := context.WithTimeout(context.Background(), p.cfg.HealthTimeout)
+			var ok bool
+			if err := call(ctx, c.client, "HealthService.Ping", &struct{}{}, &ok); err != nil || !ok {
+				c.client.Close()
+				c.client = nil
+				p.healthEvicted.Add(1)
+			}
+			cancel()
+		}
+		p.release(c)
+	}
+}
+
+// call is client.Call that also returns when ctx is done. An abandoned call
+// still completes (or fails) in the background; its result is dropped.
+func call(ctx context.Context, client *rpc.Client, serviceMethod string, args, reply any) error {
+	c := client.Go(serviceMethod, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-c.Done:
+		return c.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// isBroken reports errors that mean the connection itself is unusable. The
+// remote method's own errors arrive as rpc.ServerError, and a caller's
+// deadline says nothing about the connection; everything else (EOF,
+// connection reset, rpc.ErrShutdown, decode errors) does.
+func isBroken(err error) bool {
+	var serverErr rpc.ServerError
+	return !errors.As(err, &serverErr) &&
+		!errors.Is(err, context.Canceled) &&
+		!errors.Is(err, context.DeadlineExceeded)
+}
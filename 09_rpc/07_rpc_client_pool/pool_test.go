@@ -0,0 +1,257 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+)
+
+func newTestServer(t *testing.T) *server {
+	t.Helper()
+	srv, err := startServer("127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("startServer: %v", err)
+	}
+	t.Cleanup(srv.Stop)
+	return srv
+}
+
+func newTestPool(t *testing.T, cfg PoolConfig) *Pool {
+	t.Helper()
+	if cfg.MinBackoff == 0 {
+		cfg.MinBackoff = 10 * time.Millisecond
+		cfg.MaxBackoff = 50 * time.Millisecond
+	}
+	p := NewPool(cfg)
+	t.Cleanup(func() { p.Close() })
+	return p
+}
+
+func add(p *Pool, a, b int) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	var sum int
+	err := p.Call(ctx, "ArithService.Add", &Args{a, b}, &sum)
+	return sum, err
+}
+
+// eventually retries f until it succeeds or the deadline passes.
+func eventually(t *testing.T, d time.Duration, f func() error) {
+	t.Helper()
+	deadline := time.Now().Add(d)
+	for {
+		err := f()
+		if err == nil {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("condition not met after %v: %v", d, err)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestPool_LazyDial(t *testing.T) {
+	srv := newTestServer(t)
+	p := newTestPool(t, PoolConfig{Addr: srv.Addr(), Size: 4})
+
+	if d := p.Stats().Dials; d != 0 {
+		t.Fatalf("dials after NewPool = %d; want 0", d)
+	}
+	if sum, err := add(p, 2, 3); err != nil || sum != 5 {
+		t.Fatalf("Add(2, 3) = %d, %v; want 5", sum, err)
+	}
+	if _, err := add(p, 1, 1); err != nil {
+		t.Fatalf("second call: %v", err)
+	}
+	if d := p.Stats().Dials; d != 1 {
+		t.Fatalf("dials after two sequential calls = %d; want 1 (connection reused)", d)
+	}
+}
+
+func TestPool_BoundsConnections(t *testing.T) {
+	srv := newTestServer(t)
+	p := newTestPool(t, PoolConfig{Addr: srv.Addr(), Size: 2})
+
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			if sum, err := add(p, i, i); err != nil || sum != 2*i {
+				t.Errorf("Add(%d, %d) = %d, %v", i, i, sum, err)
+			}
+		}(i)
+	}
+	wg.Wait()
+	if d := p.Stats().Dials; d > 2 {
+		t.Fatalf("dials = %d; want at most Size (2)", d)
+	}
+}
+
+func TestPool_ReconnectsAfterRestart(t *testing.T) {
+	srv := newTestServer(t)
+	addr := srv.Addr()
+	p := newTestPool(t, PoolConfig{Addr: addr, Size: 1})
+
+	if _, err := add(p, 1, 1); err != nil {
+		t.Fatalf("before restart: %v", err)
+	}
+	srv.Stop()
+
+	// A call racing the crash may fail with a connection error; once the
+	// client has noticed, calls fail fast with ErrUnavailable.
+	eventually(t, time.Second, func() error {
+		if _, err := add(p, 1, 1); !errors.Is(err, ErrUnavailable) {
+			return err
+		}
+		return nil
+	})
+
+	restarted, err := startServer(addr)
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	t.Cleanup(restarted.Stop)
+
+	eventually(t, 2*time.Second, func() error {
+		_, err := add(p, 1, 1)
+		return err
+	})
+	if st := p.Stats(); st.BrokenDetected == 0 || st.DialFailures == 0 {
+		t.Fatalf("stats = %+v; want the dead connection detected and a failed dial", st)
+	}
+}
+
+func TestPool_SurvivesRestartUnderLoad(t *testing.T) {
+	srv := newTestServer(t)
+	addr := srv.Addr()
+	p := newTestPool(t, PoolConfig{Addr: addr, Size: 3})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	errs := make(chan error, 1000)
+	for w := 0; w < 8; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if _, err := add(p, 1, 2); err != nil && !errors.Is(err, ErrUnavailable) {
+					select {
+					case errs <- err:
+					default:
+					}
+				}
+				time.Sleep(time.Millisecond)
+			}
+		}()
+	}
+
+	time.Sleep(50 * time.Millisecond)
+	srv.Stop()
+	time.Sleep(100 * time.Millisecond)
+	restarted, err := startServer(addr)
+	if err != nil {
+		t.Fatalf("restart: %v", err)
+	}
+	t.Cleanup(restarted.Stop)
+	time.Sleep(50 * time.Millisecond)
+
+	// After the restart every caller must get through again.
+	eventually(t, 2*time.Second, func() error {
+		for i := 0; i < 10; i++ {
+			if _, err := add(p, 1, 2); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	cancel()
+	wg.Wait()
+	close(errs)
+
+	// Calls in flight at the moment of the crash may fail with a connection
+	// error (they are not retried). Anything else is a bug.
+	for err := range errs {
+		if !isBroken(err) {
+			t.Errorf("unexpected error during restart: %v", err)
+		}
+	}
+}
+
+func TestPool_HealthCheckEvictsDeadConnections(t *testing.T) {
+	srv := newTestServer(t)
+	p := newTestPool(t, PoolConfig{Addr: srv.Addr(), Size: 2, HealthInterval: 20 * time.Millisecond})
+
+	if _, err := add(p, 1, 1); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	srv.Stop()
+
+	eventually(t, time.Second, func() error {
+		if p.Stats().HealthEvicted == 0 {
+			return errors.New("no eviction yet")
+		}
+		return nil
+	})
+	// The caller never saw the dead connection, so nothing was retried.
+	if r := p.Stats().Retries; r != 0 {
+		t.Fatalf("retries = %d; want 0", r)
+	}
+}
+
+func TestPool_Backoff(t *testing.T) {
+	p := newTestPool(t, PoolConfig{Addr: "unused", MinBackoff: 10 * time.Millisecond, MaxBackoff: 80 * time.Millisecond})
+	tests := []struct {
+		failures int
+		max      time.Duration
+	}{
+		{1, 10 * time.Millisecond},
+		{2, 20 * time.Millisecond},
+		{3, 40 * time.Millisecond},
+		{4, 80 * time.Millisecond},
+		{10, 80 * time.Millisecond},
+		{100, 80 * time.Millisecond},
+	}
+	for _, tc := range tests {
+		for i := 0; i < 20; i++ {
+			if d := p.backoff(tc.failures); d < tc.max/2 || d > tc.max {
+				t.Fatalf("backoff(%d) = %v; want in [%v, %v]", tc.failures, d, tc.max/2, tc.max)
+			}
+		}
+	}
+}
+
+func TestPool_WaitsForFreeConnection(t *testing.T) {
+	srv := newTestServer(t)
+	p := newTestPool(t, PoolConfig{Addr: srv.Addr(), Size: 1})
+
+	held, err := p.acquire(context.Background())
+	if err != nil {
+		t.Fatalf("acquire: %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	var sum int
+	if err := p.Call(ctx, "ArithService.Add", &Args{1, 1}, &sum); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Call with the only connection busy: err = %v; want DeadlineExceeded", err)
+	}
+	p.release(held)
+	if _, err := add(p, 1, 1); err != nil {
+		t.Fatalf("Call after release: %v", err)
+	}
+}
+
+func TestPool_Closed(t *testing.T) {
+	srv := newTestServer(t)
+	p := NewPool(PoolConfig{Addr: srv.Addr()})
+	if _, err := add(p, 1, 1); err != nil {
+		t.Fatalf("Add: %v", err)
+	}
+	p.Close()
+	if _, err := add(p, 1, 1); !errors.Is(err, ErrPoolClosed) {
+		t.Fatalf("after Close: err = %v; want ErrPoolClosed", err)
+	}
+}
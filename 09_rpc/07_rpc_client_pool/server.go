@@ -0,0 +1,89 @@
+package main
+
+import (
+	"log"
+	"net"
+	"net/rpc"
+	"sync"
+)
+
+// Args represents the arguments for RPC calls
+type Args struct {
+	A, B int
+}
+
+// ArithService provides arithmetic operations
+type ArithService struct{}
+
+// Add performs addition
+func (a *ArithService) Add(args *Args, reply *int) error {
+	*reply = args.A + args.B
+	return nil
+}
+
+// HealthService answers the pool's health checks.
+type HealthService struct{}
+
+// Ping replies true while the server is up.
+func (h *HealthService) Ping(args *struct{}, reply *bool) error {
+	*reply = true
+	return nil
+}
+
+// server is an RPC server that can be stopped abruptly, closing every open
+// connection as a crash or restart would.
+type server struct {
+	listener net.Listener
+	rpc      *rpc.Server
+
+	mu    sync.Mutex
+	conns map[net.Conn]struct{}
+}
+
+func startServer(addr string) (*server, error) {
+	srv := rpc.NewServer()
+	if err := srv.Register(new(ArithService)); err != nil {
+		return nil, err
+	}
+	if err := srv.Register(new(HealthService)); err != nil {
+		return nil, err
+	}
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	s := &server{listener: l, rpc: srv, conns: make(map[net.Conn]struct{})}
+	go s.serve()
+	return s, nil
+}
+
+func (s *server) Addr() string { return s.listener.Addr().String() }
+
+func (s *server) serve() {
+	for {
+		conn, err := s.listener.Accept()
+		if err != nil {
+			return
+		}
+		s.mu.Lock()
+		s.conns[conn] = struct{}{}
+		s.mu.Unlock()
+		go func() {
+			s.rpc.ServeConn(conn)
+			s.mu.Lock()
+			delete(s.conns, conn)
+			s.mu.Unlock()
+		}()
+	}
+}
+
+// Stop closes the listener and drops every client connection.
+func (s *server) Stop() {
+	s.listener.Close()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for conn := range s.conns {
+		conn.Close()
+	}
+	log.Printf("Server on %s stopped (%d connections dropped)", s.Addr(), len(s.conns))
+}
@@ -0,0 +1,202 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"net/rpc"
+	"sync"
+	"time"
+)
+
+// ErrNoInstances is returned when no instance of the service could serve a
+// call.
+var ErrNoInstances = errors.New("no available instances")
+
+// Balancer resolves a service through the registry and spreads calls over
+// its instances round-robin. An instance that fails is skipped for a short
+// cooldown, and the call moves on to the next one.
+type Balancer struct {
+	service  string
+	registry *RegistryClient
+	refresh  time.Duration // how long a resolved list is reused
+	cooldown time.Duration // how long a failed instance is skipped
+
+	mu       sync.Mutex
+	addrs    []string
+	resolved time.Time
+	next     int
+	clients  map[string]*rpc.Client
+	down     map[string]time.Time // addr -> skip until
+}
+
+func NewBalancer(service string, registry *RegistryClient) *Balancer {
+	return &Balancer{
+		service:  service,
+		registry: registry,
+		refresh:  500 * time.Millisecond,
+		cooldown: time.Second,
+		clients:  make(map[string]*rpc.Client),
+		down:     make(map[string]time.Time),
+	}
+}
+
+// Call invokes serviceMethod on the next instance in round-robin order.
+//
+// It fails over to another instance only when the request cannot have
+// reached the failed one: the dial failed, or net/rpc reports
+// rpc.ErrShutdown because the connection was already dead. A call that
+// breaks mid-flight is returned to the caller, as the method may have run.
+func (b *Balancer) Call(ctx context.Context, serviceMethod string, args, reply any) error {
+	candidates, err := b.pick(ctx)
+	if err != nil {
+		return err
+	}
+	var lastErr error
+	for _, addr := range candidates {
+		client, err := b.client(ctx, addr)
+		if err != nil {
+			b.markDown(addr, err)
+			lastErr = err
+			continue
+		}
+		err = call(ctx, client, serviceMethod, args, reply)
+		if err == nil || instanceNotAtFault(err) {
+			return err
+		}
+		b.markDown(addr, err)
+		if !errors.Is(err, rpc.ErrShutdown) {
+			return err
+		}
+		lastErr = err
+	}
+	if lastErr == nil {
+		return fmt.Errorf("%s: %w", b.service, ErrNoInstances)
+	}
+	return fmt.Errorf("%s: %w: last error: %v", b.service, ErrNoInstances, lastErr)
+}
+
+// Close closes all cached connections.
+func (b *Balancer) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	for addr, c := range b.clients {
+		c.Close()
+		delete(b.clients, addr)
+	}
+}
+
+// pick returns the healthy instances, rotated so the round-robin choice is
+// first and the rest follow as failover candidates.
+func (b *Balancer) pick(ctx context.Context) ([]string, error) {
+	addrs, err := b.resolve(ctx)
+	if err != nil {
+		return nil, err
+	}
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := time.Now()
+	var healthy []string
+	for _, addr := range addrs {
+		if now.Before(b.down[addr]) {
+			continue
+		}
+		healthy = append(healthy, addr)
+	}
+	if len(healthy) == 0 {
+		return nil, fmt.Errorf("%s: %w", b.service, ErrNoInstances)
+	}
+	start := b.next % len(healthy)
+	b.next++
+	return append(healthy[start:len(healthy):len(healthy)], healthy[:start]...), nil
+}
+
+// resolve returns the cached instance list, refreshing it from the registry
+// when it is older than b.refresh. If the registry is unreachable the stale
+// list is kept: a registry outage should not take every client down.
+func (b *Balancer) resolve(ctx context.Context) ([]string, error) {
+	b.mu.Lock()
+	if time.Since(b.resolved) < b.refresh {
+		addrs := b.addrs
+		b.mu.Unlock()
+		return addrs, nil
+	}
+	b.mu.Unlock()
+
+	instances, err := b.registry.Lookup(ctx, b.service)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if err != nil {
+		if b.addrs == nil {
+			return nil, fmt.Errorf("resolving %s: %w", b.service, err)
+		}
+		log.Printf("Balancer: registry lookup failed, using %d cached instances: %v", len(b.addrs), err)
+		return b.addrs, nil
+	}
+	addrs := make([]string, len(instances))
+	for i, inst := range instances {
+		addrs[i] = inst.Addr
+	}
+	b.addrs = addrs
+	b.resolved = time.Now()
+	return addrs, nil
+}
+
+func (b *Balancer) client(ctx context.Context, addr string) (*rpc.Client, error) {
+	b.mu.Lock()
+	c, ok := b.clients[addr]
+	b.mu.Unlock()
+	if ok {
+		return c, nil
+	}
+
+	d := net.Dialer{Timeout: time.Second}
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	c = rpc.NewClient(conn)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if existing, ok := b.clients[addr]; ok { // another caller won the race
+		c.Close()
+		return existing, nil
+	}
+	b.clients[addr] = c
+	return c, nil
+}
+
+// markDown drops the connection to addr and skips it for b.cooldown. The
+// registry expires it for good if it really is gone.
+func (b *Balancer) markDown(addr string, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if c, ok := b.clients[addr]; ok {
+		c.Close()
+		delete(b.clients, addr)
+	}
+	b.down[addr] = time.Now().Add(b.cooldown)
+	log.Printf("Balancer: %s marked down for %v: %v", addr, b.cooldown, err)
+}
+
+// call is client.Call that also returns when ctx is done.
+func call(ctx context.Context, client *rpc.Client, serviceMethod string, args, reply any) error {
+	c := client.Go(serviceMethod, args, reply, make(chan *rpc.Call, 1))
+	select {
+	case <-c.Done:
+		return c.Error
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// instanceNotAtFault reports errors that say nothing about the instance's health:
+// the method's own error, or the caller's deadline.
+func instanceNotAtFault(err error) bool {
+	var serverErr rpc.ServerError
+	return errors.As(err, &serverErr) ||
+		errors.Is(err, context.Canceled) ||
+		errors.Is(err, context.DeadlineExceeded)
+}
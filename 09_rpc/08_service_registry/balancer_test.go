@@ -0,0 +1,131 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// cluster starts a registry and n registered instances.
+func cluster(t *testing.T, n int) (*RegistryClient, []*instance) {
+	t.Helper()
+	ts := httptest.NewServer(NewRegistry().Handler())
+	t.Cleanup(ts.Close)
+	rc := NewRegistryClient(ts.URL)
+
+	var instances []*instance
+	for i := 0; i < n; i++ {
+		inst, err := startInstance("127.0.0.1:0")
+		if err != nil {
+			t.Fatalf("startInstance: %v", err)
+		}
+		t.Cleanup(inst.Stop)
+		if err := rc.Register(context.Background(), "arith", inst.Addr(), time.Minute); err != nil {
+			t.Fatalf("Register: %v", err)
+		}
+		instances = append(instances, inst)
+	}
+	return rc, instances
+}
+
+func servedBy(t *testing.T, b *Balancer, calls int) map[string]int {
+	t.Helper()
+	counts := map[string]int{}
+	for i := 0; i < calls; i++ {
+		var reply Reply
+		if err := b.Call(context.Background(), "ArithService.Add", &Args{i, 1}, &reply); err != nil {
+			t.Fatalf("call %d: %v", i, err)
+		}
+		if reply.Sum != i+1 {
+			t.Fatalf("call %d: sum = %d", i, reply.Sum)
+		}
+		counts[reply.ServedBy]++
+	}
+	return counts
+}
+
+func TestBalancer_RoundRobin(t *testing.T) {
+	rc, instances := cluster(t, 3)
+	b := NewBalancer("arith", rc)
+	defer b.Close()
+
+	counts := servedBy(t, b, 30)
+	for _, inst := range instances {
+		if counts[inst.Addr()] != 10 {
+			t.Fatalf("served = %v; want 10 calls per instance", counts)
+		}
+	}
+}
+
+func TestBalancer_FailoverWhenInstanceGoesDown(t *testing.T) {
+	rc, instances := cluster(t, 3)
+	b := NewBalancer("arith", rc)
+	defer b.Close()
+	servedBy(t, b, 3) // open a connection to every instance
+
+	// Crash one instance. It stays in the registry (TTL not expired), so
+	// the balancer has to notice on its own.
+	dead := instances[1]
+	dead.Stop()
+	time.Sleep(20 * time.Millisecond) // let the client see the connection drop
+
+	counts := servedBy(t, b, 20) // fails the test on any error
+	if counts[dead.Addr()] != 0 {
+		t.Fatalf("dead instance served %d calls", counts[dead.Addr()])
+	}
+	if counts[instances[0].Addr()] == 0 || counts[instances[2].Addr()] == 0 {
+		t.Fatalf("served = %v; want the survivors to share the load", counts)
+	}
+}
+
+func TestBalancer_FailoverOnDialError(t *testing.T) {
+	rc, instances := cluster(t, 2)
+	// Registered, but nothing listens there: every dial is refused.
+	if err := rc.Register(context.Background(), "arith", "127.0.0.1:1", time.Minute); err != nil {
+		t.Fatal(err)
+	}
+	b := NewBalancer("arith", rc)
+	defer b.Close()
+
+	counts := servedBy(t, b, 6)
+	if counts[instances[0].Addr()]+counts[instances[1].Addr()] != 6 {
+		t.Fatalf("served = %v; want all calls on the live instances", counts)
+	}
+}
+
+func TestBalancer_AllDown(t *testing.T) {
+	rc, instances := cluster(t, 2)
+	b := NewBalancer("arith", rc)
+	defer b.Close()
+	for _, inst := range instances {
+		inst.Stop()
+	}
+	var reply Reply
+	err := b.Call(context.Background(), "ArithService.Add", &Args{1, 1}, &reply)
+	if !errors.Is(err, ErrNoInstances) {
+		t.Fatalf("err = %v; want ErrNoInstances", err)
+	}
+}
+
+func TestBalancer_KeepsStaleListWhenRegistryDown(t *testing.T) {
+	ts := httptest.NewServer(NewRegistry().Handler())
+	rc := NewRegistryClient(ts.URL)
+	inst, err := startInstance("127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer inst.Stop()
+	if err := rc.Register(context.Background(), "arith", inst.Addr(), time.Minute); err != nil {
+		t.Fatal(err)
+	}
+
+	b := NewBalancer("arith", rc)
+	b.refresh = 0 // look up on every call
+	defer b.Close()
+	servedBy(t, b, 1)
+
+	ts.Close()
+	servedBy(t, b, 3) // fails the test on any error
+}
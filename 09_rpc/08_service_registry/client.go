@@ -0,0 +1,86 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"time"
+)
+
+// RegistryClient talks to a Registry over HTTP.
+type RegistryClient struct {
+	BaseURL string
+	HTTP    *http.Client
+}
+
+func NewRegistryClient(baseURL string) *RegistryClient {
+	return &RegistryClient{BaseURL: baseURL, HTTP: &http.Client{Timeout: 2 * time.Second}}
+}
+
+func (c *RegistryClient) instancesURL(service string) string {
+	return c.BaseURL + "/services/" + url.PathEscape(service) + "/instances"
+}
+
+func (c *RegistryClient) Register(ctx context.Context, service, addr string, ttl time.Duration) error {
+	body, err := json.Marshal(registerRequest{Addr: addr, TTLMs: ttl.Milliseconds()})
+	if err != nil {
+		return err
+	}
+	return c.do(ctx, http.MethodPut, c.instancesURL(service), body, nil)
+}
+
+func (c *RegistryClient) Deregister(ctx context.Context, service, addr string) error {
+	return c.do(ctx, http.MethodDelete, c.instancesURL(service)+"/"+url.PathEscape(addr), nil, nil)
+}
+
+func (c *RegistryClient) Lookup(ctx context.Context, service string) ([]Instance, error) {
+	var instances []Instance
+	err := c.do(ctx, http.MethodGet, c.instancesURL(service), nil, &instances)
+	return instances, err
+}
+
+func (c *RegistryClient) do(ctx context.Context, method, u string, body []byte, out any) error {
+	req, err := http.NewRequestWithContext(ctx, method, u, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	resp, err := c.HTTP.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("registry %s %s: %s", method, u, resp.Status)
+	}
+	if out != nil {
+		return json.NewDecoder(resp.Body).Decode(out)
+	}
+	return nil
+}
+
+// Heartbeat registers addr and re-registers it every ttl/3 until ctx is
+// done. Three heartbeats per TTL means one lost request does not drop a
+// healthy instance. A crashed process simply stops heartbeating and the
+// registry expires it after ttl; a clean shutdown should also call
+// Deregister so clients stop using it right away.
+func Heartbeat(ctx context.Context, rc *RegistryClient, service, addr string, ttl time.Duration) {
+	ticker := time.NewTicker(ttl / 3)
+	defer ticker.Stop()
+	for {
+		if err := rc.Register(ctx, service, addr, ttl); err != nil && ctx.Err() == nil {
+			log.Printf("Heartbeat %s %s: %v", service, addr, err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
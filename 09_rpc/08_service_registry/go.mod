@@ -0,0 +1,3 @@
+module golang_roadmap/09_rpc/08_service_registry
+
+go 1.24.11
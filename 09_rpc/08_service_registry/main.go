@@ -0,0 +1,105 @@
+// Demonstrates service discovery for RPC: a registry, heartbeats and a
+// client-side round-robin load balancer with failover.
+//
+// This example shows:
+// - A tiny HTTP registry where instances register with a TTL
+// - Heartbeats that keep an instance registered while it is alive
+// - Clients resolving a service name instead of a fixed address
+// - Round-robin load balancing across instances
+// - Failover when an instance crashes, before its TTL even expires
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"time"
+)
+
+const serviceName = "arith"
+
+func main() {
+	// The registry.
+	regL, err := net.Listen("tcp", "localhost:8500")
+	if err != nil {
+		log.Fatal("Listen error:", err)
+	}
+	regSrv := &http.Server{Handler: NewRegistry().Handler(), ReadHeaderTimeout: 5 * time.Second}
+	go regSrv.Serve(regL)
+	defer regSrv.Close()
+	rc := NewRegistryClient("http://" + regL.Addr().String())
+	log.Println("Registry listening on", regL.Addr())
+
+	// Three instances, each heartbeating its own address.
+	const ttl = 600 * time.Millisecond
+	var instances []*instance
+	var stopHeartbeats []context.CancelFunc
+	for i := 0; i < 3; i++ {
+		inst, err := startInstance("localhost:0")
+		if err != nil {
+			log.Fatal(err)
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		go Heartbeat(ctx, rc, serviceName, inst.Addr(), ttl)
+		instances = append(instances, inst)
+		stopHeartbeats = append(stopHeartbeats, cancel)
+	}
+	time.Sleep(100 * time.Millisecond) // let the first heartbeats land
+
+	balancer := NewBalancer(serviceName, rc)
+	defer balancer.Close()
+	callN := func(n int) {
+		counts := map[string]int{}
+		for i := 0; i < n; i++ {
+			var reply Reply
+			if err := balancer.Call(context.Background(), "ArithService.Add", &Args{i, 1}, &reply); err != nil {
+				fmt.Printf("call %d: %v\n", i, err)
+				continue
+			}
+			counts[reply.ServedBy]++
+		}
+		for _, inst := range instances {
+			fmt.Printf("  %s served %d\n", inst.Addr(), counts[inst.Addr()])
+		}
+	}
+	lookup := func() {
+		list, err := rc.Lookup(context.Background(), serviceName)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("registry lists %d instances:", len(list))
+		for _, inst := range list {
+			fmt.Printf(" %s", inst.Addr)
+		}
+		fmt.Println()
+	}
+
+	fmt.Println("\n=== Round robin over three instances ===")
+	lookup()
+	callN(9)
+
+	fmt.Println("\n=== Instance 2 crashes (no deregistration) ===")
+	stopHeartbeats[1]()
+	instances[1].Stop()
+	lookup() // still listed until its TTL runs out
+	callN(9)
+
+	fmt.Println("\n=== After the TTL expires ===")
+	time.Sleep(ttl + 100*time.Millisecond)
+	lookup()
+
+	fmt.Println("\n=== Instance 3 shuts down cleanly (deregisters) ===")
+	stopHeartbeats[2]()
+	if err := rc.Deregister(context.Background(), serviceName, instances[2].Addr()); err != nil {
+		log.Fatal(err)
+	}
+	instances[2].Stop()
+	lookup()
+	time.Sleep(balancer.refresh) // the balancer's cached list ages out
+	callN(4)
+
+	stopHeartbeats[0]()
+	instances[0].Stop()
+}
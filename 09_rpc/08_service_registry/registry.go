@@ -0,0 +1,126 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+const maxTTL = time.Minute
+
+// Instance is one registered address of a service.
+type Instance struct {
+	Service   string    `json:"service"`
+	Addr      string    `json:"addr"`
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// Registry maps service names to instance addresses. Every registration
+// has a TTL: an instance that stops sending heartbeats disappears on its
+// own, so a crashed server never has to deregister itself.
+type Registry struct {
+	mu       sync.Mutex
+	services map[string]map[string]time.Time // service -> addr -> expiry
+	now      func() time.Time                // replaced in tests
+}
+
+func NewRegistry() *Registry {
+	return &Registry{services: make(map[string]map[string]time.Time), now: time.Now}
+}
+
+// Register adds addr to service, or extends its TTL if it is already there.
+// A heartbeat is just another Register.
+func (r *Registry) Register(service, addr string, ttl time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	instances, ok := r.services[service]
+	if !ok {
+		instances = make(map[string]time.Time)
+		r.services[service] = instances
+	}
+	if _, exists := instances[addr]; !exists {
+		log.Printf("Registry: %s registered %s (ttl %v)", service, addr, ttl)
+	}
+	instances[addr] = r.now().Add(ttl)
+}
+
+// Deregister removes addr from service immediately.
+func (r *Registry) Deregister(service, addr string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.services[service][addr]; ok {
+		delete(r.services[service], addr)
+		log.Printf("Registry: %s deregistered %s", service, addr)
+	}
+}
+
+// Lookup returns the live instances of service, sorted by address so every
+// client sees the same order. Expired entries are pruned on the way.
+func (r *Registry) Lookup(service string) []Instance {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	var out []Instance
+	for addr, expires := range r.services[service] {
+		if !now.Before(expires) {
+			delete(r.services[service], addr)
+			log.Printf("Registry: %s expired %s (missed heartbeats)", service, addr)
+			continue
+		}
+		out = append(out, Instance{Service: service, Addr: addr, ExpiresAt: expires})
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Addr < out[j].Addr })
+	return out
+}
+
+// registerRequest is the body of PUT /services/{service}/instances.
+type registerRequest struct {
+	Addr  string `json:"addr"`
+	TTLMs int64  `json:"ttl_ms"`
+}
+
+// Handler exposes the registry over HTTP:
+//
+//	PUT    /services/{service}/instances         register or heartbeat
+//	DELETE /services/{service}/instances/{addr}  deregister
+//	GET    /services/{service}/instances         list live instances
+func (r *Registry) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("PUT /services/{service}/instances", func(w http.ResponseWriter, req *http.Request) {
+		var body registerRequest
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			http.Error(w, "invalid JSON: "+err.Error(), http.StatusBadRequest)
+			return
+		}
+		if _, _, err := net.SplitHostPort(body.Addr); err != nil {
+			http.Error(w, "addr must be host:port", http.StatusBadRequest)
+			return
+		}
+		ttl := time.Duration(body.TTLMs) * time.Millisecond
+		if ttl <= 0 || ttl > maxTTL {
+			http.Error(w, "ttl_ms must be between 1 and 60000", http.StatusBadRequest)
+			return
+		}
+		r.Register(req.PathValue("service"), body.Addr, ttl)
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("DELETE /services/{service}/instances/{addr}", func(w http.ResponseWriter, req *http.Request) {
+		r.Deregister(req.PathValue("service"), req.PathValue("addr"))
+		w.WriteHeader(http.StatusNoContent)
+	})
+	mux.HandleFunc("GET /services/{service}/instances", func(w http.ResponseWriter, req *http.Request) {
+		instances := r.Lookup(req.PathValue("service"))
+		if instances == nil {
+			instances = []Instance{}
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(w).Encode(instances); err != nil {
+			log.Printf("Error encoding instances: %v", err)
+		}
+	})
+	return mux
+}
@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func addrs(instances []Instance) string {
+	var out []string
+	for _, inst := range instances {
+		out = append(out, inst.Addr)
+	}
+	return strings.Join(out, ",")
+}
+
+func TestRegistry_TTL(t *testing.T) {
+	r := NewRegistry()
+	now := time.Unix(1000, 0)
+	r.now = func() time.Time { return now }
+
+	r.Register("arith", "10.0.0.2:1", time.Second)
+	r.Register("arith", "10.0.0.1:1", 3*time.Second)
+	if got := addrs(r.Lookup("arith")); got != "10.0.0.1:1,10.0.0.2:1" {
+		t.Fatalf("Lookup = %s; want both, sorted", got)
+	}
+
+	now = now.Add(time.Second)
+	if got := addrs(r.Lookup("arith")); got != "10.0.0.1:1" {
+		t.Fatalf("Lookup after 1s = %s; want only the 3s instance", got)
+	}
+
+	// A heartbeat extends the TTL from the time it arrives.
+	r.Register("arith", "10.0.0.1:1", 3*time.Second)
+	now = now.Add(2500 * time.Millisecond)
+	if got := addrs(r.Lookup("arith")); got != "10.0.0.1:1" {
+		t.Fatalf("Lookup after heartbeat = %s; want it still listed", got)
+	}
+
+	r.Deregister("arith", "10.0.0.1:1")
+	if got := r.Lookup("arith"); len(got) != 0 {
+		t.Fatalf("Lookup after Deregister = %v; want none", got)
+	}
+}
+
+func TestRegistry_HTTP(t *testing.T) {
+	ts := httptest.NewServer(NewRegistry().Handler())
+	defer ts.Close()
+	rc := NewRegistryClient(ts.URL)
+	ctx := context.Background()
+
+	if err := rc.Register(ctx, "arith", "127.0.0.1:9001", time.Minute); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if err := rc.Register(ctx, "arith", "127.0.0.1:9002", time.Minute); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	got, err := rc.Lookup(ctx, "arith")
+	if err != nil || addrs(got) != "127.0.0.1:9001,127.0.0.1:9002" {
+		t.Fatalf("Lookup = %v, %v", got, err)
+	}
+	if err := rc.Deregister(ctx, "arith", "127.0.0.1:9001"); err != nil {
+		t.Fatalf("Deregister: %v", err)
+	}
+	got, err = rc.Lookup(ctx, "arith")
+	if err != nil || addrs(got) != "127.0.0.1:9002" {
+		t.Fatalf("Lookup after Deregister = %v, %v", got, err)
+	}
+	if got, err := rc.Lookup(ctx, "unknown"); err != nil || len(got) != 0 {
+		t.Fatalf("Lookup(unknown) = %v, %v; want empty", got, err)
+	}
+}
+
+func TestRegistry_HTTPValidation(t *testing.T) {
+	ts := httptest.NewServer(NewRegistry().Handler())
+	defer ts.Close()
+
+	tests := []struct {
+		name, body string
+	}{
+		{"bad json", `{`},
+		{"no port", `{"addr":"localhost","ttl_ms":1000}`},
+		{"zero ttl", `{"addr":"localhost:1","ttl_ms":0}`},
+		{"ttl too long", `{"addr":"localhost:1","ttl_ms":600000}`},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			req, _ := http.NewRequest(http.MethodPut, ts.URL+"/services/arith/instances", strings.NewReader(tc.body))
+			resp, err := http.DefaultClient.Do(req)
+			if err != nil {
+				t.Fatal(err)
+			}
+			resp.Body.Close()
+			if resp.StatusCode != http.StatusBadRequest {
+				t.Fatalf("status = %d; want 400", resp.StatusCode)
+			}
+		})
+	}
+}
+
+func TestHeartbeat_KeepsInstanceAlive(t *testing.T) {
+	r := NewRegistry()
+	ts := httptest.NewServer(r.Handler())
+	defer ts.Close()
+	rc := NewRegistryClient(ts.URL)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		Heartbeat(ctx, rc, "arith", "127.0.0.1:9001", 60*time.Millisecond)
+		close(done)
+	}()
+
+	// Well past several TTLs, the instance is still there.
+	time.Sleep(200 * time.Millisecond)
+	if got := r.Lookup("arith"); len(got) != 1 {
+		t.Fatalf("Lookup while heartbeating = %v; want 1 instance", got)
+	}
+
+	// Without heartbeats it expires on its own.
+	cancel()
+	<-done
+	time.Sleep(80 * time.Millisecond)
+	if got := r.Lookup("arith"); len(got) != 0 {
+		t.Fatalf("Lookup after heartbeats stopped = %v; want expired", got)
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"net"
+	"net/rpc"
+	"sync"
+)
+
+// Args represents the arguments for RPC calls
+type Args struct {
+	A, B int
+}
+
+// ArithService provides arithmetic operations. Each instance knows its own
+// address so replies show which instance served them.
+type ArithService struct {
+	addr string
+}
+
+// Add performs addition
+func (a *ArithService) Add(args *Args, reply *Reply) error {
+	*reply = Reply{Sum: args.A + args.B, ServedBy: a.addr}
+	return nil
+}
+
+// Reply carries the result and the address of the instance that produced it.
+type Reply struct {
+	Sum      int
+	ServedBy string
+}
+
+// instance is one RPC server process. Stop drops all connections at once,
+// like a crash.
+type instance struct {
+	listener net.Listener
+	mu       sync.Mutex
+	conns    map[net.Conn]struct{}
+}
+
+func startInstance(addr string) (*instance, error) {
+	l, err := net.Listen("tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	srv := rpc.NewServer()
+	if err := srv.Register(&ArithService{addr: l.Addr().String()}); err != nil {
+		l.Close()
+		return nil, err
+	}
+	inst := &instance{listener: l, conns: make(map[net.Conn]struct{})}
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			inst.mu.Lock()
+			inst.conns[conn] = struct{}{}
+			inst.mu.Unlock()
+			go func() {
+				srv.ServeConn(conn)
+				inst.mu.Lock()
+				delete(inst.conns, conn)
+				inst.mu.Unlock()
+			}()
+		}
+	}()
+	return inst, nil
+}
+
+func (i *instance) Addr() string { return i.listener.Addr().String() }
+
+func (i *instance) Stop() {
+	i.listener.Close()
+	i.mu.Lock()
+	defer i.mu.Unlock()
+	for conn := range i.conns {
+		conn.Close()
+	}
+}
@@ -0,0 +1,3 @@
+module golang_roadmap/09_rpc/09_plugins
+
+go 1.24.11
@@ -0,0 +1,53 @@
+// Package goplugin loads a Greeter from a Go shared object built with
+//
+//	go build -buildmode=plugin -o hello.so ./plugins/hello
+//
+// The plugin's main package declares
+//
+//	var Greeter greeter.Greeter = hello{}
+//
+// and Open looks that variable up. The plugin runs in the host's process:
+// calls are ordinary method calls, but a panic in the plugin takes the
+// host down, and a plugin cannot be unloaded.
+//
+// The plugin package works on Linux, macOS and FreeBSD with cgo. The host
+// and every plugin must be built by the same Go version, with the same
+// build flags, from the same source of every package they share —
+// greeter here, and the standard library. Otherwise Open fails with
+// "plugin was built with a different version of package".
+package goplugin
+
+import (
+	"fmt"
+	"plugin"
+
+	"golang_roadmap/09_rpc/09_plugins/greeter"
+)
+
+// Symbol is the name of the variable a plugin exports.
+const Symbol = "Greeter"
+
+// Open loads the shared object at path and returns its Greeter. Opening
+// the same path again returns the same plugin, which is loaded once.
+func Open(path string) (greeter.Greeter, error) {
+	p, err := plugin.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	sym, err := p.Lookup(Symbol)
+	if err != nil {
+		return nil, err
+	}
+	// Lookup returns a pointer to a variable and the value of a function.
+	switch g := sym.(type) {
+	case *greeter.Greeter:
+		if *g == nil {
+			return nil, fmt.Errorf("%s: %s is nil", path, Symbol)
+		}
+		return *g, nil
+	case func() greeter.Greeter:
+		return g(), nil
+	default:
+		return nil, fmt.Errorf("%s: %s is a %T, not a greeter.Greeter", path, Symbol, sym)
+	}
+}
@@ -0,0 +1,21 @@
+// Package greeter is the extension point that plugins implement. The host
+// knows only this interface; a plugin may be a Go shared object loaded
+// into the host (package goplugin) or a separate program it talks to
+// (package subproc), and the host cannot tell them apart.
+package greeter
+
+// Greeter greets people, each plugin in its own way.
+type Greeter interface {
+	// Info describes the plugin.
+	Info() Info
+	// Greet returns a greeting for name. An error is the plugin's, such
+	// as a name it refuses, and is shown to the user.
+	Greet(name string) (string, error)
+}
+
+// Info is what a plugin says about itself. The host lists plugins by
+// Name, so two plugins with the same name cannot both be loaded.
+type Info struct {
+	Name    string `json:"name"`
+	Version string `json:"version"`
+}
@@ -0,0 +1,148 @@
+// Package host finds Greeter plugins in a directory and loads each one the
+// way its file says: a .so file is a Go plugin (package goplugin), an
+// executable is a plugin process (package subproc). Everything else in
+// the directory is ignored, so a README or a config file can sit next to
+// the plugins.
+package host
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang_roadmap/09_rpc/09_plugins/goplugin"
+	"golang_roadmap/09_rpc/09_plugins/greeter"
+	"golang_roadmap/09_rpc/09_plugins/subproc"
+)
+
+// Kind says how a plugin was loaded.
+type Kind string
+
+const (
+	KindGoPlugin Kind = "goplugin"
+	KindProcess  Kind = "process"
+)
+
+// Plugin is a loaded plugin. Its Greeter is the same interface whatever
+// its Kind.
+type Plugin struct {
+	greeter.Greeter
+	Path string
+	Kind Kind
+}
+
+// Host holds the plugins found by Discover, in file name order.
+type Host struct {
+	Plugins []Plugin
+
+	closers []io.Closer
+}
+
+// Options configure Discover.
+type Options struct {
+	// Stderr receives the log output of plugin processes. Nil discards it.
+	Stderr io.Writer
+	// Timeout bounds each call to a plugin process. Zero means the
+	// subproc default.
+	Timeout time.Duration
+}
+
+// Discover loads every plugin in dir. ctx bounds the plugin processes'
+// handshakes. A plugin that fails to load does not stop the others: its
+// error is joined into the returned error, next to a Host with the rest.
+// The Host is nil only if dir cannot be read.
+func Discover(ctx context.Context, dir string, opts Options) (*Host, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, err
+	}
+	h := &Host{}
+	var errs []error
+	for _, e := range entries {
+		if strings.HasPrefix(e.Name(), ".") {
+			continue
+		}
+		path := filepath.Join(dir, e.Name())
+		// Stat, not e.Info: a plugin may be a symlink into a build directory.
+		fi, err := os.Stat(path)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if !fi.Mode().IsRegular() {
+			continue
+		}
+		var p Plugin
+		switch {
+		case filepath.Ext(path) == ".so":
+			p, err = openGoPlugin(path)
+		case isExecutable(path, fi):
+			p, err = h.startProcess(ctx, path, opts)
+		default:
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if other, ok := h.Lookup(p.Info().Name); ok {
+			errs = append(errs, fmt.Errorf("%s: plugin %q is already loaded from %s", path, other.Info().Name, other.Path))
+			continue
+		}
+		h.Plugins = append(h.Plugins, p)
+	}
+	return h, errors.Join(errs...)
+}
+
+func openGoPlugin(path string) (Plugin, error) {
+	g, err := goplugin.Open(path)
+	if err != nil {
+		return Plugin{}, err
+	}
+	return Plugin{Greeter: g, Path: path, Kind: KindGoPlugin}, nil
+}
+
+func (h *Host) startProcess(ctx context.Context, path string, opts Options) (Plugin, error) {
+	c, err := subproc.Start(ctx, path, opts.Stderr)
+	if err != nil {
+		return Plugin{}, err
+	}
+	c.Timeout = opts.Timeout
+	h.closers = append(h.closers, c)
+	return Plugin{Greeter: c, Path: path, Kind: KindProcess}, nil
+}
+
+// Lookup returns the plugin whose Info has the given name.
+func (h *Host) Lookup(name string) (Plugin, bool) {
+	for _, p := range h.Plugins {
+		if p.Info().Name == name {
+			return p, true
+		}
+	}
+	return Plugin{}, false
+}
+
+// Close stops the plugin processes, including any rejected as duplicates.
+// Go plugins stay loaded: a shared object cannot be unloaded.
+func (h *Host) Close() error {
+	var errs []error
+	for _, c := range h.closers {
+		errs = append(errs, c.Close())
+	}
+	h.closers = nil
+	return errors.Join(errs...)
+}
+
+func isExecutable(path string, fi fs.FileInfo) bool {
+	if runtime.GOOS == "windows" {
+		return strings.EqualFold(filepath.Ext(path), ".exe")
+	}
+	return fi.Mode().Perm()&0o111 != 0
+}
@@ -0,0 +1,125 @@
+package host
+
+import (
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/09_rpc/09_plugins/greeter"
+	"golang_roadmap/09_rpc/09_plugins/subproc"
+)
+
+// Started by Discover, the test binary is a plugin process; the name it
+// reports comes from the environment so one binary can be two plugins.
+func TestMain(m *testing.M) {
+	if os.Getenv(subproc.MagicCookieKey) == "" {
+		os.Exit(m.Run())
+	}
+	if err := subproc.Serve(testGreeter{}); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+type testGreeter struct{}
+
+func (testGreeter) Info() greeter.Info {
+	return greeter.Info{Name: os.Getenv("HOST_TEST_NAME"), Version: "0.1.0"}
+}
+
+func (testGreeter) Greet(name string) (string, error) { return "hi " + name, nil }
+
+// copyTestBinary installs the test binary in dir as a plugin called name.
+func copyTestBinary(t *testing.T, dir, name string) {
+	t.Helper()
+	self, err := os.Open(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer self.Close()
+	f, err := os.OpenFile(filepath.Join(dir, name), os.O_CREATE|os.O_WRONLY, 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := io.Copy(f, self); err != nil {
+		t.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func discover(t *testing.T, dir string) (*Host, error) {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	h, err := Discover(ctx, dir, Options{})
+	if h != nil {
+		t.Cleanup(func() {
+			if err := h.Close(); err != nil {
+				t.Errorf("Close: %v", err)
+			}
+		})
+	}
+	return h, err
+}
+
+func TestDiscover(t *testing.T) {
+	t.Setenv("HOST_TEST_NAME", "test")
+	dir := t.TempDir()
+	copyTestBinary(t, dir, "test-plugin")
+	// Not plugins: ignored.
+	os.WriteFile(filepath.Join(dir, "README.md"), []byte("plugins live here\n"), 0o644)
+	os.WriteFile(filepath.Join(dir, ".hidden"), []byte("#!/bin/sh\n"), 0o755)
+	os.Mkdir(filepath.Join(dir, "sub"), 0o755)
+
+	h, err := discover(t, dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(h.Plugins) != 1 {
+		t.Fatalf("Plugins = %+v; want one", h.Plugins)
+	}
+	p, ok := h.Lookup("test")
+	if !ok || p.Kind != KindProcess || p.Path != filepath.Join(dir, "test-plugin") {
+		t.Fatalf("Lookup(test) = %+v, %v", p, ok)
+	}
+	if got, err := p.Greet("Ann"); err != nil || got != "hi Ann" {
+		t.Fatalf(`Greet("Ann") = %q, %v`, got, err)
+	}
+}
+
+func TestDiscover_BadPluginsDoNotStopTheRest(t *testing.T) {
+	t.Setenv("HOST_TEST_NAME", "test")
+	dir := t.TempDir()
+	copyTestBinary(t, dir, "a-plugin")
+	copyTestBinary(t, dir, "b-duplicate") // same name as a-plugin
+	os.WriteFile(filepath.Join(dir, "broken.so"), []byte("not an ELF file"), 0o644)
+
+	h, err := discover(t, dir)
+	if h == nil {
+		t.Fatalf("Discover = nil, %v", err)
+	}
+	if err == nil {
+		t.Fatal("Discover succeeded; want errors for broken.so and the duplicate")
+	}
+	for _, want := range []string{"broken.so", `"test" is already loaded`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+	if len(h.Plugins) != 1 || h.Plugins[0].Path != filepath.Join(dir, "a-plugin") {
+		t.Fatalf("Plugins = %+v; want only a-plugin", h.Plugins)
+	}
+}
+
+func TestDiscover_MissingDir(t *testing.T) {
+	h, err := Discover(context.Background(), filepath.Join(t.TempDir(), "nope"), Options{})
+	if h != nil || !os.IsNotExist(err) {
+		t.Fatalf("Discover = %v, %v; want nil and not-exist", h, err)
+	}
+}
@@ -0,0 +1,64 @@
+// Demonstrates two ways to extend a Go program with plugins, behind one
+// Greeter interface.
+//
+// This example shows:
+// - Go plugins: shared objects loaded with plugin.Open into the host
+// - Process plugins: separate programs speaking JSON lines on stdin/stdout
+// - A handshake with a magic cookie and a protocol version
+// - A host that discovers both kinds in a directory and treats them alike
+//
+// Build the plugins first:
+//
+//	go build -buildmode=plugin -o bin/hello.so ./plugins/hello
+//	go build -o bin/shout ./plugins/shout
+//	go run . -dir bin Ann Bob
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"time"
+
+	"golang_roadmap/09_rpc/09_plugins/host"
+)
+
+func main() {
+	dir := flag.String("dir", "bin", "directory to load plugins from")
+	timeout := flag.Duration("timeout", 5*time.Second, "per-call timeout for plugin processes")
+	flag.Parse()
+	names := flag.Args()
+	if len(names) == 0 {
+		names = []string{"Gopher"}
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	h, err := host.Discover(ctx, *dir, host.Options{Stderr: os.Stderr, Timeout: *timeout})
+	if h == nil {
+		log.Fatal(err)
+	}
+	defer h.Close()
+	if err != nil {
+		// Some plugins failed to load; the rest still work.
+		log.Printf("loading plugins: %v", err)
+	}
+	if len(h.Plugins) == 0 {
+		log.Fatalf("no plugins in %s; see the README for how to build them", *dir)
+	}
+
+	for _, p := range h.Plugins {
+		info := p.Info()
+		fmt.Printf("%s %s (%s, %s)\n", info.Name, info.Version, p.Kind, p.Path)
+		for _, name := range names {
+			greeting, err := p.Greet(name)
+			if err != nil {
+				fmt.Printf("  %s: error: %v\n", name, err)
+				continue
+			}
+			fmt.Printf("  %s\n", greeting)
+		}
+	}
+}
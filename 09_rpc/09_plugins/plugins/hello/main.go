@@ -0,0 +1,28 @@
+// Command hello is a Greeter plugin built as a Go shared object:
+//
+//	go build -buildmode=plugin -o bin/hello.so ./plugins/hello
+//
+// A plugin is a main package, and its main function is never called.
+package main
+
+import (
+	"fmt"
+
+	"golang_roadmap/09_rpc/09_plugins/greeter"
+)
+
+type hello struct{}
+
+func (hello) Info() greeter.Info { return greeter.Info{Name: "hello", Version: "1.0.0"} }
+
+func (hello) Greet(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("hello needs a name")
+	}
+	return "Hello, " + name + "!", nil
+}
+
+// Greeter is what the host looks up.
+var Greeter greeter.Greeter = hello{}
+
+func main() {}
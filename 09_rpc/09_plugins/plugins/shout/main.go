@@ -0,0 +1,37 @@
+// Command shout is a Greeter plugin run as a separate process:
+//
+//	go build -o bin/shout ./plugins/shout
+//
+// It is an ordinary program. The host starts it, and subproc.Serve
+// answers the host's requests on stdin and stdout.
+package main
+
+import (
+	"fmt"
+	"log"
+	"strings"
+
+	"golang_roadmap/09_rpc/09_plugins/greeter"
+	"golang_roadmap/09_rpc/09_plugins/subproc"
+)
+
+type shout struct{}
+
+func (shout) Info() greeter.Info { return greeter.Info{Name: "shout", Version: "1.2.0"} }
+
+func (shout) Greet(name string) (string, error) {
+	if name == "" {
+		return "", fmt.Errorf("shout needs a name")
+	}
+	// Logs go to stderr, which the host passes on; stdout is the protocol.
+	log.Printf("shouting at %s", name)
+	return "HELLO, " + strings.ToUpper(name) + "!", nil
+}
+
+func main() {
+	log.SetPrefix("shout: ")
+	log.SetFlags(0)
+	if err := subproc.Serve(shout{}); err != nil {
+		log.Fatal(err)
+	}
+}
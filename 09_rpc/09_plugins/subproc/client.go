@@ -0,0 +1,200 @@
+package subproc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"sync"
+	"time"
+
+	"golang_roadmap/09_rpc/09_plugins/greeter"
+)
+
+// Client is a running plugin process. It implements greeter.Greeter, and
+// is safe for concurrent use.
+type Client struct {
+	// Timeout bounds each call; a plugin that does not answer in time is
+	// assumed hung. Zero means 10 seconds.
+	Timeout time.Duration
+
+	path  string
+	cmd   *exec.Cmd
+	stdin io.WriteCloser
+	info  greeter.Info
+
+	mu      sync.Mutex
+	enc     *json.Encoder
+	nextID  uint64
+	pending map[uint64]chan response
+	err     error         // why the plugin stopped answering
+	done    chan struct{} // closed when it has
+}
+
+// Start runs the plugin at path and waits for its handshake until ctx is
+// done. The plugin's stderr goes to stderr, which may be nil.
+func Start(ctx context.Context, path string, stderr io.Writer) (*Client, error) {
+	cmd := exec.Command(path)
+	cmd.Env = append(os.Environ(), MagicCookieKey+"="+MagicCookieValue)
+	cmd.Stderr = stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return nil, err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return nil, err
+	}
+	if err := cmd.Start(); err != nil {
+		return nil, err
+	}
+	c := &Client{
+		path:    path,
+		cmd:     cmd,
+		stdin:   stdin,
+		enc:     json.NewEncoder(stdin),
+		pending: map[uint64]chan response{},
+		done:    make(chan struct{}),
+	}
+
+	sc := bufio.NewScanner(stdout)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	hsDone := make(chan error, 1)
+	go func() {
+		var hs handshake
+		switch {
+		case !sc.Scan():
+			hsDone <- fmt.Errorf("plugin exited before its handshake: %v", cmp(sc.Err(), io.EOF))
+		case json.Unmarshal(sc.Bytes(), &hs) != nil:
+			hsDone <- fmt.Errorf("plugin's first line is not a handshake: %.80q", sc.Text())
+		case hs.Protocol != Protocol:
+			hsDone <- fmt.Errorf("plugin speaks protocol %d; this host speaks %d", hs.Protocol, Protocol)
+		default:
+			c.info = greeter.Info{Name: hs.Info.Name, Version: hs.Info.Version}
+			hsDone <- nil
+			c.read(sc)
+		}
+	}()
+	select {
+	case err = <-hsDone:
+	case <-ctx.Done():
+		err = fmt.Errorf("no handshake: %w", ctx.Err())
+	}
+	if err != nil {
+		cmd.Process.Kill()
+		cmd.Wait()
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return c, nil
+}
+
+// read delivers responses to their callers until the plugin's stdout
+// closes, then fails every call still waiting.
+func (c *Client) read(sc *bufio.Scanner) {
+	var err error
+	for sc.Scan() {
+		var resp response
+		if err = json.Unmarshal(sc.Bytes(), &resp); err != nil {
+			err = fmt.Errorf("bad response: %w", err)
+			break
+		}
+		c.mu.Lock()
+		ch := c.pending[resp.ID]
+		delete(c.pending, resp.ID)
+		c.mu.Unlock()
+		if ch != nil {
+			ch <- resp
+		}
+	}
+	if err == nil {
+		err = cmp(sc.Err(), io.EOF)
+	}
+	c.mu.Lock()
+	c.err = fmt.Errorf("plugin %s stopped: %w", c.path, err)
+	c.pending = nil
+	c.mu.Unlock()
+	close(c.done)
+}
+
+func (c *Client) Info() greeter.Info { return c.info }
+
+func (c *Client) Greet(name string) (string, error) {
+	params, _ := json.Marshal(greetParams{Name: name})
+	resp, err := c.call("greet", params)
+	if err != nil {
+		return "", err
+	}
+	if resp.Error != "" {
+		return "", errors.New(resp.Error)
+	}
+	return resp.Result, nil
+}
+
+func (c *Client) call(method string, params json.RawMessage) (response, error) {
+	ch := make(chan response, 1)
+	c.mu.Lock()
+	if c.pending == nil {
+		err := c.err
+		c.mu.Unlock()
+		return response{}, err
+	}
+	c.nextID++
+	id := c.nextID
+	c.pending[id] = ch
+	err := c.enc.Encode(request{ID: id, Method: method, Params: params})
+	c.mu.Unlock()
+	if err != nil {
+		c.forget(id)
+		return response{}, fmt.Errorf("plugin %s: %w", c.path, err)
+	}
+
+	timeout := c.Timeout
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	select {
+	case resp := <-ch:
+		return resp, nil
+	case <-c.done:
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		return response{}, c.err
+	case <-timer.C:
+		c.forget(id)
+		return response{}, fmt.Errorf("plugin %s: %s: no answer in %v", c.path, method, timeout)
+	}
+}
+
+func (c *Client) forget(id uint64) {
+	c.mu.Lock()
+	delete(c.pending, id)
+	c.mu.Unlock()
+}
+
+// Close asks the plugin to exit by closing its stdin, and kills it if it
+// has not within two seconds.
+func (c *Client) Close() error {
+	c.stdin.Close()
+	exited := make(chan error, 1)
+	go func() { exited <- c.cmd.Wait() }()
+	select {
+	case err := <-exited:
+		return err
+	case <-time.After(2 * time.Second):
+		c.cmd.Process.Kill()
+		return <-exited
+	}
+}
+
+func cmp(err, fallback error) error {
+	if err != nil {
+		return err
+	}
+	return fallback
+}
@@ -0,0 +1,63 @@
+// Package subproc runs a Greeter in a separate process and talks to it
+// over the process's stdin and stdout, in the way of hashicorp/go-plugin:
+//
+//	host                                   plugin
+//	starts it with the magic cookie set ─▶ checks the cookie
+//	                                   ◀─ {"protocol":1,"info":{"name":"shout",...}}
+//	{"id":1,"method":"greet","params":{"name":"Ann"}} ─▶
+//	                                   ◀─ {"id":1,"result":"HELLO, ANN!"}
+//	closes stdin                      ─▶ exits
+//
+// Each message is one line of JSON. Requests carry an ID, so several can
+// be in flight and answered in any order. The plugin's stderr is its log,
+// and the host passes it on.
+//
+// A plugin can be written in any language that reads and writes lines,
+// and it can crash without taking the host with it. The cost is a process
+// per plugin and a round trip per call.
+package subproc
+
+import (
+	"encoding/json"
+	"errors"
+)
+
+// Protocol is the version of the messages below. A host refuses a plugin
+// that speaks another one, rather than misread it.
+const Protocol = 1
+
+// The magic cookie is not security: it stops someone running a plugin
+// binary by hand and getting a JSON prompt, and a host running a program
+// that is not a plugin from waiting on it forever.
+const (
+	MagicCookieKey   = "GREETER_PLUGIN"
+	MagicCookieValue = "d1c8e5a7-greeter"
+)
+
+// ErrNotPlugin is returned by Serve when the program was not started by a
+// host.
+var ErrNotPlugin = errors.New("this program is a greeter plugin; it is started by the host, not run directly")
+
+type handshake struct {
+	Protocol int `json:"protocol"`
+	Info     struct {
+		Name    string `json:"name"`
+		Version string `json:"version"`
+	} `json:"info"`
+}
+
+type request struct {
+	ID     uint64          `json:"id"`
+	Method string          `json:"method"`
+	Params json.RawMessage `json:"params"`
+}
+
+type response struct {
+	ID     uint64 `json:"id"`
+	Result string `json:"result,omitempty"`
+	Error  string `json:"error,omitempty"`
+}
+
+type greetParams struct {
+	Name string `json:"name"`
+}
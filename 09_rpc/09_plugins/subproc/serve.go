@@ -0,0 +1,86 @@
+package subproc
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+
+	"golang_roadmap/09_rpc/09_plugins/greeter"
+)
+
+// Serve is the main loop of a plugin program:
+//
+//	func main() {
+//		if err := subproc.Serve(shout{}); err != nil {
+//			log.Fatal(err)
+//		}
+//	}
+//
+// It answers requests on stdin until the host closes it. Anything the
+// plugin wants to log goes to stderr; stdout belongs to the protocol.
+func Serve(g greeter.Greeter) error {
+	if os.Getenv(MagicCookieKey) != MagicCookieValue {
+		return ErrNotPlugin
+	}
+	return serve(g, os.Stdin, os.Stdout)
+}
+
+func serve(g greeter.Greeter, in io.Reader, out io.Writer) error {
+	var mu sync.Mutex // one response line at a time
+	enc := json.NewEncoder(out)
+	send := func(v any) error {
+		mu.Lock()
+		defer mu.Unlock()
+		return enc.Encode(v)
+	}
+
+	var hs handshake
+	hs.Protocol = Protocol
+	info := g.Info()
+	hs.Info.Name, hs.Info.Version = info.Name, info.Version
+	if err := send(hs); err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+	sc := bufio.NewScanner(in)
+	sc.Buffer(make([]byte, 64<<10), 1<<20)
+	for sc.Scan() {
+		var req request
+		if err := json.Unmarshal(sc.Bytes(), &req); err != nil {
+			return fmt.Errorf("bad request from the host: %w", err)
+		}
+		// Each request in its own goroutine: a slow call does not hold up
+		// the others.
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			send(handle(g, req))
+		}()
+	}
+	return sc.Err()
+}
+
+func handle(g greeter.Greeter, req request) response {
+	resp := response{ID: req.ID}
+	switch req.Method {
+	case "greet":
+		var p greetParams
+		if err := json.Unmarshal(req.Params, &p); err != nil {
+			resp.Error = "greet: " + err.Error()
+			break
+		}
+		greeting, err := g.Greet(p.Name)
+		if err != nil {
+			resp.Error = err.Error()
+		}
+		resp.Result = greeting
+	default:
+		resp.Error = fmt.Sprintf("unknown method %q", req.Method)
+	}
+	return resp
+}
@@ -0,0 +1,210 @@
+package subproc
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang_roadmap/09_rpc/09_plugins/greeter"
+)
+
+// The test binary doubles as the plugin: started by a test with
+// SUBPROC_TEST_PLUGIN set, it serves instead of running the tests.
+func TestMain(m *testing.M) {
+	switch os.Getenv("SUBPROC_TEST_PLUGIN") {
+	case "":
+		os.Exit(m.Run())
+	case "greeter":
+		if err := Serve(testGreeter{}); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+	case "wrong-protocol":
+		fmt.Println(`{"protocol":99,"info":{"name":"future"}}`)
+		io.Copy(io.Discard, os.Stdin)
+	case "silent":
+		io.Copy(io.Discard, os.Stdin)
+	}
+	os.Exit(0)
+}
+
+type testGreeter struct{}
+
+func (testGreeter) Info() greeter.Info { return greeter.Info{Name: "test", Version: "0.1.0"} }
+
+func (testGreeter) Greet(name string) (string, error) {
+	switch name {
+	case "":
+		return "", errors.New("no name")
+	case "crash":
+		os.Exit(3)
+	case "slow":
+		time.Sleep(time.Second)
+	}
+	return "hi " + name, nil
+}
+
+func startTestPlugin(t *testing.T, mode string) (*Client, error) {
+	t.Helper()
+	t.Setenv("SUBPROC_TEST_PLUGIN", mode)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	c, err := Start(ctx, os.Args[0], nil)
+	if err == nil {
+		t.Cleanup(func() { c.Close() })
+	}
+	return c, err
+}
+
+func TestServe_NotStartedByHost(t *testing.T) {
+	t.Setenv(MagicCookieKey, "")
+	if err := Serve(testGreeter{}); !errors.Is(err, ErrNotPlugin) {
+		t.Fatalf("Serve = %v; want ErrNotPlugin", err)
+	}
+}
+
+func TestServe_Protocol(t *testing.T) {
+	in := strings.Join([]string{
+		`{"id":1,"method":"greet","params":{"name":"Ann"}}`,
+		`{"id":2,"method":"greet","params":{"name":""}}`,
+		`{"id":3,"method":"wave","params":{}}`,
+	}, "\n")
+	var out strings.Builder
+	if err := serve(testGreeter{}, strings.NewReader(in), &out); err != nil {
+		t.Fatal(err)
+	}
+
+	sc := bufio.NewScanner(strings.NewReader(out.String()))
+	if !sc.Scan() {
+		t.Fatal("no handshake")
+	}
+	var hs handshake
+	if err := json.Unmarshal(sc.Bytes(), &hs); err != nil || hs.Protocol != Protocol || hs.Info.Name != "test" {
+		t.Fatalf("handshake = %s (%v)", sc.Text(), err)
+	}
+	// Responses come back in any order; match them by ID.
+	got := map[uint64]response{}
+	for sc.Scan() {
+		var resp response
+		if err := json.Unmarshal(sc.Bytes(), &resp); err != nil {
+			t.Fatal(err)
+		}
+		got[resp.ID] = resp
+	}
+	want := map[uint64]response{
+		1: {ID: 1, Result: "hi Ann"},
+		2: {ID: 2, Error: "no name"},
+		3: {ID: 3, Error: `unknown method "wave"`},
+	}
+	for id, w := range want {
+		if got[id] != w {
+			t.Errorf("response %d = %+v; want %+v", id, got[id], w)
+		}
+	}
+}
+
+func TestClient(t *testing.T) {
+	c, err := startTestPlugin(t, "greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info := c.Info(); info != (greeter.Info{Name: "test", Version: "0.1.0"}) {
+		t.Errorf("Info = %+v", info)
+	}
+	if got, err := c.Greet("Ann"); err != nil || got != "hi Ann" {
+		t.Errorf(`Greet("Ann") = %q, %v`, got, err)
+	}
+	if _, err := c.Greet(""); err == nil || err.Error() != "no name" {
+		t.Errorf(`Greet("") error = %v; want the plugin's "no name"`, err)
+	}
+
+	// Concurrent calls share the pipe; each gets its own answer, and a
+	// slow one does not hold up the rest.
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		name := fmt.Sprint("n", i)
+		if i == 0 {
+			name = "slow"
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if got, err := c.Greet(name); err != nil || got != "hi "+name {
+				t.Errorf("Greet(%q) = %q, %v", name, got, err)
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestClient_PluginCrashes(t *testing.T) {
+	c, err := startTestPlugin(t, "greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, err = c.Greet("crash")
+	if err == nil || !strings.Contains(err.Error(), "stopped") {
+		t.Fatalf(`Greet("crash") error = %v; want the plugin stopped`, err)
+	}
+	// Later calls fail at once with the same error.
+	if _, err2 := c.Greet("Ann"); err2 == nil || err2.Error() != err.Error() {
+		t.Fatalf("Greet after crash = %v; want %v", err2, err)
+	}
+}
+
+func TestClient_Timeout(t *testing.T) {
+	c, err := startTestPlugin(t, "greeter")
+	if err != nil {
+		t.Fatal(err)
+	}
+	c.Timeout = 100 * time.Millisecond
+	if _, err := c.Greet("slow"); err == nil || !strings.Contains(err.Error(), "no answer") {
+		t.Fatalf(`Greet("slow") error = %v; want a timeout`, err)
+	}
+	// The late answer is dropped, and the plugin still works.
+	if got, err := c.Greet("Ann"); err != nil || got != "hi Ann" {
+		t.Fatalf(`Greet("Ann") after a timeout = %q, %v`, got, err)
+	}
+}
+
+func TestStart_WrongProtocol(t *testing.T) {
+	_, err := startTestPlugin(t, "wrong-protocol")
+	if err == nil || !strings.Contains(err.Error(), "protocol 99") {
+		t.Fatalf("Start error = %v; want a protocol mismatch", err)
+	}
+}
+
+func TestStart_NoHandshake(t *testing.T) {
+	start := time.Now()
+	_, err := startTestPlugin(t, "silent")
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Start error = %v; want the context's deadline", err)
+	}
+	if d := time.Since(start); d > 5*time.Second {
+		t.Fatalf("Start took %v", d)
+	}
+}
+
+func TestStart_NotAPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("needs a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "tool")
+	if err := os.WriteFile(path, []byte("#!/bin/sh\necho usage: tool FILE\n"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	_, err := Start(context.Background(), path, nil)
+	if err == nil || !strings.Contains(err.Error(), "not a handshake") {
+		t.Fatalf("Start error = %v; want not a handshake", err)
+	}
+}
@@ -0,0 +1,7 @@
+module golang_roadmap/09_rpc/10_wasm
+
+go 1.24.11
+
+require github.com/tetratelabs/wazero v1.11.0
+
+require golang.org/x/sys v0.38.0 // indirect
@@ -0,0 +1,44 @@
+//go:build js && wasm
+
+// Command js is package wordfreq compiled for the browser:
+//
+//	GOOS=js GOARCH=wasm go build -o web/wordfreq.wasm ./guest/js
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" web/
+//
+// It registers a global JavaScript function,
+//
+//	wordFreq(text, n) → [{word: "the", count: 3}, ...]
+//
+// and then blocks: a Go program under GOOS=js lives as long as main does,
+// and JavaScript can call into it only while it is alive. syscall/js
+// converts between Go and JavaScript values.
+package main
+
+import (
+	"syscall/js"
+
+	"golang_roadmap/09_rpc/10_wasm/wordfreq"
+)
+
+func main() {
+	js.Global().Set("wordFreq", js.FuncOf(func(this js.Value, args []js.Value) any {
+		if len(args) < 1 || args[0].Type() != js.TypeString {
+			return js.Global().Get("Error").New("wordFreq(text, n): text must be a string")
+		}
+		n := 10
+		if len(args) > 1 && args[1].Type() == js.TypeNumber {
+			n = args[1].Int()
+		}
+		top := wordfreq.Top(args[0].String(), n)
+		out := make([]any, len(top))
+		for i, e := range top {
+			out[i] = map[string]any{"word": e.Word, "count": e.Count}
+		}
+		return out
+	}))
+	// Tell the page the function is ready; it may have been waiting.
+	if ready := js.Global().Get("onWordFreqReady"); ready.Type() == js.TypeFunction {
+		ready.Invoke()
+	}
+	select {}
+}
@@ -0,0 +1,88 @@
+//go:build wasip1
+
+// Command wasi is package wordfreq as a WASI reactor module: a library the
+// host instantiates once and then calls, rather than a program it runs.
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o bin/wordfreq.wasm ./guest/wasi
+//
+// WebAssembly functions take and return only numbers, so strings cross
+// the boundary as a pointer and a length into the module's memory:
+//
+//	host                                        module
+//	ptr := alloc(len(text))                ─▶   keeps the buffer alive
+//	writes text at ptr in module memory
+//	res := top_words(ptr, len(text), n)    ─▶   reads the text, counts,
+//	                                       ◀─   log(ptr, len) calls back into the host
+//	                                       ◀─   returns ptr<<32 | len of the JSON result
+//	reads the result, free(ptr) both       ─▶   drops the buffers
+//
+// Memory the module hands out stays the module's: the host copies out of
+// it and tells the module when it may reuse it.
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"unsafe"
+
+	"golang_roadmap/09_rpc/10_wasm/wordfreq"
+)
+
+// pinned keeps the buffers the host holds a pointer to. The Go garbage
+// collector cannot see pointers held outside the module, so without this
+// it would free them under the host.
+var pinned = map[uint32][]byte{}
+
+func pin(buf []byte) uint32 {
+	if len(buf) == 0 {
+		return 0
+	}
+	ptr := uint32(uintptr(unsafe.Pointer(unsafe.SliceData(buf))))
+	pinned[ptr] = buf
+	return ptr
+}
+
+// alloc returns a buffer of size bytes for the host to write into.
+//
+//go:wasmexport alloc
+func alloc(size uint32) uint32 {
+	return pin(make([]byte, size))
+}
+
+// free releases a buffer returned by alloc or top_words.
+//
+//go:wasmexport free
+func free(ptr uint32) {
+	delete(pinned, ptr)
+}
+
+// topWords counts the words in the size bytes at ptr and returns the n
+// most frequent as JSON, its pointer in the high 32 bits of the result and
+// its length in the low 32.
+//
+//go:wasmexport top_words
+func topWords(ptr, size, n uint32) uint64 {
+	text := ""
+	if size > 0 {
+		text = string(pinned[ptr][:size])
+	}
+	top := wordfreq.Top(text, int(n))
+	logf("counted %d bytes, returning %d words", size, len(top))
+	out, _ := json.Marshal(top)
+	return uint64(pin(out))<<32 | uint64(len(out))
+}
+
+// hostLog is provided by the host: the module has no console of its own
+// that the host reads, so it logs by calling out.
+//
+//go:wasmimport env log
+func hostLog(ptr unsafe.Pointer, size uint32)
+
+func logf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	hostLog(unsafe.Pointer(unsafe.StringData(msg)), uint32(len(msg)))
+}
+
+// main is not called in a reactor; the host calls _initialize instead,
+// which sets up the runtime and package variables.
+func main() {}
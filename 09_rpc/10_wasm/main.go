@@ -0,0 +1,106 @@
+// Demonstrates WebAssembly in both directions with one Go package, a word
+// frequency counter.
+//
+// This example shows:
+// - Go compiled to WASM for the browser (GOOS=js) and called from JavaScript
+// - Go compiled to a WASI reactor module (GOOS=wasip1, go:wasmexport)
+// - A Go host embedding that module with wazero and calling its exports
+// - The module calling back into the host (go:wasmimport)
+// - Passing strings through linear memory: alloc, pointer and length, free
+//
+// Build the modules first:
+//
+//	GOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o bin/wordfreq.wasm ./guest/wasi
+//	GOOS=js GOARCH=wasm go build -o web/wordfreq.wasm ./guest/js
+//	cp "$(go env GOROOT)/lib/wasm/wasm_exec.js" web/
+//
+// Then count words with the WASI module, or serve the browser demo:
+//
+//	go run . README.md
+//	go run . -serve localhost:8080
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"io/fs"
+	"log"
+	"net/http"
+	"os"
+	"time"
+
+	"golang_roadmap/09_rpc/10_wasm/wasmhost"
+)
+
+func main() {
+	wasmPath := flag.String("wasm", "bin/wordfreq.wasm", "WASI module built from ./guest/wasi")
+	n := flag.Int("n", 10, "number of words to show")
+	serve := flag.String("serve", "", "serve the browser demo in ./web on this address instead")
+	flag.Parse()
+
+	if *serve != "" {
+		serveWeb(*serve)
+		return
+	}
+
+	text, err := readInput(flag.Args())
+	if err != nil {
+		log.Fatal(err)
+	}
+	wasm, err := os.ReadFile(*wasmPath)
+	if errors.Is(err, fs.ErrNotExist) {
+		log.Fatalf("%s not found; build it with\n\tGOOS=wasip1 GOARCH=wasm go build -buildmode=c-shared -o %s ./guest/wasi", *wasmPath, *wasmPath)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx := context.Background()
+	start := time.Now()
+	mod, err := wasmhost.Load(ctx, wasm, os.Stderr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer mod.Close(ctx)
+	log.Printf("loaded %s (%d KiB) in %v", *wasmPath, len(wasm)>>10, time.Since(start).Round(time.Millisecond))
+
+	top, err := mod.Top(ctx, text, *n)
+	if err != nil {
+		log.Fatal(err)
+	}
+	for _, e := range top {
+		fmt.Printf("%6d  %s\n", e.Count, e.Word)
+	}
+}
+
+// readInput concatenates the named files, or reads stdin if there are none.
+func readInput(paths []string) (string, error) {
+	if len(paths) == 0 {
+		b, err := io.ReadAll(os.Stdin)
+		return string(b), err
+	}
+	var text []byte
+	for _, p := range paths {
+		b, err := os.ReadFile(p)
+		if err != nil {
+			return "", err
+		}
+		text = append(text, b...)
+		text = append(text, '\n')
+	}
+	return string(text), nil
+}
+
+// serveWeb serves ./web. The file server sends .wasm files as
+// application/wasm, which WebAssembly.instantiateStreaming requires.
+func serveWeb(addr string) {
+	if _, err := os.Stat("web/wordfreq.wasm"); err != nil {
+		log.Fatalf("web/wordfreq.wasm: %v; build it with\n\tGOOS=js GOARCH=wasm go build -o web/wordfreq.wasm ./guest/js", err)
+	}
+	srv := &http.Server{Addr: addr, Handler: http.FileServer(http.Dir("web")), ReadHeaderTimeout: 5 * time.Second}
+	log.Printf("browser demo on http://%s/", addr)
+	log.Fatal(srv.ListenAndServe())
+}
@@ -0,0 +1,138 @@
+// Package wasmhost runs the WASI word-frequency module (guest/wasi) inside
+// a Go program with wazero, a WebAssembly runtime in pure Go: no cgo, no
+// shared libraries.
+//
+// The module is sandboxed. It sees only its own memory and the functions
+// the host gives it: here WASI (for the Go runtime's clock and random
+// numbers) and env.log. It cannot open files or sockets, and a bug in it
+// traps the call, not the host.
+package wasmhost
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sync"
+
+	"github.com/tetratelabs/wazero"
+	"github.com/tetratelabs/wazero/api"
+	"github.com/tetratelabs/wazero/imports/wasi_snapshot_preview1"
+
+	"golang_roadmap/09_rpc/10_wasm/wordfreq"
+)
+
+// Module is an instantiated word-frequency module. A WebAssembly instance
+// runs one call at a time, so Module serializes them; it is safe for
+// concurrent use.
+type Module struct {
+	runtime wazero.Runtime
+
+	mu       sync.Mutex
+	mod      api.Module
+	alloc    api.Function
+	free     api.Function
+	topWords api.Function
+}
+
+// Load compiles and instantiates the module in wasm. The module's log
+// calls go to logw, which may be nil.
+func Load(ctx context.Context, wasm []byte, logw io.Writer) (*Module, error) {
+	if logw == nil {
+		logw = io.Discard
+	}
+	logger := log.New(logw, "wasm: ", 0)
+
+	r := wazero.NewRuntime(ctx)
+	m := &Module{runtime: r}
+	ok := false
+	defer func() {
+		if !ok {
+			r.Close(ctx)
+		}
+	}()
+
+	wasi_snapshot_preview1.MustInstantiate(ctx, r)
+	// The other direction: a Go function the module imports and calls.
+	_, err := r.NewHostModuleBuilder("env").
+		NewFunctionBuilder().
+		WithFunc(func(ctx context.Context, mod api.Module, ptr, size uint32) {
+			msg, ok := mod.Memory().Read(ptr, size)
+			if !ok {
+				logger.Printf("log: %d bytes at %#x are out of range", size, ptr)
+				return
+			}
+			logger.Print(string(msg))
+		}).
+		Export("log").
+		Instantiate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// A reactor module: instantiating runs _initialize, which starts the
+	// Go runtime; main is never called.
+	cfg := wazero.NewModuleConfig().WithStartFunctions("_initialize")
+	m.mod, err = r.InstantiateWithConfig(ctx, wasm, cfg)
+	if err != nil {
+		return nil, fmt.Errorf("instantiate: %w", err)
+	}
+	for name, fn := range map[string]*api.Function{"alloc": &m.alloc, "free": &m.free, "top_words": &m.topWords} {
+		if *fn = m.mod.ExportedFunction(name); *fn == nil {
+			return nil, fmt.Errorf("module does not export %s; was it built from guest/wasi with -buildmode=c-shared?", name)
+		}
+	}
+	ok = true
+	return m, nil
+}
+
+// Top calls the module's top_words: the n most frequent words in text.
+func (m *Module) Top(ctx context.Context, text string, n int) ([]wordfreq.Entry, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if m.mod == nil {
+		return nil, errors.New("wasmhost: module is closed")
+	}
+
+	// Copy the text into the module's memory.
+	var ptr uint32
+	if len(text) > 0 {
+		res, err := m.alloc.Call(ctx, uint64(len(text)))
+		if err != nil {
+			return nil, fmt.Errorf("alloc: %w", err)
+		}
+		ptr = uint32(res[0])
+		defer m.free.Call(ctx, uint64(ptr))
+		if !m.mod.Memory().WriteString(ptr, text) {
+			return nil, fmt.Errorf("alloc returned %#x, out of range for %d bytes", ptr, len(text))
+		}
+	}
+
+	res, err := m.topWords.Call(ctx, uint64(ptr), uint64(len(text)), uint64(n))
+	if err != nil {
+		return nil, fmt.Errorf("top_words: %w", err)
+	}
+	outPtr, outLen := uint32(res[0]>>32), uint32(res[0])
+	defer m.free.Call(ctx, uint64(outPtr))
+	// Read returns a view of the module's memory, valid only until the
+	// module runs again; Unmarshal copies what it needs before free does.
+	out, ok := m.mod.Memory().Read(outPtr, outLen)
+	if !ok {
+		return nil, fmt.Errorf("top_words returned %d bytes at %#x, out of range", outLen, outPtr)
+	}
+	var top []wordfreq.Entry
+	if err := json.Unmarshal(out, &top); err != nil {
+		return nil, fmt.Errorf("top_words: %w", err)
+	}
+	return top, nil
+}
+
+// Close releases the module and its runtime.
+func (m *Module) Close(ctx context.Context) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.mod = nil
+	return m.runtime.Close(ctx)
+}
@@ -0,0 +1,133 @@
+package wasmhost
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+
+	"golang_roadmap/09_rpc/10_wasm/wordfreq"
+)
+
+var (
+	buildOnce sync.Once
+	wasmBytes []byte
+	buildErr  error
+)
+
+// guestWasm builds guest/wasi once per test run, the way the README does.
+func guestWasm(t *testing.T) []byte {
+	t.Helper()
+	if testing.Short() {
+		t.Skip("builds the WASI module")
+	}
+	goTool, err := exec.LookPath("go")
+	if err != nil {
+		t.Skip("no go command to build the WASI module")
+	}
+	buildOnce.Do(func() {
+		dir, err := os.MkdirTemp("", "wasmhost")
+		if err != nil {
+			buildErr = err
+			return
+		}
+		defer os.RemoveAll(dir)
+		out := filepath.Join(dir, "wordfreq.wasm")
+		cmd := exec.Command(goTool, "build", "-buildmode=c-shared", "-o", out, "../guest/wasi")
+		cmd.Env = append(os.Environ(), "GOOS=wasip1", "GOARCH=wasm")
+		if msg, err := cmd.CombinedOutput(); err != nil {
+			buildErr = fmt.Errorf("%v\n%s", err, msg)
+			return
+		}
+		wasmBytes, buildErr = os.ReadFile(out)
+	})
+	if buildErr != nil {
+		t.Fatalf("building guest/wasi: %v", buildErr)
+	}
+	return wasmBytes
+}
+
+func TestModule_Top(t *testing.T) {
+	ctx := context.Background()
+	var logs bytes.Buffer
+	m, err := Load(ctx, guestWasm(t), &logs)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(ctx)
+
+	// The module must agree with the same package run natively.
+	texts := []string{
+		"",
+		"the cat and the dog and the bird",
+		"Grüße, grüße! don't stop",
+		strings.Repeat("go wasm wazero go ", 10000),
+	}
+	for _, text := range texts {
+		got, err := m.Top(ctx, text, 3)
+		if err != nil {
+			t.Fatalf("Top(%.20q): %v", text, err)
+		}
+		want := wordfreq.Top(text, 3)
+		if len(got) == 0 && len(want) == 0 {
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("Top(%.20q) = %v; want %v", text, got, want)
+		}
+	}
+
+	// The module called back into the host for each call.
+	if n := strings.Count(logs.String(), "wasm: counted"); n != len(texts) {
+		t.Errorf("got %d log lines from the module; want %d:\n%s", n, len(texts), logs.String())
+	}
+}
+
+func TestModule_Concurrent(t *testing.T) {
+	ctx := context.Background()
+	m, err := Load(ctx, guestWasm(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer m.Close(ctx)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				top, err := m.Top(ctx, "a b b c c c", 1)
+				if err != nil || len(top) != 1 || top[0] != (wordfreq.Entry{Word: "c", Count: 3}) {
+					t.Errorf("Top = %v, %v", top, err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func TestModule_Closed(t *testing.T) {
+	ctx := context.Background()
+	m, err := Load(ctx, guestWasm(t), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m.Close(ctx)
+	if _, err := m.Top(ctx, "a", 1); err == nil {
+		t.Fatal("Top after Close succeeded")
+	}
+}
+
+func TestLoad_NotWasm(t *testing.T) {
+	if _, err := Load(context.Background(), []byte("not wasm"), nil); err == nil {
+		t.Fatal("Load succeeded on garbage")
+	}
+}
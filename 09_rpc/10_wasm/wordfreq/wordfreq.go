@@ -0,0 +1,55 @@
+// Package wordfreq counts how often each word occurs in a text. It is
+// plain Go with no imports beyond the standard library's text packages,
+// so the same code builds for the host, for the browser (GOOS=js) and for
+// a WASI module (GOOS=wasip1).
+package wordfreq
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Entry is one word and the number of times it occurs.
+type Entry struct {
+	Word  string `json:"word"`
+	Count int    `json:"count"`
+}
+
+// Count returns the number of times each word occurs in text. A word is a
+// run of letters, digits and apostrophes, compared case-insensitively:
+// "Go", "go" and "GO!" are the same word.
+func Count(text string) map[string]int {
+	freq := make(map[string]int)
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '\''
+	})
+	for _, w := range words {
+		w = strings.Trim(w, "'")
+		if w == "" {
+			continue
+		}
+		freq[strings.ToLower(w)]++
+	}
+	return freq
+}
+
+// Top returns the n most frequent words in text, most frequent first and
+// ties in alphabetical order. n <= 0 returns them all.
+func Top(text string, n int) []Entry {
+	freq := Count(text)
+	entries := make([]Entry, 0, len(freq))
+	for w, c := range freq {
+		entries = append(entries, Entry{Word: w, Count: c})
+	}
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Count != entries[j].Count {
+			return entries[i].Count > entries[j].Count
+		}
+		return entries[i].Word < entries[j].Word
+	})
+	if n > 0 && n < len(entries) {
+		entries = entries[:n]
+	}
+	return entries
+}
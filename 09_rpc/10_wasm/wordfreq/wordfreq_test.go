@@ -0,0 +1,43 @@
+package wordfreq
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestCount(t *testing.T) {
+	tests := []struct {
+		text string
+		want map[string]int
+	}{
+		{"", map[string]int{}},
+		{"  \n\t ", map[string]int{}},
+		{"go Go GO!", map[string]int{"go": 3}},
+		{"don't 'quote' it's", map[string]int{"don't": 1, "quote": 1, "it's": 1}},
+		{"Go 1.24, go1.24", map[string]int{"go": 1, "1": 1, "24": 2, "go1": 1}},
+		{"Grüße, grüße", map[string]int{"grüße": 2}},
+		{"' '' '", map[string]int{}},
+	}
+	for _, tt := range tests {
+		if got := Count(tt.text); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("Count(%q) = %v; want %v", tt.text, got, tt.want)
+		}
+	}
+}
+
+func TestTop(t *testing.T) {
+	text := "the cat and the dog and the bird"
+	want := []Entry{{"the", 3}, {"and", 2}, {"bird", 1}}
+	if got := Top(text, 3); !reflect.DeepEqual(got, want) {
+		t.Errorf("Top(3) = %v; want %v", got, want)
+	}
+	if got := Top(text, 0); len(got) != 5 {
+		t.Errorf("Top(0) = %v; want all 5 words", got)
+	}
+	if got := Top(text, 100); len(got) != 5 {
+		t.Errorf("Top(100) = %v; want all 5 words", got)
+	}
+	if got := Top("", 3); len(got) != 0 {
+		t.Errorf(`Top("") = %v; want none`, got)
+	}
+}
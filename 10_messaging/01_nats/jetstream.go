@@ -0,0 +1,75 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// JetStream adds persistence on top of core NATS: messages on a stream's
+// subjects are stored, and consumers read them at their own pace with
+// acknowledgements. Unacknowledged messages are redelivered, which makes
+// delivery at-least-once, so handlers must be idempotent.
+
+// errRetry marks a temporary failure: the message should be redelivered.
+var errRetry = errors.New("temporary failure")
+
+func isRetryable(err error) bool { return errors.Is(err, errRetry) }
+
+// setupOrders creates (or updates) the ORDERS stream and a durable pull
+// consumer on it.
+func setupOrders(ctx context.Context, js jetstream.JetStream, ackWait time.Duration) (jetstream.Consumer, error) {
+	_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:     "ORDERS",
+		Subjects: []string{"orders.>"},
+		Storage:  jetstream.FileStorage,
+		MaxAge:   24 * time.Hour,
+	})
+	if err != nil {
+		return nil, err
+	}
+	// Durable: the consumer's position survives restarts of the client.
+	return js.CreateOrUpdateConsumer(ctx, "ORDERS", jetstream.ConsumerConfig{
+		Durable:       "order-processor",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       ackWait, // redeliver if not acked within this time
+		MaxDeliver:    5,       // then give up (see Term below)
+		FilterSubject: "orders.created",
+	})
+}
+
+// publishOrder stores an order event. The returned ack means the stream has
+// persisted it; core nc.Publish gives no such guarantee.
+func publishOrder(ctx context.Context, js jetstream.JetStream, id string) (*jetstream.PubAck, error) {
+	// The message ID makes the publish idempotent: a retry with the same ID
+	// within the stream's duplicate window is stored once.
+	return js.Publish(ctx, "orders.created", []byte(id), jetstream.WithMsgID(id))
+}
+
+// processOrders consumes messages and acknowledges them according to
+// handle's result:
+//
+//	nil            -> Ack: done, never redelivered
+//	wraps errRetry -> Nak: redeliver (after a short delay)
+//	other errors   -> Term: poison message, stop redelivering
+func processOrders(cons jetstream.Consumer, handle func(id string, attempt uint64) error) (jetstream.ConsumeContext, error) {
+	return cons.Consume(func(msg jetstream.Msg) {
+		md, err := msg.Metadata()
+		if err != nil {
+			log.Printf("metadata: %v", err)
+			return
+		}
+		switch err := handle(string(msg.Data()), md.NumDelivered); {
+		case err == nil:
+			msg.Ack()
+		case isRetryable(err):
+			msg.NakWithDelay(50 * time.Millisecond)
+		default:
+			log.Printf("order %s: giving up: %v", msg.Data(), err)
+			msg.Term()
+		}
+	})
+}
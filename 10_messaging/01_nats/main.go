@@ -0,0 +1,160 @@
+// Demonstrates messaging with NATS, using an embedded server.
+//
+// This example shows:
+// - Publish/subscribe fan-out to every subscriber
+// - Queue groups that load-balance messages across workers
+// - Request/reply, including the no-responders case
+// - JetStream persistence: messages published before the consumer runs
+// - Explicit acks, Nak-driven redelivery and Term for poison messages
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func main() {
+	storeDir, err := os.MkdirTemp("", "nats-js-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(storeDir)
+
+	ns, err := runEmbeddedServer(4222, storeDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ns.Shutdown()
+	log.Println("Embedded NATS server on", ns.ClientURL())
+
+	nc, err := connect(ns, "demo")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer nc.Drain()
+
+	fmt.Println("\n=== Pub/sub: every subscriber gets every message ===")
+	var wg sync.WaitGroup
+	for _, name := range []string{"audit", "metrics"} {
+		nc.Subscribe("users.created", func(msg *nats.Msg) {
+			fmt.Printf("  %-7s got %s\n", name, msg.Data)
+			wg.Done()
+		})
+	}
+	nc.Flush()
+	wg.Add(2 * 2)
+	nc.Publish("users.created", []byte("alice"))
+	nc.Publish("users.created", []byte("bob"))
+	wg.Wait()
+
+	fmt.Println("\n=== Queue group: each job goes to one worker ===")
+	var mu sync.Mutex
+	perWorker := map[int]int{}
+	wg.Add(9)
+	subs, err := startWorkers(nc, "jobs.resize", "resizers", 3, func(worker int, msg *nats.Msg) {
+		mu.Lock()
+		perWorker[worker]++
+		mu.Unlock()
+		wg.Done()
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	for i := 1; i <= 9; i++ {
+		nc.Publish("jobs.resize", []byte(fmt.Sprintf("image-%d", i)))
+	}
+	wg.Wait()
+	fmt.Printf("  jobs per worker: %v (9 jobs, none duplicated)\n", perWorker)
+	for _, s := range subs {
+		s.Unsubscribe()
+	}
+
+	fmt.Println("\n=== Request/reply ===")
+	if _, err := startResponder(nc, "greet", func(data []byte) []byte {
+		return []byte("hello, " + string(data))
+	}); err != nil {
+		log.Fatal(err)
+	}
+	reply, err := request(nc, "greet", []byte("gopher"), time.Second)
+	fmt.Printf("  reply: %q err=%v\n", reply, err)
+	_, err = request(nc, "nobody.home", nil, time.Second)
+	fmt.Printf("  no responder: %v (immediately, not after the timeout)\n", err)
+
+	fmt.Println("\n=== JetStream: persistence, acks and redelivery ===")
+	js, err := jetstream.New(nc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx := context.Background()
+	cons, err := setupOrders(ctx, js, time.Second)
+	if err != nil {
+		log.Fatal(err)
+	}
+	// Published while nothing consumes: stored, not lost.
+	for _, id := range []string{"order-1", "order-2", "order-3", "order-1"} {
+		ack, err := publishOrder(ctx, js, id)
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("  published %s: stream=%s seq=%d duplicate=%v\n", id, ack.Stream, ack.Sequence, ack.Duplicate)
+	}
+
+	done := make(chan struct{})
+	var finished sync.Map
+	cc, err := processOrders(cons, func(id string, attempt uint64) error {
+		var err error
+		switch {
+		case id == "order-2" && attempt == 1:
+			err = fmt.Errorf("payment service busy: %w", errRetry)
+		case id == "order-3":
+			err = errors.New("malformed order")
+		}
+		result := "ok"
+		if err != nil {
+			result = err.Error()
+		}
+		fmt.Printf("  %s attempt %d: %s\n", id, attempt, result)
+		if err == nil || !isRetryable(err) {
+			finished.Store(id, true)
+			if n := countKeys(&finished); n == 3 {
+				close(done)
+			}
+		}
+		return err
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		log.Fatal("timed out waiting for orders")
+	}
+	cc.Stop()
+
+	// Ack is fire-and-forget; give the server a moment to record the last one.
+	info, err := cons.Info(ctx)
+	for i := 0; err == nil && info.NumAckPending > 0 && i < 20; i++ {
+		time.Sleep(10 * time.Millisecond)
+		info, err = cons.Info(ctx)
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	fmt.Printf("  consumer: delivered=%d redelivered=%d ackPending=%d pending=%d\n",
+		info.Delivered.Consumer, info.Delivered.Consumer-info.Delivered.Stream, info.NumAckPending, info.NumPending)
+}
+
+func countKeys(m *sync.Map) int {
+	n := 0
+	m.Range(func(_, _ any) bool { n++; return true })
+	return n
+}
@@ -0,0 +1,238 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func newTestConn(t *testing.T) *nats.Conn {
+	t.Helper()
+	ns, err := runEmbeddedServer(-1, t.TempDir())
+	if err != nil {
+		t.Fatalf("runEmbeddedServer: %v", err)
+	}
+	t.Cleanup(ns.Shutdown)
+	nc, err := connect(ns, t.Name())
+	if err != nil {
+		t.Fatalf("connect: %v", err)
+	}
+	t.Cleanup(nc.Close)
+	return nc
+}
+
+func TestPubSubFanOut(t *testing.T) {
+	nc := newTestConn(t)
+	a, _ := nc.SubscribeSync("events")
+	b, _ := nc.SubscribeSync("events")
+	nc.Flush()
+
+	if err := nc.Publish("events", []byte("hi")); err != nil {
+		t.Fatal(err)
+	}
+	for _, sub := range []*nats.Subscription{a, b} {
+		msg, err := sub.NextMsg(time.Second)
+		if err != nil || string(msg.Data) != "hi" {
+			t.Fatalf("subscriber got %v, %v; want hi", msg, err)
+		}
+	}
+}
+
+func TestQueueGroupDeliversEachMessageOnce(t *testing.T) {
+	nc := newTestConn(t)
+
+	const jobs = 60
+	var mu sync.Mutex
+	seen := map[string]int{}
+	workers := map[int]bool{}
+	var wg sync.WaitGroup
+	wg.Add(jobs)
+	if _, err := startWorkers(nc, "jobs", "workers", 3, func(worker int, msg *nats.Msg) {
+		mu.Lock()
+		seen[string(msg.Data)]++
+		workers[worker] = true
+		mu.Unlock()
+		wg.Done()
+	}); err != nil {
+		t.Fatalf("startWorkers: %v", err)
+	}
+
+	for i := 0; i < jobs; i++ {
+		nc.Publish("jobs", []byte(fmt.Sprint(i)))
+	}
+	wg.Wait()
+
+	mu.Lock()
+	defer mu.Unlock()
+	for job, n := range seen {
+		if n != 1 {
+			t.Fatalf("job %s delivered %d times; want once", job, n)
+		}
+	}
+	if len(seen) != jobs {
+		t.Fatalf("got %d distinct jobs; want %d", len(seen), jobs)
+	}
+	if len(workers) < 2 {
+		t.Fatalf("only workers %v received jobs; want the load spread", workers)
+	}
+}
+
+func TestRequestReply(t *testing.T) {
+	nc := newTestConn(t)
+	if _, err := startResponder(nc, "upper", func(data []byte) []byte {
+		return []byte(fmt.Sprintf("<%s>", data))
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := request(nc, "upper", []byte("x"), time.Second)
+	if err != nil || string(got) != "<x>" {
+		t.Fatalf("request = %q, %v; want <x>", got, err)
+	}
+
+	start := time.Now()
+	_, err = request(nc, "missing", nil, 5*time.Second)
+	if !errors.Is(err, nats.ErrNoResponders) {
+		t.Fatalf("request with no responder: err = %v; want ErrNoResponders", err)
+	}
+	if time.Since(start) > time.Second {
+		t.Fatalf("no-responders took %v; want an immediate answer", time.Since(start))
+	}
+}
+
+func newTestConsumer(t *testing.T, ackWait time.Duration) (jetstream.JetStream, jetstream.Consumer) {
+	t.Helper()
+	js, err := jetstream.New(newTestConn(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	cons, err := setupOrders(context.Background(), js, ackWait)
+	if err != nil {
+		t.Fatalf("setupOrders: %v", err)
+	}
+	return js, cons
+}
+
+// collect runs processOrders until want handler calls have been made and
+// returns the (id, attempt) pairs in order.
+func collect(t *testing.T, cons jetstream.Consumer, want int, handle func(id string, attempt uint64) error) []string {
+	t.Helper()
+	var mu sync.Mutex
+	var calls []string
+	done := make(chan struct{})
+	cc, err := processOrders(cons, func(id string, attempt uint64) error {
+		mu.Lock()
+		calls = append(calls, fmt.Sprintf("%s#%d", id, attempt))
+		if len(calls) == want {
+			close(done)
+		}
+		mu.Unlock()
+		return handle(id, attempt)
+	})
+	if err != nil {
+		t.Fatalf("processOrders: %v", err)
+	}
+	defer cc.Stop()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatalf("timed out; calls so far: %v", calls)
+	}
+	// Wait a little for unexpected extra deliveries.
+	time.Sleep(100 * time.Millisecond)
+	mu.Lock()
+	defer mu.Unlock()
+	return append([]string(nil), calls...)
+}
+
+func TestJetStream_PersistsUntilConsumed(t *testing.T) {
+	js, cons := newTestConsumer(t, time.Second)
+	ctx := context.Background()
+	// Nobody is consuming yet; core NATS would drop these.
+	for _, id := range []string{"a", "b", "c"} {
+		if _, err := publishOrder(ctx, js, id); err != nil {
+			t.Fatalf("publish: %v", err)
+		}
+	}
+	got := collect(t, cons, 3, func(string, uint64) error { return nil })
+	if fmt.Sprint(got) != "[a#1 b#1 c#1]" {
+		t.Fatalf("deliveries = %v; want each order once, in order", got)
+	}
+}
+
+func TestJetStream_DuplicatePublishStoredOnce(t *testing.T) {
+	js, _ := newTestConsumer(t, time.Second)
+	ctx := context.Background()
+	first, err := publishOrder(ctx, js, "dup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, err := publishOrder(ctx, js, "dup")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !second.Duplicate || second.Sequence != first.Sequence {
+		t.Fatalf("second publish = %+v; want a duplicate of seq %d", second, first.Sequence)
+	}
+}
+
+func TestJetStream_NakRedelivers(t *testing.T) {
+	js, cons := newTestConsumer(t, time.Second)
+	if _, err := publishOrder(context.Background(), js, "flaky"); err != nil {
+		t.Fatal(err)
+	}
+	got := collect(t, cons, 3, func(id string, attempt uint64) error {
+		if attempt < 3 {
+			return fmt.Errorf("attempt %d: %w", attempt, errRetry)
+		}
+		return nil
+	})
+	if fmt.Sprint(got) != "[flaky#1 flaky#2 flaky#3]" {
+		t.Fatalf("deliveries = %v; want three attempts then success", got)
+	}
+}
+
+func TestJetStream_TermStopsRedelivery(t *testing.T) {
+	js, cons := newTestConsumer(t, 100*time.Millisecond)
+	if _, err := publishOrder(context.Background(), js, "poison"); err != nil {
+		t.Fatal(err)
+	}
+	collect(t, cons, 1, func(string, uint64) error { return errors.New("malformed") })
+
+	time.Sleep(200 * time.Millisecond) // longer than AckWait
+	info, err := cons.Info(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.NumAckPending != 0 || info.NumRedelivered != 0 || info.Delivered.Consumer != 1 {
+		t.Fatalf("consumer = delivered %d, ackPending %d, redelivered %d; want one delivery, settled",
+			info.Delivered.Consumer, info.NumAckPending, info.NumRedelivered)
+	}
+}
+
+func TestJetStream_AckWaitRedeliversUnacked(t *testing.T) {
+	js, cons := newTestConsumer(t, 100*time.Millisecond)
+	if _, err := publishOrder(context.Background(), js, "slow"); err != nil {
+		t.Fatal(err)
+	}
+
+	// Simulate a worker that crashes mid-message: fetch without acking.
+	batch, err := cons.Fetch(1, jetstream.FetchMaxWait(time.Second))
+	if err != nil {
+		t.Fatal(err)
+	}
+	for range batch.Messages() {
+	}
+
+	// After AckWait the server hands it out again.
+	got := collect(t, cons, 1, func(string, uint64) error { return nil })
+	if fmt.Sprint(got) != "[slow#2]" {
+		t.Fatalf("deliveries = %v; want the unacked message redelivered as attempt 2", got)
+	}
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"log"
+	"time"
+
+	"github.com/nats-io/nats.go"
+)
+
+// Core NATS is at-most-once: a message goes to whoever is subscribed at
+// the moment it is published, and is gone otherwise. Use it for events
+// where a missed message is acceptable, and for request/reply.
+
+// startWorkers subscribes n workers to subject in a queue group. NATS
+// delivers each message to exactly one member of the group, which is how
+// work is load-balanced without any broker-side configuration. Plain
+// subscribers on the same subject still get every message.
+func startWorkers(nc *nats.Conn, subject, queue string, n int, handle func(worker int, msg *nats.Msg)) ([]*nats.Subscription, error) {
+	var subs []*nats.Subscription
+	for i := 1; i <= n; i++ {
+		sub, err := nc.QueueSubscribe(subject, queue, func(msg *nats.Msg) {
+			handle(i, msg)
+		})
+		if err != nil {
+			for _, s := range subs {
+				s.Unsubscribe()
+			}
+			return nil, err
+		}
+		subs = append(subs, sub)
+	}
+	// Flush waits for the server to process the subscriptions, so messages
+	// published right after this are not missed.
+	return subs, nc.Flush()
+}
+
+// startResponder answers requests on subject. Responders usually join a
+// queue group too, so several instances share the load.
+func startResponder(nc *nats.Conn, subject string, handle func(data []byte) []byte) (*nats.Subscription, error) {
+	sub, err := nc.QueueSubscribe(subject, "responders", func(msg *nats.Msg) {
+		if err := msg.Respond(handle(msg.Data)); err != nil {
+			log.Printf("respond on %s: %v", subject, err)
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sub, nc.Flush()
+}
+
+// request sends data and waits for one reply. With no responder the server
+// answers at once with nats.ErrNoResponders instead of letting the caller
+// wait for the timeout.
+func request(nc *nats.Conn, subject string, data []byte, timeout time.Duration) ([]byte, error) {
+	msg, err := nc.Request(subject, data, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return msg.Data, nil
+}
@@ -0,0 +1,39 @@
+package main
+
+import (
+	"errors"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+)
+
+// runEmbeddedServer starts a NATS server with JetStream inside this
+// process, so neither the demo nor the tests need an external broker. Port
+// -1 picks a free port. storeDir is where JetStream keeps stream data.
+func runEmbeddedServer(port int, storeDir string) (*server.Server, error) {
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      port,
+		JetStream: true,
+		StoreDir:  storeDir,
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	go ns.Start()
+	if !ns.ReadyForConnections(5 * time.Second) {
+		ns.Shutdown()
+		return nil, errors.New("nats server did not start within 5s")
+	}
+	return ns, nil
+}
+
+// connect opens a client connection. A real service would also set
+// nats.MaxReconnects, nats.ReconnectWait and the disconnect/reconnect
+// handlers; the client reconnects and resubscribes on its own.
+func connect(ns *server.Server, name string) (*nats.Conn, error) {
+	return nats.Connect(ns.ClientURL(), nats.Name(name))
+}
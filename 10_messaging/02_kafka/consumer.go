@@ -0,0 +1,155 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sort"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+// Consumer is one member of a consumer group. It processes records
+// at-least-once: offsets are committed only after the store has applied the
+// record, so a crash before the commit means redelivery, never loss.
+type Consumer struct {
+	name   string
+	client *kgo.Client
+	store  EventStore
+
+	mu        sync.Mutex
+	processed []*kgo.Record // applied since the last commit
+
+	Applied, Duplicates atomic.Int64
+}
+
+func newConsumer(name string, brokers []string, group string, store EventStore, opts ...kgo.Opt) (*Consumer, error) {
+	c := &Consumer{name: name, store: store}
+	base := []kgo.Opt{
+		kgo.SeedBrokers(brokers...),
+		kgo.ConsumerGroup(group),
+		kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()), // a new group reads from the beginning
+		kgo.DisableAutoCommit(),
+		// No rebalance while a polled batch is being processed: the group
+		// waits until AllowRebalance, so partitions never move mid-batch.
+		kgo.BlockRebalanceOnPoll(),
+		kgo.OnPartitionsAssigned(func(_ context.Context, _ *kgo.Client, assigned map[string][]int32) {
+			log.Printf("%s: assigned %v", c.name, sorted(assigned[topic]))
+		}),
+		// Revoked partitions go to another member. Commit what we have
+		// processed first, or the new owner would redo it.
+		kgo.OnPartitionsRevoked(func(ctx context.Context, cl *kgo.Client, revoked map[string][]int32) {
+			if err := c.commit(ctx); err != nil {
+				log.Printf("%s: commit on revoke: %v", c.name, err)
+			}
+			if len(revoked[topic]) > 0 {
+				log.Printf("%s: revoked %v", c.name, sorted(revoked[topic]))
+			}
+		}),
+		// Lost partitions (e.g. session expired) may already belong to
+		// someone else: committing now could overwrite their progress.
+		kgo.OnPartitionsLost(func(_ context.Context, _ *kgo.Client, lost map[string][]int32) {
+			c.mu.Lock()
+			c.processed = nil
+			c.mu.Unlock()
+			log.Printf("%s: lost %v", c.name, sorted(lost[topic]))
+		}),
+	}
+	cl, err := kgo.NewClient(append(base, opts...)...)
+	if err != nil {
+		return nil, err
+	}
+	c.client = cl
+	return c, nil
+}
+
+// Run polls and processes until ctx is done or processing fails. On
+// failure it returns without committing the failed record, so it is
+// redelivered to whoever owns the partition next.
+func (c *Consumer) Run(ctx context.Context) error {
+	for {
+		fetches := c.client.PollRecords(ctx, 100)
+		if fetches.IsClientClosed() || ctx.Err() != nil {
+			c.client.AllowRebalance()
+			return nil
+		}
+		fetches.EachError(func(t string, p int32, err error) {
+			log.Printf("%s: fetch %s[%d]: %v", c.name, t, p, err)
+		})
+
+		var runErr error
+		fetches.EachRecord(func(r *kgo.Record) {
+			if runErr != nil {
+				return
+			}
+			if runErr = c.handle(ctx, r); runErr == nil {
+				c.mu.Lock()
+				c.processed = append(c.processed, r)
+				c.mu.Unlock()
+			}
+		})
+		if err := c.commit(ctx); err != nil && ctx.Err() == nil {
+			log.Printf("%s: commit: %v", c.name, err)
+		}
+		c.client.AllowRebalance()
+		if runErr != nil {
+			return runErr
+		}
+	}
+}
+
+// Close leaves the group. That triggers a rebalance, and OnPartitionsRevoked
+// commits the last processed offsets first.
+func (c *Consumer) Close() { c.client.Close() }
+
+func (c *Consumer) handle(ctx context.Context, r *kgo.Record) error {
+	var ev UserEvent
+	if err := json.Unmarshal(r.Value, &ev); err != nil || ev.EventID == "" {
+		// A poison record can never succeed; retrying would block the
+		// partition forever. Log it (or send it to a dead-letter topic) and
+		// move on.
+		log.Printf("%s: skipping bad record %s[%d]@%d: %v", c.name, r.Topic, r.Partition, r.Offset, err)
+		return nil
+	}
+	for attempt := 0; ; attempt++ {
+		applied, err := c.store.Apply(ctx, ev)
+		if err == nil {
+			if applied {
+				c.Applied.Add(1)
+			} else {
+				c.Duplicates.Add(1)
+			}
+			return nil
+		}
+		if attempt == 4 {
+			return err
+		}
+		log.Printf("%s: apply %s failed (attempt %d): %v", c.name, ev.EventID, attempt+1, err)
+		select {
+		case <-time.After(time.Duration(50<<attempt) * time.Millisecond):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
+
+// commit commits the offsets of the processed records, if any.
+func (c *Consumer) commit(ctx context.Context) error {
+	c.mu.Lock()
+	records := c.processed
+	c.processed = nil
+	c.mu.Unlock()
+	if len(records) == 0 {
+		return nil
+	}
+	return c.client.CommitRecords(ctx, records...)
+}
+
+func sorted(ps []int32) []int32 {
+	sort.Slice(ps, func(i, j int) bool { return ps[i] < ps[j] })
+	return ps
+}
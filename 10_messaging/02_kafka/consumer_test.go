@@ -0,0 +1,281 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/twmb/franz-go/pkg/kfake"
+	"github.com/twmb/franz-go/pkg/kgo"
+)
+
+func newTestCluster(t *testing.T) []string {
+	t.Helper()
+	cluster, err := kfake.NewCluster(kfake.NumBrokers(1), kfake.SeedTopics(3, topic))
+	if err != nil {
+		t.Fatalf("kfake: %v", err)
+	}
+	t.Cleanup(cluster.Close)
+	return cluster.ListenAddrs()
+}
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := openStore(filepath.Join(t.TempDir(), "users.db"))
+	if err != nil {
+		t.Fatalf("openStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func events(users, versions int, prefix string) []UserEvent {
+	var out []UserEvent
+	for v := 1; v <= versions; v++ {
+		for u := 1; u <= users; u++ {
+			out = append(out, UserEvent{
+				EventID: fmt.Sprintf("%su%d-v%d", prefix, u, v),
+				UserID:  fmt.Sprintf("user-%d", u),
+				Name:    fmt.Sprintf("User %d", u),
+				Email:   fmt.Sprintf("u%d-v%d@example.com", u, v),
+			})
+		}
+	}
+	return out
+}
+
+func produce(t *testing.T, brokers []string, evs []UserEvent) {
+	t.Helper()
+	p, err := newProducer(brokers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	if err := produceEvents(context.Background(), p, evs); err != nil {
+		t.Fatalf("produceEvents: %v", err)
+	}
+}
+
+// startConsumer runs a group member until the test ends or stop is called.
+func startConsumer(t *testing.T, name string, brokers []string, store EventStore) (c *Consumer, stop func() error) {
+	t.Helper()
+	c, err := newConsumer(name, brokers, "test-group", store, kgo.FetchMaxWait(200*time.Millisecond))
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- c.Run(ctx) }()
+	stopped := false
+	stop = func() error {
+		if stopped {
+			return nil
+		}
+		stopped = true
+		cancel()
+		err := <-done
+		c.Close()
+		return err
+	}
+	t.Cleanup(func() { stop() })
+	return c, stop
+}
+
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(20 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+}
+
+func TestProduce_KeyedRecordsKeepPerKeyOrder(t *testing.T) {
+	brokers := newTestCluster(t)
+	produce(t, brokers, events(8, 4, ""))
+
+	cl, err := kgo.NewClient(kgo.SeedBrokers(brokers...), kgo.ConsumeTopics(topic),
+		kgo.ConsumeResetOffset(kgo.NewOffset().AtStart()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cl.Close()
+
+	partitionOf := map[string]int32{}
+	lastEmail := map[string]string{}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	for n := 0; n < 32; {
+		fetches := cl.PollFetches(ctx)
+		if ctx.Err() != nil {
+			t.Fatalf("only %d of 32 records consumed", n)
+		}
+		fetches.EachRecord(func(r *kgo.Record) {
+			n++
+			key := string(r.Key)
+			if p, ok := partitionOf[key]; ok && p != r.Partition {
+				t.Errorf("key %s on partitions %d and %d", key, p, r.Partition)
+			}
+			partitionOf[key] = r.Partition
+			// Emails end in -v1..-v4: within a key they must arrive in order.
+			email := string(r.Value)
+			if prev := lastEmail[key]; prev > email {
+				t.Errorf("key %s out of order: %s after %s", key, email, prev)
+			}
+			lastEmail[key] = email
+		})
+	}
+}
+
+func TestStore_ApplyIsIdempotent(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	ev := UserEvent{EventID: "e1", UserID: "user-1", Name: "Ann", Email: "ann@example.com"}
+
+	if applied, err := s.Apply(ctx, ev); err != nil || !applied {
+		t.Fatalf("first Apply = %v, %v; want applied", applied, err)
+	}
+	if applied, err := s.Apply(ctx, ev); err != nil || applied {
+		t.Fatalf("second Apply = %v, %v; want duplicate", applied, err)
+	}
+	ev2 := UserEvent{EventID: "e2", UserID: "user-1", Name: "Ann", Email: "ann@new.example.com"}
+	if _, err := s.Apply(ctx, ev2); err != nil {
+		t.Fatal(err)
+	}
+	users, err := s.Users(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(users) != 1 || users[0].Version != 2 || users[0].Email != "ann@new.example.com" {
+		t.Fatalf("users = %+v; want one user at version 2", users)
+	}
+}
+
+func TestConsumer_CommitsProcessedOffsets(t *testing.T) {
+	brokers := newTestCluster(t)
+	store := newTestStore(t)
+	produce(t, brokers, events(6, 3, ""))
+
+	c1, stop1 := startConsumer(t, "c1", brokers, store)
+	waitUntil(t, "18 records applied", func() bool { return c1.Applied.Load() == 18 })
+	if err := stop1(); err != nil {
+		t.Fatalf("Run: %v", err)
+	}
+
+	// A new member of the same group starts after the committed offsets.
+	produce(t, brokers, events(1, 1, "new-"))
+	c2, _ := startConsumer(t, "c2", brokers, store)
+	waitUntil(t, "the new record", func() bool { return c2.Applied.Load() == 1 })
+	time.Sleep(300 * time.Millisecond)
+	if d := c2.Duplicates.Load(); d != 0 {
+		t.Fatalf("c2 saw %d duplicates; committed records were redelivered", d)
+	}
+}
+
+// crashAfterWrite applies the event, then reports failure for crashID: the
+// same as a process dying after the database commit but before the Kafka
+// offset commit.
+type crashAfterWrite struct {
+	*Store
+	crashID string
+}
+
+func (s crashAfterWrite) Apply(ctx context.Context, ev UserEvent) (bool, error) {
+	applied, err := s.Store.Apply(ctx, ev)
+	if err == nil && ev.EventID == s.crashID {
+		return applied, errors.New("crashed before commit")
+	}
+	return applied, err
+}
+
+func TestConsumer_AtLeastOnceRedelivery(t *testing.T) {
+	brokers := newTestCluster(t)
+	store := newTestStore(t)
+	evs := events(4, 2, "")
+	produce(t, brokers, evs)
+
+	c1, stop1 := startConsumer(t, "c1", brokers, crashAfterWrite{store, "u2-v1"})
+	waitUntil(t, "the crash", func() bool {
+		users, _ := store.Users(context.Background())
+		for _, u := range users {
+			if u.ID == "user-2" {
+				return true
+			}
+		}
+		return false
+	})
+	if err := stop1(); err == nil {
+		t.Fatalf("Run returned nil; want the crash error")
+	}
+
+	// The crashed record was not committed, so the next member gets it again.
+	// The store recognises the event ID, and the user is not updated twice.
+	c2, _ := startConsumer(t, "c2", brokers, store)
+	// u2-v1 is written by c1 but never counted there, so it shows up as
+	// c2's duplicate.
+	waitUntil(t, "all records", func() bool {
+		return c1.Applied.Load()+c2.Applied.Load() == int64(len(evs)-1)
+	})
+	if d := c2.Duplicates.Load(); d < 1 {
+		t.Fatalf("c2 duplicates = %d; want the crashed record redelivered", d)
+	}
+	users, err := store.Users(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, u := range users {
+		if u.Version != 2 {
+			t.Fatalf("%s version = %d; want 2 (each event applied once)", u.ID, u.Version)
+		}
+	}
+}
+
+func TestConsumer_SkipsPoisonRecord(t *testing.T) {
+	brokers := newTestCluster(t)
+	store := newTestStore(t)
+	p, err := newProducer(brokers)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer p.Close()
+	// Same key, so the good record sits behind the bad one on one partition.
+	if err := p.ProduceSync(context.Background(), &kgo.Record{Key: []byte("user-1"), Value: []byte("{broken")}).FirstErr(); err != nil {
+		t.Fatal(err)
+	}
+	produce(t, brokers, []UserEvent{{EventID: "ok", UserID: "user-1", Name: "Ann", Email: "a@example.com"}})
+
+	c, _ := startConsumer(t, "c", brokers, store)
+	waitUntil(t, "the good record", func() bool { return c.Applied.Load() == 1 })
+}
+
+func TestConsumer_RebalanceHandsOverCommittedWork(t *testing.T) {
+	brokers := newTestCluster(t)
+	store := newTestStore(t)
+	first := events(12, 2, "")
+	produce(t, brokers, first)
+
+	a, stopA := startConsumer(t, "a", brokers, store)
+	b, _ := startConsumer(t, "b", brokers, store)
+	waitUntil(t, "first batch", func() bool {
+		return a.Applied.Load()+b.Applied.Load() == int64(len(first))
+	})
+
+	// a leaves; its revoke callback commits, so b must not redo a's work.
+	if err := stopA(); err != nil {
+		t.Fatalf("a.Run: %v", err)
+	}
+	second := events(12, 1, "second-")
+	produce(t, brokers, second)
+	waitUntil(t, "second batch on b", func() bool {
+		return a.Applied.Load()+b.Applied.Load() == int64(len(first)+len(second))
+	})
+	time.Sleep(300 * time.Millisecond)
+	if d := b.Duplicates.Load(); d != 0 {
+		t.Fatalf("b saw %d duplicates after the rebalance; want 0", d)
+	}
+}
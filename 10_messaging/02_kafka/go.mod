@@ -0,0 +1,16 @@
+module golang_roadmap/10_messaging/02_kafka
+
+go 1.24.11
+
+require (
+	github.com/mattn/go-sqlite3 v1.14.33
+	github.com/twmb/franz-go v1.19.5
+	github.com/twmb/franz-go/pkg/kfake v0.0.0-20250729165834-29dc44e616cd
+)
+
+require (
+	github.com/klauspost/compress v1.18.0 // indirect
+	github.com/pierrec/lz4/v4 v4.1.22 // indirect
+	github.com/twmb/franz-go/pkg/kmsg v1.11.2 // indirect
+	golang.org/x/crypto v0.38.0 // indirect
+)
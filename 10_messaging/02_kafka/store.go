@@ -0,0 +1,107 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "github.com/mattn/go-sqlite3" // SQLite driver (import for side effects)
+)
+
+// UserEvent is the message value on the user-events topic. The record key
+// is UserID, so all events for one user land on one partition, in order.
+type UserEvent struct {
+	EventID string `json:"event_id"`
+	UserID  string `json:"user_id"`
+	Name    string `json:"name"`
+	Email   string `json:"email"`
+}
+
+// User is a row in the users table.
+type User struct {
+	ID, Name, Email string
+	Version         int
+}
+
+// EventStore is what the consumer writes into.
+type EventStore interface {
+	// Apply stores ev. It returns false if the event was already applied,
+	// which happens when a record is redelivered.
+	Apply(ctx context.Context, ev UserEvent) (bool, error)
+}
+
+// Store is the SQLite users table, plus a processed_events table that makes
+// Apply idempotent.
+type Store struct {
+	db *sql.DB
+}
+
+func openStore(path string) (*Store, error) {
+	db, err := sql.Open("sqlite3", path+"?_busy_timeout=5000&_journal_mode=WAL")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1) // SQLite has one writer; queueing in Go avoids SQLITE_BUSY
+	_, err = db.Exec(`
+		CREATE TABLE IF NOT EXISTS users (
+			id      TEXT PRIMARY KEY,
+			name    TEXT NOT NULL,
+			email   TEXT NOT NULL,
+			version INTEGER NOT NULL
+		);
+		CREATE TABLE IF NOT EXISTS processed_events (
+			event_id TEXT PRIMARY KEY
+		);`)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error { return s.db.Close() }
+
+// Apply upserts the user and records the event ID in one transaction. If
+// the event ID is already there the whole transaction is a no-op, so a
+// redelivered record never bumps the version twice.
+func (s *Store) Apply(ctx context.Context, ev UserEvent) (bool, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	res, err := tx.ExecContext(ctx, `INSERT INTO processed_events (event_id) VALUES (?) ON CONFLICT DO NOTHING`, ev.EventID)
+	if err != nil {
+		return false, fmt.Errorf("recording event %s: %w", ev.EventID, err)
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil // duplicate delivery
+	}
+	_, err = tx.ExecContext(ctx, `
+		INSERT INTO users (id, name, email, version) VALUES (?, ?, ?, 1)
+		ON CONFLICT (id) DO UPDATE SET name = excluded.name, email = excluded.email, version = version + 1`,
+		ev.UserID, ev.Name, ev.Email)
+	if err != nil {
+		return false, fmt.Errorf("upserting user %s: %w", ev.UserID, err)
+	}
+	return true, tx.Commit()
+}
+
+// Users returns all users ordered by ID.
+func (s *Store) Users(ctx context.Context) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name, email, version FROM users ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email, &u.Version); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// amqp091-go does not reconnect by itself: when the connection drops,
+// every channel on it is closed and every consumer's delivery channel is
+// closed. The application has to dial again and redo all of its setup.
+
+const (
+	minBackoff = 100 * time.Millisecond
+	maxBackoff = 5 * time.Second
+)
+
+// session runs fn on a connection to url and reconnects when the
+// connection is lost. fn should declare what it needs, then work until ctx
+// is done (return nil) or conn closes (return the error). Returning nil
+// ends the session; an error means "reconnect and call fn again", so fn
+// must pick up where it left off.
+func session(ctx context.Context, url, name string, fn func(ctx context.Context, conn *amqp.Connection) error) error {
+	for failures := 0; ; {
+		conn, err := amqp.DialConfig(url, amqp.Config{
+			Properties: amqp.Table{"connection_name": name}, // shown in the management UI
+			Heartbeat:  10 * time.Second,
+		})
+		if err == nil {
+			failures = 0
+			err = runConnected(ctx, conn, fn)
+			if err == nil || ctx.Err() != nil {
+				return nil
+			}
+			log.Printf("%s: %v; reconnecting", name, err)
+		} else {
+			failures++
+			log.Printf("%s: dial: %v", name, err)
+		}
+
+		select {
+		case <-time.After(backoff(failures)):
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+// runConnected calls fn with a context that is also cancelled when conn
+// closes, so fn never has to watch the connection itself.
+func runConnected(ctx context.Context, conn *amqp.Connection, fn func(context.Context, *amqp.Connection) error) error {
+	defer conn.Close()
+	closed := conn.NotifyClose(make(chan *amqp.Error, 1))
+
+	connCtx, cancel := context.WithCancelCause(ctx)
+	defer cancel(nil)
+	go func() {
+		if amqpErr, ok := <-closed; ok {
+			cancel(fmt.Errorf("connection closed: %w", amqpErr))
+		} else {
+			cancel(errors.New("connection closed"))
+		}
+	}()
+
+	err := fn(connCtx, conn)
+	if cause := context.Cause(connCtx); ctx.Err() == nil && cause != nil {
+		return cause // the connection, not the caller, ended fn
+	}
+	return err
+}
+
+// backoff doubles from minBackoff up to maxBackoff, keeping a random half
+// of the delay so that clients cut off together do not redial together.
+func backoff(failures int) time.Duration {
+	d := maxBackoff
+	if failures < 30 { // avoid overflowing the shift
+		d = min(minBackoff<<max(failures-1, 0), maxBackoff)
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
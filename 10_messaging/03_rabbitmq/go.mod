@@ -0,0 +1,5 @@
+module golang_roadmap/10_messaging/03_rabbitmq
+
+go 1.24.11
+
+require github.com/rabbitmq/amqp091-go v1.15.0
@@ -0,0 +1,84 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// Task is the message body of a work item.
+type Task struct {
+	ID     string        `json:"id"`
+	Work   time.Duration `json:"work"`             // how long the task takes
+	FailN  int           `json:"fail_n,omitempty"` // fail the first FailN deliveries
+	Reject bool          `json:"reject,omitempty"` // fail permanently
+}
+
+// errNacked means the broker could not take responsibility for a message,
+// for example because a queue reached its length limit.
+var errNacked = errors.New("publish nacked by broker")
+
+// publishConfirmed publishes tasks and waits for a broker confirm for each
+// one. It returns how many were confirmed; on error the caller reconnects
+// and publishes tasks[n:] again.
+//
+// Without confirms, Publish returns as soon as the bytes are written to the
+// socket, and a message in flight when the connection drops is silently
+// lost. A confirm means the broker has routed the message to a queue and,
+// for durable queues and persistent messages, written it to disk.
+func publishConfirmed(ctx context.Context, conn *amqp.Connection, tasks []Task) (int, error) {
+	ch, err := conn.Channel()
+	if err != nil {
+		return 0, err
+	}
+	defer ch.Close()
+	if err := declareTopology(ch); err != nil {
+		return 0, err
+	}
+	if err := ch.Confirm(false); err != nil {
+		return 0, err
+	}
+
+	for i, t := range tasks {
+		body, err := json.Marshal(t)
+		if err != nil {
+			return i, err
+		}
+		if err := publish(ctx, ch, t.ID, body); err != nil {
+			return i, fmt.Errorf("task %s: %w", t.ID, err)
+		}
+	}
+	return len(tasks), nil
+}
+
+// publish sends one persistent message and waits for its confirm. Waiting
+// for each confirm is simple and slow; a high-throughput publisher keeps
+// many DeferredConfirmations in flight and checks them as they complete.
+//
+// If the connection drops after the broker stored the message but before
+// the confirm arrived, the caller publishes it again. Confirms make
+// publishing at-least-once, so the MessageId lets consumers spot repeats.
+func publish(ctx context.Context, ch *amqp.Channel, id string, body []byte) error {
+	confirm, err := ch.PublishWithDeferredConfirmWithContext(ctx, tasksExchange, tasksKey, false, false, amqp.Publishing{
+		ContentType:  "application/json",
+		DeliveryMode: amqp.Persistent,
+		MessageId:    id,
+		Timestamp:    time.Now(),
+		Body:         body,
+	})
+	if err != nil {
+		return err
+	}
+	acked, err := confirm.WaitContext(ctx)
+	if err != nil {
+		return err
+	}
+	if !acked {
+		return errNacked
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// The work queue and its dead-letter queue:
+//
+//	publisher ──► exchange "tasks" ──► queue "tasks" ──► workers
+//	                                        │ rejected, or delivered too often
+//	                                        ▼
+//	              exchange "tasks.dlx" ──► queue "tasks.dead"
+const (
+	tasksExchange = "tasks"
+	tasksQueue    = "tasks"
+	tasksKey      = "task"
+
+	deadExchange = "tasks.dlx"
+	deadQueue    = "tasks.dead"
+	deadKey      = "dead"
+
+	// maxDeliveries is how often the broker hands out a message before it
+	// gives up and dead-letters it.
+	maxDeliveries = 3
+)
+
+// tasksQueueArgs configures the work queue. A quorum queue is replicated
+// and, unlike a classic queue, counts deliveries per message: with
+// x-delivery-limit the broker itself dead-letters a message that keeps
+// failing, so a requeue loop cannot spin forever.
+func tasksQueueArgs() amqp.Table {
+	return amqp.Table{
+		"x-queue-type":              "quorum",
+		"x-delivery-limit":          int32(maxDeliveries - 1), // redeliveries after the first attempt
+		"x-dead-letter-exchange":    deadExchange,
+		"x-dead-letter-routing-key": deadKey,
+	}
+}
+
+// declareTopology creates the exchanges, queues and bindings. Declarations
+// are idempotent, so every process runs this on every (re)connect instead
+// of depending on some other process having done it. Declaring an existing
+// queue with different arguments fails with PRECONDITION_FAILED.
+func declareTopology(ch *amqp.Channel) error {
+	// durable: survives a broker restart. Messages survive too only if
+	// they are also published as persistent.
+	for _, ex := range []string{tasksExchange, deadExchange} {
+		if err := ch.ExchangeDeclare(ex, amqp.ExchangeDirect, true, false, false, false, nil); err != nil {
+			return err
+		}
+	}
+	if _, err := ch.QueueDeclare(tasksQueue, true, false, false, false, tasksQueueArgs()); err != nil {
+		return err
+	}
+	if _, err := ch.QueueDeclare(deadQueue, true, false, false, false, nil); err != nil {
+		return err
+	}
+	if err := ch.QueueBind(tasksQueue, tasksKey, tasksExchange, false, nil); err != nil {
+		return err
+	}
+	return ch.QueueBind(deadQueue, deadKey, deadExchange, false, nil)
+}
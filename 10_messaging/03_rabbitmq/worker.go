@@ -0,0 +1,184 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	amqp "github.com/rabbitmq/amqp091-go"
+)
+
+// errPermanent marks a failure that retrying cannot fix. Wrap it (with
+// fmt.Errorf("...: %w", errPermanent)) to send a message straight to the
+// dead-letter queue.
+var errPermanent = errors.New("permanent failure")
+
+// Handler processes one task. A nil error acks the message; errPermanent
+// dead-letters it; any other error requeues it for another attempt.
+type Handler func(ctx context.Context, t Task, delivery int) error
+
+// Stats counts what the workers did with their messages.
+type Stats struct {
+	Done, Requeued, DeadLettered atomic.Int64
+}
+
+// WorkerConfig configures runWorkers.
+type WorkerConfig struct {
+	Workers  int // goroutines, each with its own channel and consumer
+	Prefetch int // unacked messages the broker may push to each worker
+	Handler  Handler
+	Stats    *Stats
+}
+
+// runWorkers consumes the tasks queue with cfg.Workers goroutines until
+// ctx is done or the connection closes. It is meant to be the function
+// passed to session, which calls it again after a reconnect.
+//
+// Each worker has its own channel with basic.qos prefetch. The broker
+// stops pushing to a worker once it holds Prefetch unacked messages, so a
+// slow task holds back only its own worker and new messages go to whoever
+// is free. With no prefetch limit, the broker deals messages round-robin
+// at publish time, and one worker can be stuck behind a queue of slow
+// tasks while the others sit idle.
+func runWorkers(ctx context.Context, conn *amqp.Connection, cfg WorkerConfig) error {
+	setup, err := conn.Channel()
+	if err != nil {
+		return err
+	}
+	err = declareTopology(setup)
+	setup.Close()
+	if err != nil {
+		return err
+	}
+
+	var wg sync.WaitGroup
+	for i := 1; i <= cfg.Workers; i++ {
+		ch, err := conn.Channel()
+		if err != nil {
+			return err
+		}
+		defer ch.Close()
+		if err := ch.Qos(cfg.Prefetch, 0, false); err != nil {
+			return err
+		}
+		name := fmt.Sprintf("worker-%d", i)
+		// autoAck=false: the broker keeps each message until we ack it,
+		// and redelivers it if this channel closes first.
+		deliveries, err := ch.ConsumeWithContext(ctx, tasksQueue, name, false, false, false, false, nil)
+		if err != nil {
+			return err
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			work(ctx, name, deliveries, cfg.Handler, cfg.Stats)
+		}()
+	}
+	// The delivery channels close when ctx is cancelled (the consumer is
+	// cancelled) or when the connection drops.
+	wg.Wait()
+	return nil
+}
+
+// work handles deliveries until the channel closes. A message that is in
+// progress when ctx is cancelled is not acked; the broker requeues it
+// when the channel closes.
+func work(ctx context.Context, name string, deliveries <-chan amqp.Delivery, h Handler, stats *Stats) {
+	for d := range deliveries {
+		switch o := process(ctx, d, h); o {
+		case outcomeDone:
+			stats.Done.Add(1)
+		case outcomeRequeued:
+			stats.Requeued.Add(1)
+		case outcomeDeadLettered:
+			stats.DeadLettered.Add(1)
+		}
+	}
+	log.Printf("%s: deliveries closed", name)
+}
+
+type outcome int
+
+const (
+	outcomeNone outcome = iota // not settled: the ack failed or ctx was cancelled
+	outcomeDone
+	outcomeRequeued
+	outcomeDeadLettered
+)
+
+// process runs h on one delivery and settles it:
+//
+//	success            → Ack
+//	undecodable body   → Reject(requeue=false) → dead-letter queue
+//	errPermanent       → Reject(requeue=false) → dead-letter queue
+//	other errors       → Nack(requeue=true); after maxDeliveries attempts
+//	                     the broker dead-letters it instead
+func process(ctx context.Context, d amqp.Delivery, h Handler) outcome {
+	var t Task
+	if err := json.Unmarshal(d.Body, &t); err != nil {
+		// A poison message fails the same way every time. Requeueing it
+		// would just bounce it between workers.
+		log.Printf("poison message %q: %v", d.MessageId, err)
+		return settle(d.Reject(false), outcomeDeadLettered)
+	}
+
+	attempt := deliveryCount(d) + 1
+	err := h(ctx, t, attempt)
+	switch {
+	case err == nil:
+		return settle(d.Ack(false), outcomeDone)
+	case ctx.Err() != nil:
+		return outcomeNone // shutting down; the broker redelivers it
+	case errors.Is(err, errPermanent):
+		log.Printf("task %s: %v; dead-lettering", t.ID, err)
+		return settle(d.Reject(false), outcomeDeadLettered)
+	default:
+		log.Printf("task %s attempt %d/%d: %v", t.ID, attempt, maxDeliveries, err)
+		// A short pause keeps a failing dependency from turning into a
+		// tight requeue loop. For long delays, use a delay queue with a
+		// message TTL that dead-letters back into the work queue.
+		select {
+		case <-time.After(time.Duration(attempt) * 50 * time.Millisecond):
+		case <-ctx.Done():
+			return outcomeNone
+		}
+		return settle(d.Nack(false, true), outcomeRequeued)
+	}
+}
+
+func settle(err error, o outcome) outcome {
+	if err != nil {
+		// The channel is gone; the broker will redeliver the message.
+		log.Printf("settle: %v", err)
+		return outcomeNone
+	}
+	return o
+}
+
+// deliveryCount is how many times a quorum queue has delivered the message
+// before, from the x-delivery-count header it adds on redelivery.
+func deliveryCount(d amqp.Delivery) int {
+	switch n := d.Headers["x-delivery-count"].(type) {
+	case int64:
+		return int(n)
+	case int32:
+		return int(n)
+	case int:
+		return n
+	}
+	return 0
+}
+
+// deadLetterReason reports why the broker dead-lettered a message
+// ("rejected", "delivery_limit", "expired" or "maxlen").
+func deadLetterReason(d amqp.Delivery) string {
+	if reason, ok := d.Headers["x-first-death-reason"].(string); ok {
+		return reason
+	}
+	return "unknown"
+}
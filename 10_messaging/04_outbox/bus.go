@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats-server/v2/server"
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// The message bus is NATS JetStream, embedded as in ../01_nats. Its
+// per-stream de-duplication by message ID is what the idempotency key
+// plugs into.
+
+const (
+	streamName = "USERS"
+	// dedupWindow is how long JetStream remembers message IDs. The relay
+	// must republish a row within this window for the broker to drop the
+	// repeat; after it, only consumer-side de-duplication helps.
+	dedupWindow = 2 * time.Minute
+)
+
+// runEmbeddedServer starts a NATS server with JetStream inside this
+// process. Port -1 picks a free port.
+func runEmbeddedServer(port int, storeDir string) (*server.Server, error) {
+	ns, err := server.NewServer(&server.Options{
+		Host:      "127.0.0.1",
+		Port:      port,
+		JetStream: true,
+		StoreDir:  storeDir,
+		NoLog:     true,
+		NoSigs:    true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	go ns.Start()
+	if !ns.ReadyForConnections(5 * time.Second) {
+		ns.Shutdown()
+		return nil, errors.New("nats server did not start within 5s")
+	}
+	return ns, nil
+}
+
+// setupStream creates the USERS stream for users.* events.
+func setupStream(ctx context.Context, js jetstream.JetStream) error {
+	_, err := js.CreateOrUpdateStream(ctx, jetstream.StreamConfig{
+		Name:       streamName,
+		Subjects:   []string{"users.*"},
+		Storage:    jetstream.FileStorage,
+		Duplicates: dedupWindow,
+	})
+	return err
+}
+
+// jsPublisher publishes to JetStream with the idempotency key as the
+// Nats-Msg-Id header.
+type jsPublisher struct {
+	js jetstream.JetStream
+
+	// Duplicates counts publishes the stream recognised as repeats.
+	Duplicates atomic.Int64
+}
+
+func (p *jsPublisher) Publish(ctx context.Context, subject string, payload []byte, key string) error {
+	msg := &nats.Msg{Subject: subject, Data: payload, Header: nats.Header{}}
+	msg.Header.Set("Idempotency-Key", key) // for consumers
+	ack, err := p.js.PublishMsg(ctx, msg, jetstream.WithMsgID(key))
+	if err != nil {
+		return err
+	}
+	if ack.Duplicate {
+		p.Duplicates.Add(1)
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+// The consumer is a separate "welcome email" service with its own
+// database. Its inbox table remembers idempotency keys, so a message that
+// arrives twice (republished by the relay, or redelivered by JetStream
+// after a lost ack) has its effect only once.
+
+const inboxSchema = `
+CREATE TABLE IF NOT EXISTS inbox (
+	idempotency_key TEXT PRIMARY KEY,
+	processed_at    TIMESTAMP NOT NULL
+);
+CREATE TABLE IF NOT EXISTS welcome_emails (
+	user_id INTEGER PRIMARY KEY,
+	email   TEXT NOT NULL
+);`
+
+// WelcomeService queues one welcome email per new user.
+type WelcomeService struct {
+	db *sql.DB
+
+	// crashBeforeAck, if set and true for a key, makes the handler return
+	// after its transaction commits but without acking, as if the process
+	// died there. JetStream redelivers after AckWait.
+	crashBeforeAck func(key string) bool
+
+	Handled, Duplicates atomic.Int64
+}
+
+func openWelcomeService(path string) (*WelcomeService, error) {
+	db, err := openDB(path, inboxSchema)
+	if err != nil {
+		return nil, err
+	}
+	return &WelcomeService{db: db}, nil
+}
+
+func (w *WelcomeService) Close() error { return w.db.Close() }
+
+// handle records key in the inbox and queues the email in one transaction.
+// It reports false if key was already processed.
+func (w *WelcomeService) handle(ctx context.Context, key string, ev UserCreated) (bool, error) {
+	tx, err := w.db.BeginTx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO inbox (idempotency_key, processed_at) VALUES (?, ?) ON CONFLICT DO NOTHING`,
+		key, time.Now().UTC())
+	if err != nil {
+		return false, err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return false, nil
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO welcome_emails (user_id, email) VALUES (?, ?)`, ev.UserID, ev.Email); err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
+
+// Emails returns the number of queued welcome emails.
+func (w *WelcomeService) Emails(ctx context.Context) (int, error) {
+	var n int
+	err := w.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM welcome_emails`).Scan(&n)
+	return n, err
+}
+
+// Start consumes users.created with a durable consumer.
+func (w *WelcomeService) Start(ctx context.Context, js jetstream.JetStream, ackWait time.Duration) (jetstream.ConsumeContext, error) {
+	cons, err := js.CreateOrUpdateConsumer(ctx, streamName, jetstream.ConsumerConfig{
+		Durable:       "welcome-emails",
+		AckPolicy:     jetstream.AckExplicitPolicy,
+		AckWait:       ackWait,
+		FilterSubject: subjectUserCreated,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return cons.Consume(func(msg jetstream.Msg) {
+		key := msg.Headers().Get("Idempotency-Key")
+		var ev UserCreated
+		if err := json.Unmarshal(msg.Data(), &ev); err != nil || key == "" {
+			log.Printf("welcome: dropping malformed message: %v", err)
+			msg.Term()
+			return
+		}
+		fresh, err := w.handle(ctx, key, ev)
+		if err != nil {
+			log.Printf("welcome: %s: %v", key, err)
+			msg.NakWithDelay(100 * time.Millisecond)
+			return
+		}
+		if fresh {
+			w.Handled.Add(1)
+		} else {
+			w.Duplicates.Add(1)
+		}
+		if w.crashBeforeAck != nil && w.crashBeforeAck(key) {
+			return
+		}
+		msg.Ack()
+	})
+}
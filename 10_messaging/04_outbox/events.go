@@ -0,0 +1,17 @@
+package main
+
+import "encoding/json"
+
+// subjectUserCreated is where the user service announces new users.
+const subjectUserCreated = "users.created"
+
+// UserCreated is the message published for every new user.
+type UserCreated struct {
+	UserID int64  `json:"user_id"`
+	Name   string `json:"name"`
+	Email  string `json:"email"`
+}
+
+func encodeUserCreated(u User) ([]byte, error) {
+	return json.Marshal(UserCreated{UserID: u.ID, Name: u.Name, Email: u.Email})
+}
@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// Fault injection for the demo and the tests: wrappers that make the
+// broker or the database fail at the moments the outbox pattern is built
+// to survive.
+
+var (
+	errBrokerDown = errors.New("broker unavailable")
+	errCrashed    = errors.New("relay crashed before recording the publish")
+)
+
+// flakyPublisher fails every Publish while Down is set.
+type flakyPublisher struct {
+	Publisher
+	Down atomic.Bool
+}
+
+func (p *flakyPublisher) Publish(ctx context.Context, subject string, payload []byte, key string) error {
+	if p.Down.Load() {
+		return errBrokerDown
+	}
+	return p.Publisher.Publish(ctx, subject, payload, key)
+}
+
+// forgetfulStore loses the next MarkPublished once ForgetNext is set: the
+// message is on the bus, but the outbox still says pending, exactly as if
+// the relay had died between the two steps.
+type forgetfulStore struct {
+	OutboxStore
+	ForgetNext atomic.Bool
+}
+
+func (s *forgetfulStore) MarkPublished(ctx context.Context, id int64) error {
+	if s.ForgetNext.CompareAndSwap(true, false) {
+		return errCrashed
+	}
+	return s.OutboxStore.MarkPublished(ctx, id)
+}
@@ -0,0 +1,153 @@
+// Demonstrates the transactional outbox pattern: a SQLite write and the
+// event announcing it are committed together, and a relay publishes the
+// event to NATS JetStream afterwards.
+//
+// This example shows:
+// - Writing a user and its outbox row in one transaction
+// - A background relay that publishes pending rows in order
+// - Idempotency keys: broker-side de-duplication and a consumer inbox
+// - Surviving a broker outage, a rolled-back transaction and a relay crash
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func main() {
+	dir, err := os.MkdirTemp("", "outbox-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ns, err := runEmbeddedServer(4223, filepath.Join(dir, "jetstream"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer ns.Shutdown()
+	nc, err := nats.Connect(ns.ClientURL(), nats.Name("outbox-demo"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer nc.Close()
+	js, err := jetstream.New(nc)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := setupStream(ctx, js); err != nil {
+		log.Fatal(err)
+	}
+
+	store, err := openStore(filepath.Join(dir, "users.db"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer store.Close()
+	welcome, err := openWelcomeService(filepath.Join(dir, "welcome.db"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer welcome.Close()
+	// Lose the ack for user 6 once, as if the consumer died right after
+	// committing.
+	var crashed atomic.Bool
+	welcome.crashBeforeAck = func(key string) bool {
+		return key == "user.created/6" && crashed.CompareAndSwap(false, true)
+	}
+	cc, err := welcome.Start(ctx, js, 500*time.Millisecond)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer cc.Stop()
+
+	bus := &jsPublisher{js: js}
+	pub := &flakyPublisher{Publisher: bus}
+	outbox := &forgetfulStore{OutboxStore: store}
+	relay := newRelay(outbox, pub, 10, 200*time.Millisecond)
+	wake := make(chan struct{}, 1)
+	relayDone := make(chan struct{})
+	go func() {
+		relay.Run(ctx, wake)
+		close(relayDone)
+	}()
+	defer func() { cancel(); <-relayDone }() // stop the relay before the store closes
+
+	createUser := func(name, email string) {
+		u, err := store.CreateUser(ctx, name, email)
+		if err != nil {
+			fmt.Printf("  CreateUser(%s): %v\n", email, err)
+			return
+		}
+		fmt.Printf("  created user %d (%s)\n", u.ID, email)
+		select { // nudge the relay; it polls anyway
+		case wake <- struct{}{}:
+		default:
+		}
+	}
+	waitEmails := func(want int) {
+		for deadline := time.Now().Add(10 * time.Second); time.Now().Before(deadline); {
+			if n, _ := welcome.Emails(ctx); n >= want {
+				break
+			}
+			time.Sleep(20 * time.Millisecond)
+		}
+		n, _ := welcome.Emails(ctx)
+		pending, _ := store.PendingCount(ctx)
+		fmt.Printf("  welcome emails queued: %d, outbox pending: %d\n", n, pending)
+	}
+
+	fmt.Println("\n=== Normal operation ===")
+	createUser("Alice", "alice@example.com")
+	createUser("Bob", "bob@example.com")
+	waitEmails(2)
+
+	fmt.Println("\n=== Broker outage: writes still succeed, events wait in the outbox ===")
+	pub.Down.Store(true)
+	createUser("Carol", "carol@example.com")
+	createUser("Dan", "dan@example.com")
+	time.Sleep(500 * time.Millisecond)
+	pending, _ := store.PendingCount(ctx)
+	fmt.Printf("  outbox pending during outage: %d\n", pending)
+	pub.Down.Store(false)
+	fmt.Println("  broker back")
+	waitEmails(4)
+
+	fmt.Println("\n=== Rolled-back transaction: no user, no event ===")
+	createUser("Alice again", "alice@example.com")
+	store.beforeCommit = func() error { return errors.New("disk full") }
+	createUser("Erin", "erin@example.com")
+	store.beforeCommit = nil
+	pending, _ = store.PendingCount(ctx)
+	fmt.Printf("  outbox pending: %d\n", pending)
+
+	fmt.Println("\n=== Relay crash after publishing, before marking the row ===")
+	outbox.ForgetNext.Store(true)
+	createUser("Frank", "frank@example.com")
+	waitEmails(5)
+	time.Sleep(300 * time.Millisecond) // let the relay republish the row
+
+	fmt.Println("\n=== Consumer crash after processing, before acking ===")
+	createUser("Grace", "grace@example.com")
+	waitEmails(6)
+	time.Sleep(time.Second) // AckWait is 500ms: JetStream redelivers
+	fmt.Printf("  redelivered: %d\n", welcome.Duplicates.Load())
+
+	fmt.Println("\n=== Summary ===")
+	users, _ := store.Users(ctx)
+	emails, _ := welcome.Emails(ctx)
+	fmt.Printf("  users: %d, welcome emails: %d\n", len(users), emails)
+	fmt.Printf("  repeats dropped by JetStream (same Nats-Msg-Id): %d\n", bus.Duplicates.Load())
+	fmt.Printf("  repeats dropped by the consumer inbox: %d\n", welcome.Duplicates.Load())
+}
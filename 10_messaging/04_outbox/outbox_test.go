@@ -0,0 +1,284 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/nats-io/nats.go"
+	"github.com/nats-io/nats.go/jetstream"
+)
+
+func newTestStore(t *testing.T) *Store {
+	t.Helper()
+	s, err := openStore(filepath.Join(t.TempDir(), "users.db"))
+	if err != nil {
+		t.Fatalf("openStore: %v", err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s
+}
+
+func newTestJetStream(t *testing.T) jetstream.JetStream {
+	t.Helper()
+	ns, err := runEmbeddedServer(-1, t.TempDir())
+	if err != nil {
+		t.Fatalf("runEmbeddedServer: %v", err)
+	}
+	t.Cleanup(ns.Shutdown)
+	nc, err := nats.Connect(ns.ClientURL())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(nc.Close)
+	js, err := jetstream.New(nc)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := setupStream(context.Background(), js); err != nil {
+		t.Fatalf("setupStream: %v", err)
+	}
+	return js
+}
+
+// memPublisher records published keys in order.
+type memPublisher struct {
+	mu     sync.Mutex
+	keys   []string
+	failOn string // key to fail on, if set
+}
+
+func (p *memPublisher) Publish(_ context.Context, _ string, _ []byte, key string) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if key == p.failOn {
+		return errBrokerDown
+	}
+	p.keys = append(p.keys, key)
+	return nil
+}
+
+func (p *memPublisher) published() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.keys...)
+}
+
+func createUsers(t *testing.T, s *Store, n int) {
+	t.Helper()
+	for i := 1; i <= n; i++ {
+		if _, err := s.CreateUser(context.Background(), fmt.Sprintf("User %d", i), fmt.Sprintf("u%d@example.com", i)); err != nil {
+			t.Fatalf("CreateUser %d: %v", i, err)
+		}
+	}
+}
+
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestCreateUser_WritesOutboxRowInSameTransaction(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	u, err := s.CreateUser(ctx, "Ann", "ann@example.com")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rows, err := s.Pending(ctx, 10)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(rows) != 1 || rows[0].IdempotencyKey != fmt.Sprintf("user.created/%d", u.ID) || rows[0].Subject != subjectUserCreated {
+		t.Fatalf("pending = %+v; want one user.created row for user %d", rows, u.ID)
+	}
+}
+
+func TestCreateUser_FailedTransactionLeavesNoEvent(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	createUsers(t, s, 1)
+
+	if _, err := s.CreateUser(ctx, "Dup", "u1@example.com"); !errors.Is(err, ErrEmailTaken) {
+		t.Fatalf("duplicate email: err = %v; want ErrEmailTaken", err)
+	}
+	s.beforeCommit = func() error { return errors.New("injected") }
+	if _, err := s.CreateUser(ctx, "Late", "late@example.com"); err == nil {
+		t.Fatal("CreateUser succeeded despite the injected failure")
+	}
+	s.beforeCommit = nil
+
+	users, _ := s.Users(ctx)
+	pending, _ := s.PendingCount(ctx)
+	if len(users) != 1 || pending != 1 {
+		t.Fatalf("users=%d pending=%d; want 1 and 1: rolled-back writes must leave neither row", len(users), pending)
+	}
+}
+
+func TestRelay_BrokerOutageKeepsRowsPending(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	createUsers(t, s, 3)
+	pub := &flakyPublisher{Publisher: &memPublisher{}}
+	relay := newRelay(s, pub, 10, time.Hour)
+
+	pub.Down.Store(true)
+	for range 2 {
+		if _, err := relay.RelayOnce(ctx); !errors.Is(err, errBrokerDown) {
+			t.Fatalf("RelayOnce during outage: err = %v; want errBrokerDown", err)
+		}
+	}
+	rows, _ := s.Pending(ctx, 10)
+	if len(rows) != 3 || rows[0].Attempts != 2 {
+		t.Fatalf("pending = %+v; want 3 rows, the first with 2 attempts", rows)
+	}
+
+	pub.Down.Store(false)
+	if n, err := relay.RelayOnce(ctx); err != nil || n != 3 {
+		t.Fatalf("RelayOnce after outage = %d, %v; want 3", n, err)
+	}
+	want := []string{"user.created/1", "user.created/2", "user.created/3"}
+	if got := pub.Publisher.(*memPublisher).published(); fmt.Sprint(got) != fmt.Sprint(want) {
+		t.Fatalf("published %v; want %v", got, want)
+	}
+}
+
+func TestRelay_StopsAtFirstFailureToKeepOrder(t *testing.T) {
+	s := newTestStore(t)
+	ctx := context.Background()
+	createUsers(t, s, 3)
+	pub := &memPublisher{failOn: "user.created/2"}
+	relay := newRelay(s, pub, 10, time.Hour)
+
+	if n, err := relay.RelayOnce(ctx); err == nil || n != 1 {
+		t.Fatalf("RelayOnce = %d, %v; want 1 and an error", n, err)
+	}
+	if got := pub.published(); len(got) != 1 {
+		t.Fatalf("published %v; row 3 must wait for row 2", got)
+	}
+	pending, _ := s.PendingCount(ctx)
+	if pending != 2 {
+		t.Fatalf("pending = %d; want 2", pending)
+	}
+}
+
+func TestRelay_CrashAfterPublishIsDeduplicatedByBroker(t *testing.T) {
+	s := newTestStore(t)
+	js := newTestJetStream(t)
+	ctx := context.Background()
+	createUsers(t, s, 2)
+	bus := &jsPublisher{js: js}
+	outbox := &forgetfulStore{OutboxStore: s}
+	relay := newRelay(outbox, bus, 10, time.Hour)
+
+	outbox.ForgetNext.Store(true)
+	if _, err := relay.RelayOnce(ctx); !errors.Is(err, errCrashed) {
+		t.Fatalf("first RelayOnce: err = %v; want errCrashed", err)
+	}
+	// The restarted relay publishes user 1 again, then user 2.
+	if n, err := relay.RelayOnce(ctx); err != nil || n != 2 {
+		t.Fatalf("second RelayOnce = %d, %v; want 2", n, err)
+	}
+
+	if got := bus.Duplicates.Load(); got != 1 {
+		t.Errorf("broker duplicates = %d; want 1", got)
+	}
+	info, err := js.Stream(ctx, streamName)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msgs := info.CachedInfo().State.Msgs; msgs != 2 {
+		t.Fatalf("stream holds %d messages; want 2", msgs)
+	}
+}
+
+func TestWelcome_RedeliveryAfterLostAckIsIgnored(t *testing.T) {
+	s := newTestStore(t)
+	js := newTestJetStream(t)
+	ctx := context.Background()
+	w, err := openWelcomeService(filepath.Join(t.TempDir(), "welcome.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	var crashed atomic.Bool
+	w.crashBeforeAck = func(string) bool { return crashed.CompareAndSwap(false, true) }
+	cc, err := w.Start(ctx, js, 200*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Stop()
+
+	createUsers(t, s, 1)
+	if _, err := newRelay(s, &jsPublisher{js: js}, 10, time.Hour).RelayOnce(ctx); err != nil {
+		t.Fatal(err)
+	}
+
+	waitUntil(t, "the redelivery", func() bool { return w.Duplicates.Load() == 1 })
+	if n, _ := w.Emails(ctx); n != 1 || w.Handled.Load() != 1 {
+		t.Fatalf("emails=%d handled=%d; want 1 and 1", n, w.Handled.Load())
+	}
+}
+
+// TestEndToEnd_OneEmailPerUserDespiteFaults runs the relay and the consumer
+// while the broker flaps, the relay keeps forgetting what it published and
+// the consumer keeps losing acks.
+func TestEndToEnd_OneEmailPerUserDespiteFaults(t *testing.T) {
+	s := newTestStore(t)
+	js := newTestJetStream(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	w, err := openWelcomeService(filepath.Join(t.TempDir(), "welcome.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer w.Close()
+	w.crashBeforeAck = func(string) bool { return rand.IntN(4) == 0 }
+	cc, err := w.Start(ctx, js, 100*time.Millisecond)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer cc.Stop()
+
+	pub := &flakyPublisher{Publisher: &jsPublisher{js: js}}
+	outbox := &forgetfulStore{OutboxStore: s}
+	relayDone := make(chan struct{})
+	go func() {
+		newRelay(outbox, pub, 5, 10*time.Millisecond).Run(ctx, nil)
+		close(relayDone)
+	}()
+	defer func() { cancel(); <-relayDone }()
+
+	const users = 30
+	for i := 1; i <= users; i++ {
+		pub.Down.Store(rand.IntN(3) == 0)
+		outbox.ForgetNext.Store(rand.IntN(3) == 0)
+		if _, err := s.CreateUser(ctx, "u", fmt.Sprintf("u%d@example.com", i)); err != nil {
+			t.Fatal(err)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+	pub.Down.Store(false)
+
+	waitUntil(t, "every user's email", func() bool {
+		n, _ := w.Emails(ctx)
+		pending, _ := s.PendingCount(ctx)
+		return n == users && pending == 0
+	})
+	if got := w.Handled.Load(); got != users {
+		t.Fatalf("handled %d events; want exactly %d", got, users)
+	}
+}
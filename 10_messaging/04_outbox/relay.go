@@ -0,0 +1,86 @@
+package main
+
+import (
+	"context"
+	"log"
+	"time"
+)
+
+// Publisher sends one message to the bus. It must return only after the
+// bus has stored the message, and should use key to drop repeats.
+type Publisher interface {
+	Publish(ctx context.Context, subject string, payload []byte, key string) error
+}
+
+// OutboxStore is the part of Store the relay uses.
+type OutboxStore interface {
+	Pending(ctx context.Context, limit int) ([]OutboxRow, error)
+	MarkPublished(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, cause error) error
+}
+
+// Relay moves outbox rows to the bus. It is the only writer to the bus for
+// these events, and it runs separately from request handling, so a slow or
+// unavailable broker delays events but never fails a CreateUser call.
+type Relay struct {
+	store     OutboxStore
+	pub       Publisher
+	batchSize int
+	interval  time.Duration
+}
+
+func newRelay(store OutboxStore, pub Publisher, batchSize int, interval time.Duration) *Relay {
+	return &Relay{store: store, pub: pub, batchSize: batchSize, interval: interval}
+}
+
+// Run relays until ctx is done. Between batches it waits for interval, or
+// for a signal on wake, so new rows go out without waiting a full poll.
+func (r *Relay) Run(ctx context.Context, wake <-chan struct{}) {
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+	for {
+		for {
+			n, err := r.RelayOnce(ctx)
+			if err != nil && ctx.Err() == nil {
+				log.Printf("relay: %v", err)
+			}
+			if err != nil || n < r.batchSize {
+				break // caught up, or retry on the next tick
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		case <-wake:
+		}
+	}
+}
+
+// RelayOnce publishes one batch of pending rows in order and returns how
+// many were published. It stops at the first failure: publishing later
+// rows first would reorder events.
+//
+// Delivery is at-least-once. If the process dies after Publish succeeds
+// but before MarkPublished, the row is published again on restart. The
+// idempotency key turns that repeat into a no-op at the broker (within its
+// de-duplication window) and at consumers that remember processed keys,
+// which is as close to exactly-once as a network allows.
+func (r *Relay) RelayOnce(ctx context.Context) (int, error) {
+	rows, err := r.store.Pending(ctx, r.batchSize)
+	if err != nil {
+		return 0, err
+	}
+	for i, row := range rows {
+		if err := r.pub.Publish(ctx, row.Subject, row.Payload, row.IdempotencyKey); err != nil {
+			if markErr := r.store.MarkFailed(ctx, row.ID, err); markErr != nil {
+				log.Printf("relay: mark %s failed: %v", row.IdempotencyKey, markErr)
+			}
+			return i, err
+		}
+		if err := r.store.MarkPublished(ctx, row.ID); err != nil {
+			return i, err
+		}
+	}
+	return len(rows), nil
+}
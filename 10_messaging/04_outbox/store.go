@@ -0,0 +1,195 @@
+package main
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// User is a row of the users table.
+type User struct {
+	ID    int64
+	Name  string
+	Email string
+}
+
+// OutboxRow is a message waiting to be published. IdempotencyKey goes out
+// with the message, so the broker and consumers can recognise a repeat.
+type OutboxRow struct {
+	ID             int64
+	IdempotencyKey string
+	Subject        string
+	Payload        []byte
+	Attempts       int
+}
+
+// ErrEmailTaken is returned by CreateUser for a duplicate email.
+var ErrEmailTaken = errors.New("email already registered")
+
+const schema = `
+CREATE TABLE IF NOT EXISTS users (
+	id    INTEGER PRIMARY KEY AUTOINCREMENT,
+	name  TEXT NOT NULL,
+	email TEXT NOT NULL UNIQUE
+);
+CREATE TABLE IF NOT EXISTS outbox (
+	id              INTEGER PRIMARY KEY AUTOINCREMENT,
+	idempotency_key TEXT NOT NULL UNIQUE,
+	subject         TEXT NOT NULL,
+	payload         BLOB NOT NULL,
+	created_at      TIMESTAMP NOT NULL,
+	published_at    TIMESTAMP,
+	attempts        INTEGER NOT NULL DEFAULT 0,
+	last_error      TEXT
+);
+CREATE INDEX IF NOT EXISTS outbox_pending ON outbox (id) WHERE published_at IS NULL;`
+
+// Store is the user service's database: its own table plus the outbox.
+type Store struct {
+	db *sql.DB
+
+	// beforeCommit, if set, runs inside CreateUser's transaction after both
+	// inserts. Tests use it to fail the transaction at the last moment.
+	beforeCommit func() error
+}
+
+func openStore(path string) (*Store, error) {
+	db, err := openDB(path, schema)
+	if err != nil {
+		return nil, err
+	}
+	return &Store{db: db}, nil
+}
+
+// openDB opens a SQLite database in WAL mode and applies schema. One open
+// connection serialises writers, which SQLite requires anyway.
+func openDB(path, schema string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(1)")
+	if err != nil {
+		return nil, err
+	}
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+func (s *Store) Close() error { return s.db.Close() }
+
+// CreateUser inserts the user and its user.created message in one
+// transaction. Either both rows exist or neither does: there is no window
+// where the user is saved but the event is lost, or the event goes out for
+// a user that was rolled back. Publishing to the broker directly from here
+// would have exactly that problem, because the broker is not part of the
+// transaction.
+func (s *Store) CreateUser(ctx context.Context, name, email string) (User, error) {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return User{}, err
+	}
+	defer tx.Rollback() // no-op after Commit
+
+	// ON CONFLICT turns a duplicate email into zero rows inserted, which
+	// needs no driver-specific error to recognise.
+	res, err := tx.ExecContext(ctx,
+		`INSERT INTO users (name, email) VALUES (?, ?) ON CONFLICT (email) DO NOTHING`, name, email)
+	if err != nil {
+		return User{}, err
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return User{}, err
+	} else if n == 0 {
+		return User{}, ErrEmailTaken
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return User{}, err
+	}
+	u := User{ID: id, Name: name, Email: email}
+
+	payload, err := encodeUserCreated(u)
+	if err != nil {
+		return User{}, err
+	}
+	// The key is derived from the change itself, so it is the same no
+	// matter how often the row is published.
+	key := fmt.Sprintf("user.created/%d", id)
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO outbox (idempotency_key, subject, payload, created_at) VALUES (?, ?, ?, ?)`,
+		key, subjectUserCreated, payload, time.Now().UTC()); err != nil {
+		return User{}, err
+	}
+
+	if s.beforeCommit != nil {
+		if err := s.beforeCommit(); err != nil {
+			return User{}, err
+		}
+	}
+	return u, tx.Commit()
+}
+
+// Users returns all users ordered by ID.
+func (s *Store) Users(ctx context.Context) ([]User, error) {
+	rows, err := s.db.QueryContext(ctx, `SELECT id, name, email FROM users ORDER BY id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var users []User
+	for rows.Next() {
+		var u User
+		if err := rows.Scan(&u.ID, &u.Name, &u.Email); err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+// Pending returns up to limit unpublished rows, oldest first.
+func (s *Store) Pending(ctx context.Context, limit int) ([]OutboxRow, error) {
+	rows, err := s.db.QueryContext(ctx, `
+		SELECT id, idempotency_key, subject, payload, attempts
+		FROM outbox WHERE published_at IS NULL ORDER BY id LIMIT ?`, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []OutboxRow
+	for rows.Next() {
+		var r OutboxRow
+		if err := rows.Scan(&r.ID, &r.IdempotencyKey, &r.Subject, &r.Payload, &r.Attempts); err != nil {
+			return nil, err
+		}
+		out = append(out, r)
+	}
+	return out, rows.Err()
+}
+
+// MarkPublished records that the broker has accepted row id.
+func (s *Store) MarkPublished(ctx context.Context, id int64) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE outbox SET published_at = ?, attempts = attempts + 1, last_error = NULL WHERE id = ?`,
+		time.Now().UTC(), id)
+	return err
+}
+
+// MarkFailed records a failed publish attempt for row id.
+func (s *Store) MarkFailed(ctx context.Context, id int64, cause error) error {
+	_, err := s.db.ExecContext(ctx,
+		`UPDATE outbox SET attempts = attempts + 1, last_error = ? WHERE id = ?`, cause.Error(), id)
+	return err
+}
+
+// PendingCount returns the number of unpublished rows.
+func (s *Store) PendingCount(ctx context.Context) (int, error) {
+	var n int
+	err := s.db.QueryRowContext(ctx, `SELECT COUNT(*) FROM outbox WHERE published_at IS NULL`).Scan(&n)
+	return n, err
+}
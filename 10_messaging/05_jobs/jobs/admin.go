@@ -0,0 +1,92 @@
+package jobs
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"time"
+)
+
+// Stats counts jobs by state.
+type Stats struct {
+	Pending int // due or scheduled, not leased
+	Running int // leased
+	Dead    int
+}
+
+// Stats returns the current counts.
+func (q *Queue) Stats(ctx context.Context) (Stats, error) {
+	var s Stats
+	now := q.now().UnixMilli()
+	err := q.db.QueryRowContext(ctx, `
+		SELECT
+			(SELECT count(*) FROM jobs WHERE leased_until IS NULL OR leased_until <= ?),
+			(SELECT count(*) FROM jobs WHERE leased_until > ?),
+			(SELECT count(*) FROM dead_jobs)`, now, now).Scan(&s.Pending, &s.Running, &s.Dead)
+	return s, err
+}
+
+// DeadJob is a job that exhausted its attempts or failed permanently.
+type DeadJob struct {
+	ID        int64
+	Kind      string
+	Payload   json.RawMessage
+	Attempts  int
+	LastError string
+	FailedAt  time.Time
+}
+
+// DeadJobs lists dead-lettered jobs, oldest first.
+func (q *Queue) DeadJobs(ctx context.Context) ([]DeadJob, error) {
+	rows, err := q.db.QueryContext(ctx,
+		`SELECT id, kind, payload, attempts, last_error, failed_at FROM dead_jobs ORDER BY failed_at, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []DeadJob
+	for rows.Next() {
+		var d DeadJob
+		var payload []byte
+		var failedAt int64
+		if err := rows.Scan(&d.ID, &d.Kind, &payload, &d.Attempts, &d.LastError, &failedAt); err != nil {
+			return nil, err
+		}
+		d.Payload = payload
+		d.FailedAt = time.UnixMilli(failedAt)
+		out = append(out, d)
+	}
+	return out, rows.Err()
+}
+
+// Retry moves a dead job back into the queue with fresh attempts, after
+// whatever made it fail has been fixed.
+func (q *Queue) Retry(ctx context.Context, id int64) error {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	now := q.now().UnixMilli()
+	res, err := tx.ExecContext(ctx, `
+		INSERT INTO jobs (id, kind, payload, max_attempts, run_at, created_at)
+		SELECT id, kind, payload, ?, ?, ? FROM dead_jobs WHERE id = ?`,
+		q.opts.MaxAttempts, now, now, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	if _, err := tx.ExecContext(ctx, `DELETE FROM dead_jobs WHERE id = ?`, id); err != nil {
+		return err
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return nil
+}
@@ -0,0 +1,299 @@
+// Package jobs is a persistent background-job queue backed by SQLite.
+//
+// Enqueue stores a job; workers claim jobs with a lease (the visibility
+// timeout), run the handler registered for the job's kind, and then delete
+// the job, schedule a retry with backoff, or move it to the dead-letter
+// table. A worker that crashes never releases its lease; the job becomes
+// visible again when the lease expires and another worker runs it. Jobs
+// therefore run at least once, and handlers must be idempotent.
+package jobs
+
+import (
+	"context"
+	crand "crypto/rand"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"time"
+
+	_ "modernc.org/sqlite"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+// ErrPermanent marks a handler error that retrying cannot fix. Wrap it
+// (fmt.Errorf("bad address: %w", jobs.ErrPermanent)) to dead-letter the
+// job at once.
+var ErrPermanent = errors.New("permanent failure")
+
+// ErrLeaseLost is returned when finishing a job whose lease expired and
+// which another worker may have claimed since.
+var ErrLeaseLost = errors.New("job lease lost")
+
+const schema = `
+CREATE TABLE IF NOT EXISTS jobs (
+	id           INTEGER PRIMARY KEY AUTOINCREMENT, -- never reused, even when the table empties
+	kind         TEXT    NOT NULL,
+	payload      BLOB    NOT NULL,
+	attempts     INTEGER NOT NULL DEFAULT 0,
+	max_attempts INTEGER NOT NULL,
+	run_at       INTEGER NOT NULL, -- unix ms: not before
+	lease        TEXT,             -- token of the worker holding it
+	leased_until INTEGER,          -- unix ms: invisible until then
+	last_error   TEXT,
+	created_at   INTEGER NOT NULL
+);
+CREATE INDEX IF NOT EXISTS jobs_ready ON jobs (run_at, id);
+CREATE TABLE IF NOT EXISTS dead_jobs (
+	id         INTEGER PRIMARY KEY,
+	kind       TEXT    NOT NULL,
+	payload    BLOB    NOT NULL,
+	attempts   INTEGER NOT NULL,
+	last_error TEXT    NOT NULL,
+	failed_at  INTEGER NOT NULL
+);`
+
+// Options configures a Queue. Zero values select the defaults.
+type Options struct {
+	// VisibilityTimeout is how long a claimed job stays invisible to other
+	// workers (default 30s). The handler's context expires at the same
+	// time, so a slow job is abandoned rather than run twice in parallel.
+	VisibilityTimeout time.Duration
+	// MaxAttempts is the default number of runs before a job is
+	// dead-lettered (default 5).
+	MaxAttempts int
+	// Backoff returns the delay before retry n (n >= 1). The default is
+	// exponential from 1s, capped at 5m, with full jitter.
+	Backoff func(n int) time.Duration
+	// PollInterval is how often idle workers look for due jobs (default
+	// 1s). Enqueue wakes them at once, so this only matters for retries,
+	// delayed jobs and expired leases.
+	PollInterval time.Duration
+	// Clock says when jobs are due, leases expire and idle workers poll
+	// (default clock.Real). Tests pass a fake one to run a day of delays
+	// and backoff in milliseconds. A handler's context deadline is always
+	// real time.
+	Clock clock.Clock
+}
+
+// Job is a claimed job, as passed to a Handler.
+type Job struct {
+	ID          int64
+	Kind        string
+	Payload     json.RawMessage
+	Attempt     int // 1 on the first run
+	MaxAttempts int
+
+	lease string
+}
+
+// Decode unmarshals the payload into v.
+func (j *Job) Decode(v any) error { return json.Unmarshal(j.Payload, v) }
+
+// Handler runs one job. Returning nil completes it; an error retries it
+// with backoff, or dead-letters it after the last attempt or if the error
+// wraps ErrPermanent.
+type Handler func(ctx context.Context, job *Job) error
+
+// Queue is safe for concurrent use.
+type Queue struct {
+	db   *sql.DB
+	opts Options
+
+	mu       sync.RWMutex
+	handlers map[string]Handler
+
+	wake chan struct{}
+}
+
+// Open opens (creating if needed) the queue database at path.
+func Open(path string, opts Options) (*Queue, error) {
+	// WAL lets readers and one writer proceed together; busy_timeout makes
+	// concurrent writers wait for the lock instead of failing with
+	// SQLITE_BUSY.
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	// Every queue operation writes, and SQLite allows one writer at a
+	// time. One connection makes workers wait their turn in database/sql's
+	// pool, in order, instead of in SQLite's busy handler, which sleeps
+	// and can starve a worker long enough for its lease to expire.
+	db.SetMaxOpenConns(1)
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	if opts.VisibilityTimeout <= 0 {
+		opts.VisibilityTimeout = 30 * time.Second
+	}
+	if opts.MaxAttempts <= 0 {
+		opts.MaxAttempts = 5
+	}
+	if opts.Backoff == nil {
+		opts.Backoff = defaultBackoff
+	}
+	if opts.PollInterval <= 0 {
+		opts.PollInterval = time.Second
+	}
+	if opts.Clock == nil {
+		opts.Clock = clock.Real()
+	}
+	return &Queue{
+		db:       db,
+		opts:     opts,
+		handlers: map[string]Handler{},
+		wake:     make(chan struct{}, 1),
+	}, nil
+}
+
+// Close closes the database. Stop the workers first.
+func (q *Queue) Close() error { return q.db.Close() }
+
+func (q *Queue) now() time.Time { return q.opts.Clock.Now() }
+
+func defaultBackoff(n int) time.Duration {
+	d := time.Second << min(n-1, 20)
+	d = min(d, 5*time.Minute)
+	return rand.N(d) + 1 // full jitter spreads out retries of a failed batch
+}
+
+// Handle registers the handler for kind.
+func (q *Queue) Handle(kind string, h Handler) {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+	q.handlers[kind] = h
+}
+
+// EnqueueOption adjusts one job.
+type EnqueueOption func(*enqueueOpts)
+
+type enqueueOpts struct {
+	delay       time.Duration
+	maxAttempts int
+}
+
+// Delay makes the job due after d instead of now.
+func Delay(d time.Duration) EnqueueOption { return func(o *enqueueOpts) { o.delay = d } }
+
+// MaxAttempts overrides Options.MaxAttempts for this job.
+func MaxAttempts(n int) EnqueueOption { return func(o *enqueueOpts) { o.maxAttempts = n } }
+
+// Enqueue stores a job of kind with payload marshalled to JSON and returns
+// its ID. The job is durable once Enqueue returns.
+func (q *Queue) Enqueue(ctx context.Context, kind string, payload any, opts ...EnqueueOption) (int64, error) {
+	o := enqueueOpts{maxAttempts: q.opts.MaxAttempts}
+	for _, opt := range opts {
+		opt(&o)
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return 0, fmt.Errorf("enqueue %s: %w", kind, err)
+	}
+	now := q.now()
+	res, err := q.db.ExecContext(ctx,
+		`INSERT INTO jobs (kind, payload, max_attempts, run_at, created_at) VALUES (?, ?, ?, ?, ?)`,
+		kind, body, o.maxAttempts, now.Add(o.delay).UnixMilli(), now.UnixMilli())
+	if err != nil {
+		return 0, fmt.Errorf("enqueue %s: %w", kind, err)
+	}
+	select {
+	case q.wake <- struct{}{}:
+	default:
+	}
+	return res.LastInsertId()
+}
+
+// claim leases the next due job, or returns nil if there is none. A job is
+// due when its run_at has passed and it has no live lease; an expired
+// lease means its worker crashed or gave up. The single UPDATE ...
+// RETURNING is atomic, so two workers never claim the same job.
+func (q *Queue) claim(ctx context.Context) (*Job, error) {
+	now := q.now()
+	lease := newLease()
+	row := q.db.QueryRowContext(ctx, `
+		UPDATE jobs
+		SET lease = ?, leased_until = ?, attempts = attempts + 1
+		WHERE id = (
+			SELECT id FROM jobs
+			WHERE run_at <= ? AND (leased_until IS NULL OR leased_until <= ?)
+			ORDER BY run_at, id
+			LIMIT 1
+		)
+		RETURNING id, kind, payload, attempts, max_attempts`,
+		lease, now.Add(q.opts.VisibilityTimeout).UnixMilli(), now.UnixMilli(), now.UnixMilli())
+	j := &Job{lease: lease}
+	var payload []byte
+	err := row.Scan(&j.ID, &j.Kind, &payload, &j.Attempt, &j.MaxAttempts)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	j.Payload = payload
+	return j, nil
+}
+
+func newLease() string { return crand.Text() }
+
+// complete deletes a finished job, if this worker still holds its lease.
+func (q *Queue) complete(ctx context.Context, j *Job) error {
+	res, err := q.db.ExecContext(ctx, `DELETE FROM jobs WHERE id = ? AND lease = ?`, j.ID, j.lease)
+	if err != nil {
+		return err
+	}
+	return checkLease(res)
+}
+
+// fail schedules a retry, or moves the job to dead_jobs after its last
+// attempt or on a permanent error.
+func (q *Queue) fail(ctx context.Context, j *Job, cause error) error {
+	if j.Attempt >= j.MaxAttempts || errors.Is(cause, ErrPermanent) {
+		return q.bury(ctx, j, cause.Error())
+	}
+	retryAt := q.now().Add(q.opts.Backoff(j.Attempt))
+	res, err := q.db.ExecContext(ctx,
+		`UPDATE jobs SET run_at = ?, lease = NULL, leased_until = NULL, last_error = ? WHERE id = ? AND lease = ?`,
+		retryAt.UnixMilli(), cause.Error(), j.ID, j.lease)
+	if err != nil {
+		return err
+	}
+	return checkLease(res)
+}
+
+// bury moves a job to dead_jobs in one transaction.
+func (q *Queue) bury(ctx context.Context, j *Job, reason string) error {
+	tx, err := q.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	res, err := tx.ExecContext(ctx, `DELETE FROM jobs WHERE id = ? AND lease = ?`, j.ID, j.lease)
+	if err != nil {
+		return err
+	}
+	if err := checkLease(res); err != nil {
+		return err
+	}
+	if _, err := tx.ExecContext(ctx,
+		`INSERT INTO dead_jobs (id, kind, payload, attempts, last_error, failed_at) VALUES (?, ?, ?, ?, ?, ?)`,
+		j.ID, j.Kind, []byte(j.Payload), j.Attempt, reason, q.now().UnixMilli()); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func checkLease(res sql.Result) error {
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if n == 0 {
+		return ErrLeaseLost
+	}
+	return nil
+}
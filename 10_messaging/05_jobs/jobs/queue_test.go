@@ -0,0 +1,396 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+func testOptions() Options {
+	return Options{
+		VisibilityTimeout: time.Second,
+		MaxAttempts:       3,
+		Backoff:           func(int) time.Duration { return 20 * time.Millisecond },
+		PollInterval:      10 * time.Millisecond,
+	}
+}
+
+func openAt(t *testing.T, path string, opts Options) *Queue {
+	t.Helper()
+	q, err := Open(path, opts)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	t.Cleanup(func() { q.Close() })
+	return q
+}
+
+func newTestQueue(t *testing.T, opts Options) *Queue {
+	t.Helper()
+	return openAt(t, filepath.Join(t.TempDir(), "jobs.db"), opts)
+}
+
+// start runs n workers until the test ends.
+func start(t *testing.T, q *Queue, n int) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx, n)
+		close(done)
+	}()
+	t.Cleanup(func() { cancel(); <-done })
+}
+
+func enqueue(t *testing.T, q *Queue, kind string, payload any, opts ...EnqueueOption) int64 {
+	t.Helper()
+	id, err := q.Enqueue(context.Background(), kind, payload, opts...)
+	if err != nil {
+		t.Fatalf("Enqueue: %v", err)
+	}
+	return id
+}
+
+func waitUntil(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(10 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("timed out waiting for %s", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func stats(t *testing.T, q *Queue) Stats {
+	t.Helper()
+	s, err := q.Stats(context.Background())
+	if err != nil {
+		t.Fatal(err)
+	}
+	return s
+}
+
+func TestEnqueue_RunsHandlerAndDeletesJob(t *testing.T) {
+	q := newTestQueue(t, testOptions())
+	got := make(chan string, 1)
+	q.Handle("greet", func(_ context.Context, j *Job) error {
+		var p struct{ Name string }
+		if err := j.Decode(&p); err != nil {
+			return err
+		}
+		got <- p.Name
+		return nil
+	})
+	start(t, q, 1)
+
+	enqueue(t, q, "greet", map[string]string{"name": "Ann"})
+	select {
+	case name := <-got:
+		if name != "Ann" {
+			t.Fatalf("handler got %q; want Ann", name)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("handler never ran")
+	}
+	waitUntil(t, "the job to be deleted", func() bool { return stats(t, q) == Stats{} })
+}
+
+func TestEnqueue_Delay(t *testing.T) {
+	q := newTestQueue(t, testOptions())
+	ran := make(chan time.Time, 1)
+	q.Handle("later", func(context.Context, *Job) error { ran <- time.Now(); return nil })
+	start(t, q, 1)
+
+	enqueued := time.Now()
+	enqueue(t, q, "later", nil, Delay(200*time.Millisecond))
+	if at := <-ran; at.Sub(enqueued) < 200*time.Millisecond {
+		t.Fatalf("ran after %v; want at least the 200ms delay", at.Sub(enqueued))
+	}
+}
+
+func TestFail_RetriesWithBackoff(t *testing.T) {
+	q := newTestQueue(t, testOptions())
+	var mu sync.Mutex
+	var attempts []int
+	var times []time.Time
+	q.Handle("flaky", func(_ context.Context, j *Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		attempts = append(attempts, j.Attempt)
+		times = append(times, time.Now())
+		if j.Attempt < 3 {
+			return errors.New("smtp timeout")
+		}
+		return nil
+	})
+	start(t, q, 2)
+
+	enqueue(t, q, "flaky", nil)
+	waitUntil(t, "the third attempt to succeed", func() bool { return stats(t, q) == Stats{} })
+	mu.Lock()
+	defer mu.Unlock()
+	if fmt.Sprint(attempts) != "[1 2 3]" {
+		t.Fatalf("attempts = %v; want [1 2 3]", attempts)
+	}
+	for i := 1; i < len(times); i++ {
+		if gap := times[i].Sub(times[i-1]); gap < 20*time.Millisecond {
+			t.Errorf("retry %d after %v; want at least the 20ms backoff", i, gap)
+		}
+	}
+}
+
+func TestFakeClock_DelayAndBackoff(t *testing.T) {
+	epoch := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	fake := clock.NewFake(epoch)
+	opts := testOptions()
+	opts.Clock = fake
+	opts.PollInterval = time.Hour
+	opts.Backoff = func(int) time.Duration { return 6 * time.Hour }
+	q := newTestQueue(t, opts)
+	var mu sync.Mutex
+	var runs []time.Time // fake time of each attempt
+	q.Handle("report", func(context.Context, *Job) error {
+		mu.Lock()
+		defer mu.Unlock()
+		runs = append(runs, fake.Now())
+		if len(runs) == 1 {
+			return errors.New("mail server down")
+		}
+		return nil
+	})
+	start(t, q, 1)
+
+	enqueue(t, q, "report", nil, Delay(24*time.Hour))
+	// A fake hour per step: the worker's poll falls due each time. The
+	// day of delay and the retry take a few dozen steps of real time.
+	waitUntil(t, "the retry to succeed", func() bool {
+		fake.Advance(time.Hour)
+		return stats(t, q) == Stats{}
+	})
+
+	mu.Lock()
+	defer mu.Unlock()
+	if len(runs) != 2 {
+		t.Fatalf("ran %d times; want 2", len(runs))
+	}
+	if due := epoch.Add(24 * time.Hour); runs[0].Before(due) {
+		t.Errorf("first run at %v; want not before %v", runs[0], due)
+	}
+	if gap := runs[1].Sub(runs[0]); gap < 6*time.Hour {
+		t.Errorf("retry %v after the failure; want at least the 6h backoff", gap)
+	}
+}
+
+func TestFail_DeadLetters(t *testing.T) {
+	tests := []struct {
+		name, kind   string
+		err          error
+		wantAttempts int
+		wantError    string
+	}{
+		{"after max attempts", "broken", errors.New("still down"), 3, "still down"},
+		{"permanent error", "invalid", fmt.Errorf("no such user: %w", ErrPermanent), 1, "no such user"},
+		{"panic", "buggy", nil, 3, "panicked: nil map"},
+		{"unknown kind", "nobody-handles-this", nil, 1, "no handler"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newTestQueue(t, testOptions())
+			q.Handle("broken", func(context.Context, *Job) error { return tt.err })
+			q.Handle("invalid", func(context.Context, *Job) error { return tt.err })
+			q.Handle("buggy", func(context.Context, *Job) error { panic("nil map") })
+			start(t, q, 1)
+
+			id := enqueue(t, q, tt.kind, map[string]int{"user_id": 7})
+			waitUntil(t, "the dead letter", func() bool { return stats(t, q).Dead == 1 })
+			dead, err := q.DeadJobs(context.Background())
+			if err != nil {
+				t.Fatal(err)
+			}
+			d := dead[0]
+			if d.ID != id || d.Kind != tt.kind || d.Attempts != tt.wantAttempts || !strings.Contains(d.LastError, tt.wantError) {
+				t.Fatalf("dead job = %+v; want id %d after %d attempts with %q", d, id, tt.wantAttempts, tt.wantError)
+			}
+			if string(d.Payload) != `{"user_id":7}` {
+				t.Errorf("payload = %s; want it kept for inspection", d.Payload)
+			}
+			if s := stats(t, q); s.Pending+s.Running != 0 {
+				t.Errorf("stats = %+v; want the job gone from the queue", s)
+			}
+		})
+	}
+}
+
+func TestRetry_RequeuesDeadJob(t *testing.T) {
+	q := newTestQueue(t, testOptions())
+	var fixed atomic.Bool
+	q.Handle("send", func(context.Context, *Job) error {
+		if !fixed.Load() {
+			return ErrPermanent
+		}
+		return nil
+	})
+	start(t, q, 1)
+
+	id := enqueue(t, q, "send", nil)
+	waitUntil(t, "the dead letter", func() bool { return stats(t, q).Dead == 1 })
+	fixed.Store(true)
+	if err := q.Retry(context.Background(), id); err != nil {
+		t.Fatal(err)
+	}
+	waitUntil(t, "the retried job to finish", func() bool { return stats(t, q) == Stats{} })
+}
+
+// TestCrashRecovery_JobSurvivesWorkerCrash claims a job and then "crashes":
+// the process goes away without finishing the job or releasing the lease.
+// A new process on the same database picks it up once the lease expires.
+func TestCrashRecovery_JobSurvivesWorkerCrash(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "jobs.db")
+	opts := testOptions()
+	opts.VisibilityTimeout = 300 * time.Millisecond
+
+	crashed := openAt(t, path, opts)
+	id := enqueue(t, crashed, "email", nil)
+	job, err := crashed.claim(context.Background())
+	if err != nil || job == nil || job.ID != id {
+		t.Fatalf("claim = %+v, %v; want job %d", job, err, id)
+	}
+	claimedAt := time.Now()
+	crashed.Close()
+
+	restarted := openAt(t, path, opts)
+	ran := make(chan *Job, 1)
+	restarted.Handle("email", func(_ context.Context, j *Job) error { ran <- j; return nil })
+	start(t, restarted, 2)
+
+	select {
+	case j := <-ran:
+		if since := time.Since(claimedAt); since < opts.VisibilityTimeout {
+			t.Errorf("rerun %v after the crash; want no sooner than the %v lease", since, opts.VisibilityTimeout)
+		}
+		if j.ID != id || j.Attempt != 2 {
+			t.Errorf("rerun job %d attempt %d; want job %d attempt 2", j.ID, j.Attempt, id)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("job lost after the crash")
+	}
+	waitUntil(t, "the job to be deleted", func() bool { return stats(t, restarted) == Stats{} })
+}
+
+// TestCrashRecovery_CrashLoopDeadLetters covers a job that crashes its
+// worker every time: it must not be retried forever.
+func TestCrashRecovery_CrashLoopDeadLetters(t *testing.T) {
+	opts := testOptions()
+	opts.VisibilityTimeout = 20 * time.Millisecond
+	q := newTestQueue(t, opts)
+	enqueue(t, q, "oom", nil, MaxAttempts(2))
+	ctx := context.Background()
+
+	for range 2 {
+		if j, err := q.claim(ctx); err != nil || j == nil {
+			t.Fatalf("claim = %v, %v", j, err)
+		}
+		time.Sleep(30 * time.Millisecond) // crash; the lease expires
+	}
+	j, err := q.claim(ctx)
+	if err != nil || j == nil || j.Attempt != 3 {
+		t.Fatalf("third claim = %+v, %v; want attempt 3", j, err)
+	}
+	if err := q.process(j); err != nil {
+		t.Fatal(err)
+	}
+	dead, _ := q.DeadJobs(ctx)
+	if len(dead) != 1 || !strings.Contains(dead[0].LastError, "worker crashed") {
+		t.Fatalf("dead = %+v; want the crash-looping job", dead)
+	}
+}
+
+func TestLease_SlowWorkerCannotFinishReclaimedJob(t *testing.T) {
+	opts := testOptions()
+	opts.VisibilityTimeout = 20 * time.Millisecond
+	q := newTestQueue(t, opts)
+	enqueue(t, q, "report", nil)
+	ctx := context.Background()
+
+	slow, _ := q.claim(ctx)
+	time.Sleep(30 * time.Millisecond)
+	fast, _ := q.claim(ctx)
+	if fast == nil || fast.ID != slow.ID {
+		t.Fatalf("second claim = %+v; want the expired job", fast)
+	}
+	if err := q.complete(ctx, slow); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("slow worker complete: err = %v; want ErrLeaseLost", err)
+	}
+	if err := q.fail(ctx, slow, errors.New("late")); !errors.Is(err, ErrLeaseLost) {
+		t.Fatalf("slow worker fail: err = %v; want ErrLeaseLost", err)
+	}
+	if err := q.complete(ctx, fast); err != nil {
+		t.Fatalf("current holder complete: %v", err)
+	}
+}
+
+func TestRun_ConcurrentWorkersRunEachJobOnce(t *testing.T) {
+	q := newTestQueue(t, testOptions())
+	var mu sync.Mutex
+	runs := map[int64]int{}
+	q.Handle("n", func(_ context.Context, j *Job) error {
+		mu.Lock()
+		runs[j.ID]++
+		mu.Unlock()
+		return nil
+	})
+	start(t, q, 8)
+
+	const jobs = 200
+	for i := range jobs {
+		enqueue(t, q, "n", i)
+	}
+	waitUntil(t, "all jobs", func() bool { return stats(t, q) == Stats{} })
+	mu.Lock()
+	defer mu.Unlock()
+	if len(runs) != jobs {
+		t.Fatalf("%d distinct jobs ran; want %d", len(runs), jobs)
+	}
+	for id, n := range runs {
+		if n != 1 {
+			t.Errorf("job %d ran %d times", id, n)
+		}
+	}
+}
+
+func TestRun_ShutdownFinishesInFlightJob(t *testing.T) {
+	q := newTestQueue(t, testOptions())
+	started := make(chan struct{})
+	q.Handle("slow", func(ctx context.Context, _ *Job) error {
+		close(started)
+		select {
+		case <-time.After(100 * time.Millisecond):
+			return nil
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	})
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		q.Run(ctx, 1)
+		close(done)
+	}()
+
+	enqueue(t, q, "slow", nil)
+	<-started
+	cancel()
+	<-done
+	if s := stats(t, q); s != (Stats{}) {
+		t.Fatalf("stats after shutdown = %+v; want the in-flight job finished and deleted", s)
+	}
+}
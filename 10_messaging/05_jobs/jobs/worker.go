@@ -0,0 +1,89 @@
+package jobs
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+// Run starts n workers and blocks until ctx is cancelled and every
+// in-flight job has finished. Cancelling ctx stops workers from claiming
+// new jobs; running jobs keep their own deadline (the visibility timeout),
+// so a shutdown does not turn them into failures.
+func (q *Queue) Run(ctx context.Context, n int) {
+	var wg sync.WaitGroup
+	for i := range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			q.work(ctx, fmt.Sprintf("worker-%d", i))
+		}()
+	}
+	wg.Wait()
+}
+
+func (q *Queue) work(ctx context.Context, name string) {
+	for {
+		job, err := q.claim(ctx)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("%s: claim: %v", name, err)
+		}
+		if job == nil {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+			case <-q.opts.Clock.After(q.opts.PollInterval):
+			}
+			continue
+		}
+		if err := q.process(job); err != nil {
+			log.Printf("%s: job %d (%s): %v", name, job.ID, job.Kind, err)
+		}
+	}
+}
+
+// process runs one claimed job and records the outcome. It uses its own
+// context: the job holds a lease, and the outcome must be written even if
+// the worker is shutting down.
+func (q *Queue) process(job *Job) error {
+	ctx, cancel := context.WithTimeout(context.Background(), q.opts.VisibilityTimeout)
+	defer cancel()
+
+	if job.Attempt > job.MaxAttempts {
+		// Every allowed run was claimed and none reported back: the job
+		// keeps crashing its worker or outliving its lease.
+		return q.bury(ctx, job, fmt.Sprintf("no result after %d attempts; worker crashed or lease expired", job.MaxAttempts))
+	}
+	q.mu.RLock()
+	h, ok := q.handlers[job.Kind]
+	q.mu.RUnlock()
+	if !ok {
+		return q.bury(ctx, job, fmt.Sprintf("no handler for kind %q", job.Kind))
+	}
+
+	err := runHandler(ctx, h, job)
+	// Record the outcome with a fresh deadline: the handler may have used
+	// up the lease's.
+	ctx, cancel = context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err == nil {
+		return q.complete(ctx, job)
+	}
+	if ferr := q.fail(ctx, job, err); ferr != nil {
+		return errors.Join(err, ferr)
+	}
+	return err
+}
+
+func runHandler(ctx context.Context, h Handler, job *Job) (err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			err = fmt.Errorf("handler panicked: %v", p)
+		}
+	}()
+	return h(ctx, job)
+}
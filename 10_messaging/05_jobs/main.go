@@ -0,0 +1,110 @@
+// Demonstrates a persistent background-job queue on SQLite with the jobs
+// package.
+//
+// This example shows:
+// - Enqueueing jobs that survive restarts
+// - N workers claiming jobs with a lease (visibility timeout)
+// - Retries with exponential backoff and jitter
+// - A dead-letter table for jobs that fail permanently or too often
+// - Crash recovery: kill the process mid-run and start it again
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"math/rand/v2"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"time"
+
+	"golang_roadmap/10_messaging/05_jobs/jobs"
+)
+
+type WelcomeEmail struct {
+	UserID int    `json:"user_id"`
+	Email  string `json:"email"`
+}
+
+func main() {
+	path := filepath.Join(os.TempDir(), "jobs-demo.db")
+	q, err := jobs.Open(path, jobs.Options{
+		VisibilityTimeout: 3 * time.Second,
+		MaxAttempts:       4,
+		Backoff:           func(n int) time.Duration { return time.Duration(n) * 300 * time.Millisecond },
+		PollInterval:      200 * time.Millisecond,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer q.Close()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	s, _ := q.Stats(ctx)
+	fmt.Printf("=== Queue %s ===\n", path)
+	fmt.Printf("  left over from earlier runs: %d pending, %d leased (by a crashed process?), %d dead\n", s.Pending, s.Running, s.Dead)
+
+	start := time.Now()
+	q.Handle("welcome_email", func(ctx context.Context, j *jobs.Job) error {
+		var p WelcomeEmail
+		if err := j.Decode(&p); err != nil {
+			return fmt.Errorf("bad payload: %w", jobs.ErrPermanent)
+		}
+		if p.Email == "" {
+			return fmt.Errorf("user %d has no email: %w", p.UserID, jobs.ErrPermanent)
+		}
+		select {
+		case <-time.After(300 * time.Millisecond): // talking to the mail server
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+		if p.UserID%4 == 0 && j.Attempt < 3 || rand.IntN(5) == 0 {
+			return errors.New("smtp: 421 service not available")
+		}
+		fmt.Printf("  %5.2fs sent welcome email to %-18s (job %d, attempt %d)\n",
+			time.Since(start).Seconds(), p.Email, j.ID, j.Attempt)
+		return nil
+	})
+
+	fmt.Println("\n=== Enqueueing ===")
+	for i := 1; i <= 10; i++ {
+		email := fmt.Sprintf("user%d@example.com", i)
+		if i == 7 {
+			email = "" // fails permanently
+		}
+		id, err := q.Enqueue(ctx, "welcome_email", WelcomeEmail{UserID: i, Email: email})
+		if err != nil {
+			log.Fatal(err)
+		}
+		fmt.Printf("  job %d: welcome email for user %d\n", id, i)
+	}
+	q.Enqueue(ctx, "resize_image", map[string]string{"file": "cat.png"}) // no handler registered
+
+	fmt.Println("\n=== Processing with 3 workers (Ctrl+C to stop gracefully; kill -9 to test crash recovery) ===")
+	workersCtx, stopWorkers := context.WithCancel(ctx)
+	done := make(chan struct{})
+	go func() {
+		q.Run(workersCtx, 3)
+		close(done)
+	}()
+	for {
+		s, err := q.Stats(ctx)
+		if err != nil || s.Pending+s.Running == 0 || ctx.Err() != nil {
+			break
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+	stopWorkers()
+	<-done
+
+	fmt.Println("\n=== Dead-letter table ===")
+	dead, _ := q.DeadJobs(context.Background())
+	for _, d := range dead {
+		fmt.Printf("  job %d %s after %d attempt(s): %s\n", d.ID, d.Kind, d.Attempts, d.LastError)
+	}
+	fmt.Println("Fix the cause, then requeue with q.Retry(ctx, id). Run again: the dead jobs are still there.")
+}
@@ -0,0 +1,190 @@
+// Package config loads the web server's configuration from layered
+// sources, validates it, and reloads it on SIGHUP.
+//
+// Precedence, lowest to highest:
+//
+//	defaults → config file (YAML, TOML or JSON) → environment → flags
+//
+// Every setting has one key, such as server.read_timeout. The key names
+// the setting in all sources: read_timeout under server in a file, the
+// environment variable APP_SERVER_READ_TIMEOUT, and the flag
+// -server.read_timeout.
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Config is the complete application configuration. Struct tags name each
+// setting: key is its path segment, secret marks values that must never be
+// printed, and usage is the flag help text.
+type Config struct {
+	Server ServerConfig `key:"server"`
+	Log    LogConfig    `key:"log"`
+	Auth   AuthConfig   `key:"auth"`
+	CORS   CORSConfig   `key:"cors"`
+	Jobs   JobsConfig   `key:"jobs"`
+}
+
+type ServerConfig struct {
+	Addr            string        `key:"addr" usage:"listen address (host:port)"`
+	ReadTimeout     time.Duration `key:"read_timeout" usage:"max time to read a request"`
+	WriteTimeout    time.Duration `key:"write_timeout" usage:"max time to write a response"`
+	IdleTimeout     time.Duration `key:"idle_timeout" usage:"keep-alive idle timeout"`
+	ShutdownTimeout time.Duration `key:"shutdown_timeout" usage:"grace period for in-flight requests on shutdown"`
+	// DebugAddr, if set, serves /debug/vars on a second listener. Keep it
+	// on a private address: it shows the command line and memory stats.
+	DebugAddr string `key:"debug_addr" usage:"listen address for /debug/vars (empty: disabled)"`
+}
+
+type LogConfig struct {
+	Level  string `key:"level" usage:"debug, info, warn or error"`
+	Format string `key:"format" usage:"text or json"`
+}
+
+type AuthConfig struct {
+	// APIKey, if set, is required as a Bearer token on write requests.
+	APIKey Secret `key:"api_key" usage:"bearer token for write requests (empty: no auth)"`
+	// KeysPath, if set, turns on per-client API keys (X-API-Key) stored in
+	// this SQLite file. APIKey then guards only the key management
+	// endpoints.
+	KeysPath string `key:"keys_path" usage:"SQLite file for per-client API keys (empty: keys disabled)"`
+}
+
+type CORSConfig struct {
+	// AllowedOrigins lists the browser origins that may call the API,
+	// separated by commas. It is a string, not a slice, so Config stays
+	// comparable.
+	AllowedOrigins string `key:"allowed_origins" usage:"comma-separated origins that may call the API from a browser, such as https://app.example.com,https://*.example.com (empty: none)"`
+}
+
+// Origins splits AllowedOrigins.
+func (c CORSConfig) Origins() []string {
+	var out []string
+	for _, o := range strings.Split(c.AllowedOrigins, ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			out = append(out, o)
+		}
+	}
+	return out
+}
+
+type JobsConfig struct {
+	Path    string `key:"path" usage:"SQLite file for the background job queue"`
+	Workers int    `key:"workers" usage:"number of background job workers"`
+}
+
+// Default returns the configuration used when no source overrides it.
+// Defaults live in code, not in a file, so the program runs with no
+// configuration at all.
+func Default() Config {
+	return Config{
+		Server: ServerConfig{
+			Addr:            ":8080",
+			ReadTimeout:     15 * time.Second,
+			WriteTimeout:    15 * time.Second,
+			IdleTimeout:     60 * time.Second,
+			ShutdownTimeout: 30 * time.Second,
+		},
+		Log:  LogConfig{Level: "info", Format: "text"},
+		Jobs: JobsConfig{Path: "jobs.db", Workers: 2},
+	}
+}
+
+// Validate reports every problem at once, not just the first, so a broken
+// deployment is fixed in one round trip.
+func (c Config) Validate() error {
+	var errs []error
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("server.addr: %w", err))
+	}
+	if a := c.Server.DebugAddr; a != "" {
+		if _, _, err := net.SplitHostPort(a); err != nil {
+			errs = append(errs, fmt.Errorf("server.debug_addr: %w", err))
+		} else if a == c.Server.Addr {
+			errs = append(errs, errors.New("server.debug_addr: must differ from server.addr"))
+		}
+	}
+	for key, d := range map[string]time.Duration{
+		"server.read_timeout":     c.Server.ReadTimeout,
+		"server.write_timeout":    c.Server.WriteTimeout,
+		"server.idle_timeout":     c.Server.IdleTimeout,
+		"server.shutdown_timeout": c.Server.ShutdownTimeout,
+	} {
+		if d <= 0 {
+			errs = append(errs, fmt.Errorf("%s: must be positive, got %v", key, d))
+		}
+	}
+	if _, err := c.Log.SlogLevel(); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+	if !slices.Contains([]string{"text", "json"}, c.Log.Format) {
+		errs = append(errs, fmt.Errorf("log.format: want text or json, got %q", c.Log.Format))
+	}
+	if k := c.Auth.APIKey; k != "" && len(k) < 16 {
+		errs = append(errs, errors.New("auth.api_key: must be at least 16 characters"))
+	}
+	if c.Auth.KeysPath != "" && c.Auth.APIKey == "" {
+		errs = append(errs, errors.New("auth.keys_path: requires auth.api_key, the admin token for managing keys"))
+	}
+	for _, o := range c.CORS.Origins() {
+		if scheme, host, ok := strings.Cut(o, "://"); o != "*" && (!ok || scheme == "" || host == "" || strings.Contains(host, "/")) {
+			errs = append(errs, fmt.Errorf("cors.allowed_origins: %q is not scheme://host[:port]", o))
+		}
+	}
+	if c.Jobs.Path == "" {
+		errs = append(errs, errors.New("jobs.path: must not be empty"))
+	}
+	if c.Jobs.Workers < 1 {
+		errs = append(errs, fmt.Errorf("jobs.workers: must be at least 1, got %d", c.Jobs.Workers))
+	}
+	slices.SortFunc(errs, func(a, b error) int { return strings.Compare(a.Error(), b.Error()) })
+	return errors.Join(errs...)
+}
+
+// SlogLevel parses Level.
+func (l LogConfig) SlogLevel() (slog.Level, error) {
+	var level slog.Level
+	err := level.UnmarshalText([]byte(l.Level))
+	return level, err
+}
+
+// String lists every setting as key = value, one per line, with secrets
+// redacted. It is safe to log.
+func (c Config) String() string {
+	var b strings.Builder
+	for _, f := range fields(&c) {
+		fmt.Fprintf(&b, "%s = %s\n", f.key, f.display())
+	}
+	return b.String()
+}
+
+// GoString keeps %#v from bypassing String and printing secrets.
+func (c Config) GoString() string { return "config.Config{\n" + c.String() + "}" }
+
+// Secret is a string that does not print itself. fmt, encoding/json and
+// log/slog all see "[REDACTED]"; code that needs the value calls Reveal.
+type Secret string
+
+const redacted = "[REDACTED]"
+
+func (s Secret) Reveal() string                { return string(s) }
+func (s Secret) String() string                { return s.mask() }
+func (s Secret) GoString() string              { return s.mask() }
+func (s Secret) MarshalText() ([]byte, error)  { return []byte(s.mask()), nil }
+func (s Secret) LogValue() slog.Value          { return slog.StringValue(s.mask()) }
+func (s *Secret) UnmarshalText(b []byte) error { *s = Secret(b); return nil }
+
+// mask shows whether a secret is set without showing it.
+func (s Secret) mask() string {
+	if s == "" {
+		return `""`
+	}
+	return redacted
+}
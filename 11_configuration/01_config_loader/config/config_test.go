@@ -0,0 +1,247 @@
+package config
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func env(vars map[string]string) func(string) (string, bool) {
+	return func(k string) (string, bool) {
+		v, ok := vars[k]
+		return v, ok
+	}
+}
+
+func mustLoad(t *testing.T, opts Options) (Config, Sources) {
+	t.Helper()
+	if opts.LookupEnv == nil {
+		opts.LookupEnv = env(nil)
+	}
+	cfg, src, err := Load(opts)
+	if err != nil {
+		t.Fatalf("Load: %v", err)
+	}
+	return cfg, src
+}
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoad_DefaultsAreValid(t *testing.T) {
+	cfg, src := mustLoad(t, Options{})
+	if cfg != Default() {
+		t.Fatalf("Load() = %v; want the defaults", cfg)
+	}
+	for key, from := range src {
+		if from != "default" {
+			t.Errorf("%s from %q; want default", key, from)
+		}
+	}
+}
+
+func TestLoad_FileFormats(t *testing.T) {
+	for _, name := range []string{"config.yaml", "config.toml", "config.json"} {
+		t.Run(name, func(t *testing.T) {
+			cfg, src := mustLoad(t, Options{Args: []string{"-config", filepath.Join("testdata", name)}})
+			if cfg.Server.Addr != ":9090" || cfg.Server.ReadTimeout != 5*time.Second || cfg.Log.Level != "debug" {
+				t.Fatalf("got %v", cfg)
+			}
+			if cfg.Server.WriteTimeout != Default().Server.WriteTimeout {
+				t.Errorf("write_timeout = %v; keys missing from the file must keep their defaults", cfg.Server.WriteTimeout)
+			}
+			if !strings.HasPrefix(src["server.addr"], "file ") {
+				t.Errorf("server.addr from %q; want the file", src["server.addr"])
+			}
+		})
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	file := writeFile(t, "c.yaml", "server:\n  addr: ':1111'\n  read_timeout: 1s\n  write_timeout: 1s\n")
+	cfg, src := mustLoad(t, Options{
+		Args: []string{"-server.write_timeout=3s"},
+		LookupEnv: env(map[string]string{
+			"APP_CONFIG":               file,
+			"APP_SERVER_READ_TIMEOUT":  "2s",
+			"APP_SERVER_WRITE_TIMEOUT": "2s",
+		}),
+	})
+	want := map[string]struct {
+		got  time.Duration
+		want time.Duration
+		from string
+	}{
+		"server.idle_timeout":  {cfg.Server.IdleTimeout, Default().Server.IdleTimeout, "default"},
+		"server.read_timeout":  {cfg.Server.ReadTimeout, 2 * time.Second, "env APP_SERVER_READ_TIMEOUT"},
+		"server.write_timeout": {cfg.Server.WriteTimeout, 3 * time.Second, "flag -server.write_timeout"},
+	}
+	for key, w := range want {
+		if w.got != w.want || src[key] != w.from {
+			t.Errorf("%s = %v from %q; want %v from %q", key, w.got, src[key], w.want, w.from)
+		}
+	}
+	if cfg.Server.Addr != ":1111" || src["server.addr"] != "file "+file {
+		t.Errorf("server.addr = %q from %q; want the file's value", cfg.Server.Addr, src["server.addr"])
+	}
+}
+
+func TestLoad_ConfigFlagBeatsEnv(t *testing.T) {
+	a := writeFile(t, "a.json", `{"log": {"level": "warn"}}`)
+	b := writeFile(t, "b.json", `{"log": {"level": "error"}}`)
+	cfg, _ := mustLoad(t, Options{Args: []string{"-config", b}, LookupEnv: env(map[string]string{"APP_CONFIG": a})})
+	if cfg.Log.Level != "error" {
+		t.Fatalf("log.level = %q; -config must win over APP_CONFIG", cfg.Log.Level)
+	}
+}
+
+func TestLoad_PlatformPort(t *testing.T) {
+	file := writeFile(t, "c.yaml", "server:\n  addr: '127.0.0.1:1111'\n")
+	cfg, src := mustLoad(t, Options{LookupEnv: env(map[string]string{"APP_CONFIG": file, "PORT": "3000"})})
+	if cfg.Server.Addr != "127.0.0.1:3000" || src["server.addr"] != "env PORT" {
+		t.Errorf("server.addr = %q from %q; want the file's host with PORT's port", cfg.Server.Addr, src["server.addr"])
+	}
+
+	cfg, _ = mustLoad(t, Options{LookupEnv: env(map[string]string{"PORT": "3000", "APP_SERVER_ADDR": ":4000"})})
+	if cfg.Server.Addr != ":4000" {
+		t.Errorf("server.addr = %q; APP_SERVER_ADDR must win over PORT", cfg.Server.Addr)
+	}
+
+	_, _, err := Load(Options{LookupEnv: env(map[string]string{"PORT": "70000"})})
+	if err == nil || !strings.Contains(err.Error(), "env PORT") {
+		t.Errorf("PORT=70000: err = %v; want an env PORT error", err)
+	}
+}
+
+func TestLoad_EnvPrefix(t *testing.T) {
+	cfg, _ := mustLoad(t, Options{EnvPrefix: "USERS", LookupEnv: env(map[string]string{
+		"USERS_LOG_LEVEL": "warn",
+		"APP_LOG_LEVEL":   "error",
+	})})
+	if cfg.Log.Level != "warn" {
+		t.Fatalf("log.level = %q; want warn from USERS_LOG_LEVEL", cfg.Log.Level)
+	}
+}
+
+func TestLoad_Errors(t *testing.T) {
+	tests := []struct {
+		name string
+		opts Options
+		want []string
+	}{
+		{"unknown file key", Options{Args: []string{"-config", writeFile(t, "c.yaml", "server:\n  read_timout: 1s\n")}},
+			[]string{`unknown key "server.read_timout"`}},
+		{"bad duration in env", Options{LookupEnv: env(map[string]string{"APP_SERVER_IDLE_TIMEOUT": "10"})},
+			[]string{"APP_SERVER_IDLE_TIMEOUT", "missing unit"}},
+		{"unsupported extension", Options{Args: []string{"-config", writeFile(t, "c.ini", "")}},
+			[]string{"unsupported extension"}},
+		{"missing file", Options{Args: []string{"-config", "nope.yaml"}},
+			[]string{"nope.yaml"}},
+		{"unknown flag", Options{Args: []string{"-server.port=1"}},
+			[]string{"server.port"}},
+		{"all validation errors", Options{Args: []string{"-server.addr=x", "-server.debug_addr=y", "-log.format=xml", "-auth.api_key=short", "-jobs.workers=0"}},
+			[]string{"server.addr", "server.debug_addr", "log.format", "auth.api_key", "jobs.workers"}},
+		{"malformed CORS origins", Options{Args: []string{"-cors.allowed_origins=https://ok.example.com, app.example.com,https://a.example.com/path"}},
+			[]string{`"app.example.com" is not scheme://host`, `"https://a.example.com/path" is not scheme://host`}},
+		{"API keys without an admin token", Options{Args: []string{"-auth.keys_path=keys.db"}},
+			[]string{"auth.keys_path: requires auth.api_key"}},
+		{"debug listener on the API address", Options{Args: []string{"-server.debug_addr=:8080"}},
+			[]string{"server.debug_addr: must differ from server.addr"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if tt.opts.LookupEnv == nil {
+				tt.opts.LookupEnv = env(nil)
+			}
+			_, _, err := Load(tt.opts)
+			if err == nil {
+				t.Fatal("Load succeeded; want an error")
+			}
+			for _, w := range tt.want {
+				if !strings.Contains(err.Error(), w) {
+					t.Errorf("error %q does not mention %q", err, w)
+				}
+			}
+		})
+	}
+}
+
+func TestSecret_NeverPrinted(t *testing.T) {
+	const key = "s3cr3t-0123456789"
+	cfg, _ := mustLoad(t, Options{LookupEnv: env(map[string]string{"APP_AUTH_API_KEY": key})})
+	if cfg.Auth.APIKey.Reveal() != key {
+		t.Fatalf("Reveal = %q; want the real key", cfg.Auth.APIKey.Reveal())
+	}
+
+	var logBuf bytes.Buffer
+	slog.New(slog.NewJSONHandler(&logBuf, nil)).Info("cfg", "key", cfg.Auth.APIKey, "auth", cfg.Auth)
+	js, _ := json.Marshal(cfg)
+	outputs := map[string]string{
+		"String":   cfg.String(),
+		"%v":       fmt.Sprintf("%v", cfg),
+		"%+v":      fmt.Sprintf("%+v", cfg),
+		"%#v":      fmt.Sprintf("%#v", cfg),
+		"%s key":   fmt.Sprintf("%s", cfg.Auth.APIKey),
+		"%+v auth": fmt.Sprintf("%+v", cfg.Auth),
+		"json":     string(js),
+		"slog":     logBuf.String(),
+	}
+	for name, out := range outputs {
+		if strings.Contains(out, key) {
+			t.Errorf("%s leaks the secret: %s", name, out)
+		}
+		if !strings.Contains(out, redacted) {
+			t.Errorf("%s = %s; want %s", name, out, redacted)
+		}
+	}
+}
+
+func TestManager_ReloadKeepsOldConfigOnError(t *testing.T) {
+	path := writeFile(t, "c.yaml", "log:\n  level: info\n")
+	load := func() (Config, Sources, error) {
+		return Load(Options{Args: []string{"-config", path}, LookupEnv: env(nil)})
+	}
+	m, _, err := NewManager(load)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var changes [][]string
+	m.OnChange(func(old, new Config) { changes = append(changes, Changed(old, new)) })
+
+	os.WriteFile(path, []byte("log:\n  level: debug\nserver:\n  addr: ':9999'\n  debug_addr: 'localhost:6060'\njobs:\n  workers: 4\n"), 0o600)
+	if err := m.Reload(); err != nil {
+		t.Fatalf("Reload: %v", err)
+	}
+	if m.Current().Log.Level != "debug" {
+		t.Fatalf("log.level = %q after reload; want debug", m.Current().Log.Level)
+	}
+	if len(changes) != 1 || fmt.Sprint(changes[0]) != "[server.addr server.debug_addr log.level jobs.workers]" {
+		t.Fatalf("changes = %v; want [[server.addr server.debug_addr log.level jobs.workers]]", changes)
+	}
+	if r := RestartRequired(changes[0]); fmt.Sprint(r) != "[server.addr server.debug_addr jobs.workers]" {
+		t.Errorf("RestartRequired = %v; want [server.addr server.debug_addr jobs.workers]", r)
+	}
+
+	os.WriteFile(path, []byte("log:\n  level: loud\n"), 0o600)
+	if err := m.Reload(); err == nil {
+		t.Fatal("Reload accepted an invalid config")
+	}
+	if m.Current().Log.Level != "debug" {
+		t.Fatalf("log.level = %q; a rejected reload must keep the old config", m.Current().Log.Level)
+	}
+	if len(changes) != 1 {
+		t.Fatalf("listeners ran %d times; want only for the successful reload", len(changes))
+	}
+}
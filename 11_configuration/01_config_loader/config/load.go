@@ -0,0 +1,338 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"net"
+	"os"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+
+	"golang_roadmap/02_core_language/21_struct_tags/envtag"
+)
+
+// Options controls where Load looks. The zero value reads the real
+// environment and no command-line arguments.
+type Options struct {
+	// EnvPrefix is prepended to environment variable names (default "APP").
+	EnvPrefix string
+	// Args are the command-line arguments without the program name.
+	Args []string
+	// LookupEnv defaults to os.LookupEnv. Tests pass a map lookup.
+	LookupEnv func(string) (string, bool)
+}
+
+// Sources records which layer set each key's final value, e.g.
+// "env APP_SERVER_ADDR". It answers "why is it set to that?".
+type Sources map[string]string
+
+// String lists the sources sorted by key.
+func (s Sources) String() string {
+	keys := make([]string, 0, len(s))
+	for k := range s {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	var b strings.Builder
+	for _, k := range keys {
+		fmt.Fprintf(&b, "%s <- %s\n", k, s[k])
+	}
+	return b.String()
+}
+
+// Load builds a Config from defaults, then the file named by -config or
+// <PREFIX>_CONFIG, then the platform's PORT variable, then <PREFIX>_*
+// environment variables, then flags, and validates the result.
+// flag.ErrHelp is returned as is for -h.
+func Load(opts Options) (Config, Sources, error) {
+	if opts.EnvPrefix == "" {
+		opts.EnvPrefix = "APP"
+	}
+	if opts.LookupEnv == nil {
+		opts.LookupEnv = os.LookupEnv
+	}
+
+	cfg := Default()
+	fs := fields(&cfg)
+	src := Sources{}
+	for _, f := range fs {
+		src[f.key] = "default"
+	}
+
+	// Flags are parsed first, because -config decides which file to read,
+	// but applied last, because they have the highest precedence.
+	flags, configPath, err := parseFlags(fs, opts.Args)
+	if err != nil {
+		return Config{}, nil, err
+	}
+	if configPath == "" {
+		configPath, _ = opts.LookupEnv(opts.EnvPrefix + "_CONFIG")
+	}
+
+	if configPath != "" {
+		values, err := readFile(configPath)
+		if err != nil {
+			return Config{}, nil, err
+		}
+		if err := apply(fs, values, src, "file "+configPath); err != nil {
+			return Config{}, nil, err
+		}
+	}
+
+	if err := applyPlatformEnv(&cfg, opts.LookupEnv, src); err != nil {
+		return Config{}, nil, err
+	}
+
+	var envErrs []error
+	for _, f := range fs {
+		if v, ok := opts.LookupEnv(envName(opts.EnvPrefix, f.key)); ok {
+			if err := f.set(v); err != nil {
+				envErrs = append(envErrs, fmt.Errorf("env %s: %w", envName(opts.EnvPrefix, f.key), err))
+				continue
+			}
+			src[f.key] = "env " + envName(opts.EnvPrefix, f.key)
+		}
+	}
+	if err := errors.Join(envErrs...); err != nil {
+		return Config{}, nil, err
+	}
+
+	if err := apply(fs, flags, src, "flag"); err != nil {
+		return Config{}, nil, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, nil, fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	return cfg, src, nil
+}
+
+// envName maps a key to its variable: server.read_timeout with prefix APP
+// is APP_SERVER_READ_TIMEOUT.
+func envName(prefix, key string) string {
+	return prefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// platformEnv holds variables that hosting platforms (Cloud Run, Heroku,
+// Render) set under their own names, outside the <PREFIX>_* scheme.
+type platformEnv struct {
+	Port *uint16 `env:"PORT"` // nil when unset
+}
+
+// applyPlatformEnv moves server.addr to the port in PORT, keeping the host.
+// It ranks below <PREFIX>_SERVER_ADDR, which is set for this program alone.
+func applyPlatformEnv(cfg *Config, lookup func(string) (string, bool), src Sources) error {
+	var p platformEnv
+	if err := envtag.LoadFrom(&p, lookup); err != nil {
+		return fmt.Errorf("env %w", err)
+	}
+	if p.Port == nil {
+		return nil
+	}
+	host, _, err := net.SplitHostPort(cfg.Server.Addr)
+	if err != nil {
+		host = "" // a malformed addr is replaced whole
+	}
+	cfg.Server.Addr = net.JoinHostPort(host, strconv.Itoa(int(*p.Port)))
+	src["server.addr"] = "env PORT"
+	return nil
+}
+
+// parseFlags defines one string flag per key, plus -config, and returns the
+// flags that were actually given. Unset flags must not override lower
+// layers with their zero value, which is why they are collected with Visit
+// instead of bound directly to the Config fields.
+func parseFlags(fs []field, args []string) (map[string]string, string, error) {
+	set := flag.NewFlagSet("config", flag.ContinueOnError)
+	configPath := set.String("config", "", "path to a YAML, TOML or JSON config file")
+	for _, f := range fs {
+		set.String(f.key, f.display(), f.usage)
+	}
+	if err := set.Parse(args); err != nil {
+		return nil, "", err
+	}
+	given := map[string]string{}
+	set.Visit(func(fl *flag.Flag) {
+		if fl.Name != "config" {
+			given[fl.Name] = fl.Value.String()
+		}
+	})
+	return given, *configPath, nil
+}
+
+// readFile decodes a config file by extension and flattens it to
+// key -> value, e.g. {"server": {"addr": ":80"}} -> "server.addr": ":80".
+func readFile(path string) (map[string]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+	var tree map[string]any
+	switch ext := strings.ToLower(filepath.Ext(path)); ext {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &tree)
+	case ".toml":
+		err = toml.Unmarshal(data, &tree)
+	case ".json":
+		err = json.Unmarshal(data, &tree)
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported extension %q (want .yaml, .toml or .json)", path, ext)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	values := map[string]string{}
+	flatten("", tree, values)
+	return values, nil
+}
+
+func flatten(prefix string, tree map[string]any, out map[string]string) {
+	for k, v := range tree {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			flatten(key, v, out)
+		case []any:
+			parts := make([]string, len(v))
+			for i, p := range v {
+				parts[i] = fmt.Sprint(p)
+			}
+			out[key] = strings.Join(parts, ",")
+		default:
+			out[key] = fmt.Sprint(v)
+		}
+	}
+}
+
+// apply sets values on the matching fields. Unknown keys are errors: a
+// typo like "read_timout" would otherwise be silently ignored.
+func apply(fs []field, values map[string]string, src Sources, source string) error {
+	byKey := map[string]field{}
+	for _, f := range fs {
+		byKey[f.key] = f
+	}
+	var errs []error
+	for key, v := range values {
+		f, ok := byKey[key]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%s: unknown key %q", source, key))
+			continue
+		}
+		if err := f.set(v); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", source, err))
+			continue
+		}
+		if source == "flag" {
+			src[key] = "flag -" + key
+		} else {
+			src[key] = source
+		}
+	}
+	sort.Slice(errs, func(i, j int) bool { return errs[i].Error() < errs[j].Error() })
+	return errors.Join(errs...)
+}
+
+// field is one leaf setting of a Config, addressed by its dotted key.
+type field struct {
+	key    string
+	value  reflect.Value // settable
+	secret bool
+	usage  string
+}
+
+// fields walks c's struct tags and returns its leaf settings in
+// declaration order.
+func fields(c *Config) []field {
+	var out []field
+	var walk func(prefix string, v reflect.Value)
+	walk = func(prefix string, v reflect.Value) {
+		t := v.Type()
+		for i := range t.NumField() {
+			sf := t.Field(i)
+			key := sf.Tag.Get("key")
+			if key == "" {
+				continue
+			}
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			fv := v.Field(i)
+			if fv.Kind() == reflect.Struct && fv.Type() != reflect.TypeFor[time.Duration]() {
+				walk(key, fv)
+				continue
+			}
+			out = append(out, field{
+				key:    key,
+				value:  fv,
+				secret: fv.Type() == reflect.TypeFor[Secret](),
+				usage:  sf.Tag.Get("usage"),
+			})
+		}
+	}
+	walk("", reflect.ValueOf(c).Elem())
+	return out
+}
+
+// set parses s according to the field's type.
+func (f field) set(s string) error {
+	v := f.value
+	switch {
+	case v.Type() == reflect.TypeFor[time.Duration]():
+		d, err := time.ParseDuration(s)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.key, err)
+		}
+		v.SetInt(int64(d))
+		return nil
+	}
+	switch v.Kind() {
+	case reflect.String:
+		v.SetString(s)
+	case reflect.Int, reflect.Int64, reflect.Int32:
+		n, err := strconv.ParseInt(s, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not an integer", f.key, s)
+		}
+		v.SetInt(n)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(s)
+		if err != nil {
+			return fmt.Errorf("%s: %q is not a boolean", f.key, s)
+		}
+		v.SetBool(b)
+	case reflect.Slice:
+		var parts []string
+		for _, p := range strings.Split(s, ",") {
+			if p = strings.TrimSpace(p); p != "" {
+				parts = append(parts, p)
+			}
+		}
+		v.Set(reflect.ValueOf(parts))
+	default:
+		return fmt.Errorf("%s: unsupported type %s", f.key, v.Type())
+	}
+	return nil
+}
+
+// display formats the current value for String and flag defaults.
+func (f field) display() string {
+	if f.secret {
+		return Secret(f.value.String()).mask()
+	}
+	if f.value.Kind() == reflect.Slice {
+		return strings.Join(f.value.Interface().([]string), ",")
+	}
+	return fmt.Sprint(f.value.Interface())
+}
@@ -0,0 +1,120 @@
+package config
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+)
+
+// Manager holds the current Config and replaces it on Reload. Readers call
+// Current on every use instead of keeping a copy, so they see a reload
+// without any locking of their own.
+type Manager struct {
+	load    func() (Config, Sources, error)
+	current atomic.Pointer[Config]
+
+	mu        sync.Mutex // serialises reloads and guards listeners
+	listeners []func(old, new Config)
+}
+
+// NewManager loads the initial configuration with load, which is usually a
+// closure around Load with the process's Options. The first load must
+// succeed: there is no previous config to fall back on.
+func NewManager(load func() (Config, Sources, error)) (*Manager, Sources, error) {
+	cfg, src, err := load()
+	if err != nil {
+		return nil, nil, err
+	}
+	m := &Manager{load: load}
+	m.current.Store(&cfg)
+	return m, src, nil
+}
+
+// Current returns the active configuration. Treat it as read-only.
+func (m *Manager) Current() *Config { return m.current.Load() }
+
+// OnChange registers fn to run after each successful reload that changed
+// something.
+func (m *Manager) OnChange(fn func(old, new Config)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.listeners = append(m.listeners, fn)
+}
+
+// Reload loads the configuration again. If the new one is invalid, the old
+// one stays active and the error is returned: a typo in a config file must
+// not take down a running server.
+func (m *Manager) Reload() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	next, _, err := m.load()
+	if err != nil {
+		return fmt.Errorf("reload rejected, keeping current config: %w", err)
+	}
+	old := *m.current.Load()
+	if len(Changed(old, next)) == 0 {
+		return nil
+	}
+	m.current.Store(&next)
+	for _, fn := range m.listeners {
+		fn(old, next)
+	}
+	return nil
+}
+
+// WatchSIGHUP reloads on every SIGHUP until ctx is done. SIGHUP is the
+// Unix convention for "re-read your configuration" (kill -HUP <pid>); on
+// Windows it is never delivered.
+func (m *Manager) WatchSIGHUP(ctx context.Context) {
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, syscall.SIGHUP)
+	go func() {
+		defer signal.Stop(sig)
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-sig:
+				if err := m.Reload(); err != nil {
+					log.Printf("config: %v", err)
+				} else {
+					log.Printf("config: reloaded")
+				}
+			}
+		}
+	}()
+}
+
+// Changed lists the keys whose values differ between a and b. Secrets are
+// compared but, as everywhere, not shown.
+func Changed(a, b Config) []string {
+	fa, fb := fields(&a), fields(&b)
+	var keys []string
+	for i := range fa {
+		if !fa[i].value.Equal(fb[i].value) {
+			keys = append(keys, fa[i].key)
+		}
+	}
+	return keys
+}
+
+// RestartRequired reports the changed keys that a running process cannot
+// apply, such as the listen addresses, the CORS origins, the API key
+// database or the job queue settings. Reload still stores them, and they
+// take effect on the next restart.
+func RestartRequired(changed []string) []string {
+	var out []string
+	for _, k := range changed {
+		if k == "server.addr" || k == "server.debug_addr" || k == "auth.keys_path" || strings.HasPrefix(k, "cors.") || strings.HasPrefix(k, "jobs.") {
+			out = append(out, k)
+		}
+	}
+	return out
+}
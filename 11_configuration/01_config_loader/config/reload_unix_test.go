@@ -0,0 +1,39 @@
+//go:build unix
+
+package config
+
+import (
+	"context"
+	"os"
+	"syscall"
+	"testing"
+	"time"
+)
+
+func TestManager_WatchSIGHUP(t *testing.T) {
+	path := writeFile(t, "c.json", `{"log": {"level": "info"}}`)
+	m, _, err := NewManager(func() (Config, Sources, error) {
+		return Load(Options{Args: []string{"-config", path}, LookupEnv: env(nil)})
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	reloaded := make(chan Config, 1)
+	m.OnChange(func(_, new Config) { reloaded <- new })
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	m.WatchSIGHUP(ctx)
+
+	os.WriteFile(path, []byte(`{"log": {"level": "warn"}}`), 0o600)
+	if err := syscall.Kill(os.Getpid(), syscall.SIGHUP); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case cfg := <-reloaded:
+		if cfg.Log.Level != "warn" {
+			t.Fatalf("log.level = %q after SIGHUP; want warn", cfg.Log.Level)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload after SIGHUP")
+	}
+}
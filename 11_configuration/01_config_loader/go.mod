@@ -0,0 +1,12 @@
+module golang_roadmap/11_configuration/01_config_loader
+
+go 1.24.11
+
+require (
+	github.com/BurntSushi/toml v1.6.0
+	golang_roadmap/02_core_language/21_struct_tags v0.0.0
+	gopkg.in/yaml.v3 v3.0.1
+)
+
+// The envtag package lives in its own module in this repository.
+replace golang_roadmap/02_core_language/21_struct_tags => ../../02_core_language/21_struct_tags
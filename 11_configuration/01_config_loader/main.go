@@ -0,0 +1,83 @@
+// Demonstrates layered configuration with the config package: defaults,
+// a config file, environment variables and flags, in that precedence.
+//
+// This example shows:
+// - One key per setting across file, env (APP_*) and flags
+// - Which source set each value
+// - Validation that reports every problem at once
+// - Secrets that redact themselves in String, fmt, JSON and slog
+// - Reloading on SIGHUP, keeping the old config if the new one is invalid
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang_roadmap/11_configuration/01_config_loader/config"
+)
+
+func main() {
+	load := func() (config.Config, config.Sources, error) {
+		return config.Load(config.Options{Args: os.Args[1:]})
+	}
+	mgr, src, err := config.NewManager(load)
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg := mgr.Current()
+
+	fmt.Println("=== Effective configuration ===")
+	fmt.Print(cfg)
+	fmt.Println("\n=== Where each value came from ===")
+	fmt.Print(src)
+
+	fmt.Println("\n=== Secrets stay hidden ===")
+	js, _ := json.Marshal(cfg.Auth)
+	fmt.Printf("  fmt %%v:   %v\n", cfg.Auth.APIKey)
+	fmt.Printf("  fmt %%#v:  %#v\n", cfg.Auth.APIKey)
+	fmt.Printf("  json:     %s\n", js)
+	slog.Info("  slog", "api_key", cfg.Auth.APIKey)
+
+	fmt.Println("\n=== Validation reports every problem ===")
+	bad := config.Default()
+	bad.Server.Addr = "8080"
+	bad.Server.ReadTimeout = 0
+	bad.Log.Level = "loud"
+	fmt.Println(bad.Validate())
+
+	mgr.OnChange(func(old, new config.Config) {
+		changed := config.Changed(old, new)
+		log.Printf("config changed: %v", changed)
+		if r := config.RestartRequired(changed); len(r) > 0 {
+			log.Printf("restart required to apply: %v", r)
+		}
+	})
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	mgr.WatchSIGHUP(ctx)
+
+	fmt.Printf("\nEdit the config file and run: kill -HUP %d   (Ctrl+C to exit)\n", os.Getpid())
+	ticker := time.NewTicker(5 * time.Second)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c := mgr.Current()
+			log.Printf("current: log.level=%s server.read_timeout=%v", c.Log.Level, c.Server.ReadTimeout)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+// Config holds the same kind of settings as ../01_config_loader, plus a
+// three-level database section. mapstructure tags map viper keys to fields;
+// viper keys are case-insensitive.
+type Config struct {
+	Server   ServerConfig   `mapstructure:"server"`
+	Log      LogConfig      `mapstructure:"log"`
+	Auth     AuthConfig     `mapstructure:"auth"`
+	Database DatabaseConfig `mapstructure:"database"`
+}
+
+type ServerConfig struct {
+	Addr         string        `mapstructure:"addr"`
+	ReadTimeout  time.Duration `mapstructure:"read_timeout"`
+	WriteTimeout time.Duration `mapstructure:"write_timeout"`
+}
+
+type LogConfig struct {
+	Level string `mapstructure:"level"`
+}
+
+type AuthConfig struct {
+	APIKey string `mapstructure:"api_key"` // secret: see Redacted
+}
+
+type DatabaseConfig struct {
+	DSN  string     `mapstructure:"dsn"`
+	Pool PoolConfig `mapstructure:"pool"`
+}
+
+type PoolConfig struct {
+	MaxOpen     int           `mapstructure:"max_open"`
+	MaxIdle     int           `mapstructure:"max_idle"`
+	MaxLifetime time.Duration `mapstructure:"max_lifetime"`
+}
+
+// setDefaults registers a default for every key. Besides supplying values,
+// this is what makes AutomaticEnv work with Unmarshal: viper only
+// unmarshals keys it knows about, and an environment variable alone does
+// not make a key known.
+func setDefaults(v *viper.Viper) {
+	v.SetDefault("server.addr", ":8080")
+	v.SetDefault("server.read_timeout", 15*time.Second)
+	v.SetDefault("server.write_timeout", 15*time.Second)
+	v.SetDefault("log.level", "info")
+	v.SetDefault("auth.api_key", "")
+	v.SetDefault("database.dsn", "file:app.db")
+	v.SetDefault("database.pool.max_open", 10)
+	v.SetDefault("database.pool.max_idle", 5)
+	v.SetDefault("database.pool.max_lifetime", 30*time.Minute)
+}
+
+// Validate checks what viper cannot: viper converts types but knows
+// nothing about valid ranges or formats.
+func (c Config) Validate() error {
+	var errs []error
+	if _, _, err := net.SplitHostPort(c.Server.Addr); err != nil {
+		errs = append(errs, fmt.Errorf("server.addr: %w", err))
+	}
+	if c.Server.ReadTimeout <= 0 || c.Server.WriteTimeout <= 0 {
+		errs = append(errs, errors.New("server timeouts must be positive"))
+	}
+	var level slog.Level
+	if err := level.UnmarshalText([]byte(c.Log.Level)); err != nil {
+		errs = append(errs, fmt.Errorf("log.level: %w", err))
+	}
+	if c.Database.Pool.MaxOpen < 1 || c.Database.Pool.MaxIdle > c.Database.Pool.MaxOpen {
+		errs = append(errs, fmt.Errorf("database.pool: need 1 <= max_idle <= max_open, got max_idle=%d max_open=%d",
+			c.Database.Pool.MaxIdle, c.Database.Pool.MaxOpen))
+	}
+	return errors.Join(errs...)
+}
+
+// Redacted returns a copy that is safe to print. Viper has no notion of
+// secrets, so this is on us, and easy to forget for a new field.
+func (c Config) Redacted() Config {
+	for _, s := range []*string{&c.Auth.APIKey, &c.Database.DSN} {
+		if *s != "" {
+			*s = "[REDACTED]"
+		}
+	}
+	return c
+}
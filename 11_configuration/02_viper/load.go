@@ -0,0 +1,132 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/spf13/pflag"
+	"github.com/spf13/viper"
+)
+
+// Options selects the sources for newViper.
+type Options struct {
+	Args       []string // command-line arguments without the program name
+	RemoteURL  string   // optional: a URL serving a YAML config document
+	SearchPath []string // directories to look for config.{yaml,toml,json}
+}
+
+// newViper assembles one viper instance from every source. Viper resolves
+// each key at read time in a fixed order, highest first:
+//
+//	flag → env → config file → key/value store (remote) → default
+//
+// so unlike ../01_config_loader, the order below is not the precedence;
+// viper's built-in order is.
+func newViper(opts Options) (*viper.Viper, error) {
+	v := viper.New()
+	setDefaults(v)
+
+	// Flags: pflag gives GNU-style --long flags. A bound flag counts
+	// only if it was actually set on the command line.
+	fs := pflag.NewFlagSet("app", pflag.ContinueOnError)
+	fs.String("config", "", "config file (default: config.{yaml,toml,json} in the search path)")
+	fs.String("server.addr", ":8080", "listen address")
+	fs.String("log.level", "info", "debug, info, warn or error")
+	fs.Int("database.pool.max_open", 10, "max open database connections")
+	if err := fs.Parse(opts.Args); err != nil {
+		return nil, err
+	}
+	// --config selects a source; it is not a setting, and binding it would
+	// make UnmarshalExact reject it as an unknown key.
+	var bindErr error
+	fs.VisitAll(func(f *pflag.Flag) {
+		if f.Name != "config" && bindErr == nil {
+			bindErr = v.BindPFlag(f.Name, f)
+		}
+	})
+	if bindErr != nil {
+		return nil, bindErr
+	}
+
+	// Environment: APP_SERVER_ADDR, APP_DATABASE_POOL_MAX_OPEN, ...
+	v.SetEnvPrefix("APP")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	// Config file: an explicit path, or config.* in the search path.
+	if path, _ := fs.GetString("config"); path != "" {
+		v.SetConfigFile(path)
+	} else {
+		v.SetConfigName("config")
+		for _, dir := range opts.SearchPath {
+			v.AddConfigPath(dir)
+		}
+	}
+	if err := v.ReadInConfig(); err != nil {
+		var notFound viper.ConfigFileNotFoundError
+		if !errors.As(err, &notFound) {
+			return nil, fmt.Errorf("config file: %w", err)
+		}
+	}
+
+	if opts.RemoteURL != "" {
+		if err := loadRemote(v, opts.RemoteURL); err != nil {
+			return nil, err
+		}
+	}
+	return v, nil
+}
+
+// loadRemote fetches a YAML document from url and puts its values in the
+// layer between the defaults and the config file, where viper keeps values
+// from a real remote key/value store.
+//
+// With etcd or Consul you would instead blank-import
+// github.com/spf13/viper/remote and call
+//
+//	v.AddRemoteProvider("etcd3", "http://127.0.0.1:2379", "/config/app.yaml")
+//	v.SetConfigType("yaml")
+//	v.ReadRemoteConfig()
+//
+// Nothing else changes: decode, Validate and the rest read from v.
+func loadRemote(v *viper.Viper, url string) error {
+	client := &http.Client{Timeout: 5 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		return fmt.Errorf("remote config: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("remote config: %s", resp.Status)
+	}
+
+	remote := viper.New()
+	remote.SetConfigType("yaml")
+	if err := remote.ReadConfig(resp.Body); err != nil {
+		return fmt.Errorf("remote config: %w", err)
+	}
+	// SetDefault overrides the built-in default but stays below the file,
+	// env and flags. It also survives WatchConfig, which replaces only the
+	// file layer when the file changes.
+	for _, key := range remote.AllKeys() {
+		v.SetDefault(key, remote.Get(key))
+	}
+	return nil
+}
+
+// decode converts v into a Config and validates it. UnmarshalExact fails
+// on keys that match no field, so a typo in the config file is an error
+// rather than a silently ignored setting.
+func decode(v *viper.Viper) (Config, error) {
+	var cfg Config
+	if err := v.UnmarshalExact(&cfg); err != nil {
+		return Config{}, err
+	}
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+	return cfg, nil
+}
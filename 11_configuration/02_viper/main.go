@@ -0,0 +1,115 @@
+// Demonstrates 12-factor configuration with viper, for comparison with the
+// hand-rolled loader in ../01_config_loader.
+//
+// This example shows:
+// - Nested keys (database.pool.max_open) in files, env and flags
+// - Environment binding with a prefix and a key replacer
+// - A remote config source layered below the file
+// - Watching the config file and swapping in validated changes
+// - Sub-trees with v.Sub, which are snapshots
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const fileConfig = `server:
+  addr: ":9000"
+  read_timeout: 5s
+log:
+  level: info
+database:
+  pool:
+    max_open: 20
+`
+
+func main() {
+	dir, err := os.MkdirTemp("", "viper-demo-")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "config.yaml")
+	if err := os.WriteFile(path, []byte(fileConfig), 0o600); err != nil {
+		log.Fatal(err)
+	}
+
+	// Stand-in for a config service (Consul, etcd, an S3 object, ...).
+	remote := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "server:\n  write_timeout: 7s\ndatabase:\n  dsn: postgres://app:hunter2@db/app\n")
+	}))
+	defer remote.Close()
+
+	// Set here so the demo is self-contained; normally the environment
+	// comes from the deployment.
+	os.Setenv("APP_DATABASE_POOL_MAX_IDLE", "8")
+
+	v, err := newViper(Options{
+		Args:       append([]string{"--config", path}, os.Args[1:]...),
+		RemoteURL:  remote.URL,
+		SearchPath: []string{"."},
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	cfg, err := decode(v)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var store Store
+	store.current.Store(&cfg)
+
+	fmt.Println("=== Effective configuration (secrets redacted) ===")
+	fmt.Printf("%+v\n", cfg.Redacted())
+	fmt.Println("\n=== Where values came from ===")
+	fmt.Println("  server.addr          :9000  config file (try --server.addr=:7000 or APP_SERVER_ADDR)")
+	fmt.Println("  server.read_timeout  5s     config file")
+	fmt.Println("  server.write_timeout 7s     remote")
+	fmt.Println("  database.pool.max_idle 8    env APP_DATABASE_POOL_MAX_IDLE")
+	fmt.Println("  everything else             defaults")
+
+	fmt.Println("\n=== Sub-trees ===")
+	// Sub hands a component only its own section, with shorter keys. It
+	// is a copy taken now: later changes to v do not reach it.
+	db := v.Sub("database")
+	fmt.Printf("  v.Sub(\"database\").GetInt(\"pool.max_idle\") = %d\n", db.GetInt("pool.max_idle"))
+	v.Set("database.pool.max_idle", 9)
+	fmt.Printf("  after v.Set(\"database.pool.max_idle\", 9): v says %d, the Sub still says %d\n",
+		v.GetInt("database.pool.max_idle"), db.GetInt("pool.max_idle"))
+	v.Set("database.pool.max_idle", nil) // drop the override again
+
+	fmt.Println("\n=== Watching the config file ===")
+	changed := make(chan Config, 1)
+	watch(v, &store, func(old, new Config) {
+		fmt.Printf("  reloaded: log.level %s -> %s, pool.max_open %d -> %d\n",
+			old.Log.Level, new.Log.Level, old.Database.Pool.MaxOpen, new.Database.Pool.MaxOpen)
+		changed <- new
+	}, func(err error) {
+		fmt.Printf("  rejected: %v\n", err)
+	})
+
+	update := func(content string) {
+		// Write a temp file and rename it over the config: the watcher
+		// never sees a half-written file.
+		tmp := path + ".tmp"
+		os.WriteFile(tmp, []byte(content), 0o600)
+		os.Rename(tmp, path)
+	}
+	update(strings.Replace(fileConfig, "level: info", "level: debug", 1))
+	select {
+	case <-changed:
+	case <-time.After(3 * time.Second):
+		fmt.Println("  no change event (filesystem without inotify?)")
+	}
+
+	update("server:\n  addr: nope\n")
+	time.Sleep(500 * time.Millisecond)
+	fmt.Printf("  still active: server.addr=%s log.level=%s\n", store.Current().Server.Addr, store.Current().Log.Level)
+}
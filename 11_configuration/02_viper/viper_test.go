@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/spf13/viper"
+)
+
+func writeConfig(t *testing.T, dir, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, "config.yaml")
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func load(t *testing.T, opts Options) (Config, *viper.Viper) {
+	t.Helper()
+	v, err := newViper(opts)
+	if err != nil {
+		t.Fatalf("newViper: %v", err)
+	}
+	cfg, err := decode(v)
+	if err != nil {
+		t.Fatalf("decode: %v", err)
+	}
+	return cfg, v
+}
+
+func remoteServer(t *testing.T, yaml string) string {
+	t.Helper()
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, yaml)
+	}))
+	t.Cleanup(srv.Close)
+	return srv.URL
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	cfg, _ := load(t, Options{SearchPath: []string{t.TempDir()}})
+	if cfg.Server.Addr != ":8080" || cfg.Database.Pool.MaxOpen != 10 || cfg.Database.Pool.MaxLifetime != 30*time.Minute {
+		t.Fatalf("defaults = %+v", cfg)
+	}
+}
+
+func TestLoad_Precedence(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "server:\n  addr: ':1'\n  read_timeout: 1s\n  write_timeout: 1s\nlog:\n  level: warn\n")
+	remote := remoteServer(t, "server:\n  addr: ':0'\n  read_timeout: 9s\ndatabase:\n  pool:\n    max_idle: 2\n")
+	t.Setenv("APP_SERVER_READ_TIMEOUT", "2s")
+	t.Setenv("APP_SERVER_ADDR", ":2")
+
+	cfg, _ := load(t, Options{SearchPath: []string{dir}, RemoteURL: remote, Args: []string{"--server.addr=:3"}})
+	checks := []struct {
+		key       string
+		got, want any
+	}{
+		{"server.addr (flag beats env)", cfg.Server.Addr, ":3"},
+		{"server.read_timeout (env beats file)", cfg.Server.ReadTimeout, 2 * time.Second},
+		{"server.write_timeout (file beats remote)", cfg.Server.WriteTimeout, time.Second},
+		{"database.pool.max_idle (remote beats default)", cfg.Database.Pool.MaxIdle, 2},
+		{"database.pool.max_open (default)", cfg.Database.Pool.MaxOpen, 10},
+	}
+	for _, c := range checks {
+		if c.got != c.want {
+			t.Errorf("%s = %v; want %v", c.key, c.got, c.want)
+		}
+	}
+}
+
+func TestLoad_NestedKeyFromEnv(t *testing.T) {
+	t.Setenv("APP_DATABASE_POOL_MAX_OPEN", "50")
+	cfg, _ := load(t, Options{SearchPath: []string{t.TempDir()}})
+	if cfg.Database.Pool.MaxOpen != 50 {
+		t.Fatalf("database.pool.max_open = %d; want 50 from APP_DATABASE_POOL_MAX_OPEN", cfg.Database.Pool.MaxOpen)
+	}
+}
+
+// TestAutomaticEnvNeedsKnownKeys shows why setDefaults registers every
+// key: without a default, file value or bound flag, Unmarshal never asks
+// the environment about a key.
+func TestAutomaticEnvNeedsKnownKeys(t *testing.T) {
+	t.Setenv("APP_LOG_LEVEL", "debug")
+	v := viper.New()
+	v.SetEnvPrefix("APP")
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+
+	if got := v.GetString("log.level"); got != "debug" {
+		t.Fatalf("Get = %q; AutomaticEnv should answer direct lookups", got)
+	}
+	var cfg Config
+	if err := v.Unmarshal(&cfg); err != nil {
+		t.Fatal(err)
+	}
+	if cfg.Log.Level != "" {
+		t.Fatalf("Unmarshal saw log.level = %q; expected viper to miss the unknown key", cfg.Log.Level)
+	}
+
+	v.SetDefault("log.level", "info")
+	if err := v.Unmarshal(&cfg); err != nil || cfg.Log.Level != "debug" {
+		t.Fatalf("with a default: log.level = %q, %v; want debug from the environment", cfg.Log.Level, err)
+	}
+}
+
+func TestDecode_RejectsUnknownAndInvalid(t *testing.T) {
+	tests := map[string]struct {
+		file string
+		want string
+	}{
+		"typo in key":     {"server:\n  read_timout: 1s\n", "read_timout"},
+		"bad duration":    {"server:\n  read_timeout: soon\n", "read_timeout"},
+		"failed validate": {"database:\n  pool:\n    max_open: 2\n    max_idle: 5\n", "database.pool"},
+	}
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeConfig(t, dir, tt.file)
+			v, err := newViper(Options{SearchPath: []string{dir}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := decode(v); err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("decode err = %v; want it to mention %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestLoad_RemoteErrors(t *testing.T) {
+	srv := httptest.NewServer(http.NotFoundHandler())
+	defer srv.Close()
+	if _, err := newViper(Options{RemoteURL: srv.URL}); err == nil {
+		t.Fatal("newViper succeeded with a 404 remote")
+	}
+}
+
+func TestRedacted(t *testing.T) {
+	cfg := Config{Auth: AuthConfig{APIKey: "k"}, Database: DatabaseConfig{DSN: "postgres://u:p@h/db"}}
+	out := fmt.Sprintf("%+v", cfg.Redacted())
+	if strings.Contains(out, "postgres://") || strings.Contains(out, "APIKey:k") {
+		t.Fatalf("Redacted leaks: %s", out)
+	}
+	if cfg.Auth.APIKey != "k" {
+		t.Fatal("Redacted modified the original")
+	}
+}
+
+func TestWatch_AppliesValidChangesOnly(t *testing.T) {
+	dir := t.TempDir()
+	writeConfig(t, dir, "log:\n  level: info\n")
+	cfg, v := load(t, Options{SearchPath: []string{dir}})
+	var store Store
+	store.current.Store(&cfg)
+
+	changes := make(chan Config, 4)
+	rejected := make(chan error, 4)
+	watch(v, &store, func(_, new Config) { changes <- new }, func(err error) { rejected <- err })
+
+	writeConfig(t, dir, "log:\n  level: debug\n")
+	select {
+	case c := <-changes:
+		if c.Log.Level != "debug" {
+			t.Fatalf("reloaded log.level = %q; want debug", c.Log.Level)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no reload after the file changed")
+	}
+
+	writeConfig(t, dir, "log:\n  level: loud\n")
+	select {
+	case <-rejected:
+	case c := <-changes:
+		t.Fatalf("invalid config applied: %+v", c)
+	case <-time.After(5 * time.Second):
+		t.Fatal("invalid config neither rejected nor applied")
+	}
+	if got := store.Current().Log.Level; got != "debug" {
+		t.Fatalf("active log.level = %q; want debug kept", got)
+	}
+}
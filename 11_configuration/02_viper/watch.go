@@ -0,0 +1,41 @@
+package main
+
+import (
+	"sync/atomic"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/spf13/viper"
+)
+
+// Store holds the decoded configuration for the rest of the program.
+//
+// Viper is not safe for concurrent use, and WatchConfig rewrites it from
+// its own goroutine. So after startup nothing but the OnConfigChange
+// callback touches v; everyone else reads the immutable Config in Store.
+type Store struct {
+	current atomic.Pointer[Config]
+}
+
+func (s *Store) Current() *Config { return s.current.Load() }
+
+// watch re-decodes the configuration whenever the config file changes.
+// A file that decodes but fails validation is reported to onError, and
+// the previous Config stays active. A file that does not parse at all
+// never reaches us: viper logs the error and keeps the old file contents.
+// onChange runs only for real changes.
+func watch(v *viper.Viper, store *Store, onChange func(old, new Config), onError func(error)) {
+	v.OnConfigChange(func(fsnotify.Event) {
+		next, err := decode(v)
+		if err != nil {
+			onError(err)
+			return
+		}
+		old := store.Current()
+		if *old == next {
+			return // editors often write twice; ignore the no-op event
+		}
+		store.current.Store(&next)
+		onChange(*old, next)
+	})
+	v.WatchConfig()
+}
@@ -0,0 +1,76 @@
+package flags
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"log"
+	"os"
+	"sync"
+	"time"
+)
+
+// File is a Provider that reads flags from a JSON file and re-reads it
+// when it changes. Operators flip a flag by editing the file (or the
+// ConfigMap it is mounted from); no deploy or restart is needed.
+type File struct {
+	*Memory
+	path string
+
+	mu   sync.Mutex // serialises reloads
+	last []byte     // contents of the last successful load
+}
+
+// OpenFile loads path. The first load must succeed.
+func OpenFile(path string) (*File, error) {
+	f := &File{Memory: NewMemory(nil), path: path}
+	if _, err := f.Reload(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Reload reads the file and applies it if its contents changed. It
+// reports whether anything was applied. A file that fails to parse is
+// rejected and the current flags stay: a typo must not switch features
+// off for everyone.
+func (f *File) Reload() (bool, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	data, err := os.ReadFile(f.path)
+	if err != nil {
+		return false, err
+	}
+	if f.last != nil && bytes.Equal(data, f.last) {
+		return false, nil
+	}
+	flags, err := Parse(data)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", f.path, err)
+	}
+	f.Replace(flags)
+	f.last = data
+	return true, nil
+}
+
+// Poll calls Reload every interval until ctx is done. Polling works on
+// every filesystem, including network mounts and Kubernetes volumes where
+// inotify events are unreliable; for a small file it is cheap.
+func (f *File) Poll(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			changed, err := f.Reload()
+			switch {
+			case err != nil:
+				log.Printf("flags: keeping current flags: %v", err)
+			case changed:
+				log.Printf("flags: reloaded %s", f.path)
+			}
+		}
+	}
+}
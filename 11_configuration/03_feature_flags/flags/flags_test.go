@@ -0,0 +1,178 @@
+package flags
+
+import (
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// TestBucket_Golden pins the hash. If Bucket ever changes, every user
+// would move to a different bucket and roughly half of the users in a
+// rollout would lose or gain the feature at once: that must be a
+// deliberate decision, not a refactoring side effect.
+func TestBucket_Golden(t *testing.T) {
+	golden := map[[2]string]int{
+		{"new-checkout", "alice"}: 56,
+		{"new-checkout", "bob"}:   55,
+		{"new-checkout", "grace"}: 1,
+		{"dark-mode", "alice"}:    65, // same user, different flag: different bucket
+	}
+	for in, want := range golden {
+		if got := Bucket(in[0], in[1]); got != want {
+			t.Errorf("Bucket(%q, %q) = %d; want %d", in[0], in[1], got, want)
+		}
+	}
+}
+
+func TestBucket_StableAndInRange(t *testing.T) {
+	for i := range 1000 {
+		u := fmt.Sprintf("user-%d", i)
+		b := Bucket("f", u)
+		if b < 0 || b > 99 {
+			t.Fatalf("Bucket(f, %s) = %d; out of range", u, b)
+		}
+		for range 3 {
+			if again := Bucket("f", u); again != b {
+				t.Fatalf("Bucket(f, %s) = %d then %d", u, b, again)
+			}
+		}
+	}
+}
+
+func TestBucket_SeparatorMatters(t *testing.T) {
+	same := 0
+	for i := range 100 {
+		u := fmt.Sprint(i)
+		if Bucket("ab", "c"+u) == Bucket("a", "bc"+u) {
+			same++
+		}
+	}
+	if same > 10 {
+		t.Fatalf("(ab, c…) and (a, bc…) share a bucket %d/100 times; the name/user boundary is lost", same)
+	}
+}
+
+func TestRollout_MatchesPercentage(t *testing.T) {
+	const users = 20000
+	for _, pct := range []int{1, 10, 25, 50, 90} {
+		f := Flag{Enabled: true, Rollout: pct}
+		on := 0
+		for i := range users {
+			if f.Evaluate("checkout", fmt.Sprintf("user-%d", i)) {
+				on++
+			}
+		}
+		got := float64(on) * 100 / users
+		if math.Abs(got-float64(pct)) > 1.0 {
+			t.Errorf("rollout %d%%: %.2f%% of users enabled", pct, got)
+		}
+	}
+}
+
+func TestRollout_RampUpOnlyAddsUsers(t *testing.T) {
+	enabled := map[string]bool{}
+	for pct := 0; pct <= 100; pct += 5 {
+		f := Flag{Enabled: true, Rollout: pct}
+		for i := range 2000 {
+			u := fmt.Sprintf("user-%d", i)
+			on := f.Evaluate("checkout", u)
+			if enabled[u] && !on {
+				t.Fatalf("%s lost the feature when rollout went up to %d%%", u, pct)
+			}
+			enabled[u] = on
+		}
+	}
+}
+
+func TestRollout_FlagsPickIndependentCohorts(t *testing.T) {
+	a := Flag{Enabled: true, Rollout: 20}
+	both, n := 0, 20000
+	for i := range n {
+		u := fmt.Sprintf("user-%d", i)
+		if a.Evaluate("flag-a", u) && a.Evaluate("flag-b", u) {
+			both++
+		}
+	}
+	// Independent 20% cohorts overlap in about 4% of users; identical
+	// cohorts would overlap in 20%.
+	if got := float64(both) * 100 / float64(n); got > 6 {
+		t.Fatalf("%.1f%% of users are in both 20%% rollouts; want about 4%%", got)
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	tests := []struct {
+		name string
+		flag Flag
+		user string
+		want bool
+	}{
+		{"kill switch beats allow-list", Flag{Enabled: false, Rollout: 100, Allow: []string{"alice"}}, "alice", false},
+		{"allow-list beats rollout", Flag{Enabled: true, Rollout: 0, Allow: []string{"alice"}}, "alice", true},
+		{"zero rollout", Flag{Enabled: true}, "bob", false},
+		{"full rollout", Flag{Enabled: true, Rollout: 100}, "bob", true},
+		{"anonymous, partial rollout", Flag{Enabled: true, Rollout: 99}, "", false},
+		{"anonymous, full rollout", Flag{Enabled: true, Rollout: 100}, "", true},
+		{"empty user not matched by allow-list", Flag{Enabled: true, Allow: []string{""}}, "", false},
+	}
+	for _, tt := range tests {
+		if got := tt.flag.Evaluate("f", tt.user); got != tt.want {
+			t.Errorf("%s: Evaluate = %v; want %v", tt.name, got, tt.want)
+		}
+	}
+}
+
+func TestMemory_UnknownFlagIsOff(t *testing.T) {
+	m := NewMemory(map[string]Flag{"on": {Enabled: true, Rollout: 100}})
+	if !m.Enabled("on", "u") || m.Enabled("missing", "u") {
+		t.Fatal("want known flag on and unknown flag off")
+	}
+}
+
+func TestParse_Validates(t *testing.T) {
+	if _, err := Parse([]byte(`{"flags": {"x": {"enabled": true, "rollout": 101}}}`)); err == nil {
+		t.Error("rollout 101 accepted")
+	}
+	if _, err := Parse([]byte(`{"flags": `)); err == nil {
+		t.Error("truncated JSON accepted")
+	}
+}
+
+func TestFile_ReloadKeepsFlagsOnBadFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "flags.json")
+	write := func(s string) {
+		t.Helper()
+		if err := os.WriteFile(path, []byte(s), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write(`{"flags": {"x": {"enabled": true, "rollout": 100}}}`)
+	f, err := OpenFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if changed, err := f.Reload(); err != nil || changed {
+		t.Fatalf("Reload of an unchanged file = %v, %v; want no change", changed, err)
+	}
+
+	write(`{"flags": {"x": {"enabled": false}}}`)
+	if changed, err := f.Reload(); err != nil || !changed || f.Enabled("x", "u") {
+		t.Fatalf("Reload = %v, %v; want x switched off", changed, err)
+	}
+
+	write(`{"flags": {"x": {"enabled": true, "rollout": 100}`) // truncated
+	if _, err := f.Reload(); err == nil {
+		t.Fatal("Reload accepted a broken file")
+	}
+	if f.Enabled("x", "u") {
+		t.Fatal("a broken file changed the flags")
+	}
+}
+
+func TestOpenFile_Missing(t *testing.T) {
+	if _, err := OpenFile(filepath.Join(t.TempDir(), "nope.json")); err == nil {
+		t.Fatal("OpenFile succeeded for a missing file")
+	}
+}
@@ -0,0 +1,79 @@
+package flags
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+// Request evaluates flags for one request's user. Each flag is evaluated
+// at most once per request and the answer is kept, so a reload in the
+// middle of a request cannot change a flag halfway through rendering.
+type Request struct {
+	provider Provider
+	userID   string
+
+	mu   sync.Mutex
+	seen map[string]bool
+}
+
+// Enabled reports whether flag name is on for this request's user.
+func (r *Request) Enabled(name string) bool {
+	if r == nil || r.provider == nil {
+		return false
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if on, ok := r.seen[name]; ok {
+		return on
+	}
+	on := r.provider.Enabled(name, r.userID)
+	if r.seen == nil {
+		r.seen = map[string]bool{}
+	}
+	r.seen[name] = on
+	return on
+}
+
+// Evaluated returns the flags this request has checked so far, for
+// logging and for tagging metrics by variant.
+func (r *Request) Evaluated() map[string]bool {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	out := make(map[string]bool, len(r.seen))
+	for k, v := range r.seen {
+		out[k] = v
+	}
+	return out
+}
+
+type ctxKey struct{}
+
+// FromContext returns the request's flags. Without the middleware it
+// returns an evaluator that reports every flag as off, so handlers can
+// always call it.
+func FromContext(ctx context.Context) *Request {
+	r, _ := ctx.Value(ctxKey{}).(*Request)
+	return r
+}
+
+// NewContext returns ctx carrying flags for userID, for code paths that
+// are not HTTP requests (jobs, consumers, tests).
+func NewContext(ctx context.Context, p Provider, userID string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, &Request{provider: p, userID: userID})
+}
+
+// Middleware attaches flags to every request. userID extracts the user
+// (usually from the authenticated session); it may return "" for
+// anonymous requests.
+func Middleware(p Provider, userID func(*http.Request) string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := NewContext(r.Context(), p, userID(r))
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
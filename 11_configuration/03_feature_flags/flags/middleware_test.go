@@ -0,0 +1,59 @@
+package flags
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestMiddleware_ExposesFlagsToHandlers(t *testing.T) {
+	p := NewMemory(map[string]Flag{"beta": {Enabled: true, Allow: []string{"alice"}}})
+	h := Middleware(p, func(r *http.Request) string { return r.Header.Get("X-User-ID") })(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if FromContext(r.Context()).Enabled("beta") {
+				io.WriteString(w, "beta")
+			} else {
+				io.WriteString(w, "stable")
+			}
+		}))
+
+	for user, want := range map[string]string{"alice": "beta", "bob": "stable", "": "stable"} {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		req.Header.Set("X-User-ID", user)
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, req)
+		if got := rec.Body.String(); got != want {
+			t.Errorf("user %q got %q; want %q", user, got, want)
+		}
+	}
+}
+
+func TestRequest_ConsistentWithinRequest(t *testing.T) {
+	p := NewMemory(map[string]Flag{"f": {Enabled: true, Rollout: 100}})
+	ff := FromContext(NewContext(context.Background(), p, "u"))
+	if !ff.Enabled("f") {
+		t.Fatal("f off; want on")
+	}
+	p.Set("f", Flag{Enabled: false}) // flipped mid-request
+	if !ff.Enabled("f") {
+		t.Fatal("flag changed within one request")
+	}
+	if got := ff.Evaluated(); len(got) != 1 || !got["f"] {
+		t.Fatalf("Evaluated = %v; want map[f:true]", got)
+	}
+	if FromContext(NewContext(context.Background(), p, "u")).Enabled("f") {
+		t.Fatal("a new request should see the new value")
+	}
+}
+
+func TestFromContext_WithoutMiddlewareIsAllOff(t *testing.T) {
+	ff := FromContext(context.Background())
+	if ff.Enabled("anything") {
+		t.Fatal("flag on without middleware")
+	}
+	if ff.Evaluated() != nil {
+		t.Fatal("Evaluated should be empty without middleware")
+	}
+}
@@ -0,0 +1,3 @@
+module golang_roadmap/11_configuration/03_feature_flags
+
+go 1.24.11
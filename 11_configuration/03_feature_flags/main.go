@@ -0,0 +1,93 @@
+// Demonstrates feature flags with the flags package: a JSON file polled
+// for changes, percentage rollouts and HTTP middleware.
+//
+// This example shows:
+// - Kill switches, allow-lists and percentage rollouts
+// - Stable bucketing: a user keeps their variant across requests
+// - Ramping a rollout up without reshuffling existing users
+// - Middleware that gives handlers per-request flag evaluation
+// - Editing flags.json while the server runs
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"golang_roadmap/11_configuration/03_feature_flags/flags"
+)
+
+func main() {
+	provider, err := flags.OpenFile("flags.json")
+	if err != nil {
+		log.Fatal(err)
+	}
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	go provider.Poll(ctx, 2*time.Second)
+
+	fmt.Println("=== new-checkout at 25%, by user ===")
+	users := []string{"alice", "bob", "carol", "dave", "erin", "frank", "grace", "heidi"}
+	for _, u := range users {
+		fmt.Printf("  %-6s bucket %2d  -> %v\n", u, flags.Bucket("new-checkout", u), provider.Enabled("new-checkout", u))
+	}
+
+	fmt.Println("\n=== Ramping up keeps earlier users in ===")
+	ramp := flags.NewMemory(nil)
+	var prev []string
+	for _, pct := range []int{10, 25, 50, 100} {
+		ramp.Set("new-checkout", flags.Flag{Enabled: true, Rollout: pct})
+		var on []string
+		for i := range 20 {
+			if u := fmt.Sprintf("user-%02d", i); ramp.Enabled("new-checkout", u) {
+				on = append(on, u)
+			}
+		}
+		fmt.Printf("  %3d%%: %2d/20 users, all %d from the previous step still on: %v\n",
+			pct, len(on), len(prev), containsAll(on, prev))
+		prev = on
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/checkout", func(w http.ResponseWriter, r *http.Request) {
+		ff := flags.FromContext(r.Context())
+		if ff.Enabled("new-checkout") {
+			fmt.Fprintln(w, "new checkout flow")
+		} else {
+			fmt.Fprintln(w, "classic checkout flow")
+		}
+		if ff.Enabled("dark-mode") {
+			fmt.Fprintln(w, "(dark mode)")
+		}
+		log.Printf("checkout flags=%v", ff.Evaluated())
+	})
+	userID := func(r *http.Request) string { return r.Header.Get("X-User-ID") } // stand-in for real auth
+	server := &http.Server{Addr: ":8081", Handler: flags.Middleware(provider, userID)(mux)}
+
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+	fmt.Println("\nServing on :8081. Try:")
+	fmt.Println(`  curl -H "X-User-ID: alice" localhost:8081/checkout`)
+	fmt.Println(`  curl -H "X-User-ID: bob" localhost:8081/checkout`)
+	fmt.Println("Then edit flags.json (e.g. rollout 100 or enabled false); it is re-read every 2s.")
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		log.Fatal(err)
+	}
+}
+
+func containsAll(set, subset []string) bool {
+	joined := "," + strings.Join(set, ",") + ","
+	for _, s := range subset {
+		if !strings.Contains(joined, ","+s+",") {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,5 @@
+module golang_roadmap/12_operations/01_health
+
+go 1.24.11
+
+require github.com/mattn/go-sqlite3 v1.14.33
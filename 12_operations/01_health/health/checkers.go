@@ -0,0 +1,89 @@
+package health
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// Pinger is implemented by *sql.DB, and by most database and cache
+// clients under some name.
+type Pinger interface {
+	PingContext(ctx context.Context) error
+}
+
+// DBPing checks that a database answers. *sql.DB.PingContext reuses an idle
+// connection or opens one, so it also catches an exhausted pool.
+func DBPing(db Pinger) Checker {
+	return CheckerFunc(db.PingContext)
+}
+
+// HTTPGet checks that url answers with a 2xx status. Point it at the
+// downstream's own health endpoint, not at a business endpoint.
+func HTTPGet(client *http.Client, url string) Checker {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return CheckerFunc(func(ctx context.Context) error {
+		req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+		if err != nil {
+			return err
+		}
+		resp, err := client.Do(req)
+		if err != nil {
+			return err
+		}
+		defer resp.Body.Close()
+		io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10)) // let the connection be reused
+		if resp.StatusCode < 200 || resp.StatusCode > 299 {
+			return fmt.Errorf("GET %s: %s", url, resp.Status)
+		}
+		return nil
+	})
+}
+
+// DiskSpace checks that the filesystem holding path has at least minFree
+// bytes available to this process. A full disk breaks writes in ways that
+// are hard to diagnose; failing readiness first makes it obvious.
+func DiskSpace(path string, minFree uint64) Checker {
+	return CheckerFunc(func(ctx context.Context) error {
+		free, err := freeBytes(path)
+		if err != nil {
+			return err
+		}
+		if free < minFree {
+			return fmt.Errorf("%s: %d MiB free, want at least %d MiB", path, free>>20, minFree>>20)
+		}
+		return nil
+	})
+}
+
+// Heartbeat is a liveness check for a loop that must keep running, such
+// as a queue consumer: the loop calls Beat on every iteration, and the
+// check fails when the last beat is older than maxAge. This catches a
+// deadlocked or wedged process, which is what liveness is for.
+type Heartbeat struct {
+	maxAge time.Duration
+	last   atomic.Int64 // unix nanos
+	now    func() time.Time
+}
+
+// NewHeartbeat returns a heartbeat that starts out fresh.
+func NewHeartbeat(maxAge time.Duration) *Heartbeat {
+	h := &Heartbeat{maxAge: maxAge, now: time.Now}
+	h.Beat()
+	return h
+}
+
+func (h *Heartbeat) Beat() { h.last.Store(h.now().UnixNano()) }
+
+func (h *Heartbeat) Check(context.Context) error {
+	age := h.now().Sub(time.Unix(0, h.last.Load()))
+	if age > h.maxAge {
+		return fmt.Errorf("no heartbeat for %v (max %v)", age.Round(time.Millisecond), h.maxAge)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+)
+
+type fakeDB struct{ err error }
+
+func (f fakeDB) PingContext(context.Context) error { return f.err }
+
+func TestDBPing(t *testing.T) {
+	if err := DBPing(fakeDB{}).Check(context.Background()); err != nil {
+		t.Errorf("healthy db: %v", err)
+	}
+	down := errors.New("connection refused")
+	if err := DBPing(fakeDB{err: down}).Check(context.Background()); !errors.Is(err, down) {
+		t.Errorf("db down: err = %v; want %v", err, down)
+	}
+}
+
+func TestHTTPGet(t *testing.T) {
+	status := http.StatusOK
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			<-r.Context().Done()
+			return
+		}
+		w.WriteHeader(status)
+	}))
+	defer srv.Close()
+
+	if err := HTTPGet(nil, srv.URL).Check(context.Background()); err != nil {
+		t.Errorf("200: %v", err)
+	}
+	status = http.StatusServiceUnavailable
+	if err := HTTPGet(nil, srv.URL).Check(context.Background()); err == nil || !strings.Contains(err.Error(), "503") {
+		t.Errorf("503: err = %v; want it reported", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := HTTPGet(srv.Client(), srv.URL+"/slow").Check(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("hanging downstream: err = %v; want DeadlineExceeded", err)
+	}
+}
+
+func TestDiskSpace(t *testing.T) {
+	switch runtime.GOOS {
+	case "linux", "darwin", "freebsd":
+	default:
+		t.Skip("disk space not supported on", runtime.GOOS)
+	}
+	dir := t.TempDir()
+	if err := DiskSpace(dir, 0).Check(context.Background()); err != nil {
+		t.Errorf("min 0: %v", err)
+	}
+	if err := DiskSpace(dir, 1<<62).Check(context.Background()); err == nil || !strings.Contains(err.Error(), "MiB free") {
+		t.Errorf("min 4 EiB: err = %v; want a free-space failure", err)
+	}
+	if err := DiskSpace(dir+"/missing", 0).Check(context.Background()); err == nil {
+		t.Error("missing path: want an error")
+	}
+}
+
+func TestHeartbeat(t *testing.T) {
+	now := time.Unix(0, 0)
+	h := &Heartbeat{maxAge: time.Second, now: func() time.Time { return now }}
+	h.Beat()
+	now = now.Add(900 * time.Millisecond)
+	if err := h.Check(context.Background()); err != nil {
+		t.Fatalf("fresh heartbeat: %v", err)
+	}
+	now = now.Add(200 * time.Millisecond)
+	if err := h.Check(context.Background()); err == nil {
+		t.Fatal("stale heartbeat passed")
+	}
+	h.Beat()
+	if err := h.Check(context.Background()); err != nil {
+		t.Fatalf("after Beat: %v", err)
+	}
+}
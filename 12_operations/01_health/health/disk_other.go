@@ -0,0 +1,12 @@
+//go:build !(linux || darwin || freebsd)
+
+package health
+
+import (
+	"errors"
+	"fmt"
+)
+
+func freeBytes(path string) (uint64, error) {
+	return 0, fmt.Errorf("disk space of %s: %w", path, errors.ErrUnsupported)
+}
@@ -0,0 +1,238 @@
+// Package health runs registered checks and serves the results as
+// Kubernetes-style /livez and /readyz endpoints.
+//
+// Liveness answers "is this process stuck?": if it fails, the orchestrator
+// restarts the container. Readiness answers "can this instance take
+// traffic right now?": if it fails, the instance is taken out of the load
+// balancer until it passes again. Dependency checks (database, disk,
+// downstream services) belong in readiness only. A database outage should
+// stop traffic, not restart every pod in a loop.
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Checker checks one thing. It must honour ctx: the registry cancels it
+// when the check's timeout expires.
+type Checker interface {
+	Check(ctx context.Context) error
+}
+
+// CheckerFunc adapts a function to Checker.
+type CheckerFunc func(ctx context.Context) error
+
+func (f CheckerFunc) Check(ctx context.Context) error { return f(ctx) }
+
+// Kind selects the endpoint a check belongs to.
+type Kind int
+
+const (
+	Liveness Kind = iota
+	Readiness
+)
+
+// Option configures a registered check.
+type Option func(*check)
+
+// WithTimeout bounds one run of the check (default 2s). A check that
+// overruns fails with context.DeadlineExceeded; a hung dependency makes
+// the probe fail instead of making it hang.
+func WithTimeout(d time.Duration) Option { return func(c *check) { c.timeout = d } }
+
+// WithCacheTTL reuses a result for d (default 0: run on every probe).
+// Probes arrive every few seconds from every load balancer and
+// orchestrator; caching keeps them from hammering the dependency.
+func WithCacheTTL(d time.Duration) Option { return func(c *check) { c.ttl = d } }
+
+// Result is the outcome of one check.
+type Result struct {
+	Status   string    `json:"status"` // "ok" or "fail"
+	Error    string    `json:"error,omitempty"`
+	Duration string    `json:"duration"`
+	Checked  time.Time `json:"checked_at"`
+	Cached   bool      `json:"cached,omitempty"`
+}
+
+// Report is the body of /livez and /readyz.
+type Report struct {
+	Status string            `json:"status"`
+	Checks map[string]Result `json:"checks"`
+}
+
+// Registry holds the registered checks. The zero value is not usable;
+// call New.
+type Registry struct {
+	mu     sync.RWMutex
+	checks map[Kind][]*check
+
+	shuttingDown chan struct{}
+	shutdownOnce sync.Once
+	now          func() time.Time
+}
+
+type check struct {
+	name    string
+	checker Checker
+	timeout time.Duration
+	ttl     time.Duration
+
+	mu   sync.Mutex // one run at a time; concurrent probes wait and share it
+	last Result
+}
+
+// New returns an empty registry.
+func New() *Registry {
+	return &Registry{
+		checks:       map[Kind][]*check{},
+		shuttingDown: make(chan struct{}),
+		now:          time.Now,
+	}
+}
+
+// Register adds a check. Names must be unique per kind.
+func (r *Registry) Register(kind Kind, name string, c Checker, opts ...Option) {
+	ch := &check{name: name, checker: c, timeout: 2 * time.Second}
+	for _, o := range opts {
+		o(ch)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, existing := range r.checks[kind] {
+		if existing.name == name {
+			panic(fmt.Sprintf("health: check %q registered twice", name))
+		}
+	}
+	r.checks[kind] = append(r.checks[kind], ch)
+}
+
+// MarkShuttingDown makes readiness fail from now on, while liveness keeps
+// passing. Call it first thing on SIGTERM, so load balancers stop sending
+// new requests while in-flight ones finish.
+func (r *Registry) MarkShuttingDown() {
+	r.shutdownOnce.Do(func() { close(r.shuttingDown) })
+}
+
+var errShuttingDown = errors.New("shutting down")
+
+// Run runs every check of kind concurrently and returns the report. The
+// report passes only if every check passed.
+func (r *Registry) Run(ctx context.Context, kind Kind) Report {
+	r.mu.RLock()
+	checks := append([]*check(nil), r.checks[kind]...)
+	r.mu.RUnlock()
+
+	report := Report{Status: "ok", Checks: make(map[string]Result, len(checks)+1)}
+	if kind == Readiness {
+		select {
+		case <-r.shuttingDown:
+			report.Checks["shutdown"] = Result{Status: "fail", Error: errShuttingDown.Error(), Checked: r.now()}
+		default:
+		}
+	}
+
+	results := make([]Result, len(checks))
+	var wg sync.WaitGroup
+	for i, c := range checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = c.run(ctx, r.now)
+		}()
+	}
+	wg.Wait()
+	for i, c := range checks {
+		report.Checks[c.name] = results[i]
+	}
+	for _, res := range report.Checks {
+		if res.Status != "ok" {
+			report.Status = "fail"
+		}
+	}
+	return report
+}
+
+func (c *check) run(ctx context.Context, now func() time.Time) Result {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.ttl > 0 && !c.last.Checked.IsZero() && now().Sub(c.last.Checked) < c.ttl {
+		cached := c.last
+		cached.Cached = true
+		return cached
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.timeout)
+	defer cancel()
+	start := now()
+	err := runChecker(ctx, c.checker)
+	res := Result{Status: "ok", Duration: now().Sub(start).Round(time.Microsecond).String(), Checked: start}
+	if err != nil {
+		res.Status = "fail"
+		res.Error = err.Error()
+	}
+	c.last = res
+	return res
+}
+
+// runChecker returns when the check returns or its deadline passes,
+// whichever is first, so a checker that ignores ctx cannot hang a probe.
+// Such a checker's goroutine still runs to completion in the background.
+func runChecker(ctx context.Context, c Checker) error {
+	done := make(chan error, 1)
+	go func() {
+		defer func() {
+			if p := recover(); p != nil {
+				done <- fmt.Errorf("check panicked: %v", p)
+			}
+		}()
+		done <- c.Check(ctx)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// Handler serves the report for kind: 200 when everything passes, 503
+// otherwise. The JSON body lists each check; probes only look at the
+// status code.
+func (r *Registry) Handler(kind Kind) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		report := r.Run(req.Context(), kind)
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		if report.Status != "ok" {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		enc.Encode(report)
+	})
+}
+
+// Mount registers /livez and /readyz on mux.
+func (r *Registry) Mount(mux *http.ServeMux) {
+	mux.Handle("GET /livez", r.Handler(Liveness))
+	mux.Handle("GET /readyz", r.Handler(Readiness))
+}
+
+// Names returns the registered check names for kind, sorted.
+func (r *Registry) Names(kind Kind) []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	var names []string
+	for _, c := range r.checks[kind] {
+		names = append(names, c.name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,155 @@
+package health
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	pass = CheckerFunc(func(context.Context) error { return nil })
+	fail = CheckerFunc(func(context.Context) error { return errors.New("boom") })
+)
+
+func get(t *testing.T, h http.Handler, path string) (int, Report) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+	var report Report
+	if err := json.Unmarshal(rec.Body.Bytes(), &report); err != nil {
+		t.Fatalf("GET %s: body %q: %v", path, rec.Body, err)
+	}
+	return rec.Code, report
+}
+
+func serve(r *Registry) http.Handler {
+	mux := http.NewServeMux()
+	r.Mount(mux)
+	return mux
+}
+
+func TestEndpoints_AggregateChecks(t *testing.T) {
+	r := New()
+	r.Register(Liveness, "loop", pass)
+	r.Register(Readiness, "db", pass)
+	r.Register(Readiness, "cache", fail)
+	h := serve(r)
+
+	code, report := get(t, h, "/livez")
+	if code != http.StatusOK || report.Status != "ok" || len(report.Checks) != 1 {
+		t.Fatalf("/livez = %d %+v; want 200 with only the liveness check", code, report)
+	}
+	code, report = get(t, h, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Status != "fail" {
+		t.Fatalf("/readyz = %d %+v; want 503", code, report)
+	}
+	if report.Checks["db"].Status != "ok" || report.Checks["cache"].Error != "boom" {
+		t.Errorf("checks = %+v; want db ok and cache failing with boom", report.Checks)
+	}
+}
+
+func TestRun_TimeoutFailsSlowCheck(t *testing.T) {
+	r := New()
+	ignoresCtx := CheckerFunc(func(context.Context) error { time.Sleep(time.Second); return nil })
+	r.Register(Readiness, "slow", ignoresCtx, WithTimeout(20*time.Millisecond))
+	r.Register(Readiness, "fast", pass)
+
+	start := time.Now()
+	report := r.Run(context.Background(), Readiness)
+	if took := time.Since(start); took > 500*time.Millisecond {
+		t.Fatalf("Run took %v; the timeout must bound the probe even if the check ignores ctx", took)
+	}
+	if got := report.Checks["slow"]; got.Status != "fail" || !strings.Contains(got.Error, "deadline") {
+		t.Fatalf("slow = %+v; want a deadline failure", got)
+	}
+	if report.Checks["fast"].Status != "ok" {
+		t.Errorf("fast = %+v; one slow check must not fail the others", report.Checks["fast"])
+	}
+}
+
+func TestRun_CachesResults(t *testing.T) {
+	r := New()
+	now := time.Unix(0, 0)
+	r.now = func() time.Time { return now }
+	var calls atomic.Int32
+	counting := CheckerFunc(func(context.Context) error { calls.Add(1); return nil })
+	r.Register(Readiness, "db", counting, WithCacheTTL(5*time.Second))
+
+	first := r.Run(context.Background(), Readiness).Checks["db"]
+	now = now.Add(4 * time.Second)
+	second := r.Run(context.Background(), Readiness).Checks["db"]
+	if calls.Load() != 1 || first.Cached || !second.Cached {
+		t.Fatalf("calls=%d first=%+v second=%+v; want one call and the second result cached", calls.Load(), first, second)
+	}
+	now = now.Add(2 * time.Second)
+	if r.Run(context.Background(), Readiness).Checks["db"].Cached || calls.Load() != 2 {
+		t.Fatalf("calls=%d; want the check to run again after the TTL", calls.Load())
+	}
+}
+
+func TestRun_ConcurrentProbesShareOneRun(t *testing.T) {
+	r := New()
+	var calls atomic.Int32
+	slow := CheckerFunc(func(context.Context) error {
+		calls.Add(1)
+		time.Sleep(50 * time.Millisecond)
+		return nil
+	})
+	r.Register(Readiness, "db", slow, WithCacheTTL(time.Minute))
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			r.Run(context.Background(), Readiness)
+		}()
+	}
+	wg.Wait()
+	if calls.Load() != 1 {
+		t.Fatalf("check ran %d times for 10 concurrent probes; want 1", calls.Load())
+	}
+}
+
+func TestRun_PanickingCheckFails(t *testing.T) {
+	r := New()
+	r.Register(Liveness, "oops", CheckerFunc(func(context.Context) error { panic("nil map") }))
+	if got := r.Run(context.Background(), Liveness).Checks["oops"]; got.Status != "fail" || !strings.Contains(got.Error, "nil map") {
+		t.Fatalf("oops = %+v; want a failure mentioning the panic", got)
+	}
+}
+
+func TestMarkShuttingDown_FailsOnlyReadiness(t *testing.T) {
+	r := New()
+	r.Register(Liveness, "loop", pass)
+	r.Register(Readiness, "db", pass)
+	h := serve(r)
+	r.MarkShuttingDown()
+
+	if code, _ := get(t, h, "/livez"); code != http.StatusOK {
+		t.Errorf("/livez = %d after MarkShuttingDown; want 200, or the orchestrator kills the draining process", code)
+	}
+	code, report := get(t, h, "/readyz")
+	if code != http.StatusServiceUnavailable || report.Checks["shutdown"].Status != "fail" {
+		t.Fatalf("/readyz = %d %+v; want 503 with a shutdown entry", code, report)
+	}
+}
+
+func TestRegister_DuplicateNamePanics(t *testing.T) {
+	r := New()
+	r.Register(Readiness, "db", pass)
+	r.Register(Liveness, "db", pass) // other kind: fine
+	defer func() {
+		if recover() == nil {
+			t.Fatal("registering db twice did not panic")
+		}
+	}()
+	r.Register(Readiness, "db", pass)
+}
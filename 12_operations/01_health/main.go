@@ -0,0 +1,144 @@
+// Demonstrates liveness and readiness endpoints with the health package.
+//
+// This example shows:
+// - Registering checks: a SQLite ping, free disk space and a downstream HTTP service
+// - /livez for "restart me" and /readyz for "send me traffic"
+// - Per-check timeouts, so a hung dependency fails the probe instead of hanging it
+// - Cached results, so frequent probes do not hammer dependencies
+// - Failing readiness, but not liveness, when shutdown starts
+package main
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"sync/atomic"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"golang_roadmap/12_operations/01_health/health"
+)
+
+func main() {
+	dir, err := os.MkdirTemp("", "health-demo")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	db, err := sql.Open("sqlite3", filepath.Join(dir, "app.db"))
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer db.Close()
+
+	downstream, mode := startDownstream()
+	defer downstream.Close()
+
+	// A worker loop that must keep running; it proves it with a heartbeat.
+	heartbeat := health.NewHeartbeat(2 * time.Second)
+	var stuck atomic.Bool
+	go func() {
+		for range time.Tick(200 * time.Millisecond) {
+			if !stuck.Load() {
+				heartbeat.Beat()
+			}
+		}
+	}()
+
+	checks := health.New()
+	checks.Register(health.Liveness, "worker", heartbeat)
+	checks.Register(health.Readiness, "db", health.DBPing(db), health.WithTimeout(time.Second))
+	checks.Register(health.Readiness, "disk", health.DiskSpace(dir, 100<<20), health.WithCacheTTL(30*time.Second))
+	checks.Register(health.Readiness, "payments", health.HTTPGet(nil, "http://"+downstream.Addr().String()+"/healthz"),
+		health.WithTimeout(300*time.Millisecond), health.WithCacheTTL(time.Second))
+
+	mux := http.NewServeMux()
+	checks.Mount(mux)
+	server := &http.Server{Addr: ":8082", Handler: mux}
+	ln, err := net.Listen("tcp", server.Addr)
+	if err != nil {
+		log.Fatal(err)
+	}
+	go server.Serve(ln)
+	base := "http://localhost:8082"
+
+	fmt.Println("=== All dependencies up ===")
+	probe(base + "/livez")
+	probe(base + "/readyz")
+
+	fmt.Println("\n=== Second probe within the TTL: disk and payments are cached ===")
+	probe(base + "/readyz")
+
+	fmt.Println("\n=== Downstream hangs: the 300ms timeout fails readiness, liveness still passes ===")
+	mode.Store("hang")
+	time.Sleep(time.Second) // let the cached result expire
+	probe(base + "/readyz")
+	probe(base + "/livez")
+	mode.Store("ok")
+
+	fmt.Println("\n=== Database closed ===")
+	time.Sleep(time.Second)
+	db.Close()
+	probe(base + "/readyz")
+
+	fmt.Println("\n=== Worker loop stuck: liveness fails, the orchestrator would restart us ===")
+	stuck.Store(true)
+	time.Sleep(2500 * time.Millisecond)
+	probe(base + "/livez")
+	stuck.Store(false)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	fmt.Println("\nServing on :8082. Try:")
+	fmt.Println("  curl -i localhost:8082/readyz")
+	fmt.Println("Press Ctrl+C: readiness fails first, then the server stops.")
+	<-ctx.Done()
+
+	checks.MarkShuttingDown()
+	probe(base + "/readyz")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	server.Shutdown(shutdownCtx)
+}
+
+// probe prints the status line and body, as `curl -i` would.
+func probe(url string) {
+	resp, err := http.Get(url)
+	if err != nil {
+		log.Printf("GET %s: %v", url, err)
+		return
+	}
+	defer resp.Body.Close()
+	body, _ := io.ReadAll(resp.Body)
+	fmt.Printf("GET %s -> %s\n%s", url[len("http://localhost:8082"):], resp.Status, body)
+}
+
+// startDownstream runs a stand-in for another service. mode switches it
+// between "ok" and "hang".
+func startDownstream() (net.Listener, *atomic.Value) {
+	var mode atomic.Value
+	mode.Store("ok")
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	go http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if mode.Load() == "hang" {
+			select {
+			case <-r.Context().Done():
+			case <-time.After(5 * time.Second):
+			}
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	}))
+	return ln, &mode
+}
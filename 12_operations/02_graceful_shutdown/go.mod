@@ -0,0 +1,8 @@
+module golang_roadmap/12_operations/02_graceful_shutdown
+
+go 1.24.11
+
+require golang_roadmap/12_operations/01_health v0.0.0
+
+// The health package lives in its own module in this repository.
+replace golang_roadmap/12_operations/01_health => ../01_health
@@ -0,0 +1,120 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"log"
+	"net"
+	"net/http"
+	"os"
+	"syscall"
+	"time"
+
+	"golang_roadmap/12_operations/01_health/health"
+)
+
+// Exit codes. Orchestrators record them; 0 means the process stopped on
+// purpose, anything else shows up as a failure.
+const (
+	exitOK           = 0
+	exitError        = 1 // could not start, or the server failed
+	exitDrainTimeout = 2 // in-flight requests were cut off at ShutdownTimeout
+	// A second signal aborts the drain: 128+signal, as a shell reports a
+	// process killed by that signal (130 for SIGINT, 143 for SIGTERM).
+)
+
+// Lifecycle runs an HTTP server from start to exit code.
+//
+// On the first SIGTERM or SIGINT it:
+//  1. fails /readyz, so load balancers stop routing new requests here;
+//  2. keeps serving for DrainDelay, because they notice only at their next
+//     probe, and requests already routed here are still arriving
+//     (Kubernetes does this with a preStop sleep, which is the same thing);
+//  3. stops accepting connections and waits up to ShutdownTimeout for
+//     in-flight requests;
+//  4. returns the exit code.
+//
+// DrainDelay + ShutdownTimeout must fit in the orchestrator's grace period
+// (Kubernetes: terminationGracePeriodSeconds, default 30s; docker stop:
+// -t, default 10s), or the process is SIGKILLed mid-drain.
+type Lifecycle struct {
+	Addr            string
+	Listener        net.Listener // used instead of Addr if set
+	Handler         http.Handler
+	Health          *health.Registry
+	DrainDelay      time.Duration
+	ShutdownTimeout time.Duration
+}
+
+// Run serves until a signal arrives on signals, then shuts down as above.
+func (l *Lifecycle) Run(signals <-chan os.Signal) int {
+	if os.Getpid() == 1 {
+		// Inside a container without an init process. Signals arrive fine
+		// because we handle them, but nobody reaps zombie children.
+		log.Printf("running as PID 1; use docker run --init (or tini) if this process starts children")
+	}
+	ln := l.Listener
+	if ln == nil {
+		var err error
+		if ln, err = net.Listen("tcp", l.Addr); err != nil {
+			log.Printf("startup failed: %v", err)
+			return exitError
+		}
+	}
+
+	mux := http.NewServeMux()
+	l.Health.Mount(mux)
+	mux.Handle("/", l.Handler)
+	server := &http.Server{Handler: mux, ReadHeaderTimeout: 5 * time.Second}
+	serveErr := make(chan error, 1)
+	go func() { serveErr <- server.Serve(ln) }()
+	log.Printf("listening on %s", ln.Addr())
+
+	var sig os.Signal
+	select {
+	case err := <-serveErr:
+		log.Printf("server failed: %v", err)
+		return exitError
+	case sig = <-signals:
+	}
+
+	log.Printf("received %v: failing readiness, draining for %v", sig, l.DrainDelay)
+	l.Health.MarkShuttingDown()
+	// Ask keep-alive clients to reconnect, which sends them to other
+	// instances once the load balancer has caught up.
+	server.SetKeepAlivesEnabled(false)
+	select {
+	case <-time.After(l.DrainDelay):
+	case sig = <-signals:
+		return abort(server, sig)
+	}
+
+	log.Printf("closing listener, waiting up to %v for in-flight requests", l.ShutdownTimeout)
+	ctx, cancel := context.WithTimeout(context.Background(), l.ShutdownTimeout)
+	defer cancel()
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- server.Shutdown(ctx) }()
+	select {
+	case err := <-shutdown:
+		if errors.Is(err, context.DeadlineExceeded) {
+			server.Close()
+			log.Printf("shutdown timed out; in-flight requests were cut off")
+			return exitDrainTimeout
+		}
+		log.Printf("shutdown complete")
+		return exitOK
+	case sig = <-signals:
+		return abort(server, sig)
+	}
+}
+
+// abort handles a second signal: the operator does not want to wait.
+func abort(server *http.Server, sig os.Signal) int {
+	server.Close()
+	code := exitError
+	if s, ok := sig.(syscall.Signal); ok {
+		code = 128 + int(s)
+	}
+	log.Printf("received %v again: aborting, exit %d", sig, code)
+	return code
+}
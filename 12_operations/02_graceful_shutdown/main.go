@@ -0,0 +1,111 @@
+// Demonstrates a graceful shutdown that loses no requests when a container
+// is stopped or a Kubernetes pod is replaced.
+//
+// This example shows:
+// - Handling SIGTERM (what docker stop and the kubelet send) and SIGINT
+// - Failing readiness first, then a preStop-style delay while load balancers catch up
+// - Draining in-flight requests with http.Server.Shutdown and a deadline
+// - Exit codes that tell the orchestrator how the process stopped
+// - A -probe mode for Docker HEALTHCHECK in images without curl
+//
+// Try it:
+//
+//	go run . &
+//	curl "localhost:8080/work?ms=3000" & sleep 0.2; kill -TERM %1
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang_roadmap/12_operations/01_health/health"
+)
+
+func main() {
+	addr := flag.String("addr", envOr("ADDR", ":8080"), "listen address")
+	drainDelay := flag.Duration("drain-delay", envDuration("DRAIN_DELAY", 5*time.Second), "time to keep serving after readiness fails")
+	shutdownTimeout := flag.Duration("shutdown-timeout", envDuration("SHUTDOWN_TIMEOUT", 10*time.Second), "time allowed for in-flight requests")
+	probe := flag.String("probe", "", "GET this URL, exit 0 on 2xx and 1 otherwise (for Docker HEALTHCHECK)")
+	flag.Parse()
+
+	if *probe != "" {
+		os.Exit(probeURL(*probe))
+	}
+
+	// Notify, not NotifyContext: the second signal must be seen too.
+	signals := make(chan os.Signal, 2)
+	signal.Notify(signals, syscall.SIGTERM, os.Interrupt)
+
+	l := &Lifecycle{
+		Addr:            *addr,
+		Handler:         routes(),
+		Health:          health.New(),
+		DrainDelay:      *drainDelay,
+		ShutdownTimeout: *shutdownTimeout,
+	}
+	code := l.Run(signals)
+	log.Printf("exit %d", code)
+	os.Exit(code)
+}
+
+func routes() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, "hello")
+	})
+	// /work?ms=N simulates a request that takes N milliseconds.
+	mux.HandleFunc("GET /work", func(w http.ResponseWriter, r *http.Request) {
+		d, err := time.ParseDuration(r.URL.Query().Get("ms") + "ms")
+		if err != nil {
+			http.Error(w, "ms must be a number", http.StatusBadRequest)
+			return
+		}
+		select {
+		case <-time.After(d):
+			fmt.Fprintf(w, "worked %v\n", d)
+		case <-r.Context().Done(): // client gone, or the server forced closed
+		}
+	})
+	return mux
+}
+
+// probeURL is a tiny curl for distroless and scratch images.
+func probeURL(url string) int {
+	client := &http.Client{Timeout: 2 * time.Second}
+	resp, err := client.Get(url)
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		return 1
+	}
+	resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		fmt.Fprintln(os.Stderr, resp.Status)
+		return 1
+	}
+	return 0
+}
+
+func envOr(key, def string) string {
+	if v, ok := os.LookupEnv(key); ok {
+		return v
+	}
+	return def
+}
+
+func envDuration(key string, def time.Duration) time.Duration {
+	v, ok := os.LookupEnv(key)
+	if !ok {
+		return def
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		log.Fatalf("%s: %v", key, err)
+	}
+	return d
+}
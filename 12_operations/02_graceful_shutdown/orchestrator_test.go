@@ -0,0 +1,279 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang_roadmap/12_operations/01_health/health"
+)
+
+// orchestrator simulates what Kubernetes does around one pod: a readiness
+// probe loop, a load balancer that routes only to ready pods, and
+// termination with a grace period.
+type orchestrator struct {
+	t       *testing.T
+	base    string
+	client  *http.Client
+	signals chan os.Signal
+	exited  chan int
+
+	ready atomic.Bool // the load balancer's view, as of the last probe
+}
+
+// launch starts l and waits until it is ready, like a startup probe.
+func launch(t *testing.T, l *Lifecycle) *orchestrator {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	l.Listener = ln
+	if l.Handler == nil {
+		l.Handler = routes()
+	}
+	if l.Health == nil {
+		l.Health = health.New()
+	}
+	o := &orchestrator{
+		t:       t,
+		base:    "http://" + ln.Addr().String(),
+		client:  &http.Client{Timeout: 10 * time.Second},
+		signals: make(chan os.Signal, 2),
+		exited:  make(chan int, 1),
+	}
+	go func() { o.exited <- l.Run(o.signals) }()
+	t.Cleanup(func() {
+		select {
+		case o.signals <- syscall.SIGKILL: // abort whatever is left
+		default:
+		}
+	})
+
+	deadline := time.Now().Add(5 * time.Second)
+	for code, _ := o.get("/readyz"); code != http.StatusOK; code, _ = o.get("/readyz") {
+		if time.Now().After(deadline) {
+			t.Fatal("never became ready")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	o.ready.Store(true)
+	return o
+}
+
+func (o *orchestrator) get(path string) (int, error) {
+	resp, err := o.client.Get(o.base + path)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, resp.Body)
+	return resp.StatusCode, nil
+}
+
+// probe polls /readyz every period until ctx is done. One failure marks
+// the pod unready (failureThreshold: 1).
+func (o *orchestrator) probe(ctx context.Context, period time.Duration) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(period):
+		}
+		code, err := o.get("/readyz")
+		o.ready.Store(err == nil && code == http.StatusOK)
+	}
+}
+
+type traffic struct {
+	ok, failed    atomic.Int64
+	okAfterSignal atomic.Int64
+	signalled     atomic.Bool
+	mu            sync.Mutex
+	firstFailure  error
+}
+
+// loadBalance sends requests from workers clients in a loop, to this pod
+// only while it is ready, as a load balancer with one pod in rotation.
+func (o *orchestrator) loadBalance(ctx context.Context, workers int) (*traffic, *sync.WaitGroup) {
+	tr := &traffic{}
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for ctx.Err() == nil {
+				if !o.ready.Load() {
+					time.Sleep(5 * time.Millisecond)
+					continue
+				}
+				code, err := o.get("/work?ms=5")
+				if err == nil && code != http.StatusOK {
+					err = fmt.Errorf("status %d", code)
+				}
+				if err != nil {
+					tr.failed.Add(1)
+					tr.mu.Lock()
+					if tr.firstFailure == nil {
+						tr.firstFailure = err
+					}
+					tr.mu.Unlock()
+					continue
+				}
+				tr.ok.Add(1)
+				if tr.signalled.Load() {
+					tr.okAfterSignal.Add(1)
+				}
+			}
+		}()
+	}
+	return tr, &wg
+}
+
+// terminate sends sig and waits for the exit code. After grace the kubelet
+// would SIGKILL the process, so overrunning it fails the test.
+func (o *orchestrator) terminate(sig os.Signal, grace time.Duration) int {
+	o.t.Helper()
+	o.signals <- sig
+	select {
+	case code := <-o.exited:
+		return code
+	case <-time.After(grace):
+		o.t.Fatalf("still running %v after %v; the orchestrator would SIGKILL it (exit 137)", grace, sig)
+		return 137
+	}
+}
+
+func TestRollingUpdate_NoFailedRequests(t *testing.T) {
+	o := launch(t, &Lifecycle{DrainDelay: 300 * time.Millisecond, ShutdownTimeout: time.Second})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go o.probe(ctx, 50*time.Millisecond)
+	tr, wg := o.loadBalance(ctx, 4)
+
+	time.Sleep(100 * time.Millisecond)
+	tr.signalled.Store(true)
+	code := o.terminate(syscall.SIGTERM, 3*time.Second)
+	cancel()
+	wg.Wait()
+
+	if code != exitOK {
+		t.Errorf("exit code %d; want %d", code, exitOK)
+	}
+	if n := tr.failed.Load(); n != 0 {
+		t.Fatalf("%d of %d requests failed, first: %v; the drain delay should cover the probe period",
+			n, n+tr.ok.Load(), tr.firstFailure)
+	}
+	if tr.okAfterSignal.Load() == 0 {
+		t.Error("no requests served after SIGTERM; the pod must keep serving while the load balancer catches up")
+	}
+}
+
+// TestRollingUpdate_WithoutDrainDelayDropsRequests shows why the delay
+// exists: the listener closes before the load balancer notices.
+func TestRollingUpdate_WithoutDrainDelayDropsRequests(t *testing.T) {
+	o := launch(t, &Lifecycle{DrainDelay: 0, ShutdownTimeout: time.Second})
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go o.probe(ctx, 100*time.Millisecond)
+	tr, wg := o.loadBalance(ctx, 4)
+
+	time.Sleep(50 * time.Millisecond)
+	o.terminate(syscall.SIGTERM, 3*time.Second)
+	time.Sleep(150 * time.Millisecond) // until the next probe takes the pod out
+	cancel()
+	wg.Wait()
+
+	if tr.failed.Load() == 0 {
+		t.Fatal("no failed requests; expected some in the window before the next probe")
+	}
+}
+
+func TestShutdown_ReadinessFailsLivenessPasses(t *testing.T) {
+	o := launch(t, &Lifecycle{DrainDelay: time.Second, ShutdownTimeout: time.Second})
+	o.signals <- syscall.SIGTERM
+	time.Sleep(50 * time.Millisecond)
+
+	if code, err := o.get("/readyz"); code != http.StatusServiceUnavailable {
+		t.Errorf("/readyz during drain = %d, %v; want 503", code, err)
+	}
+	if code, err := o.get("/livez"); code != http.StatusOK {
+		t.Errorf("/livez during drain = %d, %v; want 200, or the pod is killed mid-drain", code, err)
+	}
+	if code, err := o.get("/"); code != http.StatusOK {
+		t.Errorf("GET / during drain = %d, %v; want 200: requests still routed here must be served", code, err)
+	}
+}
+
+func TestShutdown_WaitsForInFlightRequest(t *testing.T) {
+	o := launch(t, &Lifecycle{DrainDelay: 0, ShutdownTimeout: 2 * time.Second})
+	result := make(chan error, 1)
+	go func() {
+		code, err := o.get("/work?ms=300")
+		if err == nil && code != http.StatusOK {
+			err = fmt.Errorf("status %d", code)
+		}
+		result <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	if code := o.terminate(syscall.SIGTERM, 3*time.Second); code != exitOK {
+		t.Errorf("exit code %d; want %d", code, exitOK)
+	}
+	if err := <-result; err != nil {
+		t.Fatalf("in-flight request: %v; want it to complete", err)
+	}
+	if _, err := o.get("/"); err == nil {
+		t.Error("new connection accepted after shutdown")
+	}
+}
+
+func TestShutdown_TimeoutCutsOffSlowRequest(t *testing.T) {
+	o := launch(t, &Lifecycle{DrainDelay: 0, ShutdownTimeout: 100 * time.Millisecond})
+	result := make(chan error, 1)
+	go func() {
+		_, err := o.get("/work?ms=5000")
+		result <- err
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	start := time.Now()
+	if code := o.terminate(syscall.SIGTERM, 3*time.Second); code != exitDrainTimeout {
+		t.Errorf("exit code %d; want %d", code, exitDrainTimeout)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Errorf("shutdown took %v; want about ShutdownTimeout", took)
+	}
+	if err := <-result; err == nil {
+		t.Error("slow request succeeded; want it cut off")
+	}
+}
+
+func TestShutdown_SecondSignalAborts(t *testing.T) {
+	o := launch(t, &Lifecycle{DrainDelay: time.Minute, ShutdownTimeout: time.Minute})
+	o.signals <- syscall.SIGTERM
+	time.Sleep(20 * time.Millisecond)
+	if code := o.terminate(os.Interrupt, time.Second); code != 128+int(syscall.SIGINT) {
+		t.Fatalf("exit code %d; want %d", code, 128+int(syscall.SIGINT))
+	}
+}
+
+func TestStartup_ListenFailure(t *testing.T) {
+	taken, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer taken.Close()
+	l := &Lifecycle{Addr: taken.Addr().String(), Handler: routes(), Health: health.New()}
+	if code := l.Run(make(chan os.Signal)); code != exitError {
+		t.Fatalf("exit code %d; want %d", code, exitError)
+	}
+}
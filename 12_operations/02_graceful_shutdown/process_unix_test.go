@@ -0,0 +1,79 @@
+//go:build unix
+
+package main
+
+import (
+	"bufio"
+	"errors"
+	"net/http"
+	"os"
+	"os/exec"
+	"regexp"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// TestMain lets the test binary double as the program, so
+// TestProcess_SIGTERM can run main in a child process and signal it for
+// real, as docker stop does.
+func TestMain(m *testing.M) {
+	if os.Getenv("GRACEFUL_SHUTDOWN_CHILD") == "1" {
+		os.Args = append([]string{"app"}, flagArgs()...)
+		main()
+		return
+	}
+	os.Exit(m.Run())
+}
+
+func flagArgs() []string {
+	return []string{"-addr", "127.0.0.1:0", "-drain-delay", "100ms", "-shutdown-timeout", "1s"}
+}
+
+func TestProcess_SIGTERM(t *testing.T) {
+	cmd := exec.Command(os.Args[0])
+	cmd.Env = append(os.Environ(), "GRACEFUL_SHUTDOWN_CHILD=1")
+	stderr, err := cmd.StderrPipe()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := cmd.Start(); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cmd.Process.Kill() })
+
+	lines := bufio.NewScanner(stderr)
+	listening := regexp.MustCompile(`listening on (\S+)`)
+	var addr string
+	for addr == "" && lines.Scan() {
+		if m := listening.FindStringSubmatch(lines.Text()); m != nil {
+			addr = m[1]
+		}
+	}
+	if addr == "" {
+		t.Fatal("child never reported its address")
+	}
+	if resp, err := http.Get("http://" + addr + "/readyz"); err != nil || resp.StatusCode != http.StatusOK {
+		t.Fatalf("child not ready: %v", err)
+	}
+
+	cmd.Process.Signal(syscall.SIGTERM)
+	done := make(chan error, 1)
+	go func() {
+		for lines.Scan() {
+		}
+		done <- cmd.Wait()
+	}()
+	select {
+	case err := <-done:
+		var exit *exec.ExitError
+		if err != nil && !errors.As(err, &exit) {
+			t.Fatal(err)
+		}
+		if code := cmd.ProcessState.ExitCode(); code != exitOK {
+			t.Fatalf("exit code %d; want %d", code, exitOK)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("child did not exit within 5s of SIGTERM")
+	}
+}
@@ -0,0 +1,113 @@
+// Demonstrates retrying failed calls with the retry package.
+//
+// This example shows:
+// - Exponential backoff with jitter between attempts
+// - Classifying errors: retry 5xx and timeouts, stop on 4xx
+// - Honouring a server's Retry-After
+// - Giving up when the caller's context ends
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"time"
+
+	"golang_roadmap/12_operations/03_retry/retry"
+)
+
+// statusError is a non-2xx response.
+type statusError struct {
+	code       int
+	retryAfter time.Duration
+}
+
+func (e *statusError) Error() string             { return fmt.Sprintf("HTTP %d", e.code) }
+func (e *statusError) RetryAfter() time.Duration { return e.retryAfter }
+
+// get classifies failures for retry: 5xx, 429 and network errors are
+// worth another attempt; other 4xx responses are the caller's fault.
+func get(ctx context.Context, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return retry.Permanent(err)
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	resp.Body.Close()
+	switch {
+	case resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		secs, _ := strconv.Atoi(resp.Header.Get("Retry-After"))
+		return &statusError{code: resp.StatusCode, retryAfter: time.Duration(secs) * time.Second}
+	default:
+		return retry.Permanent(&statusError{code: resp.StatusCode})
+	}
+}
+
+func main() {
+	base := startFlakyServer()
+	start := time.Now()
+	policy := retry.Policy{
+		MaxAttempts: 5,
+		Initial:     100 * time.Millisecond,
+		Max:         2 * time.Second,
+		OnRetry: func(attempt int, err error, delay time.Duration) {
+			fmt.Printf("  %5.2fs attempt %d failed: %v; retrying in %v\n",
+				time.Since(start).Seconds(), attempt, err, delay.Round(time.Millisecond))
+		},
+	}
+	run := func(title, path string, timeout time.Duration) {
+		fmt.Printf("\n=== %s ===\n", title)
+		start = time.Now()
+		ctx, cancel := context.WithTimeout(context.Background(), timeout)
+		defer cancel()
+		err := retry.Do(ctx, policy, func(ctx context.Context) error { return get(ctx, base+path) })
+		fmt.Printf("  %5.2fs result: %v\n", time.Since(start).Seconds(), errOrOK(err))
+	}
+
+	run("503 twice, then 200", "/flaky", 10*time.Second)
+	run("429 with Retry-After: 1", "/busy", 10*time.Second)
+	run("404 is permanent: no retries", "/missing", 10*time.Second)
+	run("Always 500: attempts run out", "/down", 10*time.Second)
+	run("Always 500, 500ms deadline: the context wins", "/down", 500*time.Millisecond)
+}
+
+func errOrOK(err error) any {
+	if err == nil {
+		return "ok"
+	}
+	return err
+}
+
+func startFlakyServer() string {
+	var flaky, busy atomic.Int32
+	mux := http.NewServeMux()
+	mux.HandleFunc("/flaky", func(w http.ResponseWriter, r *http.Request) {
+		if flaky.Add(1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	})
+	mux.HandleFunc("/busy", func(w http.ResponseWriter, r *http.Request) {
+		if busy.Add(1) == 1 {
+			w.Header().Set("Retry-After", "1")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}
+	})
+	mux.HandleFunc("/down", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	})
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	go http.Serve(ln, mux)
+	return "http://" + ln.Addr().String()
+}
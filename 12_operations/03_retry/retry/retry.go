@@ -0,0 +1,126 @@
+// Package retry calls a function until it succeeds, with exponential
+// backoff and jitter between attempts.
+//
+// Retry only what can succeed on a second try: timeouts, connection
+// resets, 5xx and 429 responses, "try again later" replies. Mark errors
+// that cannot (bad input, authentication failures) with Permanent, and
+// make sure the operation is safe to repeat: a timeout does not mean the
+// first attempt did nothing.
+package retry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+// Policy says how often and how fast to retry. The zero value is usable:
+// 3 attempts, backoff from 100ms doubling up to 5s.
+type Policy struct {
+	MaxAttempts int           // total attempts, including the first (default 3)
+	Initial     time.Duration // delay before the second attempt (default 100ms)
+	Max         time.Duration // cap on any one delay (default 5s)
+
+	// Retryable reports whether err is worth another attempt. The default
+	// retries everything except Permanent errors and context errors.
+	// Permanent errors are never retried, whatever Retryable says.
+	Retryable func(err error) bool
+
+	// OnRetry, if set, is called before each wait, e.g. to log.
+	OnRetry func(attempt int, err error, delay time.Duration)
+
+	// Clock times the waits (default clock.Real). Tests pass a fake one.
+	Clock clock.Clock
+}
+
+func (p Policy) withDefaults() Policy {
+	if p.MaxAttempts <= 0 {
+		p.MaxAttempts = 3
+	}
+	if p.Initial <= 0 {
+		p.Initial = 100 * time.Millisecond
+	}
+	if p.Max <= 0 {
+		p.Max = 5 * time.Second
+	}
+	if p.Clock == nil {
+		p.Clock = clock.Real()
+	}
+	if p.Retryable == nil {
+		p.Retryable = func(err error) bool {
+			return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+		}
+	}
+	return p
+}
+
+// Delay returns the wait after failed attempt n (n >= 1): Initial doubled
+// n-1 times, capped at Max, then a random value in its upper half ("equal
+// jitter"). Jitter keeps clients that failed together from retrying
+// together.
+func (p Policy) Delay(n int) time.Duration {
+	p = p.withDefaults()
+	d := p.Max
+	if n < 30 { // avoid overflowing the shift
+		d = min(p.Initial<<max(n-1, 0), p.Max)
+	}
+	half := d / 2
+	return half + rand.N(half+1)
+}
+
+// Do calls fn until it returns nil, returns a non-retryable error, or the
+// attempts run out. It returns nil or the last error, wrapped with the
+// number of attempts made. If ctx ends while waiting, Do returns at once
+// with an error that wraps both ctx.Err() and the last error.
+func Do(ctx context.Context, p Policy, fn func(ctx context.Context) error) error {
+	p = p.withDefaults()
+	for attempt := 1; ; attempt++ {
+		err := fn(ctx)
+		if err == nil {
+			return nil
+		}
+		if errors.Is(err, errPermanent) || !p.Retryable(err) {
+			return err
+		}
+		if attempt == p.MaxAttempts {
+			return fmt.Errorf("after %d attempts: %w", attempt, err)
+		}
+
+		delay := p.Delay(attempt)
+		var ra interface{ RetryAfter() time.Duration }
+		if errors.As(err, &ra) && ra.RetryAfter() > delay {
+			delay = ra.RetryAfter() // the server knows best when to come back
+		}
+		if p.OnRetry != nil {
+			p.OnRetry(attempt, err, delay)
+		}
+		select {
+		case <-p.Clock.After(delay):
+		case <-ctx.Done():
+			return fmt.Errorf("gave up after %d attempts: %w (last error: %w)", attempt, ctx.Err(), err)
+		}
+	}
+}
+
+var errPermanent = errors.New("permanent")
+
+type permanentError struct{ err error }
+
+func (e permanentError) Error() string   { return e.err.Error() }
+func (e permanentError) Unwrap() []error { return []error{e.err, errPermanent} }
+
+// Permanent marks err as not worth retrying. The message is unchanged and
+// errors.Is/As still see err.
+func Permanent(err error) error {
+	if err == nil {
+		return nil
+	}
+	return permanentError{err}
+}
+
+// IsPermanent reports whether err was marked with Permanent.
+func IsPermanent(err error) bool { return errors.Is(err, errPermanent) }
@@ -0,0 +1,191 @@
+package retry
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+var errFlaky = errors.New("connection reset")
+
+var epoch = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+// fast retries without noticeable waits.
+var fast = Policy{MaxAttempts: 5, Initial: time.Millisecond, Max: 2 * time.Millisecond}
+
+// failing returns fn that fails the first n calls with err, and counts calls.
+func failing(n int, err error) (func(context.Context) error, *int) {
+	calls := 0
+	return func(context.Context) error {
+		calls++
+		if calls <= n {
+			return err
+		}
+		return nil
+	}, &calls
+}
+
+func TestDo_SucceedsAfterTransientFailures(t *testing.T) {
+	fn, calls := failing(3, errFlaky)
+	var retries []int
+	p := fast
+	p.OnRetry = func(attempt int, err error, _ time.Duration) { retries = append(retries, attempt) }
+	if err := Do(context.Background(), p, fn); err != nil {
+		t.Fatalf("Do = %v; want success on attempt 4", err)
+	}
+	if *calls != 4 || len(retries) != 3 {
+		t.Fatalf("calls=%d retries=%v; want 4 calls and 3 retries", *calls, retries)
+	}
+}
+
+func TestDo_GivesUpAfterMaxAttempts(t *testing.T) {
+	fn, calls := failing(100, errFlaky)
+	err := Do(context.Background(), fast, fn)
+	if !errors.Is(err, errFlaky) || !strings.Contains(err.Error(), "after 5 attempts") {
+		t.Fatalf("Do = %v; want the last error after 5 attempts", err)
+	}
+	if *calls != 5 {
+		t.Fatalf("calls = %d; want 5", *calls)
+	}
+}
+
+func TestDo_StopsOnPermanentError(t *testing.T) {
+	badInput := errors.New("550 no such mailbox")
+	fn, calls := failing(100, Permanent(badInput))
+	err := Do(context.Background(), fast, fn)
+	if *calls != 1 || !errors.Is(err, badInput) || !IsPermanent(err) {
+		t.Fatalf("calls=%d err=%v; want one call returning the permanent error", *calls, err)
+	}
+	if err.Error() != badInput.Error() {
+		t.Errorf("message = %q; Permanent must not change it", err)
+	}
+}
+
+func TestDo_RetryableClassifier(t *testing.T) {
+	p := fast
+	p.Retryable = func(err error) bool { return errors.Is(err, errFlaky) }
+	other := errors.New("401 unauthorized")
+	fn, calls := failing(100, other)
+	if err := Do(context.Background(), p, fn); !errors.Is(err, other) || *calls != 1 {
+		t.Fatalf("calls=%d err=%v; want one call for a non-retryable error", *calls, err)
+	}
+}
+
+func TestDo_ContextCancelledDuringWait(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	fake := clock.NewFake(epoch)
+	p := Policy{MaxAttempts: 10, Initial: time.Hour, Max: time.Hour, Clock: fake}
+	fn, calls := failing(100, errFlaky)
+
+	errc := make(chan error)
+	go func() { errc <- Do(ctx, p, fn) }()
+	fake.BlockUntil(1) // waiting after the first attempt
+	cancel()
+	err := <-errc
+	if !errors.Is(err, context.Canceled) || !errors.Is(err, errFlaky) || *calls != 1 {
+		t.Fatalf("calls=%d err=%v; want one call and an error wrapping both causes", *calls, err)
+	}
+}
+
+func TestDo_WaitsTheFullDelay(t *testing.T) {
+	fake := clock.NewFake(epoch)
+	var delay time.Duration
+	p := Policy{MaxAttempts: 2, Initial: time.Hour, Max: time.Hour, Clock: fake}
+	p.OnRetry = func(_ int, _ error, d time.Duration) { delay = d }
+	fn, calls := failing(1, errFlaky)
+
+	errc := make(chan error)
+	go func() { errc <- Do(context.Background(), p, fn) }()
+	fake.BlockUntil(1) // OnRetry has run; delay is set
+	fake.Advance(delay - time.Nanosecond)
+	select {
+	case err := <-errc:
+		t.Fatalf("Do returned %v before its delay of %v had passed", err, delay)
+	default:
+	}
+	fake.Advance(time.Nanosecond)
+	if err := <-errc; err != nil || *calls != 2 {
+		t.Fatalf("calls=%d err=%v; want success on the second call", *calls, err)
+	}
+}
+
+func TestDo_BackoffOnTheClock(t *testing.T) {
+	// An auto-advancing clock: each wait moves the fake time forward and
+	// returns at once, so a minute-scale backoff runs instantly.
+	fake := clock.NewAutoFake(epoch)
+	var delays, gaps []time.Duration
+	p := Policy{MaxAttempts: 5, Initial: 10 * time.Second, Max: 30 * time.Second, Clock: fake}
+	p.OnRetry = func(_ int, _ error, d time.Duration) { delays = append(delays, d) }
+	last := fake.Now()
+	err := Do(context.Background(), p, func(context.Context) error {
+		if now := fake.Now(); now != last {
+			gaps = append(gaps, now.Sub(last))
+			last = now
+		}
+		return errFlaky
+	})
+	if err == nil || !strings.Contains(err.Error(), "after 5 attempts") {
+		t.Fatalf("err = %v; want failure after 5 attempts", err)
+	}
+	if !slices.Equal(gaps, delays) {
+		t.Errorf("time between attempts %v; want the delays %v", gaps, delays)
+	}
+	for i, step := range []time.Duration{10 * time.Second, 20 * time.Second, 30 * time.Second, 30 * time.Second} {
+		if d := delays[i]; d < step/2 || d > step {
+			t.Errorf("delay %d = %v; want within [%v, %v]", i+1, d, step/2, step)
+		}
+	}
+}
+
+func TestDo_ContextErrorFromFnIsNotRetried(t *testing.T) {
+	fn, calls := failing(100, context.DeadlineExceeded)
+	if err := Do(context.Background(), fast, fn); *calls != 1 || !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("calls=%d err=%v; want one call", *calls, err)
+	}
+}
+
+type busyError struct{ after time.Duration }
+
+func (e busyError) Error() string             { return "429 too many requests" }
+func (e busyError) RetryAfter() time.Duration { return e.after }
+
+func TestDo_HonoursRetryAfter(t *testing.T) {
+	fake := clock.NewAutoFake(epoch)
+	var delays []time.Duration
+	p := fast
+	p.Clock = fake
+	p.OnRetry = func(_ int, _ error, d time.Duration) { delays = append(delays, d) }
+	fn, _ := failing(1, busyError{after: 2 * time.Hour})
+	if err := Do(context.Background(), p, fn); err != nil {
+		t.Fatal(err)
+	}
+	if len(delays) != 1 || delays[0] != 2*time.Hour {
+		t.Fatalf("delays = %v; want the server's 2h", delays)
+	}
+	if waited := fake.Now().Sub(epoch); waited != 2*time.Hour {
+		t.Fatalf("waited %v on the clock; want 2h", waited)
+	}
+}
+
+func TestPolicy_DelayBounds(t *testing.T) {
+	p := Policy{Initial: 100 * time.Millisecond, Max: time.Second}
+	for n, want := range map[int]time.Duration{1: 100 * time.Millisecond, 2: 200 * time.Millisecond, 4: 800 * time.Millisecond, 5: time.Second, 100: time.Second} {
+		for range 50 {
+			if d := p.Delay(n); d < want/2 || d > want {
+				t.Fatalf("Delay(%d) = %v; want within [%v, %v]", n, d, want/2, want)
+			}
+		}
+	}
+}
+
+func TestPermanent_Nil(t *testing.T) {
+	if Permanent(nil) != nil {
+		t.Fatal("Permanent(nil) != nil")
+	}
+}
@@ -0,0 +1,194 @@
+// Package clienttrace measures where the time of an outbound HTTP request
+// goes: DNS lookup, TCP connect, TLS handshake, waiting for the first
+// response byte, and reading the body.
+//
+// Wrap a client's transport in a Transport. Each request gets an
+// httptrace.ClientTrace; when its body is closed, the timings are logged
+// with slog and observed into Prometheus histograms.
+package clienttrace
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptrace"
+	"sync"
+	"time"
+)
+
+// Timings of one request. A phase that did not happen is zero: no DNS for
+// an IP literal, no connect or TLS on a reused connection, no TLS over
+// plain HTTP.
+type Timings struct {
+	Method string
+	Host   string
+	Status int // 0 if the request failed
+	Reused bool
+	Err    error
+
+	DNS     time.Duration
+	Connect time.Duration
+	TLS     time.Duration
+	TTFB    time.Duration // from the start of the request to the first response byte
+	Total   time.Duration // from the start of the request until the body was closed
+}
+
+// recorder collects trace events. The transport may call hooks from other
+// goroutines (dialing runs separately from the request, and may still be
+// going when a cancelled request returns), so fields are behind a mutex.
+type recorder struct {
+	ctx   context.Context // the request's, for the log record
+	mu    sync.Mutex
+	start time.Time
+	t     Timings
+
+	dnsStart, connectStart, tlsStart time.Time
+}
+
+func (r *recorder) trace() *httptrace.ClientTrace {
+	return &httptrace.ClientTrace{
+		DNSStart: func(httptrace.DNSStartInfo) { r.mark(&r.dnsStart) },
+		DNSDone:  func(httptrace.DNSDoneInfo) { r.since(&r.dnsStart, &r.t.DNS) },
+		// With several addresses (IPv6 and IPv4), several connects may
+		// race. Time from the first start to the first success.
+		ConnectStart: func(_, _ string) { r.markOnce(&r.connectStart) },
+		ConnectDone: func(_, _ string, err error) {
+			if err == nil {
+				r.sinceOnce(&r.connectStart, &r.t.Connect)
+			}
+		},
+		TLSHandshakeStart: func() { r.mark(&r.tlsStart) },
+		TLSHandshakeDone: func(_ tls.ConnectionState, err error) {
+			if err == nil {
+				r.since(&r.tlsStart, &r.t.TLS)
+			}
+		},
+		GotConn: func(info httptrace.GotConnInfo) {
+			r.mu.Lock()
+			r.t.Reused = info.Reused
+			r.mu.Unlock()
+		},
+		GotFirstResponseByte: func() { r.since(&r.start, &r.t.TTFB) },
+	}
+}
+
+func (r *recorder) mark(t *time.Time) {
+	r.mu.Lock()
+	*t = time.Now()
+	r.mu.Unlock()
+}
+
+func (r *recorder) markOnce(t *time.Time) {
+	r.mu.Lock()
+	if t.IsZero() {
+		*t = time.Now()
+	}
+	r.mu.Unlock()
+}
+
+func (r *recorder) since(start *time.Time, d *time.Duration) {
+	r.mu.Lock()
+	*d = time.Since(*start)
+	r.mu.Unlock()
+}
+
+func (r *recorder) sinceOnce(start *time.Time, d *time.Duration) {
+	r.mu.Lock()
+	if *d == 0 {
+		*d = time.Since(*start)
+	}
+	r.mu.Unlock()
+}
+
+// Transport is an http.RoundTripper that traces every request.
+type Transport struct {
+	Base    http.RoundTripper // default http.DefaultTransport
+	Logger  *slog.Logger      // default slog.Default()
+	Metrics *Metrics          // optional
+
+	// OnDone, if set, receives each request's timings (tests use it).
+	OnDone func(Timings)
+}
+
+func (t *Transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	rec := &recorder{ctx: req.Context(), start: time.Now()}
+	rec.t.Method, rec.t.Host = req.Method, req.URL.Host
+	req = req.WithContext(httptrace.WithClientTrace(req.Context(), rec.trace()))
+
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		rec.mu.Lock()
+		rec.t.Err = err
+		rec.mu.Unlock()
+		t.finish(rec)
+		return nil, err
+	}
+	rec.mu.Lock()
+	rec.t.Status = resp.StatusCode
+	rec.mu.Unlock()
+	// The request is not over until the body has been read: report then.
+	resp.Body = &body{ReadCloser: resp.Body, done: func() { t.finish(rec) }}
+	return resp, nil
+}
+
+func (t *Transport) finish(rec *recorder) {
+	rec.mu.Lock()
+	rec.t.Total = time.Since(rec.start)
+	timings := rec.t
+	rec.mu.Unlock()
+
+	logger := t.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	level, attrs := slog.LevelInfo, []slog.Attr{
+		slog.String("method", timings.Method),
+		slog.String("host", timings.Host),
+		slog.Int("status", timings.Status),
+		slog.Bool("reused", timings.Reused),
+		slog.Duration("dns", timings.DNS),
+		slog.Duration("connect", timings.Connect),
+		slog.Duration("tls", timings.TLS),
+		slog.Duration("ttfb", timings.TTFB),
+		slog.Duration("total", timings.Total),
+	}
+	if timings.Err != nil {
+		level = slog.LevelWarn
+		attrs = append(attrs, slog.Any("err", timings.Err))
+	}
+	logger.LogAttrs(rec.ctx, level, "http client request", attrs...)
+
+	if t.Metrics != nil {
+		t.Metrics.observe(timings)
+	}
+	if t.OnDone != nil {
+		t.OnDone(timings)
+	}
+}
+
+// body reports once, at EOF or Close, whichever comes first.
+type body struct {
+	io.ReadCloser
+	once sync.Once
+	done func()
+}
+
+func (b *body) Read(p []byte) (int, error) {
+	n, err := b.ReadCloser.Read(p)
+	if err == io.EOF {
+		b.once.Do(b.done)
+	}
+	return n, err
+}
+
+func (b *body) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.done)
+	return err
+}
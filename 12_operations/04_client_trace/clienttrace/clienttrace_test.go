@@ -0,0 +1,214 @@
+package clienttrace
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	dto "github.com/prometheus/client_model/go"
+)
+
+// setup starts a TLS server that answers after delay and returns a client
+// that calls it as "localhost", so DNS, connect and TLS all happen.
+func setup(t *testing.T, delay time.Duration) (client *http.Client, target string, done *[]Timings, logs *bytes.Buffer, reg *prometheus.Registry) {
+	t.Helper()
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(delay)
+		io.WriteString(w, "hello")
+	}))
+	t.Cleanup(srv.Close)
+	base := srv.Client().Transport.(*http.Transport).Clone()
+	base.TLSClientConfig.ServerName = "example.com" // the test certificate's name
+	t.Cleanup(base.CloseIdleConnections)
+	u, _ := url.Parse(srv.URL)
+
+	var mu sync.Mutex
+	done = new([]Timings)
+	logs = new(bytes.Buffer)
+	reg = prometheus.NewRegistry()
+	client = &http.Client{Transport: &Transport{
+		Base:    base,
+		Logger:  slog.New(slog.NewJSONHandler(logs, nil)),
+		Metrics: NewMetrics(reg),
+		OnDone: func(tm Timings) {
+			mu.Lock()
+			*done = append(*done, tm)
+			mu.Unlock()
+		},
+	}}
+	return client, "https://localhost:" + u.Port(), done, logs, reg
+}
+
+func get(t *testing.T, c *http.Client, url string) {
+	t.Helper()
+	resp, err := c.Get(url)
+	if err != nil {
+		t.Fatal(err)
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
+
+func TestTimingsNewAndReusedConnection(t *testing.T) {
+	client, target, done, _, _ := setup(t, 20*time.Millisecond)
+	get(t, client, target)
+	get(t, client, target)
+
+	if len(*done) != 2 {
+		t.Fatalf("%d timings reported, want 2", len(*done))
+	}
+	first, second := (*done)[0], (*done)[1]
+	if first.Reused || first.DNS <= 0 || first.Connect <= 0 || first.TLS <= 0 {
+		t.Errorf("first request: %+v; want a new connection with DNS, connect and TLS", first)
+	}
+	if !second.Reused || second.DNS != 0 || second.Connect != 0 || second.TLS != 0 {
+		t.Errorf("second request: %+v; want a reused connection", second)
+	}
+	for i, tm := range *done {
+		if tm.Status != 200 || tm.TTFB < 20*time.Millisecond || tm.Total < tm.TTFB {
+			t.Errorf("request %d: status %d ttfb %v total %v", i, tm.Status, tm.TTFB, tm.Total)
+		}
+	}
+	if first.TTFB < first.DNS+first.Connect+first.TLS {
+		t.Errorf("ttfb %v shorter than the phases before it", first.TTFB)
+	}
+}
+
+// Timings are reported when the body is done, so Total covers reading it.
+func TestReportedOnBodyClose(t *testing.T) {
+	client, target, done, _, _ := setup(t, 0)
+	resp, err := client.Get(target)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(*done) != 0 {
+		t.Fatal("reported before the body was read")
+	}
+	time.Sleep(10 * time.Millisecond)
+	resp.Body.Close()
+	resp.Body.Close() // a second Close must not report again
+	if len(*done) != 1 || (*done)[0].Total < 10*time.Millisecond {
+		t.Fatalf("timings = %+v; want one report with total >= 10ms", *done)
+	}
+}
+
+func TestLogRecord(t *testing.T) {
+	client, target, _, logs, _ := setup(t, 0)
+	get(t, client, target)
+
+	var rec map[string]any
+	if err := json.Unmarshal(logs.Bytes(), &rec); err != nil {
+		t.Fatalf("log %q: %v", logs, err)
+	}
+	for _, key := range []string{"method", "host", "status", "reused", "dns", "connect", "tls", "ttfb", "total"} {
+		if _, ok := rec[key]; !ok {
+			t.Errorf("log record has no %q: %s", key, logs)
+		}
+	}
+	if rec["level"] != "INFO" || rec["status"] != float64(200) {
+		t.Errorf("level %v status %v", rec["level"], rec["status"])
+	}
+}
+
+func TestFailedRequest(t *testing.T) {
+	client, _, done, logs, reg := setup(t, 0)
+	_, err := client.Get("http://127.0.0.1:1/")
+	if err == nil {
+		t.Fatal("want a connection error")
+	}
+	if len(*done) != 1 || (*done)[0].Err == nil || (*done)[0].Status != 0 {
+		t.Fatalf("timings = %+v", *done)
+	}
+	if !strings.Contains(logs.String(), `"level":"WARN"`) || !strings.Contains(logs.String(), "refused") {
+		t.Errorf("log = %s", logs)
+	}
+	want := `
+# HELP http_client_requests_total Outbound HTTP requests by host, status code and connection reuse.
+# TYPE http_client_requests_total counter
+http_client_requests_total{code="error",host="127.0.0.1:1",reused="false"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(want), "http_client_requests_total"); err != nil {
+		t.Error(err)
+	}
+}
+
+func TestMetrics(t *testing.T) {
+	client, target, _, _, reg := setup(t, 0)
+	for range 3 {
+		get(t, client, target)
+	}
+
+	// One new connection, then two reuses: connection phases once,
+	// request phases three times.
+	counts := map[string]uint64{}
+	for _, m := range gather(t, reg, "http_client_phase_duration_seconds") {
+		counts[label(m, "phase")] = m.GetHistogram().GetSampleCount()
+	}
+	want := map[string]uint64{"dns": 1, "connect": 1, "tls": 1, "ttfb": 3, "total": 3}
+	for phase, n := range want {
+		if counts[phase] != n {
+			t.Errorf("phase %s: %d samples, want %d", phase, counts[phase], n)
+		}
+	}
+
+	reused := map[string]float64{}
+	for _, m := range gather(t, reg, "http_client_requests_total") {
+		if label(m, "code") != "200" || label(m, "host") != strings.TrimPrefix(target, "https://") {
+			t.Errorf("unexpected series %v", m.GetLabel())
+		}
+		reused[label(m, "reused")] = m.GetCounter().GetValue()
+	}
+	if reused["false"] != 1 || reused["true"] != 2 {
+		t.Errorf("requests by reused = %v, want false:1 true:2", reused)
+	}
+}
+
+func gather(t *testing.T, reg *prometheus.Registry, name string) []*dto.Metric {
+	t.Helper()
+	families, err := reg.Gather()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, f := range families {
+		if f.GetName() == name {
+			return f.GetMetric()
+		}
+	}
+	t.Fatalf("no metric %s", name)
+	return nil
+}
+
+func label(m *dto.Metric, name string) string {
+	for _, l := range m.GetLabel() {
+		if l.GetName() == name {
+			return l.GetValue()
+		}
+	}
+	return ""
+}
+
+// Cancelling a request mid-dial must not race with trace hooks that fire
+// late on the dialing goroutine. Run with -race.
+func TestCancelledRequest(t *testing.T) {
+	client, target, done, _, _ := setup(t, 50*time.Millisecond)
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, target, nil)
+	if _, err := client.Do(req); err == nil {
+		t.Fatal("want a deadline error")
+	}
+	if len(*done) != 1 || (*done)[0].Err == nil {
+		t.Fatalf("timings = %+v", *done)
+	}
+}
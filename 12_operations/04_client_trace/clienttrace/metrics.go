@@ -0,0 +1,60 @@
+package clienttrace
+
+import (
+	"strconv"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics aggregates Timings into Prometheus histograms, one series per
+// phase and host. Outbound hosts are a short, fixed list (the services a
+// program calls), so host is a safe label; a URL path would not be.
+type Metrics struct {
+	phases   *prometheus.HistogramVec
+	requests *prometheus.CounterVec
+}
+
+// NewMetrics creates the metrics and registers them with reg, for example
+// prometheus.DefaultRegisterer, or a registry a server exposes on /metrics.
+func NewMetrics(reg prometheus.Registerer) *Metrics {
+	m := &Metrics{
+		phases: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name: "http_client_phase_duration_seconds",
+			Help: "Time spent in each phase of outbound HTTP requests.",
+			// 1ms to ~4s: DNS and connects on a LAN take a millisecond or
+			// two, slow upstreams seconds.
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 13),
+		}, []string{"host", "phase"}),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "http_client_requests_total",
+			Help: "Outbound HTTP requests by host, status code and connection reuse.",
+		}, []string{"host", "code", "reused"}),
+	}
+	reg.MustRegister(m.phases, m.requests)
+	return m
+}
+
+func (m *Metrics) observe(t Timings) {
+	code := strconv.Itoa(t.Status)
+	if t.Err != nil {
+		code = "error"
+	}
+	m.requests.WithLabelValues(t.Host, code, strconv.FormatBool(t.Reused)).Inc()
+
+	// Phases that did not happen are not observed: a reused connection
+	// has no connect time, and a zero would drag the histogram down.
+	for _, p := range []struct {
+		name string
+		d    float64
+	}{
+		{"dns", t.DNS.Seconds()},
+		{"connect", t.Connect.Seconds()},
+		{"tls", t.TLS.Seconds()},
+		{"ttfb", t.TTFB.Seconds()},
+		{"total", t.Total.Seconds()},
+	} {
+		if p.d > 0 {
+			m.phases.WithLabelValues(t.Host, p.name).Observe(p.d)
+		}
+	}
+}
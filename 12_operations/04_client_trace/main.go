@@ -0,0 +1,99 @@
+// Demonstrates tracing outbound HTTP requests with net/http/httptrace.
+//
+// This example shows:
+// - DNS, connect, TLS and time-to-first-byte timings for each request
+// - The difference between a new connection and a reused one
+// - Structured logs of every request with slog
+// - The timings aggregated into Prometheus histograms on /metrics
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"log"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+
+	"golang_roadmap/12_operations/04_client_trace/clienttrace"
+)
+
+func main() {
+	// An upstream that answers after 30ms, over TLS.
+	upstream := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		time.Sleep(30 * time.Millisecond)
+		fmt.Fprintln(w, strings.Repeat("payload ", 1000))
+	}))
+	defer upstream.Close()
+
+	// Call it as "localhost" so a DNS lookup happens. The test certificate
+	// is issued for example.com, so verify against that name.
+	base := upstream.Client().Transport.(*http.Transport).Clone()
+	base.TLSClientConfig.ServerName = "example.com"
+	u, _ := url.Parse(upstream.URL)
+	target := "https://localhost:" + u.Port()
+
+	reg := prometheus.NewRegistry()
+	client := &http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &clienttrace.Transport{
+			Base:    base,
+			Logger:  slog.New(slog.NewTextHandler(os.Stdout, nil)),
+			Metrics: clienttrace.NewMetrics(reg),
+		},
+	}
+
+	fmt.Println("=== First request: new connection ===")
+	get(client, target+"/a")
+	fmt.Println("\n=== Three more: the connection is reused ===")
+	for range 3 {
+		get(client, target+"/b")
+	}
+
+	fmt.Println("\n=== A new connection per request (keep-alives off) ===")
+	noKeepAlive := base.Clone()
+	noKeepAlive.DisableKeepAlives = true
+	fresh := &http.Client{Transport: &clienttrace.Transport{
+		Base:   noKeepAlive,
+		Logger: slog.New(slog.NewTextHandler(os.Stdout, nil)), // no Metrics: log only
+	}}
+	get(fresh, target+"/c")
+
+	fmt.Println("\n=== Connection refused ===")
+	get(client, "http://127.0.0.1:1/")
+
+	// Expose the registry as a Prometheus server would scrape it.
+	metrics := httptest.NewServer(promhttp.HandlerFor(reg, promhttp.HandlerOpts{}))
+	defer metrics.Close()
+	resp, err := http.Get(metrics.URL)
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	fmt.Println("\n=== /metrics (excerpt) ===")
+	lines := bufio.NewScanner(resp.Body)
+	for lines.Scan() {
+		l := lines.Text()
+		if strings.HasPrefix(l, "http_client_requests_total") ||
+			strings.Contains(l, "_count") || strings.Contains(l, `phase="ttfb",le="0.064"`) {
+			fmt.Println(l)
+		}
+	}
+}
+
+func get(client *http.Client, url string) {
+	resp, err := client.Get(url)
+	if err != nil {
+		return // logged by the transport
+	}
+	io.Copy(io.Discard, resp.Body)
+	resp.Body.Close()
+}
@@ -0,0 +1,172 @@
+// Package debugvars exposes a process's internals on /debug/vars: custom
+// expvar counters and gauges for the HTTP requests it serves, and samples
+// of runtime/metrics (goroutines, heap, GC pauses).
+//
+// expvar variables are process-global: expvar.Publish panics if a name is
+// used twice, and expvar.Handler serves every published variable, including
+// the "cmdline" and "memstats" that the package adds itself. Serve them on a
+// separate, private listener (NewServer), not next to the public API:
+// command lines and memory layout are nobody else's business.
+package debugvars
+
+import (
+	"encoding/json"
+	"expvar"
+	"math"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/11_stats/stats"
+)
+
+// HTTPStats counts the requests of a handler. Published under a name, it
+// shows up in /debug/vars as
+//
+//	"http": {"in_flight": 1, "requests": {"200": 41, "404": 2}, "duration_ns": 123456,
+//	         "latency": {"count": 43, "p50_ms": 1.2, "p99_ms": 8.4, ...}}
+type HTTPStats struct {
+	Requests   *expvar.Map // counters, by status code
+	InFlight   *expvar.Int // gauge: requests being served now
+	DurationNS *expvar.Int // counter: total time spent serving, in nanoseconds
+	Latency    *Latency    // distribution of serving times
+}
+
+// NewHTTPStats creates the variables and publishes them as one map under
+// name. Like expvar.NewMap, it panics if name is already published, so
+// call it once, at startup.
+func NewHTTPStats(name string) *HTTPStats {
+	s, m := newHTTPStats()
+	expvar.Publish(name, m)
+	return s
+}
+
+// newHTTPStats creates the variables without publishing them.
+func newHTTPStats() (*HTTPStats, *expvar.Map) {
+	s := &HTTPStats{
+		Requests:   new(expvar.Map),
+		InFlight:   new(expvar.Int),
+		DurationNS: new(expvar.Int),
+		Latency:    NewLatency(),
+	}
+	m := new(expvar.Map)
+	m.Set("requests", s.Requests)
+	m.Set("in_flight", s.InFlight)
+	m.Set("duration_ns", s.DurationNS)
+	m.Set("latency", s.Latency)
+	return s, m
+}
+
+// Middleware updates s for every request next serves. A handler that never
+// calls WriteHeader or Write is counted as 200, as net/http answers it.
+func (s *HTTPStats) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.InFlight.Add(1)
+		start := time.Now()
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		defer func() {
+			d := time.Since(start)
+			s.InFlight.Add(-1)
+			s.DurationNS.Add(int64(d))
+			s.Latency.Observe(d)
+			s.Requests.Add(strconv.Itoa(rec.status), 1)
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// Latency is an expvar.Var holding a histogram of durations, shown as
+//
+//	{"count": 43, "mean_ms": 2.1, "stddev_ms": 1.8, "p50_ms": 1.2, "p90_ms": 4.1, "p99_ms": 8.4, "max_ms": 9.3}
+//
+// Percentiles are to 2 significant digits (within 1%), from 1ns to a
+// minute; longer durations count as a minute. Like the counters, they
+// cover the whole life of the process.
+type Latency struct {
+	mu   sync.Mutex
+	hist *stats.Histogram
+}
+
+// NewLatency returns an empty Latency.
+func NewLatency() *Latency {
+	return &Latency{hist: stats.MustHistogram(int64(time.Minute), 2)}
+}
+
+// Observe records one duration.
+func (l *Latency) Observe(d time.Duration) {
+	l.mu.Lock()
+	l.hist.Record(int64(d))
+	l.mu.Unlock()
+}
+
+// Percentile returns the duration that p percent of the observations were
+// at or below.
+func (l *Latency) Percentile(p float64) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return time.Duration(l.hist.Percentile(p))
+}
+
+// String implements expvar.Var.
+func (l *Latency) String() string {
+	l.mu.Lock()
+	h := l.hist
+	ms := func(ns float64) float64 { return math.Round(ns/1e3) / 1e3 }
+	out := struct {
+		Count  int64   `json:"count"`
+		Mean   float64 `json:"mean_ms"`
+		StdDev float64 `json:"stddev_ms"`
+		P50    float64 `json:"p50_ms"`
+		P90    float64 `json:"p90_ms"`
+		P99    float64 `json:"p99_ms"`
+		Max    float64 `json:"max_ms"`
+	}{
+		h.Count(), ms(h.Mean()), ms(h.StdDev()),
+		ms(float64(h.Percentile(50))), ms(float64(h.Percentile(90))), ms(float64(h.Percentile(99))), ms(float64(h.Max())),
+	}
+	l.mu.Unlock()
+	b, _ := json.Marshal(out)
+	return string(b)
+}
+
+// statusRecorder remembers the status code written through it.
+type statusRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+}
+
+func (r *statusRecorder) WriteHeader(code int) {
+	if !r.wroteHeader {
+		r.status, r.wroteHeader = code, true
+	}
+	r.ResponseWriter.WriteHeader(code)
+}
+
+func (r *statusRecorder) Write(p []byte) (int, error) {
+	r.wroteHeader = true
+	return r.ResponseWriter.Write(p)
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer's Flush
+// and deadlines.
+func (r *statusRecorder) Unwrap() http.ResponseWriter { return r.ResponseWriter }
+
+// Handler serves the published variables as JSON on /debug/vars.
+func Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.Handle("GET /debug/vars", expvar.Handler())
+	return mux
+}
+
+// NewServer returns a server for Handler on addr. Bind it to a loopback or
+// cluster-internal address, such as "localhost:6060".
+func NewServer(addr string) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+		WriteTimeout:      10 * time.Second,
+	}
+}
@@ -0,0 +1,206 @@
+package debugvars
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"expvar"
+	"log/slog"
+	"math"
+	"net/http"
+	"net/http/httptest"
+	"runtime"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMiddleware(t *testing.T) {
+	stats, _ := newHTTPStats() // unpublished: names are global, and tests may run twice
+	inHandler := make(chan int64, 1)
+	mux := http.NewServeMux()
+	mux.HandleFunc("/ok", func(w http.ResponseWriter, r *http.Request) {
+		inHandler <- stats.InFlight.Value()
+		w.Write([]byte("ok")) // no WriteHeader: 200
+	})
+	mux.HandleFunc("/teapot", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusTeapot)
+		w.WriteHeader(http.StatusOK) // superfluous, ignored
+	})
+	h := stats.Middleware(mux)
+
+	for _, path := range []string{"/ok", "/teapot", "/teapot", "/missing"} {
+		h.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest("GET", path, nil))
+	}
+
+	if got := <-inHandler; got != 1 {
+		t.Errorf("in_flight inside the handler = %d; want 1", got)
+	}
+	if got := stats.InFlight.Value(); got != 0 {
+		t.Errorf("in_flight after = %d; want 0", got)
+	}
+	for code, want := range map[string]string{"200": "1", "418": "2", "404": "1"} {
+		if got := stats.Requests.Get(code); got == nil || got.String() != want {
+			t.Errorf("requests[%s] = %v; want %s", code, got, want)
+		}
+	}
+	if stats.DurationNS.Value() <= 0 {
+		t.Errorf("duration_ns = %d; want > 0", stats.DurationNS.Value())
+	}
+	var lat struct {
+		Count int64   `json:"count"`
+		P50   float64 `json:"p50_ms"`
+		Max   float64 `json:"max_ms"`
+	}
+	if err := json.Unmarshal([]byte(stats.Latency.String()), &lat); err != nil {
+		t.Fatalf("latency = %s: %v", stats.Latency, err)
+	}
+	if lat.Count != 4 || lat.P50 > lat.Max {
+		t.Errorf("latency = %s; want 4 requests with p50 <= max", stats.Latency)
+	}
+}
+
+func TestLatency(t *testing.T) {
+	l := NewLatency()
+	for i := 1; i <= 100; i++ {
+		l.Observe(time.Duration(i) * time.Millisecond)
+	}
+	l.Observe(time.Hour) // counted as a minute
+	// 2 significant digits: within 1% of the exact value.
+	for p, want := range map[float64]time.Duration{50: 51 * time.Millisecond, 99: 100 * time.Millisecond, 100: time.Minute} {
+		got := l.Percentile(p)
+		if d := math.Abs(float64(got - want)); d > float64(want)/100 {
+			t.Errorf("p%v = %v; want %v within 1%%", p, got, want)
+		}
+	}
+}
+
+// Published variables cannot be removed, so each is published once per
+// test binary, however many times the tests run (go test -count).
+var (
+	publishedStats   = sync.OnceValue(func() *HTTPStats { return NewHTTPStats("test_http") })
+	publishedSampler = sync.OnceValue(func() *Sampler {
+		s := new(Sampler)
+		expvar.Publish("test_runtime", s.Var())
+		return s
+	})
+)
+
+func TestHandler(t *testing.T) {
+	stats := publishedStats()
+	stats.Requests.Add("200", 3)
+	want := stats.Requests.Get("200").(*expvar.Int).Value()
+
+	rec := httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/debug/vars", nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("status = %d; want 200", rec.Code)
+	}
+	var vars map[string]json.RawMessage
+	if err := json.Unmarshal(rec.Body.Bytes(), &vars); err != nil {
+		t.Fatalf("body is not JSON: %v", err)
+	}
+	var got struct {
+		Requests map[string]int `json:"requests"`
+		InFlight int            `json:"in_flight"`
+	}
+	if err := json.Unmarshal(vars["test_http"], &got); err != nil {
+		t.Fatalf("test_http = %s: %v", vars["test_http"], err)
+	}
+	if int64(got.Requests["200"]) != want || got.InFlight != 0 {
+		t.Errorf("test_http = %+v; want %d requests with 200, none in flight", got, want)
+	}
+	if _, ok := vars["memstats"]; !ok {
+		t.Error("memstats missing; expvar publishes it by default")
+	}
+
+	rec = httptest.NewRecorder()
+	Handler().ServeHTTP(rec, httptest.NewRequest("GET", "/", nil))
+	if rec.Code != http.StatusNotFound {
+		t.Errorf("GET / = %d; want 404, only /debug/vars is served", rec.Code)
+	}
+}
+
+func TestSampler(t *testing.T) {
+	s := publishedSampler()
+	first := s.Sample()
+	if first.Goroutines == 0 || first.HeapBytes == 0 {
+		t.Errorf("first sample = %+v; want goroutines and heap bytes", first)
+	}
+
+	runtime.GC()
+	runtime.GC()
+	second := s.Sample()
+	if second.GCCycles < first.GCCycles+2 {
+		t.Errorf("gc_cycles %d -> %d after two runtime.GC; want +2", first.GCCycles, second.GCCycles)
+	}
+	// Each cycle stops the world twice, so two cycles pause at least four times.
+	if second.GCPauses < 4 || second.GCPauseMax <= 0 || second.GCPauseP50 > second.GCPauseMax {
+		t.Errorf("second sample pauses = %d p50=%v max=%v; want >= 4 with p50 <= max",
+			second.GCPauses, second.GCPauseP50, second.GCPauseMax)
+	}
+	if s.Latest() != second {
+		t.Errorf("Latest = %+v; want the last sample", s.Latest())
+	}
+
+	// The published var reports the latest snapshot.
+	var exported Snapshot
+	if err := json.Unmarshal([]byte(expvar.Get("test_runtime").String()), &exported); err != nil {
+		t.Fatal(err)
+	}
+	if exported.GCCycles != second.GCCycles {
+		t.Errorf("exported gc_cycles = %d; want %d", exported.GCCycles, second.GCCycles)
+	}
+}
+
+func TestSamplerRun(t *testing.T) {
+	var buf bytes.Buffer
+	s := &Sampler{
+		Interval: 10 * time.Millisecond,
+		Logger:   slog.New(slog.NewTextHandler(&buf, nil)),
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan struct{})
+	go func() {
+		s.Run(ctx)
+		close(done)
+	}()
+	time.Sleep(50 * time.Millisecond)
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Run did not return after cancel")
+	}
+	// buf is only written by Run, which has returned.
+	out := buf.String()
+	if n := strings.Count(out, "msg=runtime"); n < 2 {
+		t.Errorf("logged %d samples in 50ms at a 10ms interval; want at least 2:\n%s", n, out)
+	}
+	if !strings.Contains(out, "gc_pause_p99=") {
+		t.Errorf("log lacks gc_pause_p99:\n%s", out)
+	}
+}
+
+func TestQuantile(t *testing.T) {
+	// Buckets: [0,1) [1,2) [2,4) [4,+Inf) seconds.
+	buckets := []float64{0, 1, 2, 4, math.Inf(1)}
+	for _, tc := range []struct {
+		counts []uint64
+		q      float64
+		want   time.Duration
+	}{
+		{[]uint64{0, 0, 0, 0}, 0.5, 0},
+		{[]uint64{10, 0, 0, 0}, 0.5, time.Second},
+		{[]uint64{5, 5, 0, 0}, 0.5, time.Second},
+		{[]uint64{5, 5, 0, 0}, 0.51, 2 * time.Second},
+		{[]uint64{98, 1, 1, 0}, 0.99, 2 * time.Second},
+		{[]uint64{98, 1, 1, 0}, 1, 4 * time.Second},
+		{[]uint64{0, 0, 0, 1}, 1, 4 * time.Second}, // +Inf: the lower bound
+	} {
+		if got := quantile(tc.counts, buckets, tc.q); got != tc.want {
+			t.Errorf("quantile(%v, %v) = %v; want %v", tc.counts, tc.q, got, tc.want)
+		}
+	}
+}
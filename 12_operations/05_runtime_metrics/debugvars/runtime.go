@@ -0,0 +1,174 @@
+package debugvars
+
+import (
+	"context"
+	"expvar"
+	"log/slog"
+	"math"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// The runtime/metrics names sampled. Unlike runtime.ReadMemStats, reading
+// them does not stop the world, so sampling every few seconds is cheap.
+const (
+	metricGoroutines = "/sched/goroutines:goroutines"
+	metricHeapBytes  = "/memory/classes/heap/objects:bytes"
+	metricGCCycles   = "/gc/cycles/total:gc-cycles"
+	metricGCPauses   = "/sched/pauses/total/gc:seconds"
+)
+
+// Snapshot is one sample of the runtime. GC pauses are those of the
+// interval since the previous sample, not since the process started: a
+// lifetime p99 hides a regression that began five minutes ago.
+type Snapshot struct {
+	Time       time.Time `json:"time"`
+	Goroutines uint64    `json:"goroutines"`
+	HeapBytes  uint64    `json:"heap_bytes"` // live and not yet swept heap objects
+	GCCycles   uint64    `json:"gc_cycles"`  // since the process started
+
+	GCPauses   uint64        `json:"gc_pauses"` // in the interval
+	GCPauseP50 time.Duration `json:"gc_pause_p50_ns"`
+	GCPauseP99 time.Duration `json:"gc_pause_p99_ns"`
+	GCPauseMax time.Duration `json:"gc_pause_max_ns"`
+}
+
+// Sampler reads runtime metrics, keeps the latest Snapshot, and logs it.
+type Sampler struct {
+	Interval time.Duration // default 10s
+	Logger   *slog.Logger  // default slog.Default()
+
+	mu         sync.Mutex
+	samples    []metrics.Sample
+	prevPauses []uint64 // bucket counts at the previous sample
+	latest     Snapshot
+}
+
+// Sample reads the metrics now, stores the result as the latest snapshot
+// and returns it.
+func (s *Sampler) Sample() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.samples == nil {
+		s.samples = []metrics.Sample{
+			{Name: metricGoroutines},
+			{Name: metricHeapBytes},
+			{Name: metricGCCycles},
+			{Name: metricGCPauses},
+		}
+	}
+	metrics.Read(s.samples)
+
+	snap := Snapshot{
+		Time:       time.Now(),
+		Goroutines: uint64Value(s.samples[0]),
+		HeapBytes:  uint64Value(s.samples[1]),
+		GCCycles:   uint64Value(s.samples[2]),
+	}
+	if s.samples[3].Value.Kind() == metrics.KindFloat64Histogram {
+		h := s.samples[3].Value.Float64Histogram()
+		// The histogram is cumulative and its buckets are fixed, so the
+		// pauses of the interval are the difference of the counts.
+		delta := make([]uint64, len(h.Counts))
+		for i, c := range h.Counts {
+			delta[i] = c
+			if i < len(s.prevPauses) {
+				delta[i] -= s.prevPauses[i]
+			}
+			snap.GCPauses += delta[i]
+		}
+		s.prevPauses = append(s.prevPauses[:0], h.Counts...)
+		snap.GCPauseP50 = quantile(delta, h.Buckets, 0.5)
+		snap.GCPauseP99 = quantile(delta, h.Buckets, 0.99)
+		snap.GCPauseMax = quantile(delta, h.Buckets, 1)
+	}
+	s.latest = snap
+	return snap
+}
+
+// Latest returns the most recent snapshot, zero before the first Sample.
+func (s *Sampler) Latest() Snapshot {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.latest
+}
+
+// Var exports the latest snapshot to expvar:
+//
+//	expvar.Publish("runtime", sampler.Var())
+//
+// Reading /debug/vars does not sample; it shows what Run saw last.
+func (s *Sampler) Var() expvar.Var {
+	return expvar.Func(func() any { return s.Latest() })
+}
+
+// Run samples every Interval and logs each snapshot until ctx is done.
+func (s *Sampler) Run(ctx context.Context) {
+	interval := s.Interval
+	if interval <= 0 {
+		interval = 10 * time.Second
+	}
+	logger := s.Logger
+	if logger == nil {
+		logger = slog.Default()
+	}
+	s.Sample() // the baseline for the first interval's pauses
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+		snap := s.Sample()
+		logger.LogAttrs(ctx, slog.LevelInfo, "runtime",
+			slog.Uint64("goroutines", snap.Goroutines),
+			slog.Uint64("heap_bytes", snap.HeapBytes),
+			slog.Uint64("gc_cycles", snap.GCCycles),
+			slog.Uint64("gc_pauses", snap.GCPauses),
+			slog.Duration("gc_pause_p50", snap.GCPauseP50),
+			slog.Duration("gc_pause_p99", snap.GCPauseP99),
+			slog.Duration("gc_pause_max", snap.GCPauseMax),
+		)
+	}
+}
+
+func uint64Value(s metrics.Sample) uint64 {
+	if s.Value.Kind() != metrics.KindUint64 {
+		return 0 // not supported by this Go version
+	}
+	return s.Value.Uint64()
+}
+
+// quantile returns the upper bound of the bucket holding the q-th quantile
+// of counts, where bucket i spans buckets[i] to buckets[i+1]. The answer is
+// only as precise as the buckets; runtime/metrics widens them as the
+// values grow, so the error is a fraction of the value.
+func quantile(counts []uint64, buckets []float64, q float64) time.Duration {
+	var total uint64
+	for _, c := range counts {
+		total += c
+	}
+	if total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q * float64(total)))
+	if rank == 0 {
+		rank = 1
+	}
+	var seen uint64
+	for i, c := range counts {
+		seen += c
+		if seen < rank {
+			continue
+		}
+		upper := buckets[i+1]
+		if math.IsInf(upper, 1) {
+			upper = buckets[i] // the last bucket has no upper bound
+		}
+		return time.Duration(upper * float64(time.Second))
+	}
+	return 0
+}
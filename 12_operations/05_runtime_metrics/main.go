@@ -0,0 +1,102 @@
+// Demonstrates expvar and runtime/metrics.
+//
+// This example shows:
+// - Custom expvar counters and gauges updated by HTTP middleware
+// - Sampling goroutines, heap bytes and GC pauses with runtime/metrics
+// - Logging the samples periodically with slog and exporting them to expvar
+// - Serving /debug/vars on a separate debug listener
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"expvar"
+	"fmt"
+	"log"
+	"log/slog"
+	"maps"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"golang_roadmap/12_operations/05_runtime_metrics/debugvars"
+)
+
+func main() {
+	// The public API: a handler that allocates enough to make the GC run.
+	stats := debugvars.NewHTTPStats("http")
+	api := http.NewServeMux()
+	api.HandleFunc("GET /work", func(w http.ResponseWriter, r *http.Request) {
+		// 32 blocks of 128KiB: too big for the stack, so they are garbage.
+		blocks := make([][]byte, 32)
+		for i := range blocks {
+			blocks[i] = make([]byte, 128<<10)
+		}
+		fmt.Fprintln(w, "done", len(blocks))
+	})
+	app := httptest.NewServer(stats.Middleware(api))
+	defer app.Close()
+
+	// The private debug listener. In production, NewServer("localhost:6060").
+	debug := httptest.NewServer(debugvars.Handler())
+	defer debug.Close()
+
+	sampler := &debugvars.Sampler{
+		Interval: 200 * time.Millisecond,
+		Logger:   slog.New(slog.NewTextHandler(os.Stdout, nil)),
+	}
+	expvar.Publish("runtime", sampler.Var())
+	ctx, cancel := context.WithCancel(context.Background())
+	var wg sync.WaitGroup
+	wg.Add(1)
+	go func() {
+		defer wg.Done()
+		sampler.Run(ctx)
+	}()
+
+	fmt.Println("=== Load: 200 requests from 8 clients, and a few 404s ===")
+	var clients sync.WaitGroup
+	for range 8 {
+		clients.Add(1)
+		go func() {
+			defer clients.Done()
+			for range 25 {
+				get(app.URL + "/work")
+			}
+		}()
+	}
+	clients.Wait()
+	for range 3 {
+		get(app.URL + "/missing")
+	}
+	time.Sleep(250 * time.Millisecond) // one more sample, covering the end of the load
+	cancel()
+	wg.Wait()
+
+	fmt.Println("\n=== GET /debug/vars ===")
+	resp, err := http.Get(debug.URL + "/debug/vars")
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var vars map[string]json.RawMessage
+	if err := json.NewDecoder(resp.Body).Decode(&vars); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("published:", strings.Join(slices.Sorted(maps.Keys(vars)), ", "))
+	fmt.Println("http:     ", string(vars["http"]))
+	fmt.Println("runtime:  ", string(vars["runtime"]))
+}
+
+func get(url string) {
+	resp, err := http.Get(url)
+	if err != nil {
+		log.Print(err)
+		return
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,138 @@
+// Package buildinfo reports how the running binary was built, from what
+// the go command records in it: the module and its version, the Go
+// version, the VCS revision and whether the tree had uncommitted changes,
+// the build settings, and every dependency with its version.
+//
+// None of it needs -ldflags. The VCS fields are there when the binary was
+// built with "go build" inside a checkout; "go run" and "go test" leave
+// them out, and "go install module@version" records the version instead.
+package buildinfo
+
+import (
+	"fmt"
+	"io"
+	"maps"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+type Info struct {
+	Path      string // the main package, as in example.com/app/cmd/app
+	Module    string // its module
+	Version   string // "(devel)" for a build from a checkout
+	GoVersion string
+
+	Revision string    // VCS commit, empty if unknown
+	Time     time.Time // commit time, zero if unknown
+	Modified bool      // built with uncommitted changes
+
+	Settings map[string]string // -ldflags, GOOS, GOARCH, CGO_ENABLED, ...
+	Deps     []Dep
+}
+
+type Dep struct {
+	Path    string
+	Version string
+	Sum     string
+	Replace string // "path version" or a directory, if replaced
+}
+
+// Read returns the running binary's build information. ok is false for a
+// binary built without module support.
+func Read() (info Info, ok bool) {
+	bi, ok := debug.ReadBuildInfo()
+	if !ok {
+		return Info{}, false
+	}
+	return FromDebug(bi), true
+}
+
+// FromDebug converts what debug.ReadBuildInfo or debug.ParseBuildInfo
+// returns. ParseBuildInfo reads the output of "go version -m", so the same
+// report can be made for another binary.
+func FromDebug(bi *debug.BuildInfo) Info {
+	info := Info{
+		Path:      bi.Path,
+		Module:    bi.Main.Path,
+		Version:   bi.Main.Version,
+		GoVersion: bi.GoVersion,
+		Settings:  map[string]string{},
+	}
+	for _, s := range bi.Settings {
+		switch s.Key {
+		case "vcs.revision":
+			info.Revision = s.Value
+		case "vcs.time":
+			info.Time, _ = time.Parse(time.RFC3339, s.Value)
+		case "vcs.modified":
+			info.Modified = s.Value == "true"
+		default:
+			info.Settings[s.Key] = s.Value
+		}
+	}
+	for _, m := range bi.Deps {
+		d := Dep{Path: m.Path, Version: m.Version, Sum: m.Sum}
+		if r := m.Replace; r != nil {
+			d.Replace = strings.TrimSpace(r.Path + " " + r.Version)
+		}
+		info.Deps = append(info.Deps, d)
+	}
+	return info
+}
+
+// Short is one line for a --version flag, as in
+//
+//	example.com/app v1.2.0 (3f9c2e1a0b7d, 2026-05-01, modified) go1.24.11 linux/amd64
+func (i Info) Short() string {
+	var vcs []string
+	if i.Revision != "" {
+		vcs = append(vcs, i.Revision[:min(12, len(i.Revision))])
+	}
+	if !i.Time.IsZero() {
+		vcs = append(vcs, i.Time.Format(time.DateOnly))
+	}
+	if i.Modified {
+		vcs = append(vcs, "modified")
+	}
+	s := i.Module + " " + i.Version
+	if len(vcs) > 0 {
+		s += " (" + strings.Join(vcs, ", ") + ")"
+	}
+	if i.GoVersion != "" {
+		s += " " + i.GoVersion
+	}
+	if goos, goarch := i.Settings["GOOS"], i.Settings["GOARCH"]; goos != "" {
+		s += " " + goos + "/" + goarch
+	}
+	return s
+}
+
+// Write prints everything: the Short line, the build settings and the
+// dependencies, aligned for reading.
+func (i Info) Write(w io.Writer) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, i.Short())
+	fmt.Fprintf(tw, "\npath\t%s\n", i.Path)
+	if i.Revision != "" {
+		fmt.Fprintf(tw, "revision\t%s\n", i.Revision)
+	}
+	for _, k := range slices.Sorted(maps.Keys(i.Settings)) {
+		if v := i.Settings[k]; v != "" {
+			fmt.Fprintf(tw, "%s\t%s\n", k, v)
+		}
+	}
+	if len(i.Deps) > 0 {
+		fmt.Fprintf(tw, "\ndependencies:\n")
+		for _, d := range i.Deps {
+			if d.Replace != "" {
+				fmt.Fprintf(tw, "  %s\t%s\t=> %s\n", d.Path, d.Version, d.Replace)
+			} else {
+				fmt.Fprintf(tw, "  %s\t%s\t\n", d.Path, d.Version)
+			}
+		}
+	}
+	return tw.Flush()
+}
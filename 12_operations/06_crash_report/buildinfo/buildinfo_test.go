@@ -0,0 +1,94 @@
+package buildinfo
+
+import (
+	"runtime/debug"
+	"strings"
+	"testing"
+)
+
+// goVersionM is what "go version -m" prints for a binary, less the first
+// line: the Go version comes from elsewhere in the binary, and
+// ParseBuildInfo does not read it. The tab after "(devel)" is the empty
+// checksum column.
+const goVersionM = `path	example.com/app/cmd/app
+mod	example.com/app	v1.2.0	h1:abc=
+dep	github.com/google/uuid	v1.6.0	h1:uuid=
+dep	example.com/lib	v0.3.0
+=>	../lib	(devel)	
+build	-ldflags="-s -w"
+build	CGO_CFLAGS=
+build	GOARCH=arm64
+build	GOOS=darwin
+build	vcs=git
+build	vcs.revision=3f9c2e1a0b7d44e1c2a5f0d9e8b7c6a5f4e3d2c1
+build	vcs.time=2026-05-01T10:00:00Z
+build	vcs.modified=true
+`
+
+func parse(t *testing.T) Info {
+	t.Helper()
+	bi, err := debug.ParseBuildInfo(goVersionM)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bi.GoVersion = "go1.24.11"
+	return FromDebug(bi)
+}
+
+func TestFromDebug(t *testing.T) {
+	info := parse(t)
+	if info.Module != "example.com/app" || info.Version != "v1.2.0" || info.GoVersion != "go1.24.11" {
+		t.Errorf("main module: %+v", info)
+	}
+	if !info.Modified || info.Revision[:7] != "3f9c2e1" || info.Time.Year() != 2026 {
+		t.Errorf("VCS: revision %q, time %v, modified %v", info.Revision, info.Time, info.Modified)
+	}
+	if _, ok := info.Settings["vcs.revision"]; ok || info.Settings["GOOS"] != "darwin" {
+		t.Errorf("settings = %v; want the VCS keys taken out", info.Settings)
+	}
+	if len(info.Deps) != 2 || info.Deps[1].Replace != "../lib (devel)" || info.Deps[0].Replace != "" {
+		t.Errorf("deps = %+v", info.Deps)
+	}
+}
+
+func TestShort(t *testing.T) {
+	want := "example.com/app v1.2.0 (3f9c2e1a0b7d, 2026-05-01, modified) go1.24.11 darwin/arm64"
+	if got := parse(t).Short(); got != want {
+		t.Errorf("Short() =\n%s\nwant\n%s", got, want)
+	}
+	// A build outside a checkout, as by go run: no VCS part.
+	info := Info{Module: "example.com/app", Version: "(devel)", GoVersion: "go1.24.11", Settings: map[string]string{}}
+	if got := info.Short(); got != "example.com/app (devel) go1.24.11" {
+		t.Errorf("Short() = %q", got)
+	}
+}
+
+func TestWrite(t *testing.T) {
+	var b strings.Builder
+	if err := parse(t).Write(&b); err != nil {
+		t.Fatal(err)
+	}
+	out := b.String()
+	for _, want := range []string{
+		"revision  3f9c2e1a0b7d44e1c2a5f0d9e8b7c6a5f4e3d2c1\n",
+		`-ldflags  -s -w`,
+		"github.com/google/uuid  v1.6.0",
+		"example.com/lib         v0.3.0  => ../lib (devel)",
+	} {
+		if !strings.Contains(out, want) {
+			t.Errorf("output lacks %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "CGO_CFLAGS") {
+		t.Errorf("empty setting printed:\n%s", out)
+	}
+}
+
+func TestRead(t *testing.T) {
+	// A test binary has build information too, with the package under
+	// test's module as the main one.
+	info, ok := Read()
+	if !ok || info.GoVersion == "" || !strings.HasPrefix(info.Path, "golang_roadmap/12_operations/06_crash_report") {
+		t.Errorf("Read() = %+v, %v", info, ok)
+	}
+}
@@ -0,0 +1,178 @@
+// Package crash writes a report file when a program panics: the panic
+// value, the stack of the goroutine that panicked and of all the others,
+// how the binary was built, and the state of the runtime. A user can
+// attach the file to a bug report; stderr of a crashed CLI or service is
+// often gone by the time anyone asks.
+//
+// Go has no global panic hook. A panic is recovered only by a deferred
+// call in the goroutine that panicked, so Recover is deferred at the top
+// of main and of each goroutine, which Go starts for you. What recover
+// cannot catch — a panic in a goroutine someone else started, or a fatal
+// error such as a concurrent map write — CatchFatal sends to a file with
+// debug.SetCrashOutput.
+package crash
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"runtime/debug"
+	"time"
+
+	"golang_roadmap/12_operations/06_crash_report/buildinfo"
+)
+
+// ExitCode is what the program exits with after a report, the same as
+// for a panic nobody recovered.
+const ExitCode = 2
+
+// Reporter writes crash reports for one program. Make it with New.
+type Reporter struct {
+	App    string    // names the report files
+	Dir    string    // where they go
+	Stderr io.Writer // where the report's path is announced
+
+	start time.Time
+	exit  func(int) // os.Exit; replaced in tests
+}
+
+// New returns a Reporter writing to dir, which is created on the first
+// report.
+func New(app, dir string) *Reporter {
+	return &Reporter{App: app, Dir: dir, Stderr: os.Stderr, start: time.Now(), exit: os.Exit}
+}
+
+// Recover must be deferred directly, not called from another deferred
+// function; recover only stops a panic from there.
+//
+//	defer reporter.Recover()
+//
+// If the goroutine is panicking, Recover writes a report, prints its path
+// and exits the program with ExitCode. Other deferred calls of the
+// goroutine have run by then; those of other goroutines never will.
+func (r *Reporter) Recover() {
+	v := recover()
+	if v == nil {
+		return
+	}
+	// debug.Stack here still has the frames down to the panic: they are
+	// unwound only when the deferred calls return.
+	path, err := r.Write(v, debug.Stack())
+	if err != nil {
+		fmt.Fprintf(r.Stderr, "panic: %v\n\n%s\ncould not write a crash report: %v\n", v, debug.Stack(), err)
+	} else {
+		fmt.Fprintf(r.Stderr, "%s crashed: %v\nA crash report was written to %s\nPlease attach it to a bug report.\n", r.App, v, path)
+	}
+	r.exit(ExitCode)
+}
+
+// Go runs fn in a new goroutine that reports a panic like Recover.
+func (r *Reporter) Go(fn func()) {
+	go func() {
+		defer r.Recover()
+		fn()
+	}()
+}
+
+// Write writes a report for the panic value v with the stack of the
+// goroutine that panicked, and returns its path. The file is readable by
+// its owner only: panic values and stacks can hold data that is not
+// meant to be shared.
+func (r *Reporter) Write(v any, stack []byte) (string, error) {
+	if err := os.MkdirAll(r.Dir, 0o700); err != nil {
+		return "", err
+	}
+	now := time.Now().UTC()
+	f, err := os.CreateTemp(r.Dir, fmt.Sprintf("%s-crash-%s-*.txt", r.App, now.Format("20060102T150405Z")))
+	if err != nil {
+		return "", err
+	}
+	path := f.Name()
+	werr := r.write(f, v, stack, now)
+	if err := f.Close(); werr == nil {
+		werr = err
+	}
+	return path, werr
+}
+
+func (r *Reporter) write(w io.Writer, v any, stack []byte, now time.Time) error {
+	fmt.Fprintf(w, "%s crash report\n\n", r.App)
+	fmt.Fprintf(w, "panic: %v", v)
+	if err, ok := v.(error); ok {
+		fmt.Fprintf(w, " [%T]", err)
+	}
+	fmt.Fprintf(w, "\ntime: %s\n", now.Format(time.RFC3339))
+	fmt.Fprintf(w, "uptime: %s\n\n", now.Sub(r.start).Round(time.Millisecond))
+	fmt.Fprintf(w, "%s\n", stack)
+
+	fmt.Fprintf(w, "== build\n\n")
+	if info, ok := buildinfo.Read(); ok {
+		info.Write(w)
+	} else {
+		fmt.Fprintln(w, "no build information")
+	}
+
+	fmt.Fprintf(w, "\n== runtime\n\n")
+	writeRuntime(w)
+
+	fmt.Fprintf(w, "\n== all goroutines\n\n")
+	_, err := w.Write(allStacks())
+	return err
+}
+
+// writeRuntime prints the process and memory state. ReadMemStats stops
+// the world, which is fine in a process that is about to exit.
+func writeRuntime(w io.Writer) {
+	var m runtime.MemStats
+	runtime.ReadMemStats(&m)
+	host, _ := os.Hostname()
+	exe, _ := os.Executable()
+	fmt.Fprintf(w, "pid: %d\nhost: %s\nexecutable: %s\n", os.Getpid(), host, exe)
+	fmt.Fprintf(w, "os/arch: %s/%s, %d CPUs, GOMAXPROCS %d\n", runtime.GOOS, runtime.GOARCH, runtime.NumCPU(), runtime.GOMAXPROCS(0))
+	fmt.Fprintf(w, "goroutines: %d\n", runtime.NumGoroutine())
+	fmt.Fprintf(w, "heap: %d bytes in use, %d objects, %d bytes from the OS in all\n", m.HeapAlloc, m.HeapObjects, m.Sys)
+	fmt.Fprintf(w, "gc: %d cycles", m.NumGC)
+	if m.NumGC > 0 {
+		fmt.Fprintf(w, ", last %s ago, %s paused in all", time.Since(time.Unix(0, int64(m.LastGC))).Round(time.Millisecond),
+			time.Duration(m.PauseTotalNs))
+	}
+	fmt.Fprintln(w)
+}
+
+// allStacks returns the stacks of every goroutine, doubling the buffer
+// until they fit.
+func allStacks() []byte {
+	buf := make([]byte, 64<<10)
+	for {
+		n := runtime.Stack(buf, true)
+		if n < len(buf) {
+			return buf[:n]
+		}
+		buf = make([]byte, 2*len(buf))
+	}
+}
+
+// CatchFatal sends the runtime's own crash output — for an unrecovered
+// panic in any goroutine, or a fatal error that recover cannot stop — to
+// App-fatal.log in Dir as well as stderr. The runtime writes only its
+// usual message and stacks, appended to whatever a previous crash left.
+//
+// It returns the path of that file if an earlier run left something in
+// it, so the program can point the user at it.
+func (r *Reporter) CatchFatal() (previous string, err error) {
+	if err := os.MkdirAll(r.Dir, 0o700); err != nil {
+		return "", err
+	}
+	path := filepath.Join(r.Dir, r.App+"-fatal.log")
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o600)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close() // SetCrashOutput keeps a duplicate of the descriptor
+	if fi, err := f.Stat(); err == nil && fi.Size() > 0 {
+		previous = path
+	}
+	return previous, debug.SetCrashOutput(f, debug.CrashOptions{})
+}
@@ -0,0 +1,140 @@
+package crash
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+)
+
+// testReporter returns a Reporter whose exit is recorded instead of
+// ending the test binary.
+func testReporter(t *testing.T) (*Reporter, *bytes.Buffer, *int) {
+	t.Helper()
+	r := New("demo", filepath.Join(t.TempDir(), "crashes"))
+	var stderr bytes.Buffer
+	code := -1
+	r.Stderr, r.exit = &stderr, func(c int) { code = c }
+	return r, &stderr, &code
+}
+
+func reports(t *testing.T, dir string) []string {
+	t.Helper()
+	paths, err := filepath.Glob(filepath.Join(dir, "demo-crash-*.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return paths
+}
+
+func crashingFunction() {
+	var m map[string]int
+	m["x"] = 1
+}
+
+func TestRecover(t *testing.T) {
+	r, stderr, code := testReporter(t)
+	func() {
+		defer r.Recover()
+		crashingFunction()
+	}()
+
+	if *code != ExitCode {
+		t.Errorf("exit code %d; want %d", *code, ExitCode)
+	}
+	paths := reports(t, r.Dir)
+	if len(paths) != 1 {
+		t.Fatalf("%d reports; want 1", len(paths))
+	}
+	if !strings.Contains(stderr.String(), paths[0]) {
+		t.Errorf("stderr does not name the report:\n%s", stderr)
+	}
+	data, _ := os.ReadFile(paths[0])
+	report := string(data)
+	for _, want := range []string{
+		"panic: assignment to entry in nil map [runtime.plainError]",
+		"crash.crashingFunction(", // the stack reaches the panic
+		"== build\n\ngolang_roadmap/12_operations/06_crash_report",
+		"== runtime\n\npid: ",
+		"goroutines: ",
+		"== all goroutines",
+	} {
+		if !strings.Contains(report, want) {
+			t.Errorf("report lacks %q:\n%s", want, report)
+		}
+	}
+	if fi, err := os.Stat(paths[0]); err != nil || fi.Mode().Perm() != 0o600 {
+		t.Errorf("report mode %v, %v; want 0600", fi.Mode(), err)
+	}
+}
+
+func TestRecover_NoPanic(t *testing.T) {
+	r, _, code := testReporter(t)
+	func() {
+		defer r.Recover()
+	}()
+	if *code != -1 || len(reports(t, r.Dir)) != 0 {
+		t.Errorf("exit %d with %d reports after a normal return", *code, len(reports(t, r.Dir)))
+	}
+}
+
+func TestGo(t *testing.T) {
+	r, _, _ := testReporter(t)
+	var wg sync.WaitGroup
+	wg.Add(1)
+	r.exit = func(int) { wg.Done() }
+	r.Go(func() { panic(errors.New("worker failed")) })
+	wg.Wait()
+
+	paths := reports(t, r.Dir)
+	if len(paths) != 1 {
+		t.Fatalf("%d reports; want 1", len(paths))
+	}
+	data, _ := os.ReadFile(paths[0])
+	if !strings.Contains(string(data), "panic: worker failed [*errors.errorString]") {
+		t.Errorf("report:\n%s", data)
+	}
+}
+
+// TestCatchFatal runs itself in a child process that hits a fatal error,
+// which would end this one.
+func TestCatchFatal(t *testing.T) {
+	if dir := os.Getenv("CRASH_TEST_DIR"); dir != "" {
+		r := New("demo", dir)
+		if _, err := r.CatchFatal(); err != nil {
+			t.Fatal(err)
+		}
+		done := make(chan bool)
+		go func() { panic("nobody recovers this") }()
+		<-done
+	}
+
+	dir := t.TempDir()
+	for run := 1; run <= 2; run++ {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestCatchFatal$")
+		cmd.Env = append(os.Environ(), "CRASH_TEST_DIR="+dir)
+		out, err := cmd.CombinedOutput()
+		var exitErr *exec.ExitError
+		if !errors.As(err, &exitErr) || exitErr.ExitCode() != 2 {
+			t.Fatalf("child: %v\n%s", err, out)
+		}
+	}
+	data, err := os.ReadFile(filepath.Join(dir, "demo-fatal.log"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Both crashes, appended.
+	if n := strings.Count(string(data), "nobody recovers this"); n != 2 {
+		t.Errorf("fatal log has %d crashes; want 2:\n%s", n, data)
+	}
+
+	r := New("demo", dir)
+	previous, err := r.CatchFatal()
+	if err != nil || previous != filepath.Join(dir, "demo-fatal.log") {
+		t.Errorf("CatchFatal() = %q, %v; want the earlier crash reported", previous, err)
+	}
+}
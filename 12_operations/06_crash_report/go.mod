@@ -0,0 +1,3 @@
+module golang_roadmap/12_operations/06_crash_report
+
+go 1.24.11
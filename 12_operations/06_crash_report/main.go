@@ -0,0 +1,115 @@
+// Demonstrates build information and crash reports.
+//
+// This example shows:
+// - Reading the VCS revision, dirty flag, settings and dependencies with debug.ReadBuildInfo
+// - Printing them under a -version flag, without -ldflags
+// - Recovering a panic in main or in a goroutine and writing a crash report file
+// - Stacks of all goroutines, build info and runtime stats in the report
+// - Catching what recover cannot, such as a concurrent map write, with debug.SetCrashOutput
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"golang_roadmap/12_operations/06_crash_report/buildinfo"
+	"golang_roadmap/12_operations/06_crash_report/crash"
+)
+
+func main() {
+	version := flag.Bool("version", false, "print the version and exit")
+	verbose := flag.Bool("v", false, "with -version: also the build settings and dependencies")
+	crashDir := flag.String("crash-dir", defaultCrashDir(), "where crash reports go")
+	panicIn := flag.String("panic", "", "crash on purpose: main, goroutine, unrecovered or fatal")
+	flag.Parse()
+
+	if *version {
+		info, ok := buildinfo.Read()
+		switch {
+		case !ok:
+			fmt.Println("crashdemo: no build information")
+		case *verbose:
+			info.Write(os.Stdout)
+		default:
+			fmt.Println(info.Short())
+		}
+		return
+	}
+
+	reporter := crash.New("crashdemo", *crashDir)
+	defer reporter.Recover()
+	if previous, err := reporter.CatchFatal(); err != nil {
+		fmt.Fprintln(os.Stderr, "warning: fatal errors will only go to stderr:", err)
+	} else if previous != "" {
+		fmt.Fprintf(os.Stderr, "note: an earlier run crashed; see %s\n", previous)
+	}
+
+	// Deferred calls of the goroutine that panics still run before the
+	// report is written, as with any panic.
+	defer fmt.Println("cleanup: deferred call in main ran")
+
+	records := []string{"alice,42", "bob,17", "carol,23"}
+	switch *panicIn {
+	case "":
+	case "main":
+		records = append(records, "dave") // no age: total indexes past the end
+	case "goroutine":
+		var wg sync.WaitGroup
+		wg.Add(1)
+		reporter.Go(func() {
+			defer wg.Done()
+			fmt.Println("average age:", total(records)/countOlderThan(records, 100))
+		})
+		wg.Wait()
+	case "unrecovered":
+		// Started without reporter.Go, so Recover in main cannot see it;
+		// the runtime's output also goes to the fatal log.
+		go func() { panic("unexpected state in a library goroutine") }()
+		time.Sleep(time.Second)
+	case "fatal":
+		// A fatal error, not a panic: no deferred call runs and recover
+		// returns nil. A concurrent map write is the common one, but the
+		// runtime only notices it when the writes collide.
+		var mu sync.Mutex
+		mu.Unlock()
+	default:
+		fmt.Fprintf(os.Stderr, "-panic %q: want main, goroutine, unrecovered or fatal\n", *panicIn)
+		os.Exit(2)
+	}
+	fmt.Println("total age:", total(records))
+}
+
+func defaultCrashDir() string {
+	dir, err := os.UserCacheDir()
+	if err != nil {
+		dir = os.TempDir()
+	}
+	return filepath.Join(dir, "crashdemo", "crashes")
+}
+
+// total adds up the ages in "name,age" records. It trusts its input,
+// which is the bug the -panic main case trips over.
+func total(records []string) int {
+	sum := 0
+	for _, r := range records {
+		age, _ := strconv.Atoi(strings.Split(r, ",")[1])
+		sum += age
+	}
+	return sum
+}
+
+func countOlderThan(records []string, age int) int {
+	n := 0
+	for _, r := range records {
+		if a, _ := strconv.Atoi(strings.Split(r, ",")[1]); a > age {
+			n++
+		}
+	}
+	return n
+}
@@ -0,0 +1,5 @@
+module golang_roadmap/12_operations/07_sys
+
+go 1.24.11
+
+require golang.org/x/sys v0.38.0
@@ -0,0 +1,103 @@
+// Demonstrates operating system facilities below the os package, using
+// golang.org/x/sys/unix.
+//
+// This example shows:
+// - A single-instance lock with flock(2), released by the kernel on exit
+// - Reading and raising resource limits with getrlimit/setrlimit
+// - CPU time, peak memory and context switches from getrusage(2)
+// - The process's own /proc/self/stat, status and fd entries on Linux
+// - Build tags that keep the package compiling on Windows
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+
+	"golang_roadmap/12_operations/07_sys/sys"
+)
+
+func main() {
+	lockPath := flag.String("lock", filepath.Join(os.TempDir(), "sysdemo.lock"), "lock file that keeps a second instance out")
+	hold := flag.Duration("hold", 0, "keep the lock this long, to try a second instance from another terminal")
+	flag.Parse()
+
+	// Only one instance at a time. The lock file stays; the lock goes
+	// away with the process, however it ends.
+	lock, err := sys.TryLock(*lockPath)
+	switch {
+	case errors.Is(err, sys.ErrLocked):
+		pid, _ := os.ReadFile(*lockPath)
+		log.Fatalf("another instance (pid %s) is running", strings.TrimSpace(string(pid)))
+	case errors.Is(err, errors.ErrUnsupported):
+		log.Printf("no file locks here: %v", err)
+	case err != nil:
+		log.Fatal(err)
+	default:
+		defer lock.Unlock()
+		f := lock.File()
+		f.Truncate(0)
+		fmt.Fprintf(f, "%d\n", os.Getpid())
+		fmt.Printf("holding %s as pid %d\n", *lockPath, os.Getpid())
+		if _, err := sys.TryLock(*lockPath); errors.Is(err, sys.ErrLocked) {
+			fmt.Println("a second TryLock fails:", err)
+		}
+	}
+
+	fmt.Println("\nresource limits (soft / hard):")
+	for _, r := range []sys.Resource{sys.OpenFiles, sys.CoreSize, sys.CPUTime, sys.Processes} {
+		l, err := sys.GetLimit(r)
+		if err != nil {
+			fmt.Printf("  %-10s  %v\n", r, err)
+			continue
+		}
+		fmt.Printf("  %-10s  %s / %s\n", r, limit(l.Cur), limit(l.Max))
+	}
+	if l, err := sys.RaiseOpenFilesLimit(); err == nil {
+		fmt.Printf("  open files raised to %s\n", limit(l.Cur))
+	}
+
+	// Touch 32 MiB, so that CPU time and RSS below are not all zero.
+	buf := make([]byte, 32<<20)
+	for i := range buf {
+		buf[i] = byte(i)
+	}
+
+	if u, err := sys.SelfUsage(); err == nil {
+		fmt.Println("\ngetrusage:")
+		fmt.Printf("  cpu         user %v, system %v\n", u.UserTime.Round(time.Millisecond), u.SystemTime.Round(time.Millisecond))
+		fmt.Printf("  peak rss    %d MiB\n", u.MaxRSS>>20)
+		fmt.Printf("  faults      %d minor, %d major\n", u.MinorFaults, u.MajorFaults)
+		fmt.Printf("  switches    %d voluntary, %d involuntary\n", u.VoluntarySwitches, u.InvoluntarySwitches)
+	} else {
+		fmt.Println("\ngetrusage:", err)
+	}
+
+	if p, err := sys.SelfProc(); err == nil {
+		fmt.Println("\n/proc/self:")
+		fmt.Printf("  %s pid %d, parent %d, state %c, %d threads\n", p.Command, p.PID, p.PPID, p.State, p.Threads)
+		fmt.Printf("  memory      virtual %d MiB, rss %d MiB, peak %d MiB\n", p.VirtBytes>>20, p.RSSBytes>>20, p.PeakRSS>>20)
+		fmt.Printf("  open fds    %d\n", p.OpenFDs)
+	} else {
+		fmt.Println("\n/proc/self:", err)
+	}
+	runtime.KeepAlive(buf)
+
+	if *hold > 0 {
+		fmt.Printf("\nholding the lock for %v\n", *hold)
+		time.Sleep(*hold)
+	}
+}
+
+func limit(n uint64) string {
+	if n == sys.Unlimited {
+		return "unlimited"
+	}
+	return fmt.Sprint(n)
+}
@@ -0,0 +1,41 @@
+package sys
+
+import (
+	"errors"
+	"os"
+)
+
+// ErrLocked is returned by TryLock when another holder has the lock.
+var ErrLocked = errors.New("file is locked by another process")
+
+// FileLock is an exclusive advisory lock on a file, held until Unlock or
+// until the process exits. The kernel releases it however the process
+// ends, so a crashed holder never leaves a stale lock behind, unlike a
+// lock file that is only checked for existence.
+//
+// Advisory means other programs can still read and write the file; only
+// those that also take the lock are kept out.
+type FileLock struct {
+	f *os.File
+}
+
+// Lock opens (creating if needed) the file at path and takes an exclusive
+// lock on it, waiting for the current holder to release it.
+func Lock(path string) (*FileLock, error) { return lock(path, true) }
+
+// TryLock is like Lock but returns ErrLocked instead of waiting.
+func TryLock(path string) (*FileLock, error) { return lock(path, false) }
+
+// File returns the locked file, for example to write a PID into it.
+func (l *FileLock) File() *os.File { return l.f }
+
+// Unlock releases the lock and closes the file. The file is not removed:
+// removing it would let a waiter lock the old file while a newcomer
+// creates and locks a new one.
+func (l *FileLock) Unlock() error {
+	err := unlock(l.f)
+	if cerr := l.f.Close(); err == nil {
+		err = cerr
+	}
+	return err
+}
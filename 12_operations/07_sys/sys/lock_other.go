@@ -0,0 +1,19 @@
+//go:build !(linux || darwin)
+
+package sys
+
+import (
+	"errors"
+	"fmt"
+	"os"
+)
+
+// lock is not implemented here. Windows has LockFileEx in
+// golang.org/x/sys/windows, with mandatory rather than advisory locks.
+func lock(path string, wait bool) (*FileLock, error) {
+	return nil, fmt.Errorf("lock %s: %w", path, errors.ErrUnsupported)
+}
+
+func unlock(f *os.File) error {
+	return fmt.Errorf("unlock %s: %w", f.Name(), errors.ErrUnsupported)
+}
@@ -0,0 +1,79 @@
+package sys
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func lockOrSkip(t *testing.T, path string) *FileLock {
+	t.Helper()
+	l, err := TryLock(path)
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	return l
+}
+
+func TestTryLock(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.lock")
+	l := lockOrSkip(t, path)
+
+	// flock locks belong to the open file, so a second open conflicts
+	// even in the same process.
+	if _, err := TryLock(path); !errors.Is(err, ErrLocked) {
+		t.Fatalf("second TryLock = %v; want ErrLocked", err)
+	}
+	if err := l.Unlock(); err != nil {
+		t.Fatal(err)
+	}
+	l2, err := TryLock(path)
+	if err != nil {
+		t.Fatalf("TryLock after Unlock: %v", err)
+	}
+	l2.Unlock()
+}
+
+func TestLock_WaitsForHolder(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.lock")
+	l := lockOrSkip(t, path)
+
+	got := make(chan error, 1)
+	go func() {
+		l2, err := Lock(path)
+		if err == nil {
+			err = l2.Unlock()
+		}
+		got <- err
+	}()
+	select {
+	case err := <-got:
+		t.Fatalf("Lock returned while the lock was held: %v", err)
+	case <-time.After(100 * time.Millisecond):
+	}
+	l.Unlock()
+	select {
+	case err := <-got:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Lock did not return after Unlock")
+	}
+}
+
+// Closing the file without Unlock, as a crash would, releases the lock.
+func TestLock_ReleasedOnClose(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.lock")
+	l := lockOrSkip(t, path)
+	l.File().Close()
+	l2, err := TryLock(path)
+	if err != nil {
+		t.Fatalf("TryLock after Close: %v", err)
+	}
+	l2.Unlock()
+}
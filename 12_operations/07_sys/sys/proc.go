@@ -0,0 +1,93 @@
+package sys
+
+import (
+	"bufio"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ProcInfo is what Linux reports about a process in /proc/<pid>.
+type ProcInfo struct {
+	PID        int
+	PPID       int
+	Command    string // the executable name, at most 15 bytes
+	State      byte   // R running, S sleeping, D disk wait, Z zombie, ...
+	Threads    int
+	UserTime   time.Duration
+	SystemTime time.Duration
+	VirtBytes  uint64 // virtual memory size
+	RSSBytes   uint64 // resident set size: pages in RAM
+	PeakRSS    uint64 // VmHWM: the highest RSS so far
+	OpenFDs    int    // entries in /proc/<pid>/fd
+}
+
+// SelfProc returns the calling process's /proc/self entries.
+func SelfProc() (ProcInfo, error) { return selfProc() }
+
+// clockTicks is USER_HZ, the unit of times in /proc/<pid>/stat. It is 100
+// on every mainstream Linux architecture; reading it properly takes
+// sysconf(_SC_CLK_TCK), which is libc, not a system call.
+const clockTicks = 100
+
+// parseStat parses /proc/<pid>/stat. The command is in parentheses and
+// may itself contain spaces and parentheses, so the fields are counted
+// from the last ')'. pageSize converts the RSS from pages to bytes.
+func parseStat(data string, pageSize int) (ProcInfo, error) {
+	lp, rp := strings.IndexByte(data, '('), strings.LastIndexByte(data, ')')
+	if lp < 0 || rp < lp {
+		return ProcInfo{}, fmt.Errorf("/proc stat: no command in %.40q", data)
+	}
+	var p ProcInfo
+	var err error
+	if p.PID, err = strconv.Atoi(strings.TrimSpace(data[:lp])); err != nil {
+		return ProcInfo{}, fmt.Errorf("/proc stat: pid: %w", err)
+	}
+	p.Command = data[lp+1 : rp]
+
+	// fields[0] is field 3 in proc(5): state.
+	fields := strings.Fields(data[rp+1:])
+	if len(fields) < 22 {
+		return ProcInfo{}, fmt.Errorf("/proc stat: %d fields after the command; want at least 22", len(fields))
+	}
+	num := func(i int) uint64 {
+		n, e := strconv.ParseUint(fields[i], 10, 64)
+		if e != nil && err == nil {
+			err = fmt.Errorf("/proc stat: field %d: %w", i+3, e)
+		}
+		return n
+	}
+	ticks := func(i int) time.Duration { return time.Duration(num(i)) * time.Second / clockTicks }
+
+	p.State = fields[0][0]
+	p.PPID = int(num(1))
+	p.UserTime = ticks(11)
+	p.SystemTime = ticks(12)
+	p.Threads = int(num(17))
+	p.VirtBytes = num(20)
+	p.RSSBytes = num(21) * uint64(pageSize)
+	if err != nil {
+		return ProcInfo{}, err
+	}
+	return p, nil
+}
+
+// parseStatusKiB returns the value of a "Key:   1234 kB" line in
+// /proc/<pid>/status, in bytes.
+func parseStatusKiB(data, key string) (uint64, error) {
+	sc := bufio.NewScanner(strings.NewReader(data))
+	for sc.Scan() {
+		k, v, ok := strings.Cut(sc.Text(), ":")
+		if !ok || k != key {
+			continue
+		}
+		v = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(v), "kB"))
+		n, err := strconv.ParseUint(v, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("/proc status: %s: %w", key, err)
+		}
+		return n * 1024, nil
+	}
+	return 0, fmt.Errorf("/proc status: no %s", key)
+}
@@ -0,0 +1,33 @@
+package sys
+
+import (
+	"fmt"
+	"os"
+)
+
+func selfProc() (ProcInfo, error) {
+	stat, err := os.ReadFile("/proc/self/stat")
+	if err != nil {
+		return ProcInfo{}, err
+	}
+	p, err := parseStat(string(stat), os.Getpagesize())
+	if err != nil {
+		return ProcInfo{}, err
+	}
+
+	status, err := os.ReadFile("/proc/self/status")
+	if err != nil {
+		return ProcInfo{}, err
+	}
+	if p.PeakRSS, err = parseStatusKiB(string(status), "VmHWM"); err != nil {
+		return ProcInfo{}, err
+	}
+
+	// Reading the directory opens one more descriptor, which is counted.
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return ProcInfo{}, fmt.Errorf("open files: %w", err)
+	}
+	p.OpenFDs = len(fds) - 1
+	return p, nil
+}
@@ -0,0 +1,14 @@
+//go:build !linux
+
+package sys
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Other systems have no /proc, or one in another format: macOS has
+// proc_pidinfo in libproc, Windows the process status API.
+func selfProc() (ProcInfo, error) {
+	return ProcInfo{}, fmt.Errorf("/proc/self: %w", errors.ErrUnsupported)
+}
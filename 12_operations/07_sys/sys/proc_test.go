@@ -0,0 +1,79 @@
+package sys
+
+import (
+	"errors"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+// A stat line of a process whose command contains ") (".
+const sampleStat = "4242 (my) (prog) S 1 4242 4242 0 -1 4194560 3511 0 2 0 250 75 0 0 20 0 9 0 71509 1253376000 5120 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 3 0 0 0 0 0\n"
+
+func TestParseStat(t *testing.T) {
+	p, err := parseStat(sampleStat, 4096)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := ProcInfo{
+		PID:        4242,
+		PPID:       1,
+		Command:    "my) (prog",
+		State:      'S',
+		Threads:    9,
+		UserTime:   2500 * time.Millisecond,
+		SystemTime: 750 * time.Millisecond,
+		VirtBytes:  1253376000,
+		RSSBytes:   5120 * 4096,
+	}
+	if p != want {
+		t.Fatalf("parseStat =\n%+v\nwant\n%+v", p, want)
+	}
+
+	for _, bad := range []string{"", "4242 no parens S 1", "4242 (x) S 1 2 3", "x (x)" + strings.Repeat(" 1", 30)} {
+		if _, err := parseStat(bad, 4096); err == nil {
+			t.Errorf("parseStat(%.30q) succeeded", bad)
+		}
+	}
+}
+
+func TestParseStatusKiB(t *testing.T) {
+	status := "Name:\tgo\nVmPeak:\t  20000 kB\nVmHWM:\t    1764 kB\nThreads:\t1\n"
+	if n, err := parseStatusKiB(status, "VmHWM"); err != nil || n != 1764*1024 {
+		t.Errorf("VmHWM = %d, %v; want %d", n, err, 1764*1024)
+	}
+	if _, err := parseStatusKiB(status, "VmSwap"); err == nil {
+		t.Error("missing key: no error")
+	}
+}
+
+func TestSelfProc(t *testing.T) {
+	p, err := SelfProc()
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.PID != os.Getpid() || p.PPID != os.Getppid() {
+		t.Errorf("PID, PPID = %d, %d; want %d, %d", p.PID, p.PPID, os.Getpid(), os.Getppid())
+	}
+	if p.Threads < 1 || p.RSSBytes == 0 || p.PeakRSS < p.RSSBytes {
+		t.Errorf("implausible: %+v", p)
+	}
+
+	// Each open file shows up in /proc/self/fd.
+	f, err := os.Open(os.Args[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	p2, err := SelfProc()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p2.OpenFDs != p.OpenFDs+1 {
+		t.Errorf("OpenFDs = %d after opening a file; want %d", p2.OpenFDs, p.OpenFDs+1)
+	}
+}
@@ -0,0 +1,63 @@
+package sys
+
+import "math"
+
+// Resource is a limit the kernel enforces on a process.
+type Resource int
+
+const (
+	OpenFiles Resource = iota // RLIMIT_NOFILE: file descriptors, sockets included
+	CoreSize                  // RLIMIT_CORE: bytes of a core dump; 0 disables them
+	CPUTime                   // RLIMIT_CPU: seconds of CPU before SIGXCPU
+	Processes                 // RLIMIT_NPROC: processes and threads of this user
+)
+
+func (r Resource) String() string {
+	switch r {
+	case OpenFiles:
+		return "open files"
+	case CoreSize:
+		return "core size"
+	case CPUTime:
+		return "cpu time"
+	case Processes:
+		return "processes"
+	}
+	return "unknown resource"
+}
+
+// Unlimited is the value of a Limit field with no limit (RLIM_INFINITY).
+const Unlimited = math.MaxUint64
+
+// Limit is a soft and a hard limit. The kernel enforces Cur. A process may
+// move Cur anywhere up to Max, and lower Max, but only a privileged
+// process can raise Max.
+type Limit struct {
+	Cur, Max uint64
+}
+
+// GetLimit returns the current limits on r.
+func GetLimit(r Resource) (Limit, error) { return getLimit(r) }
+
+// SetLimit sets the limits on r. Children started afterwards inherit them.
+func SetLimit(r Resource, l Limit) error { return setLimit(r, l) }
+
+// RaiseOpenFilesLimit raises the soft limit on open files to the hard
+// limit, as servers with many connections do at startup, and returns the
+// new limits. Since Go 1.19 the runtime does this itself on startup, and
+// restores the old soft limit in children it execs, so calling it matters
+// only for code that lowered the limit or for older binaries.
+func RaiseOpenFilesLimit() (Limit, error) {
+	l, err := GetLimit(OpenFiles)
+	if err != nil {
+		return Limit{}, err
+	}
+	if l.Cur == l.Max {
+		return l, nil
+	}
+	l.Cur = maxOpenFiles(l.Max)
+	if err := SetLimit(OpenFiles, l); err != nil {
+		return Limit{}, err
+	}
+	return GetLimit(OpenFiles)
+}
@@ -0,0 +1,20 @@
+//go:build !(linux || darwin)
+
+package sys
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Windows has no rlimits; job objects (golang.org/x/sys/windows) limit a
+// group of processes instead.
+func getLimit(r Resource) (Limit, error) {
+	return Limit{}, fmt.Errorf("getrlimit %s: %w", r, errors.ErrUnsupported)
+}
+
+func setLimit(r Resource, l Limit) error {
+	return fmt.Errorf("setrlimit %s: %w", r, errors.ErrUnsupported)
+}
+
+func maxOpenFiles(max uint64) uint64 { return max }
@@ -0,0 +1,58 @@
+package sys
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestLimits(t *testing.T) {
+	l, err := GetLimit(OpenFiles)
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if l.Cur == 0 || l.Cur > l.Max {
+		t.Fatalf("open files limit = %+v", l)
+	}
+	t.Cleanup(func() { SetLimit(OpenFiles, l) })
+
+	// Lowering the soft limit is always allowed...
+	lower := Limit{Cur: l.Cur / 2, Max: l.Max}
+	if err := SetLimit(OpenFiles, lower); err != nil {
+		t.Fatal(err)
+	}
+	if got, _ := GetLimit(OpenFiles); got != lower {
+		t.Fatalf("after SetLimit: %+v; want %+v", got, lower)
+	}
+
+	// ...and so is raising it back, up to the hard limit.
+	raised, err := RaiseOpenFilesLimit()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if raised.Cur != maxOpenFiles(l.Max) {
+		t.Fatalf("RaiseOpenFilesLimit = %+v; want Cur = %d", raised, maxOpenFiles(l.Max))
+	}
+
+	// The soft limit may not exceed the hard one.
+	if l.Max != Unlimited {
+		if err := SetLimit(OpenFiles, Limit{Cur: l.Max + 1, Max: l.Max}); err == nil {
+			t.Fatal("SetLimit above the hard limit succeeded")
+		}
+	}
+}
+
+func TestSelfUsage(t *testing.T) {
+	u, err := SelfUsage()
+	if errors.Is(err, errors.ErrUnsupported) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	if u.MaxRSS < 1<<20 {
+		t.Errorf("MaxRSS = %d bytes; a Go test binary uses more than 1 MiB", u.MaxRSS)
+	}
+}
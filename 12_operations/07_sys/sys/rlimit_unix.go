@@ -0,0 +1,58 @@
+//go:build linux || darwin
+
+package sys
+
+import (
+	"fmt"
+	"runtime"
+
+	"golang.org/x/sys/unix"
+)
+
+func resource(r Resource) (int, error) {
+	switch r {
+	case OpenFiles:
+		return unix.RLIMIT_NOFILE, nil
+	case CoreSize:
+		return unix.RLIMIT_CORE, nil
+	case CPUTime:
+		return unix.RLIMIT_CPU, nil
+	case Processes:
+		return unix.RLIMIT_NPROC, nil
+	}
+	return 0, fmt.Errorf("rlimit: unknown resource %d", r)
+}
+
+func getLimit(r Resource) (Limit, error) {
+	res, err := resource(r)
+	if err != nil {
+		return Limit{}, err
+	}
+	var rl unix.Rlimit
+	if err := unix.Getrlimit(res, &rl); err != nil {
+		return Limit{}, fmt.Errorf("getrlimit %s: %w", r, err)
+	}
+	return Limit{Cur: uint64(rl.Cur), Max: uint64(rl.Max)}, nil
+}
+
+func setLimit(r Resource, l Limit) error {
+	res, err := resource(r)
+	if err != nil {
+		return err
+	}
+	rl := unix.Rlimit{Cur: l.Cur, Max: l.Max}
+	if err := unix.Setrlimit(res, &rl); err != nil {
+		return fmt.Errorf("setrlimit %s to %d/%d: %w", r, l.Cur, l.Max, err)
+	}
+	return nil
+}
+
+// maxOpenFiles caps a soft limit on open files. macOS reports an
+// unlimited hard limit but refuses a soft limit above OPEN_MAX.
+func maxOpenFiles(max uint64) uint64 {
+	const openMax = 10240
+	if runtime.GOOS == "darwin" && max > openMax {
+		return openMax
+	}
+	return max
+}
@@ -0,0 +1,10 @@
+// Package sys wraps a few operating system facilities that the os package
+// does not expose, using golang.org/x/sys/unix: advisory file locks
+// (flock), resource limits (getrlimit/setrlimit), resource usage
+// (getrusage), and on Linux the process's own /proc entries.
+//
+// The implementations for Linux and macOS are in *_unix.go, and the /proc
+// reader in proc_linux.go. Elsewhere, Windows included, the same functions
+// exist and return an error wrapping errors.ErrUnsupported, so the package
+// and its callers build everywhere.
+package sys
@@ -0,0 +1,16 @@
+package sys
+
+import "time"
+
+// Usage is the resources a process has consumed so far, from getrusage(2).
+type Usage struct {
+	UserTime                 time.Duration // CPU time running the process's own code
+	SystemTime               time.Duration // CPU time in the kernel on its behalf
+	MaxRSS                   uint64        // peak resident memory, in bytes
+	MinorFaults, MajorFaults int64         // page faults without and with disk I/O
+	VoluntarySwitches        int64         // context switches waiting for I/O, locks or sleep
+	InvoluntarySwitches      int64         // context switches forced by the scheduler
+}
+
+// SelfUsage returns the usage of the calling process, all threads included.
+func SelfUsage() (Usage, error) { return selfUsage() }
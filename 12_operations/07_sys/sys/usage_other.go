@@ -0,0 +1,13 @@
+//go:build !(linux || darwin)
+
+package sys
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Windows has GetProcessTimes and GetProcessMemoryInfo instead.
+func selfUsage() (Usage, error) {
+	return Usage{}, fmt.Errorf("getrusage: %w", errors.ErrUnsupported)
+}
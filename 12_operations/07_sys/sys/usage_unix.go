@@ -0,0 +1,31 @@
+//go:build linux || darwin
+
+package sys
+
+import (
+	"fmt"
+	"runtime"
+	"time"
+
+	"golang.org/x/sys/unix"
+)
+
+func selfUsage() (Usage, error) {
+	var ru unix.Rusage
+	if err := unix.Getrusage(unix.RUSAGE_SELF, &ru); err != nil {
+		return Usage{}, fmt.Errorf("getrusage: %w", err)
+	}
+	maxRSS := uint64(ru.Maxrss)
+	if runtime.GOOS == "linux" {
+		maxRSS *= 1024 // Linux reports KiB; macOS reports bytes
+	}
+	return Usage{
+		UserTime:            time.Duration(ru.Utime.Nano()),
+		SystemTime:          time.Duration(ru.Stime.Nano()),
+		MaxRSS:              maxRSS,
+		MinorFaults:         int64(ru.Minflt),
+		MajorFaults:         int64(ru.Majflt),
+		VoluntarySwitches:   int64(ru.Nvcsw),
+		InvoluntarySwitches: int64(ru.Nivcsw),
+	}, nil
+}
@@ -0,0 +1,276 @@
+// Package breaker stops calling a dependency that keeps failing.
+//
+// A retry helps with a failure that goes away in a second. Against a
+// service that is down, every client retrying keeps it down, and every
+// caller waits for a timeout it could have been spared. A breaker counts
+// consecutive failures; past a threshold it opens and fails calls at
+// once, without sending them. After a while it lets a probe through: a
+// success closes it again, a failure opens it for another period.
+package breaker
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+// State is where a Breaker is in its cycle.
+type State int
+
+const (
+	Closed   State = iota // calls go through; failures are counted
+	Open                  // calls fail at once with ErrOpen
+	HalfOpen              // a few probe calls go through to test the dependency
+)
+
+func (s State) String() string {
+	switch s {
+	case Closed:
+		return "closed"
+	case Open:
+		return "open"
+	case HalfOpen:
+		return "half-open"
+	}
+	return fmt.Sprintf("State(%d)", int(s))
+}
+
+// ErrOpen is returned, wrapped, for calls the breaker did not let through.
+// The error has a RetryAfter method, so retry.Do waits until the breaker
+// lets a probe through instead of spending its attempts on rejections.
+var ErrOpen = errors.New("breaker: open")
+
+// Settings say when a Breaker opens and closes. The zero value is usable:
+// open after 5 consecutive failures, probe with 1 call after 10s.
+type Settings struct {
+	Threshold int           // consecutive failures that open the breaker (default 5)
+	OpenFor   time.Duration // how long it stays open before a probe (default 10s)
+	Probes    int           // probe calls let through while half-open; all must succeed to close it (default 1)
+
+	// IsFailure reports whether a non-nil err counts against the
+	// dependency. Other errors count neither way. The default counts
+	// every error except context.Canceled: a caller that gave up says
+	// nothing about the dependency; one that timed out does.
+	IsFailure func(err error) bool
+
+	// OnStateChange, if set, is called on every change, e.g. to log. It
+	// runs with the breaker locked and must not call it.
+	OnStateChange func(from, to State)
+
+	// Clock times the open period (default clock.Real). Tests pass a fake
+	// one.
+	Clock clock.Clock
+}
+
+func (s Settings) withDefaults() Settings {
+	if s.Threshold <= 0 {
+		s.Threshold = 5
+	}
+	if s.OpenFor <= 0 {
+		s.OpenFor = 10 * time.Second
+	}
+	if s.Probes <= 0 {
+		s.Probes = 1
+	}
+	if s.IsFailure == nil {
+		s.IsFailure = func(err error) bool { return !errors.Is(err, context.Canceled) }
+	}
+	if s.Clock == nil {
+		s.Clock = clock.Real()
+	}
+	return s
+}
+
+// Breaker guards one dependency. It is safe for concurrent use.
+type Breaker struct {
+	s Settings
+
+	mu        sync.Mutex
+	state     State
+	gen       uint64 // bumped on every change, so late results of an old state are ignored
+	failures  int    // consecutive, while closed
+	successes int    // probes that succeeded, while half-open
+	inFlight  int    // probes running, while half-open
+	openUntil time.Time
+}
+
+// New returns a closed Breaker.
+func New(s Settings) *Breaker {
+	return &Breaker{s: s.withDefaults()}
+}
+
+// State returns the current state. An open breaker whose period is over
+// reports HalfOpen.
+func (b *Breaker) State() State {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expireLocked()
+	return b.state
+}
+
+// Do calls fn if the breaker allows it and records the outcome. Rejected
+// calls return an error that matches ErrOpen; otherwise Do returns what
+// fn returned. A panic in fn counts as a failure and goes on up.
+func (b *Breaker) Do(ctx context.Context, fn func(ctx context.Context) error) error {
+	gen, err := b.allow()
+	if err != nil {
+		return err
+	}
+	defer b.recordPanic(gen)
+	err = fn(ctx)
+	b.record(gen, b.outcome(err))
+	return err
+}
+
+// recordPanic, deferred after a call is admitted, counts a panic in the
+// call as a failure and panics again. Without it, a probe that panicked
+// would hold its slot, and a half-open breaker would reject every call
+// from then on.
+func (b *Breaker) recordPanic(gen uint64) {
+	if p := recover(); p != nil {
+		b.record(gen, failure)
+		panic(p)
+	}
+}
+
+// allow admits a call, or returns the error that rejects it.
+func (b *Breaker) allow() (uint64, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.expireLocked()
+	switch b.state {
+	case Open:
+		return 0, &openError{wait: b.openUntil.Sub(b.s.Clock.Now())}
+	case HalfOpen:
+		if b.inFlight+b.successes >= b.s.Probes {
+			// Enough probes are out; their results decide.
+			return 0, &openError{}
+		}
+		b.inFlight++
+	}
+	return b.gen, nil
+}
+
+type outcome int
+
+const (
+	success outcome = iota
+	failure
+	ignored // neither, such as a call the caller cancelled
+)
+
+func (b *Breaker) outcome(err error) outcome {
+	switch {
+	case err == nil:
+		return success
+	case b.s.IsFailure(err):
+		return failure
+	}
+	return ignored
+}
+
+// record counts the outcome of a call admitted in generation gen.
+func (b *Breaker) record(gen uint64, o outcome) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if gen != b.gen {
+		return // admitted before the last change; too late to count
+	}
+	switch b.state {
+	case Closed:
+		switch o {
+		case success:
+			b.failures = 0
+		case failure:
+			if b.failures++; b.failures >= b.s.Threshold {
+				b.setLocked(Open)
+			}
+		}
+	case HalfOpen:
+		b.inFlight--
+		switch {
+		case o == ignored:
+			// The probe slot is free for another call.
+		case o == failure:
+			b.setLocked(Open)
+		case b.successes+1 >= b.s.Probes:
+			b.setLocked(Closed)
+		default:
+			b.successes++
+		}
+	}
+}
+
+// expireLocked moves an open breaker whose period is over to HalfOpen.
+func (b *Breaker) expireLocked() {
+	if b.state == Open && !b.s.Clock.Now().Before(b.openUntil) {
+		b.setLocked(HalfOpen)
+	}
+}
+
+func (b *Breaker) setLocked(to State) {
+	from := b.state
+	b.state = to
+	b.gen++
+	b.failures, b.successes, b.inFlight = 0, 0, 0
+	if to == Open {
+		b.openUntil = b.s.Clock.Now().Add(b.s.OpenFor)
+	}
+	if b.s.OnStateChange != nil {
+		b.s.OnStateChange(from, to)
+	}
+}
+
+type openError struct{ wait time.Duration }
+
+func (e *openError) Error() string {
+	if e.wait > 0 {
+		return fmt.Sprintf("%v; next probe in %v", ErrOpen, e.wait)
+	}
+	return fmt.Sprintf("%v; waiting for probes", ErrOpen)
+}
+
+func (e *openError) Unwrap() error { return ErrOpen }
+
+// RetryAfter is how long until the breaker lets a probe through.
+func (e *openError) RetryAfter() time.Duration { return e.wait }
+
+// Transport returns an http.RoundTripper that sends requests through base
+// (nil: http.DefaultTransport) while b allows it. Errors and 5xx
+// responses count as failures; 4xx, including 429, do not: the service
+// answered, and a rate limit is for this client to respect, not a sign
+// that the service is down.
+func Transport(b *Breaker, base http.RoundTripper) http.RoundTripper {
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	return &transport{b: b, base: base}
+}
+
+type transport struct {
+	b    *Breaker
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	gen, err := t.b.allow()
+	if err != nil {
+		if req.Body != nil {
+			req.Body.Close() // a RoundTripper must close the body, even on error
+		}
+		return nil, err
+	}
+	defer t.b.recordPanic(gen)
+	resp, err := t.base.RoundTrip(req)
+	o := t.b.outcome(err)
+	if err == nil && resp.StatusCode >= 500 {
+		o = failure
+	}
+	t.b.record(gen, o)
+	return resp, err
+}
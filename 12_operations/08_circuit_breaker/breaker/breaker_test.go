@@ -0,0 +1,213 @@
+package breaker
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+)
+
+var (
+	errDown = errors.New("connection refused")
+	epoch   = time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+)
+
+// newBreaker returns a breaker on a fake clock that records its changes.
+func newBreaker(s Settings) (*Breaker, *clock.Fake, *[]string) {
+	fake := clock.NewFake(epoch)
+	var changes []string
+	s.Clock = fake
+	s.OnStateChange = func(from, to State) { changes = append(changes, from.String()+"->"+to.String()) }
+	return New(s), fake, &changes
+}
+
+func call(b *Breaker, err error) error {
+	return b.Do(context.Background(), func(context.Context) error { return err })
+}
+
+func TestBreaker_OpensAfterThresholdConsecutiveFailures(t *testing.T) {
+	b, _, changes := newBreaker(Settings{Threshold: 3})
+	call(b, errDown)
+	call(b, errDown)
+	call(b, nil) // a success resets the count
+	call(b, errDown)
+	call(b, errDown)
+	if b.State() != Closed {
+		t.Fatalf("state after 2 consecutive failures = %v, want closed", b.State())
+	}
+	call(b, errDown)
+	if b.State() != Open || strings.Join(*changes, ",") != "closed->open" {
+		t.Fatalf("state = %v, changes %v; want open", b.State(), *changes)
+	}
+
+	called := false
+	err := b.Do(context.Background(), func(context.Context) error { called = true; return nil })
+	if called || !errors.Is(err, ErrOpen) {
+		t.Fatalf("open breaker: called=%v err=%v; want ErrOpen without a call", called, err)
+	}
+	var ra interface{ RetryAfter() time.Duration }
+	if !errors.As(err, &ra) || ra.RetryAfter() != 10*time.Second {
+		t.Errorf("RetryAfter of %v: want 10s", err)
+	}
+}
+
+func TestBreaker_HalfOpenProbe(t *testing.T) {
+	b, fake, changes := newBreaker(Settings{Threshold: 1, OpenFor: time.Minute})
+	call(b, errDown)
+	fake.Advance(59 * time.Second)
+	if err := call(b, nil); !errors.Is(err, ErrOpen) {
+		t.Fatalf("before OpenFor: %v, want ErrOpen", err)
+	}
+	fake.Advance(time.Second)
+	if b.State() != HalfOpen {
+		t.Fatalf("after OpenFor: %v, want half-open", b.State())
+	}
+
+	// A failed probe opens it for another period.
+	call(b, errDown)
+	fake.Advance(30 * time.Second)
+	if err := call(b, nil); !errors.Is(err, ErrOpen) {
+		t.Fatalf("after a failed probe: %v, want ErrOpen", err)
+	}
+	fake.Advance(30 * time.Second)
+	if err := call(b, nil); err != nil || b.State() != Closed {
+		t.Fatalf("successful probe: %v, state %v; want closed", err, b.State())
+	}
+	want := "closed->open,open->half-open,half-open->open,open->half-open,half-open->closed"
+	if got := strings.Join(*changes, ","); got != want {
+		t.Errorf("changes:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestBreaker_LimitsConcurrentProbes(t *testing.T) {
+	b, fake, _ := newBreaker(Settings{Threshold: 1, OpenFor: time.Second, Probes: 2})
+	call(b, errDown)
+	fake.Advance(time.Second)
+
+	release := make(chan struct{})
+	var wg sync.WaitGroup
+	for range 2 {
+		wg.Add(1)
+		started := make(chan struct{})
+		go func() {
+			defer wg.Done()
+			b.Do(context.Background(), func(context.Context) error { close(started); <-release; return nil })
+		}()
+		<-started
+	}
+	if err := call(b, nil); !errors.Is(err, ErrOpen) {
+		t.Errorf("third call with 2 probes out: %v, want ErrOpen", err)
+	}
+	close(release)
+	wg.Wait()
+	if b.State() != Closed {
+		t.Errorf("after 2 successful probes: %v, want closed", b.State())
+	}
+}
+
+func TestBreaker_CancelledCallsDoNotCount(t *testing.T) {
+	b, fake, _ := newBreaker(Settings{Threshold: 2, OpenFor: time.Second})
+	for range 5 {
+		call(b, context.Canceled)
+	}
+	if b.State() != Closed {
+		t.Fatalf("after cancelled calls: %v, want closed", b.State())
+	}
+
+	// A cancelled probe neither closes nor reopens the breaker; the next
+	// call probes instead.
+	call(b, errDown)
+	call(b, errDown)
+	fake.Advance(time.Second)
+	call(b, context.Canceled)
+	if b.State() != HalfOpen {
+		t.Fatalf("after a cancelled probe: %v, want half-open", b.State())
+	}
+	if err := call(b, nil); err != nil || b.State() != Closed {
+		t.Errorf("next probe: %v, state %v; want closed", err, b.State())
+	}
+}
+
+func TestBreaker_PanickingProbeFreesItsSlot(t *testing.T) {
+	b, fake, _ := newBreaker(Settings{Threshold: 1, OpenFor: time.Second})
+	call(b, errDown)
+	fake.Advance(time.Second)
+	func() {
+		defer func() {
+			if p := recover(); p != "probe panicked" {
+				t.Errorf("recovered %v; want the probe's panic", p)
+			}
+		}()
+		b.Do(context.Background(), func(context.Context) error { panic("probe panicked") })
+	}()
+	if b.State() != Open {
+		t.Fatalf("after a panicking probe: %v, want open", b.State())
+	}
+	fake.Advance(time.Second)
+	if err := call(b, nil); err != nil || b.State() != Closed {
+		t.Errorf("next probe: %v, state %v; want closed", err, b.State())
+	}
+}
+
+func TestBreaker_IgnoresResultsFromBeforeAChange(t *testing.T) {
+	b, _, _ := newBreaker(Settings{Threshold: 1})
+	started, slow, done := make(chan struct{}), make(chan struct{}), make(chan struct{})
+	go func() {
+		b.Do(context.Background(), func(context.Context) error { close(started); <-slow; return nil })
+		close(done)
+	}()
+	<-started
+	call(b, errDown) // opens it while the slow call runs
+	close(slow)
+	<-done
+	if b.State() != Open {
+		t.Errorf("a success admitted before the breaker opened closed it: %v", b.State())
+	}
+}
+
+func TestTransport(t *testing.T) {
+	var status atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(int(status.Load()))
+	}))
+	defer srv.Close()
+	b, fake, _ := newBreaker(Settings{Threshold: 2, OpenFor: time.Second})
+	c := &http.Client{Transport: Transport(b, nil)}
+	get := func() (int, error) {
+		resp, err := c.Get(srv.URL)
+		if err != nil {
+			return 0, err
+		}
+		io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+		return resp.StatusCode, nil
+	}
+
+	// 4xx responses, even 429, mean the service is up.
+	status.Store(http.StatusTooManyRequests)
+	for range 3 {
+		get()
+	}
+	if b.State() != Closed {
+		t.Fatalf("after 429s: %v, want closed", b.State())
+	}
+	status.Store(http.StatusInternalServerError)
+	get()
+	get()
+	if _, err := get(); !errors.Is(err, ErrOpen) {
+		t.Fatalf("after two 500s: %v, want ErrOpen", err)
+	}
+	status.Store(http.StatusOK)
+	fake.Advance(time.Second)
+	if code, err := get(); code != http.StatusOK || b.State() != Closed {
+		t.Errorf("probe: %d, %v, state %v; want 200 and closed", code, err, b.State())
+	}
+}
@@ -0,0 +1,76 @@
+// Demonstrates a circuit breaker in front of a service that goes down.
+//
+// This example shows:
+// - Opening after consecutive failures and failing fast while open
+// - A half-open probe after the open period, and closing when it succeeds
+// - Wrapping an http.Client's transport, so 5xx responses count as failures
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"golang_roadmap/12_operations/08_circuit_breaker/breaker"
+)
+
+func main() {
+	fmt.Println("Circuit breaker examples starting...")
+	var down atomic.Bool
+	var served atomic.Int32
+	base := startServer(&down, &served)
+
+	start := time.Now()
+	b := breaker.New(breaker.Settings{
+		Threshold: 3,
+		OpenFor:   400 * time.Millisecond,
+		OnStateChange: func(from, to breaker.State) {
+			fmt.Printf("  %5.2fs breaker %v -> %v\n", time.Since(start).Seconds(), from, to)
+		},
+	})
+	client := &http.Client{Transport: breaker.Transport(b, nil), Timeout: time.Second}
+
+	// One request every 50ms for 2s. The service is down from 0.3s to 1.2s.
+	var ok, failed, rejected int
+	for i := range 40 {
+		down.Store(i >= 6 && i < 24)
+		resp, err := client.Get(base)
+		switch {
+		case errors.Is(err, breaker.ErrOpen):
+			rejected++
+		case err != nil:
+			log.Fatal(err)
+		default:
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+			if resp.StatusCode == http.StatusOK {
+				ok++
+			} else {
+				failed++
+			}
+		}
+		time.Sleep(50 * time.Millisecond)
+	}
+	fmt.Printf("\n40 requests: %d ok, %d failed, %d rejected without being sent\n", ok, failed, rejected)
+	fmt.Printf("Of 18 requests made while the service was down, %d reached it; it handled %d in all\n", failed, served.Load())
+}
+
+// startServer serves 200, or 503 while down is set.
+func startServer(down *atomic.Bool, served *atomic.Int32) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	go http.Serve(ln, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		served.Add(1)
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}
+	}))
+	return "http://" + ln.Addr().String()
+}
@@ -0,0 +1,72 @@
+package cache
+
+import (
+	"math/rand/v2"
+	"sync"
+	"testing"
+)
+
+// mapCache is the naive baseline: a map behind a mutex, no bound, no
+// expiry.
+type mapCache[K comparable, V any] struct {
+	mu sync.Mutex
+	m  map[K]V
+}
+
+func (c *mapCache[K, V]) Get(k K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.m[k]
+	return v, ok
+}
+
+func (c *mapCache[K, V]) Set(k K, v V) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.m[k] = v
+}
+
+type getSetter interface {
+	Get(int) (int, bool)
+	Set(int, int)
+}
+
+const benchKeys = 10_000
+
+// benchmark runs a read-mostly workload (9 reads per write) from
+// GOMAXPROCS goroutines over benchKeys keys.
+func benchmark(b *testing.B, c getSetter) {
+	for k := range benchKeys {
+		c.Set(k, k)
+	}
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		r := rand.New(rand.NewPCG(rand.Uint64(), 0))
+		for pb.Next() {
+			k := r.IntN(benchKeys)
+			if r.IntN(10) == 0 {
+				c.Set(k, k)
+			} else {
+				c.Get(k)
+			}
+		}
+	})
+}
+
+// The LRU bookkeeping makes every Get a write (moving the entry to the
+// front), so it costs more per operation than the map. What it buys is a
+// memory bound: the map grows with every distinct key forever.
+func BenchmarkCache(b *testing.B) {
+	b.Run("map+mutex", func(b *testing.B) {
+		benchmark(b, &mapCache[int, int]{m: map[int]int{}})
+	})
+	b.Run("lru", func(b *testing.B) {
+		benchmark(b, New(Options[int, int]{MaxEntries: benchKeys}))
+	})
+	b.Run("lru+ttl", func(b *testing.B) {
+		benchmark(b, New(Options[int, int]{MaxEntries: benchKeys, TTL: 1 << 62}))
+	})
+	b.Run("lru/half-size", func(b *testing.B) {
+		benchmark(b, New(Options[int, int]{MaxEntries: benchKeys / 2}))
+	})
+}
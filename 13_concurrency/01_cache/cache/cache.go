@@ -0,0 +1,248 @@
+// Package cache is an in-memory cache with a size bound (least recently
+// used entries are evicted first), per-entry expiry and duplicate
+// suppression for loads.
+//
+// A cache in front of a store trades freshness for speed: a cached value
+// can be up to TTL old. Pick the TTL by asking how stale a value may be,
+// and delete the key when you change the value through this process.
+package cache
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// EvictReason says why an entry left the cache.
+type EvictReason int
+
+const (
+	Expired  EvictReason = iota // its TTL passed
+	Capacity                    // it was least recently used and the cache was full
+	Deleted                     // Delete or Clear removed it
+	Replaced                    // Set stored a new value for the key
+)
+
+func (r EvictReason) String() string {
+	switch r {
+	case Expired:
+		return "expired"
+	case Capacity:
+		return "capacity"
+	case Deleted:
+		return "deleted"
+	case Replaced:
+		return "replaced"
+	}
+	return "unknown"
+}
+
+// Options configures a Cache. The hooks are for metrics: they run with
+// the cache's lock held, so they must be quick and must not call back
+// into the cache. Counting with sync/atomic or a metrics library is fine.
+type Options[K comparable, V any] struct {
+	MaxEntries int           // 0: unbounded
+	TTL        time.Duration // default for Set and GetOrLoad; 0: entries never expire
+
+	OnHit   func(key K)
+	OnMiss  func(key K)
+	OnEvict func(key K, value V, reason EvictReason)
+	// OnLoad runs after each loader call, not once per waiting caller.
+	OnLoad func(key K, took time.Duration, err error)
+
+	// Now is the clock, for tests. Default time.Now.
+	Now func() time.Time
+}
+
+// Loader fetches the value for key on a miss.
+type Loader[K comparable, V any] func(ctx context.Context, key K) (V, error)
+
+// Cache is safe for concurrent use. The zero value is not usable; call
+// New.
+type Cache[K comparable, V any] struct {
+	opts Options[K, V]
+
+	mu    sync.Mutex
+	items map[K]*list.Element // of *entry[K, V]
+	lru   *list.List          // front: most recently used
+	calls map[K]*call[V]      // loads in progress
+}
+
+type entry[K comparable, V any] struct {
+	key     K
+	value   V
+	expires time.Time // zero: never
+}
+
+// call is one load shared by every caller that missed on the same key.
+type call[V any] struct {
+	done  chan struct{}
+	value V
+	err   error
+}
+
+// New returns an empty cache.
+func New[K comparable, V any](opts Options[K, V]) *Cache[K, V] {
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	return &Cache[K, V]{
+		opts:  opts,
+		items: map[K]*list.Element{},
+		lru:   list.New(),
+		calls: map[K]*call[V]{},
+	}
+}
+
+// Get returns the value for key if it is cached and has not expired.
+func (c *Cache[K, V]) Get(key K) (V, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.get(key)
+}
+
+func (c *Cache[K, V]) get(key K) (V, bool) {
+	el, ok := c.items[key]
+	if ok {
+		e := el.Value.(*entry[K, V])
+		if e.expires.IsZero() || c.opts.Now().Before(e.expires) {
+			c.lru.MoveToFront(el)
+			if c.opts.OnHit != nil {
+				c.opts.OnHit(key)
+			}
+			return e.value, true
+		}
+		c.remove(el, Expired)
+	}
+	if c.opts.OnMiss != nil {
+		c.opts.OnMiss(key)
+	}
+	var zero V
+	return zero, false
+}
+
+// Set stores value for key with the default TTL.
+func (c *Cache[K, V]) Set(key K, value V) {
+	c.SetWithTTL(key, value, c.opts.TTL)
+}
+
+// SetWithTTL stores value for key, expiring after ttl (0: never). It also
+// discards the result of a load for key that is still running, since that
+// result may be older than value.
+func (c *Cache[K, V]) SetWithTTL(key K, value V, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.calls, key)
+	c.set(key, value, ttl)
+}
+
+func (c *Cache[K, V]) set(key K, value V, ttl time.Duration) {
+	var expires time.Time
+	if ttl > 0 {
+		expires = c.opts.Now().Add(ttl)
+	}
+	if el, ok := c.items[key]; ok {
+		e := el.Value.(*entry[K, V])
+		if c.opts.OnEvict != nil {
+			c.opts.OnEvict(key, e.value, Replaced)
+		}
+		e.value, e.expires = value, expires
+		c.lru.MoveToFront(el)
+		return
+	}
+	c.items[key] = c.lru.PushFront(&entry[K, V]{key: key, value: value, expires: expires})
+	if c.opts.MaxEntries > 0 && c.lru.Len() > c.opts.MaxEntries {
+		c.remove(c.lru.Back(), Capacity)
+	}
+}
+
+// Delete removes key, and discards the result of a load for key that is
+// still running. Call it after changing the value in the underlying store.
+func (c *Cache[K, V]) Delete(key K) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.calls, key)
+	if el, ok := c.items[key]; ok {
+		c.remove(el, Deleted)
+	}
+}
+
+// Clear removes every entry.
+func (c *Cache[K, V]) Clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	clear(c.calls)
+	for el := c.lru.Front(); el != nil; {
+		next := el.Next()
+		c.remove(el, Deleted)
+		el = next
+	}
+}
+
+// Len returns the number of entries, including expired ones that have not
+// been looked up or evicted yet.
+func (c *Cache[K, V]) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.lru.Len()
+}
+
+func (c *Cache[K, V]) remove(el *list.Element, reason EvictReason) {
+	e := c.lru.Remove(el).(*entry[K, V])
+	delete(c.items, e.key)
+	if c.opts.OnEvict != nil {
+		c.opts.OnEvict(e.key, e.value, reason)
+	}
+}
+
+// GetOrLoad returns the cached value for key, or calls load and caches the
+// result. Concurrent misses on the same key share a single load call, so a
+// popular key expiring sends one query to the store, not one per request.
+//
+// The shared load runs with ctx's values but not its cancellation: one
+// caller giving up must not fail the others. Each caller still returns as
+// soon as its own ctx is done. Errors are returned to every waiting
+// caller and are not cached.
+func (c *Cache[K, V]) GetOrLoad(ctx context.Context, key K, load Loader[K, V]) (V, error) {
+	c.mu.Lock()
+	if v, ok := c.get(key); ok {
+		c.mu.Unlock()
+		return v, nil
+	}
+	cl, ok := c.calls[key]
+	if !ok {
+		cl = &call[V]{done: make(chan struct{})}
+		c.calls[key] = cl
+		go c.load(context.WithoutCancel(ctx), key, cl, load)
+	}
+	c.mu.Unlock()
+
+	select {
+	case <-cl.done:
+		return cl.value, cl.err
+	case <-ctx.Done():
+		var zero V
+		return zero, ctx.Err()
+	}
+}
+
+func (c *Cache[K, V]) load(ctx context.Context, key K, cl *call[V], load Loader[K, V]) {
+	start := c.opts.Now()
+	cl.value, cl.err = load(ctx, key)
+
+	c.mu.Lock()
+	if c.opts.OnLoad != nil {
+		c.opts.OnLoad(key, c.opts.Now().Sub(start), cl.err)
+	}
+	// Set or Delete during the load drop the call from the map; its
+	// result is then stale and only goes to the callers already waiting.
+	if c.calls[key] == cl {
+		delete(c.calls, key)
+		if cl.err == nil {
+			c.set(key, cl.value, c.opts.TTL)
+		}
+	}
+	c.mu.Unlock()
+	close(cl.done)
+}
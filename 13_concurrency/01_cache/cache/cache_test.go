@@ -0,0 +1,225 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock is a manually advanced clock.
+type fakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+func (c *fakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+func (c *fakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.now = c.now.Add(d)
+}
+
+func evictionLog(log *[]string) func(string, int, EvictReason) {
+	return func(k string, _ int, r EvictReason) { *log = append(*log, k+" "+r.String()) }
+}
+
+func TestCache_EvictsLeastRecentlyUsed(t *testing.T) {
+	var got []string
+	c := New(Options[string, int]{MaxEntries: 2, OnEvict: evictionLog(&got)})
+	c.Set("a", 1)
+	c.Set("b", 2)
+	c.Get("a") // b is now the least recently used
+	c.Set("c", 3)
+
+	if _, ok := c.Get("b"); ok {
+		t.Error("b still cached; it was least recently used")
+	}
+	for _, k := range []string{"a", "c"} {
+		if _, ok := c.Get(k); !ok {
+			t.Errorf("%s evicted; want it kept", k)
+		}
+	}
+	if fmt.Sprint(got) != "[b capacity]" || c.Len() != 2 {
+		t.Fatalf("evicted %v, len %d; want [b capacity] and 2", got, c.Len())
+	}
+}
+
+func TestCache_TTL(t *testing.T) {
+	clock := &fakeClock{now: time.Unix(0, 0)}
+	var got []string
+	c := New(Options[string, int]{TTL: time.Minute, Now: clock.Now, OnEvict: evictionLog(&got)})
+	c.Set("default", 1)
+	c.SetWithTTL("short", 2, time.Second)
+	c.SetWithTTL("forever", 3, 0)
+
+	clock.Advance(2 * time.Second)
+	if _, ok := c.Get("short"); ok {
+		t.Error("short still cached after its 1s TTL")
+	}
+	if _, ok := c.Get("default"); !ok {
+		t.Error("default expired before the 1m default TTL")
+	}
+	clock.Advance(time.Hour)
+	if _, ok := c.Get("default"); ok {
+		t.Error("default still cached after the default TTL")
+	}
+	if _, ok := c.Get("forever"); !ok {
+		t.Error("forever expired; a 0 TTL must never expire")
+	}
+	if fmt.Sprint(got) != "[short expired default expired]" {
+		t.Fatalf("evicted %v", got)
+	}
+}
+
+func TestCache_Hooks(t *testing.T) {
+	var hits, misses []string
+	var reasons []EvictReason
+	c := New(Options[string, int]{
+		OnHit:   func(k string) { hits = append(hits, k) },
+		OnMiss:  func(k string) { misses = append(misses, k) },
+		OnEvict: func(_ string, _ int, r EvictReason) { reasons = append(reasons, r) },
+	})
+	c.Get("a")
+	c.Set("a", 1)
+	c.Get("a")
+	c.Set("a", 2)
+	c.Delete("a")
+	c.Set("b", 1)
+	c.Clear()
+
+	if fmt.Sprint(hits, misses, reasons) != "[a] [a] [replaced deleted deleted]" {
+		t.Fatalf("hits=%v misses=%v evictions=%v", hits, misses, reasons)
+	}
+	if c.Len() != 0 {
+		t.Fatalf("len = %d after Clear", c.Len())
+	}
+}
+
+func TestGetOrLoad_ConcurrentMissesShareOneLoad(t *testing.T) {
+	var loads, onLoads atomic.Int32
+	c := New(Options[int, string]{OnLoad: func(int, time.Duration, error) { onLoads.Add(1) }})
+	release := make(chan struct{})
+	load := func(_ context.Context, id int) (string, error) {
+		loads.Add(1)
+		<-release
+		return fmt.Sprintf("user-%d", id), nil
+	}
+
+	const callers = 100
+	var wg sync.WaitGroup
+	results := make(chan string, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			v, err := c.GetOrLoad(context.Background(), 7, load)
+			if err != nil {
+				t.Error(err)
+			}
+			results <- v
+		}()
+	}
+	time.Sleep(20 * time.Millisecond) // let every caller join the load
+	close(release)
+	wg.Wait()
+	close(results)
+
+	for v := range results {
+		if v != "user-7" {
+			t.Fatalf("got %q; want user-7", v)
+		}
+	}
+	if loads.Load() != 1 || onLoads.Load() != 1 {
+		t.Fatalf("loader ran %d times, OnLoad %d; want 1 and 1", loads.Load(), onLoads.Load())
+	}
+	if v, ok := c.Get(7); !ok || v != "user-7" {
+		t.Fatalf("Get after load = %q, %v; want the loaded value cached", v, ok)
+	}
+}
+
+func TestGetOrLoad_ErrorsAreNotCached(t *testing.T) {
+	c := New(Options[int, string]{})
+	errDown := errors.New("store down")
+	calls := 0
+	load := func(context.Context, int) (string, error) {
+		calls++
+		if calls == 1 {
+			return "", errDown
+		}
+		return "ok", nil
+	}
+	if _, err := c.GetOrLoad(context.Background(), 1, load); !errors.Is(err, errDown) {
+		t.Fatalf("err = %v; want errDown", err)
+	}
+	if v, err := c.GetOrLoad(context.Background(), 1, load); err != nil || v != "ok" {
+		t.Fatalf("second GetOrLoad = %q, %v; want a fresh load after an error", v, err)
+	}
+}
+
+func TestGetOrLoad_CallerCancelDoesNotFailOthers(t *testing.T) {
+	c := New(Options[int, string]{})
+	release := make(chan struct{})
+	load := func(ctx context.Context, _ int) (string, error) {
+		select {
+		case <-release:
+			return "v", nil
+		case <-ctx.Done():
+			return "", ctx.Err()
+		}
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	first := make(chan error, 1)
+	go func() {
+		_, err := c.GetOrLoad(ctx, 1, load)
+		first <- err
+	}()
+	time.Sleep(10 * time.Millisecond) // the first caller starts the load
+	second := make(chan string, 1)
+	go func() {
+		v, _ := c.GetOrLoad(context.Background(), 1, load)
+		second <- v
+	}()
+
+	cancel()
+	if err := <-first; !errors.Is(err, context.Canceled) {
+		t.Fatalf("first caller: err = %v; want context.Canceled", err)
+	}
+	close(release)
+	if v := <-second; v != "v" {
+		t.Fatalf("second caller got %q; the first caller's cancellation must not cancel the load", v)
+	}
+}
+
+func TestGetOrLoad_DeleteDuringLoadDiscardsResult(t *testing.T) {
+	c := New(Options[int, string]{})
+	started, release := make(chan struct{}), make(chan struct{})
+	done := make(chan string)
+	go func() {
+		v, _ := c.GetOrLoad(context.Background(), 1, func(context.Context, int) (string, error) {
+			close(started)
+			<-release
+			return "old", nil
+		})
+		done <- v
+	}()
+	<-started
+	c.Delete(1) // the store changed while the load was reading it
+	close(release)
+
+	if v := <-done; v != "old" {
+		t.Fatalf("waiting caller got %q; want the loaded value", v)
+	}
+	if _, ok := c.Get(1); ok {
+		t.Fatal("stale load result cached after Delete")
+	}
+}
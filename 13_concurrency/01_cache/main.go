@@ -0,0 +1,111 @@
+// Demonstrates a generic LRU/TTL cache in front of a slow store.
+//
+// This example shows:
+// - Cache[K, V] with type parameters, for any comparable key
+// - Size-bounded LRU eviction and per-entry expiry
+// - Duplicate suppression: 100 concurrent misses, one store query
+// - Metrics hooks counting hits, misses, loads and evictions
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang_roadmap/13_concurrency/01_cache/cache"
+)
+
+type User struct {
+	ID   int
+	Name string
+}
+
+// slowStore stands in for a database: every lookup takes 50ms.
+type slowStore struct {
+	queries atomic.Int64
+}
+
+func (s *slowStore) User(ctx context.Context, id int) (User, error) {
+	s.queries.Add(1)
+	select {
+	case <-time.After(50 * time.Millisecond):
+	case <-ctx.Done():
+		return User{}, ctx.Err()
+	}
+	if id < 1 || id > 1000 {
+		return User{}, fmt.Errorf("user %d not found", id)
+	}
+	return User{ID: id, Name: fmt.Sprintf("user-%d", id)}, nil
+}
+
+// metrics counts cache events; in a service these would be Prometheus
+// counters or expvar values.
+type metrics struct {
+	hits, misses, loads, evictions atomic.Int64
+}
+
+func main() {
+	store := &slowStore{}
+	var m metrics
+	users := cache.New(cache.Options[int, User]{
+		MaxEntries: 3,
+		TTL:        500 * time.Millisecond,
+		OnHit:      func(int) { m.hits.Add(1) },
+		OnMiss:     func(int) { m.misses.Add(1) },
+		OnLoad:     func(int, time.Duration, error) { m.loads.Add(1) },
+		OnEvict: func(id int, _ User, reason cache.EvictReason) {
+			m.evictions.Add(1)
+			log.Printf("evicted user %d (%s)", id, reason)
+		},
+	})
+	ctx := context.Background()
+	lookup := func(id int) {
+		start := time.Now()
+		u, err := users.GetOrLoad(ctx, id, store.User)
+		if err != nil {
+			fmt.Printf("  user %d: %v\n", id, err)
+			return
+		}
+		fmt.Printf("  user %d: %-9s %v\n", id, u.Name, time.Since(start).Round(time.Millisecond))
+	}
+
+	fmt.Println("=== Cold, then warm ===")
+	lookup(1)
+	lookup(1)
+
+	fmt.Println("\n=== LRU eviction (MaxEntries 3) ===")
+	for _, id := range []int{2, 3, 1, 4} { // 1 is touched again, so 2 goes
+		lookup(id)
+	}
+	_, cached := users.Get(2)
+	fmt.Printf("  user 2 still cached: %v\n", cached)
+
+	fmt.Println("\n=== Expiry (TTL 500ms) ===")
+	time.Sleep(600 * time.Millisecond)
+	lookup(1)
+
+	fmt.Println("\n=== Stampede: 100 concurrent requests for an expired key ===")
+	time.Sleep(600 * time.Millisecond)
+	before := store.queries.Load()
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			users.GetOrLoad(ctx, 1, store.User)
+		}()
+	}
+	wg.Wait()
+	fmt.Printf("  store queries: %d\n", store.queries.Load()-before)
+
+	fmt.Println("\n=== Errors are not cached ===")
+	lookup(9999)
+	lookup(9999)
+
+	fmt.Println("\n=== Metrics ===")
+	fmt.Printf("  hits=%d misses=%d loads=%d evictions=%d store queries=%d\n",
+		m.hits.Load(), m.misses.Load(), m.loads.Load(), m.evictions.Load(), store.queries.Load())
+}
@@ -0,0 +1,5 @@
+module golang_roadmap/13_concurrency/02_singleflight
+
+go 1.24.11
+
+require golang.org/x/sync v0.15.0
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+// Backend is the expensive lookup being protected: a database query, a
+// call to another service, a report that takes seconds to build.
+type Backend struct {
+	Latency time.Duration
+	Calls   atomic.Int64 // lookups that reached the backend
+	Fail    atomic.Bool  // make lookups fail, e.g. during an outage
+
+	inFlight, maxInFlight atomic.Int64
+}
+
+// Price looks up the price of an item.
+func (b *Backend) Price(ctx context.Context, item string) (int, error) {
+	b.Calls.Add(1)
+	n := b.inFlight.Add(1)
+	defer b.inFlight.Add(-1)
+	for {
+		m := b.maxInFlight.Load()
+		if n <= m || b.maxInFlight.CompareAndSwap(m, n) {
+			break
+		}
+	}
+
+	select {
+	case <-time.After(b.Latency):
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+	if b.Fail.Load() {
+		return 0, fmt.Errorf("price %s: backend unavailable", item)
+	}
+	return len(item) * 100, nil
+}
+
+// MaxInFlight is the most lookups the backend ran at the same time.
+func (b *Backend) MaxInFlight() int64 { return b.maxInFlight.Load() }
+
+// PriceFunc is any of the lookup strategies below.
+type PriceFunc func(ctx context.Context, item string) (int, error)
+
+// Coalesced shares one backend call between concurrent callers for the
+// same item. Callers that arrive after the call returns start a new one:
+// singleflight deduplicates in flight, it does not cache.
+type Coalesced struct {
+	Backend *Backend
+	group   singleflight.Group
+	Shared  atomic.Int64 // results handed to more than one caller
+}
+
+// Price waits for the shared call, or for ctx.
+//
+// DoChan instead of Do, so that a caller with a short deadline can give
+// up without waiting for a call that another caller started. The call
+// itself runs with context.WithoutCancel: if it used the first caller's
+// ctx, that caller's cancellation would fail everyone sharing the call.
+func (c *Coalesced) Price(ctx context.Context, item string) (int, error) {
+	ch := c.group.DoChan(item, func() (any, error) {
+		return c.Backend.Price(context.WithoutCancel(ctx), item)
+	})
+	select {
+	case r := <-ch:
+		if r.Shared {
+			c.Shared.Add(1)
+		}
+		if r.Err != nil {
+			return 0, r.Err
+		}
+		return r.Val.(int), nil
+	case <-ctx.Done():
+		return 0, ctx.Err()
+	}
+}
+
+// Forget makes the next caller for item start a fresh call instead of
+// joining the one in flight, e.g. after the data changed.
+func (c *Coalesced) Forget(item string) { c.group.Forget(item) }
+
+// TTLCache caches lookups for a fixed time. With Load set to a
+// Coalesced lookup, only one caller refreshes an expired entry; with
+// the backend directly, every caller that misses does: a cache stampede.
+type TTLCache struct {
+	Load PriceFunc
+	TTL  time.Duration
+
+	mu      sync.Mutex
+	entries map[string]cached
+}
+
+type cached struct {
+	price   int
+	expires time.Time
+}
+
+// Price returns the cached price, loading it on a miss.
+func (c *TTLCache) Price(ctx context.Context, item string) (int, error) {
+	c.mu.Lock()
+	e, ok := c.entries[item]
+	c.mu.Unlock()
+	if ok && time.Now().Before(e.expires) {
+		return e.price, nil
+	}
+
+	price, err := c.Load(ctx, item)
+	if err != nil {
+		return 0, err
+	}
+	c.mu.Lock()
+	if c.entries == nil {
+		c.entries = map[string]cached{}
+	}
+	c.entries[item] = cached{price: price, expires: time.Now().Add(c.TTL)}
+	c.mu.Unlock()
+	return price, nil
+}
+
+// Expire drops every entry, as if their TTLs ran out together.
+func (c *TTLCache) Expire() {
+	c.mu.Lock()
+	clear(c.entries)
+	c.mu.Unlock()
+}
+
+// Burst runs n concurrent calls of fn for item, released at the same
+// moment, and returns how many failed.
+func Burst(ctx context.Context, n int, item string, fn PriceFunc) (failed int) {
+	var wg sync.WaitGroup
+	var errs atomic.Int64
+	start := make(chan struct{})
+	for range n {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if _, err := fn(ctx, item); err != nil {
+				errs.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+	return int(errs.Load())
+}
@@ -0,0 +1,70 @@
+// Demonstrates request coalescing with golang.org/x/sync/singleflight.
+//
+// This example shows:
+// - 1000 concurrent callers for one key: 1000 backend calls vs 1
+// - A cache stampede when a hot entry expires, and how coalescing stops it
+// - DoChan, so a caller can give up without cancelling the shared call
+// - That a shared call also shares its error
+package main
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+const callers = 1000
+
+func main() {
+	ctx := context.Background()
+
+	fmt.Printf("=== %d concurrent callers, one item ===\n", callers)
+	direct := &Backend{Latency: 100 * time.Millisecond}
+	start := time.Now()
+	Burst(ctx, callers, "book", direct.Price)
+	fmt.Printf("  direct:    %4d backend calls, %3d at once, %v\n",
+		direct.Calls.Load(), direct.MaxInFlight(), time.Since(start).Round(time.Millisecond))
+
+	coalesced := &Coalesced{Backend: &Backend{Latency: 100 * time.Millisecond}}
+	start = time.Now()
+	Burst(ctx, callers, "book", coalesced.Price)
+	fmt.Printf("  coalesced: %4d backend calls, %3d at once, %v (%d callers got a shared result)\n",
+		coalesced.Backend.Calls.Load(), coalesced.Backend.MaxInFlight(), time.Since(start).Round(time.Millisecond), coalesced.Shared.Load())
+
+	fmt.Println("\n=== Cache stampede: a hot entry expires ===")
+	for _, tc := range []struct {
+		name string
+		load func(*Backend) PriceFunc
+	}{
+		{"cache -> backend", func(b *Backend) PriceFunc { return b.Price }},
+		{"cache -> singleflight -> backend", func(b *Backend) PriceFunc { return (&Coalesced{Backend: b}).Price }},
+	} {
+		b := &Backend{Latency: 100 * time.Millisecond}
+		cache := &TTLCache{Load: tc.load(b), TTL: time.Minute}
+		cache.Price(ctx, "book") // warm
+		cache.Expire()
+		b.Calls.Store(0)
+		Burst(ctx, callers, "book", cache.Price)
+		fmt.Printf("  %-34s %4d backend calls to refresh it\n", tc.name+":", b.Calls.Load())
+	}
+
+	fmt.Println("\n=== A caller with a short deadline ===")
+	slow := &Coalesced{Backend: &Backend{Latency: 300 * time.Millisecond}}
+	patient := make(chan error)
+	go func() {
+		_, err := slow.Price(ctx, "book")
+		patient <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	_, err := slow.Price(short, "book")
+	cancel()
+	fmt.Printf("  impatient caller: %v\n", err)
+	fmt.Printf("  patient caller:   err=%v, backend calls=%d\n", <-patient, slow.Backend.Calls.Load())
+
+	fmt.Println("\n=== Errors are shared too ===")
+	failing := &Coalesced{Backend: &Backend{Latency: 100 * time.Millisecond}}
+	failing.Backend.Fail.Store(true)
+	failed := Burst(ctx, 100, "book", failing.Price)
+	fmt.Printf("  %d of 100 callers failed from %d backend call\n", failed, failing.Backend.Calls.Load())
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+const burst = 1000
+
+func TestBurst_WithoutCoalescingEveryCallerHitsTheBackend(t *testing.T) {
+	b := &Backend{Latency: 20 * time.Millisecond}
+	if failed := Burst(context.Background(), burst, "book", b.Price); failed != 0 {
+		t.Fatalf("%d calls failed", failed)
+	}
+	if b.Calls.Load() != burst {
+		t.Fatalf("backend calls = %d; want %d", b.Calls.Load(), burst)
+	}
+}
+
+func TestBurst_CoalescingMakesOneCall(t *testing.T) {
+	c := &Coalesced{Backend: &Backend{Latency: 20 * time.Millisecond}}
+	if failed := Burst(context.Background(), burst, "book", c.Price); failed != 0 {
+		t.Fatalf("%d calls failed", failed)
+	}
+	// Goroutines that start after the call returns begin a new one, so
+	// allow a few on a loaded machine.
+	if calls := c.Backend.Calls.Load(); calls > 3 {
+		t.Fatalf("backend calls = %d; want about 1 for %d callers", calls, burst)
+	}
+	if c.Shared.Load() < burst-3 {
+		t.Errorf("shared results = %d; want nearly all %d", c.Shared.Load(), burst)
+	}
+}
+
+func TestCoalesced_DifferentKeysAreNotShared(t *testing.T) {
+	c := &Coalesced{Backend: &Backend{Latency: 20 * time.Millisecond}}
+	done := make(chan int)
+	for _, item := range []string{"a", "bb", "ccc"} {
+		go func() {
+			p, _ := c.Price(context.Background(), item)
+			done <- p
+		}()
+	}
+	sum := <-done + <-done + <-done
+	if c.Backend.Calls.Load() != 3 || sum != 600 {
+		t.Fatalf("calls = %d, prices sum to %d; want 3 calls with each item's own price", c.Backend.Calls.Load(), sum)
+	}
+}
+
+// TestStampede shows why a cache alone is not enough: when a hot entry
+// expires, every request that arrives before the refresh finishes misses,
+// and each one goes to the backend.
+func TestStampede(t *testing.T) {
+	tests := []struct {
+		name      string
+		coalesce  bool
+		wantAtMax int64
+	}{
+		{"cache only", false, burst},
+		{"cache with singleflight", true, 3},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			b := &Backend{Latency: 20 * time.Millisecond}
+			load := PriceFunc(b.Price)
+			if tt.coalesce {
+				load = (&Coalesced{Backend: b}).Price
+			}
+			cache := &TTLCache{Load: load, TTL: time.Minute}
+			ctx := context.Background()
+
+			cache.Price(ctx, "book")
+			Burst(ctx, burst, "book", cache.Price)
+			if b.Calls.Load() != 1 {
+				t.Fatalf("backend calls while cached = %d; want 1", b.Calls.Load())
+			}
+
+			cache.Expire()
+			b.Calls.Store(0)
+			Burst(ctx, burst, "book", cache.Price)
+			if got := b.Calls.Load(); got > tt.wantAtMax || (!tt.coalesce && got < burst/2) {
+				t.Fatalf("backend calls to refresh = %d; want at most %d", got, tt.wantAtMax)
+			}
+		})
+	}
+}
+
+func TestCoalesced_CallerDeadlineDoesNotCancelSharedCall(t *testing.T) {
+	c := &Coalesced{Backend: &Backend{Latency: 100 * time.Millisecond}}
+	first := make(chan error)
+	go func() {
+		_, err := c.Price(context.Background(), "book")
+		first <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := c.Price(ctx, "book"); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("short caller: err = %v; want DeadlineExceeded", err)
+	}
+	if took := time.Since(start); took > 50*time.Millisecond {
+		t.Errorf("short caller waited %v; want it to leave at its own deadline", took)
+	}
+	if err := <-first; err != nil {
+		t.Fatalf("first caller: %v; the other caller's deadline must not cancel the call", err)
+	}
+	if c.Backend.Calls.Load() != 1 {
+		t.Fatalf("backend calls = %d; want 1", c.Backend.Calls.Load())
+	}
+}
+
+func TestCoalesced_ErrorsAreSharedButNotRemembered(t *testing.T) {
+	c := &Coalesced{Backend: &Backend{Latency: 20 * time.Millisecond}}
+	c.Backend.Fail.Store(true)
+	if failed := Burst(context.Background(), 100, "book", c.Price); failed < 97 {
+		t.Fatalf("%d of 100 callers failed; want all that shared the failing call", failed)
+	}
+	c.Backend.Fail.Store(false)
+	if _, err := c.Price(context.Background(), "book"); err != nil {
+		t.Fatalf("after recovery: %v; singleflight must not keep the error", err)
+	}
+}
+
+func TestCoalesced_ForgetStartsAFreshCall(t *testing.T) {
+	c := &Coalesced{Backend: &Backend{Latency: 50 * time.Millisecond}}
+	go c.Price(context.Background(), "book")
+	time.Sleep(10 * time.Millisecond)
+	c.Forget("book") // e.g. the price just changed; don't join the old read
+	c.Price(context.Background(), "book")
+	if c.Backend.Calls.Load() != 2 {
+		t.Fatalf("backend calls = %d; want 2 after Forget", c.Backend.Calls.Load())
+	}
+}
+
+// The benchmarks send b.N lookups from many goroutines at 8 hot keys.
+// Watch backend-calls/op: coalesced lookups mostly ride along on a call
+// already in flight. ns/op stays about the same only because the fake
+// backend never slows down; a real one does under 60x the load.
+func benchmarkLookups(b *testing.B, coalesce bool) {
+	be := &Backend{Latency: time.Millisecond}
+	fn := PriceFunc(be.Price)
+	if coalesce {
+		fn = (&Coalesced{Backend: be}).Price
+	}
+	keys := []string{"a", "b", "c", "d", "e", "f", "g", "h"}
+	b.SetParallelism(64)
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		i := 0
+		for pb.Next() {
+			fn(context.Background(), keys[i%len(keys)])
+			i++
+		}
+	})
+	b.ReportMetric(float64(be.Calls.Load())/float64(b.N), "backend-calls/op")
+}
+
+func BenchmarkLookup(b *testing.B) {
+	b.Run("direct", func(b *testing.B) { benchmarkLookups(b, false) })
+	b.Run("coalesced", func(b *testing.B) { benchmarkLookups(b, true) })
+}
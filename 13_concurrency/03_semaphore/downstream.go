@@ -0,0 +1,51 @@
+package main
+
+import (
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"time"
+)
+
+// Downstream is a slow service that records how many requests it served
+// at the same time, so the limits can be checked from outside.
+type Downstream struct {
+	Latency time.Duration
+
+	inFlight, maxInFlight, served atomic.Int64
+}
+
+func (d *Downstream) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	n := d.inFlight.Add(1)
+	defer d.inFlight.Add(-1)
+	for {
+		m := d.maxInFlight.Load()
+		if n <= m || d.maxInFlight.CompareAndSwap(m, n) {
+			break
+		}
+	}
+	d.served.Add(1)
+
+	select {
+	case <-time.After(d.Latency):
+	case <-r.Context().Done():
+		return
+	}
+	if strings.HasPrefix(r.URL.Path, "/fail") {
+		http.Error(w, "boom", http.StatusInternalServerError)
+		return
+	}
+	w.Write([]byte(r.URL.Path))
+}
+
+// MaxInFlight is the most requests served at once.
+func (d *Downstream) MaxInFlight() int64 { return d.maxInFlight.Load() }
+
+// Served is the number of requests that reached the handler.
+func (d *Downstream) Served() int64 { return d.served.Load() }
+
+// Reset clears the counters between runs.
+func (d *Downstream) Reset() {
+	d.maxInFlight.Store(0)
+	d.served.Store(0)
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+	"golang.org/x/sync/semaphore"
+)
+
+// Result is the outcome of fetching one URL.
+type Result struct {
+	URL    string
+	Status int
+	Bytes  int
+	Err    error
+}
+
+func fetch(ctx context.Context, client *http.Client, url string) Result {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return Result{URL: url, Err: err}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return Result{URL: url, Err: err}
+	}
+	defer resp.Body.Close()
+	n, err := io.Copy(io.Discard, resp.Body)
+	if err == nil && resp.StatusCode >= 400 {
+		err = fmt.Errorf("%s: %s", url, resp.Status)
+	}
+	return Result{URL: url, Status: resp.StatusCode, Bytes: int(n), Err: err}
+}
+
+// FetchChan limits concurrency with a buffered channel: sending takes a
+// slot, receiving frees it. It needs nothing outside the language, but
+// waiting for a slot ignores ctx unless you write the select yourself,
+// as done here.
+//
+// The slot is taken before starting the goroutine, so at most limit
+// goroutines exist at once, not len(urls) goroutines waiting.
+func FetchChan(ctx context.Context, client *http.Client, urls []string, limit int) []Result {
+	results := make([]Result, len(urls))
+	slots := make(chan struct{}, limit)
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		select {
+		case slots <- struct{}{}:
+		case <-ctx.Done():
+			for j := i; j < len(urls); j++ {
+				results[j] = Result{URL: urls[j], Err: ctx.Err()}
+			}
+			wg.Wait()
+			return results
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer func() { <-slots }()
+			results[i] = fetch(ctx, client, url)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// FetchWeighted limits concurrency with a semaphore.Weighted shared by
+// the caller, so several batches (or several requests to this service)
+// stay under one limit together. Acquire takes a context, and weights let
+// a heavy call count as more than one slot.
+func FetchWeighted(ctx context.Context, client *http.Client, sem *semaphore.Weighted, urls []string, weight func(url string) int64) []Result {
+	results := make([]Result, len(urls))
+	var wg sync.WaitGroup
+	for i, url := range urls {
+		w := weight(url)
+		if err := sem.Acquire(ctx, w); err != nil {
+			for j := i; j < len(urls); j++ {
+				results[j] = Result{URL: urls[j], Err: err}
+			}
+			break
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer sem.Release(w)
+			results[i] = fetch(ctx, client, url)
+		}()
+	}
+	wg.Wait()
+	return results
+}
+
+// FetchGroup limits concurrency with errgroup.SetLimit: g.Go blocks while
+// limit goroutines are running. The first error cancels ctx for the rest
+// and is returned from Wait, which suits all-or-nothing batches.
+func FetchGroup(ctx context.Context, client *http.Client, urls []string, limit int) ([]Result, error) {
+	results := make([]Result, len(urls))
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(limit)
+	for i, url := range urls {
+		if ctx.Err() != nil {
+			break // a fetch failed; don't start the rest
+		}
+		g.Go(func() error {
+			results[i] = fetch(ctx, client, url)
+			return results[i].Err
+		})
+	}
+	return results, g.Wait()
+}
+
+// LimitTransport caps the requests in flight through one http.Client,
+// whichever code makes them. Requests wait for a slot, or for their own
+// context. It is the outbound equivalent of a server's connection limit,
+// and protects a downstream service from this process.
+//
+// http.Transport.MaxConnsPerHost is similar, but counts connections to
+// one host; this counts requests across all hosts.
+type LimitTransport struct {
+	Base http.RoundTripper // nil: http.DefaultTransport
+	sem  *semaphore.Weighted
+}
+
+// NewLimitTransport allows at most n requests in flight.
+func NewLimitTransport(base http.RoundTripper, n int64) *LimitTransport {
+	return &LimitTransport{Base: base, sem: semaphore.NewWeighted(n)}
+}
+
+func (t *LimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	if err := t.sem.Acquire(req.Context(), 1); err != nil {
+		return nil, err
+	}
+	base := t.Base
+	if base == nil {
+		base = http.DefaultTransport
+	}
+	resp, err := base.RoundTrip(req)
+	if err != nil {
+		t.sem.Release(1)
+		return nil, err
+	}
+	// The request is in flight until its body is closed.
+	resp.Body = &releaseOnClose{ReadCloser: resp.Body, release: func() { t.sem.Release(1) }}
+	return resp, nil
+}
+
+type releaseOnClose struct {
+	io.ReadCloser
+	once    sync.Once
+	release func()
+}
+
+func (b *releaseOnClose) Close() error {
+	err := b.ReadCloser.Close()
+	b.once.Do(b.release)
+	return err
+}
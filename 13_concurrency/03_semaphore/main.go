@@ -0,0 +1,102 @@
+// Demonstrates bounding concurrency for outbound HTTP calls.
+//
+// This example shows:
+// - A buffered channel as a counting semaphore
+// - golang.org/x/sync/semaphore.Weighted: context-aware Acquire, weights
+// - errgroup.SetLimit: a limit plus cancel-on-first-error
+// - An http.RoundTripper that caps requests in flight for a whole client
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+func main() {
+	down := &Downstream{Latency: 100 * time.Millisecond}
+	srv := httptest.NewServer(down)
+	defer srv.Close()
+	ctx := context.Background()
+
+	urls := make([]string, 20)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/item/%d", srv.URL, i)
+	}
+	report := func(name string, start time.Time, results []Result) {
+		failed := 0
+		for _, r := range results {
+			if r.Err != nil {
+				failed++
+			}
+		}
+		fmt.Printf("  %-28s %2d requests, %d failed, max %d at once, %v\n",
+			name, down.Served(), failed, down.MaxInFlight(), time.Since(start).Round(10*time.Millisecond))
+		down.Reset()
+	}
+
+	fmt.Println("=== 20 URLs, 100ms each ===")
+	start := time.Now()
+	report("unbounded (limit 20):", start, FetchChan(ctx, srv.Client(), urls, len(urls)))
+
+	start = time.Now()
+	report("buffered channel, limit 4:", start, FetchChan(ctx, srv.Client(), urls, 4))
+
+	start = time.Now()
+	report("semaphore.Weighted(4):", start, FetchWeighted(ctx, srv.Client(), semaphore.NewWeighted(4), urls, func(string) int64 { return 1 }))
+
+	start = time.Now()
+	results, err := FetchGroup(ctx, srv.Client(), urls, 4)
+	report("errgroup.SetLimit(4):", start, results)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Println("\n=== Weights: /export/* counts as 2 of 4 slots ===")
+	mixed := append([]string{srv.URL + "/export/a", srv.URL + "/export/b"}, urls[:6]...)
+	start = time.Now()
+	report("semaphore.Weighted(4):", start, FetchWeighted(ctx, srv.Client(), semaphore.NewWeighted(4), mixed, func(u string) int64 {
+		if strings.Contains(u, "/export/") {
+			return 2
+		}
+		return 1
+	}))
+
+	fmt.Println("\n=== errgroup: the first failure cancels the rest ===")
+	failing := append([]string{srv.URL + "/fail"}, urls...)
+	start = time.Now()
+	results, err = FetchGroup(ctx, srv.Client(), failing, 4)
+	report("errgroup.SetLimit(4):", start, results)
+	fmt.Printf("  Wait returned: %v\n", err)
+
+	fmt.Println("\n=== Waiting for a slot respects the context ===")
+	sem := semaphore.NewWeighted(4)
+	sem.Acquire(ctx, 4) // someone else holds every slot
+	short, cancel := context.WithTimeout(ctx, 50*time.Millisecond)
+	results = FetchWeighted(short, srv.Client(), sem, urls[:3], func(string) int64 { return 1 })
+	cancel()
+	fmt.Printf("  first result: %v\n", results[0].Err)
+	sem.Release(4)
+	down.Reset()
+
+	fmt.Println("\n=== LimitTransport: one limit for every caller of a client ===")
+	client := &http.Client{Transport: NewLimitTransport(srv.Client().Transport, 3)}
+	var wg sync.WaitGroup
+	start = time.Now()
+	for batch := range 3 { // three unrelated callers, none of them limited
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			FetchChan(ctx, client, urls[batch*5:batch*5+5], 5)
+		}()
+	}
+	wg.Wait()
+	report("3 callers x 5, transport 3:", start, nil)
+}
@@ -0,0 +1,170 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/sync/semaphore"
+)
+
+func newDownstream(t *testing.T) (*Downstream, *httptest.Server) {
+	t.Helper()
+	d := &Downstream{Latency: 20 * time.Millisecond}
+	srv := httptest.NewServer(d)
+	t.Cleanup(srv.Close)
+	return d, srv
+}
+
+func urlsFor(srv *httptest.Server, n int) []string {
+	urls := make([]string, n)
+	for i := range urls {
+		urls[i] = fmt.Sprintf("%s/item/%d", srv.URL, i)
+	}
+	return urls
+}
+
+func one(string) int64 { return 1 }
+
+func TestStrategies_RespectTheCeiling(t *testing.T) {
+	const limit = 4
+	strategies := map[string]func(context.Context, *http.Client, []string) ([]Result, error){
+		"buffered channel": func(ctx context.Context, c *http.Client, urls []string) ([]Result, error) {
+			return FetchChan(ctx, c, urls, limit), nil
+		},
+		"semaphore.Weighted": func(ctx context.Context, c *http.Client, urls []string) ([]Result, error) {
+			return FetchWeighted(ctx, c, semaphore.NewWeighted(limit), urls, one), nil
+		},
+		"errgroup.SetLimit": func(ctx context.Context, c *http.Client, urls []string) ([]Result, error) {
+			return FetchGroup(ctx, c, urls, limit)
+		},
+		"LimitTransport": func(ctx context.Context, c *http.Client, urls []string) ([]Result, error) {
+			limited := &http.Client{Transport: NewLimitTransport(c.Transport, limit)}
+			return FetchChan(ctx, limited, urls, len(urls)), nil
+		},
+	}
+	for name, fetchAll := range strategies {
+		t.Run(name, func(t *testing.T) {
+			d, srv := newDownstream(t)
+			results, err := fetchAll(context.Background(), srv.Client(), urlsFor(srv, 30))
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, r := range results {
+				if r.Err != nil || r.Status != http.StatusOK {
+					t.Fatalf("%s: status %d, err %v", r.URL, r.Status, r.Err)
+				}
+			}
+			if d.Served() != 30 {
+				t.Fatalf("served %d; want 30", d.Served())
+			}
+			if got := d.MaxInFlight(); got > limit {
+				t.Fatalf("%d requests in flight at once; the limit is %d", got, limit)
+			} else if got < limit {
+				t.Errorf("at most %d in flight; want the limit (%d) used", got, limit)
+			}
+		})
+	}
+}
+
+func TestFetchWeighted_HeavyCallsTakeMoreSlots(t *testing.T) {
+	d, srv := newDownstream(t)
+	urls := []string{srv.URL + "/export/a", srv.URL + "/export/b", srv.URL + "/export/c"}
+	weight := func(u string) int64 {
+		if strings.Contains(u, "/export/") {
+			return 3
+		}
+		return 1
+	}
+	FetchWeighted(context.Background(), srv.Client(), semaphore.NewWeighted(4), urls, weight)
+	if d.MaxInFlight() != 1 {
+		t.Fatalf("%d exports at once; with weight 3 of 4, want 1", d.MaxInFlight())
+	}
+}
+
+func TestFetchWeighted_SharedSemaphoreLimitsAllCallers(t *testing.T) {
+	d, srv := newDownstream(t)
+	sem := semaphore.NewWeighted(3)
+	var wg sync.WaitGroup
+	for range 4 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			FetchWeighted(context.Background(), srv.Client(), sem, urlsFor(srv, 5), one)
+		}()
+	}
+	wg.Wait()
+	if d.Served() != 20 || d.MaxInFlight() > 3 {
+		t.Fatalf("served %d, max %d at once; want 20 with at most 3", d.Served(), d.MaxInFlight())
+	}
+}
+
+func TestFetchWeighted_AcquireHonoursContext(t *testing.T) {
+	d, srv := newDownstream(t)
+	sem := semaphore.NewWeighted(2)
+	sem.Acquire(context.Background(), 2) // all slots taken
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+
+	results := FetchWeighted(ctx, srv.Client(), sem, urlsFor(srv, 3), one)
+	for _, r := range results {
+		if !errors.Is(r.Err, context.DeadlineExceeded) {
+			t.Fatalf("%s: err = %v; want DeadlineExceeded while waiting for a slot", r.URL, r.Err)
+		}
+	}
+	if d.Served() != 0 {
+		t.Fatalf("served %d without a slot", d.Served())
+	}
+}
+
+func TestFetchGroup_FirstErrorCancelsTheRest(t *testing.T) {
+	d, srv := newDownstream(t)
+	urls := append([]string{srv.URL + "/fail"}, urlsFor(srv, 20)...)
+	_, err := FetchGroup(context.Background(), srv.Client(), urls, 2)
+	if err == nil || !strings.Contains(err.Error(), "500") {
+		t.Fatalf("err = %v; want the 500", err)
+	}
+	if d.Served() > 4 {
+		t.Fatalf("served %d; fetches after the failure should not start", d.Served())
+	}
+}
+
+func TestFetchChan_StopsWaitingWhenCancelled(t *testing.T) {
+	_, srv := newDownstream(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Millisecond)
+	defer cancel()
+	results := FetchChan(ctx, srv.Client(), urlsFor(srv, 20), 1)
+	if last := results[len(results)-1]; !errors.Is(last.Err, context.DeadlineExceeded) {
+		t.Fatalf("last result err = %v; want DeadlineExceeded", last.Err)
+	}
+}
+
+func TestLimitTransport_SlotHeldUntilBodyClosed(t *testing.T) {
+	_, srv := newDownstream(t)
+	client := &http.Client{Transport: NewLimitTransport(srv.Client().Transport, 1)}
+	resp, err := client.Get(srv.URL + "/a")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	req, _ := http.NewRequestWithContext(ctx, http.MethodGet, srv.URL+"/b", nil)
+	if _, err := client.Do(req); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("second request: err = %v; want it to wait while the first body is open", err)
+	}
+
+	resp.Body.Close()
+	resp.Body.Close() // a second Close must not free a second slot
+	resp, err = client.Get(srv.URL + "/c")
+	if err != nil {
+		t.Fatalf("after Close: %v", err)
+	}
+	resp.Body.Close()
+}
@@ -0,0 +1,147 @@
+// Package future runs a function in a goroutine and hands back a handle
+// to its result: a Future[T]. Then, All and Any combine futures.
+//
+// Go usually does this with a goroutine and a channel, and that is still
+// the right default. Futures earn their keep when results feed into each
+// other (A and B in parallel, then C from both), because every step gets
+// cancellation and error propagation without new plumbing.
+package future
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// Future is the eventual result of a function started with Go. Its
+// methods are safe for concurrent use.
+type Future[T any] struct {
+	done   chan struct{}
+	value  T
+	err    error
+	cancel context.CancelFunc
+}
+
+// Go runs fn in a new goroutine. fn's context is cancelled when ctx is,
+// or by Cancel. A panic in fn becomes the future's error.
+func Go[T any](ctx context.Context, fn func(ctx context.Context) (T, error)) *Future[T] {
+	ctx, cancel := context.WithCancel(ctx)
+	f := &Future[T]{done: make(chan struct{}), cancel: cancel}
+	go func() {
+		defer cancel()
+		defer close(f.done)
+		defer func() {
+			if r := recover(); r != nil {
+				f.err = fmt.Errorf("future: panic: %v", r)
+			}
+		}()
+		f.value, f.err = fn(ctx)
+	}()
+	return f
+}
+
+// Await waits for the result, or for ctx. Giving up on a future does not
+// stop it; call Cancel for that.
+func (f *Future[T]) Await(ctx context.Context) (T, error) {
+	select {
+	case <-f.done:
+		return f.value, f.err
+	case <-ctx.Done():
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+// Done is closed when the result is ready, for use in a select.
+func (f *Future[T]) Done() <-chan struct{} { return f.done }
+
+// Cancel cancels fn's context. The future still completes, with whatever
+// fn returns; usually context.Canceled.
+func (f *Future[T]) Cancel() { f.cancel() }
+
+// Then runs fn with f's value once f succeeds. If f fails, fn is not
+// called and the returned future fails with f's error.
+func Then[T, U any](ctx context.Context, f *Future[T], fn func(ctx context.Context, v T) (U, error)) *Future[U] {
+	return Go(ctx, func(ctx context.Context) (U, error) {
+		v, err := f.Await(ctx)
+		if err != nil {
+			var zero U
+			return zero, err
+		}
+		return fn(ctx, v)
+	})
+}
+
+type indexed[T any] struct {
+	i     int
+	value T
+	err   error
+}
+
+// awaitEach sends each future's result to a channel as it completes.
+// The channel is buffered, so the helper goroutines never block; they
+// exit when their future completes or ctx ends.
+func awaitEach[T any](ctx context.Context, fs []*Future[T]) <-chan indexed[T] {
+	ch := make(chan indexed[T], len(fs))
+	for i, f := range fs {
+		go func() {
+			v, err := f.Await(ctx)
+			ch <- indexed[T]{i, v, err}
+		}()
+	}
+	return ch
+}
+
+// All succeeds with every value, in argument order, once all futures
+// succeed. It fails as soon as any future fails, and cancels the others:
+// their work is no longer needed.
+func All[T any](ctx context.Context, fs ...*Future[T]) *Future[[]T] {
+	return Go(ctx, func(ctx context.Context) ([]T, error) {
+		values := make([]T, len(fs))
+		results := awaitEach(ctx, fs)
+		for range fs {
+			r := <-results
+			if r.err != nil {
+				for _, f := range fs {
+					f.Cancel()
+				}
+				return nil, r.err
+			}
+			values[r.i] = r.value
+		}
+		return values, nil
+	})
+}
+
+// ErrNoFutures is returned by Any when it is given nothing to wait for.
+var ErrNoFutures = errors.New("future: Any of no futures")
+
+// Any succeeds with the first value to arrive and cancels the other
+// futures: the usual way to race replicas or hedge a slow request. If all
+// of them fail, it fails with all the errors joined, in argument order.
+func Any[T any](ctx context.Context, fs ...*Future[T]) *Future[T] {
+	return Go(ctx, func(ctx context.Context) (T, error) {
+		var zero T
+		if len(fs) == 0 {
+			return zero, ErrNoFutures
+		}
+		defer func() {
+			for _, f := range fs {
+				f.Cancel()
+			}
+		}()
+		errs := make([]error, len(fs))
+		results := awaitEach(ctx, fs)
+		for range fs {
+			r := <-results
+			if r.err == nil {
+				return r.value, nil
+			}
+			errs[r.i] = r.err
+		}
+		if err := ctx.Err(); err != nil {
+			return zero, err // not N copies of the same cancellation
+		}
+		return zero, errors.Join(errs...)
+	})
+}
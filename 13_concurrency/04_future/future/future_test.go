@@ -0,0 +1,182 @@
+package future
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// after returns fn that yields v after d, or ctx.Err() if cancelled first.
+// cancelled counts the cancellations it observed.
+func after[T any](d time.Duration, v T, err error, cancelled *atomic.Int32) func(context.Context) (T, error) {
+	return func(ctx context.Context) (T, error) {
+		select {
+		case <-time.After(d):
+			return v, err
+		case <-ctx.Done():
+			if cancelled != nil {
+				cancelled.Add(1)
+			}
+			var zero T
+			return zero, ctx.Err()
+		}
+	}
+}
+
+func TestGo_Await(t *testing.T) {
+	f := Go(context.Background(), after(10*time.Millisecond, 42, nil, nil))
+	v, err := f.Await(context.Background())
+	if v != 42 || err != nil {
+		t.Fatalf("Await = %d, %v; want 42", v, err)
+	}
+	if v2, _ := f.Await(context.Background()); v2 != 42 {
+		t.Fatalf("second Await = %d; a result must be readable many times", v2)
+	}
+	select {
+	case <-f.Done():
+	default:
+		t.Fatal("Done not closed after completion")
+	}
+}
+
+func TestGo_PanicBecomesError(t *testing.T) {
+	f := Go(context.Background(), func(context.Context) (int, error) { panic("boom") })
+	if _, err := f.Await(context.Background()); err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Fatalf("err = %v; want the panic as an error", err)
+	}
+}
+
+func TestAwait_GivingUpDoesNotCancel(t *testing.T) {
+	var cancelled atomic.Int32
+	f := Go(context.Background(), after(50*time.Millisecond, "v", nil, &cancelled))
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Millisecond)
+	defer cancel()
+	if _, err := f.Await(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Await = %v; want DeadlineExceeded", err)
+	}
+	if v, err := f.Await(context.Background()); v != "v" || err != nil || cancelled.Load() != 0 {
+		t.Fatalf("later Await = %q, %v (cancelled %d); the future must keep running", v, err, cancelled.Load())
+	}
+}
+
+func TestCancel(t *testing.T) {
+	parent, cancelParent := context.WithCancel(context.Background())
+	byParent := Go(parent, after(time.Hour, 0, nil, nil))
+	direct := Go(context.Background(), after(time.Hour, 0, nil, nil))
+	cancelParent()
+	direct.Cancel()
+	for name, f := range map[string]*Future[int]{"parent ctx": byParent, "Cancel": direct} {
+		if _, err := f.Await(context.Background()); !errors.Is(err, context.Canceled) {
+			t.Errorf("cancelled by %s: err = %v; want context.Canceled", name, err)
+		}
+	}
+}
+
+func TestThen(t *testing.T) {
+	ctx := context.Background()
+	called := false
+	user := Go(ctx, after(5*time.Millisecond, 7, nil, nil))
+	orders := Then(ctx, user, func(_ context.Context, id int) (string, error) {
+		called = true
+		return fmt.Sprintf("orders of user %d", id), nil
+	})
+	if v, err := orders.Await(ctx); v != "orders of user 7" || err != nil || !called {
+		t.Fatalf("Then = %q, %v", v, err)
+	}
+
+	errLookup := errors.New("lookup failed")
+	called = false
+	failed := Then(ctx, Go(ctx, after(0, 0, errLookup, nil)), func(context.Context, int) (string, error) {
+		called = true
+		return "", nil
+	})
+	if _, err := failed.Await(ctx); !errors.Is(err, errLookup) || called {
+		t.Fatalf("err = %v, fn called = %v; want errLookup without calling fn", err, called)
+	}
+}
+
+func TestAll(t *testing.T) {
+	ctx := context.Background()
+	all := All(ctx,
+		Go(ctx, after(30*time.Millisecond, "a", nil, nil)),
+		Go(ctx, after(10*time.Millisecond, "b", nil, nil)),
+		Go(ctx, after(20*time.Millisecond, "c", nil, nil)),
+	)
+	v, err := all.Await(ctx)
+	if err != nil || fmt.Sprint(v) != "[a b c]" {
+		t.Fatalf("All = %v, %v; want [a b c] in argument order", v, err)
+	}
+}
+
+func TestAll_FailsFastAndCancelsTheRest(t *testing.T) {
+	ctx := context.Background()
+	errBad := errors.New("bad")
+	var cancelled atomic.Int32
+	start := time.Now()
+	all := All(ctx,
+		Go(ctx, after(time.Hour, 1, nil, &cancelled)),
+		Go(ctx, after(5*time.Millisecond, 0, errBad, nil)),
+		Go(ctx, after(time.Hour, 3, nil, &cancelled)),
+	)
+	if _, err := all.Await(ctx); !errors.Is(err, errBad) {
+		t.Fatalf("err = %v; want errBad", err)
+	}
+	if took := time.Since(start); took > time.Second {
+		t.Fatalf("All took %v; want it to return at the first error", took)
+	}
+	waitFor(t, func() bool { return cancelled.Load() == 2 })
+}
+
+func TestAny(t *testing.T) {
+	ctx := context.Background()
+	var cancelled atomic.Int32
+	first := Any(ctx,
+		Go(ctx, after(time.Hour, "slow replica", nil, &cancelled)),
+		Go(ctx, after(5*time.Millisecond, "", errors.New("replica down"), nil)),
+		Go(ctx, after(10*time.Millisecond, "fast replica", nil, nil)),
+	)
+	if v, err := first.Await(ctx); v != "fast replica" || err != nil {
+		t.Fatalf("Any = %q, %v; want the first success, skipping the failure", v, err)
+	}
+	waitFor(t, func() bool { return cancelled.Load() == 1 })
+}
+
+func TestAny_AllFail(t *testing.T) {
+	ctx := context.Background()
+	e1, e2 := errors.New("one"), errors.New("two")
+	_, err := Any(ctx, Go(ctx, after(10*time.Millisecond, 0, e1, nil)), Go(ctx, after(0, 0, e2, nil))).Await(ctx)
+	if !errors.Is(err, e1) || !errors.Is(err, e2) || err.Error() != "one\ntwo" {
+		t.Fatalf("err = %q; want both errors in argument order", err)
+	}
+	if _, err := Any[int](ctx).Await(ctx); !errors.Is(err, ErrNoFutures) {
+		t.Fatalf("Any() err = %v; want ErrNoFutures", err)
+	}
+}
+
+func TestCombinators_ParentCancellation(t *testing.T) {
+	parent, cancel := context.WithCancel(context.Background())
+	var cancelled atomic.Int32
+	a := Go(parent, after(time.Hour, 1, nil, &cancelled))
+	b := Go(parent, after(time.Hour, 2, nil, &cancelled))
+	sum := Then(parent, All(parent, a, b), func(_ context.Context, v []int) (int, error) { return v[0] + v[1], nil })
+	cancel()
+	if _, err := sum.Await(context.Background()); !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v; want context.Canceled through All and Then", err)
+	}
+	waitFor(t, func() bool { return cancelled.Load() == 2 })
+}
+
+func waitFor(t *testing.T, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatal("timed out")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
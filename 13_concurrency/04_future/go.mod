@@ -0,0 +1,3 @@
+module golang_roadmap/13_concurrency/04_future
+
+go 1.24.11
@@ -0,0 +1,125 @@
+// Demonstrates a generic Future[T] with Then, All and Any.
+//
+// This example shows:
+// - Building a page from dependent lookups with futures
+// - The same page written with plain goroutines and channels
+// - Racing two replicas with Any (hedged requests)
+// - A deadline cancelling every lookup still running
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"golang_roadmap/13_concurrency/04_future/future"
+)
+
+// lookup simulates a remote call that takes d.
+func lookup[T any](ctx context.Context, name string, d time.Duration, v T) (T, error) {
+	select {
+	case <-time.After(d):
+		return v, nil
+	case <-ctx.Done():
+		fmt.Printf("    %s cancelled\n", name)
+		var zero T
+		return zero, ctx.Err()
+	}
+}
+
+type Page struct {
+	User    string
+	Orders  []string
+	Friends []string
+}
+
+// pageWithFutures: the user first, then orders and friends in parallel.
+func pageWithFutures(ctx context.Context, latency time.Duration) (Page, error) {
+	user := future.Go(ctx, func(ctx context.Context) (string, error) {
+		return lookup(ctx, "user", latency, "ann")
+	})
+	orders := future.Then(ctx, user, func(ctx context.Context, u string) ([]string, error) {
+		return lookup(ctx, "orders", latency, []string{u + "-order-1", u + "-order-2"})
+	})
+	friends := future.Then(ctx, user, func(ctx context.Context, u string) ([]string, error) {
+		return lookup(ctx, "friends", latency, []string{"bob", "cy"})
+	})
+	both, err := future.All(ctx, orders, friends).Await(ctx)
+	if err != nil {
+		return Page{}, err
+	}
+	u, _ := user.Await(ctx)
+	return Page{User: u, Orders: both[0], Friends: both[1]}, nil
+}
+
+// pageWithChannels is the same with goroutines and channels. Each step
+// needs its own channel, and the error and cancellation handling is
+// written out by hand.
+func pageWithChannels(ctx context.Context, latency time.Duration) (Page, error) {
+	u, err := lookup(ctx, "user", latency, "ann")
+	if err != nil {
+		return Page{}, err
+	}
+	type result struct {
+		items []string
+		err   error
+	}
+	orders, friends := make(chan result, 1), make(chan result, 1)
+	go func() {
+		v, err := lookup(ctx, "orders", latency, []string{u + "-order-1", u + "-order-2"})
+		orders <- result{v, err}
+	}()
+	go func() {
+		v, err := lookup(ctx, "friends", latency, []string{"bob", "cy"})
+		friends <- result{v, err}
+	}()
+	// This waits for both even if one fails. Failing fast, like All,
+	// needs a select loop over both channels and a cancel func.
+	o, f := <-orders, <-friends
+	if err := errors.Join(o.err, f.err); err != nil {
+		return Page{}, err
+	}
+	return Page{User: u, Orders: o.items, Friends: f.items}, nil
+}
+
+func main() {
+	ctx := context.Background()
+
+	fmt.Println("=== One page, three lookups of 100ms (user, then orders and friends) ===")
+	for _, tc := range []struct {
+		name string
+		fn   func(context.Context, time.Duration) (Page, error)
+	}{{"futures", pageWithFutures}, {"channels", pageWithChannels}} {
+		start := time.Now()
+		page, err := tc.fn(ctx, 100*time.Millisecond)
+		fmt.Printf("  %-10s %+v err=%v in %v\n", tc.name+":", page, err, time.Since(start).Round(10*time.Millisecond))
+	}
+
+	fmt.Println("\n=== A 150ms deadline cancels what is still running ===")
+	short, cancel := context.WithTimeout(ctx, 150*time.Millisecond)
+	_, err := pageWithFutures(short, 100*time.Millisecond)
+	cancel()
+	time.Sleep(10 * time.Millisecond) // let the lookups report their cancellation
+	fmt.Printf("  err=%v\n", err)
+
+	fmt.Println("\n=== Hedged request: ask two replicas, keep the first answer ===")
+	start := time.Now()
+	price, err := future.Any(ctx,
+		future.Go(ctx, func(ctx context.Context) (int, error) {
+			return lookup(ctx, "replica-a (slow)", 500*time.Millisecond, 100)
+		}),
+		future.Go(ctx, func(ctx context.Context) (int, error) { return lookup(ctx, "replica-b", 50*time.Millisecond, 100) }),
+	).Await(ctx)
+	time.Sleep(10 * time.Millisecond)
+	fmt.Printf("  price=%d err=%v in %v\n", price, err, time.Since(start).Round(10*time.Millisecond))
+
+	fmt.Println("\n=== All fails fast ===")
+	start = time.Now()
+	_, err = future.All(ctx,
+		future.Go(ctx, func(ctx context.Context) (int, error) { return lookup(ctx, "stock", time.Second, 5) }),
+		future.Go(ctx, func(context.Context) (int, error) { return 0, errors.New("pricing service unavailable") }),
+	).Await(ctx)
+	time.Sleep(10 * time.Millisecond)
+	fmt.Printf("  err=%v in %v\n", err, time.Since(start).Round(10*time.Millisecond))
+}
@@ -0,0 +1,3 @@
+module golang_roadmap/13_concurrency/05_sharded_map
+
+go 1.24.11
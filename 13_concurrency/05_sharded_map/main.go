@@ -0,0 +1,107 @@
+// Demonstrates a generic sharded map next to sync.Map and a mutex map.
+//
+// This example shows:
+// - Map[K, V] split into shards by key hash, one RWMutex per shard
+// - Atomic read-modify-write with Update, which sync.Map lacks
+// - The same counter on sync.Map, which needs a CompareAndSwap loop
+// - Where to find the benchmarks: go test -bench . ./shardmap
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"strings"
+	"sync"
+	"time"
+
+	"golang_roadmap/13_concurrency/05_sharded_map/shardmap"
+)
+
+const text = `the quick brown fox jumps over the lazy dog the dog sleeps
+and the fox runs over the hill while the dog dreams of the fox`
+
+// countSharded counts words from several goroutines into a sharded map.
+func countSharded(words []string, workers int) map[string]int {
+	m := shardmap.New[string, int](0)
+	parallel(words, workers, func(w string) {
+		m.Update(w, func(n int, _ bool) int { return n + 1 })
+	})
+	out := map[string]int{}
+	m.Range(func(k string, v int) bool { out[k] = v; return true })
+	return out
+}
+
+// countSyncMap does the same with sync.Map. There is no Update, so an
+// increment is a load followed by a CompareAndSwap, retried when another
+// goroutine got there first.
+func countSyncMap(words []string, workers int) map[string]int {
+	var m sync.Map
+	parallel(words, workers, func(w string) {
+		for {
+			old, loaded := m.LoadOrStore(w, 1)
+			if !loaded || m.CompareAndSwap(w, old, old.(int)+1) {
+				return
+			}
+		}
+	})
+	out := map[string]int{}
+	m.Range(func(k, v any) bool { out[k.(string)] = v.(int); return true })
+	return out
+}
+
+// countMutex uses one map behind one mutex: the simplest version.
+func countMutex(words []string, workers int) map[string]int {
+	var mu sync.Mutex
+	m := map[string]int{}
+	parallel(words, workers, func(w string) {
+		mu.Lock()
+		m[w]++
+		mu.Unlock()
+	})
+	return m
+}
+
+// parallel calls fn for every word, 2000 times over, from workers goroutines.
+func parallel(words []string, workers int, fn func(string)) {
+	var wg sync.WaitGroup
+	for i := range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for rep := i; rep < 2000; rep += workers {
+				for _, w := range words {
+					fn(w)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+}
+
+func main() {
+	words := strings.Fields(text)
+	workers := runtime.GOMAXPROCS(0) * 2
+	fmt.Printf("=== Counting %d words x 2000 with %d goroutines (GOMAXPROCS %d) ===\n", len(words), workers, runtime.GOMAXPROCS(0))
+
+	var want map[string]int
+	for _, tc := range []struct {
+		name  string
+		count func([]string, int) map[string]int
+	}{
+		{"mutex map", countMutex},
+		{"sharded map", countSharded},
+		{"sync.Map", countSyncMap},
+	} {
+		start := time.Now()
+		got := tc.count(words, workers)
+		took := time.Since(start)
+		if want == nil {
+			want = got
+		}
+		fmt.Printf("  %-12s the=%d fox=%d dog=%d  same as mutex map: %v  %v\n",
+			tc.name, got["the"], got["fox"], got["dog"], fmt.Sprint(got) == fmt.Sprint(want), took.Round(time.Millisecond))
+	}
+
+	fmt.Println("\nOne run of a toy workload says little about speed; see the benchmarks:")
+	fmt.Println("  go test -run xxx -bench . -cpu 1,4,16 ./shardmap")
+}
@@ -0,0 +1,128 @@
+package shardmap
+
+import (
+	"fmt"
+	"math/rand/v2"
+	"sync"
+	"testing"
+)
+
+// concurrentMap is what the benchmarks exercise.
+type concurrentMap interface {
+	Load(int) (int, bool)
+	Store(int, int)
+}
+
+// mutexMap is the baseline: one sync.Mutex for the whole map.
+type mutexMap struct {
+	mu sync.Mutex
+	m  map[int]int
+}
+
+func (m *mutexMap) Load(k int) (int, bool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	v, ok := m.m[k]
+	return v, ok
+}
+
+func (m *mutexMap) Store(k, v int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.m[k] = v
+}
+
+// rwMutexMap lets readers share the lock.
+type rwMutexMap struct {
+	mu sync.RWMutex
+	m  map[int]int
+}
+
+func (m *rwMutexMap) Load(k int) (int, bool) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	v, ok := m.m[k]
+	return v, ok
+}
+
+func (m *rwMutexMap) Store(k, v int) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.m[k] = v
+}
+
+// syncMap adapts sync.Map, which stores any and needs type assertions.
+type syncMap struct{ m sync.Map }
+
+func (m *syncMap) Load(k int) (int, bool) {
+	v, ok := m.m.Load(k)
+	if !ok {
+		return 0, false
+	}
+	return v.(int), true
+}
+
+func (m *syncMap) Store(k, v int) { m.m.Store(k, v) }
+
+const keys = 1 << 16
+
+var impls = []struct {
+	name string
+	new  func() concurrentMap
+}{
+	{"mutex", func() concurrentMap { return &mutexMap{m: map[int]int{}} }},
+	{"rwmutex", func() concurrentMap { return &rwMutexMap{m: map[int]int{}} }},
+	{"sync.Map", func() concurrentMap { return &syncMap{} }},
+	{"sharded", func() concurrentMap { return New[int, int](0) }},
+}
+
+// BenchmarkWorkloads runs each map under a mix of reads and writes over
+// existing keys, from GOMAXPROCS goroutines. Compare across CPU counts:
+//
+//	go test -run xxx -bench Workloads -cpu 1,4,16 ./shardmap
+func BenchmarkWorkloads(b *testing.B) {
+	for _, writePct := range []int{1, 10, 50, 100} {
+		for _, impl := range impls {
+			b.Run(fmt.Sprintf("writes=%d%%/%s", writePct, impl.name), func(b *testing.B) {
+				m := impl.new()
+				for k := range keys {
+					m.Store(k, k)
+				}
+				b.ResetTimer()
+				b.RunParallel(func(pb *testing.PB) {
+					r := rand.New(rand.NewPCG(rand.Uint64(), 0))
+					for pb.Next() {
+						k := r.IntN(keys)
+						if r.IntN(100) < writePct {
+							m.Store(k, k)
+						} else {
+							m.Load(k)
+						}
+					}
+				})
+			})
+		}
+	}
+}
+
+// BenchmarkInsert stores new keys only: the map grows throughout. This is
+// sync.Map's worst case, since each new key goes through its dirty map.
+func BenchmarkInsert(b *testing.B) {
+	for _, impl := range impls {
+		b.Run(impl.name, func(b *testing.B) {
+			m := impl.new()
+			var next sync.Mutex
+			base := 0
+			b.RunParallel(func(pb *testing.PB) {
+				next.Lock()
+				k := base
+				base += 1 << 40 // each goroutine gets its own key range
+				next.Unlock()
+				for pb.Next() {
+					m.Store(k, k)
+					k++
+				}
+			})
+		})
+	}
+}
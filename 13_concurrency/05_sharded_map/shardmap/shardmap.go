@@ -0,0 +1,132 @@
+// Package shardmap is a concurrent map split into shards, each with its
+// own lock, so goroutines working on different keys rarely wait for each
+// other.
+//
+// A single mutex serialises every operation on the map. Sharding spreads
+// that over N locks: a key's hash picks its shard, and only that shard is
+// locked. sync.Map takes a different approach (a lock-free read path plus
+// a dirty map for writes) that suits write-once, read-many data. The
+// benchmarks in this package compare the three.
+package shardmap
+
+import (
+	"hash/maphash"
+	"runtime"
+	"sync"
+)
+
+// Map is a sharded map. The zero value is not usable; call New.
+type Map[K comparable, V any] struct {
+	seed   maphash.Seed
+	mask   uint64
+	shards []shard[K, V]
+}
+
+type shard[K comparable, V any] struct {
+	mu sync.RWMutex
+	m  map[K]V
+	_  [32]byte // pad to 64 bytes, a cache line, so neighbouring locks don't share one
+}
+
+// New returns a map with n shards, rounded up to a power of two. n <= 0
+// picks 4 × GOMAXPROCS, enough that two busy goroutines seldom collide.
+func New[K comparable, V any](n int) *Map[K, V] {
+	if n <= 0 {
+		n = 4 * runtime.GOMAXPROCS(0)
+	}
+	size := 1
+	for size < n {
+		size <<= 1
+	}
+	m := &Map[K, V]{seed: maphash.MakeSeed(), mask: uint64(size - 1), shards: make([]shard[K, V], size)}
+	for i := range m.shards {
+		m.shards[i].m = map[K]V{}
+	}
+	return m
+}
+
+func (m *Map[K, V]) shard(key K) *shard[K, V] {
+	return &m.shards[maphash.Comparable(m.seed, key)&m.mask]
+}
+
+// Load returns the value stored for key.
+func (m *Map[K, V]) Load(key K) (V, bool) {
+	s := m.shard(key)
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	v, ok := s.m[key]
+	return v, ok
+}
+
+// Store sets the value for key.
+func (m *Map[K, V]) Store(key K, value V) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.m[key] = value
+}
+
+// LoadOrStore returns the existing value for key if present. Otherwise it
+// stores value and returns it. loaded reports which happened.
+func (m *Map[K, V]) LoadOrStore(key K, value V) (actual V, loaded bool) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.m[key]; ok {
+		return v, true
+	}
+	s.m[key] = value
+	return value, false
+}
+
+// Update replaces the value for key with fn(old, exists), atomically with
+// respect to other operations on key. This is what a single-lock map
+// gives for free and sync.Map does not: a read-modify-write without a CAS
+// loop.
+func (m *Map[K, V]) Update(key K, fn func(old V, exists bool) V) V {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	old, ok := s.m[key]
+	v := fn(old, ok)
+	s.m[key] = v
+	return v
+}
+
+// Delete removes key.
+func (m *Map[K, V]) Delete(key K) {
+	s := m.shard(key)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.m, key)
+}
+
+// Len counts the entries. Shards are locked one at a time, so under
+// concurrent writes the total is not a snapshot of a single instant.
+func (m *Map[K, V]) Len() int {
+	n := 0
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		n += len(s.m)
+		s.mu.RUnlock()
+	}
+	return n
+}
+
+// Range calls fn for each entry until fn returns false. Like Len, it is
+// not a snapshot. fn runs with the shard's read lock held, so it must not
+// write to the map.
+func (m *Map[K, V]) Range(fn func(K, V) bool) {
+	for i := range m.shards {
+		s := &m.shards[i]
+		s.mu.RLock()
+		for k, v := range s.m {
+			if !fn(k, v) {
+				s.mu.RUnlock()
+				return
+			}
+		}
+		s.mu.RUnlock()
+	}
+}
@@ -0,0 +1,79 @@
+package shardmap
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"unsafe"
+)
+
+func TestNew_RoundsShardsToPowerOfTwo(t *testing.T) {
+	for n, want := range map[int]int{1: 1, 3: 4, 16: 16, 17: 32} {
+		if got := len(New[int, int](n).shards); got != want {
+			t.Errorf("New(%d) has %d shards; want %d", n, got, want)
+		}
+	}
+	if len(New[int, int](0).shards) == 0 {
+		t.Error("New(0) has no shards")
+	}
+	if size := unsafe.Sizeof(shard[int, int]{}); size%64 != 0 {
+		t.Errorf("shard is %d bytes; want a multiple of a 64-byte cache line", size)
+	}
+}
+
+func TestMap_Operations(t *testing.T) {
+	m := New[string, int](4)
+	m.Store("a", 1)
+	if v, ok := m.Load("a"); !ok || v != 1 {
+		t.Fatalf("Load(a) = %d, %v", v, ok)
+	}
+	if v, loaded := m.LoadOrStore("a", 2); !loaded || v != 1 {
+		t.Fatalf("LoadOrStore(existing) = %d, %v; want 1, true", v, loaded)
+	}
+	if v, loaded := m.LoadOrStore("b", 2); loaded || v != 2 {
+		t.Fatalf("LoadOrStore(new) = %d, %v; want 2, false", v, loaded)
+	}
+	m.Delete("a")
+	if _, ok := m.Load("a"); ok || m.Len() != 1 {
+		t.Fatalf("after Delete: len %d", m.Len())
+	}
+
+	for i := range 100 {
+		m.Store(fmt.Sprint("key-", i), i)
+	}
+	seen := 0
+	m.Range(func(string, int) bool { seen++; return true })
+	if seen != m.Len() || seen != 101 {
+		t.Fatalf("Range saw %d, Len %d; want 101", seen, m.Len())
+	}
+	seen = 0
+	m.Range(func(string, int) bool { seen++; return seen < 10 })
+	if seen != 10 {
+		t.Fatalf("Range continued to %d after returning false", seen)
+	}
+}
+
+func TestMap_ConcurrentUpdatesAreAtomic(t *testing.T) {
+	m := New[int, int](8)
+	var wg sync.WaitGroup
+	for g := range 8 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range 1000 {
+				m.Update(i%10, func(old int, _ bool) int { return old + 1 })
+				m.Store(100+g, i)
+				m.Load(i % 10)
+			}
+		}()
+	}
+	wg.Wait()
+	total := 0
+	for k := range 10 {
+		v, _ := m.Load(k)
+		total += v
+	}
+	if total != 8000 {
+		t.Fatalf("counters sum to %d; want 8000 with no lost updates", total)
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// Status is where an account is in its lifecycle:
+//
+//	Open ⇄ Frozen
+//	Open → Closed (only with a zero balance)
+type Status int
+
+const (
+	Open Status = iota
+	Frozen
+	Closed
+)
+
+func (s Status) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case Frozen:
+		return "frozen"
+	case Closed:
+		return "closed"
+	}
+	return fmt.Sprintf("Status(%d)", int(s))
+}
+
+// State is an account's data. Both implementations share these rules, so
+// the only difference between them is how access is serialised.
+type State struct {
+	Balance int
+	Status  Status
+}
+
+var (
+	ErrInvalidAmount     = errors.New("amount must be positive")
+	ErrInsufficientFunds = errors.New("insufficient funds")
+	ErrFrozen            = errors.New("account is frozen")
+	ErrClosed            = errors.New("account is closed")
+	ErrNonZeroBalance    = errors.New("balance must be zero to close")
+	ErrBadTransition     = errors.New("invalid status change")
+)
+
+func (s *State) deposit(amount int) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if err := s.usable(); err != nil {
+		return err
+	}
+	s.Balance += amount
+	return nil
+}
+
+func (s *State) withdraw(amount int) error {
+	if amount <= 0 {
+		return ErrInvalidAmount
+	}
+	if err := s.usable(); err != nil {
+		return err
+	}
+	if amount > s.Balance {
+		return ErrInsufficientFunds
+	}
+	s.Balance -= amount
+	return nil
+}
+
+func (s *State) usable() error {
+	switch s.Status {
+	case Frozen:
+		return ErrFrozen
+	case Closed:
+		return ErrClosed
+	}
+	return nil
+}
+
+// transition moves the account to status to, if the state machine allows.
+func (s *State) transition(to Status) error {
+	switch {
+	case s.Status == Closed:
+		return ErrClosed
+	case s.Status == to:
+		return nil
+	case to == Closed && s.Balance != 0:
+		return ErrNonZeroBalance
+	case to == Closed && s.Status == Frozen:
+		return fmt.Errorf("%w: %s → %s", ErrBadTransition, s.Status, to)
+	}
+	s.Status = to
+	return nil
+}
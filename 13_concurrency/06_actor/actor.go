@@ -0,0 +1,251 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// Messages the account actor understands. Each carries a reply channel,
+// buffered so the actor never blocks on a caller that has given up.
+type (
+	depositMsg struct {
+		amount int
+		reply  chan result
+	}
+	withdrawMsg struct {
+		amount int
+		reply  chan result
+	}
+	balanceMsg struct {
+		reply chan result
+	}
+	statusMsg struct {
+		to    Status
+		reply chan result
+	}
+	// inspectMsg runs caller code inside the actor, with the state. It is
+	// how a bug (a panic) gets into the actor in this example.
+	inspectMsg struct {
+		fn    func(State)
+		reply chan result
+	}
+)
+
+type result struct {
+	state State
+	err   error
+}
+
+var (
+	ErrStopped = errors.New("account actor stopped")
+	ErrCrashed = errors.New("account actor crashed while handling the request")
+)
+
+// Actor owns an account's State. Only its goroutine reads or writes the
+// state; everyone else sends it messages through the mailbox and waits
+// for the reply. There is no lock because there is no sharing.
+type Actor struct {
+	mailbox chan any
+	stop    chan struct{}
+	done    chan struct{} // closed when the actor has exited
+	opts    Options
+
+	state    State
+	restarts []time.Time
+}
+
+// Options configure an Actor. Zero values get the defaults.
+type Options struct {
+	Mailbox int // buffered messages before senders block; default 16
+
+	// Supervision: restart after a panic, at most MaxRestarts times
+	// within Window; past that, stop for good. Defaults 3 and a minute.
+	MaxRestarts int
+	Window      time.Duration
+}
+
+// NewActor starts an account actor.
+func NewActor(opts Options) *Actor {
+	if opts.Mailbox <= 0 {
+		opts.Mailbox = 16
+	}
+	if opts.MaxRestarts <= 0 {
+		opts.MaxRestarts = 3
+	}
+	if opts.Window <= 0 {
+		opts.Window = time.Minute
+	}
+	a := &Actor{
+		mailbox: make(chan any, opts.Mailbox),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+		opts:    opts,
+	}
+	go a.supervise()
+	return a
+}
+
+// supervise runs the message loop and restarts it when it panics. The
+// state survives a restart as it was before the failing message: that
+// message's changes are rolled back and its caller gets ErrCrashed.
+func (a *Actor) supervise() {
+	defer close(a.done)
+	for {
+		crashed := a.loop()
+		if !crashed {
+			return
+		}
+		now := time.Now()
+		recent := a.restarts[:0]
+		for _, t := range a.restarts {
+			if now.Sub(t) < a.opts.Window {
+				recent = append(recent, t)
+			}
+		}
+		a.restarts = append(recent, now)
+		if len(a.restarts) > a.opts.MaxRestarts {
+			log.Printf("actor: %d crashes within %v, giving up", len(a.restarts), a.opts.Window)
+			return
+		}
+		log.Printf("actor: restarting (%d of %d)", len(a.restarts), a.opts.MaxRestarts)
+	}
+}
+
+// loop handles messages until Stop. It reports whether it ended in a
+// panic.
+func (a *Actor) loop() (crashed bool) {
+	var current any
+	var snapshot State
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("actor: panic handling %T: %v", current, r)
+			a.state = snapshot
+			replyTo(current) <- result{state: a.state, err: fmt.Errorf("%w: %v", ErrCrashed, r)}
+			crashed = true
+		}
+	}()
+	for {
+		// Stop wins over a non-empty mailbox: select alone picks at random.
+		select {
+		case <-a.stop:
+			return false
+		default:
+		}
+		select {
+		case msg := <-a.mailbox:
+			current, snapshot = msg, a.state
+			a.handle(msg)
+		case <-a.stop:
+			return false
+		}
+	}
+}
+
+func (a *Actor) handle(msg any) {
+	s := &a.state
+	switch m := msg.(type) {
+	case depositMsg:
+		m.reply <- result{err: s.deposit(m.amount), state: *s}
+	case withdrawMsg:
+		m.reply <- result{err: s.withdraw(m.amount), state: *s}
+	case balanceMsg:
+		m.reply <- result{state: *s}
+	case statusMsg:
+		m.reply <- result{err: s.transition(m.to), state: *s}
+	case inspectMsg:
+		m.fn(*s)
+		m.reply <- result{state: *s}
+	}
+}
+
+func replyTo(msg any) chan result {
+	switch m := msg.(type) {
+	case depositMsg:
+		return m.reply
+	case withdrawMsg:
+		return m.reply
+	case balanceMsg:
+		return m.reply
+	case statusMsg:
+		return m.reply
+	case inspectMsg:
+		return m.reply
+	}
+	panic(fmt.Sprintf("unknown message %T", msg))
+}
+
+// ask sends msg and waits for the reply. It gives up when ctx ends, or
+// returns ErrStopped if the actor has exited. A message already in the
+// mailbox when ctx ends is still processed; only the wait is abandoned.
+func (a *Actor) ask(ctx context.Context, msg any, reply chan result) (State, error) {
+	select {
+	case a.mailbox <- msg:
+	case <-a.done:
+		return State{}, ErrStopped
+	case <-ctx.Done():
+		return State{}, ctx.Err() // mailbox full: back-pressure
+	}
+	select {
+	case r := <-reply:
+		return r.state, r.err
+	case <-a.done:
+		// The last message before a give-up gets a reply and then done
+		// closes; select picks at random, so look for the reply first.
+		select {
+		case r := <-reply:
+			return r.state, r.err
+		default:
+			return State{}, ErrStopped
+		}
+	case <-ctx.Done():
+		return State{}, ctx.Err()
+	}
+}
+
+func (a *Actor) Deposit(ctx context.Context, amount int) error {
+	reply := make(chan result, 1)
+	_, err := a.ask(ctx, depositMsg{amount, reply}, reply)
+	return err
+}
+
+func (a *Actor) Withdraw(ctx context.Context, amount int) error {
+	reply := make(chan result, 1)
+	_, err := a.ask(ctx, withdrawMsg{amount, reply}, reply)
+	return err
+}
+
+func (a *Actor) State(ctx context.Context) (State, error) {
+	reply := make(chan result, 1)
+	return a.ask(ctx, balanceMsg{reply}, reply)
+}
+
+func (a *Actor) SetStatus(ctx context.Context, to Status) error {
+	reply := make(chan result, 1)
+	_, err := a.ask(ctx, statusMsg{to, reply}, reply)
+	return err
+}
+
+// Inspect runs fn with the current state, inside the actor.
+func (a *Actor) Inspect(ctx context.Context, fn func(State)) error {
+	reply := make(chan result, 1)
+	_, err := a.ask(ctx, inspectMsg{fn, reply}, reply)
+	return err
+}
+
+// Stop ends the actor after the message it is handling, and waits for it.
+// Messages still in the mailbox are dropped; their callers get ErrStopped.
+func (a *Actor) Stop() {
+	select {
+	case <-a.stop:
+	default:
+		close(a.stop)
+	}
+	<-a.done
+}
+
+// Done is closed when the actor has exited, after Stop or after too many
+// crashes.
+func (a *Actor) Done() <-chan struct{} { return a.done }
@@ -0,0 +1,216 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestMain(m *testing.M) {
+	log.SetOutput(io.Discard)
+	os.Exit(m.Run())
+}
+
+// account is what both implementations offer, without the context.
+type account interface {
+	Deposit(int) error
+	Withdraw(int) error
+	State() State
+	SetStatus(Status) error
+}
+
+type actorAccount struct{ *Actor }
+
+func (a actorAccount) Deposit(n int) error  { return a.Actor.Deposit(context.Background(), n) }
+func (a actorAccount) Withdraw(n int) error { return a.Actor.Withdraw(context.Background(), n) }
+func (a actorAccount) SetStatus(s Status) error {
+	return a.Actor.SetStatus(context.Background(), s)
+}
+func (a actorAccount) State() State {
+	st, _ := a.Actor.State(context.Background())
+	return st
+}
+
+func implementations(t *testing.T) map[string]account {
+	a := NewActor(Options{})
+	t.Cleanup(a.Stop)
+	return map[string]account{"actor": actorAccount{a}, "mutex": &MutexAccount{}}
+}
+
+func TestRules(t *testing.T) {
+	for name, acc := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			steps := []struct {
+				what    string
+				do      func() error
+				wantErr error
+				want    State
+			}{
+				{"deposit 0", func() error { return acc.Deposit(0) }, ErrInvalidAmount, State{0, Open}},
+				{"deposit 50", func() error { return acc.Deposit(50) }, nil, State{50, Open}},
+				{"overdraw", func() error { return acc.Withdraw(60) }, ErrInsufficientFunds, State{50, Open}},
+				{"freeze", func() error { return acc.SetStatus(Frozen) }, nil, State{50, Frozen}},
+				{"withdraw frozen", func() error { return acc.Withdraw(10) }, ErrFrozen, State{50, Frozen}},
+				{"close non-zero", func() error { return acc.SetStatus(Closed) }, ErrNonZeroBalance, State{50, Frozen}},
+				{"unfreeze", func() error { return acc.SetStatus(Open) }, nil, State{50, Open}},
+				{"withdraw all", func() error { return acc.Withdraw(50) }, nil, State{0, Open}},
+				{"freeze again", func() error { return acc.SetStatus(Frozen) }, nil, State{0, Frozen}},
+				{"close frozen", func() error { return acc.SetStatus(Closed) }, ErrBadTransition, State{0, Frozen}},
+				{"unfreeze again", func() error { return acc.SetStatus(Open) }, nil, State{0, Open}},
+				{"close", func() error { return acc.SetStatus(Closed) }, nil, State{0, Closed}},
+				{"deposit closed", func() error { return acc.Deposit(1) }, ErrClosed, State{0, Closed}},
+				{"reopen", func() error { return acc.SetStatus(Open) }, ErrClosed, State{0, Closed}},
+			}
+			for _, s := range steps {
+				if err := s.do(); !errors.Is(err, s.wantErr) {
+					t.Fatalf("%s: err = %v, want %v", s.what, err, s.wantErr)
+				}
+				if got := acc.State(); got != s.want {
+					t.Fatalf("%s: state = %+v, want %+v", s.what, got, s.want)
+				}
+			}
+		})
+	}
+}
+
+// TestConcurrent runs with -race: neither implementation may lose an update
+// or let the balance go negative.
+func TestConcurrent(t *testing.T) {
+	for name, acc := range implementations(t) {
+		t.Run(name, func(t *testing.T) {
+			var wg sync.WaitGroup
+			var mu sync.Mutex
+			withdrawn := 0
+			for range 20 {
+				wg.Add(2)
+				go func() {
+					defer wg.Done()
+					for range 50 {
+						if err := acc.Deposit(2); err != nil {
+							t.Error(err)
+						}
+					}
+				}()
+				go func() {
+					defer wg.Done()
+					for range 50 {
+						err := acc.Withdraw(3)
+						if err == nil {
+							mu.Lock()
+							withdrawn += 3
+							mu.Unlock()
+						} else if !errors.Is(err, ErrInsufficientFunds) {
+							t.Error(err)
+						}
+					}
+				}()
+			}
+			wg.Wait()
+			if got, want := acc.State().Balance, 20*50*2-withdrawn; got != want {
+				t.Fatalf("balance = %d, want %d", got, want)
+			}
+		})
+	}
+}
+
+func TestRestartKeepsState(t *testing.T) {
+	ctx := context.Background()
+	a := NewActor(Options{})
+	defer a.Stop()
+	a.Deposit(ctx, 100)
+
+	err := a.Inspect(ctx, func(State) { panic("boom") })
+	if !errors.Is(err, ErrCrashed) {
+		t.Fatalf("Inspect = %v, want ErrCrashed", err)
+	}
+	if err := a.Deposit(ctx, 5); err != nil {
+		t.Fatalf("Deposit after restart: %v", err)
+	}
+	if st, _ := a.State(ctx); st.Balance != 105 {
+		t.Fatalf("balance = %d, want 105", st.Balance)
+	}
+}
+
+func TestGivesUpAfterMaxRestarts(t *testing.T) {
+	ctx := context.Background()
+	a := NewActor(Options{MaxRestarts: 2, Window: time.Minute})
+	for i := range 3 {
+		if err := a.Inspect(ctx, func(State) { panic("boom") }); !errors.Is(err, ErrCrashed) {
+			t.Fatalf("crash %d: err = %v, want ErrCrashed", i+1, err)
+		}
+	}
+	select {
+	case <-a.Done():
+	case <-time.After(time.Second):
+		t.Fatal("actor still running after 3 crashes with MaxRestarts 2")
+	}
+	if err := a.Deposit(ctx, 1); !errors.Is(err, ErrStopped) {
+		t.Fatalf("Deposit = %v, want ErrStopped", err)
+	}
+	a.Stop() // must not block or panic on a stopped actor
+}
+
+func TestCrashesOutsideWindowAreForgotten(t *testing.T) {
+	ctx := context.Background()
+	a := NewActor(Options{MaxRestarts: 1, Window: 20 * time.Millisecond})
+	defer a.Stop()
+	for i := range 3 {
+		if err := a.Inspect(ctx, func(State) { panic("boom") }); !errors.Is(err, ErrCrashed) {
+			t.Fatalf("crash %d: err = %v", i+1, err)
+		}
+		time.Sleep(40 * time.Millisecond)
+	}
+	if err := a.Deposit(ctx, 1); err != nil {
+		t.Fatalf("Deposit = %v, want the actor still running", err)
+	}
+}
+
+func TestContextWhileMailboxFull(t *testing.T) {
+	a := NewActor(Options{Mailbox: 1})
+	defer a.Stop()
+
+	// Park the actor inside a message, then fill the one-slot mailbox.
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go a.Inspect(context.Background(), func(State) { close(started); <-release })
+	<-started
+	go a.Deposit(context.Background(), 1)
+	for len(a.mailbox) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := a.Deposit(ctx, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("Deposit = %v, want DeadlineExceeded", err)
+	}
+	close(release)
+	st, err := a.State(context.Background())
+	if err != nil || st.Balance != 1 {
+		t.Fatalf("State = %+v, %v; want balance 1 (the timed-out deposit never got in)", st, err)
+	}
+}
+
+func TestStopFailsPendingCallers(t *testing.T) {
+	a := NewActor(Options{})
+	release := make(chan struct{})
+	started := make(chan struct{})
+	go a.Inspect(context.Background(), func(State) { close(started); <-release })
+	<-started
+
+	errc := make(chan error)
+	go func() { errc <- a.Deposit(context.Background(), 1) }()
+	for len(a.mailbox) == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	go func() { time.Sleep(10 * time.Millisecond); close(release) }()
+	a.Stop()
+	if err := <-errc; !errors.Is(err, ErrStopped) {
+		t.Fatalf("pending Deposit = %v, want ErrStopped", err)
+	}
+}
@@ -0,0 +1,88 @@
+// Demonstrates an account actor next to the same account behind a mutex.
+//
+// This example shows:
+// - One goroutine owning the state, fed typed messages through a mailbox
+// - Request/response with a reply channel per message and a context
+// - An Open/Frozen/Closed state machine enforced inside the actor
+// - A supervisor that restarts the actor after a panic and rolls back the message
+// - Giving up after too many crashes, so callers get ErrStopped
+// - The mutex version of the same account, for comparison
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"sync"
+	"time"
+)
+
+func main() {
+	log.SetFlags(0)
+	ctx := context.Background()
+
+	fmt.Println("=== 100 goroutines, 10 deposits of 5 and 10 withdrawals of 3 each ===")
+	actor := NewActor(Options{})
+	defer actor.Stop()
+	var mu MutexAccount
+	var wg sync.WaitGroup
+	for range 100 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for range 10 {
+				actor.Deposit(ctx, 5)
+				mu.Deposit(5)
+				actor.Withdraw(ctx, 3)
+				mu.Withdraw(3)
+			}
+		}()
+	}
+	wg.Wait()
+	st, _ := actor.State(ctx)
+	fmt.Printf("  actor balance: %d, mutex balance: %d (want %d)\n", st.Balance, mu.State().Balance, 100*10*2)
+
+	fmt.Println("\n=== State machine ===")
+	acc := NewActor(Options{})
+	defer acc.Stop()
+	step := func(what string, err error) {
+		st, _ := acc.State(ctx)
+		fmt.Printf("  %-18s err=%v  -> %s, balance %d\n", what, err, st.Status, st.Balance)
+	}
+	step("deposit 50", acc.Deposit(ctx, 50))
+	step("freeze", acc.SetStatus(ctx, Frozen))
+	step("withdraw 10", acc.Withdraw(ctx, 10))
+	step("close", acc.SetStatus(ctx, Closed))
+	step("unfreeze", acc.SetStatus(ctx, Open))
+	step("close", acc.SetStatus(ctx, Closed))
+	step("withdraw 50", acc.Withdraw(ctx, 50))
+	step("close", acc.SetStatus(ctx, Closed))
+	step("deposit 1", acc.Deposit(ctx, 1))
+
+	fmt.Println("\n=== A panic inside the actor ===")
+	sup := NewActor(Options{MaxRestarts: 2, Window: time.Minute})
+	sup.Deposit(ctx, 100)
+	err := sup.Inspect(ctx, func(State) { panic("report generator bug") })
+	fmt.Printf("  inspect: %v\n", err)
+	st, err = sup.State(ctx)
+	fmt.Printf("  after restart: balance %d, err %v\n", st.Balance, err)
+
+	fmt.Println("\n=== Too many crashes ===")
+	for i := range 2 {
+		err := sup.Inspect(ctx, func(State) { panic(fmt.Sprint("crash ", i+2)) })
+		fmt.Printf("  inspect: %v\n", err)
+	}
+	<-sup.Done()
+	err = sup.Deposit(ctx, 1)
+	fmt.Printf("  deposit after giving up: %v (ErrStopped: %v)\n", err, errors.Is(err, ErrStopped))
+
+	fmt.Println("\n=== The same panic with a mutex ===")
+	var m MutexAccount
+	m.Deposit(100)
+	func() {
+		defer func() { fmt.Printf("  recovered by the caller: %v\n", recover()) }()
+		m.Inspect(func(State) { panic("report generator bug") })
+	}()
+	fmt.Printf("  still usable thanks to defer Unlock: deposit err=%v, balance %d\n", m.Deposit(1), m.State().Balance)
+}
@@ -0,0 +1,7 @@
+module golang_roadmap/13_concurrency/07_netscan
+
+go 1.24.11
+
+require golang.org/x/net v0.47.0
+
+require golang.org/x/sys v0.38.0 // indirect
@@ -0,0 +1,189 @@
+// Demonstrates network diagnostics as a concurrency exercise.
+//
+// This example shows:
+// - A TCP port scanner: a fixed pool of workers, a per-dial timeout, sorted results
+// - Telling open, closed, filtered and unreachable ports apart by the dial error
+// - Ping by ICMP echo when the process may open an ICMP socket, by TCP connect when not
+// - A traceroute that raises the TTL one hop at a time over a raw socket
+// - Cancelling a scan with Ctrl-C and keeping the partial result
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"golang_roadmap/13_concurrency/07_netscan/netscan"
+)
+
+const usage = `usage:
+  netscan                                   demo against ports opened on 127.0.0.1
+  netscan scan [-p ports] [-c n] [-t d] [-all] host
+  netscan ping [-p port] host
+  netscan trace [-max n] [-t d] host`
+
+func main() {
+	log.SetFlags(0)
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if len(os.Args) < 2 {
+		demo(ctx)
+		return
+	}
+	var err error
+	switch cmd, args := os.Args[1], os.Args[2:]; cmd {
+	case "scan":
+		err = scanCmd(ctx, args)
+	case "ping":
+		err = pingCmd(ctx, args)
+	case "trace":
+		err = traceCmd(ctx, args)
+	default:
+		log.Fatal(usage)
+	}
+	if err != nil {
+		log.Fatalf("netscan %s: %v", os.Args[1], err)
+	}
+}
+
+func scanCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("scan", flag.ExitOnError)
+	ports := fs.String("p", "1-1024", "ports: a list and ranges, such as 22,80,8000-8100")
+	conc := fs.Int("c", 100, "connects in flight at once")
+	timeout := fs.Duration("t", time.Second, "timeout per connect")
+	all := fs.Bool("all", false, "list closed and filtered ports too")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("want one host")
+	}
+	list, err := netscan.ParsePorts(*ports)
+	if err != nil {
+		return err
+	}
+	return scan(ctx, fs.Arg(0), list, netscan.Options{Concurrency: *conc, Timeout: *timeout}, *all)
+}
+
+func scan(ctx context.Context, host string, ports []int, opts netscan.Options, all bool) error {
+	start := time.Now()
+	results, err := netscan.Scan(ctx, host, ports, opts)
+	counts := map[netscan.State]int{}
+	for _, r := range results {
+		counts[r.State]++
+		if r.State == netscan.Open || all {
+			fmt.Printf("  %5d/tcp  %-11v %v\n", r.Port, r.State, r.Latency.Round(time.Microsecond))
+		}
+	}
+	fmt.Printf("  %d of %d ports in %v: %d open, %d closed, %d filtered, %d unreachable\n",
+		len(results), len(ports), time.Since(start).Round(time.Millisecond),
+		counts[netscan.Open], counts[netscan.Closed], counts[netscan.Filtered], counts[netscan.Unreachable])
+	if errors.Is(err, context.Canceled) {
+		fmt.Println("  interrupted: the list above is partial")
+		return nil
+	}
+	return err
+}
+
+func pingCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("ping", flag.ExitOnError)
+	port := fs.Int("p", 80, "TCP port to try if ICMP is not allowed")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("want one host")
+	}
+	return ping(ctx, fs.Arg(0), *port)
+}
+
+func ping(ctx context.Context, host string, port int) error {
+	for i := range 3 {
+		if i > 0 {
+			time.Sleep(time.Second)
+		}
+		ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+		res, err := netscan.Ping(ctx, host, port)
+		cancel()
+		if err != nil {
+			fmt.Printf("  %s: %v\n", host, err)
+			continue
+		}
+		fmt.Printf("  %s (%s) answered by %s in %v\n", host, res.Addr, res.Method, res.RTT.Round(time.Microsecond))
+	}
+	return nil
+}
+
+func traceCmd(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("trace", flag.ExitOnError)
+	maxHops := fs.Int("max", 30, "most hops to try")
+	timeout := fs.Duration("t", time.Second, "wait per hop")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		return errors.New("want one host")
+	}
+	return trace(ctx, fs.Arg(0), netscan.TraceOptions{MaxHops: *maxHops, Timeout: *timeout})
+}
+
+func trace(ctx context.Context, host string, opts netscan.TraceOptions) error {
+	hops, err := netscan.Trace(ctx, host, opts)
+	for _, h := range hops {
+		if h.Addr == nil {
+			fmt.Printf("  %2d  *\n", h.TTL)
+			continue
+		}
+		fmt.Printf("  %2d  %-15s %v\n", h.TTL, h.Addr, h.RTT.Round(time.Microsecond))
+	}
+	if errors.Is(err, netscan.ErrPermission) {
+		fmt.Println("  traceroute needs a raw socket: run as root, or grant CAP_NET_RAW with setcap")
+		return nil
+	}
+	return err
+}
+
+// demo opens a few listeners on loopback and finds them among their
+// neighbours, then pings and traces loopback.
+func demo(ctx context.Context) {
+	var open []int
+	for range 3 {
+		ln, err := net.Listen("tcp", "127.0.0.1:0")
+		if err != nil {
+			log.Fatal(err)
+		}
+		defer ln.Close()
+		go func() {
+			for {
+				c, err := ln.Accept()
+				if err != nil {
+					return
+				}
+				c.Close()
+			}
+		}()
+		open = append(open, ln.Addr().(*net.TCPAddr).Port)
+	}
+	// Each listener and the 10 ports either side of it.
+	var ranges []string
+	for _, p := range open {
+		ranges = append(ranges, fmt.Sprintf("%d-%d", max(p-10, 1), min(p+10, 65535)))
+	}
+	ports, err := netscan.ParsePorts(strings.Join(ranges, ","))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	fmt.Printf("Listening on %v. Scanning %d ports of 127.0.0.1, 20 at a time:\n", open, len(ports))
+	if err := scan(ctx, "127.0.0.1", ports, netscan.Options{Concurrency: 20, Timeout: 200 * time.Millisecond}, false); err != nil {
+		log.Fatal(err)
+	}
+	fmt.Println("\nPing:")
+	ping(ctx, "localhost", open[0])
+	fmt.Println("\nTrace:")
+	if err := trace(ctx, "127.0.0.1", netscan.TraceOptions{MaxHops: 5}); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,242 @@
+package netscan
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"net"
+	"reflect"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"golang.org/x/net/ipv4"
+)
+
+// listen returns the port of a local listener that accepts and closes
+// every connection, until the test ends.
+func listen(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		for {
+			c, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			c.Close()
+		}
+	}()
+	return ln.Addr().(*net.TCPAddr).Port
+}
+
+// closedPort returns a port that was free a moment ago.
+func closedPort(t *testing.T) int {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	port := ln.Addr().(*net.TCPAddr).Port
+	ln.Close()
+	return port
+}
+
+func TestScan_Loopback(t *testing.T) {
+	open1, open2, closed := listen(t), listen(t), closedPort(t)
+	got, err := Scan(context.Background(), "127.0.0.1", []int{open1, closed, open2}, Options{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[int]State{open1: Open, open2: Open, closed: Closed}
+	if len(got) != 3 {
+		t.Fatalf("got %d results; want 3: %v", len(got), got)
+	}
+	for i, r := range got {
+		if i > 0 && got[i-1].Port >= r.Port {
+			t.Errorf("results not sorted by port: %v", got)
+		}
+		if r.State != want[r.Port] {
+			t.Errorf("port %d: %v (%v); want %v", r.Port, r.State, r.Err, want[r.Port])
+		}
+	}
+}
+
+func TestScan_Concurrency(t *testing.T) {
+	var inFlight, peak atomic.Int32
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		n := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(5 * time.Millisecond)
+		return nil, syscall.ECONNREFUSED
+	}
+	ports := make([]int, 100)
+	for i := range ports {
+		ports[i] = i + 1
+	}
+	got, err := Scan(context.Background(), "example.invalid", ports, Options{Concurrency: 7, Dial: dial})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(got) != len(ports) {
+		t.Errorf("got %d results; want %d", len(got), len(ports))
+	}
+	if p := peak.Load(); p != 7 {
+		t.Errorf("peak concurrency = %d; want 7", p)
+	}
+}
+
+func TestScan_TimeoutIsFiltered(t *testing.T) {
+	// A dropped SYN: the dial blocks until its context ends.
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		<-ctx.Done()
+		return nil, &net.OpError{Op: "dial", Net: network, Err: ctx.Err()}
+	}
+	start := time.Now()
+	got, err := Scan(context.Background(), "example.invalid", []int{1, 2, 3}, Options{Timeout: 20 * time.Millisecond, Dial: dial})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, r := range got {
+		if r.State != Filtered {
+			t.Errorf("port %d: %v; want filtered", r.Port, r.State)
+		}
+	}
+	if d := time.Since(start); d > time.Second {
+		t.Errorf("scan took %v; the per-dial timeout was 20ms", d)
+	}
+}
+
+func TestScan_Cancel(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	var dials atomic.Int32
+	dial := func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if dials.Add(1) == 10 {
+			cancel()
+		}
+		return nil, syscall.ECONNREFUSED
+	}
+	ports := make([]int, 1000)
+	for i := range ports {
+		ports[i] = i + 1
+	}
+	got, err := Scan(ctx, "example.invalid", ports, Options{Concurrency: 2, Dial: dial})
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("err = %v; want context.Canceled", err)
+	}
+	if len(got) == 0 || len(got) >= len(ports) {
+		t.Errorf("got %d results after cancel; want a partial scan", len(got))
+	}
+}
+
+func TestClassify(t *testing.T) {
+	for _, tc := range []struct {
+		err  error
+		want State
+	}{
+		{&net.OpError{Op: "dial", Err: syscall.ECONNREFUSED}, Closed},
+		{context.DeadlineExceeded, Filtered},
+		{&net.OpError{Op: "dial", Err: syscall.EHOSTUNREACH}, Unreachable},
+		{&net.OpError{Op: "dial", Err: syscall.ENETUNREACH}, Unreachable},
+	} {
+		if got := classify(tc.err); got != tc.want {
+			t.Errorf("classify(%v) = %v; want %v", tc.err, got, tc.want)
+		}
+	}
+}
+
+func TestParsePorts(t *testing.T) {
+	got, err := ParsePorts("443, 80,20-22,80")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []int{20, 21, 22, 80, 443}; !reflect.DeepEqual(got, want) {
+		t.Errorf("ParsePorts = %v; want %v", got, want)
+	}
+	for _, bad := range []string{"", "0", "65536", "http", "90-80", "1-x"} {
+		if _, err := ParsePorts(bad); err == nil {
+			t.Errorf("ParsePorts(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestPingTCP(t *testing.T) {
+	ctx := context.Background()
+	if _, err := PingTCP(ctx, "127.0.0.1", listen(t)); err != nil {
+		t.Errorf("open port: %v", err)
+	}
+	if _, err := PingTCP(ctx, "127.0.0.1", closedPort(t)); err != nil {
+		t.Errorf("closed port: %v; a refusal proves the host is up", err)
+	}
+}
+
+func TestPingICMP(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	rtt, err := PingICMP(ctx, "127.0.0.1")
+	if errors.Is(err, ErrPermission) {
+		t.Skip(err)
+	}
+	if err != nil || rtt <= 0 {
+		t.Fatalf("PingICMP(127.0.0.1) = %v, %v", rtt, err)
+	}
+}
+
+func TestPing_FallsBack(t *testing.T) {
+	res, err := Ping(context.Background(), "127.0.0.1", listen(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Either way, loopback is up; which method depends on permissions.
+	if res.Method != ICMP && res.Method != TCP || !res.Addr.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("Ping = %+v", res)
+	}
+}
+
+func TestTrace_Loopback(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+	hops, err := Trace(ctx, "127.0.0.1", TraceOptions{MaxHops: 3})
+	if errors.Is(err, ErrPermission) {
+		t.Skip(err)
+	}
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Loopback is zero routers away: the first echo reaches it.
+	if len(hops) != 1 || !hops[0].Last || !hops[0].Addr.Equal(net.IPv4(127, 0, 0, 1)) {
+		t.Errorf("hops = %+v; want the destination at TTL 1", hops)
+	}
+}
+
+func TestQuotes(t *testing.T) {
+	e := echo{id: 0x1234, seq: 7}
+	// What a router sends back: the dropped packet's IPv4 header, then the
+	// start of our echo request.
+	data := make([]byte, ipv4.HeaderLen+8)
+	data[0] = 0x45 // version 4, 5 words of header
+	inner := data[ipv4.HeaderLen:]
+	inner[0] = byte(ipv4.ICMPTypeEcho)
+	binary.BigEndian.PutUint16(inner[4:], 0x1234)
+	binary.BigEndian.PutUint16(inner[6:], 7)
+	if !quotes(data, e) {
+		t.Error("quotes = false for our own echo")
+	}
+	if quotes(data, echo{id: 0x1234, seq: 8}) {
+		t.Error("quotes = true for another sequence number")
+	}
+	if quotes(data[:ipv4.HeaderLen+4], e) {
+		t.Error("quotes = true for a truncated payload")
+	}
+}
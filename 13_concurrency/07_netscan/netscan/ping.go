@@ -0,0 +1,216 @@
+package netscan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"net"
+	"os"
+	"strconv"
+	"syscall"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// ErrPermission is returned when the process may not open an ICMP socket.
+// Linux allows unprivileged ICMP echo to the groups in
+// net.ipv4.ping_group_range; otherwise it takes root or CAP_NET_RAW.
+var ErrPermission = errors.New("netscan: no permission for ICMP sockets")
+
+// defaultWait bounds an ICMP exchange whose ctx has no deadline.
+const defaultWait = 2 * time.Second
+
+// Method is how Ping got its answer.
+type Method string
+
+const (
+	ICMP Method = "icmp" // echo request and reply
+	TCP  Method = "tcp"  // a connect, accepted or refused
+)
+
+// PingResult is the outcome of a successful Ping.
+type PingResult struct {
+	Method Method
+	Addr   net.IP
+	RTT    time.Duration
+}
+
+// Ping checks that host is up. It sends an ICMP echo if the process is
+// allowed to; if not, it connects to tcpPort instead, where a refused
+// connection counts too: only a live host sends the reset. Neither is
+// proof that a host is down: many firewalls drop ICMP, and most drop SYNs
+// to ports they do not serve.
+func Ping(ctx context.Context, host string, tcpPort int) (PingResult, error) {
+	ip, err := lookup4(ctx, host)
+	if err != nil {
+		return PingResult{}, err
+	}
+	rtt, err := PingICMP(ctx, ip.String())
+	if err == nil {
+		return PingResult{Method: ICMP, Addr: ip, RTT: rtt}, nil
+	}
+	if !errors.Is(err, ErrPermission) {
+		return PingResult{}, err
+	}
+	rtt, err = PingTCP(ctx, ip.String(), tcpPort)
+	if err != nil {
+		return PingResult{}, err
+	}
+	return PingResult{Method: TCP, Addr: ip, RTT: rtt}, nil
+}
+
+// PingTCP connects to host:port and returns how long the host took to
+// accept or refuse the connection.
+func PingTCP(ctx context.Context, host string, port int) (time.Duration, error) {
+	if _, ok := ctx.Deadline(); !ok {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, defaultWait)
+		defer cancel()
+	}
+	var d net.Dialer
+	start := time.Now()
+	conn, err := d.DialContext(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	rtt := time.Since(start)
+	switch {
+	case err == nil:
+		conn.Close()
+	case errors.Is(err, syscall.ECONNREFUSED):
+		// Refused is an answer.
+	default:
+		return 0, err
+	}
+	return rtt, nil
+}
+
+// PingICMP sends one ICMP echo request to host and waits for the reply.
+func PingICMP(ctx context.Context, host string) (time.Duration, error) {
+	ip, err := lookup4(ctx, host)
+	if err != nil {
+		return 0, err
+	}
+	c, raw, err := listenICMP()
+	if err != nil {
+		return 0, err
+	}
+	defer c.Close()
+	stop := deadlineFrom(ctx, c)
+	defer stop()
+
+	e := newEcho()
+	start := time.Now()
+	if _, err := c.WriteTo(e.marshal(), dest(ip, raw)); err != nil {
+		return 0, fmt.Errorf("netscan: sending echo to %s: %w", ip, err)
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := c.ReadFrom(buf)
+		if err != nil {
+			return 0, readErr(ctx, err)
+		}
+		m, err := icmp.ParseMessage(ipv4.ICMPTypeEcho.Protocol(), buf[:n])
+		if err != nil || m.Type != ipv4.ICMPTypeEchoReply || !sameIP(peer, ip) {
+			continue
+		}
+		// A raw socket sees every ICMP message to this host; an unprivileged
+		// one only its own, but with the ID rewritten by the kernel.
+		if reply, ok := m.Body.(*icmp.Echo); ok && reply.Seq == e.seq && (!raw || reply.ID == e.id) {
+			return time.Since(start), nil
+		}
+	}
+}
+
+// listenICMP opens an ICMP socket: an unprivileged "ping" socket if the
+// system allows one, a raw socket otherwise. raw reports which.
+func listenICMP() (c *icmp.PacketConn, raw bool, err error) {
+	if c, err := icmp.ListenPacket("udp4", "0.0.0.0"); err == nil {
+		return c, false, nil
+	}
+	return listenRaw()
+}
+
+func listenRaw() (*icmp.PacketConn, bool, error) {
+	c, err := icmp.ListenPacket("ip4:icmp", "0.0.0.0")
+	if err != nil {
+		if errors.Is(err, os.ErrPermission) || errors.Is(err, syscall.EPERM) {
+			return nil, false, fmt.Errorf("%w: %v", ErrPermission, err)
+		}
+		return nil, false, err
+	}
+	return c, true, nil
+}
+
+// echo is one request: its identifier and sequence number tell its reply
+// apart from others.
+type echo struct {
+	id, seq int
+}
+
+func newEcho() echo {
+	return echo{id: os.Getpid() & 0xffff, seq: rand.IntN(1 << 16)}
+}
+
+func (e echo) marshal() []byte {
+	m := icmp.Message{
+		Type: ipv4.ICMPTypeEcho,
+		Body: &icmp.Echo{ID: e.id, Seq: e.seq, Data: []byte("netscan")},
+	}
+	b, _ := m.Marshal(nil) // only fails for a nil body
+	return b
+}
+
+func dest(ip net.IP, raw bool) net.Addr {
+	if raw {
+		return &net.IPAddr{IP: ip}
+	}
+	return &net.UDPAddr{IP: ip}
+}
+
+func sameIP(addr net.Addr, ip net.IP) bool {
+	switch a := addr.(type) {
+	case *net.IPAddr:
+		return a.IP.Equal(ip)
+	case *net.UDPAddr:
+		return a.IP.Equal(ip)
+	}
+	return false
+}
+
+func lookup4(ctx context.Context, host string) (net.IP, error) {
+	if ip := net.ParseIP(host); ip != nil {
+		if ip4 := ip.To4(); ip4 != nil {
+			return ip4, nil
+		}
+		return nil, fmt.Errorf("netscan: %s is not IPv4", host)
+	}
+	ips, err := net.DefaultResolver.LookupIP(ctx, "ip4", host)
+	if err != nil {
+		return nil, err
+	}
+	return ips[0], nil
+}
+
+// deadlineFrom makes reads on c end at ctx's deadline, or after
+// defaultWait, and as soon as ctx is cancelled. stop undoes the
+// cancellation hook.
+func deadlineFrom(ctx context.Context, c *icmp.PacketConn) (stop func() bool) {
+	deadline, ok := ctx.Deadline()
+	if !ok {
+		deadline = time.Now().Add(defaultWait)
+	}
+	c.SetDeadline(deadline)
+	return context.AfterFunc(ctx, func() { c.SetDeadline(time.Now()) })
+}
+
+// readErr turns the timeout of a read cut short by ctx into ctx's error.
+func readErr(ctx context.Context, err error) error {
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return fmt.Errorf("netscan: no reply: %w", err)
+	}
+	return err
+}
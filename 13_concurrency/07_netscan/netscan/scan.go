@@ -0,0 +1,201 @@
+// Package netscan holds small network diagnostics: a concurrent TCP port
+// scanner, a reachability check that uses ICMP echo when the process may
+// and a TCP connect when it may not, and a traceroute over ICMP with
+// increasing TTLs.
+//
+// Scan only hosts you are allowed to. A scan of someone else's network
+// looks like the first step of an attack, because it usually is.
+package netscan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// State is what a TCP connect says about a port.
+type State int
+
+const (
+	// Open: the connect succeeded, something is listening.
+	Open State = iota
+	// Closed: the host answered with a reset. It is up, but nothing listens.
+	Closed
+	// Filtered: no answer before the timeout. A firewall dropped the SYN,
+	// or the host is down.
+	Filtered
+	// Unreachable: the network said no, with an ICMP error or no route.
+	Unreachable
+)
+
+func (s State) String() string {
+	switch s {
+	case Open:
+		return "open"
+	case Closed:
+		return "closed"
+	case Filtered:
+		return "filtered"
+	case Unreachable:
+		return "unreachable"
+	}
+	return "State(" + strconv.Itoa(int(s)) + ")"
+}
+
+// DialFunc is the signature of net.Dialer.DialContext.
+type DialFunc func(ctx context.Context, network, addr string) (net.Conn, error)
+
+// Options control a scan. The zero value scans 100 ports at a time with a
+// one-second timeout per connect.
+type Options struct {
+	Concurrency int           // connects in flight at once; default 100
+	Timeout     time.Duration // per connect; default 1s
+	Dial        DialFunc      // default (&net.Dialer{}).DialContext; set in tests
+}
+
+// Result is one port's outcome.
+type Result struct {
+	Port    int
+	State   State
+	Latency time.Duration // time to connect, or to be refused
+	Err     error         // the dial error for anything not Open
+}
+
+// Scan connects to each port of host and classifies it, at most
+// Options.Concurrency at a time. Results come back sorted by port. When ctx
+// ends, ports not yet tried are left out, so a cancelled scan returns a
+// partial, still sorted, list together with ctx's error.
+func Scan(ctx context.Context, host string, ports []int, opts Options) ([]Result, error) {
+	workers := opts.Concurrency
+	if workers <= 0 {
+		workers = 100
+	}
+	workers = min(workers, len(ports))
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	dial := opts.Dial
+	if dial == nil {
+		dial = (&net.Dialer{}).DialContext
+	}
+
+	jobs := make(chan int)
+	results := make(chan Result)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for port := range jobs {
+				results <- probe(ctx, dial, host, port, timeout)
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for _, port := range ports {
+			select {
+			case jobs <- port:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var out []Result
+	for r := range results {
+		// A probe cut short by cancel says nothing about the port.
+		if ctx.Err() != nil && errors.Is(r.Err, ctx.Err()) {
+			continue
+		}
+		out = append(out, r)
+	}
+	slices.SortFunc(out, func(a, b Result) int { return a.Port - b.Port })
+	return out, ctx.Err()
+}
+
+func probe(ctx context.Context, dial DialFunc, host string, port int, timeout time.Duration) Result {
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	start := time.Now()
+	conn, err := dial(ctx, "tcp", net.JoinHostPort(host, strconv.Itoa(port)))
+	r := Result{Port: port, Latency: time.Since(start), Err: err}
+	if err == nil {
+		conn.Close()
+		return r
+	}
+	r.State = classify(err)
+	return r
+}
+
+// classify maps a dial error to a port state.
+func classify(err error) State {
+	switch {
+	case errors.Is(err, syscall.ECONNREFUSED):
+		return Closed
+	case errors.Is(err, context.DeadlineExceeded), errors.Is(err, os.ErrDeadlineExceeded):
+		return Filtered
+	case errors.Is(err, syscall.EHOSTUNREACH), errors.Is(err, syscall.ENETUNREACH):
+		return Unreachable
+	}
+	var ne net.Error
+	if errors.As(err, &ne) && ne.Timeout() {
+		return Filtered
+	}
+	return Unreachable
+}
+
+// ParsePorts parses a list such as "22,80,443,8000-8100" into sorted,
+// distinct port numbers.
+func ParsePorts(s string) ([]int, error) {
+	var ports []int
+	for _, part := range strings.Split(s, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		lo, hi, isRange := strings.Cut(part, "-")
+		from, err := parsePort(lo)
+		if err != nil {
+			return nil, err
+		}
+		to := from
+		if isRange {
+			if to, err = parsePort(hi); err != nil {
+				return nil, err
+			}
+			if to < from {
+				return nil, fmt.Errorf("netscan: port range %q runs backwards", part)
+			}
+		}
+		for p := from; p <= to; p++ {
+			ports = append(ports, p)
+		}
+	}
+	if len(ports) == 0 {
+		return nil, errors.New("netscan: no ports")
+	}
+	slices.Sort(ports)
+	return slices.Compact(ports), nil
+}
+
+func parsePort(s string) (int, error) {
+	p, err := strconv.Atoi(strings.TrimSpace(s))
+	if err != nil || p < 1 || p > 65535 {
+		return 0, fmt.Errorf("netscan: bad port %q; want 1 to 65535", s)
+	}
+	return p, nil
+}
@@ -0,0 +1,137 @@
+package netscan
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"net"
+	"time"
+
+	"golang.org/x/net/icmp"
+	"golang.org/x/net/ipv4"
+)
+
+// Hop is one step of a route.
+type Hop struct {
+	TTL  int
+	Addr net.IP        // who answered; nil if nobody did in time
+	RTT  time.Duration // zero if nobody answered
+	Last bool          // the destination itself answered
+}
+
+// TraceOptions control Trace. The zero value tries up to 30 hops and
+// waits a second for each.
+type TraceOptions struct {
+	MaxHops int           // default 30
+	Timeout time.Duration // per hop; default 1s
+}
+
+// Trace finds the routers between this host and host, the way traceroute
+// does: it sends ICMP echo requests with TTL 1, 2, 3, ... Each router that
+// decrements the TTL to zero drops the packet and answers "time exceeded"
+// from its own address; the destination answers with an echo reply.
+//
+// Trace needs a raw socket, so root or CAP_NET_RAW: an unprivileged ICMP
+// socket does not receive the time-exceeded messages. Without one it
+// returns ErrPermission.
+func Trace(ctx context.Context, host string, opts TraceOptions) ([]Hop, error) {
+	maxHops := opts.MaxHops
+	if maxHops <= 0 {
+		maxHops = 30
+	}
+	timeout := opts.Timeout
+	if timeout <= 0 {
+		timeout = time.Second
+	}
+	ip, err := lookup4(ctx, host)
+	if err != nil {
+		return nil, err
+	}
+	c, _, err := listenRaw()
+	if err != nil {
+		return nil, err
+	}
+	defer c.Close()
+
+	var hops []Hop
+	for ttl := 1; ttl <= maxHops; ttl++ {
+		hop, err := traceHop(ctx, c, ip, ttl, timeout)
+		if err != nil {
+			return hops, err
+		}
+		hops = append(hops, hop)
+		if hop.Last {
+			return hops, nil
+		}
+	}
+	return hops, nil
+}
+
+// traceHop sends one echo with the given TTL and waits for whoever
+// answers it. A silent hop is not an error: many routers do not answer.
+func traceHop(ctx context.Context, c *icmp.PacketConn, ip net.IP, ttl int, timeout time.Duration) (Hop, error) {
+	hop := Hop{TTL: ttl}
+	if err := c.IPv4PacketConn().SetTTL(ttl); err != nil {
+		return hop, fmt.Errorf("netscan: setting TTL %d: %w", ttl, err)
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	stop := deadlineFrom(ctx, c)
+	defer stop()
+
+	e := newEcho()
+	start := time.Now()
+	if _, err := c.WriteTo(e.marshal(), &net.IPAddr{IP: ip}); err != nil {
+		return hop, fmt.Errorf("netscan: sending echo to %s: %w", ip, err)
+	}
+	buf := make([]byte, 1500)
+	for {
+		n, peer, err := c.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() == context.DeadlineExceeded {
+				return hop, nil // silent hop
+			}
+			return hop, readErr(ctx, err)
+		}
+		m, err := icmp.ParseMessage(ipv4.ICMPTypeEcho.Protocol(), buf[:n])
+		if err != nil {
+			continue
+		}
+		from := peer.(*net.IPAddr).IP
+		switch body := m.Body.(type) {
+		case *icmp.Echo:
+			if m.Type == ipv4.ICMPTypeEchoReply && from.Equal(ip) && body.ID == e.id && body.Seq == e.seq {
+				hop.Addr, hop.RTT, hop.Last = from, time.Since(start), true
+				return hop, nil
+			}
+		case *icmp.TimeExceeded:
+			if quotes(body.Data, e) {
+				hop.Addr, hop.RTT = from, time.Since(start)
+				return hop, nil
+			}
+		case *icmp.DstUnreach:
+			if quotes(body.Data, e) {
+				hop.Addr, hop.RTT, hop.Last = from, time.Since(start), true
+				return hop, nil
+			}
+		}
+	}
+}
+
+// quotes reports whether an ICMP error's payload is our echo request. The
+// payload is the IPv4 header of the dropped packet and at least the first
+// 8 bytes of what it carried: for an echo, type, code, checksum, ID and
+// sequence.
+func quotes(data []byte, e echo) bool {
+	if len(data) < ipv4.HeaderLen {
+		return false
+	}
+	hlen := int(data[0]&0x0f) * 4
+	if len(data) < hlen+8 {
+		return false
+	}
+	inner := data[hlen:]
+	return inner[0] == byte(ipv4.ICMPTypeEcho) &&
+		int(binary.BigEndian.Uint16(inner[4:6])) == e.id &&
+		int(binary.BigEndian.Uint16(inner[6:8])) == e.seq
+}
@@ -0,0 +1,261 @@
+// Package crawl fetches a site breadth-first from a seed URL, politely: it
+// obeys robots.txt, waits between requests to the same host, and stops at
+// a depth, a page count or a cancelled context.
+package crawl
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"sync"
+	"time"
+)
+
+// ErrDisallowed is the error of a Page that robots.txt kept the crawler
+// from fetching.
+var ErrDisallowed = errors.New("crawl: disallowed by robots.txt")
+
+// maxBody is the most of a page that is read for links.
+const maxBody = 5 << 20
+
+// Config controls a crawl. The zero value follows links to any depth on
+// the seed's host, with 4 workers and a second between requests to a host.
+type Config struct {
+	MaxDepth  int           // links followed from the seed; 0 for no limit
+	MaxPages  int           // pages fetched or skipped; 0 for no limit
+	Workers   int           // requests in flight across all hosts; default 4
+	Delay     time.Duration // least time between requests to one host; default 1s
+	AllHosts  bool          // follow links to other hosts too
+	UserAgent string        // sent, and matched against robots.txt; default "roadmap-crawler/1.0"
+	Client    *http.Client  // default http.DefaultClient
+}
+
+// Page is what the crawler learned about one URL.
+type Page struct {
+	URL    string
+	Depth  int   // links from the seed
+	Status int   // 0 if no response
+	Links  int   // links on the page, before deduplication
+	Err    error // ErrDisallowed, a transport error, or nil
+}
+
+// Crawl fetches seed and the pages it links to, breadth-first, and calls
+// visit for each one from a single goroutine, in the order they finish.
+// Each URL is fetched at most once.
+//
+// Cancelling ctx stops new requests and aborts those in flight, which are
+// not reported; Crawl then returns ctx's error once every worker has
+// stopped.
+func Crawl(ctx context.Context, seed string, cfg Config, visit func(Page)) error {
+	u, err := url.Parse(seed)
+	if err != nil {
+		return err
+	}
+	start := normalize(u)
+	if start == nil {
+		return fmt.Errorf("crawl: seed %q is not an http or https URL", seed)
+	}
+	c := newCrawler(cfg)
+
+	type job struct {
+		u     *url.URL
+		depth int
+	}
+	type done struct {
+		page  Page
+		links []*url.URL
+	}
+	jobs := make(chan job)
+	results := make(chan done)
+	var wg sync.WaitGroup
+	for range c.cfg.Workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				page, links := c.fetch(ctx, j.u)
+				page.Depth = j.depth
+				results <- done{page, links}
+			}
+		}()
+	}
+
+	// The frontier: URLs seen but not yet handed to a worker, in the order
+	// they were found, which makes the crawl breadth-first. Only this
+	// goroutine touches it, the seen set and the counters.
+	frontier := []job{{start, 0}}
+	seen := map[string]bool{start.String(): true}
+	sent, inFlight := 0, 0
+	cancelled := ctx.Done()
+	for {
+		var send chan job // nil, so never chosen, unless there is work to give
+		if len(frontier) > 0 && ctx.Err() == nil && (c.cfg.MaxPages == 0 || sent < c.cfg.MaxPages) {
+			send = jobs
+		} else if inFlight == 0 {
+			break
+		}
+		var next job
+		if send != nil {
+			next = frontier[0]
+		}
+		select {
+		case send <- next:
+			frontier = frontier[1:]
+			sent++
+			inFlight++
+		case r := <-results:
+			inFlight--
+			if ctx.Err() != nil && errors.Is(r.page.Err, ctx.Err()) {
+				continue
+			}
+			visit(r.page)
+			if c.cfg.MaxDepth > 0 && r.page.Depth >= c.cfg.MaxDepth {
+				continue
+			}
+			for _, l := range r.links {
+				if k := l.String(); !seen[k] && (c.cfg.AllHosts || l.Host == start.Host) {
+					seen[k] = true
+					frontier = append(frontier, job{l, r.page.Depth + 1})
+				}
+			}
+		case <-cancelled:
+			frontier = nil  // wait for the workers, which see ctx too
+			cancelled = nil // and don't spin on the closed channel meanwhile
+		}
+	}
+	close(jobs)
+	wg.Wait()
+	return ctx.Err()
+}
+
+type crawler struct {
+	cfg   Config
+	mu    sync.Mutex
+	hosts map[string]*host
+}
+
+// host is what the crawler keeps per host: its robots.txt, fetched once,
+// and the earliest time the next request may go.
+type host struct {
+	robotsOnce sync.Once
+	robots     *Robots
+	mu         sync.Mutex
+	next       time.Time
+}
+
+func newCrawler(cfg Config) *crawler {
+	if cfg.Workers <= 0 {
+		cfg.Workers = 4
+	}
+	if cfg.Delay <= 0 {
+		cfg.Delay = time.Second
+	}
+	if cfg.UserAgent == "" {
+		cfg.UserAgent = "roadmap-crawler/1.0"
+	}
+	if cfg.Client == nil {
+		cfg.Client = http.DefaultClient
+	}
+	return &crawler{cfg: cfg, hosts: map[string]*host{}}
+}
+
+func (c *crawler) host(u *url.URL) *host {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	key := u.Scheme + "://" + u.Host
+	h, ok := c.hosts[key]
+	if !ok {
+		h = new(host)
+		c.hosts[key] = h
+	}
+	return h
+}
+
+// fetch gets one page and the links on it, if robots.txt allows.
+func (c *crawler) fetch(ctx context.Context, u *url.URL) (Page, []*url.URL) {
+	page := Page{URL: u.String()}
+	h := c.host(u)
+	h.robotsOnce.Do(func() { h.robots = c.fetchRobots(ctx, h, u) })
+	if !h.robots.Allowed(u.RequestURI()) {
+		page.Err = ErrDisallowed
+		return page, nil
+	}
+	resp, err := c.get(ctx, h, u.String())
+	if err != nil {
+		page.Err = err
+		return page, nil
+	}
+	defer resp.Body.Close()
+	page.Status = resp.StatusCode
+	mediaType, _, _ := mime.ParseMediaType(resp.Header.Get("Content-Type"))
+	if resp.StatusCode != http.StatusOK || mediaType != "text/html" {
+		io.Copy(io.Discard, io.LimitReader(resp.Body, maxBody)) // reuse the connection
+		return page, nil
+	}
+	// After redirects, relative links are relative to where we ended up.
+	links, err := Links(resp.Request.URL, io.LimitReader(resp.Body, maxBody))
+	page.Links, page.Err = len(links), err
+	return page, links
+}
+
+// fetchRobots gets robots.txt for u's host. A missing file allows
+// everything, and a server error disallows everything, as RFC 9309 asks.
+// If the file cannot be fetched at all, everything is allowed: the page
+// requests that follow fail the same way and say why.
+func (c *crawler) fetchRobots(ctx context.Context, h *host, u *url.URL) *Robots {
+	resp, err := c.get(ctx, h, u.Scheme+"://"+u.Host+"/robots.txt")
+	if err != nil {
+		return new(Robots)
+	}
+	defer resp.Body.Close()
+	switch {
+	case resp.StatusCode >= 500:
+		return &Robots{rules: []rule{{pattern: "/", re: compile("/")}}}
+	case resp.StatusCode != http.StatusOK:
+		return new(Robots)
+	}
+	return ParseRobots(io.LimitReader(resp.Body, 500<<10), c.cfg.UserAgent)
+}
+
+// get sends a GET to h once h's turn comes.
+func (c *crawler) get(ctx context.Context, h *host, rawURL string) (*http.Response, error) {
+	if err := c.wait(ctx, h); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, rawURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", c.cfg.UserAgent)
+	return c.cfg.Client.Do(req)
+}
+
+// wait blocks until the next request to h may go, and books the slot
+// after it. Workers that want the same host queue up; others don't wait.
+func (c *crawler) wait(ctx context.Context, h *host) error {
+	delay := c.cfg.Delay
+	if h.robots != nil {
+		delay = max(delay, h.robots.CrawlDelay())
+	}
+	h.mu.Lock()
+	now := time.Now()
+	slot := h.next
+	if slot.Before(now) {
+		slot = now
+	}
+	h.next = slot.Add(delay)
+	h.mu.Unlock()
+
+	t := time.NewTimer(time.Until(slot))
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
@@ -0,0 +1,311 @@
+package crawl
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// site serves testdata/site and records every request.
+type site struct {
+	*httptest.Server
+	mu     sync.Mutex
+	hits   map[string]int
+	times  []time.Time
+	agents map[string]bool
+}
+
+func newSite(t *testing.T, h http.Handler) *site {
+	t.Helper()
+	if h == nil {
+		h = http.FileServer(http.Dir("testdata/site"))
+	}
+	s := &site{hits: map[string]int{}, agents: map[string]bool{}}
+	s.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		s.mu.Lock()
+		s.hits[r.URL.Path]++
+		s.times = append(s.times, time.Now())
+		s.agents[r.UserAgent()] = true
+		s.mu.Unlock()
+		h.ServeHTTP(w, r)
+	}))
+	t.Cleanup(s.Close)
+	return s
+}
+
+// crawl runs Crawl and returns the pages by path.
+func crawl(t *testing.T, ctx context.Context, seed string, cfg Config) (map[string]Page, []Page, error) {
+	t.Helper()
+	byPath := map[string]Page{}
+	var order []Page
+	err := Crawl(ctx, seed, cfg, func(p Page) {
+		u, _ := url.Parse(p.URL)
+		if _, dup := byPath[u.Path]; dup {
+			t.Errorf("%s visited twice", u.Path)
+		}
+		byPath[u.Path] = p
+		order = append(order, p)
+	})
+	return byPath, order, err
+}
+
+func TestCrawl_Site(t *testing.T) {
+	s := newSite(t, nil)
+	pages, _, err := crawl(t, context.Background(), s.URL, Config{Delay: time.Millisecond, UserAgent: "test-crawler"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]struct {
+		depth, status int
+		err           error
+	}{
+		"/":                       {0, 200, nil},
+		"/about.html":             {1, 200, nil},
+		"/blog/":                  {1, 200, nil},
+		"/private/secret.html":    {1, 0, ErrDisallowed},
+		"/private/public.html":    {1, 200, nil},
+		"/missing.html":           {2, 404, nil},
+		"/logo.png":               {2, 200, nil},
+		"/blog/first.html":        {2, 200, nil},
+		"/blog/second.html":       {2, 200, nil},
+		"/blog/deep/archive.html": {3, 200, nil}, // through <base href>; first.html's link is nofollow
+	}
+	for path, w := range want {
+		p, ok := pages[path]
+		if !ok {
+			t.Errorf("%s not visited", path)
+			continue
+		}
+		if p.Depth != w.depth || p.Status != w.status || !errors.Is(p.Err, w.err) {
+			t.Errorf("%s = depth %d, status %d, err %v; want %d, %d, %v", path, p.Depth, p.Status, p.Err, w.depth, w.status, w.err)
+		}
+	}
+	for path := range pages {
+		if _, ok := want[path]; !ok {
+			t.Errorf("unexpected page %s", path)
+		}
+	}
+
+	// Each page was requested at most once, robots.txt first and once, and
+	// the disallowed page never.
+	for path, n := range s.hits {
+		if n > 1 {
+			t.Errorf("%s requested %d times", path, n)
+		}
+	}
+	if s.hits["/private/secret.html"] != 0 {
+		t.Error("requested a page robots.txt disallows")
+	}
+	if s.hits["/robots.txt"] != 1 {
+		t.Errorf("robots.txt requested %d times; want 1", s.hits["/robots.txt"])
+	}
+	if !reflect.DeepEqual(s.agents, map[string]bool{"test-crawler": true}) {
+		t.Errorf("user agents = %v; want test-crawler", s.agents)
+	}
+}
+
+func TestCrawl_Limits(t *testing.T) {
+	s := newSite(t, nil)
+	pages, _, err := crawl(t, context.Background(), s.URL, Config{MaxDepth: 1, Delay: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for path, p := range pages {
+		if p.Depth > 1 {
+			t.Errorf("%s at depth %d with MaxDepth 1", path, p.Depth)
+		}
+	}
+	if len(pages) != 5 {
+		t.Errorf("MaxDepth 1: %d pages; want 5", len(pages))
+	}
+
+	pages, _, err = crawl(t, context.Background(), s.URL, Config{MaxPages: 3, Delay: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 3 {
+		t.Errorf("MaxPages 3: %d pages", len(pages))
+	}
+}
+
+func TestCrawl_BreadthFirst(t *testing.T) {
+	s := newSite(t, nil)
+	_, order, err := crawl(t, context.Background(), s.URL, Config{Workers: 1, Delay: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !slices.IsSortedFunc(order, func(a, b Page) int { return a.Depth - b.Depth }) {
+		var depths []int
+		for _, p := range order {
+			depths = append(depths, p.Depth)
+		}
+		t.Errorf("depths in visit order = %v; want non-decreasing", depths)
+	}
+}
+
+func TestCrawl_Politeness(t *testing.T) {
+	const crawlDelay = 40 * time.Millisecond
+	files := http.FileServer(http.Dir("testdata/site"))
+	s := newSite(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			w.Write([]byte("User-agent: *\nCrawl-delay: 0.04\n"))
+			return
+		}
+		files.ServeHTTP(w, r)
+	}))
+	// Many workers, a tiny configured delay: robots.txt's Crawl-delay must
+	// still space the requests out.
+	_, _, err := crawl(t, context.Background(), s.URL, Config{Workers: 8, Delay: time.Millisecond, MaxPages: 5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// The first gap is after robots.txt, before its delay was known.
+	for i := 2; i < len(s.times); i++ {
+		if gap := s.times[i].Sub(s.times[i-1]); gap < crawlDelay-5*time.Millisecond {
+			t.Errorf("requests %d and %d %v apart; want at least %v", i-1, i, gap, crawlDelay)
+		}
+	}
+}
+
+func TestCrawl_Cancel(t *testing.T) {
+	// Every page but the first hangs until its request is cancelled.
+	files := http.FileServer(http.Dir("testdata/site"))
+	s := newSite(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/" || r.URL.Path == "/robots.txt" {
+			files.ServeHTTP(w, r)
+			return
+		}
+		<-r.Context().Done()
+	}))
+	ctx, cancel := context.WithCancel(context.Background())
+	var visited []string
+	errc := make(chan error, 1)
+	go func() {
+		errc <- Crawl(ctx, s.URL, Config{Delay: time.Millisecond}, func(p Page) {
+			visited = append(visited, p.URL)
+			cancel()
+		})
+	}()
+	select {
+	case err := <-errc:
+		if !errors.Is(err, context.Canceled) {
+			t.Errorf("Crawl = %v; want context.Canceled", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("Crawl did not return after cancel")
+	}
+	if len(visited) != 1 || !strings.HasSuffix(visited[0], "/") {
+		t.Errorf("visited %v; want only the seed, the rest were cut short", visited)
+	}
+}
+
+func TestCrawl_RobotsServerError(t *testing.T) {
+	s := newSite(t, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/robots.txt" {
+			http.Error(w, "down", http.StatusServiceUnavailable)
+			return
+		}
+		w.Write([]byte(`<a href="/other">x</a>`))
+	}))
+	pages, _, err := crawl(t, context.Background(), s.URL, Config{Delay: time.Millisecond})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pages) != 1 || !errors.Is(pages["/"].Err, ErrDisallowed) {
+		t.Errorf("pages = %v; want the seed alone, disallowed", pages)
+	}
+	if s.hits["/"] != 0 {
+		t.Error("fetched a page while robots.txt returned 503")
+	}
+}
+
+func TestCrawl_BadSeed(t *testing.T) {
+	if err := Crawl(context.Background(), "ftp://example.com/", Config{}, func(Page) {}); err == nil {
+		t.Error("Crawl accepted an ftp seed")
+	}
+}
+
+func TestLinks(t *testing.T) {
+	base, _ := url.Parse("http://Example.COM:80/blog/second.html")
+	for _, tc := range []struct {
+		file string
+		want []string
+	}{
+		{"testdata/site/index.html", []string{
+			"http://example.com/", "http://example.com/blog/about.html", "http://example.com/blog/blog/",
+			"http://example.com/private/secret.html", "http://example.com/private/public.html", "https://example.com/",
+		}},
+		// <base href> changes what relative links resolve against.
+		{"testdata/site/blog/second.html", []string{
+			"http://example.com/blog/deep/archive.html", "http://example.com/blog/first.html",
+		}},
+		// rel="nofollow" is skipped.
+		{"testdata/site/blog/first.html", []string{"http://example.com/blog/second.html"}},
+	} {
+		f, err := os.Open(tc.file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		links, err := Links(base, f)
+		f.Close()
+		if err != nil {
+			t.Fatalf("%s: %v", tc.file, err)
+		}
+		var got []string
+		for _, l := range links {
+			got = append(got, l.String())
+		}
+		if !reflect.DeepEqual(got, tc.want) {
+			t.Errorf("%s:\n got %q\nwant %q", tc.file, got, tc.want)
+		}
+	}
+}
+
+func TestParseRobots(t *testing.T) {
+	const txt = `
+# comment
+User-agent: *
+Disallow: /private/
+Allow: /private/public.html
+Disallow: /*.pdf$
+Crawl-delay: 2.5
+
+User-agent: SpecialBot
+User-agent: OtherBot
+Disallow: /
+Allow: /$
+`
+	star := ParseRobots(strings.NewReader(txt), "roadmap-crawler/1.0")
+	special := ParseRobots(strings.NewReader(txt), "Mozilla/5.0 (compatible; SpecialBot/2.1)")
+	for _, tc := range []struct {
+		r    *Robots
+		path string
+		want bool
+	}{
+		{star, "/", true},
+		{star, "/private/", false},
+		{star, "/private/x.html", false},
+		{star, "/private/public.html", true}, // longer Allow wins
+		{star, "/docs/a.pdf", false},
+		{star, "/docs/a.pdf?x=1", true}, // $ anchors the end
+		{special, "/", true},
+		{special, "/about", false},
+		{new(Robots), "/anything", true},
+	} {
+		if got := tc.r.Allowed(tc.path); got != tc.want {
+			t.Errorf("Allowed(%q) = %v; want %v", tc.path, got, tc.want)
+		}
+	}
+	if star.CrawlDelay() != 2500*time.Millisecond || special.CrawlDelay() != 0 {
+		t.Errorf("crawl delays = %v, %v; want 2.5s, 0", star.CrawlDelay(), special.CrawlDelay())
+	}
+}
@@ -0,0 +1,101 @@
+package crawl
+
+import (
+	"io"
+	"net/url"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// Links returns the absolute http and https URLs that the HTML document
+// in r links to with <a href>, resolved against base or against the
+// document's own <base href>. Links marked rel="nofollow" are left out,
+// and fragments are removed. A document the tokenizer cannot finish still
+// yields the links found up to that point.
+func Links(base *url.URL, r io.Reader) ([]*url.URL, error) {
+	var out []*url.URL
+	z := html.NewTokenizer(r)
+	for {
+		switch z.Next() {
+		case html.ErrorToken:
+			if z.Err() == io.EOF {
+				return out, nil
+			}
+			return out, z.Err()
+		case html.StartTagToken, html.SelfClosingTagToken:
+			name, hasAttr := z.TagName()
+			if !hasAttr {
+				continue
+			}
+			attrs := attributes(z)
+			switch string(name) {
+			case "base":
+				if u, err := base.Parse(attrs["href"]); err == nil && attrs["href"] != "" {
+					base = u
+				}
+			case "a":
+				if hasToken(attrs["rel"], "nofollow") {
+					continue
+				}
+				if u := resolve(base, attrs["href"]); u != nil {
+					out = append(out, u)
+				}
+			}
+		}
+	}
+}
+
+func attributes(z *html.Tokenizer) map[string]string {
+	attrs := map[string]string{}
+	for {
+		key, val, more := z.TagAttr()
+		attrs[string(key)] = string(val)
+		if !more {
+			return attrs
+		}
+	}
+}
+
+func hasToken(list, token string) bool {
+	for _, f := range strings.Fields(list) {
+		if strings.EqualFold(f, token) {
+			return true
+		}
+	}
+	return false
+}
+
+// resolve makes href absolute and normal, or returns nil for a link that
+// is not to an http or https page.
+func resolve(base *url.URL, href string) *url.URL {
+	href = strings.TrimSpace(href)
+	if href == "" || strings.HasPrefix(href, "#") {
+		return nil
+	}
+	u, err := base.Parse(href)
+	if err != nil {
+		return nil
+	}
+	return normalize(u)
+}
+
+// normalize returns u in the form used to tell pages apart: scheme and
+// host in lower case, without the default port, the fragment or user
+// info, and with "/" for an empty path. It returns nil for other schemes.
+func normalize(u *url.URL) *url.URL {
+	n := *u
+	n.Scheme = strings.ToLower(n.Scheme)
+	if n.Scheme != "http" && n.Scheme != "https" || n.Host == "" {
+		return nil
+	}
+	n.Host = strings.ToLower(n.Host)
+	if port := n.Port(); port == "80" && n.Scheme == "http" || port == "443" && n.Scheme == "https" {
+		n.Host = n.Hostname()
+	}
+	n.Fragment, n.RawFragment, n.User = "", "", nil
+	if n.Path == "" {
+		n.Path = "/"
+	}
+	return &n
+}
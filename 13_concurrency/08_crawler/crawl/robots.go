@@ -0,0 +1,116 @@
+package crawl
+
+import (
+	"bufio"
+	"io"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Robots is the part of a robots.txt file that applies to one user agent.
+// The zero value allows everything.
+type Robots struct {
+	rules []rule
+	delay time.Duration
+}
+
+type rule struct {
+	pattern string
+	re      *regexp.Regexp
+	allow   bool
+}
+
+// ParseRobots reads a robots.txt file and keeps the group for agent: the
+// first group naming a product token that agent contains, or the "*"
+// group if none does. Within the group, Allow and Disallow match path
+// prefixes, and the longest match wins (RFC 9309). Crawl-delay is not in
+// the RFC but widely used; it is kept as a minimum gap between requests.
+func ParseRobots(r io.Reader, agent string) *Robots {
+	agent = strings.ToLower(agent)
+	var (
+		named, star *Robots
+		cur         []*Robots // the groups the current lines belong to
+		inAgents    bool      // the previous line was a User-agent
+	)
+	sc := bufio.NewScanner(r)
+	for sc.Scan() {
+		line, _, _ := strings.Cut(sc.Text(), "#")
+		key, val, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		key, val = strings.ToLower(strings.TrimSpace(key)), strings.TrimSpace(val)
+		if key == "user-agent" {
+			if !inAgents {
+				cur = nil
+			}
+			inAgents = true
+			token := strings.ToLower(val)
+			switch {
+			case token == "*" && star == nil:
+				star = new(Robots)
+				cur = append(cur, star)
+			case token != "*" && named == nil && token != "" && strings.Contains(agent, token):
+				named = new(Robots)
+				cur = append(cur, named)
+			}
+			continue
+		}
+		inAgents = false
+		for _, g := range cur {
+			switch key {
+			case "allow", "disallow":
+				if val != "" { // "Disallow:" with no path allows everything
+					g.rules = append(g.rules, rule{pattern: val, re: compile(val), allow: key == "allow"})
+				}
+			case "crawl-delay":
+				if s, err := strconv.ParseFloat(val, 64); err == nil && s > 0 {
+					g.delay = time.Duration(s * float64(time.Second))
+				}
+			}
+		}
+	}
+	switch {
+	case named != nil:
+		return named
+	case star != nil:
+		return star
+	}
+	return new(Robots)
+}
+
+// Allowed reports whether path (with its query, if any) may be fetched.
+func (r *Robots) Allowed(path string) bool {
+	best, allow := -1, true
+	for _, ru := range r.rules {
+		if !ru.re.MatchString(path) {
+			continue
+		}
+		// Longest match wins; on a tie, Allow does.
+		if n := len(ru.pattern); n > best || n == best && ru.allow {
+			best, allow = n, ru.allow
+		}
+	}
+	return allow
+}
+
+// CrawlDelay returns the Crawl-delay, or 0 if none was given.
+func (r *Robots) CrawlDelay() time.Duration { return r.delay }
+
+// compile turns a robots.txt path pattern into a regexp: "*" matches any
+// run of characters, a final "$" anchors the end, and anything else must
+// match literally at the start of the path.
+func compile(pattern string) *regexp.Regexp {
+	anchored := strings.HasSuffix(pattern, "$")
+	parts := strings.Split(strings.TrimSuffix(pattern, "$"), "*")
+	for i, p := range parts {
+		parts[i] = regexp.QuoteMeta(p)
+	}
+	expr := "^" + strings.Join(parts, ".*")
+	if anchored {
+		expr += "$"
+	}
+	return regexp.MustCompile(expr)
+}
@@ -0,0 +1,5 @@
+module golang_roadmap/13_concurrency/08_crawler
+
+go 1.24.11
+
+require golang.org/x/net v0.47.0
@@ -0,0 +1,110 @@
+// Demonstrates a polite breadth-first web crawler.
+//
+// This example shows:
+// - A frontier queue owned by one goroutine, fed by a pool of fetch workers
+// - Deduplication of normalized URLs, and depth and page limits
+// - robots.txt rules and Crawl-delay, fetched once per host
+// - A minimum gap between requests to each host, shared by all workers
+// - Link extraction with the golang.org/x/net/html tokenizer
+// - Ctrl-C stopping new requests, aborting those in flight, and still reporting
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/signal"
+	"strconv"
+	"time"
+
+	"golang_roadmap/13_concurrency/08_crawler/crawl"
+)
+
+func main() {
+	depth := flag.Int("depth", 3, "links to follow from the seed; 0 for no limit")
+	pages := flag.Int("pages", 100, "most pages to visit; 0 for no limit")
+	workers := flag.Int("c", 4, "requests in flight across all hosts")
+	delay := flag.Duration("delay", time.Second, "least time between requests to one host")
+	allHosts := flag.Bool("all-hosts", false, "follow links to other hosts")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: crawler [flags] [URL]\nWithout a URL, crawls a demo site on localhost.")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	log.SetFlags(0)
+
+	cfg := crawl.Config{MaxDepth: *depth, MaxPages: *pages, Workers: *workers, Delay: *delay, AllHosts: *allHosts}
+	seed := flag.Arg(0)
+	if seed == "" {
+		srv := httptest.NewServer(demoSite())
+		defer srv.Close()
+		seed = srv.URL + "/"
+		cfg.Delay = 20 * time.Millisecond
+		fmt.Printf("crawler: no URL; crawling a demo site at %s, %v between requests\n\n", seed, cfg.Delay)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+	start := time.Now()
+	var n, failed int
+	err := crawl.Crawl(ctx, seed, cfg, func(p crawl.Page) {
+		n++
+		status := strconv.Itoa(p.Status)
+		if p.Err != nil {
+			status, failed = p.Err.Error(), failed+1
+		}
+		fmt.Printf("%d  %-45s %-3s %d links\n", p.Depth, p.URL, status, p.Links)
+	})
+	fmt.Printf("\n%d pages in %v, %d failed or skipped\n", n, time.Since(start).Round(time.Millisecond), failed)
+	if errors.Is(err, context.Canceled) {
+		fmt.Println("interrupted")
+	} else if err != nil {
+		log.Fatal(err)
+	}
+}
+
+// demoSite serves a small tree of pages: / links to /2 and /3, /n to /2n
+// and /2n+1, up to /40, and each page to itself and the home page.
+// robots.txt keeps crawlers out of /private.
+func demoSite() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /robots.txt", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "User-agent: *\nDisallow: /private\n")
+	})
+	mux.HandleFunc("GET /private/", func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, "<p>crawlers should not be here</p>")
+	})
+	path := func(n int) string {
+		if n == 1 {
+			return "/"
+		}
+		return "/" + strconv.Itoa(n)
+	}
+	page := func(w http.ResponseWriter, n int) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		fmt.Fprintf(w, `<title>Page %d</title><a href="/">home</a> <a href="%s#top">self</a>`, n, path(n))
+		for _, c := range []int{2 * n, 2*n + 1} {
+			if c <= 40 {
+				fmt.Fprintf(w, ` <a href="/%d">%d</a>`, c, c)
+			}
+		}
+		if n == 3 {
+			fmt.Fprint(w, ` <a href="/private/notes">notes</a> <a href="/broken">broken</a>`)
+		}
+	}
+	mux.HandleFunc("GET /{$}", func(w http.ResponseWriter, r *http.Request) { page(w, 1) })
+	mux.HandleFunc("GET /{n}", func(w http.ResponseWriter, r *http.Request) {
+		n, err := strconv.Atoi(r.PathValue("n"))
+		if err != nil || n < 2 || n > 40 {
+			http.NotFound(w, r)
+			return
+		}
+		page(w, n)
+	})
+	return mux
+}
@@ -0,0 +1,79 @@
+// Command server runs the users service. It is the composition root: the
+// only code that picks adapters and connects them to the application.
+//
+//	go run ./cmd/server                     # in-memory store
+//	go run ./cmd/server -db users.db        # SQLite
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/adapters/httpapi"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/adapters/memory"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/adapters/sqlite"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/app"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/ports"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "listen address")
+	dbPath := flag.String("db", "", "SQLite database file (default: keep users in memory)")
+	flag.Parse()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	if err := run(*addr, *dbPath, log); err != nil {
+		log.Error("Server failed", "err", err)
+		os.Exit(1)
+	}
+}
+
+func run(addr, dbPath string, log *slog.Logger) error {
+	var repo ports.UserRepository = memory.New()
+	if dbPath != "" {
+		db, err := sqlite.Open(dbPath)
+		if err != nil {
+			return err
+		}
+		defer db.Close()
+		repo = db
+	}
+	users := app.NewUsers(repo, clock.Real())
+	srv := &http.Server{
+		Addr:         addr,
+		Handler:      httpapi.New(users, log),
+		ReadTimeout:  5 * time.Second,
+		WriteTimeout: 10 * time.Second,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	errc := make(chan error, 1)
+	go func() {
+		log.Info("Listening", "addr", addr, "db", dbPath)
+		errc <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+	log.Info("Shutting down")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,32 @@
+module golang_roadmap/14_projects/01_hexagonal_users
+
+go 1.24.11
+
+require (
+	golang_roadmap/04_Tooling_testing_and_code_quality/07_clock v0.0.0
+	golang_roadmap/08_web_development/04_validation v0.0.0
+	golang_roadmap/08_web_development/08_reports v0.0.0
+	modernc.org/sqlite v1.38.2
+)
+
+require (
+	github.com/dustin/go-humanize v1.0.1 // indirect
+	github.com/go-pdf/fpdf v0.9.0 // indirect
+	github.com/google/uuid v1.6.0 // indirect
+	github.com/mattn/go-isatty v0.0.20 // indirect
+	github.com/ncruces/go-strftime v0.1.9 // indirect
+	github.com/remyoudompheng/bigfft v0.0.0-20230129092748-24d4a6f8daec // indirect
+	golang.org/x/exp v0.0.0-20250620022241-b7579e27df2b // indirect
+	golang.org/x/sys v0.34.0 // indirect
+	modernc.org/libc v1.66.3 // indirect
+	modernc.org/mathutil v1.7.1 // indirect
+	modernc.org/memory v1.11.0 // indirect
+)
+
+// The clock, validate and report packages live in their own modules in
+// this repository.
+replace (
+	golang_roadmap/04_Tooling_testing_and_code_quality/07_clock => ../../04_Tooling_testing_and_code_quality/07_clock
+	golang_roadmap/08_web_development/04_validation => ../../08_web_development/04_validation
+	golang_roadmap/08_web_development/08_reports => ../../08_web_development/08_reports
+)
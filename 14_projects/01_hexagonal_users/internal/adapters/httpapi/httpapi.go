@@ -0,0 +1,168 @@
+// Package httpapi is the HTTP adapter: it turns requests into calls on
+// ports.UserService and domain results into JSON. Wire formats live here,
+// not in the domain.
+package httpapi
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang_roadmap/08_web_development/04_validation/validate"
+	"golang_roadmap/08_web_development/08_reports/report"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/domain"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/ports"
+)
+
+// userJSON is the API's view of a user.
+type userJSON struct {
+	ID        int64     `json:"id"`
+	Name      string    `json:"name"`
+	Email     string    `json:"email"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+func toJSON(u domain.User) userJSON {
+	return userJSON{ID: u.ID, Name: u.Name, Email: u.Email, CreatedAt: u.CreatedAt, UpdatedAt: u.UpdatedAt}
+}
+
+type api struct {
+	users ports.UserService
+	log   *slog.Logger
+}
+
+// New returns the API's handler:
+//
+//	GET   /users?after=ID&limit=N
+//	GET   /users/{id}
+//	POST  /users       {"name": ..., "email": ...}
+//	PATCH /users/{id}  {"name": ...}
+//	GET   /reports/users.pdf, /reports/users.txt
+func New(users ports.UserService, log *slog.Logger) http.Handler {
+	a := &api{users: users, log: log}
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users", a.list)
+	mux.HandleFunc("GET /users/{id}", a.get)
+	mux.HandleFunc("POST /users", a.create)
+	mux.HandleFunc("PATCH /users/{id}", a.rename)
+	mux.HandleFunc("GET /reports/users.pdf", a.usersReport(report.PDF, ".pdf"))
+	mux.HandleFunc("GET /reports/users.txt", a.usersReport(report.Text, ".txt"))
+	return mux
+}
+
+func (a *api) list(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+	after, err1 := optInt(q.Get("after"))
+	limit, err2 := optInt(q.Get("limit"))
+	if err := errors.Join(err1, err2); err != nil {
+		writeError(w, http.StatusBadRequest, "after and limit must be integers")
+		return
+	}
+	users, err := a.users.List(r.Context(), after, int(limit))
+	if err != nil {
+		a.fail(w, r, err)
+		return
+	}
+	out := make([]userJSON, len(users))
+	for i, u := range users {
+		out[i] = toJSON(u)
+	}
+	writeJSON(w, http.StatusOK, out)
+}
+
+func (a *api) get(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+	u, err := a.users.Get(r.Context(), id)
+	if err != nil {
+		a.fail(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toJSON(u))
+}
+
+func (a *api) create(w http.ResponseWriter, r *http.Request) {
+	var body struct{ Name, Email string }
+	if !decode(w, r, &body) {
+		return
+	}
+	u, err := a.users.Register(r.Context(), body.Name, body.Email)
+	if err != nil {
+		a.fail(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/users/"+strconv.FormatInt(u.ID, 10))
+	writeJSON(w, http.StatusCreated, toJSON(u))
+}
+
+func (a *api) rename(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, "invalid user ID")
+		return
+	}
+	var body struct{ Name string }
+	if !decode(w, r, &body) {
+		return
+	}
+	u, err := a.users.Rename(r.Context(), id, body.Name)
+	if err != nil {
+		a.fail(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, toJSON(u))
+}
+
+// fail maps domain errors to status codes. Anything else is a fault of
+// the service: logged, and not shown to the client.
+func (a *api) fail(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case domain.IsInvalid(err):
+		fields := map[string]string{}
+		for _, fe := range validate.Fields(err) {
+			fields[fe.Field] = fe.Message()
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "invalid user", "fields": fields})
+	case errors.Is(err, domain.ErrNotFound):
+		writeError(w, http.StatusNotFound, err.Error())
+	case errors.Is(err, domain.ErrEmailTaken):
+		writeError(w, http.StatusConflict, err.Error())
+	default:
+		a.log.ErrorContext(r.Context(), "Request failed", "method", r.Method, "path", r.URL.Path, "err", err)
+		writeError(w, http.StatusInternalServerError, "internal error")
+	}
+}
+
+func decode(w http.ResponseWriter, r *http.Request, v any) bool {
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1<<20))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		writeError(w, http.StatusBadRequest, "invalid JSON: "+err.Error())
+		return false
+	}
+	return true
+}
+
+func optInt(s string) (int64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(s, 10, 64)
+}
+
+func writeError(w http.ResponseWriter, code int, msg string) {
+	writeJSON(w, code, map[string]string{"error": msg})
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
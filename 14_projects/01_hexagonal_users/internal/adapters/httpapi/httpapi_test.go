@@ -0,0 +1,114 @@
+package httpapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/adapters/memory"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/app"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/domain"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/ports"
+)
+
+func serve(t *testing.T, h http.Handler, method, path, body string) (int, string) {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest(method, path, strings.NewReader(body)))
+	return rec.Code, strings.TrimSpace(rec.Body.String())
+}
+
+func TestAPI(t *testing.T) {
+	users := app.NewUsers(memory.New(), clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)))
+	h := New(users, slog.New(slog.DiscardHandler))
+
+	for _, tt := range []struct {
+		method, path, body string
+		code               int
+		want               string
+	}{
+		{"POST", "/users", `{"name":"Ada","email":"ada@example.com"}`, 201,
+			`{"id":1,"name":"Ada","email":"ada@example.com","created_at":"2024-03-01T12:00:00Z","updated_at":"2024-03-01T12:00:00Z"}`},
+		{"POST", "/users", `{"name":"Ada","email":"ADA@example.com"}`, 409, `{"error":"email already registered"}`},
+		{"POST", "/users", `{"name":"","email":"nope"}`, 422,
+			`{"error":"invalid user","fields":{"email":"must be an email address","name":"must not be empty"}}`},
+		{"POST", "/users", `{"name":"Ada","role":"admin"}`, 400, `{"error":"invalid JSON: json: unknown field \"role\""}`},
+		{"PATCH", "/users/1", `{"name":"Ada Lovelace"}`, 200,
+			`{"id":1,"name":"Ada Lovelace","email":"ada@example.com","created_at":"2024-03-01T12:00:00Z","updated_at":"2024-03-01T12:00:00Z"}`},
+		{"GET", "/users/2", "", 404, `{"error":"user not found"}`},
+		{"GET", "/users/x", "", 400, `{"error":"invalid user ID"}`},
+		{"GET", "/users?limit=ten", "", 400, `{"error":"after and limit must be integers"}`},
+		{"GET", "/users?after=1", "", 200, `[]`},
+	} {
+		code, body := serve(t, h, tt.method, tt.path, tt.body)
+		if code != tt.code || body != tt.want {
+			t.Errorf("%s %s = %d %s\nwant %d %s", tt.method, tt.path, code, body, tt.code, tt.want)
+		}
+	}
+
+	code, body := serve(t, h, "GET", "/users", "")
+	var list []userJSON
+	if err := json.Unmarshal([]byte(body), &list); code != 200 || err != nil || len(list) != 1 || list[0].Name != "Ada Lovelace" {
+		t.Errorf("GET /users = %d %s", code, body)
+	}
+}
+
+// failing is a UserService whose storage is down.
+type failing struct{ ports.UserService }
+
+func (failing) Get(context.Context, int64) (domain.User, error) {
+	return domain.User{}, errors.New("database is locked")
+}
+
+func (failing) List(context.Context, int64, int) ([]domain.User, error) {
+	return nil, errors.New("database is locked")
+}
+
+func TestAPI_HidesInternalErrors(t *testing.T) {
+	code, body := serve(t, New(failing{}, slog.New(slog.DiscardHandler)), "GET", "/users/1", "")
+	if code != 500 || strings.Contains(body, "database") {
+		t.Errorf("GET = %d %s; want 500 without the cause", code, body)
+	}
+}
+
+func TestReports(t *testing.T) {
+	users := app.NewUsers(memory.New(), clock.NewFake(time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)))
+	for i := range 250 { // more than two pages of List
+		if _, err := users.Register(context.Background(), fmt.Sprintf("User %d", i+1), fmt.Sprintf("u%d@example.com", i+1)); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := New(users, slog.New(slog.DiscardHandler))
+
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/reports/users.txt", nil))
+	disp := rec.Header().Get("Content-Disposition")
+	if rec.Code != 200 || !strings.HasPrefix(disp, "attachment; filename=users-") || !strings.HasSuffix(disp, ".txt") {
+		t.Fatalf("GET /reports/users.txt = %d, Content-Disposition %q", rec.Code, disp)
+	}
+	body := rec.Body.String()
+	for _, want := range []string{"\n1    User 1    u1@example.com    2024-03-01\n", "\n250  User 250  u250@example.com  2024-03-01\n", "\n250 users\n"} {
+		if !strings.Contains(body, want) {
+			t.Errorf("text report lacks %q", want)
+		}
+	}
+
+	rec = httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/reports/users.pdf", nil))
+	if rec.Code != 200 || rec.Header().Get("Content-Type") != "application/pdf" || !strings.HasPrefix(rec.Body.String(), "%PDF-") {
+		t.Errorf("GET /reports/users.pdf = %d, %q, %.8q", rec.Code, rec.Header().Get("Content-Type"), rec.Body.String())
+	}
+
+	code, body := serve(t, New(failing{}, slog.New(slog.DiscardHandler)), "GET", "/reports/users.pdf", "")
+	if code != 500 || strings.Contains(body, "database") {
+		t.Errorf("report with storage down = %d %s; want 500 without the cause", code, body)
+	}
+}
@@ -0,0 +1,74 @@
+package httpapi
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"golang_roadmap/08_web_development/08_reports/report"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/domain"
+)
+
+// Reports read users in pages of reportPage, and stop at maxReportRows:
+// past that a PDF runs to hundreds of pages, and an export or a paged
+// API is the better tool.
+const (
+	reportPage    = 100
+	maxReportRows = 10_000
+)
+
+// usersReport serves every user as a table to download, as a PDF for
+// GET /reports/users.pdf and as aligned text for GET /reports/users.txt.
+func (a *api) usersReport(format report.Format, ext string) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		now := time.Now().UTC()
+		users, more, err := a.allUsers(r.Context())
+		if err != nil {
+			a.fail(w, r, err)
+			return
+		}
+		rep := &report.Report{
+			Title:    "Users",
+			Subtitle: "Generated " + now.Format("2006-01-02 15:04 MST"),
+			Columns: []report.Column{
+				{Title: "ID", Width: 0.6, Right: true},
+				{Title: "Name", Width: 2},
+				{Title: "Email", Width: 2.5},
+				{Title: "Registered", Width: 1.2},
+			},
+			Footer:  fmt.Sprintf("%d users", len(users)),
+			Created: now,
+		}
+		if more {
+			rep.Footer = fmt.Sprintf("The first %d users; more are not shown.", len(users))
+		}
+		for _, u := range users {
+			rep.Rows = append(rep.Rows, []string{
+				strconv.FormatInt(u.ID, 10), u.Name, u.Email, u.CreatedAt.Format(time.DateOnly),
+			})
+		}
+		report.Serve(w, rep, format, "users-"+now.Format(time.DateOnly)+ext)
+	}
+}
+
+// allUsers pages through the users in ID order, up to maxReportRows. more
+// reports whether some were left out.
+func (a *api) allUsers(ctx context.Context) (users []domain.User, more bool, err error) {
+	var after int64
+	for {
+		page, err := a.users.List(ctx, after, reportPage)
+		if err != nil {
+			return nil, false, err
+		}
+		if len(page) == 0 {
+			return users, false, nil
+		}
+		if len(users)+len(page) > maxReportRows {
+			return append(users, page[:maxReportRows-len(users)]...), true, nil
+		}
+		users = append(users, page...)
+		after = page[len(page)-1].ID
+	}
+}
@@ -0,0 +1,76 @@
+// Package memory is a UserRepository adapter that keeps users in a map,
+// for tests and for running without a database.
+package memory
+
+import (
+	"cmp"
+	"context"
+	"slices"
+	"sync"
+
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/domain"
+)
+
+// Repo is safe for concurrent use.
+type Repo struct {
+	mu      sync.Mutex
+	byID    map[int64]domain.User
+	byEmail map[string]int64
+	lastID  int64
+}
+
+func New() *Repo {
+	return &Repo{byID: make(map[int64]domain.User), byEmail: make(map[string]int64)}
+}
+
+func (r *Repo) Create(_ context.Context, u domain.User) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.byEmail[u.Email]; ok {
+		return domain.User{}, domain.ErrEmailTaken
+	}
+	r.lastID++
+	u.ID = r.lastID
+	r.byID[u.ID] = u
+	r.byEmail[u.Email] = u.ID
+	return u, nil
+}
+
+func (r *Repo) Get(_ context.Context, id int64) (domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.byID[id]
+	if !ok {
+		return domain.User{}, domain.ErrNotFound
+	}
+	return u, nil
+}
+
+func (r *Repo) List(_ context.Context, afterID int64, limit int) ([]domain.User, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var out []domain.User
+	for _, u := range r.byID {
+		if u.ID > afterID {
+			out = append(out, u)
+		}
+	}
+	slices.SortFunc(out, func(a, b domain.User) int { return cmp.Compare(a.ID, b.ID) })
+	return out[:min(limit, len(out))], nil
+}
+
+func (r *Repo) Update(_ context.Context, u domain.User) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old, ok := r.byID[u.ID]
+	if !ok {
+		return domain.ErrNotFound
+	}
+	if id, ok := r.byEmail[u.Email]; ok && id != u.ID {
+		return domain.ErrEmailTaken
+	}
+	delete(r.byEmail, old.Email)
+	r.byID[u.ID] = u
+	r.byEmail[u.Email] = u.ID
+	return nil
+}
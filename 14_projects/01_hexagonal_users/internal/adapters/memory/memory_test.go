@@ -0,0 +1,12 @@
+package memory
+
+import (
+	"testing"
+
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/adapters/repotest"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/ports"
+)
+
+func TestRepo(t *testing.T) {
+	repotest.Run(t, func(*testing.T) ports.UserRepository { return New() })
+}
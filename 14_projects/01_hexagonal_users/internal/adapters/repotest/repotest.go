@@ -0,0 +1,88 @@
+// Package repotest is the contract every ports.UserRepository must meet.
+// Each adapter's tests call Run, so the in-memory store used in tests
+// cannot drift from the real one.
+package repotest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/domain"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/ports"
+)
+
+// Run tests the repository returned by newRepo, called once per subtest
+// for an empty repository.
+func Run(t *testing.T, newRepo func(t *testing.T) ports.UserRepository) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 123456789, time.UTC)
+	user := func(name, email string) domain.User {
+		u, err := domain.NewUser(name, email, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return u
+	}
+
+	t.Run("CreateAndGet", func(t *testing.T) {
+		r := newRepo(t)
+		a, err := r.Create(ctx, user("Ada", "ada@example.com"))
+		if err != nil || a.ID == 0 {
+			t.Fatalf("Create = %+v, %v; want an ID", a, err)
+		}
+		b, _ := r.Create(ctx, user("Bob", "bob@example.com"))
+		if b.ID == a.ID {
+			t.Errorf("both users got ID %d", a.ID)
+		}
+		got, err := r.Get(ctx, a.ID)
+		if err != nil || got.Name != "Ada" || got.Email != "ada@example.com" || !got.CreatedAt.Equal(now) {
+			t.Errorf("Get = %+v, %v; want %+v", got, err, a)
+		}
+		if _, err := r.Get(ctx, 999); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("Get(999) = %v; want ErrNotFound", err)
+		}
+	})
+
+	t.Run("DuplicateEmail", func(t *testing.T) {
+		r := newRepo(t)
+		r.Create(ctx, user("Ada", "ada@example.com"))
+		if _, err := r.Create(ctx, user("Other", "ada@example.com")); !errors.Is(err, domain.ErrEmailTaken) {
+			t.Errorf("second Create = %v; want ErrEmailTaken", err)
+		}
+	})
+
+	t.Run("List", func(t *testing.T) {
+		r := newRepo(t)
+		var ids []int64
+		for _, e := range []string{"a@x.io", "b@x.io", "c@x.io", "d@x.io"} {
+			u, _ := r.Create(ctx, user("U", e))
+			ids = append(ids, u.ID)
+		}
+		page, err := r.List(ctx, 0, 3)
+		if err != nil || len(page) != 3 || page[0].ID != ids[0] || page[2].ID != ids[2] {
+			t.Fatalf("first page = %+v, %v", page, err)
+		}
+		page, _ = r.List(ctx, page[2].ID, 3)
+		if len(page) != 1 || page[0].ID != ids[3] {
+			t.Errorf("second page = %+v; want the last user", page)
+		}
+	})
+
+	t.Run("Update", func(t *testing.T) {
+		r := newRepo(t)
+		u, _ := r.Create(ctx, user("Ada", "ada@example.com"))
+		u.Rename("Ada Lovelace", now.Add(time.Hour))
+		if err := r.Update(ctx, u); err != nil {
+			t.Fatal(err)
+		}
+		got, _ := r.Get(ctx, u.ID)
+		if got.Name != "Ada Lovelace" || !got.UpdatedAt.Equal(now.Add(time.Hour)) || !got.CreatedAt.Equal(now) {
+			t.Errorf("after Update: %+v", got)
+		}
+		if err := r.Update(ctx, domain.User{ID: 999, Email: "x@y.z"}); !errors.Is(err, domain.ErrNotFound) {
+			t.Errorf("Update(999) = %v; want ErrNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,126 @@
+// Package sqlite is a UserRepository adapter backed by SQLite
+// (modernc.org/sqlite, pure Go). All SQL in the service lives here.
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/domain"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS users (
+	id         INTEGER PRIMARY KEY AUTOINCREMENT,
+	name       TEXT NOT NULL,
+	email      TEXT NOT NULL UNIQUE,
+	created_at INTEGER NOT NULL, -- Unix nanoseconds, UTC
+	updated_at INTEGER NOT NULL
+);`
+
+// Repo is safe for concurrent use.
+type Repo struct {
+	db *sql.DB
+}
+
+// Open opens (creating if needed) the database at path.
+func Open(path string) (*Repo, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &Repo{db: db}, nil
+}
+
+func (r *Repo) Close() error { return r.db.Close() }
+
+// Ping lets a readiness check see the database.
+func (r *Repo) Ping(ctx context.Context) error { return r.db.PingContext(ctx) }
+
+func (r *Repo) Create(ctx context.Context, u domain.User) (domain.User, error) {
+	res, err := r.db.ExecContext(ctx,
+		`INSERT INTO users (name, email, created_at, updated_at) VALUES (?, ?, ?, ?)`,
+		u.Name, u.Email, u.CreatedAt.UnixNano(), u.UpdatedAt.UnixNano())
+	if err != nil {
+		return domain.User{}, mapError(err)
+	}
+	if u.ID, err = res.LastInsertId(); err != nil {
+		return domain.User{}, err
+	}
+	return u, nil
+}
+
+func (r *Repo) Get(ctx context.Context, id int64) (domain.User, error) {
+	row := r.db.QueryRowContext(ctx,
+		`SELECT id, name, email, created_at, updated_at FROM users WHERE id = ?`, id)
+	u, err := scan(row)
+	if errors.Is(err, sql.ErrNoRows) {
+		return domain.User{}, domain.ErrNotFound
+	}
+	return u, err
+}
+
+func (r *Repo) List(ctx context.Context, afterID int64, limit int) ([]domain.User, error) {
+	rows, err := r.db.QueryContext(ctx,
+		`SELECT id, name, email, created_at, updated_at FROM users WHERE id > ? ORDER BY id LIMIT ?`,
+		afterID, limit)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []domain.User
+	for rows.Next() {
+		u, err := scan(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, u)
+	}
+	return out, rows.Err()
+}
+
+func (r *Repo) Update(ctx context.Context, u domain.User) error {
+	res, err := r.db.ExecContext(ctx,
+		`UPDATE users SET name = ?, email = ?, updated_at = ? WHERE id = ?`,
+		u.Name, u.Email, u.UpdatedAt.UnixNano(), u.ID)
+	if err != nil {
+		return mapError(err)
+	}
+	if n, err := res.RowsAffected(); err != nil {
+		return err
+	} else if n == 0 {
+		return domain.ErrNotFound
+	}
+	return nil
+}
+
+func scan(row interface{ Scan(...any) error }) (domain.User, error) {
+	var u domain.User
+	var created, updated int64
+	if err := row.Scan(&u.ID, &u.Name, &u.Email, &created, &updated); err != nil {
+		return domain.User{}, err
+	}
+	u.CreatedAt = time.Unix(0, created).UTC()
+	u.UpdatedAt = time.Unix(0, updated).UTC()
+	return u, nil
+}
+
+// mapError turns the unique index on email into the domain's error, so
+// callers never see a SQLite error code.
+func mapError(err error) error {
+	var se *sqlite.Error
+	if errors.As(err, &se) && se.Code() == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+		return domain.ErrEmailTaken
+	}
+	return err
+}
@@ -0,0 +1,20 @@
+package sqlite
+
+import (
+	"path/filepath"
+	"testing"
+
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/adapters/repotest"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/ports"
+)
+
+func TestRepo(t *testing.T) {
+	repotest.Run(t, func(t *testing.T) ports.UserRepository {
+		r, err := Open(filepath.Join(t.TempDir(), "users.db"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() { r.Close() })
+		return r
+	})
+}
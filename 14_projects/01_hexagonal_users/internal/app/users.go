@@ -0,0 +1,64 @@
+// Package app implements the use cases: it loads and stores domain
+// objects through the ports and applies the domain rules. It knows no
+// HTTP, SQL or JSON.
+package app
+
+import (
+	"context"
+	"fmt"
+
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/domain"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/ports"
+)
+
+// Page sizes for List.
+const (
+	DefaultLimit = 20
+	MaxLimit     = 100
+)
+
+// Users implements ports.UserService.
+type Users struct {
+	repo  ports.UserRepository
+	clock ports.Clock
+}
+
+var _ ports.UserService = (*Users)(nil)
+
+func NewUsers(repo ports.UserRepository, clock ports.Clock) *Users {
+	return &Users{repo: repo, clock: clock}
+}
+
+func (s *Users) Register(ctx context.Context, name, email string) (domain.User, error) {
+	u, err := domain.NewUser(name, email, s.clock.Now().UTC())
+	if err != nil {
+		return domain.User{}, err
+	}
+	return s.repo.Create(ctx, u)
+}
+
+func (s *Users) Get(ctx context.Context, id int64) (domain.User, error) {
+	return s.repo.Get(ctx, id)
+}
+
+// List clamps limit to 1..MaxLimit; 0 means DefaultLimit.
+func (s *Users) List(ctx context.Context, afterID int64, limit int) ([]domain.User, error) {
+	if limit <= 0 {
+		limit = DefaultLimit
+	}
+	return s.repo.List(ctx, afterID, min(limit, MaxLimit))
+}
+
+func (s *Users) Rename(ctx context.Context, id int64, name string) (domain.User, error) {
+	u, err := s.repo.Get(ctx, id)
+	if err != nil {
+		return domain.User{}, err
+	}
+	if err := u.Rename(name, s.clock.Now().UTC()); err != nil {
+		return domain.User{}, err
+	}
+	if err := s.repo.Update(ctx, u); err != nil {
+		return domain.User{}, fmt.Errorf("rename user %d: %w", id, err)
+	}
+	return u, nil
+}
@@ -0,0 +1,61 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"golang_roadmap/04_Tooling_testing_and_code_quality/07_clock/clock"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/adapters/memory"
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/domain"
+)
+
+// The use cases run against the in-memory adapter, which repotest keeps
+// honest, and a fake clock.
+
+var start = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestRegisterAndRename(t *testing.T) {
+	ctx := context.Background()
+	clk := clock.NewFake(start)
+	s := NewUsers(memory.New(), clk)
+
+	u, err := s.Register(ctx, "Ada", "ada@example.com")
+	if err != nil || u.ID == 0 || !u.CreatedAt.Equal(start) {
+		t.Fatalf("Register = %+v, %v", u, err)
+	}
+	if _, err := s.Register(ctx, "Imposter", "ADA@example.com"); !errors.Is(err, domain.ErrEmailTaken) {
+		t.Errorf("duplicate email, other case: %v; want ErrEmailTaken", err)
+	}
+	if _, err := s.Register(ctx, "", "x"); !domain.IsInvalid(err) {
+		t.Errorf("invalid input: %v", err)
+	}
+
+	clk.Advance(time.Hour)
+	u, err = s.Rename(ctx, u.ID, "Ada Lovelace")
+	if err != nil || u.Name != "Ada Lovelace" || !u.UpdatedAt.Equal(start.Add(time.Hour)) {
+		t.Fatalf("Rename = %+v, %v", u, err)
+	}
+	if got, _ := s.Get(ctx, u.ID); got != u {
+		t.Errorf("Get after Rename = %+v; want %+v", got, u)
+	}
+	if _, err := s.Rename(ctx, 999, "X"); !errors.Is(err, domain.ErrNotFound) {
+		t.Errorf("Rename(999) = %v; want ErrNotFound", err)
+	}
+}
+
+func TestList_Limits(t *testing.T) {
+	ctx := context.Background()
+	s := NewUsers(memory.New(), clock.NewFake(start))
+	for i := range MaxLimit + 5 {
+		if _, err := s.Register(ctx, "U", string(rune('a'+i%26))+string(rune('a'+i/26))+"@x.io"); err != nil {
+			t.Fatal(err)
+		}
+	}
+	for limit, want := range map[int]int{0: DefaultLimit, -1: DefaultLimit, 5: 5, 1000: MaxLimit} {
+		if got, _ := s.List(ctx, 0, limit); len(got) != want {
+			t.Errorf("List(limit %d) = %d users; want %d", limit, len(got), want)
+		}
+	}
+}
@@ -0,0 +1,69 @@
+// Package domain holds the users service's business types and rules. It
+// imports no other layer and nothing that does I/O: what a valid user is,
+// how a user changes, and the errors that mean something to a caller.
+package domain
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+	"time"
+
+	"golang_roadmap/08_web_development/04_validation/validate"
+)
+
+// User is a registered user. Emails are stored lower-cased, so they
+// compare equal however they were typed.
+type User struct {
+	ID        int64
+	Name      string
+	Email     string
+	CreatedAt time.Time
+	UpdatedAt time.Time
+}
+
+var (
+	ErrNotFound   = errors.New("user not found")
+	ErrEmailTaken = errors.New("email already registered")
+)
+
+// NewUser returns a valid user, not yet stored (ID 0), or the field
+// errors from package validate.
+func NewUser(name, email string, now time.Time) (User, error) {
+	name = validate.Clean(name)
+	email = strings.ToLower(strings.TrimSpace(email))
+	var v validate.Validator
+	checkName(&v, name)
+	v.String("email", email).NonEmpty().MaxLen(254).Custom(isAddress, "must be an email address")
+	if err := v.Err(); err != nil {
+		return User{}, err
+	}
+	return User{Name: name, Email: email, CreatedAt: now, UpdatedAt: now}, nil
+}
+
+// Rename changes u's name. On error u is unchanged.
+func (u *User) Rename(name string, now time.Time) error {
+	name = validate.Clean(name)
+	var v validate.Validator
+	checkName(&v, name)
+	if err := v.Err(); err != nil {
+		return err
+	}
+	u.Name, u.UpdatedAt = name, now
+	return nil
+}
+
+// IsInvalid reports whether err is a validation failure, as opposed to a
+// failure of the system.
+func IsInvalid(err error) bool { return len(validate.Fields(err)) > 0 }
+
+func checkName(v *validate.Validator, name string) {
+	v.String("name", name).NonEmpty().MaxLen(100)
+}
+
+// isAddress accepts a bare address ("ada@example.com"), not a display
+// name form ("Ada <ada@example.com>").
+func isAddress(s string) bool {
+	a, err := mail.ParseAddress(s)
+	return err == nil && a.Address == s
+}
@@ -0,0 +1,43 @@
+package domain
+
+import (
+	"testing"
+	"time"
+
+	"golang_roadmap/08_web_development/04_validation/validate"
+)
+
+var now = time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+func TestNewUser(t *testing.T) {
+	u, err := NewUser(" Ada\u200b ", " Ada@Example.COM ", now)
+	if err != nil || u.Name != "Ada" || u.Email != "ada@example.com" || !u.CreatedAt.Equal(now) || u.ID != 0 {
+		t.Fatalf("NewUser = %+v, %v", u, err)
+	}
+
+	for _, tt := range []struct{ name, email, want string }{
+		{"", "ada@example.com", "name: must not be empty"},
+		{"Ada", "", "email: must not be empty"},
+		{"Ada", "ada", "email: must be an email address"},
+		{"Ada", "Ada <ada@example.com>", "email: must be an email address"},
+	} {
+		_, err := NewUser(tt.name, tt.email, now)
+		if !IsInvalid(err) || err.Error() != tt.want {
+			t.Errorf("NewUser(%q, %q) = %v; want %q", tt.name, tt.email, err, tt.want)
+		}
+	}
+	if _, err := NewUser("", "", now); len(validate.Fields(err)) != 2 {
+		t.Errorf("both empty: %v; want both fields reported", err)
+	}
+}
+
+func TestRename(t *testing.T) {
+	u, _ := NewUser("Ada", "ada@example.com", now)
+	later := now.Add(time.Hour)
+	if err := u.Rename("", later); !IsInvalid(err) || u.Name != "Ada" || !u.UpdatedAt.Equal(now) {
+		t.Errorf("Rename(\"\") = %v, user %+v; want an error and no change", err, u)
+	}
+	if err := u.Rename("Ada Lovelace", later); err != nil || u.Name != "Ada Lovelace" || !u.UpdatedAt.Equal(later) {
+		t.Errorf("Rename = %v, user %+v", err, u)
+	}
+}
@@ -0,0 +1,41 @@
+// Package ports declares the boundaries of the application: the
+// interfaces it offers (driving ports) and the ones it needs (driven
+// ports). Adapters implement or call them; the application core depends
+// on nothing else.
+package ports
+
+import (
+	"context"
+	"time"
+
+	"golang_roadmap/14_projects/01_hexagonal_users/internal/domain"
+)
+
+// UserService is the driving port: what HTTP, a CLI or a test can ask of
+// the application.
+type UserService interface {
+	Register(ctx context.Context, name, email string) (domain.User, error)
+	Get(ctx context.Context, id int64) (domain.User, error)
+	// List returns up to limit users with IDs above afterID, in ID order.
+	List(ctx context.Context, afterID int64, limit int) ([]domain.User, error)
+	Rename(ctx context.Context, id int64, name string) (domain.User, error)
+}
+
+// UserRepository is a driven port: storage for users. Every
+// implementation must pass repotest.Run.
+type UserRepository interface {
+	// Create stores u with a new ID and returns it. A duplicate email is
+	// domain.ErrEmailTaken.
+	Create(ctx context.Context, u domain.User) (domain.User, error)
+	// Get returns domain.ErrNotFound for an unknown ID.
+	Get(ctx context.Context, id int64) (domain.User, error)
+	List(ctx context.Context, afterID int64, limit int) ([]domain.User, error)
+	// Update overwrites the stored user with u.ID.
+	Update(ctx context.Context, u domain.User) error
+}
+
+// Clock is a driven port for the current time. clock.Clock from
+// 04_Tooling_testing_and_code_quality/07_clock satisfies it.
+type Clock interface {
+	Now() time.Time
+}
@@ -0,0 +1,168 @@
+// Command shortener serves the URL shortener.
+//
+//	go run ./cmd/shortener -db links.db -base-url http://localhost:8080 -debug-addr localhost:6060
+package main
+
+import (
+	"context"
+	"errors"
+	"expvar"
+	"flag"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"os"
+	"os/signal"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"golang_roadmap/12_operations/01_health/health"
+	"golang_roadmap/12_operations/05_runtime_metrics/debugvars"
+	"golang_roadmap/14_projects/02_url_shortener/internal/shortener"
+)
+
+// Config is the command line.
+type Config struct {
+	Addr          string
+	DebugAddr     string // empty: no /debug/vars listener
+	DB            string
+	BaseURL       string // empty: http://<Addr>
+	FlushInterval time.Duration
+	Shutdown      time.Duration
+}
+
+func main() {
+	var cfg Config
+	flag.StringVar(&cfg.Addr, "addr", "localhost:8080", "listen address")
+	flag.StringVar(&cfg.DebugAddr, "debug-addr", "", "address for /debug/vars (default: off)")
+	flag.StringVar(&cfg.DB, "db", "links.db", "SQLite database file")
+	flag.StringVar(&cfg.BaseURL, "base-url", "", "public URL short links start with (default http://<addr>)")
+	flag.DurationVar(&cfg.FlushInterval, "flush", 5*time.Second, "how often hit counts are written")
+	flag.DurationVar(&cfg.Shutdown, "shutdown-timeout", 10*time.Second, "time to drain requests on shutdown")
+	jsonLogs := flag.Bool("json", false, "log JSON instead of text")
+	flag.Parse()
+
+	var h slog.Handler = slog.NewTextHandler(os.Stderr, nil)
+	if *jsonLogs {
+		h = slog.NewJSONHandler(os.Stderr, nil)
+	}
+	log := slog.New(h)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, cfg, log, nil); err != nil {
+		log.Error("Shortener failed", "err", err)
+		os.Exit(1)
+	}
+}
+
+// expvar names are process-wide and publishing one twice panics, but the
+// integration test calls run more than once. So the variables are
+// published once and read whichever service is current.
+var (
+	publishOnce sync.Once
+	httpStats   *debugvars.HTTPStats
+	current     atomic.Pointer[shortener.Service]
+)
+
+func publish(svc *shortener.Service) {
+	current.Store(svc)
+	publishOnce.Do(func() {
+		httpStats = debugvars.NewHTTPStats("http")
+		expvar.Publish("shortener", expvar.Func(func() any { return current.Load().Stats.Snapshot() }))
+	})
+}
+
+// run serves until ctx is done, then shuts down in order: fail readiness,
+// drain requests, write the last hit counts, close the database. ready,
+// if set, is called with the listening address.
+func run(ctx context.Context, cfg Config, log *slog.Logger, ready func(addr string)) error {
+	store, err := shortener.OpenStore(cfg.DB)
+	if err != nil {
+		return err
+	}
+	defer store.Close()
+
+	ln, err := net.Listen("tcp", cfg.Addr)
+	if err != nil {
+		return err
+	}
+	if cfg.BaseURL == "" {
+		cfg.BaseURL = "http://" + ln.Addr().String()
+	}
+	svc, err := shortener.NewService(store, log, shortener.Options{BaseURL: cfg.BaseURL})
+	if err != nil {
+		ln.Close()
+		return err
+	}
+
+	// Metrics: request counts from debugvars, the service's own counters.
+	publish(svc)
+	checks := health.New()
+	checks.Register(health.Readiness, "db", health.DBPing(store), health.WithTimeout(time.Second))
+
+	mux := http.NewServeMux()
+	checks.Mount(mux)
+	mux.Handle("/", httpStats.Middleware(svc.Handler()))
+	srv := &http.Server{
+		Handler:           mux,
+		ReadHeaderTimeout: 5 * time.Second,
+		ReadTimeout:       10 * time.Second,
+		WriteTimeout:      10 * time.Second,
+		ErrorLog:          slog.NewLogLogger(log.Handler(), slog.LevelError),
+	}
+
+	flushCtx, stopFlush := context.WithCancel(context.Background())
+	flushDone := make(chan struct{})
+	go func() {
+		svc.RunFlusher(flushCtx, cfg.FlushInterval)
+		close(flushDone)
+	}()
+
+	var debugSrv *http.Server
+	if cfg.DebugAddr != "" {
+		debugSrv = debugvars.NewServer(cfg.DebugAddr)
+		go func() {
+			if err := debugSrv.ListenAndServe(); !errors.Is(err, http.ErrServerClosed) {
+				log.Error("Debug server failed", "err", err)
+			}
+		}()
+	}
+
+	errc := make(chan error, 1)
+	go func() { errc <- srv.Serve(ln) }()
+	log.Info("Listening", "addr", ln.Addr().String(), "base_url", cfg.BaseURL, "db", cfg.DB)
+	if ready != nil {
+		ready(ln.Addr().String())
+	}
+
+	select {
+	case err := <-errc:
+		stopFlush()
+		return err
+	case <-ctx.Done():
+	}
+
+	log.Info("Shutting down")
+	checks.MarkShuttingDown()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.Shutdown)
+	defer cancel()
+	var errs []error
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		errs = append(errs, fmt.Errorf("drain requests: %w", err))
+	}
+	if debugSrv != nil {
+		debugSrv.Close()
+	}
+	// No requests are running now, so no more hits can arrive.
+	stopFlush()
+	<-flushDone
+	if err := svc.FlushHits(shutdownCtx); err != nil {
+		errs = append(errs, err)
+	}
+	log.Info("Stopped")
+	return errors.Join(errs...)
+}
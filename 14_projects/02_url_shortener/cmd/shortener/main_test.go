@@ -0,0 +1,85 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// start runs the whole program on a free port and returns its address and
+// a function that shuts it down and returns run's error.
+func start(t *testing.T, db string) (string, func() error) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+	addrc := make(chan string, 1)
+	errc := make(chan error, 1)
+	cfg := Config{Addr: "127.0.0.1:0", DB: db, FlushInterval: time.Hour, Shutdown: 5 * time.Second}
+	go func() { errc <- run(ctx, cfg, slog.New(slog.DiscardHandler), func(a string) { addrc <- a }) }()
+	select {
+	case addr := <-addrc:
+		return "http://" + addr, func() error { cancel(); return <-errc }
+	case err := <-errc:
+		cancel()
+		t.Fatalf("run: %v", err)
+	}
+	return "", nil
+}
+
+var noRedirects = &http.Client{CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse }}
+
+func do(t *testing.T, method, url, body string) (int, http.Header, string) {
+	t.Helper()
+	req, _ := http.NewRequest(method, url, strings.NewReader(body))
+	resp, err := noRedirects.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	b, _ := io.ReadAll(resp.Body)
+	return resp.StatusCode, resp.Header, string(b)
+}
+
+// TestIntegration goes through the real listener, database and shutdown
+// path, then restarts on the same database.
+func TestIntegration(t *testing.T) {
+	if testing.Short() {
+		t.Skip("starts servers")
+	}
+	db := filepath.Join(t.TempDir(), "links.db")
+	base, stop := start(t, db)
+
+	code, _, body := do(t, "POST", base+"/api/links", `{"url":"https://go.dev/blog/","code":"blog"}`)
+	var created struct {
+		ShortURL string `json:"short_url"`
+	}
+	json.Unmarshal([]byte(body), &created)
+	if code != http.StatusCreated || created.ShortURL != base+"/blog" {
+		t.Fatalf("create: %d %s", code, body)
+	}
+	for range 3 {
+		if code, h, _ := do(t, "GET", created.ShortURL, ""); code != http.StatusFound || h.Get("Location") != "https://go.dev/blog/" {
+			t.Fatalf("redirect: %d %v", code, h)
+		}
+	}
+	if code, _, body := do(t, "GET", base+"/readyz", ""); code != http.StatusOK {
+		t.Errorf("readyz: %d %s", code, body)
+	}
+
+	// The flush interval is an hour: the hits reach the database only
+	// through the flush at shutdown.
+	if err := stop(); err != nil {
+		t.Fatalf("shutdown: %v", err)
+	}
+	base, stop = start(t, db)
+	defer stop()
+	code, _, body = do(t, "GET", base+"/api/links/blog", "")
+	if code != http.StatusOK || !strings.Contains(body, `"hits":3`) {
+		t.Errorf("after restart: %d %s; want 3 hits", code, body)
+	}
+}
@@ -0,0 +1,154 @@
+package shortener
+
+import (
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"golang_roadmap/08_web_development/04_validation/validate"
+	"golang_roadmap/08_web_development/07_qrcode/qr"
+)
+
+type linkJSON struct {
+	Code      string    `json:"code"`
+	ShortURL  string    `json:"short_url"`
+	URL       string    `json:"url"`
+	CreatedAt time.Time `json:"created_at"`
+	Hits      int64     `json:"hits"`
+}
+
+// Handler serves the API and the redirects:
+//
+//	POST /api/links               {"url": "https://...", "code": "optional"}
+//	GET  /api/links/{code}        the link and its hit count
+//	GET  /api/links/{code}/qr.png its short URL as a QR code, ?size=64-1024&level=L|M|Q|H
+//	GET  /{code}                  302 to the link's URL
+func (s *Service) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /api/links", s.create)
+	mux.HandleFunc("GET /api/links/{code}", s.show)
+	mux.HandleFunc("GET /api/links/{code}/qr.png", s.qrCode)
+	mux.HandleFunc("GET /{code}", s.redirect)
+	return logRequests(s.log, mux)
+}
+
+func (s *Service) toJSON(l Link) linkJSON {
+	return linkJSON{Code: l.Code, ShortURL: s.ShortURL(l.Code), URL: l.URL, CreatedAt: l.CreatedAt, Hits: l.Hits}
+}
+
+func (s *Service) create(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		URL  string `json:"url"`
+		Code string `json:"code"`
+	}
+	dec := json.NewDecoder(http.MaxBytesReader(w, r.Body, 8<<10))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "invalid JSON"})
+		return
+	}
+	l, err := s.Shorten(r.Context(), body.URL, body.Code)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	w.Header().Set("Location", "/api/links/"+l.Code)
+	writeJSON(w, http.StatusCreated, s.toJSON(l))
+}
+
+func (s *Service) show(w http.ResponseWriter, r *http.Request) {
+	l, err := s.Link(r.Context(), r.PathValue("code"))
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	writeJSON(w, http.StatusOK, s.toJSON(l))
+}
+
+// qrCode draws the short URL, not the target: scans go through the
+// redirect and are counted. Links never change, so the lookup goes through
+// the redirect cache, without counting a hit, and the image may be cached
+// for a day.
+func (s *Service) qrCode(w http.ResponseWriter, r *http.Request) {
+	opt, err := qr.ParseOptions(r.URL.Query())
+	if err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+	l, err := s.links.GetOrLoad(r.Context(), r.PathValue("code"), s.store.Get)
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	if err := qr.Serve(w, r, s.ShortURL(l.Code), opt, 24*time.Hour); err != nil {
+		s.fail(w, r, err)
+	}
+}
+
+// redirect answers 302, not 301: browsers cache a 301 for good and would
+// stop coming back, so hits would go uncounted.
+func (s *Service) redirect(w http.ResponseWriter, r *http.Request) {
+	l, err := s.Resolve(r.Context(), r.PathValue("code"))
+	if errors.Is(err, ErrNotFound) {
+		http.NotFound(w, r)
+		return
+	}
+	if err != nil {
+		s.fail(w, r, err)
+		return
+	}
+	http.Redirect(w, r, l.URL, http.StatusFound)
+}
+
+func (s *Service) fail(w http.ResponseWriter, r *http.Request, err error) {
+	switch fields := validate.Fields(err); {
+	case len(fields) > 0:
+		msgs := map[string]string{}
+		for _, fe := range fields {
+			msgs[fe.Field] = fe.Message()
+		}
+		writeJSON(w, http.StatusUnprocessableEntity, map[string]any{"error": "invalid link", "fields": msgs})
+	case errors.Is(err, ErrNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
+	case errors.Is(err, ErrCodeTaken):
+		writeJSON(w, http.StatusConflict, map[string]string{"error": err.Error()})
+	default:
+		s.log.ErrorContext(r.Context(), "Request failed", "method", r.Method, "path", r.URL.Path, "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "internal error"})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, code int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(v)
+}
+
+// logRequests logs one line per request at Info, with its status and
+// duration.
+func logRequests(log *slog.Logger, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &statusWriter{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		log.LogAttrs(r.Context(), slog.LevelInfo, "request",
+			slog.String("method", r.Method),
+			slog.String("path", r.URL.Path),
+			slog.Int("status", rec.status),
+			slog.Duration("took", time.Since(start)))
+	})
+}
+
+type statusWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (w *statusWriter) WriteHeader(code int) {
+	w.status = code
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *statusWriter) Unwrap() http.ResponseWriter { return w.ResponseWriter }
@@ -0,0 +1,92 @@
+package shortener
+
+import (
+	"image/png"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestHandler(t *testing.T) {
+	svc, _ := newTestService(t, Options{
+		NewCode: func() string { return "rnd1234" },
+		Now:     func() time.Time { return time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC) },
+	})
+	h := svc.Handler()
+
+	for _, tt := range []struct {
+		method, path, body string
+		code               int
+		want               string
+	}{
+		{"POST", "/api/links", `{"url":"https://go.dev/doc/"}`, 201,
+			`{"code":"rnd1234","short_url":"https://sho.rt/rnd1234","url":"https://go.dev/doc/","created_at":"2024-03-01T12:00:00Z","hits":0}`},
+		{"POST", "/api/links", `{"url":"https://go.dev","code":"rnd1234"}`, 409, `{"error":"code already in use"}`},
+		{"POST", "/api/links", `{"url":"go.dev","code":"x"}`, 422,
+			`{"error":"invalid link","fields":{"code":"must be at least 3 characters","url":"must be an absolute http or https URL to another site"}}`},
+		{"POST", "/api/links", `{"link":"https://go.dev"}`, 400, `{"error":"invalid JSON"}`},
+		{"GET", "/rnd1234", "", 302, `<a href="https://go.dev/doc/">Found</a>.`},
+		{"GET", "/missing", "", 404, "404 page not found"},
+		{"GET", "/api/links/rnd1234", "", 200,
+			`{"code":"rnd1234","short_url":"https://sho.rt/rnd1234","url":"https://go.dev/doc/","created_at":"2024-03-01T12:00:00Z","hits":1}`},
+		{"GET", "/api/links/missing", "", 404, `{"error":"link not found"}`},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest(tt.method, tt.path, strings.NewReader(tt.body)))
+		if got := strings.TrimSpace(rec.Body.String()); rec.Code != tt.code || got != tt.want {
+			t.Errorf("%s %s = %d %s\nwant %d %s", tt.method, tt.path, rec.Code, got, tt.code, tt.want)
+		}
+		if tt.code == http.StatusFound && rec.Header().Get("Location") != "https://go.dev/doc/" {
+			t.Errorf("redirect Location = %q", rec.Header().Get("Location"))
+		}
+	}
+}
+
+func TestQRCode(t *testing.T) {
+	svc, _ := newTestService(t, Options{NewCode: func() string { return "rnd1234" }})
+	h := svc.Handler()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("POST", "/api/links", strings.NewReader(`{"url":"https://go.dev/"}`)))
+	if rec.Code != http.StatusCreated {
+		t.Fatalf("create: %d %s", rec.Code, rec.Body)
+	}
+
+	for _, tt := range []struct {
+		path string
+		code int
+		want string
+	}{
+		{"/api/links/rnd1234/qr.png?size=300&level=h", 200, ""},
+		{"/api/links/missing/qr.png", 404, `{"error":"link not found"}`},
+		{"/api/links/rnd1234/qr.png?level=X", 400, `{"error":"level must be one of L, M, Q or H, not \"X\""}`},
+		{"/api/links/rnd1234/qr.png?size=10", 400, `{"error":"size must be a number from 64 to 1024"}`},
+	} {
+		rec := httptest.NewRecorder()
+		h.ServeHTTP(rec, httptest.NewRequest("GET", tt.path, nil))
+		if rec.Code != tt.code {
+			t.Errorf("GET %s = %d %s, want %d", tt.path, rec.Code, rec.Body, tt.code)
+			continue
+		}
+		if tt.want != "" {
+			if got := strings.TrimSpace(rec.Body.String()); got != tt.want {
+				t.Errorf("GET %s = %s\nwant %s", tt.path, got, tt.want)
+			}
+			continue
+		}
+		if ct := rec.Header().Get("Content-Type"); ct != "image/png" || rec.Header().Get("ETag") == "" {
+			t.Errorf("GET %s: Content-Type %q, ETag %q", tt.path, ct, rec.Header().Get("ETag"))
+		}
+		img, err := png.Decode(rec.Body)
+		if err != nil || img.Bounds().Dx() > 300 || img.Bounds().Dx() < 200 {
+			t.Errorf("GET %s: image %v, %v", tt.path, img, err)
+		}
+	}
+
+	// A QR code is not a visit: scans are counted when they follow the
+	// short URL.
+	if n := svc.PendingHits(); n != 0 {
+		t.Errorf("pending hits = %d, want 0", n)
+	}
+}
@@ -0,0 +1,235 @@
+// Package shortener is a URL shortening service: links in SQLite, a
+// read-through cache in front of them for redirects, and hit counts
+// batched in memory and written periodically.
+package shortener
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang_roadmap/08_web_development/04_validation/validate"
+	"golang_roadmap/13_concurrency/01_cache/cache"
+)
+
+const (
+	codeLen      = 7
+	codeAlphabet = "abcdefghijkmnopqrstuvwxyzABCDEFGHJKLMNPQRSTUVWXYZ23456789" // no 0/O, 1/l/I
+	maxURLLen    = 2048
+)
+
+var customCodeRe = regexp.MustCompile(`^[A-Za-z0-9_-]+$`)
+
+// Stats are the service's counters, for /debug/vars.
+type Stats struct {
+	Created     atomic.Int64
+	Redirects   atomic.Int64
+	CacheHits   atomic.Int64
+	CacheMisses atomic.Int64
+}
+
+// Snapshot returns the counters by name, for an expvar.Func.
+func (s *Stats) Snapshot() map[string]int64 {
+	return map[string]int64{
+		"links_created": s.Created.Load(),
+		"redirects":     s.Redirects.Load(),
+		"cache_hits":    s.CacheHits.Load(),
+		"cache_misses":  s.CacheMisses.Load(),
+	}
+}
+
+// Options configures a Service. Zero values get defaults.
+type Options struct {
+	CacheSize int           // links kept for redirects (default 10000)
+	CacheTTL  time.Duration // default 10m
+	// BaseURL is the public address short links are built on, such as
+	// "https://sho.rt". Links to it are refused, so the service cannot
+	// redirect in circles.
+	BaseURL string
+
+	Now     func() time.Time // default time.Now
+	NewCode func() string    // default: 7 random characters
+}
+
+// Service creates and resolves links. It is safe for concurrent use.
+type Service struct {
+	store *Store
+	opts  Options
+	base  *url.URL
+	links *cache.Cache[string, Link]
+	log   *slog.Logger
+	Stats Stats
+
+	mu   sync.Mutex
+	hits map[string]int64 // not yet flushed
+}
+
+func NewService(store *Store, log *slog.Logger, opts Options) (*Service, error) {
+	base, err := url.Parse(opts.BaseURL)
+	if err != nil || base.Host == "" {
+		return nil, fmt.Errorf("shortener: base URL %q must be absolute", opts.BaseURL)
+	}
+	if opts.CacheSize <= 0 {
+		opts.CacheSize = 10000
+	}
+	if opts.CacheTTL <= 0 {
+		opts.CacheTTL = 10 * time.Minute
+	}
+	if opts.Now == nil {
+		opts.Now = time.Now
+	}
+	if opts.NewCode == nil {
+		opts.NewCode = randomCode
+	}
+	s := &Service{store: store, opts: opts, base: base, log: log, hits: make(map[string]int64)}
+	s.links = cache.New(cache.Options[string, Link]{
+		MaxEntries: opts.CacheSize,
+		TTL:        opts.CacheTTL,
+		OnHit:      func(string) { s.Stats.CacheHits.Add(1) },
+		OnMiss:     func(string) { s.Stats.CacheMisses.Add(1) },
+		Now:        opts.Now,
+	})
+	return s, nil
+}
+
+// ShortURL is the public URL for code.
+func (s *Service) ShortURL(code string) string {
+	return s.base.JoinPath(code).String()
+}
+
+// Shorten stores a link to rawURL under code, or under a random code if
+// code is empty. Invalid input is reported as validate field errors.
+func (s *Service) Shorten(ctx context.Context, rawURL, code string) (Link, error) {
+	rawURL = strings.TrimSpace(rawURL)
+	var v validate.Validator
+	v.String("url", rawURL).NonEmpty().MaxLen(maxURLLen).Custom(s.allowedURL, "must be an absolute http or https URL to another site")
+	if code != "" {
+		v.String("code", code).MinLen(3).MaxLen(32).Matches(customCodeRe, "made of letters, digits, _ and -")
+	}
+	if err := v.Err(); err != nil {
+		return Link{}, err
+	}
+
+	l := Link{Code: code, URL: rawURL, CreatedAt: s.opts.Now().UTC().Truncate(time.Second)}
+	if code != "" {
+		if err := s.store.Create(ctx, l); err != nil {
+			return Link{}, err
+		}
+		s.Stats.Created.Add(1)
+		return l, nil
+	}
+	// Random codes collide rarely; try a few before giving up.
+	for range 5 {
+		l.Code = s.opts.NewCode()
+		err := s.store.Create(ctx, l)
+		if errors.Is(err, ErrCodeTaken) {
+			continue
+		}
+		if err != nil {
+			return Link{}, err
+		}
+		s.Stats.Created.Add(1)
+		return l, nil
+	}
+	return Link{}, errors.New("shortener: no free code after 5 attempts")
+}
+
+// Resolve returns the link for code and counts a hit. Links never change,
+// so redirects are served from the cache.
+func (s *Service) Resolve(ctx context.Context, code string) (Link, error) {
+	l, err := s.links.GetOrLoad(ctx, code, s.store.Get)
+	if err != nil {
+		return Link{}, err
+	}
+	s.mu.Lock()
+	s.hits[code]++
+	s.mu.Unlock()
+	s.Stats.Redirects.Add(1)
+	return l, nil
+}
+
+// Link returns the stored link with its hit count, including hits not
+// yet flushed.
+func (s *Service) Link(ctx context.Context, code string) (Link, error) {
+	l, err := s.store.Get(ctx, code)
+	if err != nil {
+		return Link{}, err
+	}
+	s.mu.Lock()
+	l.Hits += s.hits[code]
+	s.mu.Unlock()
+	return l, nil
+}
+
+// FlushHits writes the pending hit counts to the store. On failure they
+// are kept for the next flush.
+func (s *Service) FlushHits(ctx context.Context) error {
+	s.mu.Lock()
+	pending := s.hits
+	s.hits = make(map[string]int64)
+	s.mu.Unlock()
+	if len(pending) == 0 {
+		return nil
+	}
+	if err := s.store.AddHits(ctx, pending); err != nil {
+		s.mu.Lock()
+		for code, n := range pending {
+			s.hits[code] += n
+		}
+		s.mu.Unlock()
+		return fmt.Errorf("flush hits: %w", err)
+	}
+	s.log.Debug("Flushed hits", "links", len(pending))
+	return nil
+}
+
+// RunFlusher calls FlushHits every interval until ctx is done. The caller
+// flushes once more after stopping it, at shutdown.
+func (s *Service) RunFlusher(ctx context.Context, interval time.Duration) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+			if err := s.FlushHits(ctx); err != nil {
+				s.log.Warn("Flushing hits failed", "err", err)
+			}
+		}
+	}
+}
+
+// PendingHits is the number of links with unflushed hits.
+func (s *Service) PendingHits() int {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return len(s.hits)
+}
+
+func (s *Service) allowedURL(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return false
+	}
+	return !strings.EqualFold(u.Host, s.base.Host)
+}
+
+func randomCode() string {
+	b := make([]byte, codeLen)
+	rand.Read(b)
+	for i := range b {
+		// 256 is not a multiple of the alphabet's length, so a few
+		// characters are slightly more likely; that is fine for codes.
+		b[i] = codeAlphabet[int(b[i])%len(codeAlphabet)]
+	}
+	return string(b)
+}
@@ -0,0 +1,136 @@
+package shortener
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"golang_roadmap/08_web_development/04_validation/validate"
+)
+
+func newTestService(t *testing.T, opts Options) (*Service, *Store) {
+	t.Helper()
+	store, err := OpenStore(filepath.Join(t.TempDir(), "links.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { store.Close() })
+	if opts.BaseURL == "" {
+		opts.BaseURL = "https://sho.rt"
+	}
+	svc, err := NewService(store, slog.New(slog.DiscardHandler), opts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return svc, store
+}
+
+func TestShorten_Validation(t *testing.T) {
+	svc, _ := newTestService(t, Options{})
+	ctx := context.Background()
+	for _, tt := range []struct{ url, code, want string }{
+		{"", "", "url: must not be empty"},
+		{"example.com/page", "", "url: must be an absolute http or https URL to another site"},
+		{"ftp://example.com/file", "", "url: must be an absolute http or https URL to another site"},
+		{"https://SHO.RT/abc", "", "url: must be an absolute http or https URL to another site"},
+		{"https://example.com/" + strings.Repeat("a", 2048), "", "url: must be at most 2048 characters"},
+		{"https://example.com", "ab", "code: must be at least 3 characters"},
+		{"https://example.com", "has space", "code: must be made of letters, digits, _ and -"},
+	} {
+		_, err := svc.Shorten(ctx, tt.url, tt.code)
+		if len(validate.Fields(err)) != 1 || err.Error() != tt.want {
+			t.Errorf("Shorten(%.30q, %q) = %v; want %q", tt.url, tt.code, err, tt.want)
+		}
+	}
+}
+
+func TestShorten_Codes(t *testing.T) {
+	codes := []string{"taken01", "taken01", "fresh02"}
+	svc, _ := newTestService(t, Options{NewCode: func() string {
+		c := codes[0]
+		codes = codes[1:]
+		return c
+	}})
+	ctx := context.Background()
+
+	if l, err := svc.Shorten(ctx, "https://example.com/a", ""); err != nil || l.Code != "taken01" {
+		t.Fatalf("first = %+v, %v", l, err)
+	}
+	if l, err := svc.Shorten(ctx, "https://example.com/b", ""); err != nil || l.Code != "fresh02" {
+		t.Fatalf("after a collision = %+v, %v; want the next code", l, err)
+	}
+	if _, err := svc.Shorten(ctx, "https://example.com/c", "fresh02"); !errors.Is(err, ErrCodeTaken) {
+		t.Errorf("custom code in use: %v; want ErrCodeTaken", err)
+	}
+	if l, err := svc.Shorten(ctx, " https://example.com/c ", "go-docs"); err != nil || l.URL != "https://example.com/c" {
+		t.Errorf("custom code = %+v, %v", l, err)
+	}
+	if got := svc.ShortURL("go-docs"); got != "https://sho.rt/go-docs" {
+		t.Errorf("ShortURL = %q", got)
+	}
+}
+
+func TestRandomCode(t *testing.T) {
+	seen := map[string]bool{}
+	for range 1000 {
+		c := randomCode()
+		if len(c) != codeLen || strings.Trim(c, codeAlphabet) != "" {
+			t.Fatalf("randomCode() = %q", c)
+		}
+		seen[c] = true
+	}
+	if len(seen) < 1000 {
+		t.Errorf("%d repeats in 1000 codes", 1000-len(seen))
+	}
+}
+
+func TestResolve_CachesAndCountsHits(t *testing.T) {
+	svc, _ := newTestService(t, Options{})
+	ctx := context.Background()
+	svc.Shorten(ctx, "https://example.com", "abc")
+
+	for range 3 {
+		if l, err := svc.Resolve(ctx, "abc"); err != nil || l.URL != "https://example.com" {
+			t.Fatalf("Resolve = %+v, %v", l, err)
+		}
+	}
+	if _, err := svc.Resolve(ctx, "nope"); !errors.Is(err, ErrNotFound) {
+		t.Errorf("Resolve(unknown) = %v; want ErrNotFound", err)
+	}
+	if h, m := svc.Stats.CacheHits.Load(), svc.Stats.CacheMisses.Load(); h != 2 || m != 2 {
+		t.Errorf("cache hits/misses = %d/%d; want 2/2", h, m)
+	}
+
+	// Hits show up before and after a flush.
+	if l, _ := svc.Link(ctx, "abc"); l.Hits != 3 {
+		t.Errorf("before flush: %d hits; want 3", l.Hits)
+	}
+	if err := svc.FlushHits(ctx); err != nil || svc.PendingHits() != 0 {
+		t.Fatalf("FlushHits: %v, %d pending", err, svc.PendingHits())
+	}
+	svc.Resolve(ctx, "abc")
+	if l, _ := svc.Link(ctx, "abc"); l.Hits != 4 {
+		t.Errorf("after flush: %d hits; want 4", l.Hits)
+	}
+}
+
+func TestFlushHits_KeepsCountsOnFailure(t *testing.T) {
+	svc, store := newTestService(t, Options{CacheTTL: time.Hour})
+	ctx := context.Background()
+	svc.Shorten(ctx, "https://example.com", "abc")
+	svc.Resolve(ctx, "abc")
+	svc.Resolve(ctx, "abc")
+
+	store.Close()
+	if err := svc.FlushHits(ctx); err == nil {
+		t.Fatal("FlushHits on a closed store succeeded")
+	}
+	svc.Resolve(ctx, "abc") // from the cache
+	if svc.hits["abc"] != 3 {
+		t.Errorf("pending hits = %d; want the failed 2 plus 1", svc.hits["abc"])
+	}
+}
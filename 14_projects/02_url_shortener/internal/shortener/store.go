@@ -0,0 +1,99 @@
+package shortener
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// Link is a short code and the URL it points to.
+type Link struct {
+	Code      string
+	URL       string
+	CreatedAt time.Time
+	Hits      int64 // as of the last flush; see Service.FlushHits
+}
+
+var (
+	ErrNotFound  = errors.New("link not found")
+	ErrCodeTaken = errors.New("code already in use")
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS links (
+	code       TEXT PRIMARY KEY,
+	url        TEXT NOT NULL,
+	created_at INTEGER NOT NULL, -- Unix seconds
+	hits       INTEGER NOT NULL DEFAULT 0
+);`
+
+// Store keeps links in SQLite. It is safe for concurrent use.
+type Store struct {
+	db *sql.DB
+}
+
+// OpenStore opens (creating if needed) the database at path.
+func OpenStore(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error { return s.db.Close() }
+
+// PingContext makes the store a health.Pinger.
+func (s *Store) PingContext(ctx context.Context) error { return s.db.PingContext(ctx) }
+
+// Create inserts l. An existing code is ErrCodeTaken.
+func (s *Store) Create(ctx context.Context, l Link) error {
+	_, err := s.db.ExecContext(ctx, `INSERT INTO links (code, url, created_at) VALUES (?, ?, ?)`,
+		l.Code, l.URL, l.CreatedAt.Unix())
+	var se *sqlite.Error
+	if errors.As(err, &se) && se.Code() == sqlite3.SQLITE_CONSTRAINT_PRIMARYKEY {
+		return ErrCodeTaken
+	}
+	return err
+}
+
+func (s *Store) Get(ctx context.Context, code string) (Link, error) {
+	var l Link
+	var created int64
+	err := s.db.QueryRowContext(ctx, `SELECT code, url, created_at, hits FROM links WHERE code = ?`, code).
+		Scan(&l.Code, &l.URL, &created, &l.Hits)
+	if errors.Is(err, sql.ErrNoRows) {
+		return Link{}, ErrNotFound
+	}
+	l.CreatedAt = time.Unix(created, 0).UTC()
+	return l, err
+}
+
+// AddHits adds counts to the links' hit counters in one transaction.
+func (s *Store) AddHits(ctx context.Context, counts map[string]int64) error {
+	tx, err := s.db.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	stmt, err := tx.PrepareContext(ctx, `UPDATE links SET hits = hits + ? WHERE code = ?`)
+	if err != nil {
+		return err
+	}
+	defer stmt.Close()
+	for code, n := range counts {
+		if _, err := stmt.ExecContext(ctx, n, code); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,77 @@
+// Command chat is a terminal client for chatserver, built with Bubble Tea.
+//
+//	go run ./cmd/chat -server ws://localhost:8080 -room general -user ada
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"net/url"
+	"os"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+
+	"golang_roadmap/14_projects/03_chat/internal/chat"
+)
+
+func main() {
+	server := flag.String("server", "ws://localhost:8080", "chat server URL")
+	room := flag.String("room", "general", "room to join")
+	user := flag.String("user", os.Getenv("USER"), "your name")
+	flag.Parse()
+
+	if err := run(*server, *room, *user); err != nil {
+		fmt.Fprintln(os.Stderr, "chat:", err)
+		os.Exit(1)
+	}
+}
+
+func run(server, room, user string) error {
+	u, err := url.Parse(server)
+	if err != nil {
+		return err
+	}
+	u = u.JoinPath("ws")
+	u.RawQuery = url.Values{"room": {room}, "user": {user}}.Encode()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	dialCtx, dialCancel := context.WithTimeout(ctx, 5*time.Second)
+	conn, resp, err := websocket.Dial(dialCtx, u.String(), nil)
+	dialCancel()
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("connect: %s", resp.Status)
+		}
+		return fmt.Errorf("connect: %w", err)
+	}
+	defer conn.CloseNow()
+
+	send := func(text string) error {
+		ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		defer cancel()
+		return wsjson.Write(ctx, conn, chat.Event{Type: chat.TypeMessage, Text: text})
+	}
+	p := tea.NewProgram(newModel(room, user, send), tea.WithAltScreen())
+
+	// Events arrive on their own goroutine and enter the UI through
+	// p.Send, which is safe to call from anywhere.
+	go func() {
+		for {
+			var ev chat.Event
+			if err := wsjson.Read(ctx, conn, &ev); err != nil {
+				p.Send(closedMsg{err})
+				return
+			}
+			p.Send(eventMsg(ev))
+		}
+	}()
+
+	_, err = p.Run()
+	conn.Close(websocket.StatusNormalClosure, "bye")
+	return err
+}
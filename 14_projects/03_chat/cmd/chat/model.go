@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/charmbracelet/bubbles/textinput"
+	"github.com/charmbracelet/bubbles/viewport"
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+
+	"golang_roadmap/14_projects/03_chat/internal/chat"
+)
+
+// Messages the model receives besides key presses and resizes.
+type (
+	eventMsg  chat.Event
+	closedMsg struct{ err error } // the connection ended
+	sentMsg   struct{ err error } // result of sending the input line
+)
+
+var (
+	nameStyle   = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("6"))
+	ownStyle    = lipgloss.NewStyle().Bold(true).Foreground(lipgloss.Color("2"))
+	noticeStyle = lipgloss.NewStyle().Faint(true)
+	errorStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
+	headerStyle = lipgloss.NewStyle().Reverse(true).Padding(0, 1)
+)
+
+// model is the terminal UI: a header with the room and who is in it, the
+// scrolling conversation, and an input line. It does no I/O itself; send
+// runs in a tea.Cmd.
+type model struct {
+	room, user string
+	send       func(text string) error
+
+	lines    []string
+	presence []string
+	status   string
+	view     viewport.Model
+	input    textinput.Model
+	ready    bool
+}
+
+func newModel(room, user string, send func(string) error) model {
+	in := textinput.New()
+	in.Placeholder = "Say something; Enter sends, Esc quits"
+	in.CharLimit = 1000
+	in.Focus()
+	return model{room: room, user: user, send: send, input: in, view: viewport.New(80, 20)}
+}
+
+func (m model) Init() tea.Cmd { return textinput.Blink }
+
+func (m model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	switch msg := msg.(type) {
+	case tea.WindowSizeMsg:
+		m.view.Width = msg.Width
+		m.view.Height = max(msg.Height-3, 1) // header, input, status
+		m.input.Width = msg.Width - 4
+		m.ready = true
+		m.refresh()
+		return m, nil
+
+	case tea.KeyMsg:
+		switch msg.Type {
+		case tea.KeyEsc, tea.KeyCtrlC:
+			return m, tea.Quit
+		case tea.KeyEnter:
+			text := strings.TrimSpace(m.input.Value())
+			if text == "" {
+				return m, nil
+			}
+			m.input.Reset()
+			send := m.send
+			return m, func() tea.Msg { return sentMsg{send(text)} }
+		case tea.KeyPgUp, tea.KeyPgDown:
+			var cmd tea.Cmd
+			m.view, cmd = m.view.Update(msg)
+			return m, cmd
+		}
+
+	case eventMsg:
+		m.apply(chat.Event(msg))
+		m.refresh()
+		return m, nil
+
+	case sentMsg:
+		if msg.err != nil {
+			m.status = errorStyle.Render("send failed: " + msg.err.Error())
+		}
+		return m, nil
+
+	case closedMsg:
+		m.status = errorStyle.Render(fmt.Sprintf("disconnected: %v (Esc quits)", msg.err))
+		m.input.Blur()
+		return m, nil
+	}
+
+	var cmd tea.Cmd
+	m.input, cmd = m.input.Update(msg)
+	return m, cmd
+}
+
+// apply records an event from the server.
+func (m *model) apply(ev chat.Event) {
+	switch ev.Type {
+	case chat.TypeHistory:
+		for _, h := range ev.Messages {
+			m.lines = append(m.lines, m.format(h))
+		}
+		if len(ev.Messages) > 0 {
+			m.lines = append(m.lines, noticeStyle.Render("--- earlier messages above ---"))
+		}
+	case chat.TypeMessage:
+		m.lines = append(m.lines, m.format(ev))
+	case chat.TypeJoin:
+		m.lines = append(m.lines, noticeStyle.Render(ev.User+" joined"))
+	case chat.TypeLeave:
+		m.lines = append(m.lines, noticeStyle.Render(ev.User+" left"))
+	case chat.TypePresence:
+		m.presence = ev.Users
+	case chat.TypeError:
+		m.status = errorStyle.Render(ev.Text)
+	}
+}
+
+func (m model) format(ev chat.Event) string {
+	style := nameStyle
+	if ev.User == m.user {
+		style = ownStyle
+	}
+	return fmt.Sprintf("%s %s %s", noticeStyle.Render(ev.Time.Local().Format("15:04")), style.Render(ev.User+":"), ev.Text)
+}
+
+// refresh puts the lines in the viewport and follows the conversation
+// unless the user has scrolled up.
+func (m *model) refresh() {
+	atBottom := m.view.AtBottom()
+	m.view.SetContent(strings.Join(m.lines, "\n"))
+	if atBottom || !m.ready {
+		m.view.GotoBottom()
+	}
+}
+
+func (m model) View() string {
+	header := headerStyle.Render(fmt.Sprintf("#%s  %d online: %s", m.room, len(m.presence), strings.Join(m.presence, ", ")))
+	return header + "\n" + m.view.View() + "\n" + m.input.View() + "\n" + m.status
+}
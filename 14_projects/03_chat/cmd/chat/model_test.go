@@ -0,0 +1,76 @@
+package main
+
+import (
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+
+	"golang_roadmap/14_projects/03_chat/internal/chat"
+)
+
+// The model is tested without a terminal: feed it messages, look at View.
+
+func update(m model, msgs ...tea.Msg) (model, []tea.Cmd) {
+	var cmds []tea.Cmd
+	for _, msg := range msgs {
+		next, cmd := m.Update(msg)
+		m = next.(model)
+		cmds = append(cmds, cmd)
+	}
+	return m, cmds
+}
+
+func TestModel_ShowsEvents(t *testing.T) {
+	at := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	m := newModel("go", "ada", nil)
+	m, _ = update(m,
+		tea.WindowSizeMsg{Width: 80, Height: 12},
+		eventMsg{Type: chat.TypeHistory, Messages: []chat.Event{{Type: chat.TypeMessage, User: "bob", Text: "earlier", Time: at}}},
+		eventMsg{Type: chat.TypePresence, Users: []string{"ada", "bob"}},
+		eventMsg{Type: chat.TypeJoin, User: "cy"},
+		eventMsg{Type: chat.TypeMessage, User: "cy", Text: "hi all", Time: at},
+		eventMsg{Type: chat.TypeError, Text: "text: must not be empty"},
+	)
+	view := m.View()
+	for _, want := range []string{"#go", "2 online: ada, bob", "bob:", "earlier", "earlier messages above", "cy joined", "cy:", "hi all", "text: must not be empty"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("view lacks %q:\n%s", want, view)
+		}
+	}
+}
+
+func TestModel_EnterSends(t *testing.T) {
+	var sent []string
+	m := newModel("go", "ada", func(text string) error { sent = append(sent, text); return nil })
+	m, _ = update(m, tea.WindowSizeMsg{Width: 80, Height: 12})
+	for _, r := range " hello " {
+		m, _ = update(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{r}})
+	}
+	m, cmds := update(m, tea.KeyMsg{Type: tea.KeyEnter})
+	if cmds[0] == nil {
+		t.Fatal("Enter returned no command")
+	}
+	cmds[0]() // the send runs in the command, off the UI goroutine
+	if len(sent) != 1 || sent[0] != "hello" || m.input.Value() != "" {
+		t.Errorf("sent %q, input %q; want \"hello\" and a cleared input", sent, m.input.Value())
+	}
+
+	// Enter on an empty line sends nothing.
+	if _, cmds = update(m, tea.KeyMsg{Type: tea.KeyEnter}); cmds[0] != nil {
+		t.Error("empty line produced a command")
+	}
+}
+
+func TestModel_Disconnected(t *testing.T) {
+	m := newModel("go", "ada", nil)
+	m, _ = update(m, closedMsg{err: io.EOF})
+	if !strings.Contains(m.View(), "disconnected") || m.input.Focused() {
+		t.Errorf("after close: %s", m.View())
+	}
+	if _, cmds := update(m, tea.KeyMsg{Type: tea.KeyEsc}); cmds[0] == nil {
+		t.Error("Esc does not quit")
+	}
+}
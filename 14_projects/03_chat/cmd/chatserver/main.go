@@ -0,0 +1,72 @@
+// Command chatserver runs the chat server.
+//
+//	go run ./cmd/chatserver -addr localhost:8080 -db chat.db
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"log/slog"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"golang_roadmap/14_projects/03_chat/internal/chat"
+)
+
+func main() {
+	addr := flag.String("addr", "localhost:8080", "listen address")
+	dbPath := flag.String("db", "chat.db", "SQLite database file for message history")
+	flag.Parse()
+
+	log := slog.New(slog.NewTextHandler(os.Stderr, nil))
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, *addr, *dbPath, log); err != nil {
+		log.Error("Chat server failed", "err", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, addr, dbPath string, log *slog.Logger) error {
+	history, err := chat.OpenHistory(dbPath)
+	if err != nil {
+		return err
+	}
+	defer history.Close()
+
+	hub := chat.NewHub(64)
+	srv := &http.Server{
+		Addr:              addr,
+		Handler:           chat.NewServer(hub, history, log).Handler(),
+		ReadHeaderTimeout: 5 * time.Second,
+	}
+	errc := make(chan error, 1)
+	go func() {
+		log.Info("Listening", "addr", addr)
+		errc <- srv.ListenAndServe()
+	}()
+	select {
+	case err := <-errc:
+		return err
+	case <-ctx.Done():
+	}
+
+	// Shutdown does not wait for hijacked connections such as WebSockets,
+	// so close the hub first: every connection's writer sees its
+	// subscription end and sends a close frame.
+	log.Info("Shutting down")
+	hub.Close()
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		return err
+	}
+	if err := <-errc; !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	return nil
+}
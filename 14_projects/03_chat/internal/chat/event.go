@@ -0,0 +1,30 @@
+// Package chat is a multi-room chat server: WebSocket connections, a
+// pub/sub hub that fans messages out to each room's subscribers, presence
+// tracking, and message history in SQLite.
+package chat
+
+import "time"
+
+// Event types, the Type field of an Event.
+const (
+	TypeMessage  = "message"  // a chat message; the only type clients send
+	TypeJoin     = "join"     // User joined Room
+	TypeLeave    = "leave"    // User left Room
+	TypePresence = "presence" // Users is everyone now in Room
+	TypeHistory  = "history"  // Messages are Room's recent messages, oldest first
+	TypeError    = "error"    // Text says what the server rejected
+)
+
+// Event is what travels over a WebSocket, one JSON object per frame. A
+// client sends {"type":"message","text":"hi"}; the server fills in the
+// rest.
+type Event struct {
+	Type     string    `json:"type"`
+	ID       int64     `json:"id,omitempty"` // message ID, from the history store
+	Room     string    `json:"room,omitempty"`
+	User     string    `json:"user,omitempty"`
+	Text     string    `json:"text,omitempty"`
+	Time     time.Time `json:"time,omitzero"`
+	Users    []string  `json:"users,omitempty"`
+	Messages []Event   `json:"messages,omitempty"`
+}
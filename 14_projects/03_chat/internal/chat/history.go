@@ -0,0 +1,74 @@
+package chat
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+const schema = `
+CREATE TABLE IF NOT EXISTS messages (
+	id   INTEGER PRIMARY KEY AUTOINCREMENT,
+	room TEXT NOT NULL,
+	user TEXT NOT NULL,
+	text TEXT NOT NULL,
+	time INTEGER NOT NULL -- Unix milliseconds
+);
+CREATE INDEX IF NOT EXISTS messages_room ON messages (room, id);`
+
+// History stores chat messages in SQLite. It is safe for concurrent use.
+type History struct {
+	db *sql.DB
+}
+
+// OpenHistory opens (creating if needed) the database at path.
+func OpenHistory(path string) (*History, error) {
+	db, err := sql.Open("sqlite", "file:"+path+"?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)")
+	if err != nil {
+		return nil, err
+	}
+	if _, err := db.Exec(schema); err != nil {
+		db.Close()
+		return nil, fmt.Errorf("create schema: %w", err)
+	}
+	return &History{db: db}, nil
+}
+
+func (h *History) Close() error { return h.db.Close() }
+
+// Save stores a message event and returns it with its ID.
+func (h *History) Save(ctx context.Context, ev Event) (Event, error) {
+	res, err := h.db.ExecContext(ctx, `INSERT INTO messages (room, user, text, time) VALUES (?, ?, ?, ?)`,
+		ev.Room, ev.User, ev.Text, ev.Time.UnixMilli())
+	if err != nil {
+		return Event{}, err
+	}
+	ev.ID, err = res.LastInsertId()
+	return ev, err
+}
+
+// Recent returns up to n of room's latest messages, oldest first.
+func (h *History) Recent(ctx context.Context, room string, n int) ([]Event, error) {
+	rows, err := h.db.QueryContext(ctx,
+		`SELECT id, user, text, time FROM messages WHERE room = ? ORDER BY id DESC LIMIT ?`, room, n)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []Event
+	for rows.Next() {
+		ev := Event{Type: TypeMessage, Room: room}
+		var ms int64
+		if err := rows.Scan(&ev.ID, &ev.User, &ev.Text, &ms); err != nil {
+			return nil, err
+		}
+		ev.Time = time.UnixMilli(ms).UTC()
+		out = append(out, ev)
+	}
+	slices.Reverse(out)
+	return out, rows.Err()
+}
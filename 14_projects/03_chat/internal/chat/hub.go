@@ -0,0 +1,145 @@
+package chat
+
+import (
+	"slices"
+	"sync"
+)
+
+// Hub fans events out to the subscribers of a room and tracks who is
+// present. A user is present while at least one of their connections is
+// subscribed, so a second browser tab does not announce a second join.
+//
+// Publishing never blocks on a subscriber. A subscriber whose buffer is
+// full is too slow to keep up: it is dropped and its channel closed, and
+// the connection's writer ends the connection.
+type Hub struct {
+	mu     sync.Mutex
+	rooms  map[string]map[*Subscription]struct{}
+	counts map[string]map[string]int // room -> user -> connections
+	closed bool
+	buffer int
+}
+
+// Subscription receives a room's events on C until it is cancelled,
+// dropped for being slow, or the hub closes.
+type Subscription struct {
+	C    <-chan Event
+	c    chan Event
+	hub  *Hub
+	room string
+	user string
+	once sync.Once
+}
+
+// NewHub returns a hub whose subscribers buffer up to buffer events.
+func NewHub(buffer int) *Hub {
+	return &Hub{
+		rooms:  make(map[string]map[*Subscription]struct{}),
+		counts: make(map[string]map[string]int),
+		buffer: buffer,
+	}
+}
+
+// Subscribe adds user to room. The first connection of a user publishes a
+// join event; every subscriber, the new one included, then gets the new
+// presence list. ok is false once the hub is closed.
+func (h *Hub) Subscribe(room, user string) (sub *Subscription, ok bool) {
+	c := make(chan Event, h.buffer)
+	sub = &Subscription{C: c, c: c, hub: h, room: room, user: user}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.closed {
+		return nil, false
+	}
+	if h.rooms[room] == nil {
+		h.rooms[room] = make(map[*Subscription]struct{})
+		h.counts[room] = make(map[string]int)
+	}
+	h.rooms[room][sub] = struct{}{}
+	h.counts[room][user]++
+	if h.counts[room][user] == 1 {
+		h.publishLocked(Event{Type: TypeJoin, Room: room, User: user})
+	}
+	h.publishLocked(Event{Type: TypePresence, Room: room, Users: h.presenceLocked(room)})
+	return sub, true
+}
+
+// Cancel unsubscribes. It is safe to call more than once.
+func (s *Subscription) Cancel() {
+	s.hub.mu.Lock()
+	defer s.hub.mu.Unlock()
+	s.hub.removeLocked(s)
+}
+
+// removeLocked drops s from its room, announces the leave if it was the
+// user's last connection, and closes s's channel.
+func (h *Hub) removeLocked(s *Subscription) {
+	subs := h.rooms[s.room]
+	if _, ok := subs[s]; !ok {
+		return
+	}
+	delete(subs, s)
+	s.once.Do(func() { close(s.c) })
+	h.counts[s.room][s.user]--
+	if h.counts[s.room][s.user] == 0 {
+		delete(h.counts[s.room], s.user)
+		h.publishLocked(Event{Type: TypeLeave, Room: s.room, User: s.user})
+		h.publishLocked(Event{Type: TypePresence, Room: s.room, Users: h.presenceLocked(s.room)})
+	}
+	if len(subs) == 0 {
+		delete(h.rooms, s.room)
+		delete(h.counts, s.room)
+	}
+}
+
+// Publish sends ev to every subscriber of ev.Room.
+func (h *Hub) Publish(ev Event) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.publishLocked(ev)
+}
+
+func (h *Hub) publishLocked(ev Event) {
+	var slow []*Subscription
+	for sub := range h.rooms[ev.Room] {
+		select {
+		case sub.c <- ev:
+		default:
+			slow = append(slow, sub)
+		}
+	}
+	for _, sub := range slow {
+		h.removeLocked(sub) // may publish a leave, to the remaining subscribers
+	}
+}
+
+// Presence returns the users in room, sorted.
+func (h *Hub) Presence(room string) []string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return h.presenceLocked(room)
+}
+
+func (h *Hub) presenceLocked(room string) []string {
+	users := make([]string, 0, len(h.counts[room]))
+	for u := range h.counts[room] {
+		users = append(users, u)
+	}
+	slices.Sort(users)
+	return users
+}
+
+// Close ends every subscription and refuses new ones, for shutdown.
+func (h *Hub) Close() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closed = true
+	for _, subs := range h.rooms {
+		for sub := range subs {
+			sub.once.Do(func() { close(sub.c) })
+		}
+	}
+	h.rooms = make(map[string]map[*Subscription]struct{})
+	h.counts = make(map[string]map[string]int)
+}
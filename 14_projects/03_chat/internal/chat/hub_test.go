@@ -0,0 +1,105 @@
+package chat
+
+import (
+	"slices"
+	"testing"
+)
+
+// drain returns the events waiting on sub.
+func drain(sub *Subscription) []Event {
+	var out []Event
+	for {
+		select {
+		case ev, ok := <-sub.C:
+			if !ok {
+				return out
+			}
+			out = append(out, ev)
+		default:
+			return out
+		}
+	}
+}
+
+func types(evs []Event) []string {
+	var out []string
+	for _, ev := range evs {
+		out = append(out, ev.Type+":"+ev.User)
+	}
+	return out
+}
+
+func TestHub_JoinLeavePresence(t *testing.T) {
+	h := NewHub(16)
+	ada, _ := h.Subscribe("go", "ada")
+	if got := types(drain(ada)); !slices.Equal(got, []string{"join:ada", "presence:"}) {
+		t.Errorf("ada on join: %v", got)
+	}
+
+	bob, _ := h.Subscribe("go", "bob")
+	bob2, _ := h.Subscribe("go", "bob") // a second tab: no second join
+	other, _ := h.Subscribe("rust", "cy")
+	if got := types(drain(ada)); !slices.Equal(got, []string{"join:bob", "presence:", "presence:"}) {
+		t.Errorf("ada after bob joins twice: %v", got)
+	}
+	if got := h.Presence("go"); !slices.Equal(got, []string{"ada", "bob"}) {
+		t.Errorf("Presence = %v", got)
+	}
+
+	h.Publish(Event{Type: TypeMessage, Room: "go", User: "ada", Text: "hi"})
+	if got := drain(bob2); len(got) == 0 || got[len(got)-1].Text != "hi" {
+		t.Errorf("bob's second tab: %v; want the message", got)
+	}
+	if got := drain(other); len(got) != 2 {
+		t.Errorf("another room got %v; want only its own join and presence", types(got))
+	}
+
+	drain(ada)
+	bob.Cancel()
+	bob.Cancel() // harmless
+	if got := types(drain(ada)); len(got) != 0 {
+		t.Errorf("after one of bob's tabs leaves: %v; want nothing", got)
+	}
+	bob2.Cancel()
+	if got := types(drain(ada)); !slices.Equal(got, []string{"leave:bob", "presence:"}) {
+		t.Errorf("after bob's last tab leaves: %v", got)
+	}
+	drain(bob)
+	if _, ok := <-bob.C; ok {
+		t.Error("cancelled subscription's channel is open")
+	}
+}
+
+func TestHub_DropsSlowSubscribers(t *testing.T) {
+	h := NewHub(8)
+	slow, _ := h.Subscribe("go", "slow")
+	fast, _ := h.Subscribe("go", "fast")
+	for range 10 {
+		h.Publish(Event{Type: TypeMessage, Room: "go", Text: "x"})
+		drain(fast)
+	}
+	if got := h.Presence("go"); !slices.Equal(got, []string{"fast"}) {
+		t.Errorf("Presence = %v; want the slow subscriber dropped", got)
+	}
+	n := 0
+	for range slow.C {
+		n++
+	}
+	if n != 8 {
+		t.Errorf("slow subscriber got %d buffered events, then a closed channel; want 8", n)
+	}
+}
+
+func TestHub_Close(t *testing.T) {
+	h := NewHub(4)
+	sub, _ := h.Subscribe("go", "ada")
+	h.Close()
+	drain(sub)
+	if _, ok := <-sub.C; ok {
+		t.Error("channel open after Close")
+	}
+	if _, ok := h.Subscribe("go", "bob"); ok {
+		t.Error("Subscribe after Close succeeded")
+	}
+	sub.Cancel() // after Close: no panic
+}
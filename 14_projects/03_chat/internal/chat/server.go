@@ -0,0 +1,177 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"regexp"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+
+	"golang_roadmap/08_web_development/04_validation/validate"
+)
+
+const (
+	historySize  = 50
+	maxTextLen   = 1000
+	writeTimeout = 5 * time.Second
+	pingInterval = 30 * time.Second
+)
+
+var nameRe = regexp.MustCompile(`^[\p{L}\p{N}_.-]+$`)
+
+// Server accepts WebSocket connections and connects them to the hub.
+type Server struct {
+	hub     *Hub
+	history *History
+	log     *slog.Logger
+	now     func() time.Time
+}
+
+func NewServer(hub *Hub, history *History, log *slog.Logger) *Server {
+	return &Server{hub: hub, history: history, log: log, now: time.Now}
+}
+
+// Handler serves:
+//
+//	GET /ws?room=R&user=U         the chat connection
+//	GET /rooms/{room}/history     recent messages as JSON
+//	GET /rooms/{room}/presence    who is in the room
+func (s *Server) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /ws", s.serveWS)
+	mux.HandleFunc("GET /rooms/{room}/history", func(w http.ResponseWriter, r *http.Request) {
+		msgs, err := s.history.Recent(r.Context(), r.PathValue("room"), historySize)
+		if err != nil {
+			s.log.ErrorContext(r.Context(), "Reading history", "err", err)
+			http.Error(w, "internal error", http.StatusInternalServerError)
+			return
+		}
+		writeJSON(w, msgs)
+	})
+	mux.HandleFunc("GET /rooms/{room}/presence", func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, s.hub.Presence(r.PathValue("room")))
+	})
+	return mux
+}
+
+// checkNames validates the room and user of a connection.
+func checkNames(room, user string) error {
+	var v validate.Validator
+	v.String("room", room).NonEmpty().MaxLen(32).Matches(nameRe, "letters, digits, _, . and -")
+	v.String("user", user).NonEmpty().MaxLen(32).Matches(nameRe, "letters, digits, _, . and -")
+	return v.Err()
+}
+
+func (s *Server) serveWS(w http.ResponseWriter, r *http.Request) {
+	room, user := r.URL.Query().Get("room"), r.URL.Query().Get("user")
+	if err := checkNames(room, user); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		return // Accept has written the error response
+	}
+	defer conn.CloseNow()
+	conn.SetReadLimit(16 << 10)
+
+	// Cancelling ctx stops the reader when the writer gives up.
+	ctx, cancel := context.WithCancel(r.Context())
+	defer cancel()
+
+	history, err := s.history.Recent(ctx, room, historySize)
+	if err != nil {
+		s.log.ErrorContext(ctx, "Reading history", "room", room, "err", err)
+		conn.Close(websocket.StatusInternalError, "history unavailable")
+		return
+	}
+	if err := s.write(ctx, conn, Event{Type: TypeHistory, Room: room, Messages: history}); err != nil {
+		return
+	}
+	sub, ok := s.hub.Subscribe(room, user)
+	if !ok {
+		conn.Close(websocket.StatusGoingAway, "server shutting down")
+		return
+	}
+	defer sub.Cancel()
+	s.log.InfoContext(ctx, "Connected", "room", room, "user", user)
+
+	// One goroutine reads, this one writes events. The reader also writes
+	// error replies: coder/websocket allows concurrent writes, but only
+	// one reader.
+	readErr := make(chan error, 1)
+	go func() { readErr <- s.readLoop(ctx, conn, room, user) }()
+
+	ping := time.NewTicker(pingInterval)
+	defer ping.Stop()
+	for {
+		select {
+		case ev, ok := <-sub.C:
+			if !ok {
+				conn.Close(websocket.StatusGoingAway, "disconnected by server")
+				return
+			}
+			if err := s.write(ctx, conn, ev); err != nil {
+				return
+			}
+		case <-ping.C:
+			pctx, pcancel := context.WithTimeout(ctx, writeTimeout)
+			err := conn.Ping(pctx)
+			pcancel()
+			if err != nil {
+				return
+			}
+		case err := <-readErr:
+			status := websocket.CloseStatus(err)
+			if status != websocket.StatusNormalClosure && status != websocket.StatusGoingAway && !errors.Is(err, context.Canceled) {
+				s.log.InfoContext(ctx, "Connection ended", "room", room, "user", user, "err", err)
+			}
+			conn.Close(websocket.StatusNormalClosure, "")
+			return
+		}
+	}
+}
+
+// readLoop handles the client's messages until the connection fails.
+func (s *Server) readLoop(ctx context.Context, conn *websocket.Conn, room, user string) error {
+	for {
+		var in Event
+		if err := wsjson.Read(ctx, conn, &in); err != nil {
+			return err
+		}
+		text := validate.CleanText(in.Text)
+		var v validate.Validator
+		v.String("text", text).NonEmpty().MaxLen(maxTextLen)
+		if in.Type != TypeMessage {
+			v.Fail("type", "must be %q", TypeMessage)
+		}
+		if err := v.Err(); err != nil {
+			if err := s.write(ctx, conn, Event{Type: TypeError, Text: err.Error()}); err != nil {
+				return err
+			}
+			continue
+		}
+		ev, err := s.history.Save(ctx, Event{Type: TypeMessage, Room: room, User: user, Text: text, Time: s.now().UTC()})
+		if err != nil {
+			s.log.ErrorContext(ctx, "Saving message", "room", room, "err", err)
+			return err
+		}
+		s.hub.Publish(ev)
+	}
+}
+
+func (s *Server) write(ctx context.Context, conn *websocket.Conn, ev Event) error {
+	ctx, cancel := context.WithTimeout(ctx, writeTimeout)
+	defer cancel()
+	return wsjson.Write(ctx, conn, ev)
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
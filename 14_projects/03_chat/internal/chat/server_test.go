@@ -0,0 +1,145 @@
+package chat
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+func newTestServer(t *testing.T) (*httptest.Server, *Hub) {
+	t.Helper()
+	history, err := OpenHistory(filepath.Join(t.TempDir(), "chat.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { history.Close() })
+	hub := NewHub(16)
+	srv := httptest.NewServer(NewServer(hub, history, slog.New(slog.DiscardHandler)).Handler())
+	t.Cleanup(srv.Close)
+	return srv, hub
+}
+
+type client struct {
+	t    *testing.T
+	conn *websocket.Conn
+}
+
+func dial(t *testing.T, srv *httptest.Server, room, user string) *client {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	conn, _, err := websocket.Dial(ctx, "ws"+strings.TrimPrefix(srv.URL, "http")+"/ws?room="+room+"&user="+user, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.CloseNow() })
+	return &client{t, conn}
+}
+
+// next returns the next event of type typ, skipping others.
+func (c *client) next(typ string) Event {
+	c.t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	for {
+		var ev Event
+		if err := wsjson.Read(ctx, c.conn, &ev); err != nil {
+			c.t.Fatalf("waiting for %s: %v", typ, err)
+		}
+		if ev.Type == typ {
+			return ev
+		}
+	}
+}
+
+func (c *client) say(text string) {
+	c.t.Helper()
+	if err := wsjson.Write(context.Background(), c.conn, Event{Type: TypeMessage, Text: text}); err != nil {
+		c.t.Fatal(err)
+	}
+}
+
+func TestServer_Conversation(t *testing.T) {
+	srv, _ := newTestServer(t)
+
+	ada := dial(t, srv, "go", "ada")
+	if h := ada.next(TypeHistory); len(h.Messages) != 0 {
+		t.Errorf("empty room history: %v", h.Messages)
+	}
+	bob := dial(t, srv, "go", "bob")
+	bob.next(TypeHistory)
+	ada.next(TypePresence) // her own arrival
+	if p := ada.next(TypePresence); !slices.Equal(p.Users, []string{"ada", "bob"}) {
+		t.Errorf("presence = %v", p.Users)
+	}
+
+	bob.say("  hello\x00 ada  ")
+	for _, c := range []*client{ada, bob} {
+		if m := c.next(TypeMessage); m.User != "bob" || m.Text != "hello ada" || m.ID == 0 || m.Time.IsZero() {
+			t.Errorf("message = %+v", m)
+		}
+	}
+
+	bob.say("   ")
+	if e := bob.next(TypeError); e.Text != "text: must not be empty" {
+		t.Errorf("error = %q", e.Text)
+	}
+
+	bob.conn.Close(websocket.StatusNormalClosure, "")
+	if l := ada.next(TypeLeave); l.User != "bob" {
+		t.Errorf("leave = %+v", l)
+	}
+
+	// A newcomer gets the history.
+	cy := dial(t, srv, "go", "cy")
+	if h := cy.next(TypeHistory); len(h.Messages) != 1 || h.Messages[0].Text != "hello ada" {
+		t.Errorf("history = %+v", h.Messages)
+	}
+	resp, err := http.Get(srv.URL + "/rooms/go/history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer resp.Body.Close()
+	var msgs []Event
+	if json.NewDecoder(resp.Body).Decode(&msgs); len(msgs) != 1 || msgs[0].User != "bob" {
+		t.Errorf("GET history = %+v", msgs)
+	}
+}
+
+func TestServer_RejectsBadNames(t *testing.T) {
+	srv, _ := newTestServer(t)
+	for _, q := range []string{"room=&user=ada", "room=go&user=", "room=go&user=a%20b", "room=" + strings.Repeat("x", 33) + "&user=ada"} {
+		resp, err := http.Get(srv.URL + "/ws?" + q)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+		if resp.StatusCode != http.StatusBadRequest {
+			t.Errorf("%s: %d; want 400", q, resp.StatusCode)
+		}
+	}
+}
+
+func TestServer_HubCloseEndsConnections(t *testing.T) {
+	srv, hub := newTestServer(t)
+	ada := dial(t, srv, "go", "ada")
+	ada.next(TypePresence)
+	hub.Close()
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	var ev Event
+	err := wsjson.Read(ctx, ada.conn, &ev)
+	if websocket.CloseStatus(err) != websocket.StatusGoingAway {
+		t.Errorf("after hub.Close: %v; want a going-away close", err)
+	}
+}
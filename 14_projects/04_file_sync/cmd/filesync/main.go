@@ -0,0 +1,102 @@
+// Command filesync mirrors a source directory into a destination.
+//
+//	go run ./cmd/filesync -exclude .git -exclude '*.tmp' -dry-run ~/photos /mnt/backup/photos
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"golang_roadmap/14_projects/04_file_sync/internal/filesync"
+)
+
+// patterns is a repeatable flag; each value may also hold several
+// comma-separated patterns.
+type patterns []string
+
+func (p *patterns) String() string { return strings.Join(*p, ",") }
+
+func (p *patterns) Set(v string) error {
+	*p = append(*p, strings.Split(v, ",")...)
+	return nil
+}
+
+func main() {
+	var opts filesync.Options
+	flag.BoolVar(&opts.DryRun, "dry-run", false, "list the changes without making them")
+	flag.BoolVar(&opts.Delete, "delete", false, "delete destination files that are not in the source")
+	flag.IntVar(&opts.Workers, "workers", 0, "files hashed and copied in parallel (default: number of CPUs)")
+	flag.StringVar(&opts.StatePath, "state", "", "state file (default: "+filesync.StateFileName+" in the destination)")
+	flag.Var((*patterns)(&opts.Filter.Include), "include", "only sync files matching this glob (repeatable)")
+	flag.Var((*patterns)(&opts.Filter.Exclude), "exclude", "skip files and directories matching this glob (repeatable)")
+	interval := flag.Duration("progress", 500*time.Millisecond, "progress update interval; 0 turns it off")
+	verbose := flag.Bool("v", false, "list every change, not only in a dry run")
+	flag.Usage = func() {
+		fmt.Fprintf(flag.CommandLine.Output(), "usage: filesync [flags] SOURCE DEST\n\n")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if flag.NArg() != 2 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	opts.Source, opts.Dest = flag.Arg(0), flag.Arg(1)
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := run(ctx, opts, *interval, *verbose, os.Stdout, os.Stderr); err != nil {
+		fmt.Fprintln(os.Stderr, "filesync:", err)
+		os.Exit(1)
+	}
+}
+
+func run(ctx context.Context, opts filesync.Options, interval time.Duration, verbose bool, stdout, stderr io.Writer) error {
+	opts.Progress = new(filesync.Progress)
+	done := make(chan struct{})
+	reported := make(chan struct{})
+	if interval > 0 {
+		go func() {
+			defer close(reported)
+			opts.Progress.Report(stderr, interval, done)
+		}()
+	} else {
+		close(reported)
+	}
+	res, err := filesync.Sync(ctx, opts)
+	close(done)
+	<-reported
+	if res == nil {
+		return err
+	}
+
+	if opts.DryRun || verbose {
+		prefix := ""
+		if opts.DryRun {
+			prefix = "would "
+		}
+		for _, a := range res.Actions {
+			fmt.Fprintf(stdout, "%s%-6s %s\n", prefix, a.Kind, a.Path)
+		}
+	}
+	for _, path := range res.Skipped {
+		fmt.Fprintf(stderr, "skipped %s: not a regular file\n", path)
+	}
+	counts := map[filesync.ActionKind]int{}
+	for _, a := range res.Actions {
+		counts[a.Kind]++
+	}
+	fmt.Fprintf(stdout, "%d created, %d updated, %d deleted, %d unchanged, %d bytes\n",
+		counts[filesync.Create], counts[filesync.Update], counts[filesync.Delete], res.Unchanged, res.Bytes)
+	if errors.Is(err, context.Canceled) {
+		return errors.New("interrupted; run again to resume")
+	}
+	return err
+}
@@ -0,0 +1,3 @@
+module golang_roadmap/14_projects/04_file_sync
+
+go 1.24.11
@@ -0,0 +1,82 @@
+package filesync
+
+import (
+	"fmt"
+	"path"
+	"strings"
+)
+
+// Filter decides which paths take part in a sync. Patterns use
+// path.Match syntax on slash-separated paths relative to the root, plus:
+//
+//   - a pattern without a slash matches the base name at any depth
+//     ("*.tmp", "node_modules");
+//   - "**" as a whole path element matches any number of elements
+//     ("docs/**/*.md").
+//
+// A path is synced if it matches no exclude pattern and, when there are
+// include patterns, matches one of them. An excluded directory is not
+// entered. Include patterns apply to files only, so "*.go" still walks
+// every directory to find them.
+type Filter struct {
+	Include []string
+	Exclude []string
+}
+
+// Validate reports malformed patterns, which path.Match would otherwise
+// treat as never matching.
+func (f Filter) Validate() error {
+	for _, p := range append(f.Include[:len(f.Include):len(f.Include)], f.Exclude...) {
+		if _, err := path.Match(strings.ReplaceAll(p, "**", "*"), ""); err != nil {
+			return fmt.Errorf("bad pattern %q: %w", p, err)
+		}
+	}
+	return nil
+}
+
+// Skip reports whether rel, a file or directory, is left out.
+func (f Filter) Skip(rel string, isDir bool) bool {
+	for _, p := range f.Exclude {
+		if matchPattern(p, rel) {
+			return true
+		}
+	}
+	if isDir || len(f.Include) == 0 {
+		return false
+	}
+	for _, p := range f.Include {
+		if matchPattern(p, rel) {
+			return false
+		}
+	}
+	return true
+}
+
+func matchPattern(pattern, rel string) bool {
+	if !strings.Contains(pattern, "/") {
+		ok, _ := path.Match(pattern, path.Base(rel))
+		return ok
+	}
+	return matchElems(strings.Split(pattern, "/"), strings.Split(rel, "/"))
+}
+
+func matchElems(pat, name []string) bool {
+	for len(pat) > 0 {
+		if pat[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchElems(pat[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if ok, _ := path.Match(pat[0], name[0]); !ok {
+			return false
+		}
+		pat, name = pat[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,45 @@
+package filesync
+
+import "testing"
+
+func TestFilter(t *testing.T) {
+	f := Filter{
+		Include: []string{"*.go", "docs/**/*.md"},
+		Exclude: []string{"vendor", "*_test.go", "docs/drafts/**"},
+	}
+	for _, tt := range []struct {
+		rel   string
+		isDir bool
+		skip  bool
+	}{
+		{"main.go", false, false},
+		{"internal/sync/sync.go", false, false}, // base-name patterns match at any depth
+		{"internal/sync/sync_test.go", false, true},
+		{"README.txt", false, true}, // no include matches
+		{"vendor", true, true},
+		{"a/vendor", true, true},
+		{"internal", true, false},       // includes do not apply to directories
+		{"docs/guide.md", false, false}, // ** matches no elements too
+		{"docs/a/b/guide.md", false, false},
+		{"docs/drafts/x.md", false, true},
+		{"docs/drafts", true, true}, // so the directory is not entered at all
+		{"notes/guide.md", false, true},
+	} {
+		if got := f.Skip(tt.rel, tt.isDir); got != tt.skip {
+			t.Errorf("Skip(%q, dir=%v) = %v; want %v", tt.rel, tt.isDir, got, tt.skip)
+		}
+	}
+
+	if (Filter{}).Skip("anything/at/all", false) {
+		t.Error("empty filter skips a file")
+	}
+}
+
+func TestFilter_Validate(t *testing.T) {
+	if err := (Filter{Include: []string{"**/*.go", "a/[bc]"}}).Validate(); err != nil {
+		t.Errorf("valid patterns: %v", err)
+	}
+	if err := (Filter{Exclude: []string{"[z-a"}}).Validate(); err == nil {
+		t.Error("malformed pattern accepted")
+	}
+}
@@ -0,0 +1,47 @@
+package filesync
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+)
+
+// hashFile returns the hex SHA-256 of the file at path, counting the
+// bytes read into p.HashedBytes.
+func hashFile(ctx context.Context, path string, p *Progress) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, &progressReader{ctx: ctx, r: f, p: p}); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// progressReader counts the bytes read into p, as hashed or as copied,
+// and stops at ctx's cancellation, so a large file does not hold up
+// Ctrl-C.
+type progressReader struct {
+	ctx  context.Context
+	r    io.Reader
+	p    *Progress
+	copy bool
+}
+
+func (r *progressReader) Read(b []byte) (int, error) {
+	if err := r.ctx.Err(); err != nil {
+		return 0, err
+	}
+	n, err := r.r.Read(b)
+	if r.copy {
+		r.p.CopiedBytes.Add(int64(n))
+	} else {
+		r.p.HashedBytes.Add(int64(n))
+	}
+	return n, err
+}
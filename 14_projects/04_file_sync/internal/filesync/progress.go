@@ -0,0 +1,63 @@
+package filesync
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Progress counts work as a sync goes. Its fields are updated from the
+// worker goroutines and may be read at any time.
+type Progress struct {
+	Scanned     atomic.Int64 // files found in the source
+	ToHash      atomic.Int64 // files whose contents must be compared
+	Hashed      atomic.Int64
+	HashedBytes atomic.Int64
+	ToCopy      atomic.Int64
+	Copied      atomic.Int64
+	CopiedBytes atomic.Int64
+	TotalBytes  atomic.Int64 // bytes to copy
+}
+
+func (p *Progress) String() string {
+	s := fmt.Sprintf("scanned %d", p.Scanned.Load())
+	if n := p.ToHash.Load(); n > 0 {
+		s += fmt.Sprintf(", hashed %d/%d (%s)", p.Hashed.Load(), n, formatBytes(p.HashedBytes.Load()))
+	}
+	if n := p.ToCopy.Load(); n > 0 {
+		s += fmt.Sprintf(", copied %d/%d (%s/%s)", p.Copied.Load(), n,
+			formatBytes(p.CopiedBytes.Load()), formatBytes(p.TotalBytes.Load()))
+	}
+	return s
+}
+
+// Report writes p to w every interval until stop is closed, then once
+// more. Each line starts with a carriage return, so a terminal shows one
+// updating line.
+func (p *Progress) Report(w io.Writer, interval time.Duration, stop <-chan struct{}) {
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+			fmt.Fprintf(w, "\r%s", p)
+		case <-stop:
+			fmt.Fprintf(w, "\r%s\n", p)
+			return
+		}
+	}
+}
+
+func formatBytes(n int64) string {
+	const unit = 1024
+	if n < unit {
+		return fmt.Sprintf("%d B", n)
+	}
+	div, exp := int64(unit), 0
+	for m := n / unit; m >= unit; m /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %ciB", float64(n)/float64(div), "KMGTPE"[exp])
+}
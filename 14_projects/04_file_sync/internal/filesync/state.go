@@ -0,0 +1,109 @@
+package filesync
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+// StateFileName is the default state file, kept in the destination root
+// and never synced or deleted.
+const StateFileName = ".filesync-state.json"
+
+// FileState is what the last sync copied or verified for one path: the
+// source's size and modification time then, and the content's SHA-256.
+type FileState struct {
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mtime"`
+	SHA256  string    `json:"sha256"`
+}
+
+// State is saved as JSON after every few files, so an interrupted sync
+// resumes where it stopped: files recorded here whose source and copy
+// still match in size and time are not hashed again.
+type State struct {
+	Version int                  `json:"version"`
+	Source  string               `json:"source"`
+	Files   map[string]FileState `json:"files"`
+
+	mu   sync.Mutex
+	path string
+}
+
+const stateVersion = 1
+
+// LoadState reads the state at path. A missing file, or one written for
+// another source or version, gives an empty state.
+func LoadState(path, source string) (*State, error) {
+	s := &State{Version: stateVersion, Source: source, Files: map[string]FileState{}, path: path}
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return s, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	var saved State
+	if err := json.Unmarshal(data, &saved); err != nil {
+		return nil, fmt.Errorf("state %s: %w", path, err)
+	}
+	if saved.Version == stateVersion && saved.Source == source && saved.Files != nil {
+		s.Files = saved.Files
+	}
+	return s, nil
+}
+
+func (s *State) Get(rel string) (FileState, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	fst, ok := s.Files[rel]
+	return fst, ok
+}
+
+func (s *State) Set(rel string, fst FileState) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.Files[rel] = fst
+}
+
+func (s *State) Delete(rel string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.Files, rel)
+}
+
+// Save writes the state atomically: to a temporary file, then renamed
+// over the old one, so a crash mid-write leaves the previous state.
+func (s *State) Save() error {
+	s.mu.Lock()
+	data, err := json.MarshalIndent(s, "", "  ")
+	s.mu.Unlock()
+	if err != nil {
+		return err
+	}
+	if err := os.MkdirAll(filepath.Dir(s.path), 0o755); err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(filepath.Dir(s.path), ".filesync-state-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name()) // after a successful rename, a no-op
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Sync(); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	return os.Rename(tmp.Name(), s.path)
+}
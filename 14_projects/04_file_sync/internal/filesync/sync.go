@@ -0,0 +1,476 @@
+// Package filesync mirrors a source directory into a destination. It
+// copies files that are new or changed, optionally deletes files the
+// source no longer has, and records what it copied in a JSON state file
+// so an interrupted run resumes without redoing its work.
+//
+// A file counts as unchanged, without reading it, when the state records
+// its current size and modification time and the copy matches them too.
+// Otherwise both sides are hashed with SHA-256 on a pool of workers, and
+// only files whose contents differ are copied. Copies go to a temporary
+// file that is renamed into place, so the destination never holds half a
+// file.
+package filesync
+
+import (
+	"cmp"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Options configure a sync.
+type Options struct {
+	Source string
+	Dest   string
+	Filter Filter
+
+	// DryRun plans the sync, hashing as needed, but changes nothing.
+	DryRun bool
+	// Delete removes destination files that are not in the source.
+	// Files the filter leaves out are kept.
+	Delete bool
+
+	// Workers hash and copy files in parallel; 0 means runtime.NumCPU().
+	Workers int
+	// StatePath is the state file, by default StateFileName in Dest.
+	StatePath string
+	// SaveEvery saves the state after this many copies; 0 means 20.
+	SaveEvery int
+	// Progress, if not nil, is updated as the sync goes.
+	Progress *Progress
+
+	afterCopy func(rel string) // for tests
+}
+
+type ActionKind int
+
+const (
+	Create ActionKind = iota // the destination has no such file
+	Update                   // the destination's file differs
+	Delete                   // the source has no such file
+)
+
+func (k ActionKind) String() string {
+	switch k {
+	case Create:
+		return "create"
+	case Update:
+		return "update"
+	case Delete:
+		return "delete"
+	}
+	return fmt.Sprintf("ActionKind(%d)", int(k))
+}
+
+// Action is one change to the destination. Path is relative to the
+// roots, with forward slashes.
+type Action struct {
+	Kind ActionKind
+	Path string
+	Size int64
+}
+
+// Result describes a sync: what it changed, or in a dry run would
+// change. After an interruption, Actions is the whole plan, only part of
+// which was carried out.
+type Result struct {
+	Actions   []Action // sorted by path
+	Unchanged int
+	Bytes     int64    // bytes copied, or to copy
+	Skipped   []string // symlinks and other non-regular files
+}
+
+// file is a regular file found in the source.
+type file struct {
+	rel     string
+	size    int64
+	modTime time.Time
+	mode    fs.FileMode
+}
+
+// Sync mirrors opts.Source into opts.Dest. Errors with single files do
+// not stop it; they are joined into the returned error, and the Result
+// covers the rest. If ctx is cancelled, Sync saves the state and returns
+// ctx's error, and the next run picks up from there.
+func Sync(ctx context.Context, opts Options) (*Result, error) {
+	if err := opts.setDefaults(); err != nil {
+		return nil, err
+	}
+	files, skipped, err := scanSource(opts)
+	if err != nil {
+		return nil, err
+	}
+	state, err := LoadState(opts.StatePath, opts.Source)
+	if err != nil {
+		return nil, err
+	}
+
+	res := &Result{Skipped: skipped}
+	copies, unchanged, errs := plan(ctx, opts, state, files)
+	res.Unchanged = unchanged
+	for _, c := range copies {
+		res.Actions = append(res.Actions, c.action)
+		res.Bytes += c.size
+	}
+	var deletes []Action
+	if opts.Delete {
+		deletes, err = planDeletes(opts, files)
+		if err != nil {
+			errs = append(errs, err)
+		}
+		res.Actions = append(res.Actions, deletes...)
+	}
+	slices.SortFunc(res.Actions, func(a, b Action) int { return cmp.Compare(a.Path, b.Path) })
+	if opts.DryRun {
+		return res, errors.Join(append(errs, ctx.Err())...)
+	}
+	if ctx.Err() == nil {
+		errs = append(errs, execute(ctx, opts, state, copies, deletes)...)
+	}
+
+	// Forget files that have left the source, or the filter.
+	for rel := range state.Files {
+		if _, ok := files[rel]; !ok {
+			state.Delete(rel)
+		}
+	}
+	if err := state.Save(); err != nil {
+		errs = append(errs, fmt.Errorf("saving state: %w", err))
+	}
+	return res, errors.Join(append(errs, ctx.Err())...)
+}
+
+func (o *Options) setDefaults() error {
+	if o.Source == "" || o.Dest == "" {
+		return errors.New("filesync: source and destination are required")
+	}
+	var err error
+	if o.Source, err = filepath.Abs(o.Source); err != nil {
+		return err
+	}
+	if o.Dest, err = filepath.Abs(o.Dest); err != nil {
+		return err
+	}
+	if fi, err := os.Stat(o.Source); err != nil {
+		return err
+	} else if !fi.IsDir() {
+		return fmt.Errorf("filesync: %s is not a directory", o.Source)
+	}
+	if rel, err := filepath.Rel(o.Source, o.Dest); err == nil && !strings.HasPrefix(rel, "..") {
+		return fmt.Errorf("filesync: destination %s is inside the source", o.Dest)
+	}
+	if err := o.Filter.Validate(); err != nil {
+		return fmt.Errorf("filesync: %w", err)
+	}
+	if o.Workers <= 0 {
+		o.Workers = runtime.NumCPU()
+	}
+	if o.StatePath == "" {
+		o.StatePath = filepath.Join(o.Dest, StateFileName)
+	}
+	if o.SaveEvery <= 0 {
+		o.SaveEvery = 20
+	}
+	if o.Progress == nil {
+		o.Progress = new(Progress)
+	}
+	return nil
+}
+
+// scanSource walks the source, returning its regular files by relative
+// path, and the paths of anything else.
+func scanSource(opts Options) (map[string]file, []string, error) {
+	files := map[string]file{}
+	var skipped []string
+	err := filepath.WalkDir(opts.Source, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		rel := relPath(opts.Source, path)
+		if rel == "." {
+			return nil
+		}
+		if opts.Filter.Skip(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if !d.Type().IsRegular() {
+			skipped = append(skipped, rel)
+			return nil
+		}
+		fi, err := d.Info()
+		if err != nil {
+			return err
+		}
+		files[rel] = file{rel: rel, size: fi.Size(), modTime: fi.ModTime(), mode: fi.Mode().Perm()}
+		opts.Progress.Scanned.Add(1)
+		return nil
+	})
+	return files, skipped, err
+}
+
+func relPath(root, path string) string {
+	rel, _ := filepath.Rel(root, path) // path is always under root
+	return filepath.ToSlash(rel)
+}
+
+// pendingCopy is a planned Create or Update.
+type pendingCopy struct {
+	action Action
+	file
+}
+
+// plan decides which files to copy. Files that need hashing are hashed on
+// opts.Workers goroutines; when the contents turn out equal, the state
+// learns that, so the next run can skip them without hashing.
+func plan(ctx context.Context, opts Options, state *State, files map[string]file) ([]pendingCopy, int, []error) {
+	var copies, toHash []pendingCopy
+	var errs []error
+	unchanged := 0
+	for _, f := range files {
+		dst, err := os.Stat(filepath.Join(opts.Dest, filepath.FromSlash(f.rel)))
+		switch {
+		case errors.Is(err, fs.ErrNotExist):
+			copies = append(copies, pendingCopy{Action{Create, f.rel, f.size}, f})
+		case err != nil:
+			errs = append(errs, err)
+		case !dst.Mode().IsRegular():
+			errs = append(errs, fmt.Errorf("%s: destination is not a regular file", f.rel))
+		case dst.Size() != f.size:
+			copies = append(copies, pendingCopy{Action{Update, f.rel, f.size}, f})
+		case upToDate(state, f, dst):
+			unchanged++
+		default:
+			toHash = append(toHash, pendingCopy{Action{Update, f.rel, f.size}, f})
+		}
+	}
+
+	opts.Progress.ToHash.Store(int64(len(toHash)))
+	var mu sync.Mutex
+	errs = append(errs, forEach(ctx, opts.Workers, toHash, func(c pendingCopy) error {
+		defer opts.Progress.Hashed.Add(1)
+		srcSum, err := hashFile(ctx, filepath.Join(opts.Source, filepath.FromSlash(c.rel)), opts.Progress)
+		if err != nil {
+			return err
+		}
+		dstSum, err := hashFile(ctx, filepath.Join(opts.Dest, filepath.FromSlash(c.rel)), opts.Progress)
+		if err != nil {
+			return err
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if srcSum != dstSum {
+			copies = append(copies, c)
+			return nil
+		}
+		unchanged++
+		state.Set(c.rel, FileState{Size: c.size, ModTime: c.modTime, SHA256: srcSum})
+		return nil
+	})...)
+	return copies, unchanged, errs
+}
+
+// upToDate reports whether the state shows f was copied, or found equal,
+// as it is now, and the copy still looks the same.
+func upToDate(state *State, f file, dst fs.FileInfo) bool {
+	st, ok := state.Get(f.rel)
+	return ok && st.Size == f.size && st.ModTime.Equal(f.modTime) && dst.ModTime().Equal(f.modTime)
+}
+
+// planDeletes lists destination files that are not in the source. The
+// state file, and files the filter leaves out, are kept.
+func planDeletes(opts Options, files map[string]file) ([]Action, error) {
+	var deletes []Action
+	err := filepath.WalkDir(opts.Dest, func(path string, d fs.DirEntry, err error) error {
+		if errors.Is(err, fs.ErrNotExist) && path == opts.Dest {
+			return filepath.SkipAll
+		}
+		if err != nil {
+			return err
+		}
+		rel := relPath(opts.Dest, path)
+		if rel == "." || path == opts.StatePath || isTemp(d.Name()) {
+			return nil
+		}
+		if opts.Filter.Skip(rel, d.IsDir()) {
+			if d.IsDir() {
+				return filepath.SkipDir
+			}
+			return nil
+		}
+		if d.IsDir() {
+			return nil
+		}
+		if _, ok := files[rel]; !ok {
+			var size int64
+			if fi, err := d.Info(); err == nil {
+				size = fi.Size()
+			}
+			deletes = append(deletes, Action{Delete, rel, size})
+		}
+		return nil
+	})
+	return deletes, err
+}
+
+// execute copies and deletes. Each copy is recorded in the state, and the
+// state is saved every opts.SaveEvery copies.
+func execute(ctx context.Context, opts Options, state *State, copies []pendingCopy, deletes []Action) []error {
+	// Largest first, so a long copy does not start last and leave the
+	// other workers idle.
+	slices.SortFunc(copies, func(a, b pendingCopy) int { return cmp.Compare(b.size, a.size) })
+	opts.Progress.ToCopy.Store(int64(len(copies)))
+	for _, c := range copies {
+		opts.Progress.TotalBytes.Add(c.size)
+	}
+
+	var mu sync.Mutex
+	sinceSave := 0
+	errs := forEach(ctx, opts.Workers, copies, func(c pendingCopy) error {
+		sum, err := copyFile(ctx, opts, c.file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", c.rel, err)
+		}
+		state.Set(c.rel, FileState{Size: c.size, ModTime: c.modTime, SHA256: sum})
+		opts.Progress.Copied.Add(1)
+		if opts.afterCopy != nil {
+			opts.afterCopy(c.rel)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		if sinceSave++; sinceSave >= opts.SaveEvery {
+			sinceSave = 0
+			return state.Save()
+		}
+		return nil
+	})
+
+	if ctx.Err() != nil {
+		return errs
+	}
+	for _, d := range deletes {
+		if err := os.Remove(filepath.Join(opts.Dest, filepath.FromSlash(d.Path))); err != nil && !errors.Is(err, fs.ErrNotExist) {
+			errs = append(errs, err)
+			continue
+		}
+		state.Delete(d.Path)
+		removeEmptyParents(opts.Dest, filepath.Dir(filepath.FromSlash(d.Path)))
+	}
+	return errs
+}
+
+// removeEmptyParents removes dir, relative to root, and its parents up to
+// root, for as long as they are empty.
+func removeEmptyParents(root, dir string) {
+	for dir != "." && dir != string(filepath.Separator) {
+		if os.Remove(filepath.Join(root, dir)) != nil {
+			return // not empty
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+const tempPrefix = ".filesync-tmp-"
+
+func isTemp(name string) bool { return strings.HasPrefix(name, tempPrefix) }
+
+// copyFile copies f to the destination through a temporary file in the
+// same directory, renamed into place once complete, and gives it the
+// source's permissions and modification time. It returns the SHA-256 of
+// what it copied. If the source changed during the copy, the copy is
+// discarded.
+func copyFile(ctx context.Context, opts Options, f file) (string, error) {
+	src := filepath.Join(opts.Source, filepath.FromSlash(f.rel))
+	dst := filepath.Join(opts.Dest, filepath.FromSlash(f.rel))
+	if err := os.MkdirAll(filepath.Dir(dst), 0o755); err != nil {
+		return "", err
+	}
+	in, err := os.Open(src)
+	if err != nil {
+		return "", err
+	}
+	defer in.Close()
+	tmp, err := os.CreateTemp(filepath.Dir(dst), tempPrefix+"*")
+	if err != nil {
+		return "", err
+	}
+	defer os.Remove(tmp.Name()) // after a successful rename, a no-op
+
+	h := sha256.New()
+	n, err := io.Copy(io.MultiWriter(tmp, h), &progressReader{ctx: ctx, r: in, p: opts.Progress, copy: true})
+	if err == nil {
+		err = tmp.Sync()
+	}
+	if cerr := tmp.Close(); err == nil {
+		err = cerr
+	}
+	if err != nil {
+		return "", err
+	}
+	if fi, err := in.Stat(); err != nil {
+		return "", err
+	} else if n != f.size || fi.Size() != f.size || !fi.ModTime().Equal(f.modTime) {
+		return "", errors.New("changed while being copied; run again")
+	}
+	if err := os.Chmod(tmp.Name(), f.mode); err != nil {
+		return "", err
+	}
+	if err := os.Chtimes(tmp.Name(), time.Time{}, f.modTime); err != nil {
+		return "", err
+	}
+	if err := os.Rename(tmp.Name(), dst); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// forEach calls fn for each item on up to workers goroutines, and returns
+// the errors. Once ctx is cancelled, no new items are started.
+func forEach[T any](ctx context.Context, workers int, items []T, fn func(T) error) []error {
+	work := make(chan T)
+	var (
+		wg   sync.WaitGroup
+		mu   sync.Mutex
+		errs []error
+	)
+	for range min(workers, len(items)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range work {
+				if err := fn(item); err != nil && ctx.Err() == nil {
+					mu.Lock()
+					errs = append(errs, err)
+					mu.Unlock()
+				}
+			}
+		}()
+	}
+feed:
+	for _, item := range items {
+		select {
+		case work <- item:
+		case <-ctx.Done():
+			break feed
+		}
+	}
+	close(work)
+	wg.Wait()
+	return errs
+}
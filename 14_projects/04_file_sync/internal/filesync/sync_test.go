@@ -0,0 +1,262 @@
+package filesync
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+)
+
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(root, filepath.FromSlash(rel))
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// readTree returns the files under root, without the state file.
+func readTree(t *testing.T, root string) map[string]string {
+	t.Helper()
+	files := map[string]string{}
+	err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+		if err != nil || d.IsDir() || d.Name() == StateFileName {
+			return err
+		}
+		data, err := os.ReadFile(path)
+		files[relPath(root, path)] = string(data)
+		return err
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return files
+}
+
+func paths(actions []Action, kind ActionKind) []string {
+	var out []string
+	for _, a := range actions {
+		if a.Kind == kind {
+			out = append(out, a.Path)
+		}
+	}
+	return out
+}
+
+var tree = map[string]string{
+	"a.txt":         "alpha",
+	"b/c.txt":       "charlie",
+	"b/d/e.txt":     "echo",
+	"empty":         "",
+	"b/d/large.bin": strings.Repeat("x", 1<<20),
+}
+
+func TestSync_MirrorsThenSkipsWithoutHashing(t *testing.T) {
+	src, dst := t.TempDir(), filepath.Join(t.TempDir(), "backup")
+	writeTree(t, src, tree)
+	old := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	if err := os.Chtimes(filepath.Join(src, "a.txt"), old, old); err != nil {
+		t.Fatal(err)
+	}
+
+	res, err := Sync(context.Background(), Options{Source: src, Dest: dst})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := paths(res.Actions, Create); len(got) != len(tree) {
+		t.Errorf("created %v; want all %d files", got, len(tree))
+	}
+	if got := readTree(t, dst); !maps.Equal(got, tree) {
+		t.Errorf("destination has %d files; want a copy of the source", len(got))
+	}
+	if fi, err := os.Stat(filepath.Join(dst, "a.txt")); err != nil || !fi.ModTime().Equal(old) {
+		t.Errorf("copy's mtime = %v, %v; want the source's %v", fi.ModTime(), err, old)
+	}
+
+	p := new(Progress)
+	res, err = Sync(context.Background(), Options{Source: src, Dest: dst, Progress: p})
+	if err != nil || len(res.Actions) != 0 || res.Unchanged != len(tree) {
+		t.Fatalf("second run: %+v, %v; want everything unchanged", res, err)
+	}
+	if n := p.ToHash.Load(); n != 0 {
+		t.Errorf("second run hashed %d files; want none, from the state", n)
+	}
+}
+
+func TestSync_DetectsChanges(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTree(t, src, tree)
+	if _, err := Sync(context.Background(), Options{Source: src, Dest: dst}); err != nil {
+		t.Fatal(err)
+	}
+
+	// Same size, new content and time.
+	writeTree(t, src, map[string]string{"a.txt": "ALPHA", "new.txt": "new"})
+	later := time.Now().Add(time.Hour)
+	os.Chtimes(filepath.Join(src, "a.txt"), later, later)
+	// Touched, but with the same content: hashed and found equal.
+	os.Chtimes(filepath.Join(src, "b/c.txt"), later, later)
+
+	p := new(Progress)
+	res, err := Sync(context.Background(), Options{Source: src, Dest: dst, Progress: p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := paths(res.Actions, Update); !slices.Equal(got, []string{"a.txt"}) {
+		t.Errorf("updated %v; want [a.txt]", got)
+	}
+	if got := paths(res.Actions, Create); !slices.Equal(got, []string{"new.txt"}) {
+		t.Errorf("created %v; want [new.txt]", got)
+	}
+	if n := p.ToHash.Load(); n != 2 {
+		t.Errorf("hashed %d files; want a.txt and b/c.txt", n)
+	}
+	if got := readTree(t, dst)["a.txt"]; got != "ALPHA" {
+		t.Errorf("a.txt = %q after update", got)
+	}
+}
+
+func TestSync_ExistingCopyWithoutState(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTree(t, src, tree)
+	writeTree(t, dst, tree)
+	writeTree(t, dst, map[string]string{"b/c.txt": "CHARLIE"})
+
+	res, err := Sync(context.Background(), Options{Source: src, Dest: dst})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := paths(res.Actions, Update); !slices.Equal(got, []string{"b/c.txt"}) || res.Unchanged != len(tree)-1 {
+		t.Errorf("updated %v with %d unchanged; want only b/c.txt copied", got, res.Unchanged)
+	}
+	state, err := LoadState(filepath.Join(dst, StateFileName), src)
+	if err != nil || len(state.Files) != len(tree) {
+		t.Errorf("state has %d files, %v; want all of them recorded", len(state.Files), err)
+	}
+	if got := state.Files["a.txt"].SHA256; got != "8ed3f6ad685b959ead7022518e1af76cd816f8e8ec7ccdda1ed4018e8f2223f8" {
+		t.Errorf("a.txt recorded with SHA-256 %s", got)
+	}
+}
+
+func TestSync_DryRun(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTree(t, src, tree)
+	writeTree(t, dst, map[string]string{"a.txt": "stale", "gone.txt": "x"})
+
+	res, err := Sync(context.Background(), Options{Source: src, Dest: dst, DryRun: true, Delete: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(paths(res.Actions, Create)) != len(tree)-1 ||
+		!slices.Equal(paths(res.Actions, Update), []string{"a.txt"}) ||
+		!slices.Equal(paths(res.Actions, Delete), []string{"gone.txt"}) {
+		t.Errorf("planned %v", res.Actions)
+	}
+	if got := readTree(t, dst); len(got) != 2 || got["a.txt"] != "stale" {
+		t.Errorf("dry run changed the destination: %v", got)
+	}
+	if _, err := os.Stat(filepath.Join(dst, StateFileName)); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("dry run wrote the state file: %v", err)
+	}
+}
+
+func TestSync_DeleteAndFilter(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"keep.txt": "k", "debug.log": "src log", "cache/x": "x"})
+	writeTree(t, dst, map[string]string{
+		"old/deep/gone.txt": "g",
+		"app.log":           "excluded, so kept",
+		"stray.txt":         "s",
+	})
+
+	res, err := Sync(context.Background(), Options{
+		Source: src, Dest: dst, Delete: true,
+		Filter: Filter{Exclude: []string{"*.log", "cache"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := paths(res.Actions, Delete); !slices.Equal(got, []string{"old/deep/gone.txt", "stray.txt"}) {
+		t.Errorf("deleted %v", got)
+	}
+	want := map[string]string{"keep.txt": "k", "app.log": "excluded, so kept"}
+	if got := readTree(t, dst); !maps.Equal(got, want) {
+		t.Errorf("destination = %v; want %v", got, want)
+	}
+	if _, err := os.Stat(filepath.Join(dst, "old")); !errors.Is(err, fs.ErrNotExist) {
+		t.Errorf("emptied directory left behind: %v", err)
+	}
+}
+
+func TestSync_ResumesAfterInterruption(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTree(t, src, tree)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	copied := 0
+	_, err := Sync(ctx, Options{
+		Source: src, Dest: dst, Workers: 1, SaveEvery: 100,
+		afterCopy: func(string) {
+			if copied++; copied == 2 {
+				cancel()
+			}
+		},
+	})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("err = %v; want context.Canceled", err)
+	}
+	state, err := LoadState(filepath.Join(dst, StateFileName), src)
+	if err != nil || len(state.Files) != 2 {
+		t.Fatalf("state after interruption has %d files, %v; want the 2 copied", len(state.Files), err)
+	}
+
+	p := new(Progress)
+	res, err := Sync(context.Background(), Options{Source: src, Dest: dst, Progress: p})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if n := len(paths(res.Actions, Create)); n != len(tree)-2 || res.Unchanged != 2 || p.ToHash.Load() != 0 {
+		t.Errorf("resumed run created %d, left %d unchanged, hashed %d; want the other %d copied without hashing",
+			n, res.Unchanged, p.ToHash.Load(), len(tree)-2)
+	}
+	if got := readTree(t, dst); !maps.Equal(got, tree) {
+		t.Error("destination differs from the source after resuming")
+	}
+}
+
+func TestSync_SkipsSymlinks(t *testing.T) {
+	src, dst := t.TempDir(), t.TempDir()
+	writeTree(t, src, map[string]string{"real.txt": "r"})
+	if err := os.Symlink("real.txt", filepath.Join(src, "link.txt")); err != nil {
+		t.Skip(err)
+	}
+	res, err := Sync(context.Background(), Options{Source: src, Dest: dst})
+	if err != nil || !slices.Equal(res.Skipped, []string{"link.txt"}) {
+		t.Errorf("Skipped = %v, %v; want [link.txt]", res.Skipped, err)
+	}
+}
+
+func TestSync_Errors(t *testing.T) {
+	src := t.TempDir()
+	for name, opts := range map[string]Options{
+		"no dest":        {Source: src},
+		"missing source": {Source: filepath.Join(src, "nope"), Dest: t.TempDir()},
+		"dest in source": {Source: src, Dest: filepath.Join(src, "backup")},
+		"bad pattern":    {Source: src, Dest: t.TempDir(), Filter: Filter{Include: []string{"["}}},
+	} {
+		if _, err := Sync(context.Background(), opts); err == nil {
+			t.Errorf("%s: no error", name)
+		}
+	}
+}